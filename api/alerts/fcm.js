@@ -0,0 +1,113 @@
+// Push notifier - sends alerts through the Firebase Cloud Messaging HTTP v1
+// API, authenticating with a service account (signed JWT exchanged for an
+// OAuth access token) without the Firebase SDK
+const crypto = require("crypto");
+
+const TOKEN_URL = "https://oauth2.googleapis.com/token";
+const SCOPE = "https://www.googleapis.com/auth/firebase.messaging";
+
+const config = () => ({
+  projectId: process.env.FCM_PROJECT_ID,
+  clientEmail: process.env.FCM_CLIENT_EMAIL,
+  // Keys pasted into a single env line keep their newlines escaped
+  privateKey: (process.env.FCM_PRIVATE_KEY || "").replace(/\\n/g, "\n"),
+});
+
+const isConfigured = () => {
+  const { projectId, clientEmail, privateKey } = config();
+  return Boolean(projectId && clientEmail && privateKey);
+};
+
+let cachedToken = null;
+
+const base64url = (value) => Buffer.from(value).toString("base64url");
+
+const accessToken = async () => {
+  if (cachedToken && cachedToken.expiresAt > Date.now() + 60000) {
+    return cachedToken.value;
+  }
+  const { clientEmail, privateKey } = config();
+  const now = Math.floor(Date.now() / 1000);
+  const unsigned = [
+    base64url(JSON.stringify({ alg: "RS256", typ: "JWT" })),
+    base64url(
+      JSON.stringify({
+        iss: clientEmail,
+        scope: SCOPE,
+        aud: TOKEN_URL,
+        iat: now,
+        exp: now + 3600,
+      })
+    ),
+  ].join(".");
+  const signature = crypto
+    .createSign("RSA-SHA256")
+    .update(unsigned)
+    .sign(privateKey, "base64url");
+
+  const response = await fetch(TOKEN_URL, {
+    method: "POST",
+    headers: { "Content-Type": "application/x-www-form-urlencoded" },
+    body: new URLSearchParams({
+      grant_type: "urn:ietf:params:oauth:grant-type:jwt-bearer",
+      assertion: `${unsigned}.${signature}`,
+    }),
+  });
+  const result = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(
+      `FCM token ${response.status}: ${
+        result.error_description || result.error || response.statusText
+      }`
+    );
+  }
+  cachedToken = {
+    value: result.access_token,
+    expiresAt: Date.now() + result.expires_in * 1000,
+  };
+  return cachedToken.value;
+};
+
+// Send an alert as a push notification; to is a device registration token
+// or "topic:<name>". Resolves with the FCM message name.
+const send = async (to, alert) => {
+  const { projectId } = config();
+  const target = to.startsWith("topic:")
+    ? { topic: to.slice("topic:".length) }
+    : { token: to };
+  const data = Object.fromEntries(
+    Object.entries(alert.data || {}).map(([key, value]) => [
+      key,
+      String(value),
+    ])
+  );
+
+  const response = await fetch(
+    `https://fcm.googleapis.com/v1/projects/${encodeURIComponent(
+      projectId
+    )}/messages:send`,
+    {
+      method: "POST",
+      headers: {
+        Authorization: `Bearer ${await accessToken()}`,
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({
+        message: {
+          ...target,
+          notification: { title: alert.title, body: alert.body },
+          data,
+        },
+      }),
+    }
+  );
+  const result = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(
+      `FCM ${response.status}: ${result.error?.message || response.statusText}`
+    );
+  }
+  return { providerId: result.name };
+};
+
+module.exports = { isConfigured, send };
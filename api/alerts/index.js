@@ -0,0 +1,306 @@
+// Alerting - matches chaincode events against user-defined rules and
+// delivers alerts by e-mail, SMS or push, tracking every delivery
+const crypto = require("crypto");
+const smtp = require("./smtp");
+const twilio = require("./twilio");
+const fcm = require("./fcm");
+
+const MAX_ATTEMPTS = parseInt(process.env.ALERT_MAX_ATTEMPTS, 10) || 3;
+const RETRY_DELAY_MS = parseInt(process.env.ALERT_RETRY_DELAY_MS, 10) || 30000;
+
+// Deliveries kept for status queries
+const MAX_DELIVERIES = 1000;
+
+// Notifiers by channel; registerNotifier plugs in others
+const notifiers = new Map([
+  ["email", smtp],
+  ["sms", twilio],
+  ["push", fcm],
+]);
+
+// Rules and deliveries are kept in memory, like the other temporary stores
+const rules = new Map();
+const deliveries = new Map();
+
+const registerNotifier = (channel, notifier) => {
+  if (
+    typeof notifier?.send !== "function" ||
+    typeof notifier?.isConfigured !== "function"
+  ) {
+    throw new Error("A notifier needs send() and isConfigured()");
+  }
+  notifiers.set(channel, notifier);
+};
+
+const listChannels = () =>
+  [...notifiers.entries()].map(([channel, notifier]) => ({
+    channel,
+    configured: notifier.isConfigured(),
+  }));
+
+const splitList = (value) =>
+  (Array.isArray(value) ? value : String(value || "").split(","))
+    .map((item) => String(item).trim())
+    .filter(Boolean);
+
+const newId = (prefix) =>
+  `${prefix}-${Date.now()}-${crypto.randomBytes(3).toString("hex")}`;
+
+// Validate and normalize a rule; throws on invalid input
+const buildRule = (input, existing = {}) => {
+  const rule = {
+    id: existing.id || newId("RULE"),
+    name: String(input.name ?? existing.name ?? "").trim(),
+    eventTypes: splitList(input.eventTypes ?? existing.eventTypes).map((t) =>
+      t.toUpperCase()
+    ),
+    assetTypes: splitList(input.assetTypes ?? existing.assetTypes).map((t) =>
+      t.toUpperCase()
+    ),
+    assetIds: splitList(input.assetIds ?? existing.assetIds),
+    orgs: splitList(input.orgs ?? existing.orgs),
+    channels: (input.channels ?? existing.channels ?? []).map((target) => ({
+      channel: String(target?.channel || "").toLowerCase(),
+      to: String(target?.to || "").trim(),
+    })),
+    enabled: input.enabled ?? existing.enabled ?? true,
+    createdAt: existing.createdAt || new Date().toISOString(),
+    updatedAt: new Date().toISOString(),
+  };
+
+  if (!rule.name) {
+    throw new Error("'name' is required");
+  }
+  if (rule.eventTypes.length === 0) {
+    throw new Error("'eventTypes' needs at least one event type");
+  }
+  if (rule.channels.length === 0) {
+    throw new Error("'channels' needs at least one { channel, to } target");
+  }
+  for (const { channel, to } of rule.channels) {
+    if (!notifiers.has(channel)) {
+      throw new Error(
+        `Unknown channel '${channel}' (expected one of: ${[
+          ...notifiers.keys(),
+        ].join(", ")})`
+      );
+    }
+    if (!to) {
+      throw new Error(`The ${channel} target needs a 'to' address`);
+    }
+  }
+  rule.enabled = rule.enabled !== false && rule.enabled !== "false";
+  return rule;
+};
+
+const saveRule = (input) => {
+  const rule = buildRule(input);
+  rules.set(rule.id, rule);
+  return rule;
+};
+
+const updateRule = (id, input) => {
+  const existing = rules.get(id);
+  if (!existing) {
+    return null;
+  }
+  const rule = buildRule(input, existing);
+  rules.set(id, rule);
+  return rule;
+};
+
+const deleteRule = (id) => rules.delete(id);
+const getRule = (id) => rules.get(id) || null;
+const listRules = () => [...rules.values()];
+
+// Subjects of notices are asset keys such as WASTE_W1 or EXTRACTION_E1
+const splitSubject = (subject) => {
+  const index = String(subject || "").indexOf("_");
+  return index > 0
+    ? { assetType: subject.slice(0, index), assetId: subject.slice(index + 1) }
+    : { assetType: "", assetId: subject || "" };
+};
+
+// Alerts an event raises: one per notice of a LedgerChanged event (SLA
+// breaches, appeals, claims...), one per other named event
+const alertsOf = (event) => {
+  let data = event.payload;
+  try {
+    data = JSON.parse(event.payload);
+  } catch {
+    // Non-JSON payloads are alerted on by event name only
+  }
+
+  if (event.eventName === "LedgerChanged") {
+    return (data?.notices || []).map((notice) => ({
+      type: notice.kind,
+      ...splitSubject(notice.subject),
+      orgs: notice.recipients || [],
+      message: notice.message,
+      transactionId: event.transactionId,
+    }));
+  }
+  return [
+    {
+      type: String(event.eventName).toUpperCase(),
+      assetType: data?.wasteId ? "WASTE" : "",
+      assetId: data?.wasteId || "",
+      orgs: [],
+      message: `${event.eventName} event`,
+      transactionId: event.transactionId,
+    },
+  ];
+};
+
+const matchesRule = (rule, alert) =>
+  rule.enabled &&
+  (rule.eventTypes.includes("*") || rule.eventTypes.includes(alert.type)) &&
+  (rule.assetTypes.length === 0 || rule.assetTypes.includes(alert.assetType)) &&
+  (rule.assetIds.length === 0 || rule.assetIds.includes(alert.assetId)) &&
+  (rule.orgs.length === 0 || rule.orgs.some((org) => alert.orgs.includes(org)));
+
+const forgetOldDeliveries = () => {
+  for (const [id, delivery] of deliveries) {
+    if (deliveries.size < MAX_DELIVERIES) {
+      break;
+    }
+    if (delivery.status !== "PENDING") {
+      deliveries.delete(id);
+    }
+  }
+};
+
+// Attempt a delivery, retrying failures after RETRY_DELAY_MS up to
+// MAX_ATTEMPTS; deliveries to unconfigured channels are SKIPPED
+const attempt = async (delivery, alert) => {
+  const notifier = notifiers.get(delivery.channel);
+  if (!notifier.isConfigured()) {
+    delivery.status = "SKIPPED";
+    delivery.error = `The ${delivery.channel} channel is not configured`;
+    return delivery;
+  }
+
+  delivery.attempts++;
+  delivery.lastAttemptAt = new Date().toISOString();
+  try {
+    const result = await notifier.send(delivery.to, alert);
+    delivery.status = "SENT";
+    delivery.providerId = result?.providerId || "";
+    delivery.sentAt = new Date().toISOString();
+    delivery.error = undefined;
+  } catch (error) {
+    delivery.error = error.message;
+    if (delivery.attempts >= MAX_ATTEMPTS) {
+      delivery.status = "FAILED";
+      console.error(`❌ Alert delivery ${delivery.id} failed:`, error.message);
+    } else {
+      setTimeout(
+        () => attempt(delivery, alert),
+        RETRY_DELAY_MS * delivery.attempts
+      ).unref();
+    }
+  }
+  return delivery;
+};
+
+// Track and start a delivery of a message ({ title, body, data }) to one
+// target; ruleId is null for messages sent outside alert rules
+const send = (ruleId, channel, to, alert, message) => {
+  const delivery = {
+    id: newId("DELIVERY"),
+    ruleId,
+    channel,
+    to,
+    alertType: alert.type,
+    assetType: alert.assetType,
+    assetId: alert.assetId,
+    transactionId: alert.transactionId,
+    status: "PENDING",
+    attempts: 0,
+    createdAt: new Date().toISOString(),
+  };
+  forgetOldDeliveries();
+  deliveries.set(delivery.id, delivery);
+  attempt(delivery, message);
+  return delivery;
+};
+
+const deliver = (rule, alert) =>
+  rule.channels.map(({ channel, to }) =>
+    send(rule.id, channel, to, alert, {
+      title: `[${alert.type}] ${rule.name}`,
+      body: alert.message,
+      data: {
+        type: alert.type,
+        assetType: alert.assetType,
+        assetId: alert.assetId,
+        transactionId: alert.transactionId || "",
+      },
+    })
+  );
+
+// Send a message of the given type to one { channel, to } target with the
+// same tracking and retries as alerts, e.g. deadline digests
+const sendMessage = ({ channel, to }, type, title, body) => {
+  if (!notifiers.has(channel)) {
+    throw new Error(`Unknown channel '${channel}'`);
+  }
+  return send(null, channel, to, { type, assetType: "", assetId: "" }, {
+    title,
+    body,
+    data: { type },
+  });
+};
+
+// Contract listener: deliver the alerts of an event to every matching rule
+const handleEvent = (event) => {
+  for (const alert of alertsOf(event)) {
+    for (const rule of rules.values()) {
+      if (matchesRule(rule, alert)) {
+        deliver(rule, alert);
+      }
+    }
+  }
+};
+
+// Send a sample alert through a rule's channels
+const testRule = (id) => {
+  const rule = rules.get(id);
+  if (!rule) {
+    return null;
+  }
+  return deliver(
+    { ...rule, enabled: true },
+    {
+      type: "TEST",
+      assetType: "",
+      assetId: "",
+      orgs: [],
+      message: `Test alert for rule ${rule.name}`,
+    }
+  );
+};
+
+const listDeliveries = ({ status, ruleId } = {}) =>
+  [...deliveries.values()].filter(
+    (delivery) =>
+      (!status || delivery.status === status) &&
+      (!ruleId || delivery.ruleId === ruleId)
+  );
+
+const getDelivery = (id) => deliveries.get(id) || null;
+
+module.exports = {
+  registerNotifier,
+  listChannels,
+  saveRule,
+  updateRule,
+  deleteRule,
+  getRule,
+  listRules,
+  handleEvent,
+  testRule,
+  sendMessage,
+  listDeliveries,
+  getDelivery,
+};
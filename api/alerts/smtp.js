@@ -0,0 +1,173 @@
+// E-mail notifier - a minimal SMTP client (EHLO, STARTTLS, AUTH PLAIN, one
+// message per connection), enough for a relay such as Postfix, SES or
+// Mailgun without a mail library
+const net = require("net");
+const tls = require("tls");
+const os = require("os");
+
+const TIMEOUT_MS = 15000;
+
+const config = () => {
+  const secure = process.env.SMTP_SECURE === "true";
+  return {
+    host: process.env.SMTP_HOST,
+    port: parseInt(process.env.SMTP_PORT, 10) || (secure ? 465 : 587),
+    secure,
+    user: process.env.SMTP_USER,
+    password: process.env.SMTP_PASSWORD,
+    from: process.env.ALERT_EMAIL_FROM,
+  };
+};
+
+const isConfigured = () => {
+  const { host, from } = config();
+  return Boolean(host && from);
+};
+
+// Reads multi-line SMTP replies ("250-..." continued, "250 ..." last) off a
+// socket, one reply per read()
+class ReplyReader {
+  constructor(socket) {
+    this.attach(socket);
+  }
+
+  attach(socket) {
+    this.socket = socket;
+    this.buffer = "";
+    this.lines = [];
+    this.waiting = null;
+    socket.setEncoding("utf8");
+    socket.on("data", (chunk) => {
+      this.buffer += chunk;
+      let index;
+      while ((index = this.buffer.indexOf("\r\n")) >= 0) {
+        this.lines.push(this.buffer.slice(0, index));
+        this.buffer = this.buffer.slice(index + 2);
+      }
+      this.flush();
+    });
+    socket.on("error", (error) => this.fail(error));
+    socket.on("timeout", () => this.fail(new Error("SMTP timeout")));
+  }
+
+  flush() {
+    const end = this.lines.findIndex((line) => /^\d{3}(?: |$)/.test(line));
+    if (!this.waiting || end < 0) {
+      return;
+    }
+    const lines = this.lines.splice(0, end + 1);
+    const { resolve } = this.waiting;
+    this.waiting = null;
+    resolve({
+      code: parseInt(lines[end].slice(0, 3), 10),
+      lines: lines.map((line) => line.slice(4)),
+    });
+  }
+
+  fail(error) {
+    if (this.waiting) {
+      this.waiting.reject(error);
+      this.waiting = null;
+    }
+  }
+
+  read() {
+    return new Promise((resolve, reject) => {
+      this.waiting = { resolve, reject };
+      this.flush();
+    });
+  }
+}
+
+const connect = ({ host, port, secure }) =>
+  new Promise((resolve, reject) => {
+    const socket = secure
+      ? tls.connect({ host, port, servername: host }, () => resolve(socket))
+      : net.connect({ host, port }, () => resolve(socket));
+    socket.setTimeout(TIMEOUT_MS);
+    socket.once("error", reject);
+  });
+
+const upgrade = (socket, host) =>
+  new Promise((resolve, reject) => {
+    const secured = tls.connect({ socket, servername: host }, () =>
+      resolve(secured)
+    );
+    secured.setTimeout(TIMEOUT_MS);
+    secured.once("error", reject);
+  });
+
+// Dot-stuffing and CRLF line endings as DATA requires
+const encodeBody = (text) =>
+  String(text)
+    .replace(/\r?\n/g, "\r\n")
+    .replace(/^\./gm, "..");
+
+const buildMessage = ({ from, to, subject, text }) =>
+  [
+    `From: ${from}`,
+    `To: ${to}`,
+    `Subject: ${subject.replace(/[\r\n]+/g, " ")}`,
+    `Date: ${new Date().toUTCString()}`,
+    "MIME-Version: 1.0",
+    "Content-Type: text/plain; charset=utf-8",
+    "Content-Transfer-Encoding: 8bit",
+    "",
+    encodeBody(text),
+  ].join("\r\n");
+
+// Send an alert by e-mail to the address to; resolves with the relay's reply
+// to the message
+const send = async (to, alert) => {
+  const settings = config();
+  let socket = await connect(settings);
+  const reader = new ReplyReader(socket);
+
+  const command = async (line, expected) => {
+    if (line !== null) {
+      socket.write(`${line}\r\n`);
+    }
+    const reply = await reader.read();
+    if (!expected.includes(reply.code)) {
+      throw new Error(
+        `SMTP ${line ? line.split(" ")[0] : "greeting"} failed: ` +
+          `${reply.code} ${reply.lines.join(" ")}`
+      );
+    }
+    return reply;
+  };
+
+  try {
+    await command(null, [220]);
+    let hello = await command(`EHLO ${os.hostname()}`, [250]);
+    if (!settings.secure && hello.lines.some((l) => /^STARTTLS/i.test(l))) {
+      await command("STARTTLS", [220]);
+      socket.removeAllListeners("data");
+      socket = await upgrade(socket, settings.host);
+      reader.attach(socket);
+      hello = await command(`EHLO ${os.hostname()}`, [250]);
+    }
+    if (settings.user) {
+      const credentials = Buffer.from(
+        `\u0000${settings.user}\u0000${settings.password || ""}`
+      ).toString("base64");
+      await command(`AUTH PLAIN ${credentials}`, [235]);
+    }
+    await command(`MAIL FROM:<${settings.from}>`, [250]);
+    await command(`RCPT TO:<${to}>`, [250, 251]);
+    await command("DATA", [354]);
+    const message = buildMessage({
+      from: settings.from,
+      to,
+      subject: alert.title,
+      text: alert.body,
+    });
+    const accepted = await command(`${message}\r\n.`, [250]);
+    await command("QUIT", [221]).catch(() => {});
+    return { providerId: accepted.lines.join(" ") };
+  } finally {
+    socket.destroy();
+  }
+};
+
+module.exports = { isConfigured, send };
@@ -0,0 +1,45 @@
+// SMS notifier - sends alerts through the Twilio Messages REST API
+const config = () => ({
+  accountSid: process.env.TWILIO_ACCOUNT_SID,
+  authToken: process.env.TWILIO_AUTH_TOKEN,
+  from: process.env.TWILIO_FROM_NUMBER,
+});
+
+const isConfigured = () => {
+  const { accountSid, authToken, from } = config();
+  return Boolean(accountSid && authToken && from);
+};
+
+// SMS bodies are kept to a few segments
+const MAX_BODY_LENGTH = 480;
+
+// Send an alert by SMS to the E.164 number to; resolves with the message SID
+const send = async (to, alert) => {
+  const { accountSid, authToken, from } = config();
+  const text = `${alert.title}\n${alert.body}`.slice(0, MAX_BODY_LENGTH);
+
+  const response = await fetch(
+    `https://api.twilio.com/2010-04-01/Accounts/${encodeURIComponent(
+      accountSid
+    )}/Messages.json`,
+    {
+      method: "POST",
+      headers: {
+        Authorization: `Basic ${Buffer.from(
+          `${accountSid}:${authToken}`
+        ).toString("base64")}`,
+        "Content-Type": "application/x-www-form-urlencoded",
+      },
+      body: new URLSearchParams({ To: to, From: from, Body: text }),
+    }
+  );
+  const result = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(
+      `Twilio ${response.status}: ${result.message || response.statusText}`
+    );
+  }
+  return { providerId: result.sid };
+};
+
+module.exports = { isConfigured, send };
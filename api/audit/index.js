@@ -0,0 +1,335 @@
+// API audit log - a record of every call to the gateway (who, what, when,
+// from where and with which result), kept apart from the ledger for
+// security reviews. Records are appended to daily JSON-lines segments and
+// chained by hash, so an edited or removed line breaks the chain; the only
+// deletions are whole segments older than the retention of their log.
+// Personal data and credentials are redacted before anything is written.
+const crypto = require("crypto");
+const fs = require("fs");
+const path = require("path");
+const readline = require("readline");
+
+const AUDIT_DIR =
+  process.env.AUDIT_LOG_DIR || path.join(process.cwd(), "audit-log");
+
+// Largest captured body, in bytes of JSON; larger ones are summarized
+const MAX_BODY_BYTES = parseInt(process.env.AUDIT_MAX_BODY_BYTES, 10) || 4096;
+
+// Two logs with their own chain and retention: reads are successful GET
+// and HEAD calls; writes are every other call, including refused reads,
+// which security keeps as long as changes
+const RETENTION_DAYS = {
+  reads: parseInt(process.env.AUDIT_READ_RETENTION_DAYS, 10) || 90,
+  writes: parseInt(process.env.AUDIT_WRITE_RETENTION_DAYS, 10) || 365,
+};
+const LOGS = Object.keys(RETENTION_DAYS);
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// Keys whose values are personal data or credentials, at any depth
+const REDACTED_KEYS = new RegExp(
+  `^(${[
+    "owner",
+    "ownerName",
+    "farm",
+    "location",
+    "pii",
+    "contact",
+    "email",
+    "phone",
+    "address",
+    "password",
+    "secret",
+    "token",
+    "authorization",
+    "apiKey",
+  ].join("|")})$`,
+  "i"
+);
+const REDACTED = "[REDACTED]";
+
+// Calls whose response bodies are not captured: the audit log itself
+const UNCAPTURED_PATHS = [/^\/api\/admin\/api-audit/];
+
+const redact = (value) => {
+  if (Array.isArray(value)) {
+    return value.map(redact);
+  }
+  if (!value || typeof value !== "object") {
+    return value;
+  }
+  return Object.fromEntries(
+    Object.entries(value).map(([key, field]) => [
+      key,
+      REDACTED_KEYS.test(key) ? REDACTED : redact(field),
+    ])
+  );
+};
+
+// Redacted body, or its size when it is too large to keep
+const capture = (body) => {
+  if (body === undefined || body === null || body === "") {
+    return undefined;
+  }
+  const json = JSON.stringify(redact(body));
+  if (json === undefined) {
+    return undefined;
+  }
+  if (Buffer.byteLength(json) > MAX_BODY_BYTES) {
+    return { truncated: true, bytes: Buffer.byteLength(json) };
+  }
+  return JSON.parse(json);
+};
+
+const segmentName = (log, day) => `${log}-${day}.jsonl`;
+
+const parseSegment = (fileName) => {
+  const match = /^([a-z]+)-(\d{4}-\d{2}-\d{2})\.jsonl$/.exec(fileName);
+  return match && LOGS.includes(match[1])
+    ? { log: match[1], day: match[2], fileName }
+    : null;
+};
+
+// Segments of every log, oldest first
+const listSegments = async () => {
+  const files = await fs.promises.readdir(AUDIT_DIR).catch((error) => {
+    if (error.code === "ENOENT") {
+      return [];
+    }
+    throw error;
+  });
+  return files
+    .map(parseSegment)
+    .filter(Boolean)
+    .sort((a, b) => a.day.localeCompare(b.day));
+};
+
+const readRecords = async function* (segment) {
+  const lines = readline.createInterface({
+    input: fs.createReadStream(path.join(AUDIT_DIR, segment.fileName)),
+    crlfDelay: Infinity,
+  });
+  for await (const line of lines) {
+    if (line.trim()) {
+      yield JSON.parse(line);
+    }
+  }
+};
+
+const hashRecord = (record) =>
+  crypto.createHash("sha256").update(JSON.stringify(record)).digest("hex");
+
+// Head of each chain: the sequence and hash of its last record
+const chains = {};
+let loading = null;
+
+const loadChains = () => {
+  if (!loading) {
+    loading = (async () => {
+      await fs.promises.mkdir(AUDIT_DIR, { recursive: true, mode: 0o750 });
+      LOGS.forEach((log) => {
+        chains[log] = { sequence: 0, hash: null };
+      });
+      for (const segment of await listSegments()) {
+        for await (const record of readRecords(segment)) {
+          chains[segment.log] = {
+            sequence: record.sequence,
+            hash: record.hash,
+          };
+        }
+      }
+    })();
+  }
+  return loading;
+};
+
+// Appends run one at a time so each record chains to the previous one
+let appending = Promise.resolve();
+
+const append = (log, entry) => {
+  appending = appending
+    .then(async () => {
+      await loadChains();
+      const chain = chains[log];
+      const record = {
+        ...entry,
+        log,
+        sequence: chain.sequence + 1,
+        previousHash: chain.hash,
+      };
+      record.hash = hashRecord(record);
+      await fs.promises.appendFile(
+        path.join(AUDIT_DIR, segmentName(log, record.at.slice(0, 10))),
+        `${JSON.stringify(record)}\n`,
+        { flag: "a", mode: 0o640 }
+      );
+      chains[log] = { sequence: record.sequence, hash: record.hash };
+    })
+    .catch((error) =>
+      console.error("❌ Failed to write API audit record:", error.message)
+    );
+  return appending;
+};
+
+// Delete the segments older than the retention of their log
+const prune = async () => {
+  const today = Date.parse(new Date().toISOString().slice(0, 10));
+  for (const segment of await listSegments()) {
+    const age = (today - Date.parse(segment.day)) / DAY_MS;
+    if (age >= RETENTION_DAYS[segment.log]) {
+      await fs.promises.rm(path.join(AUDIT_DIR, segment.fileName), {
+        force: true,
+      });
+    }
+  }
+};
+
+let pruner = null;
+
+const startPruning = () => {
+  if (!pruner) {
+    prune().catch(() => {});
+    pruner = setInterval(() => prune().catch(() => {}), 60 * 60 * 1000);
+    pruner.unref();
+  }
+};
+
+const actorOf = (req) => {
+  if (req.serviceAccount) {
+    return {
+      type: "SERVICE_ACCOUNT",
+      id: req.serviceAccount.id,
+      org: req.serviceAccount.org,
+      delegationId: req.serviceAccount.delegationId,
+    };
+  }
+  const org = req.body?.org || req.query?.org;
+  return { type: "ORGANIZATION", id: null, org: org || null };
+};
+
+// Express middleware recording every request once its response is sent
+const auditMiddleware = (req, res, next) => {
+  startPruning();
+  const startedAt = Date.now();
+  let responseBody;
+  const json = res.json.bind(res);
+  res.json = (body) => {
+    responseBody = body;
+    return json(body);
+  };
+
+  res.on("finish", () => {
+    const read = ["GET", "HEAD"].includes(req.method);
+    const log = read && res.statusCode < 400 ? "reads" : "writes";
+    const urlPath = req.originalUrl.split("?")[0];
+    const captureResponse =
+      !read && !UNCAPTURED_PATHS.some((pattern) => pattern.test(urlPath));
+    append(log, {
+      at: new Date(startedAt).toISOString(),
+      actor: actorOf(req),
+      ip: req.ip,
+      forwardedFor: req.get("X-Forwarded-For") || undefined,
+      userAgent: req.get("User-Agent") || null,
+      method: req.method,
+      path: urlPath,
+      query: capture(req.query),
+      body: read ? undefined : capture(req.body),
+      status: res.statusCode,
+      durationMs: Date.now() - startedAt,
+      response:
+        captureResponse || res.statusCode >= 400
+          ? capture(responseBody)
+          : undefined,
+      blockchainTxId: responseBody?.blockchainTxId,
+      count: responseBody?.count,
+    });
+  });
+  next();
+};
+
+const matches = (record, filters) =>
+  (!filters.from || record.at >= filters.from) &&
+  (!filters.to || record.at.slice(0, filters.to.length) <= filters.to) &&
+  (!filters.actor || record.actor.id === filters.actor) &&
+  (!filters.org || record.actor.org === filters.org) &&
+  (!filters.method || record.method === filters.method.toUpperCase()) &&
+  (!filters.path || record.path.startsWith(filters.path)) &&
+  (!filters.status || String(record.status).startsWith(filters.status)) &&
+  (!filters.ip || record.ip === filters.ip);
+
+// Records matching filters ({ log, from, to, actor, org, method, path,
+// status, ip }), newest first, at most limit of them; from and to are dates
+// or ISO timestamps, status may be a class such as "4"
+const search = async (filters = {}, limit = 100) => {
+  await appending;
+  const segments = (await listSegments()).filter(
+    (segment) =>
+      (!filters.log || segment.log === filters.log) &&
+      (!filters.from || segment.day >= filters.from.slice(0, 10)) &&
+      (!filters.to || segment.day <= filters.to.slice(0, 10))
+  );
+
+  const found = [];
+  for (const segment of segments.reverse()) {
+    const records = [];
+    for await (const record of readRecords(segment)) {
+      if (matches(record, filters)) {
+        records.push(record);
+      }
+    }
+    found.push(...records.reverse());
+    if (found.length >= limit) {
+      break;
+    }
+  }
+  return found.sort((a, b) => b.at.localeCompare(a.at)).slice(0, limit);
+};
+
+// Check the hash chain of each log over its retained segments. The first
+// retained record anchors the chain, since older ones may have expired.
+const verify = async () => {
+  await appending;
+  const results = Object.fromEntries(
+    LOGS.map((log) => [
+      log,
+      { valid: true, records: 0, from: null, to: null, brokenAt: null },
+    ])
+  );
+  const last = {};
+  for (const segment of await listSegments()) {
+    const result = results[segment.log];
+    for await (const record of readRecords(segment)) {
+      if (!result.valid) {
+        break;
+      }
+      const { hash, ...content } = record;
+      const previous = last[segment.log];
+      const linked =
+        !previous ||
+        (record.previousHash === previous.hash &&
+          record.sequence === previous.sequence + 1);
+      if (!linked || hashRecord(content) !== hash) {
+        result.valid = false;
+        result.brokenAt = {
+          segment: segment.fileName,
+          sequence: record.sequence,
+        };
+        break;
+      }
+      last[segment.log] = record;
+      result.records += 1;
+      result.from = result.from || record.at;
+      result.to = record.at;
+    }
+  }
+  return results;
+};
+
+module.exports = {
+  auditMiddleware,
+  search,
+  verify,
+  redact,
+  RETENTION_DAYS,
+  LOGS,
+};
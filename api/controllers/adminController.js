@@ -0,0 +1,1462 @@
+// Admin Controller - runtime configuration of the chaincode
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const indexer = require("../indexer");
+const audit = require("../audit");
+const { describeStorage } = require("../objectStorage");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for admin"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Organization whose gateway identity carries the admin role
+const ADMIN_ORG = process.env.ADMIN_ORG || "farmer";
+
+// Organization whose gateway identity reads the audit trail
+const AUDITOR_ORG = process.env.AUDITOR_ORG || ADMIN_ORG;
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}(T\S+)?$/;
+
+// Get the full chaincode configuration
+exports.getConfig = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const config = await blockchainClient.query(ADMIN_ORG, "GetConfig");
+
+    res.status(200).json({
+      success: true,
+      data: typeof config === "string" ? JSON.parse(config) : config,
+    });
+  } catch (error) {
+    console.error("❌ Error in getConfig:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Set (or reset with an empty value) a namespaced setting
+exports.setConfig = async (req, res) => {
+  try {
+    const { namespace, key } = req.params;
+    const { value } = req.body;
+
+    if (value === undefined) {
+      return res.status(400).json({
+        error: "Missing value",
+        details: "The 'value' field is required (empty string resets)",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    console.log(`⚙️ Setting config ${namespace}.${key}`);
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "SetConfig",
+      namespace,
+      key,
+      String(value)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Configuration updated on blockchain",
+      namespace: namespace,
+      key: key,
+      value: String(value),
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in setConfig:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+const PROFILE_FIELDS = ["farm", "location", "plotId"];
+
+// Validation profiles: the optional lot fields each organization must fill in
+exports.listValidationProfiles = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const profiles =
+      (await blockchainClient.query(ADMIN_ORG, "GetValidationProfiles")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: profiles,
+      count: profiles.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listValidationProfiles:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+exports.getValidationProfile = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const profile = await blockchainClient.query(
+      ADMIN_ORG,
+      "ReadValidationProfile",
+      req.params.orgMsp
+    );
+
+    res.status(200).json({
+      success: true,
+      data: profile,
+    });
+  } catch (error) {
+    console.error("❌ Error in getValidationProfile:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Replace the required fields of an organization; an empty list lifts them
+exports.setValidationProfile = async (req, res) => {
+  try {
+    const { orgMsp } = req.params;
+    const { requiredFields } = req.body;
+
+    if (
+      !Array.isArray(requiredFields) ||
+      requiredFields.some((field) => !PROFILE_FIELDS.includes(field))
+    ) {
+      return res.status(400).json({
+        error: "Invalid required fields",
+        details: `'requiredFields' must list fields among: ${PROFILE_FIELDS.join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "SetValidationProfile",
+      orgMsp,
+      requiredFields.join(",")
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Validation profile of ${orgMsp} updated`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in setValidationProfile:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+const RULE_ASSET_TYPES = ["WASTE", "EXTRACTION", "RECYCLING"];
+const RULE_SEVERITIES = ["REJECT", "WARN"];
+
+// List validation rules, optionally of one ?assetType=
+exports.listValidationRules = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const rules =
+      (await blockchainClient.query(
+        ADMIN_ORG,
+        "GetValidationRules",
+        String(req.query.assetType || "").toUpperCase()
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: rules,
+      count: rules.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listValidationRules:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Create (POST) or replace (PUT /:ruleId) a validation rule:
+// { assetType, expression, message?, severity?: "REJECT"|"WARN" }
+exports.setValidationRule = async (req, res) => {
+  try {
+    const { expression, message } = req.body;
+    const assetType = String(req.body.assetType || "").toUpperCase();
+    const severity = String(req.body.severity || "REJECT").toUpperCase();
+
+    if (!RULE_ASSET_TYPES.includes(assetType) || !expression) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: `Required fields: assetType (one of ${RULE_ASSET_TYPES.join(", ")}), expression`,
+      });
+    }
+    if (!RULE_SEVERITIES.includes(severity)) {
+      return res.status(400).json({
+        error: "Invalid severity",
+        details: `'severity' must be one of: ${RULE_SEVERITIES.join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "SetValidationRule",
+      req.params.ruleId || "",
+      assetType,
+      expression,
+      message || "",
+      severity
+    );
+
+    res.status(req.params.ruleId ? 200 : 201).json({
+      success: true,
+      message: `Validation rule ${req.params.ruleId ? "updated" : "created"}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in setValidationRule:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Turn a validation rule on or off: { enabled }
+exports.setValidationRuleEnabled = async (req, res) => {
+  try {
+    if (typeof req.body.enabled !== "boolean") {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "'enabled' must be true or false",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "SetValidationRuleEnabled",
+      req.params.ruleId,
+      String(req.body.enabled)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Validation rule ${req.body.enabled ? "enabled" : "disabled"}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in setValidationRuleEnabled:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Evaluate an expression against an asset without storing anything:
+// { assetType, expression, asset } where asset is an asset object or the
+// ID of a stored one
+exports.testValidationRule = async (req, res) => {
+  try {
+    const { expression, asset } = req.body;
+    const assetType = String(req.body.assetType || "").toUpperCase();
+
+    if (!RULE_ASSET_TYPES.includes(assetType) || !expression || !asset) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: assetType, expression, asset",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    // Evaluated on one peer, like any query; nothing is written
+    const evaluation = await blockchainClient.query(
+      ADMIN_ORG,
+      "TestValidationRule",
+      assetType,
+      expression,
+      typeof asset === "string" ? asset : JSON.stringify(asset)
+    );
+
+    res.status(200).json({
+      success: true,
+      data: evaluation,
+    });
+  } catch (error) {
+    console.error("❌ Error in testValidationRule:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Run chaincode housekeeping (notification pruning, data retention, daily
+// state snapshot)
+exports.runMaintenance = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    console.log("🧹 Running chaincode maintenance");
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "RunMaintenance"
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Maintenance completed on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in runMaintenance:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List the registered schema migrations with their progress
+exports.listMigrations = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const migrations =
+      (await blockchainClient.query(ADMIN_ORG, "GetMigrations")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: migrations,
+      count: migrations.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listMigrations:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Advance a schema migration by one batch; with "untilComplete" batches are
+// run back to back until the migration completes
+exports.runMigration = async (req, res) => {
+  try {
+    const version = parseInt(req.params.version, 10);
+    const batchSize = parseInt(req.body?.batchSize, 10) || 0;
+    const bookmark = req.body?.bookmark || "";
+    const untilComplete = req.body?.untilComplete === true;
+
+    if (!Number.isInteger(version) || version < 1) {
+      return res.status(400).json({
+        error: "Invalid version",
+        details: "The migration version must be a positive integer",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    console.log(`🔧 Running migration ${version}`);
+
+    let result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "RunMigration",
+      String(version),
+      String(batchSize),
+      bookmark
+    );
+    let batches = 1;
+    while (untilComplete && result?.result?.status === "IN_PROGRESS") {
+      result = await blockchainClient.submitTransaction(
+        ADMIN_ORG,
+        "RunMigration",
+        String(version),
+        String(batchSize),
+        ""
+      );
+      batches++;
+    }
+
+    res.status(200).json({
+      success: true,
+      message:
+        result?.result?.status === "COMPLETE"
+          ? "Migration complete"
+          : "Migration batch recorded on blockchain",
+      batches: batches,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in runMigration:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Page through the invocation audit trail, oldest first; actor is a caller
+// identity or MSP ID, from/to are dates or ISO timestamps
+exports.queryAuditTrail = async (req, res) => {
+  try {
+    const { actor, from, to, bookmark } = req.query;
+    const pageSize = parseInt(req.query.pageSize, 10) || 0;
+
+    const badDate = [from, to].find(
+      (value) => value && !DATE_PATTERN.test(value)
+    );
+    if (badDate) {
+      return res.status(400).json({
+        error: "Invalid date",
+        details: `'${badDate}' must be YYYY-MM-DD or an ISO timestamp`,
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const page = await blockchainClient.query(
+      AUDITOR_ORG,
+      "QueryAuditTrail",
+      actor || "",
+      from || "",
+      to || "",
+      String(pageSize),
+      bookmark || ""
+    );
+    const records = page?.records || [];
+
+    res.status(200).json({
+      success: true,
+      data: records,
+      count: records.length,
+      bookmark: page?.bookmark || null,
+      truncated: page?.truncated || false,
+    });
+  } catch (error) {
+    console.error("❌ Error in queryAuditTrail:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Per-function chaincode metrics since ?since= (a date or ISO timestamp;
+// by default the chaincode's metrics.windowHours): invocations, failures
+// and read/write set sizes recorded on chain while metrics.record is on,
+// with the durations measured by the peer that answered
+exports.getFunctionMetrics = async (req, res) => {
+  try {
+    const { since } = req.query;
+    if (since && !DATE_PATTERN.test(since)) {
+      return res.status(400).json({
+        error: "Invalid date",
+        details: `'${since}' must be YYYY-MM-DD or an ISO timestamp`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const report = await blockchainClient.query(
+      AUDITOR_ORG,
+      "GetFunctionMetrics",
+      since || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      data: report,
+      count: report?.functions?.length || 0,
+    });
+  } catch (error) {
+    console.error("❌ Error in getFunctionMetrics:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Extraction and recycling records out of step with the lots and
+// extractions they were made from (orphaned records, lots never updated,
+// consumed quantities and output links missing)
+exports.checkIntegrity = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const report = await blockchainClient.query(
+      AUDITOR_ORG,
+      "CheckIntegrity",
+      "false"
+    );
+
+    res.status(200).json({
+      success: true,
+      data: report,
+      count: report?.issues?.length || 0,
+    });
+  } catch (error) {
+    console.error("❌ Error in checkIntegrity:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Repair what checkIntegrity reports: orphaned records are marked ORPHANED,
+// lots and extractions are brought in line with their records
+exports.repairIntegrity = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "CheckIntegrity",
+      "true"
+    );
+    const report = result?.result;
+
+    res.status(200).json({
+      success: true,
+      message: `${report?.repaired || 0} integrity issue(s) repaired`,
+      data: report,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in repairIntegrity:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Draw a random sample of lots for physical inspection; the chosen lots move
+// to UNDER_AUDIT. filter narrows the population (type, category, status,
+// region, ownerMsp, harvestFrom, harvestTo).
+exports.selectAuditSample = async (req, res) => {
+  try {
+    const { id, filter } = req.body;
+    const size = parseInt(req.body.size, 10);
+
+    if (!(size > 0)) {
+      return res.status(400).json({
+        error: "Invalid sample size",
+        details: "'size' must be a positive number of lots",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      AUDITOR_ORG,
+      "SelectAuditSample",
+      id || "",
+      JSON.stringify(filter || {}),
+      String(size)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `${size} lots selected for inspection`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in selectAuditSample:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Audit samples, newest first; ?status=OPEN keeps those still being inspected
+exports.listAuditSamples = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const samples =
+      (await blockchainClient.query(
+        AUDITOR_ORG,
+        "GetAuditSamples",
+        String(req.query.status || "").toUpperCase()
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: samples,
+      count: samples.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listAuditSamples:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+exports.getAuditSample = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const sample = await blockchainClient.query(
+      AUDITOR_ORG,
+      "ReadAuditSample",
+      req.params.sampleId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: sample,
+    });
+  } catch (error) {
+    console.error("❌ Error in getAuditSample:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Record the inspection of a sampled lot, which leaves UNDER_AUDIT
+exports.recordAuditInspection = async (req, res) => {
+  try {
+    const { sampleId, wasteId } = req.params;
+    const { passed, notes } = req.body;
+
+    if (typeof passed !== "boolean") {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "'passed' must be true or false",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      AUDITOR_ORG,
+      "RecordAuditInspection",
+      sampleId,
+      wasteId,
+      String(passed),
+      notes || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Inspection of waste ${wasteId} recorded`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in recordAuditInspection:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Turn down the inspection of a sampled lot assigned to the auditor
+// identity; the lot goes to the next certifier in line: { reason }
+exports.declineAuditAssignment = async (req, res) => {
+  try {
+    const { sampleId, wasteId } = req.params;
+    const { reason } = req.body;
+
+    if (!reason) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "'reason' is required to decline an inspection",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      AUDITOR_ORG,
+      "DeclineAuditAssignment",
+      sampleId,
+      wasteId,
+      reason
+    );
+    const lot = (result?.result?.lots || []).find(
+      (sampled) => sampled.wasteId === wasteId
+    );
+
+    res.status(200).json({
+      success: true,
+      message: lot?.assignedTo
+        ? `Waste ${wasteId} reassigned to certifier ${lot.assignedTo}`
+        : `Waste ${wasteId} left without a certifier`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in declineAuditAssignment:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Assign the lots of a sample that have no certifier yet
+exports.assignAuditSample = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      AUDITOR_ORG,
+      "AssignAuditSample",
+      req.params.sampleId
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Audit sample ${req.params.sampleId} assigned`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in assignAuditSample:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Register the auditor identity as a certifier, or change its declared
+// capacity: { name, capacity }
+exports.registerCertifier = async (req, res) => {
+  try {
+    const { name } = req.body;
+    const capacity = parseInt(req.body.capacity, 10);
+
+    if (!name || !(capacity > 0)) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: name, capacity (positive number of lots)",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      AUDITOR_ORG,
+      "RegisterCertifier",
+      name,
+      String(capacity)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Certifier ${name} registered with capacity ${capacity}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in registerCertifier:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+exports.listCertifiers = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const certifiers =
+      (await blockchainClient.query(AUDITOR_ORG, "GetCertifiers")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: certifiers,
+      count: certifiers.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listCertifiers:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Stop assigning lots to a certifier; its open lots go to the others
+exports.retireCertifier = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      AUDITOR_ORG,
+      "RetireCertifier",
+      req.params.certifierId,
+      req.body.reason || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Certifier ${req.params.certifierId} retired`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in retireCertifier:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Open, inspected and declined lots of every certifier, or of one with
+// /certifiers/:certifierId/workload
+exports.getCertifierWorkloads = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const { certifierId } = req.params;
+    if (certifierId) {
+      const workload = await blockchainClient.query(
+        AUDITOR_ORG,
+        "ReadCertifierWorkload",
+        certifierId
+      );
+      return res.status(200).json({
+        success: true,
+        data: workload,
+      });
+    }
+
+    const workloads =
+      (await blockchainClient.query(AUDITOR_ORG, "GetCertifierWorkloads")) ||
+      [];
+
+    res.status(200).json({
+      success: true,
+      data: workloads,
+      count: workloads.length,
+    });
+  } catch (error) {
+    if (/does not exist/.test(error.message)) {
+      return res.status(404).json({
+        error: "Not found",
+        details: error.message,
+      });
+    }
+    console.error("❌ Error in getCertifierWorkloads:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Honor an erasure request for a participant's personal data
+exports.eraseParticipant = async (req, res) => {
+  try {
+    const { participantId, reason } = req.body;
+
+    if (!participantId || !reason) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: participantId, reason",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    console.log(`🗑️ Erasing personal data of participant ${participantId}`);
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "EraseParticipantPII",
+      participantId,
+      reason
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Personal data erased; traceability kept under the pseudonym",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in eraseParticipant:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Identity bindings of participants to the enrollment IDs of their
+// certificates, optionally of one organization (?mspId=)
+exports.listIdentityBindings = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const bindings =
+      (await blockchainClient.query(
+        ADMIN_ORG,
+        "GetIdentityBindings",
+        req.query.mspId || ""
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: bindings,
+      count: bindings.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listIdentityBindings:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Participant an identity recorded in history (?identity=) stands for today
+exports.resolveIdentity = async (req, res) => {
+  try {
+    if (!req.query.identity) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required query parameter: identity",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const resolution = await blockchainClient.query(
+      ADMIN_ORG,
+      "ResolveParticipantIdentity",
+      req.query.identity
+    );
+
+    res.status(200).json({
+      success: true,
+      data: resolution,
+    });
+  } catch (error) {
+    console.error("❌ Error in resolveIdentity:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Re-associate an enrollment with an existing participant after its
+// certificate was re-issued under a new enrollment ID
+exports.rebindIdentity = async (req, res) => {
+  try {
+    const { mspId, enrollmentId } = req.params;
+    const { participantId, reason } = req.body;
+
+    if (!participantId || !reason) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: participantId, reason",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "RebindParticipantIdentity",
+      mspId,
+      enrollmentId,
+      participantId,
+      reason
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Enrollment ${enrollmentId} of ${mspId} rebound`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in rebindIdentity:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Object store (provider and whether it is configured) of each organization
+exports.getObjectStorage = async (req, res) => {
+  const stores = describeStorage(["farmer", "processor", "recycler"]);
+  res.status(200).json({
+    success: true,
+    data: stores,
+    count: stores.length,
+  });
+};
+
+// Gateway peers of each organization with their health scores
+exports.getPeers = async (req, res) => {
+  res.status(200).json({
+    success: true,
+    blockchainConnected: blockchainInitialized,
+    data: blockchainClient.getPeerStatus(),
+  });
+};
+
+// Read the peers configuration again without restarting
+exports.reloadPeers = async (req, res) => {
+  if (!blockchainClient.reloadPeers()) {
+    const status = blockchainClient.getPeerStatus();
+    return res.status(422).json({
+      error: "Invalid peers configuration",
+      details: `${status.lastReloadError}; the current peers were kept`,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    message: "Peers configuration reloaded",
+    data: blockchainClient.getPeerStatus(),
+  });
+};
+
+const DEAD_LETTER_STATUSES = ["OPEN", "REPLAYED", "DISCARDED"];
+const DEAD_LETTER_KINDS = ["EVENT", "ASSET"];
+
+// The read-model indexer runs with the analytics API; its tooling needs it
+// started
+const requireIndexer = (res) => {
+  if (!indexer.isIndexerRunning()) {
+    res.status(503).json({
+      error: "Indexer unavailable",
+    });
+    return false;
+  }
+  return true;
+};
+
+// Indexer state: last block, dead letters per status, last consistency
+// check and re-sync
+exports.getIndexerStatus = async (req, res) => {
+  res.status(200).json({
+    success: true,
+    data: indexer.indexerStatus(),
+  });
+};
+
+// Events and assets the indexer failed to process (?status=OPEN&kind=ASSET)
+exports.listDeadLetters = async (req, res) => {
+  const { status, kind } = req.query;
+  if (status && !DEAD_LETTER_STATUSES.includes(status)) {
+    return res.status(400).json({
+      error: "Invalid status",
+      details: `'status' must be one of: ${DEAD_LETTER_STATUSES.join(", ")}`,
+    });
+  }
+  if (kind && !DEAD_LETTER_KINDS.includes(kind)) {
+    return res.status(400).json({
+      error: "Invalid kind",
+      details: `'kind' must be one of: ${DEAD_LETTER_KINDS.join(", ")}`,
+    });
+  }
+  const entries = indexer.deadLetters.list({ status, kind });
+  res.status(200).json({
+    success: true,
+    data: entries,
+    count: entries.length,
+  });
+};
+
+// Reprocess every open dead letter
+exports.replayDeadLetters = async (req, res) => {
+  try {
+    if (!requireIndexer(res)) {
+      return;
+    }
+    const summary = await indexer.replayDeadLetters();
+    res.status(200).json({
+      success: true,
+      message: `${summary.replayed} replayed, ${summary.failed} still failing`,
+      data: summary,
+    });
+  } catch (error) {
+    console.error("❌ Error in replayDeadLetters:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Look up a dead letter that is still open, answering 404 or 409 otherwise
+const openDeadLetter = (req, res) => {
+  const entry = indexer.deadLetters.get(req.params.deadLetterId);
+  if (!entry) {
+    res.status(404).json({
+      error: "Dead letter not found",
+      deadLetterId: req.params.deadLetterId,
+    });
+    return null;
+  }
+  if (entry.status !== "OPEN") {
+    res.status(409).json({
+      error: "Dead letter not open",
+      details: `Only OPEN dead letters can be processed; this one is ${entry.status}`,
+    });
+    return null;
+  }
+  return entry;
+};
+
+// Reprocess one dead letter
+exports.replayDeadLetter = async (req, res) => {
+  try {
+    if (!requireIndexer(res) || !openDeadLetter(req, res)) {
+      return;
+    }
+    const entry = await indexer.replayDeadLetter(req.params.deadLetterId);
+    res.status(200).json({
+      success: entry.status === "REPLAYED",
+      message:
+        entry.status === "REPLAYED"
+          ? "Dead letter replayed"
+          : "Dead letter still failing",
+      data: entry,
+    });
+  } catch (error) {
+    console.error("❌ Error in replayDeadLetter:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Give up on a dead letter, e.g. an event whose payload will never parse
+exports.discardDeadLetter = async (req, res) => {
+  if (!openDeadLetter(req, res)) {
+    return;
+  }
+  res.status(200).json({
+    success: true,
+    message: "Dead letter discarded",
+    data: indexer.discardDeadLetter(req.params.deadLetterId),
+  });
+};
+
+// Re-ingest every chaincode event from { fromBlock } on
+exports.resyncIndexer = async (req, res) => {
+  try {
+    const fromBlock = Number(req.body?.fromBlock);
+    if (!Number.isInteger(fromBlock) || fromBlock < 0) {
+      return res.status(400).json({
+        error: "Invalid block height",
+        details: "'fromBlock' must be a non-negative integer",
+      });
+    }
+    if (!requireIndexer(res)) {
+      return;
+    }
+    const resync = await indexer.resyncFrom(fromBlock);
+    res.status(202).json({
+      success: true,
+      message: `Re-syncing from block ${fromBlock}`,
+      data: resync,
+    });
+  } catch (error) {
+    console.error("❌ Error in resyncIndexer:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Compare ledger keys with read-model rows now; { repair: true } also
+// re-ingests or removes the rows that differ
+exports.runConsistencyCheck = async (req, res) => {
+  try {
+    if (!requireIndexer(res)) {
+      return;
+    }
+    const report = await indexer.checkConsistency({
+      repair: req.body?.repair === true,
+    });
+    res.status(200).json({
+      success: true,
+      message: report.consistent
+        ? "Read model consistent with the ledger"
+        : "Read model diverges from the ledger",
+      data: report,
+    });
+  } catch (error) {
+    console.error("❌ Error in runConsistencyCheck:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Recent consistency checks, newest first
+exports.listConsistencyChecks = async (req, res) => {
+  const reports = indexer.listConsistencyReports();
+  res.status(200).json({
+    success: true,
+    data: reports,
+    count: reports.length,
+  });
+};
+
+exports.getConsistencyCheck = async (req, res) => {
+  const report = indexer.getConsistencyReport(req.params.checkId);
+  if (!report) {
+    return res.status(404).json({
+      error: "Consistency check not found",
+      checkId: req.params.checkId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    data: report,
+  });
+};
+
+// API audit log: every call to the gateway, apart from the ledger
+const MAX_API_AUDIT_LIMIT = 1000;
+
+// Search the API audit log: ?log=reads|writes, ?from= and ?to= (dates or
+// ISO timestamps), ?actor= (service account), ?org=, ?method=, ?path=
+// (prefix), ?status= (code or class such as 4), ?ip= and ?limit=
+exports.searchApiAudit = async (req, res) => {
+  try {
+    const { log, from, to } = req.query;
+    if (log && !audit.LOGS.includes(log)) {
+      return res.status(400).json({
+        error: "Invalid log",
+        details: `'log' must be one of: ${audit.LOGS.join(", ")}`,
+      });
+    }
+    const badDate = [from, to].find(
+      (value) => value && !DATE_PATTERN.test(value)
+    );
+    if (badDate) {
+      return res.status(400).json({
+        error: "Invalid date",
+        details: `'${badDate}' must be YYYY-MM-DD or an ISO timestamp`,
+      });
+    }
+    const limit = Math.min(
+      parseInt(req.query.limit, 10) || 100,
+      MAX_API_AUDIT_LIMIT
+    );
+
+    const filters = ["actor", "org", "method", "path", "status", "ip"].reduce(
+      (selected, key) =>
+        req.query[key] ? { ...selected, [key]: req.query[key] } : selected,
+      { log, from, to }
+    );
+    const records = await audit.search(filters, limit);
+
+    res.status(200).json({
+      success: true,
+      data: records,
+      count: records.length,
+      retentionDays: audit.RETENTION_DAYS,
+    });
+  } catch (error) {
+    console.error("❌ Error in searchApiAudit:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Check the hash chains of the API audit log over the retained records
+exports.verifyApiAudit = async (req, res) => {
+  try {
+    const logs = await audit.verify();
+    const valid = Object.values(logs).every((result) => result.valid);
+    res.status(200).json({
+      success: true,
+      data: { valid, logs },
+    });
+  } catch (error) {
+    console.error("❌ Error in verifyApiAudit:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
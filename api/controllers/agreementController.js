@@ -0,0 +1,160 @@
+// Agreement Controller - cross-organization data-sharing agreements
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for agreements"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+// Propose a data-sharing agreement to another organization
+exports.proposeAgreement = async (req, res) => {
+  try {
+    const { id, counterparty, scope, validFrom, validUntil } = req.body;
+
+    if (!id || !counterparty || !scope || !validFrom || !validUntil) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details:
+          "All fields are required: id, counterparty, scope, validFrom, validUntil",
+      });
+    }
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "ProposeAgreement",
+      id,
+      counterparty,
+      Array.isArray(scope) ? scope.join(",") : scope,
+      validFrom,
+      validUntil
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Agreement proposed on blockchain",
+      agreementId: id,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in proposeAgreement:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+const changeAgreement = (functionName, message) => async (req, res) => {
+  try {
+    const { agreementId } = req.params;
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      functionName,
+      agreementId
+    );
+
+    res.status(200).json({
+      success: true,
+      message,
+      agreementId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error(`❌ Error in ${functionName}:`, error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Accept (counterparty) or revoke (either party) an agreement
+exports.acceptAgreement = changeAgreement(
+  "AcceptAgreement",
+  "Agreement accepted on blockchain"
+);
+exports.revokeAgreement = changeAgreement(
+  "RevokeAgreement",
+  "Agreement revoked on blockchain"
+);
+
+// List the agreements of the requesting organization
+exports.listAgreements = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const agreements =
+      (await blockchainClient.query(org, "GetMyAgreements")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: agreements,
+      count: agreements.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listAgreements:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,170 @@
+// Alert Controller - alerting rules on chaincode events and their deliveries
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const alerts = require("../alerts");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const ALERT_ORG = process.env.ALERT_ORG || "farmer";
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    await blockchainClient.addContractListener(ALERT_ORG, alerts.handleEvent);
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for alerts"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const DELIVERY_STATUSES = ["PENDING", "SENT", "FAILED", "SKIPPED"];
+
+// List alerting rules and the state of the notification channels
+exports.listRules = async (req, res) => {
+  const rules = alerts.listRules();
+  res.status(200).json({
+    success: true,
+    data: rules,
+    count: rules.length,
+    channels: alerts.listChannels(),
+    listening: blockchainInitialized,
+  });
+};
+
+// Create a rule: { name, eventTypes, assetTypes?, assetIds?, orgs?,
+// channels: [{ channel: "email"|"sms"|"push", to }] }
+exports.createRule = async (req, res) => {
+  try {
+    const rule = alerts.saveRule(req.body || {});
+    res.status(201).json({
+      success: true,
+      message: "Alert rule created",
+      data: rule,
+    });
+  } catch (error) {
+    res.status(400).json({
+      error: "Invalid alert rule",
+      details: error.message,
+    });
+  }
+};
+
+// Get one rule
+exports.getRule = async (req, res) => {
+  const rule = alerts.getRule(req.params.ruleId);
+  if (!rule) {
+    return res.status(404).json({
+      error: "Alert rule not found",
+      ruleId: req.params.ruleId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    data: rule,
+  });
+};
+
+// Update the fields of a rule that are present in the body
+exports.updateRule = async (req, res) => {
+  try {
+    const rule = alerts.updateRule(req.params.ruleId, req.body || {});
+    if (!rule) {
+      return res.status(404).json({
+        error: "Alert rule not found",
+        ruleId: req.params.ruleId,
+      });
+    }
+    res.status(200).json({
+      success: true,
+      message: "Alert rule updated",
+      data: rule,
+    });
+  } catch (error) {
+    res.status(400).json({
+      error: "Invalid alert rule",
+      details: error.message,
+    });
+  }
+};
+
+// Delete a rule
+exports.deleteRule = async (req, res) => {
+  if (!alerts.deleteRule(req.params.ruleId)) {
+    return res.status(404).json({
+      error: "Alert rule not found",
+      ruleId: req.params.ruleId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    message: "Alert rule deleted",
+  });
+};
+
+// Send a sample alert through every channel of a rule
+exports.testRule = async (req, res) => {
+  const deliveries = alerts.testRule(req.params.ruleId);
+  if (!deliveries) {
+    return res.status(404).json({
+      error: "Alert rule not found",
+      ruleId: req.params.ruleId,
+    });
+  }
+  res.status(202).json({
+    success: true,
+    message: "Test alert queued",
+    data: deliveries,
+  });
+};
+
+// List deliveries, optionally by ?status= and ?ruleId=
+exports.listDeliveries = async (req, res) => {
+  const status = req.query.status
+    ? String(req.query.status).toUpperCase()
+    : "";
+  if (status && !DELIVERY_STATUSES.includes(status)) {
+    return res.status(400).json({
+      error: "Invalid status",
+      details: `status must be one of: ${DELIVERY_STATUSES.join(", ")}`,
+    });
+  }
+  const deliveries = alerts.listDeliveries({
+    status,
+    ruleId: req.query.ruleId,
+  });
+  res.status(200).json({
+    success: true,
+    data: deliveries,
+    count: deliveries.length,
+  });
+};
+
+// Get the status of one delivery
+exports.getDelivery = async (req, res) => {
+  const delivery = alerts.getDelivery(req.params.deliveryId);
+  if (!delivery) {
+    return res.status(404).json({
+      error: "Delivery not found",
+      deliveryId: req.params.deliveryId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    data: delivery,
+  });
+};
@@ -0,0 +1,219 @@
+// Analytics Controller - dashboard KPIs served from the indexer's read model
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { readModel, startIndexer } = require("../indexer");
+const {
+  relationshipGraph,
+  toCytoscape,
+  toGraphml,
+} = require("../indexer/graph");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    await startIndexer(blockchainClient);
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for analytics"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+// Ledger graphs are read as the indexer's organization, so both sources
+// show the same lots
+const GRAPH_ORG = process.env.INDEXER_ORG || "farmer";
+const GRAPH_FORMATS = ["cytoscape", "json", "graphml"];
+
+// Validate ?from=&to= (YYYY-MM-DD, inclusive); null when invalid
+const parseRange = (req, res) => {
+  const { from, to } = req.query;
+  for (const value of [from, to]) {
+    if (value && !DATE_PATTERN.test(value)) {
+      res.status(400).json({
+        error: "Invalid date range",
+        details: "'from' and 'to' must be dates formatted YYYY-MM-DD",
+      });
+      return null;
+    }
+  }
+  return { from, to };
+};
+
+const sendView = (res, range, data, extra = {}) =>
+  res.status(200).json({
+    success: true,
+    range,
+    ...extra,
+    data,
+    indexedAt: readModel.lastIngestedAt,
+  });
+
+// Quantity collected, grouped by day, month or region
+exports.getCollected = (req, res) => {
+  try {
+    const range = parseRange(req, res);
+    if (!range) {
+      return;
+    }
+    const groupBy = req.query.groupBy || "month";
+    if (!["day", "month", "region", "none"].includes(groupBy)) {
+      return res.status(400).json({
+        error: "Invalid grouping",
+        details: "'groupBy' must be one of: day, month, region, none",
+      });
+    }
+
+    sendView(res, range, readModel.collectedQuantity(range, groupBy), {
+      groupBy,
+    });
+  } catch (error) {
+    console.error("❌ Error in getCollected:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Recycled / collected quantity per region
+exports.getRecyclingRate = (req, res) => {
+  try {
+    const range = parseRange(req, res);
+    if (!range) {
+      return;
+    }
+
+    sendView(res, range, readModel.recyclingRateByRegion(range), {
+      groupBy: "region",
+    });
+  } catch (error) {
+    console.error("❌ Error in getRecyclingRate:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Processors ranked by extracted quantity
+exports.getTopProcessors = (req, res) => {
+  try {
+    const range = parseRange(req, res);
+    if (!range) {
+      return;
+    }
+    const limit = Math.min(parseInt(req.query.limit, 10) || 5, 100);
+
+    sendView(res, range, readModel.topProcessors(range, limit), { limit });
+  } catch (error) {
+    console.error("❌ Error in getTopProcessors:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Read model size and freshness
+exports.getStatus = (req, res) => {
+  res.status(200).json({
+    success: true,
+    blockchainConnected: blockchainInitialized,
+    data: readModel.status(),
+  });
+};
+
+// Relationship graph around an asset (farm -> lot -> extraction -> product,
+// recyclings, shipments) up to ?depth= hops, as ?format=cytoscape elements,
+// plain json nodes and edges, or a graphml download. Served from the read
+// model; ?source=ledger asks the chaincode, which also knows shipments.
+exports.getRelationshipGraph = async (req, res) => {
+  try {
+    const { rootId } = req.params;
+    const depth = parseInt(req.query.depth, 10) || 0;
+    const format = req.query.format || "cytoscape";
+    const source = req.query.source || "read-model";
+
+    if (!GRAPH_FORMATS.includes(format)) {
+      return res.status(400).json({
+        error: "Invalid format",
+        details: `'format' must be one of: ${GRAPH_FORMATS.join(", ")}`,
+      });
+    }
+    if (!["read-model", "ledger"].includes(source)) {
+      return res.status(400).json({
+        error: "Invalid source",
+        details: "'source' must be read-model or ledger",
+      });
+    }
+
+    let graph;
+    if (source === "ledger") {
+      if (!blockchainInitialized) {
+        return res.status(503).json({
+          error: "Blockchain unavailable",
+        });
+      }
+      graph = await blockchainClient.query(
+        GRAPH_ORG,
+        "GetRelationshipGraph",
+        rootId,
+        String(depth)
+      );
+    } else {
+      graph = relationshipGraph(readModel, rootId, depth);
+    }
+    if (!graph) {
+      return res.status(404).json({
+        error: "Asset not found in the relationship graph",
+        rootId,
+      });
+    }
+
+    if (format === "graphml") {
+      res.setHeader("Content-Type", "application/graphml+xml; charset=utf-8");
+      res.setHeader(
+        "Content-Disposition",
+        `attachment; filename="graph-${encodeURIComponent(rootId)}.graphml"`
+      );
+      return res.status(200).send(toGraphml(graph));
+    }
+
+    res.status(200).json({
+      success: true,
+      source,
+      data: format === "cytoscape" ? toCytoscape(graph) : graph,
+      indexedAt: source === "read-model" ? readModel.lastIngestedAt : undefined,
+    });
+  } catch (error) {
+    if (/no asset .* in the relationship graph/.test(error.message)) {
+      return res.status(404).json({
+        error: "Asset not found in the relationship graph",
+        rootId: req.params.rootId,
+      });
+    }
+    console.error("❌ Error in getRelationshipGraph:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,346 @@
+// Approval Controller - multi-signature approval of high-value transfers and
+// the approval matrices deciding which transfers need it
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for approvals"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const APPROVER_ROLES = [
+  "OWNER",
+  "COOPERATIVE_ADMIN",
+  "ADMIN",
+  "AUDITOR",
+  "BUYER",
+];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+// Chaincode refusals of an invalid approval matrix
+const INVALID_REQUEST =
+  /invalid |unknown |in the past|already in effect|lists no role|more than one /;
+
+const sendError = (res, name, error) => {
+  if (/does not exist/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  if (/not authorized|only .* or an admin/.test(error.message)) {
+    return res.status(403).json({
+      error: "Forbidden",
+      details: error.message,
+    });
+  }
+  if (INVALID_REQUEST.test(error.message)) {
+    return res.status(400).json({
+      error: "Invalid request",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Approvals, newest first; wasteId and status (e.g. PENDING_APPROVAL)
+// filter them
+exports.listApprovals = async (req, res) => {
+  try {
+    const { wasteId, status } = req.query;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const approvals =
+      (await blockchainClient.query(
+        org,
+        "GetApprovals",
+        wasteId || "",
+        String(status || "").toUpperCase()
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: approvals,
+      count: approvals.length,
+    });
+  } catch (error) {
+    sendError(res, "listApprovals", error);
+  }
+};
+
+exports.getApproval = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const approval = await blockchainClient.query(
+      org,
+      "ReadApproval",
+      req.params.approvalId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: approval,
+    });
+  } catch (error) {
+    sendError(res, "getApproval", error);
+  }
+};
+
+// Sign an approval in a role; the signature completing the quorum runs the
+// held operation
+exports.approve = async (req, res) => {
+  try {
+    const { approvalId } = req.params;
+    const role = String(req.body.role || "").toUpperCase();
+
+    if (!APPROVER_ROLES.includes(role)) {
+      return res.status(400).json({
+        error: "Invalid role",
+        details: `'role' must be one of: ${APPROVER_ROLES.join(", ")}`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "Approve",
+      approvalId,
+      role
+    );
+    const approval = result?.result;
+
+    res.status(200).json({
+      success: true,
+      message:
+        approval?.status === "EXECUTED"
+          ? `Approval ${approvalId} complete; the operation was executed`
+          : `Approval ${approvalId} is ${approval?.status || "updated"}`,
+      data: approval,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "approve", error);
+  }
+};
+
+exports.reject = async (req, res) => {
+  try {
+    const { approvalId } = req.params;
+    const { reason } = req.body;
+
+    if (!reason) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required field: reason",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RejectApproval",
+      approvalId,
+      reason
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Approval ${approvalId} rejected`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "reject", error);
+  }
+};
+
+// Approval matrices of all organizations (admin identity of ?org=)
+exports.listMatrices = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const matrices =
+      (await blockchainClient.query(org, "GetApprovalMatrices")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: matrices,
+      count: matrices.length,
+    });
+  } catch (error) {
+    sendError(res, "listMatrices", error);
+  }
+};
+
+// Approval matrix of an organization (MSP ID), with its past and scheduled
+// versions
+exports.getMatrix = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const matrix = await blockchainClient.query(
+      org,
+      "ReadApprovalMatrix",
+      req.params.orgMsp
+    );
+
+    res.status(200).json({
+      success: true,
+      data: matrix,
+    });
+  } catch (error) {
+    sendError(res, "getMatrix", error);
+  }
+};
+
+// Approval rules of an organization in effect ?at= a date (now by default)
+exports.getEffectiveRules = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const rules = await blockchainClient.query(
+      org,
+      "GetEffectiveApprovalRules",
+      req.params.orgMsp,
+      req.query.at || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      data: rules,
+    });
+  } catch (error) {
+    sendError(res, "getEffectiveRules", error);
+  }
+};
+
+// Schedule a version of an organization's approval matrix:
+// { effectiveFrom, rules: [{ operation, minQuantity, requiredRoles }] }
+exports.setMatrix = async (req, res) => {
+  try {
+    const { orgMsp } = req.params;
+    const { effectiveFrom, rules } = req.body;
+
+    if (!Array.isArray(rules)) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required field: rules (an array, empty to lift approvals)",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "SetApprovalMatrix",
+      orgMsp,
+      effectiveFrom || "",
+      JSON.stringify(rules)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Approval matrix of ${orgMsp} updated`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "setMatrix", error);
+  }
+};
+
+// Cancel a version of an organization's approval matrix not in effect yet
+exports.cancelMatrixVersion = async (req, res) => {
+  try {
+    const { orgMsp, effectiveFrom } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CancelApprovalMatrixVersion",
+      orgMsp,
+      effectiveFrom
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Version ${effectiveFrom} of the approval matrix of ${orgMsp} cancelled`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "cancelMatrixVersion", error);
+  }
+};
@@ -0,0 +1,168 @@
+// Campaign Controller - harvest campaigns and campaign statistics
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for campaigns"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const requireBlockchain = (res) => {
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return false;
+  }
+  return true;
+};
+
+// Open a new harvest campaign (e.g. "2025/26 season")
+exports.createCampaign = async (req, res) => {
+  try {
+    const { id, name, startDate, endDate, actor } = req.body;
+
+    if (!id || !name || !startDate || !endDate) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: id, name, startDate, endDate",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "farmer",
+      "CreateCampaign",
+      id,
+      name,
+      startDate,
+      endDate,
+      actor || "farmer_001"
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Campaign opened on blockchain",
+      campaignId: id,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in createCampaign:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Close a campaign, freezing waste creation under it
+exports.closeCampaign = async (req, res) => {
+  try {
+    const { campaignId } = req.params;
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "farmer",
+      "CloseCampaign",
+      campaignId,
+      req.body.actor || "farmer_001"
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Campaign closed on blockchain",
+      campaignId: campaignId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in closeCampaign:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List all campaigns
+exports.listCampaigns = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const campaigns =
+      (await blockchainClient.query("farmer", "GetAllCampaigns")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: campaigns,
+      count: campaigns.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listCampaigns:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Campaign-scoped totals and yields
+exports.getCampaignStatistics = async (req, res) => {
+  try {
+    const { campaignId } = req.params;
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const statistics = await blockchainClient.query(
+      "farmer",
+      "GetCampaignStatistics",
+      campaignId
+    );
+    if (!statistics) {
+      return res.status(404).json({
+        error: "Campaign not found",
+        campaignId: campaignId,
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: statistics,
+    });
+  } catch (error) {
+    console.error("❌ Error in getCampaignStatistics:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
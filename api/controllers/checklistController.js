@@ -0,0 +1,206 @@
+// Checklist Controller - checklists lots complete before status transitions
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for checklists"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Define or replace the checklist of a transition (admin identity); items
+// are { key, label, mandatory, valueRequired }
+exports.defineTemplate = async (req, res) => {
+  try {
+    const { transition } = req.params;
+    const { name, items } = req.body;
+
+    if (
+      !Array.isArray(items) ||
+      items.length === 0 ||
+      items.some((item) => !item?.key || !item?.label)
+    ) {
+      return res.status(400).json({
+        error: "Invalid checklist",
+        details:
+          "'items' must list { key, label, mandatory, valueRequired } entries",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "DefineChecklistTemplate",
+      transition.toUpperCase(),
+      name || "",
+      JSON.stringify(
+        items.map((item) => ({
+          key: item.key,
+          label: item.label,
+          mandatory: Boolean(item.mandatory),
+          valueRequired: Boolean(item.valueRequired),
+        }))
+      )
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Checklist for ${transition.toUpperCase()} defined`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "defineTemplate", error);
+  }
+};
+
+exports.removeTemplate = async (req, res) => {
+  try {
+    const { transition } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RemoveChecklistTemplate",
+      transition.toUpperCase()
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Checklist for ${transition.toUpperCase()} removed`,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "removeTemplate", error);
+  }
+};
+
+exports.listTemplates = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const templates =
+      (await blockchainClient.query(org, "GetChecklistTemplates")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: templates,
+      count: templates.length,
+    });
+  } catch (error) {
+    sendError(res, "listTemplates", error);
+  }
+};
+
+// Progress of a lot through the checklist of a transition
+exports.getProgress = async (req, res) => {
+  try {
+    const { transition, wasteId } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const progress = await blockchainClient.query(
+      org,
+      "GetChecklistProgress",
+      wasteId,
+      transition.toUpperCase()
+    );
+
+    res.status(200).json({
+      success: true,
+      data: progress,
+    });
+  } catch (error) {
+    sendError(res, "getProgress", error);
+  }
+};
+
+// Complete a checklist item for a lot, with its reading or reference
+exports.completeItem = async (req, res) => {
+  try {
+    const { transition, wasteId, itemKey } = req.params;
+    const value = req.body.value === undefined ? "" : String(req.body.value);
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CompleteChecklistItem",
+      wasteId,
+      transition.toUpperCase(),
+      itemKey,
+      value
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Checklist item ${itemKey} completed for waste ${wasteId}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "completeItem", error);
+  }
+};
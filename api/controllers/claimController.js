@@ -0,0 +1,328 @@
+// Claim Controller - insurance claims for lost or damaged lots
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for claims"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Organizations whose gateway identities hold the insurer and assessor roles
+const INSURER_ORG =
+  process.env.INSURER_ORG || process.env.ADMIN_ORG || "farmer";
+const ASSESSOR_ORG =
+  process.env.ASSESSOR_ORG || process.env.ADMIN_ORG || "farmer";
+
+const CLAIM_KINDS = ["LOST", "DAMAGED"];
+const SHA256_PATTERN = /^[0-9a-fA-F]{64}$/;
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const requireBlockchain = (res) => {
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return false;
+  }
+  return true;
+};
+
+const sendResult = (res, status, message, result) =>
+  res.status(status).json({
+    success: true,
+    message: message,
+    data: result?.result,
+    blockchainTxId: result?.transactionId || "pending",
+  });
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// File a claim for a lost or damaged lot (lot owner)
+exports.fileClaim = async (req, res) => {
+  try {
+    const {
+      id,
+      wasteId,
+      shipmentRef,
+      kind,
+      lossQuantity,
+      description,
+      insurerMsp,
+    } = req.body;
+
+    if (!wasteId || !kind || !lossQuantity || !description) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: wasteId, kind, lossQuantity, description",
+      });
+    }
+    if (!CLAIM_KINDS.includes(String(kind).toUpperCase())) {
+      return res.status(400).json({
+        error: "Invalid claim kind",
+        details: `'kind' must be one of: ${CLAIM_KINDS.join(", ")}`,
+      });
+    }
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    console.log(`🛡️ Filing ${kind} claim for waste ${wasteId}`);
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "FileClaim",
+      id || "",
+      wasteId,
+      shipmentRef || "",
+      String(kind).toUpperCase(),
+      String(parseFloat(lossQuantity)),
+      description,
+      insurerMsp || ""
+    );
+
+    sendResult(res, 201, "Claim filed on blockchain", result);
+  } catch (error) {
+    sendError(res, "fileClaim", error);
+  }
+};
+
+// Anchor an evidence document hash to a claim. "role" picks the gateway
+// identity: claimant (with org), assessor or insurer.
+exports.addEvidence = async (req, res) => {
+  try {
+    const { claimId } = req.params;
+    const { docType, docHash, uri, role } = req.body;
+
+    if (!docType || !SHA256_PATTERN.test(docHash || "")) {
+      return res.status(400).json({
+        error: "Invalid evidence",
+        details: "'docType' and a hex SHA-256 'docHash' are required",
+      });
+    }
+
+    let org;
+    if (role === "assessor") {
+      org = ASSESSOR_ORG;
+    } else if (role === "insurer") {
+      org = INSURER_ORG;
+    } else {
+      org = resolveOrg(req, res);
+      if (!org) {
+        return;
+      }
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "AddClaimEvidence",
+      claimId,
+      docType,
+      docHash.toLowerCase(),
+      uri || ""
+    );
+
+    sendResult(res, 200, "Evidence anchored to claim", result);
+  } catch (error) {
+    sendError(res, "addEvidence", error);
+  }
+};
+
+// Record the assessor's findings
+exports.assessClaim = async (req, res) => {
+  try {
+    const { claimId } = req.params;
+    const { finding, lossQuantity, recommendedAmount } = req.body;
+
+    if (!finding || lossQuantity === undefined) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: finding, lossQuantity, recommendedAmount",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ASSESSOR_ORG,
+      "AssessClaim",
+      claimId,
+      finding,
+      String(parseFloat(lossQuantity) || 0),
+      String(parseFloat(recommendedAmount) || 0)
+    );
+
+    sendResult(res, 200, "Claim assessment recorded on blockchain", result);
+  } catch (error) {
+    sendError(res, "assessClaim", error);
+  }
+};
+
+// Approve or reject an assessed claim (insurer)
+exports.decideClaim = async (req, res) => {
+  try {
+    const { claimId } = req.params;
+    const { approve, reason } = req.body;
+
+    if (typeof approve !== "boolean" || !reason) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: approve (boolean), reason",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      INSURER_ORG,
+      "DecideClaim",
+      claimId,
+      String(approve),
+      reason
+    );
+
+    sendResult(
+      res,
+      200,
+      approve ? "Claim approved on blockchain" : "Claim rejected on blockchain",
+      result
+    );
+  } catch (error) {
+    sendError(res, "decideClaim", error);
+  }
+};
+
+// Record the payout of an approved claim; the lot becomes LOST/WRITTEN_OFF
+exports.settleClaim = async (req, res) => {
+  try {
+    const { claimId } = req.params;
+    const { amount, reference } = req.body;
+
+    if (!(parseFloat(amount) > 0)) {
+      return res.status(400).json({
+        error: "Invalid amount",
+        details: "'amount' must be a positive number",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      INSURER_ORG,
+      "SettleClaim",
+      claimId,
+      String(parseFloat(amount)),
+      reference || ""
+    );
+
+    sendResult(res, 200, "Claim settled on blockchain", result);
+  } catch (error) {
+    sendError(res, "settleClaim", error);
+  }
+};
+
+// Get one claim
+exports.getClaim = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const claim = await blockchainClient.query(
+      org,
+      "ReadClaim",
+      req.params.claimId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: claim,
+    });
+  } catch (error) {
+    sendError(res, "getClaim", error);
+  }
+};
+
+// Claims filed for a lot
+exports.listClaims = async (req, res) => {
+  try {
+    const { wasteId } = req.query;
+
+    if (!wasteId) {
+      return res.status(400).json({
+        error: "Missing wasteId",
+        details: "The 'wasteId' query parameter is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const claims =
+      (await blockchainClient.query(org, "GetClaimsForWaste", wasteId)) || [];
+
+    res.status(200).json({
+      success: true,
+      data: claims,
+      count: claims.length,
+    });
+  } catch (error) {
+    sendError(res, "listClaims", error);
+  }
+};
@@ -0,0 +1,232 @@
+// Collection Controller - pickup requests and collector scheduling
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for collections"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const requireBlockchain = (res) => {
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return false;
+  }
+  return true;
+};
+
+// Creation functions return the stored asset, carrying any generated ID
+const returnedId = (result, requestedId) =>
+  result?.result?.id || requestedId || "pending";
+
+// Farmer requests a pickup before the lot exists on-chain
+exports.requestCollection = async (req, res) => {
+  try {
+    const { farm, owner, estimatedQuantity, windowStart, windowEnd } =
+      req.body;
+
+    if (!farm || !estimatedQuantity || !windowStart || !windowEnd) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details:
+          "All fields are required: farm, estimatedQuantity, windowStart, windowEnd",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    // An empty id lets the chaincode generate one
+    const result = await blockchainClient.submitTransaction(
+      "farmer",
+      "RequestCollection",
+      req.body.id || "",
+      farm,
+      owner || "farmer_001",
+      String(parseFloat(estimatedQuantity)),
+      windowStart,
+      windowEnd
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Collection request recorded on blockchain",
+      requestId: returnedId(result, req.body.id),
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in requestCollection:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Assign a collector to a pending request
+exports.assignCollector = async (req, res) => {
+  try {
+    const { requestId } = req.params;
+    const { collector, actor } = req.body;
+
+    if (!collector) {
+      return res.status(400).json({
+        error: "Missing collector",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "processor",
+      "AssignCollector",
+      requestId,
+      collector,
+      actor || collector
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Collector assigned on blockchain",
+      requestId: requestId,
+      collector: collector,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in assignCollector:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Cancel a request that has not been fulfilled
+exports.cancelCollection = async (req, res) => {
+  try {
+    const { requestId } = req.params;
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "farmer",
+      "CancelCollectionRequest",
+      requestId,
+      req.body.actor || "farmer_001",
+      req.body.reason || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Collection request cancelled on blockchain",
+      requestId: requestId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in cancelCollection:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Record the collected lot, creating the waste linked to the request
+exports.fulfillCollection = async (req, res) => {
+  try {
+    const { requestId } = req.params;
+    const { type, quantity, harvestDate, location } = req.body;
+
+    if (!type || !quantity || !harvestDate) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: type, quantity, harvestDate",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "farmer",
+      "FulfillCollectionRequest",
+      requestId,
+      req.body.wasteId || "",
+      type,
+      String(parseFloat(quantity)),
+      harvestDate,
+      location || ""
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Collected waste recorded and linked to request",
+      requestId: requestId,
+      wasteId: returnedId(result, req.body.wasteId),
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in fulfillCollection:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List collection requests, optionally by status
+exports.listCollections = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const requests =
+      (await blockchainClient.query(
+        "farmer",
+        "GetAllCollectionRequests",
+        req.query.status || ""
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: requests,
+      count: requests.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listCollections:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
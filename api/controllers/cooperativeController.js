@@ -0,0 +1,315 @@
+// Cooperative Controller - cooperatives, their member farms and what their
+// admins may do with member lots
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for cooperatives"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const COOPERATIVE_ACTIONS = ["VIEW", "LIST", "CLAIM", "APPROVE"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendResult = (res, status, message, result) =>
+  res.status(status).json({
+    success: true,
+    message: message,
+    data: result?.result,
+    blockchainTxId: result?.transactionId || "pending",
+  });
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Register a cooperative; the requesting identity becomes its first admin
+exports.registerCooperative = async (req, res) => {
+  try {
+    const { name } = req.body;
+
+    if (!name) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "The 'name' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    console.log(`🤝 Registering cooperative ${name}`);
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RegisterCooperative",
+      name
+    );
+
+    sendResult(res, 201, "Cooperative registered on blockchain", result);
+  } catch (error) {
+    sendError(res, "registerCooperative", error);
+  }
+};
+
+// Let another client identity administer the cooperative
+exports.addAdmin = async (req, res) => {
+  try {
+    const { adminId } = req.body;
+
+    if (!adminId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "The 'adminId' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "AddCooperativeAdmin",
+      req.params.cooperativeId,
+      adminId
+    );
+
+    sendResult(res, 200, "Cooperative admin added", result);
+  } catch (error) {
+    sendError(res, "addAdmin", error);
+  }
+};
+
+// Replace the permission matrix: { tier: ["VIEW", "LIST", "CLAIM", "APPROVE"] }
+exports.setPermissions = async (req, res) => {
+  try {
+    const { permissions } = req.body;
+
+    const valid =
+      permissions &&
+      typeof permissions === "object" &&
+      Object.values(permissions).every(
+        (actions) =>
+          Array.isArray(actions) &&
+          actions.every((action) =>
+            COOPERATIVE_ACTIONS.includes(String(action).toUpperCase())
+          )
+      );
+    if (!valid) {
+      return res.status(400).json({
+        error: "Invalid permission matrix",
+        details: `'permissions' must map member tiers to actions among: ${COOPERATIVE_ACTIONS.join(", ")}`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "SetCooperativePermissions",
+      req.params.cooperativeId,
+      JSON.stringify(permissions)
+    );
+
+    sendResult(res, 200, "Cooperative permissions updated", result);
+  } catch (error) {
+    sendError(res, "setPermissions", error);
+  }
+};
+
+// Join a participant (farm or cooperative) to the cooperative
+exports.joinCooperative = async (req, res) => {
+  try {
+    const { memberId, tier } = req.body;
+
+    if (!memberId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "The 'memberId' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "JoinCooperative",
+      req.params.cooperativeId,
+      memberId,
+      tier || ""
+    );
+
+    sendResult(res, 201, "Member joined the cooperative", result);
+  } catch (error) {
+    sendError(res, "joinCooperative", error);
+  }
+};
+
+// End a participant's membership (member side or cooperative admins)
+exports.leaveCooperative = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "LeaveCooperative",
+      req.params.memberId,
+      req.body?.reason || ""
+    );
+
+    sendResult(res, 200, "Membership ended", result);
+  } catch (error) {
+    sendError(res, "leaveCooperative", error);
+  }
+};
+
+// Current membership of a participant with its join/leave history
+exports.getMembership = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const membership = await blockchainClient.query(
+      org,
+      "GetMembership",
+      req.params.memberId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: membership,
+    });
+  } catch (error) {
+    sendError(res, "getMembership", error);
+  }
+};
+
+// Get one cooperative with its admins and permission matrix
+exports.getCooperative = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const cooperative = await blockchainClient.query(
+      org,
+      "ReadCooperative",
+      req.params.cooperativeId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: cooperative,
+    });
+  } catch (error) {
+    sendError(res, "getCooperative", error);
+  }
+};
+
+// Active members (?nested=true adds the members of member cooperatives)
+exports.listMembers = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const members =
+      (await blockchainClient.query(
+        org,
+        "GetCooperativeMembers",
+        req.params.cooperativeId,
+        String(req.query.nested === "true")
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: members,
+      count: members.length,
+    });
+  } catch (error) {
+    sendError(res, "listMembers", error);
+  }
+};
+
+// Member lots the requesting cooperative admin may view
+exports.listAssets = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const assets =
+      (await blockchainClient.query(
+        org,
+        "GetCooperativeAssets",
+        req.params.cooperativeId
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: assets,
+      count: assets.length,
+    });
+  } catch (error) {
+    sendError(res, "listAssets", error);
+  }
+};
@@ -0,0 +1,355 @@
+// Credit Controller - credit limits and payment terms sellers set per buyer,
+// the invoices buyers owe and the unpaid exposure computed from them
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for credit"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const POLICIES = ["WARN", "BLOCK"];
+
+const INVOICE_STATUSES = ["UNPAID", "PAID", "VOID"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist|no credit limit/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  if (/(^|: )only /.test(error.message)) {
+    return res.status(403).json({
+      error: "Forbidden",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Credit limits the organization set or is subject to
+exports.listLimits = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const limits = (await blockchainClient.query(org, "GetCreditLimits")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: limits,
+      count: limits.length,
+    });
+  } catch (error) {
+    sendError(res, "listLimits", error);
+  }
+};
+
+// Set the credit the organization extends to :buyerMsp:
+// { org, limit, paymentTermsDays, policy }
+exports.setLimit = async (req, res) => {
+  try {
+    const { buyerMsp } = req.params;
+    const limit = Number(req.body.limit);
+    const paymentTermsDays = Number(req.body.paymentTermsDays || 0);
+    const policy = String(req.body.policy || "").toUpperCase();
+
+    if (!(limit >= 0)) {
+      return res.status(400).json({
+        error: "Invalid limit",
+        details: "'limit' must be an amount, zero or more",
+      });
+    }
+    if (!Number.isInteger(paymentTermsDays) || paymentTermsDays < 0) {
+      return res.status(400).json({
+        error: "Invalid payment terms",
+        details: "'paymentTermsDays' must be a whole number of days",
+      });
+    }
+    if (policy && !POLICIES.includes(policy)) {
+      return res.status(400).json({
+        error: "Invalid policy",
+        details: `'policy' must be one of: ${POLICIES.join(", ")}`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "SetCreditLimit",
+      buyerMsp,
+      String(limit),
+      String(paymentTermsDays),
+      policy
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Credit limit for ${buyerMsp} set to ${limit}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "setLimit", error);
+  }
+};
+
+exports.removeLimit = async (req, res) => {
+  try {
+    const { buyerMsp } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RemoveCreditLimit",
+      buyerMsp
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Credit limit for ${buyerMsp} removed`,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "removeLimit", error);
+  }
+};
+
+// What ?buyerMsp= owes ?sellerMsp= (the organization by default)
+exports.getExposure = async (req, res) => {
+  try {
+    const { sellerMsp, buyerMsp } = req.query;
+    if (!buyerMsp) {
+      return res.status(400).json({
+        error: "Missing buyer",
+        details: "The 'buyerMsp' query parameter is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const exposure = await blockchainClient.query(
+      org,
+      "GetCreditExposure",
+      sellerMsp || "",
+      buyerMsp
+    );
+
+    res.status(200).json({
+      success: true,
+      data: exposure,
+    });
+  } catch (error) {
+    sendError(res, "getExposure", error);
+  }
+};
+
+// Invoices issued or received, newest first (?counterpartyMsp=&status=)
+exports.listInvoices = async (req, res) => {
+  try {
+    const status = String(req.query.status || "").toUpperCase();
+    if (status && !INVOICE_STATUSES.includes(status)) {
+      return res.status(400).json({
+        error: "Invalid status",
+        details: `'status' must be one of: ${INVOICE_STATUSES.join(", ")}`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const invoices =
+      (await blockchainClient.query(
+        org,
+        "GetInvoices",
+        req.query.counterpartyMsp || "",
+        status
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: invoices,
+      count: invoices.length,
+    });
+  } catch (error) {
+    sendError(res, "listInvoices", error);
+  }
+};
+
+exports.getInvoice = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const invoice = await blockchainClient.query(
+      org,
+      "ReadInvoice",
+      req.params.invoiceId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: invoice,
+    });
+  } catch (error) {
+    sendError(res, "getInvoice", error);
+  }
+};
+
+// Invoice a sale made off the marketplace:
+// { org, buyerMsp, amount, wasteId, reference }
+exports.issueInvoice = async (req, res) => {
+  try {
+    const { buyerMsp, wasteId, reference } = req.body;
+    const amount = Number(req.body.amount);
+
+    if (!buyerMsp || !(amount > 0)) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: buyerMsp, amount (positive)",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "IssueInvoice",
+      buyerMsp,
+      String(amount),
+      wasteId || "",
+      reference || ""
+    );
+    const invoice = result?.result;
+
+    res.status(201).json({
+      success: true,
+      message: `Invoice ${invoice?.id} issued to ${buyerMsp}, due ${invoice?.dueAt}`,
+      data: invoice,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "issueInvoice", error);
+  }
+};
+
+exports.markInvoicePaid = async (req, res) => {
+  try {
+    const { invoiceId } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "MarkInvoicePaid",
+      invoiceId
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Invoice ${invoiceId} paid`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "markInvoicePaid", error);
+  }
+};
+
+exports.voidInvoice = async (req, res) => {
+  try {
+    const { invoiceId } = req.params;
+    const { reason } = req.body;
+
+    if (!reason) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required field: reason",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "VoidInvoice",
+      invoiceId,
+      reason
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Invoice ${invoiceId} voided`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "voidInvoice", error);
+  }
+};
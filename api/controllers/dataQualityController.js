@@ -0,0 +1,352 @@
+// Data Quality Controller - legacy lots failing the current completeness
+// rules (farm, location, harvest date, plot) and bulk backfills of the
+// missing fields by their owners
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { readModel } = require("../indexer");
+const {
+  QUALITY_FIELDS,
+  SEVERITIES,
+  assessWaste,
+  dataQualityReport,
+} = require("../indexer/quality");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for data quality"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Validate ?field=&minSeverity=&source=; null when invalid
+const parseFilters = (req, res) => {
+  const { field, ownerMsp } = req.query;
+  const minSeverity = req.query.minSeverity?.toUpperCase();
+  const source = req.query.source || "read-model";
+  if (field && !QUALITY_FIELDS.includes(field)) {
+    res.status(400).json({
+      error: "Invalid field",
+      details: `'field' must be one of: ${QUALITY_FIELDS.join(", ")}`,
+    });
+    return null;
+  }
+  if (minSeverity && !SEVERITIES.includes(minSeverity)) {
+    res.status(400).json({
+      error: "Invalid severity",
+      details: `'minSeverity' must be one of: ${SEVERITIES.join(", ")}`,
+    });
+    return null;
+  }
+  if (!["read-model", "ledger"].includes(source)) {
+    res.status(400).json({
+      error: "Invalid source",
+      details: "'source' must be read-model or ledger",
+    });
+    return null;
+  }
+  return { field, minSeverity, ownerMsp, source };
+};
+
+// Incomplete lots, worst first (?field=farm&minSeverity=MEDIUM). Served from
+// the read model; ?source=ledger asks the chaincode, which also weighs the
+// fields each owner's validation profile requires and sees the farms and
+// locations of the caller's own pseudonymized lots.
+exports.getReport = async (req, res) => {
+  try {
+    const filters = parseFilters(req, res);
+    if (!filters) {
+      return;
+    }
+
+    let report;
+    if (filters.source === "ledger") {
+      if (!blockchainInitialized) {
+        return res.status(503).json({
+          error: "Blockchain unavailable",
+        });
+      }
+      report = await blockchainClient.query(
+        req.query.org || "farmer",
+        "GetDataQualityReport",
+        filters.field || "",
+        filters.minSeverity || ""
+      );
+      if (filters.ownerMsp && report) {
+        report.records = report.records.filter(
+          (record) => record.ownerMsp === filters.ownerMsp
+        );
+      }
+    } else {
+      report = dataQualityReport(readModel, filters);
+    }
+
+    res.status(200).json({
+      success: true,
+      source: filters.source,
+      data: report,
+      count: report?.records?.length || 0,
+      indexedAt:
+        filters.source === "read-model" ? readModel.lastIngestedAt : undefined,
+    });
+  } catch (error) {
+    console.error("❌ Error in getReport:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Completeness of one lot
+exports.getWasteQuality = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const source = req.query.source || "read-model";
+
+    let quality;
+    if (source === "ledger") {
+      if (!blockchainInitialized) {
+        return res.status(503).json({
+          error: "Blockchain unavailable",
+        });
+      }
+      quality = await blockchainClient.query(
+        req.query.org || "farmer",
+        "GetWasteQuality",
+        wasteId
+      );
+    } else {
+      const waste = readModel.wastes.get(wasteId);
+      quality = waste ? assessWaste(waste) : null;
+    }
+    if (!quality) {
+      return res.status(404).json({
+        error: "Waste not found",
+        wasteId,
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      source,
+      data: quality,
+    });
+  } catch (error) {
+    if (/does not exist/.test(error.message)) {
+      return res.status(404).json({
+        error: "Waste not found",
+        wasteId: req.params.wasteId,
+      });
+    }
+    console.error("❌ Error in getWasteQuality:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Backfill missing fields on the caller's lots:
+// { org, enrichments: [{ wasteId, farm, location, harvestDate, plotId }] }.
+// Fields already set are never overwritten; each addition is noted in the
+// lot's history. Farms and locations travel as transient data.
+exports.enrichWastes = async (req, res) => {
+  try {
+    const { enrichments } = req.body;
+    const org = req.body.org || "farmer";
+
+    if (!Array.isArray(enrichments) || enrichments.length === 0) {
+      return res.status(400).json({
+        error: "Missing enrichments",
+        details: "'enrichments' must be a non-empty array",
+      });
+    }
+    if (enrichments.some((enrichment) => !enrichment?.wasteId)) {
+      return res.status(400).json({
+        error: "Invalid enrichments",
+        details: "Each enrichment needs a 'wasteId'",
+      });
+    }
+    if (!ORGANIZATIONS.includes(org)) {
+      return res.status(400).json({
+        error: "Invalid organization",
+        details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const batch = enrichments.map(
+      ({ wasteId, farm, location, harvestDate, plotId }) => ({
+        wasteId,
+        farm,
+        location,
+        harvestDate,
+        plotId,
+      })
+    );
+    const result = await blockchainClient.submitPrivateTransaction(
+      org,
+      "EnrichWastes",
+      { enrichments: batch },
+      ""
+    );
+    const data = result?.result;
+
+    res.status(200).json({
+      success: true,
+      message: `${Object.keys(data?.enriched || {}).length} of ${
+        batch.length
+      } lots enriched`,
+      data,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in enrichWastes:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Suspected duplicate lots: same owner, harvest date, waste type and plot
+// with quantities within ?tolerancePct= of each other (the ledger setting
+// by default). Admins and auditors see every organization's lots.
+exports.getDuplicates = async (req, res) => {
+  try {
+    const tolerancePct = Number(req.query.tolerancePct || 0);
+    const org = req.query.org || "farmer";
+
+    if (!Number.isFinite(tolerancePct) || tolerancePct < 0) {
+      return res.status(400).json({
+        error: "Invalid tolerance",
+        details: "'tolerancePct' must be a non-negative number",
+      });
+    }
+    if (!ORGANIZATIONS.includes(org)) {
+      return res.status(400).json({
+        error: "Invalid organization",
+        details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const report = await blockchainClient.query(
+      org,
+      "FindDuplicateWastes",
+      String(tolerancePct)
+    );
+
+    res.status(200).json({
+      success: true,
+      data: report,
+      count: report?.groups?.length || 0,
+    });
+  } catch (error) {
+    console.error("❌ Error in getDuplicates:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Merge duplicates into the lot to keep (admin identity):
+// { org, keepId, duplicateIds }. Duplicates are marked MERGED and their
+// history is copied into the kept lot's.
+exports.mergeDuplicates = async (req, res) => {
+  try {
+    const { keepId, duplicateIds } = req.body;
+    const org = req.body.org || "farmer";
+
+    if (
+      !keepId ||
+      !Array.isArray(duplicateIds) ||
+      duplicateIds.length === 0 ||
+      duplicateIds.some((id) => typeof id !== "string" || id === "")
+    ) {
+      return res.status(400).json({
+        error: "Invalid merge",
+        details: "Required: keepId and a non-empty duplicateIds array",
+      });
+    }
+    if (!ORGANIZATIONS.includes(org)) {
+      return res.status(400).json({
+        error: "Invalid organization",
+        details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "MergeDuplicates",
+      keepId,
+      JSON.stringify(duplicateIds)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `${duplicateIds.length} duplicates merged into ${keepId}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    if (/does not exist/.test(error.message)) {
+      return res.status(404).json({
+        error: "Waste not found",
+        details: error.message,
+      });
+    }
+    if (/(^|: )only /.test(error.message)) {
+      return res.status(403).json({
+        error: "Forbidden",
+        details: error.message,
+      });
+    }
+    if (/merged|not the same harvest|more than once/.test(error.message)) {
+      return res.status(409).json({
+        error: "Conflict",
+        details: error.message,
+      });
+    }
+    console.error("❌ Error in mergeDuplicates:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
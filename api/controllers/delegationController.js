@@ -0,0 +1,331 @@
+// Delegation Controller - on-chain delegations and service-account tokens
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  SERVICE_ACCOUNT_PREFIX,
+  issueServiceToken,
+  verifyServiceToken,
+  scopeForPath,
+} = require(path.join(__dirname, "..", "..", "blockchain", "serviceTokens"));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for delegations"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// MSP of each organization's gateway identity
+const ORG_MSPS = {
+  farmer: "FarmerOrgMSP",
+  processor: "ProcessorOrgMSP",
+  recycler: "RecyclerOrgMSP",
+};
+
+// Service tokens live one hour unless asked otherwise, and never past a day
+// or the delegation's own expiry
+const DEFAULT_TOKEN_TTL_SECONDS = 60 * 60;
+const MAX_TOKEN_TTL_SECONDS = 24 * 60 * 60;
+
+// Delegations are re-read from the ledger at most once a minute per token
+const DELEGATION_CHECK_TTL_MS = 60 * 1000;
+const checkedDelegations = new Map();
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const isUsable = (delegation) =>
+  delegation?.status === "ACTIVE" &&
+  new Date(delegation.expiresAt).getTime() > Date.now();
+
+// Delegate a read scope to an identity or to one of the organization's
+// service accounts
+exports.createDelegation = async (req, res) => {
+  try {
+    const { id, serviceAccount, scope, expiresAt } = req.body;
+    let { delegate, delegateMsp } = req.body;
+
+    if (!id || !scope || !expiresAt || (!delegate && !serviceAccount)) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details:
+          "Required fields: id, scope, expiresAt and delegate (with delegateMsp) or serviceAccount",
+      });
+    }
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    if (serviceAccount) {
+      delegate = `${SERVICE_ACCOUNT_PREFIX}${serviceAccount}`;
+      delegateMsp = ORG_MSPS[org];
+    }
+
+    console.log(`🔑 Delegating ${scope} to ${delegate} (${delegateMsp})`);
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CreateDelegation",
+      id,
+      delegate,
+      delegateMsp || "",
+      Array.isArray(scope) ? scope.join(",") : scope,
+      expiresAt
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Delegation recorded on blockchain",
+      delegationId: id,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in createDelegation:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Revoke a delegation; tokens issued for it stop working at once
+exports.revokeDelegation = async (req, res) => {
+  try {
+    const { delegationId } = req.params;
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RevokeDelegation",
+      delegationId
+    );
+    checkedDelegations.delete(delegationId);
+
+    res.status(200).json({
+      success: true,
+      message: "Delegation revoked on blockchain",
+      delegationId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in revokeDelegation:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List the delegations granted or received by the organization's identity
+exports.listDelegations = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const delegations =
+      (await blockchainClient.query(org, "GetMyDelegations")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: delegations,
+      count: delegations.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listDelegations:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Issue a bearer token for the service account of a delegation
+exports.issueToken = async (req, res) => {
+  try {
+    const { delegationId } = req.params;
+    const ttlSeconds = Math.min(
+      Number(req.body?.ttlSeconds) || DEFAULT_TOKEN_TTL_SECONDS,
+      MAX_TOKEN_TTL_SECONDS
+    );
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const delegation = await blockchainClient.query(
+      org,
+      "ReadDelegation",
+      delegationId
+    );
+    if (!delegation?.delegate?.startsWith(SERVICE_ACCOUNT_PREFIX)) {
+      return res.status(400).json({
+        error: "Not a service-account delegation",
+        details: `Delegation ${delegationId} does not name a service account`,
+      });
+    }
+    if (delegation.delegatorMsp !== ORG_MSPS[org]) {
+      return res.status(403).json({
+        error: "Forbidden",
+        details: `Delegation ${delegationId} was not granted by ${org}`,
+      });
+    }
+    if (!isUsable(delegation)) {
+      return res.status(409).json({
+        error: "Delegation not active",
+        details: `Delegation ${delegationId} is revoked or expired`,
+      });
+    }
+
+    const exp = Math.floor(
+      Math.min(
+        Date.now() + ttlSeconds * 1000,
+        new Date(delegation.expiresAt).getTime()
+      ) / 1000
+    );
+    const token = issueServiceToken({
+      sub: delegation.delegate.slice(SERVICE_ACCOUNT_PREFIX.length),
+      delegationId,
+      org,
+      scopes: delegation.scope,
+      exp,
+    });
+
+    console.log(`🎫 Issued service token for ${delegation.delegate}`);
+
+    res.status(201).json({
+      success: true,
+      message: "Service-account token issued",
+      data: {
+        token,
+        tokenType: "Bearer",
+        serviceAccount: delegation.delegate,
+        scopes: delegation.scope,
+        expiresAt: new Date(exp * 1000).toISOString(),
+      },
+    });
+  } catch (error) {
+    console.error("❌ Error in issueToken:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Re-read a token's delegation from the ledger unless checked recently
+const loadDelegation = async (claims) => {
+  const cached = checkedDelegations.get(claims.delegationId);
+  if (cached && Date.now() - cached.checkedAt < DELEGATION_CHECK_TTL_MS) {
+    return cached.delegation;
+  }
+
+  const delegation = await blockchainClient.query(
+    claims.org,
+    "ReadDelegation",
+    claims.delegationId
+  );
+  checkedDelegations.set(claims.delegationId, {
+    delegation,
+    checkedAt: Date.now(),
+  });
+  return delegation;
+};
+
+// Express middleware: requests carrying a service token may only read the
+// API areas its delegation still covers. Requests without one pass through.
+exports.authenticateServiceAccount = async (req, res, next) => {
+  const header = req.get("Authorization") || "";
+  if (!header.startsWith("Bearer ")) {
+    return next();
+  }
+
+  let claims;
+  try {
+    claims = verifyServiceToken(header.slice("Bearer ".length).trim());
+  } catch (error) {
+    return res.status(401).json({
+      error: "Unauthorized",
+      details: error.message,
+    });
+  }
+
+  const scope = scopeForPath(req.path);
+  if (req.method !== "GET" || !scope || !claims.scopes?.includes(scope)) {
+    return res.status(403).json({
+      error: "Forbidden",
+      details: `Service account ${claims.sub} may not ${req.method} ${req.path}`,
+    });
+  }
+
+  try {
+    const delegation = await loadDelegation(claims);
+    if (!isUsable(delegation) || !delegation.scope?.includes(scope)) {
+      return res.status(401).json({
+        error: "Unauthorized",
+        details: `Delegation ${claims.delegationId} no longer grants ${scope}`,
+      });
+    }
+  } catch (error) {
+    console.error("❌ Error checking delegation:", error);
+    return res.status(503).json({
+      error: "Blockchain unavailable",
+      details: error.message,
+    });
+  }
+
+  req.serviceAccount = {
+    id: claims.sub,
+    delegationId: claims.delegationId,
+    org: claims.org,
+    scopes: claims.scopes,
+  };
+  next();
+};
@@ -0,0 +1,221 @@
+// Document Controller - files of lots (certificates, invoices, lab reports)
+// uploaded to the organization's object store and anchored by their hash
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  requestUpload,
+  completeUpload,
+  getUpload,
+  summarize,
+  downloadUrl,
+} = require("../documents");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for documents"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Get a pre-signed URL to upload a document of a lot:
+// { org, wasteId, docType, hash, mimeType, size, actor, expectedVersion }.
+// The client sends the file to the URL with the returned method and
+// headers; its hash is then anchored on the lot automatically.
+exports.requestUpload = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    let upload;
+    try {
+      upload = requestUpload(blockchainClient, org, req.body);
+    } catch (error) {
+      return res.status(400).json({
+        error: "Invalid upload",
+        details: error.message,
+      });
+    }
+
+    res.status(201).json({
+      success: true,
+      message: "Upload the file to the URL before it expires",
+      data: {
+        uploadId: upload.id,
+        url: upload.url,
+        method: upload.method,
+        headers: upload.headers,
+        provider: upload.provider,
+        expiresAt: upload.expiresAt,
+      },
+    });
+  } catch (error) {
+    sendError(res, "requestUpload", error);
+  }
+};
+
+// Status of an upload: PENDING, ANCHORED, EXPIRED or FAILED
+exports.getUpload = async (req, res) => {
+  try {
+    const upload = getUpload(req.params.uploadId);
+    if (!upload) {
+      return res.status(404).json({
+        error: "Upload not found",
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: summarize(upload),
+    });
+  } catch (error) {
+    sendError(res, "getUpload", error);
+  }
+};
+
+// Tell the backend the file was uploaded so it is anchored right away
+exports.completeUpload = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const upload = await completeUpload(blockchainClient, req.params.uploadId);
+    if (!upload) {
+      return res.status(404).json({
+        error: "Upload not found",
+      });
+    }
+
+    res.status(upload.status === "FAILED" ? 409 : 200).json({
+      success: upload.status !== "FAILED",
+      data: summarize(upload),
+    });
+  } catch (error) {
+    sendError(res, "completeUpload", error);
+  }
+};
+
+// Documents anchored on a lot
+exports.listDocuments = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const documents =
+      (await blockchainClient.query(
+        org,
+        "GetWasteDocuments",
+        req.params.wasteId
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: documents,
+      count: documents.length,
+    });
+  } catch (error) {
+    sendError(res, "listDocuments", error);
+  }
+};
+
+// Short-lived URL to read an anchored document from the store holding it;
+// ?redirect=true answers with a redirect to it instead
+exports.downloadDocument = async (req, res) => {
+  try {
+    const { wasteId, hash } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const documents =
+      (await blockchainClient.query(org, "GetWasteDocuments", wasteId)) || [];
+    const document = documents.find(
+      (candidate) => candidate.hash === String(hash).toLowerCase()
+    );
+    if (!document) {
+      return res.status(404).json({
+        error: "Document not found",
+        details: `Waste ${wasteId} has no document ${hash}`,
+      });
+    }
+
+    const download = downloadUrl(document, wasteId, ORGANIZATIONS);
+    if (!download) {
+      return res.status(404).json({
+        error: "Document file not found",
+        details: `No configured store holds ${document.uri || hash}`,
+      });
+    }
+    if (req.query.redirect === "true") {
+      return res.redirect(302, download.url);
+    }
+
+    res.status(200).json({
+      success: true,
+      data: { ...document, ...download },
+    });
+  } catch (error) {
+    sendError(res, "downloadDocument", error);
+  }
+};
@@ -0,0 +1,161 @@
+// ERP Controller - connectors posting deliveries and invoices to the
+// cooperatives' ERPs, their postings and reconciliation logs
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const erp = require("../erp");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    await erp.startConnectors(blockchainClient);
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for ERP"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const POSTING_STATUSES = ["PENDING", "POSTED", "FAILED"];
+const DOCUMENT_TYPES = ["delivery", "invoice"];
+
+// List ERP providers and whether they are configured
+exports.listConnectors = async (req, res) => {
+  const connectors = erp.listConnectors();
+  res.status(200).json({
+    success: true,
+    data: connectors,
+    count: connectors.length,
+  });
+};
+
+// List postings, optionally by ?status=, ?provider= and ?documentType=
+exports.listPostings = async (req, res) => {
+  const status = req.query.status
+    ? String(req.query.status).toUpperCase()
+    : "";
+  if (status && !POSTING_STATUSES.includes(status)) {
+    return res.status(400).json({
+      error: "Invalid status",
+      details: `status must be one of: ${POSTING_STATUSES.join(", ")}`,
+    });
+  }
+  if (
+    req.query.documentType &&
+    !DOCUMENT_TYPES.includes(req.query.documentType)
+  ) {
+    return res.status(400).json({
+      error: "Invalid document type",
+      details: `documentType must be one of: ${DOCUMENT_TYPES.join(", ")}`,
+    });
+  }
+  const postings = erp.listPostings({
+    status,
+    provider: req.query.provider,
+    documentType: req.query.documentType,
+  });
+  res.status(200).json({
+    success: true,
+    data: postings,
+    count: postings.length,
+  });
+};
+
+// Get one posting with the document it carries
+exports.getPosting = async (req, res) => {
+  const posting = erp.getPosting(req.params.postingId);
+  if (!posting) {
+    return res.status(404).json({
+      error: "Posting not found",
+      postingId: req.params.postingId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    data: posting,
+  });
+};
+
+// Post a failed posting again
+exports.retryPosting = async (req, res) => {
+  const posting = erp.getPosting(req.params.postingId);
+  if (!posting) {
+    return res.status(404).json({
+      error: "Posting not found",
+      postingId: req.params.postingId,
+    });
+  }
+  if (posting.status !== "FAILED") {
+    return res.status(409).json({
+      error: "Posting not failed",
+      details: `Only FAILED postings can be retried; this one is ${posting.status}`,
+    });
+  }
+  res.status(202).json({
+    success: true,
+    message: "Posting queued",
+    data: erp.retryPosting(posting.id),
+  });
+};
+
+// Reconcile postings against the ERPs, of one { provider } or of all
+exports.runReconciliation = async (req, res) => {
+  try {
+    const { provider } = req.body || {};
+    if (provider && !erp.hasProvider(provider)) {
+      return res.status(400).json({
+        error: "Unknown provider",
+        provider,
+      });
+    }
+    const run = await erp.reconcile(provider);
+    res.status(200).json({
+      success: true,
+      message: "Reconciliation completed",
+      data: run,
+    });
+  } catch (error) {
+    console.error("❌ Error in runReconciliation:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List reconciliation runs, newest first
+exports.listReconciliations = async (req, res) => {
+  const runs = erp.listReconciliations();
+  res.status(200).json({
+    success: true,
+    data: runs,
+    count: runs.length,
+  });
+};
+
+// Get the log of one reconciliation run
+exports.getReconciliation = async (req, res) => {
+  const run = erp.getReconciliation(req.params.reconciliationId);
+  if (!run) {
+    return res.status(404).json({
+      error: "Reconciliation not found",
+      reconciliationId: req.params.reconciliationId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    data: run,
+  });
+};
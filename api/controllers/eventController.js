@@ -0,0 +1,177 @@
+// Event Controller - chaincode event stream with tag filters and saved filters
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+// Open Server-Sent Events connections
+const subscribers = new Set();
+
+// Saved filters by name (in memory, like the other temporary stores)
+const savedFilters = new Map();
+
+const splitList = (value) =>
+  (Array.isArray(value) ? value : String(value || "").split(","))
+    .map((item) => String(item).trim())
+    .filter(Boolean);
+
+// Events with a tag list match when they carry one of the filter's tags
+const matchesFilter = (event, filter) => {
+  if (filter.events.length > 0 && !filter.events.includes(event.eventName)) {
+    return false;
+  }
+  if (filter.tags.length === 0) {
+    return true;
+  }
+  const tags = event.data?.tags || [];
+  return filter.tags.some((tag) => tags.includes(tag));
+};
+
+const broadcast = (event) => {
+  let data = event.payload;
+  try {
+    data = JSON.parse(event.payload);
+  } catch {
+    // Non-JSON payloads are forwarded as text
+  }
+  const message = {
+    eventName: event.eventName,
+    transactionId: event.transactionId,
+    data,
+  };
+
+  for (const subscriber of subscribers) {
+    if (matchesFilter(message, subscriber.filter)) {
+      subscriber.res.write(`event: ${message.eventName}\n`);
+      subscriber.res.write(`data: ${JSON.stringify(message)}\n\n`);
+    }
+  }
+};
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    await blockchainClient.addContractListener("farmer", broadcast);
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for events"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Stream chaincode events, optionally filtered by ?tags=, ?events= or ?filter=
+exports.streamEvents = async (req, res) => {
+  try {
+    let filter = {
+      tags: splitList(req.query.tags),
+      events: splitList(req.query.events),
+    };
+    if (req.query.filter) {
+      filter = savedFilters.get(req.query.filter);
+      if (!filter) {
+        return res.status(404).json({
+          error: "Filter not found",
+          filter: req.query.filter,
+        });
+      }
+    }
+
+    res.setHeader("Content-Type", "text/event-stream");
+    res.setHeader("Cache-Control", "no-cache");
+    res.setHeader("Connection", "keep-alive");
+    res.flushHeaders();
+    res.write(
+      `: connected (blockchain ${blockchainInitialized ? "on" : "off"})\n\n`
+    );
+
+    const subscriber = { res, filter };
+    subscribers.add(subscriber);
+    req.on("close", () => {
+      subscribers.delete(subscriber);
+    });
+  } catch (error) {
+    console.error("❌ Error in streamEvents:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Save a named filter of tags and event names
+exports.saveFilter = async (req, res) => {
+  try {
+    const { name } = req.body;
+    const filter = {
+      name,
+      tags: splitList(req.body.tags).map((tag) => tag.toLowerCase()),
+      events: splitList(req.body.events),
+    };
+
+    if (!name) {
+      return res.status(400).json({
+        error: "Missing required fields",
+        details: "name is required",
+      });
+    }
+    if (filter.tags.length === 0 && filter.events.length === 0) {
+      return res.status(400).json({
+        error: "Empty filter",
+        details: "Provide at least one tag or event name",
+      });
+    }
+
+    savedFilters.set(name, filter);
+
+    res.status(201).json({
+      success: true,
+      message: "Filter saved",
+      data: filter,
+    });
+  } catch (error) {
+    console.error("❌ Error in saveFilter:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List saved filters
+exports.listFilters = async (req, res) => {
+  const filters = [...savedFilters.values()];
+  res.status(200).json({
+    success: true,
+    data: filters,
+    count: filters.length,
+  });
+};
+
+// Delete a saved filter
+exports.deleteFilter = async (req, res) => {
+  const { name } = req.params;
+  if (!savedFilters.delete(name)) {
+    return res.status(404).json({
+      error: "Filter not found",
+      filter: name,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    message: "Filter deleted",
+  });
+};
@@ -0,0 +1,217 @@
+// Export Controller - long-running exports of the ledger's lots,
+// extractions and recyclings as downloadable files
+const fs = require("fs");
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  DATASETS,
+  FORMATS,
+  createExport,
+  cancelExport,
+  getExport,
+  listExports,
+  summarize,
+} = require("../exports");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for exports"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Organization reading each dataset unless "org" says otherwise
+const DEFAULT_ORGS = {
+  wastes: "farmer",
+  extractions: "processor",
+  recyclings: "recycler",
+};
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const JOB_STATUSES = [
+  "QUEUED",
+  "RUNNING",
+  "COMPLETED",
+  "FAILED",
+  "CANCELED",
+  "EXPIRED",
+];
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+// Queue an export: { dataset, format, from, to, status, org }. Poll the
+// job, then download the file once it is COMPLETED.
+exports.createExport = async (req, res) => {
+  try {
+    const { dataset, format = "csv", from, to, status, org } = req.body;
+
+    if (!DATASETS[dataset]) {
+      return res.status(400).json({
+        error: "Invalid dataset",
+        details: `'dataset' must be one of: ${Object.keys(DATASETS).join(", ")}`,
+      });
+    }
+    if (!FORMATS[format]) {
+      return res.status(400).json({
+        error: "Invalid format",
+        details: `'format' must be one of: ${Object.keys(FORMATS).join(", ")}`,
+      });
+    }
+    if ((from && !DATE_PATTERN.test(from)) || (to && !DATE_PATTERN.test(to))) {
+      return res.status(400).json({
+        error: "Invalid date range",
+        details: "'from' and 'to' must be YYYY-MM-DD dates",
+      });
+    }
+    if (org && !ORGANIZATIONS.includes(org)) {
+      return res.status(400).json({
+        error: "Invalid organization",
+        details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const job = createExport(blockchainClient, org || DEFAULT_ORGS[dataset], {
+      dataset,
+      format,
+      from,
+      to,
+      status,
+    });
+
+    console.log(`📦 Export ${job.id} of ${dataset} queued`);
+
+    res.status(202).json({
+      success: true,
+      message: "Export queued; poll the job for its progress",
+      jobId: job.id,
+      data: summarize(job),
+    });
+  } catch (error) {
+    console.error("❌ Error in createExport:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Export jobs held by this server, newest first (?status=RUNNING)
+exports.listExports = async (req, res) => {
+  const { status } = req.query;
+  if (status && !JOB_STATUSES.includes(status)) {
+    return res.status(400).json({
+      error: "Invalid status",
+      details: `'status' must be one of: ${JOB_STATUSES.join(", ")}`,
+    });
+  }
+  const jobs = listExports().filter((job) => !status || job.status === status);
+  res.status(200).json({
+    success: true,
+    data: jobs,
+    count: jobs.length,
+  });
+};
+
+// Status and progress of an export job
+exports.getExport = async (req, res) => {
+  const job = getExport(req.params.jobId);
+  if (!job) {
+    return res.status(404).json({
+      error: "Export job not found",
+      jobId: req.params.jobId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    data: summarize(job),
+  });
+};
+
+// Cancel a queued or running export
+exports.cancelExport = async (req, res) => {
+  const job = getExport(req.params.jobId);
+  if (!job) {
+    return res.status(404).json({
+      error: "Export job not found",
+      jobId: req.params.jobId,
+    });
+  }
+  if (!cancelExport(job.id)) {
+    return res.status(409).json({
+      error: "Export job finished",
+      details: `Only QUEUED or RUNNING exports can be canceled; this one is ${job.status}`,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    message:
+      job.status === "CANCELED"
+        ? "Export canceled"
+        : "Export canceling; it stops after its current page",
+    data: summarize(job),
+  });
+};
+
+// Download the file of a completed export; X-Content-SHA256 lets the
+// recipient check it
+exports.downloadExport = async (req, res) => {
+  const job = getExport(req.params.jobId);
+  if (!job) {
+    return res.status(404).json({
+      error: "Export job not found",
+      jobId: req.params.jobId,
+    });
+  }
+  if (job.status === "EXPIRED") {
+    return res.status(410).json({
+      error: "Export expired",
+      details: `The file was deleted after ${job.expiresAt}; run the export again`,
+    });
+  }
+  if (job.status !== "COMPLETED") {
+    return res.status(409).json({
+      error: "Export not ready",
+      details: `Export job is ${job.status}`,
+      progress: job.progress,
+    });
+  }
+
+  res.setHeader("Content-Type", FORMATS[job.format].contentType);
+  res.setHeader(
+    "Content-Disposition",
+    `attachment; filename="${path.basename(job.filePath)}"`
+  );
+  res.setHeader("Content-Length", job.size);
+  res.setHeader("X-Content-SHA256", job.contentHash);
+  fs.createReadStream(job.filePath)
+    .on("error", (error) => {
+      console.error("❌ Error in downloadExport:", error);
+      res.destroy(error);
+    })
+    .pipe(res);
+};
@@ -7,6 +7,11 @@ const BlockchainClient = require(path.join(
   "blockchain",
   "enhancedClient"
 ));
+const {
+  parseListQuery,
+  paginate,
+  sendListQueryError,
+} = require("../listQuery");
 
 // Initialize enhanced blockchain client
 const blockchainClient = new BlockchainClient();
@@ -85,6 +90,7 @@ exports.addExtraction = async (req, res) => {
       productType,
       quantity: parseFloat(quantity),
       quality,
+      facilityId: extractionData.facilityId || "",
       extractionMethod: extractionData.extractionMethod || "Cold Press",
       temperature: extractionData.temperature || "27°C",
       pressure: extractionData.pressure || "3 bars",
@@ -186,6 +192,8 @@ exports.addExtraction = async (req, res) => {
 // Get list of extractions with blockchain integration
 exports.listExtractions = async (req, res) => {
   try {
+    const query = parseListQuery(req, "EXTRACTION");
+
     console.log("📋 Fetching extractions list...");
 
     let extractionList = [];
@@ -228,16 +236,22 @@ exports.listExtractions = async (req, res) => {
       extractionList = extractions;
     }
 
+    const { data, page } = paginate(extractionList, query);
+
     res.status(200).json({
       success: true,
-      data: extractionList,
-      count: extractionList.length,
+      data: data,
+      count: data.length,
+      page: page,
       source: source,
       timestamp: new Date().toISOString(),
     });
 
-    console.log(`✅ Sent list of ${extractionList.length} extractions`);
+    console.log(`✅ Sent list of ${data.length} extractions`);
   } catch (error) {
+    if (sendListQueryError(res, error)) {
+      return;
+    }
     console.error("❌ Error in listExtractions:", error);
     res.status(500).json({
       error: "Internal server error",
@@ -373,6 +387,55 @@ exports.getExtractionsByWasteId = async (req, res) => {
   }
 };
 
+// Dry-run extraction: validate on the ledger without committing
+exports.simulateAddExtraction = async (req, res) => {
+  try {
+    const { extractionData } = req.body;
+
+    if (!extractionData) {
+      return res.status(400).json({
+        error: "Missing extraction data",
+        details: "The 'extractionData' field is required",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+        details: "Simulation requires a blockchain connection",
+      });
+    }
+
+    // An empty id lets the chaincode generate one
+    const extractionId = extractionData.id || "";
+
+    const simulation = await blockchainClient.query(
+      "processor",
+      "SimulateCreateExtraction",
+      extractionId,
+      extractionData.wasteId || "",
+      extractionData.productType || "",
+      String(parseFloat(extractionData.quantity) || 0),
+      extractionData.quality || "",
+      req.body.processorId || extractionData.processorId || "processor_001",
+      extractionData.facilityId || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      committed: false,
+      data: simulation,
+      source: "blockchain",
+    });
+  } catch (error) {
+    console.error("❌ Error in simulateAddExtraction:", error);
+    res.status(422).json({
+      error: "Validation failed",
+      details: error.message,
+    });
+  }
+};
+
 // Update extraction status with blockchain integration
 exports.updateExtractionStatus = async (req, res) => {
   try {
@@ -467,3 +530,331 @@ exports.updateExtractionStatus = async (req, res) => {
     });
   }
 };
+
+// Record an extraction run with several output lines (oil, pomace, wastewater)
+exports.addExtractionWithOutputs = async (req, res) => {
+  try {
+    const { wasteId, outputs, facilityId } = req.body;
+
+    if (!wasteId || !Array.isArray(outputs) || outputs.length === 0) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "wasteId and a non-empty outputs array are required",
+      });
+    }
+    const invalidLine = outputs.findIndex(
+      (output) => !output.productType || !(parseFloat(output.quantity) > 0)
+    );
+    if (invalidLine !== -1) {
+      return res.status(400).json({
+        error: "Invalid output line",
+        details: `Line ${
+          invalidLine + 1
+        } needs a productType and a positive quantity`,
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "processor",
+      "CreateExtractionWithOutputs",
+      req.body.id || "",
+      wasteId,
+      JSON.stringify(
+        outputs.map((output) => ({
+          productType: output.productType,
+          quantity: parseFloat(output.quantity),
+          quality: output.quality || "",
+        }))
+      ),
+      req.body.processorId || "processor_001",
+      facilityId || ""
+    );
+
+    res.status(201).json({
+      success: true,
+      message: `Extraction with ${outputs.length} output line(s) recorded on blockchain`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in addExtractionWithOutputs:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Recycle part of an output line into a downstream product
+exports.recycleOutput = async (req, res) => {
+  try {
+    const { extractionId, line } = req.params;
+    const { recycledProduct, quantity, method, facilityId } = req.body;
+
+    if (!recycledProduct || !quantity || !method) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: recycledProduct, quantity, method",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "recycler",
+      "CreateRecyclingFromOutput",
+      req.body.id || "",
+      extractionId,
+      String(parseInt(line, 10)),
+      recycledProduct,
+      String(parseFloat(quantity)),
+      method,
+      req.body.recyclerId || "recycler_001",
+      facilityId || ""
+    );
+
+    res.status(201).json({
+      success: true,
+      message: `Output line ${line} of extraction ${extractionId} recycled on blockchain`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in recycleOutput:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Follow one output line into the products made from it
+exports.getOutputTrace = async (req, res) => {
+  try {
+    const { extractionId, line } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const trace = await blockchainClient.query(
+      "processor",
+      "GetExtractionOutputTrace",
+      extractionId,
+      String(parseInt(line, 10))
+    );
+
+    if (!trace) {
+      return res.status(404).json({
+        error: "Output line not found",
+        extractionId,
+        line,
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: trace,
+    });
+  } catch (error) {
+    console.error("❌ Error in getOutputTrace:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Organization whose gateway identity holds the independent grader role
+const GRADER_ORG = process.env.GRADER_ORG || process.env.ADMIN_ORG || "farmer";
+
+const QUALITY_GRADES = ["A", "B", "C", "D"];
+
+const invalidGrade = (res, field, grade) => {
+  if (QUALITY_GRADES.includes(grade)) {
+    return false;
+  }
+  res.status(400).json({
+    error: "Invalid grade",
+    details: `'${field}' must be one of: ${QUALITY_GRADES.join(", ")}`,
+  });
+  return true;
+};
+
+// Grade an extraction on the quality scale (grader)
+exports.gradeExtraction = async (req, res) => {
+  try {
+    const { extractionId } = req.params;
+    const { grade, evidence } = req.body;
+
+    if (invalidGrade(res, "grade", grade)) {
+      return;
+    }
+    if (!evidence) {
+      return res.status(400).json({
+        error: "Missing evidence",
+        details: "'evidence' must reference what the grade is based on",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      GRADER_ORG,
+      "GradeExtraction",
+      extractionId,
+      grade,
+      evidence
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Extraction ${extractionId} graded ${grade} on blockchain`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in gradeExtraction:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Grading decisions of an extraction, separate from its status history
+exports.getGradingHistory = async (req, res) => {
+  try {
+    const { extractionId } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const grading =
+      (await blockchainClient.query(
+        "processor",
+        "GetGradingHistory",
+        extractionId
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: grading,
+      count: grading.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in getGradingHistory:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Appeal the grade of an extraction
+exports.requestRegrade = async (req, res) => {
+  try {
+    const { extractionId } = req.params;
+    const { proposedGrade, reason, evidence } = req.body;
+    const org = req.body.org || "processor";
+
+    if (invalidGrade(res, "proposedGrade", proposedGrade)) {
+      return;
+    }
+    if (!reason || !evidence) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: proposedGrade, reason, evidence",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RequestRegrade",
+      extractionId,
+      proposedGrade,
+      reason,
+      evidence
+    );
+
+    res.status(201).json({
+      success: true,
+      message: `Regrade of extraction ${extractionId} requested on blockchain`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in requestRegrade:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Decide an appeal; keeping the current grade dismisses it (grader)
+exports.confirmRegrade = async (req, res) => {
+  try {
+    const { requestId } = req.params;
+    const { grade, evidence } = req.body;
+
+    if (invalidGrade(res, "grade", grade)) {
+      return;
+    }
+    if (!evidence) {
+      return res.status(400).json({
+        error: "Missing evidence",
+        details: "'evidence' must reference what the grade is based on",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      GRADER_ORG,
+      "ConfirmRegrade",
+      requestId,
+      grade,
+      evidence
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Regrade request ${requestId} decided on blockchain`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in confirmRegrade:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,394 @@
+// Facility Controller - processing facilities, equipment and capacity
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for facilities"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const OPERATORS = ["processor", "recycler"];
+
+// Resolve which operating organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!OPERATORS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${OPERATORS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+// Register a facility operated by the requesting organization
+exports.registerFacility = async (req, res) => {
+  try {
+    const { id, name, type, location, dailyCapacity, certifications } =
+      req.body;
+
+    if (!id || !name || !type || !dailyCapacity) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: id, name, type, dailyCapacity",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RegisterFacility",
+      id,
+      name,
+      type,
+      location || "",
+      String(parseFloat(dailyCapacity)),
+      Array.isArray(certifications)
+        ? certifications.join(",")
+        : certifications || ""
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Facility registered on blockchain",
+      facilityId: id,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in registerFacility:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Activate or deactivate a facility
+exports.updateFacilityStatus = async (req, res) => {
+  try {
+    const { facilityId } = req.params;
+    const { status, details } = req.body;
+
+    if (!status) {
+      return res.status(400).json({
+        error: "Missing required fields",
+        details: "status is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "UpdateFacilityStatus",
+      facilityId,
+      status,
+      details || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Facility status updated to ${status}`,
+      facilityId: facilityId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in updateFacilityStatus:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Set the geofence deliveries to the facility are confirmed in
+// ({ latitude, longitude, radiusMeters }); a zero radius removes it
+exports.setFacilityGeofence = async (req, res) => {
+  try {
+    const { facilityId } = req.params;
+    const latitude = parseFloat(req.body.latitude) || 0;
+    const longitude = parseFloat(req.body.longitude) || 0;
+    const radiusMeters = parseFloat(req.body.radiusMeters);
+
+    if (
+      !(radiusMeters >= 0) ||
+      (radiusMeters > 0 &&
+        (Math.abs(latitude) > 90 || Math.abs(longitude) > 180))
+    ) {
+      return res.status(400).json({
+        error: "Invalid geofence",
+        details: "Required: radiusMeters (0 removes it), latitude, longitude",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "SetFacilityGeofence",
+      facilityId,
+      String(latitude),
+      String(longitude),
+      String(radiusMeters)
+    );
+
+    res.status(200).json({
+      success: true,
+      message:
+        radiusMeters > 0
+          ? `Geofence of facility ${facilityId} set to ${radiusMeters} m`
+          : `Geofence of facility ${facilityId} removed`,
+      facilityId: facilityId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in setFacilityGeofence:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Install equipment in a facility
+exports.registerEquipment = async (req, res) => {
+  try {
+    const { facilityId } = req.params;
+    const { id, name, type, dailyCapacity } = req.body;
+
+    if (!id || !name || !dailyCapacity) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: id, name, dailyCapacity",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RegisterEquipment",
+      id,
+      facilityId,
+      name,
+      type || "",
+      String(parseFloat(dailyCapacity))
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Equipment registered on blockchain",
+      equipmentId: id,
+      facilityId: facilityId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in registerEquipment:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Record a maintenance status change for equipment
+exports.updateEquipmentMaintenance = async (req, res) => {
+  try {
+    const { equipmentId } = req.params;
+    const { status, details } = req.body;
+
+    if (!status) {
+      return res.status(400).json({
+        error: "Missing required fields",
+        details: "status is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "UpdateEquipmentMaintenance",
+      equipmentId,
+      status,
+      details || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Equipment maintenance status updated to ${status}`,
+      equipmentId: equipmentId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in updateEquipmentMaintenance:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List all registered facilities
+exports.listFacilities = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const facilities =
+      (await blockchainClient.query("processor", "GetAllFacilities")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: facilities,
+      count: facilities.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listFacilities:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Facility details with its equipment and today's utilization
+exports.getFacility = async (req, res) => {
+  try {
+    const { facilityId } = req.params;
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const facility = await blockchainClient.query(
+      "processor",
+      "ReadFacility",
+      facilityId
+    );
+    if (!facility) {
+      return res.status(404).json({
+        error: "Facility not found",
+        facilityId: facilityId,
+      });
+    }
+
+    const date = req.query.date || new Date().toISOString().slice(0, 10);
+    const equipment =
+      (await blockchainClient.query(
+        "processor",
+        "GetFacilityEquipment",
+        facilityId
+      )) || [];
+    const utilization = await blockchainClient.query(
+      "processor",
+      "GetFacilityUtilization",
+      facilityId,
+      date
+    );
+
+    res.status(200).json({
+      success: true,
+      data: {
+        ...facility,
+        equipment,
+        utilization,
+      },
+    });
+  } catch (error) {
+    console.error("❌ Error in getFacility:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Compliance picture of a facility for its operator: status, certifications,
+// facility SLA for lots received between from and to (YYYY-MM-DD) and open
+// incidents
+exports.getFacilityCompliance = async (req, res) => {
+  try {
+    const { facilityId } = req.params;
+    const { from, to } = req.query;
+
+    const datePattern = /^\d{4}-\d{2}-\d{2}$/;
+    if ((from && !datePattern.test(from)) || (to && !datePattern.test(to))) {
+      return res.status(400).json({
+        error: "Invalid period",
+        details: "'from' and 'to' must be YYYY-MM-DD dates",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const compliance = await blockchainClient.query(
+      org,
+      "GetFacilityCompliance",
+      facilityId,
+      from || "",
+      to || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      data: compliance,
+    });
+  } catch (error) {
+    console.error("❌ Error in getFacilityCompliance:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
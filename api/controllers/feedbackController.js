@@ -0,0 +1,326 @@
+// Feedback Controller - consumer ratings submitted through public trace tokens
+const crypto = require("crypto");
+const path = require("path");
+const { tenantForMsp, publicTheme } = require("../tenants");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for feedback"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Organization whose gateway identity relays anonymous consumer feedback
+const FEEDBACK_ORG =
+  process.env.FEEDBACK_ORG || process.env.ADMIN_ORG || "farmer";
+
+const ASSET_TYPES = ["WASTE", "EXTRACTION", "RECYCLING"];
+
+// Rating summary scopes by URL segment
+const RATING_SCOPES = {
+  products: "PRODUCT",
+  farms: "FARM",
+};
+
+const MAX_COMMENT_LENGTH = 2000;
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const resolveScope = (req, res) => {
+  const scope = RATING_SCOPES[req.params.scope];
+  if (!scope) {
+    res.status(400).json({
+      error: "Invalid scope",
+      details: `'scope' must be one of: ${Object.keys(RATING_SCOPES).join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return scope;
+};
+
+// Issue the public trace token of a waste lot or product
+exports.issueToken = async (req, res) => {
+  try {
+    const { assetType, assetId } = req.body;
+
+    if (!assetType || !assetId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: assetType, assetId",
+      });
+    }
+    if (!ASSET_TYPES.includes(String(assetType).toUpperCase())) {
+      return res.status(400).json({
+        error: "Invalid asset type",
+        details: `'assetType' must be one of: ${ASSET_TYPES.join(", ")}`,
+      });
+    }
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    console.log(`🏷️ Issuing trace token for ${assetType} ${assetId}`);
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "IssueTraceToken",
+      String(assetType).toUpperCase(),
+      assetId
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Trace token recorded on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in issueToken:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Revoke a trace token; it stops accepting feedback
+exports.revokeToken = async (req, res) => {
+  try {
+    const { token } = req.params;
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RevokeTraceToken",
+      token
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Trace token revoked on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in revokeToken:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Public: the product behind a trace token, with the theme of the tenant
+// of the organization that issued it
+exports.getToken = async (req, res) => {
+  try {
+    const { token } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const traceToken = await blockchainClient.query(
+      FEEDBACK_ORG,
+      "ReadTraceToken",
+      token
+    );
+    if (!traceToken) {
+      return res.status(404).json({
+        error: "Trace token not found",
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: {
+        token: traceToken.token,
+        assetType: traceToken.assetType,
+        assetId: traceToken.assetId,
+        wasteId: traceToken.wasteId,
+        revoked: Boolean(traceToken.revoked),
+        feedbackCount: traceToken.feedbackCount,
+        theme: publicTheme(tenantForMsp(traceToken.issuedByMsp)),
+      },
+    });
+  } catch (error) {
+    console.error("❌ Error in getToken:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Public: rate the product behind a trace token. Only the sha256 of the
+// comment is recorded on the ledger.
+exports.submitFeedback = async (req, res) => {
+  try {
+    const { token } = req.params;
+    const rating = parseInt(req.body?.rating, 10);
+    const comment = String(req.body?.comment || "").trim();
+
+    if (!Number.isInteger(rating) || rating < 1 || rating > 5) {
+      return res.status(400).json({
+        error: "Invalid rating",
+        details: "'rating' must be an integer between 1 and 5",
+      });
+    }
+    if (comment.length > MAX_COMMENT_LENGTH) {
+      return res.status(400).json({
+        error: "Comment too long",
+        details: `'comment' must not exceed ${MAX_COMMENT_LENGTH} characters`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const commentHash = comment
+      ? crypto.createHash("sha256").update(comment, "utf8").digest("hex")
+      : "";
+
+    console.log(`⭐ Recording ${rating}-star feedback for token ${token}`);
+
+    const result = await blockchainClient.submitTransaction(
+      FEEDBACK_ORG,
+      "SubmitFeedback",
+      token,
+      String(rating),
+      commentHash
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Feedback recorded on blockchain",
+      commentHash: commentHash || undefined,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    if (/\bFEEDBACK_THROTTLED:/.test(error.message)) {
+      return res.status(429).json({
+        error: "Too many requests",
+        details: error.message,
+      });
+    }
+    console.error("❌ Error in submitFeedback:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Rating summaries of every product or farm, best rated first
+exports.listRatings = async (req, res) => {
+  try {
+    const scope = resolveScope(req, res);
+    if (!scope) {
+      return;
+    }
+
+    const summaries =
+      (await blockchainClient.query(
+        FEEDBACK_ORG,
+        "GetRatingSummaries",
+        scope
+      )) || [];
+    summaries.sort((a, b) => b.average - a.average || b.count - a.count);
+
+    res.status(200).json({
+      success: true,
+      data: summaries,
+      count: summaries.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listRatings:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Rating summary of one product (asset ID) or farm (participant ID)
+exports.getRating = async (req, res) => {
+  try {
+    const scope = resolveScope(req, res);
+    if (!scope) {
+      return;
+    }
+
+    const summary = await blockchainClient.query(
+      FEEDBACK_ORG,
+      "GetRatingSummary",
+      scope,
+      req.params.key
+    );
+
+    res.status(200).json({
+      success: true,
+      data: summary,
+    });
+  } catch (error) {
+    console.error("❌ Error in getRating:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
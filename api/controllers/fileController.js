@@ -0,0 +1,90 @@
+// File Controller - uploads to and downloads from local disk stores (see
+// api/objectStorage/local.js), authorized by the signature of their URL
+const fs = require("fs");
+const { localStoreById } = require("../objectStorage");
+
+// The store and object key of a signed request; sends the error response
+// and returns null when the URL is not valid
+const resolveObject = (req, res) => {
+  const store = localStoreById(String(req.query.store || ""));
+  if (!store) {
+    res.status(404).json({
+      error: "Not found",
+      details: "No local store signed this URL",
+    });
+    return null;
+  }
+  const key = [].concat(req.params.key).join("/");
+  try {
+    return { store, key, fields: store.verify(req.method, key, req.query) };
+  } catch (error) {
+    res.status(403).json({
+      error: "Forbidden",
+      details: error.message,
+    });
+    return null;
+  }
+};
+
+// Store the body of a signed upload
+exports.putFile = async (req, res) => {
+  try {
+    const object = resolveObject(req, res);
+    if (!object) {
+      return;
+    }
+
+    const { size } = await object.store.receive(object.key, req, object.fields);
+
+    res.status(201).json({
+      success: true,
+      data: { key: object.key, size, sha256: object.fields.sha256 },
+    });
+  } catch (error) {
+    if (/does not match|Invalid object key/.test(error.message)) {
+      return res.status(400).json({
+        error: "Invalid upload",
+        details: error.message,
+      });
+    }
+    console.error("❌ Error in putFile:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Send a stored file (GET) or its headers (HEAD)
+exports.getFile = async (req, res) => {
+  try {
+    const object = resolveObject(req, res);
+    if (!object) {
+      return;
+    }
+
+    const stored = await object.store.open(object.key);
+    if (!stored) {
+      return res.status(404).json({
+        error: "Not found",
+        details: `No file ${object.key}`,
+      });
+    }
+
+    res.set({
+      "Content-Type": stored.contentType || "application/octet-stream",
+      "Content-Length": String(stored.size),
+      ETag: `"${stored.sha256}"`,
+    });
+    if (req.method === "HEAD") {
+      return res.status(200).end();
+    }
+    fs.createReadStream(stored.path).pipe(res);
+  } catch (error) {
+    console.error("❌ Error in getFile:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,332 @@
+// Handoff Controller - lots leaving the network for another Fabric network
+// or an outside system, and lots arriving from one, with the hash of the
+// traceability bundle that travels with them
+const crypto = require("crypto");
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for handoffs"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const KINDS = ["EXPORT", "IMPORT"];
+
+const HASH_PATTERN = /^[0-9a-f]{64}$/;
+
+// sha256 of a bundle as received: text is hashed as is, anything else as
+// its JSON serialization
+const bundleHash = (bundle) =>
+  crypto
+    .createHash("sha256")
+    .update(typeof bundle === "string" ? bundle : JSON.stringify(bundle))
+    .digest("hex");
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org || "farmer";
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist|no (export|import) handoff/.test(error.message)) {
+    return res.status(404).json({
+      error: "Handoff not found",
+      details: error.message,
+    });
+  }
+  if (/already handed off/.test(error.message)) {
+    return res.status(409).json({
+      error: "Already handed off",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Handoffs of the organization, newest first (?kind=EXPORT&system=)
+exports.listHandoffs = async (req, res) => {
+  try {
+    const kind = String(req.query.kind || "").toUpperCase();
+    if (kind && !KINDS.includes(kind)) {
+      return res.status(400).json({
+        error: "Invalid kind",
+        details: `'kind' must be one of: ${KINDS.join(", ")}`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const handoffs =
+      (await blockchainClient.query(
+        org,
+        "GetHandoffs",
+        kind,
+        req.query.system || ""
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: handoffs,
+      count: handoffs.length,
+    });
+  } catch (error) {
+    sendError(res, "listHandoffs", error);
+  }
+};
+
+// The handoff of a system's external reference
+// (?kind=IMPORT&system=&reference=)
+exports.findHandoff = async (req, res) => {
+  try {
+    const kind = String(req.query.kind || "").toUpperCase();
+    const { system, reference } = req.query;
+    if (!KINDS.includes(kind) || !system || !reference) {
+      return res.status(400).json({
+        error: "Incomplete query",
+        details: `Required: kind (${KINDS.join(" or ")}), system, reference`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const handoff = await blockchainClient.query(
+      org,
+      "GetHandoffByReference",
+      kind,
+      system,
+      reference
+    );
+
+    res.status(200).json({
+      success: true,
+      data: handoff,
+    });
+  } catch (error) {
+    sendError(res, "findHandoff", error);
+  }
+};
+
+exports.getHandoff = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const handoff = await blockchainClient.query(
+      org,
+      "ReadHandoff",
+      req.params.handoffId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: handoff,
+    });
+  } catch (error) {
+    sendError(res, "getHandoff", error);
+  }
+};
+
+// Hand { wasteId } off to { system } as { externalReference }. The response
+// carries the traceability bundle exactly as hashed on the ledger: send it
+// to the destination unchanged, so its hash still matches.
+exports.exportHandoff = async (req, res) => {
+  try {
+    const { wasteId, system, externalReference, notes } = req.body;
+    if (!wasteId || !system || !externalReference) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: wasteId, system, externalReference",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "ExportHandoff",
+      wasteId,
+      system,
+      externalReference,
+      notes || "",
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+    const exported = result?.result;
+
+    res.status(201).json({
+      success: true,
+      message: `Waste ${wasteId} handed off to ${system} as ${externalReference}`,
+      data: exported?.handoff,
+      bundle: exported?.bundle,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "exportHandoff", error);
+  }
+};
+
+// Record a lot received from outside: { wasteData: { id, type, quantity,
+// harvestDate, owner, farm, location, plotId }, origin: { system,
+// externalReference, externalLotId, contentHash }, bundle }. With the
+// bundle, its hash is computed here and must match contentHash when both
+// are given. Personal data travels as transient data, as for new lots.
+exports.importHandoff = async (req, res) => {
+  try {
+    const { wasteData, bundle } = req.body;
+    const origin = req.body.origin || {};
+
+    if (!wasteData?.type || !wasteData.quantity || !wasteData.harvestDate) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required wasteData fields: type, quantity, harvestDate",
+      });
+    }
+    if (!origin.system || !origin.externalReference) {
+      return res.status(400).json({
+        error: "Incomplete origin",
+        details: "Required origin fields: system, externalReference",
+      });
+    }
+    const computed = bundle !== undefined ? bundleHash(bundle) : null;
+    const contentHash = String(origin.contentHash || computed || "")
+      .trim()
+      .toLowerCase();
+    if (!HASH_PATTERN.test(contentHash)) {
+      return res.status(400).json({
+        error: "Missing content hash",
+        details:
+          "Provide origin.contentHash (hex sha256) or the bundle to hash",
+      });
+    }
+    if (computed && computed !== contentHash) {
+      return res.status(400).json({
+        error: "Bundle hash mismatch",
+        details: `The bundle hashes to ${computed}, not ${contentHash}`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitPrivateTransaction(
+      org,
+      "ImportHandoff",
+      {
+        pii: {
+          owner: wasteData.owner || "",
+          farm: wasteData.farm || "",
+          location: wasteData.location || "",
+        },
+      },
+      wasteData.id || "",
+      wasteData.type,
+      String(parseFloat(wasteData.quantity)),
+      String(wasteData.harvestDate).slice(0, 10),
+      "",
+      "",
+      "",
+      wasteData.plotId || "",
+      JSON.stringify({
+        system: origin.system,
+        externalReference: origin.externalReference,
+        externalLotId: origin.externalLotId || "",
+        contentHash,
+      })
+    );
+    const waste = result?.result;
+
+    res.status(201).json({
+      success: true,
+      message: `Waste ${waste?.id} imported from ${origin.system}`,
+      data: waste,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "importHandoff", error);
+  }
+};
+
+// Check a bundle received or sent against a handoff: { bundle }
+exports.verifyBundle = async (req, res) => {
+  try {
+    const { bundle } = req.body;
+    if (bundle === undefined) {
+      return res.status(400).json({
+        error: "Missing bundle",
+        details: "The 'bundle' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const handoff = await blockchainClient.query(
+      org,
+      "ReadHandoff",
+      req.params.handoffId
+    );
+    const computed = bundleHash(bundle);
+
+    res.status(200).json({
+      success: true,
+      data: {
+        handoffId: handoff.id,
+        matches: computed === handoff.contentHash,
+        contentHash: handoff.contentHash,
+        bundleHash: computed,
+      },
+    });
+  } catch (error) {
+    sendError(res, "verifyBundle", error);
+  }
+};
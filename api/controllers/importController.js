@@ -0,0 +1,199 @@
+// Import Controller - bulk import of legacy ERP exports (CSV)
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  IMPORT_KINDS,
+  startImport,
+  getJob,
+  listJobs,
+  reportCsv,
+} = require("../import");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for imports"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Organization submitting each kind of import unless "org" says otherwise
+const DEFAULT_ORGS = {
+  WASTE: "farmer",
+  EXTRACTION: "processor",
+};
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Import kinds by URL segment
+const KINDS = {
+  wastes: "WASTE",
+  extractions: "EXTRACTION",
+};
+
+// Start an import. The CSV comes as a text/csv body (options in the query
+// string) or as the "csv" field of a JSON body next to the options:
+// org, dryRun, batchSize, delayMs, idPrefix and columns ({ field: column }).
+exports.startImport = async (req, res) => {
+  try {
+    const kind = KINDS[req.params.kind];
+    if (!kind || !IMPORT_KINDS[kind]) {
+      return res.status(400).json({
+        error: "Invalid import kind",
+        details: `Import kind must be one of: ${Object.keys(KINDS).join(", ")}`,
+      });
+    }
+
+    const isCsvBody = typeof req.body === "string";
+    const options = isCsvBody ? req.query : req.body || {};
+    const csvText = isCsvBody ? req.body : options.csv;
+    if (!csvText) {
+      return res.status(400).json({
+        error: "Missing CSV",
+        details: "Send the file as a text/csv body or in the 'csv' field",
+      });
+    }
+
+    const org = options.org || DEFAULT_ORGS[kind];
+    if (!ORGANIZATIONS.includes(org)) {
+      return res.status(400).json({
+        error: "Invalid organization",
+        details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+      });
+    }
+    let columns = options.columns || {};
+    if (typeof columns === "string") {
+      try {
+        columns = JSON.parse(columns);
+      } catch {
+        return res.status(400).json({
+          error: "Invalid columns",
+          details: "'columns' must map field names to legacy column names",
+        });
+      }
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    let job;
+    try {
+      job = startImport(blockchainClient, kind, csvText, {
+        org,
+        columns,
+        dryRun: options.dryRun === true || options.dryRun === "true",
+        batchSize: options.batchSize,
+        delayMs: options.delayMs,
+        idPrefix: options.idPrefix,
+      });
+    } catch (error) {
+      return res.status(400).json({
+        error: "Invalid CSV",
+        details: error.message,
+      });
+    }
+
+    console.log(
+      `📥 Import ${job.id} started: ${job.totals.rows} ${req.params.kind}`
+    );
+
+    res.status(202).json({
+      success: true,
+      message: job.dryRun
+        ? "Dry-run validation started"
+        : "Import started; poll the job for its reconciliation report",
+      jobId: job.id,
+      data: {
+        id: job.id,
+        kind: job.kind,
+        status: job.status,
+        dryRun: job.dryRun,
+        totals: job.totals,
+      },
+    });
+  } catch (error) {
+    console.error("❌ Error in startImport:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Recent import jobs without their row reports
+exports.listJobs = async (req, res) => {
+  try {
+    const jobs = listJobs();
+
+    res.status(200).json({
+      success: true,
+      data: jobs,
+      count: jobs.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listJobs:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Progress and reconciliation report of a job; ?format=csv downloads the
+// row report, ?outcome=REJECTED keeps only rows with that outcome
+exports.getJob = async (req, res) => {
+  try {
+    const job = getJob(req.params.jobId);
+    if (!job) {
+      return res.status(404).json({
+        error: "Import job not found",
+      });
+    }
+
+    const outcome = String(req.query.outcome || "").toUpperCase();
+    const rows = outcome
+      ? job.rows.filter((row) => row.outcome === outcome)
+      : job.rows;
+
+    if (req.query.format === "csv") {
+      res.setHeader("Content-Type", "text/csv; charset=utf-8");
+      res.setHeader(
+        "Content-Disposition",
+        `attachment; filename="${job.id}-reconciliation.csv"`
+      );
+      return res.status(200).send(reportCsv({ ...job, rows }));
+    }
+
+    res.status(200).json({
+      success: true,
+      data: { ...job, rows },
+    });
+  } catch (error) {
+    console.error("❌ Error in getJob:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,291 @@
+// Incident Controller - on-site accidents, spills and their corrective actions
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for incidents"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const INCIDENT_KINDS = ["ACCIDENT", "SPILL", "EMISSION", "FIRE", "OTHER"];
+const SEVERITIES = ["LOW", "MEDIUM", "HIGH", "CRITICAL"];
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendResult = (res, message, result) => {
+  res.status(200).json({
+    success: true,
+    message,
+    data: result?.result,
+    blockchainTxId: result?.transactionId || "pending",
+  });
+};
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Report an incident linked to a facility, a shipment and/or a lot
+exports.reportIncident = async (req, res) => {
+  try {
+    const {
+      id,
+      facilityId,
+      shipmentId,
+      wasteId,
+      kind,
+      severity,
+      description,
+      occurredAt,
+    } = req.body;
+
+    const normalizedKind = String(kind || "").toUpperCase();
+    const normalizedSeverity = String(severity || "").toUpperCase();
+    if (
+      !INCIDENT_KINDS.includes(normalizedKind) ||
+      !SEVERITIES.includes(normalizedSeverity) ||
+      !description
+    ) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: `Required fields: kind (${INCIDENT_KINDS.join(", ")}), severity (${SEVERITIES.join(", ")}), description`,
+      });
+    }
+    if (!facilityId && !shipmentId && !wasteId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Link the incident to a facilityId, shipmentId or wasteId",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "ReportIncident",
+      id || "",
+      facilityId || "",
+      shipmentId || "",
+      wasteId || "",
+      normalizedKind,
+      normalizedSeverity,
+      description,
+      occurredAt || ""
+    );
+
+    sendResult(res, "Incident reported on blockchain", result);
+  } catch (error) {
+    sendError(res, "reportIncident", error);
+  }
+};
+
+// Incidents, most serious first; facilityId, wasteId and status filter them
+exports.listIncidents = async (req, res) => {
+  try {
+    const { facilityId, wasteId, status } = req.query;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const incidents =
+      (await blockchainClient.query(
+        org,
+        "GetIncidents",
+        facilityId || "",
+        wasteId || "",
+        String(status || "").toUpperCase()
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: incidents,
+      count: incidents.length,
+    });
+  } catch (error) {
+    sendError(res, "listIncidents", error);
+  }
+};
+
+exports.getIncident = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const incident = await blockchainClient.query(
+      org,
+      "ReadIncident",
+      req.params.incidentId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: incident,
+    });
+  } catch (error) {
+    sendError(res, "getIncident", error);
+  }
+};
+
+// Add a corrective action (description, optional dueDate YYYY-MM-DD)
+exports.addCorrectiveAction = async (req, res) => {
+  try {
+    const { incidentId } = req.params;
+    const { description, dueDate } = req.body;
+
+    if (!description || (dueDate && !DATE_PATTERN.test(dueDate))) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required field: description; dueDate must be YYYY-MM-DD",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "AddCorrectiveAction",
+      incidentId,
+      description,
+      dueDate || ""
+    );
+
+    sendResult(
+      res,
+      `Corrective action added to incident ${incidentId}`,
+      result
+    );
+  } catch (error) {
+    sendError(res, "addCorrectiveAction", error);
+  }
+};
+
+// Mark a corrective action (by line) as done
+exports.completeCorrectiveAction = async (req, res) => {
+  try {
+    const { incidentId, line } = req.params;
+
+    if (!(parseInt(line, 10) > 0)) {
+      return res.status(400).json({
+        error: "Invalid corrective action",
+        details: "The action line must be a positive number",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CompleteCorrectiveAction",
+      incidentId,
+      String(parseInt(line, 10)),
+      req.body.notes || ""
+    );
+
+    sendResult(res, `Corrective action ${line} completed`, result);
+  } catch (error) {
+    sendError(res, "completeCorrectiveAction", error);
+  }
+};
+
+// Record that the regulator was notified, with its case reference
+exports.notifyRegulator = async (req, res) => {
+  try {
+    const { incidentId } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RecordRegulatorNotification",
+      incidentId,
+      req.body.reference || ""
+    );
+
+    sendResult(
+      res,
+      `Regulator notification recorded for ${incidentId}`,
+      result
+    );
+  } catch (error) {
+    sendError(res, "notifyRegulator", error);
+  }
+};
+
+// Close an incident once its corrective actions are done
+exports.closeIncident = async (req, res) => {
+  try {
+    const { incidentId } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CloseIncident",
+      incidentId,
+      req.body.notes || ""
+    );
+
+    sendResult(res, `Incident ${incidentId} closed`, result);
+  } catch (error) {
+    sendError(res, "closeIncident", error);
+  }
+};
@@ -0,0 +1,279 @@
+// Marketplace Controller - lot listings with sealed bids
+const crypto = require("crypto");
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  parseListQuery,
+  paginate,
+  sendListQueryError,
+} = require("../listQuery");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for marketplace"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+// List a lot for sale by sealed bids
+exports.createListing = async (req, res) => {
+  try {
+    const { id, wasteId, quantity, reservePrice, expiresAt } = req.body;
+
+    if (!wasteId || !quantity || !expiresAt) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: wasteId, quantity, expiresAt",
+      });
+    }
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CreateListing",
+      id || "",
+      wasteId,
+      String(parseFloat(quantity)),
+      String(parseFloat(reservePrice) || 0),
+      expiresAt
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Listing created on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in createListing:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Place or replace the organization's sealed bid; the amount only travels as
+// transient data, with a random salt hiding it in its commitment, and never
+// appears in the transaction arguments
+exports.submitBid = async (req, res) => {
+  try {
+    const { listingId } = req.params;
+    const amount = parseFloat(req.body.amount);
+
+    if (!(amount > 0)) {
+      return res.status(400).json({
+        error: "Invalid amount",
+        details: "'amount' must be a positive number",
+      });
+    }
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    console.log(`🔒 Sealed bid on listing ${listingId} from ${org}`);
+
+    const result = await blockchainClient.submitPrivateTransaction(
+      org,
+      "SubmitBid",
+      { bid: { amount, salt: crypto.randomBytes(16).toString("hex") } },
+      listingId
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Sealed bid recorded on blockchain",
+      listingId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in submitBid:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+const changeListing = (functionName, message) => async (req, res) => {
+  try {
+    const { listingId } = req.params;
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      functionName,
+      listingId
+    );
+
+    res.status(200).json({
+      success: true,
+      message,
+      listingId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error(`❌ Error in ${functionName}:`, error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Reveal the organization's own bid once the listing has expired (bidder)
+exports.revealBid = changeListing("RevealBid", "Bid revealed on blockchain");
+
+// Award an expired listing among the revealed bids (seller)
+exports.awardListing = changeListing(
+  "AwardListing",
+  "Listing awarded on blockchain"
+);
+
+// Settle an awarded listing (seller)
+exports.settleListing = changeListing(
+  "SettleListing",
+  "Listing settled on blockchain"
+);
+
+// List listings, optionally filtered by status
+exports.listListings = async (req, res) => {
+  try {
+    const query = parseListQuery(req, "LISTING");
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const listings =
+      (await blockchainClient.query(
+        org,
+        "GetListings",
+        (req.query.status || "").toUpperCase()
+      )) || [];
+
+    const { data, page } = paginate(listings, query);
+
+    res.status(200).json({
+      success: true,
+      data: data,
+      count: data.length,
+      page: page,
+    });
+  } catch (error) {
+    if (sendListQueryError(res, error)) {
+      return;
+    }
+    console.error("❌ Error in listListings:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Get one listing
+exports.getListing = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const listing = await blockchainClient.query(
+      org,
+      "ReadListing",
+      req.params.listingId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: listing,
+    });
+  } catch (error) {
+    console.error("❌ Error in getListing:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Get the organization's own sealed bid on a listing
+exports.getMyBid = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const bid = await blockchainClient.query(
+      org,
+      "ReadMyBid",
+      req.params.listingId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: bid,
+    });
+  } catch (error) {
+    console.error("❌ Error in getMyBid:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
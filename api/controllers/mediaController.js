@@ -0,0 +1,273 @@
+// Media Controller - photo and video galleries of lots and products
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  requestUpload,
+  completeUpload,
+  getUpload,
+  summarize,
+} = require("../media");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for media"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const ASSET_TYPES = ["WASTE", "EXTRACTION", "RECYCLING"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+// Validate the asset type of the URL; sends the error response when invalid
+const resolveAssetType = (req, res) => {
+  const assetType = String(req.params.assetType || "").toUpperCase();
+  if (!ASSET_TYPES.includes(assetType)) {
+    res.status(400).json({
+      error: "Invalid asset type",
+      details: `Asset type must be one of: ${ASSET_TYPES.join(", ")}`,
+    });
+    return null;
+  }
+  return assetType;
+};
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Get a pre-signed URL to upload a photo or video of an asset. The client
+// PUTs the file to the URL with the returned headers; the file's hash is
+// then anchored on the ledger automatically.
+exports.requestUpload = async (req, res) => {
+  try {
+    const { assetType, assetId } = req.body;
+
+    if (!ASSET_TYPES.includes(String(assetType || "").toUpperCase())) {
+      return res.status(400).json({
+        error: "Invalid asset type",
+        details: `'assetType' must be one of: ${ASSET_TYPES.join(", ")}`,
+      });
+    }
+    if (!assetId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: assetId, hash, mimeType, size",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    let upload;
+    try {
+      upload = requestUpload(blockchainClient, org, req.body);
+    } catch (error) {
+      return res.status(400).json({
+        error: "Invalid upload",
+        details: error.message,
+      });
+    }
+
+    res.status(201).json({
+      success: true,
+      message: "Upload the file to the URL before it expires",
+      data: {
+        uploadId: upload.id,
+        url: upload.url,
+        method: upload.method,
+        headers: upload.headers,
+        provider: upload.provider,
+        expiresAt: upload.expiresAt,
+      },
+    });
+  } catch (error) {
+    sendError(res, "requestUpload", error);
+  }
+};
+
+// Status of an upload: PENDING, ANCHORED, EXPIRED or FAILED
+exports.getUpload = async (req, res) => {
+  try {
+    const upload = getUpload(req.params.uploadId);
+    if (!upload) {
+      return res.status(404).json({
+        error: "Upload not found",
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: summarize(upload),
+    });
+  } catch (error) {
+    sendError(res, "getUpload", error);
+  }
+};
+
+// Tell the backend the file was uploaded so it is anchored right away
+exports.completeUpload = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const upload = await completeUpload(blockchainClient, req.params.uploadId);
+    if (!upload) {
+      return res.status(404).json({
+        error: "Upload not found",
+      });
+    }
+
+    res.status(upload.status === "FAILED" ? 409 : 200).json({
+      success: upload.status !== "FAILED",
+      data: summarize(upload),
+    });
+  } catch (error) {
+    sendError(res, "completeUpload", error);
+  }
+};
+
+// Media of an asset in display order
+exports.getGallery = async (req, res) => {
+  try {
+    const assetType = resolveAssetType(req, res);
+    if (!assetType) {
+      return;
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const gallery = await blockchainClient.query(
+      org,
+      "GetMediaGallery",
+      assetType,
+      req.params.assetId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: gallery,
+      count: gallery?.items?.length || 0,
+    });
+  } catch (error) {
+    sendError(res, "getGallery", error);
+  }
+};
+
+// Reorder a gallery; 'hashes' lists every media hash in the new order
+exports.reorderGallery = async (req, res) => {
+  try {
+    const { hashes } = req.body;
+
+    if (!Array.isArray(hashes) || hashes.length === 0) {
+      return res.status(400).json({
+        error: "Invalid order",
+        details: "'hashes' must list the media hashes in display order",
+      });
+    }
+    const assetType = resolveAssetType(req, res);
+    if (!assetType) {
+      return;
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "ReorderMedia",
+      assetType,
+      req.params.assetId,
+      JSON.stringify(hashes)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Gallery reordered",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "reorderGallery", error);
+  }
+};
+
+// Detach a media item from a gallery (the file stays in storage)
+exports.removeMedia = async (req, res) => {
+  try {
+    const assetType = resolveAssetType(req, res);
+    if (!assetType) {
+      return;
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RemoveMedia",
+      assetType,
+      req.params.assetId,
+      req.params.hash
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Media ${req.params.hash} removed`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "removeMedia", error);
+  }
+};
@@ -0,0 +1,251 @@
+// Notification Controller - per-organization on-chain inbox, and the
+// deadline digests users subscribe to
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+const {
+  DEADLINE_KINDS,
+  LEAD_DAYS,
+  savePreferences,
+  deletePreferences,
+  getPreferences,
+  listPreferences,
+  dueFor,
+  runDigest,
+  startDigests,
+  listRuns,
+} = require("../digests");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for notifications"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+startDigests();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Resolve which organization's inbox the request addresses
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+// List a page of the organization's notifications
+exports.listNotifications = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const { unread, pageSize, bookmark } = req.query;
+
+    const page = await blockchainClient.query(
+      org,
+      "GetMyNotifications",
+      String(unread === "true"),
+      String(parseInt(pageSize, 10) || 0),
+      bookmark || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      data: page?.notifications || [],
+      count: page?.notifications?.length || 0,
+      bookmark: page?.bookmark || null,
+      truncated: page?.truncated || false,
+    });
+  } catch (error) {
+    console.error("❌ Error in listNotifications:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Mark the given notifications (or all of them) as read
+exports.markRead = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const { ids } = req.body;
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "MarkNotificationsRead",
+      Array.isArray(ids) ? ids.join(",") : ids || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Notifications marked as read",
+      marked: result?.result ?? 0,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in markRead:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Deadline digest subscriptions, optionally of one participant
+exports.listDigestPreferences = (req, res) => {
+  const preferences = listPreferences({ participant: req.query.participant });
+  res.status(200).json({
+    success: true,
+    data: preferences,
+    count: preferences.length,
+  });
+};
+
+exports.getDigestPreferences = (req, res) => {
+  const preference = getPreferences(req.params.userId);
+  if (!preference) {
+    return res.status(404).json({
+      error: "Preferences not found",
+      userId: req.params.userId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    data: preference,
+  });
+};
+
+// Subscribe a user to the digests of a participant:
+// { participant, channels: [{ channel, to }], kinds, leadDays,
+//   includeOverdue, enabled }
+exports.setDigestPreferences = (req, res) => {
+  try {
+    const preference = savePreferences(req.params.userId, req.body || {});
+    res.status(200).json({
+      success: true,
+      message: `Digest preferences of ${preference.userId} saved`,
+      data: preference,
+    });
+  } catch (error) {
+    res.status(400).json({
+      error: "Invalid preferences",
+      details: error.message,
+    });
+  }
+};
+
+exports.deleteDigestPreferences = (req, res) => {
+  if (!deletePreferences(req.params.userId)) {
+    return res.status(404).json({
+      error: "Preferences not found",
+      userId: req.params.userId,
+    });
+  }
+  res.status(200).json({
+    success: true,
+    message: `${req.params.userId} unsubscribed from deadline digests`,
+  });
+};
+
+// Deadlines of a participant within the default lead times (or ?days=),
+// overdue ones included, from the read model
+exports.listDeadlines = (req, res) => {
+  const days = req.query.days !== undefined ? Number(req.query.days) : null;
+  const kinds = String(req.query.kinds || "")
+    .split(",")
+    .map((kind) => kind.trim().toUpperCase())
+    .filter(Boolean);
+
+  if (days !== null && !(days >= 0)) {
+    return res.status(400).json({
+      error: "Invalid days",
+      details: "'days' must be a number of days, zero or more",
+    });
+  }
+  const unknown = kinds.find((kind) => !DEADLINE_KINDS.includes(kind));
+  if (unknown) {
+    return res.status(400).json({
+      error: "Invalid kind",
+      details: `'kinds' must be among: ${DEADLINE_KINDS.join(", ")}`,
+    });
+  }
+
+  const leadDays =
+    days === null
+      ? LEAD_DAYS
+      : Object.fromEntries(DEADLINE_KINDS.map((kind) => [kind, days]));
+  const deadlines = dueFor(req.query.participant, { kinds, leadDays });
+  res.status(200).json({
+    success: true,
+    data: deadlines,
+    count: deadlines.length,
+  });
+};
+
+// Past digest runs, newest first
+exports.listDigestRuns = (req, res) => {
+  const runs = listRuns();
+  res.status(200).json({
+    success: true,
+    data: runs,
+    count: runs.length,
+  });
+};
+
+// Run the digest now; { dryRun: true } shows who would be reminded of what
+exports.runDigest = async (req, res) => {
+  try {
+    const run = await runDigest({ dryRun: req.body?.dryRun === true });
+    res.status(200).json({
+      success: true,
+      message: `${run.digests.length} digest(s) ${
+        run.dryRun ? "prepared" : "sent"
+      }`,
+      data: run,
+    });
+  } catch (error) {
+    console.error("❌ Error in runDigest:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
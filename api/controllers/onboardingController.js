@@ -0,0 +1,311 @@
+// Onboarding Controller - the staged onboarding wizard of new participants,
+// their activation on chain and reminders for stalled onboardings
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+const {
+  STEPS,
+  ROLES,
+  REQUIRED_DOCUMENTS,
+  STALL_DAYS,
+  startOnboarding,
+  submitStep,
+  reviewStep,
+  activate,
+  cancelOnboarding,
+  getOnboarding,
+  listOnboardings,
+  describe,
+  runReminders,
+  startReminders,
+  listRuns,
+} = require("../onboarding");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for onboarding"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+startReminders();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  return org;
+};
+
+const requireBlockchain = (res) => {
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return false;
+  }
+  return true;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  if (/ is (already|not|active|cancelled)\b/.test(error.message)) {
+    return res.status(409).json({
+      error: "Conflict",
+      details: error.message,
+    });
+  }
+  if (/required|must be|needs |unknown /i.test(error.message)) {
+    return res.status(400).json({
+      error: "Invalid request",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// The steps, roles and documents of the wizard
+exports.getWizard = (req, res) => {
+  res.status(200).json({
+    success: true,
+    data: {
+      steps: STEPS,
+      roles: ROLES,
+      requiredDocuments: REQUIRED_DOCUMENTS,
+      stallDays: STALL_DAYS,
+    },
+  });
+};
+
+// Onboardings with their progress; ?org=, ?status= and ?stalled=true filter
+exports.listOnboardings = (req, res) => {
+  const { org, status, stalled } = req.query;
+  const onboardings = listOnboardings({
+    org,
+    status: status && String(status).toUpperCase(),
+    stalled: stalled === "true",
+  }).map((onboarding) => describe(onboarding));
+
+  res.status(200).json({
+    success: true,
+    data: onboardings,
+    count: onboardings.length,
+  });
+};
+
+// An onboarding with its progress and the data submitted at each step
+exports.getOnboarding = (req, res) => {
+  const onboarding = getOnboarding(req.params.id);
+  if (!onboarding) {
+    return res.status(404).json({
+      error: "Onboarding not found",
+    });
+  }
+
+  res.status(200).json({
+    success: true,
+    data: describe(onboarding, { withData: true }),
+  });
+};
+
+// Start an onboarding: { org, name, participantId, contact, reviewers }
+exports.startOnboarding = (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const onboarding = startOnboarding(org, req.body);
+
+    res.status(201).json({
+      success: true,
+      message: `Onboarding ${onboarding.id} started`,
+      data: describe(onboarding),
+    });
+  } catch (error) {
+    sendError(res, "startOnboarding", error);
+  }
+};
+
+// Submit the data of a step, or resubmit it after a rejection
+exports.submitStep = (req, res) => {
+  try {
+    const { id, step } = req.params;
+    const onboarding = submitStep(id, step, req.body);
+
+    res.status(200).json({
+      success: true,
+      message: `Step ${String(step).toUpperCase()} submitted for review`,
+      data: describe(onboarding),
+    });
+  } catch (error) {
+    sendError(res, "submitStep", error);
+  }
+};
+
+// Approve or reject a submitted step: { approved, reason, reviewer }.
+// Approved steps are recorded on chain.
+exports.reviewStep = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const { id, step } = req.params;
+    const onboarding = await reviewStep(blockchainClient, id, step, req.body);
+    const reviewed = onboarding.steps.find(
+      (candidate) => candidate.step === String(step).toUpperCase()
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Step ${reviewed.step} ${reviewed.status.toLowerCase()}`,
+      data: describe(onboarding),
+      blockchainTxId: reviewed.txId || null,
+    });
+  } catch (error) {
+    sendError(res, "reviewStep", error);
+  }
+};
+
+// Activate the participant once every step is verified: { actor }
+exports.activate = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const onboarding = await activate(
+      blockchainClient,
+      req.params.id,
+      req.body?.actor
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Participant ${onboarding.participantId} activated`,
+      data: describe(onboarding),
+      blockchainTxId: onboarding.activationTxId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "activate", error);
+  }
+};
+
+// Cancel an onboarding: { reason, actor }
+exports.cancelOnboarding = (req, res) => {
+  try {
+    const onboarding = cancelOnboarding(
+      req.params.id,
+      req.body?.reason,
+      req.body?.actor
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Onboarding ${onboarding.id} cancelled`,
+      data: describe(onboarding),
+    });
+  } catch (error) {
+    sendError(res, "cancelOnboarding", error);
+  }
+};
+
+// On-chain onboarding record of a participant, with the required steps it
+// still misses
+exports.getParticipantOnboarding = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org || !requireBlockchain(res)) {
+      return;
+    }
+
+    const { participantId } = req.params;
+    const [missing, record] = await Promise.all([
+      blockchainClient.query(org, "GetMissingOnboardingSteps", participantId),
+      blockchainClient
+        .query(org, "GetParticipantOnboarding", participantId)
+        .catch((error) => {
+          if (/does not exist/.test(error.message)) {
+            return null;
+          }
+          throw error;
+        }),
+    ]);
+
+    res.status(200).json({
+      success: true,
+      data: {
+        participantId,
+        status: record?.status || "NOT_STARTED",
+        missingSteps: missing || [],
+        record,
+      },
+    });
+  } catch (error) {
+    sendError(res, "getParticipantOnboarding", error);
+  }
+};
+
+// Past reminder runs, newest first
+exports.listReminderRuns = (req, res) => {
+  const runs = listRuns();
+  res.status(200).json({
+    success: true,
+    data: runs,
+    count: runs.length,
+  });
+};
+
+// Remind stalled onboardings now; { dryRun: true } shows who would be
+// reminded
+exports.runReminders = async (req, res) => {
+  try {
+    const run = await runReminders({ dryRun: req.body?.dryRun === true });
+    res.status(200).json({
+      success: true,
+      message: `${run.reminders.length} onboarding(s) reminded${
+        run.dryRun ? " (dry run)" : ""
+      }`,
+      data: run,
+    });
+  } catch (error) {
+    sendError(res, "runReminders", error);
+  }
+};
@@ -0,0 +1,272 @@
+// Planning Controller - intake processors can expect at their facilities,
+// day by day, from reservations, shipments in transit and supply contracts
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { toCsv } = require("../import/csv");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for planning"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+// Columns of the intake forecast export
+const FORECAST_COLUMNS = [
+  "facilityId",
+  "facilityName",
+  "date",
+  "capacity",
+  "reserved",
+  "inTransit",
+  "committed",
+  "expected",
+  "remaining",
+  "overbooked",
+  "reservations",
+  "shipments",
+];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org || "processor";
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+// Check optional YYYY-MM-DD ?from= and ?to= query parameters
+const checkPeriod = (req, res) => {
+  const { from, to } = req.query;
+  if ((from && !DATE_PATTERN.test(from)) || (to && !DATE_PATTERN.test(to))) {
+    res.status(400).json({
+      error: "Invalid period",
+      details: "'from' and 'to' must be YYYY-MM-DD dates",
+    });
+    return false;
+  }
+  return true;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  if (/(^|: )only /.test(error.message)) {
+    return res.status(403).json({
+      error: "Forbidden",
+      details: error.message,
+    });
+  }
+  if (/invalid date|at most \d+ days|must not be before/.test(error.message)) {
+    return res.status(400).json({
+      error: "Invalid period",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Intake forecast of the organization's facilities, per facility and day
+// (?facilityId=&from=&to=, a week from today by default); ?format=csv
+// downloads it
+exports.getForecast = async (req, res) => {
+  try {
+    if (!checkPeriod(req, res)) {
+      return;
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const forecast = await blockchainClient.query(
+      org,
+      "GetIntakeForecast",
+      req.query.facilityId || "",
+      req.query.from || "",
+      req.query.to || ""
+    );
+    const days = forecast?.days || [];
+
+    if (req.query.format === "csv") {
+      res.setHeader("Content-Type", "text/csv; charset=utf-8");
+      res.setHeader(
+        "Content-Disposition",
+        `attachment; filename="intake-forecast-${forecast?.from}-${forecast?.to}.csv"`
+      );
+      return res.status(200).send(toCsv(FORECAST_COLUMNS, days));
+    }
+
+    res.status(200).json({
+      success: true,
+      data: forecast,
+      count: days.length,
+    });
+  } catch (error) {
+    sendError(res, "getForecast", error);
+  }
+};
+
+// Reservations made by the organization or at its facilities
+// (?facilityId=&from=&to=)
+exports.listReservations = async (req, res) => {
+  try {
+    if (!checkPeriod(req, res)) {
+      return;
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const reservations =
+      (await blockchainClient.query(
+        org,
+        "GetIntakeReservations",
+        req.query.facilityId || "",
+        req.query.from || "",
+        req.query.to || ""
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: reservations,
+      count: reservations.length,
+    });
+  } catch (error) {
+    sendError(res, "listReservations", error);
+  }
+};
+
+exports.getReservation = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const reservation = await blockchainClient.query(
+      org,
+      "ReadIntakeReservation",
+      req.params.reservationId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: reservation,
+    });
+  } catch (error) {
+    sendError(res, "getReservation", error);
+  }
+};
+
+// Reserve intake at a facility:
+// { org, id, facilityId, date, quantity, wasteId, contractId, notes }
+exports.reserveIntake = async (req, res) => {
+  try {
+    const { id, facilityId, date, wasteId, contractId, notes } = req.body;
+    const quantity = Number(req.body.quantity);
+
+    if (!facilityId || !DATE_PATTERN.test(date || "") || !(quantity > 0)) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details:
+          "Required fields: facilityId, date (YYYY-MM-DD), quantity (positive)",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "ReserveIntake",
+      id || "",
+      facilityId,
+      date,
+      String(quantity),
+      wasteId || "",
+      contractId || "",
+      notes || ""
+    );
+    const reservation = result?.result;
+
+    res.status(201).json({
+      success: true,
+      message: `${quantity} reserved at facility ${facilityId} on ${date}`,
+      data: reservation,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "reserveIntake", error);
+  }
+};
+
+exports.cancelReservation = async (req, res) => {
+  try {
+    const { reservationId } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CancelIntakeReservation",
+      reservationId,
+      req.body.reason || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Intake reservation ${reservationId} cancelled`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "cancelReservation", error);
+  }
+};
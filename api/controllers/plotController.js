@@ -0,0 +1,380 @@
+// Plot Controller - farm parcels (LPIS / cadastral IDs) and plot statistics
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { toCsv } = require("../import/csv");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for plots"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Organization whose gateway identity carries the admin role
+const ADMIN_ORG = process.env.ADMIN_ORG || "farmer";
+
+const PARCEL_SCHEMES = ["LPIS", "CADASTRAL"];
+
+const QUOTA_POLICIES = ["FLAG", "BLOCK"];
+
+// Columns of the parcel-level compliance export
+const STATISTICS_COLUMNS = [
+  "plotId",
+  "parcelId",
+  "scheme",
+  "farm",
+  "areaHa",
+  "wasteCount",
+  "totalCollected",
+  "totalProcessed",
+  "totalRecycled",
+  "recyclingRate",
+  "yieldPerHa",
+];
+
+const requireBlockchain = (res) => {
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return false;
+  }
+  return true;
+};
+
+// Register a parcel of one of the farmer organization's farms
+exports.registerPlot = async (req, res) => {
+  try {
+    const { id, farm, parcelId, scheme, areaHa, region, actor } = req.body;
+
+    if (!farm || !parcelId || !scheme) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: farm, parcelId, scheme",
+      });
+    }
+    if (!PARCEL_SCHEMES.includes(String(scheme).toUpperCase())) {
+      return res.status(400).json({
+        error: "Invalid parcel scheme",
+        details: `'scheme' must be one of: ${PARCEL_SCHEMES.join(", ")}`,
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "farmer",
+      "RegisterPlot",
+      id || "",
+      farm,
+      parcelId,
+      String(scheme).toUpperCase(),
+      String(parseFloat(areaHa) || 0),
+      region || "",
+      actor || "farmer_001"
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Plot registered on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in registerPlot:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Retire a plot so it can no longer be declared on new lots
+exports.retirePlot = async (req, res) => {
+  try {
+    const { plotId } = req.params;
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "farmer",
+      "RetirePlot",
+      plotId,
+      req.body.reason || "",
+      req.body.actor || "farmer_001"
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Plot retired on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in retirePlot:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List plots, optionally of one farm (?farm=)
+exports.listPlots = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const plots =
+      (await blockchainClient.query(
+        "farmer",
+        "GetPlotsForFarm",
+        req.query.farm || ""
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: plots,
+      count: plots.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listPlots:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Get one plot
+exports.getPlot = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const plot = await blockchainClient.query(
+      "farmer",
+      "ReadPlot",
+      req.params.plotId
+    );
+    if (!plot) {
+      return res.status(404).json({
+        error: "Plot not found",
+        plotId: req.params.plotId,
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: plot,
+    });
+  } catch (error) {
+    console.error("❌ Error in getPlot:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Plot-level totals (?farm=); ?format=csv downloads them as the parcel-level
+// compliance export
+exports.getPlotStatistics = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const statistics =
+      (await blockchainClient.query(
+        "farmer",
+        "GetPlotStatistics",
+        req.query.farm || ""
+      )) || [];
+
+    if (req.query.format === "csv") {
+      res.setHeader("Content-Type", "text/csv; charset=utf-8");
+      res.setHeader(
+        "Content-Disposition",
+        'attachment; filename="plot-statistics.csv"'
+      );
+      return res.status(200).send(toCsv(STATISTICS_COLUMNS, statistics));
+    }
+
+    res.status(200).json({
+      success: true,
+      data: statistics,
+      count: statistics.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in getPlotStatistics:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Seasonal declared-quantity quotas of farms, optionally of one ?season=
+exports.listQuotas = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const quotas =
+      (await blockchainClient.query(
+        "farmer",
+        "GetFarmQuotas",
+        req.query.season || ""
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: quotas,
+      count: quotas.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listQuotas:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Season a harvest date (?harvestDate=YYYY-MM-DD) counts towards
+exports.getQuotaSeason = async (req, res) => {
+  try {
+    if (!req.query.harvestDate) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required query parameter: harvestDate",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const season = await blockchainClient.query(
+      "farmer",
+      "GetQuotaSeason",
+      req.query.harvestDate
+    );
+
+    res.status(200).json({
+      success: true,
+      data: { harvestDate: req.query.harvestDate, season },
+    });
+  } catch (error) {
+    console.error("❌ Error in getQuotaSeason:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Quota of a farm for a season, with the quantity declared so far
+exports.getQuota = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const quota = await blockchainClient.query(
+      "farmer",
+      "ReadFarmQuota",
+      req.params.farm,
+      req.params.season
+    );
+
+    res.status(200).json({
+      success: true,
+      data: quota,
+    });
+  } catch (error) {
+    if (/no quota/.test(error.message)) {
+      return res.status(404).json({
+        error: "Quota not found",
+        farm: req.params.farm,
+        season: req.params.season,
+      });
+    }
+    console.error("❌ Error in getQuota:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Set a farm's quota for a season: an explicit quota, or areaHa (default:
+// the farm's active plots) times yieldPerHa (default: the crop's configured
+// yield). policy FLAG records over-quota lots with a warning, BLOCK refuses
+// them.
+exports.setQuota = async (req, res) => {
+  try {
+    const { season, farm } = req.params;
+    const { crop, areaHa, yieldPerHa, quota } = req.body;
+    const policy = String(req.body.policy || "FLAG").toUpperCase();
+
+    if (!QUOTA_POLICIES.includes(policy)) {
+      return res.status(400).json({
+        error: "Invalid quota policy",
+        details: `'policy' must be one of: ${QUOTA_POLICIES.join(", ")}`,
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "SetFarmQuota",
+      farm,
+      season,
+      crop || "",
+      String(parseFloat(areaHa) || 0),
+      String(parseFloat(yieldPerHa) || 0),
+      String(parseFloat(quota) || 0),
+      policy
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Quota of ${farm} for ${season} set`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in setQuota:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,157 @@
+// Pricing Controller - market price index and lot valuation snapshots
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for pricing"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Organization whose gateway identity maintains the price index: an admin or
+// the identity designated by the chaincode setting oracle.priceIdentity
+const ORACLE_ORG =
+  process.env.PRICE_ORACLE_ORG || process.env.ADMIN_ORG || "farmer";
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+// Record the market price of a product type from a date on (oracle feed)
+exports.recordPrice = async (req, res) => {
+  try {
+    const { productType, date, pricePerUnit, currency, source } = req.body;
+
+    if (!productType || !date || pricePerUnit === undefined || !currency) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details:
+          "Required fields: productType, date (YYYY-MM-DD), pricePerUnit, currency",
+      });
+    }
+    if (!DATE_PATTERN.test(date)) {
+      return res.status(400).json({
+        error: "Invalid date",
+        details: "'date' must be formatted as YYYY-MM-DD",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    console.log(`💶 Recording ${productType} price for ${date}`);
+
+    const result = await blockchainClient.submitTransaction(
+      ORACLE_ORG,
+      "RecordPriceIndex",
+      productType,
+      date,
+      String(parseFloat(pricePerUnit)),
+      currency,
+      source || ""
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Price recorded on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in recordPrice:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Dated prices of a product type, oldest first
+exports.getPriceIndex = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const entries =
+      (await blockchainClient.query(
+        ORACLE_ORG,
+        "GetPriceIndex",
+        req.params.productType
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: entries,
+      count: entries.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in getPriceIndex:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Valuation snapshots of a lot (creation, transfers, sales)
+exports.getValuationHistory = async (req, res) => {
+  try {
+    const org = req.query.org || "farmer";
+    if (!ORGANIZATIONS.includes(org)) {
+      return res.status(400).json({
+        error: "Invalid organization",
+        details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const valuations =
+      (await blockchainClient.query(
+        org,
+        "GetValuationHistory",
+        req.params.wasteId
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: valuations,
+      count: valuations.length,
+      unpriced: valuations.filter((valuation) => valuation.unpriced).length,
+    });
+  } catch (error) {
+    console.error("❌ Error in getValuationHistory:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,62 @@
+// Public Controller - unauthenticated, k-anonymized network statistics for
+// the public explorer page
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  startPublicStatistics,
+  getPublicStatistics,
+} = require("../public");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    startPublicStatistics(blockchainClient);
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for public stats"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Browsers and CDNs may cache the figures this long
+const MAX_AGE_SECONDS =
+  parseInt(process.env.PUBLIC_STATS_MAX_AGE_SECONDS, 10) || 300;
+
+// Tonnes recorded and valorized, CO2e avoided and farms participating, in
+// total and per group, each group covering enough farms to stay anonymous
+exports.getStats = async (req, res) => {
+  try {
+    const { statistics, refreshedAt } = await getPublicStatistics();
+    if (!statistics) {
+      return res.status(503).json({
+        error: "Statistics unavailable",
+        details: "Public statistics have not been computed yet",
+      });
+    }
+
+    res.setHeader("Cache-Control", `public, max-age=${MAX_AGE_SECONDS}`);
+    res.status(200).json({
+      success: true,
+      data: statistics,
+      refreshedAt,
+    });
+  } catch (error) {
+    console.error("❌ Error in getStats:", error);
+    res.status(500).json({
+      error: "Internal server error",
+    });
+  }
+};
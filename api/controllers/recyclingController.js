@@ -7,6 +7,11 @@ const BlockchainClient = require(path.join(
   "blockchain",
   "enhancedClient"
 ));
+const {
+  parseListQuery,
+  paginate,
+  sendListQueryError,
+} = require("../listQuery");
 
 // Initialize enhanced blockchain client
 const blockchainClient = new BlockchainClient();
@@ -62,6 +67,12 @@ exports.addRecycling = async (req, res) => {
       });
     }
 
+    // Several input lots: record a blended run
+    if (Array.isArray(recyclingData.inputs)) {
+      req.body = { ...recyclingData, recyclerId: req.body.recyclerId };
+      return exports.addBlendedRecycling(req, res);
+    }
+
     // Validate required fields
     const { wasteId, recycledProduct, quantity, method } = recyclingData;
     if (!wasteId || !recycledProduct || !quantity || !method) {
@@ -85,6 +96,7 @@ exports.addRecycling = async (req, res) => {
       recycledProduct,
       quantity: parseFloat(quantity),
       method,
+      facilityId: recyclingData.facilityId || "",
       qualityGrade: recyclingData.qualityGrade || "Standard",
       processingTime: recyclingData.processingTime || "7 days",
       environmentalImpact: recyclingData.environmentalImpact || "Positive",
@@ -186,6 +198,8 @@ exports.addRecycling = async (req, res) => {
 // Get list of recyclings with blockchain integration
 exports.listRecyclings = async (req, res) => {
   try {
+    const query = parseListQuery(req, "RECYCLING");
+
     console.log("📋 Fetching recyclings list...");
 
     let recyclingList = [];
@@ -228,16 +242,22 @@ exports.listRecyclings = async (req, res) => {
       recyclingList = recyclings;
     }
 
+    const { data, page } = paginate(recyclingList, query);
+
     res.status(200).json({
       success: true,
-      data: recyclingList,
-      count: recyclingList.length,
+      data: data,
+      count: data.length,
+      page: page,
       source: source,
       timestamp: new Date().toISOString(),
     });
 
-    console.log(`✅ Sent list of ${recyclingList.length} recyclings`);
+    console.log(`✅ Sent list of ${data.length} recyclings`);
   } catch (error) {
+    if (sendListQueryError(res, error)) {
+      return;
+    }
     console.error("❌ Error in listRecyclings:", error);
     res.status(500).json({
       error: "Internal server error",
@@ -373,6 +393,55 @@ exports.getRecyclingsByWasteId = async (req, res) => {
   }
 };
 
+// Dry-run recycling: validate on the ledger without committing
+exports.simulateAddRecycling = async (req, res) => {
+  try {
+    const { recyclingData } = req.body;
+
+    if (!recyclingData) {
+      return res.status(400).json({
+        error: "Missing recycling data",
+        details: "The 'recyclingData' field is required",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+        details: "Simulation requires a blockchain connection",
+      });
+    }
+
+    // An empty id lets the chaincode generate one
+    const recyclingId = recyclingData.id || "";
+
+    const simulation = await blockchainClient.query(
+      "recycler",
+      "SimulateCreateRecycling",
+      recyclingId,
+      recyclingData.wasteId || "",
+      recyclingData.recycledProduct || "",
+      String(parseFloat(recyclingData.quantity) || 0),
+      recyclingData.method || "",
+      req.body.recyclerId || recyclingData.recyclerId || "recycler_001",
+      recyclingData.facilityId || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      committed: false,
+      data: simulation,
+      source: "blockchain",
+    });
+  } catch (error) {
+    console.error("❌ Error in simulateAddRecycling:", error);
+    res.status(422).json({
+      error: "Validation failed",
+      details: error.message,
+    });
+  }
+};
+
 // Get complete traceability chain with blockchain integration
 exports.getCompleteTraceability = async (req, res) => {
   try {
@@ -469,3 +538,435 @@ exports.getCompleteTraceability = async (req, res) => {
     });
   }
 };
+
+// Lightweight traceability: linked asset IDs, statuses and recent history
+exports.getTraceabilityLite = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const trace = await blockchainClient.query(
+      req.query.org || "recycler",
+      "GetTraceabilityLite",
+      wasteId,
+      String(parseInt(req.query.historyLimit, 10) || 0)
+    );
+
+    if (!trace) {
+      return res.status(404).json({
+        error: "Traceability data not found",
+        wasteId: wasteId,
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: trace,
+    });
+  } catch (error) {
+    console.error("❌ Error in getTraceabilityLite:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Look up a waste, extraction or recycling by its sequential reference
+// (e.g. WST-2025-000123)
+exports.getAssetByReference = async (req, res) => {
+  try {
+    const { reference } = req.params;
+
+    if (!/^[A-Za-z]{3}-\d{4}-\d{6,}$/.test(reference)) {
+      return res.status(400).json({
+        error: "Invalid reference",
+        details: "references look like WST-2025-000123",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const asset = await blockchainClient.query(
+      req.query.org || "recycler",
+      "GetAssetByReference",
+      reference
+    );
+
+    if (!asset) {
+      return res.status(404).json({
+        error: "Reference not found",
+        reference,
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: asset,
+    });
+  } catch (error) {
+    console.error("❌ Error in getAssetByReference:", error);
+    const notFound = /no asset has reference/.test(error.message);
+    res.status(notFound ? 404 : 500).json({
+      error: notFound ? "Reference not found" : "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Public proof that a lot was fully extracted or recycled
+exports.getCompletionCertificate = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const certificate = await blockchainClient.query(
+      req.query.org || "recycler",
+      "ReadCompletionCertificate",
+      wasteId
+    );
+
+    if (!certificate) {
+      return res.status(404).json({
+        error: "Completion certificate not found",
+        wasteId,
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: certificate,
+    });
+  } catch (error) {
+    console.error("❌ Error in getCompletionCertificate:", error);
+    const notFound = /does not exist|not been fully valorized/.test(
+      error.message
+    );
+    res.status(notFound ? 404 : 500).json({
+      error: notFound
+        ? "Completion certificate not found"
+        : "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Page through the full history of a waste, extraction or recycling
+exports.getHistorySegment = async (req, res) => {
+  try {
+    const { assetType, assetId } = req.params;
+    const type = String(assetType).toUpperCase();
+
+    if (!["WASTE", "EXTRACTION", "RECYCLING"].includes(type)) {
+      return res.status(400).json({
+        error: "Invalid asset type",
+        details: "assetType must be one of: waste, extraction, recycling",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const segment = await blockchainClient.query(
+      req.query.org || "recycler",
+      "GetAssetHistorySegment",
+      type,
+      assetId,
+      String(parseInt(req.query.offset, 10) || 0),
+      String(parseInt(req.query.limit, 10) || 0)
+    );
+
+    const next = new URLSearchParams(req.query);
+    next.set("offset", segment?.nextOffset);
+
+    res.status(200).json({
+      success: true,
+      data: segment,
+      next:
+        segment && segment.nextOffset >= 0
+          ? `${req.baseUrl}${req.path}?${next}`
+          : null,
+    });
+  } catch (error) {
+    console.error("❌ Error in getHistorySegment:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Page through the history checkpoints compacted out of an asset
+exports.getArchivedHistory = async (req, res) => {
+  try {
+    const { assetType, assetId } = req.params;
+    const type = String(assetType).toUpperCase();
+
+    if (!["WASTE", "EXTRACTION", "RECYCLING"].includes(type)) {
+      return res.status(400).json({
+        error: "Invalid asset type",
+        details: "assetType must be one of: waste, extraction, recycling",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const page = await blockchainClient.query(
+      req.query.org || "recycler",
+      "GetArchivedHistory",
+      type,
+      assetId,
+      String(parseInt(req.query.pageSize, 10) || 0),
+      req.query.bookmark || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      data: page?.checkpoints || [],
+      count: page?.checkpoints?.length || 0,
+      bookmark: page?.bookmark || null,
+      truncated: page?.truncated || false,
+    });
+  } catch (error) {
+    console.error("❌ Error in getArchivedHistory:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Organization whose gateway identity carries the admin role
+const ADMIN_ORG = process.env.ADMIN_ORG || "farmer";
+
+// List the recycling method catalog
+exports.listMethods = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const methods =
+      (await blockchainClient.query("recycler", "GetAllRecyclingMethods")) ||
+      [];
+
+    res.status(200).json({
+      success: true,
+      data: methods,
+      count: methods.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listMethods:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Add or update a catalog method (admin)
+exports.defineMethod = async (req, res) => {
+  try {
+    const { code } = req.params;
+    const {
+      name,
+      emissionFactor,
+      requiredCertifications,
+      typicalDurationDays,
+    } = req.body;
+
+    if (!name || emissionFactor === undefined) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "name and emissionFactor are required",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "DefineRecyclingMethod",
+      code,
+      name,
+      String(parseFloat(emissionFactor)),
+      Array.isArray(requiredCertifications)
+        ? requiredCertifications.join(",")
+        : requiredCertifications || "",
+      String(parseInt(typicalDurationDays, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Recycling method ${code} saved`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in defineMethod:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Retire a catalog method (admin)
+exports.retireMethod = async (req, res) => {
+  try {
+    const { code } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "RetireRecyclingMethod",
+      code
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Recycling method ${code} retired`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in retireMethod:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Record a recycling run blending several lots, each with its own quantity
+exports.addBlendedRecycling = async (req, res) => {
+  try {
+    const { inputs, recycledProduct, method, facilityId } = req.body;
+
+    if (!Array.isArray(inputs) || inputs.length < 2) {
+      return res.status(400).json({
+        error: "Invalid inputs",
+        details: "'inputs' must list at least two { wasteId, quantity } lots",
+      });
+    }
+    if (!recycledProduct || !method) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: inputs, recycledProduct, method",
+      });
+    }
+    const invalidInput = inputs.findIndex(
+      (input) => !input.wasteId || !(parseFloat(input.quantity) > 0)
+    );
+    if (invalidInput !== -1) {
+      return res.status(400).json({
+        error: "Invalid input lot",
+        details: `Input ${
+          invalidInput + 1
+        } needs a wasteId and a positive quantity`,
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    console.log(`♻️ Blending ${inputs.length} lots into ${recycledProduct}`);
+
+    const result = await blockchainClient.submitTransaction(
+      "recycler",
+      "CreateBlendedRecycling",
+      req.body.id || "",
+      JSON.stringify(
+        inputs.map((input) => ({
+          wasteId: input.wasteId,
+          quantity: parseFloat(input.quantity),
+        }))
+      ),
+      recycledProduct,
+      method,
+      req.body.recyclerId || "recycler_001",
+      facilityId || ""
+    );
+
+    res.status(201).json({
+      success: true,
+      message: `Blended recycling of ${inputs.length} lots recorded on blockchain`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in addBlendedRecycling:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Every lot and farm a recycled product was made from
+exports.getRecyclingProvenance = async (req, res) => {
+  try {
+    const { recyclingId } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const provenance = await blockchainClient.query(
+      "recycler",
+      "GetRecyclingProvenance",
+      recyclingId
+    );
+
+    if (!provenance) {
+      return res.status(404).json({
+        error: "Recycling not found",
+        recyclingId,
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: provenance,
+    });
+  } catch (error) {
+    console.error("❌ Error in getRecyclingProvenance:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
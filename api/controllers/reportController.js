@@ -0,0 +1,354 @@
+// Report Controller - traceability certificates rendered as PDF, and
+// CSRD/GRI style impact reports of buyers
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  renderTraceabilityReport,
+  hashDocument,
+} = require("../reports/traceabilityReport");
+const {
+  resolvePeriod,
+  buildImpactReport,
+  renderImpactReport,
+} = require("../reports/impactReport");
+const { signCredential } = require("../reports/verifiableCredential");
+const {
+  tenantForOrg,
+  tenantForMsp,
+  tenantLanguage,
+  publicTheme,
+} = require("../tenants");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for reports"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Organization whose gateway identity reads trace tokens
+const TOKEN_ORG = process.env.FEEDBACK_ORG || process.env.ADMIN_ORG || "farmer";
+
+const getTraceUrl = (req, tenant, wasteId) => {
+  const base =
+    tenant.traceUrl || `${req.protocol}://${req.get("host")}/api/traceability`;
+  return `${base.replace(/\/$/, "")}/${encodeURIComponent(wasteId)}`;
+};
+
+// Tenant a report is branded for: the one bound to the trace token it is
+// printed for (?token=), else the one of the acting organization (?org=)
+const resolveTenant = async (req) => {
+  const token = req.body?.token || req.query.token;
+  if (token && blockchainInitialized) {
+    const traceToken = await blockchainClient.query(
+      TOKEN_ORG,
+      "ReadTraceToken",
+      token
+    );
+    return tenantForMsp(traceToken?.issuedByMsp);
+  }
+  return tenantForOrg(req.body?.org || req.query.org);
+};
+
+const loadTraceability = async (wasteId) => {
+  if (!blockchainInitialized) {
+    return null;
+  }
+  const trace = await blockchainClient.query(
+    "recycler",
+    "GetTraceability",
+    wasteId
+  );
+  if (!trace) {
+    return null;
+  }
+  return typeof trace === "string" ? JSON.parse(trace) : trace;
+};
+
+const buildReport = async (req, wasteId) => {
+  const trace = await loadTraceability(wasteId);
+  if (!trace || !trace.waste) {
+    return null;
+  }
+  const tenant = await resolveTenant(req);
+  const pdf = renderTraceabilityReport(trace, {
+    traceUrl: getTraceUrl(req, tenant, wasteId),
+    tenant,
+    language: tenantLanguage(tenant, req),
+  });
+  return { pdf, hash: hashDocument(pdf), tenant: tenant.id };
+};
+
+// Theme of the tenant of a trace token (?token=) or organization (?org=),
+// for pages branded like its reports
+exports.getTheme = async (req, res) => {
+  try {
+    const tenant = await resolveTenant(req);
+
+    res.status(200).json({
+      success: true,
+      data: { ...publicTheme(tenant), language: tenantLanguage(tenant, req) },
+    });
+  } catch (error) {
+    if (/does not exist/.test(error.message)) {
+      return res.status(404).json({
+        error: "Trace token not found",
+      });
+    }
+    console.error("❌ Error in getTheme:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Download the traceability certificate of a waste lot
+exports.downloadTraceabilityReport = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+
+    console.log(`📄 Generating traceability report for waste ${wasteId}`);
+
+    const report = await buildReport(req, wasteId);
+    if (!report) {
+      return res.status(404).json({
+        error: "Traceability data not found",
+        wasteId: wasteId,
+      });
+    }
+
+    res.setHeader("Content-Type", "application/pdf");
+    res.setHeader(
+      "Content-Disposition",
+      `attachment; filename="traceability-${wasteId}.pdf"`
+    );
+    res.setHeader("X-Document-Hash", report.hash);
+    res.setHeader("X-Tenant", report.tenant);
+    res.status(200).send(report.pdf);
+  } catch (error) {
+    console.error("❌ Error in downloadTraceabilityReport:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Generate the certificate and anchor its hash on the ledger
+exports.anchorTraceabilityReport = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const actor = req.body?.actor || "farmer_001";
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+        details: "Report hashes can only be anchored on the ledger",
+      });
+    }
+
+    const report = await buildReport(req, wasteId);
+    if (!report) {
+      return res.status(404).json({
+        error: "Traceability data not found",
+        wasteId: wasteId,
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      "farmer",
+      "AttachWasteDocument",
+      wasteId,
+      "TRACEABILITY_REPORT",
+      report.hash,
+      req.body?.uri || "",
+      actor,
+      String(parseInt(req.body?.expectedVersion, 10) || 0)
+    );
+
+    console.log(`✅ Report hash ${report.hash} anchored for waste ${wasteId}`);
+
+    res.status(201).json({
+      success: true,
+      message: "Traceability report hash anchored on blockchain",
+      wasteId: wasteId,
+      documentHash: report.hash,
+      tenant: report.tenant,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+      report: report.pdf.toString("base64"),
+    });
+  } catch (error) {
+    console.error("❌ Error in anchorTraceabilityReport:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Export the traceability of a waste lot as a signed W3C verifiable credential
+exports.exportTraceabilityCredential = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const org = req.query.org || "farmer";
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+        details: "Credentials are issued from ledger data only",
+      });
+    }
+
+    const credential = await blockchainClient.query(
+      org,
+      "ExportTraceabilityVC",
+      wasteId
+    );
+    if (!credential) {
+      return res.status(404).json({
+        error: "Traceability data not found",
+        wasteId: wasteId,
+      });
+    }
+
+    const identity = await blockchainClient.getSigningIdentity(org);
+    if (!identity) {
+      return res.status(503).json({
+        error: "Signing identity unavailable",
+        details: `No wallet identity for organization ${org}`,
+      });
+    }
+
+    let signed;
+    try {
+      signed = signCredential(
+        typeof credential === "string" ? JSON.parse(credential) : credential,
+        { privateKey: identity.privateKey }
+      );
+    } catch (signError) {
+      console.error("❌ Credential signing failed:", signError.message);
+      return res.status(503).json({
+        error: "Signing identity unavailable",
+        details: signError.message,
+      });
+    }
+
+    console.log(`🪪 Issued traceability credential for waste ${wasteId}`);
+
+    res.setHeader("Content-Type", "application/vc+ld+json");
+    res.status(200).send(JSON.stringify(signed, null, 2));
+  } catch (error) {
+    console.error("❌ Error in exportTraceabilityCredential:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Impact report of a buyer organization (?buyer=, an MSP ID; the acting
+// organization's when omitted) over ?period= (YYYY, YYYY-Qn or YYYY-MM) or
+// ?from= and ?to=, as structured JSON or, with ?format=pdf, as a branded PDF
+exports.getImpactReport = async (req, res) => {
+  try {
+    const org = req.query.org || "farmer";
+    const format = String(req.query.format || "json").toLowerCase();
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+        details: "Impact reports are computed from ledger data only",
+      });
+    }
+
+    let period;
+    try {
+      period = resolvePeriod(req.query);
+    } catch (periodError) {
+      return res.status(400).json({
+        error: "Invalid request",
+        details: periodError.message,
+      });
+    }
+
+    const ledger = await blockchainClient.query(
+      org,
+      "ComputeImpactReport",
+      req.query.buyer || "",
+      period.from,
+      period.to
+    );
+    const tenant = tenantForOrg(org);
+    const report = buildImpactReport(
+      typeof ledger === "string" ? JSON.parse(ledger) : ledger,
+      { period, tenant }
+    );
+
+    console.log(
+      `🌱 Impact report for ${report.reportingEntity.buyer}: ` +
+        `${report.scope.lots} lot(s)`
+    );
+
+    if (format !== "pdf") {
+      return res.status(200).json({
+        success: true,
+        data: report,
+      });
+    }
+
+    const pdf = renderImpactReport(report, {
+      tenant,
+      language: tenantLanguage(tenant, req),
+    });
+    const name = [report.reportingEntity.buyer, period.label || period.from]
+      .filter(Boolean)
+      .join("-");
+    res.setHeader("Content-Type", "application/pdf");
+    res.setHeader(
+      "Content-Disposition",
+      `attachment; filename="impact-${name}.pdf"`
+    );
+    res.setHeader("X-Document-Hash", hashDocument(pdf));
+    res.setHeader("X-Tenant", tenant.id);
+    res.status(200).send(pdf);
+  } catch (error) {
+    if (/only .* or an admin/.test(error.message)) {
+      return res.status(403).json({
+        error: "Forbidden",
+        details: error.message,
+      });
+    }
+    if (/invalid date/i.test(error.message)) {
+      return res.status(400).json({
+        error: "Invalid request",
+        details: error.message,
+      });
+    }
+    console.error("❌ Error in getImpactReport:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,230 @@
+// Research Controller - participant consent and anonymized research datasets
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const {
+  exportResearchDataset,
+  getJob,
+  listJobs,
+  summarize,
+} = require("../research");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for research"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Organization whose gateway identity carries the admin role
+const ADMIN_ORG = process.env.ADMIN_ORG || "farmer";
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Grant or withdraw a participant's consent to research use of its lots
+exports.setConsent = async (req, res) => {
+  try {
+    const { granted } = req.body;
+
+    if (typeof granted !== "boolean") {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required field: granted (boolean)",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "SetResearchConsent",
+      req.params.participantId,
+      String(granted)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: granted
+        ? "Research consent granted"
+        : "Research consent withdrawn",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "setConsent", error);
+  }
+};
+
+// Current consent of a participant with its history
+exports.getConsent = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const consent = await blockchainClient.query(
+      org,
+      "GetResearchConsent",
+      req.params.participantId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: consent,
+    });
+  } catch (error) {
+    sendError(res, "getConsent", error);
+  }
+};
+
+// Start an anonymized dataset export for a requester; poll the job, then
+// download the file once it is COMPLETED
+exports.startExport = async (req, res) => {
+  try {
+    const { requester, purpose, bucketSize, minGroupSize } = req.body;
+
+    if (!requester || !purpose) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: requester, purpose",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const job = exportResearchDataset(blockchainClient, ADMIN_ORG, {
+      requester,
+      purpose,
+      bucketSize,
+      minGroupSize,
+    });
+
+    console.log(`🎓 Research dataset ${job.id} started for ${requester}`);
+
+    res.status(202).json({
+      success: true,
+      message: "Dataset export started; poll the job for its status",
+      jobId: job.id,
+      data: summarize(job),
+    });
+  } catch (error) {
+    sendError(res, "startExport", error);
+  }
+};
+
+// Exports recorded on the ledger, with the jobs still held by this server
+exports.listExports = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const records =
+      (await blockchainClient.query(ADMIN_ORG, "GetDatasetExports")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: records,
+      count: records.length,
+      jobs: listJobs(),
+    });
+  } catch (error) {
+    sendError(res, "listExports", error);
+  }
+};
+
+// Status of an export job
+exports.getExport = async (req, res) => {
+  try {
+    const job = getJob(req.params.exportId);
+    if (!job) {
+      return res.status(404).json({
+        error: "Export job not found",
+      });
+    }
+
+    res.status(200).json({
+      success: true,
+      data: summarize(job),
+    });
+  } catch (error) {
+    sendError(res, "getExport", error);
+  }
+};
+
+// Download a completed dataset; X-Content-SHA256 repeats the hash recorded
+// on the ledger so the recipient can check the file
+exports.downloadExport = async (req, res) => {
+  try {
+    const job = getJob(req.params.exportId);
+    if (!job || job.status !== "COMPLETED") {
+      return res.status(404).json({
+        error: "Dataset not available",
+        details: job ? `Export job is ${job.status}` : "Export job not found",
+      });
+    }
+
+    res.setHeader("Content-Type", "text/csv; charset=utf-8");
+    res.setHeader(
+      "Content-Disposition",
+      `attachment; filename="${job.id}.csv"`
+    );
+    res.setHeader("X-Content-SHA256", job.contentHash);
+    res.status(200).send(job.content);
+  } catch (error) {
+    sendError(res, "downloadExport", error);
+  }
+};
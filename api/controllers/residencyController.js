@@ -0,0 +1,226 @@
+// Residency Controller - regions whose participants' personal data stays on
+// their own organizations' peers, and full views of lots assembled from them
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for residency"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const CODE_PATTERN = /^[A-Z][A-Z0-9_]{0,31}$/;
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  if (/(^|: )only |is restricted to/.test(error.message)) {
+    return res.status(403).json({
+      error: "Forbidden",
+      details: error.message,
+    });
+  }
+  if (
+    /already belongs to|already pinned|participants pinned/.test(error.message)
+  ) {
+    return res.status(409).json({
+      error: "Conflict",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+const isMspList = (value) =>
+  Array.isArray(value) &&
+  value.every((msp) => typeof msp === "string" && msp.trim() !== "");
+
+// Define or change a residency region (admin identity):
+// { org, label, collection, msps, readers }. The collection must already be
+// declared in the chaincode's collections_config.json.
+exports.defineRegion = async (req, res) => {
+  try {
+    const code = req.params.code.toUpperCase();
+    const { label, collection, msps, readers = [] } = req.body;
+
+    if (
+      !CODE_PATTERN.test(code) ||
+      !collection ||
+      !isMspList(msps) ||
+      msps.length === 0 ||
+      !isMspList(readers)
+    ) {
+      return res.status(400).json({
+        error: "Invalid region",
+        details:
+          "Required: upper-case code, collection, msps (MSP IDs); optional readers",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "DefineResidencyRegion",
+      code,
+      label || "",
+      collection,
+      JSON.stringify(msps),
+      JSON.stringify(readers)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Residency region ${code} pinned to ${collection}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "defineRegion", error);
+  }
+};
+
+exports.removeRegion = async (req, res) => {
+  try {
+    const code = req.params.code.toUpperCase();
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RemoveResidencyRegion",
+      code
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Residency region ${code} removed`,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "removeRegion", error);
+  }
+};
+
+exports.getRegion = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const region = await blockchainClient.query(
+      org,
+      "ReadResidencyRegion",
+      req.params.code.toUpperCase()
+    );
+
+    res.status(200).json({
+      success: true,
+      data: region,
+    });
+  } catch (error) {
+    sendError(res, "getRegion", error);
+  }
+};
+
+exports.listRegions = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const regions =
+      (await blockchainClient.query(org, "GetResidencyRegions")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: regions,
+      count: regions.length,
+    });
+  } catch (error) {
+    sendError(res, "listRegions", error);
+  }
+};
+
+// A lot with its personal data, for its owner, admins and the readers of
+// the region it is pinned to. When the gateway peer is outside that region
+// the view comes back unresolved, naming the organizations hosting it.
+exports.getWasteView = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const view = await blockchainClient.query(
+      org,
+      "ReadWasteFullView",
+      req.params.wasteId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: view,
+      message: view?.resolved
+        ? undefined
+        : `Query a peer of ${(view?.hosts || []).join(", ")}`,
+    });
+  } catch (error) {
+    sendError(res, "getWasteView", error);
+  }
+};
@@ -0,0 +1,178 @@
+// Scorecard Controller - participant KPIs for comparing processors, cached
+// since computing them scans the ledger
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for scorecards"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+const CACHE_TTL_MS =
+  parseInt(process.env.SCORECARD_CACHE_TTL_MS, 10) || 15 * 60 * 1000;
+const CACHE_MAX_ENTRIES = 500;
+
+// "participant|from|to" -> { scorecard, cachedAt }
+const scorecards = new Map();
+
+const cachedScorecard = (key) => {
+  const cached = scorecards.get(key);
+  if (!cached || Date.now() - cached.cachedAt >= CACHE_TTL_MS) {
+    scorecards.delete(key);
+    return null;
+  }
+  return cached;
+};
+
+const cacheScorecard = (key, scorecard) => {
+  // Maps iterate in insertion order, so the first key is the oldest
+  if (scorecards.size >= CACHE_MAX_ENTRIES) {
+    scorecards.delete(scorecards.keys().next().value);
+  }
+  const entry = { scorecard, cachedAt: Date.now() };
+  scorecards.set(key, entry);
+  return entry;
+};
+
+// Validate the period and organization of a scorecard request; null when
+// invalid
+const parseRequest = (req, res) => {
+  const { from = "", to = "" } = req.query;
+  const org = req.query.org || "processor";
+
+  if ((from && !DATE_PATTERN.test(from)) || (to && !DATE_PATTERN.test(to))) {
+    res.status(400).json({
+      error: "Invalid period",
+      details: "'from' and 'to' must be YYYY-MM-DD dates",
+    });
+    return null;
+  }
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return { org, from, to, refresh: req.query.refresh === "true" };
+};
+
+// Cached scorecard of a participant, computed by the chaincode on a miss
+const loadScorecard = async ({ org, from, to, refresh }, participant) => {
+  const key = [participant, from, to].join("|");
+  const cached = refresh ? null : cachedScorecard(key);
+  if (cached) {
+    return { ...cached, cached: true };
+  }
+
+  const scorecard = await blockchainClient.query(
+    org,
+    "ComputeParticipantScorecard",
+    participant,
+    from,
+    to
+  );
+  return { ...cacheScorecard(key, scorecard), cached: false };
+};
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Scorecard of a participant (MSP ID) between from and to (YYYY-MM-DD);
+// served from cache unless ?refresh=true
+exports.getScorecard = async (req, res) => {
+  try {
+    const request = parseRequest(req, res);
+    if (!request) {
+      return;
+    }
+
+    const entry = await loadScorecard(request, req.params.participant);
+
+    const age = Math.floor((Date.now() - entry.cachedAt) / 1000);
+    const maxAge = Math.max(Math.floor(CACHE_TTL_MS / 1000) - age, 0);
+    res.set("Cache-Control", `private, max-age=${maxAge}`);
+    res.status(200).json({
+      success: true,
+      data: entry.scorecard,
+      cached: entry.cached,
+      cachedAt: new Date(entry.cachedAt).toISOString(),
+    });
+  } catch (error) {
+    sendError(res, "getScorecard", error);
+  }
+};
+
+// Scorecards of several participants side by side, best on-time delivery
+// first: ?participants=ProcessorOrgMSP,RecyclerOrgMSP
+exports.compareScorecards = async (req, res) => {
+  try {
+    const participants = String(req.query.participants || "")
+      .split(",")
+      .map((participant) => participant.trim())
+      .filter(Boolean);
+    if (participants.length === 0) {
+      return res.status(400).json({
+        error: "Missing participants",
+        details: "'participants' lists MSP IDs separated by commas",
+      });
+    }
+    const request = parseRequest(req, res);
+    if (!request) {
+      return;
+    }
+
+    const entries = await Promise.all(
+      participants.map((participant) => loadScorecard(request, participant))
+    );
+    const scorecards = entries
+      .map((entry) => entry.scorecard)
+      .sort(
+        (a, b) => (b?.onTimeDeliveryRate || 0) - (a?.onTimeDeliveryRate || 0)
+      );
+
+    res.status(200).json({
+      success: true,
+      data: scorecards,
+      count: scorecards.length,
+    });
+  } catch (error) {
+    sendError(res, "compareScorecards", error);
+  }
+};
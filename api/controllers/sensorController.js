@@ -0,0 +1,122 @@
+// Sensor Controller - temperature readings and automatic quality downgrades
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for sensors"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ASSET_TYPES = ["WASTE", "EXTRACTION"];
+
+// Organization whose gateway identity is a registered sensor gateway
+// (chaincode settings oracle.sensorIdentities / oracle.sensorMsp)
+const SENSOR_GATEWAY_ORG = process.env.SENSOR_GATEWAY_ORG || "processor";
+
+// Record a temperature reading for a waste or extraction lot
+exports.recordReading = async (req, res) => {
+  try {
+    const { assetId, sensorId, temperature, recordedAt } = req.body;
+    const assetType = String(req.body.assetType || "").toUpperCase();
+
+    if (!ASSET_TYPES.includes(assetType)) {
+      return res.status(400).json({
+        error: "Invalid asset type",
+        details: `'assetType' must be one of: ${ASSET_TYPES.join(", ")}`,
+      });
+    }
+    if (!assetId || !sensorId || temperature === undefined) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: assetId, sensorId, temperature",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      SENSOR_GATEWAY_ORG,
+      "RecordSensorReading",
+      assetType,
+      assetId,
+      sensorId,
+      String(parseFloat(temperature)),
+      recordedAt || new Date().toISOString().replace(/\.\d{3}Z$/, "Z")
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Sensor reading recorded on blockchain",
+      assetId: assetId,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in recordReading:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Readings and breach state of a lot
+exports.getSensorLog = async (req, res) => {
+  try {
+    const { assetId } = req.params;
+    const assetType = String(req.params.assetType).toUpperCase();
+
+    if (!ASSET_TYPES.includes(assetType)) {
+      return res.status(400).json({
+        error: "Invalid asset type",
+        details: `'assetType' must be one of: ${ASSET_TYPES.join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const log = await blockchainClient.query(
+      "processor",
+      "GetSensorLog",
+      assetType,
+      assetId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: log,
+    });
+  } catch (error) {
+    console.error("❌ Error in getSensorLog:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
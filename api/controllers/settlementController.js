@@ -0,0 +1,236 @@
+// Settlement Controller - two-phase token settlements paying for shares of
+// co-owned lots through the token chaincode
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for settlements"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const STATUSES = ["PREPARED", "CONFIRMED", "RELEASING", "RELEASED"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist/.test(error.message)) {
+    return res.status(404).json({
+      error: "Settlement not found",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Settlements, newest first; wasteId and status filter them
+exports.listSettlements = async (req, res) => {
+  try {
+    const { wasteId } = req.query;
+    const status = String(req.query.status || "").toUpperCase();
+
+    if (status && !STATUSES.includes(status)) {
+      return res.status(400).json({
+        error: "Invalid status",
+        details: `'status' must be one of: ${STATUSES.join(", ")}`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const settlements =
+      (await blockchainClient.query(
+        org,
+        "GetTokenSettlements",
+        wasteId || "",
+        status
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: settlements,
+      count: settlements.length,
+    });
+  } catch (error) {
+    sendError(res, "listSettlements", error);
+  }
+};
+
+exports.getSettlement = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const settlement = await blockchainClient.query(
+      org,
+      "ReadTokenSettlement",
+      req.params.settlementId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: settlement,
+    });
+  } catch (error) {
+    sendError(res, "getSettlement", error);
+  }
+};
+
+// Phase one, by the buyer: hold { amount } tokens for { percentage } of
+// { wasteId } held by { sellerId } and lock the lot until the seller confirms
+exports.prepareSettlement = async (req, res) => {
+  try {
+    const { wasteId, sellerId } = req.body;
+    const percentage = Number(req.body.percentage);
+    const amount = Number(req.body.amount);
+
+    if (!wasteId || !sellerId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: wasteId, sellerId, percentage, amount",
+      });
+    }
+    if (!(percentage > 0 && percentage <= 100) || !(amount > 0)) {
+      return res.status(400).json({
+        error: "Invalid settlement",
+        details: "'percentage' must be in (0, 100] and 'amount' positive",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "PrepareTokenSettlement",
+      wasteId,
+      sellerId,
+      String(percentage),
+      String(amount),
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+    const settlement = result?.result;
+
+    res.status(201).json({
+      success: true,
+      message: `Settlement ${settlement?.id} prepared until ${settlement?.expiresAt}`,
+      data: settlement,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "prepareSettlement", error);
+  }
+};
+
+// Phase two, by the seller: capture the held tokens and transfer the share;
+// a refused capture releases both sides instead
+exports.confirmSettlement = async (req, res) => {
+  try {
+    const { settlementId } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "ConfirmTokenSettlement",
+      settlementId
+    );
+    const settlement = result?.result;
+    const confirmed = settlement?.status === "CONFIRMED";
+
+    res.status(200).json({
+      success: true,
+      message: confirmed
+        ? `Settlement ${settlementId} confirmed; the share was transferred`
+        : `Settlement ${settlementId} is ${settlement?.status}: ${settlement?.reason}`,
+      data: settlement,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "confirmSettlement", error);
+  }
+};
+
+exports.cancelSettlement = async (req, res) => {
+  try {
+    const { settlementId } = req.params;
+    const { reason } = req.body;
+
+    if (!reason) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required field: reason",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CancelTokenSettlement",
+      settlementId,
+      reason
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Settlement ${settlementId} canceled`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "cancelSettlement", error);
+  }
+};
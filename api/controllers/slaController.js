@@ -0,0 +1,221 @@
+// SLA Controller - processing turnaround promises and their compliance
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { toCsv } = require("../import/csv");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for SLAs"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const SLA_SCOPES = ["CONTRACT", "FACILITY"];
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+const COMPLIANCE_COLUMNS = [
+  "processor",
+  "from",
+  "to",
+  "received",
+  "met",
+  "breached",
+  "pending",
+  "complianceRate",
+  "avgTurnaroundDays",
+];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Define or change the SLA of a supply contract (buyer) or facility
+// (operator): lots received are processed within maxDays
+exports.defineSla = async (req, res) => {
+  try {
+    const { scope, scopeId, maxDays } = req.body;
+
+    const normalizedScope = String(scope || "").toUpperCase();
+    if (
+      !SLA_SCOPES.includes(normalizedScope) ||
+      !scopeId ||
+      !(parseInt(maxDays, 10) > 0)
+    ) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: `Required fields: scope (${SLA_SCOPES.join(" or ")}), scopeId, maxDays (positive)`,
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "DefineSLA",
+      normalizedScope,
+      scopeId,
+      String(parseInt(maxDays, 10))
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "SLA defined on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "defineSla", error);
+  }
+};
+
+// Get the SLA of a contract or facility
+exports.getSla = async (req, res) => {
+  try {
+    const { scope, scopeId } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const sla = await blockchainClient.query(
+      org,
+      "ReadSLA",
+      scope.toUpperCase(),
+      scopeId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: sla,
+    });
+  } catch (error) {
+    sendError(res, "getSla", error);
+  }
+};
+
+// Record a lot's arrival at a facility, starting the facility's SLA clock
+exports.receiveLot = async (req, res) => {
+  try {
+    const { facilityId, wasteId } = req.body;
+
+    if (!facilityId || !wasteId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: facilityId, wasteId",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "ReceiveLot",
+      facilityId,
+      wasteId,
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Lot received at facility",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "receiveLot", error);
+  }
+};
+
+// Compliance per processor for lots received between from and to
+// (YYYY-MM-DD); ?format=csv downloads the report
+exports.getCompliance = async (req, res) => {
+  try {
+    const { processor, from, to } = req.query;
+
+    if ((from && !DATE_PATTERN.test(from)) || (to && !DATE_PATTERN.test(to))) {
+      return res.status(400).json({
+        error: "Invalid period",
+        details: "'from' and 'to' must be YYYY-MM-DD dates",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const compliance =
+      (await blockchainClient.query(
+        org,
+        "GetSLACompliance",
+        processor || "",
+        from || "",
+        to || ""
+      )) || [];
+
+    if (req.query.format === "csv") {
+      res.setHeader("Content-Type", "text/csv; charset=utf-8");
+      res.setHeader(
+        "Content-Disposition",
+        'attachment; filename="sla-compliance.csv"'
+      );
+      return res.status(200).send(toCsv(COMPLIANCE_COLUMNS, compliance));
+    }
+
+    res.status(200).json({
+      success: true,
+      data: compliance,
+      count: compliance.length,
+    });
+  } catch (error) {
+    sendError(res, "getCompliance", error);
+  }
+};
@@ -0,0 +1,214 @@
+// Snapshot Controller - Merkle digests of the world state and inclusion proofs
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { verifyInclusion } = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "snapshotProof"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+// Organization whose gateway identity carries the admin role
+const ADMIN_ORG = process.env.ADMIN_ORG || "farmer";
+
+// Chaincode cannot see block numbers, so the height of the block committing a
+// snapshot is recorded from the SNAPSHOT_COMPLETED notice of its
+// LedgerChanged event
+const SNAPSHOT_COMPLETED = "SNAPSHOT_COMPLETED";
+
+const completedSnapshots = (event) => {
+  if (event.eventName !== "LedgerChanged") {
+    return [];
+  }
+  const { notices = [] } = JSON.parse(event.payload);
+  return notices
+    .filter((notice) => notice.kind === SNAPSHOT_COMPLETED)
+    .map((notice) => notice.subject.replace(/^SNAPSHOT_/, ""));
+};
+
+const anchorCompletedSnapshots = () =>
+  blockchainClient.addContractListener(ADMIN_ORG, async (event) => {
+    try {
+      for (const snapshotId of completedSnapshots(event)) {
+        await blockchainClient.submitTransaction(
+          ADMIN_ORG,
+          "RecordSnapshotBlock",
+          snapshotId,
+          String(event.blockNumber)
+        );
+        console.log(
+          `⚓ Snapshot ${snapshotId} anchored at block ${event.blockNumber}`
+        );
+      }
+    } catch (error) {
+      console.warn("⚠️ Could not anchor snapshot:", error.message);
+    }
+  });
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for snapshots"
+    );
+    await anchorCompletedSnapshots();
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Hash the next page of keys into a snapshot (today's when no id is given)
+exports.advanceSnapshot = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const { snapshotId } = req.body;
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "SnapshotDigest",
+      snapshotId || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Snapshot advanced on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in advanceSnapshot:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List all snapshots
+exports.listSnapshots = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const snapshots =
+      (await blockchainClient.query(ADMIN_ORG, "GetSnapshots")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: snapshots,
+      count: snapshots.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in listSnapshots:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Get one snapshot
+exports.getSnapshot = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const snapshot = await blockchainClient.query(
+      ADMIN_ORG,
+      "ReadSnapshot",
+      req.params.snapshotId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: snapshot,
+    });
+  } catch (error) {
+    console.error("❌ Error in getSnapshot:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Prove that an asset key (and optionally its exact stored JSON value) was
+// part of a snapshot; the proof is checked here against the on-chain root
+exports.verifyInclusion = async (req, res) => {
+  try {
+    const { snapshotId } = req.params;
+    const key = req.body?.key || req.query.key;
+    const value = req.body?.value;
+
+    if (!key) {
+      return res.status(400).json({
+        error: "Missing key",
+        details: "The world-state key of the asset is required",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const proof = await blockchainClient.query(
+      ADMIN_ORG,
+      "GetSnapshotProof",
+      snapshotId,
+      key
+    );
+    if (!proof) {
+      return res.status(404).json({
+        error: "Proof unavailable",
+        details: `No proof for ${key} in snapshot ${snapshotId}`,
+      });
+    }
+
+    const verification = verifyInclusion(proof, value);
+
+    res.status(200).json({
+      success: true,
+      data: {
+        ...verification,
+        root: proof.root,
+        blockHeight: proof.blockHeight || null,
+        proof,
+      },
+    });
+  } catch (error) {
+    console.error("❌ Error in verifyInclusion:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,231 @@
+// Status Reason Controller - reason codes given when lots change status,
+// and how often each was used
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for status reasons"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+const CODE_PATTERN = /^[A-Z][A-Z0-9_]{0,31}$/;
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  if (/no reasons are defined/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  if (/(^|: )only /.test(error.message)) {
+    return res.status(403).json({
+      error: "Forbidden",
+      details: error.message,
+    });
+  }
+  if (/already belongs to|duplicate reason/.test(error.message)) {
+    return res.status(409).json({
+      error: "Conflict",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Define or replace the reason codes of a transition (admin identity);
+// reasons are { code, label } and a required catalog refuses the
+// transition without one of them
+exports.defineReasons = async (req, res) => {
+  try {
+    const transition = req.params.transition.toUpperCase();
+    const { reasons, required } = req.body;
+
+    if (
+      !Array.isArray(reasons) ||
+      reasons.length === 0 ||
+      reasons.some(
+        (reason) =>
+          !CODE_PATTERN.test(String(reason?.code || "").toUpperCase()) ||
+          !reason?.label
+      )
+    ) {
+      return res.status(400).json({
+        error: "Invalid reasons",
+        details:
+          "'reasons' must list { code, label } entries with upper-case codes",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "DefineStatusReasons",
+      transition,
+      String(Boolean(required)),
+      JSON.stringify(
+        reasons.map((reason) => ({
+          code: String(reason.code).toUpperCase(),
+          label: reason.label,
+        }))
+      )
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Reasons for ${transition} defined`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "defineReasons", error);
+  }
+};
+
+exports.removeReasons = async (req, res) => {
+  try {
+    const transition = req.params.transition.toUpperCase();
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RemoveStatusReasons",
+      transition
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Reasons for ${transition} removed`,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "removeReasons", error);
+  }
+};
+
+exports.getReasons = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const catalog = await blockchainClient.query(
+      org,
+      "ReadStatusReasons",
+      req.params.transition.toUpperCase()
+    );
+
+    res.status(200).json({
+      success: true,
+      data: catalog,
+    });
+  } catch (error) {
+    sendError(res, "getReasons", error);
+  }
+};
+
+exports.listReasons = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const catalogs =
+      (await blockchainClient.query(org, "GetStatusReasonCatalogs")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: catalogs,
+      count: catalogs.length,
+    });
+  } catch (error) {
+    sendError(res, "listReasons", error);
+  }
+};
+
+// Status changes between from and to (YYYY-MM-DD) counted by reason code
+exports.getStatistics = async (req, res) => {
+  try {
+    const { from = "", to = "" } = req.query;
+
+    if ((from && !DATE_PATTERN.test(from)) || (to && !DATE_PATTERN.test(to))) {
+      return res.status(400).json({
+        error: "Invalid period",
+        details: "'from' and 'to' must be YYYY-MM-DD dates",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const statistics = await blockchainClient.query(
+      org,
+      "GetStatusReasonStatistics",
+      from,
+      to
+    );
+
+    res.status(200).json({
+      success: true,
+      data: statistics,
+    });
+  } catch (error) {
+    sendError(res, "getStatistics", error);
+  }
+};
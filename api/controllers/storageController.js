@@ -0,0 +1,225 @@
+// Storage Controller - depots holding lots between collection and processing
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for storage"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Register a depot operated by the requesting organization; minTempC and
+// maxTempC make it temperature-controlled
+exports.registerSite = async (req, res) => {
+  try {
+    const { id, name, location, capacity, minTempC, maxTempC } = req.body;
+
+    if (!id || !name || !(parseFloat(capacity) > 0)) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: id, name, capacity (positive)",
+      });
+    }
+    const controlled = minTempC !== undefined && maxTempC !== undefined;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RegisterStorageSite",
+      id,
+      name,
+      location || "",
+      String(parseFloat(capacity)),
+      String(controlled),
+      String(controlled ? parseFloat(minTempC) : 0),
+      String(controlled ? parseFloat(maxTempC) : 0)
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Storage site registered on blockchain",
+      siteId: id,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "registerSite", error);
+  }
+};
+
+// Store a lot at a site; quantity defaults to the lot's remaining quantity
+exports.checkIn = async (req, res) => {
+  try {
+    const { siteId } = req.params;
+    const { wasteId, quantity } = req.body;
+
+    if (!wasteId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "The 'wasteId' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CheckIn",
+      siteId,
+      wasteId,
+      String(parseFloat(quantity) || 0),
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+    const warnings = result?.result?.warnings || [];
+    warnings.forEach((warning) => console.warn(`⚠️ ${warning}`));
+
+    res.status(200).json({
+      success: true,
+      message: "Lot checked in",
+      data: result?.result,
+      warnings,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "checkIn", error);
+  }
+};
+
+// Release a lot from a site
+exports.checkOut = async (req, res) => {
+  try {
+    const { siteId } = req.params;
+    const { wasteId } = req.body;
+
+    if (!wasteId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "The 'wasteId' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CheckOut",
+      siteId,
+      wasteId,
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: "Lot checked out",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "checkOut", error);
+  }
+};
+
+// Get one site with the lots it holds
+exports.getSite = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const site = await blockchainClient.query(
+      org,
+      "ReadStorageSite",
+      req.params.siteId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: site,
+    });
+  } catch (error) {
+    sendError(res, "getSite", error);
+  }
+};
+
+// Stock and occupancy of all active sites, fullest first (?operator=MSP ID)
+exports.getOccupancy = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const occupancy =
+      (await blockchainClient.query(
+        org,
+        "GetSiteOccupancy",
+        req.query.operator || ""
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: occupancy,
+      count: occupancy.length,
+      overCapacity: occupancy.filter((site) => site.overCapacity).length,
+    });
+  } catch (error) {
+    sendError(res, "getOccupancy", error);
+  }
+};
@@ -0,0 +1,333 @@
+// Subsidy Controller - valorization subsidy schemes defined on chain, the
+// eligibility of lots against them and claims exports for paying agencies
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { toCsv } = require("../import/csv");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for subsidies"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const CODE_PATTERN = /^[A-Z][A-Z0-9_]{0,31}$/;
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+const CLAIM_COLUMNS = [
+  "wasteId",
+  "reference",
+  "owner",
+  "ownerMsp",
+  "harvestDate",
+  "quantity",
+  "amount",
+  "evaluatedAt",
+];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  if (/(^|: )only /.test(error.message)) {
+    return res.status(403).json({
+      error: "Forbidden",
+      details: error.message,
+    });
+  }
+  if (/is retired|already retired/.test(error.message)) {
+    return res.status(409).json({
+      error: "Conflict",
+      details: error.message,
+    });
+  }
+  if (
+    /invalid |needs |duplicate condition|must (not|be)/.test(error.message)
+  ) {
+    return res.status(400).json({
+      error: "Invalid scheme",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+const isDate = (value) => value === undefined || DATE_PATTERN.test(value);
+
+// Define or change a subsidy scheme (admin identity):
+// { org, name, agency, conditions: [{ code, description, expression }],
+// ratePerUnit, currency, validFrom, validTo }. Expressions see the lot as
+// waste and what is known of its valorization as facts, e.g.
+// "facts.daysToRecycling >= 0 && facts.daysToRecycling <= 30".
+exports.defineScheme = async (req, res) => {
+  try {
+    const schemeId = req.params.schemeId.toUpperCase();
+    const {
+      name,
+      agency,
+      conditions,
+      ratePerUnit = 0,
+      currency = "",
+      validFrom,
+      validTo,
+    } = req.body;
+
+    if (
+      !CODE_PATTERN.test(schemeId) ||
+      !name ||
+      !agency ||
+      !Array.isArray(conditions) ||
+      conditions.length === 0 ||
+      typeof ratePerUnit !== "number" ||
+      ratePerUnit < 0 ||
+      !isDate(validFrom) ||
+      !isDate(validTo)
+    ) {
+      return res.status(400).json({
+        error: "Invalid scheme",
+        details:
+          "Required: upper-case scheme ID, name, agency, conditions; optional ratePerUnit, currency, validFrom, validTo (YYYY-MM-DD)",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "DefineSubsidyScheme",
+      schemeId,
+      name,
+      agency,
+      JSON.stringify(conditions),
+      String(ratePerUnit),
+      currency,
+      validFrom || "",
+      validTo || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Subsidy scheme ${schemeId} defined`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "defineScheme", error);
+  }
+};
+
+exports.retireScheme = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RetireSubsidyScheme",
+      req.params.schemeId.toUpperCase()
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Subsidy scheme ${req.params.schemeId.toUpperCase()} retired`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "retireScheme", error);
+  }
+};
+
+exports.getScheme = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const scheme = await blockchainClient.query(
+      org,
+      "ReadSubsidyScheme",
+      req.params.schemeId.toUpperCase()
+    );
+
+    res.status(200).json({
+      success: true,
+      data: scheme,
+    });
+  } catch (error) {
+    sendError(res, "getScheme", error);
+  }
+};
+
+exports.listSchemes = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const schemes =
+      (await blockchainClient.query(org, "GetSubsidySchemes")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: schemes,
+      count: schemes.length,
+    });
+  } catch (error) {
+    sendError(res, "listSchemes", error);
+  }
+};
+
+// Evaluate a lot against a scheme, flagging it eligible or not with the
+// reasons; run by the lot's owner organization
+exports.evaluateWaste = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "EvaluateSubsidyEligibility",
+      req.params.wasteId,
+      req.params.schemeId.toUpperCase()
+    );
+    const eligibility = result?.result;
+
+    res.status(200).json({
+      success: true,
+      message: eligibility?.eligible
+        ? `Waste ${req.params.wasteId} is eligible`
+        : `Waste ${req.params.wasteId} is not eligible`,
+      data: eligibility,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "evaluateWaste", error);
+  }
+};
+
+// Latest evaluations against a scheme (?eligible=true for eligible lots)
+exports.listEligibilities = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const evaluations =
+      (await blockchainClient.query(
+        org,
+        "GetSubsidyEligibilities",
+        req.params.schemeId.toUpperCase(),
+        String(req.query.eligible === "true")
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: evaluations,
+      count: evaluations.length,
+    });
+  } catch (error) {
+    sendError(res, "listEligibilities", error);
+  }
+};
+
+// Claims of a scheme for its paying agency, lots found eligible between
+// ?from=&to= (YYYY-MM-DD); ?format=csv downloads the lines
+exports.exportClaims = async (req, res) => {
+  try {
+    const { from, to } = req.query;
+    if (!isDate(from) || !isDate(to)) {
+      return res.status(400).json({
+        error: "Invalid period",
+        details: "'from' and 'to' must be YYYY-MM-DD",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const schemeId = req.params.schemeId.toUpperCase();
+    const claims = await blockchainClient.query(
+      org,
+      "ExportSubsidyClaims",
+      schemeId,
+      from || "",
+      to || ""
+    );
+    const lines = claims?.lines || [];
+
+    if (req.query.format === "csv") {
+      res.setHeader("Content-Type", "text/csv; charset=utf-8");
+      res.setHeader(
+        "Content-Disposition",
+        `attachment; filename="subsidy-claims-${schemeId}.csv"`
+      );
+      return res.status(200).send(toCsv(CLAIM_COLUMNS, lines));
+    }
+
+    res.status(200).json({
+      success: true,
+      data: claims,
+      count: lines.length,
+    });
+  } catch (error) {
+    sendError(res, "exportClaims", error);
+  }
+};
@@ -0,0 +1,370 @@
+// Supply Controller - standing supply contracts between farmers and processors
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { toCsv } = require("../import/csv");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for supply contracts"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const PRICE_KINDS = ["FIXED", "INDEX"];
+
+// Columns of the adjustments ledger export used for invoicing
+const ADJUSTMENT_COLUMNS = [
+  "createdAt",
+  "id",
+  "ruleId",
+  "kind",
+  "wasteId",
+  "extractionId",
+  "grade",
+  "daysLate",
+  "amount",
+  "currency",
+  "reason",
+];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendResult = (res, status, message, result) =>
+  res.status(status).json({
+    success: true,
+    message: message,
+    data: result?.result,
+    blockchainTxId: result?.transactionId || "pending",
+  });
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Propose a contract between a supplier and a buyer MSP, one of them the
+// requesting organization's. price: { kind: FIXED|INDEX, basePrice, premium,
+// currency }
+exports.proposeContract = async (req, res) => {
+  try {
+    const {
+      id,
+      supplier,
+      buyer,
+      productType,
+      committedVolume,
+      price,
+      startDate,
+      endDate,
+    } = req.body;
+
+    if (
+      !id ||
+      !supplier ||
+      !buyer ||
+      !productType ||
+      !(parseFloat(committedVolume) > 0) ||
+      !startDate ||
+      !endDate
+    ) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details:
+          "Required fields: id, supplier, buyer, productType, committedVolume, startDate, endDate",
+      });
+    }
+    const kind = String(price?.kind || "").toUpperCase();
+    if (!PRICE_KINDS.includes(kind) || !price.currency) {
+      return res.status(400).json({
+        error: "Invalid price formula",
+        details: `'price.kind' must be one of: ${PRICE_KINDS.join(", ")}, with 'price.currency'`,
+      });
+    }
+
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    console.log(`📑 Proposing supply contract ${id}`);
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "ProposeSupplyContract",
+      id,
+      supplier,
+      buyer,
+      productType,
+      String(parseFloat(committedVolume)),
+      kind,
+      String(parseFloat(price.basePrice) || 0),
+      String(parseFloat(price.premium) || 0),
+      price.currency,
+      startDate,
+      endDate
+    );
+
+    sendResult(res, 201, "Supply contract proposed on blockchain", result);
+  } catch (error) {
+    sendError(res, "proposeContract", error);
+  }
+};
+
+// Accept a contract proposed by the other party
+exports.acceptContract = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "AcceptSupplyContract",
+      req.params.contractId
+    );
+
+    sendResult(res, 200, "Supply contract accepted", result);
+  } catch (error) {
+    sendError(res, "acceptContract", error);
+  }
+};
+
+// Replace the bonus and penalty rules of a proposed contract; the other
+// party then has to accept it again. rules: [{ id, kind: BONUS|PENALTY,
+// trigger: GRADE|LATE, grade, maxDays, basis: PER_UNIT|PERCENT, rate, cap }]
+exports.setRules = async (req, res) => {
+  try {
+    const { rules } = req.body;
+
+    if (!Array.isArray(rules)) {
+      return res.status(400).json({
+        error: "Invalid rules",
+        details: "'rules' must be an array of adjustment rules",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "SetSupplyContractRules",
+      req.params.contractId,
+      JSON.stringify(rules)
+    );
+
+    sendResult(res, 200, "Supply contract rules proposed", result);
+  } catch (error) {
+    sendError(res, "setRules", error);
+  }
+};
+
+// Count a lot of the supplier towards the commitment; quantity defaults to
+// the whole lot
+exports.recordDelivery = async (req, res) => {
+  try {
+    const { contractId } = req.params;
+    const { wasteId, quantity } = req.body;
+
+    if (!wasteId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "The 'wasteId' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RecordContractDelivery",
+      contractId,
+      wasteId,
+      String(parseFloat(quantity) || 0)
+    );
+
+    sendResult(res, 200, "Delivery recorded against supply contract", result);
+  } catch (error) {
+    sendError(res, "recordDelivery", error);
+  }
+};
+
+// Link an extraction to the delivery of its source lot
+exports.linkExtraction = async (req, res) => {
+  try {
+    const { contractId } = req.params;
+    const { extractionId } = req.body;
+
+    if (!extractionId) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "The 'extractionId' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "LinkExtractionToContract",
+      contractId,
+      extractionId
+    );
+
+    sendResult(res, 200, "Extraction linked to supply contract", result);
+  } catch (error) {
+    sendError(res, "linkExtraction", error);
+  }
+};
+
+// Get one contract with its deliveries
+exports.getContract = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const contract = await blockchainClient.query(
+      org,
+      "ReadSupplyContract",
+      req.params.contractId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: contract,
+    });
+  } catch (error) {
+    sendError(res, "getContract", error);
+  }
+};
+
+// Contracts of the requesting organization
+exports.listContracts = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const contracts =
+      (await blockchainClient.query(org, "GetSupplyContracts")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: contracts,
+      count: contracts.length,
+    });
+  } catch (error) {
+    sendError(res, "listContracts", error);
+  }
+};
+
+// Fulfilment progress of the organization's contracts (?atRisk=true keeps
+// the ones unlikely to meet their commitment)
+exports.getCommitments = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    let statuses =
+      (await blockchainClient.query(org, "GetCommitmentStatus")) || [];
+    if (req.query.atRisk === "true") {
+      statuses = statuses.filter((status) => status.atRisk);
+    }
+
+    res.status(200).json({
+      success: true,
+      data: statuses,
+      count: statuses.length,
+    });
+  } catch (error) {
+    sendError(res, "getCommitments", error);
+  }
+};
+
+// Bonuses and penalties of a contract with their totals; ?format=csv
+// downloads the ledger for invoicing
+exports.getAdjustments = async (req, res) => {
+  try {
+    const { contractId } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const ledger = await blockchainClient.query(
+      org,
+      "GetContractAdjustments",
+      contractId
+    );
+
+    if (req.query.format === "csv") {
+      res.setHeader("Content-Type", "text/csv; charset=utf-8");
+      res.setHeader(
+        "Content-Disposition",
+        `attachment; filename="${contractId}-adjustments.csv"`
+      );
+      return res
+        .status(200)
+        .send(toCsv(ADJUSTMENT_COLUMNS, ledger?.adjustments || []));
+    }
+
+    res.status(200).json({
+      success: true,
+      data: ledger,
+    });
+  } catch (error) {
+    sendError(res, "getAdjustments", error);
+  }
+};
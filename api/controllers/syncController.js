@@ -0,0 +1,263 @@
+// Sync Controller - differential pull and offline mutation replay for
+// offline-first mobile clients
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { readModel } = require("../indexer");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for sync"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// The read model lives in memory, so cursors carry the epoch of this process;
+// a cursor from an earlier epoch triggers a full resync
+const EPOCH = Date.now().toString(36);
+
+// Applied mutations by idempotency key, kept for a day
+const IDEMPOTENCY_TTL_MS = 24 * 60 * 60 * 1000;
+const appliedMutations = new Map();
+
+const MAX_MUTATIONS_PER_BATCH = 100;
+
+// Chaincode call for each replayable offline operation
+const OPERATIONS = {
+  createWaste: (args) => ({
+    org: "farmer",
+    functionName: "CreateWaste",
+    transient: {
+      pii: {
+        owner: args.owner || "",
+        farm: args.farm || "",
+        location: args.location || "",
+      },
+    },
+    args: [
+      args.id || "",
+      args.type,
+      String(parseFloat(args.quantity)),
+      args.harvestDate,
+      "",
+      "",
+      "",
+      args.plotId || "",
+    ],
+  }),
+  updateWasteStatus: (args) => ({
+    org: "farmer",
+    functionName: "UpdateWasteStatus",
+    args: [
+      args.id,
+      args.status,
+      args.actor || "",
+      args.details || "",
+      args.reasonCode || "",
+      String(parseInt(args.expectedVersion, 10) || 0),
+    ],
+  }),
+  addTag: (args) => ({
+    org: "farmer",
+    functionName: "AddTag",
+    args: [
+      args.wasteId,
+      args.tag,
+      args.actor || "",
+      String(parseInt(args.expectedVersion, 10) || 0),
+    ],
+  }),
+  recordSensorReading: (args) => ({
+    org: process.env.SENSOR_GATEWAY_ORG || "processor",
+    functionName: "RecordSensorReading",
+    args: [
+      args.assetType,
+      args.assetId,
+      args.sensorId,
+      String(parseFloat(args.temperature)),
+      args.recordedAt,
+    ],
+  }),
+  requestCollection: (args) => ({
+    org: "farmer",
+    functionName: "RequestCollection",
+    args: [
+      args.id || "",
+      args.farm,
+      args.owner || "",
+      String(parseFloat(args.estimatedQuantity)),
+      args.windowStart,
+      args.windowEnd,
+    ],
+  }),
+};
+
+const parseCursor = (cursor) => {
+  const match = /^([0-9a-z]+)\.(\d+)$/.exec(cursor || "");
+  if (!match || match[1] !== EPOCH) {
+    return null;
+  }
+  return parseInt(match[2], 10);
+};
+
+const pruneAppliedMutations = () => {
+  const cutoff = Date.now() - IDEMPOTENCY_TTL_MS;
+  for (const [key, entry] of appliedMutations) {
+    if (entry.appliedAt < cutoff) {
+      appliedMutations.delete(key);
+    }
+  }
+};
+
+// Pull the assets changed since a cursor (all assets without one)
+exports.pullChanges = (req, res) => {
+  try {
+    const since = parseCursor(req.query.since);
+    const reset = since === null;
+    const { changed, deleted } = readModel.changesSince(reset ? -1 : since);
+
+    // The newest block may still be ingesting, so the cursor stays one block
+    // behind; clients apply assets by version and ignore repeats
+    const cursorBlock = Math.max(readModel.lastBlock - 1, 0);
+
+    res.status(200).json({
+      success: true,
+      reset,
+      cursor: `${EPOCH}.${cursorBlock}`,
+      changed,
+      deleted,
+      count: changed.length + deleted.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in pullChanges:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Replay a batch of queued offline mutations in order; mutations whose
+// idempotency key was already applied return the original result
+exports.pushMutations = async (req, res) => {
+  try {
+    const { mutations, stopOnError } = req.body;
+
+    if (!Array.isArray(mutations) || mutations.length === 0) {
+      return res.status(400).json({
+        error: "Missing mutations",
+        details: "'mutations' must be a non-empty array",
+      });
+    }
+    if (mutations.length > MAX_MUTATIONS_PER_BATCH) {
+      return res.status(413).json({
+        error: "Batch too large",
+        details: `At most ${MAX_MUTATIONS_PER_BATCH} mutations per batch`,
+      });
+    }
+    const invalid = mutations.findIndex(
+      (mutation) =>
+        !mutation.idempotencyKey || !OPERATIONS[mutation.operation]
+    );
+    if (invalid !== -1) {
+      return res.status(400).json({
+        error: "Invalid mutation",
+        details: `Mutation ${invalid} needs an idempotencyKey and one of: ${Object.keys(
+          OPERATIONS
+        ).join(", ")}`,
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    pruneAppliedMutations();
+
+    const results = [];
+    let halted = false;
+    for (const mutation of mutations) {
+      const { idempotencyKey, operation } = mutation;
+      if (halted) {
+        results.push({ idempotencyKey, status: "skipped" });
+        continue;
+      }
+
+      const applied = appliedMutations.get(idempotencyKey);
+      if (applied) {
+        results.push({
+          idempotencyKey,
+          status: "duplicate",
+          data: applied.data,
+          blockchainTxId: applied.transactionId,
+        });
+        continue;
+      }
+
+      try {
+        const call = OPERATIONS[operation](mutation.args || {});
+        const result = await blockchainClient.submitPrivateTransaction(
+          call.org,
+          call.functionName,
+          call.transient || {},
+          ...call.args
+        );
+        appliedMutations.set(idempotencyKey, {
+          data: result?.result,
+          transactionId: result?.transactionId,
+          appliedAt: Date.now(),
+        });
+        results.push({
+          idempotencyKey,
+          status: "applied",
+          data: result?.result,
+          blockchainTxId: result?.transactionId || "pending",
+        });
+      } catch (error) {
+        // Failures are not remembered so the client can retry the same key
+        results.push({
+          idempotencyKey,
+          status: "failed",
+          error: error.message,
+        });
+        halted = Boolean(stopOnError);
+      }
+    }
+
+    console.log(
+      `📲 Replayed ${results.filter((r) => r.status === "applied").length}/${
+        mutations.length
+      } offline mutations`
+    );
+
+    res.status(200).json({
+      success: results.every((result) => result.status !== "failed"),
+      results,
+    });
+  } catch (error) {
+    console.error("❌ Error in pushMutations:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
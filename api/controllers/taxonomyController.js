@@ -0,0 +1,207 @@
+// Taxonomy Controller - waste categories, subtypes and type mappings
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for taxonomy"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Organization whose gateway identity carries the admin role
+const ADMIN_ORG = process.env.ADMIN_ORG || "farmer";
+
+const requireBlockchain = (res) => {
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return false;
+  }
+  return true;
+};
+
+// Get the taxonomy with its free-text mappings
+exports.getTaxonomy = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const taxonomy = await blockchainClient.query("farmer", "GetTaxonomy");
+
+    res.status(200).json({
+      success: true,
+      data: taxonomy,
+    });
+  } catch (error) {
+    console.error("❌ Error in getTaxonomy:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Waste counts and quantities grouped by category
+exports.getCategoryStatistics = async (req, res) => {
+  try {
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const statistics =
+      (await blockchainClient.query(
+        "farmer",
+        "GetWasteStatisticsByCategory"
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: statistics,
+      count: statistics.length,
+    });
+  } catch (error) {
+    console.error("❌ Error in getCategoryStatistics:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Add or relabel a category
+exports.defineCategory = async (req, res) => {
+  try {
+    const { code, label } = req.body;
+
+    if (!code || !label) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: code, label",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "DefineWasteCategory",
+      code,
+      label
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Category ${code} saved`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in defineCategory:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Add or relabel a subtype of a category
+exports.defineSubtype = async (req, res) => {
+  try {
+    const { category } = req.params;
+    const { code, label } = req.body;
+
+    if (!code || !label) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: code, label",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "DefineWasteSubtype",
+      category,
+      code,
+      label
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Subtype ${category}/${code} saved`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in defineSubtype:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Map a legacy free-text type onto a taxonomy node (empty category unmaps it)
+exports.mapWasteType = async (req, res) => {
+  try {
+    const { wasteType, category, subtype } = req.body;
+
+    if (!wasteType) {
+      return res.status(400).json({
+        error: "Missing required fields",
+        details: "wasteType is required",
+      });
+    }
+    if (!requireBlockchain(res)) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      ADMIN_ORG,
+      "MapWasteType",
+      wasteType,
+      category || "",
+      subtype || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      message: category
+        ? `"${wasteType}" mapped to ${category}/${subtype}`
+        : `Mapping of "${wasteType}" removed`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in mapWasteType:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
@@ -0,0 +1,229 @@
+// Template Controller - lot templates participants reuse when recording the
+// same kind of waste day after day during harvest
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for templates"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendError = (res, name, error) => {
+  if (/does not exist|was erased/.test(error.message)) {
+    return res.status(404).json({
+      error: "Not found",
+      details: error.message,
+    });
+  }
+  if (/(^|: )only /.test(error.message)) {
+    return res.status(403).json({
+      error: "Forbidden",
+      details: error.message,
+    });
+  }
+  if (/already exists/.test(error.message)) {
+    return res.status(409).json({
+      error: "Conflict",
+      details: error.message,
+    });
+  }
+  if (/invalid |needs |unknown |must (not|be)|required/i.test(error.message)) {
+    return res.status(400).json({
+      error: "Invalid request",
+      details: error.message,
+    });
+  }
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// The farm and location of a template travel as transient data and are
+// kept in the participant's private collection
+const saveTemplate = async (org, participantId, name, template, pii) =>
+  blockchainClient.submitPrivateTransaction(
+    org,
+    "SaveWasteTemplate",
+    { template, ...(pii ? { pii } : {}) },
+    participantId,
+    name,
+    ""
+  );
+
+exports.listTemplates = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const templates =
+      (await blockchainClient.query(
+        org,
+        "GetWasteTemplates",
+        req.params.participantId
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: templates,
+      count: templates.length,
+    });
+  } catch (error) {
+    sendError(res, "listTemplates", error);
+  }
+};
+
+// Save a template for an owner without a participant ID yet:
+// { org, owner, name, template }. The owner is registered as a participant
+// whose ID the saved template carries.
+exports.registerTemplate = async (req, res) => {
+  try {
+    const { owner, name, template } = req.body;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await saveTemplate(org, "", name, template, { owner });
+
+    res.status(201).json({
+      success: true,
+      message: `Template ${name} saved`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "registerTemplate", error);
+  }
+};
+
+// Create or replace a template: { org, template: { type, unit,
+// defaultQuantity, farm, location, plotId, qualityGrade, composition } }
+exports.saveTemplate = async (req, res) => {
+  try {
+    const { participantId, name } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await saveTemplate(
+      org,
+      participantId,
+      name,
+      req.body.template
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Template ${name} saved`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "saveTemplate", error);
+  }
+};
+
+exports.deleteTemplate = async (req, res) => {
+  try {
+    const { participantId, name } = req.params;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "DeleteWasteTemplate",
+      participantId,
+      name
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Template ${name} deleted`,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "deleteTemplate", error);
+  }
+};
+
+// Record a lot from a template: { org, wasteId, quantity, harvestDate }, all
+// optional; quantity is in the template's unit
+exports.createWaste = async (req, res) => {
+  try {
+    const { participantId, name } = req.params;
+    const { wasteId = "", quantity = 0, harvestDate = "" } = req.body;
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CreateWasteFromTemplate",
+      participantId,
+      name,
+      wasteId,
+      String(quantity),
+      String(harvestDate).slice(0, 10)
+    );
+
+    res.status(201).json({
+      success: true,
+      message: `Waste recorded from template ${name}`,
+      data: result?.result,
+      warnings: result?.result?.warnings || [],
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    sendError(res, "createWaste", error);
+  }
+};
@@ -0,0 +1,401 @@
+// Transport Controller - vehicle and driver registry, shipments of lots
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for transport"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+const ORGANIZATIONS = ["farmer", "processor", "recycler"];
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+// Resolve which organization's gateway identity acts for the request
+const resolveOrg = (req, res) => {
+  const org = req.body?.org || req.query.org;
+  if (!ORGANIZATIONS.includes(org)) {
+    res.status(400).json({
+      error: "Invalid organization",
+      details: `'org' must be one of: ${ORGANIZATIONS.join(", ")}`,
+    });
+    return null;
+  }
+  if (!blockchainInitialized) {
+    res.status(503).json({
+      error: "Blockchain unavailable",
+    });
+    return null;
+  }
+  return org;
+};
+
+const sendResult = (res, status, message, result) =>
+  res.status(status).json({
+    success: true,
+    message: message,
+    data: result?.result,
+    blockchainTxId: result?.transactionId || "pending",
+  });
+
+const sendError = (res, name, error) => {
+  console.error(`❌ Error in ${name}:`, error);
+  res.status(500).json({
+    error: "Internal server error",
+    details: error.message,
+  });
+};
+
+// Register a truck of the organization
+exports.registerVehicle = async (req, res) => {
+  try {
+    const { id, plate, type, capacityKg, actor } = req.body;
+
+    if (!plate) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "The 'plate' field is required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RegisterVehicle",
+      id || "",
+      plate,
+      type || "",
+      String(parseFloat(capacityKg) || 0),
+      actor || org
+    );
+
+    sendResult(res, 201, "Vehicle registered on blockchain", result);
+  } catch (error) {
+    sendError(res, "registerVehicle", error);
+  }
+};
+
+// Register a driver of the organization
+exports.registerDriver = async (req, res) => {
+  try {
+    const { id, licenseNumber, licenseExpiresAt, actor } = req.body;
+
+    if (!id || !licenseNumber || !DATE_PATTERN.test(licenseExpiresAt || "")) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details:
+          "Required fields: id, licenseNumber, licenseExpiresAt (YYYY-MM-DD)",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RegisterDriver",
+      id,
+      licenseNumber,
+      licenseExpiresAt,
+      actor || org
+    );
+
+    sendResult(res, 201, "Driver registered on blockchain", result);
+  } catch (error) {
+    sendError(res, "registerDriver", error);
+  }
+};
+
+// Add or renew a permit (e.g. ADR) of a vehicle or driver
+const recordPermit = (holderType) => async (req, res) => {
+  try {
+    const { kind, number, expiresAt, actor } = req.body;
+
+    if (!kind || !number || !DATE_PATTERN.test(expiresAt || "")) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: kind, number, expiresAt (YYYY-MM-DD)",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RecordPermit",
+      holderType,
+      req.params.holderId,
+      kind,
+      number,
+      expiresAt,
+      actor || org
+    );
+
+    sendResult(res, 200, "Permit recorded on blockchain", result);
+  } catch (error) {
+    sendError(res, "recordPermit", error);
+  }
+};
+
+exports.recordVehiclePermit = recordPermit("VEHICLE");
+exports.recordDriverPermit = recordPermit("DRIVER");
+
+// Take a vehicle out of service
+exports.retireVehicle = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "RetireVehicle",
+      req.params.holderId,
+      req.body.reason || "",
+      req.body.actor || org
+    );
+
+    sendResult(res, 200, "Vehicle retired on blockchain", result);
+  } catch (error) {
+    sendError(res, "retireVehicle", error);
+  }
+};
+
+// Vehicles of the organization
+exports.listVehicles = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const vehicles = (await blockchainClient.query(org, "GetVehicles")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: vehicles,
+      count: vehicles.length,
+    });
+  } catch (error) {
+    sendError(res, "listVehicles", error);
+  }
+};
+
+// Get one vehicle or driver
+const getHolder = (functionName) => async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const holder = await blockchainClient.query(
+      org,
+      functionName,
+      req.params.holderId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: holder,
+    });
+  } catch (error) {
+    sendError(res, "getHolder", error);
+  }
+};
+
+exports.getVehicle = getHolder("ReadVehicle");
+exports.getDriver = getHolder("ReadDriver");
+
+// Dispatch (part of) a lot with a registered vehicle and driver
+exports.createShipment = async (req, res) => {
+  try {
+    const {
+      id,
+      wasteId,
+      vehicleId,
+      driverId,
+      quantity,
+      origin,
+      destination,
+      departureDate,
+      actor,
+    } = req.body;
+
+    if (
+      !wasteId ||
+      !vehicleId ||
+      !driverId ||
+      !quantity ||
+      !destination ||
+      !DATE_PATTERN.test(departureDate || "")
+    ) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details:
+          "Required fields: wasteId, vehicleId, driverId, quantity, destination, departureDate (YYYY-MM-DD)",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    console.log(`🚚 Dispatching lot ${wasteId} with vehicle ${vehicleId}`);
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "CreateShipment",
+      id || "",
+      wasteId,
+      vehicleId,
+      driverId,
+      String(parseFloat(quantity)),
+      origin || "",
+      destination,
+      departureDate,
+      actor || org
+    );
+
+    sendResult(
+      res,
+      201,
+      result?.result?.flagged
+        ? "Shipment recorded on blockchain and flagged for expired permits"
+        : "Shipment recorded on blockchain",
+      result
+    );
+  } catch (error) {
+    sendError(res, "createShipment", error);
+  }
+};
+
+// Mark a shipment as delivered where it was handed over
+// ({ latitude, longitude }), which must be within the destination's
+// geofence unless an admin gives an overrideReason
+exports.deliverShipment = async (req, res) => {
+  try {
+    const latitude = parseFloat(req.body.latitude);
+    const longitude = parseFloat(req.body.longitude);
+
+    if (!(Math.abs(latitude) <= 90) || !(Math.abs(longitude) <= 180)) {
+      return res.status(400).json({
+        error: "Invalid position",
+        details: "'latitude' and 'longitude' of the delivery are required",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      org,
+      "DeliverShipment",
+      req.params.shipmentId,
+      req.body.actor || org,
+      String(latitude),
+      String(longitude),
+      req.body.overrideReason || ""
+    );
+
+    sendResult(
+      res,
+      200,
+      result?.result?.delivery?.result === "OVERRIDDEN"
+        ? "Shipment delivered outside the geofence on override"
+        : "Shipment delivered",
+      result
+    );
+  } catch (error) {
+    if (/geofence/.test(error.message)) {
+      return res.status(422).json({
+        error: "Delivery position rejected",
+        details: error.message,
+      });
+    }
+    sendError(res, "deliverShipment", error);
+  }
+};
+
+// Get one shipment
+exports.getShipment = async (req, res) => {
+  try {
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const shipment = await blockchainClient.query(
+      org,
+      "ReadShipment",
+      req.params.shipmentId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: shipment,
+    });
+  } catch (error) {
+    sendError(res, "getShipment", error);
+  }
+};
+
+// Shipments of a lot (?wasteId=) or flagged shipments (?flagged=true)
+exports.listShipments = async (req, res) => {
+  try {
+    const { wasteId, flagged } = req.query;
+
+    if (!wasteId && flagged !== "true") {
+      return res.status(400).json({
+        error: "Missing filter",
+        details: "Pass 'wasteId' or 'flagged=true'",
+      });
+    }
+    const org = resolveOrg(req, res);
+    if (!org) {
+      return;
+    }
+
+    const shipments =
+      (wasteId
+        ? await blockchainClient.query(org, "GetShipmentsForWaste", wasteId)
+        : await blockchainClient.query(org, "GetFlaggedShipments")) || [];
+
+    res.status(200).json({
+      success: true,
+      data: shipments,
+      count: shipments.length,
+    });
+  } catch (error) {
+    sendError(res, "listShipments", error);
+  }
+};
@@ -7,6 +7,12 @@ const BlockchainClient = require(path.join(
   "blockchain",
   "enhancedClient"
 ));
+const {
+  parseListQuery,
+  paginate,
+  sendListQueryError,
+} = require("../listQuery");
+const { readModel } = require("../indexer");
 
 // Initialize enhanced blockchain client
 const blockchainClient = new BlockchainClient();
@@ -104,11 +110,26 @@ exports.addWaste = async (req, res) => {
     // Try blockchain transaction first
     if (blockchainInitialized) {
       try {
-        const result = await blockchainClient.submitTransaction(
+        // Personal data travels as transient data and is stored in the
+        // chaincode's private collection, never in the transaction arguments
+        const result = await blockchainClient.submitPrivateTransaction(
           "farmer",
           "CreateWaste",
+          {
+            pii: {
+              owner: blockchainWasteData.farmerId,
+              farm: wasteData.farm || "",
+              location: blockchainWasteData.location,
+            },
+          },
           wasteId,
-          JSON.stringify(blockchainWasteData)
+          type,
+          String(blockchainWasteData.quantity),
+          String(harvestDate).slice(0, 10),
+          "",
+          "",
+          "",
+          wasteData.plotId || ""
         );
 
         console.log("✅ Blockchain transaction successful:", result);
@@ -124,6 +145,7 @@ exports.addWaste = async (req, res) => {
           success: true,
           message: "Waste successfully recorded on blockchain",
           data: newWaste,
+          warnings: result?.result?.warnings || [],
           blockchainTxId: result?.transactionId || "pending",
           source: "blockchain",
         });
@@ -174,6 +196,8 @@ exports.addWaste = async (req, res) => {
 // Get waste list with blockchain integration
 exports.listWaste = async (req, res) => {
   try {
+    const query = parseListQuery(req, "WASTE");
+
     console.log("📋 Fetching waste list...");
 
     let wastes = [];
@@ -212,14 +236,20 @@ exports.listWaste = async (req, res) => {
       wastes = tempWastes;
     }
 
+    const { data, page } = paginate(wastes, query);
+
     res.status(200).json({
       success: true,
-      data: wastes,
-      count: wastes.length,
+      data: data,
+      count: data.length,
+      page: page,
       source: source,
       timestamp: new Date().toISOString(),
     });
   } catch (error) {
+    if (sendListQueryError(res, error)) {
+      return;
+    }
     console.error("❌ Error in listWaste:", error);
     res.status(500).json({
       error: "Internal server error",
@@ -231,7 +261,7 @@ exports.listWaste = async (req, res) => {
 // Update waste status with blockchain integration
 exports.updateWasteStatus = async (req, res) => {
   try {
-    const { wasteId, newStatus, transferData } = req.body;
+    const { wasteId, newStatus, transferData, expectedVersion } = req.body;
 
     if (!wasteId || !newStatus) {
       return res.status(400).json({
@@ -257,7 +287,10 @@ exports.updateWasteStatus = async (req, res) => {
           "UpdateWasteStatus",
           wasteId,
           newStatus,
-          JSON.stringify(updateData)
+          transferData?.actor || "farmer_001",
+          transferData?.details || "",
+          transferData?.reasonCode || "",
+          String(parseInt(expectedVersion, 10) || 0)
         );
 
         console.log("✅ Blockchain status update successful");
@@ -279,10 +312,19 @@ exports.updateWasteStatus = async (req, res) => {
           message: "Waste status updated on blockchain",
           wasteId: wasteId,
           newStatus: newStatus,
+          data: result?.result,
           blockchainTxId: result?.transactionId || "pending",
           source: "blockchain",
         });
       } catch (blockchainError) {
+        // A stale expectedVersion must not be papered over by the fallback
+        if (/\bCONFLICT:/.test(blockchainError.message)) {
+          return res.status(409).json({
+            error: "Conflict",
+            details: blockchainError.message,
+            wasteId: wasteId,
+          });
+        }
         console.error("❌ Blockchain update error:", blockchainError);
         // Fall back to temporary storage update
       }
@@ -323,6 +365,918 @@ exports.updateWasteStatus = async (req, res) => {
   }
 };
 
+// Dry-run waste creation: validate on the ledger without committing
+exports.simulateAddWaste = async (req, res) => {
+  try {
+    const { wasteData } = req.body;
+
+    if (!wasteData) {
+      return res.status(400).json({
+        error: "Missing waste data",
+        details: "The 'wasteData' field is required",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+        details: "Simulation requires a blockchain connection",
+      });
+    }
+
+    // An empty id lets the chaincode generate one
+    const wasteId = wasteData.id || "";
+
+    const simulation = await blockchainClient.query(
+      "farmer",
+      "SimulateCreateWaste",
+      wasteId,
+      wasteData.type || "",
+      String(parseFloat(wasteData.quantity) || 0),
+      wasteData.harvestDate || "",
+      req.body.farmerId || wasteData.farmerId || "farmer_001",
+      wasteData.farm || "",
+      wasteData.location || "",
+      wasteData.plotId || ""
+    );
+
+    res.status(200).json({
+      success: true,
+      committed: false,
+      data: simulation,
+      source: "blockchain",
+    });
+  } catch (error) {
+    console.error("❌ Error in simulateAddWaste:", error);
+    res.status(422).json({
+      error: "Validation failed",
+      details: error.message,
+    });
+  }
+};
+
+// Dry-run status update: validate on the ledger without committing
+exports.simulateUpdateWasteStatus = async (req, res) => {
+  try {
+    const { wasteId, newStatus, actor, details, reasonCode, expectedVersion } =
+      req.body;
+
+    if (!wasteId || !newStatus) {
+      return res.status(400).json({
+        error: "Missing required fields",
+        details: "wasteId and newStatus are required",
+      });
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+        details: "Simulation requires a blockchain connection",
+      });
+    }
+
+    const simulation = await blockchainClient.query(
+      "farmer",
+      "SimulateUpdateWasteStatus",
+      wasteId,
+      newStatus,
+      actor || "farmer_001",
+      details || "",
+      reasonCode || "",
+      String(parseInt(expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      committed: false,
+      data: simulation,
+      source: "blockchain",
+    });
+  } catch (error) {
+    console.error("❌ Error in simulateUpdateWasteStatus:", error);
+    res.status(422).json({
+      error: "Validation failed",
+      details: error.message,
+    });
+  }
+};
+
+// Tag a waste lot (e.g. "priority", "export", "contested")
+exports.addWasteTag = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const { tag, actor } = req.body;
+
+    if (!tag) {
+      return res.status(400).json({
+        error: "Missing required fields",
+        details: "tag is required",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.body.org || "farmer",
+      "AddTag",
+      wasteId,
+      tag,
+      actor || "farmer_001",
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(201).json({
+      success: true,
+      message: `Waste tagged ${tag}`,
+      wasteId: wasteId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in addWasteTag:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Remove a tag from a waste lot
+exports.removeWasteTag = async (req, res) => {
+  try {
+    const { wasteId, tag } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.query.org || "farmer",
+      "RemoveTag",
+      wasteId,
+      tag,
+      req.query.actor || "farmer_001",
+      String(parseInt(req.query.expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Tag ${tag} removed`,
+      wasteId: wasteId,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in removeWasteTag:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Get the owner, farm and location of a lot from the private collection
+exports.getWastePersonalData = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const personalData = await blockchainClient.query(
+      req.query.org || "farmer",
+      "ReadWastePersonalData",
+      wasteId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: personalData,
+    });
+  } catch (error) {
+    console.error("❌ Error in getWastePersonalData:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Split a solely owned lot between co-owners
+exports.splitOwnership = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const { shares } = req.body;
+
+    if (!Array.isArray(shares) || shares.length < 2) {
+      return res.status(400).json({
+        error: "Invalid shares",
+        details:
+          "'shares' must list at least two { holderId, holderMsp, percentage }",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.body.org || "farmer",
+      "SplitOwnership",
+      wasteId,
+      JSON.stringify(
+        shares.map((share) => ({
+          holderId: share.holderId,
+          holderMsp: share.holderMsp,
+          percentage: parseFloat(share.percentage),
+        }))
+      ),
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Waste ${wasteId} split between ${shares.length} holders`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in splitOwnership:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Transfer part of the caller's share to a buyer (signed by the seller)
+exports.transferShare = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const { percentage, buyerId, buyerMsp } = req.body;
+
+    if (!percentage || !buyerId || !buyerMsp) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "All fields are required: percentage, buyerId, buyerMsp",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.body.org || "farmer",
+      "TransferShare",
+      wasteId,
+      String(parseFloat(percentage)),
+      buyerId,
+      buyerMsp,
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    const pendingApprovalId = result?.result?.pendingApprovalId;
+    res.status(200).json({
+      success: true,
+      message: pendingApprovalId
+        ? `Transfer of ${percentage}% of waste ${wasteId} awaits approval ${pendingApprovalId}`
+        : `${percentage}% of waste ${wasteId} transferred`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in transferShare:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Record the measured moisture and oil content of a lot; extractions from it
+// then balance on dry matter when their outputs report moisture as well
+exports.setWasteComposition = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const composition = {};
+    ["moisturePct", "oilContentPct"].forEach((field) => {
+      if (req.body[field] !== undefined && req.body[field] !== "") {
+        composition[field] = parseFloat(req.body[field]);
+      }
+    });
+
+    const values = Object.values(composition);
+    if (values.length === 0 || values.some((value) => Number.isNaN(value))) {
+      return res.status(400).json({
+        error: "Invalid composition",
+        details: "Provide numeric 'moisturePct' and/or 'oilContentPct'",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.body.org || "farmer",
+      "SetWasteComposition",
+      wasteId,
+      JSON.stringify(composition),
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Composition of waste ${wasteId} recorded`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in setWasteComposition:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Hide a lot from other organizations for a number of hours; 0 lifts the
+// embargo
+exports.setWasteEmbargo = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const hours = Number(req.body.hours);
+
+    if (!Number.isInteger(hours) || hours < 0) {
+      return res.status(400).json({
+        error: "Invalid hours",
+        details: "'hours' must be a non-negative whole number",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.body.org || "farmer",
+      "SetWasteEmbargo",
+      wasteId,
+      String(hours),
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message:
+        hours === 0
+          ? `Embargo of waste ${wasteId} lifted`
+          : `Waste ${wasteId} embargoed until ${result?.result?.embargoUntil}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in setWasteEmbargo:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+const BASE64_KEY = /^[A-Za-z0-9+/]{43}=$/;
+
+// Reject a request whose sealing key is not a base64 AES-256 key; the key
+// only travels as transient data and is never logged
+const checkSealKey = (req, res) => {
+  if (!BASE64_KEY.test(req.body.key || "")) {
+    res.status(400).json({
+      error: "Invalid key",
+      details: "'key' must be a base64-encoded 32-byte AES key",
+    });
+    return false;
+  }
+  return true;
+};
+
+// Store a field the client encrypted itself (AES-256-GCM, base64 nonce and
+// ciphertext bound to "<wasteId>/<field>"); the chaincode checks the key
+// opens it and keeps only the key's fingerprint
+exports.sealWasteField = async (req, res) => {
+  try {
+    const { wasteId, field } = req.params;
+    const { ciphertext } = req.body;
+
+    if (!ciphertext) {
+      return res.status(400).json({
+        error: "Missing ciphertext",
+        details: "'ciphertext' is required",
+      });
+    }
+    if (!checkSealKey(req, res)) {
+      return;
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitPrivateTransaction(
+      req.body.org || "farmer",
+      "SealWasteField",
+      { sealKey: req.body.key },
+      wasteId,
+      field,
+      ciphertext,
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Field ${field} of waste ${wasteId} sealed`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in sealWasteField:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Decrypt a sealed field with its key; evaluated only, so the plaintext is
+// never recorded on the ledger
+exports.openSealedWasteField = async (req, res) => {
+  try {
+    const { wasteId, field } = req.params;
+
+    if (!checkSealKey(req, res)) {
+      return;
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const unsealed = await blockchainClient.queryPrivate(
+      req.body.org || "farmer",
+      "ReadSealedWasteField",
+      { sealKey: req.body.key },
+      wasteId,
+      field
+    );
+
+    res.set("Cache-Control", "no-store");
+    res.status(200).json({
+      success: true,
+      data: unsealed,
+    });
+  } catch (error) {
+    console.error("❌ Error in openSealedWasteField:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+const DONATION_REASONS = [
+  "SURPLUS",
+  "NO_BUYER",
+  "COMMUNITY",
+  "RESEARCH",
+  "SOIL_AMENDMENT",
+];
+const DISPOSAL_REASONS = [
+  "CONTAMINATED",
+  "SPOILED",
+  "NO_OUTLET",
+  "REGULATORY_ORDER",
+];
+
+const checkReasonCode = (res, reasonCode, reasons) => {
+  if (!reasons.includes(String(reasonCode || "").toUpperCase())) {
+    res.status(400).json({
+      error: "Invalid reason code",
+      details: `'reasonCode' must be one of: ${reasons.join(", ")}`,
+    });
+    return false;
+  }
+  return true;
+};
+
+// Donate what remains of a lot to a recipient outside the commercial chain
+exports.donateWaste = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const { reasonCode, recipient, notes } = req.body;
+
+    if (!checkReasonCode(res, reasonCode, DONATION_REASONS)) {
+      return;
+    }
+    if (!recipient?.name || !recipient?.kind) {
+      return res.status(400).json({
+        error: "Incomplete recipient",
+        details: "Required fields: recipient.name, recipient.kind",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.body.org || "farmer",
+      "DonateWaste",
+      wasteId,
+      reasonCode.toUpperCase(),
+      JSON.stringify(recipient),
+      notes || "",
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(201).json({
+      success: true,
+      message: `Waste ${wasteId} donated to ${recipient.name}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in donateWaste:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Send what remains of a lot to a registered landfill as a last resort
+exports.disposeWaste = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const { reasonCode, facilityId, notes } = req.body;
+
+    if (!checkReasonCode(res, reasonCode, DISPOSAL_REASONS)) {
+      return;
+    }
+    if (!facilityId) {
+      return res.status(400).json({
+        error: "Missing landfill",
+        details: "'facilityId' must name a registered LANDFILL facility",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.body.org || "farmer",
+      "DisposeWaste",
+      wasteId,
+      reasonCode.toUpperCase(),
+      facilityId,
+      notes || "",
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(201).json({
+      success: true,
+      message: `Waste ${wasteId} sent to landfill ${facilityId}`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in disposeWaste:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Get a donation or disposal record
+exports.getDisposition = async (req, res) => {
+  try {
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const disposition = await blockchainClient.query(
+      req.query.org || "farmer",
+      "ReadDisposition",
+      req.params.dispositionId
+    );
+
+    res.status(200).json({
+      success: true,
+      data: disposition,
+    });
+  } catch (error) {
+    console.error("❌ Error in getDisposition:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Donated and disposed quantities next to the recycling rate for lots
+// collected between from and to (YYYY-MM-DD)
+exports.getDispositionStatistics = async (req, res) => {
+  try {
+    const { from = "", to = "" } = req.query;
+    const datePattern = /^\d{4}-\d{2}-\d{2}$/;
+
+    if ((from && !datePattern.test(from)) || (to && !datePattern.test(to))) {
+      return res.status(400).json({
+        error: "Invalid period",
+        details: "'from' and 'to' must be YYYY-MM-DD dates",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const statistics = await blockchainClient.query(
+      req.query.org || "farmer",
+      "GetDispositionStatistics",
+      from,
+      to
+    );
+
+    res.status(200).json({
+      success: true,
+      data: statistics,
+    });
+  } catch (error) {
+    console.error("❌ Error in getDispositionStatistics:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Split a payment for a lot among its co-owners
+exports.getSettlementSplit = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const amount = parseFloat(req.query.amount);
+
+    if (!(amount >= 0)) {
+      return res.status(400).json({
+        error: "Invalid amount",
+        details: "'amount' must be a non-negative number",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const lines =
+      (await blockchainClient.query(
+        req.query.org || "farmer",
+        "GetSettlementSplit",
+        wasteId,
+        String(amount)
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      amount,
+      data: lines,
+    });
+  } catch (error) {
+    console.error("❌ Error in getSettlementSplit:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// List the waste lots carrying a tag
+exports.listWastesByTag = async (req, res) => {
+  try {
+    const { tag } = req.params;
+    const query = parseListQuery(req, "WASTE");
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const wastes =
+      (await blockchainClient.query(
+        req.query.org || "farmer",
+        "QueryWastesByTag",
+        tag
+      )) || [];
+
+    const { data, page } = paginate(wastes, query);
+
+    res.status(200).json({
+      success: true,
+      data: data,
+      count: data.length,
+      page: page,
+      tag: tag,
+    });
+  } catch (error) {
+    if (sendListQueryError(res, error)) {
+      return;
+    }
+    console.error("❌ Error in listWastesByTag:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Lots with unresolved validation warnings; code filters them (e.g.
+// FARM_MISSING)
+exports.listWastesWithWarnings = async (req, res) => {
+  try {
+    const query = parseListQuery(req, "WASTE");
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const wastes =
+      (await blockchainClient.query(
+        req.query.org || "farmer",
+        "GetWastesWithWarnings",
+        String(req.query.code || "").toUpperCase()
+      )) || [];
+
+    const { data, page } = paginate(wastes, query);
+
+    res.status(200).json({
+      success: true,
+      data: data,
+      count: data.length,
+      page: page,
+    });
+  } catch (error) {
+    if (sendListQueryError(res, error)) {
+      return;
+    }
+    console.error("❌ Error in listWastesWithWarnings:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Close an open validation warning of a lot
+exports.resolveWasteWarning = async (req, res) => {
+  try {
+    const { wasteId, code } = req.params;
+    const { resolution } = req.body;
+
+    if (!resolution) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required field: resolution",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const result = await blockchainClient.submitTransaction(
+      req.body.org || "farmer",
+      "ResolveWasteWarning",
+      wasteId,
+      code.toUpperCase(),
+      resolution,
+      String(parseInt(req.body.expectedVersion, 10) || 0)
+    );
+
+    res.status(200).json({
+      success: true,
+      message: `Warning ${code.toUpperCase()} of waste ${wasteId} resolved`,
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in resolveWasteWarning:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// State of a lot as of a past time (?at= RFC3339 or YYYY-MM-DD, the end of
+// that day in UTC). ?source=read-model answers from the indexer's versions
+// (as the indexer organization sees the lot) and falls back to the ledger
+// when they do not reach back that far.
+exports.getWasteAsOf = async (req, res) => {
+  try {
+    const { wasteId } = req.params;
+    const at = String(req.query.at || "");
+    const source = req.query.source || "ledger";
+    const isDate = /^\d{4}-\d{2}-\d{2}$/.test(at);
+
+    if (!at || Number.isNaN(Date.parse(at))) {
+      return res.status(400).json({
+        error: "Invalid time",
+        details: "'at' must be an RFC3339 timestamp or a YYYY-MM-DD date",
+      });
+    }
+    if (!["ledger", "read-model"].includes(source)) {
+      return res.status(400).json({
+        error: "Invalid source",
+        details: "source must be one of: ledger, read-model",
+      });
+    }
+
+    if (source === "read-model") {
+      const asOf = isDate ? `${at}T23:59:59.999Z` : at;
+      const version = readModel.wasteAsOf(wasteId, asOf);
+      if (version) {
+        return res.status(200).json({
+          success: true,
+          data: {
+            wasteId,
+            asOf: new Date(asOf).toISOString(),
+            waste: version.waste,
+            deleted: Boolean(version.deleted),
+            modifiedAt: version.validFrom,
+          },
+          source: "read-model",
+        });
+      }
+    }
+
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const state = await blockchainClient.query(
+      req.query.org || "farmer",
+      "GetWasteAsOf",
+      wasteId,
+      at
+    );
+
+    res.status(200).json({
+      success: true,
+      data: state,
+      source: "ledger",
+    });
+  } catch (error) {
+    console.error("❌ Error in getWasteAsOf:", error);
+    const notFound = /did not exist at/.test(error.message);
+    const erased = /WASTE_ERASED_AT/.test(error.message);
+    res.status(notFound ? 404 : erased ? 410 : 500).json({
+      error: notFound
+        ? "Waste not found at that time"
+        : erased
+        ? "Version erased"
+        : "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
 // Get waste traceability history with blockchain integration
 exports.getWasteHistory = async (req, res) => {
   try {
@@ -0,0 +1,152 @@
+// Weather Controller - oracle-reported weather observations for farms
+const path = require("path");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for weather"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+// Initialize on startup
+initializeBlockchain();
+
+// Organization whose gateway identity is designated as the weather oracle
+// (chaincode settings oracle.weatherIdentity / oracle.weatherMsp)
+const ORACLE_ORG =
+  process.env.WEATHER_ORACLE_ORG || process.env.ADMIN_ORG || "farmer";
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+// Record a weather observation for a farm (oracle feed)
+exports.recordObservation = async (req, res) => {
+  try {
+    const {
+      farm,
+      date,
+      event,
+      severity,
+      temperatureMin,
+      temperatureMax,
+      precipitationMm,
+      source,
+    } = req.body;
+
+    if (!farm || !date || !event) {
+      return res.status(400).json({
+        error: "Incomplete data",
+        details: "Required fields: farm, date (YYYY-MM-DD), event",
+      });
+    }
+    if (!DATE_PATTERN.test(date)) {
+      return res.status(400).json({
+        error: "Invalid date",
+        details: "'date' must be formatted as YYYY-MM-DD",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    console.log(`🌦️ Recording ${event} for farm ${farm} on ${date}`);
+
+    const result = await blockchainClient.submitTransaction(
+      ORACLE_ORG,
+      "RecordWeatherObservation",
+      farm,
+      date,
+      event,
+      severity || "",
+      String(parseFloat(temperatureMin) || 0),
+      String(parseFloat(temperatureMax) || 0),
+      String(parseFloat(precipitationMm) || 0),
+      source || ""
+    );
+
+    res.status(201).json({
+      success: true,
+      message: "Weather observation recorded on blockchain",
+      data: result?.result,
+      blockchainTxId: result?.transactionId || "pending",
+    });
+  } catch (error) {
+    console.error("❌ Error in recordObservation:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
+
+// Observations of a farm between two days (defaults to the last 30 days)
+exports.listObservations = async (req, res) => {
+  try {
+    const { farm } = req.query;
+    const today = new Date().toISOString().slice(0, 10);
+    const to = req.query.to || today;
+    const from =
+      req.query.from ||
+      new Date(Date.now() - 30 * 86400000).toISOString().slice(0, 10);
+
+    if (!farm) {
+      return res.status(400).json({
+        error: "Missing farm",
+        details: "The 'farm' query parameter is required",
+      });
+    }
+    if (!DATE_PATTERN.test(from) || !DATE_PATTERN.test(to)) {
+      return res.status(400).json({
+        error: "Invalid date",
+        details: "'from' and 'to' must be formatted as YYYY-MM-DD",
+      });
+    }
+    if (!blockchainInitialized) {
+      return res.status(503).json({
+        error: "Blockchain unavailable",
+      });
+    }
+
+    const observations =
+      (await blockchainClient.query(
+        ORACLE_ORG,
+        "GetWeatherObservations",
+        farm,
+        from,
+        to
+      )) || [];
+
+    res.status(200).json({
+      success: true,
+      data: observations,
+      count: observations.length,
+      farm,
+      from,
+      to,
+    });
+  } catch (error) {
+    console.error("❌ Error in listObservations:", error);
+    res.status(500).json({
+      error: "Internal server error",
+      details: error.message,
+    });
+  }
+};
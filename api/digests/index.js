@@ -0,0 +1,278 @@
+// Deadline digests - a job that scans the read model for what is about to
+// expire (agreements, supply contracts, listings, delegations, permits,
+// settlements, approvals and lot SLAs) and sends each subscribed user one
+// consolidated message about their organization's deadlines
+const crypto = require("crypto");
+const { listChannels, sendMessage } = require("../alerts");
+const { readModel, refreshDeadlines } = require("../indexer");
+
+// How often the digest runs (0 disables the schedule)
+const INTERVAL_MS =
+  process.env.DIGEST_INTERVAL_MS !== undefined
+    ? parseInt(process.env.DIGEST_INTERVAL_MS, 10) || 0
+    : 24 * 60 * 60 * 1000;
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// Days before a deadline its first reminder goes out, per kind;
+// DIGEST_LEAD_DAYS ("KIND:days,...") and each user's preferences override
+// them
+const DEFAULT_LEAD_DAYS = {
+  AGREEMENT: 30,
+  SUPPLY_CONTRACT: 30,
+  PERMIT: 30,
+  DELEGATION: 7,
+  LISTING: 2,
+  SETTLEMENT: 1,
+  APPROVAL: 2,
+  SLA: 2,
+};
+
+const KINDS = Object.keys(DEFAULT_LEAD_DAYS);
+
+const parseLeadDays = (value) =>
+  Object.fromEntries(
+    String(value || "")
+      .split(",")
+      .map((entry) => entry.split(":").map((part) => part.trim()))
+      .filter(([kind, days]) => KINDS.includes(kind) && Number(days) >= 0)
+      .map(([kind, days]) => [kind, Number(days)])
+  );
+
+const LEAD_DAYS = {
+  ...DEFAULT_LEAD_DAYS,
+  ...parseLeadDays(process.env.DIGEST_LEAD_DAYS),
+};
+
+// Digest runs kept, newest first
+const MAX_RUNS = 30;
+
+const preferences = new Map();
+const runs = [];
+// Reminders already sent, per user: "KIND:id:dueAt:STAGE"
+const sent = new Map();
+let timer = null;
+
+// Validate and normalize a user's preferences; throws on invalid input
+const buildPreferences = (userId, input, existing = {}) => {
+  const channels = input.channels ?? existing.channels ?? [];
+  const known = listChannels().map(({ channel }) => channel);
+  const leadDays = input.leadDays ?? existing.leadDays ?? {};
+  const kinds = (input.kinds ?? existing.kinds ?? []).map((kind) =>
+    String(kind).toUpperCase()
+  );
+  const preference = {
+    userId,
+    participant: String(input.participant ?? existing.participant ?? ""),
+    channels: channels.map((target) => ({
+      channel: String(target?.channel || "").toLowerCase(),
+      to: String(target?.to || "").trim(),
+    })),
+    kinds,
+    leadDays,
+    includeOverdue: (input.includeOverdue ?? existing.includeOverdue) !== false,
+    enabled: (input.enabled ?? existing.enabled) !== false,
+    createdAt: existing.createdAt || new Date().toISOString(),
+    updatedAt: new Date().toISOString(),
+  };
+
+  if (!preference.participant) {
+    throw new Error("'participant' (an organization MSP ID) is required");
+  }
+  if (preference.channels.length === 0) {
+    throw new Error("'channels' needs at least one { channel, to } target");
+  }
+  for (const { channel, to } of preference.channels) {
+    if (!known.includes(channel)) {
+      throw new Error(
+        `Unknown channel '${channel}' (expected one of: ${known.join(", ")})`
+      );
+    }
+    if (!to) {
+      throw new Error(`The ${channel} target needs a 'to' address`);
+    }
+  }
+  const unknown = [...kinds, ...Object.keys(leadDays)].find(
+    (kind) => !KINDS.includes(kind)
+  );
+  if (unknown) {
+    throw new Error(
+      `Unknown deadline kind '${unknown}' (expected one of: ${KINDS.join(", ")})`
+    );
+  }
+  if (Object.values(leadDays).some((days) => !(Number(days) >= 0))) {
+    throw new Error("'leadDays' values must be days, zero or more");
+  }
+  return preference;
+};
+
+const savePreferences = (userId, input) => {
+  const preference = buildPreferences(userId, input, preferences.get(userId));
+  preferences.set(userId, preference);
+  return preference;
+};
+
+const deletePreferences = (userId) => {
+  sent.delete(userId);
+  return preferences.delete(userId);
+};
+
+const getPreferences = (userId) => preferences.get(userId) || null;
+
+const listPreferences = ({ participant } = {}) =>
+  [...preferences.values()].filter(
+    (preference) => !participant || preference.participant === participant
+  );
+
+// Deadlines of the read model, SLAs of the indexed lots included
+const allDeadlines = () => {
+  const slas = [...readModel.wastes.values()]
+    .filter((waste) => waste.sla?.status === "PENDING" && waste.sla.dueAt)
+    .map((waste) => ({
+      kind: "SLA",
+      assetId: waste.id,
+      label: `Processing of waste ${waste.id} under SLA ${waste.sla.slaId}`,
+      dueAt: waste.sla.dueAt,
+      parties: [waste.sla.processor],
+    }));
+  return [...readModel.deadlines.values(), ...slas];
+};
+
+// Deadlines of a participant due within their lead time (or past), with
+// the stage of the reminder
+const dueFor = (participant, options = {}, now = Date.now()) => {
+  const leadDays = { ...LEAD_DAYS, ...(options.leadDays || {}) };
+  const kinds = options.kinds?.length ? options.kinds : KINDS;
+  return allDeadlines()
+    .filter(
+      (deadline) =>
+        kinds.includes(deadline.kind) &&
+        (!participant || deadline.parties.includes(participant))
+    )
+    .map((deadline) => {
+      const dueAt = Date.parse(deadline.dueAt);
+      const daysLeft = Math.ceil((dueAt - now) / DAY_MS);
+      return {
+        ...deadline,
+        daysLeft,
+        stage: dueAt < now ? "OVERDUE" : "UPCOMING",
+      };
+    })
+    .filter(
+      (deadline) =>
+        !Number.isNaN(deadline.daysLeft) &&
+        deadline.daysLeft <= leadDays[deadline.kind] &&
+        (deadline.stage === "UPCOMING" || options.includeOverdue !== false)
+    )
+    .sort((a, b) => Date.parse(a.dueAt) - Date.parse(b.dueAt));
+};
+
+const reminderKey = (deadline) =>
+  `${deadline.kind}:${deadline.assetId}:${deadline.dueAt}:${deadline.stage}`;
+
+const formatDigest = (participant, deadlines) => {
+  const lines = deadlines.map((deadline) => {
+    const when =
+      deadline.stage === "OVERDUE"
+        ? `overdue since ${deadline.dueAt.slice(0, 10)}`
+        : `due ${deadline.dueAt.slice(0, 10)} (${deadline.daysLeft} day(s))`;
+    return `- [${deadline.kind}] ${deadline.label} (${deadline.assetId}): ${when}`;
+  });
+  return {
+    title: `${deadlines.length} deadline(s) coming up for ${participant}`,
+    body: lines.join("\n"),
+  };
+};
+
+// Send every enabled user a digest of the deadlines they were not reminded
+// of at this stage yet; dryRun builds the digests without sending them or
+// remembering them
+const runDigest = async ({ dryRun = false } = {}) => {
+  const run = {
+    id: `DIGEST-${Date.now()}-${crypto.randomBytes(3).toString("hex")}`,
+    startedAt: new Date().toISOString(),
+    dryRun,
+    digests: [],
+    errors: [],
+  };
+  try {
+    await refreshDeadlines();
+  } catch (error) {
+    // Deadlines indexed earlier are still reminded of
+    run.errors.push({ error: `Deadlines not refreshed: ${error.message}` });
+  }
+
+  const now = Date.now();
+  for (const preference of preferences.values()) {
+    if (!preference.enabled) {
+      continue;
+    }
+    const reminded = sent.get(preference.userId) || new Set();
+    const deadlines = dueFor(preference.participant, preference, now).filter(
+      (deadline) => !reminded.has(reminderKey(deadline))
+    );
+    if (deadlines.length === 0) {
+      continue;
+    }
+    const digest = {
+      userId: preference.userId,
+      participant: preference.participant,
+      deadlines: deadlines.length,
+      deliveries: [],
+    };
+    if (!dryRun) {
+      const { title, body } = formatDigest(preference.participant, deadlines);
+      for (const target of preference.channels) {
+        try {
+          const delivery = sendMessage(target, "DEADLINE_DIGEST", title, body);
+          digest.deliveries.push(delivery.id);
+        } catch (error) {
+          run.errors.push({ userId: preference.userId, error: error.message });
+        }
+      }
+      deadlines.forEach((deadline) => reminded.add(reminderKey(deadline)));
+      sent.set(preference.userId, reminded);
+    }
+    run.digests.push(digest);
+  }
+
+  run.finishedAt = new Date().toISOString();
+  runs.unshift(run);
+  runs.splice(MAX_RUNS);
+  if (run.digests.length > 0) {
+    console.log(
+      `🔔 Deadline digest ${run.id}: ${run.digests.length} user(s)${
+        dryRun ? " (dry run)" : ""
+      }`
+    );
+  }
+  return run;
+};
+
+// Run the digest on its schedule
+const startDigests = () => {
+  if (timer || INTERVAL_MS <= 0) {
+    return;
+  }
+  timer = setInterval(() => {
+    runDigest().catch((error) =>
+      console.warn("⚠️ Deadline digest failed:", error.message)
+    );
+  }, INTERVAL_MS);
+  timer.unref();
+};
+
+const listRuns = () => runs;
+
+module.exports = {
+  DEADLINE_KINDS: KINDS,
+  LEAD_DAYS,
+  savePreferences,
+  deletePreferences,
+  getPreferences,
+  listPreferences,
+  dueFor,
+  runDigest,
+  startDigests,
+  listRuns,
+};
@@ -0,0 +1,109 @@
+// Document uploads - certificates, invoices and lab reports of lots kept in
+// the organization's object store, their SHA-256 anchored on the lot with
+// AttachWasteDocument once the file is there, and read back through
+// short-lived download URLs
+const { storageForOrg, storageForObject } = require("../objectStorage");
+const {
+  startUpload,
+  completeUpload,
+  getUpload,
+  summarize,
+} = require("../objectStorage/uploads");
+
+const MAX_SIZE_BYTES =
+  parseInt(process.env.DOCUMENT_MAX_SIZE_BYTES, 10) || 20 * 1024 * 1024;
+
+const DOWNLOAD_URL_TTL_SECONDS =
+  parseInt(process.env.DOCUMENT_DOWNLOAD_URL_TTL_SECONDS, 10) || 300;
+
+const DOCUMENT_TYPE_PATTERN = /^[A-Z][A-Z0-9_]{1,39}$/;
+
+// Documents are stored under their hash, once per lot
+const objectKey = (wasteId, hash) => `documents/waste/${wasteId}/${hash}`;
+
+const anchor = async (client, upload) =>
+  client.submitTransaction(
+    upload.org,
+    "AttachWasteDocument",
+    upload.wasteId,
+    upload.docType,
+    upload.hash,
+    upload.uri,
+    upload.actor,
+    String(upload.expectedVersion || 0)
+  );
+
+// Validate a document description; returns an error message or null
+const validate = (document) => {
+  if (!document.wasteId) {
+    return "'wasteId' is required";
+  }
+  if (!DOCUMENT_TYPE_PATTERN.test(document.docType)) {
+    return "'docType' must be an upper-case code such as LAB_REPORT";
+  }
+  if (!/^[0-9a-f]{64}$/.test(document.hash)) {
+    return "'hash' must be the hex SHA-256 digest of the file";
+  }
+  if (!/^[\w.+-]+\/[\w.+-]+$/.test(document.mimeType)) {
+    return "'mimeType' must be a media type such as application/pdf";
+  }
+  if (!(document.size > 0) || document.size > MAX_SIZE_BYTES) {
+    return `'size' must be between 1 and ${MAX_SIZE_BYTES} bytes`;
+  }
+  return null;
+};
+
+// Issue a pre-signed upload URL for a document of a lot in org's store; the
+// hash is anchored under org's identity once the file is there and matches
+// it. Throws when storage is not configured or the description is invalid.
+const requestUpload = (client, org, options) => {
+  const document = {
+    org,
+    wasteId: options.wasteId,
+    docType: String(options.docType || "").toUpperCase(),
+    hash: String(options.hash || "").toLowerCase(),
+    mimeType: String(options.mimeType || "").toLowerCase(),
+    size: parseInt(options.size, 10),
+    actor: options.actor || org,
+    expectedVersion: parseInt(options.expectedVersion, 10) || 0,
+  };
+  const error = validate(document);
+  if (error) {
+    throw new Error(error);
+  }
+
+  return startUpload(
+    client,
+    storageForOrg(org),
+    objectKey(document.wasteId, document.hash),
+    document,
+    anchor
+  );
+};
+
+// Download URL of an anchored document, from the store its URI points to;
+// null when the lot has no such document or it was anchored without a file
+// in any configured store
+const downloadUrl = (document, wasteId, orgs) => {
+  const key = objectKey(wasteId, document.hash);
+  const store = storageForObject(orgs, key, document.uri);
+  if (!store) {
+    return null;
+  }
+  return {
+    url: store.presignDownload(key, DOWNLOAD_URL_TTL_SECONDS),
+    provider: store.provider,
+    expiresAt: new Date(
+      Date.now() + DOWNLOAD_URL_TTL_SECONDS * 1000
+    ).toISOString(),
+  };
+};
+
+module.exports = {
+  MAX_SIZE_BYTES,
+  requestUpload,
+  completeUpload,
+  getUpload,
+  summarize,
+  downloadUrl,
+};
@@ -0,0 +1,328 @@
+// ERP connectors - map ledger events into ERP documents (deliveries of
+// collected lots, invoices of awarded listings), post them to every
+// configured ERP with retries, and reconcile what was posted against the ERP
+const crypto = require("crypto");
+const odoo = require("./odoo");
+const rest = require("./rest");
+
+const ERP_ORG = process.env.ERP_ORG || "farmer";
+const CURRENCY = process.env.ERP_CURRENCY || "EUR";
+const MAX_ATTEMPTS = parseInt(process.env.ERP_MAX_ATTEMPTS, 10) || 5;
+const RETRY_DELAY_MS = parseInt(process.env.ERP_RETRY_DELAY_MS, 10) || 60000;
+
+// Reconciliation runs kept for review
+const MAX_RECONCILIATIONS = 50;
+
+// Providers by name; registerProvider plugs in others (e.g. a native SAP
+// connector). A provider has isConfigured(), post(document) and
+// lookup(document).
+const providers = new Map([
+  ["odoo", odoo],
+  ["rest", rest],
+]);
+
+// Postings by provider and document key, and reconciliation runs, are kept
+// in memory like the other temporary stores
+const postings = new Map();
+const reconciliations = [];
+
+let blockchainClient = null;
+
+const registerProvider = (name, provider) => {
+  if (
+    ["isConfigured", "post", "lookup"].some(
+      (method) => typeof provider?.[method] !== "function"
+    )
+  ) {
+    throw new Error(
+      "An ERP provider needs isConfigured(), post() and lookup()"
+    );
+  }
+  providers.set(name, provider);
+};
+
+const listConnectors = () =>
+  [...providers.entries()].map(([name, provider]) => ({
+    provider: name,
+    configured: provider.isConfigured(),
+    listening: Boolean(blockchainClient),
+  }));
+
+const hasProvider = (name) => providers.has(name);
+
+const configuredProviders = () =>
+  [...providers.keys()].filter((name) => providers.get(name).isConfigured());
+
+const newId = (prefix) =>
+  `${prefix}-${Date.now()}-${crypto.randomBytes(3).toString("hex")}`;
+
+const round = (value, decimals) => Number(value.toFixed(decimals));
+
+const read = (fn, id) => blockchainClient.query(ERP_ORG, fn, id);
+
+// A collected lot is delivered by its farm to the cooperative
+const deliveryOf = async (wasteId) => {
+  const waste = await read("ReadWaste", wasteId);
+  return {
+    type: "delivery",
+    key: `DLV-${waste.id}`,
+    source: { assetType: "WASTE", id: waste.id },
+    partner: waste.ownerMsp || waste.owner,
+    product: waste.type,
+    quantity: waste.quantity,
+    description: `Lot ${waste.reference || waste.id}${
+      waste.farm ? ` from ${waste.farm}` : ""
+    }`,
+    date: waste.createdAt,
+  };
+};
+
+// An awarded listing is invoiced to the winning bidder at its winning price
+const invoiceOf = async (listingId) => {
+  const listing = await read("ReadListing", listingId);
+  const waste = await read("ReadWaste", listing.wasteId);
+  return {
+    type: "invoice",
+    key: `INV-${listing.id}`,
+    source: { assetType: "LISTING", id: listing.id },
+    partner: listing.winningMsp,
+    currency: CURRENCY,
+    amount: listing.winningPrice,
+    lines: [
+      {
+        description: `Lot ${waste.reference || waste.id} (listing ${
+          listing.id
+        })`,
+        product: waste.type,
+        quantity: listing.quantity,
+        unitPrice: listing.quantity
+          ? round(listing.winningPrice / listing.quantity, 4)
+          : listing.winningPrice,
+      },
+    ],
+    date: listing.updatedAt,
+  };
+};
+
+// ERP documents by the notice kind that calls for them
+const DOCUMENTS = {
+  COLLECTION_FULFILLED: { prefix: "WASTE_", build: deliveryOf },
+  LISTING_AWARDED: { prefix: "LISTING_", build: invoiceOf },
+};
+
+const documentsOf = async (event) => {
+  if (event.eventName !== "LedgerChanged") {
+    return [];
+  }
+  let data;
+  try {
+    data = JSON.parse(event.payload);
+  } catch {
+    return [];
+  }
+
+  const documents = [];
+  for (const notice of data?.notices || []) {
+    const mapping = DOCUMENTS[notice.kind];
+    if (!mapping || !String(notice.subject).startsWith(mapping.prefix)) {
+      continue;
+    }
+    const document = await mapping.build(
+      notice.subject.slice(mapping.prefix.length)
+    );
+    documents.push({ ...document, transactionId: event.transactionId });
+  }
+  return documents;
+};
+
+// Attempt a posting, retrying failures after RETRY_DELAY_MS (growing with
+// each attempt) up to MAX_ATTEMPTS
+const attempt = async (posting) => {
+  const provider = providers.get(posting.provider);
+  posting.attempts++;
+  posting.lastAttemptAt = new Date().toISOString();
+  try {
+    const result = await provider.post(posting.document);
+    posting.status = "POSTED";
+    posting.externalId = result?.externalId || "";
+    posting.postedAt = new Date().toISOString();
+    posting.error = undefined;
+  } catch (error) {
+    posting.error = error.message;
+    if (posting.attempts >= MAX_ATTEMPTS) {
+      posting.status = "FAILED";
+      console.error(
+        `❌ ERP posting ${posting.id} to ${posting.provider} failed:`,
+        error.message
+      );
+    } else {
+      setTimeout(
+        () => attempt(posting),
+        RETRY_DELAY_MS * posting.attempts
+      ).unref();
+    }
+  }
+  return posting;
+};
+
+// Queue a document for every configured provider; a document already
+// posted to a provider is not posted again
+const submit = (document) =>
+  configuredProviders().map((provider) => {
+    const key = `${provider}:${document.key}`;
+    const existing = postings.get(key);
+    if (existing && existing.status !== "FAILED") {
+      return existing;
+    }
+    const posting = {
+      id: existing?.id || newId("POSTING"),
+      provider,
+      documentType: document.type,
+      documentKey: document.key,
+      document,
+      status: "PENDING",
+      attempts: 0,
+      createdAt: existing?.createdAt || new Date().toISOString(),
+    };
+    postings.set(key, posting);
+    attempt(posting);
+    return posting;
+  });
+
+// Contract listener: post the documents an event calls for
+const handleEvent = async (event) => {
+  try {
+    for (const document of await documentsOf(event)) {
+      submit(document);
+    }
+  } catch (error) {
+    console.warn(
+      `⚠️ Could not map ${event.transactionId} to ERP documents:`,
+      error.message
+    );
+  }
+};
+
+const startConnectors = async (client) => {
+  blockchainClient = client;
+  await client.addContractListener(ERP_ORG, handleEvent);
+};
+
+const listPostings = ({ status, provider, documentType } = {}) =>
+  [...postings.values()].filter(
+    (posting) =>
+      (!status || posting.status === status) &&
+      (!provider || posting.provider === provider) &&
+      (!documentType || posting.documentType === documentType)
+  );
+
+const getPosting = (id) =>
+  [...postings.values()].find((posting) => posting.id === id) || null;
+
+// Post a failed posting again, with a fresh set of attempts
+const retryPosting = (id) => {
+  const posting = getPosting(id);
+  if (!posting || posting.status !== "FAILED") {
+    return null;
+  }
+  posting.status = "PENDING";
+  posting.attempts = 0;
+  attempt(posting);
+  return posting;
+};
+
+// Compare an ERP's copy of a posted document with the ledger's
+const reconcileOne = async (posting) => {
+  const entry = {
+    postingId: posting.id,
+    provider: posting.provider,
+    documentKey: posting.documentKey,
+  };
+  const copy = await providers.get(posting.provider).lookup(posting.document);
+
+  if (!copy) {
+    entry.result = posting.status === "POSTED" ? "MISSING" : "NOT_POSTED";
+    return entry;
+  }
+  if (posting.status !== "POSTED") {
+    posting.status = "POSTED";
+    posting.externalId = copy.externalId;
+    posting.postedAt = posting.postedAt || new Date().toISOString();
+    posting.error = undefined;
+    entry.result = "RECOVERED";
+    return entry;
+  }
+  if (
+    posting.documentType === "invoice" &&
+    copy.amount !== undefined &&
+    Math.abs(copy.amount - posting.document.amount) > 0.005
+  ) {
+    entry.result = "AMOUNT_MISMATCH";
+    entry.details = `ledger ${posting.document.amount}, ERP ${copy.amount}`;
+    return entry;
+  }
+  entry.result = "MATCHED";
+  return entry;
+};
+
+// Reconcile the postings of one provider, or of all configured providers,
+// logging the outcome of each
+const reconcile = async (provider) => {
+  const run = {
+    id: newId("RECON"),
+    provider: provider || "*",
+    startedAt: new Date().toISOString(),
+    entries: [],
+  };
+  for (const posting of postings.values()) {
+    if (
+      (provider && posting.provider !== provider) ||
+      !providers.get(posting.provider).isConfigured()
+    ) {
+      continue;
+    }
+    try {
+      run.entries.push(await reconcileOne(posting));
+    } catch (error) {
+      run.entries.push({
+        postingId: posting.id,
+        provider: posting.provider,
+        documentKey: posting.documentKey,
+        result: "ERROR",
+        details: error.message,
+      });
+    }
+  }
+  run.finishedAt = new Date().toISOString();
+  run.summary = run.entries.reduce((counts, entry) => {
+    counts[entry.result] = (counts[entry.result] || 0) + 1;
+    return counts;
+  }, {});
+
+  reconciliations.unshift(run);
+  reconciliations.splice(MAX_RECONCILIATIONS);
+  return run;
+};
+
+const listReconciliations = () =>
+  reconciliations.map(({ entries, ...run }) => ({
+    ...run,
+    checked: entries.length,
+  }));
+
+const getReconciliation = (id) =>
+  reconciliations.find((run) => run.id === id) || null;
+
+module.exports = {
+  registerProvider,
+  listConnectors,
+  hasProvider,
+  startConnectors,
+  handleEvent,
+  listPostings,
+  getPosting,
+  retryPosting,
+  reconcile,
+  listReconciliations,
+  getReconciliation,
+};
@@ -0,0 +1,196 @@
+// Odoo connector - posts deliveries as stock pickings and invoices as
+// customer invoices through Odoo's external JSON-RPC API. Documents carry
+// their key in the picking origin / invoice reference, which makes posting
+// idempotent and lets reconciliation find them again.
+const config = () => ({
+  url: (process.env.ODOO_URL || "").replace(/\/+$/, ""),
+  db: process.env.ODOO_DB,
+  username: process.env.ODOO_USERNAME,
+  // An API key can be used in place of the password
+  password: process.env.ODOO_PASSWORD,
+  pickingTypeId: parseInt(process.env.ODOO_PICKING_TYPE_ID, 10) || 0,
+});
+
+const isConfigured = () => {
+  const { url, db, username, password, pickingTypeId } = config();
+  return Boolean(url && db && username && password && pickingTypeId);
+};
+
+let requestId = 0;
+let cachedUid = null;
+
+const rpc = async (service, method, args) => {
+  const response = await fetch(`${config().url}/jsonrpc`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({
+      jsonrpc: "2.0",
+      method: "call",
+      params: { service, method, args },
+      id: ++requestId,
+    }),
+  });
+  if (!response.ok) {
+    throw new Error(`Odoo ${response.status}: ${response.statusText}`);
+  }
+  const result = await response.json();
+  if (result.error) {
+    throw new Error(
+      `Odoo: ${result.error.data?.message || result.error.message}`
+    );
+  }
+  return result.result;
+};
+
+const uid = async () => {
+  if (!cachedUid) {
+    const { db, username, password } = config();
+    cachedUid = await rpc("common", "login", [db, username, password]);
+    if (!cachedUid) {
+      throw new Error("Odoo: login refused");
+    }
+  }
+  return cachedUid;
+};
+
+// Call a model method as the configured user
+const execute = async (model, method, args, kwargs = {}) => {
+  const { db, password } = config();
+  return rpc("object", "execute_kw", [
+    db,
+    await uid(),
+    password,
+    model,
+    method,
+    args,
+    kwargs,
+  ]);
+};
+
+// Find a record by a domain, creating it with values when missing
+const findOrCreate = async (model, domain, values) => {
+  const [id] = await execute(model, "search", [domain], { limit: 1 });
+  return id || execute(model, "create", [values]);
+};
+
+// Partners are the organizations of the chain, by MSP ID
+const partner = (name) =>
+  findOrCreate("res.partner", [["ref", "=", name]], { name, ref: name });
+
+// Products are waste types and by-products, by internal reference
+const product = (code) =>
+  findOrCreate("product.product", [["default_code", "=", code]], {
+    name: code,
+    default_code: code,
+    type: "consu",
+  });
+
+const postDelivery = async (document) => {
+  const { pickingTypeId } = config();
+  const [pickingType] = await execute(
+    "stock.picking.type",
+    "read",
+    [[pickingTypeId]],
+    { fields: ["default_location_src_id", "default_location_dest_id"] }
+  );
+  const productId = await product(document.product);
+  const [{ uom_id: uom }] = await execute(
+    "product.product",
+    "read",
+    [[productId]],
+    { fields: ["uom_id"] }
+  );
+  const locations = {
+    location_id: pickingType.default_location_src_id[0],
+    location_dest_id: pickingType.default_location_dest_id[0],
+  };
+
+  return execute("stock.picking", "create", [
+    {
+      partner_id: await partner(document.partner),
+      picking_type_id: pickingTypeId,
+      origin: document.key,
+      scheduled_date: document.date.replace("T", " ").slice(0, 19),
+      ...locations,
+      move_ids_without_package: [
+        [
+          0,
+          0,
+          {
+            name: document.description,
+            product_id: productId,
+            product_uom_qty: document.quantity,
+            product_uom: uom[0],
+            ...locations,
+          },
+        ],
+      ],
+    },
+  ]);
+};
+
+const postInvoice = async (document) => {
+  const lines = await Promise.all(
+    document.lines.map(async (line) => [
+      0,
+      0,
+      {
+        name: line.description,
+        product_id: await product(line.product),
+        quantity: line.quantity,
+        price_unit: line.unitPrice,
+      },
+    ])
+  );
+
+  return execute("account.move", "create", [
+    {
+      move_type: "out_invoice",
+      partner_id: await partner(document.partner),
+      ref: document.key,
+      invoice_date: document.date.slice(0, 10),
+      invoice_line_ids: lines,
+    },
+  ]);
+};
+
+// Odoo record holding a document, as [model, field] of its key
+const RECORDS = {
+  delivery: ["stock.picking", "origin"],
+  invoice: ["account.move", "ref"],
+};
+
+// Look a document up by its key; resolves with its Odoo record or null
+const lookup = async (document) => {
+  const [model, field] = RECORDS[document.type];
+  const fields = document.type === "invoice" ? ["amount_untaxed"] : ["state"];
+  const [record] = await execute(
+    model,
+    "search_read",
+    [[[field, "=", document.key]]],
+    { fields, limit: 1 }
+  );
+  if (!record) {
+    return null;
+  }
+  return {
+    externalId: `${model}/${record.id}`,
+    amount: record.amount_untaxed,
+  };
+};
+
+// Post a document unless a previous attempt already did; resolves with
+// the Odoo record
+const post = async (document) => {
+  const existing = await lookup(document);
+  if (existing) {
+    return existing;
+  }
+  const id =
+    document.type === "invoice"
+      ? await postInvoice(document)
+      : await postDelivery(document);
+  return { externalId: `${RECORDS[document.type][0]}/${id}` };
+};
+
+module.exports = { isConfigured, post, lookup };
@@ -0,0 +1,64 @@
+// Generic REST connector - pushes documents as JSON to an ERP gateway (an
+// SAP Integration Suite iFlow, a middleware or a custom endpoint):
+//   PUT {ERP_REST_URL}/{deliveries|invoices}/{key}  to post a document
+//   GET {ERP_REST_URL}/{deliveries|invoices}/{key}  to reconcile it (404 when
+//                                                   missing)
+// Keys are stable, so retried posts overwrite rather than duplicate.
+const config = () => ({
+  url: (process.env.ERP_REST_URL || "").replace(/\/+$/, ""),
+  token: process.env.ERP_REST_TOKEN,
+});
+
+const isConfigured = () => Boolean(config().url);
+
+const COLLECTIONS = { delivery: "deliveries", invoice: "invoices" };
+
+const documentUrl = (document) =>
+  `${config().url}/${COLLECTIONS[document.type]}/${encodeURIComponent(
+    document.key
+  )}`;
+
+const headers = () => ({
+  "Content-Type": "application/json",
+  Accept: "application/json",
+  ...(config().token ? { Authorization: `Bearer ${config().token}` } : {}),
+});
+
+const failure = async (response) => {
+  const text = await response.text().catch(() => "");
+  return new Error(
+    `ERP ${response.status}: ${text.slice(0, 200) || response.statusText}`
+  );
+};
+
+// Post a document; resolves with the ERP's ID for it when it returns one
+const post = async (document) => {
+  const response = await fetch(documentUrl(document), {
+    method: "PUT",
+    headers: headers(),
+    body: JSON.stringify(document),
+  });
+  if (!response.ok) {
+    throw await failure(response);
+  }
+  const result = await response.json().catch(() => ({}));
+  return { externalId: result.id ? String(result.id) : document.key };
+};
+
+// Look a document up by its key; resolves with the ERP's copy or null
+const lookup = async (document) => {
+  const response = await fetch(documentUrl(document), { headers: headers() });
+  if (response.status === 404) {
+    return null;
+  }
+  if (!response.ok) {
+    throw await failure(response);
+  }
+  const result = await response.json().catch(() => ({}));
+  return {
+    externalId: result.id ? String(result.id) : document.key,
+    amount: result.amount,
+  };
+};
+
+module.exports = { isConfigured, post, lookup };
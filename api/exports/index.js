@@ -0,0 +1,350 @@
+// Export jobs - large ledger exports (quarter-end files of hundreds of
+// thousands of rows) written to disk in the background by a pool of
+// workers, with progress, cancelation and retention of the finished files
+const crypto = require("crypto");
+const fs = require("fs");
+const os = require("os");
+const path = require("path");
+const { csvLine } = require("../import/csv");
+const { readModel } = require("../indexer");
+
+const EXPORT_DIR =
+  process.env.EXPORT_DIR || path.join(os.tmpdir(), "olive-exports");
+const WORKERS = parseInt(process.env.EXPORT_WORKERS, 10) || 2;
+const PAGE_SIZE = parseInt(process.env.EXPORT_PAGE_SIZE, 10) || 500;
+
+// How long finished files (and their jobs) are kept
+const RETENTION_MS =
+  parseInt(process.env.EXPORT_RETENTION_MS, 10) || 24 * 60 * 60 * 1000;
+
+const FORMATS = {
+  csv: { extension: "csv", contentType: "text/csv; charset=utf-8" },
+  ndjson: { extension: "ndjson", contentType: "application/x-ndjson" },
+};
+
+const FINISHED = ["COMPLETED", "FAILED", "CANCELED", "EXPIRED"];
+
+// Exportable datasets: their columns, the date ?from= and ?to= filter on,
+// and how their rows are read from the ledger, page by page. total is an
+// estimate of the rows to scan, for progress.
+const DATASETS = {
+  wastes: {
+    columns: [
+      "id",
+      "reference",
+      "type",
+      "category",
+      "subtype",
+      "quantity",
+      "consumed",
+      "harvestDate",
+      "status",
+      "owner",
+      "ownerMsp",
+      "farm",
+      "location",
+      "plotId",
+      "region",
+      "qualityGrade",
+      "tags",
+      "createdAt",
+      "updatedAt",
+      "version",
+    ],
+    dateField: "harvestDate",
+    total: () => readModel.wastes.size || null,
+    // GetWastesPage keeps each response under the message size limits
+    async *pages(client, org) {
+      let bookmark = "";
+      do {
+        const page = await client.query(
+          org,
+          "GetWastesPage",
+          String(PAGE_SIZE),
+          bookmark
+        );
+        yield page?.wastes || [];
+        bookmark = page?.bookmark || "";
+      } while (bookmark);
+    },
+  },
+  extractions: {
+    columns: [
+      "id",
+      "reference",
+      "wasteId",
+      "productType",
+      "quantity",
+      "quality",
+      "qualityGrade",
+      "extractionDate",
+      "processor",
+      "facilityId",
+      "status",
+      "createdAt",
+      "version",
+    ],
+    dateField: "extractionDate",
+    total: () => readModel.extractions.size || null,
+    async *pages(client, org) {
+      yield* chunks((await client.query(org, "GetAllExtractions")) || []);
+    },
+  },
+  recyclings: {
+    columns: [
+      "id",
+      "reference",
+      "wasteId",
+      "extractionId",
+      "recycledProduct",
+      "quantity",
+      "method",
+      "co2eAvoided",
+      "recyclingDate",
+      "recycler",
+      "facilityId",
+      "status",
+      "createdAt",
+      "version",
+    ],
+    dateField: "recyclingDate",
+    total: () => readModel.recyclings.size || null,
+    async *pages(client, org) {
+      yield* chunks((await client.query(org, "GetAllRecyclings")) || []);
+    },
+  },
+};
+
+const jobs = new Map();
+const queue = [];
+let running = 0;
+let sweeper = null;
+
+// Split a full listing into pages, so large ones are written in steps
+function* chunks(rows) {
+  for (let i = 0; i < rows.length; i += PAGE_SIZE) {
+    yield rows.slice(i, i + PAGE_SIZE);
+  }
+}
+
+const matches = (job, row) => {
+  const { dateField } = DATASETS[job.dataset];
+  const date = String(row[dateField] || "").slice(0, 10);
+  return (
+    (!job.filters.from || date >= job.filters.from) &&
+    (!job.filters.to || date <= job.filters.to) &&
+    (!job.filters.status || row.status === job.filters.status)
+  );
+};
+
+const formatRow = (job, row) => {
+  if (job.format === "ndjson") {
+    return `${JSON.stringify(row)}\n`;
+  }
+  const { columns } = DATASETS[job.dataset];
+  return `${csvLine(
+    columns.map((column) =>
+      Array.isArray(row[column]) ? row[column].join(";") : row[column]
+    )
+  )}\r\n`;
+};
+
+// Write to the file, waiting for it to drain when its buffer is full
+const write = (stream, text) =>
+  stream.write(text)
+    ? Promise.resolve()
+    : new Promise((resolve) => stream.once("drain", resolve));
+
+const close = (stream) =>
+  new Promise((resolve, reject) => {
+    stream.once("error", reject);
+    stream.end(resolve);
+  });
+
+const updateProgress = (job) => {
+  // Never 100% before the file is complete; an estimate that turns out
+  // short stops at 99%
+  job.progress = job.total
+    ? Math.min(99, Math.floor((job.scanned / job.total) * 100))
+    : null;
+  job.updatedAt = new Date().toISOString();
+};
+
+const runJob = async (client, job) => {
+  const dataset = DATASETS[job.dataset];
+  const partPath = `${job.filePath}.part`;
+  const hash = crypto.createHash("sha256");
+  let stream;
+  try {
+    await fs.promises.mkdir(EXPORT_DIR, { recursive: true });
+    stream = fs.createWriteStream(partPath);
+    const header =
+      job.format === "csv" ? `${csvLine(dataset.columns)}\r\n` : "";
+    hash.update(header);
+    await write(stream, header);
+
+    job.total = dataset.total();
+    for await (const page of dataset.pages(client, job.org)) {
+      if (job.cancelRequested) {
+        break;
+      }
+      for (const row of page) {
+        job.scanned += 1;
+        if (!matches(job, row)) {
+          continue;
+        }
+        const line = formatRow(job, row);
+        hash.update(line);
+        await write(stream, line);
+        job.rowCount += 1;
+      }
+      updateProgress(job);
+      // Let requests through between pages
+      await new Promise((resolve) => setImmediate(resolve));
+    }
+    await close(stream);
+
+    if (job.cancelRequested) {
+      await fs.promises.rm(partPath, { force: true });
+      job.status = "CANCELED";
+    } else {
+      await fs.promises.rename(partPath, job.filePath);
+      job.size = (await fs.promises.stat(job.filePath)).size;
+      job.contentHash = hash.digest("hex");
+      job.progress = 100;
+      job.status = "COMPLETED";
+      console.log(`✅ Export ${job.id}: ${job.rowCount} ${job.dataset} rows`);
+    }
+  } catch (error) {
+    console.error(`❌ Export ${job.id} failed:`, error);
+    stream?.destroy();
+    await fs.promises.rm(partPath, { force: true }).catch(() => {});
+    job.status = "FAILED";
+    job.error = error.message;
+  }
+  job.completedAt = new Date().toISOString();
+  job.updatedAt = job.completedAt;
+  job.expiresAt = new Date(Date.now() + RETENTION_MS).toISOString();
+};
+
+// Start queued jobs while workers are free
+const drain = (client) => {
+  while (running < WORKERS && queue.length > 0) {
+    const job = queue.shift();
+    if (job.status !== "QUEUED") {
+      continue;
+    }
+    running += 1;
+    job.status = "RUNNING";
+    job.startedAt = new Date().toISOString();
+    runJob(client, job).finally(() => {
+      running -= 1;
+      drain(client);
+    });
+  }
+};
+
+// Delete the files of jobs finished longer ago than the retention period;
+// their jobs stay listed as EXPIRED for another period
+const sweep = async () => {
+  const now = Date.now();
+  for (const [id, job] of jobs) {
+    if (!job.expiresAt || Date.parse(job.expiresAt) > now) {
+      continue;
+    }
+    if (job.status === "EXPIRED") {
+      if (Date.parse(job.expiresAt) + RETENTION_MS <= now) {
+        jobs.delete(id);
+      }
+      continue;
+    }
+    await fs.promises.rm(job.filePath, { force: true }).catch(() => {});
+    job.status = "EXPIRED";
+    job.updatedAt = new Date().toISOString();
+  }
+};
+
+// Queue an export of a dataset ({ dataset, format, from, to, status }) read
+// with the gateway identity of org; returns the job to poll
+const createExport = (client, org, options) => {
+  if (!sweeper) {
+    sweeper = setInterval(
+      sweep,
+      Math.min(RETENTION_MS, 10 * 60 * 1000)
+    ).unref();
+  }
+  const format = options.format || "csv";
+  const now = new Date().toISOString();
+  const id = `EXPORT-${Date.now()}-${crypto.randomBytes(3).toString("hex")}`;
+  const job = {
+    id,
+    org,
+    dataset: options.dataset,
+    format,
+    filters: {
+      from: options.from || null,
+      to: options.to || null,
+      status: options.status || null,
+    },
+    status: "QUEUED",
+    progress: 0,
+    scanned: 0,
+    total: null,
+    rowCount: 0,
+    size: null,
+    contentHash: null,
+    filePath: path.join(EXPORT_DIR, `${id}.${FORMATS[format].extension}`),
+    createdAt: now,
+    updatedAt: now,
+    startedAt: null,
+    completedAt: null,
+    expiresAt: null,
+    cancelRequested: false,
+  };
+  jobs.set(id, job);
+  queue.push(job);
+  drain(client);
+  return job;
+};
+
+// Cancel a queued or running job; a running one stops at its next page.
+// Returns null when the job is already finished.
+const cancelExport = (id) => {
+  const job = jobs.get(id);
+  if (!job || FINISHED.includes(job.status)) {
+    return null;
+  }
+  job.cancelRequested = true;
+  if (job.status === "QUEUED") {
+    job.status = "CANCELED";
+    job.completedAt = new Date().toISOString();
+    job.expiresAt = new Date(Date.now() + RETENTION_MS).toISOString();
+  }
+  job.updatedAt = new Date().toISOString();
+  return job;
+};
+
+const getExport = (id) => jobs.get(id) || null;
+
+// A job without its server-side details, with its place in the queue
+const summarize = ({ org, filePath, cancelRequested, ...summary }) => ({
+  ...summary,
+  queuePosition:
+    summary.status === "QUEUED"
+      ? queue
+          .filter((job) => job.status === "QUEUED")
+          .findIndex((job) => job.id === summary.id) + 1
+      : undefined,
+});
+
+const listExports = () => [...jobs.values()].reverse().map(summarize);
+
+module.exports = {
+  DATASETS,
+  FORMATS,
+  createExport,
+  cancelExport,
+  getExport,
+  listExports,
+  summarize,
+};
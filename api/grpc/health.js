@@ -0,0 +1,116 @@
+// Standard grpc.health.v1 statuses of the gRPC API for load balancers and
+// grpcurl. WasteQueries, and the server as a whole (""), serve while every
+// organization has a reachable gateway peer. The read model, which answers
+// source=read-model streams, serves while the indexer runs and trails the
+// ledger by at most GRPC_MAX_READ_MODEL_LAG blocks.
+const { HealthImplementation } = require("grpc-health-check");
+const { readModel, isIndexerRunning } = require("../indexer");
+
+const SERVICES = {
+  server: "",
+  wasteQueries: "greenolivechain.v1.WasteQueries",
+  readModel: "greenolivechain.v1.ReadModel",
+};
+
+const CHECK_INTERVAL_MS =
+  parseInt(process.env.GRPC_HEALTH_INTERVAL_MS, 10) || 10 * 1000;
+
+// Blocks the read model may trail the ledger by. Blocks without events of
+// the chaincode count too, so leave room for configuration blocks.
+const MAX_READ_MODEL_LAG =
+  parseInt(process.env.GRPC_MAX_READ_MODEL_LAG, 10) || 10;
+
+const INDEXER_ORG = process.env.INDEXER_ORG || "farmer";
+
+// Last computed statuses with what they were based on
+let lastCheck = null;
+
+const fabricReachable = (blockchainClient, initialized) => {
+  if (!initialized) {
+    return false;
+  }
+  const { organizations } = blockchainClient.getPeerStatus();
+  return Object.values(organizations).every((peers) =>
+    peers.some((peer) => peer.available)
+  );
+};
+
+// Blocks between the ledger height and the last block the read model
+// ingested, or null when the height is unknown
+const readModelLag = async (blockchainClient) => {
+  const height = await blockchainClient.getBlockHeight(INDEXER_ORG);
+  if (height === null || height === undefined) {
+    return null;
+  }
+  return Math.max(0, height - 1 - readModel.lastBlock);
+};
+
+const check = async (health, blockchainClient, initialized) => {
+  const fabric = fabricReachable(blockchainClient, initialized);
+  let lag = null;
+  let lagError = null;
+  if (fabric && isIndexerRunning()) {
+    try {
+      lag = await readModelLag(blockchainClient);
+    } catch (error) {
+      lagError = error.message;
+    }
+  }
+  const readModelServing =
+    isIndexerRunning() &&
+    !lagError &&
+    (lag === null || lag <= MAX_READ_MODEL_LAG);
+
+  const statuses = {
+    [SERVICES.server]: fabric ? "SERVING" : "NOT_SERVING",
+    [SERVICES.wasteQueries]: fabric ? "SERVING" : "NOT_SERVING",
+    [SERVICES.readModel]: readModelServing ? "SERVING" : "NOT_SERVING",
+  };
+  Object.entries(statuses).forEach(([service, status]) =>
+    health.setStatus(service, status)
+  );
+  lastCheck = {
+    checkedAt: new Date().toISOString(),
+    statuses,
+    fabricReachable: fabric,
+    indexerRunning: isIndexerRunning(),
+    readModelLag: lag,
+    lagError,
+  };
+};
+
+// Add the health service to server and keep its statuses current;
+// isInitialized tells whether the blockchain client has connected
+const addHealthService = (server, blockchainClient, isInitialized) => {
+  const health = new HealthImplementation(
+    Object.fromEntries(
+      Object.values(SERVICES).map((service) => [service, "NOT_SERVING"])
+    )
+  );
+  health.addToServer(server);
+
+  let checking = false;
+  const refresh = () => {
+    if (checking) {
+      return;
+    }
+    checking = true;
+    check(health, blockchainClient, isInitialized())
+      .catch((error) =>
+        console.warn("⚠️ gRPC health check failed:", error.message)
+      )
+      .finally(() => {
+        checking = false;
+      });
+  };
+  refresh();
+  setInterval(refresh, CHECK_INTERVAL_MS).unref();
+
+  return health;
+};
+
+module.exports = {
+  addHealthService,
+  healthStatus: () => lastCheck,
+  SERVICES,
+};
@@ -0,0 +1,248 @@
+// gRPC server - server-streaming queries (see wasteQueries.proto) for
+// partners reading result sets too large for one REST response
+const path = require("path");
+const grpc = require("@grpc/grpc-js");
+const protoLoader = require("@grpc/proto-loader");
+const { ReflectionService } = require("@grpc/reflection");
+const { protoPath: healthProtoPath } = require("grpc-health-check");
+const BlockchainClient = require(path.join(
+  __dirname,
+  "..",
+  "..",
+  "blockchain",
+  "enhancedClient"
+));
+const { readModel } = require("../indexer");
+const { addHealthService } = require("./health");
+
+// Initialize enhanced blockchain client
+const blockchainClient = new BlockchainClient();
+let blockchainInitialized = false;
+
+const initializeBlockchain = async () => {
+  try {
+    await blockchainClient.initialize();
+    blockchainInitialized = true;
+    console.log(
+      "✅ Enhanced blockchain client initialized successfully for gRPC"
+    );
+  } catch (error) {
+    console.error("❌ Blockchain initialization error:", error);
+    blockchainInitialized = false;
+  }
+};
+
+const DEFAULT_PAGE_SIZE = 100;
+const MAX_PAGE_SIZE = 1000;
+const ORGS = ["farmer", "processor", "recycler"];
+
+const packageDefinition = protoLoader.loadSync(
+  path.join(__dirname, "wasteQueries.proto"),
+  { keepCase: false, longs: Number, enums: String, defaults: true }
+);
+// Every service the server exposes, health included, for reflection
+const reflectedDefinition = protoLoader.loadSync(
+  [path.join(__dirname, "wasteQueries.proto"), healthProtoPath],
+  { keepCase: true, longs: String, enums: String, defaults: true }
+);
+const { WasteQueries } =
+  grpc.loadPackageDefinition(packageDefinition).greenolivechain.v1;
+
+class StreamError extends Error {
+  constructor(code, message) {
+    super(message);
+    this.code = code;
+  }
+}
+
+const pageSizeOf = (request) =>
+  Math.min(
+    request.pageSize > 0 ? request.pageSize : DEFAULT_PAGE_SIZE,
+    MAX_PAGE_SIZE
+  );
+
+const orgOf = (request, fallback) => {
+  const org = request.org || fallback;
+  if (!ORGS.includes(org)) {
+    throw new StreamError(
+      grpc.status.INVALID_ARGUMENT,
+      `org must be one of: ${ORGS.join(", ")}`
+    );
+  }
+  return org;
+};
+
+const requireBlockchain = () => {
+  if (!blockchainInitialized) {
+    throw new StreamError(grpc.status.UNAVAILABLE, "Blockchain unavailable");
+  }
+};
+
+// Write one message, waiting for the client to drain the stream when its
+// buffer is full
+const send = (call, message) =>
+  new Promise((resolve) => {
+    if (call.write(message)) {
+      resolve();
+    } else {
+      call.once("drain", resolve);
+    }
+  });
+
+// Run a streaming handler, ending the call with a gRPC status on failure
+const streaming = (name, handler) => async (call) => {
+  try {
+    await handler(call);
+    call.end();
+  } catch (error) {
+    if (call.cancelled) {
+      return;
+    }
+    console.error(`❌ Error in ${name}:`, error);
+    call.destroy({
+      code: error.code || grpc.status.INTERNAL,
+      details: error.message,
+    });
+  }
+};
+
+const listWastesFromLedger = async (call, org, pageSize, status) => {
+  requireBlockchain();
+  let bookmark = "";
+  do {
+    const page = await blockchainClient.query(
+      org,
+      "GetWastesPage",
+      String(pageSize),
+      bookmark
+    );
+    for (const waste of page?.wastes || []) {
+      if (call.cancelled) {
+        return;
+      }
+      if (!status || waste.status === status) {
+        await send(call, { id: waste.id, json: JSON.stringify(waste) });
+      }
+    }
+    bookmark = page?.bookmark || "";
+  } while (bookmark);
+};
+
+// The read model holds the indexer's copy of the lots, so it answers
+// without a round trip to the peers but may trail the ledger slightly
+const listWastesFromReadModel = async (call, status) => {
+  for (const waste of [...readModel.store("WASTE").values()]) {
+    if (call.cancelled) {
+      return;
+    }
+    if (!status || waste.status === status) {
+      await send(call, { id: waste.id, json: JSON.stringify(waste) });
+    }
+  }
+};
+
+const listWastesStream = streaming("ListWastesStream", async (call) => {
+  const { request } = call;
+  const org = orgOf(request, "farmer");
+  const source = request.source || "ledger";
+
+  if (source === "read-model") {
+    return listWastesFromReadModel(call, request.status);
+  }
+  if (source !== "ledger") {
+    throw new StreamError(
+      grpc.status.INVALID_ARGUMENT,
+      "source must be one of: ledger, read-model"
+    );
+  }
+  return listWastesFromLedger(call, org, pageSizeOf(request), request.status);
+});
+
+const traceabilityStream = streaming("TraceabilityStream", async (call) => {
+  const { request } = call;
+  const org = orgOf(request, "recycler");
+  const { wasteId } = request;
+  if (!wasteId) {
+    throw new StreamError(grpc.status.INVALID_ARGUMENT, "wasteId is required");
+  }
+  requireBlockchain();
+
+  let waste;
+  try {
+    waste = await blockchainClient.query(org, "ReadWaste", wasteId);
+  } catch (error) {
+    if (/does not exist|NOT_FOUND/.test(error.message)) {
+      throw new StreamError(grpc.status.NOT_FOUND, error.message);
+    }
+    throw error;
+  }
+  await send(call, { kind: "WASTE", id: wasteId, json: JSON.stringify(waste) });
+
+  const pageSize = pageSizeOf(request);
+  let offset = 0;
+  while (offset >= 0 && !call.cancelled) {
+    const segment = await blockchainClient.query(
+      org,
+      "GetAssetHistorySegment",
+      "WASTE",
+      wasteId,
+      String(offset),
+      String(pageSize)
+    );
+    const entries = segment?.entries || [];
+    for (const [position, entry] of entries.entries()) {
+      await send(call, {
+        kind: "HISTORY",
+        id: wasteId,
+        index: segment.offset + position,
+        json: JSON.stringify(entry),
+      });
+    }
+    offset = entries.length > 0 ? segment.nextOffset : -1;
+  }
+
+  const [extractions, recyclings] = await Promise.all([
+    blockchainClient.query(org, "GetAllExtractions"),
+    blockchainClient.query(org, "GetAllRecyclings"),
+  ]);
+  const products = [
+    ...(extractions || []).map((item) => ["EXTRACTION", item]),
+    ...(recyclings || []).map((item) => ["RECYCLING", item]),
+  ].filter(([, item]) => item.wasteId === wasteId);
+  for (const [kind, item] of products) {
+    if (call.cancelled) {
+      return;
+    }
+    await send(call, { kind, id: item.id, json: JSON.stringify(item) });
+  }
+});
+
+// Start the gRPC server on port with the health and reflection services;
+// resolves with the bound port
+const startGrpcServer = (port) => {
+  initializeBlockchain();
+
+  const server = new grpc.Server();
+  server.addService(WasteQueries.service, {
+    listWastesStream,
+    traceabilityStream,
+  });
+  addHealthService(server, blockchainClient, () => blockchainInitialized);
+  new ReflectionService(reflectedDefinition).addToServer(server);
+
+  return new Promise((resolve, reject) => {
+    server.bindAsync(
+      `0.0.0.0:${port}`,
+      grpc.ServerCredentials.createInsecure(),
+      (error, boundPort) => {
+        if (error) {
+          return reject(error);
+        }
+        server.start();
+        resolve(boundPort);
+      }
+    );
+  });
+};
+
+module.exports = { startGrpcServer };
@@ -0,0 +1,106 @@
+// Minimal CSV reader and writer (RFC 4180: quoted fields, doubled quotes,
+// CRLF or LF line ends). Legacy ERP exports often use ";" as the delimiter,
+// which is detected from the header line.
+
+const detectDelimiter = (headerLine) => {
+  const counts = [",", ";", "\t"].map((delimiter) => ({
+    delimiter,
+    count: headerLine.split(delimiter).length,
+  }));
+  counts.sort((a, b) => b.count - a.count);
+  return counts[0].delimiter;
+};
+
+// Split CSV text into records of raw fields, each with its 1-based line
+const parseRecords = (text, delimiter) => {
+  const records = [];
+  let fields = [];
+  let field = "";
+  let quoted = false;
+  let line = 1;
+  let recordLine = 1;
+
+  const endField = () => {
+    fields.push(field);
+    field = "";
+  };
+  const endRecord = () => {
+    endField();
+    if (fields.length > 1 || fields[0] !== "") {
+      records.push({ line: recordLine, fields });
+    }
+    fields = [];
+    recordLine = line;
+  };
+
+  for (let i = 0; i < text.length; i++) {
+    const char = text[i];
+    if (quoted) {
+      if (char === '"' && text[i + 1] === '"') {
+        field += '"';
+        i++;
+      } else if (char === '"') {
+        quoted = false;
+      } else {
+        if (char === "\n") {
+          line++;
+        }
+        field += char;
+      }
+    } else if (char === '"' && field === "") {
+      quoted = true;
+    } else if (char === delimiter) {
+      endField();
+    } else if (char === "\n" || char === "\r") {
+      if (char === "\r" && text[i + 1] === "\n") {
+        i++;
+      }
+      line++;
+      endRecord();
+    } else {
+      field += char;
+    }
+  }
+  if (field !== "" || fields.length > 0) {
+    endRecord();
+  }
+  return records;
+};
+
+// Parse CSV text with a header line into { line, row } objects keyed by the
+// trimmed header names
+const parseCsv = (text) => {
+  const source = String(text || "").replace(/^\uFEFF/, "");
+  const headerLine = source.split(/\r?\n/, 1)[0] || "";
+  const delimiter = detectDelimiter(headerLine);
+  const [header, ...records] = parseRecords(source, delimiter);
+  if (!header) {
+    return { columns: [], rows: [] };
+  }
+
+  const columns = header.fields.map((name) => name.trim());
+  const rows = records.map(({ line, fields }) => {
+    const row = {};
+    columns.forEach((column, index) => {
+      row[column] = (fields[index] || "").trim();
+    });
+    return { line, row };
+  });
+  return { columns, rows };
+};
+
+const escapeField = (value) => {
+  const text = value === undefined || value === null ? "" : String(value);
+  return /[",\r\n]/.test(text) ? `"${text.replace(/"/g, '""')}"` : text;
+};
+
+// Render one record of fields as a CSV line, without its line end
+const csvLine = (fields) => fields.map(escapeField).join(",");
+
+// Render objects as CSV with the given columns
+const toCsv = (columns, items) =>
+  [columns, ...items.map((item) => columns.map((column) => item[column]))]
+    .map(csvLine)
+    .join("\r\n");
+
+module.exports = { parseCsv, toCsv, csvLine };
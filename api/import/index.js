@@ -0,0 +1,353 @@
+// Legacy ERP import - validates CSV rows against the chaincode rules, maps
+// legacy identifiers to ledger IDs and submits them in throttled batches,
+// keeping a reconciliation report per import job
+const crypto = require("crypto");
+const { parseCsv, toCsv } = require("./csv");
+
+const DEFAULT_BATCH_SIZE = parseInt(process.env.IMPORT_BATCH_SIZE, 10) || 10;
+const MAX_BATCH_SIZE = 100;
+const DEFAULT_BATCH_DELAY_MS =
+  parseInt(process.env.IMPORT_BATCH_DELAY_MS, 10) || 500;
+const DEFAULT_ID_PREFIX = "LEGACY-";
+
+// Finished jobs kept for their reports
+const MAX_JOBS = 50;
+
+// Row outcomes in the reconciliation report
+const OUTCOMES = {
+  IMPORTED: "IMPORTED",
+  VALID: "VALID", // dry run: would be imported
+  SKIPPED: "SKIPPED", // already on the ledger from an earlier import
+  REJECTED: "REJECTED",
+};
+
+const REPORT_COLUMNS = [
+  "line",
+  "legacyId",
+  "ledgerId",
+  "outcome",
+  "reason",
+  "txId",
+];
+
+const jobs = new Map();
+
+const sleep = (ms) => new Promise((resolve) => setTimeout(resolve, ms));
+
+// Legacy identifiers become ledger IDs under a fixed prefix, so re-running an
+// import finds the rows it already wrote and extractions can reference the
+// lots of an earlier waste import by their legacy ID
+const ledgerId = (legacyId, prefix) =>
+  legacyId ? `${prefix}${legacyId.replace(/[^A-Za-z0-9_.-]/g, "-")}` : "";
+
+// Accept ISO dates and the day-first formats ERP exports use
+const normalizeDate = (value) => {
+  const text = String(value || "").trim();
+  let match = text.match(/^(\d{4})[-/](\d{2})[-/](\d{2})/);
+  if (match) {
+    return `${match[1]}-${match[2]}-${match[3]}`;
+  }
+  match = text.match(/^(\d{1,2})[/.](\d{1,2})[/.](\d{4})$/);
+  if (match) {
+    const [, day, month, year] = match;
+    return `${year}-${month.padStart(2, "0")}-${day.padStart(2, "0")}`;
+  }
+  return text;
+};
+
+// Accept decimal commas ("12,5") and thousands separators ("1 250")
+const normalizeNumber = (value) => {
+  let text = String(value || "").replace(/\s/g, "");
+  if (text.includes(",") && !text.includes(".")) {
+    text = text.replace(",", ".");
+  }
+  return parseFloat(text.replace(/,/g, ""));
+};
+
+const isValidDate = (date) =>
+  /^\d{4}-\d{2}-\d{2}$/.test(date) &&
+  !Number.isNaN(new Date(`${date}T00:00:00Z`).getTime()) &&
+  new Date(`${date}T00:00:00Z`).toISOString().startsWith(date);
+
+// What each kind of import reads, checks locally (the same rules the
+// chaincode applies) and submits
+const IMPORT_KINDS = {
+  WASTE: {
+    fields: [
+      "id",
+      "type",
+      "quantity",
+      "harvestDate",
+      "owner",
+      "farm",
+      "location",
+      "plotId",
+    ],
+    required: ["id", "type", "quantity", "harvestDate"],
+    map: (fields, prefix) => ({
+      ...fields,
+      ledgerId: ledgerId(fields.id, prefix),
+      quantity: normalizeNumber(fields.quantity),
+      harvestDate: normalizeDate(fields.harvestDate),
+    }),
+    validate: (record) => {
+      if (!(record.quantity > 0)) {
+        return "quantity must be positive";
+      }
+      if (!isValidDate(record.harvestDate)) {
+        return `invalid harvest date "${record.harvestDate}"`;
+      }
+      return null;
+    },
+    simulate: (client, org, record) =>
+      client.query(
+        org,
+        "SimulateCreateWaste",
+        record.ledgerId,
+        record.type,
+        String(record.quantity),
+        record.harvestDate,
+        record.owner || "",
+        record.farm || "",
+        record.location || "",
+        record.plotId || ""
+      ),
+    // Personal data travels as transient data, as for manual entries
+    submit: (client, org, record) =>
+      client.submitPrivateTransaction(
+        org,
+        "CreateWaste",
+        {
+          pii: {
+            owner: record.owner || "",
+            farm: record.farm || "",
+            location: record.location || "",
+          },
+        },
+        record.ledgerId,
+        record.type,
+        String(record.quantity),
+        record.harvestDate,
+        "",
+        "",
+        "",
+        record.plotId || ""
+      ),
+  },
+  EXTRACTION: {
+    fields: [
+      "id",
+      "wasteId",
+      "productType",
+      "quantity",
+      "quality",
+      "processor",
+      "facilityId",
+    ],
+    required: ["id", "wasteId", "productType", "quantity"],
+    map: (fields, prefix) => ({
+      ...fields,
+      ledgerId: ledgerId(fields.id, prefix),
+      wasteLedgerId: ledgerId(fields.wasteId, prefix),
+      quantity: normalizeNumber(fields.quantity),
+    }),
+    validate: (record) =>
+      record.quantity > 0 ? null : "quantity must be positive",
+    simulate: (client, org, record) =>
+      client.query(
+        org,
+        "SimulateCreateExtraction",
+        record.ledgerId,
+        record.wasteLedgerId,
+        record.productType,
+        String(record.quantity),
+        record.quality || "",
+        record.processor || "",
+        record.facilityId || ""
+      ),
+    submit: (client, org, record) =>
+      client.submitTransaction(
+        org,
+        "CreateExtraction",
+        record.ledgerId,
+        record.wasteLedgerId,
+        record.productType,
+        String(record.quantity),
+        record.quality || "",
+        record.processor || "",
+        record.facilityId || ""
+      ),
+  },
+};
+
+// Read the mapped fields of a legacy row; columns maps field names to the
+// legacy column names when they differ
+const readFields = (row, spec, columns) => {
+  const fields = {};
+  spec.fields.forEach((field) => {
+    fields[field] = row[columns[field] || field] || "";
+  });
+  return fields;
+};
+
+const importRow = async (client, job, spec, entry, seen) => {
+  const fields = readFields(entry.row, spec, job.columns);
+  const record = spec.map(fields, job.idPrefix);
+  const result = {
+    line: entry.line,
+    legacyId: fields.id,
+    ledgerId: record.ledgerId,
+  };
+
+  const missing = spec.required.filter((field) => !fields[field]);
+  let reason = missing.length
+    ? `missing ${missing.join(", ")}`
+    : spec.validate(record);
+  if (!reason && seen.has(record.ledgerId)) {
+    reason = `duplicate of line ${seen.get(record.ledgerId)}`;
+  }
+  if (reason) {
+    return { ...result, outcome: OUTCOMES.REJECTED, reason };
+  }
+  seen.set(record.ledgerId, entry.line);
+
+  try {
+    // Chaincode validation without writing (taxonomy, campaigns, balances)
+    const simulation = await spec.simulate(client, job.org, record);
+    const warnings = simulation?.warnings || [];
+    if (job.dryRun) {
+      return {
+        ...result,
+        outcome: OUTCOMES.VALID,
+        reason: warnings.join("; "),
+      };
+    }
+
+    const submitted = await spec.submit(client, job.org, record);
+    return {
+      ...result,
+      outcome: OUTCOMES.IMPORTED,
+      reason: warnings.join("; "),
+      txId: submitted?.transactionId || "",
+    };
+  } catch (error) {
+    if (/ALREADY_EXISTS/.test(error.message)) {
+      return {
+        ...result,
+        outcome: OUTCOMES.SKIPPED,
+        reason: "already on the ledger",
+      };
+    }
+    return { ...result, outcome: OUTCOMES.REJECTED, reason: error.message };
+  }
+};
+
+const runJob = async (client, job, rows) => {
+  const spec = IMPORT_KINDS[job.kind];
+  const seen = new Map();
+  try {
+    for (let start = 0; start < rows.length; start += job.batchSize) {
+      const batch = rows.slice(start, start + job.batchSize);
+      const results = await Promise.all(
+        batch.map((entry) => importRow(client, job, spec, entry, seen))
+      );
+      results.forEach((result) => {
+        job.rows.push(result);
+        job.totals[result.outcome.toLowerCase()]++;
+      });
+      job.totals.processed += results.length;
+      job.updatedAt = new Date().toISOString();
+
+      if (start + job.batchSize < rows.length) {
+        await sleep(job.delayMs);
+      }
+    }
+    job.status = "COMPLETED";
+    console.log(
+      `✅ Import ${job.id}: ${job.totals.imported} imported, ${job.totals.skipped} skipped, ${job.totals.rejected} rejected`
+    );
+  } catch (error) {
+    console.error(`❌ Import ${job.id} failed:`, error);
+    job.status = "FAILED";
+    job.error = error.message;
+  }
+  job.rows.sort((a, b) => a.line - b.line);
+  job.completedAt = new Date().toISOString();
+};
+
+const forgetOldJobs = () => {
+  for (const [id, job] of jobs) {
+    if (jobs.size < MAX_JOBS) {
+      break;
+    }
+    if (job.status !== "RUNNING") {
+      jobs.delete(id);
+    }
+  }
+};
+
+// Start an import job over CSV text and return it; rows are processed in the
+// background. Throws on an unknown kind or a file without rows.
+const startImport = (client, kind, csvText, options = {}) => {
+  const spec = IMPORT_KINDS[kind];
+  if (!spec) {
+    throw new Error(`Unsupported import kind ${kind}`);
+  }
+  const { rows } = parseCsv(csvText);
+  if (rows.length === 0) {
+    throw new Error("The CSV file has no data rows");
+  }
+
+  const delayMs = parseInt(options.delayMs, 10);
+
+  forgetOldJobs();
+  const job = {
+    id: `IMPORT-${Date.now()}-${crypto.randomBytes(3).toString("hex")}`,
+    kind,
+    org: options.org,
+    dryRun: Boolean(options.dryRun),
+    idPrefix: options.idPrefix ?? DEFAULT_ID_PREFIX,
+    columns: options.columns || {},
+    batchSize: Math.min(
+      parseInt(options.batchSize, 10) || DEFAULT_BATCH_SIZE,
+      MAX_BATCH_SIZE
+    ),
+    delayMs: Number.isNaN(delayMs)
+      ? DEFAULT_BATCH_DELAY_MS
+      : Math.max(delayMs, 0),
+    status: "RUNNING",
+    startedAt: new Date().toISOString(),
+    updatedAt: null,
+    completedAt: null,
+    totals: {
+      rows: rows.length,
+      processed: 0,
+      imported: 0,
+      valid: 0,
+      skipped: 0,
+      rejected: 0,
+    },
+    rows: [],
+  };
+  jobs.set(job.id, job);
+
+  runJob(client, job, rows);
+  return job;
+};
+
+const getJob = (id) => jobs.get(id) || null;
+
+const listJobs = () =>
+  [...jobs.values()].map(({ rows, columns, ...summary }) => summary);
+
+// Reconciliation report of a job as CSV
+const reportCsv = (job) => toCsv(REPORT_COLUMNS, job.rows);
+
+module.exports = {
+  IMPORT_KINDS,
+  OUTCOMES,
+  startImport,
+  getJob,
+  listJobs,
+  reportCsv,
+};
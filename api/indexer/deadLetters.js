@@ -0,0 +1,104 @@
+// Dead-letter table of the indexer: events it could not parse and assets it
+// could not ingest, kept until they are replayed or discarded so the read
+// model never silently diverges from the ledger
+
+// Entries kept; resolved ones are dropped first once the table is full
+const MAX_DEAD_LETTERS =
+  parseInt(process.env.INDEXER_MAX_DEAD_LETTERS, 10) || 1000;
+
+const STATUSES = ["OPEN", "REPLAYED", "DISCARDED"];
+
+class DeadLetters {
+  constructor() {
+    this.entries = new Map();
+    this.sequence = 0;
+  }
+
+  // Record a failure. kind is EVENT (the payload could not be read) or
+  // ASSET (the asset could not be ingested); an asset that fails again
+  // while dead-lettered bumps its open entry instead of adding one
+  record(failure) {
+    const now = new Date().toISOString();
+    const open =
+      failure.kind === "ASSET" &&
+      [...this.entries.values()].find(
+        (entry) =>
+          entry.status === "OPEN" &&
+          entry.kind === "ASSET" &&
+          entry.assetType === failure.assetType &&
+          entry.assetId === failure.assetId
+      );
+    if (open) {
+      open.attempts += 1;
+      open.error = failure.error;
+      open.blockNumber = Math.max(open.blockNumber, failure.blockNumber || 0);
+      open.updatedAt = now;
+      return open;
+    }
+
+    this.sequence += 1;
+    const entry = {
+      id: `DL-${this.sequence}`,
+      kind: failure.kind,
+      eventName: failure.eventName,
+      transactionId: failure.transactionId,
+      blockNumber: failure.blockNumber || 0,
+      payload: failure.payload,
+      assetType: failure.assetType,
+      assetId: failure.assetId,
+      error: failure.error,
+      attempts: 1,
+      status: "OPEN",
+      createdAt: now,
+      updatedAt: now,
+    };
+    this.entries.set(entry.id, entry);
+    this.prune();
+    return entry;
+  }
+
+  prune() {
+    if (this.entries.size <= MAX_DEAD_LETTERS) {
+      return;
+    }
+    const resolved = [...this.entries.values()].find(
+      (entry) => entry.status !== "OPEN"
+    );
+    const oldest = resolved ? resolved.id : this.entries.keys().next().value;
+    this.entries.delete(oldest);
+  }
+
+  get(id) {
+    return this.entries.get(id) || null;
+  }
+
+  // Entries oldest first, optionally filtered by status and kind
+  list({ status, kind } = {}) {
+    return [...this.entries.values()].filter(
+      (entry) =>
+        (!status || entry.status === status) && (!kind || entry.kind === kind)
+    );
+  }
+
+  // A replay of the entry failed again
+  retried(entry, error) {
+    entry.attempts += 1;
+    entry.error = error;
+    entry.updatedAt = new Date().toISOString();
+  }
+
+  resolve(entry, status) {
+    entry.status = status;
+    entry.updatedAt = new Date().toISOString();
+  }
+
+  status() {
+    const counts = Object.fromEntries(STATUSES.map((status) => [status, 0]));
+    this.entries.forEach((entry) => {
+      counts[entry.status] += 1;
+    });
+    return counts;
+  }
+}
+
+module.exports = { DeadLetters, STATUSES };
@@ -0,0 +1,283 @@
+// Relationship graph of the read model - the same nodes and edges as the
+// chaincode's GetRelationshipGraph (without shipments, which are not
+// indexed), exported as Cytoscape elements or GraphML
+const DEFAULT_DEPTH = 2;
+const MAX_DEPTH = parseInt(process.env.GRAPH_MAX_DEPTH, 10) || 6;
+const MAX_NODES = parseInt(process.env.GRAPH_MAX_NODES, 10) || 1000;
+
+const nodeId = (type, id) => `${type}:${id}`;
+
+// Build every node and edge of the read model
+const buildGraph = (readModel) => {
+  const nodes = new Map();
+  const edges = [];
+  const adjacent = new Map();
+
+  const addNode = (node) => {
+    if (!nodes.has(node.id)) {
+      nodes.set(node.id, node);
+    }
+  };
+  const addEdge = (source, target, type, quantity) => {
+    const edge = { id: `${source}->${target}`, source, target, type };
+    if (quantity) {
+      edge.quantity = quantity;
+    }
+    edges.push(edge);
+    for (const end of [source, target]) {
+      adjacent.set(end, [...(adjacent.get(end) || []), edge]);
+    }
+  };
+
+  for (const waste of readModel.wastes.values()) {
+    // Lots the indexer may not see are indexed redacted, without farm,
+    // plot or quantity
+    const id = nodeId("WASTE", waste.id);
+    addNode({
+      id,
+      type: "WASTE",
+      assetId: waste.id,
+      label: waste.type,
+      status: waste.status,
+      quantity: waste.quantity,
+      date: waste.harvestDate,
+    });
+    if (waste.farm) {
+      const farm = nodeId("FARM", waste.farm);
+      addNode({
+        id: farm,
+        type: "FARM",
+        assetId: waste.farm,
+        label: waste.farm,
+      });
+      addEdge(farm, id, "DECLARED", waste.quantity);
+    }
+    if (waste.plotId) {
+      const plot = nodeId("PLOT", waste.plotId);
+      addNode({
+        id: plot,
+        type: "PLOT",
+        assetId: waste.plotId,
+        label: waste.plotId,
+      });
+      addEdge(plot, id, "GROWN_ON", waste.quantity);
+    }
+  }
+
+  for (const extraction of readModel.extractions.values()) {
+    const id = nodeId("EXTRACTION", extraction.id);
+    addNode({
+      id,
+      type: "EXTRACTION",
+      assetId: extraction.id,
+      label: extraction.processor,
+      status: extraction.status,
+      quantity: extraction.quantity,
+      date: extraction.extractionDate,
+    });
+    addEdge(
+      nodeId("WASTE", extraction.wasteId),
+      id,
+      "PROCESSED_INTO",
+      extraction.quantity
+    );
+    const outputs = extraction.outputs?.length
+      ? extraction.outputs
+      : [
+          {
+            line: 1,
+            productType: extraction.productType,
+            quantity: extraction.quantity,
+          },
+        ];
+    for (const output of outputs) {
+      const productId = `${extraction.id}/${output.line || 0}`;
+      const product = nodeId("PRODUCT", productId);
+      addNode({
+        id: product,
+        type: "PRODUCT",
+        assetId: productId,
+        label: output.productType,
+        quantity: output.quantity,
+        date: extraction.extractionDate,
+      });
+      addEdge(id, product, "PRODUCED", output.quantity);
+    }
+  }
+
+  for (const recycling of readModel.recyclings.values()) {
+    const id = nodeId("RECYCLING", recycling.id);
+    addNode({
+      id,
+      type: "RECYCLING",
+      assetId: recycling.id,
+      label: recycling.method,
+      status: recycling.status,
+      quantity: recycling.quantity,
+      date: recycling.recyclingDate,
+    });
+    if (recycling.extractionId) {
+      addEdge(
+        nodeId(
+          "PRODUCT",
+          `${recycling.extractionId}/${recycling.outputLine || 0}`
+        ),
+        id,
+        "RECYCLED_INTO",
+        recycling.quantity
+      );
+    } else {
+      const inputs = recycling.inputs?.length
+        ? recycling.inputs
+        : [{ wasteId: recycling.wasteId, quantity: recycling.quantity }];
+      for (const input of inputs) {
+        addEdge(
+          nodeId("WASTE", input.wasteId),
+          id,
+          "RECYCLED_INTO",
+          input.quantity
+        );
+      }
+    }
+    const product = nodeId("PRODUCT", recycling.id);
+    addNode({
+      id: product,
+      type: "PRODUCT",
+      assetId: recycling.id,
+      label: recycling.recycledProduct,
+      quantity: recycling.quantity,
+      date: recycling.recyclingDate,
+    });
+    addEdge(id, product, "PRODUCED", recycling.quantity);
+  }
+
+  return { nodes, edges, adjacent };
+};
+
+const ROOT_TYPES = ["WASTE", "EXTRACTION", "RECYCLING", "FARM", "PLOT"];
+
+// Neighbourhood of a root (node ID or asset ID) up to depth hops, following
+// edges both ways; null when the root is unknown
+const relationshipGraph = (readModel, rootId, depth) => {
+  const { nodes, edges, adjacent } = buildGraph(readModel);
+  const root = nodes.has(rootId)
+    ? rootId
+    : ROOT_TYPES.map((type) => nodeId(type, rootId)).find((id) =>
+        nodes.has(id)
+      );
+  if (!root) {
+    return null;
+  }
+  depth = Math.min(depth > 0 ? depth : DEFAULT_DEPTH, MAX_DEPTH);
+
+  const reached = new Set([root]);
+  let frontier = [root];
+  let truncated = false;
+  for (let hop = 0; hop < depth && frontier.length && !truncated; hop++) {
+    const next = [];
+    for (const id of frontier) {
+      for (const edge of adjacent.get(id) || []) {
+        const other = edge.target === id ? edge.source : edge.target;
+        if (reached.has(other) || !nodes.has(other)) {
+          continue;
+        }
+        if (reached.size >= MAX_NODES) {
+          truncated = true;
+          break;
+        }
+        reached.add(other);
+        next.push(other);
+      }
+    }
+    frontier = next;
+  }
+
+  const byId = (a, b) => (a.id < b.id ? -1 : a.id > b.id ? 1 : 0);
+  return {
+    root,
+    depth,
+    nodes: [...reached].map((id) => nodes.get(id)).sort(byId),
+    edges: edges
+      .filter((edge) => reached.has(edge.source) && reached.has(edge.target))
+      .sort(byId),
+    truncated,
+  };
+};
+
+// Cytoscape.js elements: cy.add(toCytoscape(graph).elements)
+const toCytoscape = (graph) => ({
+  root: graph.root,
+  depth: graph.depth,
+  truncated: graph.truncated,
+  elements: {
+    nodes: graph.nodes.map((node) => ({ data: node })),
+    edges: graph.edges.map((edge) => ({ data: edge })),
+  },
+});
+
+const escapeXml = (value) =>
+  String(value)
+    .replace(/&/g, "&amp;")
+    .replace(/</g, "&lt;")
+    .replace(/>/g, "&gt;")
+    .replace(/"/g, "&quot;");
+
+// GraphML attributes: [name, domain, GraphML type]
+const GRAPHML_KEYS = [
+  ["type", "node", "string"],
+  ["assetId", "node", "string"],
+  ["label", "node", "string"],
+  ["status", "node", "string"],
+  ["quantity", "node", "double"],
+  ["date", "node", "string"],
+  ["redacted", "node", "boolean"],
+  ["type", "edge", "string"],
+  ["quantity", "edge", "double"],
+];
+
+const graphmlData = (domain, element) =>
+  GRAPHML_KEYS.filter(
+    ([name, keyDomain]) =>
+      keyDomain === domain && element[name] !== undefined
+  )
+    .map(
+      ([name]) =>
+        `      <data key="${domain}_${name}">${escapeXml(
+          element[name]
+        )}</data>`
+    )
+    .join("\n");
+
+// GraphML document, for Gephi, yEd or NetworkX (read_graphml)
+const toGraphml = (graph) =>
+  [
+    '<?xml version="1.0" encoding="UTF-8"?>',
+    '<graphml xmlns="http://graphml.graphdrawing.org/xmlns">',
+    ...GRAPHML_KEYS.map(
+      ([name, domain, type]) =>
+        `  <key id="${domain}_${name}" for="${domain}" ` +
+        `attr.name="${name}" attr.type="${type}"/>`
+    ),
+    `  <graph id="${escapeXml(graph.root)}" edgedefault="directed">`,
+    ...graph.nodes.map(
+      (node) =>
+        `    <node id="${escapeXml(node.id)}">\n${graphmlData(
+          "node",
+          node
+        )}\n    </node>`
+    ),
+    ...graph.edges.map(
+      (edge) =>
+        `    <edge id="${escapeXml(edge.id)}" source="${escapeXml(
+          edge.source
+        )}" target="${escapeXml(edge.target)}">\n${graphmlData(
+          "edge",
+          edge
+        )}\n    </edge>`
+    ),
+    "  </graph>",
+    "</graphml>",
+    "",
+  ].join("\n");
+
+module.exports = { relationshipGraph, toCytoscape, toGraphml };
@@ -0,0 +1,437 @@
+// Ledger indexer - backfills the read model and keeps it fresh from
+// chaincode events
+const { ReadModel } = require("./readModel");
+const { DeadLetters } = require("./deadLetters");
+
+const INDEXER_ORG = process.env.INDEXER_ORG || "farmer";
+
+// How often the read model is compared with the ledger (0 disables)
+const CONSISTENCY_INTERVAL_MS =
+  process.env.INDEXER_CONSISTENCY_INTERVAL_MS !== undefined
+    ? parseInt(process.env.INDEXER_CONSISTENCY_INTERVAL_MS, 10) || 0
+    : 60 * 60 * 1000;
+
+// How far ahead deadlines are indexed
+const DEADLINE_HORIZON_DAYS =
+  parseInt(process.env.INDEXER_DEADLINE_HORIZON_DAYS, 10) || 90;
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// Consistency reports kept, newest first
+const MAX_CONSISTENCY_REPORTS = 20;
+
+
+// Chaincode reader, pager and read-model writer for each asset type
+const ASSET_TYPES = {
+  WASTE: {
+    read: "ReadWaste",
+    page: "GetWastesPage",
+    items: "wastes",
+    upsert: "upsertWaste",
+  },
+  EXTRACTION: {
+    read: "GetExtraction",
+    page: "GetExtractionsPage",
+    items: "extractions",
+    upsert: "upsertExtraction",
+  },
+  RECYCLING: {
+    read: "GetRecycling",
+    page: "GetRecyclingsPage",
+    items: "recyclings",
+    upsert: "upsertRecycling",
+  },
+};
+
+// Assets read per page when the whole ledger is listed
+const PAGE_SIZE = parseInt(process.env.INDEXER_PAGE_SIZE, 10) || 500;
+
+const readModel = new ReadModel();
+const deadLetters = new DeadLetters();
+
+// Client the indexer runs with, the close function of its event
+// subscription and the consistency reports, once started
+let indexerClient = null;
+let closeListener = null;
+let lastResync = null;
+const consistencyReports = [];
+
+// Called after each event or backfill that changed the read model
+const ingestionListeners = [];
+
+const onIngested = (listener) => {
+  ingestionListeners.push(listener);
+};
+
+const notifyIngested = () =>
+  ingestionListeners.forEach((listener) => {
+    try {
+      listener(readModel);
+    } catch (error) {
+      console.warn("⚠️ Ingestion listener failed:", error.message);
+    }
+  });
+
+// Assets an event says were written, as { assetType, id } pairs; throws
+// when the payload of a LedgerChanged event cannot be read
+const changedAssets = (event) => {
+  if (event.eventName !== "LedgerChanged") {
+    return [];
+  }
+  let data;
+  try {
+    data = JSON.parse(event.payload);
+  } catch (error) {
+    throw new Error(`Invalid ${event.eventName} payload: ${error.message}`);
+  }
+  return data?.changes || [];
+};
+
+const ingestAsset = async (blockchainClient, assetType, id, blockNumber) => {
+  const handler = ASSET_TYPES[assetType];
+  if (!handler) {
+    return;
+  }
+  let asset;
+  try {
+    asset = await blockchainClient.query(INDEXER_ORG, handler.read, id);
+  } catch (error) {
+    if (!/does not exist|NOT_FOUND/.test(error.message)) {
+      throw error;
+    }
+    readModel.remove(assetType, id);
+    readModel.markChanged(assetType, id, blockNumber, true);
+    return;
+  }
+  if (asset) {
+    readModel[handler.upsert](asset);
+    readModel.markChanged(assetType, id, blockNumber);
+  }
+};
+
+// Every asset of a type on the ledger, read page by page so that no query
+// goes over the chaincode's query budget
+const loadAll = async (blockchainClient, assetType) => {
+  const { page: pageFunction, items } = ASSET_TYPES[assetType];
+  const assets = [];
+  let bookmark = "";
+  do {
+    const page = await blockchainClient.query(
+      INDEXER_ORG,
+      pageFunction,
+      String(PAGE_SIZE),
+      bookmark
+    );
+    assets.push(...(page?.[items] || []));
+    bookmark = page?.bookmark || "";
+  } while (bookmark);
+  return assets;
+};
+
+const backfill = async (blockchainClient) => {
+  const [wastes, extractions, recyclings] = await Promise.all([
+    loadAll(blockchainClient, "WASTE"),
+    loadAll(blockchainClient, "EXTRACTION"),
+    loadAll(blockchainClient, "RECYCLING"),
+  ]);
+  // Wastes first so recyclings can resolve their region
+  (wastes || []).forEach((waste) => {
+    readModel.upsertWaste(waste);
+    readModel.markChanged("WASTE", waste.id, 0);
+  });
+  (extractions || []).forEach((extraction) => {
+    readModel.upsertExtraction(extraction);
+    readModel.markChanged("EXTRACTION", extraction.id, 0);
+  });
+  (recyclings || []).forEach((recycling) => {
+    readModel.upsertRecycling(recycling);
+    readModel.markChanged("RECYCLING", recycling.id, 0);
+  });
+};
+
+// Replace the read model's deadlines with those ending within horizonDays
+// (or already past); the chaincode shows the indexer's organization the
+// deadlines it is party to, and every deadline to an admin identity
+const refreshDeadlines = async (
+  blockchainClient = indexerClient,
+  horizonDays = DEADLINE_HORIZON_DAYS
+) => {
+  if (!blockchainClient) {
+    throw new Error("The indexer has not started");
+  }
+  const until = new Date(Date.now() + horizonDays * DAY_MS).toISOString();
+  const deadlines =
+    (await blockchainClient.query(
+      INDEXER_ORG,
+      "GetUpcomingDeadlines",
+      until
+    )) || [];
+  readModel.setDeadlines(deadlines);
+  return deadlines;
+};
+
+// Ingest the assets an event changed; what fails goes to the dead-letter
+// table
+const handleEvent = async (event) => {
+  let changes;
+  try {
+    changes = changedAssets(event);
+  } catch (error) {
+    deadLetters.record({
+      kind: "EVENT",
+      eventName: event.eventName,
+      transactionId: event.transactionId,
+      blockNumber: event.blockNumber,
+      payload: event.payload,
+      error: error.message,
+    });
+    console.warn(
+      `⚠️ Could not read event ${event.transactionId}:`,
+      error.message
+    );
+    return;
+  }
+  for (const change of changes) {
+    try {
+      await ingestAsset(
+        indexerClient,
+        change.assetType,
+        change.id,
+        event.blockNumber || readModel.lastBlock
+      );
+    } catch (error) {
+      deadLetters.record({
+        kind: "ASSET",
+        eventName: event.eventName,
+        transactionId: event.transactionId,
+        blockNumber: event.blockNumber,
+        assetType: change.assetType,
+        assetId: change.id,
+        error: error.message,
+      });
+      console.warn(
+        `⚠️ Could not index ${change.assetType} ${change.id}:`,
+        error.message
+      );
+    }
+  }
+  if (changes.length > 0) {
+    notifyIngested();
+  }
+};
+
+// (Re)subscribe to chaincode events, from startBlock when given
+const listen = async (startBlock) => {
+  if (closeListener) {
+    closeListener();
+    closeListener = null;
+  }
+  closeListener = await indexerClient.addContractListener(
+    INDEXER_ORG,
+    handleEvent,
+    { startBlock }
+  );
+};
+
+// Reprocess a dead letter: an asset is read again from the ledger, an event
+// has its stored payload parsed again. Returns the updated entry, or null
+// when there is no open entry with that ID.
+const replayDeadLetter = async (id) => {
+  const entry = deadLetters.get(id);
+  if (!entry || entry.status !== "OPEN") {
+    return null;
+  }
+  try {
+    const changes =
+      entry.kind === "EVENT"
+        ? changedAssets(entry)
+        : [{ assetType: entry.assetType, id: entry.assetId }];
+    for (const change of changes) {
+      await ingestAsset(
+        indexerClient,
+        change.assetType,
+        change.id,
+        entry.blockNumber || readModel.lastBlock
+      );
+    }
+    deadLetters.resolve(entry, "REPLAYED");
+  } catch (error) {
+    deadLetters.retried(entry, error.message);
+  }
+  return entry;
+};
+
+// Reprocess every open dead letter, oldest first
+const replayDeadLetters = async () => {
+  const summary = { replayed: 0, failed: 0, entries: [] };
+  for (const { id } of deadLetters.list({ status: "OPEN" })) {
+    const entry = await replayDeadLetter(id);
+    summary[entry.status === "REPLAYED" ? "replayed" : "failed"] += 1;
+    summary.entries.push(entry);
+  }
+  return summary;
+};
+
+// Leave a dead letter unprocessed; returns null when there is no open
+// entry with that ID
+const discardDeadLetter = (id) => {
+  const entry = deadLetters.get(id);
+  if (!entry || entry.status !== "OPEN") {
+    return null;
+  }
+  deadLetters.resolve(entry, "DISCARDED");
+  return entry;
+};
+
+// Re-ingest every event from a block height: the event subscription is
+// restarted at that block and then carries on live. Ingestion re-reads the
+// assets and ignores versions the read model already holds, so replaying
+// blocks it has seen is harmless.
+const resyncFrom = async (blockNumber) => {
+  await listen(blockNumber);
+  lastResync = {
+    fromBlock: blockNumber,
+    lastBlockBefore: readModel.lastBlock,
+    startedAt: new Date().toISOString(),
+  };
+  console.log(`🔁 Re-syncing the read model from block ${blockNumber}`);
+  return lastResync;
+};
+
+// Compare the asset keys and versions on the ledger with the read model.
+// Assets missing from the read model or older there are reported, as are
+// read-model rows the ledger no longer has; with repair they are
+// re-ingested or removed.
+const checkConsistency = async ({ repair = false } = {}) => {
+  const report = {
+    id: `CHK-${Date.now()}`,
+    startedAt: new Date().toISOString(),
+    repair,
+    assets: {},
+    missing: [],
+    stale: [],
+    extra: [],
+  };
+  const ledger = await Promise.all(
+    Object.keys(ASSET_TYPES).map((assetType) =>
+      loadAll(indexerClient, assetType)
+    )
+  );
+  Object.keys(ASSET_TYPES).forEach((assetType, i) => {
+    const store = readModel.store(assetType);
+    const onLedger = new Map((ledger[i] || []).map((a) => [a.id, a]));
+    report.assets[assetType] = { ledger: onLedger.size, readModel: store.size };
+    onLedger.forEach((asset, id) => {
+      const indexed = store.get(id);
+      if (!indexed) {
+        report.missing.push({ assetType, id, version: asset.version });
+      } else if ((indexed.version || 0) !== (asset.version || 0)) {
+        report.stale.push({
+          assetType,
+          id,
+          version: asset.version,
+          indexedVersion: indexed.version,
+        });
+      }
+    });
+    store.forEach((_, id) => {
+      if (!onLedger.has(id)) {
+        report.extra.push({ assetType, id });
+      }
+    });
+  });
+  report.consistent =
+    report.missing.length + report.stale.length + report.extra.length === 0;
+
+  if (repair && !report.consistent) {
+    report.repaired = 0;
+    report.repairErrors = [];
+    for (const { assetType, id, version } of [
+      ...report.missing,
+      ...report.stale,
+    ]) {
+      try {
+        await ingestAsset(indexerClient, assetType, id, readModel.lastBlock);
+        // Ingestion never replaces a newer version with an older one
+        const indexed = readModel.store(assetType).get(id);
+        if ((indexed?.version || 0) !== (version || 0)) {
+          throw new Error(
+            `Read model holds version ${indexed?.version}, ledger ${version}`
+          );
+        }
+        report.repaired += 1;
+      } catch (error) {
+        report.repairErrors.push({ assetType, id, error: error.message });
+      }
+    }
+    for (const { assetType, id } of report.extra) {
+      readModel.remove(assetType, id);
+      readModel.markChanged(assetType, id, readModel.lastBlock, true);
+      report.repaired += 1;
+    }
+  }
+
+  report.finishedAt = new Date().toISOString();
+  consistencyReports.unshift(report);
+  consistencyReports.splice(MAX_CONSISTENCY_REPORTS);
+  if (!report.consistent) {
+    console.warn(
+      `⚠️ Read model diverges from the ledger: ${report.missing.length} missing, ${report.stale.length} stale, ${report.extra.length} extra`
+    );
+  }
+  return report;
+};
+
+const indexerStatus = () => ({
+  running: Boolean(closeListener),
+  lastBlock: readModel.lastBlock,
+  deadLetters: deadLetters.status(),
+  lastConsistencyCheck: consistencyReports[0]
+    ? {
+        id: consistencyReports[0].id,
+        finishedAt: consistencyReports[0].finishedAt,
+        consistent: consistencyReports[0].consistent,
+      }
+    : null,
+  lastResync,
+});
+
+// Backfill from the ledger, then ingest every change event and compare the
+// read model with the ledger periodically
+const startIndexer = async (blockchainClient) => {
+  indexerClient = blockchainClient;
+  await backfill(blockchainClient);
+  await refreshDeadlines(blockchainClient).catch((error) =>
+    console.warn("⚠️ Could not index deadlines:", error.message)
+  );
+  console.log("📊 Read model backfilled:", readModel.status());
+  notifyIngested();
+
+  await listen();
+
+  if (CONSISTENCY_INTERVAL_MS > 0) {
+    setInterval(() => {
+      checkConsistency().catch((error) =>
+        console.warn("⚠️ Consistency check failed:", error.message)
+      );
+    }, CONSISTENCY_INTERVAL_MS).unref();
+  }
+};
+
+module.exports = {
+  readModel,
+  deadLetters,
+  startIndexer,
+  onIngested,
+  refreshDeadlines,
+  isIndexerRunning: () => Boolean(closeListener),
+  indexerStatus,
+  replayDeadLetter,
+  replayDeadLetters,
+  discardDeadLetter,
+  resyncFrom,
+  checkConsistency,
+  listConsistencyReports: () => consistencyReports,
+  getConsistencyReport: (id) =>
+    consistencyReports.find((report) => report.id === id) || null,
+};
@@ -0,0 +1,136 @@
+// Data quality of the read model - the chaincode's completeness rules
+// (GetDataQualityReport) applied to the indexed lots. Farms and locations of
+// pseudonymized lots are not indexed, so an open FARM_MISSING or
+// LOCATION_MISSING warning (or no region) tells whether they were given.
+// Validation profiles are not indexed either: required fields do not weigh
+// double here.
+const RULES = [
+  { field: "farm", weight: 30 },
+  { field: "harvestDate", weight: 30 },
+  { field: "location", weight: 25 },
+  { field: "plotId", weight: 15 },
+];
+
+const FIELDS = RULES.map((rule) => rule.field);
+
+const SEVERITIES = { LOW: 1, MEDIUM: 30, HIGH: 60 };
+
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+
+const openWarning = (waste, code) =>
+  (waste.warnings || []).some(
+    (warning) => warning.code === code && !warning.resolved
+  );
+
+const validDate = (value) =>
+  DATE_PATTERN.test(value) &&
+  new Date(`${value}T00:00:00Z`).toISOString().slice(0, 10) === value;
+
+// Problem of a lot's field: MISSING, INVALID or null
+const problemOf = (waste, field) => {
+  const pseudonymized = Boolean(waste.participantId);
+  switch (field) {
+    case "farm":
+      if (pseudonymized) {
+        return openWarning(waste, "FARM_MISSING") ? "MISSING" : null;
+      }
+      return waste.farm ? null : "MISSING";
+    case "location":
+      if (pseudonymized) {
+        return !waste.region || openWarning(waste, "LOCATION_MISSING")
+          ? "MISSING"
+          : null;
+      }
+      return waste.location ? null : "MISSING";
+    case "harvestDate":
+      if (!waste.harvestDate) {
+        return "MISSING";
+      }
+      return validDate(waste.harvestDate) ? null : "INVALID";
+    default:
+      return waste[field] ? null : "MISSING";
+  }
+};
+
+const severityOf = (score) => {
+  if (score >= SEVERITIES.HIGH) {
+    return "HIGH";
+  }
+  if (score >= SEVERITIES.MEDIUM) {
+    return "MEDIUM";
+  }
+  return score > 0 ? "LOW" : undefined;
+};
+
+// Completeness of one indexed lot
+const assessWaste = (waste) => {
+  const issues = RULES.map(({ field, weight }) => ({
+    field,
+    problem: problemOf(waste, field),
+    weight,
+  })).filter((issue) => issue.problem);
+  const score = Math.min(
+    100,
+    issues.reduce((sum, issue) => sum + issue.weight, 0)
+  );
+  return {
+    wasteId: waste.id,
+    ownerMsp: waste.ownerMsp,
+    status: waste.status,
+    createdAt: waste.createdAt,
+    score,
+    severity: severityOf(score),
+    missing: issues.map((issue) => issue.field),
+    issues,
+  };
+};
+
+// Incomplete lots, worst first, with totals per field and severity; field
+// keeps the lots missing that field, minSeverity the lots at least that bad
+// and ownerMsp the lots of one organization
+const dataQualityReport = (readModel, { field, minSeverity, ownerMsp }) => {
+  const minScore = SEVERITIES[minSeverity || "LOW"];
+  const report = {
+    checked: 0,
+    incomplete: 0,
+    byField: {},
+    bySeverity: {},
+    records: [],
+  };
+  for (const waste of readModel.wastes.values()) {
+    if (ownerMsp && waste.ownerMsp !== ownerMsp) {
+      continue;
+    }
+    report.checked += 1;
+    const quality = assessWaste(waste);
+    if (quality.score === 0) {
+      continue;
+    }
+    report.incomplete += 1;
+    report.bySeverity[quality.severity] =
+      (report.bySeverity[quality.severity] || 0) + 1;
+    quality.missing.forEach((missing) => {
+      report.byField[missing] = (report.byField[missing] || 0) + 1;
+    });
+    if (
+      quality.score >= minScore &&
+      (!field || quality.missing.includes(field))
+    ) {
+      report.records.push(quality);
+    }
+  }
+  report.records.sort(
+    (a, b) =>
+      b.score - a.score ||
+      String(a.createdAt).localeCompare(String(b.createdAt)) ||
+      a.wasteId.localeCompare(b.wasteId)
+  );
+  return report;
+};
+
+module.exports = {
+  QUALITY_FIELDS: FIELDS,
+  SEVERITIES: Object.keys(SEVERITIES),
+  assessWaste,
+  dataQualityReport,
+};
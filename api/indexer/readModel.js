@@ -0,0 +1,332 @@
+// In-memory read model: latest asset versions plus daily rollup tables that
+// are updated incrementally whenever an asset is ingested
+
+const UNKNOWN_REGION = "Unknown";
+
+// Past versions kept per lot for as-of queries
+const MAX_WASTE_VERSIONS =
+  parseInt(process.env.READ_MODEL_WASTE_VERSIONS, 10) || 50;
+
+const day = (timestamp) => String(timestamp || "").slice(0, 10);
+
+// Collection measures of a lot; what remained of donated or disposed lots is
+// tracked apart so they do not count as recycling failures
+const collectedMeasures = (waste) => {
+  const remaining = Math.max((waste.quantity || 0) - (waste.consumed || 0), 0);
+  return {
+    quantity: waste.quantity || 0,
+    lots: 1,
+    donated: waste.status === "DONATED" ? remaining : 0,
+    disposed: waste.status === "DISPOSED" ? remaining : 0,
+  };
+};
+
+// Rollup table keyed by "<day>|<dimension>" holding summed measures
+class Rollup {
+  constructor() {
+    this.rows = new Map();
+  }
+
+  apply(date, dimension, measures, sign) {
+    if (!date) {
+      return;
+    }
+    const key = `${date}|${dimension}`;
+    const row = this.rows.get(key) || { date, dimension, values: {} };
+    Object.entries(measures).forEach(([name, value]) => {
+      row.values[name] = (row.values[name] || 0) + sign * value;
+    });
+    this.rows.set(key, row);
+  }
+
+  // Sum rows within [from, to] grouped by the key returned for each row
+  query({ from, to }, groupKey) {
+    const groups = new Map();
+    for (const row of this.rows.values()) {
+      if ((from && row.date < from) || (to && row.date > to)) {
+        continue;
+      }
+      const key = groupKey(row);
+      const group = groups.get(key) || {};
+      Object.entries(row.values).forEach(([name, value]) => {
+        group[name] = (group[name] || 0) + value;
+      });
+      groups.set(key, group);
+    }
+    return groups;
+  }
+}
+
+class ReadModel {
+  constructor() {
+    this.wastes = new Map();
+    this.extractions = new Map();
+    this.recyclings = new Map();
+    // Versions of each lot as ingested, oldest first, with the time each
+    // became current
+    this.wasteVersions = new Map();
+    this.collected = new Rollup(); // day | region -> quantity, lots
+    this.recycled = new Rollup(); // day | region -> quantity
+    this.processed = new Rollup(); // day | processor -> quantity, runs
+    this.lastIngestedAt = null;
+    // Latest change per asset ("TYPE:id") with the block that carried it
+    this.changes = new Map();
+    this.lastBlock = 0;
+    // Upcoming deadlines of the assets not indexed above ("KIND:id:dueAt"),
+    // replaced as a whole on each refresh
+    this.deadlines = new Map();
+    this.deadlinesRefreshedAt = null;
+  }
+
+  setDeadlines(deadlines) {
+    this.deadlines = new Map(
+      deadlines.map((deadline) => [
+        `${deadline.kind}:${deadline.assetId}:${deadline.dueAt}`,
+        deadline,
+      ])
+    );
+    this.deadlinesRefreshedAt = new Date().toISOString();
+  }
+
+  // Record that an asset changed (or disappeared) in the given block;
+  // backfilled assets are recorded at block 0
+  markChanged(assetType, id, blockNumber, deleted = false) {
+    this.changes.set(`${assetType}:${id}`, {
+      assetType,
+      id,
+      block: blockNumber,
+      deleted,
+    });
+    this.lastBlock = Math.max(this.lastBlock, blockNumber);
+  }
+
+  // Changes carried by blocks after sinceBlock, oldest first, with the
+  // current version of each changed asset
+  changesSince(sinceBlock) {
+    const changed = [];
+    const deleted = [];
+    const entries = [...this.changes.values()]
+      .filter((change) => change.block > sinceBlock)
+      .sort((a, b) => a.block - b.block);
+    for (const change of entries) {
+      const { assetType, id, block } = change;
+      if (change.deleted) {
+        deleted.push({ assetType, id, block });
+        continue;
+      }
+      const asset = this.store(assetType)?.get(id);
+      if (asset) {
+        changed.push({ assetType, block, asset });
+      }
+    }
+    return { changed, deleted };
+  }
+
+  remove(assetType, id) {
+    this.store(assetType)?.delete(id);
+    if (assetType === "WASTE") {
+      this.addWasteVersion(id, {
+        validFrom: new Date().toISOString(),
+        deleted: true,
+      });
+    }
+  }
+
+  addWasteVersion(id, version) {
+    const versions = this.wasteVersions.get(id) || [];
+    versions.push(version);
+    if (versions.length > MAX_WASTE_VERSIONS) {
+      versions.shift();
+    }
+    this.wasteVersions.set(id, versions);
+  }
+
+  // The version of a lot current at the ISO time at, or null when the read
+  // model holds no version that old; versions become current at the
+  // lot's updatedAt, so the answer is as fresh as the last ingestion
+  wasteAsOf(id, at) {
+    const versions = this.wasteVersions.get(id) || [];
+    let found = null;
+    for (const version of versions) {
+      if (Date.parse(version.validFrom) > Date.parse(at)) {
+        break;
+      }
+      found = version;
+    }
+    return found;
+  }
+
+  store(assetType) {
+    return {
+      WASTE: this.wastes,
+      EXTRACTION: this.extractions,
+      RECYCLING: this.recyclings,
+    }[assetType];
+  }
+
+  regionOf(wasteId) {
+    return this.wastes.get(wasteId)?.region || UNKNOWN_REGION;
+  }
+
+  // Newer versions replace older ones; stale or duplicate events are ignored
+  isStale(store, asset) {
+    const current = store.get(asset.id);
+    return current && (current.version || 0) >= (asset.version || 0);
+  }
+
+  upsertWaste(waste) {
+    if (!waste?.id || this.isStale(this.wastes, waste)) {
+      return false;
+    }
+    const previous = this.wastes.get(waste.id);
+    if (previous) {
+      this.collected.apply(
+        day(previous.createdAt),
+        previous.region || UNKNOWN_REGION,
+        collectedMeasures(previous),
+        -1
+      );
+    }
+    this.collected.apply(
+      day(waste.createdAt),
+      waste.region || UNKNOWN_REGION,
+      collectedMeasures(waste),
+      1
+    );
+    this.wastes.set(waste.id, waste);
+    this.addWasteVersion(waste.id, {
+      validFrom: waste.updatedAt || waste.createdAt,
+      version: waste.version,
+      waste,
+    });
+    this.touch();
+    return true;
+  }
+
+  upsertExtraction(extraction) {
+    if (!extraction?.id || this.isStale(this.extractions, extraction)) {
+      return false;
+    }
+    const previous = this.extractions.get(extraction.id);
+    if (previous) {
+      this.processed.apply(
+        day(previous.createdAt),
+        previous.processor,
+        { quantity: previous.quantity || 0, runs: 1 },
+        -1
+      );
+    }
+    this.processed.apply(
+      day(extraction.createdAt),
+      extraction.processor,
+      { quantity: extraction.quantity || 0, runs: 1 },
+      1
+    );
+    this.extractions.set(extraction.id, extraction);
+    this.touch();
+    return true;
+  }
+
+  upsertRecycling(recycling) {
+    if (!recycling?.id || this.isStale(this.recyclings, recycling)) {
+      return false;
+    }
+    const previous = this.recyclings.get(recycling.id);
+    if (previous) {
+      this.recycled.apply(
+        day(previous.createdAt),
+        previous.region,
+        { quantity: previous.quantity || 0 },
+        -1
+      );
+    }
+    const indexed = { ...recycling, region: this.regionOf(recycling.wasteId) };
+    this.recycled.apply(
+      day(indexed.createdAt),
+      indexed.region,
+      { quantity: indexed.quantity || 0 },
+      1
+    );
+    this.recyclings.set(recycling.id, indexed);
+    this.touch();
+    return true;
+  }
+
+  touch() {
+    this.lastIngestedAt = new Date().toISOString();
+  }
+
+  // Quantity collected, grouped by day, month, region or not at all
+  collectedQuantity(range, groupBy) {
+    const groups = this.collected.query(range, (row) => {
+      switch (groupBy) {
+        case "day":
+          return row.date;
+        case "month":
+          return row.date.slice(0, 7);
+        case "region":
+          return row.dimension;
+        default:
+          return "total";
+      }
+    });
+    return [...groups.entries()]
+      .map(([key, values]) => ({
+        key,
+        quantity: round(values.quantity),
+        lots: values.lots,
+      }))
+      .sort((a, b) => a.key.localeCompare(b.key));
+  }
+
+  // Recycled over collected quantity per region within the range; donated
+  // quantities leave the denominator, disposed ones stay in it
+  recyclingRateByRegion(range) {
+    const collected = this.collected.query(range, (row) => row.dimension);
+    const recycled = this.recycled.query(range, (row) => row.dimension);
+    const regions = new Set([...collected.keys(), ...recycled.keys()]);
+    return [...regions]
+      .map((region) => {
+        const collectedQuantity = collected.get(region)?.quantity || 0;
+        const donated = collected.get(region)?.donated || 0;
+        const recycledQuantity = recycled.get(region)?.quantity || 0;
+        const recyclable = collectedQuantity - donated;
+        return {
+          region,
+          collected: round(collectedQuantity),
+          recycled: round(recycledQuantity),
+          donated: round(donated),
+          disposed: round(collected.get(region)?.disposed || 0),
+          rate: recyclable > 0 ? round(recycledQuantity / recyclable, 4) : null,
+        };
+      })
+      .sort((a, b) => a.region.localeCompare(b.region));
+  }
+
+  // Processors ranked by extracted quantity within the range
+  topProcessors(range, limit) {
+    const groups = this.processed.query(range, (row) => row.dimension);
+    return [...groups.entries()]
+      .map(([processor, values]) => ({
+        processor,
+        quantity: round(values.quantity),
+        runs: values.runs,
+      }))
+      .sort((a, b) => b.quantity - a.quantity)
+      .slice(0, limit);
+  }
+
+  status() {
+    return {
+      wastes: this.wastes.size,
+      extractions: this.extractions.size,
+      recyclings: this.recyclings.size,
+      deadlines: this.deadlines.size,
+      lastIngestedAt: this.lastIngestedAt,
+    };
+  }
+}
+
+const round = (value, digits = 2) => Number((value || 0).toFixed(digits));
+
+module.exports = { ReadModel, UNKNOWN_REGION };
@@ -0,0 +1,276 @@
+// List conventions shared by the collection endpoints:
+//   ?limit=&cursor=           keyset pages with an opaque continuation token
+//   ?sort=createdAt&order=desc stable sort, ties broken by id
+//   ?<filter>=                per-endpoint equality and range filters
+//   ?count=exact|estimate     total matching items (estimate: read model)
+const crypto = require("crypto");
+const { readModel } = require("./indexer");
+
+const DEFAULT_LIMIT = 100;
+const MAX_LIMIT = 1000;
+const SORT_FIELDS = ["createdAt", "updatedAt", "quantity"];
+const COUNT_MODES = ["none", "exact", "estimate"];
+
+class ListQueryError extends Error {}
+
+// Filters on asset fields: eq matches case-insensitively, min/max bound a
+// numeric field, from/to bound a timestamp by day (YYYY-MM-DD, inclusive)
+const eq = (field) => ({ field, match: "eq" });
+const min = (field) => ({ field, match: "min" });
+const max = (field) => ({ field, match: "max" });
+const from = (field) => ({ field, match: "from" });
+const to = (field) => ({ field, match: "to" });
+
+// Query parameters accepted by each asset list, mirroring the fields the
+// chaincode queries filter on
+const FILTERS = {
+  WASTE: {
+    status: eq("status"),
+    type: eq("type"),
+    category: eq("category"),
+    region: eq("region"),
+    farm: eq("farm"),
+    plotId: eq("plotId"),
+    ownerMsp: eq("ownerMsp"),
+    campaignId: eq("campaignId"),
+    qualityGrade: eq("qualityGrade"),
+    tag: { field: "tags", match: "contains" },
+    minQuantity: min("quantity"),
+    maxQuantity: max("quantity"),
+    createdFrom: from("createdAt"),
+    createdTo: to("createdAt"),
+  },
+  EXTRACTION: {
+    status: eq("status"),
+    wasteId: eq("wasteId"),
+    processor: eq("processor"),
+    facilityId: eq("facilityId"),
+    productType: eq("productType"),
+    qualityGrade: eq("qualityGrade"),
+    minQuantity: min("quantity"),
+    maxQuantity: max("quantity"),
+    createdFrom: from("createdAt"),
+    createdTo: to("createdAt"),
+  },
+  RECYCLING: {
+    status: eq("status"),
+    wasteId: eq("wasteId"),
+    method: eq("method"),
+    recycler: eq("recycler"),
+    facilityId: eq("facilityId"),
+    recycledProduct: eq("recycledProduct"),
+    minQuantity: min("quantity"),
+    maxQuantity: max("quantity"),
+    createdFrom: from("createdAt"),
+    createdTo: to("createdAt"),
+  },
+  LISTING: {
+    status: eq("status"),
+    wasteId: eq("wasteId"),
+    sellerMsp: eq("sellerMsp"),
+    minQuantity: min("quantity"),
+    maxQuantity: max("quantity"),
+    createdFrom: from("createdAt"),
+    createdTo: to("createdAt"),
+  },
+};
+
+const base64url = (text) =>
+  Buffer.from(text)
+    .toString("base64")
+    .replace(/=+$/, "")
+    .replace(/\+/g, "-")
+    .replace(/\//g, "_");
+
+const fromBase64url = (text) =>
+  Buffer.from(
+    String(text).replace(/-/g, "+").replace(/_/g, "/"),
+    "base64"
+  ).toString("utf8");
+
+const sortValue = (item, sort) => {
+  const value = item[sort] ?? item.createdAt;
+  return sort === "quantity" ? Number(value) || 0 : String(value || "");
+};
+
+const compare = (a, b) => (a < b ? -1 : a > b ? 1 : 0);
+
+// Stable ordering: the sort field, then the id
+const compareItems = (query) => (a, b) => {
+  const byValue = compare(
+    sortValue(a, query.sort),
+    sortValue(b, query.sort)
+  );
+  const ordered = byValue || compare(String(a.id), String(b.id));
+  return query.order === "desc" ? -ordered : ordered;
+};
+
+// Fingerprint of the sort and filters a cursor was issued for
+const signature = (query) =>
+  crypto
+    .createHash("sha256")
+    .update(JSON.stringify([query.sort, query.order, query.filters]))
+    .digest("base64")
+    .slice(0, 12);
+
+const matches = (item, filters, definitions) =>
+  Object.entries(filters).every(([name, expected]) => {
+    const { field, match } = definitions[name];
+    const value = item[field];
+    switch (match) {
+      case "eq":
+        return String(value ?? "").toLowerCase() === expected.toLowerCase();
+      case "contains":
+        return (value || []).some(
+          (entry) => String(entry).toLowerCase() === expected.toLowerCase()
+        );
+      case "min":
+        return Number(value) >= Number(expected);
+      case "max":
+        return Number(value) <= Number(expected);
+      case "from":
+        return String(value || "").slice(0, 10) >= expected;
+      case "to":
+        return String(value || "").slice(0, 10) <= expected;
+      default:
+        return true;
+    }
+  });
+
+// Parse and validate the list parameters of a request for an asset type;
+// throws ListQueryError on invalid input
+const parseListQuery = (req, assetType, defaults = {}) => {
+  const definitions = FILTERS[assetType] || {};
+  const params = req.query || {};
+
+  const limit =
+    params.limit === undefined
+      ? defaults.limit || DEFAULT_LIMIT
+      : parseInt(params.limit, 10);
+  if (!Number.isInteger(limit) || limit < 1 || limit > MAX_LIMIT) {
+    throw new ListQueryError(`'limit' must be between 1 and ${MAX_LIMIT}`);
+  }
+
+  const sort = params.sort || defaults.sort || "createdAt";
+  if (!SORT_FIELDS.includes(sort)) {
+    throw new ListQueryError(
+      `'sort' must be one of: ${SORT_FIELDS.join(", ")}`
+    );
+  }
+  const order = (params.order || defaults.order || "desc").toLowerCase();
+  if (!["asc", "desc"].includes(order)) {
+    throw new ListQueryError("'order' must be asc or desc");
+  }
+  const count = params.count || "none";
+  if (!COUNT_MODES.includes(count)) {
+    throw new ListQueryError(
+      `'count' must be one of: ${COUNT_MODES.join(", ")}`
+    );
+  }
+
+  const filters = {};
+  Object.entries(definitions).forEach(([name, { match }]) => {
+    const value = params[name];
+    if (value === undefined || value === "") {
+      return;
+    }
+    if (["min", "max"].includes(match) && Number.isNaN(Number(value))) {
+      throw new ListQueryError(`'${name}' must be a number`);
+    }
+    if (["from", "to"].includes(match) && !/^\d{4}-\d{2}-\d{2}$/.test(value)) {
+      throw new ListQueryError(`'${name}' must be formatted as YYYY-MM-DD`);
+    }
+    filters[name] = String(value);
+  });
+
+  const query = { assetType, limit, sort, order, count, filters };
+
+  if (params.cursor) {
+    let cursor;
+    try {
+      cursor = JSON.parse(fromBase64url(params.cursor));
+    } catch {
+      throw new ListQueryError("'cursor' is not a valid continuation token");
+    }
+    if (!cursor || cursor.sig !== signature(query)) {
+      throw new ListQueryError(
+        "'cursor' was issued for a different sort or filter"
+      );
+    }
+    query.after = { value: cursor.value, id: cursor.id };
+  }
+
+  return query;
+};
+
+// Filter, sort and cut one page out of a full item list; count=estimate
+// counts the read model's copy of the assets instead
+const paginate = (items, query) => {
+  const definitions = FILTERS[query.assetType] || {};
+  const filtered = items.filter((item) =>
+    matches(item, query.filters, definitions)
+  );
+  const sorted = filtered.sort(compareItems(query));
+
+  let start = 0;
+  if (query.after) {
+    const anchor = { id: query.after.id, [query.sort]: query.after.value };
+    const isAfter = compareItems(query);
+    start = sorted.findIndex((item) => isAfter(item, anchor) > 0);
+    if (start === -1) {
+      start = sorted.length;
+    }
+  }
+  const data = sorted.slice(start, start + query.limit);
+
+  const page = {
+    limit: query.limit,
+    sort: query.sort,
+    order: query.order,
+    filters: query.filters,
+    nextCursor: null,
+  };
+  if (start + query.limit < sorted.length && data.length > 0) {
+    const last = data[data.length - 1];
+    page.nextCursor = base64url(
+      JSON.stringify({
+        value: sortValue(last, query.sort),
+        id: last.id,
+        sig: signature(query),
+      })
+    );
+  }
+  // Lists without a read-model copy answer estimates with the exact count
+  const indexed = readModel.store(query.assetType);
+  if (query.count === "estimate" && indexed) {
+    page.total = [...indexed.values()].filter((item) =>
+      matches(item, query.filters, definitions)
+    ).length;
+    page.totalIsEstimate = true;
+  } else if (query.count !== "none") {
+    page.total = sorted.length;
+    page.totalIsEstimate = false;
+  }
+
+  return { data, page };
+};
+
+// Send a 400 for an invalid list query; returns false when the error is not
+// a ListQueryError
+const sendListQueryError = (res, error) => {
+  if (!(error instanceof ListQueryError)) {
+    return false;
+  }
+  res.status(400).json({
+    error: "Invalid list parameters",
+    details: error.message,
+  });
+  return true;
+};
+
+module.exports = {
+  parseListQuery,
+  paginate,
+  sendListQueryError,
+  ListQueryError,
+};
@@ -0,0 +1,110 @@
+// Media uploads - issues pre-signed upload URLs for photos and videos of
+// assets in the organization's object store and anchors each file's hash
+// on the ledger once it is there
+const { storageForOrg } = require("../objectStorage");
+const {
+  startUpload,
+  completeUpload,
+  getUpload,
+  summarize,
+} = require("../objectStorage/uploads");
+
+const MAX_SIZE_BYTES =
+  parseInt(process.env.MEDIA_MAX_SIZE_BYTES, 10) || 50 * 1024 * 1024;
+
+// Files are stored under their hash, so the same photo is kept once per asset
+const objectKey = (upload) =>
+  `${upload.assetType.toLowerCase()}/${upload.assetId}/${upload.hash}`;
+
+const anchor = async (client, upload, object) => {
+  const media = {
+    hash: upload.hash,
+    mimeType: upload.mimeType,
+    uri: upload.uri,
+    size: object.size,
+    capturedAt: upload.capturedAt || "",
+    caption: upload.caption || "",
+  };
+  if (upload.latitude !== undefined && upload.longitude !== undefined) {
+    media.latitude = upload.latitude;
+    media.longitude = upload.longitude;
+  }
+
+  return client.submitTransaction(
+    upload.org,
+    "AttachMedia",
+    upload.assetType,
+    upload.assetId,
+    JSON.stringify(media)
+  );
+};
+
+// Validate a media description; returns an error message or null
+const validate = (media) => {
+  if (!/^[0-9a-f]{64}$/.test(media.hash)) {
+    return "'hash' must be the hex SHA-256 digest of the file";
+  }
+  if (!/^(image|video)\/[\w.+-]+$/.test(media.mimeType)) {
+    return "'mimeType' must be an image or video type";
+  }
+  if (!(media.size > 0) || media.size > MAX_SIZE_BYTES) {
+    return `'size' must be between 1 and ${MAX_SIZE_BYTES} bytes`;
+  }
+  if (media.capturedAt && Number.isNaN(Date.parse(media.capturedAt))) {
+    return "'capturedAt' must be an ISO timestamp";
+  }
+  const hasLatitude = media.latitude !== undefined;
+  if (hasLatitude !== (media.longitude !== undefined)) {
+    return "Send both 'latitude' and 'longitude' or neither";
+  }
+  if (
+    hasLatitude &&
+    !(Math.abs(media.latitude) <= 90 && Math.abs(media.longitude) <= 180)
+  ) {
+    return "'latitude' and 'longitude' are out of range";
+  }
+  return null;
+};
+
+// Issue a pre-signed upload URL for a file of an asset in org's store. The
+// hash is anchored with AttachMedia under org's identity as soon as the
+// file is in storage and matches it. Throws when storage is not configured
+// or the description is invalid.
+const requestUpload = (client, org, options) => {
+  const media = {
+    org,
+    assetType: String(options.assetType || "").toUpperCase(),
+    assetId: options.assetId,
+    hash: String(options.hash || "").toLowerCase(),
+    mimeType: String(options.mimeType || "").toLowerCase(),
+    size: parseInt(options.size, 10),
+    capturedAt: options.capturedAt,
+    caption: options.caption,
+    latitude:
+      options.latitude === undefined ? undefined : parseFloat(options.latitude),
+    longitude:
+      options.longitude === undefined
+        ? undefined
+        : parseFloat(options.longitude),
+  };
+  const error = validate(media);
+  if (error) {
+    throw new Error(error);
+  }
+
+  return startUpload(
+    client,
+    storageForOrg(org),
+    objectKey(media),
+    media,
+    anchor
+  );
+};
+
+module.exports = {
+  MAX_SIZE_BYTES,
+  requestUpload,
+  completeUpload,
+  getUpload,
+  summarize,
+};
@@ -0,0 +1,135 @@
+// Object storage of attachments (media, documents) behind one interface, so
+// each organization keeps its files in the store it chose. A tenant of the
+// tenants file (see api/tenants) may bring its own store:
+//
+//   "storage": { "provider": "azure", "account": "sfaxolive",
+//                "accountKey": "env:SFAX_AZURE_KEY", "container": "docs" }
+//
+// String settings written "env:NAME" are read from that environment
+// variable, so the file need not hold secrets. Organizations without a
+// tenant store use STORAGE_PROVIDER and its environment settings.
+//
+// Every store offers the same pre-signed flow:
+//   presignUpload(key, { contentType, sha256, expiresSeconds })
+//     -> { url, method, headers } the client sends the file with
+//   presignDownload(key, expiresSeconds) -> URL the client reads it from
+//   head(key) -> { size } or null while the object is missing
+//   objectUri(key) -> where the object lives, recorded on the ledger
+//   verifiesChecksum: whether the store itself refuses a body whose SHA-256
+//     differs from the signed one; otherwise it is checked on arrival
+const s3 = require("./s3");
+const azure = require("./azure");
+const gcs = require("./gcs");
+const local = require("./local");
+const { tenantForOrg, getTenant } = require("../tenants");
+
+const PROVIDERS = {
+  s3: { create: s3.createS3Storage, envSettings: s3.envSettings },
+  azure: { create: azure.createAzureStorage, envSettings: azure.envSettings },
+  gcs: { create: gcs.createGcsStorage, envSettings: gcs.envSettings },
+  local: { create: local.createLocalStorage, envSettings: local.envSettings },
+};
+
+const DEFAULT_PROVIDER = process.env.STORAGE_PROVIDER || "s3";
+
+// Stores already built, by tenant and settings
+const stores = new Map();
+
+const resolveSettings = (settings) =>
+  Object.fromEntries(
+    Object.entries(settings).map(([name, value]) => [
+      name,
+      typeof value === "string" && value.startsWith("env:")
+        ? process.env[value.slice("env:".length)]
+        : value,
+    ])
+  );
+
+// Storage settings of a tenant, its own or those of the environment
+const tenantSettings = (tenant) => {
+  if (tenant.storage) {
+    return resolveSettings(tenant.storage);
+  }
+  const provider = PROVIDERS[DEFAULT_PROVIDER];
+  return {
+    provider: DEFAULT_PROVIDER,
+    ...(provider ? provider.envSettings() : {}),
+  };
+};
+
+const storageForTenant = (tenant) => {
+  const settings = tenantSettings(tenant);
+  const provider = PROVIDERS[settings.provider];
+  if (!provider) {
+    throw new Error(
+      `Tenant ${tenant.id}: unknown storage provider '${settings.provider}'` +
+        ` (one of: ${Object.keys(PROVIDERS).join(", ")})`
+    );
+  }
+  const cacheKey = `${tenant.id}:${JSON.stringify(settings)}`;
+  if (!stores.has(cacheKey)) {
+    stores.set(cacheKey, provider.create(settings, tenant.id));
+  }
+  return stores.get(cacheKey);
+};
+
+// Store of the organization a request acts as (gateway name, e.g. farmer).
+// Throws when it is not configured.
+const storageForOrg = (org) => {
+  const store = storageForTenant(tenantForOrg(org));
+  if (!store.isConfigured()) {
+    throw new Error(`Object storage (${store.provider}) is not configured`);
+  }
+  return store;
+};
+
+// Store of one of orgs that keeps key at uri, or null; objects are read
+// back from the store they were uploaded to, whoever reads them
+const storageForObject = (orgs, key, uri) => {
+  for (const org of orgs) {
+    try {
+      const store = storageForTenant(tenantForOrg(org));
+      if (store.isConfigured() && store.objectUri(key) === uri) {
+        return store;
+      }
+    } catch (error) {
+      console.warn(`⚠️ Storage of ${org} unavailable:`, error.message);
+    }
+  }
+  return null;
+};
+
+// Local store that signed a URL of the files route, or null
+const localStoreById = (storeId) => {
+  const tenant = getTenant(storeId);
+  if (!tenant) {
+    return null;
+  }
+  const store = storageForTenant(tenant);
+  return store.provider === "local" && store.isConfigured() ? store : null;
+};
+
+// Which provider each organization's files go to
+const describeStorage = (orgs) =>
+  orgs.map((org) => {
+    const tenant = tenantForOrg(org);
+    try {
+      const store = storageForTenant(tenant);
+      return {
+        org,
+        tenant: tenant.id,
+        provider: store.provider,
+        configured: store.isConfigured(),
+      };
+    } catch (error) {
+      return { org, tenant: tenant.id, provider: null, error: error.message };
+    }
+  });
+
+module.exports = {
+  PROVIDERS: Object.keys(PROVIDERS),
+  storageForOrg,
+  storageForObject,
+  localStoreById,
+  describeStorage,
+};
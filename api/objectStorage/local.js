@@ -0,0 +1,172 @@
+// Local disk storage for deployments without an object store. Uploads and
+// downloads go through the backend itself (/api/files) with URLs signed by
+// an HMAC of the store's secret, so clients follow the same pre-signed flow
+// as with the cloud providers.
+const crypto = require("crypto");
+const fs = require("fs");
+const path = require("path");
+const { encodeKey } = require("./signing");
+
+const envSettings = () => ({
+  dir: process.env.STORAGE_LOCAL_DIR,
+  secret: process.env.STORAGE_LOCAL_SECRET,
+  publicUrl: process.env.STORAGE_LOCAL_PUBLIC_URL,
+});
+
+const createLocalStorage = (settings, storeId) => {
+  const config = {
+    dir: settings.dir ? path.resolve(settings.dir) : null,
+    secret: settings.secret,
+    publicUrl: (
+      settings.publicUrl || `http://localhost:${process.env.PORT || 5000}`
+    ).replace(/\/$/, ""),
+  };
+
+  // Path of an object on disk; keys may not leave the storage directory
+  const filePath = (key) => {
+    const resolved = path.resolve(config.dir, key);
+    if (!resolved.startsWith(config.dir + path.sep)) {
+      throw new Error(`Invalid object key ${key}`);
+    }
+    return resolved;
+  };
+
+  const signature = (fields) =>
+    crypto
+      .createHmac("sha256", config.secret)
+      .update(
+        [
+          fields.method,
+          storeId,
+          fields.key,
+          fields.expires,
+          fields.sha256 || "",
+          fields.type || "",
+        ].join("\n")
+      )
+      .digest("hex");
+
+  const objectUrl = (key) =>
+    new URL(`${config.publicUrl}/api/files/${encodeKey(key)}`);
+
+  const presign = (method, key, expiresSeconds, extra = {}) => {
+    const fields = {
+      method,
+      key,
+      expires: String(Math.floor(Date.now() / 1000) + expiresSeconds),
+      ...extra,
+    };
+    const url = objectUrl(key);
+    url.search = new URLSearchParams({
+      store: storeId,
+      expires: fields.expires,
+      ...extra,
+      sig: signature(fields),
+    }).toString();
+    return url.toString();
+  };
+
+  // Check the query of a signed request for an object; returns the signed
+  // fields or throws when the signature is wrong or expired. GET URLs also
+  // sign HEAD requests.
+  const verify = (method, key, query) => {
+    const fields = {
+      method: method === "HEAD" ? "GET" : method,
+      key,
+      expires: String(query.expires || ""),
+      sha256: query.sha256,
+      type: query.type,
+    };
+    const expected = Buffer.from(signature(fields), "hex");
+    const given = Buffer.from(String(query.sig || ""), "hex");
+    if (
+      given.length !== expected.length ||
+      !crypto.timingSafeEqual(given, expected)
+    ) {
+      throw new Error("Invalid signature");
+    }
+    if (Date.now() / 1000 > parseInt(fields.expires, 10)) {
+      throw new Error("Signed URL expired");
+    }
+    return fields;
+  };
+
+  // Store the body of an upload once its SHA-256 matches the signed one
+  const receive = async (key, body, fields) => {
+    const target = filePath(key);
+    await fs.promises.mkdir(path.dirname(target), { recursive: true });
+    const partPath = `${target}.part-${crypto.randomBytes(4).toString("hex")}`;
+    const hash = crypto.createHash("sha256");
+    let size = 0;
+    try {
+      await new Promise((resolve, reject) => {
+        const out = fs.createWriteStream(partPath);
+        body.on("data", (chunk) => {
+          hash.update(chunk);
+          size += chunk.length;
+        });
+        body.on("error", reject);
+        out.on("error", reject);
+        out.on("finish", resolve);
+        body.pipe(out);
+      });
+      if (hash.digest("hex") !== fields.sha256) {
+        throw new Error("The body does not match the signed SHA-256");
+      }
+      await fs.promises.rename(partPath, target);
+      // The metadata is written last: objects without it are not there yet
+      const meta = { contentType: fields.type, size, sha256: fields.sha256 };
+      await fs.promises.writeFile(`${target}.meta.json`, JSON.stringify(meta));
+      return { size };
+    } finally {
+      await fs.promises.rm(partPath, { force: true }).catch(() => {});
+    }
+  };
+
+  // Metadata and path of a stored object, or null
+  const open = async (key) => {
+    const target = filePath(key);
+    try {
+      const meta = JSON.parse(
+        await fs.promises.readFile(`${target}.meta.json`, "utf8")
+      );
+      return { ...meta, path: target };
+    } catch (error) {
+      if (error.code === "ENOENT") {
+        return null;
+      }
+      throw error;
+    }
+  };
+
+  return {
+    provider: "local",
+    storeId,
+    // The upload handler refuses bodies whose SHA-256 differs
+    verifiesChecksum: true,
+
+    isConfigured: () => Boolean(config.dir && config.secret),
+
+    objectUri: (key) => objectUrl(key).toString(),
+
+    presignUpload: (key, { contentType, sha256, expiresSeconds }) => ({
+      url: presign("PUT", key, expiresSeconds, { sha256, type: contentType }),
+      method: "PUT",
+      headers: { "content-type": contentType },
+    }),
+
+    presignDownload: (key, expiresSeconds) =>
+      presign("GET", key, expiresSeconds),
+
+    head: async (key) => {
+      const object = await open(key);
+      return object ? { size: object.size } : null;
+    },
+
+    verify,
+    receive,
+    open,
+  };
+};
+
+module.exports = { createLocalStorage, envSettings };
@@ -0,0 +1,134 @@
+// S3-compatible object storage (AWS S3, MinIO, Ceph...) through pre-signed
+// URLs using Signature Version 4 query authentication, so clients upload
+// and the backend checks objects without an SDK
+const {
+  encodeKey,
+  hmac,
+  sha256Hex,
+  canonicalQuery,
+  canonicalHeaders,
+  compactTimestamp,
+} = require("./signing");
+
+// Settings from the environment, for organizations without a tenant store
+const envSettings = () => ({
+  endpoint: process.env.MEDIA_S3_ENDPOINT,
+  region: process.env.MEDIA_S3_REGION,
+  bucket: process.env.MEDIA_S3_BUCKET,
+  accessKeyId: process.env.MEDIA_S3_ACCESS_KEY_ID,
+  secretAccessKey: process.env.MEDIA_S3_SECRET_ACCESS_KEY,
+  pathStyle: process.env.MEDIA_S3_PATH_STYLE,
+});
+
+const createS3Storage = (settings) => {
+  const config = {
+    endpoint: settings.endpoint || "https://s3.amazonaws.com",
+    region: settings.region || "us-east-1",
+    bucket: settings.bucket,
+    accessKeyId: settings.accessKeyId,
+    secretAccessKey: settings.secretAccessKey,
+    // MinIO and most self-hosted stores only serve path-style URLs
+    pathStyle: String(settings.pathStyle) !== "false",
+  };
+
+  // URL of an object, path-style or virtual-hosted
+  const objectUrl = (key) => {
+    const url = new URL(config.endpoint);
+    const base = url.pathname.replace(/\/$/, "");
+    if (config.pathStyle) {
+      url.pathname = `${base}/${config.bucket}/${encodeKey(key)}`;
+    } else {
+      url.hostname = `${config.bucket}.${url.hostname}`;
+      url.pathname = `${base}/${encodeKey(key)}`;
+    }
+    return url;
+  };
+
+  // Pre-sign a request for an object; headers (lower-case names) are
+  // signed, so the client must send them unchanged
+  const presign = (method, key, expiresSeconds, headers = {}) => {
+    const url = objectUrl(key);
+    const amzDate = compactTimestamp();
+    const day = amzDate.slice(0, 8);
+    const scope = `${day}/${config.region}/s3/aws4_request`;
+
+    const signedHeaders = canonicalHeaders({ host: url.host, ...headers });
+    const query = canonicalQuery({
+      "X-Amz-Algorithm": "AWS4-HMAC-SHA256",
+      "X-Amz-Credential": `${config.accessKeyId}/${scope}`,
+      "X-Amz-Date": amzDate,
+      "X-Amz-Expires": String(expiresSeconds),
+      "X-Amz-SignedHeaders": signedHeaders.signed,
+    });
+
+    const canonicalRequest = [
+      method,
+      url.pathname,
+      query,
+      signedHeaders.block,
+      signedHeaders.signed,
+      "UNSIGNED-PAYLOAD",
+    ].join("\n");
+    const stringToSign = [
+      "AWS4-HMAC-SHA256",
+      amzDate,
+      scope,
+      sha256Hex(canonicalRequest),
+    ].join("\n");
+
+    const signingKey = ["s3", "aws4_request"].reduce(
+      (signing, part) => hmac(signing, part),
+      hmac(hmac(`AWS4${config.secretAccessKey}`, day), config.region)
+    );
+    const signature = hmac(signingKey, stringToSign).toString("hex");
+
+    url.search = `${query}&X-Amz-Signature=${signature}`;
+    return url.toString();
+  };
+
+  return {
+    provider: "s3",
+    // S3 rejects a body whose SHA-256 differs from the signed checksum
+    verifiesChecksum: true,
+
+    isConfigured: () =>
+      Boolean(config.bucket && config.accessKeyId && config.secretAccessKey),
+
+    objectUri: (key) => objectUrl(key).toString(),
+
+    presignUpload: (key, { contentType, sha256, expiresSeconds }) => {
+      const headers = {
+        "content-type": contentType,
+        "x-amz-checksum-sha256": Buffer.from(sha256, "hex").toString("base64"),
+      };
+      return {
+        url: presign("PUT", key, expiresSeconds, headers),
+        method: "PUT",
+        headers,
+      };
+    },
+
+    presignDownload: (key, expiresSeconds) =>
+      presign("GET", key, expiresSeconds),
+
+    // Whether an object exists, with its size when it does
+    head: async (key) => {
+      const response = await fetch(presign("HEAD", key, 60), {
+        method: "HEAD",
+      });
+      if (response.status === 404 || response.status === 403) {
+        return null;
+      }
+      if (!response.ok) {
+        throw new Error(
+          `Object storage answered ${response.status} for ${key}`
+        );
+      }
+      return {
+        size: parseInt(response.headers.get("content-length"), 10) || 0,
+      };
+    },
+  };
+};
+
+module.exports = { createS3Storage, envSettings };
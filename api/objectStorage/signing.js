@@ -0,0 +1,50 @@
+// Helpers shared by the pre-signed URLs of the storage providers
+const crypto = require("crypto");
+
+// RFC 3986 encoding as SigV4 and the GCS V4 signature expect it
+const encode = (value) =>
+  encodeURIComponent(value).replace(
+    /[!'()*]/g,
+    (char) => `%${char.charCodeAt(0).toString(16).toUpperCase()}`
+  );
+
+// Path of an object key, each segment encoded
+const encodeKey = (key) => key.split("/").map(encode).join("/");
+
+const hmac = (key, value) =>
+  crypto.createHmac("sha256", key).update(value).digest();
+
+const sha256Hex = (value) =>
+  crypto.createHash("sha256").update(value).digest("hex");
+
+// Query string of parameters sorted by name, as canonical requests need it
+const canonicalQuery = (query) =>
+  Object.keys(query)
+    .sort()
+    .map((name) => `${encode(name)}=${encode(query[name])}`)
+    .join("&");
+
+// Canonical headers block and signed header list of a canonical request
+const canonicalHeaders = (headers) => {
+  const names = Object.keys(headers).sort();
+  return {
+    block: names
+      .map((name) => `${name}:${String(headers[name]).trim()}\n`)
+      .join(""),
+    signed: names.join(";"),
+  };
+};
+
+// Timestamp in the compact ISO 8601 form of SigV4 and GCS V4 signatures
+const compactTimestamp = () =>
+  new Date().toISOString().replace(/[-:]|\.\d{3}/g, "");
+
+module.exports = {
+  encode,
+  encodeKey,
+  hmac,
+  sha256Hex,
+  canonicalQuery,
+  canonicalHeaders,
+  compactTimestamp,
+};
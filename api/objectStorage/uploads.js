@@ -0,0 +1,158 @@
+// Pending uploads - files clients were given a pre-signed URL for, watched
+// until they arrive in storage and then anchored on the ledger by their
+// hash. Stores that do not check the SHA-256 themselves have each arrived
+// object read back and hashed before it is anchored.
+const crypto = require("crypto");
+
+const UPLOAD_URL_TTL_SECONDS =
+  parseInt(
+    process.env.STORAGE_UPLOAD_URL_TTL_SECONDS ||
+      process.env.MEDIA_UPLOAD_URL_TTL_SECONDS,
+    10
+  ) || 900;
+const POLL_INTERVAL_MS =
+  parseInt(
+    process.env.STORAGE_POLL_INTERVAL_MS || process.env.MEDIA_POLL_INTERVAL_MS,
+    10
+  ) || 15000;
+
+// Finished uploads kept for status queries
+const MAX_UPLOADS = 200;
+
+const uploads = new Map();
+const checking = new Set();
+let watcher = null;
+
+// SHA-256 of an object, read back through a download URL
+const hashObject = async (store, key) => {
+  const response = await fetch(store.presignDownload(key, 300));
+  if (!response.ok) {
+    throw new Error(`Storage answered ${response.status} reading ${key}`);
+  }
+  const hash = crypto.createHash("sha256");
+  for await (const chunk of response.body) {
+    hash.update(chunk);
+  }
+  return hash.digest("hex");
+};
+
+// Look for the object of a pending upload and anchor it when it has arrived;
+// uploads never made before the URL expired are marked EXPIRED
+const checkUpload = async (client, upload) => {
+  // The watcher and an explicit completion may look at the same upload
+  if (upload.status !== "PENDING" || checking.has(upload.id)) {
+    return upload;
+  }
+  checking.add(upload.id);
+  try {
+    const object = await upload.store.head(upload.key);
+    if (object) {
+      if (
+        !upload.store.verifiesChecksum &&
+        (await hashObject(upload.store, upload.key)) !== upload.hash
+      ) {
+        throw new Error("The stored file does not match the declared hash");
+      }
+      const result = await upload.anchor(client, upload, object);
+      upload.status = "ANCHORED";
+      upload.txId = result?.transactionId || "";
+      upload.anchoredAt = new Date().toISOString();
+      console.log(`✅ Upload ${upload.id} anchored (${upload.hash})`);
+    } else if (Date.now() > Date.parse(upload.expiresAt)) {
+      upload.status = "EXPIRED";
+    }
+  } catch (error) {
+    console.error(`❌ Upload ${upload.id} failed:`, error);
+    upload.status = "FAILED";
+    upload.error = error.message;
+  } finally {
+    checking.delete(upload.id);
+  }
+  return upload;
+};
+
+const forgetOldUploads = () => {
+  for (const [id, upload] of uploads) {
+    if (uploads.size < MAX_UPLOADS) {
+      break;
+    }
+    if (upload.status !== "PENDING") {
+      uploads.delete(id);
+    }
+  }
+};
+
+// Poll storage for pending uploads while there are any
+const watch = (client) => {
+  if (watcher) {
+    return;
+  }
+  watcher = setInterval(async () => {
+    const pending = [...uploads.values()].filter(
+      (upload) => upload.status === "PENDING"
+    );
+    if (pending.length === 0) {
+      clearInterval(watcher);
+      watcher = null;
+      return;
+    }
+    for (const upload of pending) {
+      await checkUpload(client, upload);
+    }
+  }, POLL_INTERVAL_MS);
+  watcher.unref();
+};
+
+// Issue a pre-signed URL for a file to be stored at key in store and watch
+// for it; anchor(client, upload, { size }) records it on the ledger once it
+// is there. fields describe the file: hash (hex SHA-256), mimeType, size and
+// whatever the anchor needs.
+const startUpload = (client, store, key, fields, anchor) => {
+  const upload = {
+    ...fields,
+    id: `UPLOAD-${Date.now()}-${crypto.randomBytes(3).toString("hex")}`,
+    key,
+    provider: store.provider,
+    uri: store.objectUri(key),
+    store,
+    anchor,
+  };
+  const { url, method, headers } = store.presignUpload(key, {
+    contentType: upload.mimeType,
+    sha256: upload.hash,
+    expiresSeconds: UPLOAD_URL_TTL_SECONDS,
+  });
+  upload.url = url;
+  upload.method = method;
+  upload.headers = headers;
+  upload.status = "PENDING";
+  upload.createdAt = new Date().toISOString();
+  upload.expiresAt = new Date(
+    Date.now() + UPLOAD_URL_TTL_SECONDS * 1000
+  ).toISOString();
+
+  forgetOldUploads();
+  uploads.set(upload.id, upload);
+  watch(client);
+  return upload;
+};
+
+// Check an upload right away instead of waiting for the next poll
+const completeUpload = async (client, id) => {
+  const upload = uploads.get(id);
+  return upload ? checkUpload(client, upload) : null;
+};
+
+const getUpload = (id) => uploads.get(id) || null;
+
+// An upload without its signed URL and internals
+const summarize = ({ url, headers, org, store, anchor, key, ...summary }) =>
+  summary;
+
+module.exports = {
+  UPLOAD_URL_TTL_SECONDS,
+  startUpload,
+  completeUpload,
+  getUpload,
+  summarize,
+};
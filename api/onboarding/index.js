@@ -0,0 +1,480 @@
+// Participant onboarding - the wizard new participants (farmers first) go
+// through before they may act on the network. Each step is submitted by the
+// applicant and reviewed by a verifier of the organization, in order:
+//
+//   DOCUMENTS -> FARM_REGISTRATION -> ROLE_ASSIGNMENT -> activation
+//
+// An approved step is recorded on chain with RecordOnboardingStep by the
+// SHA-256 of what was reviewed, so personal data stays here; the chaincode's
+// ActivateParticipant refuses participants whose required steps are not all
+// verified. Onboardings stalled for a while get reminders: the applicant
+// when a step waits on them, the reviewers when it waits on review.
+const crypto = require("crypto");
+const { sendMessage } = require("../alerts");
+
+const STEPS = ["DOCUMENTS", "FARM_REGISTRATION", "ROLE_ASSIGNMENT"];
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// Document types the DOCUMENTS step needs
+const REQUIRED_DOCUMENTS = (
+  process.env.ONBOARDING_REQUIRED_DOCUMENTS || "IDENTITY,LAND_TITLE"
+)
+  .split(",")
+  .map((type) => type.trim().toUpperCase())
+  .filter(Boolean);
+
+// Roles the ROLE_ASSIGNMENT step may assign
+const ROLES = ["FARMER", "COLLECTOR", "TRANSPORTER", "PROCESSOR", "RECYCLER"];
+
+// Days without activity after which an onboarding is stalled, and between
+// two reminders of the same onboarding
+const STALL_DAYS = parseInt(process.env.ONBOARDING_STALL_DAYS, 10) || 7;
+
+// How often stalled onboardings are looked for (0 disables the schedule)
+const REMINDER_INTERVAL_MS =
+  process.env.ONBOARDING_REMINDER_INTERVAL_MS !== undefined
+    ? parseInt(process.env.ONBOARDING_REMINDER_INTERVAL_MS, 10) || 0
+    : 24 * 60 * 60 * 1000;
+
+// Reminder runs kept, newest first
+const MAX_RUNS = 30;
+
+// Onboardings are kept in memory, like the other temporary stores; what was
+// verified is on the ledger
+const onboardings = new Map();
+const runs = [];
+let timer = null;
+
+const newId = (prefix) =>
+  `${prefix}-${Date.now()}-${crypto.randomBytes(3).toString("hex")}`;
+
+const sha256 = (value) =>
+  crypto.createHash("sha256").update(JSON.stringify(value)).digest("hex");
+
+const buildTargets = (targets, label) =>
+  (Array.isArray(targets) ? targets : []).map((target) => {
+    const built = {
+      channel: String(target?.channel || "").toLowerCase(),
+      to: String(target?.to || "").trim(),
+    };
+    if (!built.channel || !built.to) {
+      throw new Error(`Every ${label} target needs a 'channel' and a 'to'`);
+    }
+    return built;
+  });
+
+// Validate the data of a step; returns it normalized or throws
+const STEP_DATA = {
+  DOCUMENTS: (data) => {
+    const documents = (Array.isArray(data.documents) ? data.documents : []).map(
+      (document) => ({
+        docType: String(document?.docType || "").toUpperCase(),
+        hash: String(document?.hash || "").toLowerCase(),
+        uri: document?.uri ? String(document.uri) : undefined,
+      })
+    );
+    if (documents.some((document) => !/^[0-9a-f]{64}$/.test(document.hash))) {
+      throw new Error("Every document needs a 'hash', its hex SHA-256 digest");
+    }
+    const missing = REQUIRED_DOCUMENTS.filter(
+      (type) => !documents.some((document) => document.docType === type)
+    );
+    if (missing.length > 0) {
+      throw new Error(`Documents required: ${missing.join(", ")}`);
+    }
+    return { documents };
+  },
+  FARM_REGISTRATION: (data) => {
+    const farm = {
+      farm: String(data.farm || "").trim(),
+      location: String(data.location || "").trim(),
+      plotIds: (Array.isArray(data.plotIds) ? data.plotIds : []).map(String),
+      areaHectares:
+        data.areaHectares === undefined
+          ? undefined
+          : parseFloat(data.areaHectares),
+    };
+    if (!farm.farm || !farm.location) {
+      throw new Error("'farm' and 'location' are required");
+    }
+    if (farm.areaHectares !== undefined && !(farm.areaHectares > 0)) {
+      throw new Error("'areaHectares' must be a positive number");
+    }
+    return farm;
+  },
+  ROLE_ASSIGNMENT: (data) => {
+    const role = String(data.role || "").toUpperCase();
+    if (!ROLES.includes(role)) {
+      throw new Error(`'role' must be one of: ${ROLES.join(", ")}`);
+    }
+    return { role };
+  },
+};
+
+// What an approved step records on chain besides its hash; never personal
+const stepNote = (step, data) => {
+  if (step === "DOCUMENTS") {
+    return data.documents.map((document) => document.docType).join(",");
+  }
+  if (step === "ROLE_ASSIGNMENT") {
+    return data.role;
+  }
+  return "";
+};
+
+const touch = (onboarding, action, actor, details = "") => {
+  const now = new Date().toISOString();
+  onboarding.updatedAt = now;
+  onboarding.lastActivityAt = now;
+  onboarding.history.push({ timestamp: now, action, actor, details });
+};
+
+const requireOnboarding = (id) => {
+  const onboarding = onboardings.get(id);
+  if (!onboarding) {
+    throw new Error(`Onboarding ${id} does not exist`);
+  }
+  return onboarding;
+};
+
+const requireOpen = (onboarding) => {
+  if (onboarding.status === "ACTIVE" || onboarding.status === "CANCELLED") {
+    throw new Error(
+      `Onboarding ${onboarding.id} is ${onboarding.status.toLowerCase()}`
+    );
+  }
+};
+
+const findStep = (onboarding, name) => {
+  const step = onboarding.steps.find(
+    (candidate) => candidate.step === String(name || "").toUpperCase()
+  );
+  if (!step) {
+    throw new Error(
+      `Unknown onboarding step '${name}' (expected one of: ${STEPS.join(
+        ", "
+      )})`
+    );
+  }
+  return step;
+};
+
+// The step the onboarding waits on, or null once every step is verified
+const currentStep = (onboarding) =>
+  onboarding.steps.find((step) => step.status !== "VERIFIED") || null;
+
+// Who the onboarding waits on: the applicant, a reviewer, or nobody
+const waitingOn = (onboarding) => {
+  if (onboarding.status === "AWAITING_ACTIVATION") {
+    return "REVIEWER";
+  }
+  if (onboarding.status !== "IN_PROGRESS") {
+    return null;
+  }
+  return currentStep(onboarding)?.status === "SUBMITTED"
+    ? "REVIEWER"
+    : "APPLICANT";
+};
+
+// Progress of an onboarding, with whether it is stalled
+const progress = (onboarding, now = Date.now()) => {
+  const verified = onboarding.steps.filter(
+    (step) => step.status === "VERIFIED"
+  ).length;
+  const idleDays = Math.floor(
+    (now - Date.parse(onboarding.lastActivityAt)) / DAY_MS
+  );
+  const waiting = waitingOn(onboarding);
+  return {
+    verifiedSteps: verified,
+    totalSteps: onboarding.steps.length,
+    percent: Math.round((verified / onboarding.steps.length) * 100),
+    currentStep: currentStep(onboarding)?.step || null,
+    waitingOn: waiting,
+    idleDays,
+    stalled: waiting !== null && idleDays >= STALL_DAYS,
+  };
+};
+
+// An onboarding with its progress; step data is left out unless asked for
+const describe = (onboarding, { withData = false } = {}) => ({
+  ...onboarding,
+  steps: onboarding.steps.map(({ data, ...step }) =>
+    withData ? { ...step, data } : step
+  ),
+  progress: progress(onboarding),
+});
+
+// Start the onboarding of a new participant of org:
+// { name, participantId, contact, reviewers }. name is who the participant
+// is registered as on chain; participantId resumes a participant already
+// registered. contact and reviewers are { channel, to } reminder targets.
+const startOnboarding = (org, input) => {
+  const name = String(input.name || "").trim();
+  if (!name && !input.participantId) {
+    throw new Error("'name' or 'participantId' is required");
+  }
+  const contact = input.contact ? buildTargets([input.contact], "contact") : [];
+  const now = new Date().toISOString();
+  const onboarding = {
+    id: newId("ONBOARDING"),
+    org,
+    name,
+    participantId: input.participantId ? String(input.participantId) : "",
+    status: "IN_PROGRESS",
+    contact: contact[0] || null,
+    reviewers: buildTargets(input.reviewers, "reviewer"),
+    steps: STEPS.map((step, index) => ({
+      step,
+      status: index === 0 ? "OPEN" : "LOCKED",
+    })),
+    reminders: 0,
+    createdAt: now,
+    updatedAt: now,
+    lastActivityAt: now,
+    history: [],
+  };
+  touch(onboarding, "ONBOARDING_STARTED", org);
+  onboardings.set(onboarding.id, onboarding);
+  return onboarding;
+};
+
+// Submit (or resubmit after a rejection) the data of the current step
+const submitStep = (id, name, data = {}) => {
+  const onboarding = requireOnboarding(id);
+  requireOpen(onboarding);
+  const step = findStep(onboarding, name);
+  if (step.status === "LOCKED") {
+    throw new Error(
+      `Step ${step.step} is not open before ${
+        currentStep(onboarding).step
+      } is verified`
+    );
+  }
+  if (step.status === "VERIFIED") {
+    throw new Error(`Step ${step.step} is already verified`);
+  }
+
+  step.data = STEP_DATA[step.step](data);
+  step.status = "SUBMITTED";
+  step.submittedAt = new Date().toISOString();
+  delete step.rejectionReason;
+  touch(onboarding, "STEP_SUBMITTED", onboarding.name, step.step);
+  return onboarding;
+};
+
+// Approve or reject a submitted step: { approved, reason, reviewer }. An
+// approved step is recorded on chain under org's identity, registering the
+// participant with its first step; the next step then opens.
+const reviewStep = async (client, id, name, review = {}) => {
+  const onboarding = requireOnboarding(id);
+  requireOpen(onboarding);
+  const step = findStep(onboarding, name);
+  if (step.status !== "SUBMITTED") {
+    throw new Error(`Step ${step.step} is not awaiting review`);
+  }
+  const reviewer = String(review.reviewer || onboarding.org);
+
+  if (review.approved !== true) {
+    const reason = String(review.reason || "").trim();
+    if (!reason) {
+      throw new Error("A 'reason' is required to reject a step");
+    }
+    step.status = "REJECTED";
+    step.rejectionReason = reason;
+    touch(onboarding, "STEP_REJECTED", reviewer, `${step.step}: ${reason}`);
+    return onboarding;
+  }
+
+  const evidenceHash = sha256(step.data);
+  const result = await client.submitPrivateTransaction(
+    onboarding.org,
+    "RecordOnboardingStep",
+    onboarding.participantId ? {} : { pii: { owner: onboarding.name } },
+    onboarding.participantId,
+    step.step,
+    evidenceHash,
+    stepNote(step.step, step.data)
+  );
+  onboarding.participantId =
+    result?.result?.participantId || onboarding.participantId;
+
+  step.status = "VERIFIED";
+  step.evidenceHash = evidenceHash;
+  step.verifiedBy = reviewer;
+  step.verifiedAt = new Date().toISOString();
+  step.txId = result?.transactionId || "";
+  const next = currentStep(onboarding);
+  if (next) {
+    next.status = next.status === "LOCKED" ? "OPEN" : next.status;
+  } else {
+    onboarding.status = "AWAITING_ACTIVATION";
+  }
+  touch(onboarding, "STEP_VERIFIED", reviewer, step.step);
+  return onboarding;
+};
+
+// Activate the participant on chain once every step is verified
+const activate = async (client, id, actor) => {
+  const onboarding = requireOnboarding(id);
+  if (onboarding.status !== "AWAITING_ACTIVATION") {
+    throw new Error(
+      `Onboarding ${id} is not ready: every step must be verified first`
+    );
+  }
+
+  const result = await client.submitTransaction(
+    onboarding.org,
+    "ActivateParticipant",
+    onboarding.participantId
+  );
+  onboarding.status = "ACTIVE";
+  onboarding.activatedAt = new Date().toISOString();
+  onboarding.activationTxId = result?.transactionId || "";
+  touch(onboarding, "PARTICIPANT_ACTIVATED", actor || onboarding.org);
+  return onboarding;
+};
+
+const cancelOnboarding = (id, reason, actor) => {
+  const onboarding = requireOnboarding(id);
+  requireOpen(onboarding);
+  if (!String(reason || "").trim()) {
+    throw new Error("A 'reason' is required to cancel an onboarding");
+  }
+  onboarding.status = "CANCELLED";
+  touch(onboarding, "ONBOARDING_CANCELLED", actor || onboarding.org, reason);
+  return onboarding;
+};
+
+const getOnboarding = (id) => onboardings.get(id) || null;
+
+// Onboardings, optionally of an organization, in a status, or stalled only
+const listOnboardings = ({ org, status, stalled } = {}) =>
+  [...onboardings.values()].filter(
+    (onboarding) =>
+      (!org || onboarding.org === org) &&
+      (!status || onboarding.status === status) &&
+      (!stalled || progress(onboarding).stalled)
+  );
+
+const formatReminder = (onboarding, waiting) => {
+  const step = currentStep(onboarding);
+  const { idleDays } = progress(onboarding);
+  if (waiting === "APPLICANT") {
+    return {
+      title: "Your onboarding is waiting for you",
+      body:
+        step.status === "REJECTED"
+          ? `Step ${step.step} was rejected (${step.rejectionReason}); ` +
+            "please correct and resubmit it."
+          : `Please complete step ${step.step} to continue your onboarding.`,
+    };
+  }
+  return {
+    title: `Onboarding ${onboarding.id} is waiting for review`,
+    body: step
+      ? `Step ${step.step} of ${onboarding.name || onboarding.participantId} ` +
+        `was submitted ${idleDays} day(s) ago and awaits review.`
+      : `Every step of ${onboarding.name || onboarding.participantId} is ` +
+        "verified; the participant awaits activation.",
+  };
+};
+
+// Remind whoever stalled onboardings wait on, at most once per stall
+// period; dryRun lists them without sending anything
+const runReminders = async ({ dryRun = false } = {}) => {
+  const run = {
+    id: newId("ONBOARDING-REMINDERS"),
+    startedAt: new Date().toISOString(),
+    dryRun,
+    reminders: [],
+    errors: [],
+  };
+
+  const now = Date.now();
+  for (const onboarding of onboardings.values()) {
+    const { stalled, waitingOn: waiting } = progress(onboarding, now);
+    const lastReminded = Date.parse(onboarding.lastRemindedAt || 0);
+    if (!stalled || now - lastReminded < STALL_DAYS * DAY_MS) {
+      continue;
+    }
+    const targets =
+      waiting === "APPLICANT"
+        ? [onboarding.contact].filter(Boolean)
+        : onboarding.reviewers;
+    const reminder = {
+      onboardingId: onboarding.id,
+      waitingOn: waiting,
+      deliveries: [],
+    };
+    if (!dryRun) {
+      const { title, body } = formatReminder(onboarding, waiting);
+      for (const target of targets) {
+        try {
+          const delivery = sendMessage(
+            target,
+            "ONBOARDING_REMINDER",
+            title,
+            body
+          );
+          reminder.deliveries.push(delivery.id);
+        } catch (error) {
+          run.errors.push({
+            onboardingId: onboarding.id,
+            error: error.message,
+          });
+        }
+      }
+      // Reminders do not count as activity, so the stall keeps its age
+      onboarding.reminders += 1;
+      onboarding.lastRemindedAt = new Date(now).toISOString();
+    }
+    run.reminders.push(reminder);
+  }
+
+  run.finishedAt = new Date().toISOString();
+  runs.unshift(run);
+  runs.splice(MAX_RUNS);
+  if (run.reminders.length > 0) {
+    console.log(
+      `🔔 Onboarding reminders ${run.id}: ${run.reminders.length} onboarding(s)${
+        dryRun ? " (dry run)" : ""
+      }`
+    );
+  }
+  return run;
+};
+
+// Look for stalled onboardings on their schedule
+const startReminders = () => {
+  if (timer || REMINDER_INTERVAL_MS <= 0) {
+    return;
+  }
+  timer = setInterval(() => {
+    runReminders().catch((error) =>
+      console.warn("⚠️ Onboarding reminders failed:", error.message)
+    );
+  }, REMINDER_INTERVAL_MS);
+  timer.unref();
+};
+
+const listRuns = () => runs;
+
+module.exports = {
+  STEPS,
+  ROLES,
+  REQUIRED_DOCUMENTS,
+  STALL_DAYS,
+  startOnboarding,
+  submitStep,
+  reviewStep,
+  activate,
+  cancelOnboarding,
+  getOnboarding,
+  listOnboardings,
+  describe,
+  runReminders,
+  startReminders,
+  listRuns,
+};
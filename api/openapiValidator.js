@@ -0,0 +1,156 @@
+// Request validation against the gateway's OpenAPI document. openapi.json is
+// generated from the Go request models (blockchain/chaincode/pkg/api) by
+// blockchain/chaincode/cmd/openapi; do not edit it by hand.
+const openApiDocument = require("./openapi.json");
+
+const schemaNamed = (ref) =>
+  openApiDocument.components.schemas[ref.split("/").pop()];
+
+const resolve = (schema) => {
+  let resolved = schema || {};
+  while (resolved.$ref) {
+    resolved = schemaNamed(resolved.$ref) || {};
+  }
+  return resolved;
+};
+
+// One matcher per documented operation
+const operations = Object.entries(openApiDocument.paths).flatMap(
+  ([path, item]) =>
+    Object.entries(item).map(([method, operation]) => ({
+      method: method.toUpperCase(),
+      pattern: new RegExp(`^${path.replace(/\{[^}]+\}/g, "[^/]+")}/?$`),
+      operation,
+    }))
+);
+
+const isMissing = (value) =>
+  value === undefined || value === null || value === "";
+
+// Numbers may arrive as numeric strings, as the gateway always accepted them
+const asNumber = (value) => {
+  if (typeof value === "number") {
+    return value;
+  }
+  if (typeof value === "string" && value.trim() !== "") {
+    const number = Number(value);
+    return Number.isNaN(number) ? null : number;
+  }
+  return null;
+};
+
+const checkBounds = (schema, number, at, violations) => {
+  if (schema.minimum !== undefined) {
+    if (schema.exclusiveMinimum && number <= schema.minimum) {
+      violations.push(`${at} must be greater than ${schema.minimum}`);
+    } else if (number < schema.minimum) {
+      violations.push(`${at} must be at least ${schema.minimum}`);
+    }
+  }
+  if (schema.maximum !== undefined && number > schema.maximum) {
+    violations.push(`${at} must be at most ${schema.maximum}`);
+  }
+};
+
+// Collect the violations of value against schema; at is the member path
+const check = (schema, value, at, violations) => {
+  const resolved = resolve(schema);
+  const label = at || "body";
+
+  switch (resolved.type) {
+    case "object": {
+      if (typeof value !== "object" || value === null || Array.isArray(value)) {
+        violations.push(`${label} must be an object`);
+        return;
+      }
+      const member = (name) => (at ? `${at}.${name}` : name);
+      (resolved.required || []).forEach((name) => {
+        if (isMissing(value[name])) {
+          violations.push(`${member(name)} is required`);
+        }
+      });
+      Object.entries(resolved.properties || {}).forEach(([name, property]) => {
+        if (!isMissing(value[name])) {
+          check(property, value[name], member(name), violations);
+        }
+      });
+      return;
+    }
+    case "array":
+      if (!Array.isArray(value)) {
+        violations.push(`${label} must be an array`);
+        return;
+      }
+      value.forEach((item, index) =>
+        check(resolved.items, item, `${label}[${index}]`, violations)
+      );
+      return;
+    case "integer":
+    case "number": {
+      const number = asNumber(value);
+      if (number === null) {
+        violations.push(`${label} must be a number`);
+        return;
+      }
+      if (resolved.type === "integer" && !Number.isInteger(number)) {
+        violations.push(`${label} must be a whole number`);
+        return;
+      }
+      checkBounds(resolved, number, label, violations);
+      return;
+    }
+    case "boolean":
+      if (![true, false, "true", "false"].includes(value)) {
+        violations.push(`${label} must be true or false`);
+      }
+      return;
+    case "string":
+      if (typeof value !== "string") {
+        violations.push(`${label} must be a string`);
+        return;
+      }
+      if (resolved.enum && !resolved.enum.includes(value)) {
+        violations.push(`${label} must be one of: ${resolved.enum.join(", ")}`);
+      }
+      return;
+    default:
+  }
+};
+
+// Express middleware rejecting requests to documented operations whose
+// query parameters or body do not match the OpenAPI document
+const validateRequest = (req, res, next) => {
+  const match = operations.find(
+    ({ method, pattern }) => method === req.method && pattern.test(req.path)
+  );
+  if (!match) {
+    return next();
+  }
+
+  const { operation } = match;
+  const violations = [];
+  (operation.parameters || [])
+    .filter((parameter) => parameter.in === "query")
+    .forEach((parameter) => {
+      const value = req.query[parameter.name];
+      if (!isMissing(value)) {
+        check(parameter.schema, value, parameter.name, violations);
+      }
+    });
+  const body = operation.requestBody?.content?.["application/json"]?.schema;
+  if (body) {
+    check(body, req.body ?? {}, "", violations);
+  }
+
+  if (violations.length > 0) {
+    return res.status(400).json({
+      error: "Invalid request",
+      details: violations.join("; "),
+      operationId: operation.operationId,
+      violations,
+    });
+  }
+  next();
+};
+
+module.exports = { openApiDocument, validateRequest };
@@ -0,0 +1,461 @@
+// Response envelopes of the REST gateway:
+//   2xx  { "success": true, "data": ..., ...metadata }
+//   4xx/5xx  RFC 7807 application/problem+json
+// Controllers keep answering { error, details }; problemResponses turns those
+// bodies into problems, classifying the underlying failure (chaincode error
+// code, Fabric validation code or gateway error) into a documented type.
+// The types are listed at GET /problems and each type URI resolves to its
+// documentation.
+const PROBLEM_BASE_URI = process.env.PROBLEM_BASE_URI || "/problems/";
+
+const PROBLEM_CONTENT_TYPE = "application/problem+json";
+
+// Problem types: title, HTTP status and what the client should do
+const PROBLEM_TYPES = {
+  // Request errors detected by the gateway itself
+  "bad-request": {
+    status: 400,
+    title: "Invalid request",
+    description: "Parameters or body failed validation; fix and resend.",
+  },
+  unauthorized: {
+    status: 401,
+    title: "Unauthorized",
+    description: "The bearer token is missing, invalid or expired.",
+  },
+  forbidden: {
+    status: 403,
+    title: "Forbidden",
+    description: "The credentials do not grant access to this resource.",
+  },
+  "not-found": {
+    status: 404,
+    title: "Not found",
+    description: "No resource or route matches the request.",
+  },
+  conflict: {
+    status: 409,
+    title: "Conflict",
+    description: "The request conflicts with the current state; re-read it.",
+  },
+  "unprocessable-entity": {
+    status: 422,
+    title: "Unprocessable entity",
+    description: "The request is well formed but cannot be carried out.",
+  },
+  "payload-too-large": {
+    status: 413,
+    title: "Payload too large",
+    description: "The request body exceeds the accepted size.",
+  },
+  "too-many-requests": {
+    status: 429,
+    title: "Too many requests",
+    description: "A rate limit was hit; retry later.",
+  },
+  "internal-error": {
+    status: 500,
+    title: "Internal server error",
+    description: "An unexpected gateway failure; report it if it persists.",
+  },
+
+  // Chaincode error codes (see the chaincode's stable error codes)
+  "ledger-read-failed": {
+    status: 500,
+    title: "Ledger read failed",
+    code: "LEDGER_READ_FAILED",
+    description: "The chaincode could not read a record of the world state.",
+  },
+  "ledger-write-failed": {
+    status: 500,
+    title: "Ledger write failed",
+    code: "LEDGER_WRITE_FAILED",
+    description: "The chaincode could not write a record of the world state.",
+  },
+  "identity-unavailable": {
+    status: 500,
+    title: "Client identity unavailable",
+    code: "IDENTITY_UNAVAILABLE",
+    description: "The chaincode could not read the submitting identity.",
+  },
+  "admin-required": {
+    status: 403,
+    title: "Admin role required",
+    code: "ADMIN_REQUIRED",
+    description: "Only identities holding the admin role may do this.",
+  },
+  "timestamp-unavailable": {
+    status: 500,
+    title: "Transaction timestamp unavailable",
+    code: "TIMESTAMP_UNAVAILABLE",
+    description: "The chaincode could not read the transaction timestamp.",
+  },
+  "waste-type-required": {
+    status: 422,
+    title: "Waste type required",
+    code: "WASTE_TYPE_REQUIRED",
+    description: "A lot needs a waste type.",
+  },
+  "quantity-not-positive": {
+    status: 422,
+    title: "Quantity must be positive",
+    code: "QUANTITY_NOT_POSITIVE",
+    description: "Quantities must be greater than zero.",
+  },
+  "harvest-date-invalid": {
+    status: 422,
+    title: "Invalid harvest date",
+    code: "HARVEST_DATE_INVALID",
+    description: "Harvest dates must be YYYY-MM-DD.",
+  },
+  "waste-already-exists": {
+    status: 409,
+    title: "Waste already exists",
+    code: "WASTE_ALREADY_EXISTS",
+    description: "A lot with this ID is already on the ledger.",
+  },
+  "waste-not-found": {
+    status: 404,
+    title: "Waste not found",
+    code: "WASTE_NOT_FOUND",
+    description: "No lot with this ID is on the ledger.",
+  },
+  "campaign-closed": {
+    status: 409,
+    title: "Campaign closed",
+    code: "CAMPAIGN_CLOSED",
+    description: "The harvest campaign of the lot no longer accepts lots.",
+  },
+  "status-required": {
+    status: 422,
+    title: "Status required",
+    code: "STATUS_REQUIRED",
+    description: "A status or status transition must be given.",
+  },
+  "extraction-already-exists": {
+    status: 409,
+    title: "Extraction already exists",
+    code: "EXTRACTION_ALREADY_EXISTS",
+    description: "An extraction with this ID is already on the ledger.",
+  },
+  "extraction-not-found": {
+    status: 404,
+    title: "Extraction not found",
+    code: "EXTRACTION_NOT_FOUND",
+    description: "No extraction with this ID is on the ledger.",
+  },
+  "recycling-already-exists": {
+    status: 409,
+    title: "Recycling already exists",
+    code: "RECYCLING_ALREADY_EXISTS",
+    description: "A recycling with this ID is already on the ledger.",
+  },
+  "version-conflict": {
+    status: 409,
+    title: "Record modified concurrently",
+    code: "CONFLICT",
+    description:
+      "The record changed since the expected version; re-read and retry.",
+  },
+  "waste-not-visible": {
+    status: 403,
+    title: "Waste not shared",
+    code: "WASTE_NOT_VISIBLE",
+    description:
+      "The lot is not shared with your organization (agreement, delegation or embargo).",
+  },
+  "feedback-throttled": {
+    status: 429,
+    title: "Feedback throttled",
+    code: "FEEDBACK_THROTTLED",
+    description: "The trace token accepts no more feedback for now.",
+  },
+  "plot-unavailable": {
+    status: 422,
+    title: "Plot unavailable",
+    code: "PLOT_UNAVAILABLE",
+    description: "The plot is not an active plot of your organization.",
+  },
+  "plot-farm-mismatch": {
+    status: 422,
+    title: "Plot belongs to another farm",
+    code: "PLOT_FARM_MISMATCH",
+    description: "The declared farm differs from the farm of the plot.",
+  },
+  "field-required": {
+    status: 422,
+    title: "Required field missing",
+    code: "FIELD_REQUIRED",
+    description:
+      "The validation profile of your organization requires this field.",
+  },
+  "waste-erased-at": {
+    status: 410,
+    title: "Version erased",
+    code: "WASTE_ERASED_AT",
+    description:
+      "The lot's participant had personal data erased after that version.",
+  },
+  "quota-exceeded": {
+    status: 422,
+    title: "Seasonal quota exceeded",
+    code: "QUOTA_EXCEEDED",
+    description:
+      "The declaration takes the farm over its blocking quota for the season.",
+  },
+  "rule-violated": {
+    status: 422,
+    title: "Validation rule violated",
+    code: "RULE_VIOLATED",
+    description:
+      "The asset breaks a blocking validation rule; the detail names the rule.",
+  },
+  "query-truncated": {
+    status: 422,
+    title: "Query over budget",
+    code: "QUERY_TRUNCATED",
+    description:
+      "The query reads more than the ledger's result budget; use a paged query.",
+  },
+  "onboarding-incomplete": {
+    status: 422,
+    title: "Onboarding incomplete",
+    code: "ONBOARDING_INCOMPLETE",
+    description:
+      "The participant has onboarding steps still to verify before activation.",
+  },
+  "participant-not-active": {
+    status: 422,
+    title: "Participant not active",
+    code: "PARTICIPANT_NOT_ACTIVE",
+    description:
+      "The participant's onboarding is under way; activate it before it acts.",
+  },
+  "chaincode-rejected": {
+    status: 422,
+    title: "Transaction rejected by chaincode",
+    description: "The chaincode refused the transaction; the detail says why.",
+  },
+
+  // Fabric validation codes of transactions that failed to commit
+  "ledger-conflict": {
+    status: 409,
+    title: "Ledger read conflict",
+    description:
+      "Another transaction changed the data read (MVCC or phantom read conflict); retry.",
+  },
+  "endorsement-policy-failure": {
+    status: 502,
+    title: "Endorsement policy not satisfied",
+    description: "Not enough organizations endorsed the transaction.",
+  },
+  "transaction-invalid": {
+    status: 502,
+    title: "Transaction invalidated",
+    description:
+      "The peers invalidated the transaction; the code gives the reason.",
+  },
+
+  // Gateway errors reaching the network
+  "blockchain-unavailable": {
+    status: 503,
+    title: "Blockchain unavailable",
+    description: "The gateway cannot reach the network; retry later.",
+  },
+  "commit-timeout": {
+    status: 504,
+    title: "Commit not confirmed in time",
+    description:
+      "The transaction was sent but its commit was not seen in time; check before resubmitting.",
+  },
+  "gateway-identity-missing": {
+    status: 500,
+    title: "Gateway identity missing",
+    description: "The wallet lacks the identity of the organization.",
+  },
+};
+
+const CHAINCODE_CODES = {};
+Object.entries(PROBLEM_TYPES).forEach(([slug, type]) => {
+  if (type.code) {
+    CHAINCODE_CODES[type.code] = slug;
+  }
+});
+
+const FABRIC_CONFLICT_CODES = ["MVCC_READ_CONFLICT", "PHANTOM_READ_CONFLICT"];
+
+// Problem type of a gateway-detected error by HTTP status
+const STATUS_TYPES = {
+  400: "bad-request",
+  401: "unauthorized",
+  403: "forbidden",
+  404: "not-found",
+  409: "conflict",
+  413: "payload-too-large",
+  422: "unprocessable-entity",
+  429: "too-many-requests",
+  503: "blockchain-unavailable",
+};
+
+const COMMIT_TIMEOUT = /Event strategy not satisfied|TimeoutError|DEADLINE_EXCEEDED/;
+
+const GATEWAY_UNAVAILABLE = [
+  /Blockchain client not initialized/,
+  /Failed to connect before the deadline/i,
+  /DiscoveryService/,
+  /\bUNAVAILABLE\b/,
+  /ECONNREFUSED/,
+];
+
+// Classify an error message from the Fabric SDK or the chaincode into a
+// problem type, with the chaincode or validation code when there is one
+const classifyError = (message) => {
+  const text = String(message || "");
+
+  const coded = text.match(/\b([A-Z][A-Z_]*[A-Z]): /g) || [];
+  for (const match of coded) {
+    const code = match.slice(0, -2);
+    if (CHAINCODE_CODES[code]) {
+      return { type: CHAINCODE_CODES[code], code };
+    }
+  }
+
+  const validation = text.match(/with status ([A-Z_]+)/);
+  if (validation) {
+    const code = validation[1];
+    if (FABRIC_CONFLICT_CODES.includes(code)) {
+      return { type: "ledger-conflict", code };
+    }
+    if (code === "ENDORSEMENT_POLICY_FAILURE") {
+      return { type: "endorsement-policy-failure", code };
+    }
+    return { type: "transaction-invalid", code };
+  }
+
+  if (COMMIT_TIMEOUT.test(text)) {
+    return { type: "commit-timeout" };
+  }
+  if (GATEWAY_UNAVAILABLE.some((pattern) => pattern.test(text))) {
+    return { type: "blockchain-unavailable" };
+  }
+  if (/Identity \S+ not found in wallet/.test(text)) {
+    return { type: "gateway-identity-missing" };
+  }
+  if (/No valid responses from any peers|status=500, message=/.test(text)) {
+    return { type: "chaincode-rejected" };
+  }
+  return null;
+};
+
+// Chaincode message of an endorsement error ("... message=<text>"), or the
+// text itself
+const chaincodeMessage = (text) => {
+  const match = String(text || "").match(/message=(.*)$/m);
+  return match ? match[1].trim() : text;
+};
+
+// Build the problem for an error body ({ error, details, ...extensions })
+// answered with status
+const toProblem = (req, status, body) => {
+  const { error, details, ...extensions } = body;
+  const classified =
+    status >= 500 || status === 409 ? classifyError(details) : null;
+
+  let typeName = classified?.type;
+  if (!typeName) {
+    typeName =
+      STATUS_TYPES[status] ||
+      (status >= 500 ? "internal-error" : "bad-request");
+  }
+  const type = PROBLEM_TYPES[typeName];
+  // Controllers answer 500 for any failure of the network call; the
+  // classified type knows better
+  const problemStatus = classified && status === 500 ? type.status : status;
+
+  return {
+    type: PROBLEM_BASE_URI + typeName,
+    title: classified ? type.title : error,
+    status: problemStatus,
+    detail: details ? chaincodeMessage(details) : error,
+    instance: req.originalUrl,
+    ...(classified?.code ? { code: classified.code } : {}),
+    ...extensions,
+    // Fields of the earlier error bodies, kept for existing clients
+    error,
+    details,
+  };
+};
+
+// Express middleware enforcing the envelopes on the JSON responses of /api
+const problemResponses = (req, res, next) => {
+  const json = res.json.bind(res);
+  res.json = (body) => {
+    const isObject = body && typeof body === "object" && !Array.isArray(body);
+    if (res.statusCode >= 400 && isObject && typeof body.error === "string") {
+      const problem = toProblem(req, res.statusCode, body);
+      res.status(problem.status);
+      res.type(PROBLEM_CONTENT_TYPE);
+      return json(problem);
+    }
+    if (
+      res.statusCode < 300 &&
+      req.originalUrl.startsWith("/api/") &&
+      !(isObject && "success" in body)
+    ) {
+      return json({ success: true, data: body });
+    }
+    return json(body);
+  };
+  next();
+};
+
+// Unknown routes
+const notFoundHandler = (req, res) => {
+  res.status(404).json({
+    error: "Not found",
+    details: `No route for ${req.method} ${req.path}`,
+  });
+};
+
+// Errors thrown out of handlers or middleware (e.g. malformed JSON bodies)
+const errorHandler = (error, req, res, next) => {
+  const status = error.status || error.statusCode || 500;
+  if (status >= 500) {
+    console.error("❌ Unhandled error:", error);
+  }
+  res.status(status).json({
+    error: status >= 500 ? "Internal server error" : "Invalid request",
+    details: error.message,
+  });
+};
+
+// Documentation of the problem types
+const listProblemTypes = (req, res) => {
+  res.status(200).json(
+    Object.entries(PROBLEM_TYPES).map(([name, type]) => ({
+      type: PROBLEM_BASE_URI + name,
+      ...type,
+    }))
+  );
+};
+
+const getProblemType = (req, res) => {
+  const type = PROBLEM_TYPES[req.params.type];
+  if (!type) {
+    return res.status(404).json({
+      error: "Unknown problem type",
+      details: `No problem type named '${req.params.type}'`,
+    });
+  }
+  res.status(200).json({ type: PROBLEM_BASE_URI + req.params.type, ...type });
+};
+
+module.exports = {
+  PROBLEM_TYPES,
+  classifyError,
+  problemResponses,
+  notFoundHandler,
+  errorHandler,
+  listProblemTypes,
+  getProblemType,
+};
@@ -0,0 +1,73 @@
+// Public statistics - the chaincode's k-anonymized network figures, cached
+// and refreshed whenever the indexer ingests events, so unauthenticated
+// requests never reach the ledger
+const { onIngested } = require("../indexer");
+
+const PUBLIC_ORG = process.env.INDEXER_ORG || "farmer";
+
+// Ingestions within this window trigger a single refresh
+const REFRESH_DEBOUNCE_MS =
+  parseInt(process.env.PUBLIC_STATS_DEBOUNCE_MS, 10) || 10000;
+
+let client = null;
+let statistics = null;
+let refreshedAt = null;
+let lastError = null;
+let timer = null;
+let refreshing = null;
+
+const refresh = async () => {
+  if (!client) {
+    return null;
+  }
+  if (!refreshing) {
+    refreshing = client
+      .query(PUBLIC_ORG, "GetPublicStatistics")
+      .then((result) => {
+        statistics = result;
+        refreshedAt = new Date().toISOString();
+        lastError = null;
+      })
+      .catch((error) => {
+        lastError = error.message;
+        console.warn(
+          "⚠️ Could not refresh public statistics:",
+          error.message
+        );
+      })
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  await refreshing;
+  return statistics;
+};
+
+const scheduleRefresh = () => {
+  if (timer) {
+    return;
+  }
+  timer = setTimeout(() => {
+    timer = null;
+    refresh();
+  }, REFRESH_DEBOUNCE_MS);
+  timer.unref();
+};
+
+// Refresh the figures with client's gateway identity after each ingestion
+const startPublicStatistics = (blockchainClient) => {
+  if (client) {
+    return;
+  }
+  client = blockchainClient;
+  onIngested(scheduleRefresh);
+};
+
+// The cached figures, read once when nothing was cached yet
+const getPublicStatistics = async () => ({
+  statistics: statistics || (await refresh()),
+  refreshedAt,
+  lastError,
+});
+
+module.exports = { startPublicStatistics, getPublicStatistics };
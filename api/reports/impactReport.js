@@ -0,0 +1,316 @@
+// Impact reports - the ledger's ComputeImpactReport aggregates for a buyer
+// and period, laid out as CSRD (ESRS) / GRI style disclosures. Every figure
+// lists the ledger transactions it was computed from, so an auditor can
+// check it against the ledger.
+const PdfDocument = require("./pdfDocument");
+const {
+  ReportWriter,
+  getBranding,
+  drawHeader,
+  drawFooters,
+} = require("./traceabilityReport");
+const { tenantForOrg } = require("../tenants");
+
+const REPORT_VERSION = "1.0";
+
+// Disclosures of the report and the ledger figure each one reports
+const DISCLOSURES = [
+  {
+    id: "waste-diverted",
+    gri: "GRI 306-4",
+    esrs: "ESRS E5-5",
+    metric: "divertedQuantity",
+    unit: "t",
+    lotValue: (lot) => lot.diverted,
+  },
+  {
+    id: "waste-recycled",
+    gri: "GRI 306-4 (b-ii)",
+    esrs: "ESRS E5-5",
+    metric: "recycledQuantity",
+    unit: "t",
+    lotValue: (lot) => lot.recycled,
+  },
+  {
+    id: "waste-donated",
+    gri: "GRI 306-4 (b-i)",
+    esrs: "ESRS E5-5",
+    metric: "donatedQuantity",
+    unit: "t",
+    lotValue: (lot) => lot.donated,
+  },
+  {
+    id: "waste-landfilled",
+    gri: "GRI 306-5",
+    esrs: "ESRS E5-5",
+    metric: "landfilledQuantity",
+    unit: "t",
+    lotValue: (lot) => lot.landfilled,
+  },
+  {
+    id: "diversion-rate",
+    gri: "GRI 306-4",
+    esrs: "ESRS E5-5",
+    metric: "diversionRate",
+    unit: "%",
+    lotValue: (lot) => lot.quantity,
+  },
+  {
+    id: "co2e-avoided",
+    gri: "GRI 305-5",
+    esrs: "ESRS E1 (entity-specific)",
+    metric: "co2eAvoided",
+    unit: "tCO2e",
+    lotValue: (lot) => lot.co2eAvoided,
+  },
+  {
+    id: "certified-farms",
+    gri: "GRI 308-1",
+    esrs: "ESRS G1-2",
+    metric: "certifiedFarmShare",
+    unit: "%",
+    lotValue: (lot) => lot.certifications.length,
+  },
+];
+
+// Report labels per language; other languages fall back to English
+const LABELS = {
+  en: {
+    title: "Impact report",
+    subtitle: (buyer, period) => `${buyer} - ${period}`,
+    allTime: "all time",
+    summary: "Reporting scope",
+    buyer: "Buyer organization",
+    period: "Reporting period",
+    lots: "Lots acquired",
+    acquired: "Quantity acquired (t)",
+    farms: "Supplying farms",
+    disclosures: "Disclosures",
+    "waste-diverted": "Waste diverted from disposal",
+    "waste-recycled": "Waste recycled",
+    "waste-donated": "Waste donated for reuse",
+    "waste-landfilled": "Waste directed to disposal",
+    "diversion-rate": "Diversion rate",
+    "co2e-avoided": "GHG emissions avoided by recycling",
+    "certified-farms": "Share of certified supplying farms",
+    transactions: (count) => `${count} ledger transaction(s)`,
+    ledger: "Ledger evidence",
+    lot: "Lot",
+    noLots: "No lot acquired over the period.",
+    methodology: "Methodology",
+    generated: "Generated",
+    page: "page",
+  },
+  fr: {
+    title: "Rapport d'impact",
+    subtitle: (buyer, period) => `${buyer} - ${period}`,
+    allTime: "depuis l'origine",
+    summary: "Périmètre",
+    buyer: "Organisation acheteuse",
+    period: "Période",
+    lots: "Lots acquis",
+    acquired: "Quantité acquise (t)",
+    farms: "Exploitations fournisseuses",
+    disclosures: "Indicateurs",
+    "waste-diverted": "Déchets détournés de l'élimination",
+    "waste-recycled": "Déchets recyclés",
+    "waste-donated": "Déchets donnés pour réemploi",
+    "waste-landfilled": "Déchets destinés à l'élimination",
+    "diversion-rate": "Taux de détournement",
+    "co2e-avoided": "Émissions de GES évitées par le recyclage",
+    "certified-farms": "Part des exploitations certifiées",
+    transactions: (count) => `${count} transaction(s) du registre`,
+    ledger: "Preuves du registre",
+    lot: "Lot",
+    noLots: "Aucun lot acquis sur la période.",
+    methodology: "Méthodologie",
+    generated: "Généré le",
+    page: "page",
+  },
+};
+
+const METHODOLOGY = [
+  "Lots are those invoiced to the buyer, or delivered to it under a supply " +
+    "contract, over the period; a lot acquired both ways counts once.",
+  "A lot disposed of counts what it still held as directed to disposal, a " +
+    "lot donated what it still held as donated; the rest of the lot was " +
+    "diverted from disposal.",
+  "Avoided emissions are those recorded on chain by each recycling, shared " +
+    "between its input lots in proportion of the quantity taken from each.",
+  "A farm counts as certified when one of its lots carries a certification " +
+    "document of the listed types anchored on the ledger.",
+];
+
+const pad = (value) => String(value).padStart(2, "0");
+
+// Reporting period from ?period= (YYYY, YYYY-Qn or YYYY-MM), else from
+// ?from= and ?to= (YYYY-MM-DD); throws on a malformed period
+const resolvePeriod = ({ period, from, to } = {}) => {
+  if (!period) {
+    return { label: "", from: from || "", to: to || "" };
+  }
+  const match = /^(\d{4})(?:-(?:Q([1-4])|(0[1-9]|1[0-2])))?$/i.exec(period);
+  if (!match) {
+    throw new Error("'period' must be YYYY, YYYY-Qn or YYYY-MM");
+  }
+  const year = parseInt(match[1], 10);
+  let [first, last] = [1, 12];
+  if (match[2]) {
+    first = (parseInt(match[2], 10) - 1) * 3 + 1;
+    last = first + 2;
+  } else if (match[3]) {
+    first = last = parseInt(match[3], 10);
+  }
+  const lastDay = new Date(Date.UTC(year, last, 0)).getUTCDate();
+  return {
+    label: period.toUpperCase(),
+    from: `${year}-${pad(first)}-01`,
+    to: `${year}-${pad(last)}-${pad(lastDay)}`,
+  };
+};
+
+// Transactions a set of lots were computed from, each once
+const lotTransactions = (lots) => [
+  ...new Set(
+    lots.flatMap((lot) => lot.sources.map((source) => source.txId))
+  ),
+].filter(Boolean);
+
+// Lays out a ledger impact report (ComputeImpactReport) as a structured,
+// framework-tagged report
+const buildImpactReport = (ledger, { period, tenant, generatedAt } = {}) => {
+  const lots = ledger.lots || [];
+  const sources = {};
+  lots.forEach((lot) =>
+    lot.sources.forEach((source) => {
+      if (source.txId) {
+        sources[source.txId] = source;
+      }
+    })
+  );
+
+  return {
+    reportType: "IMPACT_REPORT",
+    version: REPORT_VERSION,
+    frameworks: ["CSRD/ESRS", "GRI"],
+    reportingEntity: {
+      buyer: ledger.buyer,
+      tenant: (tenant || tenantForOrg()).name,
+    },
+    period: {
+      label: period?.label || "",
+      from: ledger.from,
+      to: ledger.to,
+    },
+    scope: {
+      lots: ledger.lotCount,
+      acquiredQuantity: ledger.acquiredQuantity,
+      farms: ledger.farms,
+      certifiedFarms: ledger.certifiedFarms,
+      fullyValorizedLots: ledger.fullyValorizedLots,
+      certificationTypes: ledger.certificationTypes,
+    },
+    disclosures: DISCLOSURES.map((disclosure) => {
+      const contributing = lots.filter((lot) => disclosure.lotValue(lot) > 0);
+      return {
+        id: disclosure.id,
+        standards: { gri: disclosure.gri, esrs: disclosure.esrs },
+        value: ledger[disclosure.metric],
+        unit: disclosure.unit,
+        lots: contributing.map((lot) => lot.wasteId),
+        transactions: lotTransactions(contributing),
+      };
+    }),
+    ledger: {
+      computedAt: ledger.computedAt,
+      lots,
+      transactions: Object.values(sources),
+    },
+    methodology: METHODOLOGY,
+    generatedAt: generatedAt || new Date().toISOString(),
+  };
+};
+
+// Renders a structured impact report as a PDF buffer, branded for tenant
+// (see api/tenants) and labelled in language
+const renderImpactReport = (report, { tenant, language } = {}) => {
+  const branding = getBranding(tenant || tenantForOrg());
+  const labels = LABELS[language] || LABELS.en;
+  const { period } = report;
+  const periodLabel =
+    period.label ||
+    (period.from || period.to
+      ? `${period.from || "..."} / ${period.to || "..."}`
+      : labels.allTime);
+
+  const doc = new PdfDocument({
+    title: `${labels.title} ${report.reportingEntity.buyer} ${periodLabel}`,
+    author: branding.name,
+  });
+  const writer = new ReportWriter(doc, branding);
+
+  drawHeader(
+    doc,
+    branding,
+    labels.title,
+    labels.subtitle(report.reportingEntity.buyer, periodLabel)
+  );
+  writer.y = doc.height - 130;
+
+  writer.heading(labels.summary);
+  writer.field(labels.buyer, report.reportingEntity.buyer);
+  writer.field(labels.period, periodLabel);
+  writer.field(labels.lots, report.scope.lots);
+  writer.field(labels.acquired, report.scope.acquiredQuantity);
+  writer.field(labels.farms, report.scope.farms);
+
+  writer.heading(labels.disclosures);
+  report.disclosures.forEach((disclosure) => {
+    writer.field(
+      labels[disclosure.id],
+      `${disclosure.value} ${disclosure.unit}`
+    );
+    writer.paragraph(
+      `${disclosure.standards.gri} / ${disclosure.standards.esrs} - ` +
+        labels.transactions(disclosure.transactions.length),
+      { size: 8, indent: 15 }
+    );
+  });
+
+  writer.heading(labels.ledger);
+  if (report.ledger.lots.length === 0) {
+    writer.paragraph(labels.noLots);
+  }
+  report.ledger.lots.forEach((lot) => {
+    writer.paragraph(
+      `${labels.lot} ${lot.wasteId}  ${lot.acquiredBy} ${lot.acquisitionId}` +
+        `  ${lot.quantity} t, ${lot.diverted} t diverted, ` +
+        `${lot.co2eAvoided} tCO2e`
+    );
+    lot.sources.forEach((source) => {
+      writer.paragraph(`${source.assetType} ${source.id}  tx ${source.txId}`, {
+        size: 8,
+        indent: 15,
+      });
+    });
+  });
+
+  writer.heading(labels.methodology);
+  report.methodology.forEach((note) => writer.paragraph(note, { size: 9 }));
+
+  drawFooters(
+    doc,
+    branding,
+    (index, count) =>
+      `${labels.generated} ${report.generatedAt} - ${labels.page} ` +
+      `${index + 1}/${count}`
+  );
+
+  return doc.toBuffer();
+};
+
+module.exports = {
+  resolvePeriod,
+  buildImpactReport,
+  renderImpactReport,
+};
@@ -0,0 +1,261 @@
+// Minimal PDF writer for text, rectangles, lines and JPEG images (PDF 1.4,
+// standard fonts)
+// Keeps the report service free of native or heavyweight dependencies.
+
+const PAGE_WIDTH = 595; // A4 in points
+const PAGE_HEIGHT = 842;
+
+const FONTS = {
+  regular: "F1",
+  bold: "F2",
+};
+
+// Helvetica average glyph width as a fraction of the font size
+const AVERAGE_CHAR_WIDTH = 0.5;
+
+const formatNumber = (value) =>
+  Number.isInteger(value) ? String(value) : value.toFixed(2);
+
+const formatColor = ([r, g, b]) =>
+  [r, g, b].map((c) => formatNumber(c / 255)).join(" ");
+
+// Escapes a string for a PDF literal and restricts it to the WinAnsi range
+const escapeText = (text) =>
+  String(text)
+    .replace(/[^\x20-\xff]/g, "?")
+    .replace(/\\/g, "\\\\")
+    .replace(/\(/g, "\\(")
+    .replace(/\)/g, "\\)");
+
+// JPEG start-of-frame markers (SOF0-SOF15 but DHT, JPG and DAC)
+const isFrameMarker = (marker) =>
+  marker >= 0xc0 && marker <= 0xcf && ![0xc4, 0xc8, 0xcc].includes(marker);
+
+const JPEG_COLOR_SPACES = {
+  1: "DeviceGray",
+  3: "DeviceRGB",
+  4: "DeviceCMYK",
+};
+
+// Reads the size and color components of a JPEG image from its frame header
+const jpegInfo = (data) => {
+  if (!Buffer.isBuffer(data) || data[0] !== 0xff || data[1] !== 0xd8) {
+    throw new Error("image is not a JPEG file");
+  }
+  let offset = 2;
+  while (offset + 9 < data.length) {
+    if (data[offset] !== 0xff) {
+      offset++;
+      continue;
+    }
+    const marker = data[offset + 1];
+    if (isFrameMarker(marker)) {
+      const info = {
+        height: data.readUInt16BE(offset + 5),
+        width: data.readUInt16BE(offset + 7),
+        components: data[offset + 9],
+      };
+      if (!JPEG_COLOR_SPACES[info.components]) {
+        throw new Error(`unsupported JPEG with ${info.components} components`);
+      }
+      return info;
+    }
+    offset += 2 + data.readUInt16BE(offset + 2);
+  }
+  throw new Error("JPEG file has no frame header");
+};
+
+class PdfDocument {
+  constructor({ title = "", author = "" } = {}) {
+    this.title = title;
+    this.author = author;
+    this.pages = [];
+    this.images = [];
+    this.addPage();
+  }
+
+  static jpegInfo(data) {
+    return jpegInfo(data);
+  }
+
+  get width() {
+    return PAGE_WIDTH;
+  }
+
+  get height() {
+    return PAGE_HEIGHT;
+  }
+
+  addPage() {
+    this.current = [];
+    this.pages.push(this.current);
+    return this;
+  }
+
+  // Draws text with its baseline at (x, y), origin at the bottom-left corner
+  text(str, x, y, { size = 10, bold = false, color = [0, 0, 0] } = {}) {
+    this.current.push(
+      "BT",
+      `${formatColor(color)} rg`,
+      `/${bold ? FONTS.bold : FONTS.regular} ${formatNumber(size)} Tf`,
+      `${formatNumber(x)} ${formatNumber(y)} Td`,
+      `(${escapeText(str)}) Tj`,
+      "ET"
+    );
+    return this;
+  }
+
+  rect(x, y, width, height, { fill = [0, 0, 0] } = {}) {
+    this.current.push(
+      `${formatColor(fill)} rg`,
+      `${formatNumber(x)} ${formatNumber(y)} ${formatNumber(
+        width
+      )} ${formatNumber(height)} re f`
+    );
+    return this;
+  }
+
+  line(x1, y1, x2, y2, { color = [0, 0, 0], width = 1 } = {}) {
+    this.current.push(
+      `${formatColor(color)} RG`,
+      `${formatNumber(width)} w`,
+      `${formatNumber(x1)} ${formatNumber(y1)} m ${formatNumber(
+        x2
+      )} ${formatNumber(y2)} l S`
+    );
+    return this;
+  }
+
+  // Draws a JPEG image into the box with its bottom-left corner at (x, y);
+  // an image drawn again is embedded once
+  image(data, x, y, width, height) {
+    let image = this.images.find((candidate) => candidate.data === data);
+    if (!image) {
+      image = {
+        name: `Im${this.images.length + 1}`,
+        data,
+        ...jpegInfo(data),
+      };
+      this.images.push(image);
+    }
+    this.current.push(
+      "q",
+      `${formatNumber(width)} 0 0 ${formatNumber(height)} ${formatNumber(
+        x
+      )} ${formatNumber(y)} cm`,
+      `/${image.name} Do`,
+      "Q"
+    );
+    return this;
+  }
+
+  // Approximate width of a string, good enough for wrapping
+  measure(str, size = 10) {
+    return String(str).length * size * AVERAGE_CHAR_WIDTH;
+  }
+
+  // Splits text into lines fitting the given width
+  wrap(str, maxWidth, size = 10) {
+    const maxChars = Math.max(
+      1,
+      Math.floor(maxWidth / (size * AVERAGE_CHAR_WIDTH))
+    );
+    const lines = [];
+    let line = "";
+    for (const word of String(str).split(/\s+/)) {
+      const candidate = line ? `${line} ${word}` : word;
+      if (candidate.length <= maxChars) {
+        line = candidate;
+        continue;
+      }
+      if (line) {
+        lines.push(line);
+      }
+      line = word;
+      while (line.length > maxChars) {
+        lines.push(line.slice(0, maxChars));
+        line = line.slice(maxChars);
+      }
+    }
+    if (line) {
+      lines.push(line);
+    }
+    return lines;
+  }
+
+  toBuffer() {
+    const objects = [];
+    const addObject = (body) => {
+      objects.push(body);
+      return objects.length;
+    };
+
+    const catalogId = addObject(null);
+    const pagesId = addObject(null);
+    const regularFontId = addObject(
+      "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+    );
+    const boldFontId = addObject(
+      "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"
+    );
+    const infoId = addObject(
+      `<< /Title (${escapeText(this.title)}) /Author (${escapeText(
+        this.author
+      )}) /Producer (Green Olive Chain) >>`
+    );
+
+    const imageIds = this.images.map((image) =>
+      addObject(
+        `<< /Type /XObject /Subtype /Image /Width ${image.width} /Height ${image.height} ` +
+          `/ColorSpace /${JPEG_COLOR_SPACES[image.components]} /BitsPerComponent 8 ` +
+          `/Filter /DCTDecode /Length ${image.data.length} >>\n` +
+          `stream\n${image.data.toString("latin1")}\nendstream`
+      )
+    );
+    const xObjects = this.images
+      .map((image, index) => `/${image.name} ${imageIds[index]} 0 R`)
+      .join(" ");
+
+    const pageIds = this.pages.map((operations) => {
+      const content = operations.join("\n");
+      const contentId = addObject(
+        `<< /Length ${Buffer.byteLength(
+          content,
+          "latin1"
+        )} >>\nstream\n${content}\nendstream`
+      );
+      return addObject(
+        `<< /Type /Page /Parent ${pagesId} 0 R /MediaBox [0 0 ${PAGE_WIDTH} ${PAGE_HEIGHT}] ` +
+          `/Resources << /Font << /${FONTS.regular} ${regularFontId} 0 R /${FONTS.bold} ${boldFontId} 0 R >>` +
+          (xObjects ? ` /XObject << ${xObjects} >>` : "") +
+          " >> " +
+          `/Contents ${contentId} 0 R >>`
+      );
+    });
+
+    objects[catalogId - 1] = `<< /Type /Catalog /Pages ${pagesId} 0 R >>`;
+    objects[pagesId - 1] = `<< /Type /Pages /Kids [${pageIds
+      .map((id) => `${id} 0 R`)
+      .join(" ")}] /Count ${pageIds.length} >>`;
+
+    let output = "%PDF-1.4\n";
+    const offsets = objects.map((body, index) => {
+      const offset = Buffer.byteLength(output, "latin1");
+      output += `${index + 1} 0 obj\n${body}\nendobj\n`;
+      return offset;
+    });
+
+    const xrefOffset = Buffer.byteLength(output, "latin1");
+    output += `xref\n0 ${objects.length + 1}\n0000000000 65535 f \n`;
+    offsets.forEach((offset) => {
+      output += `${String(offset).padStart(10, "0")} 00000 n \n`;
+    });
+    output +=
+      `trailer\n<< /Size ${objects.length + 1} /Root ${catalogId} 0 R /Info ${infoId} 0 R >>\n` +
+      `startxref\n${xrefOffset}\n%%EOF\n`;
+
+    return Buffer.from(output, "latin1");
+  }
+}
+
+module.exports = PdfDocument;
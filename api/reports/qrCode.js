@@ -0,0 +1,421 @@
+// Minimal QR Code encoder (byte mode, error correction level M, versions 1-10)
+// Enough to encode trace URLs into the PDF reports without extra dependencies.
+
+const MAX_VERSION = 10;
+
+// Error correction codewords per block and block count for level M, by version
+const ECC_CODEWORDS_PER_BLOCK = [-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26];
+const NUM_ERROR_CORRECTION_BLOCKS = [-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5];
+
+// Format bits for error correction level M
+const ECC_FORMAT_BITS = 0;
+
+const getBit = (value, index) => ((value >>> index) & 1) !== 0;
+
+const getNumRawDataModules = (version) => {
+  let result = (16 * version + 128) * version + 64;
+  if (version >= 2) {
+    const numAlign = Math.floor(version / 7) + 2;
+    result -= (25 * numAlign - 10) * numAlign - 55;
+    if (version >= 7) {
+      result -= 36;
+    }
+  }
+  return result;
+};
+
+const getNumDataCodewords = (version) =>
+  Math.floor(getNumRawDataModules(version) / 8) -
+  ECC_CODEWORDS_PER_BLOCK[version] * NUM_ERROR_CORRECTION_BLOCKS[version];
+
+const getAlignmentPatternPositions = (version) => {
+  if (version === 1) {
+    return [];
+  }
+  const size = version * 4 + 17;
+  const numAlign = Math.floor(version / 7) + 2;
+  const step = Math.ceil((version * 4 + 4) / (numAlign * 2 - 2)) * 2;
+  const result = [6];
+  for (let pos = size - 7; result.length < numAlign; pos -= step) {
+    result.splice(1, 0, pos);
+  }
+  return result;
+};
+
+// Galois field GF(2^8) multiplication modulo x^8 + x^4 + x^3 + x^2 + 1
+const reedSolomonMultiply = (x, y) => {
+  let z = 0;
+  for (let i = 7; i >= 0; i--) {
+    z = (z << 1) ^ ((z >>> 7) * 0x11d);
+    z ^= ((y >>> i) & 1) * x;
+  }
+  return z;
+};
+
+const reedSolomonComputeDivisor = (degree) => {
+  const result = new Array(degree - 1).fill(0);
+  result.push(1);
+  let root = 1;
+  for (let i = 0; i < degree; i++) {
+    for (let j = 0; j < result.length; j++) {
+      result[j] = reedSolomonMultiply(result[j], root);
+      if (j + 1 < result.length) {
+        result[j] ^= result[j + 1];
+      }
+    }
+    root = reedSolomonMultiply(root, 0x02);
+  }
+  return result;
+};
+
+const reedSolomonComputeRemainder = (data, divisor) => {
+  const result = divisor.map(() => 0);
+  for (const b of data) {
+    const factor = b ^ result.shift();
+    result.push(0);
+    divisor.forEach((coef, i) => {
+      result[i] ^= reedSolomonMultiply(coef, factor);
+    });
+  }
+  return result;
+};
+
+// Splits data into blocks, appends error correction and interleaves them
+const addEccAndInterleave = (data, version) => {
+  const numBlocks = NUM_ERROR_CORRECTION_BLOCKS[version];
+  const blockEccLen = ECC_CODEWORDS_PER_BLOCK[version];
+  const rawCodewords = Math.floor(getNumRawDataModules(version) / 8);
+  const numShortBlocks = numBlocks - (rawCodewords % numBlocks);
+  const shortBlockLen = Math.floor(rawCodewords / numBlocks);
+
+  const divisor = reedSolomonComputeDivisor(blockEccLen);
+  const blocks = [];
+  for (let i = 0, k = 0; i < numBlocks; i++) {
+    const dat = data.slice(
+      k,
+      k + shortBlockLen - blockEccLen + (i < numShortBlocks ? 0 : 1)
+    );
+    k += dat.length;
+    const ecc = reedSolomonComputeRemainder(dat, divisor);
+    if (i < numShortBlocks) {
+      dat.push(0);
+    }
+    blocks.push(dat.concat(ecc));
+  }
+
+  const result = [];
+  for (let i = 0; i < blocks[0].length; i++) {
+    blocks.forEach((block, j) => {
+      if (i !== shortBlockLen - blockEccLen || j >= numShortBlocks) {
+        result.push(block[i]);
+      }
+    });
+  }
+  return result;
+};
+
+const buildDataCodewords = (bytes, version) => {
+  const bits = [];
+  const appendBits = (value, length) => {
+    for (let i = length - 1; i >= 0; i--) {
+      bits.push((value >>> i) & 1);
+    }
+  };
+
+  appendBits(0x4, 4); // byte mode
+  appendBits(bytes.length, version < 10 ? 8 : 16);
+  bytes.forEach((b) => appendBits(b, 8));
+
+  const capacityBits = getNumDataCodewords(version) * 8;
+  appendBits(0, Math.min(4, capacityBits - bits.length));
+  appendBits(0, (8 - (bits.length % 8)) % 8);
+  for (let pad = 0xec; bits.length < capacityBits; pad ^= 0xec ^ 0x11) {
+    appendBits(pad, 8);
+  }
+
+  const codewords = [];
+  for (let i = 0; i < bits.length; i += 8) {
+    codewords.push(bits.slice(i, i + 8).reduce((acc, bit) => (acc << 1) | bit));
+  }
+  return codewords;
+};
+
+class QrMatrix {
+  constructor(version) {
+    this.version = version;
+    this.size = version * 4 + 17;
+    this.modules = [];
+    this.isFunction = [];
+    for (let i = 0; i < this.size; i++) {
+      this.modules.push(new Array(this.size).fill(false));
+      this.isFunction.push(new Array(this.size).fill(false));
+    }
+  }
+
+  setFunctionModule(x, y, dark) {
+    this.modules[y][x] = dark;
+    this.isFunction[y][x] = true;
+  }
+
+  drawFunctionPatterns() {
+    for (let i = 0; i < this.size; i++) {
+      this.setFunctionModule(6, i, i % 2 === 0);
+      this.setFunctionModule(i, 6, i % 2 === 0);
+    }
+
+    this.drawFinderPattern(3, 3);
+    this.drawFinderPattern(this.size - 4, 3);
+    this.drawFinderPattern(3, this.size - 4);
+
+    const alignPos = getAlignmentPatternPositions(this.version);
+    const numAlign = alignPos.length;
+    for (let i = 0; i < numAlign; i++) {
+      for (let j = 0; j < numAlign; j++) {
+        const isFinderCorner =
+          (i === 0 && j === 0) ||
+          (i === 0 && j === numAlign - 1) ||
+          (i === numAlign - 1 && j === 0);
+        if (!isFinderCorner) {
+          this.drawAlignmentPattern(alignPos[i], alignPos[j]);
+        }
+      }
+    }
+
+    this.drawFormatBits(0);
+    this.drawVersion();
+  }
+
+  drawFinderPattern(x, y) {
+    for (let dy = -4; dy <= 4; dy++) {
+      for (let dx = -4; dx <= 4; dx++) {
+        const dist = Math.max(Math.abs(dx), Math.abs(dy));
+        const xx = x + dx;
+        const yy = y + dy;
+        if (xx >= 0 && xx < this.size && yy >= 0 && yy < this.size) {
+          this.setFunctionModule(xx, yy, dist !== 2 && dist !== 4);
+        }
+      }
+    }
+  }
+
+  drawAlignmentPattern(x, y) {
+    for (let dy = -2; dy <= 2; dy++) {
+      for (let dx = -2; dx <= 2; dx++) {
+        this.setFunctionModule(
+          x + dx,
+          y + dy,
+          Math.max(Math.abs(dx), Math.abs(dy)) !== 1
+        );
+      }
+    }
+  }
+
+  drawFormatBits(mask) {
+    const data = (ECC_FORMAT_BITS << 3) | mask;
+    let rem = data;
+    for (let i = 0; i < 10; i++) {
+      rem = (rem << 1) ^ ((rem >>> 9) * 0x537);
+    }
+    const bits = ((data << 10) | rem) ^ 0x5412;
+
+    for (let i = 0; i <= 5; i++) {
+      this.setFunctionModule(8, i, getBit(bits, i));
+    }
+    this.setFunctionModule(8, 7, getBit(bits, 6));
+    this.setFunctionModule(8, 8, getBit(bits, 7));
+    this.setFunctionModule(7, 8, getBit(bits, 8));
+    for (let i = 9; i < 15; i++) {
+      this.setFunctionModule(14 - i, 8, getBit(bits, i));
+    }
+
+    for (let i = 0; i < 8; i++) {
+      this.setFunctionModule(this.size - 1 - i, 8, getBit(bits, i));
+    }
+    for (let i = 8; i < 15; i++) {
+      this.setFunctionModule(8, this.size - 15 + i, getBit(bits, i));
+    }
+    this.setFunctionModule(8, this.size - 8, true);
+  }
+
+  drawVersion() {
+    if (this.version < 7) {
+      return;
+    }
+    let rem = this.version;
+    for (let i = 0; i < 12; i++) {
+      rem = (rem << 1) ^ ((rem >>> 11) * 0x1f25);
+    }
+    const bits = (this.version << 12) | rem;
+    for (let i = 0; i < 18; i++) {
+      const bit = getBit(bits, i);
+      const a = this.size - 11 + (i % 3);
+      const b = Math.floor(i / 3);
+      this.setFunctionModule(a, b, bit);
+      this.setFunctionModule(b, a, bit);
+    }
+  }
+
+  drawCodewords(data) {
+    let i = 0;
+    for (let right = this.size - 1; right >= 1; right -= 2) {
+      if (right === 6) {
+        right = 5;
+      }
+      for (let vert = 0; vert < this.size; vert++) {
+        for (let j = 0; j < 2; j++) {
+          const x = right - j;
+          const upward = ((right + 1) & 2) === 0;
+          const y = upward ? this.size - 1 - vert : vert;
+          if (!this.isFunction[y][x] && i < data.length * 8) {
+            this.modules[y][x] = getBit(data[i >>> 3], 7 - (i & 7));
+            i++;
+          }
+        }
+      }
+    }
+  }
+
+  applyMask(mask) {
+    for (let y = 0; y < this.size; y++) {
+      for (let x = 0; x < this.size; x++) {
+        let invert;
+        switch (mask) {
+          case 0:
+            invert = (x + y) % 2 === 0;
+            break;
+          case 1:
+            invert = y % 2 === 0;
+            break;
+          case 2:
+            invert = x % 3 === 0;
+            break;
+          case 3:
+            invert = (x + y) % 3 === 0;
+            break;
+          case 4:
+            invert = (Math.floor(x / 3) + Math.floor(y / 2)) % 2 === 0;
+            break;
+          case 5:
+            invert = ((x * y) % 2) + ((x * y) % 3) === 0;
+            break;
+          case 6:
+            invert = (((x * y) % 2) + ((x * y) % 3)) % 2 === 0;
+            break;
+          default:
+            invert = (((x + y) % 2) + ((x * y) % 3)) % 2 === 0;
+        }
+        if (!this.isFunction[y][x] && invert) {
+          this.modules[y][x] = !this.modules[y][x];
+        }
+      }
+    }
+  }
+
+  // Penalty score from the four standard rules, used to pick the best mask
+  getPenaltyScore() {
+    const size = this.size;
+    const lines = [];
+    for (let y = 0; y < size; y++) {
+      lines.push(this.modules[y]);
+    }
+    for (let x = 0; x < size; x++) {
+      lines.push(this.modules.map((row) => row[x]));
+    }
+
+    let penalty = 0;
+    const finderLike = /(0000)?1011101(0000)?/g;
+    for (const line of lines) {
+      let runColor = line[0];
+      let runLength = 1;
+      for (let i = 1; i <= line.length; i++) {
+        if (i < line.length && line[i] === runColor) {
+          runLength++;
+          continue;
+        }
+        if (runLength >= 5) {
+          penalty += 3 + (runLength - 5);
+        }
+        if (i < line.length) {
+          runColor = line[i];
+          runLength = 1;
+        }
+      }
+
+      const text = "0000" + line.map((m) => (m ? "1" : "0")).join("") + "0000";
+      for (const match of text.matchAll(finderLike)) {
+        if (match[1] || match[2]) {
+          penalty += 40;
+        }
+      }
+    }
+
+    let dark = 0;
+    for (let y = 0; y < size; y++) {
+      for (let x = 0; x < size; x++) {
+        const color = this.modules[y][x];
+        if (color) {
+          dark++;
+        }
+        if (
+          x < size - 1 &&
+          y < size - 1 &&
+          color === this.modules[y][x + 1] &&
+          color === this.modules[y + 1][x] &&
+          color === this.modules[y + 1][x + 1]
+        ) {
+          penalty += 3;
+        }
+      }
+    }
+
+    const total = size * size;
+    penalty += Math.floor(Math.abs(dark * 20 - total * 10) / total) * 10;
+    return penalty;
+  }
+}
+
+// Encodes text into a QR code and returns its size and module matrix
+const encodeQrCode = (text) => {
+  const bytes = Array.from(Buffer.from(String(text), "utf8"));
+
+  let version = 1;
+  for (; version <= MAX_VERSION; version++) {
+    const neededBits = 4 + (version < 10 ? 8 : 16) + bytes.length * 8;
+    if (neededBits <= getNumDataCodewords(version) * 8) {
+      break;
+    }
+  }
+  if (version > MAX_VERSION) {
+    throw new Error(
+      `QR payload too long (${bytes.length} bytes, max version ${MAX_VERSION})`
+    );
+  }
+
+  const codewords = addEccAndInterleave(
+    buildDataCodewords(bytes, version),
+    version
+  );
+
+  const qr = new QrMatrix(version);
+  qr.drawFunctionPatterns();
+  qr.drawCodewords(codewords);
+
+  let bestMask = 0;
+  let minPenalty = Infinity;
+  for (let mask = 0; mask < 8; mask++) {
+    qr.applyMask(mask);
+    qr.drawFormatBits(mask);
+    const penalty = qr.getPenaltyScore();
+    if (penalty < minPenalty) {
+      bestMask = mask;
+      minPenalty = penalty;
+    }
+    qr.applyMask(mask); // XOR again to undo
+  }
+  qr.applyMask(bestMask);
+  qr.drawFormatBits(bestMask);
+
+  return { version, size: qr.size, modules: qr.modules };
+};
+
+module.exports = { encodeQrCode };
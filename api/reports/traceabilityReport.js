@@ -0,0 +1,441 @@
+// Traceability certificate rendering (branded PDF with provenance and QR code)
+const crypto = require("crypto");
+const PdfDocument = require("./pdfDocument");
+const { encodeQrCode } = require("./qrCode");
+const { tenantForOrg } = require("../tenants");
+
+const MARGIN = 50;
+const LINE_HEIGHT = 14;
+
+// Indicative avoided emissions in tCO2e per tonne of valorized waste, used for
+// recyclings recorded before the method catalog stored factors on-chain
+const CARBON_FACTORS = {
+  composting: 0.42,
+  compost: 0.42,
+  biochar: 1.9,
+  biogas: 0.65,
+  pellets: 1.2,
+  default: 0.3,
+};
+
+const parseHexColor = (hex, fallback) => {
+  const match = /^#?([0-9a-f]{6})$/i.exec(hex || "");
+  if (!match) {
+    return fallback;
+  }
+  const value = parseInt(match[1], 16);
+  return [(value >> 16) & 0xff, (value >> 8) & 0xff, value & 0xff];
+};
+
+// Report labels per language; a tenant language without labels falls back
+// to English
+const LABELS = {
+  en: {
+    certificate: "Traceability Certificate",
+    title: "Traceability certificate",
+    lot: "Lot",
+    lotSummary: "Lot summary",
+    wasteId: "Waste ID",
+    type: "Type",
+    quantity: "Quantity",
+    harvestDate: "Harvest date",
+    farm: "Farm",
+    location: "Location",
+    plot: "Plot",
+    owner: "Owner",
+    currentStatus: "Current status",
+    qualityGrade: "Quality grade",
+    extraction: "Extraction",
+    extractionId: "Extraction ID",
+    product: "Product",
+    quality: "Quality",
+    processor: "Processor",
+    date: "Date",
+    output: "Output",
+    recycled: "recycled",
+    processLoss: "Process loss",
+    recycling: "Recycling",
+    recyclingId: "Recycling ID",
+    method: "Method",
+    recycler: "Recycler",
+    weather: "Weather before harvest",
+    source: "Source",
+    provenance: "Provenance chain",
+    noEvents: "No lifecycle events recorded.",
+    by: "by",
+    documents: "Certificates and documents",
+    noDocuments: "No documents anchored on the ledger.",
+    carbon: "Carbon savings",
+    valorizedQuantity: "Valorized quantity",
+    co2eAvoided: "CO2e avoided (t)",
+    catalogFactor: (factor) =>
+      `Computed with the ledger factor of ${factor} tCO2e per tonne for this method.`,
+    estimateFactor: (factor) =>
+      `Indicative estimate using a factor of ${factor} tCO2e per tonne.`,
+    noRecycling: "No recycling recorded yet for this lot.",
+    generated: "Generated",
+    page: "page",
+  },
+  fr: {
+    certificate: "Certificat de traçabilité",
+    title: "Certificat de traçabilité",
+    lot: "Lot",
+    lotSummary: "Résumé du lot",
+    wasteId: "ID du déchet",
+    type: "Type",
+    quantity: "Quantité",
+    harvestDate: "Date de récolte",
+    farm: "Exploitation",
+    location: "Localisation",
+    plot: "Parcelle",
+    owner: "Propriétaire",
+    currentStatus: "Statut actuel",
+    qualityGrade: "Classe de qualité",
+    extraction: "Extraction",
+    extractionId: "ID d'extraction",
+    product: "Produit",
+    quality: "Qualité",
+    processor: "Transformateur",
+    date: "Date",
+    output: "Sortie",
+    recycled: "recyclé",
+    processLoss: "Perte de procédé",
+    recycling: "Recyclage",
+    recyclingId: "ID de recyclage",
+    method: "Méthode",
+    recycler: "Recycleur",
+    weather: "Météo avant la récolte",
+    source: "Source",
+    provenance: "Chaîne de provenance",
+    noEvents: "Aucun événement enregistré.",
+    by: "par",
+    documents: "Certificats et documents",
+    noDocuments: "Aucun document ancré dans le registre.",
+    carbon: "Économies de carbone",
+    valorizedQuantity: "Quantité valorisée",
+    co2eAvoided: "CO2e évité (t)",
+    catalogFactor: (factor) =>
+      `Calculé avec le facteur du registre de ${factor} tCO2e par tonne pour cette méthode.`,
+    estimateFactor: (factor) =>
+      `Estimation indicative avec un facteur de ${factor} tCO2e par tonne.`,
+    noRecycling: "Aucun recyclage enregistré pour ce lot.",
+    generated: "Généré le",
+    page: "page",
+  },
+};
+
+// Branding of the tenant the report is issued for
+const getBranding = (tenant) => ({
+  name: tenant.name,
+  logo: tenant.logo,
+  primaryColor: parseHexColor(tenant.primaryColor, [61, 122, 56]),
+  accentColor: parseHexColor(
+    tenant.accentColor,
+    parseHexColor(tenant.primaryColor, [61, 122, 56])
+  ),
+  footer: tenant.footer,
+});
+
+// Estimates the CO2e avoided by the recycling step of a traceability chain
+const estimateCarbonSavings = (trace) => {
+  const recycling = trace.recycling;
+  if (!recycling || !recycling.quantity) {
+    return null;
+  }
+
+  if (recycling.emissionFactor) {
+    return {
+      method: recycling.method,
+      quantity: recycling.quantity,
+      factor: recycling.emissionFactor,
+      co2eAvoided: Number(recycling.co2eAvoided.toFixed(2)),
+      source: "catalog",
+    };
+  }
+
+  const method = String(recycling.method || "").toLowerCase();
+  const factorKey =
+    Object.keys(CARBON_FACTORS).find((key) => method.includes(key)) ||
+    "default";
+  const factor = CARBON_FACTORS[factorKey];
+
+  return {
+    method: recycling.method,
+    quantity: recycling.quantity,
+    factor,
+    co2eAvoided: Number((recycling.quantity * factor).toFixed(2)),
+    source: "estimate",
+  };
+};
+
+class ReportWriter {
+  constructor(doc, branding) {
+    this.doc = doc;
+    this.branding = branding;
+    this.y = doc.height - MARGIN;
+  }
+
+  ensureSpace(height) {
+    if (this.y - height < MARGIN + 30) {
+      this.doc.addPage();
+      this.y = this.doc.height - MARGIN;
+    }
+  }
+
+  heading(title) {
+    this.ensureSpace(LINE_HEIGHT * 3);
+    this.y -= LINE_HEIGHT;
+    this.doc.text(title, MARGIN, this.y, {
+      size: 13,
+      bold: true,
+      color: this.branding.primaryColor,
+    });
+    this.y -= 6;
+    this.doc.line(MARGIN, this.y, this.doc.width - MARGIN, this.y, {
+      color: this.branding.accentColor,
+      width: 0.8,
+    });
+    this.y -= LINE_HEIGHT;
+  }
+
+  field(label, value) {
+    this.ensureSpace(LINE_HEIGHT);
+    this.doc.text(`${label}:`, MARGIN, this.y, { bold: true });
+    this.doc.text(value === undefined || value === "" ? "-" : value, 190, this.y);
+    this.y -= LINE_HEIGHT;
+  }
+
+  paragraph(text, { size = 10, indent = 0 } = {}) {
+    const width = this.doc.width - MARGIN * 2 - indent;
+    for (const line of this.doc.wrap(text, width, size)) {
+      this.ensureSpace(LINE_HEIGHT);
+      this.doc.text(line, MARGIN + indent, this.y, { size });
+      this.y -= LINE_HEIGHT;
+    }
+  }
+}
+
+// Header band of a report: the tenant's logo in a square left of its name,
+// the report title and a subtitle
+const drawHeader = (doc, branding, title, subtitle) => {
+  doc.rect(0, doc.height - 110, doc.width, 110, { fill: branding.primaryColor });
+  let titleX = MARGIN;
+  if (branding.logo) {
+    const { width, height } = PdfDocument.jpegInfo(branding.logo);
+    const scale = 70 / Math.max(width, height);
+    doc.image(
+      branding.logo,
+      MARGIN + (70 - width * scale) / 2,
+      doc.height - 90 + (70 - height * scale) / 2,
+      width * scale,
+      height * scale
+    );
+    titleX = MARGIN + 85;
+  }
+  doc.text(branding.name, titleX, doc.height - 50, {
+    size: 20,
+    bold: true,
+    color: [255, 255, 255],
+  });
+  doc.text(title, titleX, doc.height - 75, {
+    size: 14,
+    color: [255, 255, 255],
+  });
+  doc.text(subtitle, titleX, doc.height - 95, {
+    size: 10,
+    color: [255, 255, 255],
+  });
+};
+
+// Footer on every page: the tenant's footer and a caption(index, count)
+const drawFooters = (doc, branding, caption) => {
+  doc.pages.forEach((operations, index) => {
+    doc.current = operations;
+    doc.line(MARGIN, MARGIN + 10, doc.width - MARGIN, MARGIN + 10, {
+      color: [180, 180, 180],
+      width: 0.5,
+    });
+    doc.text(branding.footer, MARGIN, MARGIN, {
+      size: 8,
+      color: [110, 110, 110],
+    });
+    doc.text(caption(index, doc.pages.length), MARGIN, MARGIN - 12, {
+      size: 8,
+      color: [110, 110, 110],
+    });
+  });
+};
+
+const drawQrCode = (doc, text, x, y, size) => {
+  const qr = encodeQrCode(text);
+  const quietZone = 4;
+  const moduleSize = size / (qr.size + quietZone * 2);
+
+  doc.rect(x, y, size, size, { fill: [255, 255, 255] });
+  qr.modules.forEach((row, rowIndex) => {
+    row.forEach((dark, colIndex) => {
+      if (dark) {
+        doc.rect(
+          x + (colIndex + quietZone) * moduleSize,
+          y + size - (rowIndex + quietZone + 1) * moduleSize,
+          moduleSize,
+          moduleSize
+        );
+      }
+    });
+  });
+};
+
+// Renders a traceability certificate as a PDF buffer, branded for tenant
+// (see api/tenants) and labelled in language
+const renderTraceabilityReport = (
+  trace,
+  { traceUrl, generatedAt, tenant, language } = {}
+) => {
+  const waste = trace.waste || {};
+  const branding = getBranding(tenant || tenantForOrg());
+  const labels = LABELS[language] || LABELS.en;
+  const generated = generatedAt || new Date().toISOString();
+
+  const doc = new PdfDocument({
+    title: `${labels.title} ${waste.id || ""}`,
+    author: branding.name,
+  });
+  const writer = new ReportWriter(doc, branding);
+
+  drawHeader(
+    doc,
+    branding,
+    labels.certificate,
+    `${labels.lot} ${waste.id || "-"}`
+  );
+  if (traceUrl) {
+    drawQrCode(doc, traceUrl, doc.width - MARGIN - 90, doc.height - 100, 90);
+  }
+  writer.y = doc.height - 130;
+
+  writer.heading(labels.lotSummary);
+  writer.field(labels.wasteId, waste.id);
+  writer.field(labels.type, waste.type);
+  writer.field(labels.quantity, waste.quantity);
+  writer.field(labels.harvestDate, waste.harvestDate);
+  writer.field(labels.farm, waste.farm);
+  writer.field(labels.location, waste.location);
+  writer.field(labels.plot, waste.plotId);
+  writer.field(labels.owner, waste.owner);
+  writer.field(labels.currentStatus, waste.status);
+  writer.field(labels.qualityGrade, waste.qualityGrade);
+
+  if (trace.extraction) {
+    writer.heading(labels.extraction);
+    writer.field(labels.extractionId, trace.extraction.id);
+    writer.field(labels.product, trace.extraction.productType);
+    writer.field(labels.quantity, trace.extraction.quantity);
+    writer.field(labels.quality, trace.extraction.quality);
+    writer.field(labels.qualityGrade, trace.extraction.qualityGrade);
+    writer.field(labels.processor, trace.extraction.processor);
+    writer.field(labels.date, trace.extraction.extractionDate);
+    (trace.extraction.outputs || []).forEach((output) => {
+      writer.field(
+        `${labels.output} ${output.line}`,
+        `${output.productType} ${output.quantity}` +
+          (output.consumed ? ` (${output.consumed} ${labels.recycled})` : "")
+      );
+    });
+    if (trace.extraction.massBalance) {
+      writer.field(labels.processLoss, trace.extraction.massBalance.loss);
+    }
+  }
+
+  if (trace.recycling) {
+    writer.heading(labels.recycling);
+    writer.field(labels.recyclingId, trace.recycling.id);
+    writer.field(labels.product, trace.recycling.recycledProduct);
+    writer.field(labels.quantity, trace.recycling.quantity);
+    writer.field(labels.method, trace.recycling.method);
+    writer.field(labels.recycler, trace.recycling.recycler);
+    writer.field(labels.date, trace.recycling.recyclingDate);
+  }
+
+  if (trace.weather && trace.weather.length > 0) {
+    writer.heading(labels.weather);
+    trace.weather.forEach((observation) => {
+      writer.paragraph(
+        `${observation.date}  ${observation.event}` +
+          (observation.severity ? ` (${observation.severity})` : "") +
+          `  ${observation.temperatureMin}-${observation.temperatureMax} C` +
+          `, ${observation.precipitationMm} mm`
+      );
+      if (observation.source) {
+        writer.paragraph(`${labels.source}: ${observation.source}`, {
+          size: 8,
+          indent: 15,
+        });
+      }
+    });
+  }
+
+  writer.heading(labels.provenance);
+  const chain = [...(trace.chain || [])].sort((a, b) =>
+    String(a.timestamp).localeCompare(String(b.timestamp))
+  );
+  if (chain.length === 0) {
+    writer.paragraph(labels.noEvents);
+  }
+  chain.forEach((entry) => {
+    writer.paragraph(
+      `${entry.timestamp}  ${entry.action}  ${labels.by} ${entry.actor || "-"}`
+    );
+    if (entry.details) {
+      writer.paragraph(entry.details, { size: 9, indent: 15 });
+    }
+  });
+
+  writer.heading(labels.documents);
+  const documents = waste.documents || [];
+  if (documents.length === 0) {
+    writer.paragraph(labels.noDocuments);
+  }
+  documents.forEach((document) => {
+    writer.paragraph(`${document.type} (${document.addedAt})`);
+    writer.paragraph(`SHA-256 ${document.hash}`, { size: 8, indent: 15 });
+  });
+
+  writer.heading(labels.carbon);
+  const carbon = estimateCarbonSavings(trace);
+  if (carbon) {
+    writer.field(labels.method, carbon.method);
+    writer.field(labels.valorizedQuantity, carbon.quantity);
+    writer.field(labels.co2eAvoided, carbon.co2eAvoided);
+    writer.paragraph(
+      carbon.source === "catalog"
+        ? labels.catalogFactor(carbon.factor)
+        : labels.estimateFactor(carbon.factor),
+      { size: 8 }
+    );
+  } else {
+    writer.paragraph(labels.noRecycling);
+  }
+
+  drawFooters(
+    doc,
+    branding,
+    (index, count) =>
+      `${labels.generated} ${generated} - ${labels.page} ${index + 1}/${count}`
+  );
+
+  return doc.toBuffer();
+};
+
+const hashDocument = (buffer) =>
+  crypto.createHash("sha256").update(buffer).digest("hex");
+
+module.exports = {
+  ReportWriter,
+  getBranding,
+  drawHeader,
+  drawFooters,
+  renderTraceabilityReport,
+  estimateCarbonSavings,
+  hashDocument,
+};
@@ -0,0 +1,119 @@
+const express = require("express");
+const router = express.Router();
+const adminController = require("../controllers/adminController");
+
+// Runtime configuration
+router.get("/config", adminController.getConfig);
+router.put("/config/:namespace/:key", adminController.setConfig);
+
+// Per-organization required lot fields
+router.get("/validation-profiles", adminController.listValidationProfiles);
+router.get(
+  "/validation-profiles/:orgMsp",
+  adminController.getValidationProfile
+);
+router.put(
+  "/validation-profiles/:orgMsp",
+  adminController.setValidationProfile
+);
+
+// Validation expressions evaluated when assets are written
+router.get("/validation-rules", adminController.listValidationRules);
+router.post("/validation-rules", adminController.setValidationRule);
+router.post("/validation-rules/test", adminController.testValidationRule);
+router.put("/validation-rules/:ruleId", adminController.setValidationRule);
+router.put(
+  "/validation-rules/:ruleId/enabled",
+  adminController.setValidationRuleEnabled
+);
+
+// Housekeeping
+router.post("/maintenance", adminController.runMaintenance);
+
+// Schema migrations
+router.get("/migrations", adminController.listMigrations);
+router.post("/migrations/:version/run", adminController.runMigration);
+
+// Invocation audit trail (who invoked what, when)
+router.get("/audit", adminController.queryAuditTrail);
+
+// API audit log (every gateway call, kept apart from the ledger)
+router.get("/api-audit", adminController.searchApiAudit);
+router.get("/api-audit/verify", adminController.verifyApiAudit);
+
+// Chaincode function metrics (invocations, failures, read/write sets, timings)
+router.get("/metrics", adminController.getFunctionMetrics);
+
+// Integrity of extraction and recycling records against their source lots
+router.get("/integrity", adminController.checkIntegrity);
+router.post("/integrity/repair", adminController.repairIntegrity);
+
+// Random audit samples for physical inspection
+router.get("/audit-samples", adminController.listAuditSamples);
+router.post("/audit-samples", adminController.selectAuditSample);
+router.get("/audit-samples/:sampleId", adminController.getAuditSample);
+router.post(
+  "/audit-samples/:sampleId/inspections/:wasteId",
+  adminController.recordAuditInspection
+);
+router.post(
+  "/audit-samples/:sampleId/inspections/:wasteId/decline",
+  adminController.declineAuditAssignment
+);
+router.post(
+  "/audit-samples/:sampleId/assign",
+  adminController.assignAuditSample
+);
+
+// Certifiers inspecting sampled lots, weighted by declared capacity
+router.get("/certifiers", adminController.listCertifiers);
+router.post("/certifiers", adminController.registerCertifier);
+router.get("/certifiers/workloads", adminController.getCertifierWorkloads);
+router.get(
+  "/certifiers/:certifierId/workload",
+  adminController.getCertifierWorkloads
+);
+router.post(
+  "/certifiers/:certifierId/retire",
+  adminController.retireCertifier
+);
+
+// Participant identities bound to certificate enrollment IDs
+router.get("/identities", adminController.listIdentityBindings);
+router.get("/identities/resolve", adminController.resolveIdentity);
+router.put("/identities/:mspId/:enrollmentId", adminController.rebindIdentity);
+
+// Object store each organization's attachments go to
+router.get("/object-storage", adminController.getObjectStorage);
+
+// Gateway peers: health and configuration reload
+router.get("/peers", adminController.getPeers);
+router.post("/peers/reload", adminController.reloadPeers);
+
+// Read-model indexer: dead letters, re-sync and consistency checks
+router.get("/indexer", adminController.getIndexerStatus);
+router.get("/indexer/dead-letters", adminController.listDeadLetters);
+router.post("/indexer/dead-letters/replay", adminController.replayDeadLetters);
+router.post(
+  "/indexer/dead-letters/:deadLetterId/replay",
+  adminController.replayDeadLetter
+);
+router.post(
+  "/indexer/dead-letters/:deadLetterId/discard",
+  adminController.discardDeadLetter
+);
+router.post("/indexer/resync", adminController.resyncIndexer);
+router.get(
+  "/indexer/consistency-checks",
+  adminController.listConsistencyChecks
+);
+router.post("/indexer/consistency-checks", adminController.runConsistencyCheck);
+router.get(
+  "/indexer/consistency-checks/:checkId",
+  adminController.getConsistencyCheck
+);
+
+// GDPR erasure requests
+router.post("/erasure-requests", adminController.eraseParticipant);
+
+module.exports = router;
@@ -0,0 +1,11 @@
+const express = require("express");
+const router = express.Router();
+const agreementController = require("../controllers/agreementController");
+
+// Data-sharing agreements between organizations
+router.get("/", agreementController.listAgreements);
+router.post("/propose", agreementController.proposeAgreement);
+router.post("/:agreementId/accept", agreementController.acceptAgreement);
+router.post("/:agreementId/revoke", agreementController.revokeAgreement);
+
+module.exports = router;
@@ -0,0 +1,17 @@
+const express = require("express");
+const router = express.Router();
+const alertController = require("../controllers/alertController");
+
+// Alerting rules on chaincode events
+router.get("/rules", alertController.listRules);
+router.post("/rules", alertController.createRule);
+router.get("/rules/:ruleId", alertController.getRule);
+router.put("/rules/:ruleId", alertController.updateRule);
+router.delete("/rules/:ruleId", alertController.deleteRule);
+router.post("/rules/:ruleId/test", alertController.testRule);
+
+// Delivery status tracking
+router.get("/deliveries", alertController.listDeliveries);
+router.get("/deliveries/:deliveryId", alertController.getDelivery);
+
+module.exports = router;
@@ -0,0 +1,14 @@
+const express = require("express");
+const router = express.Router();
+const analyticsController = require("../controllers/analyticsController");
+
+// Dashboard KPIs (?from=YYYY-MM-DD&to=YYYY-MM-DD)
+router.get("/collected", analyticsController.getCollected);
+router.get("/recycling-rate", analyticsController.getRecyclingRate);
+router.get("/top-processors", analyticsController.getTopProcessors);
+router.get("/status", analyticsController.getStatus);
+
+// Asset relationship graph for network analysis
+router.get("/graph/:rootId", analyticsController.getRelationshipGraph);
+
+module.exports = router;
@@ -0,0 +1,21 @@
+const express = require("express");
+const router = express.Router();
+const approvalController = require("../controllers/approvalController");
+
+// Approval matrices per organization (MSP ID) and their versions
+router.get("/matrices", approvalController.listMatrices);
+router.get("/matrices/:orgMsp", approvalController.getMatrix);
+router.put("/matrices/:orgMsp", approvalController.setMatrix);
+router.get("/matrices/:orgMsp/effective", approvalController.getEffectiveRules);
+router.delete(
+  "/matrices/:orgMsp/versions/:effectiveFrom",
+  approvalController.cancelMatrixVersion
+);
+
+// Multi-signature approvals of high-value operations
+router.get("/", approvalController.listApprovals);
+router.get("/:approvalId", approvalController.getApproval);
+router.post("/:approvalId/approve", approvalController.approve);
+router.post("/:approvalId/reject", approvalController.reject);
+
+module.exports = router;
@@ -0,0 +1,11 @@
+const express = require("express");
+const router = express.Router();
+const campaignController = require("../controllers/campaignController");
+
+// Harvest campaigns
+router.get("/", campaignController.listCampaigns);
+router.post("/", campaignController.createCampaign);
+router.post("/:campaignId/close", campaignController.closeCampaign);
+router.get("/:campaignId/statistics", campaignController.getCampaignStatistics);
+
+module.exports = router;
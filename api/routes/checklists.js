@@ -0,0 +1,15 @@
+const express = require("express");
+const router = express.Router();
+const checklistController = require("../controllers/checklistController");
+
+// Checklists required before a lot moves to a status (e.g. RECEIVED)
+router.get("/", checklistController.listTemplates);
+router.put("/:transition", checklistController.defineTemplate);
+router.delete("/:transition", checklistController.removeTemplate);
+router.get("/:transition/waste/:wasteId", checklistController.getProgress);
+router.put(
+  "/:transition/waste/:wasteId/items/:itemKey",
+  checklistController.completeItem
+);
+
+module.exports = router;
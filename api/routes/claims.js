@@ -0,0 +1,16 @@
+const express = require("express");
+const router = express.Router();
+const claimController = require("../controllers/claimController");
+
+// Insurance claims for lost or damaged lots
+router.get("/", claimController.listClaims);
+router.post("/", claimController.fileClaim);
+router.get("/:claimId", claimController.getClaim);
+router.post("/:claimId/evidence", claimController.addEvidence);
+
+// Approval flow: assessor findings, insurer decision, settlement
+router.post("/:claimId/assessment", claimController.assessClaim);
+router.post("/:claimId/decision", claimController.decideClaim);
+router.post("/:claimId/settlement", claimController.settleClaim);
+
+module.exports = router;
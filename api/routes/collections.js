@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const collectionController = require("../controllers/collectionController");
+
+// Pickup requests and scheduling
+router.get("/", collectionController.listCollections);
+router.post("/request", collectionController.requestCollection);
+router.post("/:requestId/assign", collectionController.assignCollector);
+router.post("/:requestId/cancel", collectionController.cancelCollection);
+router.post("/:requestId/fulfill", collectionController.fulfillCollection);
+
+module.exports = router;
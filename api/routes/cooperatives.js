@@ -0,0 +1,19 @@
+const express = require("express");
+const router = express.Router();
+const cooperativeController = require("../controllers/cooperativeController");
+
+// Cooperatives and memberships
+router.post("/", cooperativeController.registerCooperative);
+router.get("/memberships/:memberId", cooperativeController.getMembership);
+router.post(
+  "/memberships/:memberId/leave",
+  cooperativeController.leaveCooperative
+);
+router.get("/:cooperativeId", cooperativeController.getCooperative);
+router.post("/:cooperativeId/admins", cooperativeController.addAdmin);
+router.put("/:cooperativeId/permissions", cooperativeController.setPermissions);
+router.get("/:cooperativeId/members", cooperativeController.listMembers);
+router.post("/:cooperativeId/members", cooperativeController.joinCooperative);
+router.get("/:cooperativeId/assets", cooperativeController.listAssets);
+
+module.exports = router;
@@ -0,0 +1,18 @@
+const express = require("express");
+const router = express.Router();
+const creditController = require("../controllers/creditController");
+
+// Credit limits and payment terms per buyer
+router.get("/limits", creditController.listLimits);
+router.put("/limits/:buyerMsp", creditController.setLimit);
+router.delete("/limits/:buyerMsp", creditController.removeLimit);
+router.get("/exposure", creditController.getExposure);
+
+// Invoices (listing awards are invoiced automatically)
+router.get("/invoices", creditController.listInvoices);
+router.post("/invoices", creditController.issueInvoice);
+router.get("/invoices/:invoiceId", creditController.getInvoice);
+router.post("/invoices/:invoiceId/paid", creditController.markInvoicePaid);
+router.post("/invoices/:invoiceId/void", creditController.voidInvoice);
+
+module.exports = router;
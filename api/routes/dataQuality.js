@@ -0,0 +1,16 @@
+const express = require("express");
+const router = express.Router();
+const dataQualityController = require("../controllers/dataQualityController");
+
+// Lots failing the completeness rules (?field=&minSeverity=&source=ledger)
+router.get("/", dataQualityController.getReport);
+router.get("/wastes/:wasteId", dataQualityController.getWasteQuality);
+
+// Lots recorded more than once, and their consolidation (admin identity)
+router.get("/duplicates", dataQualityController.getDuplicates);
+router.post("/duplicates/merge", dataQualityController.mergeDuplicates);
+
+// Owners backfilling missing fields in bulk
+router.post("/enrich", dataQualityController.enrichWastes);
+
+module.exports = router;
@@ -0,0 +1,11 @@
+const express = require("express");
+const router = express.Router();
+const delegationController = require("../controllers/delegationController");
+
+// Delegations and the service-account tokens issued under them
+router.get("/", delegationController.listDelegations);
+router.post("/", delegationController.createDelegation);
+router.post("/:delegationId/revoke", delegationController.revokeDelegation);
+router.post("/:delegationId/tokens", delegationController.issueToken);
+
+module.exports = router;
@@ -0,0 +1,14 @@
+const express = require("express");
+const router = express.Router();
+const documentController = require("../controllers/documentController");
+
+// Pre-signed uploads to the organization's object store, anchored on lots
+router.post("/uploads", documentController.requestUpload);
+router.get("/uploads/:uploadId", documentController.getUpload);
+router.post("/uploads/:uploadId/complete", documentController.completeUpload);
+
+// Documents of lots and short-lived download URLs
+router.get("/:wasteId", documentController.listDocuments);
+router.get("/:wasteId/:hash/download", documentController.downloadDocument);
+
+module.exports = router;
@@ -0,0 +1,21 @@
+const express = require("express");
+const router = express.Router();
+const erpController = require("../controllers/erpController");
+
+// ERP connectors (Odoo, generic REST)
+router.get("/connectors", erpController.listConnectors);
+
+// Deliveries and invoices posted to the ERPs
+router.get("/postings", erpController.listPostings);
+router.get("/postings/:postingId", erpController.getPosting);
+router.post("/postings/:postingId/retry", erpController.retryPosting);
+
+// Reconciliation of postings against the ERPs
+router.get("/reconciliations", erpController.listReconciliations);
+router.post("/reconciliations", erpController.runReconciliation);
+router.get(
+  "/reconciliations/:reconciliationId",
+  erpController.getReconciliation
+);
+
+module.exports = router;
@@ -0,0 +1,13 @@
+const express = require("express");
+const router = express.Router();
+const eventController = require("../controllers/eventController");
+
+// Chaincode event stream (Server-Sent Events)
+router.get("/stream", eventController.streamEvents);
+
+// Saved filters
+router.get("/filters", eventController.listFilters);
+router.post("/filters", eventController.saveFilter);
+router.delete("/filters/:name", eventController.deleteFilter);
+
+module.exports = router;
@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const exportController = require("../controllers/exportController");
+
+// Long-running exports (dataset: wastes, extractions or recyclings)
+router.get("/jobs", exportController.listExports);
+router.post("/jobs", exportController.createExport);
+router.get("/jobs/:jobId", exportController.getExport);
+router.post("/jobs/:jobId/cancel", exportController.cancelExport);
+router.get("/jobs/:jobId/download", exportController.downloadExport);
+
+module.exports = router;
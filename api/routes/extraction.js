@@ -12,4 +12,27 @@ router.get("/by-id/:extractionId", extractionController.getExtractionById);
 router.get("/by-waste/:wasteId", extractionController.getExtractionsByWasteId);
 router.put("/update-status", extractionController.updateExtractionStatus);
 
+// Multi-output runs and per-line downstream traceability
+router.post("/outputs", extractionController.addExtractionWithOutputs);
+router.post(
+  "/:extractionId/outputs/:line/recycle",
+  extractionController.recycleOutput
+);
+router.get(
+  "/:extractionId/outputs/:line/trace",
+  extractionController.getOutputTrace
+);
+
+// Quality grading and appeals
+router.post("/:extractionId/grade", extractionController.gradeExtraction);
+router.get("/:extractionId/grading", extractionController.getGradingHistory);
+router.post("/:extractionId/regrade", extractionController.requestRegrade);
+router.post(
+  "/regrades/:requestId/confirm",
+  extractionController.confirmRegrade
+);
+
+// Dry-run (query-only) variant
+router.post("/simulate", extractionController.simulateAddExtraction);
+
 module.exports = router;
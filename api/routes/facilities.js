@@ -0,0 +1,21 @@
+const express = require("express");
+const router = express.Router();
+const facilityController = require("../controllers/facilityController");
+
+// Facilities and equipment
+router.get("/", facilityController.listFacilities);
+router.post("/", facilityController.registerFacility);
+router.get("/:facilityId", facilityController.getFacility);
+router.get(
+  "/:facilityId/compliance",
+  facilityController.getFacilityCompliance
+);
+router.put("/:facilityId/status", facilityController.updateFacilityStatus);
+router.put("/:facilityId/geofence", facilityController.setFacilityGeofence);
+router.post("/:facilityId/equipment", facilityController.registerEquipment);
+router.put(
+  "/equipment/:equipmentId/maintenance",
+  facilityController.updateEquipmentMaintenance
+);
+
+module.exports = router;
@@ -0,0 +1,17 @@
+const express = require("express");
+const router = express.Router();
+const feedbackController = require("../controllers/feedbackController");
+
+// Trace tokens printed on products
+router.post("/tokens", feedbackController.issueToken);
+router.post("/tokens/:token/revoke", feedbackController.revokeToken);
+
+// Public consumer feedback through a trace token
+router.get("/trace/:token", feedbackController.getToken);
+router.post("/trace/:token", feedbackController.submitFeedback);
+
+// Aggregated ratings for the dashboard (scope: products or farms)
+router.get("/ratings/:scope", feedbackController.listRatings);
+router.get("/ratings/:scope/:key", feedbackController.getRating);
+
+module.exports = router;
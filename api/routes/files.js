@@ -0,0 +1,9 @@
+const express = require("express");
+const router = express.Router();
+const fileController = require("../controllers/fileController");
+
+// Objects of local disk stores, through signed URLs
+router.put("/*key", fileController.putFile);
+router.get("/*key", fileController.getFile);
+
+module.exports = router;
@@ -0,0 +1,13 @@
+const express = require("express");
+const router = express.Router();
+const handoffController = require("../controllers/handoffController");
+
+// Lots handed off to or received from other networks and systems
+router.get("/", handoffController.listHandoffs);
+router.get("/by-reference", handoffController.findHandoff);
+router.post("/exports", handoffController.exportHandoff);
+router.post("/imports", handoffController.importHandoff);
+router.get("/:handoffId", handoffController.getHandoff);
+router.post("/:handoffId/verify", handoffController.verifyBundle);
+
+module.exports = router;
@@ -0,0 +1,13 @@
+const express = require("express");
+const router = express.Router();
+const importController = require("../controllers/importController");
+
+// Legacy exports may be large; accept raw CSV bodies up to 20 MB
+const csvBody = express.text({ type: "text/csv", limit: "20mb" });
+
+// Bulk import of legacy ERP data (kind: wastes or extractions)
+router.get("/jobs", importController.listJobs);
+router.get("/jobs/:jobId", importController.getJob);
+router.post("/:kind", csvBody, importController.startImport);
+
+module.exports = router;
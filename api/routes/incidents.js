@@ -0,0 +1,17 @@
+const express = require("express");
+const router = express.Router();
+const incidentController = require("../controllers/incidentController");
+
+// Incident reporting and closure workflow
+router.get("/", incidentController.listIncidents);
+router.post("/", incidentController.reportIncident);
+router.get("/:incidentId", incidentController.getIncident);
+router.post("/:incidentId/actions", incidentController.addCorrectiveAction);
+router.put(
+  "/:incidentId/actions/:line/complete",
+  incidentController.completeCorrectiveAction
+);
+router.post("/:incidentId/regulator", incidentController.notifyRegulator);
+router.post("/:incidentId/close", incidentController.closeIncident);
+
+module.exports = router;
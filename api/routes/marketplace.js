@@ -0,0 +1,18 @@
+const express = require("express");
+const router = express.Router();
+const marketplaceController = require("../controllers/marketplaceController");
+
+// Lot listings with sealed bids
+router.get("/listings", marketplaceController.listListings);
+router.post("/listings", marketplaceController.createListing);
+router.get("/listings/:listingId", marketplaceController.getListing);
+router.post("/listings/:listingId/bids", marketplaceController.submitBid);
+router.get("/listings/:listingId/bids/mine", marketplaceController.getMyBid);
+router.post(
+  "/listings/:listingId/bids/reveal",
+  marketplaceController.revealBid
+);
+router.post("/listings/:listingId/award", marketplaceController.awardListing);
+router.post("/listings/:listingId/settle", marketplaceController.settleListing);
+
+module.exports = router;
@@ -0,0 +1,15 @@
+const express = require("express");
+const router = express.Router();
+const mediaController = require("../controllers/mediaController");
+
+// Pre-signed uploads to object storage, anchored on the ledger
+router.post("/uploads", mediaController.requestUpload);
+router.get("/uploads/:uploadId", mediaController.getUpload);
+router.post("/uploads/:uploadId/complete", mediaController.completeUpload);
+
+// Galleries of lots, extractions and recycling products
+router.get("/:assetType/:assetId", mediaController.getGallery);
+router.put("/:assetType/:assetId/order", mediaController.reorderGallery);
+router.delete("/:assetType/:assetId/:hash", mediaController.removeMedia);
+
+module.exports = router;
@@ -0,0 +1,32 @@
+const express = require("express");
+const router = express.Router();
+const notificationController = require("../controllers/notificationController");
+
+// Organization inbox
+router.get("/", notificationController.listNotifications);
+router.post("/read", notificationController.markRead);
+
+// Upcoming deadlines (?participant=ProcessorOrgMSP&days=30&kinds=PERMIT)
+router.get("/deadlines", notificationController.listDeadlines);
+
+// Deadline digests and who receives them
+router.get("/digests", notificationController.listDigestRuns);
+router.post("/digests/run", notificationController.runDigest);
+router.get(
+  "/digests/preferences",
+  notificationController.listDigestPreferences
+);
+router.get(
+  "/digests/preferences/:userId",
+  notificationController.getDigestPreferences
+);
+router.put(
+  "/digests/preferences/:userId",
+  notificationController.setDigestPreferences
+);
+router.delete(
+  "/digests/preferences/:userId",
+  notificationController.deleteDigestPreferences
+);
+
+module.exports = router;
@@ -0,0 +1,27 @@
+const express = require("express");
+const router = express.Router();
+const onboardingController = require("../controllers/onboardingController");
+
+// Steps, roles and required documents of the wizard
+router.get("/wizard", onboardingController.getWizard);
+
+// Reminders for stalled onboardings
+router.get("/reminders", onboardingController.listReminderRuns);
+router.post("/reminders/run", onboardingController.runReminders);
+
+// On-chain onboarding record of a participant (?org=farmer)
+router.get(
+  "/participants/:participantId",
+  onboardingController.getParticipantOnboarding
+);
+
+// Onboardings (?org=farmer&status=IN_PROGRESS&stalled=true)
+router.get("/", onboardingController.listOnboardings);
+router.post("/", onboardingController.startOnboarding);
+router.get("/:id", onboardingController.getOnboarding);
+router.put("/:id/steps/:step", onboardingController.submitStep);
+router.post("/:id/steps/:step/review", onboardingController.reviewStep);
+router.post("/:id/activate", onboardingController.activate);
+router.post("/:id/cancel", onboardingController.cancelOnboarding);
+
+module.exports = router;
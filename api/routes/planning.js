@@ -0,0 +1,17 @@
+const express = require("express");
+const router = express.Router();
+const planningController = require("../controllers/planningController");
+
+// Intake forecast of processor facilities
+router.get("/", planningController.getForecast);
+
+// Intake reservations
+router.get("/reservations", planningController.listReservations);
+router.post("/reservations", planningController.reserveIntake);
+router.get("/reservations/:reservationId", planningController.getReservation);
+router.post(
+  "/reservations/:reservationId/cancel",
+  planningController.cancelReservation
+);
+
+module.exports = router;
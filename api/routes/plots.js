@@ -0,0 +1,19 @@
+const express = require("express");
+const router = express.Router();
+const plotController = require("../controllers/plotController");
+
+// Farm plots identified by LPIS or cadastral parcel IDs
+router.get("/", plotController.listPlots);
+router.post("/", plotController.registerPlot);
+router.get("/statistics", plotController.getPlotStatistics);
+
+// Seasonal declared-quantity quotas per farm
+router.get("/quotas", plotController.listQuotas);
+router.get("/quotas/season", plotController.getQuotaSeason);
+router.get("/quotas/:season/:farm", plotController.getQuota);
+router.put("/quotas/:season/:farm", plotController.setQuota);
+
+router.get("/:plotId", plotController.getPlot);
+router.post("/:plotId/retire", plotController.retirePlot);
+
+module.exports = router;
@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const pricingController = require("../controllers/pricingController");
+
+// Market price index maintained by the price oracle
+router.post("/index", pricingController.recordPrice);
+router.get("/index/:productType", pricingController.getPriceIndex);
+
+// Lot valuations at index prices
+router.get("/valuations/:wasteId", pricingController.getValuationHistory);
+
+module.exports = router;
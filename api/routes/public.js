@@ -0,0 +1,8 @@
+const express = require("express");
+const router = express.Router();
+const publicController = require("../controllers/publicController");
+
+// Unauthenticated, k-anonymized network statistics
+router.get("/stats", publicController.getStats);
+
+module.exports = router;
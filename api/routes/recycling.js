@@ -4,15 +4,40 @@ const recyclingController = require("../controllers/recyclingController");
 
 // Basic recycling operations
 router.post("/add", recyclingController.addRecycling);
+router.post("/blended", recyclingController.addBlendedRecycling);
 router.get("/list", recyclingController.listRecyclings);
 router.get("/", recyclingController.listRecyclings); // Alternative endpoint
 
 // Enhanced blockchain routes
 router.get("/by-id/:recyclingId", recyclingController.getRecyclingById);
 router.get("/by-waste/:wasteId", recyclingController.getRecyclingsByWasteId);
+router.get(
+  "/:recyclingId/provenance",
+  recyclingController.getRecyclingProvenance
+);
 router.get(
   "/traceability/:wasteId",
   recyclingController.getCompleteTraceability
 );
+router.get(
+  "/traceability/:wasteId/lite",
+  recyclingController.getTraceabilityLite
+);
+router.get(
+  "/history/:assetType/:assetId",
+  recyclingController.getHistorySegment
+);
+router.get(
+  "/history/:assetType/:assetId/archive",
+  recyclingController.getArchivedHistory
+);
+
+// Recycling method catalog
+router.get("/methods", recyclingController.listMethods);
+router.put("/methods/:code", recyclingController.defineMethod);
+router.post("/methods/:code/retire", recyclingController.retireMethod);
+
+// Dry-run (query-only) variant
+router.post("/simulate", recyclingController.simulateAddRecycling);
 
 module.exports = router;
@@ -0,0 +1,25 @@
+const express = require("express");
+const router = express.Router();
+const reportController = require("../controllers/reportController");
+
+// Whitelabel theme of the tenant reports are branded for
+router.get("/theme", reportController.getTheme);
+
+// Traceability certificates
+router.get(
+  "/traceability/:wasteId",
+  reportController.downloadTraceabilityReport
+);
+router.post(
+  "/traceability/:wasteId/anchor",
+  reportController.anchorTraceabilityReport
+);
+router.get(
+  "/traceability/:wasteId/credential",
+  reportController.exportTraceabilityCredential
+);
+
+// Impact reports of a buyer per period (?org=processor&period=2025-Q1)
+router.get("/impact", reportController.getImpactReport);
+
+module.exports = router;
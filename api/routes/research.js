@@ -0,0 +1,13 @@
+const express = require("express");
+const router = express.Router();
+const researchController = require("../controllers/researchController");
+
+// Research consent and anonymized dataset exports
+router.get("/consent/:participantId", researchController.getConsent);
+router.put("/consent/:participantId", researchController.setConsent);
+router.get("/exports", researchController.listExports);
+router.post("/exports", researchController.startExport);
+router.get("/exports/:exportId", researchController.getExport);
+router.get("/exports/:exportId/download", researchController.downloadExport);
+
+module.exports = router;
@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const residencyController = require("../controllers/residencyController");
+
+// Regions pinning participants' personal data to their own collection
+router.get("/", residencyController.listRegions);
+router.get("/wastes/:wasteId", residencyController.getWasteView);
+router.get("/:code", residencyController.getRegion);
+router.put("/:code", residencyController.defineRegion);
+router.delete("/:code", residencyController.removeRegion);
+
+module.exports = router;
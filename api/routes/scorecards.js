@@ -0,0 +1,9 @@
+const express = require("express");
+const router = express.Router();
+const scorecardController = require("../controllers/scorecardController");
+
+// Participant scorecards (on-time delivery, grades, disputes, SLA breaches)
+router.get("/", scorecardController.compareScorecards);
+router.get("/:participant", scorecardController.getScorecard);
+
+module.exports = router;
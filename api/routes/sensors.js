@@ -0,0 +1,9 @@
+const express = require("express");
+const router = express.Router();
+const sensorController = require("../controllers/sensorController");
+
+// Cold-chain temperature readings
+router.post("/readings", sensorController.recordReading);
+router.get("/:assetType/:assetId", sensorController.getSensorLog);
+
+module.exports = router;
@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const settlementController = require("../controllers/settlementController");
+
+// Two-phase token settlements (prepare by the buyer, confirm by the seller)
+router.get("/", settlementController.listSettlements);
+router.post("/", settlementController.prepareSettlement);
+router.get("/:settlementId", settlementController.getSettlement);
+router.post("/:settlementId/confirm", settlementController.confirmSettlement);
+router.post("/:settlementId/cancel", settlementController.cancelSettlement);
+
+module.exports = router;
@@ -0,0 +1,11 @@
+const express = require("express");
+const router = express.Router();
+const slaController = require("../controllers/slaController");
+
+// Processing SLAs, lot receipts and compliance reporting
+router.post("/", slaController.defineSla);
+router.get("/compliance", slaController.getCompliance);
+router.post("/receipts", slaController.receiveLot);
+router.get("/:scope/:scopeId", slaController.getSla);
+
+module.exports = router;
@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const snapshotController = require("../controllers/snapshotController");
+
+// World-state snapshots for regulators
+router.get("/", snapshotController.listSnapshots);
+router.post("/", snapshotController.advanceSnapshot);
+router.get("/:snapshotId", snapshotController.getSnapshot);
+router.get("/:snapshotId/proof", snapshotController.verifyInclusion);
+router.post("/:snapshotId/verify", snapshotController.verifyInclusion);
+
+module.exports = router;
@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const statusReasonController = require("../controllers/statusReasonController");
+
+// Reason codes of status transitions (e.g. REJECTED_QUALITY for REJECTED)
+router.get("/", statusReasonController.listReasons);
+router.get("/statistics", statusReasonController.getStatistics);
+router.get("/:transition", statusReasonController.getReasons);
+router.put("/:transition", statusReasonController.defineReasons);
+router.delete("/:transition", statusReasonController.removeReasons);
+
+module.exports = router;
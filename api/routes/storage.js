@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const storageController = require("../controllers/storageController");
+
+// Storage sites and stock levels
+router.get("/occupancy", storageController.getOccupancy);
+router.post("/", storageController.registerSite);
+router.get("/:siteId", storageController.getSite);
+router.post("/:siteId/check-in", storageController.checkIn);
+router.post("/:siteId/check-out", storageController.checkOut);
+
+module.exports = router;
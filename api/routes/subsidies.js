@@ -0,0 +1,20 @@
+const express = require("express");
+const router = express.Router();
+const subsidyController = require("../controllers/subsidyController");
+
+// Valorization subsidy schemes, lot eligibility and agency claims
+router.get("/schemes", subsidyController.listSchemes);
+router.get("/schemes/:schemeId", subsidyController.getScheme);
+router.put("/schemes/:schemeId", subsidyController.defineScheme);
+router.post("/schemes/:schemeId/retire", subsidyController.retireScheme);
+router.post(
+  "/schemes/:schemeId/wastes/:wasteId",
+  subsidyController.evaluateWaste
+);
+router.get(
+  "/schemes/:schemeId/eligibilities",
+  subsidyController.listEligibilities
+);
+router.get("/schemes/:schemeId/claims", subsidyController.exportClaims);
+
+module.exports = router;
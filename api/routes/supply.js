@@ -0,0 +1,16 @@
+const express = require("express");
+const router = express.Router();
+const supplyController = require("../controllers/supplyController");
+
+// Standing supply contracts and their commitments
+router.get("/", supplyController.listContracts);
+router.get("/commitments", supplyController.getCommitments);
+router.post("/", supplyController.proposeContract);
+router.get("/:contractId", supplyController.getContract);
+router.post("/:contractId/accept", supplyController.acceptContract);
+router.put("/:contractId/rules", supplyController.setRules);
+router.get("/:contractId/adjustments", supplyController.getAdjustments);
+router.post("/:contractId/deliveries", supplyController.recordDelivery);
+router.post("/:contractId/extractions", supplyController.linkExtraction);
+
+module.exports = router;
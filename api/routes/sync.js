@@ -0,0 +1,9 @@
+const express = require("express");
+const router = express.Router();
+const syncController = require("../controllers/syncController");
+
+// Offline-first clients
+router.get("/", syncController.pullChanges);
+router.post("/mutations", syncController.pushMutations);
+
+module.exports = router;
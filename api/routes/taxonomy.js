@@ -0,0 +1,17 @@
+const express = require("express");
+const router = express.Router();
+const taxonomyController = require("../controllers/taxonomyController");
+
+// Waste taxonomy
+router.get("/", taxonomyController.getTaxonomy);
+router.get("/statistics", taxonomyController.getCategoryStatistics);
+
+// Admin edits
+router.post("/categories", taxonomyController.defineCategory);
+router.post(
+  "/categories/:category/subtypes",
+  taxonomyController.defineSubtype
+);
+router.put("/mappings", taxonomyController.mapWasteType);
+
+module.exports = router;
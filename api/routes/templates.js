@@ -0,0 +1,12 @@
+const express = require("express");
+const router = express.Router();
+const templateController = require("../controllers/templateController");
+
+// Lot templates of participants for recurring waste creation
+router.post("/", templateController.registerTemplate);
+router.get("/:participantId", templateController.listTemplates);
+router.put("/:participantId/:name", templateController.saveTemplate);
+router.delete("/:participantId/:name", templateController.deleteTemplate);
+router.post("/:participantId/:name/wastes", templateController.createWaste);
+
+module.exports = router;
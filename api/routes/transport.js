@@ -0,0 +1,30 @@
+const express = require("express");
+const router = express.Router();
+const transportController = require("../controllers/transportController");
+
+// Vehicle and driver registry
+router.get("/vehicles", transportController.listVehicles);
+router.post("/vehicles", transportController.registerVehicle);
+router.get("/vehicles/:holderId", transportController.getVehicle);
+router.post(
+  "/vehicles/:holderId/permits",
+  transportController.recordVehiclePermit
+);
+router.post("/vehicles/:holderId/retire", transportController.retireVehicle);
+router.post("/drivers", transportController.registerDriver);
+router.get("/drivers/:holderId", transportController.getDriver);
+router.post(
+  "/drivers/:holderId/permits",
+  transportController.recordDriverPermit
+);
+
+// Shipments
+router.get("/shipments", transportController.listShipments);
+router.post("/shipments", transportController.createShipment);
+router.get("/shipments/:shipmentId", transportController.getShipment);
+router.post(
+  "/shipments/:shipmentId/deliver",
+  transportController.deliverShipment
+);
+
+module.exports = router;
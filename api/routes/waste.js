@@ -8,8 +8,53 @@ router.get("/list", wasteController.listWaste);
 router.get("/", wasteController.listWaste); // Alternative endpoint
 router.put("/update-status", wasteController.updateWasteStatus);
 
+// Dry-run (query-only) variants
+router.post("/simulate", wasteController.simulateAddWaste);
+router.put("/update-status/simulate", wasteController.simulateUpdateWasteStatus);
+
+// Tags
+router.get("/tags/:tag", wasteController.listWastesByTag);
+router.post("/:wasteId/tags", wasteController.addWasteTag);
+router.delete("/:wasteId/tags/:tag", wasteController.removeWasteTag);
+
+// Co-ownership
+router.post("/:wasteId/ownership/split", wasteController.splitOwnership);
+router.post("/:wasteId/ownership/transfer", wasteController.transferShare);
+router.get("/:wasteId/settlement", wasteController.getSettlementSplit);
+
+// Moisture and oil content (dry-matter accounting)
+router.put("/:wasteId/composition", wasteController.setWasteComposition);
+router.put("/:wasteId/embargo", wasteController.setWasteEmbargo);
+
+// Fields encrypted by the client (only the key fingerprint is on-chain)
+router.put("/:wasteId/sealed/:field", wasteController.sealWasteField);
+router.post(
+  "/:wasteId/sealed/:field/open",
+  wasteController.openSealedWasteField
+);
+
+// Donations and landfill disposals (lots leaving the chain unsold)
+router.get(
+  "/dispositions/statistics",
+  wasteController.getDispositionStatistics
+);
+router.get("/dispositions/:dispositionId", wasteController.getDisposition);
+router.post("/:wasteId/donate", wasteController.donateWaste);
+router.post("/:wasteId/dispose", wasteController.disposeWaste);
+
+// Validation warnings (non-blocking data-entry issues)
+router.get("/warnings", wasteController.listWastesWithWarnings);
+router.post(
+  "/:wasteId/warnings/:code/resolve",
+  wasteController.resolveWasteWarning
+);
+
+// Personal data (private collection, owner organization only)
+router.get("/:wasteId/personal-data", wasteController.getWastePersonalData);
+
 // Blockchain-specific routes
 router.get("/history/:wasteId", wasteController.getWasteHistory);
+router.get("/:wasteId/as-of", wasteController.getWasteAsOf);
 router.get("/blockchain-status", wasteController.getBlockchainStatus);
 
 module.exports = router;
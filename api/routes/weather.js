@@ -0,0 +1,9 @@
+const express = require("express");
+const router = express.Router();
+const weatherController = require("../controllers/weatherController");
+
+// Weather observations reported by the oracle
+router.get("/observations", weatherController.listObservations);
+router.post("/observations", weatherController.recordObservation);
+
+module.exports = router;
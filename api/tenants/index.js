@@ -0,0 +1,199 @@
+// Whitelabel tenants - the cooperatives served by this backend under their
+// own brand, read from an optional JSON file that is reloaded whenever it
+// changes:
+//
+//   {
+//     "sfax": {
+//       "name": "Sfax Olive Cooperative",
+//       "orgs": ["processor"],
+//       "msps": ["ProcessorOrgMSP"],
+//       "logo": "/etc/olive/tenants/sfax.jpg",
+//       "logoUrl": "https://sfax-olive.example/logo.png",
+//       "primaryColor": "#1f4e79",
+//       "accentColor": "#e0a800",
+//       "footer": "Certificate issued by the Sfax Olive Cooperative.",
+//       "languages": ["fr", "en"],
+//       "traceUrl": "https://trace.sfax-olive.example/api/traceability",
+//       "storage": { "provider": "s3", "bucket": "sfax-documents", ... }
+//     }
+//   }
+//
+// Requests acting as one of "orgs" (?org=) are branded by that tenant, and
+// so are public trace pages of tokens issued by one of its "msps". The logo
+// embedded in PDF reports must be a JPEG file; logoUrl is what public pages
+// display. Everything else gets the default brand from REPORT_BRAND_*.
+// "storage" is where the tenant's attachments are kept (see api/objectStorage).
+const fs = require("fs");
+const path = require("path");
+const PdfDocument = require("../reports/pdfDocument");
+
+const TENANTS_FILE = process.env.TENANTS_FILE;
+
+const DEFAULT_TENANT_ID = "default";
+
+const HEX_COLOR_PATTERN = /^#[0-9a-f]{6}$/i;
+
+const LANGUAGE_PATTERN = /^[a-z]{2}$/;
+
+const defaultTenant = () => ({
+  id: DEFAULT_TENANT_ID,
+  name: process.env.REPORT_BRAND_NAME || "Green Olive Chain",
+  orgs: [],
+  msps: [],
+  logo: null,
+  logoUrl: process.env.REPORT_LOGO_URL || "",
+  primaryColor: HEX_COLOR_PATTERN.test(process.env.REPORT_BRAND_COLOR || "")
+    ? process.env.REPORT_BRAND_COLOR
+    : "#3d7a38",
+  accentColor: HEX_COLOR_PATTERN.test(process.env.REPORT_ACCENT_COLOR || "")
+    ? process.env.REPORT_ACCENT_COLOR
+    : "",
+  footer:
+    process.env.REPORT_FOOTER ||
+    "This certificate reflects data recorded on the Green Olive Chain ledger.",
+  languages: ["en"],
+  traceUrl: process.env.PUBLIC_TRACE_URL || "",
+  storage: null,
+});
+
+// Check one tenant entry of the file and load its logo
+const validateTenant = (id, entry) => {
+  if (!entry || typeof entry !== "object" || !entry.name) {
+    throw new Error(`tenant ${id} needs a name`);
+  }
+  for (const field of ["primaryColor", "accentColor"]) {
+    if (entry[field] && !HEX_COLOR_PATTERN.test(entry[field])) {
+      throw new Error(`tenant ${id}: ${field} must be a #rrggbb color`);
+    }
+  }
+  const languages = entry.languages || ["en"];
+  if (
+    !Array.isArray(languages) ||
+    languages.length === 0 ||
+    languages.some((language) => !LANGUAGE_PATTERN.test(language))
+  ) {
+    throw new Error(`tenant ${id}: languages must list two-letter codes`);
+  }
+  if (
+    entry.storage &&
+    (typeof entry.storage !== "object" || !entry.storage.provider)
+  ) {
+    throw new Error(`tenant ${id}: storage needs a provider`);
+  }
+  let logo = null;
+  if (entry.logo) {
+    logo = fs.readFileSync(path.resolve(entry.logo));
+    PdfDocument.jpegInfo(logo);
+  }
+  const fallback = defaultTenant();
+  return {
+    id,
+    name: entry.name,
+    orgs: entry.orgs || [],
+    msps: entry.msps || [],
+    logo,
+    logoUrl: entry.logoUrl || "",
+    primaryColor: entry.primaryColor || fallback.primaryColor,
+    accentColor: entry.accentColor || "",
+    footer: entry.footer || fallback.footer,
+    languages,
+    traceUrl: entry.traceUrl || fallback.traceUrl,
+    storage: entry.storage || null,
+  };
+};
+
+let tenants = {};
+let watching = false;
+
+// Read the tenants file again; an invalid file keeps the current tenants
+const reloadTenants = () => {
+  try {
+    const configured = TENANTS_FILE
+      ? JSON.parse(fs.readFileSync(path.resolve(TENANTS_FILE), "utf8"))
+      : {};
+    const loaded = {};
+    Object.entries(configured).forEach(([id, entry]) => {
+      if (id === DEFAULT_TENANT_ID) {
+        throw new Error(`'${DEFAULT_TENANT_ID}' is reserved`);
+      }
+      loaded[id] = validateTenant(id, entry);
+    });
+    tenants = loaded;
+    return true;
+  } catch (error) {
+    console.error(
+      "❌ Could not load the tenants configuration:",
+      error.message
+    );
+    return false;
+  }
+};
+
+// Reload whenever the tenants file changes and on SIGHUP
+const watchTenants = () => {
+  if (watching) {
+    return;
+  }
+  watching = true;
+  if (TENANTS_FILE) {
+    fs.watchFile(path.resolve(TENANTS_FILE), { interval: 5000 }, () => {
+      if (reloadTenants()) {
+        console.log("🔄 Tenants configuration reloaded");
+      }
+    }).unref();
+  }
+  process.on("SIGHUP", reloadTenants);
+};
+
+const findTenant = (predicate) =>
+  Object.values(tenants).find(predicate) || defaultTenant();
+
+// Tenant by ID, the default one included, or null
+const getTenant = (id) =>
+  id === DEFAULT_TENANT_ID ? defaultTenant() : tenants[id] || null;
+
+// Tenant of the organization a request acts as (gateway name, e.g. farmer)
+const tenantForOrg = (org) =>
+  findTenant((tenant) => Boolean(org) && tenant.orgs.includes(org));
+
+// Tenant of the organization (MSP ID) that issued a public trace token
+const tenantForMsp = (msp) =>
+  findTenant((tenant) => Boolean(msp) && tenant.msps.includes(msp));
+
+// First of the tenant's languages the client asked for (?lang= or
+// Accept-Language), else the tenant's first language
+const tenantLanguage = (tenant, req) => {
+  const requested = [
+    req.query.lang,
+    ...String(req.get("Accept-Language") || "")
+      .split(",")
+      .map((range) => range.split(";")[0].trim().slice(0, 2).toLowerCase()),
+  ].filter(Boolean);
+  return (
+    requested.find((language) => tenant.languages.includes(language)) ||
+    tenant.languages[0]
+  );
+};
+
+// What public trace pages need to brand themselves
+const publicTheme = (tenant) => ({
+  tenant: tenant.id,
+  name: tenant.name,
+  logoUrl: tenant.logoUrl || null,
+  primaryColor: tenant.primaryColor,
+  accentColor: tenant.accentColor || null,
+  footer: tenant.footer,
+  languages: tenant.languages,
+});
+
+reloadTenants();
+
+module.exports = {
+  reloadTenants,
+  watchTenants,
+  getTenant,
+  tenantForOrg,
+  tenantForMsp,
+  tenantLanguage,
+  publicTheme,
+};
@@ -0,0 +1,452 @@
+// Command openapi generates the OpenAPI 3 document of the REST gateway and
+// the generated part of the Go client from the operations and request models
+// of pkg/api. Run it with go generate ./pkg/api after changing them.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chaincode/pkg/api"
+)
+
+func main() {
+	specPath := flag.String("spec", "api/openapi.json", "where to write the OpenAPI document")
+	clientPath := flag.String("client", "pkg/client/zz_generated.go", "where to write the generated client code")
+	flag.Parse()
+
+	g := newGenerator()
+	spec, err := json.MarshalIndent(g.document(api.Operations), "", "  ")
+	if err != nil {
+		log.Fatalf("failed to encode the OpenAPI document: %v", err)
+	}
+	if err := ioutil.WriteFile(*specPath, append(spec, '\n'), 0644); err != nil {
+		log.Fatalf("failed to write %s: %v", *specPath, err)
+	}
+
+	client, err := g.client(api.Operations)
+	if err != nil {
+		log.Fatalf("failed to generate the client: %v", err)
+	}
+	if err := ioutil.WriteFile(*clientPath, client, 0644); err != nil {
+		log.Fatalf("failed to write %s: %v", *clientPath, err)
+	}
+}
+
+type object = map[string]interface{}
+
+// generator collects the named schemas met while describing the operations
+type generator struct {
+	schemas map[string]object
+	types   map[string]reflect.Type
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: map[string]object{},
+		types:   map[string]reflect.Type{},
+	}
+}
+
+// document returns the OpenAPI document of the operations
+func (g *generator) document(operations []api.Operation) object {
+	paths := object{}
+	for _, op := range operations {
+		item, ok := paths[op.Path].(object)
+		if !ok {
+			item = object{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = g.operation(op)
+	}
+
+	schemas := object{
+		"Envelope": envelopeSchema(),
+		"Page":     pageSchema(),
+		"Problem":  problemSchema(),
+	}
+	for name, schema := range g.schemas {
+		schemas[name] = schema
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "Green Olive Chain REST gateway",
+			"version":     "1.0.0",
+			"description": "Successful responses wrap their result in an envelope; errors are RFC 7807 problems whose types are listed at /problems.",
+		},
+		"paths":      paths,
+		"components": object{"schemas": schemas},
+	}
+}
+
+func (g *generator) operation(op api.Operation) object {
+	parameters := []object{}
+	for _, name := range pathParams(op.Path) {
+		parameters = append(parameters, object{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   object{"type": "string"},
+		})
+	}
+	if op.Query != nil {
+		for _, field := range fields(reflect.TypeOf(op.Query)) {
+			parameters = append(parameters, object{
+				"name":   field.name,
+				"in":     "query",
+				"schema": g.fieldSchema(field),
+			})
+		}
+	}
+
+	result := object{"$ref": "#/components/schemas/Envelope"}
+	if op.Data != nil {
+		result = object{"allOf": []object{
+			result,
+			{"properties": object{"data": g.schema(reflect.TypeOf(op.Data))}},
+		}}
+	}
+	status := "200"
+	if op.Created {
+		status = "201"
+	}
+
+	operation := object{
+		"operationId": op.ID,
+		"summary":     op.Summary,
+		"tags":        []string{op.Tag},
+		"parameters":  parameters,
+		"responses": object{
+			status: object{
+				"description": op.Summary,
+				"content":     object{"application/json": object{"schema": result}},
+			},
+			"default": object{
+				"description": "Problem",
+				"content": object{"application/problem+json": object{
+					"schema": object{"$ref": "#/components/schemas/Problem"},
+				}},
+			},
+		},
+	}
+	if op.Body != nil {
+		operation["requestBody"] = object{
+			"required": true,
+			"content": object{"application/json": object{
+				"schema": g.schema(reflect.TypeOf(op.Body)),
+			}},
+		}
+	}
+
+	return operation
+}
+
+// schema returns the schema of a Go type; named structs become components
+func (g *generator) schema(t reflect.Type) object {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.component(t)
+	}
+
+	return object{}
+}
+
+func (g *generator) component(t reflect.Type) object {
+	ref := object{"$ref": "#/components/schemas/" + t.Name()}
+	if known, ok := g.types[t.Name()]; ok {
+		if known != t {
+			log.Fatalf("schema name %s is used by both %s and %s", t.Name(), known, t)
+		}
+		return ref
+	}
+	g.types[t.Name()] = t
+
+	properties := object{}
+	required := []string{}
+	for _, field := range fields(t) {
+		properties[field.name] = g.fieldSchema(field)
+		if field.rules["required"] != "" {
+			required = append(required, field.name)
+		}
+	}
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	g.schemas[t.Name()] = schema
+
+	return ref
+}
+
+// fieldSchema returns the schema of a field with its validation rules
+func (g *generator) fieldSchema(field field) object {
+	schema := g.schema(field.typ)
+	if _, isRef := schema["$ref"]; isRef {
+		return schema
+	}
+	constrained := object{}
+	for key, value := range schema {
+		constrained[key] = value
+	}
+	if min, ok := field.number("min"); ok {
+		constrained["minimum"] = min
+	}
+	if gt, ok := field.number("gt"); ok {
+		constrained["minimum"] = gt
+		constrained["exclusiveMinimum"] = true
+	}
+	if max, ok := field.number("max"); ok {
+		constrained["maximum"] = max
+	}
+	if enum := field.rules["enum"]; enum != "" {
+		constrained["enum"] = strings.Split(enum, "|")
+	}
+
+	return constrained
+}
+
+// field is a JSON member of a struct with its validate rules
+type field struct {
+	name   string
+	goName string
+	typ    reflect.Type
+	rules  map[string]string
+}
+
+func (f field) number(rule string) (float64, bool) {
+	value, ok := f.rules[rule]
+	if !ok {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("rule %s=%s of %s is not a number", rule, value, f.goName)
+	}
+
+	return number, true
+}
+
+// fields returns the JSON members of a struct, embedded structs flattened
+func fields(t reflect.Type) []field {
+	var members []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			members = append(members, fields(sf.Type)...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		rules := map[string]string{}
+		for _, rule := range strings.Split(sf.Tag.Get("validate"), ",") {
+			if rule == "" {
+				continue
+			}
+			parts := strings.SplitN(rule, "=", 2)
+			if len(parts) == 1 {
+				rules[parts[0]] = "true"
+			} else {
+				rules[parts[0]] = parts[1]
+			}
+		}
+		members = append(members, field{name: name, goName: sf.Name, typ: sf.Type, rules: rules})
+	}
+
+	return members
+}
+
+// pathParams returns the {names} of a path template
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+
+	return names
+}
+
+func envelopeSchema() object {
+	return object{
+		"type":     "object",
+		"required": []string{"success"},
+		"properties": object{
+			"success":        object{"type": "boolean"},
+			"message":        object{"type": "string"},
+			"data":           object{},
+			"count":          object{"type": "integer"},
+			"page":           object{"$ref": "#/components/schemas/Page"},
+			"blockchainTxId": object{"type": "string"},
+		},
+	}
+}
+
+func pageSchema() object {
+	return object{
+		"type": "object",
+		"properties": object{
+			"limit":           object{"type": "integer"},
+			"sort":            object{"type": "string"},
+			"order":           object{"type": "string"},
+			"filters":         object{"type": "object"},
+			"nextCursor":      object{"type": "string", "nullable": true},
+			"total":           object{"type": "integer"},
+			"totalIsEstimate": object{"type": "boolean"},
+		},
+	}
+}
+
+func problemSchema() object {
+	return object{
+		"type":     "object",
+		"required": []string{"type", "title", "status"},
+		"properties": object{
+			"type":     object{"type": "string"},
+			"title":    object{"type": "string"},
+			"status":   object{"type": "integer"},
+			"detail":   object{"type": "string"},
+			"instance": object{"type": "string"},
+			"code":     object{"type": "string"},
+		},
+	}
+}
+
+// client returns the generated part of pkg/client: aliases of the request
+// and result types and a method per operation
+func (g *generator) client(operations []api.Operation) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by cmd/openapi; DO NOT EDIT.\n\n")
+	b.WriteString("package client\n\n")
+	b.WriteString("import (\n\t\"context\"\n\t\"net/url\"\n\n")
+	b.WriteString("\t\"github.com/chaincode/internal/models\"\n\t\"github.com/chaincode/pkg/api\"\n)\n\n")
+
+	// Query types and embedded structs are not schemas; alias them too
+	for _, op := range operations {
+		if op.Query != nil {
+			g.types[reflect.TypeOf(op.Query).Name()] = reflect.TypeOf(op.Query)
+		}
+	}
+	for _, t := range g.types {
+		for i := 0; i < t.NumField(); i++ {
+			if sf := t.Field(i); sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				g.types[sf.Type.Name()] = sf.Type
+			}
+		}
+	}
+	names := make([]string, 0, len(g.types))
+	for name := range g.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b.WriteString("// Types of the requests and results\ntype (\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\t%s = %s.%s\n", name, packageName(g.types[name]), name)
+	}
+	b.WriteString(")\n")
+
+	for _, op := range operations {
+		writeMethod(&b, op)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func packageName(t reflect.Type) string {
+	parts := strings.Split(t.PkgPath(), "/")
+	return parts[len(parts)-1]
+}
+
+func writeMethod(b *bytes.Buffer, op api.Operation) {
+	params := []string{"ctx context.Context"}
+	path := strconv.Quote(op.Path)
+	for _, name := range pathParams(op.Path) {
+		goName := goIdentifier(name)
+		params = append(params, goName+" string")
+		path = strings.Replace(path, "{"+name+"}", `" + url.PathEscape(`+goName+`) + "`, 1)
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, `"" + `), ` + ""`)
+	query, body := "nil", "nil"
+	if op.Query != nil {
+		params = append(params, "query *"+reflect.TypeOf(op.Query).Name())
+		query = "query"
+	}
+	if op.Body != nil {
+		params = append(params, "body *"+reflect.TypeOf(op.Body).Name())
+		body = "body"
+	}
+
+	fmt.Fprintf(b, "\n// %s calls %s %s: %s\n", op.ID, op.Method, op.Path, op.Summary)
+	if op.Data == nil {
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (*Response, error) {\n", op.ID, strings.Join(params, ", "))
+		fmt.Fprintf(b, "\treturn c.do(ctx, %q, %s, %s, %s, nil)\n}\n", op.Method, path, query, body)
+		return
+	}
+
+	dataType := goType(reflect.TypeOf(op.Data))
+	result := dataType
+	if reflect.TypeOf(op.Data).Kind() == reflect.Struct {
+		result = "*" + dataType
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, *Response, error) {\n", op.ID, strings.Join(params, ", "), result)
+	fmt.Fprintf(b, "\tvar data %s\n", dataType)
+	fmt.Fprintf(b, "\tresponse, err := c.do(ctx, %q, %s, %s, %s, &data)\n", op.Method, path, query, body)
+	fmt.Fprintf(b, "\tif err != nil {\n\t\treturn nil, response, err\n\t}\n")
+	if result != dataType {
+		b.WriteString("\treturn &data, response, nil\n}\n")
+	} else {
+		b.WriteString("\treturn data, response, nil\n}\n")
+	}
+}
+
+// goType names a result type through the client's aliases
+func goType(t reflect.Type) string {
+	if t.Kind() == reflect.Slice {
+		return "[]" + goType(t.Elem())
+	}
+
+	return t.Name()
+}
+
+// goIdentifier turns a path parameter such as wasteId into wasteID
+func goIdentifier(name string) string {
+	if strings.HasSuffix(name, "Id") {
+		return strings.TrimSuffix(name, "Id") + "ID"
+	}
+
+	return name
+}
@@ -0,0 +1,88 @@
+package contract
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AdminRole is the value of the "role" certificate attribute granting admin rights
+const AdminRole = "admin"
+
+// GraderRole is the role of independent quality graders
+const GraderRole = "grader"
+
+// Roles taking part in insurance claims
+const (
+	InsurerRole  = "insurer"
+	AssessorRole = "assessor"
+)
+
+// AuditorRole is the role of compliance officers reading the audit trail
+const AuditorRole = "auditor"
+
+// callerID returns the participant ID of the transaction submitter. It is
+// bound to the enrollment of the submitter's certificate, so it stays the
+// same across certificate renewals (see identity.go).
+func callerID(ctx contractapi.TransactionContextInterface) (string, error) {
+	id, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", newError(ctx, ErrIdentity, err)
+	}
+
+	return callerParticipant(ctx, id)
+}
+
+// callerMSP returns the MSP ID of the transaction submitter's organization
+func callerMSP(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", newError(ctx, ErrIdentity, err)
+	}
+
+	return mspID, nil
+}
+
+// isAdmin reports whether the caller holds the admin role
+func isAdmin(ctx contractapi.TransactionContextInterface) bool {
+	return hasRole(ctx, AdminRole)
+}
+
+// hasRole reports whether the caller holds a role, either through a Fabric CA
+// "role" attribute or an organizational unit of that name (NodeOUs)
+func hasRole(ctx contractapi.TransactionContextInterface, role string) bool {
+	identity := ctx.GetClientIdentity()
+	if identity.AssertAttributeValue("role", role) == nil {
+		return true
+	}
+
+	cert, err := identity.GetX509Certificate()
+	if err != nil || cert == nil {
+		return false
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if strings.EqualFold(ou, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requireAdmin rejects the transaction unless the caller is an admin
+func requireAdmin(ctx contractapi.TransactionContextInterface) error {
+	if !isAdmin(ctx) {
+		return newError(ctx, ErrNotAdmin)
+	}
+
+	return nil
+}
+
+// requireGrader rejects the transaction unless the caller is a quality grader
+func requireGrader(ctx contractapi.TransactionContextInterface) error {
+	if !hasRole(ctx, GraderRole) {
+		return newError(ctx, ErrGraderRequired)
+	}
+
+	return nil
+}
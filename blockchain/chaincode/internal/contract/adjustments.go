@@ -0,0 +1,331 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SetSupplyContractRules replaces the bonus and penalty rules of a proposed
+// supply contract with the JSON array rulesJson. Either party may amend the
+// rules; the amendment becomes a proposal the other party has to accept.
+func (s *SmartContract) SetSupplyContractRules(ctx contractapi.TransactionContextInterface, contractId string, rulesJson string) (*models.SupplyContract, error) {
+	var rules []models.AdjustmentRule
+	if err := json.Unmarshal([]byte(rulesJson), &rules); err != nil {
+		return nil, newError(ctx, ErrAdjustmentRulesInvalid, err)
+	}
+	seen := map[string]bool{}
+	for i := range rules {
+		if err := normalizeAdjustmentRule(ctx, &rules[i]); err != nil {
+			return nil, err
+		}
+		if seen[rules[i].ID] {
+			return nil, newError(ctx, ErrAdjustmentRuleDuplicate, rules[i].ID)
+		}
+		seen[rules[i].ID] = true
+	}
+
+	contract, err := s.ReadSupplyContract(ctx, contractId)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status != models.SupplyProposed {
+		return nil, newError(ctx, ErrAdjustmentRulesLocked, contractId, models.SupplyProposed)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != contract.Supplier && mspID != contract.Buyer {
+		return nil, newError(ctx, ErrSupplyAmendmentForbidden, contract.Supplier, contract.Buyer, contractId)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contract.Rules = rules
+	contract.ProposedBy = mspID
+	contract.UpdatedAt = now
+	contract.History = append(contract.History, models.History{
+		Timestamp: now,
+		Action:    "RULES_SET",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%d adjustment rules proposed by %s", len(rules), mspID),
+	})
+
+	if err := s.putSupplyContract(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	counterparty := contract.Buyer
+	if mspID == contract.Buyer {
+		counterparty = contract.Supplier
+	}
+	if err := notify(ctx, counterparty, models.NotifySupplyProposed, "SUPPLY_"+contractId, fmt.Sprintf("%s amended the adjustment rules of supply contract %s", mspID, contractId)); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// GetContractAdjustments returns the adjustments ledger of a supply contract;
+// parties and admins only
+func (s *SmartContract) GetContractAdjustments(ctx contractapi.TransactionContextInterface, contractId string) (*models.AdjustmentLedger, error) {
+	contract, err := s.ReadSupplyContract(ctx, contractId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireSupplyParty(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	adjustments, err := loadContractAdjustments(ctx, contractId)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := &models.AdjustmentLedger{
+		ContractID:  contractId,
+		Currency:    contract.Price.Currency,
+		Adjustments: adjustments,
+	}
+	for _, adjustment := range adjustments {
+		if adjustment.Kind == models.AdjustmentBonus {
+			ledger.Bonuses += adjustment.Amount
+		} else {
+			ledger.Penalties += adjustment.Amount
+		}
+	}
+	ledger.Bonuses = math.Round(ledger.Bonuses*100) / 100
+	ledger.Penalties = math.Round(ledger.Penalties*100) / 100
+	ledger.Net = math.Round((ledger.Bonuses+ledger.Penalties)*100) / 100
+
+	return ledger, nil
+}
+
+// applyContractAdjustments brings the adjustments of a graded extraction's
+// delivery in line with the contract rules: for each rule, the difference
+// between what it now yields and what the ledger already holds is recorded
+func (s *SmartContract) applyContractAdjustments(ctx contractapi.TransactionContextInterface, extraction *models.Extraction) error {
+	if extraction.SupplyContractID == "" || !isGraded(extraction) {
+		return nil
+	}
+	contract, err := s.ReadSupplyContract(ctx, extraction.SupplyContractID)
+	if err != nil {
+		return err
+	}
+	if len(contract.Rules) == 0 {
+		return nil
+	}
+	var delivery *models.ContractDelivery
+	for i := range contract.Deliveries {
+		if contract.Deliveries[i].ExtractionID == extraction.ID {
+			delivery = &contract.Deliveries[i]
+		}
+	}
+	if delivery == nil {
+		return nil
+	}
+
+	daysLate := 0
+	waste, err := s.readWaste(ctx, delivery.WasteID)
+	if err != nil {
+		return err
+	}
+	harvested, errHarvest := time.Parse("2006-01-02", waste.HarvestDate)
+	delivered, errDelivery := time.Parse(time.RFC3339, delivery.DeliveredAt)
+	if errHarvest == nil && errDelivery == nil {
+		daysLate = int(delivered.Sub(harvested).Hours() / 24)
+	}
+
+	existing, err := loadContractAdjustments(ctx, contract.ID)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	grade := gradeOrDefault(extraction.QualityGrade)
+
+	for _, rule := range contract.Rules {
+		amount, reason, late := adjustmentAmount(rule, delivery, grade, daysLate)
+		recorded := 0.0
+		for _, adjustment := range existing {
+			if adjustment.ExtractionID == extraction.ID && adjustment.RuleID == rule.ID {
+				recorded += adjustment.Amount
+			}
+		}
+		difference := math.Round((amount-recorded)*100) / 100
+		if difference == 0 {
+			continue
+		}
+		if recorded != 0 {
+			if reason == "" {
+				reason = "rule no longer applies"
+			}
+			reason = fmt.Sprintf("correction after grade %s: %s", grade, reason)
+		}
+
+		id, err := newAssetID(ctx, "ADJ")
+		if err != nil {
+			return err
+		}
+		adjustment := models.ContractAdjustment{
+			ID:           id,
+			ContractID:   contract.ID,
+			RuleID:       rule.ID,
+			Kind:         rule.Kind,
+			WasteID:      delivery.WasteID,
+			ExtractionID: extraction.ID,
+			Grade:        grade,
+			DaysLate:     late,
+			Amount:       difference,
+			Currency:     contract.Price.Currency,
+			Reason:       reason,
+			CreatedAt:    now,
+		}
+		if err := newAssetStore(ctx).Put(adjustmentKey(contract.ID, now, id), adjustment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// adjustmentAmount returns the signed amount a rule yields for a delivery
+// with the given grade, taking daysTaken from harvest to delivery, along with
+// the reason and the days late it was computed on
+func adjustmentAmount(rule models.AdjustmentRule, delivery *models.ContractDelivery, grade string, daysTaken int) (float64, string, int) {
+	factor := 0.0
+	reason := ""
+	late := 0
+	switch rule.Trigger {
+	case models.TriggerGrade:
+		better := gradeRank(grade) <= gradeRank(rule.Grade)
+		worse := gradeRank(grade) >= gradeRank(rule.Grade)
+		if (rule.Kind == models.AdjustmentBonus && better) || (rule.Kind == models.AdjustmentPenalty && worse) {
+			factor = 1
+			reason = fmt.Sprintf("graded %s (rule threshold %s)", grade, rule.Grade)
+		}
+	case models.TriggerLate:
+		if daysTaken > rule.MaxDays {
+			late = daysTaken - rule.MaxDays
+			factor = float64(late)
+			reason = fmt.Sprintf("delivered %d days after harvest, %d allowed", daysTaken, rule.MaxDays)
+		}
+	}
+	if factor == 0 {
+		return 0, "", 0
+	}
+
+	amount := rule.Rate * factor * delivery.Quantity
+	if rule.Basis == models.BasisPercent {
+		amount = rule.Rate * factor / 100 * delivery.Amount
+	}
+	if rule.Cap > 0 && amount > rule.Cap {
+		amount = rule.Cap
+	}
+	if rule.Kind == models.AdjustmentPenalty {
+		amount = -amount
+	}
+
+	return math.Round(amount*100) / 100, reason, late
+}
+
+// normalizeAdjustmentRule upper-cases the rule's enumerations and checks it
+// is one the engine can apply
+func normalizeAdjustmentRule(ctx contractapi.TransactionContextInterface, rule *models.AdjustmentRule) error {
+	rule.Kind = strings.ToUpper(rule.Kind)
+	rule.Trigger = strings.ToUpper(rule.Trigger)
+	rule.Basis = strings.ToUpper(rule.Basis)
+	if rule.ID == "" {
+		return newError(ctx, ErrAdjustmentRuleIDRequired)
+	}
+	if rule.Kind != models.AdjustmentBonus && rule.Kind != models.AdjustmentPenalty {
+		return newError(ctx, ErrAdjustmentKindInvalid, rule.ID, models.AdjustmentBonus, models.AdjustmentPenalty)
+	}
+	switch rule.Trigger {
+	case models.TriggerGrade:
+		if !isQualityGrade(rule.Grade) {
+			return newError(ctx, ErrAdjustmentGradeUnknown, rule.ID, rule.Grade, qualityGrades)
+		}
+	case models.TriggerLate:
+		if rule.Kind != models.AdjustmentPenalty {
+			return newError(ctx, ErrAdjustmentPenaltyOnly, rule.ID, models.TriggerLate)
+		}
+		if rule.MaxDays < 0 {
+			return newError(ctx, ErrAdjustmentMaxDaysNegative, rule.ID)
+		}
+	default:
+		return newError(ctx, ErrAdjustmentTriggerInvalid, rule.ID, models.TriggerGrade, models.TriggerLate)
+	}
+	if rule.Basis != models.BasisPerUnit && rule.Basis != models.BasisPercent {
+		return newError(ctx, ErrAdjustmentBasisInvalid, rule.ID, models.BasisPerUnit, models.BasisPercent)
+	}
+	if rule.Rate <= 0 || rule.Cap < 0 {
+		return newError(ctx, ErrAdjustmentRateInvalid, rule.ID)
+	}
+
+	return nil
+}
+
+// isGraded reports whether an extraction has a grade of its own rather than
+// the one inherited from its lot
+func isGraded(extraction *models.Extraction) bool {
+	for _, record := range extraction.Grading {
+		if record.Kind != models.GradeInherited {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gradeRank is the position of a grade on the quality scale, best first
+func gradeRank(grade string) int {
+	grade = gradeOrDefault(grade)
+	for i, g := range qualityGrades {
+		if g == grade {
+			return i
+		}
+	}
+
+	return len(qualityGrades)
+}
+
+func adjustmentKey(contractID string, createdAt string, id string) string {
+	return "ADJUSTMENT_" + contractID + "_" + createdAt + "_" + id
+}
+
+func loadContractAdjustments(ctx contractapi.TransactionContextInterface, contractID string) ([]models.ContractAdjustment, error) {
+	prefix := "ADJUSTMENT_" + contractID + "_"
+	adjustments := []models.ContractAdjustment{}
+	err := newAssetStore(ctx).Range(prefix, prefix+"~", func(_ string, value []byte) error {
+		var adjustment models.ContractAdjustment
+		if err := json.Unmarshal(value, &adjustment); err != nil {
+			return err
+		}
+		if adjustment.ContractID == contractID {
+			adjustments = append(adjustments, adjustment)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
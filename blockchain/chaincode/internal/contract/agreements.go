@@ -0,0 +1,259 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// agreementScopes lists the asset types an agreement may cover. Only lots
+// are hidden from other organizations; extractions and recyclings are
+// readable by every member, so sharing them needs no agreement.
+var agreementScopes = map[string]bool{
+	"WASTE": true,
+}
+
+// ProposeAgreement offers a data-sharing agreement from the caller's organization
+// to a counterparty organization; scope is a comma-separated list of asset types
+func (s *SmartContract) ProposeAgreement(ctx contractapi.TransactionContextInterface, id string, counterparty string, scope string, validFrom string, validUntil string) (*models.Agreement, error) {
+	if id == "" {
+		return nil, newError(ctx, ErrAgreementIDRequired)
+	}
+
+	proposer, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if counterparty == "" || counterparty == proposer {
+		return nil, newError(ctx, ErrAgreementCounterpartyInvalid)
+	}
+
+	scopes, err := parseAgreementScope(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := time.Parse("2006-01-02", validFrom)
+	if err != nil {
+		return nil, newError(ctx, ErrValidFromInvalid, validFrom)
+	}
+	until, err := time.Parse("2006-01-02", validUntil)
+	if err != nil {
+		return nil, newError(ctx, ErrValidUntilInvalid, validUntil)
+	}
+	if until.Before(from) {
+		return nil, newError(ctx, ErrValidityRangeInvalid)
+	}
+
+	exists, err := newAssetStore(ctx).Exists("AGREEMENT_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrAgreementAlreadyExists, id)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	agreement := &models.Agreement{
+		ID:           id,
+		Proposer:     proposer,
+		Counterparty: counterparty,
+		Scope:        scopes,
+		ValidFrom:    validFrom,
+		ValidUntil:   validUntil,
+		Status:       models.AgreementProposed,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "PROPOSED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("%s proposed sharing %s with %s", proposer, strings.Join(scopes, ","), counterparty),
+			},
+		},
+	}
+
+	if err := s.putAgreement(ctx, agreement); err != nil {
+		return nil, err
+	}
+
+	if err := notify(ctx, counterparty, models.NotifyAgreementProposed, "AGREEMENT_"+id, fmt.Sprintf("%s proposes sharing %s from %s to %s", proposer, strings.Join(scopes, ","), validFrom, validUntil)); err != nil {
+		return nil, err
+	}
+
+	return agreement, nil
+}
+
+// AcceptAgreement activates a proposed agreement; only the counterparty may accept
+func (s *SmartContract) AcceptAgreement(ctx contractapi.TransactionContextInterface, id string) (*models.Agreement, error) {
+	agreement, err := s.ReadAgreement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if agreement.Status != models.AgreementProposed {
+		return nil, newError(ctx, ErrAgreementStatusInvalid, id, agreement.Status, models.AgreementProposed)
+	}
+
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != agreement.Counterparty {
+		return nil, newError(ctx, ErrAgreementAcceptForbidden, agreement.Counterparty, id)
+	}
+
+	if err := s.changeAgreementStatus(ctx, agreement, models.AgreementActive, "ACCEPTED"); err != nil {
+		return nil, err
+	}
+
+	if err := notify(ctx, agreement.Proposer, models.NotifyAgreementAccepted, "AGREEMENT_"+id, fmt.Sprintf("%s accepted agreement %s", mspID, id)); err != nil {
+		return nil, err
+	}
+
+	return agreement, nil
+}
+
+// RevokeAgreement ends an agreement; either party may revoke
+func (s *SmartContract) RevokeAgreement(ctx contractapi.TransactionContextInterface, id string) (*models.Agreement, error) {
+	agreement, err := s.ReadAgreement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if agreement.Status == models.AgreementRevoked {
+		return nil, newError(ctx, ErrAgreementAlreadyRevoked, id)
+	}
+
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != agreement.Proposer && mspID != agreement.Counterparty {
+		return nil, newError(ctx, ErrAgreementRevokeForbidden, id)
+	}
+
+	if err := s.changeAgreementStatus(ctx, agreement, models.AgreementRevoked, "REVOKED"); err != nil {
+		return nil, err
+	}
+
+	otherParty := agreement.Counterparty
+	if mspID == agreement.Counterparty {
+		otherParty = agreement.Proposer
+	}
+	if err := notify(ctx, otherParty, models.NotifyAgreementRevoked, "AGREEMENT_"+id, fmt.Sprintf("%s revoked agreement %s", mspID, id)); err != nil {
+		return nil, err
+	}
+
+	return agreement, nil
+}
+
+// ReadAgreement returns the agreement stored with the given id
+func (s *SmartContract) ReadAgreement(ctx contractapi.TransactionContextInterface, id string) (*models.Agreement, error) {
+	var agreement models.Agreement
+	found, err := newAssetStore(ctx).Get("AGREEMENT_"+id, &agreement)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "AGREEMENT_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrAgreementNotFound, id)
+	}
+
+	return &agreement, nil
+}
+
+// GetMyAgreements returns the agreements the caller's organization is party to
+func (s *SmartContract) GetMyAgreements(ctx contractapi.TransactionContextInterface) ([]*models.Agreement, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	agreements, err := loadAgreements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mine []*models.Agreement
+	for _, agreement := range agreements {
+		if agreement.Proposer == mspID || agreement.Counterparty == mspID {
+			mine = append(mine, agreement)
+		}
+	}
+
+	return mine, nil
+}
+
+func (s *SmartContract) changeAgreementStatus(ctx contractapi.TransactionContextInterface, agreement *models.Agreement, status string, action string) error {
+	actor, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	agreement.Status = status
+	agreement.UpdatedAt = now
+	agreement.History = append(agreement.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("Agreement %s is now %s", agreement.ID, status),
+	})
+
+	return s.putAgreement(ctx, agreement)
+}
+
+func (s *SmartContract) putAgreement(ctx contractapi.TransactionContextInterface, agreement *models.Agreement) error {
+	return newAssetStore(ctx).Put("AGREEMENT_"+agreement.ID, agreement)
+}
+
+func loadAgreements(ctx contractapi.TransactionContextInterface) ([]*models.Agreement, error) {
+	var agreements []*models.Agreement
+	err := newAssetStore(ctx).Range("AGREEMENT_", "AGREEMENT_~", func(_ string, value []byte) error {
+		var agreement models.Agreement
+		if err := json.Unmarshal(value, &agreement); err != nil {
+			return err
+		}
+		agreements = append(agreements, &agreement)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return agreements, nil
+}
+
+func parseAgreementScope(ctx contractapi.TransactionContextInterface, scope string) ([]string, error) {
+	var scopes []string
+	for _, part := range strings.Split(scope, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if !agreementScopes[part] {
+			return nil, newError(ctx, ErrAgreementScopeUnknown, part)
+		}
+		scopes = append(scopes, part)
+	}
+	if len(scopes) == 0 {
+		return nil, newError(ctx, ErrAgreementScopeRequired)
+	}
+
+	return scopes, nil
+}
@@ -0,0 +1,380 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// approvalOperations are the operations an approval matrix may hold
+var approvalOperations = []string{models.ApprovalTransferShare}
+
+// SetApprovalMatrix schedules a version of an organization's approval
+// matrix: rulesJson is a JSON array of approval rules ({operation,
+// minQuantity, requiredRoles}), in effect from effectiveFrom (YYYY-MM-DD or
+// RFC3339, now when empty) until the next version. Versions already in
+// effect cannot be changed; a later one with the same date replaces the
+// scheduled one. An empty array lifts approvals from that date. Admin only.
+func (s *SmartContract) SetApprovalMatrix(ctx contractapi.TransactionContextInterface, orgMsp string, effectiveFrom string, rulesJson string) (*models.ApprovalMatrix, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if orgMsp == "" {
+		return nil, newError(ctx, ErrOrganizationRequired)
+	}
+	var rules []models.ApprovalRule
+	if err := json.Unmarshal([]byte(rulesJson), &rules); err != nil {
+		return nil, newError(ctx, ErrApprovalRulesInvalid, err)
+	}
+	rules, err := normalizeApprovalRules(ctx, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := parseEffectiveFrom(ctx, effectiveFrom, now)
+	if err != nil {
+		return nil, err
+	}
+	if from < now {
+		return nil, newError(ctx, ErrEffectiveDatePast, from)
+	}
+
+	matrix, err := readApprovalMatrix(ctx, orgMsp)
+	if err != nil {
+		return nil, err
+	}
+	if matrix == nil {
+		matrix = &models.ApprovalMatrix{
+			OrgMSP:    orgMsp,
+			Versions:  []models.ApprovalMatrixVersion{},
+			CreatedAt: now,
+			History:   []models.History{},
+		}
+	}
+	action := "VERSION_SCHEDULED"
+	versions := []models.ApprovalMatrixVersion{}
+	for _, version := range matrix.Versions {
+		if version.EffectiveFrom == from {
+			action = "VERSION_REPLACED"
+			continue
+		}
+		versions = append(versions, version)
+	}
+	versions = append(versions, models.ApprovalMatrixVersion{
+		EffectiveFrom: from,
+		Rules:         rules,
+		SetBy:         actor,
+		SetAt:         now,
+	})
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].EffectiveFrom < versions[j].EffectiveFrom
+	})
+	matrix.Versions = versions
+	matrix.UpdatedAt = now
+	matrix.History = append(matrix.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("%d rule(s) in effect from %s", len(rules), from),
+	})
+
+	if err := newAssetStore(ctx).Put("APPROVALMATRIX_"+orgMsp, matrix); err != nil {
+		return nil, err
+	}
+
+	return matrix, nil
+}
+
+// CancelApprovalMatrixVersion drops a version of an organization's approval
+// matrix that is not in effect yet. Admin only.
+func (s *SmartContract) CancelApprovalMatrixVersion(ctx contractapi.TransactionContextInterface, orgMsp string, effectiveFrom string) (*models.ApprovalMatrix, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	matrix, err := readApprovalMatrix(ctx, orgMsp)
+	if err != nil {
+		return nil, err
+	}
+	if matrix == nil {
+		return nil, newError(ctx, ErrApprovalMatrixNotFound, orgMsp)
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := parseEffectiveFrom(ctx, effectiveFrom, now)
+	if err != nil {
+		return nil, err
+	}
+	if from <= now {
+		return nil, newError(ctx, ErrApprovalMatrixVersionInEffect, from, orgMsp)
+	}
+
+	versions := []models.ApprovalMatrixVersion{}
+	for _, version := range matrix.Versions {
+		if version.EffectiveFrom != from {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == len(matrix.Versions) {
+		return nil, newError(ctx, ErrApprovalMatrixVersionNotFound, from, orgMsp)
+	}
+	matrix.Versions = versions
+	matrix.UpdatedAt = now
+	matrix.History = append(matrix.History, models.History{
+		Timestamp: now,
+		Action:    "VERSION_CANCELLED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Version from %s cancelled", from),
+	})
+
+	if err := newAssetStore(ctx).Put("APPROVALMATRIX_"+orgMsp, matrix); err != nil {
+		return nil, err
+	}
+
+	return matrix, nil
+}
+
+// ReadApprovalMatrix returns the approval matrix of an organization, with
+// its past and scheduled versions; admins and the organization may read it
+func (s *SmartContract) ReadApprovalMatrix(ctx contractapi.TransactionContextInterface, orgMsp string) (*models.ApprovalMatrix, error) {
+	if err := requireMatrixReader(ctx, orgMsp); err != nil {
+		return nil, err
+	}
+	matrix, err := readApprovalMatrix(ctx, orgMsp)
+	if err != nil {
+		return nil, err
+	}
+	if matrix == nil {
+		return nil, newError(ctx, ErrApprovalMatrixNotFound, orgMsp)
+	}
+
+	return matrix, nil
+}
+
+// GetApprovalMatrices returns the approval matrices of all organizations.
+// Admin only.
+func (s *SmartContract) GetApprovalMatrices(ctx contractapi.TransactionContextInterface) ([]*models.ApprovalMatrix, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	matrices := []*models.ApprovalMatrix{}
+	err := newAssetStore(ctx).Range("APPROVALMATRIX_", "APPROVALMATRIX_~", func(_ string, value []byte) error {
+		var matrix models.ApprovalMatrix
+		if err := json.Unmarshal(value, &matrix); err != nil {
+			return err
+		}
+		matrices = append(matrices, &matrix)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matrices, nil
+}
+
+// GetEffectiveApprovalRules returns the approval rules of an organization
+// in effect at a time (YYYY-MM-DD or RFC3339, now when empty): those of its
+// matrix version in effect then, else the ones built from the approvals.*
+// settings, with an empty EffectiveFrom
+func (s *SmartContract) GetEffectiveApprovalRules(ctx contractapi.TransactionContextInterface, orgMsp string, at string) (*models.ApprovalMatrixVersion, error) {
+	if err := requireMatrixReader(ctx, orgMsp); err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	at, err = parseEffectiveFrom(ctx, at, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return effectiveApprovalRules(ctx, orgMsp, at)
+}
+
+// transferApprovalRule returns the rule a share transfer of quantity by the
+// seller's organization falls under, with the version it is part of, or
+// nil when the transfer needs no approval
+func transferApprovalRule(ctx contractapi.TransactionContextInterface, sellerMSP string, quantity float64) (*models.ApprovalRule, *models.ApprovalMatrixVersion, error) {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	version, err := effectiveApprovalRules(ctx, sellerMSP, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var applied *models.ApprovalRule
+	for i, rule := range version.Rules {
+		if rule.Operation != models.ApprovalTransferShare || quantity <= rule.MinQuantity {
+			continue
+		}
+		if applied == nil || rule.MinQuantity > applied.MinQuantity {
+			applied = &version.Rules[i]
+		}
+	}
+
+	return applied, version, nil
+}
+
+// effectiveApprovalRules returns the matrix version of an organization in
+// effect at an RFC3339 time, or the rules of the approvals.transferThreshold
+// and approvals.transferRoles settings when none is
+func effectiveApprovalRules(ctx contractapi.TransactionContextInterface, orgMsp string, at string) (*models.ApprovalMatrixVersion, error) {
+	matrix, err := readApprovalMatrix(ctx, orgMsp)
+	if err != nil {
+		return nil, err
+	}
+	if matrix != nil {
+		for i := len(matrix.Versions) - 1; i >= 0; i-- {
+			if matrix.Versions[i].EffectiveFrom <= at {
+				return &matrix.Versions[i], nil
+			}
+		}
+	}
+
+	version := &models.ApprovalMatrixVersion{Rules: []models.ApprovalRule{}}
+	threshold := configFloat(ctx, "approvals", "transferThreshold", 0)
+	if threshold <= 0 {
+		return version, nil
+	}
+	rules, err := normalizeApprovalRules(ctx, []models.ApprovalRule{{
+		Operation:     models.ApprovalTransferShare,
+		MinQuantity:   threshold,
+		RequiredRoles: splitList(configString(ctx, "approvals", "transferRoles", defaultTransferApprovers)),
+	}})
+	if err != nil {
+		return nil, newError(ctx, ErrApprovalSettingsInvalid, err)
+	}
+	version.Rules = rules
+
+	return version, nil
+}
+
+// normalizeApprovalRules upper-cases and checks approval rules: known
+// operations and roles, at least one role each and one rule per operation
+// and quantity
+func normalizeApprovalRules(ctx contractapi.TransactionContextInterface, rules []models.ApprovalRule) ([]models.ApprovalRule, error) {
+	normalized := []models.ApprovalRule{}
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		rule.Operation = strings.ToUpper(strings.TrimSpace(rule.Operation))
+		if !isApprovalOperation(rule.Operation) {
+			return nil, newError(ctx, ErrApprovalOperationUnknown, rule.Operation, strings.Join(approvalOperations, ", "))
+		}
+		if rule.MinQuantity < 0 {
+			return nil, newError(ctx, ErrApprovalMinQuantityNegative)
+		}
+		key := fmt.Sprintf("%s/%g", rule.Operation, rule.MinQuantity)
+		if seen[key] {
+			return nil, newError(ctx, ErrApprovalRuleDuplicate, rule.Operation, rule.MinQuantity)
+		}
+		seen[key] = true
+
+		roles := []string{}
+		seenRoles := map[string]bool{}
+		for _, role := range rule.RequiredRoles {
+			role = strings.ToUpper(strings.TrimSpace(role))
+			if !isApproverRole(role) {
+				return nil, newError(ctx, ErrApprovalRoleUnknown, role, strings.Join(approverRoles, ", "))
+			}
+			if !seenRoles[role] {
+				roles = append(roles, role)
+			}
+			seenRoles[role] = true
+		}
+		if len(roles) == 0 {
+			return nil, newError(ctx, ErrApprovalRuleNoRole, rule.Operation, rule.MinQuantity)
+		}
+		rule.RequiredRoles = roles
+		normalized = append(normalized, rule)
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		if normalized[i].Operation != normalized[j].Operation {
+			return normalized[i].Operation < normalized[j].Operation
+		}
+		return normalized[i].MinQuantity < normalized[j].MinQuantity
+	})
+
+	return normalized, nil
+}
+
+// parseEffectiveFrom reads a YYYY-MM-DD date (midnight UTC) or an RFC3339
+// time as an RFC3339 UTC time; empty stands for now
+func parseEffectiveFrom(ctx contractapi.TransactionContextInterface, value string, now string) (string, error) {
+	if value == "" {
+		return now, nil
+	}
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return day.UTC().Format(time.RFC3339), nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", newError(ctx, ErrEffectiveDateInvalid, value)
+	}
+
+	return at.UTC().Format(time.RFC3339), nil
+}
+
+// requireMatrixReader lets admins and members of orgMsp through
+func requireMatrixReader(ctx contractapi.TransactionContextInterface, orgMsp string) error {
+	if isAdmin(ctx) {
+		return nil
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if mspID != orgMsp {
+		return newError(ctx, ErrApprovalMatrixReadForbidden, orgMsp)
+	}
+
+	return nil
+}
+
+// isApprovalOperation reports whether operation may need approval
+func isApprovalOperation(operation string) bool {
+	for _, o := range approvalOperations {
+		if o == operation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readApprovalMatrix returns the approval matrix of an organization, or nil
+// if it never had one
+func readApprovalMatrix(ctx contractapi.TransactionContextInterface, orgMsp string) (*models.ApprovalMatrix, error) {
+	var matrix models.ApprovalMatrix
+	found, err := newAssetStore(ctx).Get("APPROVALMATRIX_"+orgMsp, &matrix)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "APPROVALMATRIX_"+orgMsp, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &matrix, nil
+}
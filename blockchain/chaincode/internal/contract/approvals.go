@@ -0,0 +1,433 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Approval defaults, overridden by approvals.transferRoles (for
+// organizations without an approval matrix in effect) and approvals.ttlDays
+const (
+	defaultTransferApprovers = models.ApproverOwner + "," + models.ApproverCooperativeAdmin
+	defaultApprovalTTLDays   = 7
+)
+
+// approvalActor is recorded as the actor of approvals closed on expiry
+const approvalActor = "maintenance"
+
+var approverRoles = []string{models.ApproverOwner, models.ApproverCooperativeAdmin, models.ApproverAdmin, models.ApproverAuditor, models.ApproverBuyer}
+
+// Approve signs a pending approval in one of its required roles; each role
+// needs a different identity. The transaction completing the quorum runs the
+// operation; if it can no longer run (the seller sold the share meanwhile)
+// the approval ends FAILED. Approvals past their deadline end EXPIRED.
+func (s *SmartContract) Approve(ctx contractapi.TransactionContextInterface, approvalId string, role string) (*models.Approval, error) {
+	role = strings.ToUpper(strings.TrimSpace(role))
+	approval, err := s.pendingApproval(ctx, approvalId)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if now > approval.ExpiresAt {
+		if err := s.closeApproval(ctx, approval, models.ApprovalExpired, "approval deadline passed", approvalActor, now); err != nil {
+			return nil, err
+		}
+		return approval, nil
+	}
+
+	required := false
+	for _, r := range approval.RequiredRoles {
+		required = required || r == role
+	}
+	if !required {
+		return nil, newError(ctx, ErrApprovalRoleNotNeeded, approvalId, role, strings.Join(approval.RequiredRoles, ", "))
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, signature := range approval.Signatures {
+		if signature.Role == role {
+			return nil, newError(ctx, ErrApprovalRoleAlreadySigned, approvalId, role, signature.Approver)
+		}
+		if signature.Approver == actor {
+			return nil, newError(ctx, ErrApprovalAlreadySigned, actor, approvalId, signature.Role)
+		}
+	}
+
+	waste, err := s.readWaste(ctx, approval.WasteID)
+	if err != nil {
+		return nil, err
+	}
+	holds, err := holdsApproverRole(ctx, approval, waste, role)
+	if err != nil {
+		return nil, err
+	}
+	if !holds {
+		return nil, newError(ctx, ErrApprovalSignForbidden, approvalId, role)
+	}
+
+	if err := signApproval(ctx, approval, role, actor, now); err != nil {
+		return nil, err
+	}
+	if len(approval.Signatures) < len(approval.RequiredRoles) {
+		if err := putApproval(ctx, approval); err != nil {
+			return nil, err
+		}
+		return approval, nil
+	}
+
+	// Quorum met: run the operation on the lot as it stands now
+	if err := checkShareTransfer(ctx, waste, approval.Transfer); err != nil {
+		if err := s.closeApproval(ctx, approval, models.ApprovalFailed, err.Error(), actor, now); err != nil {
+			return nil, err
+		}
+		return approval, nil
+	}
+	waste.PendingApprovalID = ""
+	if err := s.applyShareTransfer(ctx, waste, approval.Transfer, actor); err != nil {
+		return nil, err
+	}
+	approval.Status = models.ApprovalExecuted
+	approval.DecidedAt = now
+	approval.History = append(approval.History, models.History{
+		Timestamp: now,
+		Action:    "EXECUTED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Quorum of %s met", strings.Join(approval.RequiredRoles, ", ")),
+	})
+	if err := putApproval(ctx, approval); err != nil {
+		return nil, err
+	}
+
+	return approval, nil
+}
+
+// RejectApproval turns down a pending approval; the requester, anyone able
+// to sign one of its roles and admins may reject it
+func (s *SmartContract) RejectApproval(ctx contractapi.TransactionContextInterface, approvalId string, reason string) (*models.Approval, error) {
+	if reason == "" {
+		return nil, newError(ctx, ErrRejectionReasonRequired)
+	}
+	approval, err := s.pendingApproval(ctx, approvalId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := actor == approval.RequestedBy || isAdmin(ctx)
+	if !allowed {
+		waste, err := s.readWaste(ctx, approval.WasteID)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range approval.RequiredRoles {
+			holds, err := holdsApproverRole(ctx, approval, waste, role)
+			if err != nil {
+				return nil, err
+			}
+			allowed = allowed || holds
+		}
+	}
+	if !allowed {
+		return nil, newError(ctx, ErrApprovalRejectForbidden, approvalId)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.closeApproval(ctx, approval, models.ApprovalRejected, reason, actor, now); err != nil {
+		return nil, err
+	}
+
+	return approval, nil
+}
+
+// ReadApproval returns the approval stored with the given id
+func (s *SmartContract) ReadApproval(ctx contractapi.TransactionContextInterface, id string) (*models.Approval, error) {
+	var approval models.Approval
+	found, err := newAssetStore(ctx).Get("APPROVAL_"+id, &approval)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "APPROVAL_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrApprovalNotFound, id)
+	}
+
+	return &approval, nil
+}
+
+// GetApprovals returns approvals, newest first; wasteId and status filter
+// them when set
+func (s *SmartContract) GetApprovals(ctx contractapi.TransactionContextInterface, wasteId string, status string) ([]*models.Approval, error) {
+	status = strings.ToUpper(status)
+	return loadApprovals(ctx, func(approval *models.Approval) bool {
+		return (wasteId == "" || approval.WasteID == wasteId) && (status == "" || approval.Status == status)
+	})
+}
+
+// requestTransferApproval holds a share transfer for approval by the roles
+// of the approval rule it falls under (see transferApprovalRule). The
+// requester signs the first role they hold; the lot is written with the
+// pending approval.
+func (s *SmartContract) requestTransferApproval(ctx contractapi.TransactionContextInterface, waste *models.Waste, transfer *models.ShareTransfer, rule *models.ApprovalRule, version *models.ApprovalMatrixVersion) error {
+	roles := rule.RequiredRoles
+
+	id, err := newAssetID(ctx, "APPROVAL")
+	if err != nil {
+		return err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	created, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return err
+	}
+
+	approval := &models.Approval{
+		ID:             id,
+		Operation:      models.ApprovalTransferShare,
+		WasteID:        waste.ID,
+		Transfer:       transfer,
+		RequiredRoles:  roles,
+		Threshold:      rule.MinQuantity,
+		Signatures:     []models.ApprovalSignature{},
+		Status:         models.ApprovalPending,
+		RequestedBy:    actor,
+		RequestedByMSP: mspID,
+		ExpiresAt:      created.AddDate(0, 0, configInt(ctx, "approvals", "ttlDays", defaultApprovalTTLDays)).UTC().Format(time.RFC3339),
+		CreatedAt:      now,
+		History: []models.History{{
+			Timestamp: now,
+			Action:    "REQUESTED",
+			Actor:     actor,
+			Details:   fmt.Sprintf("Transfer of %.4f%% (%.2f units) of waste %s to %s", transfer.Percentage, transfer.Quantity, waste.ID, transfer.BuyerID),
+		}},
+	}
+	if version.EffectiveFrom != "" {
+		approval.MatrixMSP = mspID
+		approval.MatrixVersion = version.EffectiveFrom
+	}
+	for _, role := range roles {
+		holds, err := holdsApproverRole(ctx, approval, waste, role)
+		if err != nil {
+			return err
+		}
+		if holds {
+			if err := signApproval(ctx, approval, role, actor, now); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	waste.PendingApprovalID = id
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "TRANSFER_PENDING_APPROVAL",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Transfer to %s awaits approval %s by %s", transfer.BuyerID, id, strings.Join(roles, ", ")),
+	})
+	if err := s.putWaste(ctx, waste); err != nil {
+		return err
+	}
+	if err := putApproval(ctx, approval); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("A transfer of %.2f%% of waste %s awaits approval %s", transfer.Percentage, waste.ID, id)
+	for _, recipient := range []string{waste.OwnerMSP, transfer.BuyerMSP} {
+		if recipient == "" || recipient == mspID {
+			continue
+		}
+		if err := notify(ctx, recipient, models.NotifyApprovalRequested, "APPROVAL_"+id, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expireApprovals closes the pending approvals of the next page of the
+// maintenance scan past their deadline and returns how many it closed
+func (s *SmartContract) expireApprovals(ctx contractapi.TransactionContextInterface, run *maintenanceRun, ranAt time.Time) (int, error) {
+	now := ranAt.UTC().Format(time.RFC3339)
+	approvals := []*models.Approval{}
+	err := run.scan(ctx, "approvals", "APPROVAL_", func(_ string, value []byte) error {
+		var approval models.Approval
+		if err := json.Unmarshal(value, &approval); err != nil {
+			return err
+		}
+		if approval.Status == models.ApprovalPending && approval.ExpiresAt < now {
+			approvals = append(approvals, &approval)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, approval := range approvals {
+		if err := s.closeApproval(ctx, approval, models.ApprovalExpired, "approval deadline passed", approvalActor, now); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(approvals), nil
+}
+
+// pendingApproval reads an approval that is still waiting for signatures
+func (s *SmartContract) pendingApproval(ctx contractapi.TransactionContextInterface, approvalId string) (*models.Approval, error) {
+	approval, err := s.ReadApproval(ctx, approvalId)
+	if err != nil {
+		return nil, err
+	}
+	if approval.Status != models.ApprovalPending {
+		return nil, newError(ctx, ErrApprovalStatusInvalid, approvalId, approval.Status)
+	}
+
+	return approval, nil
+}
+
+// closeApproval ends a pending approval without running its operation and
+// releases the lot for other transfers
+func (s *SmartContract) closeApproval(ctx contractapi.TransactionContextInterface, approval *models.Approval, status string, reason string, actor string, now string) error {
+	approval.Status = status
+	approval.Reason = reason
+	approval.DecidedAt = now
+	approval.History = append(approval.History, models.History{
+		Timestamp: now,
+		Action:    status,
+		Actor:     actor,
+		Details:   reason,
+	})
+
+	waste, err := s.readWaste(ctx, approval.WasteID)
+	if err != nil {
+		return err
+	}
+	if waste.PendingApprovalID == approval.ID {
+		waste.PendingApprovalID = ""
+		waste.UpdatedAt = now
+		waste.History = append(waste.History, models.History{
+			Timestamp: now,
+			Action:    "TRANSFER_" + status,
+			Actor:     actor,
+			Details:   fmt.Sprintf("Approval %s: %s", approval.ID, reason),
+		})
+		if err := s.putWaste(ctx, waste); err != nil {
+			return err
+		}
+	}
+
+	return putApproval(ctx, approval)
+}
+
+// holdsApproverRole reports whether the caller may sign an approval of a
+// lot in a role
+func holdsApproverRole(ctx contractapi.TransactionContextInterface, approval *models.Approval, waste *models.Waste, role string) (bool, error) {
+	caller, err := callerID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	switch role {
+	case models.ApproverOwner:
+		return approval.Transfer != nil && caller == approval.Transfer.SellerID, nil
+	case models.ApproverBuyer:
+		return approval.Transfer != nil && caller == approval.Transfer.BuyerID, nil
+	case models.ApproverAdmin:
+		return isAdmin(ctx), nil
+	case models.ApproverAuditor:
+		return hasRole(ctx, AuditorRole), nil
+	case models.ApproverCooperativeAdmin:
+		return newCooperativeAccess(ctx).grants(ctx, waste.ParticipantID, models.CoopApprove)
+	default:
+		return false, nil
+	}
+}
+
+func signApproval(ctx contractapi.TransactionContextInterface, approval *models.Approval, role string, actor string, now string) error {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	approval.Signatures = append(approval.Signatures, models.ApprovalSignature{
+		Role:        role,
+		Approver:    actor,
+		ApproverMSP: mspID,
+		ApprovedAt:  now,
+	})
+	approval.History = append(approval.History, models.History{
+		Timestamp: now,
+		Action:    "APPROVED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Signed as %s", role),
+	})
+
+	return nil
+}
+
+// isApproverRole reports whether role is a known approver role
+func isApproverRole(role string) bool {
+	for _, r := range approverRoles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func putApproval(ctx contractapi.TransactionContextInterface, approval *models.Approval) error {
+	return newAssetStore(ctx).Put("APPROVAL_"+approval.ID, approval)
+}
+
+// loadApprovals returns the approvals kept by keep, newest first
+func loadApprovals(ctx contractapi.TransactionContextInterface, keep func(*models.Approval) bool) ([]*models.Approval, error) {
+	approvals := []*models.Approval{}
+	err := newAssetStore(ctx).Range("APPROVAL_", "APPROVAL_~", func(_ string, value []byte) error {
+		var approval models.Approval
+		if err := json.Unmarshal(value, &approval); err != nil {
+			return err
+		}
+		if keep(&approval) {
+			approvals = append(approvals, &approval)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt > approvals[j].CreatedAt
+	})
+
+	return approvals, nil
+}
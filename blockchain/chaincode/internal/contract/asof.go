@@ -0,0 +1,122 @@
+package contract
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// GetWasteAsOf returns a lot as it stood at asOf, an RFC3339 timestamp or a
+// YYYY-MM-DD date meaning the end of that day (UTC), by replaying the key's
+// ledger history; peers must keep the history database enabled. The version
+// found is redacted as ReadWaste would redact it, and refused when it was
+// written before the personal data of the lot's participant was erased.
+func (s *SmartContract) GetWasteAsOf(ctx contractapi.TransactionContextInterface, wasteId string, asOf string) (*models.WasteAsOf, error) {
+	at, err := parseAsOf(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := ctx.GetStub().GetHistoryForKey("WASTE_" + wasteId)
+	if err != nil {
+		return nil, newError(ctx, ErrHistoryRead, "WASTE_"+wasteId, err)
+	}
+	defer iterator.Close()
+
+	// Modifications are not guaranteed to come in order, so keep the latest
+	// one that is not after the requested time
+	result := &models.WasteAsOf{WasteID: wasteId, AsOf: at.Format(time.RFC3339)}
+	var latest time.Time
+	var value []byte
+	var txID string
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		ts := modification.GetTimestamp()
+		modified := time.Unix(ts.GetSeconds(), int64(ts.GetNanos())).UTC()
+		if modified.After(at) || (result.TxID != "" && !modified.After(latest)) {
+			continue
+		}
+		latest = modified
+		txID = modification.GetTxId()
+		result.TxID = txID
+		result.Deleted = modification.GetIsDelete()
+		value = modification.GetValue()
+	}
+	if result.TxID == "" {
+		return nil, newError(ctx, ErrWasteNotFoundAt, wasteId, result.AsOf)
+	}
+	result.ModifiedAt = latest.Format(time.RFC3339)
+	if result.Deleted {
+		return result, nil
+	}
+
+	var waste models.Waste
+	if err := json.Unmarshal(value, &waste); err != nil {
+		return nil, newError(ctx, ErrWasteHistoryDecodeFailed, wasteId, result.AsOf, err)
+	}
+	if err := refuseErasedVersion(ctx, wasteId, &waste, txID, latest, result.AsOf); err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Waste, err = viewer.view(ctx, &waste); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// refuseErasedVersion refuses a version of a lot written before the personal
+// data of its participant was erased: EraseParticipantPII scrubbed the owner,
+// farm and location from the lot's current version only, and the erased
+// values are gone, so older versions cannot be scrubbed in turn. The version
+// written by the erasure itself, or after it, is served.
+func refuseErasedVersion(ctx contractapi.TransactionContextInterface, wasteID string, waste *models.Waste, txID string, modified time.Time, asOf string) error {
+	participantID := waste.ParticipantID
+	if participantID == "" {
+		var current models.Waste
+		if _, err := newAssetStore(ctx).Get("WASTE_"+wasteID, &current); err != nil {
+			return newError(ctx, ErrLedgerRead, "WASTE_"+wasteID, err)
+		}
+		participantID = current.ParticipantID
+	}
+	if participantID == "" {
+		return nil
+	}
+
+	var receipt models.ErasureReceipt
+	found, err := newAssetStore(ctx).Get("ERASURE_"+participantID, &receipt)
+	if err != nil {
+		return newError(ctx, ErrLedgerRead, "ERASURE_"+participantID, err)
+	}
+	if !found || (receipt.TxID != "" && receipt.TxID == txID) {
+		return nil
+	}
+	// ErasedAt has a one-second resolution, so a version written within the
+	// second of the erasure is refused unless it is the erasure's own
+	if erasedAt, err := time.Parse(time.RFC3339, receipt.ErasedAt); err == nil && modified.Truncate(time.Second).After(erasedAt) {
+		return nil
+	}
+
+	return newError(ctx, ErrWasteErasedAt, wasteID, asOf, receipt.ErasedAt)
+}
+
+// parseAsOf reads an RFC3339 timestamp, or a date as the end of that day
+func parseAsOf(ctx contractapi.TransactionContextInterface, asOf string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, asOf); err == nil {
+		return at.UTC(), nil
+	}
+	day, err := time.Parse("2006-01-02", asOf)
+	if err != nil {
+		return time.Time{}, newError(ctx, ErrTimeInvalid, asOf)
+	}
+
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,214 @@
+package contract
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/chaincode/internal/store"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// auditPrefix keys audit records by transaction time, then transaction ID,
+// so that a date range is a key range
+const auditPrefix = "AUDIT_"
+
+// maxAuditErrorLength caps the failure reasons kept in the audit trail
+const maxAuditErrorLength = 500
+
+// noteAuditWrite records a key written by a transaction; audit records and
+// metric samples are left out
+func noteAuditWrite(ctx contractapi.TransactionContextInterface, key string) {
+	if strings.HasPrefix(key, auditPrefix) || strings.HasPrefix(key, metricPrefix) {
+		return
+	}
+
+	tx := transactionState(ctx)
+	for _, written := range tx.writes {
+		if written == key {
+			return
+		}
+	}
+	tx.writes = append(tx.writes, key)
+}
+
+// beginAudit is the contract's before-transaction hook: it notes the function
+// invoked and clears the writes recorded for the transaction
+func beginAudit(ctx contractapi.TransactionContextInterface) error {
+	tx := transactionState(ctx)
+	tx.function = invokedFunction(ctx)
+	tx.writes = nil
+
+	return nil
+}
+
+// invokedFunction returns the name of the function a transaction invokes
+func invokedFunction(ctx contractapi.TransactionContextInterface) string {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	// Functions may be invoked as "<contract>:<function>"
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+
+	return function
+}
+
+// endAudit is the contract's after-transaction hook: it writes the audit
+// record of an invocation that changed the world state. Fabric only calls it
+// when the function succeeded; failures are recorded by the client through
+// RecordFailedInvocation.
+func endAudit(ctx contractapi.TransactionContextInterface, _ interface{}) error {
+	tx := transactionState(ctx)
+	function, writes := tx.function, tx.writes
+
+	// Queries write nothing and leave no trace
+	if len(writes) == 0 {
+		return nil
+	}
+
+	return putAuditRecord(ctx, function, writes, models.AuditSucceeded, "")
+}
+
+// RecordFailedInvocation adds a failed invocation of the caller to the audit
+// trail. Failed transactions cannot write to the ledger, so clients report
+// them in a follow-up transaction; assetIds is a comma-separated list. The
+// failure also counts in the function metrics when metrics.record is on.
+func (s *SmartContract) RecordFailedInvocation(ctx contractapi.TransactionContextInterface, function string, assetIds string, reason string) error {
+	function = strings.TrimSpace(function)
+	if function == "" {
+		return newError(ctx, ErrFunctionRequired)
+	}
+	if len(reason) > maxAuditErrorLength {
+		reason = reason[:maxAuditErrorLength]
+	}
+
+	if err := putMetricSample(ctx, function, 0, 0, models.AuditFailed); err != nil {
+		return err
+	}
+
+	return putAuditRecord(ctx, function, splitList(assetIds), models.AuditFailed, reason)
+}
+
+func putAuditRecord(ctx contractapi.TransactionContextInterface, function string, assets []string, outcome string, reason string) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	actorMSP, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+
+	record := &models.AuditRecord{
+		TxID:      ctx.GetStub().GetTxID(),
+		Function:  function,
+		Actor:     actor,
+		ActorMSP:  actorMSP,
+		Assets:    assets,
+		Outcome:   outcome,
+		Error:     reason,
+		Timestamp: now,
+	}
+
+	return newAssetStore(ctx).Put(auditPrefix+now+"_"+record.TxID, record)
+}
+
+// QueryAuditTrail returns a page of audit records, oldest first, for admins
+// and auditors. actor filters on the caller identity or MSP ID; from and to
+// are dates (2006-01-02) or RFC 3339 timestamps and either may be empty.
+// Pass the returned bookmark to get the next page.
+func (s *SmartContract) QueryAuditTrail(ctx contractapi.TransactionContextInterface, actor string, from string, to string, pageSize int, bookmark string) (*models.AuditPage, error) {
+	if !isAdmin(ctx) && !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrAuditTrailForbidden)
+	}
+	if pageSize <= 0 {
+		pageSize = defaultNotificationPageSize
+	}
+
+	start, err := auditBound(ctx, from, false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := auditBound(ctx, to, true)
+	if err != nil {
+		return nil, err
+	}
+	if bookmark != "" {
+		if !strings.HasPrefix(bookmark, auditPrefix) {
+			return nil, newError(ctx, ErrBookmarkInvalid, bookmark)
+		}
+		start = bookmark
+	}
+
+	page := &models.AuditPage{Records: []*models.AuditRecord{}}
+	lastKey := ""
+	scanned := ""
+	err = newAssetStore(ctx).Range(start, end, func(key string, value []byte) error {
+		if key == bookmark {
+			return nil
+		}
+		var record models.AuditRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		if actor != "" && record.Actor != actor && record.ActorMSP != actor {
+			scanned = key
+			return nil
+		}
+		if len(page.Records) == pageSize {
+			page.Bookmark = lastKey
+			return store.ErrStopRange
+		}
+		page.Records = append(page.Records, &record)
+		lastKey = key
+		scanned = key
+
+		return nil
+	})
+	// Records of other actors count in the budget too, so a truncated page
+	// resumes after the last record scanned
+	if budgetExceeded(err) && scanned != "" {
+		page.Bookmark = scanned
+		page.Truncated = len(page.Records) < pageSize
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// auditBound turns a date or timestamp into the start key or, with end set,
+// the exclusive end key of an audit trail range; a date covers its whole day
+func auditBound(ctx contractapi.TransactionContextInterface, value string, end bool) (string, error) {
+	if value == "" {
+		if end {
+			return auditPrefix + "~", nil
+		}
+		return auditPrefix, nil
+	}
+
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		if end {
+			return auditPrefix + day.AddDate(0, 0, 1).Format(time.RFC3339), nil
+		}
+		return auditPrefix + day.Format(time.RFC3339), nil
+	}
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", newError(ctx, ErrAuditDateInvalid, value)
+	}
+	bound := auditPrefix + ts.UTC().Format(time.RFC3339)
+	if end {
+		// Include records of the final second
+		bound += "_~"
+	}
+
+	return bound, nil
+}
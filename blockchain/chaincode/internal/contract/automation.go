@@ -0,0 +1,93 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// transitionTriggers lists the asset types whose status changes may move
+// their linked waste lot
+var transitionTriggers = map[string]bool{
+	"COLLECTION": true,
+	"EXTRACTION": true,
+	"RECYCLING":  true,
+	"LISTING":    true,
+}
+
+// transitionActor is recorded as the actor of rule-driven status changes
+const transitionActor = "automation"
+
+// loadTransitionRules reads the "automation.transitionRules" JSON rule list;
+// no rules apply when it is unset
+func loadTransitionRules(ctx contractapi.TransactionContextInterface) ([]models.TransitionRule, error) {
+	value := configString(ctx, "automation", "transitionRules", "")
+	if value == "" {
+		return nil, nil
+	}
+
+	var rules []models.TransitionRule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, newError(ctx, ErrTransitionRulesSettingInvalid, err)
+	}
+	for _, rule := range rules {
+		if rule.Name == "" || !transitionTriggers[rule.On] || rule.Event == "" || rule.Transition == "" {
+			return nil, newError(ctx, ErrTransitionRuleInvalid, rule.Name)
+		}
+	}
+
+	return rules, nil
+}
+
+// applyTransitionRules moves waste, the lot linked to an asset that has just
+// reached status event, as the configured rules say and reports whether it
+// changed; a rule whose target status has open checklist items is skipped. Callers pass the waste they are about to write: reading it again
+// would return the committed state without the transaction's pending changes.
+func applyTransitionRules(ctx contractapi.TransactionContextInterface, assetType string, assetID string, event string, waste *models.Waste) (bool, error) {
+	rules, err := loadTransitionRules(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, rule := range rules {
+		if rule.On != assetType || rule.Event != event || waste.Status == rule.Transition {
+			continue
+		}
+		// Rules do not skip checklists; the lot stays until they are done
+		checklist, err := transitionChecklist(ctx, waste.ID, rule.Transition)
+		if err != nil {
+			return false, err
+		}
+		if checklist != nil && !checklist.Complete {
+			continue
+		}
+
+		now, err := txTimestamp(ctx)
+		if err != nil {
+			return false, err
+		}
+		applyStatusChange(waste, rule.Transition, transitionActor, fmt.Sprintf("Rule %s: %s %s reached %s", rule.Name, assetType, assetID, event), now)
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// transitionLinkedWaste applies the transition rules to a waste lot the
+// calling transaction has not loaded and writes it if a rule moved it
+func (s *SmartContract) transitionLinkedWaste(ctx contractapi.TransactionContextInterface, assetType string, assetID string, event string, wasteId string) error {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return err
+	}
+
+	changed, err := applyTransitionRules(ctx, assetType, assetID, event, waste)
+	if err != nil || !changed {
+		return err
+	}
+
+	return s.putWaste(ctx, waste)
+}
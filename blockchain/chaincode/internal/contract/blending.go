@@ -0,0 +1,138 @@
+package contract
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateBlendedRecycling records a recycling run (a composting batch, for
+// example) fed by several lots, each contributing part of its remaining
+// quantity; the ID is generated when id is empty. The record's WasteID is the
+// largest input.
+func (s *SmartContract) CreateBlendedRecycling(ctx contractapi.TransactionContextInterface, id string, inputs []models.RecyclingInput, recycledProduct string, method string, recycler string, facilityId string) (*models.Recycling, error) {
+	staged := newStagedWrites(ctx)
+	recycling, wastes, err := s.buildBlendedRecycling(ctx, staged, id, inputs, recycledProduct, method, recycler, facilityId)
+	if err != nil {
+		return nil, err
+	}
+
+	staged.recycling(recycling)
+	for _, waste := range wastes {
+		created, _ := recyclingOutput(recycling, waste.ID)
+		if err := s.issueCompletionCertificate(ctx, staged, waste, created); err != nil {
+			return nil, err
+		}
+		staged.waste(waste)
+	}
+	if err := staged.commit(); err != nil {
+		return nil, err
+	}
+
+	return recycling, nil
+}
+
+// GetRecyclingProvenance returns a recycling record with every lot it was
+// made from and the farms those lots came from
+func (s *SmartContract) GetRecyclingProvenance(ctx contractapi.TransactionContextInterface, recyclingId string) (*models.RecyclingProvenance, error) {
+	recycling, err := s.GetRecycling(ctx, recyclingId)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provenance := &models.RecyclingProvenance{Recycling: recycling, Inputs: []*models.InputProvenance{}, Farms: []string{}}
+	farms := map[string]bool{}
+	for _, input := range recycling.InputLots() {
+		entry := &models.InputProvenance{WasteID: input.WasteID, Quantity: input.Quantity}
+		if recycling.Quantity > 0 {
+			entry.Share = input.Quantity / recycling.Quantity
+		}
+
+		waste, err := s.readWaste(ctx, input.WasteID)
+		if err != nil {
+			return nil, err
+		}
+		entry.OwnerMSP = waste.OwnerMSP
+		visible, err := viewer.canView(ctx, waste)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			entry.Farm = waste.Farm
+			entry.HarvestDate = waste.HarvestDate
+			if waste.Farm != "" && !farms[waste.Farm] {
+				farms[waste.Farm] = true
+				provenance.Farms = append(provenance.Farms, waste.Farm)
+			}
+		} else {
+			entry.Redacted = true
+		}
+		provenance.Inputs = append(provenance.Inputs, entry)
+	}
+	sort.Strings(provenance.Farms)
+
+	return provenance, nil
+}
+
+// buildBlendedRecycling validates a blended run against the remaining
+// quantity of each input and returns the record with the updated input lots
+func (s *SmartContract) buildBlendedRecycling(ctx contractapi.TransactionContextInterface, staged *stagedWrites, id string, inputs []models.RecyclingInput, recycledProduct string, method string, recycler string, facilityId string) (*models.Recycling, []*models.Waste, error) {
+	if len(inputs) < 2 {
+		return nil, nil, newError(ctx, ErrBlendInputsTooFew)
+	}
+
+	total := 0.0
+	primary := 0
+	seen := map[string]bool{}
+	for i, input := range inputs {
+		if input.WasteID == "" || input.Quantity <= 0 {
+			return nil, nil, newError(ctx, ErrBlendInputInvalid, i+1)
+		}
+		if seen[input.WasteID] {
+			return nil, nil, newError(ctx, ErrBlendInputDuplicate, input.WasteID)
+		}
+		seen[input.WasteID] = true
+		total += input.Quantity
+		if input.Quantity > inputs[primary].Quantity {
+			primary = i
+		}
+	}
+
+	// The run is validated (ID, facility capacity, method) as a recycling of
+	// its largest input, then linked to the others
+	recycling, primaryWaste, _, err := s.buildRecycling(ctx, staged, id, inputs[primary].WasteID, recycledProduct, total, method, recycler, facilityId)
+	if err != nil {
+		return nil, nil, err
+	}
+	recycling.Inputs = append([]models.RecyclingInput(nil), inputs...)
+	recycling.History[0].Details = fmt.Sprintf("Blended %d lots (%.2f units) into %s using %s at facility %s", len(inputs), total, recycledProduct, recycling.Method, facilityId)
+
+	wastes := make([]*models.Waste, 0, len(inputs))
+	for i, input := range inputs {
+		waste := primaryWaste
+		if i != primary {
+			waste, err = s.readWaste(ctx, input.WasteID)
+			if err != nil {
+				return nil, nil, err
+			}
+			applyStatusChange(waste, "RECYCLED", recycler, fmt.Sprintf("Blended %.2f units into %s as %s", input.Quantity, recycledProduct, recycling.ID), recycling.CreatedAt)
+			if _, err := applyTransitionRules(ctx, "RECYCLING", recycling.ID, recycling.Status, waste); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if remaining := waste.Quantity - waste.Consumed; input.Quantity > remaining {
+			return nil, nil, newError(ctx, ErrWasteQuantityInsufficient, input.WasteID, remaining, input.Quantity)
+		}
+		waste.Consumed += input.Quantity
+		wastes = append(wastes, waste)
+	}
+
+	return recycling, wastes, nil
+}
@@ -0,0 +1,206 @@
+package contract
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateExtractionWithOutputs records an extraction run yielding several
+// products at once and returns the stored record; the ID is generated when
+// id is empty
+func (s *SmartContract) CreateExtractionWithOutputs(ctx contractapi.TransactionContextInterface, id string, wasteId string, outputs []models.ExtractionOutput, processor string, facilityId string) (*models.Extraction, error) {
+	return s.storeExtraction(ctx, id, wasteId, outputs, processor, facilityId)
+}
+
+// CreateRecyclingFromOutput recycles part of an extraction output line (for
+// example pomace into compost) and links the new record to that line
+func (s *SmartContract) CreateRecyclingFromOutput(ctx contractapi.TransactionContextInterface, id string, extractionId string, line int, recycledProduct string, quantity float64, method string, recycler string, facilityId string) (*models.Recycling, error) {
+	extraction, err := s.readExtraction(ctx, extractionId)
+	if err != nil {
+		return nil, err
+	}
+	outputs := extractionOutputs(extraction)
+	if line < 1 || line > len(outputs) {
+		return nil, newError(ctx, ErrOutputLineNotFound, extractionId, line)
+	}
+	output := &outputs[line-1]
+
+	staged := newStagedWrites(ctx)
+	recycling, waste, _, err := s.buildRecycling(ctx, staged, id, extraction.WasteID, recycledProduct, quantity, method, recycler, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	recycling.ExtractionID = extractionId
+	recycling.OutputLine = line
+
+	if output.Consumed+quantity > output.Quantity {
+		message := fmt.Sprintf("line %d of extraction %s has %.2f units left, %.2f recycled", line, extractionId, output.Quantity-output.Consumed, quantity)
+		if configString(ctx, "extraction", "massBalancePolicy", "warn") == "reject" {
+			return nil, newError(ctx, ErrMassBalanceExceeded, message)
+		}
+		recycling.History = append(recycling.History, models.History{
+			Timestamp: recycling.CreatedAt,
+			Action:    "MASS_BALANCE_EXCEEDED",
+			Actor:     recycler,
+			Details:   message,
+		})
+	}
+
+	output.Consumed += quantity
+	output.Downstream = append(output.Downstream, recycling.ID)
+	extraction.Outputs = outputs
+	extraction.History = append(extraction.History, models.History{
+		Timestamp: recycling.CreatedAt,
+		Action:    "OUTPUT_RECYCLED",
+		Actor:     recycler,
+		Details:   fmt.Sprintf("%.2f units of %s (line %d) recycled into %s as %s", quantity, output.ProductType, line, recycledProduct, recycling.ID),
+	})
+
+	staged.recycling(recycling)
+	staged.extraction(extraction)
+	staged.waste(waste)
+	if err := staged.commit(); err != nil {
+		return nil, err
+	}
+
+	return recycling, nil
+}
+
+// GetExtractionOutputTrace returns an output line of an extraction with the
+// recycling records made from it
+func (s *SmartContract) GetExtractionOutputTrace(ctx contractapi.TransactionContextInterface, extractionId string, line int) (*models.ExtractionOutputTrace, error) {
+	extraction, err := s.readExtraction(ctx, extractionId)
+	if err != nil {
+		return nil, err
+	}
+	outputs := extractionOutputs(extraction)
+	if line < 1 || line > len(outputs) {
+		return nil, newError(ctx, ErrOutputLineNotFound, extractionId, line)
+	}
+
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := &models.ExtractionOutputTrace{
+		ExtractionID: extractionId,
+		WasteID:      extraction.WasteID,
+		Output:       &outputs[line-1],
+		Recyclings:   []*models.Recycling{},
+	}
+	for _, recycling := range recyclings {
+		if recycling.ExtractionID == extractionId && recycling.OutputLine == line {
+			trace.Recyclings = append(trace.Recyclings, recycling)
+		}
+	}
+
+	return trace, nil
+}
+
+// singleOutput wraps the product of a classic one-product extraction
+func singleOutput(productType string, quantity float64, quality string) []models.ExtractionOutput {
+	return []models.ExtractionOutput{{ProductType: productType, Quantity: quantity, Quality: quality}}
+}
+
+// numberOutputs validates output lines, numbers them from 1 and returns
+// them with their total quantity
+func numberOutputs(ctx contractapi.TransactionContextInterface, outputs []models.ExtractionOutput) ([]models.ExtractionOutput, float64, error) {
+	if len(outputs) == 0 {
+		return nil, 0, newError(ctx, ErrOutputLinesRequired)
+	}
+
+	numbered := make([]models.ExtractionOutput, len(outputs))
+	total := 0.0
+	for i, output := range outputs {
+		if output.Quantity <= 0 {
+			return nil, 0, newError(ctx, ErrQuantityInvalid)
+		}
+		if output.Composition != nil {
+			if err := validateComposition(ctx, output.Composition); err != nil {
+				return nil, 0, newError(ctx, ErrOutputLineInvalid, i+1, err)
+			}
+		}
+		numbered[i] = models.ExtractionOutput{
+			Line:        i + 1,
+			ProductType: output.ProductType,
+			Quantity:    output.Quantity,
+			Quality:     output.Quality,
+			Composition: output.Composition,
+			DryMatter:   dryMatter(output.Composition, output.Quantity),
+		}
+		total += output.Quantity
+	}
+
+	return numbered, total, nil
+}
+
+// checkMassBalance compares the total output with the input lot, on dry
+// matter when the moisture of the lot and of every output line is known
+// (evaporated water is not a loss of material) and on wet weight otherwise;
+// producing more than the lot holds is a warning unless the
+// "extraction.massBalancePolicy" setting is "reject"
+func checkMassBalance(ctx contractapi.TransactionContextInterface, waste *models.Waste, outputs []models.ExtractionOutput, output float64) (*models.MassBalance, []string, error) {
+	balance := &models.MassBalance{
+		Input:  waste.Quantity,
+		Output: output,
+		Loss:   waste.Quantity - output,
+		Basis:  models.BalanceWet,
+	}
+
+	dryInput, dry := waste.Composition.DryMatter(waste.Quantity)
+	dryOutput := 0.0
+	for _, line := range outputs {
+		lineDry, ok := line.Composition.DryMatter(line.Quantity)
+		dry = dry && ok
+		dryOutput += lineDry
+	}
+
+	message := ""
+	if dry {
+		balance.Basis = models.BalanceDry
+		balance.DryInput = math.Round(dryInput*1000) / 1000
+		balance.DryOutput = math.Round(dryOutput*1000) / 1000
+		balance.DryLoss = math.Round((dryInput-dryOutput)*1000) / 1000
+		if dryOutput > dryInput {
+			message = fmt.Sprintf("extracted dry matter %.2f exceeds waste dry matter %.2f", dryOutput, dryInput)
+		}
+	} else if output > waste.Quantity {
+		message = fmt.Sprintf("extracted quantity %.2f exceeds waste quantity %.2f", output, waste.Quantity)
+	}
+
+	if message != "" {
+		if configString(ctx, "extraction", "massBalancePolicy", "warn") == "reject" {
+			return nil, nil, newError(ctx, ErrMassBalanceExceeded, message)
+		}
+		return balance, []string{message}, nil
+	}
+
+	return balance, nil, nil
+}
+
+// extractionOutputs returns the output lines of an extraction, deriving a
+// single line for records created before outputs were tracked
+func extractionOutputs(extraction *models.Extraction) []models.ExtractionOutput {
+	if len(extraction.Outputs) > 0 {
+		return extraction.Outputs
+	}
+
+	outputs := singleOutput(extraction.ProductType, extraction.Quantity, extraction.Quality)
+	outputs[0].Line = 1
+	return outputs
+}
+
+// describeOutputs renders output lines as "oil 12.00, pomace 80.00"
+func describeOutputs(outputs []models.ExtractionOutput) string {
+	parts := make([]string, len(outputs))
+	for i, output := range outputs {
+		parts[i] = fmt.Sprintf("%s %.2f", output.ProductType, output.Quantity)
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,245 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateCampaign opens a harvest campaign for the caller's organization;
+// wastes harvested within its dates are attached to it automatically
+func (s *SmartContract) CreateCampaign(ctx contractapi.TransactionContextInterface, id string, name string, startDate string, endDate string, actor string) (*models.Campaign, error) {
+	if id == "" || name == "" {
+		return nil, newError(ctx, ErrCampaignFieldsRequired)
+	}
+
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, newError(ctx, ErrStartDateInvalid, startDate)
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, newError(ctx, ErrEndDateInvalid, endDate)
+	}
+	if end.Before(start) {
+		return nil, newError(ctx, ErrDateRangeInvalid)
+	}
+
+	exists, err := newAssetStore(ctx).Exists("CAMPAIGN_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrCampaignAlreadyExists, id)
+	}
+
+	organization, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	campaigns, err := loadCampaigns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range campaigns {
+		if other.Organization == organization && startDate <= other.EndDate && endDate >= other.StartDate {
+			return nil, newError(ctx, ErrCampaignOverlap, other.ID)
+		}
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign := &models.Campaign{
+		ID:           id,
+		Name:         name,
+		Organization: organization,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Status:       models.CampaignOpen,
+		CreatedAt:    now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "OPENED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("Campaign %s from %s to %s", name, startDate, endDate),
+			},
+		},
+	}
+
+	if err := s.putCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// CloseCampaign freezes a campaign: no further wastes can be created under it
+func (s *SmartContract) CloseCampaign(ctx contractapi.TransactionContextInterface, id string, actor string) (*models.Campaign, error) {
+	campaign, err := s.ReadCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == models.CampaignClosed {
+		return nil, newError(ctx, ErrCampaignAlreadyClosed, id)
+	}
+
+	organization, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if organization != campaign.Organization && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrCampaignCloseForbidden, campaign.Organization, id)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign.Status = models.CampaignClosed
+	campaign.ClosedAt = now
+	campaign.History = append(campaign.History, models.History{
+		Timestamp: now,
+		Action:    "CLOSED",
+		Actor:     actor,
+		Details:   "Campaign closed to new wastes",
+	})
+
+	if err := s.putCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// ReadCampaign returns the campaign stored with the given id
+func (s *SmartContract) ReadCampaign(ctx contractapi.TransactionContextInterface, id string) (*models.Campaign, error) {
+	var campaign models.Campaign
+	found, err := newAssetStore(ctx).Get("CAMPAIGN_"+id, &campaign)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "CAMPAIGN_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrCampaignNotFound, id)
+	}
+
+	return &campaign, nil
+}
+
+// GetAllCampaigns returns all campaigns
+func (s *SmartContract) GetAllCampaigns(ctx contractapi.TransactionContextInterface) ([]*models.Campaign, error) {
+	return loadCampaigns(ctx)
+}
+
+// GetCampaignStatistics returns collected, processed and recycled totals for a campaign
+func (s *SmartContract) GetCampaignStatistics(ctx contractapi.TransactionContextInterface, id string) (*models.CampaignStatistics, error) {
+	campaign, err := s.ReadCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.CampaignStatistics{CampaignID: id, Status: campaign.Status}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	campaignWastes := map[string]bool{}
+	for _, waste := range wastes {
+		if waste.CampaignID != id {
+			continue
+		}
+		campaignWastes[waste.ID] = true
+		stats.WasteCount++
+		stats.TotalCollected += waste.Quantity
+		if waste.Status == "PROCESSED" || waste.Status == "RECYCLED" {
+			stats.TotalProcessed += waste.Quantity
+		}
+		donated, disposed := dispositionQuantities(waste)
+		stats.TotalDonated += donated
+		stats.TotalDisposed += disposed
+	}
+
+	extractions, err := s.GetAllExtractions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, extraction := range extractions {
+		if campaignWastes[extraction.WasteID] {
+			stats.ExtractionRecords++
+			stats.TotalExtracted += extraction.Quantity
+		}
+	}
+
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, recycling := range recyclings {
+		counted := false
+		for _, input := range recycling.InputLots() {
+			if campaignWastes[input.WasteID] {
+				stats.TotalRecycled += input.Quantity
+				counted = true
+			}
+		}
+		if counted {
+			stats.RecyclingRecords++
+		}
+	}
+
+	if stats.TotalProcessed > 0 {
+		stats.ExtractionYield = stats.TotalExtracted / stats.TotalProcessed
+	}
+	if recyclable := stats.TotalCollected - stats.TotalDonated; recyclable > 0 {
+		stats.RecyclingRate = stats.TotalRecycled / recyclable
+	}
+
+	return stats, nil
+}
+
+// findCampaign returns the organization's campaign covering the given date, if any
+func findCampaign(ctx contractapi.TransactionContextInterface, organization string, date string) (*models.Campaign, error) {
+	campaigns, err := loadCampaigns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, campaign := range campaigns {
+		if campaign.Organization == organization && date >= campaign.StartDate && date <= campaign.EndDate {
+			return campaign, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func loadCampaigns(ctx contractapi.TransactionContextInterface) ([]*models.Campaign, error) {
+	var campaigns []*models.Campaign
+	err := newAssetStore(ctx).Range("CAMPAIGN_", "CAMPAIGN_~", func(_ string, value []byte) error {
+		var campaign models.Campaign
+		if err := json.Unmarshal(value, &campaign); err != nil {
+			return err
+		}
+		campaigns = append(campaigns, &campaign)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+func (s *SmartContract) putCampaign(ctx contractapi.TransactionContextInterface, campaign *models.Campaign) error {
+	return newAssetStore(ctx).Put("CAMPAIGN_"+campaign.ID, campaign)
+}
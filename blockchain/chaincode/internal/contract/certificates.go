@@ -0,0 +1,186 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ReadCompletionCertificate returns the completion certificate of a lot.
+// Certificates are public proof of valorization and only name the lot's
+// outputs and the actors involved, so every member may read them.
+func (s *SmartContract) ReadCompletionCertificate(ctx contractapi.TransactionContextInterface, wasteId string) (*models.CompletionCertificate, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if waste.CertificateID == "" {
+		return nil, newError(ctx, ErrWasteNotValorized, wasteId)
+	}
+
+	return readCompletionCertificate(ctx, waste.CertificateID)
+}
+
+// readCompletionCertificate loads a certificate from the world state
+func readCompletionCertificate(ctx contractapi.TransactionContextInterface, id string) (*models.CompletionCertificate, error) {
+	var certificate models.CompletionCertificate
+	found, err := newAssetStore(ctx).Get("CERTIFICATE_"+id, &certificate)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "CERTIFICATE_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrCertificateNotFound, id)
+	}
+
+	return &certificate, nil
+}
+
+// issueCompletionCertificate certifies a lot once the whole of it has been
+// valorized: an extraction takes the entire lot, recyclings count towards
+// its consumed quantity. created is the extraction or recycling the
+// transaction is writing, which range queries do not see yet. It sets the
+// lot's CertificateID and stages the certificate; the caller stores the lot.
+func (s *SmartContract) issueCompletionCertificate(ctx contractapi.TransactionContextInterface, staged *stagedWrites, waste *models.Waste, created models.CertificateOutput) error {
+	if waste.CertificateID != "" {
+		return nil
+	}
+	if created.AssetType != "EXTRACTION" && waste.Consumed < waste.Quantity-1e-9 {
+		return nil
+	}
+
+	outputs, err := s.lotOutputs(ctx, waste.ID)
+	if err != nil {
+		return err
+	}
+	outputs = append(outputs, created)
+	sort.SliceStable(outputs, func(i, j int) bool { return outputs[i].Date < outputs[j].Date })
+
+	id, err := newAssetID(ctx, "CERTIFICATE")
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	certificate := &models.CompletionCertificate{
+		ID:          id,
+		WasteID:     waste.ID,
+		Reference:   waste.Reference,
+		WasteType:   waste.Type,
+		Quantity:    waste.Quantity,
+		Producer:    waste.Owner,
+		ProducerMSP: waste.OwnerMSP,
+		Outputs:     outputs,
+		Actors:      []string{},
+		CollectedAt: waste.CreatedAt,
+		CompletedAt: now,
+		IssuedAt:    now,
+	}
+	seen := map[string]bool{}
+	for _, actor := range append([]string{waste.Owner}, outputActors(outputs)...) {
+		if actor != "" && !seen[actor] {
+			seen[actor] = true
+			certificate.Actors = append(certificate.Actors, actor)
+		}
+	}
+
+	waste.CertificateID = id
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "COMPLETION_CERTIFIED",
+		Actor:     created.Actor,
+		Details:   fmt.Sprintf("Fully valorized through %d output(s); certificate %s issued", len(outputs), id),
+	})
+
+	staged.write(func() error {
+		if err := newAssetStore(ctx).Put("CERTIFICATE_"+id, certificate); err != nil {
+			return err
+		}
+		if err := recordChange(ctx, "CERTIFICATE", id, 1); err != nil {
+			return err
+		}
+
+		return notify(ctx, waste.OwnerMSP, models.NotifyLotCompleted, "CERTIFICATE_"+id, fmt.Sprintf("Waste %s is fully valorized; completion certificate %s issued", waste.ID, id))
+	})
+
+	return nil
+}
+
+// lotOutputs lists the stored extractions and recyclings a lot went into
+func (s *SmartContract) lotOutputs(ctx contractapi.TransactionContextInterface, wasteId string) ([]models.CertificateOutput, error) {
+	outputs := []models.CertificateOutput{}
+	err := newAssetStore(ctx).Range("EXTRACTION_", "EXTRACTION_~", func(_ string, value []byte) error {
+		var extraction models.Extraction
+		if err := json.Unmarshal(value, &extraction); err != nil {
+			return err
+		}
+		if extraction.WasteID == wasteId {
+			outputs = append(outputs, extractionOutput(&extraction))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, recycling := range recyclings {
+		if output, ok := recyclingOutput(recycling, wasteId); ok {
+			outputs = append(outputs, output)
+		}
+	}
+
+	return outputs, nil
+}
+
+// extractionOutput summarizes an extraction for a certificate
+func extractionOutput(extraction *models.Extraction) models.CertificateOutput {
+	return models.CertificateOutput{
+		AssetType: "EXTRACTION",
+		ID:        extraction.ID,
+		Reference: extraction.Reference,
+		Product:   extraction.ProductType,
+		Quantity:  extraction.Quantity,
+		Actor:     extraction.Processor,
+		Date:      extraction.ExtractionDate,
+	}
+}
+
+// recyclingOutput summarizes what a recycling took from a lot; ok is false
+// when the recycling did not use the lot
+func recyclingOutput(recycling *models.Recycling, wasteId string) (models.CertificateOutput, bool) {
+	for _, input := range recycling.InputLots() {
+		if input.WasteID != wasteId {
+			continue
+		}
+		return models.CertificateOutput{
+			AssetType: "RECYCLING",
+			ID:        recycling.ID,
+			Reference: recycling.Reference,
+			Product:   recycling.RecycledProduct,
+			Quantity:  input.Quantity,
+			Actor:     recycling.Recycler,
+			Date:      recycling.RecyclingDate,
+		}, true
+	}
+
+	return models.CertificateOutput{}, false
+}
+
+// outputActors returns who ran each output
+func outputActors(outputs []models.CertificateOutput) []string {
+	actors := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		actors = append(actors, output.Actor)
+	}
+
+	return actors
+}
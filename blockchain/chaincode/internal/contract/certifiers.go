@@ -0,0 +1,577 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegisterCertifier registers the calling inspector as a certifier taking
+// audit sample lots, or changes its name and capacity. Capacity is how many
+// lots it can have open at once and weighs its share of the assignments.
+// Callers holding the auditor role only.
+func (s *SmartContract) RegisterCertifier(ctx contractapi.TransactionContextInterface, name string, capacity int) (*models.Certifier, error) {
+	if !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrCertifierRoleRequired)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, newError(ctx, ErrCertifierNameRequired)
+	}
+	if capacity <= 0 {
+		return nil, newError(ctx, ErrCertifierCapacityInvalid)
+	}
+	identity, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	certifier, err := certifierByIdentity(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	action := "CAPACITY_CHANGED"
+	if certifier == nil {
+		id, err := newAssetID(ctx, "CERTIFIER")
+		if err != nil {
+			return nil, err
+		}
+		action = "REGISTERED"
+		certifier = &models.Certifier{
+			ID:           id,
+			Identity:     identity,
+			MSP:          mspID,
+			RegisteredAt: now,
+			History:      []models.History{},
+		}
+	} else if !certifier.Active {
+		action = "REACTIVATED"
+	}
+	certifier.Name = name
+	certifier.Capacity = capacity
+	certifier.Active = true
+	certifier.UpdatedAt = now
+	certifier.History = append(certifier.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     identity,
+		Details:   fmt.Sprintf("Capacity %d", capacity),
+	})
+
+	if err := putCertifier(ctx, certifier); err != nil {
+		return nil, err
+	}
+
+	return certifier, nil
+}
+
+// RetireCertifier stops assigning lots to a certifier and hands its open
+// assignments to the others; the certifier itself or an admin only
+func (s *SmartContract) RetireCertifier(ctx contractapi.TransactionContextInterface, certifierId string, reason string) (*models.Certifier, error) {
+	certifier, err := readCertifier(ctx, certifierId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if actor != certifier.Identity && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrCertifierRetireForbidden, certifierId)
+	}
+	if !certifier.Active {
+		return nil, newError(ctx, ErrCertifierAlreadyRetired, certifierId)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	certifier.Active = false
+	certifier.UpdatedAt = now
+	certifier.History = append(certifier.History, models.History{
+		Timestamp: now,
+		Action:    "RETIRED",
+		Actor:     actor,
+		Details:   reason,
+	})
+	if err := putCertifier(ctx, certifier); err != nil {
+		return nil, err
+	}
+
+	samples, err := s.GetAuditSamples(ctx, models.SampleOpen)
+	if err != nil {
+		return nil, err
+	}
+	assigner, err := newCertifierAssigner(ctx, samples)
+	if err != nil {
+		return nil, err
+	}
+	// The certifiers were read before the retirement was written
+	for _, other := range assigner.certifiers {
+		if other.ID == certifier.ID {
+			other.Active = false
+		}
+	}
+	for _, sample := range samples {
+		reassigned := 0
+		for i := range sample.Lots {
+			lot := &sample.Lots[i]
+			if lot.AssignedTo != certifier.ID || lot.Inspected {
+				continue
+			}
+			assigner.open[certifier.ID]--
+			if err := assigner.assign(ctx, sample, lot, now); err != nil {
+				return nil, err
+			}
+			reassigned++
+		}
+		if reassigned == 0 {
+			continue
+		}
+		sample.History = append(sample.History, models.History{
+			Timestamp: now,
+			Action:    "LOTS_REASSIGNED",
+			Actor:     actor,
+			Details:   fmt.Sprintf("%d lots of retired certifier %s reassigned", reassigned, certifier.ID),
+		})
+		if err := putAuditSample(ctx, sample); err != nil {
+			return nil, err
+		}
+	}
+	if err := assigner.notifyAssigned(ctx); err != nil {
+		return nil, err
+	}
+
+	return certifier, nil
+}
+
+// AssignAuditSample gives the lots of a sample still without a certifier to
+// the registered certifiers, e.g. for samples drawn before any registered
+// or lots every certifier declined; admins and auditors only
+func (s *SmartContract) AssignAuditSample(ctx contractapi.TransactionContextInterface, sampleId string) (*models.AuditSample, error) {
+	if !isAdmin(ctx) && !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrSampleAssignForbidden)
+	}
+	sample, err := s.ReadAuditSample(ctx, sampleId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	samples, err := s.GetAuditSamples(ctx, models.SampleOpen)
+	if err != nil {
+		return nil, err
+	}
+	assigner, err := newCertifierAssigner(ctx, samples)
+	if err != nil {
+		return nil, err
+	}
+	assigned := 0
+	for i := range sample.Lots {
+		lot := &sample.Lots[i]
+		if lot.AssignedTo != "" || lot.Inspected {
+			continue
+		}
+		if err := assigner.assign(ctx, sample, lot, now); err != nil {
+			return nil, err
+		}
+		if lot.AssignedTo != "" {
+			assigned++
+		}
+	}
+	if assigned == 0 {
+		return nil, newError(ctx, ErrSampleUnassignable, sampleId)
+	}
+	sample.History = append(sample.History, models.History{
+		Timestamp: now,
+		Action:    "LOTS_ASSIGNED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%d lots assigned to certifiers", assigned),
+	})
+
+	if err := putAuditSample(ctx, sample); err != nil {
+		return nil, err
+	}
+	if err := assigner.notifyAssigned(ctx); err != nil {
+		return nil, err
+	}
+
+	return sample, nil
+}
+
+// DeclineAuditAssignment lets the certifier assigned to a sampled lot turn
+// it down. The lot goes to the next certifier in line for the sample's seed
+// among those who have not declined it, or stays unassigned when none is
+// left. The assigned certifier or an admin only; a reason is required.
+func (s *SmartContract) DeclineAuditAssignment(ctx contractapi.TransactionContextInterface, sampleId string, wasteId string, reason string) (*models.AuditSample, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, newError(ctx, ErrDeclineReasonRequired)
+	}
+	sample, err := s.ReadAuditSample(ctx, sampleId)
+	if err != nil {
+		return nil, err
+	}
+	lot := sampledLot(sample, wasteId)
+	if lot == nil {
+		return nil, newError(ctx, ErrWasteNotInSample, wasteId, sampleId)
+	}
+	if lot.Inspected {
+		return nil, newError(ctx, ErrWasteAlreadyInspected, wasteId, sampleId)
+	}
+	if lot.AssignedTo == "" {
+		return nil, newError(ctx, ErrWasteNotAssigned, wasteId, sampleId)
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAssignedCertifier(ctx, actor, sample, lot); err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	samples, err := s.GetAuditSamples(ctx, models.SampleOpen)
+	if err != nil {
+		return nil, err
+	}
+	assigner, err := newCertifierAssigner(ctx, samples)
+	if err != nil {
+		return nil, err
+	}
+	declined := lot.AssignedTo
+	assigner.open[declined]--
+	lot.Declines = append(lot.Declines, models.AssignmentDecline{
+		CertifierID: declined,
+		Reason:      reason,
+		DeclinedAt:  now,
+	})
+	if err := assigner.assign(ctx, sample, lot, now); err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("Certifier %s declined waste %s (%s); left unassigned", declined, wasteId, reason)
+	if lot.AssignedTo != "" {
+		details = fmt.Sprintf("Certifier %s declined waste %s (%s); reassigned to %s", declined, wasteId, reason, lot.AssignedTo)
+	}
+	sample.History = append(sample.History, models.History{
+		Timestamp: now,
+		Action:    "ASSIGNMENT_DECLINED",
+		Actor:     actor,
+		Details:   details,
+	})
+
+	if err := putAuditSample(ctx, sample); err != nil {
+		return nil, err
+	}
+	if err := assigner.notifyAssigned(ctx); err != nil {
+		return nil, err
+	}
+
+	return sample, nil
+}
+
+// ReadCertifier returns a registered certifier
+func (s *SmartContract) ReadCertifier(ctx contractapi.TransactionContextInterface, id string) (*models.Certifier, error) {
+	return readCertifier(ctx, id)
+}
+
+// GetCertifiers returns the registered certifiers, retired ones included
+func (s *SmartContract) GetCertifiers(ctx contractapi.TransactionContextInterface) ([]*models.Certifier, error) {
+	return loadCertifiers(ctx)
+}
+
+// GetCertifierWorkloads returns, for every certifier, its open assignments
+// against its capacity and the lots it inspected or declined; admins and
+// auditors only
+func (s *SmartContract) GetCertifierWorkloads(ctx contractapi.TransactionContextInterface) ([]*models.CertifierWorkload, error) {
+	if !isAdmin(ctx) && !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrWorkloadReadForbidden)
+	}
+	certifiers, err := loadCertifiers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := s.GetAuditSamples(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]*models.CertifierWorkload, len(certifiers))
+	byID := map[string]*models.CertifierWorkload{}
+	for i, certifier := range certifiers {
+		workloads[i] = &models.CertifierWorkload{
+			CertifierID: certifier.ID,
+			Name:        certifier.Name,
+			MSP:         certifier.MSP,
+			Capacity:    certifier.Capacity,
+			Active:      certifier.Active,
+			Assignments: []models.AuditAssignment{},
+		}
+		byID[certifier.ID] = workloads[i]
+	}
+	for _, sample := range samples {
+		for _, lot := range sample.Lots {
+			for _, decline := range lot.Declines {
+				if workload, ok := byID[decline.CertifierID]; ok {
+					workload.Declined++
+				}
+			}
+			workload, ok := byID[lot.AssignedTo]
+			if !ok {
+				continue
+			}
+			if lot.Inspected {
+				workload.Inspected++
+				continue
+			}
+			workload.Open++
+			workload.Assignments = append(workload.Assignments, models.AuditAssignment{
+				SampleID:   sample.ID,
+				WasteID:    lot.WasteID,
+				AssignedAt: lot.AssignedAt,
+			})
+		}
+	}
+	for _, workload := range workloads {
+		if workload.Capacity > 0 {
+			workload.Utilization = float64(workload.Open) / float64(workload.Capacity)
+		}
+		sort.Slice(workload.Assignments, func(i, j int) bool {
+			return workload.Assignments[i].AssignedAt < workload.Assignments[j].AssignedAt
+		})
+	}
+
+	return workloads, nil
+}
+
+// ReadCertifierWorkload returns the workload of one certifier; admins and
+// auditors only
+func (s *SmartContract) ReadCertifierWorkload(ctx contractapi.TransactionContextInterface, certifierId string) (*models.CertifierWorkload, error) {
+	workloads, err := s.GetCertifierWorkloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, workload := range workloads {
+		if workload.CertifierID == certifierId {
+			return workload, nil
+		}
+	}
+
+	return nil, newError(ctx, ErrCertifierNotFound, certifierId)
+}
+
+// certifierAssigner hands sampled lots to certifiers, keeping count of their
+// open assignments and of the lots each was given for notification
+type certifierAssigner struct {
+	certifiers []*models.Certifier
+	open       map[string]int
+	given      map[string][]string
+}
+
+// newCertifierAssigner counts the open assignments of every certifier in
+// the given open samples
+func newCertifierAssigner(ctx contractapi.TransactionContextInterface, samples []*models.AuditSample) (*certifierAssigner, error) {
+	certifiers, err := loadCertifiers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	assigner := &certifierAssigner{
+		certifiers: certifiers,
+		open:       map[string]int{},
+		given:      map[string][]string{},
+	}
+	for _, sample := range samples {
+		for _, lot := range sample.Lots {
+			if lot.AssignedTo != "" && !lot.Inspected {
+				assigner.open[lot.AssignedTo]++
+			}
+		}
+	}
+
+	return assigner, nil
+}
+
+// assign gives a lot to the certifier picked for it, or leaves it
+// unassigned when no certifier is eligible
+func (a *certifierAssigner) assign(ctx contractapi.TransactionContextInterface, sample *models.AuditSample, lot *models.SampledLot, now string) error {
+	var waste models.Waste
+	if _, err := newAssetStore(ctx).Get("WASTE_"+lot.WasteID, &waste); err != nil {
+		return err
+	}
+	excluded := map[string]bool{}
+	for _, decline := range lot.Declines {
+		excluded[decline.CertifierID] = true
+	}
+
+	lot.AssignedTo = ""
+	lot.AssignedAt = ""
+	certifier := pickCertifier(a.certifiers, a.open, sample.Seed, lot.WasteID, waste.OwnerMSP, excluded)
+	if certifier == nil {
+		return nil
+	}
+	lot.AssignedTo = certifier.ID
+	lot.AssignedAt = now
+	sample.Assignment = models.CertifierAssignmentMethod
+	a.open[certifier.ID]++
+	a.given[certifier.ID] = append(a.given[certifier.ID], sample.ID+"/"+lot.WasteID)
+
+	return nil
+}
+
+// notifyAssigned tells the organization of each certifier given lots which
+// ones, in certifier order so every endorser writes the same notifications
+func (a *certifierAssigner) notifyAssigned(ctx contractapi.TransactionContextInterface) error {
+	for _, certifier := range a.certifiers {
+		lots := a.given[certifier.ID]
+		if len(lots) == 0 {
+			continue
+		}
+		message := fmt.Sprintf("Certifier %s (%s) was assigned %d audit lots to inspect: %s", certifier.Name, certifier.ID, len(lots), strings.Join(lots, ", "))
+		if err := notify(ctx, certifier.MSP, models.NotifyAuditAssigned, "CERTIFIER_"+certifier.ID, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pickCertifier draws the certifier of a sampled lot. Every eligible
+// certifier scores -ln(u) / capacity, with u taken from
+// SHA-256(seed|wasteId|certifierId), and the lowest score wins: each is
+// picked in proportion to its capacity, and anyone can recompute the draw.
+// Retired certifiers, those of the lot owner's organization and those who
+// declined the lot are not eligible; certifiers at capacity only are when
+// no other is left.
+func pickCertifier(certifiers []*models.Certifier, open map[string]int, seed string, wasteID string, ownerMSP string, excluded map[string]bool) *models.Certifier {
+	var eligible, withRoom []*models.Certifier
+	for _, certifier := range certifiers {
+		if !certifier.Active || certifier.Capacity <= 0 || certifier.MSP == ownerMSP || excluded[certifier.ID] {
+			continue
+		}
+		eligible = append(eligible, certifier)
+		if open[certifier.ID] < certifier.Capacity {
+			withRoom = append(withRoom, certifier)
+		}
+	}
+	if len(withRoom) > 0 {
+		eligible = withRoom
+	}
+
+	var best *models.Certifier
+	bestScore := 0.0
+	for _, certifier := range eligible {
+		digest := sha256.Sum256([]byte(seed + "|" + wasteID + "|" + certifier.ID))
+		u := (float64(binary.BigEndian.Uint64(digest[:8])>>11) + 0.5) / (1 << 53)
+		score := -math.Log(u) / float64(certifier.Capacity)
+		if best == nil || score < bestScore {
+			best, bestScore = certifier, score
+		}
+	}
+
+	return best
+}
+
+// requireAssignedCertifier rejects callers other than the certifier a lot is
+// assigned to, admins excepted
+func requireAssignedCertifier(ctx contractapi.TransactionContextInterface, identity string, sample *models.AuditSample, lot *models.SampledLot) error {
+	if lot.AssignedTo == "" || isAdmin(ctx) {
+		return nil
+	}
+	certifier, err := certifierByIdentity(ctx, identity)
+	if err != nil {
+		return err
+	}
+	if certifier == nil || certifier.ID != lot.AssignedTo {
+		return newError(ctx, ErrCertifierNotAssigned, lot.AssignedTo, lot.WasteID, sample.ID)
+	}
+
+	return nil
+}
+
+// sampledLot returns the lot of a sample with the given waste ID, or nil
+func sampledLot(sample *models.AuditSample, wasteID string) *models.SampledLot {
+	for i := range sample.Lots {
+		if sample.Lots[i].WasteID == wasteID {
+			return &sample.Lots[i]
+		}
+	}
+
+	return nil
+}
+
+func readCertifier(ctx contractapi.TransactionContextInterface, id string) (*models.Certifier, error) {
+	var certifier models.Certifier
+	found, err := newAssetStore(ctx).Get("CERTIFIER_"+id, &certifier)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "CERTIFIER_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrCertifierNotFound, id)
+	}
+
+	return &certifier, nil
+}
+
+// certifierByIdentity returns the certifier registered by an identity, or
+// nil when it registered none
+func certifierByIdentity(ctx contractapi.TransactionContextInterface, identity string) (*models.Certifier, error) {
+	certifiers, err := loadCertifiers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, certifier := range certifiers {
+		if certifier.Identity == identity {
+			return certifier, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func loadCertifiers(ctx contractapi.TransactionContextInterface) ([]*models.Certifier, error) {
+	certifiers := []*models.Certifier{}
+	err := newAssetStore(ctx).Range("CERTIFIER_", "CERTIFIER_~", func(_ string, value []byte) error {
+		var certifier models.Certifier
+		if err := json.Unmarshal(value, &certifier); err != nil {
+			return err
+		}
+		certifiers = append(certifiers, &certifier)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return certifiers, nil
+}
+
+func putCertifier(ctx contractapi.TransactionContextInterface, certifier *models.Certifier) error {
+	return newAssetStore(ctx).Put("CERTIFIER_"+certifier.ID, certifier)
+}
@@ -0,0 +1,323 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DefineChecklistTemplate sets the checklist a lot must complete before it
+// can move to status transition (admin only); itemsJson is a list of
+// {"key", "label", "mandatory", "valueRequired"}. Items already completed
+// for a lot keep counting when the template changes and their key remains.
+func (s *SmartContract) DefineChecklistTemplate(ctx contractapi.TransactionContextInterface, transition string, name string, itemsJson string) (*models.ChecklistTemplate, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	transition = strings.ToUpper(strings.TrimSpace(transition))
+	if transition == "" {
+		return nil, newError(ctx, ErrStatusRequired)
+	}
+
+	var items []models.ChecklistItemSpec
+	if err := json.Unmarshal([]byte(itemsJson), &items); err != nil {
+		return nil, newError(ctx, ErrChecklistItemsInvalid, err)
+	}
+	if len(items) == 0 {
+		return nil, newError(ctx, ErrChecklistEmpty)
+	}
+	seen := map[string]bool{}
+	for i, item := range items {
+		if item.Key == "" || item.Label == "" {
+			return nil, newError(ctx, ErrChecklistItemInvalid, i+1)
+		}
+		if seen[item.Key] {
+			return nil, newError(ctx, ErrChecklistItemDuplicate, item.Key)
+		}
+		seen[item.Key] = true
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := readChecklistTemplate(ctx, transition)
+	if err != nil {
+		return nil, err
+	}
+	action := "TEMPLATE_CHANGED"
+	if template == nil {
+		action = "TEMPLATE_DEFINED"
+		template = &models.ChecklistTemplate{
+			Transition: transition,
+			CreatedAt:  now,
+			History:    []models.History{},
+		}
+	}
+	template.Name = name
+	template.Items = items
+	template.Version++
+	template.UpdatedAt = now
+	template.History = append(template.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("Version %d with %d items", template.Version, len(items)),
+	})
+
+	if err := newAssetStore(ctx).Put("CHECKTEMPLATE_"+transition, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// RemoveChecklistTemplate lifts the checklist requirement of a transition
+// (admin only)
+func (s *SmartContract) RemoveChecklistTemplate(ctx contractapi.TransactionContextInterface, transition string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	transition = strings.ToUpper(transition)
+	template, err := readChecklistTemplate(ctx, transition)
+	if err != nil {
+		return err
+	}
+	if template == nil {
+		return newError(ctx, ErrChecklistNotDefined, transition)
+	}
+
+	return newAssetStore(ctx).Delete("CHECKTEMPLATE_" + transition)
+}
+
+// ReadChecklistTemplate returns the checklist of a transition
+func (s *SmartContract) ReadChecklistTemplate(ctx contractapi.TransactionContextInterface, transition string) (*models.ChecklistTemplate, error) {
+	template, err := readChecklistTemplate(ctx, strings.ToUpper(transition))
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, newError(ctx, ErrChecklistNotDefined, strings.ToUpper(transition))
+	}
+
+	return template, nil
+}
+
+// GetChecklistTemplates returns the checklists of all transitions
+func (s *SmartContract) GetChecklistTemplates(ctx contractapi.TransactionContextInterface) ([]*models.ChecklistTemplate, error) {
+	templates := []*models.ChecklistTemplate{}
+	err := newAssetStore(ctx).Range("CHECKTEMPLATE_", "CHECKTEMPLATE_~", func(_ string, value []byte) error {
+		var template models.ChecklistTemplate
+		if err := json.Unmarshal(value, &template); err != nil {
+			return err
+		}
+		templates = append(templates, &template)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// CompleteChecklistItem ticks an item of a lot's checklist for a transition,
+// with the reading or reference the item asks for; completing an item again
+// replaces the earlier value
+func (s *SmartContract) CompleteChecklistItem(ctx contractapi.TransactionContextInterface, wasteId string, transition string, itemKey string, value string) (*models.ChecklistProgress, error) {
+	transition = strings.ToUpper(transition)
+	template, err := s.ReadChecklistTemplate(ctx, transition)
+	if err != nil {
+		return nil, err
+	}
+	var spec *models.ChecklistItemSpec
+	for i := range template.Items {
+		if template.Items[i].Key == itemKey {
+			spec = &template.Items[i]
+		}
+	}
+	if spec == nil {
+		return nil, newError(ctx, ErrChecklistItemNotFound, transition, itemKey)
+	}
+	if spec.ValueRequired && strings.TrimSpace(value) == "" {
+		return nil, newError(ctx, ErrChecklistValueRequired, itemKey)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if waste.Status == transition {
+		return nil, newError(ctx, ErrWasteStatusUnchanged, wasteId, transition)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist, err := readChecklist(ctx, wasteId, transition)
+	if err != nil {
+		return nil, err
+	}
+	if checklist == nil {
+		checklist = &models.Checklist{
+			WasteID:    wasteId,
+			Transition: transition,
+			Items:      map[string]models.ChecklistCompletion{},
+			CreatedAt:  now,
+			History:    []models.History{},
+		}
+	}
+	action := "ITEM_COMPLETED"
+	if _, done := checklist.Items[itemKey]; done {
+		action = "ITEM_UPDATED"
+	}
+	checklist.Items[itemKey] = models.ChecklistCompletion{
+		Value:          value,
+		CompletedBy:    actor,
+		CompletedByMSP: mspID,
+		CompletedAt:    now,
+	}
+	checklist.UpdatedAt = now
+	checklist.History = append(checklist.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("%s: %s", spec.Label, value),
+	})
+
+	if err := newAssetStore(ctx).Put(checklistKey(wasteId, transition), checklist); err != nil {
+		return nil, err
+	}
+
+	return checklistProgress(template, checklist, wasteId), nil
+}
+
+// GetChecklistProgress returns how far a lot is through the checklist of a
+// transition
+func (s *SmartContract) GetChecklistProgress(ctx contractapi.TransactionContextInterface, wasteId string, transition string) (*models.ChecklistProgress, error) {
+	transition = strings.ToUpper(transition)
+	template, err := s.ReadChecklistTemplate(ctx, transition)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.readWaste(ctx, wasteId); err != nil {
+		return nil, err
+	}
+	checklist, err := readChecklist(ctx, wasteId, transition)
+	if err != nil {
+		return nil, err
+	}
+
+	return checklistProgress(template, checklist, wasteId), nil
+}
+
+// checkTransitionChecklist refuses to move a lot to a status whose checklist
+// still has open mandatory items
+func checkTransitionChecklist(ctx contractapi.TransactionContextInterface, wasteID string, newStatus string) error {
+	progress, err := transitionChecklist(ctx, wasteID, newStatus)
+	if err != nil {
+		return err
+	}
+	if progress != nil && !progress.Complete {
+		return newError(ctx, ErrChecklistIncomplete, wasteID, newStatus, strings.Join(progress.Missing, ", "))
+	}
+
+	return nil
+}
+
+// transitionChecklist returns a lot's progress through the checklist of a
+// status, or nil when the status has no checklist
+func transitionChecklist(ctx contractapi.TransactionContextInterface, wasteID string, status string) (*models.ChecklistProgress, error) {
+	template, err := readChecklistTemplate(ctx, status)
+	if err != nil || template == nil {
+		return nil, err
+	}
+	checklist, err := readChecklist(ctx, wasteID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return checklistProgress(template, checklist, wasteID), nil
+}
+
+// checklistProgress sets a lot's completions (nil when none) against a
+// template
+func checklistProgress(template *models.ChecklistTemplate, checklist *models.Checklist, wasteID string) *models.ChecklistProgress {
+	progress := &models.ChecklistProgress{
+		WasteID:    wasteID,
+		Transition: template.Transition,
+		Name:       template.Name,
+		Items:      []models.ChecklistItemProgress{},
+		Missing:    []string{},
+	}
+	for _, spec := range template.Items {
+		item := models.ChecklistItemProgress{ChecklistItemSpec: spec}
+		if checklist != nil {
+			if completion, ok := checklist.Items[spec.Key]; ok {
+				item.Completion = &completion
+			}
+		}
+		if item.Completion == nil && spec.Mandatory {
+			progress.Missing = append(progress.Missing, spec.Key)
+		}
+		progress.Items = append(progress.Items, item)
+	}
+	sort.Strings(progress.Missing)
+	progress.Complete = len(progress.Missing) == 0
+
+	return progress
+}
+
+func checklistKey(wasteID string, transition string) string {
+	return "CHECKLIST_" + wasteID + "_" + transition
+}
+
+// readChecklistTemplate returns the checklist of a transition, or nil if
+// none is defined
+func readChecklistTemplate(ctx contractapi.TransactionContextInterface, transition string) (*models.ChecklistTemplate, error) {
+	var template models.ChecklistTemplate
+	found, err := newAssetStore(ctx).Get("CHECKTEMPLATE_"+transition, &template)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "CHECKTEMPLATE_"+transition, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &template, nil
+}
+
+// readChecklist returns the completions of a lot for a transition, or nil
+// if nothing was completed yet
+func readChecklist(ctx contractapi.TransactionContextInterface, wasteID string, transition string) (*models.Checklist, error) {
+	var checklist models.Checklist
+	found, err := newAssetStore(ctx).Get(checklistKey(wasteID, transition), &checklist)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, checklistKey(wasteID, transition), err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &checklist, nil
+}
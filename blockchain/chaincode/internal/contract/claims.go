@@ -0,0 +1,399 @@
+package contract
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// FileClaim opens an insurance claim for a lot lost or damaged in transit
+// (kind LOST or DAMAGED); the ID is generated when id is empty. shipmentRef
+// identifies the shipment (e.g. a collection request or carrier reference)
+// and insurerMsp, when set, restricts the decision to that insurer. Only the
+// lot's owner, the admins of a cooperative allowed to claim for its owner or
+// an admin may file, and a lot has one open claim at a time.
+func (s *SmartContract) FileClaim(ctx contractapi.TransactionContextInterface, id string, wasteId string, shipmentRef string, kind string, lossQuantity float64, description string, insurerMsp string) (*models.Claim, error) {
+	kind = strings.ToUpper(strings.TrimSpace(kind))
+	if kind != models.ClaimLost && kind != models.ClaimDamaged {
+		return nil, newError(ctx, ErrClaimKindUnsupported, kind)
+	}
+	if description == "" {
+		return nil, newError(ctx, ErrClaimDescriptionRequired)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && waste.OwnerMSP != mspID && !isAdmin(ctx) {
+		granted, err := newCooperativeAccess(ctx).grants(ctx, waste.ParticipantID, models.CoopClaim)
+		if err != nil {
+			return nil, err
+		}
+		if !granted {
+			return nil, newError(ctx, ErrClaimFileForbidden, wasteId)
+		}
+	}
+	if waste.Status == models.WasteLost || waste.Status == models.WasteWrittenOff {
+		return nil, newError(ctx, ErrWasteStatusUnchanged, wasteId, waste.Status)
+	}
+	if lossQuantity <= 0 || lossQuantity > waste.Quantity {
+		return nil, newError(ctx, ErrLossQuantityInvalid, waste.Quantity)
+	}
+
+	claims, err := s.GetClaimsForWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range claims {
+		if existing.Status != models.ClaimRejected && existing.Status != models.ClaimSettled {
+			return nil, newError(ctx, ErrClaimAlreadyOpen, wasteId, existing.ID)
+		}
+	}
+
+	if id == "" {
+		if id, err = newAssetID(ctx, "CLAIM"); err != nil {
+			return nil, err
+		}
+	}
+	exists, err := newAssetStore(ctx).Exists("CLAIM_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrClaimAlreadyExists, id)
+	}
+
+	claimant, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claim := &models.Claim{
+		ID:           id,
+		WasteID:      wasteId,
+		ShipmentRef:  shipmentRef,
+		Kind:         kind,
+		Description:  description,
+		LossQuantity: lossQuantity,
+		Claimant:     claimant,
+		ClaimantMSP:  mspID,
+		InsurerMSP:   insurerMsp,
+		Status:       models.ClaimFiled,
+		Evidence:     []models.Document{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		History: []models.History{{
+			Timestamp: now,
+			Action:    "FILED",
+			Actor:     claimant,
+			Details:   fmt.Sprintf("%s claim for %.2f units of waste %s", kind, lossQuantity, wasteId),
+		}},
+	}
+
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "CLAIM_FILED",
+		Actor:     claimant,
+		Details:   fmt.Sprintf("Insurance claim %s filed (%s)", id, kind),
+	})
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+	if err := putClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	if err := notify(ctx, insurerMsp, models.NotifyClaimFiled, "CLAIM_"+id, fmt.Sprintf("%s filed a %s claim for waste %s", mspID, kind, wasteId)); err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// AddClaimEvidence anchors the SHA-256 hash of an evidence document (photos,
+// carrier report, police report) to a claim that is not yet settled. The
+// claimant's organization, assessors and insurers may add evidence.
+func (s *SmartContract) AddClaimEvidence(ctx contractapi.TransactionContextInterface, claimId string, docType string, docHash string, uri string) (*models.Claim, error) {
+	if docType == "" {
+		return nil, newError(ctx, ErrDocumentTypeRequired)
+	}
+	docHash = strings.ToLower(docHash)
+	if decoded, err := hex.DecodeString(docHash); err != nil || len(decoded) != 32 {
+		return nil, newError(ctx, ErrDocumentHashInvalid)
+	}
+
+	claim, err := s.ReadClaim(ctx, claimId)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Status == models.ClaimSettled || claim.Status == models.ClaimRejected {
+		return nil, newError(ctx, ErrClaimStatusInvalid, claimId, claim.Status)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != claim.ClaimantMSP && !hasRole(ctx, AssessorRole) && !hasRole(ctx, InsurerRole) {
+		return nil, newError(ctx, ErrClaimEvidenceForbidden, claimId)
+	}
+	for _, doc := range claim.Evidence {
+		if doc.Hash == docHash {
+			return nil, newError(ctx, ErrClaimDocumentDuplicate, docHash, claimId)
+		}
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claim.Evidence = append(claim.Evidence, models.Document{
+		Type:    docType,
+		Hash:    docHash,
+		URI:     uri,
+		AddedBy: actor,
+		AddedAt: now,
+	})
+	appendClaimHistory(claim, "EVIDENCE_ADDED", actor, fmt.Sprintf("Attached %s document %s", docType, docHash), now)
+	if err := putClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// AssessClaim records an assessor's findings on a filed claim. The assessor
+// must hold the assessor role and cannot be the claimant.
+func (s *SmartContract) AssessClaim(ctx contractapi.TransactionContextInterface, claimId string, finding string, lossQuantity float64, recommendedAmount float64) (*models.Claim, error) {
+	if !hasRole(ctx, AssessorRole) {
+		return nil, newError(ctx, ErrAssessorRequired)
+	}
+	if finding == "" {
+		return nil, newError(ctx, ErrFindingRequired)
+	}
+	if lossQuantity < 0 || recommendedAmount < 0 {
+		return nil, newError(ctx, ErrAssessmentAmountsNegative)
+	}
+
+	claim, err := s.ReadClaim(ctx, claimId)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Status != models.ClaimFiled {
+		return nil, newError(ctx, ErrClaimStatusUnexpected, claimId, claim.Status, models.ClaimFiled)
+	}
+	if lossQuantity > claim.LossQuantity {
+		return nil, newError(ctx, ErrAssessedLossExceedsClaim, lossQuantity, claim.LossQuantity)
+	}
+	assessor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if assessor == claim.Claimant {
+		return nil, newError(ctx, ErrClaimSelfAssessment, claimId)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claim.Assessment = &models.ClaimAssessment{
+		Assessor:          assessor,
+		AssessorMSP:       mspID,
+		Finding:           finding,
+		LossQuantity:      lossQuantity,
+		RecommendedAmount: recommendedAmount,
+		AssessedAt:        now,
+	}
+	claim.Status = models.ClaimAssessed
+	appendClaimHistory(claim, "ASSESSED", assessor, fmt.Sprintf("Assessed loss %.2f, recommended %.2f", lossQuantity, recommendedAmount), now)
+	if err := putClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// DecideClaim approves or rejects an assessed claim. The caller must hold the
+// insurer role and belong to the claim's insurer when one was named.
+func (s *SmartContract) DecideClaim(ctx contractapi.TransactionContextInterface, claimId string, approve bool, reason string) (*models.Claim, error) {
+	claim, insurer, mspID, err := s.insurerClaim(ctx, claimId, models.ClaimAssessed)
+	if err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, newError(ctx, ErrReasonRequired)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claim.Decision = &models.ClaimDecision{
+		Approved:   approve,
+		Reason:     reason,
+		Insurer:    insurer,
+		InsurerMSP: mspID,
+		DecidedAt:  now,
+	}
+	claim.Status = models.ClaimRejected
+	if approve {
+		claim.Status = models.ClaimApproved
+	}
+	appendClaimHistory(claim, claim.Status, insurer, reason, now)
+	if err := putClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	if err := notify(ctx, claim.ClaimantMSP, models.NotifyClaimDecided, "CLAIM_"+claimId, fmt.Sprintf("Claim %s was %s: %s", claimId, strings.ToLower(claim.Status), reason)); err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// SettleClaim records the payout of an approved claim and marks the lot LOST
+// (lost shipment) or WRITTEN_OFF (damaged shipment). Insurer only.
+func (s *SmartContract) SettleClaim(ctx contractapi.TransactionContextInterface, claimId string, amount float64, reference string) (*models.Claim, error) {
+	claim, insurer, _, err := s.insurerClaim(ctx, claimId, models.ClaimApproved)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, newError(ctx, ErrSettlementAmountInvalid)
+	}
+	waste, err := s.readWaste(ctx, claim.WasteID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claim.Settlement = &models.ClaimSettlement{
+		Amount:    amount,
+		Reference: reference,
+		SettledBy: insurer,
+		SettledAt: now,
+	}
+	claim.Status = models.ClaimSettled
+	appendClaimHistory(claim, "SETTLED", insurer, fmt.Sprintf("Settled for %.2f %s", amount, reference), now)
+
+	status := models.WasteWrittenOff
+	if claim.Kind == models.ClaimLost {
+		status = models.WasteLost
+	}
+	applyStatusChange(waste, status, insurer, fmt.Sprintf("Insurance claim %s settled.", claimId), now)
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+	if err := putClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	if err := notify(ctx, claim.ClaimantMSP, models.NotifyClaimSettled, "CLAIM_"+claimId, fmt.Sprintf("Claim %s settled for %.2f; waste %s is now %s", claimId, amount, waste.ID, status)); err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// ReadClaim returns the claim stored with the given id
+func (s *SmartContract) ReadClaim(ctx contractapi.TransactionContextInterface, id string) (*models.Claim, error) {
+	var claim models.Claim
+	found, err := newAssetStore(ctx).Get("CLAIM_"+id, &claim)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "CLAIM_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrClaimNotFound, id)
+	}
+
+	return &claim, nil
+}
+
+// GetClaimsForWaste returns the claims filed for a lot, oldest first
+func (s *SmartContract) GetClaimsForWaste(ctx contractapi.TransactionContextInterface, wasteId string) ([]*models.Claim, error) {
+	claims := []*models.Claim{}
+	err := newAssetStore(ctx).Range("CLAIM_", "CLAIM_~", func(_ string, value []byte) error {
+		var claim models.Claim
+		if err := json.Unmarshal(value, &claim); err != nil {
+			return err
+		}
+		if claim.WasteID == wasteId {
+			claims = append(claims, &claim)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// insurerClaim loads a claim in the expected status for a caller holding the
+// insurer role and returns it with the caller's identity and MSP
+func (s *SmartContract) insurerClaim(ctx contractapi.TransactionContextInterface, claimId string, status string) (*models.Claim, string, string, error) {
+	if !hasRole(ctx, InsurerRole) {
+		return nil, "", "", newError(ctx, ErrInsurerRequired)
+	}
+	claim, err := s.ReadClaim(ctx, claimId)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if claim.Status != status {
+		return nil, "", "", newError(ctx, ErrClaimStatusUnexpected, claimId, claim.Status, status)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if claim.InsurerMSP != "" && claim.InsurerMSP != mspID {
+		return nil, "", "", newError(ctx, ErrClaimInsurerMismatch, claimId, claim.InsurerMSP)
+	}
+	insurer, err := callerID(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return claim, insurer, mspID, nil
+}
+
+func appendClaimHistory(claim *models.Claim, action string, actor string, details string, now string) {
+	claim.UpdatedAt = now
+	claim.History = append(claim.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   details,
+	})
+}
+
+func putClaim(ctx contractapi.TransactionContextInterface, claim *models.Claim) error {
+	return newAssetStore(ctx).Put("CLAIM_"+claim.ID, claim)
+}
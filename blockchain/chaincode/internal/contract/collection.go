@@ -0,0 +1,283 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RequestCollection records a pickup request for a farm within a preferred
+// window and returns it; the ID is generated when id is empty
+func (s *SmartContract) RequestCollection(ctx contractapi.TransactionContextInterface, id string, farm string, owner string, estimatedQuantity float64, windowStart string, windowEnd string) (*models.CollectionRequest, error) {
+	if farm == "" {
+		return nil, newError(ctx, ErrFarmRequired)
+	}
+	if estimatedQuantity <= 0 {
+		return nil, newError(ctx, ErrEstimatedQuantityInvalid)
+	}
+
+	start, err := time.Parse("2006-01-02", windowStart)
+	if err != nil {
+		return nil, newError(ctx, ErrWindowStartInvalid, windowStart)
+	}
+	end, err := time.Parse("2006-01-02", windowEnd)
+	if err != nil {
+		return nil, newError(ctx, ErrWindowEndInvalid, windowEnd)
+	}
+	if end.Before(start) {
+		return nil, newError(ctx, ErrWindowRangeInvalid)
+	}
+
+	if id == "" {
+		if id, err = newAssetID(ctx, "COLLECTION"); err != nil {
+			return nil, err
+		}
+	}
+
+	exists, err := newAssetStore(ctx).Exists("COLLECTION_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrCollectionAlreadyExists, id)
+	}
+
+	ownerMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &models.CollectionRequest{
+		ID:                id,
+		Farm:              farm,
+		Owner:             owner,
+		OwnerMSP:          ownerMSP,
+		EstimatedQuantity: estimatedQuantity,
+		WindowStart:       windowStart,
+		WindowEnd:         windowEnd,
+		Status:            models.CollectionRequested,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "REQUESTED",
+				Actor:     owner,
+				Details:   fmt.Sprintf("Pickup of ~%.2f units at %s between %s and %s", estimatedQuantity, farm, windowStart, windowEnd),
+			},
+		},
+	}
+
+	if err := s.putCollectionRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// AssignCollector assigns (or reassigns) a collector, by participant ID, to an
+// open request. The requesting organization or an admin only.
+func (s *SmartContract) AssignCollector(ctx contractapi.TransactionContextInterface, id string, collector string, actor string) (*models.CollectionRequest, error) {
+	if collector == "" {
+		return nil, newError(ctx, ErrCollectorRequired)
+	}
+
+	request, err := s.ReadCollectionRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRequester(ctx, request); err != nil {
+		return nil, err
+	}
+	if request.Status != models.CollectionRequested && request.Status != models.CollectionAssigned {
+		return nil, newError(ctx, ErrCollectionNotAssignable, id, request.Status)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Collector = collector
+	request.Status = models.CollectionAssigned
+	request.UpdatedAt = now
+	request.History = append(request.History, models.History{
+		Timestamp: now,
+		Action:    "ASSIGNED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Assigned to collector %s", collector),
+	})
+
+	if err := s.putCollectionRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	if err := notify(ctx, request.OwnerMSP, models.NotifyCollectionAssigned, "COLLECTION_"+id, fmt.Sprintf("Pickup at %s assigned to collector %s", request.Farm, collector)); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// CancelCollectionRequest cancels a request that has not been fulfilled yet.
+// The requesting organization or an admin only.
+func (s *SmartContract) CancelCollectionRequest(ctx contractapi.TransactionContextInterface, id string, actor string, reason string) (*models.CollectionRequest, error) {
+	request, err := s.ReadCollectionRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRequester(ctx, request); err != nil {
+		return nil, err
+	}
+	if request.Status == models.CollectionFulfilled || request.Status == models.CollectionCancelled {
+		return nil, newError(ctx, ErrCollectionStatusUnchanged, id, request.Status)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = models.CollectionCancelled
+	request.UpdatedAt = now
+	request.History = append(request.History, models.History{
+		Timestamp: now,
+		Action:    "CANCELLED",
+		Actor:     actor,
+		Details:   reason,
+	})
+
+	if err := s.putCollectionRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// FulfillCollectionRequest creates the collected waste, owned by the
+// requesting organization, and links it to the assigned request, which is
+// marked fulfilled in the same transaction; it returns the new waste, whose
+// ID is generated when wasteId is empty. The assigned collector only.
+func (s *SmartContract) FulfillCollectionRequest(ctx contractapi.TransactionContextInterface, id string, wasteId string, wasteType string, quantity float64, harvestDate string, location string) (*models.Waste, error) {
+	request, err := s.ReadCollectionRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != models.CollectionAssigned {
+		return nil, newError(ctx, ErrCollectionNotFulfillable, id, models.CollectionAssigned, request.Status)
+	}
+	collector, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if collector != request.Collector {
+		return nil, newError(ctx, ErrCollectorMismatch, request.Collector, id)
+	}
+
+	staged := newStagedWrites(ctx)
+	waste, _, err := s.buildWasteFor(ctx, staged, request.OwnerMSP, wasteId, wasteType, quantity, harvestDate, request.Owner, request.Farm, location, "")
+	if err != nil {
+		return nil, err
+	}
+	waste.CollectionRequestID = request.ID
+	waste.History = append(waste.History, models.History{
+		Timestamp: waste.CreatedAt,
+		Action:    "COLLECTED",
+		Actor:     request.Collector,
+		Details:   fmt.Sprintf("Collected under request %s", request.ID),
+	})
+
+	request.Status = models.CollectionFulfilled
+	request.WasteID = waste.ID
+	request.UpdatedAt = waste.CreatedAt
+	request.History = append(request.History, models.History{
+		Timestamp: waste.CreatedAt,
+		Action:    "FULFILLED",
+		Actor:     request.Collector,
+		Details:   fmt.Sprintf("Collected %.2f units as waste %s", quantity, waste.ID),
+	})
+	if _, err := applyTransitionRules(ctx, "COLLECTION", request.ID, request.Status, waste); err != nil {
+		return nil, err
+	}
+
+	staged.waste(waste)
+	if err := staged.commit(); err != nil {
+		return nil, err
+	}
+	if err := recordValuation(ctx, waste, models.ValuationCreated, waste.Quantity, 0, "Collected as "+request.ID); err != nil {
+		return nil, err
+	}
+	if err := recordQuotaUsage(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	if err := s.putCollectionRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	if err := notify(ctx, request.OwnerMSP, models.NotifyCollectionDone, "WASTE_"+waste.ID, fmt.Sprintf("Pickup %s collected %.2f units as waste %s", request.ID, quantity, waste.ID)); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// ReadCollectionRequest returns the collection request stored with the given id
+func (s *SmartContract) ReadCollectionRequest(ctx contractapi.TransactionContextInterface, id string) (*models.CollectionRequest, error) {
+	var request models.CollectionRequest
+	found, err := newAssetStore(ctx).Get("COLLECTION_"+id, &request)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "COLLECTION_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrCollectionNotFound, id)
+	}
+
+	return &request, nil
+}
+
+// GetAllCollectionRequests returns all collection requests, optionally filtered by status
+func (s *SmartContract) GetAllCollectionRequests(ctx contractapi.TransactionContextInterface, status string) ([]*models.CollectionRequest, error) {
+	var requests []*models.CollectionRequest
+	err := newAssetStore(ctx).Range("COLLECTION_", "COLLECTION_~", func(_ string, value []byte) error {
+		var request models.CollectionRequest
+		if err := json.Unmarshal(value, &request); err != nil {
+			return err
+		}
+		if status == "" || request.Status == status {
+			requests = append(requests, &request)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// requireRequester lets the organization that requested a collection and
+// admins through
+func requireRequester(ctx contractapi.TransactionContextInterface, request *models.CollectionRequest) error {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if mspID != request.OwnerMSP && !isAdmin(ctx) {
+		return newError(ctx, ErrCollectionManageForbidden, request.OwnerMSP, request.ID)
+	}
+
+	return nil
+}
+
+func (s *SmartContract) putCollectionRequest(ctx contractapi.TransactionContextInterface, request *models.CollectionRequest) error {
+	return newAssetStore(ctx).Put("COLLECTION_"+request.ID, request)
+}
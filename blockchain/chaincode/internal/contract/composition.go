@@ -0,0 +1,110 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SetWasteComposition records the measured composition of a lot from
+// compositionJson ({"moisturePct": 48.5, "oilContentPct": 6.2}, either field
+// may be left out) and derives its dry matter; the owning organization or an
+// admin only. Extractions made from the lot afterwards balance on dry matter
+// when their outputs report moisture too. A non-zero expectedVersion guards
+// against concurrent updates.
+func (s *SmartContract) SetWasteComposition(ctx contractapi.TransactionContextInterface, wasteId string, compositionJson string, expectedVersion int) (*models.Waste, error) {
+	var composition models.Composition
+	if err := json.Unmarshal([]byte(compositionJson), &composition); err != nil {
+		return nil, newError(ctx, ErrCompositionInvalid, err)
+	}
+	if err := validateComposition(ctx, &composition); err != nil {
+		return nil, err
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrCompositionForbidden, waste.OwnerMSP, wasteId)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	waste.Composition = &composition
+	waste.DryMatter = dryMatter(&composition, waste.Quantity)
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "COMPOSITION_RECORDED",
+		Actor:     actor,
+		Details:   describeComposition(&composition),
+	})
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// validateComposition checks that percentages lie between 0 and 100 and
+// leave room for dry matter
+func validateComposition(ctx contractapi.TransactionContextInterface, composition *models.Composition) error {
+	if !composition.HasMoisture && !composition.HasOilContent {
+		return newError(ctx, ErrCompositionEmpty)
+	}
+	if m := composition.MoisturePct; composition.HasMoisture && (m < 0 || m >= 100) {
+		return newError(ctx, ErrMoistureOutOfRange)
+	}
+	if o := composition.OilContentPct; composition.HasOilContent && (o < 0 || o > 100) {
+		return newError(ctx, ErrOilContentOutOfRange)
+	}
+	if composition.HasMoisture && composition.HasOilContent && composition.MoisturePct+composition.OilContentPct > 100 {
+		return newError(ctx, ErrCompositionOverTotal)
+	}
+
+	return nil
+}
+
+// dryMatter returns the rounded dry-matter equivalent of a wet quantity, or
+// 0 when the moisture is unknown
+func dryMatter(composition *models.Composition, quantity float64) float64 {
+	dry, ok := composition.DryMatter(quantity)
+	if !ok {
+		return 0
+	}
+
+	return math.Round(dry*1000) / 1000
+}
+
+// describeComposition renders a composition as "moisture 48.5%, oil 6.2%"
+func describeComposition(composition *models.Composition) string {
+	var parts []string
+	if composition.HasMoisture {
+		parts = append(parts, fmt.Sprintf("moisture %.1f%%", composition.MoisturePct))
+	}
+	if composition.HasOilContent {
+		parts = append(parts, fmt.Sprintf("oil %.1f%%", composition.OilContentPct))
+	}
+
+	return strings.Join(parts, ", ")
+}
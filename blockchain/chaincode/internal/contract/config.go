@@ -0,0 +1,139 @@
+package contract
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const configKey = "CONFIG"
+
+func configSettingKey(namespace string, key string) string {
+	return namespace + "." + key
+}
+
+// loadConfig reads the configuration asset, returning an empty one if unset
+func loadConfig(ctx contractapi.TransactionContextInterface) (*models.ChaincodeConfig, error) {
+	config := &models.ChaincodeConfig{Settings: map[string]string{}}
+	if _, err := newAssetStore(ctx).Get(configKey, config); err != nil {
+		// Not newError: picking the language reads the config again
+		return nil, &CodedError{Code: ErrLedgerRead, Message: localize(LangEnglish, ErrLedgerRead, configKey, err)}
+	}
+	if config.Settings == nil {
+		config.Settings = map[string]string{}
+	}
+
+	return config, nil
+}
+
+// SetConfig sets a namespaced setting; an empty value restores the default
+func (s *SmartContract) SetConfig(ctx contractapi.TransactionContextInterface, namespace string, key string, value string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if namespace == "" || key == "" {
+		return newError(ctx, ErrConfigKeyRequired)
+	}
+	if strings.Contains(namespace, ".") {
+		return newError(ctx, ErrConfigNamespaceInvalid)
+	}
+
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	settingKey := configSettingKey(namespace, key)
+	oldValue := config.Settings[settingKey]
+	if value == "" {
+		delete(config.Settings, settingKey)
+	} else {
+		config.Settings[settingKey] = value
+	}
+	config.UpdatedAt = now
+	config.UpdatedBy = actor
+
+	if err := newAssetStore(ctx).Put(configKey, config); err != nil {
+		return err
+	}
+
+	eventJSON, err := json.Marshal(models.ConfigChange{
+		Namespace: namespace,
+		Key:       key,
+		OldValue:  oldValue,
+		NewValue:  value,
+		UpdatedBy: actor,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().SetEvent("ConfigChanged", eventJSON)
+}
+
+// GetConfig returns the whole runtime configuration
+func (s *SmartContract) GetConfig(ctx contractapi.TransactionContextInterface) (*models.ChaincodeConfig, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return loadConfig(ctx)
+}
+
+// configString returns a setting or the given default when unset or unreadable
+func configString(ctx contractapi.TransactionContextInterface, namespace string, key string, defaultValue string) string {
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return defaultValue
+	}
+
+	value, ok := config.Settings[configSettingKey(namespace, key)]
+	if !ok || value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+// configBool returns a boolean setting or the given default
+func configBool(ctx contractapi.TransactionContextInterface, namespace string, key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(configString(ctx, namespace, key, strconv.FormatBool(defaultValue)))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// configInt returns an integer setting or the given default
+func configInt(ctx contractapi.TransactionContextInterface, namespace string, key string, defaultValue int) int {
+	value, err := strconv.Atoi(configString(ctx, namespace, key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// configFloat returns a numeric setting or the given default
+func configFloat(ctx contractapi.TransactionContextInterface, namespace string, key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(configString(ctx, namespace, key, ""), 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
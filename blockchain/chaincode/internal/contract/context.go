@@ -0,0 +1,47 @@
+package contract
+
+import (
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransactionContext is the context handed to every function of the
+// contract: the Fabric one, plus what a transaction accumulates from one
+// helper to the next. contractapi creates one per invocation, so nothing
+// outlives the transaction or leaks into the next.
+type TransactionContext struct {
+	contractapi.TransactionContext
+	tx txState
+}
+
+// txState is the state of one transaction: its ID sequences, the assets
+// and notices of its LedgerChanged event, and the function and keys of its
+// audit record
+type txState struct {
+	sequences map[string]int
+	changes   []models.AssetChange
+	notices   []models.Notice
+	function  string
+	writes    []string
+}
+
+// transactionState returns the state of the transaction ctx belongs to.
+// Contexts not created by the contract get a fresh state on every call.
+func transactionState(ctx contractapi.TransactionContextInterface) *txState {
+	if txCtx, ok := ctx.(*TransactionContext); ok {
+		return &txCtx.tx
+	}
+
+	return &txState{}
+}
+
+// next returns 1, 2, 3... on successive calls with the same key within the
+// transaction
+func (t *txState) next(key string) int {
+	if t.sequences == nil {
+		t.sequences = map[string]int{}
+	}
+	t.sequences[key]++
+
+	return t.sequences[key]
+}
@@ -0,0 +1,582 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxCooperativeDepth bounds how many cooperatives may be nested above a
+// participant
+const maxCooperativeDepth = 8
+
+// cooperativeActions are the actions a permission matrix may grant
+var cooperativeActions = []string{models.CoopView, models.CoopList, models.CoopClaim, models.CoopApprove}
+
+// RegisterCooperative registers a cooperative of the caller's organization as
+// a participant; the caller becomes its first admin. Admins see the lots of
+// members of the default tier only until the permission matrix says more.
+func (s *SmartContract) RegisterCooperative(ctx contractapi.TransactionContextInterface, name string) (*models.Cooperative, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, newError(ctx, ErrCooperativeNameRequired)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	participant, collection, err := ensureParticipant(ctx, mspID, name)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := newAssetStore(ctx).Exists("COOP_" + participant.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrCooperativeNameTaken, name, participant.ID)
+	}
+	if err := putPrivate(ctx, collection, "PARTICIPANT_"+participant.ID, participant); err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cooperative := &models.Cooperative{
+		ID:          participant.ID,
+		Name:        name,
+		MSP:         mspID,
+		Admins:      []string{actor},
+		Permissions: map[string][]string{models.DefaultMemberTier: {models.CoopView}},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "REGISTERED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("Cooperative %s registered by %s", name, mspID),
+			},
+		},
+	}
+
+	if err := putCooperative(ctx, cooperative); err != nil {
+		return nil, err
+	}
+
+	return cooperative, nil
+}
+
+// AddCooperativeAdmin lets another client identity act as an admin of the
+// cooperative; cooperative admins and admins only
+func (s *SmartContract) AddCooperativeAdmin(ctx contractapi.TransactionContextInterface, cooperativeId string, adminId string) (*models.Cooperative, error) {
+	if adminId == "" {
+		return nil, newError(ctx, ErrAdminIdentityRequired)
+	}
+	cooperative, err := s.ReadCooperative(ctx, cooperativeId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := requireCooperativeAdmin(ctx, cooperative)
+	if err != nil {
+		return nil, err
+	}
+	for _, admin := range cooperative.Admins {
+		if admin == adminId {
+			return cooperative, nil
+		}
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cooperative.Admins = append(cooperative.Admins, adminId)
+	cooperative.UpdatedAt = now
+	cooperative.History = append(cooperative.History, models.History{
+		Timestamp: now,
+		Action:    "ADMIN_ADDED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Admin %s added", adminId),
+	})
+
+	if err := putCooperative(ctx, cooperative); err != nil {
+		return nil, err
+	}
+
+	return cooperative, nil
+}
+
+// SetCooperativePermissions replaces the cooperative's permission matrix with
+// matrixJson, an object mapping each member tier to the actions (VIEW, LIST,
+// CLAIM) its admins may take on those members' lots. Active members are
+// notified of the change.
+func (s *SmartContract) SetCooperativePermissions(ctx contractapi.TransactionContextInterface, cooperativeId string, matrixJson string) (*models.Cooperative, error) {
+	var matrix map[string][]string
+	if err := json.Unmarshal([]byte(matrixJson), &matrix); err != nil {
+		return nil, newError(ctx, ErrPermissionMatrixInvalid, err)
+	}
+	tiers := make([]string, 0, len(matrix))
+	for tier, actions := range matrix {
+		if tier == "" {
+			return nil, newError(ctx, ErrMemberTiersRequired)
+		}
+		for i, action := range actions {
+			actions[i] = strings.ToUpper(action)
+			if !isCooperativeAction(actions[i]) {
+				return nil, newError(ctx, ErrCooperativeActionUnknown, action, cooperativeActions)
+			}
+		}
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+
+	cooperative, err := s.ReadCooperative(ctx, cooperativeId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := requireCooperativeAdmin(ctx, cooperative)
+	if err != nil {
+		return nil, err
+	}
+	members, err := loadMemberships(ctx, cooperativeId)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if _, ok := matrix[member.Tier]; !ok {
+			return nil, newError(ctx, ErrMemberTierExcluded, member.MemberID, member.Tier)
+		}
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cooperative.Permissions = matrix
+	cooperative.UpdatedAt = now
+	cooperative.History = append(cooperative.History, models.History{
+		Timestamp: now,
+		Action:    "PERMISSIONS_SET",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Permission matrix set for tiers %s", strings.Join(tiers, ", ")),
+	})
+
+	if err := putCooperative(ctx, cooperative); err != nil {
+		return nil, err
+	}
+
+	notified := map[string]bool{}
+	for _, member := range members {
+		if notified[member.MemberMSP] || member.MemberMSP == cooperative.MSP {
+			continue
+		}
+		notified[member.MemberMSP] = true
+		if err := notify(ctx, member.MemberMSP, models.NotifyMembershipChanged, "COOP_"+cooperativeId, fmt.Sprintf("Cooperative %s changed what its admins may do with member lots", cooperative.Name)); err != nil {
+			return nil, err
+		}
+	}
+
+	return cooperative, nil
+}
+
+// JoinCooperative makes a participant, a farm or another cooperative, a
+// member of a cooperative in the given tier (MEMBER when empty). The member's
+// organization joins, which consents to the cooperative's permission matrix;
+// member cooperatives join through their own admins.
+func (s *SmartContract) JoinCooperative(ctx contractapi.TransactionContextInterface, cooperativeId string, memberId string, tier string) (*models.Membership, error) {
+	if tier == "" {
+		tier = models.DefaultMemberTier
+	}
+	if memberId == cooperativeId {
+		return nil, newError(ctx, ErrCooperativeSelfMembership)
+	}
+	cooperative, err := s.ReadCooperative(ctx, cooperativeId)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cooperative.Permissions[tier]; !ok {
+		return nil, newError(ctx, ErrMemberTierUnknown, cooperativeId, tier)
+	}
+	memberMSP, err := s.requireParticipantSide(ctx, memberId)
+	if err != nil {
+		return nil, err
+	}
+
+	// A cooperative cannot join one of its own (indirect) members
+	ancestor := cooperativeId
+	for depth := 0; ancestor != ""; depth++ {
+		if depth >= maxCooperativeDepth {
+			return nil, newError(ctx, ErrCooperativeTooDeep, maxCooperativeDepth)
+		}
+		parent, err := readMembership(ctx, ancestor)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.Status != models.MembershipActive {
+			break
+		}
+		if parent.CooperativeID == memberId {
+			return nil, newError(ctx, ErrCooperativeCycle, memberId, cooperativeId)
+		}
+		ancestor = parent.CooperativeID
+	}
+
+	membership, err := readMembership(ctx, memberId)
+	if err != nil {
+		return nil, err
+	}
+	if membership != nil && membership.Status == models.MembershipActive {
+		return nil, newError(ctx, ErrAlreadyMember, memberId, membership.CooperativeID)
+	}
+	if membership == nil {
+		membership = &models.Membership{MemberID: memberId, History: []models.History{}}
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	membership.CooperativeID = cooperativeId
+	membership.MemberMSP = memberMSP
+	membership.Tier = tier
+	membership.Status = models.MembershipActive
+	membership.JoinedAt = now
+	membership.LeftAt = ""
+	membership.History = append(membership.History, models.History{
+		Timestamp: now,
+		Action:    "JOINED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Joined cooperative %s as %s", cooperativeId, tier),
+	})
+
+	if err := putMembership(ctx, membership); err != nil {
+		return nil, err
+	}
+	if err := notify(ctx, cooperative.MSP, models.NotifyMembershipChanged, "COOP_"+cooperativeId, fmt.Sprintf("%s joined cooperative %s as %s", memberId, cooperative.Name, tier)); err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// LeaveCooperative ends a participant's membership; the member's side or an
+// admin of the cooperative may end it
+func (s *SmartContract) LeaveCooperative(ctx contractapi.TransactionContextInterface, memberId string, reason string) (*models.Membership, error) {
+	membership, err := readMembership(ctx, memberId)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil || membership.Status != models.MembershipActive {
+		return nil, newError(ctx, ErrNotAMember, memberId)
+	}
+	cooperative, err := s.ReadCooperative(ctx, membership.CooperativeID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient := cooperative.MSP
+	if _, err := requireCooperativeAdmin(ctx, cooperative); err == nil {
+		recipient = membership.MemberMSP
+	} else if _, err := s.requireParticipantSide(ctx, memberId); err != nil {
+		return nil, newError(ctx, ErrMembershipEndForbidden, memberId, cooperative.ID)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	details := fmt.Sprintf("Left cooperative %s", cooperative.ID)
+	if reason != "" {
+		details += ": " + reason
+	}
+	membership.Status = models.MembershipLeft
+	membership.LeftAt = now
+	membership.History = append(membership.History, models.History{
+		Timestamp: now,
+		Action:    "LEFT",
+		Actor:     actor,
+		Details:   details,
+	})
+
+	if err := putMembership(ctx, membership); err != nil {
+		return nil, err
+	}
+	if err := notify(ctx, recipient, models.NotifyMembershipChanged, "COOP_"+cooperative.ID, fmt.Sprintf("%s left cooperative %s", memberId, cooperative.Name)); err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// ReadCooperative returns the cooperative registered with the given id
+func (s *SmartContract) ReadCooperative(ctx contractapi.TransactionContextInterface, id string) (*models.Cooperative, error) {
+	var cooperative models.Cooperative
+	found, err := newAssetStore(ctx).Get("COOP_"+id, &cooperative)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "COOP_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrCooperativeNotFound, id)
+	}
+
+	return &cooperative, nil
+}
+
+// GetMembership returns a participant's membership with its history
+func (s *SmartContract) GetMembership(ctx contractapi.TransactionContextInterface, memberId string) (*models.Membership, error) {
+	membership, err := readMembership(ctx, memberId)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil {
+		return nil, newError(ctx, ErrMembershipNotFound, memberId)
+	}
+
+	return membership, nil
+}
+
+// GetCooperativeMembers returns the active members of a cooperative, with
+// the members of its member cooperatives when nested is true
+func (s *SmartContract) GetCooperativeMembers(ctx contractapi.TransactionContextInterface, cooperativeId string, nested bool) ([]*models.Membership, error) {
+	if _, err := s.ReadCooperative(ctx, cooperativeId); err != nil {
+		return nil, err
+	}
+	all, err := loadMemberships(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	members := []*models.Membership{}
+	parents := map[string]bool{cooperativeId: true}
+	for depth := 0; depth < maxCooperativeDepth && len(parents) > 0; depth++ {
+		next := map[string]bool{}
+		for _, membership := range all {
+			if parents[membership.CooperativeID] {
+				members = append(members, membership)
+				next[membership.MemberID] = true
+			}
+		}
+		if !nested {
+			break
+		}
+		parents = next
+	}
+
+	return members, nil
+}
+
+// GetCooperativeAssets returns the lots of a cooperative's members, nested
+// members included, that the caller may view as a cooperative admin
+func (s *SmartContract) GetCooperativeAssets(ctx contractapi.TransactionContextInterface, cooperativeId string) ([]*models.Waste, error) {
+	members, err := s.GetCooperativeMembers(ctx, cooperativeId, true)
+	if err != nil {
+		return nil, err
+	}
+	memberIDs := map[string]bool{}
+	for _, member := range members {
+		memberIDs[member.MemberID] = true
+	}
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	access := newCooperativeAccess(ctx)
+	assets := []*models.Waste{}
+	for _, waste := range wastes {
+		if !memberIDs[waste.ParticipantID] {
+			continue
+		}
+		granted, err := access.grants(ctx, waste.ParticipantID, models.CoopView)
+		if err != nil {
+			return nil, err
+		}
+		if granted {
+			assets = append(assets, waste)
+		}
+	}
+
+	return assets, nil
+}
+
+// cooperativeAccess decides, for one transaction, whether the caller acts
+// for a participant as an admin of a cooperative above it
+type cooperativeAccess struct {
+	caller  string
+	decided map[string]bool
+}
+
+func newCooperativeAccess(ctx contractapi.TransactionContextInterface) *cooperativeAccess {
+	caller, _ := callerID(ctx)
+	return &cooperativeAccess{caller: caller, decided: map[string]bool{}}
+}
+
+// grants walks up the cooperatives above the participant until it finds one
+// the caller administers; the action must be permitted for the member's tier
+// at every level on the way
+func (a *cooperativeAccess) grants(ctx contractapi.TransactionContextInterface, participantID string, action string) (bool, error) {
+	if participantID == "" || a.caller == "" {
+		return false, nil
+	}
+	if granted, ok := a.decided[participantID+"|"+action]; ok {
+		return granted, nil
+	}
+
+	granted := false
+	member := participantID
+	for depth := 0; depth < maxCooperativeDepth; depth++ {
+		membership, err := readMembership(ctx, member)
+		if err != nil {
+			return false, err
+		}
+		if membership == nil || membership.Status != models.MembershipActive {
+			break
+		}
+		var cooperative models.Cooperative
+		found, err := newAssetStore(ctx).Get("COOP_"+membership.CooperativeID, &cooperative)
+		if err != nil {
+			return false, err
+		}
+		if !found || !cooperative.Permits(membership.Tier, action) {
+			break
+		}
+		if isCooperativeAdmin(&cooperative, a.caller) {
+			granted = true
+			break
+		}
+		member = cooperative.ID
+	}
+
+	a.decided[participantID+"|"+action] = granted
+	return granted, nil
+}
+
+// requireCooperativeAdmin returns the caller's identity if they administer
+// the cooperative or are an admin
+func requireCooperativeAdmin(ctx contractapi.TransactionContextInterface, cooperative *models.Cooperative) (string, error) {
+	actor, err := callerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !isCooperativeAdmin(cooperative, actor) && !isAdmin(ctx) {
+		return "", newError(ctx, ErrCooperativeAdminRequired, cooperative.ID)
+	}
+
+	return actor, nil
+}
+
+// requireParticipantSide checks that the caller acts for a participant: its
+// organization for a farm, its admins for a cooperative. It returns the
+// participant's organization.
+func (s *SmartContract) requireParticipantSide(ctx contractapi.TransactionContextInterface, participantID string) (string, error) {
+	var cooperative models.Cooperative
+	found, err := newAssetStore(ctx).Get("COOP_"+participantID, &cooperative)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		if _, err := requireCooperativeAdmin(ctx, &cooperative); err != nil {
+			return "", err
+		}
+		return cooperative.MSP, nil
+	}
+
+	participant, err := readParticipant(ctx, participantID)
+	if err != nil {
+		return "", err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return "", err
+	}
+	if mspID != participant.MSP && !isAdmin(ctx) {
+		return "", newError(ctx, ErrParticipantActForbidden, participant.MSP, participantID)
+	}
+
+	return participant.MSP, nil
+}
+
+func isCooperativeAdmin(cooperative *models.Cooperative, identity string) bool {
+	for _, admin := range cooperative.Admins {
+		if admin == identity {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isCooperativeAction(action string) bool {
+	for _, a := range cooperativeActions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+func putCooperative(ctx contractapi.TransactionContextInterface, cooperative *models.Cooperative) error {
+	return newAssetStore(ctx).Put("COOP_"+cooperative.ID, cooperative)
+}
+
+func putMembership(ctx contractapi.TransactionContextInterface, membership *models.Membership) error {
+	return newAssetStore(ctx).Put("MEMBERSHIP_"+membership.MemberID, membership)
+}
+
+// readMembership returns a participant's membership, or nil if it never
+// joined a cooperative
+func readMembership(ctx contractapi.TransactionContextInterface, memberID string) (*models.Membership, error) {
+	var membership models.Membership
+	found, err := newAssetStore(ctx).Get("MEMBERSHIP_"+memberID, &membership)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "MEMBERSHIP_"+memberID, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &membership, nil
+}
+
+// loadMemberships returns the active memberships, of one cooperative when
+// cooperativeID is set
+func loadMemberships(ctx contractapi.TransactionContextInterface, cooperativeID string) ([]*models.Membership, error) {
+	memberships := []*models.Membership{}
+	err := newAssetStore(ctx).Range("MEMBERSHIP_", "MEMBERSHIP_~", func(_ string, value []byte) error {
+		var membership models.Membership
+		if err := json.Unmarshal(value, &membership); err != nil {
+			return err
+		}
+		if membership.Status == models.MembershipActive && (cooperativeID == "" || membership.CooperativeID == cooperativeID) {
+			memberships = append(memberships, &membership)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
@@ -0,0 +1,113 @@
+package contract
+
+import (
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// W3C Verifiable Credentials data model contexts and types
+const (
+	credentialsContext      = "https://www.w3.org/2018/credentials/v1"
+	traceabilityContext     = "https://w3id.org/traceability/v1"
+	verifiableCredential    = "VerifiableCredential"
+	traceabilityCredential  = "WasteTraceabilityCredential"
+	credentialSubjectPrefix = "urn:green-olive-chain:waste:"
+)
+
+// ExportTraceabilityVC returns the traceability of a waste lot as an unsigned
+// verifiable credential issued by the caller's network identity
+func (s *SmartContract) ExportTraceabilityVC(ctx contractapi.TransactionContextInterface, wasteId string) (*models.VerifiableCredential, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, newError(ctx, ErrWasteNotVisible, wasteId)
+	}
+
+	trace, err := s.GetTraceability(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plot, err := plotOrigin(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+
+	stub := ctx.GetStub()
+	issuer := configString(ctx, "credentials", "issuerDid", "did:fabric:"+stub.GetChannelID()+":"+mspID)
+
+	subject := &models.ProvenanceSummary{
+		ID:           credentialSubjectPrefix + waste.ID,
+		WasteType:    waste.Type,
+		Quantity:     waste.Quantity,
+		HarvestDate:  waste.HarvestDate,
+		Farm:         waste.Farm,
+		Location:     waste.Location,
+		Plot:         plot,
+		Owner:        waste.Owner,
+		OwnerMSP:     waste.OwnerMSP,
+		Status:       waste.Status,
+		QualityGrade: waste.QualityGrade,
+		CampaignID:   waste.CampaignID,
+		Documents:    waste.Documents,
+		Weather:      trace.Weather,
+		EventCount:   len(trace.Chain),
+	}
+	if trace.Extraction != nil {
+		subject.Extraction = &models.ProcessSummary{
+			ID:         trace.Extraction.ID,
+			Product:    trace.Extraction.ProductType,
+			Quantity:   trace.Extraction.Quantity,
+			Operator:   trace.Extraction.Processor,
+			FacilityID: trace.Extraction.FacilityID,
+			Date:       trace.Extraction.ExtractionDate,
+		}
+	}
+	if trace.Recycling != nil {
+		subject.Recycling = &models.ProcessSummary{
+			ID:         trace.Recycling.ID,
+			Product:    trace.Recycling.RecycledProduct,
+			Quantity:   trace.Recycling.Quantity,
+			Method:     trace.Recycling.Method,
+			Operator:   trace.Recycling.Recycler,
+			FacilityID: trace.Recycling.FacilityID,
+			Date:       trace.Recycling.RecyclingDate,
+		}
+	}
+
+	return &models.VerifiableCredential{
+		Context:           []string{credentialsContext, traceabilityContext},
+		ID:                "urn:green-olive-chain:credential:" + stub.GetTxID(),
+		Type:              []string{verifiableCredential, traceabilityCredential},
+		Issuer:            issuer,
+		IssuanceDate:      now,
+		CredentialSubject: subject,
+		Evidence: []models.CredentialEvidence{
+			{
+				Type:          []string{"LedgerTransaction"},
+				Channel:       stub.GetChannelID(),
+				TransactionID: stub.GetTxID(),
+			},
+		},
+	}, nil
+}
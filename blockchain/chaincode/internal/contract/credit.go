@@ -0,0 +1,452 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Credit defaults, overridden by credit.paymentTermsDays (days an invoice is
+// due after issue when its seller set no terms for the buyer) and
+// credit.policy (WARN or BLOCK, for limits without a policy of their own)
+const (
+	defaultPaymentTermsDays = 30
+	defaultCreditPolicy     = models.CreditWarn
+)
+
+// SetCreditLimit sets the unpaid amount the caller's organization accepts
+// from buyerMsp before new transfers to it are flagged, with the payment
+// terms of the invoices it issues to that buyer (0 for
+// credit.paymentTermsDays) and the policy applied past the limit (WARN,
+// BLOCK, or empty for credit.policy).
+func (s *SmartContract) SetCreditLimit(ctx contractapi.TransactionContextInterface, buyerMsp string, limit float64, paymentTermsDays int, policy string) (*models.CreditLimit, error) {
+	if buyerMsp == "" {
+		return nil, newError(ctx, ErrBuyerRequired)
+	}
+	if limit < 0 || paymentTermsDays < 0 {
+		return nil, newError(ctx, ErrCreditTermsNegative)
+	}
+	policy = strings.ToUpper(strings.TrimSpace(policy))
+	if policy != "" && policy != models.CreditWarn && policy != models.CreditBlock {
+		return nil, newError(ctx, ErrCreditPolicyInvalid, models.CreditWarn, models.CreditBlock)
+	}
+	sellerMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sellerMSP == buyerMsp {
+		return nil, newError(ctx, ErrCreditSelf)
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	credit := &models.CreditLimit{
+		SellerMSP:        sellerMSP,
+		BuyerMSP:         buyerMsp,
+		Limit:            limit,
+		PaymentTermsDays: paymentTermsDays,
+		Policy:           policy,
+		UpdatedBy:        actor,
+		UpdatedAt:        now,
+	}
+	if err := newAssetStore(ctx).Put(creditLimitKey(sellerMSP, buyerMsp), credit); err != nil {
+		return nil, err
+	}
+
+	return credit, nil
+}
+
+// RemoveCreditLimit lifts the credit limit the caller's organization set for
+// buyerMsp
+func (s *SmartContract) RemoveCreditLimit(ctx contractapi.TransactionContextInterface, buyerMsp string) error {
+	sellerMSP, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	exists, err := newAssetStore(ctx).Exists(creditLimitKey(sellerMSP, buyerMsp))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return newError(ctx, ErrCreditLimitNotFound, buyerMsp)
+	}
+
+	return newAssetStore(ctx).Delete(creditLimitKey(sellerMSP, buyerMsp))
+}
+
+// GetCreditLimits returns the credit limits the caller's organization set or
+// is subject to; admins see all
+func (s *SmartContract) GetCreditLimits(ctx contractapi.TransactionContextInterface) ([]*models.CreditLimit, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+
+	limits := []*models.CreditLimit{}
+	err = newAssetStore(ctx).Range("CREDITLIMIT_", "CREDITLIMIT_~", func(_ string, value []byte) error {
+		var credit models.CreditLimit
+		if err := json.Unmarshal(value, &credit); err != nil {
+			return err
+		}
+		if admin || credit.SellerMSP == mspID || credit.BuyerMSP == mspID {
+			limits = append(limits, &credit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// GetCreditExposure returns what buyerMsp owes sellerMsp (the caller's
+// organization when empty): its unpaid invoices, the overdue part and what
+// remains of the seller's credit limit. The two parties and admins only.
+func (s *SmartContract) GetCreditExposure(ctx contractapi.TransactionContextInterface, sellerMsp string, buyerMsp string) (*models.CreditExposure, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sellerMsp == "" {
+		sellerMsp = mspID
+	}
+	if mspID != sellerMsp && mspID != buyerMsp && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrCreditExposureForbidden, sellerMsp, buyerMsp)
+	}
+
+	return creditExposure(ctx, sellerMsp, buyerMsp, 0)
+}
+
+// IssueInvoice bills buyerMsp for a sale made off the marketplace, optionally
+// of waste wasteId; it is due after the payment terms the caller's
+// organization set for the buyer. Awarded listings are invoiced on their own.
+func (s *SmartContract) IssueInvoice(ctx contractapi.TransactionContextInterface, buyerMsp string, amount float64, wasteId string, reference string) (*models.Invoice, error) {
+	if buyerMsp == "" {
+		return nil, newError(ctx, ErrBuyerRequired)
+	}
+	if amount <= 0 {
+		return nil, newError(ctx, ErrInvoiceAmountInvalid)
+	}
+	sellerMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sellerMSP == buyerMsp {
+		return nil, newError(ctx, ErrInvoiceSelf)
+	}
+	if wasteId != "" {
+		if _, err := s.readWaste(ctx, wasteId); err != nil {
+			return nil, err
+		}
+	}
+
+	return issueInvoice(ctx, sellerMSP, buyerMsp, amount, wasteId, "", reference)
+}
+
+// MarkInvoicePaid records the payment of an unpaid invoice. The seller or an
+// admin only.
+func (s *SmartContract) MarkInvoicePaid(ctx contractapi.TransactionContextInterface, invoiceId string) (*models.Invoice, error) {
+	invoice, err := readSellerInvoice(ctx, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := payInvoice(ctx, invoice, "Payment recorded"); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// VoidInvoice cancels an unpaid invoice, e.g. one issued in error; it no
+// longer counts in the buyer's exposure. The seller or an admin only.
+func (s *SmartContract) VoidInvoice(ctx contractapi.TransactionContextInterface, invoiceId string, reason string) (*models.Invoice, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, newError(ctx, ErrReasonRequired)
+	}
+	invoice, err := readSellerInvoice(ctx, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != models.InvoiceUnpaid {
+		return nil, newError(ctx, ErrInvoiceStatusInvalid, invoiceId, invoice.Status)
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.Status = models.InvoiceVoid
+	invoice.Reason = reason
+	invoice.History = append(invoice.History, models.History{Timestamp: now, Action: "VOIDED", Actor: actor, Details: reason})
+	if err := putInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// ReadInvoice returns an invoice to its seller, its buyer and admins
+func (s *SmartContract) ReadInvoice(ctx contractapi.TransactionContextInterface, invoiceId string) (*models.Invoice, error) {
+	invoice, err := readInvoice(ctx, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != invoice.SellerMSP && mspID != invoice.BuyerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrInvoiceReadForbidden, invoice.SellerMSP, invoice.BuyerMSP, invoiceId)
+	}
+
+	return invoice, nil
+}
+
+// GetInvoices returns the invoices the caller's organization issued or
+// received, newest first; counterpartyMsp and status filter them when set.
+// Admins see all.
+func (s *SmartContract) GetInvoices(ctx contractapi.TransactionContextInterface, counterpartyMsp string, status string) ([]*models.Invoice, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+	status = strings.ToUpper(status)
+
+	return loadInvoices(ctx, func(invoice *models.Invoice) bool {
+		party := admin || invoice.SellerMSP == mspID || invoice.BuyerMSP == mspID
+		counterparty := counterpartyMsp == "" || invoice.SellerMSP == counterpartyMsp || invoice.BuyerMSP == counterpartyMsp
+		return party && counterparty && (status == "" || invoice.Status == status)
+	})
+}
+
+// issueInvoice stores an unpaid invoice due after the payment terms of the
+// pair
+func issueInvoice(ctx contractapi.TransactionContextInterface, sellerMSP string, buyerMSP string, amount float64, wasteId string, listingId string, reference string) (*models.Invoice, error) {
+	credit, err := readCreditLimit(ctx, sellerMSP, buyerMSP)
+	if err != nil {
+		return nil, err
+	}
+	terms := configInt(ctx, "credit", "paymentTermsDays", defaultPaymentTermsDays)
+	if credit != nil && credit.PaymentTermsDays > 0 {
+		terms = credit.PaymentTermsDays
+	}
+	id, err := newAssetID(ctx, "INVOICE")
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issued, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := &models.Invoice{
+		ID:        id,
+		SellerMSP: sellerMSP,
+		BuyerMSP:  buyerMSP,
+		WasteID:   wasteId,
+		ListingID: listingId,
+		Reference: reference,
+		Amount:    amount,
+		Status:    models.InvoiceUnpaid,
+		IssuedAt:  now,
+		DueAt:     issued.AddDate(0, 0, terms).Format(time.RFC3339),
+		History: []models.History{
+			{Timestamp: now, Action: "ISSUED", Actor: actor, Details: fmt.Sprintf("%.2f due by %s within %d days", amount, buyerMSP, terms)},
+		},
+	}
+	if err := putInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// payInvoice marks an unpaid invoice paid
+func payInvoice(ctx contractapi.TransactionContextInterface, invoice *models.Invoice, details string) error {
+	if invoice.Status != models.InvoiceUnpaid {
+		return newError(ctx, ErrInvoiceStatusInvalid, invoice.ID, invoice.Status)
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	invoice.Status = models.InvoicePaid
+	invoice.PaidAt = now
+	invoice.History = append(invoice.History, models.History{Timestamp: now, Action: "PAID", Actor: actor, Details: details})
+
+	return putInvoice(ctx, invoice)
+}
+
+// creditExposure computes what buyerMSP owes sellerMSP, exceeding the limit
+// when amount more would take the unpaid invoices over it
+func creditExposure(ctx contractapi.TransactionContextInterface, sellerMSP string, buyerMSP string, amount float64) (*models.CreditExposure, error) {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	invoices, err := loadInvoices(ctx, func(invoice *models.Invoice) bool {
+		return invoice.Status == models.InvoiceUnpaid && invoice.SellerMSP == sellerMSP && invoice.BuyerMSP == buyerMSP
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exposure := &models.CreditExposure{SellerMSP: sellerMSP, BuyerMSP: buyerMSP, UnpaidInvoices: len(invoices)}
+	for _, invoice := range invoices {
+		exposure.Outstanding += invoice.Amount
+		if invoice.DueAt < now {
+			exposure.Overdue += invoice.Amount
+		}
+	}
+
+	credit, err := readCreditLimit(ctx, sellerMSP, buyerMSP)
+	if err != nil {
+		return nil, err
+	}
+	if credit != nil {
+		exposure.Limited = true
+		exposure.Limit = credit.Limit
+		exposure.Available = credit.Limit - exposure.Outstanding
+		exposure.Policy = credit.Policy
+		if exposure.Policy == "" {
+			exposure.Policy = strings.ToUpper(configString(ctx, "credit", "policy", defaultCreditPolicy))
+		}
+		exposure.Exceeded = exposure.Outstanding+amount > credit.Limit
+	}
+
+	return exposure, nil
+}
+
+// creditAllows applies the seller's credit policy to a transfer of amount
+// (0 when the transfer carries no price) to buyerMSP: past the limit, a
+// BLOCK policy refuses it and a WARN policy notifies the seller and lets it
+// through
+func creditAllows(ctx contractapi.TransactionContextInterface, sellerMSP string, buyerMSP string, amount float64, subject string) (*models.CreditExposure, bool, error) {
+	if sellerMSP == "" || sellerMSP == buyerMSP {
+		return nil, true, nil
+	}
+	exposure, err := creditExposure(ctx, sellerMSP, buyerMSP, amount)
+	if err != nil {
+		return nil, false, err
+	}
+	if !exposure.Exceeded {
+		return exposure, true, nil
+	}
+	if exposure.Policy == models.CreditBlock {
+		return exposure, false, nil
+	}
+
+	message := fmt.Sprintf("%s owes %.2f (%.2f overdue) against a credit limit of %.2f; the transfer went ahead", buyerMSP, exposure.Outstanding, exposure.Overdue, exposure.Limit)
+	if err := notify(ctx, sellerMSP, models.NotifyCreditLimitExceeded, subject, message); err != nil {
+		return nil, false, err
+	}
+
+	return exposure, true, nil
+}
+
+// creditRefusal is the error of a transfer blocked by a credit limit
+func creditRefusal(ctx contractapi.TransactionContextInterface, exposure *models.CreditExposure) error {
+	return newError(ctx, ErrCreditLimitExceeded, exposure.BuyerMSP, exposure.SellerMSP, exposure.Outstanding, exposure.Overdue, exposure.Limit)
+}
+
+// readSellerInvoice reads an invoice the caller's organization issued
+func readSellerInvoice(ctx contractapi.TransactionContextInterface, invoiceId string) (*models.Invoice, error) {
+	invoice, err := readInvoice(ctx, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != invoice.SellerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrInvoiceManageForbidden, invoice.SellerMSP, invoiceId)
+	}
+
+	return invoice, nil
+}
+
+func readInvoice(ctx contractapi.TransactionContextInterface, id string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	found, err := newAssetStore(ctx).Get("INVOICE_"+id, &invoice)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "INVOICE_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrInvoiceNotFound, id)
+	}
+
+	return &invoice, nil
+}
+
+func putInvoice(ctx contractapi.TransactionContextInterface, invoice *models.Invoice) error {
+	return newAssetStore(ctx).Put("INVOICE_"+invoice.ID, invoice)
+}
+
+func loadInvoices(ctx contractapi.TransactionContextInterface, keep func(*models.Invoice) bool) ([]*models.Invoice, error) {
+	invoices := []*models.Invoice{}
+	err := newAssetStore(ctx).Range("INVOICE_", "INVOICE_~", func(_ string, value []byte) error {
+		var invoice models.Invoice
+		if err := json.Unmarshal(value, &invoice); err != nil {
+			return err
+		}
+		if keep(&invoice) {
+			invoices = append(invoices, &invoice)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		return invoices[i].IssuedAt > invoices[j].IssuedAt
+	})
+
+	return invoices, nil
+}
+
+// readCreditLimit returns the limit sellerMSP set for buyerMSP, nil if none
+func readCreditLimit(ctx contractapi.TransactionContextInterface, sellerMSP string, buyerMSP string) (*models.CreditLimit, error) {
+	var credit models.CreditLimit
+	found, err := newAssetStore(ctx).Get(creditLimitKey(sellerMSP, buyerMSP), &credit)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &credit, nil
+}
+
+func creditLimitKey(sellerMSP string, buyerMSP string) string {
+	return "CREDITLIMIT_" + sellerMSP + "_" + buyerMSP
+}
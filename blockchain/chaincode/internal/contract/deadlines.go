@@ -0,0 +1,156 @@
+package contract
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// GetUpcomingDeadlines returns what ends before until (a date or RFC3339
+// time) and is still running: active agreements and supply contracts, open
+// listings, active delegations, permits and driving licenses of active
+// vehicles and drivers, prepared token settlements and pending approvals.
+// Callers see the deadlines their organization is party to; admins see all.
+// Soonest first; deadlines already past are included, so reminders can
+// follow overdue ones.
+func (s *SmartContract) GetUpcomingDeadlines(ctx contractapi.TransactionContextInterface, until string) ([]*models.Deadline, error) {
+	if until == "" {
+		return nil, newError(ctx, ErrUntilRequired)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+
+	deadlines := []*models.Deadline{}
+	add := func(kind string, id string, label string, dueAt string, parties ...string) {
+		if dueAt == "" || !dueBefore(dueAt, until) {
+			return
+		}
+		visible := admin
+		for _, party := range parties {
+			visible = visible || party == mspID
+		}
+		if visible {
+			deadlines = append(deadlines, &models.Deadline{Kind: kind, AssetID: id, Label: label, DueAt: dueAt, Parties: parties})
+		}
+	}
+
+	agreements, err := loadAgreements(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, agreement := range agreements {
+		if agreement.Status == models.AgreementActive {
+			add(models.DeadlineAgreement, agreement.ID, fmt.Sprintf("Data-sharing agreement between %s and %s", agreement.Proposer, agreement.Counterparty), agreement.ValidUntil, agreement.Proposer, agreement.Counterparty)
+		}
+	}
+
+	contracts, err := loadSupplyContracts(ctx, func(contract *models.SupplyContract) bool {
+		return contract.Status == models.SupplyActive
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, contract := range contracts {
+		add(models.DeadlineSupplyContract, contract.ID, fmt.Sprintf("Supply contract for %.2f of %s (%.2f delivered)", contract.CommittedVolume, contract.ProductType, contract.FulfilledVolume), contract.EndDate, contract.Supplier, contract.Buyer)
+	}
+
+	listings, err := loadListings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, listing := range listings {
+		if listing.Status == models.ListingOpen {
+			add(models.DeadlineListing, listing.ID, fmt.Sprintf("Listing of waste %s closes to bids", listing.WasteID), listing.ExpiresAt, listing.SellerMSP)
+		}
+	}
+
+	delegations, err := loadDelegations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, delegation := range delegations {
+		if delegation.Status == models.DelegationActive {
+			add(models.DeadlineDelegation, delegation.ID, fmt.Sprintf("Delegation to %s", delegation.Delegate), delegation.ExpiresAt, delegation.DelegatorMSP, delegation.DelegateMSP)
+		}
+	}
+
+	vehicles, err := loadVehicles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, vehicle := range vehicles {
+		if vehicle.Status != models.TransportActive {
+			continue
+		}
+		for _, permit := range vehicle.Permits {
+			add(models.DeadlinePermit, vehicle.ID, fmt.Sprintf("%s permit %s of vehicle %s", permit.Kind, permit.Number, vehicle.Plate), permit.ExpiresAt, vehicle.OperatorMSP)
+		}
+	}
+	drivers, err := loadDrivers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, driver := range drivers {
+		if driver.Status != models.TransportActive {
+			continue
+		}
+		add(models.DeadlinePermit, driver.ID, fmt.Sprintf("Driving license of driver %s", driver.ID), driver.LicenseExpiresAt, driver.OperatorMSP)
+		for _, permit := range driver.Permits {
+			add(models.DeadlinePermit, driver.ID, fmt.Sprintf("%s permit %s of driver %s", permit.Kind, permit.Number, driver.ID), permit.ExpiresAt, driver.OperatorMSP)
+		}
+	}
+
+	settlements, err := loadSettlements(ctx, func(settlement *models.TokenSettlement) bool {
+		return settlement.Status == models.SettlementPrepared
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, settlement := range settlements {
+		// The seller confirms, so their organization comes first
+		parties := []string{settlement.Transfer.BuyerMSP}
+		waste, err := s.readWaste(ctx, settlement.WasteID)
+		if err != nil {
+			return nil, err
+		}
+		if sellerMSP := holderMSP(waste, settlement.Transfer.SellerID); sellerMSP != "" && sellerMSP != settlement.Transfer.BuyerMSP {
+			parties = append([]string{sellerMSP}, parties...)
+		}
+		add(models.DeadlineSettlement, settlement.ID, fmt.Sprintf("Token settlement for %.2f%% of waste %s awaits confirmation", settlement.Transfer.Percentage, settlement.WasteID), settlement.ExpiresAt, parties...)
+	}
+
+	approvals, err := loadApprovals(ctx, func(approval *models.Approval) bool {
+		return approval.Status == models.ApprovalPending
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, approval := range approvals {
+		parties := []string{approval.RequestedByMSP}
+		if approval.Transfer != nil && approval.Transfer.BuyerMSP != approval.RequestedByMSP {
+			parties = append(parties, approval.Transfer.BuyerMSP)
+		}
+		add(models.DeadlineApproval, approval.ID, fmt.Sprintf("Approval of a transfer of waste %s awaits %d signature(s)", approval.WasteID, len(approval.RequiredRoles)-len(approval.Signatures)), approval.ExpiresAt, parties...)
+	}
+
+	sort.SliceStable(deadlines, func(i, j int) bool {
+		return deadlines[i].DueAt < deadlines[j].DueAt
+	})
+
+	return deadlines, nil
+}
+
+// dueBefore compares a date or RFC3339 deadline with a date or RFC3339
+// bound; date-only values compare on the day
+func dueBefore(dueAt string, until string) bool {
+	if len(dueAt) < len(until) {
+		return dueAt <= until[:len(dueAt)]
+	}
+
+	return dueAt[:len(until)] <= until
+}
@@ -0,0 +1,216 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateDelegation lets delegate (a client identity of delegateMsp, or a
+// backend service account) read the caller organization's assets of the
+// comma-separated scope until expiresAt (RFC3339)
+func (s *SmartContract) CreateDelegation(ctx contractapi.TransactionContextInterface, id string, delegate string, delegateMsp string, scope string, expiresAt string) (*models.Delegation, error) {
+	if id == "" {
+		return nil, newError(ctx, ErrDelegationIDRequired)
+	}
+	if delegate == "" || delegateMsp == "" {
+		return nil, newError(ctx, ErrDelegateRequired)
+	}
+
+	delegator, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	delegatorMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if delegate == delegator && delegateMsp == delegatorMSP {
+		return nil, newError(ctx, ErrDelegationSelf)
+	}
+
+	scopes, err := parseAgreementScope(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return nil, newError(ctx, ErrExpiresAtInvalid, err)
+	}
+	expiresAt = expiry.UTC().Format(time.RFC3339)
+	if expiresAt <= now {
+		return nil, newError(ctx, ErrExpiresAtPast)
+	}
+
+	exists, err := newAssetStore(ctx).Exists("DELEGATION_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrDelegationAlreadyExists, id)
+	}
+
+	delegation := &models.Delegation{
+		ID:           id,
+		Delegator:    delegator,
+		DelegatorMSP: delegatorMSP,
+		Delegate:     delegate,
+		DelegateMSP:  delegateMsp,
+		Scope:        scopes,
+		ExpiresAt:    expiresAt,
+		Status:       models.DelegationActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "GRANTED",
+				Actor:     delegator,
+				Details:   fmt.Sprintf("%s delegated %s to %s until %s", delegatorMSP, strings.Join(scopes, ","), delegateMsp, expiresAt),
+			},
+		},
+	}
+
+	if err := putDelegation(ctx, delegation); err != nil {
+		return nil, err
+	}
+
+	if delegateMsp != delegatorMSP {
+		if err := notify(ctx, delegateMsp, models.NotifyDelegationGranted, "DELEGATION_"+id, fmt.Sprintf("%s delegated %s until %s", delegatorMSP, strings.Join(scopes, ","), expiresAt)); err != nil {
+			return nil, err
+		}
+	}
+
+	return delegation, nil
+}
+
+// RevokeDelegation ends a delegation; the delegator, an admin of the
+// delegator's organization or the delegate may revoke it
+func (s *SmartContract) RevokeDelegation(ctx contractapi.TransactionContextInterface, id string) (*models.Delegation, error) {
+	delegation, err := s.ReadDelegation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delegation.Status == models.DelegationRevoked {
+		return nil, newError(ctx, ErrDelegationAlreadyRevoked, id)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isDelegator := actor == delegation.Delegator && mspID == delegation.DelegatorMSP
+	isDelegate := actor == delegation.Delegate && mspID == delegation.DelegateMSP
+	orgAdmin := isAdmin(ctx) && mspID == delegation.DelegatorMSP
+	if !isDelegator && !isDelegate && !orgAdmin {
+		return nil, newError(ctx, ErrDelegationRevokeForbidden, id)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	delegation.Status = models.DelegationRevoked
+	delegation.UpdatedAt = now
+	delegation.History = append(delegation.History, models.History{
+		Timestamp: now,
+		Action:    "REVOKED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Delegation %s revoked by %s", id, mspID),
+	})
+	if err := putDelegation(ctx, delegation); err != nil {
+		return nil, err
+	}
+
+	if delegation.DelegateMSP != delegation.DelegatorMSP {
+		otherParty := delegation.DelegateMSP
+		if mspID == delegation.DelegateMSP {
+			otherParty = delegation.DelegatorMSP
+		}
+		if err := notify(ctx, otherParty, models.NotifyDelegationRevoked, "DELEGATION_"+id, fmt.Sprintf("%s revoked delegation %s", mspID, id)); err != nil {
+			return nil, err
+		}
+	}
+
+	return delegation, nil
+}
+
+// ReadDelegation returns the delegation stored with the given id
+func (s *SmartContract) ReadDelegation(ctx contractapi.TransactionContextInterface, id string) (*models.Delegation, error) {
+	var delegation models.Delegation
+	found, err := newAssetStore(ctx).Get("DELEGATION_"+id, &delegation)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "DELEGATION_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrDelegationNotFound, id)
+	}
+
+	return &delegation, nil
+}
+
+// GetMyDelegations returns the delegations the caller granted or received;
+// admins see every delegation granted by their organization
+func (s *SmartContract) GetMyDelegations(ctx contractapi.TransactionContextInterface) ([]*models.Delegation, error) {
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+
+	delegations, err := loadDelegations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mine := []*models.Delegation{}
+	for _, delegation := range delegations {
+		switch {
+		case delegation.Delegator == actor && delegation.DelegatorMSP == mspID,
+			delegation.Delegate == actor && delegation.DelegateMSP == mspID,
+			admin && delegation.DelegatorMSP == mspID:
+			mine = append(mine, delegation)
+		}
+	}
+
+	return mine, nil
+}
+
+func putDelegation(ctx contractapi.TransactionContextInterface, delegation *models.Delegation) error {
+	return newAssetStore(ctx).Put("DELEGATION_"+delegation.ID, delegation)
+}
+
+func loadDelegations(ctx contractapi.TransactionContextInterface) ([]*models.Delegation, error) {
+	var delegations []*models.Delegation
+	err := newAssetStore(ctx).Range("DELEGATION_", "DELEGATION_~", func(_ string, value []byte) error {
+		var delegation models.Delegation
+		if err := json.Unmarshal(value, &delegation); err != nil {
+			return err
+		}
+		delegations = append(delegations, &delegation)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return delegations, nil
+}
@@ -0,0 +1,245 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DonateWaste gives what remains of a lot to a recipient outside the
+// commercial chain, e.g. a neighbouring farm or a research lab. reasonCode
+// is one of models.DonationReasons. The owning organization or an admin
+// only; a non-zero expectedVersion guards against concurrent updates.
+func (s *SmartContract) DonateWaste(ctx contractapi.TransactionContextInterface, wasteId string, reasonCode string, recipient models.DonationRecipient, notes string, expectedVersion int) (*models.Disposition, error) {
+	if strings.TrimSpace(recipient.Name) == "" || strings.TrimSpace(recipient.Kind) == "" {
+		return nil, newError(ctx, ErrRecipientRequired)
+	}
+
+	return s.recordDisposition(ctx, wasteId, models.DispositionDonation, reasonCode, notes, expectedVersion, func(disposition *models.Disposition) error {
+		disposition.Recipient = &recipient
+		return nil
+	})
+}
+
+// DisposeWaste sends what remains of a lot to a registered landfill as a
+// last resort. reasonCode is one of models.DisposalReasons. The owning
+// organization or an admin only; a non-zero expectedVersion guards against
+// concurrent updates.
+func (s *SmartContract) DisposeWaste(ctx contractapi.TransactionContextInterface, wasteId string, reasonCode string, facilityId string, notes string, expectedVersion int) (*models.Disposition, error) {
+	return s.recordDisposition(ctx, wasteId, models.DispositionDisposal, reasonCode, notes, expectedVersion, func(disposition *models.Disposition) error {
+		facility, err := s.ReadFacility(ctx, facilityId)
+		if err != nil {
+			return err
+		}
+		if facility.Type != models.FacilityLandfill {
+			return newError(ctx, ErrFacilityTypeMismatch, facilityId, facility.Type, models.FacilityLandfill)
+		}
+		if facility.Status != models.FacilityActive {
+			return newError(ctx, ErrFacilityStatusInvalid, facilityId, facility.Status)
+		}
+		disposition.FacilityID = facilityId
+
+		return nil
+	})
+}
+
+// ReadDisposition returns the donation or disposal stored with the given id
+func (s *SmartContract) ReadDisposition(ctx contractapi.TransactionContextInterface, id string) (*models.Disposition, error) {
+	var disposition models.Disposition
+	found, err := newAssetStore(ctx).Get("DISPOSITION_"+id, &disposition)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "DISPOSITION_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrDispositionNotFound, id)
+	}
+
+	return &disposition, nil
+}
+
+// GetDispositionStatistics totals the lots collected between from and to
+// (YYYY-MM-DD, inclusive, either may be empty) by how they left the chain,
+// keeping donations and disposals out of the recycling rate. Admins see
+// every organization's lots, others their own.
+func (s *SmartContract) GetDispositionStatistics(ctx contractapi.TransactionContextInterface, from string, to string) (*models.DispositionStatistics, error) {
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := &models.DispositionStatistics{From: from, To: to, ByReason: map[string]float64{}}
+	counted := map[string]bool{}
+	for _, waste := range wastes {
+		if (!admin && waste.OwnerMSP != mspID) || !onDayBetween(waste.CreatedAt, from, to) {
+			continue
+		}
+		counted[waste.ID] = true
+		stats.Collected += waste.Quantity
+	}
+
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, recycling := range recyclings {
+		for _, input := range recycling.InputLots() {
+			if counted[input.WasteID] {
+				stats.Recycled += input.Quantity
+			}
+		}
+	}
+
+	err = newAssetStore(ctx).Range("DISPOSITION_", "DISPOSITION_~", func(_ string, value []byte) error {
+		var disposition models.Disposition
+		if err := json.Unmarshal(value, &disposition); err != nil {
+			return err
+		}
+		if !counted[disposition.WasteID] {
+			return nil
+		}
+		if disposition.Kind == models.DispositionDonation {
+			stats.Donations++
+			stats.Donated += disposition.Quantity
+		} else {
+			stats.Disposals++
+			stats.Disposed += disposition.Quantity
+		}
+		stats.ByReason[disposition.ReasonCode] += disposition.Quantity
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if recyclable := stats.Collected - stats.Donated; recyclable > 0 {
+		stats.RecyclingRate = stats.Recycled / recyclable
+	}
+	if stats.Collected > 0 {
+		stats.DiversionRate = (stats.Recycled + stats.Donated) / stats.Collected
+		stats.LandfillRate = stats.Disposed / stats.Collected
+	}
+
+	return stats, nil
+}
+
+// recordDisposition closes a lot as donated or disposed of; complete fills
+// in what is specific to the kind
+func (s *SmartContract) recordDisposition(ctx contractapi.TransactionContextInterface, wasteId string, kind string, reasonCode string, notes string, expectedVersion int, complete func(*models.Disposition) error) (*models.Disposition, error) {
+	reasons, status := models.DonationReasons, models.WasteDonated
+	if kind == models.DispositionDisposal {
+		reasons, status = models.DisposalReasons, models.WasteDisposed
+	}
+	reasonCode = strings.ToUpper(strings.TrimSpace(reasonCode))
+	if !isReasonCode(reasons, reasonCode) {
+		return nil, newError(ctx, ErrDispositionReasonInvalid, strings.Join(reasons, ", "))
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrDispositionForbidden, waste.OwnerMSP, wasteId)
+	}
+	switch waste.Status {
+	case "PROCESSED", "RECYCLED", models.WasteDonated, models.WasteDisposed, models.WasteLost, models.WasteWrittenOff, models.WasteExported:
+		return nil, newError(ctx, ErrWasteStatusUnchanged, wasteId, waste.Status)
+	}
+	remaining := waste.Quantity - waste.Consumed
+	if remaining <= 0 {
+		return nil, newError(ctx, ErrWasteExhausted, wasteId)
+	}
+
+	id, err := newAssetID(ctx, "DISPOSITION")
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	disposition := &models.Disposition{
+		ID:            id,
+		WasteID:       wasteId,
+		Kind:          kind,
+		ReasonCode:    reasonCode,
+		Notes:         notes,
+		Quantity:      remaining,
+		RecordedBy:    actor,
+		RecordedByMSP: mspID,
+		CreatedAt:     now,
+	}
+	if err := complete(disposition); err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("%.2f sent to landfill %s (%s)", remaining, disposition.FacilityID, reasonCode)
+	if disposition.Recipient != nil {
+		details = fmt.Sprintf("%.2f donated to %s (%s)", remaining, disposition.Recipient.Name, reasonCode)
+	}
+	waste.DispositionID = id
+	applyStatusChange(waste, status, actor, details, now)
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+	if err := newAssetStore(ctx).Put("DISPOSITION_"+id, disposition); err != nil {
+		return nil, err
+	}
+
+	return disposition, nil
+}
+
+// dispositionQuantities returns what a donated or disposed lot had left
+// when it left the chain; donations leave recycling-rate denominators,
+// disposals stay in them as unrecycled quantity
+func dispositionQuantities(waste *models.Waste) (float64, float64) {
+	remaining := waste.Quantity - waste.Consumed
+	switch waste.Status {
+	case models.WasteDonated:
+		return remaining, 0
+	case models.WasteDisposed:
+		return 0, remaining
+	}
+
+	return 0, 0
+}
+
+// isReasonCode reports whether code is one of the reasons
+func isReasonCode(reasons []string, code string) bool {
+	for _, r := range reasons {
+		if r == code {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package contract
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AttachWasteDocument anchors the SHA-256 hash of an off-chain document to a
+// waste item; a non-zero expectedVersion guards against concurrent updates
+func (s *SmartContract) AttachWasteDocument(ctx contractapi.TransactionContextInterface, wasteId string, docType string, docHash string, uri string, actor string, expectedVersion int) (*models.Waste, error) {
+	if docType == "" {
+		return nil, newError(ctx, ErrDocumentTypeRequired)
+	}
+
+	docHash = strings.ToLower(docHash)
+	if decoded, err := hex.DecodeString(docHash); err != nil || len(decoded) != 32 {
+		return nil, newError(ctx, ErrDocumentHashInvalid)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	for _, doc := range waste.Documents {
+		if doc.Hash == docHash {
+			return nil, newError(ctx, ErrDocumentAlreadyAttached, docHash, wasteId)
+		}
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	waste.Documents = append(waste.Documents, models.Document{
+		Type:    docType,
+		Hash:    docHash,
+		URI:     uri,
+		AddedBy: actor,
+		AddedAt: now,
+	})
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "DOCUMENT_ATTACHED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Attached %s document %s", docType, docHash),
+	})
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// GetWasteDocuments returns the documents anchored to a waste item
+func (s *SmartContract) GetWasteDocuments(ctx contractapi.TransactionContextInterface, wasteId string) ([]models.Document, error) {
+	waste, err := s.ReadWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+
+	return waste.Documents, nil
+}
@@ -0,0 +1,327 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// harvestIndex is the composite-key object type indexing lots by owning
+// organization and harvest date, where new lots look for their duplicates
+const harvestIndex = "msp~harvest~waste"
+
+// defaultDuplicateTolerancePct is how far apart, in percent of the smaller
+// one, two quantities may lie and still be taken for the same harvest
+const defaultDuplicateTolerancePct = 2.0
+
+// FindDuplicateWastes groups the lots of one owner, harvest date, waste type
+// and plot whose quantities lie within tolerancePct of each other (the
+// "duplicates.quantityTolerancePct" setting when 0). Admins and auditors
+// see every organization's lots, others their own. Lots already merged are
+// left out.
+func (s *SmartContract) FindDuplicateWastes(ctx contractapi.TransactionContextInterface, tolerancePct float64) (*models.DuplicateReport, error) {
+	if tolerancePct < 0 {
+		return nil, newError(ctx, ErrToleranceNegative)
+	}
+	if tolerancePct == 0 {
+		tolerancePct = configFloat(ctx, "duplicates", "quantityTolerancePct", defaultDuplicateTolerancePct)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all := isAdmin(ctx) || hasRole(ctx, AuditorRole)
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DuplicateReport{
+		TolerancePct: tolerancePct,
+		Groups:       []models.DuplicateGroup{},
+		CheckedAt:    now,
+	}
+	harvests := map[string][]*models.Waste{}
+	var keys []string
+	for _, waste := range wastes {
+		if waste.Status == models.WasteMerged || (!all && waste.OwnerMSP != mspID) {
+			continue
+		}
+		report.Lots++
+		key := harvestKey(waste)
+		if _, ok := harvests[key]; !ok {
+			keys = append(keys, key)
+		}
+		harvests[key] = append(harvests[key], waste)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		lots := harvests[key]
+		if len(lots) < 2 {
+			continue
+		}
+		sort.SliceStable(lots, func(i, j int) bool { return lots[i].Quantity < lots[j].Quantity })
+		for start := 0; start < len(lots); {
+			end := start + 1
+			for end < len(lots) && withinTolerance(lots[start].Quantity, lots[end].Quantity, tolerancePct) {
+				end++
+			}
+			if end-start > 1 {
+				report.Groups = append(report.Groups, duplicateGroup(lots[start:end]))
+			}
+			start = end
+		}
+	}
+
+	return report, nil
+}
+
+// MergeDuplicates consolidates lots recorded more than once into the lot to
+// keep (admin only); duplicateIdsJson lists the IDs of its duplicates, which
+// must share its owner, harvest date and waste type and must not have been
+// used by an extraction or recycling. Each duplicate is marked MERGED with
+// its own history intact, and its history entries are copied into the kept
+// lot's, labelled with the duplicate's ID. Open duplicate warnings of the
+// lots are resolved.
+func (s *SmartContract) MergeDuplicates(ctx contractapi.TransactionContextInterface, keepId string, duplicateIdsJson string) (*models.Waste, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	var duplicateIDs []string
+	if err := json.Unmarshal([]byte(duplicateIdsJson), &duplicateIDs); err != nil {
+		return nil, newError(ctx, ErrDuplicateIDsInvalid, err)
+	}
+	if len(duplicateIDs) == 0 {
+		return nil, newError(ctx, ErrDuplicatesRequired)
+	}
+
+	keep, err := s.readWaste(ctx, keepId)
+	if err != nil {
+		return nil, err
+	}
+	if keep.Status == models.WasteMerged {
+		return nil, newError(ctx, ErrWasteAlreadyMerged, keep.ID, keep.MergedInto)
+	}
+
+	extractions, err := s.GetAllExtractions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{keep.ID: true}
+	var duplicates []*models.Waste
+	for _, id := range duplicateIDs {
+		if seen[id] {
+			return nil, newError(ctx, ErrBlendInputDuplicate, id)
+		}
+		seen[id] = true
+		duplicate, err := s.readWaste(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if duplicate.Status == models.WasteMerged {
+			return nil, newError(ctx, ErrWasteAlreadyMerged, duplicate.ID, duplicate.MergedInto)
+		}
+		if harvestKey(duplicate) != harvestKey(keep) {
+			return nil, newError(ctx, ErrWasteHarvestMismatch, duplicate.ID, keep.ID)
+		}
+		if duplicate.Consumed > 0 || duplicate.PendingApprovalID != "" || duplicate.PendingSettlementID != "" {
+			return nil, newError(ctx, ErrWasteInUse, duplicate.ID)
+		}
+		for _, extraction := range extractions {
+			if extraction.WasteID == duplicate.ID && extraction.Status != models.RecordOrphaned {
+				return nil, newError(ctx, ErrWasteUsedByExtraction, duplicate.ID, extraction.ID)
+			}
+		}
+		for _, recycling := range recyclings {
+			if recycling.UsesWaste(duplicate.ID) && recycling.Status != models.RecordOrphaned {
+				return nil, newError(ctx, ErrWasteUsedByRecycling, duplicate.ID, recycling.ID)
+			}
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	staged := newStagedWrites(ctx)
+	resolution := fmt.Sprintf("Duplicates merged into %s", keep.ID)
+	for _, duplicate := range duplicates {
+		for _, entry := range duplicate.History {
+			entry.Details = fmt.Sprintf("[%s] %s", duplicate.ID, entry.Details)
+			keep.History = append(keep.History, entry)
+		}
+		resolveDuplicateWarnings(duplicate, actor, resolution, now)
+		duplicate.MergedInto = keep.ID
+		applyStatusChange(duplicate, models.WasteMerged, actor, fmt.Sprintf("Merged into %s as its duplicate", keep.ID), now)
+		staged.waste(duplicate)
+		keep.MergedFrom = append(keep.MergedFrom, duplicate.ID)
+	}
+	// Copied entries take their place in time among the kept lot's own
+	sort.SliceStable(keep.History, func(i, j int) bool {
+		return keep.History[i].Timestamp < keep.History[j].Timestamp
+	})
+	resolveDuplicateWarnings(keep, actor, resolution, now)
+	keep.UpdatedAt = now
+	keep.History = append(keep.History, models.History{
+		Timestamp: now,
+		Action:    "DUPLICATES_MERGED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Merged duplicates %s", strings.Join(duplicateIDs, ", ")),
+	})
+	staged.waste(keep)
+
+	if err := staged.commit(); err != nil {
+		return nil, err
+	}
+
+	return keep, nil
+}
+
+// duplicateWarning looks among the indexed lots of an organization's
+// harvest date for one the new lot seems to repeat: same owner, waste type
+// and plot, and a quantity within the duplicate tolerance. Lots recorded
+// before the index existed are only found by FindDuplicateWastes.
+func duplicateWarning(ctx contractapi.TransactionContextInterface, ownerMSP string, owner string, harvestDate string, wasteType string, plotID string, quantity float64, now string) (*models.ValidationWarning, error) {
+	participantID, err := lookupParticipantID(ctx, ownerMSP, owner)
+	if err != nil {
+		return nil, err
+	}
+	tolerancePct := configFloat(ctx, "duplicates", "quantityTolerancePct", defaultDuplicateTolerancePct)
+
+	var matches []string
+	err = partialKeyQuery(ctx, harvestIndex, []string{ownerMSP, harvestDate}, func(keyParts []string, _ []byte) error {
+		var lot models.Waste
+		found, err := newAssetStore(ctx).Get("WASTE_"+keyParts[2], &lot)
+		if err != nil {
+			return err
+		}
+		if !found || lot.Status == models.WasteMerged || lot.PlotID != plotID {
+			return nil
+		}
+		sameOwner := (participantID != "" && lot.ParticipantID == participantID) || (lot.ParticipantID == "" && lot.Owner == owner)
+		if !sameOwner || models.NormalizeWasteType(lot.Type) != models.NormalizeWasteType(wasteType) {
+			return nil
+		}
+		if withinTolerance(lot.Quantity, quantity, tolerancePct) || withinTolerance(quantity, lot.Quantity, tolerancePct) {
+			matches = append(matches, lot.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	warning := newValidationWarning(models.WarnDuplicate, fmt.Sprintf("same owner, type and harvest date as %s with a quantity within %.1f%%", strings.Join(matches, ", "), tolerancePct), now)
+	return &warning, nil
+}
+
+// indexHarvest stages the harvest index entry of a new lot
+func indexHarvest(staged *stagedWrites, waste *models.Waste) {
+	ctx := staged.ctx
+	staged.write(func() error {
+		indexKey, err := ctx.GetStub().CreateCompositeKey(harvestIndex, []string{waste.OwnerMSP, waste.HarvestDate, waste.ID})
+		if err != nil {
+			return err
+		}
+
+		return ctx.GetStub().PutState(indexKey, []byte{0x00})
+	})
+}
+
+// lookupParticipantID returns the participant ID registered for a name
+// within an organization, or "" when there is none yet
+func lookupParticipantID(ctx contractapi.TransactionContextInterface, mspID string, name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil
+	}
+	collection, err := residencyCollection(ctx, mspID)
+	if err != nil {
+		return "", err
+	}
+	idJSON, err := ctx.GetStub().GetPrivateData(collection, participantNameKey(mspID, name))
+	if err != nil {
+		return "", newError(ctx, ErrParticipantLookupFailed, err)
+	}
+
+	return string(idJSON), nil
+}
+
+// harvestKey identifies the harvest a lot records: its organization, owner,
+// harvest date, waste type and plot
+func harvestKey(waste *models.Waste) string {
+	owner := waste.ParticipantID
+	if owner == "" {
+		owner = waste.Owner
+	}
+
+	return strings.Join([]string{waste.OwnerMSP, owner, waste.HarvestDate, models.NormalizeWasteType(waste.Type), waste.PlotID}, "|")
+}
+
+// withinTolerance reports whether larger lies at most tolerancePct above
+// smaller
+func withinTolerance(smaller float64, larger float64, tolerancePct float64) bool {
+	if smaller <= 0 {
+		return larger == smaller
+	}
+
+	return (larger-smaller)/smaller*100 <= tolerancePct
+}
+
+// duplicateGroup describes lots sorted by increasing quantity
+func duplicateGroup(lots []*models.Waste) models.DuplicateGroup {
+	first, last := lots[0], lots[len(lots)-1]
+	group := models.DuplicateGroup{
+		OwnerMSP:    first.OwnerMSP,
+		Owner:       first.Owner,
+		HarvestDate: first.HarvestDate,
+		Type:        first.Type,
+		PlotID:      first.PlotID,
+	}
+	for _, lot := range lots {
+		group.WasteIDs = append(group.WasteIDs, lot.ID)
+		group.Quantities = append(group.Quantities, lot.Quantity)
+	}
+	if first.Quantity > 0 {
+		group.SpreadPct = math.Round((last.Quantity-first.Quantity)/first.Quantity*10000) / 100
+	}
+
+	return group
+}
+
+// resolveDuplicateWarnings closes the open duplicate warnings of a lot
+func resolveDuplicateWarnings(waste *models.Waste, actor string, resolution string, now string) {
+	for i := range waste.Warnings {
+		warning := &waste.Warnings[i]
+		if warning.Code == models.WarnDuplicate && !warning.Resolved {
+			warning.Resolved = true
+			warning.ResolvedBy = actor
+			warning.ResolvedAt = now
+			warning.Resolution = resolution
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package contract
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultEmbargoHours is how long a new lot stays hidden from other
+// organizations unless config embargo.hours says otherwise
+const defaultEmbargoHours = 48
+
+// SetWasteEmbargo hides a lot from other organizations for hours from now,
+// whatever agreements or delegations they hold; 0 lifts the embargo. The
+// owning organization or an admin only; a non-zero expectedVersion guards
+// against concurrent updates.
+func (s *SmartContract) SetWasteEmbargo(ctx contractapi.TransactionContextInterface, wasteId string, hours int, expectedVersion int) (*models.Waste, error) {
+	if hours < 0 {
+		return nil, newError(ctx, ErrEmbargoHoursNegative)
+	}
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrEmbargoForbidden, waste.OwnerMSP, wasteId)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	until, err := embargoEnd(ctx, now, hours)
+	if err != nil {
+		return nil, err
+	}
+	entry := models.History{
+		Timestamp: now,
+		Action:    "EMBARGO_SET",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Hidden from other organizations until %s", until),
+	}
+	if until == "" {
+		entry.Action = "EMBARGO_LIFTED"
+		entry.Details = "Visible to other organizations under the sharing rules"
+	}
+	waste.EmbargoUntil = until
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, entry)
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// newWasteEmbargo returns the end of the embargo of a lot created at now,
+// or "" when config embargo.hours disables embargoes
+func newWasteEmbargo(ctx contractapi.TransactionContextInterface, now string) (string, error) {
+	return embargoEnd(ctx, now, configInt(ctx, "embargo", "hours", defaultEmbargoHours))
+}
+
+// embargoEnd returns the timestamp hours after now, or "" for no embargo
+func embargoEnd(ctx contractapi.TransactionContextInterface, now string, hours int) (string, error) {
+	if hours <= 0 {
+		return "", nil
+	}
+	start, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return "", newError(ctx, ErrTimestampInvalid, now, err)
+	}
+
+	return start.Add(time.Duration(hours) * time.Hour).UTC().Format(time.RFC3339), nil
+}
+
+// embargoed reports whether a lot is still hidden from other organizations
+// at now
+func embargoed(waste *models.Waste, now string) bool {
+	return waste.EmbargoUntil != "" && now < waste.EmbargoUntil
+}
@@ -0,0 +1,187 @@
+package contract
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// sealKeyTransient is the transient entry carrying the base64 AES-256 key of
+// a sealed field; the key is used to check or open the ciphertext and is
+// never written to the ledger
+const sealKeyTransient = "sealKey"
+
+// sealedFieldName limits the names sealed fields may be stored under
+var sealedFieldName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,63}$`)
+
+// SealWasteField stores a value the client encrypted itself, for data too
+// sensitive even for the private collections. ciphertext is the base64
+// nonce and AES-256-GCM output, with "<wasteId>/<field>" as additional
+// data so it cannot be moved to another lot or field. The key comes in the
+// "sealKey" transient entry: the chaincode checks that it opens the
+// ciphertext and keeps only its fingerprint. The owning organization or an
+// admin only; a non-zero expectedVersion guards against concurrent updates.
+func (s *SmartContract) SealWasteField(ctx contractapi.TransactionContextInterface, wasteId string, field string, ciphertext string, expectedVersion int) (*models.Waste, error) {
+	if !sealedFieldName.MatchString(field) {
+		return nil, newError(ctx, ErrSealedFieldInvalid, field)
+	}
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrSealForbidden, waste.OwnerMSP, wasteId)
+	}
+
+	key, err := sealKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := openSealed(ctx, key, ciphertext, wasteId, field); err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := keyFingerprint(key)
+	if waste.Sealed == nil {
+		waste.Sealed = map[string]*models.SealedField{}
+	}
+	waste.Sealed[field] = &models.SealedField{
+		Ciphertext:     ciphertext,
+		Algorithm:      models.SealAlgorithm,
+		KeyFingerprint: fingerprint,
+		SealedBy:       actor,
+		SealedAt:       now,
+	}
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "FIELD_SEALED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Field %s sealed with key %s", field, fingerprint),
+	})
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// ReadSealedWasteField decrypts a sealed field with the key supplied in the
+// "sealKey" transient entry, which must match the stored fingerprint. Meant
+// to be evaluated rather than submitted, so that the plaintext is not
+// recorded in a block. The owning organization or an admin only.
+func (s *SmartContract) ReadSealedWasteField(ctx contractapi.TransactionContextInterface, wasteId string, field string) (*models.UnsealedField, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrSealedFieldsRestricted, wasteId, waste.OwnerMSP)
+	}
+	sealed, ok := waste.Sealed[field]
+	if !ok {
+		return nil, newError(ctx, ErrSealedFieldNotFound, wasteId, field)
+	}
+
+	key, err := sealKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := keyFingerprint(key)
+	if fingerprint != sealed.KeyFingerprint {
+		return nil, newError(ctx, ErrSealingKeyMismatch, field, wasteId, sealed.KeyFingerprint, fingerprint)
+	}
+	plaintext, err := openSealed(ctx, key, sealed.Ciphertext, wasteId, field)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UnsealedField{
+		WasteID:        wasteId,
+		Field:          field,
+		Value:          string(plaintext),
+		KeyFingerprint: fingerprint,
+	}, nil
+}
+
+// sealKey returns the AES-256 key of the "sealKey" transient entry
+func sealKey(ctx contractapi.TransactionContextInterface) ([]byte, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	encoded, ok := transient[sealKeyTransient]
+	if !ok {
+		return nil, newError(ctx, ErrSealingKeyRequired, sealKeyTransient)
+	}
+	key, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, newError(ctx, ErrTransientDataInvalid, sealKeyTransient, err)
+	}
+	if len(key) != 32 {
+		return nil, newError(ctx, ErrSealingKeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// openSealed decrypts a base64 nonce-and-ciphertext bound to a lot's field
+func openSealed(ctx contractapi.TransactionContextInterface, key []byte, ciphertext string, wasteId string, field string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, newError(ctx, ErrCiphertextInvalid, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, newError(ctx, ErrCiphertextTooShort, field)
+	}
+
+	nonce, sealed := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, []byte(wasteId+"/"+field))
+	if err != nil {
+		return nil, newError(ctx, ErrCiphertextKeyMismatch, field)
+	}
+
+	return plaintext, nil
+}
+
+// keyFingerprint identifies a key without revealing it
+func keyFingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
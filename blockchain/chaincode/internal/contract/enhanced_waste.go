@@ -0,0 +1,1085 @@
+// Package contract implements the transactions of the waste chaincode
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/chaincode/internal/store"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SmartContract manages all olive waste operations
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// NewSmartContract returns the contract with its transaction hooks set
+func NewSmartContract() *SmartContract {
+	s := &SmartContract{}
+	s.TransactionContextHandler = new(TransactionContext)
+	s.BeforeTransaction = beginTransaction
+	s.AfterTransaction = endTransaction
+
+	return s
+}
+
+// InitLedger initializes the ledger with sample data
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	fmt.Println("Initializing Green Olive Chain ledger...")
+
+	// Sample waste data
+	wastes := []models.Waste{
+		{
+			ID:          "waste1",
+			Type:        "Olive Branches",
+			Quantity:    50.5,
+			HarvestDate: "2025-06-01",
+			Status:      "COLLECTED",
+			Owner:       "farmer1",
+			Farm:        "Olive Farm Alpha",
+			Location:    "Andalusia, Spain",
+			CreatedAt:   time.Now().Format(time.RFC3339),
+			UpdatedAt:   time.Now().Format(time.RFC3339),
+			History: []models.History{
+				{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Action:    "CREATED",
+					Actor:     "farmer1",
+					Details:   "Initial waste collection",
+				},
+			},
+		},
+	}
+
+	for _, waste := range wastes {
+		if err := newAssetStore(ctx).Put("WASTE_"+waste.ID, waste); err != nil {
+			return newError(ctx, ErrLedgerWrite, "WASTE_"+waste.ID, err)
+		}
+	}
+
+	fmt.Println("Ledger initialized successfully")
+	return nil
+}
+
+// CreateWaste adds new waste to the blockchain and returns it; the ID is
+// generated when id is empty and plotId optionally names the registered
+// plot the lot was harvested on. Minor issues such as a missing farm do not
+// block the lot but are recorded in its warnings.
+func (s *SmartContract) CreateWaste(ctx contractapi.TransactionContextInterface, id string, wasteType string, quantity float64, harvestDate string, owner string, farm string, location string, plotId string) (*models.Waste, error) {
+	staged := newStagedWrites(ctx)
+	waste, _, err := s.buildWaste(ctx, staged, id, wasteType, quantity, harvestDate, owner, farm, location, plotId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.commitNewWaste(ctx, staged, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// commitNewWaste stores a lot built by buildWaste along with the writes
+// staged for it, and records its valuation and quota usage
+func (s *SmartContract) commitNewWaste(ctx contractapi.TransactionContextInterface, staged *stagedWrites, waste *models.Waste) error {
+	staged.waste(waste)
+	if err := staged.commit(); err != nil {
+		return err
+	}
+	if err := recordValuation(ctx, waste, models.ValuationCreated, waste.Quantity, 0, ""); err != nil {
+		return err
+	}
+
+	return recordQuotaUsage(ctx, waste)
+}
+
+// buildWaste validates creation arguments and returns the waste that
+// CreateWaste would store, along with non-blocking warnings
+func (s *SmartContract) buildWaste(ctx contractapi.TransactionContextInterface, staged *stagedWrites, id string, wasteType string, quantity float64, harvestDate string, owner string, farm string, location string, plotId string) (*models.Waste, []string, error) {
+	ownerMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.buildWasteFor(ctx, staged, ownerMSP, id, wasteType, quantity, harvestDate, owner, farm, location, plotId)
+}
+
+// buildWasteFor is buildWaste for a lot owned by ownerMSP, whose plots,
+// required fields and campaigns apply
+func (s *SmartContract) buildWasteFor(ctx contractapi.TransactionContextInterface, staged *stagedWrites, ownerMSP string, id string, wasteType string, quantity float64, harvestDate string, owner string, farm string, location string, plotId string) (*models.Waste, []string, error) {
+	if wasteType == "" {
+		return nil, nil, newError(ctx, ErrWasteTypeRequired)
+	}
+	if quantity <= 0 {
+		return nil, nil, newError(ctx, ErrQuantityInvalid)
+	}
+	if _, err := time.Parse("2006-01-02", harvestDate); err != nil {
+		return nil, nil, newError(ctx, ErrHarvestDate, harvestDate)
+	}
+	owner, farm, location, err := personalData(ctx, owner, farm, location)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := requireActiveOwner(ctx, ownerMSP, owner); err != nil {
+		return nil, nil, err
+	}
+	if id == "" {
+		generated, err := newAssetID(ctx, "WASTE")
+		if err != nil {
+			return nil, nil, err
+		}
+		id = generated
+	}
+
+	// Check if waste already exists
+	exists, err := s.WasteExists(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exists {
+		return nil, nil, newError(ctx, ErrWasteExists, id)
+	}
+
+	var plot *models.Plot
+	if plotId != "" {
+		if plot, farm, err = checkWastePlot(ctx, plotId, farm, ownerMSP); err != nil {
+			return nil, nil, err
+		}
+	}
+	err = checkRequiredFields(ctx, ownerMSP, map[string]string{
+		models.FieldFarm:     farm,
+		models.FieldLocation: location,
+		models.FieldPlot:     plotId,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Attach the lot to the organization's campaign covering the harvest date
+	campaign, err := findCampaign(ctx, ownerMSP, harvestDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	campaignID := ""
+	if campaign != nil {
+		if campaign.Status == models.CampaignClosed {
+			return nil, nil, newError(ctx, ErrCampaignClosed, campaign.ID)
+		}
+		campaignID = campaign.ID
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var issues []models.ValidationWarning
+	if farm == "" {
+		issues = append(issues, newValidationWarning(models.WarnFarmMissing, "farm is not set", now))
+	}
+	if location == "" {
+		issues = append(issues, newValidationWarning(models.WarnLocationMissing, "location is not set", now))
+	}
+	if issue := yieldWarning(ctx, plot, quantity, now); issue != nil {
+		issues = append(issues, *issue)
+	}
+	issue, err := checkFarmQuota(ctx, farm, harvestDate, quantity, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if issue != nil {
+		issues = append(issues, *issue)
+	}
+	if issue, err = duplicateWarning(ctx, ownerMSP, owner, harvestDate, wasteType, plotId, quantity, now); err != nil {
+		return nil, nil, err
+	}
+	if issue != nil {
+		issues = append(issues, *issue)
+	}
+
+	taxonomy, err := loadTaxonomy(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	category, subtype := taxonomy.Classify(wasteType)
+	if category == models.Uncategorized {
+		issues = append(issues, newValidationWarning(models.WarnTypeUnmapped, fmt.Sprintf("waste type %q is not mapped to the taxonomy", wasteType), now))
+	}
+	embargoUntil, err := newWasteEmbargo(ctx, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	reference, err := claimReference(ctx, "WASTE", id)
+	if err != nil {
+		return nil, nil, err
+	}
+	staged.reference(reference)
+
+	// Create new waste
+	waste := &models.Waste{
+		ID:           id,
+		Reference:    reference.entry.Reference,
+		Type:         wasteType,
+		Category:     category,
+		Subtype:      subtype,
+		Quantity:     quantity,
+		HarvestDate:  harvestDate,
+		Status:       "COLLECTED",
+		Owner:        owner,
+		OwnerMSP:     ownerMSP,
+		Farm:         farm,
+		Location:     location,
+		PlotID:       plotId,
+		CampaignID:   campaignID,
+		QualityGrade: qualityGrades[0],
+		EmbargoUntil: embargoUntil,
+		Warnings:     issues,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "CREATED",
+				Actor:     owner,
+				Details:   fmt.Sprintf("Waste collected: %s, Quantity: %.2f", wasteType, quantity),
+			},
+		},
+	}
+
+	indexHarvest(staged, waste)
+
+	return waste, warningMessages(issues), nil
+}
+
+// ReadWaste returns the waste stored in the world state with given id,
+// redacted when the caller's organization may not see it in full
+func (s *SmartContract) ReadWaste(ctx contractapi.TransactionContextInterface, id string) (*models.Waste, error) {
+	waste, err := s.readWaste(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return viewer.view(ctx, waste)
+}
+
+// readWaste loads a waste item without applying visibility rules
+func (s *SmartContract) readWaste(ctx contractapi.TransactionContextInterface, id string) (*models.Waste, error) {
+	var waste models.Waste
+	found, err := newAssetStore(ctx).Get("WASTE_"+id, &waste)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "WASTE_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrWasteNotFound, id)
+	}
+
+	return &waste, nil
+}
+
+// putWaste bumps the version of a waste item, serializes it and writes it to
+// the world state, moving any personal data to the private collection first
+func (s *SmartContract) putWaste(ctx contractapi.TransactionContextInterface, waste *models.Waste) error {
+	if _, err := checkWaste(ctx, waste); err != nil {
+		return err
+	}
+
+	return writeWaste(ctx, waste)
+}
+
+// checkWaste applies the validation rules to a waste item about to be
+// written, records the warnings of the failing WARN rules on it and returns
+// those it did not carry yet
+func checkWaste(ctx contractapi.TransactionContextInterface, waste *models.Waste) ([]models.ValidationWarning, error) {
+	warnings, err := applyValidationRules(ctx, "WASTE", waste.ID, waste)
+	if err != nil {
+		return nil, err
+	}
+	var recorded []models.ValidationWarning
+	for _, warning := range warnings {
+		if !hasOpenWarning(waste, warning.Code) {
+			waste.Warnings = append(waste.Warnings, warning)
+			recorded = append(recorded, warning)
+		}
+	}
+
+	return recorded, nil
+}
+
+// writeWaste writes a checked waste item to the world state, its personal
+// data to the private collection
+func writeWaste(ctx contractapi.TransactionContextInterface, waste *models.Waste) error {
+	if err := pseudonymizeWaste(ctx, waste); err != nil {
+		return err
+	}
+
+	var err error
+	if waste.History, err = recordFieldChanges(ctx, "WASTE_"+waste.ID, waste, waste.History, waste.ArchivedHistory); err != nil {
+		return err
+	}
+	if waste.History, waste.ArchivedHistory, err = compactHistory(ctx, "WASTE", waste.ID, waste.History, waste.ArchivedHistory); err != nil {
+		return err
+	}
+
+	waste.Version++
+	if err := newAssetStore(ctx).Put("WASTE_"+waste.ID, waste); err != nil {
+		return err
+	}
+
+	return recordChange(ctx, "WASTE", waste.ID, waste.Version)
+}
+
+// txTimestamp returns the transaction timestamp formatted as RFC3339, which
+// unlike the local clock is identical on every endorsing peer
+func txTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", newError(ctx, ErrTimestamp, err)
+	}
+
+	return time.Unix(ts.GetSeconds(), int64(ts.GetNanos())).UTC().Format(time.RFC3339), nil
+}
+
+// UpdateWasteStatus updates the status of a waste item and returns it;
+// reasonCode is one of the reasons defined for newStatus, required when its
+// catalog says so. A non-zero expectedVersion rejects the update if the
+// waste has changed since it was read.
+func (s *SmartContract) UpdateWasteStatus(ctx contractapi.TransactionContextInterface, id string, newStatus string, actor string, details string, reasonCode string, expectedVersion int) (*models.Waste, error) {
+	waste, _, err := s.buildWasteStatusUpdate(ctx, id, newStatus, actor, details, reasonCode, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// buildWasteStatusUpdate returns the waste as UpdateWasteStatus would store it
+func (s *SmartContract) buildWasteStatusUpdate(ctx contractapi.TransactionContextInterface, id string, newStatus string, actor string, details string, reasonCode string, expectedVersion int) (*models.Waste, []string, error) {
+	if newStatus == "" {
+		return nil, nil, newError(ctx, ErrStatusRequired)
+	}
+
+	waste, err := s.readWaste(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+id, waste.Version, expectedVersion); err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []string
+	if waste.Status == newStatus {
+		warnings = append(warnings, fmt.Sprintf("waste %s is already %s", id, newStatus))
+	} else if err := checkTransitionChecklist(ctx, id, newStatus); err != nil {
+		return nil, nil, err
+	} else if err := checkStatusReason(ctx, id, newStatus, reasonCode); err != nil {
+		return nil, nil, err
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	applyStatusChange(waste, newStatus, actor, details, now)
+	waste.History[len(waste.History)-1].ReasonCode = reasonCode
+
+	return waste, warnings, nil
+}
+
+// applyStatusChange sets a new status on a waste item and records it in its history
+func applyStatusChange(waste *models.Waste, newStatus string, actor string, details string, now string) {
+	// Update status
+	oldStatus := waste.Status
+	waste.Status = newStatus
+	waste.UpdatedAt = now
+
+	// Add to history
+	historyEntry := models.History{
+		Timestamp: now,
+		Action:    "STATUS_CHANGED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Status changed from %s to %s. %s", oldStatus, newStatus, details),
+	}
+	waste.History = append(waste.History, historyEntry)
+}
+
+// CreateExtraction records extraction process and returns the stored record;
+// the ID is generated when id is empty
+func (s *SmartContract) CreateExtraction(ctx contractapi.TransactionContextInterface, id string, wasteId string, productType string, quantity float64, quality string, processor string, facilityId string) (*models.Extraction, error) {
+	return s.storeExtraction(ctx, id, wasteId, singleOutput(productType, quantity, quality), processor, facilityId)
+}
+
+// storeExtraction builds an extraction with the given output lines and writes
+// it together with the updated source waste, once both passed validation
+func (s *SmartContract) storeExtraction(ctx contractapi.TransactionContextInterface, id string, wasteId string, outputs []models.ExtractionOutput, processor string, facilityId string) (*models.Extraction, error) {
+	staged := newStagedWrites(ctx)
+	extraction, waste, _, err := s.buildExtraction(ctx, staged, id, wasteId, outputs, processor, facilityId)
+	if err != nil {
+		return nil, err
+	}
+
+	staged.extraction(extraction)
+	if err := s.issueCompletionCertificate(ctx, staged, waste, extractionOutput(extraction)); err != nil {
+		return nil, err
+	}
+	staged.waste(waste)
+	if err := staged.commit(); err != nil {
+		return nil, err
+	}
+
+	return extraction, nil
+}
+
+// buildExtraction validates an extraction and returns it together with the
+// source waste as CreateExtraction would store them; the reference it
+// numbers is staged
+func (s *SmartContract) buildExtraction(ctx contractapi.TransactionContextInterface, staged *stagedWrites, id string, wasteId string, outputs []models.ExtractionOutput, processor string, facilityId string) (*models.Extraction, *models.Waste, []string, error) {
+	outputs, quantity, err := numberOutputs(ctx, outputs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	productType := outputs[0].ProductType
+	if id == "" {
+		generated, err := newAssetID(ctx, "EXTRACTION")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		id = generated
+	}
+
+	// Verify waste exists
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Check if extraction already exists
+	exists, err := newAssetStore(ctx).Exists("EXTRACTION_" + id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if exists {
+		return nil, nil, nil, newError(ctx, ErrExtractionExists, id)
+	}
+
+	balance, warnings, err := checkMassBalance(ctx, waste, outputs, quantity)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if waste.Status == "PROCESSED" || waste.Status == "RECYCLED" {
+		warnings = append(warnings, fmt.Sprintf("waste %s is already %s", wasteId, waste.Status))
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	capacityWarnings, err := s.checkFacility(ctx, facilityId, models.FacilityExtraction, quantity, now)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	warnings = append(warnings, capacityWarnings...)
+	reference, err := claimReference(ctx, "EXTRACTION", id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	staged.reference(reference)
+
+	// Create extraction record
+	extraction := &models.Extraction{
+		ID:             id,
+		Reference:      reference.entry.Reference,
+		WasteID:        wasteId,
+		ProductType:    productType,
+		Quantity:       quantity,
+		Quality:        outputs[0].Quality,
+		QualityGrade:   gradeOrDefault(waste.QualityGrade),
+		Outputs:        outputs,
+		MassBalance:    balance,
+		ExtractionDate: now,
+		Processor:      processor,
+		FacilityID:     facilityId,
+		Status:         "PROCESSED",
+		CreatedAt:      now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "EXTRACTED",
+				Actor:     processor,
+				Details:   fmt.Sprintf("Extracted %s (%.2f units) from waste %s at facility %s", describeOutputs(outputs), quantity, wasteId, facilityId),
+			},
+		},
+	}
+	for _, warning := range capacityWarnings {
+		extraction.History = append(extraction.History, models.History{
+			Timestamp: now,
+			Action:    "CAPACITY_EXCEEDED",
+			Actor:     processor,
+			Details:   warning,
+		})
+	}
+
+	// The run starts with the grade of its source lot
+	processorMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	extraction.Grading = []models.GradeRecord{
+		{
+			Grade:     extraction.QualityGrade,
+			Kind:      models.GradeInherited,
+			Grader:    processor,
+			GraderMSP: processorMSP,
+			Evidence:  "WASTE_" + wasteId,
+			GradedAt:  now,
+		},
+	}
+
+	applyStatusChange(waste, "PROCESSED", processor, fmt.Sprintf("Used for %s extraction", productType), now)
+	completeSLA(waste, now)
+	if _, err := applyTransitionRules(ctx, "EXTRACTION", extraction.ID, extraction.Status, waste); err != nil {
+		return nil, nil, nil, err
+	}
+	indexExtractionLink(staged, extraction)
+
+	return extraction, waste, warnings, nil
+}
+
+// putExtraction bumps the version of an extraction record, serializes it and writes it to the world state
+func (s *SmartContract) putExtraction(ctx contractapi.TransactionContextInterface, extraction *models.Extraction) error {
+	if _, err := checkExtraction(ctx, extraction); err != nil {
+		return err
+	}
+
+	return writeExtraction(ctx, extraction)
+}
+
+// checkExtraction applies the validation rules to a extraction record about
+// to be written and returns the warnings of the failing WARN rules
+func checkExtraction(ctx contractapi.TransactionContextInterface, extraction *models.Extraction) ([]models.ValidationWarning, error) {
+	return applyValidationRules(ctx, "EXTRACTION", extraction.ID, extraction)
+}
+
+// writeExtraction writes a checked extraction record to the world state
+func writeExtraction(ctx contractapi.TransactionContextInterface, extraction *models.Extraction) error {
+	var err error
+	if extraction.History, err = recordFieldChanges(ctx, "EXTRACTION_"+extraction.ID, extraction, extraction.History, extraction.ArchivedHistory); err != nil {
+		return err
+	}
+	if extraction.History, extraction.ArchivedHistory, err = compactHistory(ctx, "EXTRACTION", extraction.ID, extraction.History, extraction.ArchivedHistory); err != nil {
+		return err
+	}
+
+	extraction.Version++
+	if err := newAssetStore(ctx).Put("EXTRACTION_"+extraction.ID, extraction); err != nil {
+		return err
+	}
+
+	return recordChange(ctx, "EXTRACTION", extraction.ID, extraction.Version)
+}
+
+// GetExtraction returns the extraction record stored with the given id
+func (s *SmartContract) GetExtraction(ctx contractapi.TransactionContextInterface, id string) (*models.Extraction, error) {
+	return s.readExtraction(ctx, id)
+}
+
+// readExtraction loads an extraction record from the world state
+func (s *SmartContract) readExtraction(ctx contractapi.TransactionContextInterface, id string) (*models.Extraction, error) {
+	var extraction models.Extraction
+	found, err := newAssetStore(ctx).Get("EXTRACTION_"+id, &extraction)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "EXTRACTION_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrExtractionMissing, id)
+	}
+
+	return &extraction, nil
+}
+
+// CreateRecycling records recycling process and returns the stored record;
+// the ID is generated when id is empty
+func (s *SmartContract) CreateRecycling(ctx contractapi.TransactionContextInterface, id string, wasteId string, recycledProduct string, quantity float64, method string, recycler string, facilityId string) (*models.Recycling, error) {
+	staged := newStagedWrites(ctx)
+	recycling, waste, _, err := s.buildRecycling(ctx, staged, id, wasteId, recycledProduct, quantity, method, recycler, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	waste.Consumed += quantity
+
+	staged.recycling(recycling)
+	created, _ := recyclingOutput(recycling, wasteId)
+	if err := s.issueCompletionCertificate(ctx, staged, waste, created); err != nil {
+		return nil, err
+	}
+	staged.waste(waste)
+	if err := staged.commit(); err != nil {
+		return nil, err
+	}
+
+	return recycling, nil
+}
+
+// buildRecycling validates a recycling record and returns it together with
+// the source waste as CreateRecycling would store them; the reference it
+// numbers is staged
+func (s *SmartContract) buildRecycling(ctx contractapi.TransactionContextInterface, staged *stagedWrites, id string, wasteId string, recycledProduct string, quantity float64, method string, recycler string, facilityId string) (*models.Recycling, *models.Waste, []string, error) {
+	if quantity <= 0 {
+		return nil, nil, nil, newError(ctx, ErrQuantityInvalid)
+	}
+	if id == "" {
+		generated, err := newAssetID(ctx, "RECYCLING")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		id = generated
+	}
+
+	// Verify waste exists
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Check if recycling already exists
+	exists, err := newAssetStore(ctx).Exists("RECYCLING_" + id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if exists {
+		return nil, nil, nil, newError(ctx, ErrRecyclingExists, id)
+	}
+
+	var warnings []string
+	if quantity > waste.Quantity {
+		warnings = append(warnings, fmt.Sprintf("recycled quantity %.2f exceeds waste quantity %.2f", quantity, waste.Quantity))
+	}
+	if waste.Status == "RECYCLED" {
+		warnings = append(warnings, fmt.Sprintf("waste %s is already RECYCLED", wasteId))
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	capacityWarnings, err := s.checkFacility(ctx, facilityId, models.FacilityRecycling, quantity, now)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	warnings = append(warnings, capacityWarnings...)
+
+	catalogMethod, err := s.checkRecyclingMethod(ctx, method, facilityId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	method = catalogMethod.Code
+	reference, err := claimReference(ctx, "RECYCLING", id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	staged.reference(reference)
+
+	// Create recycling record; the method's factor is copied so later catalog
+	// edits do not rewrite past carbon figures
+	recycling := &models.Recycling{
+		ID:              id,
+		Reference:       reference.entry.Reference,
+		WasteID:         wasteId,
+		RecycledProduct: recycledProduct,
+		Quantity:        quantity,
+		Method:          method,
+		EmissionFactor:  catalogMethod.EmissionFactor,
+		CO2eAvoided:     quantity * catalogMethod.EmissionFactor,
+		RecyclingDate:   now,
+		Recycler:        recycler,
+		FacilityID:      facilityId,
+		ExpectedEnd:     catalogMethod.ExpectedCompletion(now),
+		Status:          "COMPLETED",
+		CreatedAt:       now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "RECYCLED",
+				Actor:     recycler,
+				Details:   fmt.Sprintf("Recycled waste %s into %s (%.2f units) using %s at facility %s", wasteId, recycledProduct, quantity, method, facilityId),
+			},
+		},
+	}
+	for _, warning := range capacityWarnings {
+		recycling.History = append(recycling.History, models.History{
+			Timestamp: now,
+			Action:    "CAPACITY_EXCEEDED",
+			Actor:     recycler,
+			Details:   warning,
+		})
+	}
+
+	applyStatusChange(waste, "RECYCLED", recycler, fmt.Sprintf("Recycled into %s using %s", recycledProduct, method), now)
+	if _, err := applyTransitionRules(ctx, "RECYCLING", recycling.ID, recycling.Status, waste); err != nil {
+		return nil, nil, nil, err
+	}
+	indexRecyclingLinks(staged, recycling)
+
+	return recycling, waste, warnings, nil
+}
+
+// putRecycling bumps the version of a recycling record, serializes it and writes it to the world state
+func (s *SmartContract) putRecycling(ctx contractapi.TransactionContextInterface, recycling *models.Recycling) error {
+	if _, err := checkRecycling(ctx, recycling); err != nil {
+		return err
+	}
+
+	return writeRecycling(ctx, recycling)
+}
+
+// checkRecycling applies the validation rules to a recycling record about to
+// be written and returns the warnings of the failing WARN rules
+func checkRecycling(ctx contractapi.TransactionContextInterface, recycling *models.Recycling) ([]models.ValidationWarning, error) {
+	return applyValidationRules(ctx, "RECYCLING", recycling.ID, recycling)
+}
+
+// writeRecycling writes a checked recycling record to the world state
+func writeRecycling(ctx contractapi.TransactionContextInterface, recycling *models.Recycling) error {
+	var err error
+	if recycling.History, err = recordFieldChanges(ctx, "RECYCLING_"+recycling.ID, recycling, recycling.History, recycling.ArchivedHistory); err != nil {
+		return err
+	}
+	if recycling.History, recycling.ArchivedHistory, err = compactHistory(ctx, "RECYCLING", recycling.ID, recycling.History, recycling.ArchivedHistory); err != nil {
+		return err
+	}
+
+	recycling.Version++
+	if err := newAssetStore(ctx).Put("RECYCLING_"+recycling.ID, recycling); err != nil {
+		return err
+	}
+
+	return recordChange(ctx, "RECYCLING", recycling.ID, recycling.Version)
+}
+
+// GetRecycling returns the recycling record stored with the given id
+func (s *SmartContract) GetRecycling(ctx contractapi.TransactionContextInterface, id string) (*models.Recycling, error) {
+	var recycling models.Recycling
+	found, err := newAssetStore(ctx).Get("RECYCLING_"+id, &recycling)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "RECYCLING_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrRecyclingNotFound, id)
+	}
+
+	return &recycling, nil
+}
+
+// GetAllWastes returns all waste items
+func (s *SmartContract) GetAllWastes(ctx contractapi.TransactionContextInterface) ([]*models.Waste, error) {
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, waste := range wastes {
+		if wastes[i], err = viewer.view(ctx, waste); err != nil {
+			return nil, err
+		}
+	}
+
+	return wastes, nil
+}
+
+// defaultWastePageSize is used when GetWastesPage, GetExtractionsPage or
+// GetRecyclingsPage gets no page size
+const defaultWastePageSize = 100
+
+// GetWastesPage returns a page of the wastes in ID order, redacted like
+// GetAllWastes; pass the returned bookmark to get the next page. Clients
+// streaming large ledgers use it where GetAllWastes would exceed the
+// message size limits.
+func (s *SmartContract) GetWastesPage(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*models.WastePage, error) {
+	if pageSize <= 0 {
+		pageSize = defaultWastePageSize
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.WastePage{Wastes: []*models.Waste{}}
+	err = newAssetStore(ctx).Range("WASTE_"+bookmark, "WASTE_~", func(_ string, value []byte) error {
+		var waste models.Waste
+		if err := json.Unmarshal(value, &waste); err != nil {
+			return err
+		}
+		if bookmark != "" && waste.ID == bookmark {
+			return nil
+		}
+		if len(page.Wastes) == pageSize {
+			page.Bookmark = page.Wastes[pageSize-1].ID
+			return store.ErrStopRange
+		}
+		visible, err := viewer.view(ctx, &waste)
+		if err != nil {
+			return err
+		}
+		page.Wastes = append(page.Wastes, visible)
+
+		return nil
+	})
+	if budgetExceeded(err) && len(page.Wastes) > 0 {
+		page.Bookmark = page.Wastes[len(page.Wastes)-1].ID
+		page.Truncated = len(page.Wastes) < pageSize
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// loadWastes reads every waste item without applying visibility rules
+func loadWastes(ctx contractapi.TransactionContextInterface) ([]*models.Waste, error) {
+	var wastes []*models.Waste
+	err := newAssetStore(ctx).Range("WASTE_", "WASTE_~", func(_ string, value []byte) error {
+		var waste models.Waste
+		if err := json.Unmarshal(value, &waste); err != nil {
+			return err
+		}
+		wastes = append(wastes, &waste)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wastes, nil
+}
+
+// GetAllExtractions returns all extraction records
+func (s *SmartContract) GetAllExtractions(ctx contractapi.TransactionContextInterface) ([]*models.Extraction, error) {
+	var extractions []*models.Extraction
+	err := newAssetStore(ctx).Range("EXTRACTION_", "EXTRACTION_~", func(_ string, value []byte) error {
+		var extraction models.Extraction
+		if err := json.Unmarshal(value, &extraction); err != nil {
+			return err
+		}
+		extractions = append(extractions, &extraction)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return extractions, nil
+}
+
+// GetAllRecyclings returns all recycling records
+func (s *SmartContract) GetAllRecyclings(ctx contractapi.TransactionContextInterface) ([]*models.Recycling, error) {
+	var recyclings []*models.Recycling
+	err := newAssetStore(ctx).Range("RECYCLING_", "RECYCLING_~", func(_ string, value []byte) error {
+		var recycling models.Recycling
+		if err := json.Unmarshal(value, &recycling); err != nil {
+			return err
+		}
+		recyclings = append(recyclings, &recycling)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recyclings, nil
+}
+
+// GetExtractionsPage returns a page of the extractions in ID order; pass
+// the returned bookmark to get the next page
+func (s *SmartContract) GetExtractionsPage(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*models.ExtractionPage, error) {
+	if pageSize <= 0 {
+		pageSize = defaultWastePageSize
+	}
+
+	page := &models.ExtractionPage{Extractions: []*models.Extraction{}}
+	err := newAssetStore(ctx).Range("EXTRACTION_"+bookmark, "EXTRACTION_~", func(_ string, value []byte) error {
+		var extraction models.Extraction
+		if err := json.Unmarshal(value, &extraction); err != nil {
+			return err
+		}
+		if bookmark != "" && extraction.ID == bookmark {
+			return nil
+		}
+		if len(page.Extractions) == pageSize {
+			page.Bookmark = page.Extractions[pageSize-1].ID
+			return store.ErrStopRange
+		}
+		page.Extractions = append(page.Extractions, &extraction)
+
+		return nil
+	})
+	if budgetExceeded(err) && len(page.Extractions) > 0 {
+		page.Bookmark = page.Extractions[len(page.Extractions)-1].ID
+		page.Truncated = len(page.Extractions) < pageSize
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetRecyclingsPage returns a page of the recyclings in ID order; pass the
+// returned bookmark to get the next page
+func (s *SmartContract) GetRecyclingsPage(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*models.RecyclingPage, error) {
+	if pageSize <= 0 {
+		pageSize = defaultWastePageSize
+	}
+
+	page := &models.RecyclingPage{Recyclings: []*models.Recycling{}}
+	err := newAssetStore(ctx).Range("RECYCLING_"+bookmark, "RECYCLING_~", func(_ string, value []byte) error {
+		var recycling models.Recycling
+		if err := json.Unmarshal(value, &recycling); err != nil {
+			return err
+		}
+		if bookmark != "" && recycling.ID == bookmark {
+			return nil
+		}
+		if len(page.Recyclings) == pageSize {
+			page.Bookmark = page.Recyclings[pageSize-1].ID
+			return store.ErrStopRange
+		}
+		page.Recyclings = append(page.Recyclings, &recycling)
+
+		return nil
+	})
+	if budgetExceeded(err) && len(page.Recyclings) > 0 {
+		page.Bookmark = page.Recyclings[len(page.Recyclings)-1].ID
+		page.Truncated = len(page.Recyclings) < pageSize
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetTraceability provides complete traceability for a waste item
+func (s *SmartContract) GetTraceability(ctx contractapi.TransactionContextInterface, wasteId string) (*models.TraceabilityInfo, error) {
+	// Get waste
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	// The completion certificate is public proof of valorization, shown
+	// even when the lot itself is redacted
+	var certificate *models.CompletionCertificate
+	if waste.CertificateID != "" {
+		if certificate, err = readCompletionCertificate(ctx, waste.CertificateID); err != nil {
+			return nil, err
+		}
+	}
+	if !canView {
+		return &models.TraceabilityInfo{Waste: redactWaste(waste), Chain: []models.History{}, Certificate: certificate}, nil
+	}
+
+	traceInfo := &models.TraceabilityInfo{
+		Waste:       waste,
+		Chain:       waste.History,
+		Certificate: certificate,
+	}
+
+	// Find related extractions
+	err = newAssetStore(ctx).Range("EXTRACTION_", "EXTRACTION_~", func(_ string, value []byte) error {
+		var extraction models.Extraction
+		if err := json.Unmarshal(value, &extraction); err != nil {
+			return nil
+		}
+
+		if extraction.WasteID == wasteId {
+			traceInfo.Extraction = &extraction
+			traceInfo.Chain = append(traceInfo.Chain, extraction.History...)
+			return store.ErrStopRange
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Find related recyclings
+	err = newAssetStore(ctx).Range("RECYCLING_", "RECYCLING_~", func(_ string, value []byte) error {
+		var recycling models.Recycling
+		if err := json.Unmarshal(value, &recycling); err != nil {
+			return nil
+		}
+
+		if recycling.UsesWaste(wasteId) {
+			traceInfo.Recycling = &recycling
+			traceInfo.Chain = append(traceInfo.Chain, recycling.History...)
+			return store.ErrStopRange
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Weather reported for the farm around the harvest
+	traceInfo.Weather, err = harvestWeather(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+
+	return traceInfo, nil
+}
+
+// WasteExists returns true when waste with given ID exists in world state
+func (s *SmartContract) WasteExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	exists, err := newAssetStore(ctx).Exists("WASTE_" + id)
+	if err != nil {
+		return false, newError(ctx, ErrLedgerRead, "WASTE_"+id, err)
+	}
+
+	return exists, nil
+}
+
+// GetWasteHistory returns the history of changes for a waste item; entries
+// compacted out of the asset are read with GetArchivedHistory
+func (s *SmartContract) GetWasteHistory(ctx contractapi.TransactionContextInterface, id string) ([]models.History, error) {
+	waste, err := s.ReadWaste(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return waste.History, nil
+}
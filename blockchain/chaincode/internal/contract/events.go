@@ -0,0 +1,50 @@
+package contract
+
+import (
+	"encoding/json"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// recordChange adds an asset write to the transaction's LedgerChanged event
+func recordChange(ctx contractapi.TransactionContextInterface, assetType string, id string, version int) error {
+	tx := transactionState(ctx)
+	tx.changes = append(tx.changes, models.AssetChange{AssetType: assetType, ID: id, Version: version})
+
+	return emitLedgerChanged(ctx, tx)
+}
+
+// recordNotice adds a notice to the transaction's LedgerChanged event;
+// notices of the same kind and subject are merged
+func recordNotice(ctx contractapi.TransactionContextInterface, kind string, subject string, message string, recipient string) error {
+	tx := transactionState(ctx)
+	merged := false
+	for i := range tx.notices {
+		if tx.notices[i].Kind == kind && tx.notices[i].Subject == subject {
+			tx.notices[i].Recipients = append(tx.notices[i].Recipients, recipient)
+			merged = true
+		}
+	}
+	if !merged {
+		tx.notices = append(tx.notices, models.Notice{Kind: kind, Subject: subject, Message: message, Recipients: []string{recipient}})
+	}
+
+	return emitLedgerChanged(ctx, tx)
+}
+
+// emitLedgerChanged sets the LedgerChanged event with every change and
+// notice of the transaction so far; Fabric keeps only the last event set by
+// a transaction, so each call re-emits the full lists
+func emitLedgerChanged(ctx contractapi.TransactionContextInterface, tx *txState) error {
+	event := models.LedgerChangedEvent{
+		Changes: append([]models.AssetChange{}, tx.changes...),
+		Notices: tx.notices,
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().SetEvent("LedgerChanged", eventJSON)
+}
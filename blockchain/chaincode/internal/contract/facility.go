@@ -0,0 +1,436 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegisterFacility registers a facility operated by the caller's organization;
+// certifications is a comma-separated list
+func (s *SmartContract) RegisterFacility(ctx contractapi.TransactionContextInterface, id string, name string, facilityType string, location string, dailyCapacity float64, certifications string) (*models.Facility, error) {
+	if id == "" || name == "" {
+		return nil, newError(ctx, ErrFacilityFieldsRequired)
+	}
+	facilityType = strings.ToUpper(facilityType)
+	if facilityType != models.FacilityExtraction && facilityType != models.FacilityRecycling && facilityType != models.FacilityMixed && facilityType != models.FacilityLandfill {
+		return nil, newError(ctx, ErrFacilityTypeInvalid, models.FacilityExtraction, models.FacilityRecycling, models.FacilityMixed, models.FacilityLandfill)
+	}
+	if dailyCapacity <= 0 {
+		return nil, newError(ctx, ErrDailyCapacityInvalid)
+	}
+
+	exists, err := newAssetStore(ctx).Exists("FACILITY_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrFacilityAlreadyExists, id)
+	}
+
+	operator, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	facility := &models.Facility{
+		ID:             id,
+		Name:           name,
+		Type:           facilityType,
+		Operator:       operator,
+		Location:       location,
+		DailyCapacity:  dailyCapacity,
+		Certifications: splitList(certifications),
+		Status:         models.FacilityActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "REGISTERED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("%s facility with daily capacity %.2f", facilityType, dailyCapacity),
+			},
+		},
+	}
+
+	if err := s.putFacility(ctx, facility); err != nil {
+		return nil, err
+	}
+
+	return facility, nil
+}
+
+// UpdateFacilityStatus activates or deactivates a facility
+func (s *SmartContract) UpdateFacilityStatus(ctx contractapi.TransactionContextInterface, id string, status string, details string) (*models.Facility, error) {
+	if status != models.FacilityActive && status != models.FacilityInactive {
+		return nil, newError(ctx, ErrFacilityStatusUnknown, models.FacilityActive, models.FacilityInactive)
+	}
+
+	facility, err := s.ReadFacility(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireFacilityOperator(ctx, facility); err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	facility.Status = status
+	facility.UpdatedAt = now
+	facility.History = append(facility.History, models.History{
+		Timestamp: now,
+		Action:    "STATUS_CHANGED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Facility is now %s. %s", status, details),
+	})
+
+	if err := s.putFacility(ctx, facility); err != nil {
+		return nil, err
+	}
+
+	return facility, nil
+}
+
+// RegisterEquipment adds equipment to a facility
+func (s *SmartContract) RegisterEquipment(ctx contractapi.TransactionContextInterface, id string, facilityId string, name string, equipmentType string, dailyCapacity float64) (*models.Equipment, error) {
+	if id == "" || name == "" {
+		return nil, newError(ctx, ErrEquipmentFieldsRequired)
+	}
+	if dailyCapacity <= 0 {
+		return nil, newError(ctx, ErrDailyCapacityInvalid)
+	}
+
+	facility, err := s.ReadFacility(ctx, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireFacilityOperator(ctx, facility); err != nil {
+		return nil, err
+	}
+
+	exists, err := newAssetStore(ctx).Exists("EQUIPMENT_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrEquipmentAlreadyExists, id)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	equipment := &models.Equipment{
+		ID:                id,
+		FacilityID:        facilityId,
+		Name:              name,
+		Type:              equipmentType,
+		DailyCapacity:     dailyCapacity,
+		MaintenanceStatus: models.EquipmentOperational,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "REGISTERED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("Installed in facility %s", facilityId),
+			},
+		},
+	}
+
+	if err := s.putEquipment(ctx, equipment); err != nil {
+		return nil, err
+	}
+
+	return equipment, nil
+}
+
+// UpdateEquipmentMaintenance records a maintenance status change for equipment
+func (s *SmartContract) UpdateEquipmentMaintenance(ctx contractapi.TransactionContextInterface, id string, status string, details string) (*models.Equipment, error) {
+	if status != models.EquipmentOperational && status != models.EquipmentUnderMaintenance && status != models.EquipmentOutOfService {
+		return nil, newError(ctx, ErrMaintenanceStatusInvalid, models.EquipmentOperational, models.EquipmentUnderMaintenance, models.EquipmentOutOfService)
+	}
+
+	equipment, err := s.ReadEquipment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	facility, err := s.ReadFacility(ctx, equipment.FacilityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireFacilityOperator(ctx, facility); err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if equipment.MaintenanceStatus == models.EquipmentUnderMaintenance && status == models.EquipmentOperational {
+		equipment.LastMaintenance = now
+	}
+	equipment.MaintenanceStatus = status
+	equipment.UpdatedAt = now
+	equipment.History = append(equipment.History, models.History{
+		Timestamp: now,
+		Action:    "MAINTENANCE",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Maintenance status %s. %s", status, details),
+	})
+
+	if err := s.putEquipment(ctx, equipment); err != nil {
+		return nil, err
+	}
+
+	return equipment, nil
+}
+
+// ReadFacility returns the facility stored with the given id
+func (s *SmartContract) ReadFacility(ctx contractapi.TransactionContextInterface, id string) (*models.Facility, error) {
+	var facility models.Facility
+	found, err := newAssetStore(ctx).Get("FACILITY_"+id, &facility)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "FACILITY_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrFacilityNotFound, id)
+	}
+
+	return &facility, nil
+}
+
+// ReadEquipment returns the equipment stored with the given id
+func (s *SmartContract) ReadEquipment(ctx contractapi.TransactionContextInterface, id string) (*models.Equipment, error) {
+	var equipment models.Equipment
+	found, err := newAssetStore(ctx).Get("EQUIPMENT_"+id, &equipment)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "EQUIPMENT_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrEquipmentNotFound, id)
+	}
+
+	return &equipment, nil
+}
+
+// GetAllFacilities returns all registered facilities
+func (s *SmartContract) GetAllFacilities(ctx contractapi.TransactionContextInterface) ([]*models.Facility, error) {
+	var facilities []*models.Facility
+	err := newAssetStore(ctx).Range("FACILITY_", "FACILITY_~", func(_ string, value []byte) error {
+		var facility models.Facility
+		if err := json.Unmarshal(value, &facility); err != nil {
+			return err
+		}
+		facilities = append(facilities, &facility)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return facilities, nil
+}
+
+// GetFacilityEquipment returns the equipment installed in a facility
+func (s *SmartContract) GetFacilityEquipment(ctx contractapi.TransactionContextInterface, facilityId string) ([]*models.Equipment, error) {
+	var equipments []*models.Equipment
+	err := newAssetStore(ctx).Range("EQUIPMENT_", "EQUIPMENT_~", func(_ string, value []byte) error {
+		var equipment models.Equipment
+		if err := json.Unmarshal(value, &equipment); err != nil {
+			return err
+		}
+		if equipment.FacilityID == facilityId {
+			equipments = append(equipments, &equipment)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return equipments, nil
+}
+
+// GetFacilityUtilization returns claimed throughput against capacity for a day (YYYY-MM-DD)
+func (s *SmartContract) GetFacilityUtilization(ctx contractapi.TransactionContextInterface, facilityId string, date string) (*models.FacilityUtilization, error) {
+	facility, err := s.ReadFacility(ctx, facilityId)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := s.facilityCapacity(ctx, facility)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.facilityThroughput(ctx, facilityId, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FacilityUtilization{
+		FacilityID: facilityId,
+		Date:       date,
+		Capacity:   capacity,
+		Claimed:    claimed,
+		Remaining:  capacity - claimed,
+	}, nil
+}
+
+// facilityCapacity is the facility's daily capacity, reduced to the capacity of
+// its operational equipment when equipment is registered
+func (s *SmartContract) facilityCapacity(ctx contractapi.TransactionContextInterface, facility *models.Facility) (float64, error) {
+	equipments, err := s.GetFacilityEquipment(ctx, facility.ID)
+	if err != nil {
+		return 0, err
+	}
+	if len(equipments) == 0 {
+		return facility.DailyCapacity, nil
+	}
+
+	operational := 0.0
+	for _, equipment := range equipments {
+		if equipment.MaintenanceStatus == models.EquipmentOperational {
+			operational += equipment.DailyCapacity
+		}
+	}
+	if operational < facility.DailyCapacity {
+		return operational, nil
+	}
+
+	return facility.DailyCapacity, nil
+}
+
+// facilityThroughput sums the quantities of extraction and recycling records
+// claimed at a facility on the given day
+func (s *SmartContract) facilityThroughput(ctx contractapi.TransactionContextInterface, facilityId string, date string) (float64, error) {
+	total := 0.0
+
+	extractions, err := s.GetAllExtractions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, extraction := range extractions {
+		if extraction.FacilityID == facilityId && strings.HasPrefix(extraction.CreatedAt, date) {
+			total += extraction.Quantity
+		}
+	}
+
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, recycling := range recyclings {
+		if recycling.FacilityID == facilityId && strings.HasPrefix(recycling.CreatedAt, date) {
+			total += recycling.Quantity
+		}
+	}
+
+	return total, nil
+}
+
+// checkFacility verifies that a registered, active facility of a suitable type
+// can take the claimed quantity today; exceeding capacity is a warning unless
+// the "facility.capacityPolicy" setting is "reject"
+func (s *SmartContract) checkFacility(ctx contractapi.TransactionContextInterface, facilityId string, processType string, quantity float64, now string) ([]string, error) {
+	if facilityId == "" {
+		return nil, newError(ctx, ErrFacilityRequired)
+	}
+
+	facility, err := s.ReadFacility(ctx, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	if facility.Status != models.FacilityActive {
+		return nil, newError(ctx, ErrFacilityStatusInvalid, facilityId, facility.Status)
+	}
+	if facility.Type != processType && facility.Type != models.FacilityMixed {
+		return nil, newError(ctx, ErrFacilityTypeMismatch, facilityId, facility.Type, processType)
+	}
+
+	capacity, err := s.facilityCapacity(ctx, facility)
+	if err != nil {
+		return nil, err
+	}
+	date := now[:len("2006-01-02")]
+	claimed, err := s.facilityThroughput(ctx, facilityId, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if claimed+quantity > capacity {
+		message := fmt.Sprintf("facility %s throughput %.2f on %s exceeds its capacity %.2f", facilityId, claimed+quantity, date, capacity)
+		if configString(ctx, "facility", "capacityPolicy", "warn") == "reject" {
+			return nil, newError(ctx, ErrCapacityExceeded, message)
+		}
+		return []string{message}, nil
+	}
+
+	return nil, nil
+}
+
+// requireFacilityOperator allows only the operating organization or an admin
+func requireFacilityOperator(ctx contractapi.TransactionContextInterface, facility *models.Facility) error {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if mspID != facility.Operator && !isAdmin(ctx) {
+		return newError(ctx, ErrFacilityManageForbidden, facility.Operator, facility.ID)
+	}
+
+	return nil
+}
+
+func (s *SmartContract) putFacility(ctx contractapi.TransactionContextInterface, facility *models.Facility) error {
+	return newAssetStore(ctx).Put("FACILITY_"+facility.ID, facility)
+}
+
+func (s *SmartContract) putEquipment(ctx contractapi.TransactionContextInterface, equipment *models.Equipment) error {
+	return newAssetStore(ctx).Put("EQUIPMENT_"+equipment.ID, equipment)
+}
+
+// splitList splits a comma-separated argument into trimmed, non-empty items
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
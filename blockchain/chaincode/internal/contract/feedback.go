@@ -0,0 +1,296 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Feedback throttling defaults, overridden by feedback.minIntervalSeconds and
+// feedback.dailyLimit
+const (
+	defaultFeedbackIntervalSeconds = 60
+	defaultFeedbackDailyLimit      = 20
+)
+
+// commentHashPattern matches a hex-encoded sha256 digest
+var commentHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// IssueTraceToken creates the public trace token of a waste lot, extraction
+// or recycling product. The caller must be allowed to view the lot the
+// product comes from.
+func (s *SmartContract) IssueTraceToken(ctx contractapi.TransactionContextInterface, assetType string, assetId string) (*models.TraceToken, error) {
+	assetType = strings.ToUpper(strings.TrimSpace(assetType))
+	wasteID, err := s.productWasteID(ctx, assetType, assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	waste, err := s.readWaste(ctx, wasteID)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, newError(ctx, ErrWasteNotVisible, wasteID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Derived from the transaction ID so every endorser computes the same
+	// token while nobody can predict it
+	digest := sha256.Sum256([]byte(ctx.GetStub().GetTxID() + "|" + assetType + "|" + assetId))
+	farmID := waste.ParticipantID
+	if farmID == "" {
+		farmID = waste.Farm
+	}
+
+	token := &models.TraceToken{
+		Token:       hex.EncodeToString(digest[:12]),
+		AssetType:   assetType,
+		AssetID:     assetId,
+		WasteID:     wasteID,
+		FarmID:      farmID,
+		IssuedBy:    viewer.id,
+		IssuedByMSP: mspID,
+		CreatedAt:   now,
+	}
+	if err := putTraceToken(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// RevokeTraceToken stops a trace token from accepting feedback. Only the
+// issuing organization or an admin may revoke it.
+func (s *SmartContract) RevokeTraceToken(ctx contractapi.TransactionContextInterface, token string) (*models.TraceToken, error) {
+	traceToken, err := s.ReadTraceToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if traceToken.IssuedByMSP != mspID && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrTraceTokenIssuerMismatch, token, traceToken.IssuedByMSP)
+	}
+
+	traceToken.Revoked = true
+	if err := putTraceToken(ctx, traceToken); err != nil {
+		return nil, err
+	}
+
+	return traceToken, nil
+}
+
+// ReadTraceToken returns the trace token with the given value
+func (s *SmartContract) ReadTraceToken(ctx contractapi.TransactionContextInterface, token string) (*models.TraceToken, error) {
+	var traceToken models.TraceToken
+	found, err := newAssetStore(ctx).Get("TRACETOKEN_"+token, &traceToken)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "TRACETOKEN_"+token, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrTraceTokenNotFound, token)
+	}
+
+	return &traceToken, nil
+}
+
+// SubmitFeedback records a consumer rating (1-5) of the product behind a
+// trace token, with the sha256 hex digest of the comment when there is one.
+// A token accepts one feedback every feedback.minIntervalSeconds (default 60)
+// and at most feedback.dailyLimit (default 20) per day.
+func (s *SmartContract) SubmitFeedback(ctx contractapi.TransactionContextInterface, token string, rating int, commentHash string) (*models.Feedback, error) {
+	if rating < 1 || rating > 5 {
+		return nil, newError(ctx, ErrRatingOutOfRange)
+	}
+	commentHash = strings.ToLower(strings.TrimSpace(commentHash))
+	if commentHash != "" && !commentHashPattern.MatchString(commentHash) {
+		return nil, newError(ctx, ErrCommentHashInvalid)
+	}
+
+	traceToken, err := s.ReadTraceToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if traceToken.Revoked {
+		return nil, newError(ctx, ErrTraceTokenRevoked, token)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	submittedAt, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := configInt(ctx, "feedback", "minIntervalSeconds", defaultFeedbackIntervalSeconds)
+	dailyLimit := configInt(ctx, "feedback", "dailyLimit", defaultFeedbackDailyLimit)
+	today := now[:len("2006-01-02")]
+	if traceToken.FeedbackDay != today {
+		traceToken.FeedbackDay = today
+		traceToken.FeedbackToday = 0
+	}
+	throttled := dailyLimit > 0 && traceToken.FeedbackToday >= dailyLimit
+	if last, err := time.Parse(time.RFC3339, traceToken.LastFeedbackAt); err == nil && submittedAt.Sub(last) < time.Duration(interval)*time.Second {
+		throttled = true
+	}
+	if throttled {
+		return nil, newError(ctx, ErrFeedbackThrottled, token, interval, dailyLimit)
+	}
+
+	id, err := newAssetID(ctx, "FEEDBACK")
+	if err != nil {
+		return nil, err
+	}
+	feedback := &models.Feedback{
+		ID:          id,
+		Token:       token,
+		AssetType:   traceToken.AssetType,
+		AssetID:     traceToken.AssetID,
+		WasteID:     traceToken.WasteID,
+		FarmID:      traceToken.FarmID,
+		Rating:      rating,
+		CommentHash: commentHash,
+		SubmittedAt: now,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	if err := newAssetStore(ctx).Put("FEEDBACK_"+id, feedback); err != nil {
+		return nil, err
+	}
+
+	traceToken.FeedbackCount++
+	traceToken.FeedbackToday++
+	traceToken.LastFeedbackAt = now
+	if err := putTraceToken(ctx, traceToken); err != nil {
+		return nil, err
+	}
+
+	if err := addRating(ctx, models.RatingScopeProduct, traceToken.AssetID, rating, now); err != nil {
+		return nil, err
+	}
+	if traceToken.FarmID != "" {
+		if err := addRating(ctx, models.RatingScopeFarm, traceToken.FarmID, rating, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return feedback, nil
+}
+
+// GetRatingSummary returns the aggregated ratings of a product (scope PRODUCT,
+// keyed by asset ID) or a farm (scope FARM, keyed by participant ID)
+func (s *SmartContract) GetRatingSummary(ctx contractapi.TransactionContextInterface, scope string, key string) (*models.RatingSummary, error) {
+	scope, err := ratingScope(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.RatingSummary{Scope: scope, Key: key}
+	if _, err := newAssetStore(ctx).Get(ratingKey(scope, key), summary); err != nil {
+		return nil, newError(ctx, ErrLedgerRead, ratingKey(scope, key), err)
+	}
+
+	return summary, nil
+}
+
+// GetRatingSummaries returns the rating summaries of every product or farm
+// that received feedback, ordered by key
+func (s *SmartContract) GetRatingSummaries(ctx contractapi.TransactionContextInterface, scope string) ([]*models.RatingSummary, error) {
+	scope, err := ratingScope(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ratingKey(scope, "")
+	summaries := []*models.RatingSummary{}
+	err = newAssetStore(ctx).Range(prefix, prefix+"~", func(_ string, value []byte) error {
+		var summary models.RatingSummary
+		if err := json.Unmarshal(value, &summary); err != nil {
+			return err
+		}
+		summaries = append(summaries, &summary)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+// productWasteID returns the waste lot a traced product comes from
+func (s *SmartContract) productWasteID(ctx contractapi.TransactionContextInterface, assetType string, assetId string) (string, error) {
+	switch assetType {
+	case "WASTE":
+		return assetId, nil
+	case "EXTRACTION":
+		extraction, err := s.GetExtraction(ctx, assetId)
+		if err != nil {
+			return "", err
+		}
+		return extraction.WasteID, nil
+	case "RECYCLING":
+		recycling, err := s.GetRecycling(ctx, assetId)
+		if err != nil {
+			return "", err
+		}
+		return recycling.WasteID, nil
+	default:
+		return "", newError(ctx, ErrAssetTypeUnsupported, assetType)
+	}
+}
+
+// addRating folds a rating into the summary of a product or farm
+func addRating(ctx contractapi.TransactionContextInterface, scope string, key string, rating int, at string) error {
+	summary := &models.RatingSummary{Scope: scope, Key: key}
+	if _, err := newAssetStore(ctx).Get(ratingKey(scope, key), summary); err != nil {
+		return newError(ctx, ErrLedgerRead, ratingKey(scope, key), err)
+	}
+	summary.Add(rating, at)
+
+	return newAssetStore(ctx).Put(ratingKey(scope, key), summary)
+}
+
+func ratingScope(ctx contractapi.TransactionContextInterface, scope string) (string, error) {
+	scope = strings.ToUpper(strings.TrimSpace(scope))
+	if scope != models.RatingScopeProduct && scope != models.RatingScopeFarm {
+		return "", newError(ctx, ErrRatingScopeUnsupported, scope)
+	}
+
+	return scope, nil
+}
+
+func ratingKey(scope string, key string) string {
+	return "RATING_" + scope + "_" + key
+}
+
+func putTraceToken(ctx contractapi.TransactionContextInterface, token *models.TraceToken) error {
+	return newAssetStore(ctx).Put("TRACETOKEN_"+token.Token, token)
+}
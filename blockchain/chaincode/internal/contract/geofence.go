@@ -0,0 +1,158 @@
+package contract
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// earthRadiusMeters is the mean radius used for great-circle distances
+const earthRadiusMeters = 6371000
+
+// SetFacilityGeofence sets the circle around a facility within which
+// deliveries to it are confirmed; a zero radius removes it. Only the
+// operator or an admin may set it.
+func (s *SmartContract) SetFacilityGeofence(ctx contractapi.TransactionContextInterface, facilityId string, latitude float64, longitude float64, radiusMeters float64) (*models.Facility, error) {
+	if radiusMeters < 0 {
+		return nil, newError(ctx, ErrGeofenceRadiusNegative)
+	}
+	if radiusMeters > 0 {
+		if err := checkPosition(ctx, latitude, longitude); err != nil {
+			return nil, err
+		}
+	}
+
+	facility, err := s.ReadFacility(ctx, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireFacilityOperator(ctx, facility); err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	action, details := "GEOFENCE_REMOVED", "Deliveries are no longer checked against a geofence"
+	facility.Geofence = nil
+	if radiusMeters > 0 {
+		facility.Geofence = &models.Geofence{Latitude: latitude, Longitude: longitude, RadiusMeters: radiusMeters}
+		action, details = "GEOFENCE_SET", fmt.Sprintf("%.0f m around %.6f,%.6f", radiusMeters, latitude, longitude)
+	}
+	facility.UpdatedAt = now
+	facility.History = append(facility.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   details,
+	})
+
+	if err := s.putFacility(ctx, facility); err != nil {
+		return nil, err
+	}
+
+	return facility, nil
+}
+
+// checkDeliveryPosition compares where a shipment is being delivered with
+// the geofence of its destination facility. Positions outside it, and
+// destinations without one when transport.requireGeofence is set, are
+// refused unless an admin gives an override reason.
+func (s *SmartContract) checkDeliveryPosition(ctx contractapi.TransactionContextInterface, shipment *models.Shipment, latitude float64, longitude float64, overrideReason string) (*models.DeliveryCheck, error) {
+	if err := checkPosition(ctx, latitude, longitude); err != nil {
+		return nil, err
+	}
+	overrideReason = strings.TrimSpace(overrideReason)
+	if overrideReason != "" && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrGeofenceOverrideForbidden)
+	}
+
+	check := &models.DeliveryCheck{Latitude: latitude, Longitude: longitude, Result: models.GeofenceUndefined}
+	facility, err := s.destinationFacility(ctx, shipment.Destination)
+	if err != nil {
+		return nil, err
+	}
+	var refusal string
+	if facility != nil {
+		check.FacilityID = facility.ID
+	}
+	if facility != nil && facility.Geofence != nil {
+		fence := facility.Geofence
+		check.RadiusMeters = fence.RadiusMeters
+		check.DistanceMeters = math.Round(distanceMeters(latitude, longitude, fence.Latitude, fence.Longitude))
+		check.Result = models.GeofenceInside
+		if check.DistanceMeters > fence.RadiusMeters {
+			refusal = fmt.Sprintf("delivery position is %.0f m from facility %s, outside its %.0f m geofence", check.DistanceMeters, facility.ID, fence.RadiusMeters)
+		}
+	} else if configBool(ctx, "transport", "requireGeofence", false) {
+		refusal = fmt.Sprintf("destination %s has no geofence to confirm the delivery against", shipment.Destination)
+	}
+
+	if refusal != "" {
+		if overrideReason == "" {
+			return nil, newError(ctx, ErrGeofenceRefused, refusal)
+		}
+		actor, err := callerID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		check.Result = models.GeofenceOverridden
+		check.OverriddenBy = actor
+		check.OverrideReason = overrideReason
+	}
+
+	return check, nil
+}
+
+// destinationFacility returns the facility a shipment destination names by
+// ID or name, or nil when it is not a registered facility
+func (s *SmartContract) destinationFacility(ctx contractapi.TransactionContextInterface, destination string) (*models.Facility, error) {
+	var facility models.Facility
+	found, err := newAssetStore(ctx).Get("FACILITY_"+destination, &facility)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "FACILITY_"+destination, err)
+	}
+	if found {
+		return &facility, nil
+	}
+
+	facilities, err := s.GetAllFacilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range facilities {
+		if strings.EqualFold(destination, candidate.Name) {
+			return candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// checkPosition refuses coordinates outside the valid ranges
+func checkPosition(ctx contractapi.TransactionContextInterface, latitude float64, longitude float64) error {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return newError(ctx, ErrPositionOutOfRange, latitude, longitude)
+	}
+
+	return nil
+}
+
+// distanceMeters is the great-circle (haversine) distance between two
+// positions
+func distanceMeters(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
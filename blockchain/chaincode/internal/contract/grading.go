@@ -0,0 +1,266 @@
+package contract
+
+import (
+	"fmt"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// GradeExtraction records a grader's assessment of an extraction on the
+// quality scale, with a reference to the evidence it rests on, and applies
+// the bonus and penalty rules of the supply contract it was delivered under.
+// Graders only; an extraction under appeal is regraded through ConfirmRegrade.
+func (s *SmartContract) GradeExtraction(ctx contractapi.TransactionContextInterface, extractionId string, grade string, evidence string) (*models.Extraction, error) {
+	if err := requireGrader(ctx); err != nil {
+		return nil, err
+	}
+	if !isQualityGrade(grade) {
+		return nil, newError(ctx, ErrQualityGradeUnknown, grade, qualityGrades)
+	}
+	if evidence == "" {
+		return nil, newError(ctx, ErrGradingEvidenceRequired)
+	}
+
+	extraction, err := s.readExtraction(ctx, extractionId)
+	if err != nil {
+		return nil, err
+	}
+	if extraction.PendingRegrade != "" {
+		return nil, newError(ctx, ErrExtractionUnderAppeal, extractionId, extraction.PendingRegrade)
+	}
+
+	record, err := newGradeRecord(ctx, extraction, grade, models.GradeAssessed, evidence, "")
+	if err != nil {
+		return nil, err
+	}
+	extraction.QualityGrade = grade
+	extraction.Grading = append(extraction.Grading, *record)
+
+	if err := s.putExtraction(ctx, extraction); err != nil {
+		return nil, err
+	}
+	if err := s.applyContractAdjustments(ctx, extraction); err != nil {
+		return nil, err
+	}
+
+	return extraction, nil
+}
+
+// RequestRegrade appeals the grade of an extraction; one appeal may be
+// pending per extraction and graders cannot appeal
+func (s *SmartContract) RequestRegrade(ctx contractapi.TransactionContextInterface, extractionId string, proposedGrade string, reason string, evidence string) (*models.RegradeRequest, error) {
+	if hasRole(ctx, GraderRole) {
+		return nil, newError(ctx, ErrGraderAppealForbidden)
+	}
+	if !isQualityGrade(proposedGrade) {
+		return nil, newError(ctx, ErrQualityGradeUnknown, proposedGrade, qualityGrades)
+	}
+	if reason == "" || evidence == "" {
+		return nil, newError(ctx, ErrAppealFieldsRequired)
+	}
+
+	extraction, err := s.readExtraction(ctx, extractionId)
+	if err != nil {
+		return nil, err
+	}
+	if extraction.PendingRegrade != "" {
+		return nil, newError(ctx, ErrExtractionAlreadyAppealed, extractionId, extraction.PendingRegrade)
+	}
+	currentGrade := gradeOrDefault(extraction.QualityGrade)
+	if proposedGrade == currentGrade {
+		return nil, newError(ctx, ErrExtractionGradeUnchanged, extractionId, currentGrade)
+	}
+
+	id, err := newAssetID(ctx, "REGRADE")
+	if err != nil {
+		return nil, err
+	}
+	requester, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &models.RegradeRequest{
+		ID:            id,
+		ExtractionID:  extractionId,
+		CurrentGrade:  currentGrade,
+		ProposedGrade: proposedGrade,
+		Reason:        reason,
+		Evidence:      evidence,
+		RequestedBy:   requester,
+		RequestedMSP:  mspID,
+		Status:        models.RegradePending,
+		CreatedAt:     now,
+	}
+	extraction.PendingRegrade = id
+
+	if err := putRegradeRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	if err := s.putExtraction(ctx, extraction); err != nil {
+		return nil, err
+	}
+	if err := notify(ctx, extractionProcessor(extraction), models.NotifyRegradeRequested, "EXTRACTION_"+extractionId, fmt.Sprintf("%s appeals grade %s of extraction %s, proposing %s", mspID, currentGrade, extractionId, proposedGrade)); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// ConfirmRegrade resolves an appeal with the grade an independent grader
+// assigns: a grader from another organization than the appellant who did not
+// set the appealed grade. Keeping the current grade dismisses the appeal.
+func (s *SmartContract) ConfirmRegrade(ctx contractapi.TransactionContextInterface, requestId string, grade string, evidence string) (*models.RegradeRequest, error) {
+	if err := requireGrader(ctx); err != nil {
+		return nil, err
+	}
+	if !isQualityGrade(grade) {
+		return nil, newError(ctx, ErrQualityGradeUnknown, grade, qualityGrades)
+	}
+	if evidence == "" {
+		return nil, newError(ctx, ErrGradingEvidenceRequired)
+	}
+
+	request, err := s.ReadRegradeRequest(ctx, requestId)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != models.RegradePending {
+		return nil, newError(ctx, ErrRegradeStatusUnchanged, requestId, request.Status)
+	}
+	extraction, err := s.readExtraction(ctx, request.ExtractionID)
+	if err != nil {
+		return nil, err
+	}
+
+	grader, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID == request.RequestedMSP {
+		return nil, newError(ctx, ErrAppealOwnOrganization, mspID)
+	}
+	if n := len(extraction.Grading); n > 0 && extraction.Grading[n-1].Grader == grader {
+		return nil, newError(ctx, ErrAppealOwnGrade)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request.Grade = grade
+	request.Grader = grader
+	request.GraderMSP = mspID
+	request.GraderEvidence = evidence
+	request.ResolvedAt = now
+	request.Status = models.RegradeDismissed
+	if grade != gradeOrDefault(extraction.QualityGrade) {
+		request.Status = models.RegradeConfirmed
+		record, err := newGradeRecord(ctx, extraction, grade, models.GradeRegraded, evidence, request.Reason)
+		if err != nil {
+			return nil, err
+		}
+		record.RequestID = requestId
+		extraction.QualityGrade = grade
+		extraction.Grading = append(extraction.Grading, *record)
+	}
+	extraction.PendingRegrade = ""
+
+	if err := putRegradeRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	if err := s.putExtraction(ctx, extraction); err != nil {
+		return nil, err
+	}
+	if err := s.applyContractAdjustments(ctx, extraction); err != nil {
+		return nil, err
+	}
+
+	if err := notify(ctx, request.RequestedMSP, models.NotifyRegradeResolved, "EXTRACTION_"+extraction.ID, fmt.Sprintf("Appeal %s on extraction %s %s: grade %s", requestId, extraction.ID, request.Status, grade)); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// ReadRegradeRequest returns the regrade request stored with the given id
+func (s *SmartContract) ReadRegradeRequest(ctx contractapi.TransactionContextInterface, id string) (*models.RegradeRequest, error) {
+	var request models.RegradeRequest
+	found, err := newAssetStore(ctx).Get("REGRADE_"+id, &request)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "REGRADE_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrRegradeNotFound, id)
+	}
+
+	return &request, nil
+}
+
+// GetGradingHistory returns the grading decisions of an extraction, oldest first
+func (s *SmartContract) GetGradingHistory(ctx contractapi.TransactionContextInterface, extractionId string) ([]models.GradeRecord, error) {
+	extraction, err := s.readExtraction(ctx, extractionId)
+	if err != nil {
+		return nil, err
+	}
+	if extraction.Grading == nil {
+		return []models.GradeRecord{}, nil
+	}
+
+	return extraction.Grading, nil
+}
+
+// newGradeRecord describes the caller grading an extraction
+func newGradeRecord(ctx contractapi.TransactionContextInterface, extraction *models.Extraction, grade string, kind string, evidence string, reason string) (*models.GradeRecord, error) {
+	grader, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GradeRecord{
+		Grade:         grade,
+		PreviousGrade: gradeOrDefault(extraction.QualityGrade),
+		Kind:          kind,
+		Grader:        grader,
+		GraderMSP:     mspID,
+		Evidence:      evidence,
+		Reason:        reason,
+		GradedAt:      now,
+	}, nil
+}
+
+// isQualityGrade reports whether grade is on the quality scale
+func isQualityGrade(grade string) bool {
+	for _, g := range qualityGrades {
+		if g == grade {
+			return true
+		}
+	}
+
+	return false
+}
+
+func putRegradeRequest(ctx contractapi.TransactionContextInterface, request *models.RegradeRequest) error {
+	return newAssetStore(ctx).Put("REGRADE_"+request.ID, request)
+}
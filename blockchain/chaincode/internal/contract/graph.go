@@ -0,0 +1,214 @@
+package contract
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Graph defaults; config graph.defaultDepth, graph.maxDepth and
+// graph.maxNodes override them
+const (
+	defaultGraphDepth    = 2
+	defaultGraphMaxDepth = 6
+	defaultGraphMaxNodes = 1000
+)
+
+// GetRelationshipGraph returns the assets linked to a root asset up to depth
+// hops away, in either direction: farms and plots declaring lots, lots
+// processed into extractions and their products, recyclings and shipments.
+// rootId is a node ID (FARM:name, WASTE:id...) or the ID of a lot,
+// extraction, recycling or shipment.
+func (s *SmartContract) GetRelationshipGraph(ctx contractapi.TransactionContextInterface, rootId string, depth int) (*models.RelationshipGraph, error) {
+	if depth <= 0 {
+		depth = configInt(ctx, "graph", "defaultDepth", defaultGraphDepth)
+	}
+	if maxDepth := configInt(ctx, "graph", "maxDepth", defaultGraphMaxDepth); depth > maxDepth {
+		depth = maxDepth
+	}
+
+	graph, err := s.buildRelationshipGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	root := graph.resolve(strings.TrimSpace(rootId))
+	if root == "" {
+		return nil, newError(ctx, ErrGraphAssetNotFound, rootId)
+	}
+
+	return graph.neighbourhood(root, depth, configInt(ctx, "graph", "maxNodes", defaultGraphMaxNodes)), nil
+}
+
+// relationshipGraph holds every node and edge, indexed for traversal
+type relationshipGraph struct {
+	nodes    map[string]*models.GraphNode
+	edges    []*models.GraphEdge
+	adjacent map[string][]*models.GraphEdge
+}
+
+func graphNodeID(nodeType string, id string) string {
+	return nodeType + ":" + id
+}
+
+func (g *relationshipGraph) addNode(node *models.GraphNode) {
+	if _, ok := g.nodes[node.ID]; !ok {
+		g.nodes[node.ID] = node
+	}
+}
+
+func (g *relationshipGraph) addEdge(source string, target string, edgeType string, quantity float64) {
+	edge := &models.GraphEdge{
+		ID:       source + "->" + target,
+		Source:   source,
+		Target:   target,
+		Type:     edgeType,
+		Quantity: quantity,
+	}
+	g.edges = append(g.edges, edge)
+	g.adjacent[source] = append(g.adjacent[source], edge)
+	g.adjacent[target] = append(g.adjacent[target], edge)
+}
+
+// buildRelationshipGraph loads the lots, extractions, recyclings and
+// shipments into a graph; lots the caller may not see are redacted
+func (s *SmartContract) buildRelationshipGraph(ctx contractapi.TransactionContextInterface) (*relationshipGraph, error) {
+	g := &relationshipGraph{nodes: map[string]*models.GraphNode{}, adjacent: map[string][]*models.GraphEdge{}}
+
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, waste := range wastes {
+		id := graphNodeID(models.GraphWaste, waste.ID)
+		canView, err := viewer.canView(ctx, waste)
+		if err != nil {
+			return nil, err
+		}
+		if !canView {
+			g.addNode(&models.GraphNode{ID: id, Type: models.GraphWaste, AssetID: waste.ID, Label: waste.Type, Status: waste.Status, Redacted: true})
+			continue
+		}
+		g.addNode(&models.GraphNode{ID: id, Type: models.GraphWaste, AssetID: waste.ID, Label: waste.Type, Status: waste.Status, Quantity: waste.Quantity, Date: waste.HarvestDate})
+		if waste.Farm != "" {
+			farm := graphNodeID(models.GraphFarm, waste.Farm)
+			g.addNode(&models.GraphNode{ID: farm, Type: models.GraphFarm, AssetID: waste.Farm, Label: waste.Farm})
+			g.addEdge(farm, id, models.EdgeDeclared, waste.Quantity)
+		}
+		if waste.PlotID != "" {
+			plot := graphNodeID(models.GraphPlot, waste.PlotID)
+			g.addNode(&models.GraphNode{ID: plot, Type: models.GraphPlot, AssetID: waste.PlotID, Label: waste.PlotID})
+			g.addEdge(plot, id, models.EdgeGrownOn, waste.Quantity)
+		}
+	}
+
+	extractions, err := s.GetAllExtractions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, extraction := range extractions {
+		id := graphNodeID(models.GraphExtraction, extraction.ID)
+		g.addNode(&models.GraphNode{ID: id, Type: models.GraphExtraction, AssetID: extraction.ID, Label: extraction.Processor, Status: extraction.Status, Quantity: extraction.Quantity, Date: extraction.ExtractionDate})
+		g.addEdge(graphNodeID(models.GraphWaste, extraction.WasteID), id, models.EdgeProcessedInto, extraction.Quantity)
+		for _, output := range extractionOutputs(extraction) {
+			productID := fmt.Sprintf("%s/%d", extraction.ID, output.Line)
+			product := graphNodeID(models.GraphProduct, productID)
+			g.addNode(&models.GraphNode{ID: product, Type: models.GraphProduct, AssetID: productID, Label: output.ProductType, Quantity: output.Quantity, Date: extraction.ExtractionDate})
+			g.addEdge(id, product, models.EdgeProduced, output.Quantity)
+		}
+	}
+
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, recycling := range recyclings {
+		id := graphNodeID(models.GraphRecycling, recycling.ID)
+		g.addNode(&models.GraphNode{ID: id, Type: models.GraphRecycling, AssetID: recycling.ID, Label: recycling.Method, Status: recycling.Status, Quantity: recycling.Quantity, Date: recycling.RecyclingDate})
+		if recycling.ExtractionID != "" {
+			source := graphNodeID(models.GraphProduct, fmt.Sprintf("%s/%d", recycling.ExtractionID, recycling.OutputLine))
+			g.addEdge(source, id, models.EdgeRecycledInto, recycling.Quantity)
+		} else {
+			for _, input := range recycling.InputLots() {
+				g.addEdge(graphNodeID(models.GraphWaste, input.WasteID), id, models.EdgeRecycledInto, input.Quantity)
+			}
+		}
+		product := graphNodeID(models.GraphProduct, recycling.ID)
+		g.addNode(&models.GraphNode{ID: product, Type: models.GraphProduct, AssetID: recycling.ID, Label: recycling.RecycledProduct, Quantity: recycling.Quantity, Date: recycling.RecyclingDate})
+		g.addEdge(id, product, models.EdgeProduced, recycling.Quantity)
+	}
+
+	shipments, err := loadShipments(ctx, func(*models.Shipment) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	for _, shipment := range shipments {
+		id := graphNodeID(models.GraphShipment, shipment.ID)
+		g.addNode(&models.GraphNode{ID: id, Type: models.GraphShipment, AssetID: shipment.ID, Label: shipment.Plate, Status: shipment.Status, Quantity: shipment.Quantity, Date: shipment.DepartureDate})
+		g.addEdge(id, graphNodeID(models.GraphWaste, shipment.WasteID), models.EdgeCarries, shipment.Quantity)
+	}
+
+	return g, nil
+}
+
+// resolve finds the node of a root ID, given as a node ID or an asset ID
+func (g *relationshipGraph) resolve(rootID string) string {
+	if _, ok := g.nodes[rootID]; ok {
+		return rootID
+	}
+	for _, nodeType := range []string{models.GraphWaste, models.GraphExtraction, models.GraphRecycling, models.GraphShipment, models.GraphFarm, models.GraphPlot} {
+		if _, ok := g.nodes[graphNodeID(nodeType, rootID)]; ok {
+			return graphNodeID(nodeType, rootID)
+		}
+	}
+
+	return ""
+}
+
+// neighbourhood walks the graph breadth-first from the root, following
+// edges both ways, and returns the nodes reached and the edges between them
+func (g *relationshipGraph) neighbourhood(root string, depth int, maxNodes int) *models.RelationshipGraph {
+	result := &models.RelationshipGraph{Root: root, Depth: depth, Nodes: []*models.GraphNode{}, Edges: []*models.GraphEdge{}}
+	reached := map[string]bool{root: true}
+	frontier := []string{root}
+	for hop := 0; hop < depth && len(frontier) > 0 && !result.Truncated; hop++ {
+		var next []string
+		for _, id := range frontier {
+			for _, edge := range g.adjacent[id] {
+				other := edge.Target
+				if other == id {
+					other = edge.Source
+				}
+				if reached[other] || g.nodes[other] == nil {
+					continue
+				}
+				if len(reached) >= maxNodes {
+					result.Truncated = true
+					break
+				}
+				reached[other] = true
+				next = append(next, other)
+			}
+		}
+		frontier = next
+	}
+
+	for id := range reached {
+		result.Nodes = append(result.Nodes, g.nodes[id])
+	}
+	for _, edge := range g.edges {
+		if reached[edge.Source] && reached[edge.Target] {
+			result.Edges = append(result.Edges, edge)
+		}
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].ID < result.Nodes[j].ID })
+	sort.Slice(result.Edges, func(i, j int) bool { return result.Edges[i].ID < result.Edges[j].ID })
+
+	return result
+}
@@ -0,0 +1,289 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ExportHandoff hands what remains of a lot off to another Fabric network
+// or an outside system (system) that knows it as externalReference. The
+// lot's traceability credential is the bundle handed over: its sha256 is
+// recorded with the handoff and the bundle returned exactly as hashed, so
+// the receiver can check it against the ledger. The lot becomes EXPORTED
+// and can no longer change hands here. The owning organization or an admin
+// only; a non-zero expectedVersion guards against concurrent updates.
+func (s *SmartContract) ExportHandoff(ctx contractapi.TransactionContextInterface, wasteId string, system string, externalReference string, notes string, expectedVersion int) (*models.ExportedHandoff, error) {
+	system, externalReference = strings.TrimSpace(system), strings.TrimSpace(externalReference)
+	if system == "" || externalReference == "" {
+		return nil, newError(ctx, ErrHandoffDestinationRequired)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrHandoffForbidden, waste.OwnerMSP, wasteId)
+	}
+	switch waste.Status {
+	case "PROCESSED", "RECYCLED", models.WasteDonated, models.WasteDisposed, models.WasteLost, models.WasteWrittenOff, models.WasteExported:
+		return nil, newError(ctx, ErrWasteStatusUnchanged, wasteId, waste.Status)
+	}
+	if waste.PendingApprovalID != "" {
+		return nil, newError(ctx, ErrWasteTransferPending, wasteId, waste.PendingApprovalID)
+	}
+	if waste.PendingSettlementID != "" {
+		return nil, newError(ctx, ErrWasteLocked, wasteId, waste.PendingSettlementID)
+	}
+	remaining := waste.Quantity - waste.Consumed
+	if remaining <= 0 {
+		return nil, newError(ctx, ErrWasteExhausted, wasteId)
+	}
+
+	credential, err := s.ExportTraceabilityVC(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := json.Marshal(credential)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(bundle)
+
+	handoff, err := newHandoff(ctx, models.HandoffExport, wasteId, system, externalReference, hex.EncodeToString(digest[:]))
+	if err != nil {
+		return nil, err
+	}
+	handoff.Quantity = remaining
+	handoff.Notes = notes
+
+	waste.ExportHandoffID = handoff.ID
+	applyStatusChange(waste, models.WasteExported, handoff.RecordedBy, fmt.Sprintf("%.2f handed off to %s as %s (handoff %s)", remaining, system, externalReference, handoff.ID), handoff.CreatedAt)
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+	if err := putHandoff(ctx, handoff); err != nil {
+		return nil, err
+	}
+
+	return &models.ExportedHandoff{Handoff: handoff, Bundle: string(bundle)}, nil
+}
+
+// ImportHandoff records a lot received from another network or system as a
+// new lot of the caller's organization, created like CreateWaste, with its
+// external provenance attached: the source system, the reference and lot ID
+// it had there and the sha256 of the traceability bundle that came with it.
+// An external reference is imported from a system once.
+func (s *SmartContract) ImportHandoff(ctx contractapi.TransactionContextInterface, id string, wasteType string, quantity float64, harvestDate string, owner string, farm string, location string, plotId string, origin models.ExternalOrigin) (*models.Waste, error) {
+	origin.System = strings.TrimSpace(origin.System)
+	origin.ExternalReference = strings.TrimSpace(origin.ExternalReference)
+	origin.ContentHash = strings.ToLower(strings.TrimSpace(origin.ContentHash))
+	if origin.System == "" || origin.ExternalReference == "" {
+		return nil, newError(ctx, ErrHandoffSourceRequired)
+	}
+	if !commentHashPattern.MatchString(origin.ContentHash) {
+		return nil, newError(ctx, ErrContentHashInvalid)
+	}
+
+	staged := newStagedWrites(ctx)
+	waste, _, err := s.buildWaste(ctx, staged, id, wasteType, quantity, harvestDate, owner, farm, location, plotId)
+	if err != nil {
+		return nil, err
+	}
+
+	handoff, err := newHandoff(ctx, models.HandoffImport, waste.ID, origin.System, origin.ExternalReference, origin.ContentHash)
+	if err != nil {
+		return nil, err
+	}
+	handoff.ExternalLotID = origin.ExternalLotID
+	handoff.Quantity = quantity
+	staged.write(func() error { return putHandoff(ctx, handoff) })
+
+	origin.HandoffID = handoff.ID
+	waste.Origin = &origin
+	waste.History = append(waste.History, models.History{
+		Timestamp: handoff.CreatedAt,
+		Action:    "IMPORTED",
+		Actor:     handoff.RecordedBy,
+		Details:   fmt.Sprintf("Received from %s as %s (bundle %s)", origin.System, origin.ExternalReference, origin.ContentHash),
+	})
+	staged.waste(waste)
+	if err := staged.commit(); err != nil {
+		return nil, err
+	}
+	if err := recordValuation(ctx, waste, models.ValuationCreated, waste.Quantity, 0, ""); err != nil {
+		return nil, err
+	}
+	if err := recordQuotaUsage(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// ReadHandoff returns the handoff stored with the given id to those who may
+// see its lot
+func (s *SmartContract) ReadHandoff(ctx contractapi.TransactionContextInterface, id string) (*models.Handoff, error) {
+	var handoff models.Handoff
+	found, err := newAssetStore(ctx).Get("HANDOFF_"+id, &handoff)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "HANDOFF_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrHandoffNotFound, id)
+	}
+	if err := s.checkHandoffVisible(ctx, &handoff); err != nil {
+		return nil, err
+	}
+
+	return &handoff, nil
+}
+
+// GetHandoffByReference returns the handoff of kind (EXPORT or IMPORT)
+// recorded for a system's external reference
+func (s *SmartContract) GetHandoffByReference(ctx contractapi.TransactionContextInterface, kind string, system string, externalReference string) (*models.Handoff, error) {
+	var id string
+	found, err := newAssetStore(ctx).Get(handoffReferenceKey(strings.ToUpper(kind), strings.TrimSpace(system), strings.TrimSpace(externalReference)), &id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newError(ctx, ErrHandoffReferenceNotFound, strings.ToLower(kind), externalReference, system)
+	}
+
+	return s.ReadHandoff(ctx, id)
+}
+
+// GetHandoffs lists the handoffs of the caller's organization, or every
+// organization's for admins, newest first; kind (EXPORT or IMPORT) and
+// system filter them when set
+func (s *SmartContract) GetHandoffs(ctx contractapi.TransactionContextInterface, kind string, system string) ([]*models.Handoff, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+	kind = strings.ToUpper(kind)
+
+	handoffs := []*models.Handoff{}
+	err = newAssetStore(ctx).Range("HANDOFF_", "HANDOFF_~", func(_ string, value []byte) error {
+		var handoff models.Handoff
+		if err := json.Unmarshal(value, &handoff); err != nil {
+			return err
+		}
+		if (!admin && handoff.RecordedByMSP != mspID) || (kind != "" && handoff.Kind != kind) || (system != "" && handoff.System != system) {
+			return nil
+		}
+		handoffs = append(handoffs, &handoff)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(handoffs, func(i, j int) bool {
+		return handoffs[i].CreatedAt > handoffs[j].CreatedAt
+	})
+
+	return handoffs, nil
+}
+
+// newHandoff returns a handoff recorded by the caller, refusing an external
+// reference already handed off in the same direction
+func newHandoff(ctx contractapi.TransactionContextInterface, kind string, wasteId string, system string, externalReference string, contentHash string) (*models.Handoff, error) {
+	exists, err := newAssetStore(ctx).Exists(handoffReferenceKey(kind, system, externalReference))
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrHandoffReferenceDuplicate, externalReference, system, strings.ToLower(kind))
+	}
+
+	id, err := newAssetID(ctx, "HANDOFF")
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Handoff{
+		ID:                id,
+		Kind:              kind,
+		WasteID:           wasteId,
+		System:            system,
+		ExternalReference: externalReference,
+		ContentHash:       contentHash,
+		HashAlgorithm:     models.HandoffHashAlgorithm,
+		RecordedBy:        actor,
+		RecordedByMSP:     mspID,
+		CreatedAt:         now,
+	}, nil
+}
+
+// putHandoff stores a handoff and indexes it under its external reference
+func putHandoff(ctx contractapi.TransactionContextInterface, handoff *models.Handoff) error {
+	if err := newAssetStore(ctx).Put("HANDOFF_"+handoff.ID, handoff); err != nil {
+		return err
+	}
+
+	return newAssetStore(ctx).Put(handoffReferenceKey(handoff.Kind, handoff.System, handoff.ExternalReference), handoff.ID)
+}
+
+func handoffReferenceKey(kind string, system string, externalReference string) string {
+	return "HANDOFFREF_" + kind + "_" + system + "_" + externalReference
+}
+
+// checkHandoffVisible lets the recording organization, admins and those who
+// may see the lot read a handoff
+func (s *SmartContract) checkHandoffVisible(ctx contractapi.TransactionContextInterface, handoff *models.Handoff) error {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if handoff.RecordedByMSP == mspID || isAdmin(ctx) {
+		return nil
+	}
+
+	waste, err := s.readWaste(ctx, handoff.WasteID)
+	if err != nil {
+		return err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return err
+	}
+	if !canView {
+		return newError(ctx, ErrWasteNotVisible, handoff.WasteID)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/chaincode/internal/store"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultHistoryMaxEntries caps embedded histories unless history.maxEntries
+// is configured; 0 disables compaction
+const defaultHistoryMaxEntries = 50
+
+// GetArchivedHistory returns a page of the history checkpoints of a waste,
+// extraction or recycling; pass the returned bookmark to get the next page
+func (s *SmartContract) GetArchivedHistory(ctx contractapi.TransactionContextInterface, assetType string, id string, pageSize int, bookmark string) (*models.ArchivedHistoryPage, error) {
+	if _, _, err := s.visibleHistory(ctx, assetType, id); err != nil {
+		return nil, err
+	}
+	if pageSize <= 0 {
+		pageSize = defaultNotificationPageSize
+	}
+
+	prefix := historyArchivePrefix(assetType, id)
+	start := prefix
+	if bookmark != "" {
+		start = prefix + bookmark
+	}
+
+	page := &models.ArchivedHistoryPage{Checkpoints: []*models.HistoryCheckpoint{}}
+	err := newAssetStore(ctx).Range(start, prefix+"~", func(_ string, value []byte) error {
+		var checkpoint models.HistoryCheckpoint
+		if err := json.Unmarshal(value, &checkpoint); err != nil {
+			return err
+		}
+		// IDs sharing a prefix (A and A_B) share the key range
+		if checkpoint.AssetID != id || checkpointKeySuffix(checkpoint.FirstIndex) == bookmark {
+			return nil
+		}
+		if len(page.Checkpoints) == pageSize {
+			page.Bookmark = checkpointKeySuffix(page.Checkpoints[pageSize-1].FirstIndex)
+			return store.ErrStopRange
+		}
+		page.Checkpoints = append(page.Checkpoints, &checkpoint)
+
+		return nil
+	})
+	if budgetExceeded(err) && len(page.Checkpoints) > 0 {
+		page.Bookmark = checkpointKeySuffix(page.Checkpoints[len(page.Checkpoints)-1].FirstIndex)
+		page.Truncated = len(page.Checkpoints) < pageSize
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// compactHistory keeps at most history.maxEntries entries embedded in an
+// asset; once exceeded, all but the newest half are rolled into a checkpoint
+// so that compaction happens in batches. It returns the kept entries and the
+// new total of archived entries.
+func compactHistory(ctx contractapi.TransactionContextInterface, assetType string, id string, history []models.History, archived int) ([]models.History, int, error) {
+	maxEntries := configInt(ctx, "history", "maxEntries", defaultHistoryMaxEntries)
+	if maxEntries <= 0 || len(history) <= maxEntries {
+		return history, archived, nil
+	}
+
+	keep := maxEntries / 2
+	if keep < 1 {
+		keep = 1
+	}
+	rolled := len(history) - keep
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	checkpoint := &models.HistoryCheckpoint{
+		AssetType:  assetType,
+		AssetID:    id,
+		FirstIndex: archived,
+		Entries:    history[:rolled],
+		ArchivedAt: now,
+	}
+	if err := putHistoryCheckpoint(ctx, checkpoint); err != nil {
+		return nil, 0, err
+	}
+
+	kept := make([]models.History, keep)
+	copy(kept, history[rolled:])
+
+	return kept, archived + rolled, nil
+}
+
+// loadHistoryCheckpoints returns all checkpoints of an asset, oldest first
+func loadHistoryCheckpoints(ctx contractapi.TransactionContextInterface, assetType string, id string) ([]*models.HistoryCheckpoint, error) {
+	prefix := historyArchivePrefix(assetType, id)
+	var checkpoints []*models.HistoryCheckpoint
+	err := newAssetStore(ctx).Range(prefix, prefix+"~", func(_ string, value []byte) error {
+		var checkpoint models.HistoryCheckpoint
+		if err := json.Unmarshal(value, &checkpoint); err != nil {
+			return err
+		}
+		if checkpoint.AssetID == id {
+			checkpoints = append(checkpoints, &checkpoint)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].FirstIndex < checkpoints[j].FirstIndex })
+
+	return checkpoints, nil
+}
+
+// scrubArchivedHistory applies scrubHistory to every checkpoint of an asset
+func scrubArchivedHistory(ctx contractapi.TransactionContextInterface, assetType string, id string, replacements map[string]string) error {
+	checkpoints, err := loadHistoryCheckpoints(ctx, assetType, id)
+	if err != nil {
+		return err
+	}
+	for _, checkpoint := range checkpoints {
+		checkpoint.Entries = scrubHistory(checkpoint.Entries, replacements)
+		if err := putHistoryCheckpoint(ctx, checkpoint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func putHistoryCheckpoint(ctx contractapi.TransactionContextInterface, checkpoint *models.HistoryCheckpoint) error {
+	return newAssetStore(ctx).Put(historyArchivePrefix(checkpoint.AssetType, checkpoint.AssetID)+checkpointKeySuffix(checkpoint.FirstIndex), checkpoint)
+}
+
+func historyArchivePrefix(assetType string, id string) string {
+	return "HISTORY_" + assetType + "_" + id + "_"
+}
+
+// checkpointKeySuffix zero-pads the first index so keys sort chronologically
+func checkpointKeySuffix(firstIndex int) string {
+	return fmt.Sprintf("%010d", firstIndex)
+}
@@ -0,0 +1,115 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// diffIgnoredFields change on every write or hold the history itself
+var diffIgnoredFields = map[string]bool{
+	"history":         true,
+	"archivedHistory": true,
+	"version":         true,
+	"updatedAt":       true,
+}
+
+// recordFieldChanges compares an asset about to be written under key with
+// its stored version and attaches the fields that differ to the last entry
+// the update appended to its history; an update that appended none gets a
+// FIELDS_CHANGED entry. New assets and unchanged ones are left as they are.
+func recordFieldChanges(ctx contractapi.TransactionContextInterface, key string, asset interface{}, history []models.History, archived int) ([]models.History, error) {
+	var stored map[string]interface{}
+	found, err := newAssetStore(ctx).Get(key, &stored)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, key, err)
+	}
+	if !found {
+		return history, nil
+	}
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return nil, err
+	}
+	var current map[string]interface{}
+	if err := json.Unmarshal(assetJSON, &current); err != nil {
+		return nil, err
+	}
+
+	changes := []models.FieldChange{}
+	diffFields("", stored, current, &changes)
+	if len(changes) == 0 {
+		return history, nil
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	storedEntries, _ := stored["history"].([]interface{})
+	storedArchived, _ := stored["archivedHistory"].(float64)
+	if len(history) <= len(storedEntries)+int(storedArchived)-archived {
+		actor, err := callerID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		now, err := txTimestamp(ctx)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, models.History{
+			Timestamp: now,
+			Action:    "FIELDS_CHANGED",
+			Actor:     actor,
+			Details:   fmt.Sprintf("%d fields changed", len(changes)),
+		})
+	}
+	history[len(history)-1].Changes = changes
+
+	return history, nil
+}
+
+// diffFields appends a change for every field of before and after that
+// differs, descending into objects
+func diffFields(prefix string, before map[string]interface{}, after map[string]interface{}, changes *[]models.FieldChange) {
+	fields := map[string]bool{}
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	for field := range fields {
+		if prefix == "" && diffIgnoredFields[field] {
+			continue
+		}
+		path := field
+		if prefix != "" {
+			path = prefix + "." + field
+		}
+		beforeObject, beforeIsObject := before[field].(map[string]interface{})
+		afterObject, afterIsObject := after[field].(map[string]interface{})
+		if beforeIsObject && afterIsObject {
+			diffFields(path, beforeObject, afterObject, changes)
+			continue
+		}
+		oldValue, newValue := fieldText(before[field]), fieldText(after[field])
+		if oldValue != newValue {
+			*changes = append(*changes, models.FieldChange{Field: path, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+}
+
+// fieldText renders a decoded JSON value for a field change
+func fieldText(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		text, _ := json.Marshal(value)
+		return string(text)
+	}
+}
@@ -0,0 +1,264 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Identity bindings are keyed by enrollment; aliases map every certificate
+// identity seen (hashed, as they are long DN strings) to its binding key
+const (
+	identityPrefix      = "IDENTITY_"
+	identityAliasPrefix = "IDALIAS_"
+)
+
+// RebindParticipantIdentity re-associates an enrollment of an organization
+// with an existing participant, for certificates re-issued under a new
+// enrollment ID or participants whose certificates were renewed before
+// bindings existed. participantId is an identity found in history; records
+// made under the enrollment's previous participant ID keep resolving to the
+// new one. Admin only.
+func (s *SmartContract) RebindParticipantIdentity(ctx contractapi.TransactionContextInterface, mspId string, enrollmentId string, participantId string, reason string) (*models.IdentityBinding, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	mspId = strings.TrimSpace(mspId)
+	enrollmentId = strings.TrimSpace(enrollmentId)
+	participantId = strings.TrimSpace(participantId)
+	if mspId == "" || enrollmentId == "" || participantId == "" {
+		return nil, newError(ctx, ErrIdentityBindingFieldsRequired)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, newError(ctx, ErrRebindReasonRequired)
+	}
+
+	// A participant bound to another enrollment is merged into this one
+	target, err := resolveIdentity(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	if target.Binding != nil && target.Binding.MSP != mspId {
+		return nil, newError(ctx, ErrParticipantOrganizationMismatch, target.ParticipantID, target.Binding.MSP, mspId)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	binding, err := readIdentityBinding(ctx, mspId, enrollmentId)
+	if err != nil {
+		return nil, err
+	}
+	if binding == nil {
+		binding = &models.IdentityBinding{MSP: mspId, EnrollmentID: enrollmentId, BoundAt: now, Identities: []string{}, History: []models.History{}}
+	}
+	previous := binding.ParticipantID
+	binding.ParticipantID = target.ParticipantID
+	identities := []string{target.ParticipantID, previous}
+	if target.Binding != nil {
+		identities = append(identities, target.Binding.Identities...)
+	}
+	for _, identity := range identities {
+		if identity != "" && !binding.HasIdentity(identity) {
+			binding.Identities = append(binding.Identities, identity)
+		}
+	}
+	binding.UpdatedAt = now
+	binding.History = append(binding.History, models.History{
+		Timestamp: now,
+		Action:    "IDENTITY_REBOUND",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Rebound from %q to %q: %s", previous, target.ParticipantID, reason),
+	})
+
+	if err := putIdentityBinding(ctx, binding); err != nil {
+		return nil, err
+	}
+
+	return binding, nil
+}
+
+// ResolveParticipantIdentity returns the participant an identity recorded
+// in history (an actor, owner or signer) stands for today
+func (s *SmartContract) ResolveParticipantIdentity(ctx contractapi.TransactionContextInterface, identity string) (*models.IdentityResolution, error) {
+	if strings.TrimSpace(identity) == "" {
+		return nil, newError(ctx, ErrIdentityRequired)
+	}
+
+	return resolveIdentity(ctx, identity)
+}
+
+// GetIdentityBindings returns the identity bindings of an organization, or
+// of all organizations when mspId is empty. Admin only.
+func (s *SmartContract) GetIdentityBindings(ctx contractapi.TransactionContextInterface, mspId string) ([]*models.IdentityBinding, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	prefix := identityPrefix
+	if mspId != "" {
+		prefix += mspId + "_"
+	}
+	bindings := []*models.IdentityBinding{}
+	err := newAssetStore(ctx).Range(prefix, prefix+"~", func(_ string, value []byte) error {
+		var binding models.IdentityBinding
+		if err := json.Unmarshal(value, &binding); err != nil {
+			return err
+		}
+		bindings = append(bindings, &binding)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+// callerParticipant resolves the caller to its participant ID through the
+// binding of its enrollment, binding it on first sight and recording
+// renewed certificates. Callers without an enrollment ID are identified by
+// their certificate identity, as before bindings existed.
+func callerParticipant(ctx contractapi.TransactionContextInterface, identity string) (string, error) {
+	mspID, enrollmentID, err := callerEnrollment(ctx)
+	if err != nil || enrollmentID == "" {
+		return identity, err
+	}
+
+	binding, err := readIdentityBinding(ctx, mspID, enrollmentID)
+	if err != nil {
+		return "", err
+	}
+	if binding != nil && binding.HasIdentity(identity) {
+		return binding.ParticipantID, nil
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+	if binding == nil {
+		binding = &models.IdentityBinding{
+			ParticipantID: identity,
+			MSP:           mspID,
+			EnrollmentID:  enrollmentID,
+			Identities:    []string{},
+			BoundAt:       now,
+			History:       []models.History{},
+		}
+	}
+	action := "IDENTITY_BOUND"
+	if len(binding.Identities) > 0 {
+		action = "CERTIFICATE_RENEWED"
+	}
+	binding.Identities = append(binding.Identities, identity)
+	binding.UpdatedAt = now
+	binding.History = append(binding.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     binding.ParticipantID,
+		Details:   fmt.Sprintf("Certificate identity %d of enrollment %s", len(binding.Identities), enrollmentID),
+	})
+	if err := putIdentityBinding(ctx, binding); err != nil {
+		return "", err
+	}
+
+	return binding.ParticipantID, nil
+}
+
+// callerEnrollment returns the caller's MSP ID and enrollment ID: the
+// hf.EnrollmentID attribute Fabric CA embeds in certificates, else the
+// subject common name. Both survive certificate renewal, unlike the
+// certificate identity (subject and issuer).
+func callerEnrollment(ctx contractapi.TransactionContextInterface) (string, string, error) {
+	identity := ctx.GetClientIdentity()
+	mspID, err := identity.GetMSPID()
+	if err != nil {
+		return "", "", newError(ctx, ErrIdentity, err)
+	}
+	if enrollmentID, found, err := identity.GetAttributeValue("hf.EnrollmentID"); err == nil && found && enrollmentID != "" {
+		return mspID, enrollmentID, nil
+	}
+	cert, err := identity.GetX509Certificate()
+	if err != nil || cert == nil {
+		return mspID, "", nil
+	}
+
+	return mspID, cert.Subject.CommonName, nil
+}
+
+// resolveIdentity follows an identity's alias to its binding
+func resolveIdentity(ctx contractapi.TransactionContextInterface, identity string) (*models.IdentityResolution, error) {
+	resolution := &models.IdentityResolution{Identity: identity, ParticipantID: identity}
+
+	var bindingKey string
+	found, err := newAssetStore(ctx).Get(identityAliasKey(identity), &bindingKey)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, identityAliasKey(identity), err)
+	}
+	if !found {
+		return resolution, nil
+	}
+	var binding models.IdentityBinding
+	found, err = newAssetStore(ctx).Get(bindingKey, &binding)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, bindingKey, err)
+	}
+	if found {
+		resolution.ParticipantID = binding.ParticipantID
+		resolution.Binding = &binding
+	}
+
+	return resolution, nil
+}
+
+func identityBindingKey(mspID string, enrollmentID string) string {
+	return identityPrefix + mspID + "_" + enrollmentID
+}
+
+func identityAliasKey(identity string) string {
+	sum := sha256.Sum256([]byte(identity))
+	return identityAliasPrefix + hex.EncodeToString(sum[:])
+}
+
+// readIdentityBinding returns the binding of an enrollment, or nil if none
+func readIdentityBinding(ctx contractapi.TransactionContextInterface, mspID string, enrollmentID string) (*models.IdentityBinding, error) {
+	var binding models.IdentityBinding
+	found, err := newAssetStore(ctx).Get(identityBindingKey(mspID, enrollmentID), &binding)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, identityBindingKey(mspID, enrollmentID), err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &binding, nil
+}
+
+// putIdentityBinding stores a binding and points the aliases of all its
+// identities to it
+func putIdentityBinding(ctx contractapi.TransactionContextInterface, binding *models.IdentityBinding) error {
+	key := identityBindingKey(binding.MSP, binding.EnrollmentID)
+	assets := newAssetStore(ctx)
+	if err := assets.Put(key, binding); err != nil {
+		return err
+	}
+	for _, identity := range binding.Identities {
+		if err := assets.Put(identityAliasKey(identity), key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package contract
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// newAssetID generates an ID of the form PREFIX-20060102T150405Z-<tx>-<n>
+// from the transaction timestamp, the first characters of the transaction ID
+// and a per-transaction sequence number, so that several assets created by
+// one transaction never collide
+func newAssetID(ctx contractapi.TransactionContextInterface, prefix string) (string, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", newError(ctx, ErrTimestamp, err)
+	}
+	txID := ctx.GetStub().GetTxID()
+	fragment := txID
+	if len(fragment) > 8 {
+		fragment = fragment[:8]
+	}
+
+	sequence := transactionState(ctx).next("ID")
+
+	stamp := time.Unix(ts.GetSeconds(), 0).UTC().Format("20060102T150405Z")
+
+	return fmt.Sprintf("%s-%s-%s-%d", prefix, stamp, fragment, sequence), nil
+}
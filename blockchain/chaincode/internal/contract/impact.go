@@ -0,0 +1,300 @@
+package contract
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultCertificationDocuments are the lot document types that show the
+// producing farm is certified, until impact.certificationDocuments (a
+// comma-separated list) says otherwise
+const defaultCertificationDocuments = "ORGANIC_CERTIFICATE,GLOBALGAP_CERTIFICATE,FAIRTRADE_CERTIFICATE"
+
+// ComputeImpactReport aggregates the impact of the lots a buyer organization
+// (its MSP ID; the caller's when empty) acquired between from and to
+// (YYYY-MM-DD, inclusive, either may be empty): invoiced to it or delivered
+// to it under a supply contract. It reports the quantity diverted from
+// landfill, the CO2e its recyclings avoided and the share of certified
+// farms, with each lot's figures and the transactions that wrote them.
+// Only the buyer or an admin may compute it.
+func (s *SmartContract) ComputeImpactReport(ctx contractapi.TransactionContextInterface, buyerMsp string, from string, to string) (*models.ImpactReport, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if buyerMsp == "" {
+		buyerMsp = mspID
+	}
+	if buyerMsp != mspID && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrImpactReportForbidden, buyerMsp)
+	}
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImpactReport{
+		Buyer:              buyerMsp,
+		From:               from,
+		To:                 to,
+		CertificationTypes: splitList(strings.ToUpper(configString(ctx, "impact", "certificationDocuments", defaultCertificationDocuments))),
+		Lots:               []models.ImpactLot{},
+		ComputedAt:         now,
+	}
+	lots, err := acquiredLots(ctx, buyerMsp, from, to)
+	if err != nil {
+		return nil, err
+	}
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	farms := map[string]bool{}
+	for _, lot := range lots {
+		waste, err := s.readWaste(ctx, lot.WasteID)
+		if err != nil {
+			return nil, err
+		}
+		if err := measureImpactLot(ctx, &lot, waste, recyclings, report.CertificationTypes); err != nil {
+			return nil, err
+		}
+
+		report.LotCount++
+		report.AcquiredQuantity += lot.Quantity
+		report.DivertedQuantity += lot.Diverted
+		report.RecycledQuantity += lot.Recycled
+		report.DonatedQuantity += lot.Donated
+		report.LandfilledQuantity += lot.Landfilled
+		report.CO2eAvoided += lot.CO2eAvoided
+		if lot.CompletionCertID != "" {
+			report.FullyValorizedLots++
+		}
+		farms[lot.Farm] = farms[lot.Farm] || len(lot.Certifications) > 0
+		report.Lots = append(report.Lots, lot)
+	}
+
+	for _, certified := range farms {
+		report.Farms++
+		if certified {
+			report.CertifiedFarms++
+		}
+	}
+	report.AcquiredQuantity = roundImpact(report.AcquiredQuantity)
+	report.DivertedQuantity = roundImpact(report.DivertedQuantity)
+	report.RecycledQuantity = roundImpact(report.RecycledQuantity)
+	report.DonatedQuantity = roundImpact(report.DonatedQuantity)
+	report.LandfilledQuantity = roundImpact(report.LandfilledQuantity)
+	report.CO2eAvoided = roundImpact(report.CO2eAvoided)
+	if report.AcquiredQuantity > 0 {
+		report.DiversionRate = math.Round(report.DivertedQuantity/report.AcquiredQuantity*10000) / 100
+	}
+	if report.Farms > 0 {
+		report.CertifiedFarmShare = math.Round(float64(report.CertifiedFarms)/float64(report.Farms)*10000) / 100
+	}
+
+	return report, nil
+}
+
+// acquiredLots lists the lots invoiced or delivered to a buyer within the
+// period, oldest acquisition first; a lot both invoiced and delivered
+// counts once, by its earliest acquisition
+func acquiredLots(ctx contractapi.TransactionContextInterface, buyerMSP string, from string, to string) ([]models.ImpactLot, error) {
+	byWaste := map[string]models.ImpactLot{}
+	acquire := func(lot models.ImpactLot) {
+		if existing, found := byWaste[lot.WasteID]; !found || lot.AcquiredAt < existing.AcquiredAt {
+			byWaste[lot.WasteID] = lot
+		}
+	}
+
+	invoices, err := loadInvoices(ctx, func(invoice *models.Invoice) bool {
+		return invoice.BuyerMSP == buyerMSP && invoice.WasteID != "" && invoice.Status != models.InvoiceVoid && onDayBetween(invoice.IssuedAt, from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, invoice := range invoices {
+		acquire(models.ImpactLot{
+			WasteID:       invoice.WasteID,
+			AcquiredBy:    models.AcquiredByInvoice,
+			AcquisitionID: invoice.ID,
+			AcquiredAt:    invoice.IssuedAt,
+		})
+	}
+
+	contracts, err := loadSupplyContracts(ctx, func(contract *models.SupplyContract) bool {
+		return contract.Buyer == buyerMSP
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, contract := range contracts {
+		for _, delivery := range contract.Deliveries {
+			if !onDayBetween(delivery.DeliveredAt, from, to) {
+				continue
+			}
+			acquire(models.ImpactLot{
+				WasteID:       delivery.WasteID,
+				AcquiredBy:    models.AcquiredBySupplyDelivery,
+				AcquisitionID: contract.ID,
+				AcquiredAt:    delivery.DeliveredAt,
+				Quantity:      delivery.Quantity,
+			})
+		}
+	}
+
+	lots := make([]models.ImpactLot, 0, len(byWaste))
+	for _, lot := range byWaste {
+		lots = append(lots, lot)
+	}
+	sort.Slice(lots, func(i, j int) bool {
+		if lots[i].AcquiredAt != lots[j].AcquiredAt {
+			return lots[i].AcquiredAt < lots[j].AcquiredAt
+		}
+		return lots[i].WasteID < lots[j].WasteID
+	})
+
+	return lots, nil
+}
+
+// measureImpactLot fills in what an acquired lot diverted from landfill,
+// the avoided emissions of the recyclings it went into and its farm's
+// certifications, with the ledger sources of each figure. Invoiced lots are
+// acquired whole; delivered ones for the quantity delivered.
+func measureImpactLot(ctx contractapi.TransactionContextInterface, lot *models.ImpactLot, waste *models.Waste, recyclings []*models.Recycling, certificationTypes []string) error {
+	if lot.Quantity == 0 || lot.Quantity > waste.Quantity {
+		lot.Quantity = waste.Quantity
+	}
+	lot.Reference = waste.Reference
+	lot.Farm = waste.ParticipantID
+	if lot.Farm == "" {
+		lot.Farm = waste.Owner
+	}
+	lot.CompletionCertID = waste.CertificateID
+	lot.Certifications = []models.Document{}
+	lot.Recyclings = []models.ImpactContribution{}
+
+	// What the lot still held when it was donated or disposed of left it
+	// that way; the rest of the acquired quantity was valorized
+	share := 1.0
+	if waste.Quantity > 0 {
+		share = lot.Quantity / waste.Quantity
+	}
+	remaining := math.Max(waste.Quantity-waste.Consumed, 0) * share
+	switch waste.Status {
+	case models.WasteDonated:
+		lot.Donated = roundImpact(remaining)
+	case models.WasteDisposed:
+		lot.Landfilled = roundImpact(remaining)
+	}
+	lot.Diverted = roundImpact(lot.Quantity - lot.Landfilled)
+
+	acquisitionKey := "INVOICE_" + lot.AcquisitionID
+	acquisitionType := "INVOICE"
+	if lot.AcquiredBy == models.AcquiredBySupplyDelivery {
+		acquisitionKey = "SUPPLY_" + lot.AcquisitionID
+		acquisitionType = "SUPPLY_CONTRACT"
+	}
+	acquisition, err := impactSource(ctx, acquisitionType, lot.AcquisitionID, acquisitionKey, lot.AcquiredAt)
+	if err != nil {
+		return err
+	}
+	wasteSource, err := impactSource(ctx, "WASTE", waste.ID, "WASTE_"+waste.ID, waste.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	lot.Sources = []models.ImpactSource{acquisition, wasteSource}
+
+	for _, recycling := range recyclings {
+		inputs := recycling.InputLots()
+		total := 0.0
+		taken := 0.0
+		for _, input := range inputs {
+			total += input.Quantity
+			if input.WasteID == waste.ID {
+				taken += input.Quantity
+			}
+		}
+		if taken == 0 {
+			continue
+		}
+		source, err := impactSource(ctx, "RECYCLING", recycling.ID, "RECYCLING_"+recycling.ID, recycling.CreatedAt)
+		if err != nil {
+			return err
+		}
+		contribution := models.ImpactContribution{
+			RecyclingID: recycling.ID,
+			Method:      recycling.Method,
+			Quantity:    roundImpact(taken * share),
+			CO2eAvoided: roundImpact(recycling.CO2eAvoided * taken / total * share),
+			TxID:        source.TxID,
+		}
+		lot.Recycled += contribution.Quantity
+		lot.CO2eAvoided += contribution.CO2eAvoided
+		lot.Recyclings = append(lot.Recyclings, contribution)
+		lot.Sources = append(lot.Sources, source)
+	}
+	lot.Recycled = roundImpact(lot.Recycled)
+	lot.CO2eAvoided = roundImpact(lot.CO2eAvoided)
+
+	for _, document := range waste.Documents {
+		for _, docType := range certificationTypes {
+			if strings.EqualFold(document.Type, docType) {
+				lot.Certifications = append(lot.Certifications, document)
+			}
+		}
+	}
+
+	return nil
+}
+
+// impactSource names the transaction that wrote the version of a key
+// current at the RFC3339 time at, from the key's ledger history; the
+// latest transaction when at cannot be read
+func impactSource(ctx contractapi.TransactionContextInterface, assetType string, id string, key string, at string) (models.ImpactSource, error) {
+	source := models.ImpactSource{AssetType: assetType, ID: id}
+	until, err := time.Parse(time.RFC3339, at)
+	bounded := err == nil
+
+	iterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return source, newError(ctx, ErrHistoryRead, key, err)
+	}
+	defer iterator.Close()
+
+	// Modifications are not guaranteed to come in order
+	var latest time.Time
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return source, err
+		}
+		ts := modification.GetTimestamp()
+		modified := time.Unix(ts.GetSeconds(), int64(ts.GetNanos())).UTC()
+		if (bounded && modified.Truncate(time.Second).After(until)) || (source.TxID != "" && !modified.After(latest)) {
+			continue
+		}
+		latest = modified
+		source.TxID = modification.GetTxId()
+		source.Timestamp = modified.Format(time.RFC3339)
+	}
+
+	return source, nil
+}
+
+func roundImpact(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
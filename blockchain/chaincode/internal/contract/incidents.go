@@ -0,0 +1,471 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// incidentSeverities lists the severities from least to most serious
+var incidentSeverities = []string{models.SeverityLow, models.SeverityMedium, models.SeverityHigh, models.SeverityCritical}
+
+var incidentKinds = []string{models.IncidentAccident, models.IncidentSpill, models.IncidentEmission, models.IncidentFire, models.IncidentOther}
+
+// ReportIncident records an accident or environmental incident linked to a
+// facility, a shipment and/or a lot (at least one); the ID is generated when
+// id is empty and occurredAt (RFC 3339) defaults to now. The facility
+// operator, else the shipment carrier, else the lot owner is responsible for
+// handling it and is notified.
+func (s *SmartContract) ReportIncident(ctx contractapi.TransactionContextInterface, id string, facilityId string, shipmentId string, wasteId string, kind string, severity string, description string, occurredAt string) (*models.Incident, error) {
+	kind = strings.ToUpper(strings.TrimSpace(kind))
+	if !isIncidentKind(kind) {
+		return nil, newError(ctx, ErrIncidentKindUnsupported, kind, strings.Join(incidentKinds, ", "))
+	}
+	severity = strings.ToUpper(strings.TrimSpace(severity))
+	if severityRank(severity) < 0 {
+		return nil, newError(ctx, ErrSeverityUnsupported, severity, strings.Join(incidentSeverities, ", "))
+	}
+	if description == "" {
+		return nil, newError(ctx, ErrIncidentDescriptionRequired)
+	}
+	if facilityId == "" && shipmentId == "" && wasteId == "" {
+		return nil, newError(ctx, ErrIncidentSubjectRequired)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if occurredAt == "" {
+		occurredAt = now
+	} else if _, err := time.Parse(time.RFC3339, occurredAt); err != nil {
+		return nil, newError(ctx, ErrOccurrenceTimeInvalid, occurredAt)
+	} else if occurredAt > now {
+		return nil, newError(ctx, ErrOccurrenceTimeFuture)
+	}
+
+	var waste *models.Waste
+	if wasteId != "" {
+		if waste, err = s.readWaste(ctx, wasteId); err != nil {
+			return nil, err
+		}
+	}
+	responsible := ""
+	if waste != nil {
+		responsible = waste.OwnerMSP
+	}
+	if shipmentId != "" {
+		shipment, err := s.ReadShipment(ctx, shipmentId)
+		if err != nil {
+			return nil, err
+		}
+		responsible = shipment.CarrierMSP
+	}
+	if facilityId != "" {
+		facility, err := s.ReadFacility(ctx, facilityId)
+		if err != nil {
+			return nil, err
+		}
+		responsible = facility.Operator
+	}
+
+	if id == "" {
+		if id, err = newAssetID(ctx, "INCIDENT"); err != nil {
+			return nil, err
+		}
+	}
+	exists, err := newAssetStore(ctx).Exists("INCIDENT_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrIncidentAlreadyExists, id)
+	}
+
+	reporter, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if responsible == "" {
+		responsible = mspID
+	}
+
+	incident := &models.Incident{
+		ID:                id,
+		FacilityID:        facilityId,
+		ShipmentID:        shipmentId,
+		WasteID:           wasteId,
+		Kind:              kind,
+		Severity:          severity,
+		Description:       description,
+		OccurredAt:        occurredAt,
+		Reporter:          reporter,
+		ReporterMSP:       mspID,
+		ResponsibleMSP:    responsible,
+		CorrectiveActions: []models.CorrectiveAction{},
+		Status:            models.IncidentOpen,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		History: []models.History{{
+			Timestamp: now,
+			Action:    "REPORTED",
+			Actor:     reporter,
+			Details:   fmt.Sprintf("%s %s incident", severity, kind),
+		}},
+	}
+
+	if waste != nil {
+		waste.UpdatedAt = now
+		waste.History = append(waste.History, models.History{
+			Timestamp: now,
+			Action:    "INCIDENT_REPORTED",
+			Actor:     reporter,
+			Details:   fmt.Sprintf("%s %s incident %s", severity, kind, id),
+		})
+		if err := s.putWaste(ctx, waste); err != nil {
+			return nil, err
+		}
+	}
+	if err := putIncident(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	if responsible != mspID {
+		message := fmt.Sprintf("%s reported a %s %s incident: %s", mspID, severity, kind, description)
+		if err := notify(ctx, responsible, models.NotifyIncidentReported, "INCIDENT_"+id, message); err != nil {
+			return nil, err
+		}
+	}
+
+	return incident, nil
+}
+
+// AddCorrectiveAction adds a corrective action to an open incident; dueDate
+// (YYYY-MM-DD) is optional. The reporting and responsible organizations and
+// admins may add actions.
+func (s *SmartContract) AddCorrectiveAction(ctx contractapi.TransactionContextInterface, incidentId string, description string, dueDate string) (*models.Incident, error) {
+	if description == "" {
+		return nil, newError(ctx, ErrCorrectiveActionRequired)
+	}
+	if dueDate != "" {
+		if _, err := time.Parse("2006-01-02", dueDate); err != nil {
+			return nil, newError(ctx, ErrDueDateInvalid, dueDate)
+		}
+	}
+
+	incident, actor, now, err := s.openIncidentForUpdate(ctx, incidentId)
+	if err != nil {
+		return nil, err
+	}
+
+	line := len(incident.CorrectiveActions) + 1
+	incident.CorrectiveActions = append(incident.CorrectiveActions, models.CorrectiveAction{
+		Line:        line,
+		Description: description,
+		DueDate:     dueDate,
+		AddedBy:     actor,
+		AddedAt:     now,
+	})
+	incident.UpdatedAt = now
+	incident.History = append(incident.History, models.History{
+		Timestamp: now,
+		Action:    "CORRECTIVE_ACTION_ADDED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Action %d: %s", line, description),
+	})
+
+	if err := putIncident(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// CompleteCorrectiveAction marks a corrective action (by line) as done
+func (s *SmartContract) CompleteCorrectiveAction(ctx contractapi.TransactionContextInterface, incidentId string, line int, notes string) (*models.Incident, error) {
+	incident, actor, now, err := s.openIncidentForUpdate(ctx, incidentId)
+	if err != nil {
+		return nil, err
+	}
+	if line < 1 || line > len(incident.CorrectiveActions) {
+		return nil, newError(ctx, ErrCorrectiveActionNotFound, incidentId, line)
+	}
+	action := &incident.CorrectiveActions[line-1]
+	if action.CompletedAt != "" {
+		return nil, newError(ctx, ErrCorrectiveActionCompleted, line, incidentId)
+	}
+
+	action.CompletedBy = actor
+	action.CompletedAt = now
+	action.Notes = notes
+	incident.UpdatedAt = now
+	incident.History = append(incident.History, models.History{
+		Timestamp: now,
+		Action:    "CORRECTIVE_ACTION_COMPLETED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Action %d completed", line),
+	})
+
+	if err := putIncident(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// RecordRegulatorNotification records that the environmental regulator was
+// told about an incident, with the regulator's case reference if any
+func (s *SmartContract) RecordRegulatorNotification(ctx contractapi.TransactionContextInterface, incidentId string, reference string) (*models.Incident, error) {
+	incident, actor, now, err := s.openIncidentForUpdate(ctx, incidentId)
+	if err != nil {
+		return nil, err
+	}
+	if incident.RegulatorNotified {
+		return nil, newError(ctx, ErrRegulatorAlreadyNotified, incidentId)
+	}
+
+	incident.RegulatorNotified = true
+	incident.RegulatorNotice = &models.RegulatorNotice{
+		Reference:  reference,
+		NotifiedBy: actor,
+		NotifiedAt: now,
+	}
+	incident.UpdatedAt = now
+	incident.History = append(incident.History, models.History{
+		Timestamp: now,
+		Action:    "REGULATOR_NOTIFIED",
+		Actor:     actor,
+		Details:   reference,
+	})
+
+	if err := putIncident(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// CloseIncident closes an incident once it has at least one corrective
+// action and all of them are done. Incidents at or above the
+// "incident.regulatorSeverity" setting (HIGH by default) also need the
+// regulator to have been notified.
+func (s *SmartContract) CloseIncident(ctx contractapi.TransactionContextInterface, incidentId string, notes string) (*models.Incident, error) {
+	incident, actor, now, err := s.openIncidentForUpdate(ctx, incidentId)
+	if err != nil {
+		return nil, err
+	}
+	if len(incident.CorrectiveActions) == 0 {
+		return nil, newError(ctx, ErrCorrectiveActionsMissing, incidentId)
+	}
+	for _, action := range incident.CorrectiveActions {
+		if action.CompletedAt == "" {
+			return nil, newError(ctx, ErrCorrectiveActionPending, action.Line, incidentId)
+		}
+	}
+	if regulatorRequired(ctx, incident.Severity) && !incident.RegulatorNotified {
+		return nil, newError(ctx, ErrRegulatorNotNotified, incident.Severity, incidentId)
+	}
+
+	incident.Status = models.IncidentClosed
+	incident.ClosedAt = now
+	incident.ClosureNotes = notes
+	incident.UpdatedAt = now
+	incident.History = append(incident.History, models.History{
+		Timestamp: now,
+		Action:    "CLOSED",
+		Actor:     actor,
+		Details:   notes,
+	})
+
+	if err := putIncident(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// ReadIncident returns the incident stored with the given id
+func (s *SmartContract) ReadIncident(ctx contractapi.TransactionContextInterface, id string) (*models.Incident, error) {
+	var incident models.Incident
+	found, err := newAssetStore(ctx).Get("INCIDENT_"+id, &incident)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "INCIDENT_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrIncidentNotFound, id)
+	}
+
+	return &incident, nil
+}
+
+// GetIncidents returns incidents, most serious first; facilityId, wasteId
+// and status filter them when set
+func (s *SmartContract) GetIncidents(ctx contractapi.TransactionContextInterface, facilityId string, wasteId string, status string) ([]*models.Incident, error) {
+	status = strings.ToUpper(status)
+	return loadIncidents(ctx, func(incident *models.Incident) bool {
+		return (facilityId == "" || incident.FacilityID == facilityId) &&
+			(wasteId == "" || incident.WasteID == wasteId) &&
+			(status == "" || incident.Status == status)
+	})
+}
+
+// GetFacilityCompliance returns the compliance picture of a facility: its
+// status and certifications, how lots received there between from and to
+// (YYYY-MM-DD, inclusive, either may be empty) kept the facility SLA, and
+// its open incidents. The operator, admins and auditors may query it.
+func (s *SmartContract) GetFacilityCompliance(ctx contractapi.TransactionContextInterface, facilityId string, from string, to string) (*models.FacilityCompliance, error) {
+	facility, err := s.ReadFacility(ctx, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	if !hasRole(ctx, AuditorRole) {
+		if err := requireFacilityOperator(ctx, facility); err != nil {
+			return nil, err
+		}
+	}
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+
+	compliance := &models.FacilityCompliance{
+		FacilityID:     facility.ID,
+		Name:           facility.Name,
+		Operator:       facility.Operator,
+		Status:         facility.Status,
+		Certifications: facility.Certifications,
+	}
+
+	sla, err := readSLA(ctx, models.SLAScopeFacility, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	if sla != nil {
+		wastes, err := loadWastes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		statuses := []*models.SLAStatus{}
+		for _, waste := range wastes {
+			if waste.SLA != nil && waste.SLA.SLAID == sla.ID && receivedBetween(waste.SLA, from, to) {
+				statuses = append(statuses, waste.SLA)
+			}
+		}
+		compliance.SLA = summarizeSLAs(sla.Processor, from, to, statuses)
+	}
+
+	if compliance.OpenIncidents, err = s.GetIncidents(ctx, facilityId, "", models.IncidentOpen); err != nil {
+		return nil, err
+	}
+	if len(compliance.OpenIncidents) > 0 {
+		compliance.HighestOpenSeverity = compliance.OpenIncidents[0].Severity
+	}
+
+	return compliance, nil
+}
+
+// openIncidentForUpdate reads an open incident the caller may handle (its
+// reporting or responsible organization, or an admin) along with the
+// caller identity and transaction time
+func (s *SmartContract) openIncidentForUpdate(ctx contractapi.TransactionContextInterface, incidentId string) (*models.Incident, string, string, error) {
+	incident, err := s.ReadIncident(ctx, incidentId)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if incident.Status != models.IncidentOpen {
+		return nil, "", "", newError(ctx, ErrIncidentStatusInvalid, incidentId, incident.Status)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if mspID != incident.ReporterMSP && mspID != incident.ResponsibleMSP && !isAdmin(ctx) {
+		return nil, "", "", newError(ctx, ErrIncidentUpdateForbidden, incident.ReporterMSP, incident.ResponsibleMSP, incidentId)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return incident, actor, now, nil
+}
+
+// severityRank is the position of a severity in incidentSeverities, or -1
+func severityRank(severity string) int {
+	for i, known := range incidentSeverities {
+		if known == severity {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// isIncidentKind reports whether kind is a known incident kind
+func isIncidentKind(kind string) bool {
+	for _, k := range incidentKinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// regulatorRequired reports whether incidents of a severity must be reported
+// to the regulator before closing
+func regulatorRequired(ctx contractapi.TransactionContextInterface, severity string) bool {
+	threshold := severityRank(strings.ToUpper(configString(ctx, "incident", "regulatorSeverity", models.SeverityHigh)))
+	return threshold >= 0 && severityRank(severity) >= threshold
+}
+
+func putIncident(ctx contractapi.TransactionContextInterface, incident *models.Incident) error {
+	return newAssetStore(ctx).Put("INCIDENT_"+incident.ID, incident)
+}
+
+// loadIncidents returns the incidents kept by keep, most serious first and
+// then most recent first
+func loadIncidents(ctx contractapi.TransactionContextInterface, keep func(*models.Incident) bool) ([]*models.Incident, error) {
+	incidents := []*models.Incident{}
+	err := newAssetStore(ctx).Range("INCIDENT_", "INCIDENT_~", func(_ string, value []byte) error {
+		var incident models.Incident
+		if err := json.Unmarshal(value, &incident); err != nil {
+			return err
+		}
+		if keep(&incident) {
+			incidents = append(incidents, &incident)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		if ri, rj := severityRank(incidents[i].Severity), severityRank(incidents[j].Severity); ri != rj {
+			return ri > rj
+		}
+		return incidents[i].OccurredAt > incidents[j].OccurredAt
+	})
+
+	return incidents, nil
+}
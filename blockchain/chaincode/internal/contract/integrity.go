@@ -0,0 +1,195 @@
+package contract
+
+import (
+	"fmt"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CheckIntegrity looks for extraction and recycling records left out of step
+// with what they were made from: records whose lot (or, for recyclings of an
+// extraction output, extraction) does not exist, lots not written since a
+// record used them, lots whose consumed quantity is below what direct and
+// blended recyclings took from them, and extraction output lines that do
+// not list a recycling made from them. With repair, orphaned records are
+// marked ORPHANED and lots and extractions are brought in line with their
+// records, every repaired asset passing validation before any is written.
+// Admins and auditors may check; only admins may repair.
+func (s *SmartContract) CheckIntegrity(ctx contractapi.TransactionContextInterface, repair bool) (*models.IntegrityReport, error) {
+	if repair {
+		if err := requireAdmin(ctx); err != nil {
+			return nil, err
+		}
+	} else if !isAdmin(ctx) && !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrIntegrityCheckForbidden)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	extractions, err := s.GetAllExtractions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lots := make(map[string]*models.Waste, len(wastes))
+	for _, waste := range wastes {
+		lots[waste.ID] = waste
+	}
+	runs := make(map[string]*models.Extraction, len(extractions))
+	for _, extraction := range extractions {
+		runs[extraction.ID] = extraction
+	}
+
+	report := &models.IntegrityReport{
+		CheckedAt:   now,
+		Extractions: len(extractions),
+		Recyclings:  len(recyclings),
+		Issues:      []models.IntegrityIssue{},
+	}
+	staged := newStagedWrites(ctx)
+	stagedLots := map[string]bool{}
+	stageLot := func(waste *models.Waste) {
+		if !stagedLots[waste.ID] {
+			stagedLots[waste.ID] = true
+			staged.waste(waste)
+		}
+	}
+	stagedRuns := map[string]bool{}
+	stageRun := func(extraction *models.Extraction) {
+		if !stagedRuns[extraction.ID] {
+			stagedRuns[extraction.ID] = true
+			staged.extraction(extraction)
+		}
+	}
+	found := func(issue models.IntegrityIssue, fix func()) {
+		if repair {
+			fix()
+			issue.Repaired = true
+			report.Repaired++
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+	repaired := func(details string) models.History {
+		return models.History{Timestamp: now, Action: "INTEGRITY_REPAIRED", Actor: actor, Details: details}
+	}
+
+	for _, extraction := range extractions {
+		if extraction.Status == models.RecordOrphaned {
+			continue
+		}
+		waste, ok := lots[extraction.WasteID]
+		if !ok {
+			details := fmt.Sprintf("waste %s does not exist", extraction.WasteID)
+			found(models.IntegrityIssue{Kind: models.IntegrityOrphanedRecord, AssetType: "EXTRACTION", AssetID: extraction.ID, WasteID: extraction.WasteID, Details: details}, func() {
+				extraction.Status = models.RecordOrphaned
+				extraction.History = append(extraction.History, models.History{Timestamp: now, Action: models.RecordOrphaned, Actor: actor, Details: details})
+				stageRun(extraction)
+			})
+			continue
+		}
+		if waste.UpdatedAt < extraction.CreatedAt {
+			found(models.IntegrityIssue{Kind: models.IntegrityLotNotUpdated, AssetType: "EXTRACTION", AssetID: extraction.ID, WasteID: waste.ID, Details: fmt.Sprintf("waste %s is still %s", waste.ID, waste.Status)}, func() {
+				applyStatusChange(waste, "PROCESSED", actor, fmt.Sprintf("Repaired: used for extraction %s.", extraction.ID), now)
+				stageLot(waste)
+			})
+		}
+	}
+
+	// Recyclings of an extraction output take nothing from the lot itself
+	consumed := map[string]float64{}
+	for _, recycling := range recyclings {
+		if recycling.Status == models.RecordOrphaned {
+			continue
+		}
+		orphan := func(wasteID string, details string) {
+			found(models.IntegrityIssue{Kind: models.IntegrityOrphanedRecord, AssetType: "RECYCLING", AssetID: recycling.ID, WasteID: wasteID, Details: details}, func() {
+				recycling.Status = models.RecordOrphaned
+				recycling.History = append(recycling.History, models.History{Timestamp: now, Action: models.RecordOrphaned, Actor: actor, Details: details})
+				staged.recycling(recycling)
+			})
+		}
+
+		if recycling.ExtractionID != "" {
+			extraction, ok := runs[recycling.ExtractionID]
+			if !ok {
+				orphan(recycling.WasteID, fmt.Sprintf("extraction %s does not exist", recycling.ExtractionID))
+				continue
+			}
+			outputs := extractionOutputs(extraction)
+			if recycling.OutputLine < 1 || recycling.OutputLine > len(outputs) {
+				orphan(recycling.WasteID, fmt.Sprintf("extraction %s has no output line %d", extraction.ID, recycling.OutputLine))
+				continue
+			}
+			output := &outputs[recycling.OutputLine-1]
+			linked := false
+			for _, downstream := range output.Downstream {
+				linked = linked || downstream == recycling.ID
+			}
+			if !linked {
+				found(models.IntegrityIssue{Kind: models.IntegrityOutputLinkMissing, AssetType: "RECYCLING", AssetID: recycling.ID, WasteID: extraction.WasteID, Details: fmt.Sprintf("line %d of extraction %s does not list it", recycling.OutputLine, extraction.ID)}, func() {
+					output.Consumed += recycling.Quantity
+					output.Downstream = append(output.Downstream, recycling.ID)
+					extraction.Outputs = outputs
+					extraction.History = append(extraction.History, repaired(fmt.Sprintf("%.2f units of line %d recycled as %s", recycling.Quantity, recycling.OutputLine, recycling.ID)))
+					stageRun(extraction)
+				})
+			}
+			continue
+		}
+
+		missing := ""
+		for _, input := range recycling.InputLots() {
+			if _, ok := lots[input.WasteID]; !ok {
+				missing = input.WasteID
+				break
+			}
+		}
+		if missing != "" {
+			orphan(missing, fmt.Sprintf("waste %s does not exist", missing))
+			continue
+		}
+		for _, input := range recycling.InputLots() {
+			consumed[input.WasteID] += input.Quantity
+			waste := lots[input.WasteID]
+			if waste.UpdatedAt < recycling.CreatedAt {
+				found(models.IntegrityIssue{Kind: models.IntegrityLotNotUpdated, AssetType: "RECYCLING", AssetID: recycling.ID, WasteID: waste.ID, Details: fmt.Sprintf("waste %s is still %s", waste.ID, waste.Status)}, func() {
+					applyStatusChange(waste, "RECYCLED", actor, fmt.Sprintf("Repaired: recycled as %s.", recycling.ID), now)
+					stageLot(waste)
+				})
+			}
+		}
+	}
+
+	for _, waste := range wastes {
+		used := consumed[waste.ID]
+		if waste.Consumed >= used-1e-9 {
+			continue
+		}
+		found(models.IntegrityIssue{Kind: models.IntegrityConsumptionMismatch, AssetType: "WASTE", AssetID: waste.ID, WasteID: waste.ID, Details: fmt.Sprintf("%.2f consumed, %.2f recycled", waste.Consumed, used)}, func() {
+			waste.History = append(waste.History, repaired(fmt.Sprintf("Consumed quantity raised from %.2f to %.2f to match its recyclings", waste.Consumed, used)))
+			waste.Consumed = used
+			stageLot(waste)
+		})
+	}
+
+	if err := staged.commit(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
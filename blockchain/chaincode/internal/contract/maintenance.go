@@ -0,0 +1,154 @@
+package contract
+
+import (
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RunMaintenance performs periodic housekeeping: notifications older than
+// notifications.retentionDays (default 30) are deleted, and when
+// privacy.retentionDays is set, personal data of older lots is purged.
+// Parties to supply contracts falling behind near their end are alerted and
+// lots left unprocessed past their SLA deadline are marked as breached.
+// Approvals past their deadline expire, and token settlements past theirs
+// release their holds and lots (holds a token chaincode refused to release
+// are retried). Function metric samples older than metrics.retentionDays
+// (default 7) are deleted.
+// Unless snapshot.daily is false, each run also advances the day's state
+// snapshot by one page. Steps that scan the ledger read one page within the
+// query budget per run and resume where the previous run stopped, so a
+// large ledger is covered over several runs (see the report's resume).
+// Admin only.
+func (s *SmartContract) RunMaintenance(ctx contractapi.TransactionContextInterface) (*models.MaintenanceReport, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ranAt, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := newMaintenanceRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	retentionDays := configInt(ctx, "notifications", "retentionDays", 30)
+	pruned, err := pruneNotifications(ctx, run, ranAt.AddDate(0, 0, -retentionDays))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.MaintenanceReport{RanAt: now, NotificationsPruned: pruned}
+
+	if days := configInt(ctx, "privacy", "retentionDays", 0); days > 0 {
+		if report.PersonalDataPurged, err = purgeExpiredPersonalData(ctx, run, ranAt.AddDate(0, 0, -days)); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.SupplyContractsAtRisk, err = checkSupplyCommitments(ctx, run, ranAt); err != nil {
+		return nil, err
+	}
+
+	if report.SLABreaches, err = s.checkSLABreaches(ctx, run, ranAt); err != nil {
+		return nil, err
+	}
+
+	if report.ApprovalsExpired, err = s.expireApprovals(ctx, run, ranAt); err != nil {
+		return nil, err
+	}
+
+	if report.SettlementsReleased, err = s.releaseExpiredSettlements(ctx, run, ranAt); err != nil {
+		return nil, err
+	}
+
+	metricDays := configInt(ctx, "metrics", "retentionDays", defaultMetricRetentionDays)
+	if report.MetricSamplesPruned, err = pruneMetricSamples(ctx, ranAt.AddDate(0, 0, -metricDays)); err != nil {
+		return nil, err
+	}
+
+	if configBool(ctx, "snapshot", "daily", true) {
+		if report.Snapshot, err = advanceSnapshot(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := run.save(ctx); err != nil {
+		return nil, err
+	}
+	if len(run.cursors.Cursors) > 0 {
+		report.Resume = run.cursors.Cursors
+	}
+
+	return report, nil
+}
+
+const maintenanceCursorsKey = "MAINTENANCECURSORS"
+
+// maintenanceRun carries the cursors of the steps of one maintenance run
+type maintenanceRun struct {
+	cursors *models.MaintenanceCursors
+	changed bool
+}
+
+func newMaintenanceRun(ctx contractapi.TransactionContextInterface) (*maintenanceRun, error) {
+	cursors := &models.MaintenanceCursors{}
+	if _, err := newAssetStore(ctx).Get(maintenanceCursorsKey, cursors); err != nil {
+		return nil, newError(ctx, ErrLedgerRead, maintenanceCursorsKey, err)
+	}
+	if cursors.Cursors == nil {
+		cursors.Cursors = map[string]string{}
+	}
+
+	return &maintenanceRun{cursors: cursors}, nil
+}
+
+// scan visits the next page of the assets under prefix for a step, from
+// where the step's previous run stopped
+func (r *maintenanceRun) scan(ctx contractapi.TransactionContextInterface, step string, prefix string, visit func(key string, value []byte) error) error {
+	return r.page(step, func(cursor string) (string, error) {
+		return scanPage(ctx, prefix, prefix+"~", cursor, visit)
+	})
+}
+
+// scanPrivate is scan over the entries under prefix of a private data
+// collection
+func (r *maintenanceRun) scanPrivate(ctx contractapi.TransactionContextInterface, step string, collection string, prefix string, visit func(key string, value []byte) error) error {
+	return r.page(step, func(cursor string) (string, error) {
+		return scanPrivatePage(ctx, collection, prefix, prefix+"~", cursor, visit)
+	})
+}
+
+// page reads a step's next page from its cursor and moves the cursor to
+// where the page stopped, dropping it once the step reached the end
+func (r *maintenanceRun) page(step string, read func(cursor string) (string, error)) error {
+	previous := r.cursors.Cursors[step]
+	next, err := read(previous)
+	if err != nil {
+		return err
+	}
+	if next == "" {
+		delete(r.cursors.Cursors, step)
+	} else {
+		r.cursors.Cursors[step] = next
+	}
+	r.changed = r.changed || next != previous
+
+	return nil
+}
+
+// save writes the cursors back when a step moved its own
+func (r *maintenanceRun) save(ctx contractapi.TransactionContextInterface) error {
+	if !r.changed {
+		return nil
+	}
+
+	return newAssetStore(ctx).Put(maintenanceCursorsKey, r.cursors)
+}
@@ -0,0 +1,626 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// implicitCollectionPrefix names the per-organization implicit collections
+// sealed bids are kept in, so that no other organization's peer ever holds
+// them
+const implicitCollectionPrefix = "_implicit_org_"
+
+// defaultRevealHours is how long bidders have to reveal their bids once a
+// listing expires, unless config marketplace.revealHours says otherwise
+const defaultRevealHours = 24
+
+// CreateListing offers quantity units of a lot by sealed bids until expiresAt
+// (RFC3339); only the owning organization, or the admins of a cooperative
+// allowed to list for the lot's owner, may list a lot, and the ID is
+// generated when id is empty
+func (s *SmartContract) CreateListing(ctx contractapi.TransactionContextInterface, id string, wasteId string, quantity float64, reservePrice float64, expiresAt string) (*models.Listing, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != waste.OwnerMSP {
+		granted, err := newCooperativeAccess(ctx).grants(ctx, waste.ParticipantID, models.CoopList)
+		if err != nil {
+			return nil, err
+		}
+		if !granted {
+			return nil, newError(ctx, ErrListingForbidden, waste.OwnerMSP, wasteId)
+		}
+	}
+	if quantity <= 0 || quantity > waste.Quantity {
+		return nil, newError(ctx, ErrListedQuantityInvalid, waste.Quantity)
+	}
+	if reservePrice < 0 {
+		return nil, newError(ctx, ErrReservePriceNegative)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return nil, newError(ctx, ErrExpiresAtInvalid, err)
+	}
+	// Stored in UTC so that it compares with transaction timestamps as a string
+	expiresAt = expiry.UTC().Format(time.RFC3339)
+	if expiresAt <= now {
+		return nil, newError(ctx, ErrListingExpiryPast)
+	}
+
+	listings, err := loadListings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, listing := range listings {
+		if listing.WasteID == wasteId && (listing.Status == models.ListingOpen || listing.Status == models.ListingAwarded) {
+			return nil, newError(ctx, ErrWasteAlreadyListed, wasteId, listing.ID)
+		}
+	}
+
+	if id == "" {
+		if id, err = newAssetID(ctx, "LISTING"); err != nil {
+			return nil, err
+		}
+	}
+	exists, err := newAssetStore(ctx).Exists("LISTING_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrListingAlreadyExists, id)
+	}
+
+	seller, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listing := &models.Listing{
+		ID:           id,
+		WasteID:      wasteId,
+		Seller:       seller,
+		SellerMSP:    mspID,
+		Quantity:     quantity,
+		ReservePrice: reservePrice,
+		ExpiresAt:    expiresAt,
+		Status:       models.ListingOpen,
+		Bids:         []models.BidReceipt{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "LISTED",
+				Actor:     seller,
+				Details:   fmt.Sprintf("%.2f units of waste %s listed until %s", quantity, wasteId, expiresAt),
+			},
+		},
+	}
+
+	if err := putListing(ctx, listing); err != nil {
+		return nil, err
+	}
+
+	return listing, nil
+}
+
+// SubmitBid places or replaces the caller organization's sealed bid on an open
+// listing. The bid is read from the "bid" transient entry ({"amount": ...,
+// "salt": ...}) and stored only in the organization's implicit collection;
+// the listing records who bid, when and the bid's SHA-256 commitment.
+func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, listingId string) (*models.Listing, error) {
+	listing, err := s.ReadListing(ctx, listingId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID == listing.SellerMSP {
+		return nil, newError(ctx, ErrSellerBidForbidden, listingId)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Status != models.ListingOpen || now >= listing.ExpiresAt {
+		return nil, newError(ctx, ErrListingClosed, listingId)
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	bidJSON, ok := transient["bid"]
+	if !ok {
+		return nil, newError(ctx, ErrBidAmountRequired)
+	}
+	var bid models.SealedBid
+	if err := json.Unmarshal(bidJSON, &bid); err != nil {
+		return nil, newError(ctx, ErrBidTransientInvalid, err)
+	}
+	if bid.Amount <= 0 {
+		return nil, newError(ctx, ErrBidAmountInvalid)
+	}
+	if len(bid.Salt) < 16 {
+		return nil, newError(ctx, ErrBidSaltRequired)
+	}
+
+	bidder, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bid.ListingID = listingId
+	bid.Bidder = bidder
+	bid.BidderMSP = mspID
+	bid.SubmittedAt = now
+	commitment, err := putBid(ctx, &bid)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := []models.BidReceipt{}
+	for _, receipt := range listing.Bids {
+		if receipt.BidderMSP != mspID {
+			receipts = append(receipts, receipt)
+		}
+	}
+	listing.Bids = append(receipts, models.BidReceipt{BidderMSP: mspID, SubmittedAt: now, Commitment: commitment})
+	listing.UpdatedAt = now
+
+	if err := putListing(ctx, listing); err != nil {
+		return nil, err
+	}
+
+	return listing, nil
+}
+
+// RevealBid opens the caller organization's sealed bid on an expired listing
+// within the reveal period (config marketplace.revealHours): the bid read
+// from its implicit collection must match the commitment made when bidding.
+// The amount is written to the listing's bid receipt and the sealed copy is
+// purged; bids not revealed in time are left out of the award.
+func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, listingId string) (*models.Listing, error) {
+	listing, err := s.ReadListing(ctx, listingId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Status != models.ListingOpen {
+		return nil, newError(ctx, ErrBidRevealClosed, listingId, listing.Status)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if now < listing.ExpiresAt {
+		return nil, newError(ctx, ErrBidsSealed, listingId, listing.ExpiresAt)
+	}
+	deadline, err := revealDeadline(ctx, listing)
+	if err != nil {
+		return nil, err
+	}
+	if now >= deadline {
+		return nil, newError(ctx, ErrBidRevealExpired, listingId, deadline)
+	}
+
+	receipt := -1
+	for i := range listing.Bids {
+		if listing.Bids[i].BidderMSP == mspID {
+			receipt = i
+		}
+	}
+	if receipt < 0 {
+		return nil, newError(ctx, ErrBidNotFound, mspID, listingId)
+	}
+	if listing.Bids[receipt].RevealedAt != "" {
+		return nil, newError(ctx, ErrBidAlreadyRevealed, mspID, listingId)
+	}
+
+	key := bidKey(listingId, mspID)
+	bidJSON, err := ctx.GetStub().GetPrivateData(bidCollection(mspID), key)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, key, err)
+	}
+	if bidJSON == nil {
+		return nil, newError(ctx, ErrBidNotOnPeer, mspID, listingId)
+	}
+	if bidCommitment(bidJSON) != listing.Bids[receipt].Commitment {
+		return nil, newError(ctx, ErrBidCommitmentMismatch, mspID, listingId)
+	}
+	var bid models.SealedBid
+	if err := json.Unmarshal(bidJSON, &bid); err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listing.Bids[receipt].Bidder = bid.Bidder
+	listing.Bids[receipt].Amount = bid.Amount
+	listing.Bids[receipt].RevealedAt = now
+	listing.UpdatedAt = now
+	listing.History = append(listing.History, models.History{
+		Timestamp: now,
+		Action:    "BID_REVEALED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%s revealed a bid of %.2f", mspID, bid.Amount),
+	})
+
+	if err := ctx.GetStub().DelPrivateData(bidCollection(mspID), key); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PurgePrivateData(bidCollection(mspID), key); err != nil {
+		return nil, err
+	}
+	if err := putListing(ctx, listing); err != nil {
+		return nil, err
+	}
+
+	return listing, nil
+}
+
+// ReadMyBid returns the caller organization's bid on a listing: the sealed
+// one until it is revealed, then the amount of its bid receipt
+func (s *SmartContract) ReadMyBid(ctx contractapi.TransactionContextInterface, listingId string) (*models.SealedBid, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bidJSON, err := ctx.GetStub().GetPrivateData(bidCollection(mspID), bidKey(listingId, mspID))
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, bidKey(listingId, mspID), err)
+	}
+	if bidJSON == nil {
+		listing, err := s.ReadListing(ctx, listingId)
+		if err != nil {
+			return nil, err
+		}
+		for _, receipt := range listing.Bids {
+			if receipt.BidderMSP == mspID && receipt.RevealedAt != "" {
+				return &models.SealedBid{
+					ListingID:   listingId,
+					Bidder:      receipt.Bidder,
+					BidderMSP:   mspID,
+					Amount:      receipt.Amount,
+					SubmittedAt: receipt.SubmittedAt,
+				}, nil
+			}
+		}
+		return nil, newError(ctx, ErrBidNotFound, mspID, listingId)
+	}
+
+	var bid models.SealedBid
+	if err := json.Unmarshal(bidJSON, &bid); err != nil {
+		return nil, err
+	}
+
+	return &bid, nil
+}
+
+// AwardListing awards an expired listing, once every bid is revealed or the
+// reveal period is over, to the highest revealed bid at or above the reserve
+// price (earliest bid on a tie), passing over buyers the seller's credit
+// limits block. The winner is invoiced for its bid; without a valid bid the
+// listing expires. Seller or admin only.
+func (s *SmartContract) AwardListing(ctx contractapi.TransactionContextInterface, listingId string) (*models.Listing, error) {
+	listing, err := s.ReadListing(ctx, listingId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireSeller(ctx, listing); err != nil {
+		return nil, err
+	}
+	if listing.Status != models.ListingOpen {
+		return nil, newError(ctx, ErrListingNotAwardable, listingId, listing.Status)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if now < listing.ExpiresAt {
+		return nil, newError(ctx, ErrBidsSealed, listingId, listing.ExpiresAt)
+	}
+
+	bids := []models.BidReceipt{}
+	for _, receipt := range listing.Bids {
+		if receipt.RevealedAt != "" {
+			bids = append(bids, receipt)
+		}
+	}
+	deadline, err := revealDeadline(ctx, listing)
+	if err != nil {
+		return nil, err
+	}
+	if len(bids) < len(listing.Bids) && now < deadline {
+		return nil, newError(ctx, ErrBidsUnrevealed, len(bids), len(listing.Bids), listingId, deadline)
+	}
+	sort.Slice(bids, func(i, j int) bool {
+		if bids[i].Amount != bids[j].Amount {
+			return bids[i].Amount > bids[j].Amount
+		}
+		if bids[i].SubmittedAt != bids[j].SubmittedAt {
+			return bids[i].SubmittedAt < bids[j].SubmittedAt
+		}
+		return bids[i].BidderMSP < bids[j].BidderMSP
+	})
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listing.UpdatedAt = now
+
+	// Bids of buyers over the seller's credit limit under a BLOCK policy are
+	// passed over for the next highest
+	winning, passedOver := -1, 0
+	for i, bid := range bids {
+		if bid.Amount < listing.ReservePrice {
+			break
+		}
+		_, allowed, err := creditAllows(ctx, listing.SellerMSP, bid.BidderMSP, bid.Amount, "LISTING_"+listingId)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			winning = i
+			break
+		}
+		passedOver++
+	}
+	creditNote := ""
+	if unrevealed := len(listing.Bids) - len(bids); unrevealed > 0 {
+		creditNote = fmt.Sprintf("; %d not revealed", unrevealed)
+	}
+	if passedOver > 0 {
+		creditNote += fmt.Sprintf("; %d passed over for credit limits", passedOver)
+	}
+
+	if winning < 0 {
+		listing.Status = models.ListingExpired
+		listing.History = append(listing.History, models.History{
+			Timestamp: now,
+			Action:    "EXPIRED",
+			Actor:     actor,
+			Details:   fmt.Sprintf("No bid met the reserve price (%d bids%s)", len(bids), creditNote),
+		})
+		if err := putListing(ctx, listing); err != nil {
+			return nil, err
+		}
+		if err := s.transitionLinkedWaste(ctx, "LISTING", listingId, listing.Status, listing.WasteID); err != nil {
+			return nil, err
+		}
+
+		return listing, nil
+	}
+
+	winner := bids[winning]
+	invoice, err := issueInvoice(ctx, listing.SellerMSP, winner.BidderMSP, winner.Amount, listing.WasteID, listingId, "")
+	if err != nil {
+		return nil, err
+	}
+	listing.Status = models.ListingAwarded
+	listing.WinningBidder = winner.Bidder
+	listing.WinningMSP = winner.BidderMSP
+	listing.WinningPrice = winner.Amount
+	listing.InvoiceID = invoice.ID
+	listing.History = append(listing.History, models.History{
+		Timestamp: now,
+		Action:    "AWARDED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Awarded to %s at %.2f (%d bids%s), invoice %s", winner.BidderMSP, winner.Amount, len(bids), creditNote, invoice.ID),
+	})
+
+	if err := putListing(ctx, listing); err != nil {
+		return nil, err
+	}
+	if err := s.transitionLinkedWaste(ctx, "LISTING", listingId, listing.Status, listing.WasteID); err != nil {
+		return nil, err
+	}
+	if err := notify(ctx, winner.BidderMSP, models.NotifyListingAwarded, "LISTING_"+listingId, fmt.Sprintf("Your bid of %.2f won listing %s for waste %s", winner.Amount, listingId, listing.WasteID)); err != nil {
+		return nil, err
+	}
+
+	return listing, nil
+}
+
+// SettleListing records the payment of an awarded listing, split among the
+// lot's holders, and marks its invoice paid. Seller or admin only.
+func (s *SmartContract) SettleListing(ctx contractapi.TransactionContextInterface, listingId string) (*models.Listing, error) {
+	listing, err := s.ReadListing(ctx, listingId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireSeller(ctx, listing); err != nil {
+		return nil, err
+	}
+	if listing.Status != models.ListingAwarded {
+		return nil, newError(ctx, ErrListingNotSettleable, listingId, models.ListingAwarded, listing.Status)
+	}
+
+	waste, err := s.readWaste(ctx, listing.WasteID)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listing.Status = models.ListingSettled
+	listing.Settlement = settlementSplit(waste, listing.WinningPrice)
+	listing.UpdatedAt = now
+	listing.History = append(listing.History, models.History{
+		Timestamp: now,
+		Action:    "SETTLED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%.2f settled to %d holders", listing.WinningPrice, len(listing.Settlement)),
+	})
+
+	changed, err := applyTransitionRules(ctx, "LISTING", listingId, listing.Status, waste)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		if err := s.putWaste(ctx, waste); err != nil {
+			return nil, err
+		}
+	}
+	if err := putListing(ctx, listing); err != nil {
+		return nil, err
+	}
+	if listing.InvoiceID != "" {
+		invoice, err := readInvoice(ctx, listing.InvoiceID)
+		if err != nil {
+			return nil, err
+		}
+		if invoice.Status == models.InvoiceUnpaid {
+			if err := payInvoice(ctx, invoice, "Listing "+listingId+" settled"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := recordValuation(ctx, waste, models.ValuationSold, listing.Quantity, listing.WinningPrice, "Listing "+listingId+" to "+listing.WinningMSP); err != nil {
+		return nil, err
+	}
+
+	return listing, nil
+}
+
+// ReadListing returns the listing stored with the given id
+func (s *SmartContract) ReadListing(ctx contractapi.TransactionContextInterface, id string) (*models.Listing, error) {
+	var listing models.Listing
+	found, err := newAssetStore(ctx).Get("LISTING_"+id, &listing)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "LISTING_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrListingNotFound, id)
+	}
+
+	return &listing, nil
+}
+
+// GetListings returns all listings, optionally filtered by status
+func (s *SmartContract) GetListings(ctx contractapi.TransactionContextInterface, status string) ([]*models.Listing, error) {
+	listings, err := loadListings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []*models.Listing{}
+	for _, listing := range listings {
+		if status == "" || listing.Status == status {
+			filtered = append(filtered, listing)
+		}
+	}
+
+	return filtered, nil
+}
+
+// requireSeller rejects callers outside the selling organization, admins aside
+func requireSeller(ctx contractapi.TransactionContextInterface, listing *models.Listing) error {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if mspID != listing.SellerMSP && !isAdmin(ctx) {
+		return newError(ctx, ErrListingManageForbidden, listing.SellerMSP, listing.ID)
+	}
+
+	return nil
+}
+
+func loadListings(ctx contractapi.TransactionContextInterface) ([]*models.Listing, error) {
+	var listings []*models.Listing
+	err := newAssetStore(ctx).Range("LISTING_", "LISTING_~", func(_ string, value []byte) error {
+		var listing models.Listing
+		if err := json.Unmarshal(value, &listing); err != nil {
+			return err
+		}
+		listings = append(listings, &listing)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return listings, nil
+}
+
+func putListing(ctx contractapi.TransactionContextInterface, listing *models.Listing) error {
+	return newAssetStore(ctx).Put("LISTING_"+listing.ID, listing)
+}
+
+func bidKey(listingId string, mspID string) string {
+	return "BID_" + listingId + "_" + mspID
+}
+
+// bidCollection is the implicit collection of an organization, held by its
+// own peers only
+func bidCollection(mspID string) string {
+	return implicitCollectionPrefix + mspID
+}
+
+// putBid stores a sealed bid in its bidder's implicit collection and returns
+// its commitment
+func putBid(ctx contractapi.TransactionContextInterface, bid *models.SealedBid) (string, error) {
+	bidJSON, err := json.Marshal(bid)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutPrivateData(bidCollection(bid.BidderMSP), bidKey(bid.ListingID, bid.BidderMSP), bidJSON); err != nil {
+		return "", err
+	}
+
+	return bidCommitment(bidJSON), nil
+}
+
+// bidCommitment is the hex SHA-256 of a sealed bid as stored
+func bidCommitment(bidJSON []byte) string {
+	sum := sha256.Sum256(bidJSON)
+	return hex.EncodeToString(sum[:])
+}
+
+// revealDeadline returns when the reveal period of a listing ends
+func revealDeadline(ctx contractapi.TransactionContextInterface, listing *models.Listing) (string, error) {
+	expiry, err := time.Parse(time.RFC3339, listing.ExpiresAt)
+	if err != nil {
+		return "", newError(ctx, ErrListingExpiryInvalid, listing.ID, err)
+	}
+	hours := configInt(ctx, "marketplace", "revealHours", defaultRevealHours)
+
+	return expiry.Add(time.Duration(hours) * time.Hour).UTC().Format(time.RFC3339), nil
+}
@@ -0,0 +1,251 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultMediaPerAsset caps a gallery unless "media.maxPerAsset" says otherwise
+const defaultMediaPerAsset = 20
+
+// AttachMedia anchors a photo or video of a waste lot, extraction or
+// recycling product (assetType WASTE, EXTRACTION or RECYCLING) at the end of
+// its gallery. mediaJson carries hash (hex SHA-256), mimeType (image/* or
+// video/*), uri, and optionally size, capturedAt (RFC 3339), latitude,
+// longitude and caption. The caller must be allowed to view the lot.
+func (s *SmartContract) AttachMedia(ctx contractapi.TransactionContextInterface, assetType string, assetId string, mediaJson string) (*models.MediaGallery, error) {
+	var media models.MediaAttachment
+	if err := json.Unmarshal([]byte(mediaJson), &media); err != nil {
+		return nil, newError(ctx, ErrMediaInvalid, err)
+	}
+	media.Hash = strings.ToLower(media.Hash)
+	if !commentHashPattern.MatchString(media.Hash) {
+		return nil, newError(ctx, ErrMediaHashInvalid)
+	}
+	media.MimeType = strings.ToLower(media.MimeType)
+	if !strings.HasPrefix(media.MimeType, "image/") && !strings.HasPrefix(media.MimeType, "video/") {
+		return nil, newError(ctx, ErrMediaTypeUnsupported, media.MimeType)
+	}
+	if media.URI == "" {
+		return nil, newError(ctx, ErrMediaURIRequired)
+	}
+	if media.Size < 0 {
+		return nil, newError(ctx, ErrMediaSizeNegative)
+	}
+	if !media.HasLocation && (media.Latitude != 0 || media.Longitude != 0) {
+		return nil, newError(ctx, ErrCapturePositionIncomplete)
+	}
+	if media.HasLocation && (media.Latitude < -90 || media.Latitude > 90 || media.Longitude < -180 || media.Longitude > 180) {
+		return nil, newError(ctx, ErrCapturePositionOutOfRange, media.Latitude, media.Longitude)
+	}
+
+	gallery, err := s.readMediaGallery(ctx, assetType, assetId)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if media.CapturedAt != "" {
+		if _, err := time.Parse(time.RFC3339, media.CapturedAt); err != nil {
+			return nil, newError(ctx, ErrCaptureTimeInvalid, media.CapturedAt)
+		}
+		if media.CapturedAt > now {
+			return nil, newError(ctx, ErrCaptureTimeFuture)
+		}
+	}
+	for _, item := range gallery.Items {
+		if item.Hash == media.Hash {
+			return nil, newError(ctx, ErrMediaAlreadyAttached, media.Hash, gallery.AssetType, assetId)
+		}
+	}
+	if limit := configInt(ctx, "media", "maxPerAsset", defaultMediaPerAsset); len(gallery.Items) >= limit {
+		return nil, newError(ctx, ErrMediaLimitReached, gallery.AssetType, assetId, limit)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	media.AddedBy = actor
+	media.AddedByMSP = mspID
+	media.AddedAt = now
+	gallery.Items = append(gallery.Items, media)
+	gallery.UpdatedAt = now
+	gallery.History = append(gallery.History, models.History{
+		Timestamp: now,
+		Action:    "MEDIA_ATTACHED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%s %s", media.MimeType, media.Hash),
+	})
+
+	if err := putMediaGallery(ctx, gallery); err != nil {
+		return nil, err
+	}
+
+	return gallery, nil
+}
+
+// RemoveMedia detaches a media item from a gallery; only the organization
+// that attached it or an admin may remove it
+func (s *SmartContract) RemoveMedia(ctx contractapi.TransactionContextInterface, assetType string, assetId string, hash string) (*models.MediaGallery, error) {
+	gallery, err := s.readMediaGallery(ctx, assetType, assetId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hash = strings.ToLower(hash)
+	index := -1
+	for i, item := range gallery.Items {
+		if item.Hash == hash {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, newError(ctx, ErrMediaNotAttached, hash, gallery.AssetType, assetId)
+	}
+	if gallery.Items[index].AddedByMSP != mspID && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrMediaRemoveForbidden, gallery.Items[index].AddedByMSP, hash)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gallery.Items = append(gallery.Items[:index], gallery.Items[index+1:]...)
+	gallery.UpdatedAt = now
+	gallery.History = append(gallery.History, models.History{
+		Timestamp: now,
+		Action:    "MEDIA_REMOVED",
+		Actor:     actor,
+		Details:   hash,
+	})
+
+	if err := putMediaGallery(ctx, gallery); err != nil {
+		return nil, err
+	}
+
+	return gallery, nil
+}
+
+// ReorderMedia sets the display order of a gallery; hashesJson lists every
+// media hash of the gallery in the new order
+func (s *SmartContract) ReorderMedia(ctx contractapi.TransactionContextInterface, assetType string, assetId string, hashesJson string) (*models.MediaGallery, error) {
+	var hashes []string
+	if err := json.Unmarshal([]byte(hashesJson), &hashes); err != nil {
+		return nil, newError(ctx, ErrMediaOrderInvalid, err)
+	}
+
+	gallery, err := s.readMediaGallery(ctx, assetType, assetId)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) != len(gallery.Items) {
+		return nil, newError(ctx, ErrMediaOrderIncomplete, len(gallery.Items), gallery.AssetType, assetId)
+	}
+
+	byHash := map[string]models.MediaAttachment{}
+	for _, item := range gallery.Items {
+		byHash[item.Hash] = item
+	}
+	ordered := make([]models.MediaAttachment, 0, len(hashes))
+	for _, hash := range hashes {
+		item, ok := byHash[strings.ToLower(hash)]
+		if !ok {
+			return nil, newError(ctx, ErrMediaOrderMismatch, hash, gallery.AssetType, assetId)
+		}
+		ordered = append(ordered, item)
+		delete(byHash, item.Hash)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gallery.Items = ordered
+	gallery.UpdatedAt = now
+	gallery.History = append(gallery.History, models.History{
+		Timestamp: now,
+		Action:    "MEDIA_REORDERED",
+		Actor:     actor,
+	})
+
+	if err := putMediaGallery(ctx, gallery); err != nil {
+		return nil, err
+	}
+
+	return gallery, nil
+}
+
+// GetMediaGallery returns the media of an asset in display order
+func (s *SmartContract) GetMediaGallery(ctx contractapi.TransactionContextInterface, assetType string, assetId string) (*models.MediaGallery, error) {
+	return s.readMediaGallery(ctx, assetType, assetId)
+}
+
+// readMediaGallery returns the gallery of an asset, empty when it has
+// no media yet, after checking that the caller may view the asset's lot
+func (s *SmartContract) readMediaGallery(ctx contractapi.TransactionContextInterface, assetType string, assetId string) (*models.MediaGallery, error) {
+	assetType = strings.ToUpper(strings.TrimSpace(assetType))
+	wasteID, err := s.productWasteID(ctx, assetType, assetId)
+	if err != nil {
+		return nil, err
+	}
+	waste, err := s.readWaste(ctx, wasteID)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, newError(ctx, ErrWasteNotVisible, wasteID)
+	}
+
+	gallery := &models.MediaGallery{
+		AssetType: assetType,
+		AssetID:   assetId,
+		WasteID:   wasteID,
+		Items:     []models.MediaAttachment{},
+		History:   []models.History{},
+	}
+	if _, err := newAssetStore(ctx).Get(mediaKey(assetType, assetId), gallery); err != nil {
+		return nil, newError(ctx, ErrLedgerRead, mediaKey(assetType, assetId), err)
+	}
+
+	return gallery, nil
+}
+
+func mediaKey(assetType string, assetID string) string {
+	return "MEDIA_" + assetType + "_" + assetID
+}
+
+func putMediaGallery(ctx contractapi.TransactionContextInterface, gallery *models.MediaGallery) error {
+	return newAssetStore(ctx).Put(mediaKey(gallery.AssetType, gallery.AssetID), gallery)
+}
@@ -0,0 +1,3376 @@
+package contract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Supported message languages
+const (
+	LangEnglish = "en"
+	LangFrench  = "fr"
+)
+
+// Stable error codes; clients should match on these, not on the text
+const (
+	ErrLedgerRead           = "LEDGER_READ_FAILED"
+	ErrLedgerWrite          = "LEDGER_WRITE_FAILED"
+	ErrHistoryRead          = "HISTORY_READ_FAILED"
+	ErrIdentity             = "IDENTITY_UNAVAILABLE"
+	ErrNotAdmin             = "ADMIN_REQUIRED"
+	ErrTimestamp            = "TIMESTAMP_UNAVAILABLE"
+	ErrWasteTypeRequired    = "WASTE_TYPE_REQUIRED"
+	ErrQuantityInvalid      = "QUANTITY_NOT_POSITIVE"
+	ErrHarvestDate          = "HARVEST_DATE_INVALID"
+	ErrWasteExists          = "WASTE_ALREADY_EXISTS"
+	ErrWasteNotFound        = "WASTE_NOT_FOUND"
+	ErrCampaignClosed       = "CAMPAIGN_CLOSED"
+	ErrStatusRequired       = "STATUS_REQUIRED"
+	ErrExtractionExists     = "EXTRACTION_ALREADY_EXISTS"
+	ErrExtractionMissing    = "EXTRACTION_NOT_FOUND"
+	ErrRecyclingExists      = "RECYCLING_ALREADY_EXISTS"
+	ErrConflict             = "CONFLICT"
+	ErrWasteNotVisible      = "WASTE_NOT_VISIBLE"
+	ErrFeedbackThrottled    = "FEEDBACK_THROTTLED"
+	ErrPlotUnavailable      = "PLOT_UNAVAILABLE"
+	ErrPlotFarmMismatch     = "PLOT_FARM_MISMATCH"
+	ErrFieldRequired        = "FIELD_REQUIRED"
+	ErrQuotaExceeded        = "QUOTA_EXCEEDED"
+	ErrRuleViolated         = "RULE_VIOLATED"
+	ErrQueryTruncated       = "QUERY_TRUNCATED"
+	ErrOnboardingIncomplete = "ONBOARDING_INCOMPLETE"
+
+	// Access
+	ErrGraderRequired = "GRADER_REQUIRED"
+
+	// Supply contract adjustments
+	ErrAdjustmentRulesInvalid    = "ADJUSTMENT_RULES_INVALID"
+	ErrAdjustmentRuleDuplicate   = "ADJUSTMENT_RULE_DUPLICATE"
+	ErrAdjustmentRulesLocked     = "ADJUSTMENT_RULES_LOCKED"
+	ErrSupplyAmendmentForbidden  = "SUPPLY_AMENDMENT_FORBIDDEN"
+	ErrAdjustmentRuleIDRequired  = "ADJUSTMENT_RULE_ID_REQUIRED"
+	ErrAdjustmentKindInvalid     = "ADJUSTMENT_KIND_INVALID"
+	ErrAdjustmentGradeUnknown    = "ADJUSTMENT_GRADE_UNKNOWN"
+	ErrAdjustmentPenaltyOnly     = "ADJUSTMENT_PENALTY_ONLY"
+	ErrAdjustmentMaxDaysNegative = "ADJUSTMENT_MAX_DAYS_NEGATIVE"
+	ErrAdjustmentTriggerInvalid  = "ADJUSTMENT_TRIGGER_INVALID"
+	ErrAdjustmentBasisInvalid    = "ADJUSTMENT_BASIS_INVALID"
+	ErrAdjustmentRateInvalid     = "ADJUSTMENT_RATE_INVALID"
+
+	// Data sharing agreements
+	ErrAgreementIDRequired          = "AGREEMENT_ID_REQUIRED"
+	ErrAgreementCounterpartyInvalid = "AGREEMENT_COUNTERPARTY_INVALID"
+	ErrValidFromInvalid             = "VALID_FROM_INVALID"
+	ErrValidUntilInvalid            = "VALID_UNTIL_INVALID"
+	ErrValidityRangeInvalid         = "VALIDITY_RANGE_INVALID"
+	ErrAgreementAlreadyExists       = "AGREEMENT_ALREADY_EXISTS"
+	ErrAgreementStatusInvalid       = "AGREEMENT_STATUS_INVALID"
+	ErrAgreementAcceptForbidden     = "AGREEMENT_ACCEPT_FORBIDDEN"
+	ErrAgreementAlreadyRevoked      = "AGREEMENT_ALREADY_REVOKED"
+	ErrAgreementRevokeForbidden     = "AGREEMENT_REVOKE_FORBIDDEN"
+	ErrAgreementNotFound            = "AGREEMENT_NOT_FOUND"
+	ErrAgreementScopeUnknown        = "AGREEMENT_SCOPE_UNKNOWN"
+	ErrAgreementScopeRequired       = "AGREEMENT_SCOPE_REQUIRED"
+
+	// Approval matrices
+	ErrOrganizationRequired          = "ORGANIZATION_REQUIRED"
+	ErrApprovalRulesInvalid          = "APPROVAL_RULES_INVALID"
+	ErrEffectiveDatePast             = "EFFECTIVE_DATE_PAST"
+	ErrApprovalMatrixNotFound        = "APPROVAL_MATRIX_NOT_FOUND"
+	ErrApprovalMatrixVersionInEffect = "APPROVAL_MATRIX_VERSION_IN_EFFECT"
+	ErrApprovalMatrixVersionNotFound = "APPROVAL_MATRIX_VERSION_NOT_FOUND"
+	ErrApprovalSettingsInvalid       = "APPROVAL_SETTINGS_INVALID"
+	ErrApprovalOperationUnknown      = "APPROVAL_OPERATION_UNKNOWN"
+	ErrApprovalMinQuantityNegative   = "APPROVAL_MIN_QUANTITY_NEGATIVE"
+	ErrApprovalRuleDuplicate         = "APPROVAL_RULE_DUPLICATE"
+	ErrApprovalRoleUnknown           = "APPROVAL_ROLE_UNKNOWN"
+	ErrApprovalRuleNoRole            = "APPROVAL_RULE_NO_ROLE"
+	ErrEffectiveDateInvalid          = "EFFECTIVE_DATE_INVALID"
+	ErrApprovalMatrixReadForbidden   = "APPROVAL_MATRIX_READ_FORBIDDEN"
+
+	// Approvals
+	ErrApprovalRoleNotNeeded     = "APPROVAL_ROLE_NOT_NEEDED"
+	ErrApprovalRoleAlreadySigned = "APPROVAL_ROLE_ALREADY_SIGNED"
+	ErrApprovalAlreadySigned     = "APPROVAL_ALREADY_SIGNED"
+	ErrApprovalSignForbidden     = "APPROVAL_SIGN_FORBIDDEN"
+	ErrRejectionReasonRequired   = "REJECTION_REASON_REQUIRED"
+	ErrApprovalRejectForbidden   = "APPROVAL_REJECT_FORBIDDEN"
+	ErrApprovalNotFound          = "APPROVAL_NOT_FOUND"
+	ErrApprovalStatusInvalid     = "APPROVAL_STATUS_INVALID"
+
+	// Point-in-time reads
+	ErrWasteNotFoundAt          = "WASTE_NOT_FOUND_AT"
+	ErrWasteHistoryDecodeFailed = "WASTE_HISTORY_DECODE_FAILED"
+	ErrTimeInvalid              = "TIME_INVALID"
+	ErrWasteErasedAt            = "WASTE_ERASED_AT"
+
+	// Audit trail
+	ErrFunctionRequired    = "FUNCTION_REQUIRED"
+	ErrAuditTrailForbidden = "AUDIT_TRAIL_FORBIDDEN"
+	ErrBookmarkInvalid     = "BOOKMARK_INVALID"
+	ErrAuditDateInvalid    = "AUDIT_DATE_INVALID"
+
+	// Automation
+	ErrTransitionRulesSettingInvalid = "TRANSITION_RULES_SETTING_INVALID"
+	ErrTransitionRuleInvalid         = "TRANSITION_RULE_INVALID"
+
+	// Blended recyclings
+	ErrBlendInputsTooFew         = "BLEND_INPUTS_TOO_FEW"
+	ErrBlendInputInvalid         = "BLEND_INPUT_INVALID"
+	ErrBlendInputDuplicate       = "BLEND_INPUT_DUPLICATE"
+	ErrWasteQuantityInsufficient = "WASTE_QUANTITY_INSUFFICIENT"
+
+	// Extraction outputs
+	ErrOutputLineNotFound  = "OUTPUT_LINE_NOT_FOUND"
+	ErrOutputLinesRequired = "OUTPUT_LINES_REQUIRED"
+	ErrMassBalanceExceeded = "MASS_BALANCE_EXCEEDED"
+	ErrOutputLineInvalid   = "OUTPUT_LINE_INVALID"
+
+	// Campaigns
+	ErrCampaignFieldsRequired = "CAMPAIGN_FIELDS_REQUIRED"
+	ErrStartDateInvalid       = "START_DATE_INVALID"
+	ErrEndDateInvalid         = "END_DATE_INVALID"
+	ErrDateRangeInvalid       = "DATE_RANGE_INVALID"
+	ErrCampaignAlreadyExists  = "CAMPAIGN_ALREADY_EXISTS"
+	ErrCampaignOverlap        = "CAMPAIGN_OVERLAP"
+	ErrCampaignAlreadyClosed  = "CAMPAIGN_ALREADY_CLOSED"
+	ErrCampaignCloseForbidden = "CAMPAIGN_CLOSE_FORBIDDEN"
+	ErrCampaignNotFound       = "CAMPAIGN_NOT_FOUND"
+
+	// Completion certificates
+	ErrWasteNotValorized   = "WASTE_NOT_VALORIZED"
+	ErrCertificateNotFound = "CERTIFICATE_NOT_FOUND"
+
+	// Certifiers
+	ErrCertifierRoleRequired    = "CERTIFIER_ROLE_REQUIRED"
+	ErrCertifierNameRequired    = "CERTIFIER_NAME_REQUIRED"
+	ErrCertifierCapacityInvalid = "CERTIFIER_CAPACITY_INVALID"
+	ErrCertifierRetireForbidden = "CERTIFIER_RETIRE_FORBIDDEN"
+	ErrCertifierAlreadyRetired  = "CERTIFIER_ALREADY_RETIRED"
+	ErrSampleAssignForbidden    = "SAMPLE_ASSIGN_FORBIDDEN"
+	ErrSampleUnassignable       = "SAMPLE_UNASSIGNABLE"
+	ErrDeclineReasonRequired    = "DECLINE_REASON_REQUIRED"
+	ErrWasteNotInSample         = "WASTE_NOT_IN_SAMPLE"
+	ErrWasteAlreadyInspected    = "WASTE_ALREADY_INSPECTED"
+	ErrWasteNotAssigned         = "WASTE_NOT_ASSIGNED"
+	ErrWorkloadReadForbidden    = "WORKLOAD_READ_FORBIDDEN"
+	ErrCertifierNotFound        = "CERTIFIER_NOT_FOUND"
+	ErrCertifierNotAssigned     = "CERTIFIER_NOT_ASSIGNED"
+
+	// Checklists
+	ErrChecklistItemsInvalid  = "CHECKLIST_ITEMS_INVALID"
+	ErrChecklistEmpty         = "CHECKLIST_EMPTY"
+	ErrChecklistItemInvalid   = "CHECKLIST_ITEM_INVALID"
+	ErrChecklistItemDuplicate = "CHECKLIST_ITEM_DUPLICATE"
+	ErrChecklistNotDefined    = "CHECKLIST_NOT_DEFINED"
+	ErrChecklistItemNotFound  = "CHECKLIST_ITEM_NOT_FOUND"
+	ErrChecklistValueRequired = "CHECKLIST_VALUE_REQUIRED"
+	ErrWasteStatusUnchanged   = "WASTE_STATUS_UNCHANGED"
+	ErrChecklistIncomplete    = "CHECKLIST_INCOMPLETE"
+
+	// Insurance claims
+	ErrClaimKindUnsupported      = "CLAIM_KIND_UNSUPPORTED"
+	ErrClaimDescriptionRequired  = "CLAIM_DESCRIPTION_REQUIRED"
+	ErrClaimFileForbidden        = "CLAIM_FILE_FORBIDDEN"
+	ErrLossQuantityInvalid       = "LOSS_QUANTITY_INVALID"
+	ErrClaimAlreadyOpen          = "CLAIM_ALREADY_OPEN"
+	ErrClaimAlreadyExists        = "CLAIM_ALREADY_EXISTS"
+	ErrDocumentTypeRequired      = "DOCUMENT_TYPE_REQUIRED"
+	ErrDocumentHashInvalid       = "DOCUMENT_HASH_INVALID"
+	ErrClaimStatusInvalid        = "CLAIM_STATUS_INVALID"
+	ErrClaimEvidenceForbidden    = "CLAIM_EVIDENCE_FORBIDDEN"
+	ErrClaimDocumentDuplicate    = "CLAIM_DOCUMENT_DUPLICATE"
+	ErrAssessorRequired          = "ASSESSOR_REQUIRED"
+	ErrFindingRequired           = "FINDING_REQUIRED"
+	ErrAssessmentAmountsNegative = "ASSESSMENT_AMOUNTS_NEGATIVE"
+	ErrClaimStatusUnexpected     = "CLAIM_STATUS_UNEXPECTED"
+	ErrAssessedLossExceedsClaim  = "ASSESSED_LOSS_EXCEEDS_CLAIM"
+	ErrClaimSelfAssessment       = "CLAIM_SELF_ASSESSMENT"
+	ErrReasonRequired            = "REASON_REQUIRED"
+	ErrSettlementAmountInvalid   = "SETTLEMENT_AMOUNT_INVALID"
+	ErrClaimNotFound             = "CLAIM_NOT_FOUND"
+	ErrInsurerRequired           = "INSURER_REQUIRED"
+	ErrClaimInsurerMismatch      = "CLAIM_INSURER_MISMATCH"
+
+	// Collection requests
+	ErrFarmRequired              = "FARM_REQUIRED"
+	ErrEstimatedQuantityInvalid  = "ESTIMATED_QUANTITY_INVALID"
+	ErrWindowStartInvalid        = "WINDOW_START_INVALID"
+	ErrWindowEndInvalid          = "WINDOW_END_INVALID"
+	ErrWindowRangeInvalid        = "WINDOW_RANGE_INVALID"
+	ErrCollectionAlreadyExists   = "COLLECTION_ALREADY_EXISTS"
+	ErrCollectorRequired         = "COLLECTOR_REQUIRED"
+	ErrCollectionNotAssignable   = "COLLECTION_NOT_ASSIGNABLE"
+	ErrCollectionStatusUnchanged = "COLLECTION_STATUS_UNCHANGED"
+	ErrCollectionNotFulfillable  = "COLLECTION_NOT_FULFILLABLE"
+	ErrCollectorMismatch         = "COLLECTOR_MISMATCH"
+	ErrCollectionNotFound        = "COLLECTION_NOT_FOUND"
+	ErrCollectionManageForbidden = "COLLECTION_MANAGE_FORBIDDEN"
+
+	// Composition
+	ErrCompositionInvalid   = "COMPOSITION_INVALID"
+	ErrCompositionForbidden = "COMPOSITION_FORBIDDEN"
+	ErrCompositionEmpty     = "COMPOSITION_EMPTY"
+	ErrMoistureOutOfRange   = "MOISTURE_OUT_OF_RANGE"
+	ErrOilContentOutOfRange = "OIL_CONTENT_OUT_OF_RANGE"
+	ErrCompositionOverTotal = "COMPOSITION_OVER_TOTAL"
+
+	// Configuration
+	ErrConfigKeyRequired      = "CONFIG_KEY_REQUIRED"
+	ErrConfigNamespaceInvalid = "CONFIG_NAMESPACE_INVALID"
+
+	// Cooperatives
+	ErrCooperativeNameRequired   = "COOPERATIVE_NAME_REQUIRED"
+	ErrCooperativeNameTaken      = "COOPERATIVE_NAME_TAKEN"
+	ErrAdminIdentityRequired     = "ADMIN_IDENTITY_REQUIRED"
+	ErrPermissionMatrixInvalid   = "PERMISSION_MATRIX_INVALID"
+	ErrMemberTiersRequired       = "MEMBER_TIERS_REQUIRED"
+	ErrCooperativeActionUnknown  = "COOPERATIVE_ACTION_UNKNOWN"
+	ErrMemberTierExcluded        = "MEMBER_TIER_EXCLUDED"
+	ErrCooperativeSelfMembership = "COOPERATIVE_SELF_MEMBERSHIP"
+	ErrMemberTierUnknown         = "MEMBER_TIER_UNKNOWN"
+	ErrCooperativeTooDeep        = "COOPERATIVE_TOO_DEEP"
+	ErrCooperativeCycle          = "COOPERATIVE_CYCLE"
+	ErrAlreadyMember             = "ALREADY_MEMBER"
+	ErrNotAMember                = "NOT_A_MEMBER"
+	ErrMembershipEndForbidden    = "MEMBERSHIP_END_FORBIDDEN"
+	ErrCooperativeNotFound       = "COOPERATIVE_NOT_FOUND"
+	ErrMembershipNotFound        = "MEMBERSHIP_NOT_FOUND"
+	ErrCooperativeAdminRequired  = "COOPERATIVE_ADMIN_REQUIRED"
+	ErrParticipantActForbidden   = "PARTICIPANT_ACT_FORBIDDEN"
+
+	// Credit and invoices
+	ErrBuyerRequired           = "BUYER_REQUIRED"
+	ErrCreditTermsNegative     = "CREDIT_TERMS_NEGATIVE"
+	ErrCreditPolicyInvalid     = "CREDIT_POLICY_INVALID"
+	ErrCreditSelf              = "CREDIT_SELF"
+	ErrCreditLimitNotFound     = "CREDIT_LIMIT_NOT_FOUND"
+	ErrCreditExposureForbidden = "CREDIT_EXPOSURE_FORBIDDEN"
+	ErrInvoiceAmountInvalid    = "INVOICE_AMOUNT_INVALID"
+	ErrInvoiceSelf             = "INVOICE_SELF"
+	ErrInvoiceStatusInvalid    = "INVOICE_STATUS_INVALID"
+	ErrInvoiceReadForbidden    = "INVOICE_READ_FORBIDDEN"
+	ErrCreditLimitExceeded     = "CREDIT_LIMIT_EXCEEDED"
+	ErrInvoiceManageForbidden  = "INVOICE_MANAGE_FORBIDDEN"
+	ErrInvoiceNotFound         = "INVOICE_NOT_FOUND"
+
+	// Deadlines
+	ErrUntilRequired = "UNTIL_REQUIRED"
+
+	// Delegations
+	ErrDelegationIDRequired      = "DELEGATION_ID_REQUIRED"
+	ErrDelegateRequired          = "DELEGATE_REQUIRED"
+	ErrDelegationSelf            = "DELEGATION_SELF"
+	ErrExpiresAtInvalid          = "EXPIRES_AT_INVALID"
+	ErrExpiresAtPast             = "EXPIRES_AT_PAST"
+	ErrDelegationAlreadyExists   = "DELEGATION_ALREADY_EXISTS"
+	ErrDelegationAlreadyRevoked  = "DELEGATION_ALREADY_REVOKED"
+	ErrDelegationRevokeForbidden = "DELEGATION_REVOKE_FORBIDDEN"
+	ErrDelegationNotFound        = "DELEGATION_NOT_FOUND"
+
+	// Dispositions
+	ErrRecipientRequired        = "RECIPIENT_REQUIRED"
+	ErrFacilityTypeMismatch     = "FACILITY_TYPE_MISMATCH"
+	ErrFacilityStatusInvalid    = "FACILITY_STATUS_INVALID"
+	ErrDispositionNotFound      = "DISPOSITION_NOT_FOUND"
+	ErrDateInvalid              = "DATE_INVALID"
+	ErrDispositionReasonInvalid = "DISPOSITION_REASON_INVALID"
+	ErrDispositionForbidden     = "DISPOSITION_FORBIDDEN"
+	ErrWasteExhausted           = "WASTE_EXHAUSTED"
+
+	// Documents
+	ErrDocumentAlreadyAttached = "DOCUMENT_ALREADY_ATTACHED"
+
+	// Duplicates
+	ErrToleranceNegative       = "TOLERANCE_NEGATIVE"
+	ErrDuplicateIDsInvalid     = "DUPLICATE_IDS_INVALID"
+	ErrDuplicatesRequired      = "DUPLICATES_REQUIRED"
+	ErrWasteAlreadyMerged      = "WASTE_ALREADY_MERGED"
+	ErrWasteHarvestMismatch    = "WASTE_HARVEST_MISMATCH"
+	ErrWasteInUse              = "WASTE_IN_USE"
+	ErrWasteUsedByExtraction   = "WASTE_USED_BY_EXTRACTION"
+	ErrWasteUsedByRecycling    = "WASTE_USED_BY_RECYCLING"
+	ErrParticipantLookupFailed = "PARTICIPANT_LOOKUP_FAILED"
+
+	// Embargoes
+	ErrEmbargoHoursNegative = "EMBARGO_HOURS_NEGATIVE"
+	ErrEmbargoForbidden     = "EMBARGO_FORBIDDEN"
+	ErrTimestampInvalid     = "TIMESTAMP_INVALID"
+
+	// Sealed fields
+	ErrSealedFieldInvalid     = "SEALED_FIELD_INVALID"
+	ErrSealForbidden          = "SEAL_FORBIDDEN"
+	ErrSealedFieldsRestricted = "SEALED_FIELDS_RESTRICTED"
+	ErrSealedFieldNotFound    = "SEALED_FIELD_NOT_FOUND"
+	ErrSealingKeyMismatch     = "SEALING_KEY_MISMATCH"
+	ErrSealingKeyRequired     = "SEALING_KEY_REQUIRED"
+	ErrTransientDataInvalid   = "TRANSIENT_DATA_INVALID"
+	ErrSealingKeySize         = "SEALING_KEY_SIZE"
+	ErrCiphertextInvalid      = "CIPHERTEXT_INVALID"
+	ErrCiphertextTooShort     = "CIPHERTEXT_TOO_SHORT"
+	ErrCiphertextKeyMismatch  = "CIPHERTEXT_KEY_MISMATCH"
+
+	// Recyclings
+	ErrRecyclingNotFound = "RECYCLING_NOT_FOUND"
+
+	// Facilities
+	ErrFacilityFieldsRequired   = "FACILITY_FIELDS_REQUIRED"
+	ErrFacilityTypeInvalid      = "FACILITY_TYPE_INVALID"
+	ErrDailyCapacityInvalid     = "DAILY_CAPACITY_INVALID"
+	ErrFacilityAlreadyExists    = "FACILITY_ALREADY_EXISTS"
+	ErrFacilityStatusUnknown    = "FACILITY_STATUS_UNKNOWN"
+	ErrEquipmentFieldsRequired  = "EQUIPMENT_FIELDS_REQUIRED"
+	ErrEquipmentAlreadyExists   = "EQUIPMENT_ALREADY_EXISTS"
+	ErrMaintenanceStatusInvalid = "MAINTENANCE_STATUS_INVALID"
+	ErrFacilityNotFound         = "FACILITY_NOT_FOUND"
+	ErrEquipmentNotFound        = "EQUIPMENT_NOT_FOUND"
+	ErrFacilityRequired         = "FACILITY_REQUIRED"
+	ErrFacilityManageForbidden  = "FACILITY_MANAGE_FORBIDDEN"
+	ErrCapacityExceeded         = "CAPACITY_EXCEEDED"
+
+	// Consumer feedback
+	ErrTraceTokenIssuerMismatch = "TRACE_TOKEN_ISSUER_MISMATCH"
+	ErrTraceTokenNotFound       = "TRACE_TOKEN_NOT_FOUND"
+	ErrRatingOutOfRange         = "RATING_OUT_OF_RANGE"
+	ErrCommentHashInvalid       = "COMMENT_HASH_INVALID"
+	ErrTraceTokenRevoked        = "TRACE_TOKEN_REVOKED"
+	ErrAssetTypeUnsupported     = "ASSET_TYPE_UNSUPPORTED"
+	ErrRatingScopeUnsupported   = "RATING_SCOPE_UNSUPPORTED"
+
+	// Geofences
+	ErrGeofenceRadiusNegative    = "GEOFENCE_RADIUS_NEGATIVE"
+	ErrGeofenceOverrideForbidden = "GEOFENCE_OVERRIDE_FORBIDDEN"
+	ErrPositionOutOfRange        = "POSITION_OUT_OF_RANGE"
+	ErrGeofenceRefused           = "GEOFENCE_REFUSED"
+
+	// Grading
+	ErrQualityGradeUnknown       = "QUALITY_GRADE_UNKNOWN"
+	ErrGradingEvidenceRequired   = "GRADING_EVIDENCE_REQUIRED"
+	ErrExtractionUnderAppeal     = "EXTRACTION_UNDER_APPEAL"
+	ErrGraderAppealForbidden     = "GRADER_APPEAL_FORBIDDEN"
+	ErrAppealFieldsRequired      = "APPEAL_FIELDS_REQUIRED"
+	ErrExtractionAlreadyAppealed = "EXTRACTION_ALREADY_APPEALED"
+	ErrExtractionGradeUnchanged  = "EXTRACTION_GRADE_UNCHANGED"
+	ErrRegradeStatusUnchanged    = "REGRADE_STATUS_UNCHANGED"
+	ErrAppealOwnOrganization     = "APPEAL_OWN_ORGANIZATION"
+	ErrAppealOwnGrade            = "APPEAL_OWN_GRADE"
+	ErrRegradeNotFound           = "REGRADE_NOT_FOUND"
+
+	// Relationship graph
+	ErrGraphAssetNotFound = "GRAPH_ASSET_NOT_FOUND"
+
+	// Handoffs
+	ErrHandoffDestinationRequired = "HANDOFF_DESTINATION_REQUIRED"
+	ErrHandoffForbidden           = "HANDOFF_FORBIDDEN"
+	ErrWasteTransferPending       = "WASTE_TRANSFER_PENDING"
+	ErrWasteLocked                = "WASTE_LOCKED"
+	ErrHandoffSourceRequired      = "HANDOFF_SOURCE_REQUIRED"
+	ErrContentHashInvalid         = "CONTENT_HASH_INVALID"
+	ErrHandoffNotFound            = "HANDOFF_NOT_FOUND"
+	ErrHandoffReferenceNotFound   = "HANDOFF_REFERENCE_NOT_FOUND"
+	ErrHandoffReferenceDuplicate  = "HANDOFF_REFERENCE_DUPLICATE"
+
+	// Identity bindings
+	ErrIdentityBindingFieldsRequired   = "IDENTITY_BINDING_FIELDS_REQUIRED"
+	ErrRebindReasonRequired            = "REBIND_REASON_REQUIRED"
+	ErrParticipantOrganizationMismatch = "PARTICIPANT_ORGANIZATION_MISMATCH"
+	ErrIdentityRequired                = "IDENTITY_REQUIRED"
+
+	// Impact reports
+	ErrImpactReportForbidden = "IMPACT_REPORT_FORBIDDEN"
+
+	// Incidents
+	ErrIncidentKindUnsupported     = "INCIDENT_KIND_UNSUPPORTED"
+	ErrSeverityUnsupported         = "SEVERITY_UNSUPPORTED"
+	ErrIncidentDescriptionRequired = "INCIDENT_DESCRIPTION_REQUIRED"
+	ErrIncidentSubjectRequired     = "INCIDENT_SUBJECT_REQUIRED"
+	ErrOccurrenceTimeInvalid       = "OCCURRENCE_TIME_INVALID"
+	ErrOccurrenceTimeFuture        = "OCCURRENCE_TIME_FUTURE"
+	ErrIncidentAlreadyExists       = "INCIDENT_ALREADY_EXISTS"
+	ErrCorrectiveActionRequired    = "CORRECTIVE_ACTION_REQUIRED"
+	ErrDueDateInvalid              = "DUE_DATE_INVALID"
+	ErrCorrectiveActionNotFound    = "CORRECTIVE_ACTION_NOT_FOUND"
+	ErrCorrectiveActionCompleted   = "CORRECTIVE_ACTION_COMPLETED"
+	ErrRegulatorAlreadyNotified    = "REGULATOR_ALREADY_NOTIFIED"
+	ErrCorrectiveActionsMissing    = "CORRECTIVE_ACTIONS_MISSING"
+	ErrCorrectiveActionPending     = "CORRECTIVE_ACTION_PENDING"
+	ErrRegulatorNotNotified        = "REGULATOR_NOT_NOTIFIED"
+	ErrIncidentNotFound            = "INCIDENT_NOT_FOUND"
+	ErrIncidentStatusInvalid       = "INCIDENT_STATUS_INVALID"
+	ErrIncidentUpdateForbidden     = "INCIDENT_UPDATE_FORBIDDEN"
+
+	// Integrity checks
+	ErrIntegrityCheckForbidden = "INTEGRITY_CHECK_FORBIDDEN"
+
+	// Marketplace
+	ErrListingForbidden       = "LISTING_FORBIDDEN"
+	ErrListedQuantityInvalid  = "LISTED_QUANTITY_INVALID"
+	ErrReservePriceNegative   = "RESERVE_PRICE_NEGATIVE"
+	ErrListingExpiryPast      = "LISTING_EXPIRY_PAST"
+	ErrWasteAlreadyListed     = "WASTE_ALREADY_LISTED"
+	ErrListingAlreadyExists   = "LISTING_ALREADY_EXISTS"
+	ErrSellerBidForbidden     = "SELLER_BID_FORBIDDEN"
+	ErrListingClosed          = "LISTING_CLOSED"
+	ErrBidAmountRequired      = "BID_AMOUNT_REQUIRED"
+	ErrBidTransientInvalid    = "BID_TRANSIENT_INVALID"
+	ErrBidAmountInvalid       = "BID_AMOUNT_INVALID"
+	ErrBidSaltRequired        = "BID_SALT_REQUIRED"
+	ErrBidRevealClosed        = "BID_REVEAL_CLOSED"
+	ErrBidsSealed             = "BIDS_SEALED"
+	ErrBidRevealExpired       = "BID_REVEAL_EXPIRED"
+	ErrBidNotFound            = "BID_NOT_FOUND"
+	ErrBidAlreadyRevealed     = "BID_ALREADY_REVEALED"
+	ErrBidNotOnPeer           = "BID_NOT_ON_PEER"
+	ErrBidCommitmentMismatch  = "BID_COMMITMENT_MISMATCH"
+	ErrListingNotAwardable    = "LISTING_NOT_AWARDABLE"
+	ErrBidsUnrevealed         = "BIDS_UNREVEALED"
+	ErrListingNotSettleable   = "LISTING_NOT_SETTLEABLE"
+	ErrListingNotFound        = "LISTING_NOT_FOUND"
+	ErrListingManageForbidden = "LISTING_MANAGE_FORBIDDEN"
+	ErrListingExpiryInvalid   = "LISTING_EXPIRY_INVALID"
+
+	// Media
+	ErrMediaInvalid              = "MEDIA_INVALID"
+	ErrMediaHashInvalid          = "MEDIA_HASH_INVALID"
+	ErrMediaTypeUnsupported      = "MEDIA_TYPE_UNSUPPORTED"
+	ErrMediaURIRequired          = "MEDIA_URI_REQUIRED"
+	ErrMediaSizeNegative         = "MEDIA_SIZE_NEGATIVE"
+	ErrCapturePositionIncomplete = "CAPTURE_POSITION_INCOMPLETE"
+	ErrCapturePositionOutOfRange = "CAPTURE_POSITION_OUT_OF_RANGE"
+	ErrCaptureTimeInvalid        = "CAPTURE_TIME_INVALID"
+	ErrCaptureTimeFuture         = "CAPTURE_TIME_FUTURE"
+	ErrMediaAlreadyAttached      = "MEDIA_ALREADY_ATTACHED"
+	ErrMediaLimitReached         = "MEDIA_LIMIT_REACHED"
+	ErrMediaNotAttached          = "MEDIA_NOT_ATTACHED"
+	ErrMediaRemoveForbidden      = "MEDIA_REMOVE_FORBIDDEN"
+	ErrMediaOrderInvalid         = "MEDIA_ORDER_INVALID"
+	ErrMediaOrderIncomplete      = "MEDIA_ORDER_INCOMPLETE"
+	ErrMediaOrderMismatch        = "MEDIA_ORDER_MISMATCH"
+
+	// Recycling methods
+	ErrMethodCodeInvalid            = "METHOD_CODE_INVALID"
+	ErrMethodNameRequired           = "METHOD_NAME_REQUIRED"
+	ErrEmissionFactorNegative       = "EMISSION_FACTOR_NEGATIVE"
+	ErrTypicalDurationNegative      = "TYPICAL_DURATION_NEGATIVE"
+	ErrMethodNotInCatalog           = "METHOD_NOT_IN_CATALOG"
+	ErrMethodRetired                = "METHOD_RETIRED"
+	ErrFacilityCertificationMissing = "FACILITY_CERTIFICATION_MISSING"
+
+	// Metrics
+	ErrMetricsReadForbidden = "METRICS_READ_FORBIDDEN"
+
+	// Migrations
+	ErrMigrationOrder         = "MIGRATION_ORDER"
+	ErrMigrationNotRegistered = "MIGRATION_NOT_REGISTERED"
+	ErrMigrationFailed        = "MIGRATION_FAILED"
+
+	// Notifications
+	ErrNotificationNotFound = "NOTIFICATION_NOT_FOUND"
+
+	// Onboarding
+	ErrOnboardingStepUnknown    = "ONBOARDING_STEP_UNKNOWN"
+	ErrEvidenceHashInvalid      = "EVIDENCE_HASH_INVALID"
+	ErrOnboardingStepOrder      = "ONBOARDING_STEP_ORDER"
+	ErrParticipantIDRequired    = "PARTICIPANT_ID_REQUIRED"
+	ErrParticipantAlreadyActive = "PARTICIPANT_ALREADY_ACTIVE"
+	ErrOnboardingNotFound       = "ONBOARDING_NOT_FOUND"
+	ErrParticipantNotActive     = "PARTICIPANT_NOT_ACTIVE"
+
+	// Co-ownership
+	ErrWasteAlreadyCoowned       = "WASTE_ALREADY_COOWNED"
+	ErrOwnershipSplitForbidden   = "OWNERSHIP_SPLIT_FORBIDDEN"
+	ErrShareHolderRequired       = "SHARE_HOLDER_REQUIRED"
+	ErrShareNotPositive          = "SHARE_NOT_POSITIVE"
+	ErrShareHolderDuplicate      = "SHARE_HOLDER_DUPLICATE"
+	ErrCoownershipHoldersTooFew  = "COOWNERSHIP_HOLDERS_TOO_FEW"
+	ErrSharesTotalInvalid        = "SHARES_TOTAL_INVALID"
+	ErrTransferPercentageInvalid = "TRANSFER_PERCENTAGE_INVALID"
+	ErrBuyerIdentityRequired     = "BUYER_IDENTITY_REQUIRED"
+	ErrWasteHandedOff            = "WASTE_HANDED_OFF"
+	ErrTransferSameParty         = "TRANSFER_SAME_PARTY"
+	ErrShareInsufficient         = "SHARE_INSUFFICIENT"
+	ErrShareNotHeld              = "SHARE_NOT_HELD"
+	ErrAmountNegative            = "AMOUNT_NEGATIVE"
+
+	// Intake planning
+	ErrIntakeDatePast                   = "INTAKE_DATE_PAST"
+	ErrIntakeReservationsUnsupported    = "INTAKE_RESERVATIONS_UNSUPPORTED"
+	ErrSupplyContractStatusInvalid      = "SUPPLY_CONTRACT_STATUS_INVALID"
+	ErrSupplyContractPartyMismatch      = "SUPPLY_CONTRACT_PARTY_MISMATCH"
+	ErrIntakeReserveForbidden           = "INTAKE_RESERVE_FORBIDDEN"
+	ErrIntakeReservationAlreadyExists   = "INTAKE_RESERVATION_ALREADY_EXISTS"
+	ErrIntakeReservationStatusUnchanged = "INTAKE_RESERVATION_STATUS_UNCHANGED"
+	ErrIntakeCancelForbidden            = "INTAKE_CANCEL_FORBIDDEN"
+	ErrIntakeReservationNotFound        = "INTAKE_RESERVATION_NOT_FOUND"
+	ErrIntakePlanForbidden              = "INTAKE_PLAN_FORBIDDEN"
+	ErrIntakeUnsupported                = "INTAKE_UNSUPPORTED"
+	ErrPeriodInvalid                    = "PERIOD_INVALID"
+	ErrForecastTooLong                  = "FORECAST_TOO_LONG"
+
+	// Plots
+	ErrPlotFieldsRequired      = "PLOT_FIELDS_REQUIRED"
+	ErrParcelSchemeInvalid     = "PARCEL_SCHEME_INVALID"
+	ErrPlotAreaNegative        = "PLOT_AREA_NEGATIVE"
+	ErrPlotAlreadyExists       = "PLOT_ALREADY_EXISTS"
+	ErrParcelAlreadyRegistered = "PARCEL_ALREADY_REGISTERED"
+	ErrPlotAlreadyRetired      = "PLOT_ALREADY_RETIRED"
+	ErrPlotRetireForbidden     = "PLOT_RETIRE_FORBIDDEN"
+	ErrPlotNotFound            = "PLOT_NOT_FOUND"
+
+	// Prices
+	ErrProductTypeRequired  = "PRODUCT_TYPE_REQUIRED"
+	ErrPriceNegative        = "PRICE_NEGATIVE"
+	ErrCurrencyInvalid      = "CURRENCY_INVALID"
+	ErrPriceAlreadyRecorded = "PRICE_ALREADY_RECORDED"
+	ErrPriceOracleRequired  = "PRICE_ORACLE_REQUIRED"
+
+	// Personal data
+	ErrPersonalDataRestricted     = "PERSONAL_DATA_RESTRICTED"
+	ErrPersonalDataNotFound       = "PERSONAL_DATA_NOT_FOUND"
+	ErrPIITransientInvalid        = "PII_TRANSIENT_INVALID"
+	ErrPIISubjectRequired         = "PII_SUBJECT_REQUIRED"
+	ErrParticipantManageForbidden = "PARTICIPANT_MANAGE_FORBIDDEN"
+	ErrParticipantNotFound        = "PARTICIPANT_NOT_FOUND"
+
+	// Public statistics
+	ErrPublicStatisticsDisabled = "PUBLIC_STATISTICS_DISABLED"
+	ErrPublicGroupByInvalid     = "PUBLIC_GROUP_BY_INVALID"
+
+	// Data quality
+	ErrFieldUnknown            = "FIELD_UNKNOWN"
+	ErrSeverityInvalid         = "SEVERITY_INVALID"
+	ErrEnrichmentsInvalid      = "ENRICHMENTS_INVALID"
+	ErrEnrichmentRequired      = "ENRICHMENT_REQUIRED"
+	ErrEnrichmentBatchTooLarge = "ENRICHMENT_BATCH_TOO_LARGE"
+	ErrEnrichmentForbidden     = "ENRICHMENT_FORBIDDEN"
+	ErrPersonalDataErased      = "PERSONAL_DATA_ERASED"
+	ErrFieldAlreadySet         = "FIELD_ALREADY_SET"
+	ErrNoMissingField          = "NO_MISSING_FIELD"
+	ErrHarvestDateFuture       = "HARVEST_DATE_FUTURE"
+
+	// Quotas
+	ErrSeasonInvalid       = "SEASON_INVALID"
+	ErrQuotaPolicyInvalid  = "QUOTA_POLICY_INVALID"
+	ErrQuotaValuesNegative = "QUOTA_VALUES_NEGATIVE"
+	ErrFarmAreaUnknown     = "FARM_AREA_UNKNOWN"
+	ErrQuotaNotFound       = "QUOTA_NOT_FOUND"
+
+	// References
+	ErrReferenceNotFound = "REFERENCE_NOT_FOUND"
+
+	// Research exports
+	ErrDatasetFieldsRequired = "DATASET_FIELDS_REQUIRED"
+	ErrRecordCountNegative   = "RECORD_COUNT_NEGATIVE"
+	ErrDatasetAlreadyExists  = "DATASET_ALREADY_EXISTS"
+	ErrDatasetNotFound       = "DATASET_NOT_FOUND"
+
+	// Data residency
+	ErrRegionCodeInvalid            = "REGION_CODE_INVALID"
+	ErrRegionCollectionRequired     = "REGION_COLLECTION_REQUIRED"
+	ErrRegionOrganizationsInvalid   = "REGION_ORGANIZATIONS_INVALID"
+	ErrRegionOrganizationsRequired  = "REGION_ORGANIZATIONS_REQUIRED"
+	ErrRegionReadersInvalid         = "REGION_READERS_INVALID"
+	ErrRegionCollectionTaken        = "REGION_COLLECTION_TAKEN"
+	ErrRegionAlreadyPinned          = "REGION_ALREADY_PINNED"
+	ErrRegionInUse                  = "REGION_IN_USE"
+	ErrRegionNotFound               = "REGION_NOT_FOUND"
+	ErrPersonalDataRegionRestricted = "PERSONAL_DATA_REGION_RESTRICTED"
+
+	// Validation rules
+	ErrRuleAssetTypeInvalid   = "RULE_ASSET_TYPE_INVALID"
+	ErrExpressionInvalid      = "EXPRESSION_INVALID"
+	ErrRuleSeverityInvalid    = "RULE_SEVERITY_INVALID"
+	ErrRuleWarningUnsupported = "RULE_WARNING_UNSUPPORTED"
+	ErrRuleNotFound           = "RULE_NOT_FOUND"
+	ErrAssetJSONInvalid       = "ASSET_JSON_INVALID"
+	ErrRuleEvaluationFailed   = "RULE_EVALUATION_FAILED"
+
+	// Audit sampling
+	ErrSampleSelectForbidden     = "SAMPLE_SELECT_FORBIDDEN"
+	ErrSampleSizeInvalid         = "SAMPLE_SIZE_INVALID"
+	ErrSampleFilterInvalid       = "SAMPLE_FILTER_INVALID"
+	ErrSampleAlreadyExists       = "SAMPLE_ALREADY_EXISTS"
+	ErrSamplePopulationEmpty     = "SAMPLE_POPULATION_EMPTY"
+	ErrSampleTooLarge            = "SAMPLE_TOO_LARGE"
+	ErrInspectionRecordForbidden = "INSPECTION_RECORD_FORBIDDEN"
+	ErrSampleNotFound            = "SAMPLE_NOT_FOUND"
+
+	// Sensors
+	ErrSensorAssetTypeInvalid    = "SENSOR_ASSET_TYPE_INVALID"
+	ErrSensorIDRequired          = "SENSOR_ID_REQUIRED"
+	ErrRecordedAtInvalid         = "RECORDED_AT_INVALID"
+	ErrReadingOutOfOrder         = "READING_OUT_OF_ORDER"
+	ErrBreachRulesSettingInvalid = "BREACH_RULES_SETTING_INVALID"
+	ErrBreachRuleInvalid         = "BREACH_RULE_INVALID"
+	ErrSensorGatewayUnregistered = "SENSOR_GATEWAY_UNREGISTERED"
+	ErrSensorGatewayRequired     = "SENSOR_GATEWAY_REQUIRED"
+
+	// Settlements
+	ErrTokenChaincodeUnset        = "TOKEN_CHAINCODE_UNSET"
+	ErrSettlementConfirmForbidden = "SETTLEMENT_CONFIRM_FORBIDDEN"
+	ErrCancelReasonRequired       = "CANCEL_REASON_REQUIRED"
+	ErrSettlementCancelForbidden  = "SETTLEMENT_CANCEL_FORBIDDEN"
+	ErrSettlementNotFound         = "SETTLEMENT_NOT_FOUND"
+	ErrSettlementStatusInvalid    = "SETTLEMENT_STATUS_INVALID"
+	ErrTokenCallFailed            = "TOKEN_CALL_FAILED"
+
+	// SLAs
+	ErrSLADaysInvalid     = "SLA_DAYS_INVALID"
+	ErrSLADefineForbidden = "SLA_DEFINE_FORBIDDEN"
+	ErrSLAScopeInvalid    = "SLA_SCOPE_INVALID"
+	ErrSLANotFound        = "SLA_NOT_FOUND"
+
+	// Snapshots
+	ErrSnapshotIncomplete      = "SNAPSHOT_INCOMPLETE"
+	ErrSnapshotAlreadyAnchored = "SNAPSHOT_ALREADY_ANCHORED"
+	ErrSnapshotNotFound        = "SNAPSHOT_NOT_FOUND"
+	ErrSnapshotKeyNotFound     = "SNAPSHOT_KEY_NOT_FOUND"
+	ErrSnapshotChunkMissing    = "SNAPSHOT_CHUNK_MISSING"
+	ErrLeafHashInvalid         = "LEAF_HASH_INVALID"
+
+	// Status reasons
+	ErrStatusReasonsInvalid    = "STATUS_REASONS_INVALID"
+	ErrStatusReasonsEmpty      = "STATUS_REASONS_EMPTY"
+	ErrStatusReasonInvalid     = "STATUS_REASON_INVALID"
+	ErrStatusReasonDuplicate   = "STATUS_REASON_DUPLICATE"
+	ErrStatusReasonTaken       = "STATUS_REASON_TAKEN"
+	ErrStatusReasonsNotDefined = "STATUS_REASONS_NOT_DEFINED"
+	ErrStatusReasonRequired    = "STATUS_REASON_REQUIRED"
+	ErrStatusReasonUnknown     = "STATUS_REASON_UNKNOWN"
+
+	// Storage
+	ErrStorageFieldsRequired   = "STORAGE_FIELDS_REQUIRED"
+	ErrStorageCapacityInvalid  = "STORAGE_CAPACITY_INVALID"
+	ErrTemperatureRangeInvalid = "TEMPERATURE_RANGE_INVALID"
+	ErrStorageAlreadyExists    = "STORAGE_ALREADY_EXISTS"
+	ErrStorageStatusInvalid    = "STORAGE_STATUS_INVALID"
+	ErrWasteAlreadyStored      = "WASTE_ALREADY_STORED"
+	ErrCheckInQuantityInvalid  = "CHECK_IN_QUANTITY_INVALID"
+	ErrWasteNotStored          = "WASTE_NOT_STORED"
+	ErrStorageNotFound         = "STORAGE_NOT_FOUND"
+	ErrStorageManageForbidden  = "STORAGE_MANAGE_FORBIDDEN"
+
+	// Subsidies
+	ErrSchemeIDInvalid           = "SCHEME_ID_INVALID"
+	ErrSchemeFieldsRequired      = "SCHEME_FIELDS_REQUIRED"
+	ErrRatePerUnitNegative       = "RATE_PER_UNIT_NEGATIVE"
+	ErrValidityPeriodInvalid     = "VALIDITY_PERIOD_INVALID"
+	ErrSubsidyConditionsInvalid  = "SUBSIDY_CONDITIONS_INVALID"
+	ErrSubsidyConditionsEmpty    = "SUBSIDY_CONDITIONS_EMPTY"
+	ErrSubsidyConditionInvalid   = "SUBSIDY_CONDITION_INVALID"
+	ErrSubsidyConditionDuplicate = "SUBSIDY_CONDITION_DUPLICATE"
+	ErrSubsidyExpressionInvalid  = "SUBSIDY_EXPRESSION_INVALID"
+	ErrSchemeAlreadyRetired      = "SCHEME_ALREADY_RETIRED"
+	ErrSchemeNotFound            = "SCHEME_NOT_FOUND"
+	ErrSchemeRetired             = "SCHEME_RETIRED"
+	ErrSubsidyClaimForbidden     = "SUBSIDY_CLAIM_FORBIDDEN"
+	ErrSubsidyConditionFailed    = "SUBSIDY_CONDITION_FAILED"
+	ErrSubsidyExportForbidden    = "SUBSIDY_EXPORT_FORBIDDEN"
+
+	// Supply contracts
+	ErrSupplyContractFieldsRequired   = "SUPPLY_CONTRACT_FIELDS_REQUIRED"
+	ErrSupplyContractPartiesInvalid   = "SUPPLY_CONTRACT_PARTIES_INVALID"
+	ErrCommittedVolumeInvalid         = "COMMITTED_VOLUME_INVALID"
+	ErrBasePriceInvalid               = "BASE_PRICE_INVALID"
+	ErrPriceKindInvalid               = "PRICE_KIND_INVALID"
+	ErrSupplyContractPartyRequired    = "SUPPLY_CONTRACT_PARTY_REQUIRED"
+	ErrSupplyContractAlreadyExists    = "SUPPLY_CONTRACT_ALREADY_EXISTS"
+	ErrSupplyContractStatusUnexpected = "SUPPLY_CONTRACT_STATUS_UNEXPECTED"
+	ErrSupplyContractAcceptForbidden  = "SUPPLY_CONTRACT_ACCEPT_FORBIDDEN"
+	ErrExtractionAlreadyLinked        = "EXTRACTION_ALREADY_LINKED"
+	ErrSupplyContractNotFound         = "SUPPLY_CONTRACT_NOT_FOUND"
+	ErrWasteSupplierMismatch          = "WASTE_SUPPLIER_MISMATCH"
+	ErrWasteStatusUnexpected          = "WASTE_STATUS_UNEXPECTED"
+	ErrWasteAlreadyDelivered          = "WASTE_ALREADY_DELIVERED"
+	ErrDeliveredQuantityExceeded      = "DELIVERED_QUANTITY_EXCEEDED"
+	ErrSupplyContractOutOfTerm        = "SUPPLY_CONTRACT_OUT_OF_TERM"
+	ErrSupplyContractManageForbidden  = "SUPPLY_CONTRACT_MANAGE_FORBIDDEN"
+
+	// Tags
+	ErrTagInvalid         = "TAG_INVALID"
+	ErrWasteAlreadyTagged = "WASTE_ALREADY_TAGGED"
+	ErrTagLimitReached    = "TAG_LIMIT_REACHED"
+	ErrWasteNotTagged     = "WASTE_NOT_TAGGED"
+
+	// Taxonomy
+	ErrCategoryCodeInvalid  = "CATEGORY_CODE_INVALID"
+	ErrSubtypeCodeInvalid   = "SUBTYPE_CODE_INVALID"
+	ErrCategoryNotFound     = "CATEGORY_NOT_FOUND"
+	ErrTaxonomyNodeNotFound = "TAXONOMY_NODE_NOT_FOUND"
+
+	// Waste templates
+	ErrTemplateNameRequired      = "TEMPLATE_NAME_REQUIRED"
+	ErrTemplateNotFound          = "TEMPLATE_NOT_FOUND"
+	ErrTemplateInvalid           = "TEMPLATE_INVALID"
+	ErrTemplateWasteTypeRequired = "TEMPLATE_WASTE_TYPE_REQUIRED"
+	ErrUnitUnknown               = "UNIT_UNKNOWN"
+	ErrDefaultQuantityNegative   = "DEFAULT_QUANTITY_NEGATIVE"
+
+	// Traceability
+	ErrOffsetNegative        = "OFFSET_NEGATIVE"
+	ErrTraceAssetTypeInvalid = "TRACE_ASSET_TYPE_INVALID"
+
+	// Transport
+	ErrVehiclePlateRequired        = "VEHICLE_PLATE_REQUIRED"
+	ErrVehicleCapacityNegative     = "VEHICLE_CAPACITY_NEGATIVE"
+	ErrVehicleAlreadyExists        = "VEHICLE_ALREADY_EXISTS"
+	ErrPlateAlreadyRegistered      = "PLATE_ALREADY_REGISTERED"
+	ErrDriverFieldsRequired        = "DRIVER_FIELDS_REQUIRED"
+	ErrLicenseExpiryInvalid        = "LICENSE_EXPIRY_INVALID"
+	ErrDriverAlreadyExists         = "DRIVER_ALREADY_EXISTS"
+	ErrPermitFieldsRequired        = "PERMIT_FIELDS_REQUIRED"
+	ErrPermitExpiryInvalid         = "PERMIT_EXPIRY_INVALID"
+	ErrVehiclePermitForbidden      = "VEHICLE_PERMIT_FORBIDDEN"
+	ErrDriverPermitForbidden       = "DRIVER_PERMIT_FORBIDDEN"
+	ErrPermitHolderTypeInvalid     = "PERMIT_HOLDER_TYPE_INVALID"
+	ErrVehicleAlreadyRetired       = "VEHICLE_ALREADY_RETIRED"
+	ErrVehicleRetireForbidden      = "VEHICLE_RETIRE_FORBIDDEN"
+	ErrVehicleNotFound             = "VEHICLE_NOT_FOUND"
+	ErrDriverNotFound              = "DRIVER_NOT_FOUND"
+	ErrShipmentCarrierRequired     = "SHIPMENT_CARRIER_REQUIRED"
+	ErrShipmentDestinationRequired = "SHIPMENT_DESTINATION_REQUIRED"
+	ErrDepartureDateInvalid        = "DEPARTURE_DATE_INVALID"
+	ErrShipmentQuantityInvalid     = "SHIPMENT_QUANTITY_INVALID"
+	ErrVehicleUnavailable          = "VEHICLE_UNAVAILABLE"
+	ErrDriverUnavailable           = "DRIVER_UNAVAILABLE"
+	ErrDriverLicenseExpired        = "DRIVER_LICENSE_EXPIRED"
+	ErrShipmentAlreadyExists       = "SHIPMENT_ALREADY_EXISTS"
+	ErrShipmentStatusInvalid       = "SHIPMENT_STATUS_INVALID"
+	ErrShipmentDeliverForbidden    = "SHIPMENT_DELIVER_FORBIDDEN"
+	ErrShipmentNotFound            = "SHIPMENT_NOT_FOUND"
+
+	// Validation warnings
+	ErrResolutionRequired      = "RESOLUTION_REQUIRED"
+	ErrWarningResolveForbidden = "WARNING_RESOLVE_FORBIDDEN"
+	ErrWarningNotFound         = "WARNING_NOT_FOUND"
+	ErrFieldNotRequirable      = "FIELD_NOT_REQUIRABLE"
+
+	// Weather
+	ErrWeatherEventUnknown      = "WEATHER_EVENT_UNKNOWN"
+	ErrWeatherSeverityUnknown   = "WEATHER_SEVERITY_UNKNOWN"
+	ErrTemperatureBoundsInvalid = "TEMPERATURE_BOUNDS_INVALID"
+	ErrPrecipitationNegative    = "PRECIPITATION_NEGATIVE"
+	ErrWeatherAlreadyRecorded   = "WEATHER_ALREADY_RECORDED"
+	ErrRangeInvalid             = "RANGE_INVALID"
+	ErrWeatherOracleUnset       = "WEATHER_ORACLE_UNSET"
+	ErrWeatherOracleRequired    = "WEATHER_ORACLE_REQUIRED"
+)
+
+// messageCatalog holds the localized template of each error code
+var messageCatalog = map[string]map[string]string{
+	ErrLedgerRead: {
+		LangEnglish: "failed to read record %s: %v",
+		LangFrench:  "échec de la lecture de l'enregistrement %s : %v",
+	},
+	ErrLedgerWrite: {
+		LangEnglish: "failed to write record %s: %v",
+		LangFrench:  "échec de l'écriture de l'enregistrement %s : %v",
+	},
+	ErrHistoryRead: {
+		LangEnglish: "failed to read the history of %s: %v",
+		LangFrench:  "échec de la lecture de l'historique de %s : %v",
+	},
+	ErrIdentity: {
+		LangEnglish: "failed to read client identity: %v",
+		LangFrench:  "impossible de lire l'identité du client : %v",
+	},
+	ErrNotAdmin: {
+		LangEnglish: "caller is not authorized: admin role required",
+		LangFrench:  "appelant non autorisé : rôle administrateur requis",
+	},
+	ErrTimestamp: {
+		LangEnglish: "failed to read transaction timestamp: %v",
+		LangFrench:  "impossible de lire l'horodatage de la transaction : %v",
+	},
+	ErrWasteTypeRequired: {
+		LangEnglish: "waste type is required",
+		LangFrench:  "le type de déchet est requis",
+	},
+	ErrQuantityInvalid: {
+		LangEnglish: "quantity must be positive",
+		LangFrench:  "la quantité doit être positive",
+	},
+	ErrHarvestDate: {
+		LangEnglish: "invalid harvest date %q (expected YYYY-MM-DD)",
+		LangFrench:  "date de récolte %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrWasteExists: {
+		LangEnglish: "waste %s already exists",
+		LangFrench:  "le déchet %s existe déjà",
+	},
+	ErrWasteNotFound: {
+		LangEnglish: "waste %s does not exist",
+		LangFrench:  "le déchet %s n'existe pas",
+	},
+	ErrCampaignClosed: {
+		LangEnglish: "campaign %s is closed to new wastes",
+		LangFrench:  "la campagne %s est fermée aux nouveaux déchets",
+	},
+	ErrStatusRequired: {
+		LangEnglish: "new status is required",
+		LangFrench:  "le nouveau statut est requis",
+	},
+	ErrExtractionExists: {
+		LangEnglish: "extraction %s already exists",
+		LangFrench:  "l'extraction %s existe déjà",
+	},
+	ErrExtractionMissing: {
+		LangEnglish: "extraction %s does not exist",
+		LangFrench:  "l'extraction %s n'existe pas",
+	},
+	ErrRecyclingExists: {
+		LangEnglish: "recycling %s already exists",
+		LangFrench:  "le recyclage %s existe déjà",
+	},
+	ErrConflict: {
+		LangEnglish: "record %s was modified concurrently: expected version %d, found %d",
+		LangFrench:  "l'enregistrement %s a été modifié entre-temps : version %d attendue, version %d trouvée",
+	},
+	ErrWasteNotVisible: {
+		LangEnglish: "waste %s is not shared with your organization",
+		LangFrench:  "le déchet %s n'est pas partagé avec votre organisation",
+	},
+	ErrFeedbackThrottled: {
+		LangEnglish: "trace token %s accepts one feedback every %d seconds and at most %d per day",
+		LangFrench:  "le jeton de traçabilité %s accepte un avis toutes les %d secondes et au plus %d par jour",
+	},
+	ErrPlotUnavailable: {
+		LangEnglish: "plot %s is not an active plot of your organization",
+		LangFrench:  "la parcelle %s n'est pas une parcelle active de votre organisation",
+	},
+	ErrPlotFarmMismatch: {
+		LangEnglish: "plot %s belongs to farm %q, not to the declared farm %q",
+		LangFrench:  "la parcelle %s appartient à l'exploitation %q et non à l'exploitation déclarée %q",
+	},
+	ErrFieldRequired: {
+		LangEnglish: "%s is required for lots of %s",
+		LangFrench:  "le champ %s est obligatoire pour les lots de %s",
+	},
+	ErrQuotaExceeded: {
+		LangEnglish: "farm %q would declare %.2f in season %s, over its quota of %.2f",
+		LangFrench:  "l'exploitation %q déclarerait %.2f pour la saison %s, au-delà de son quota de %.2f",
+	},
+	ErrRuleViolated: {
+		LangEnglish: "%s %s breaks validation rule %s: %s",
+		LangFrench:  "%s %s enfreint la règle de validation %s : %s",
+	},
+	ErrQueryTruncated: {
+		LangEnglish: "query over [%s, %s) exceeds its budget of %d results and %d bytes after %d results; use a paged query",
+		LangFrench:  "la requête sur [%s, %s) dépasse son budget de %d résultats et %d octets après %d résultats ; utilisez une requête paginée",
+	},
+	ErrOnboardingIncomplete: {
+		LangEnglish: "participant %s cannot be activated before its onboarding steps %s are verified",
+		LangFrench:  "le participant %s ne peut être activé avant la vérification de ses étapes d'intégration %s",
+	},
+	// Access
+	ErrGraderRequired: {
+		LangEnglish: "only quality graders can grade extractions",
+		LangFrench:  "seuls les classificateurs qualité peuvent classer les extractions",
+	},
+
+	// Supply contract adjustments
+	ErrAdjustmentRulesInvalid: {
+		LangEnglish: "invalid adjustment rules: %v",
+		LangFrench:  "règles d'ajustement invalides : %v",
+	},
+	ErrAdjustmentRuleDuplicate: {
+		LangEnglish: "duplicate adjustment rule %s",
+		LangFrench:  "règle d'ajustement %s en double",
+	},
+	ErrAdjustmentRulesLocked: {
+		LangEnglish: "rules of supply contract %s can only change while it is %s",
+		LangFrench:  "les règles du contrat d'approvisionnement %s ne peuvent changer que lorsqu'il est %s",
+	},
+	ErrSupplyAmendmentForbidden: {
+		LangEnglish: "only %s and %s can amend supply contract %s",
+		LangFrench:  "seuls %s et %s peuvent modifier le contrat d'approvisionnement %s",
+	},
+	ErrAdjustmentRuleIDRequired: {
+		LangEnglish: "adjustment rules need an id",
+		LangFrench:  "les règles d'ajustement doivent avoir un identifiant",
+	},
+	ErrAdjustmentKindInvalid: {
+		LangEnglish: "rule %s: kind must be %s or %s",
+		LangFrench:  "règle %s : le type doit être %s ou %s",
+	},
+	ErrAdjustmentGradeUnknown: {
+		LangEnglish: "rule %s: unknown quality grade %q (expected one of %v)",
+		LangFrench:  "règle %s : classe de qualité %q inconnue (valeurs attendues %v)",
+	},
+	ErrAdjustmentPenaltyOnly: {
+		LangEnglish: "rule %s: %s rules can only be penalties",
+		LangFrench:  "règle %s : les règles %s ne peuvent être que des pénalités",
+	},
+	ErrAdjustmentMaxDaysNegative: {
+		LangEnglish: "rule %s: maxDays must not be negative",
+		LangFrench:  "règle %s : maxDays ne doit pas être négatif",
+	},
+	ErrAdjustmentTriggerInvalid: {
+		LangEnglish: "rule %s: trigger must be %s or %s",
+		LangFrench:  "règle %s : le déclencheur doit être %s ou %s",
+	},
+	ErrAdjustmentBasisInvalid: {
+		LangEnglish: "rule %s: basis must be %s or %s",
+		LangFrench:  "règle %s : la base doit être %s ou %s",
+	},
+	ErrAdjustmentRateInvalid: {
+		LangEnglish: "rule %s: rate must be positive and cap not negative",
+		LangFrench:  "règle %s : le taux doit être positif et le plafond non négatif",
+	},
+
+	// Data sharing agreements
+	ErrAgreementIDRequired: {
+		LangEnglish: "agreement id is required",
+		LangFrench:  "l'identifiant de l'accord est requis",
+	},
+	ErrAgreementCounterpartyInvalid: {
+		LangEnglish: "counterparty must be another organization",
+		LangFrench:  "la contrepartie doit être une autre organisation",
+	},
+	ErrValidFromInvalid: {
+		LangEnglish: "invalid validFrom %q (expected YYYY-MM-DD)",
+		LangFrench:  "validFrom %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrValidUntilInvalid: {
+		LangEnglish: "invalid validUntil %q (expected YYYY-MM-DD)",
+		LangFrench:  "validUntil %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrValidityRangeInvalid: {
+		LangEnglish: "validUntil must not be before validFrom",
+		LangFrench:  "validUntil ne doit pas précéder validFrom",
+	},
+	ErrAgreementAlreadyExists: {
+		LangEnglish: "agreement %s already exists",
+		LangFrench:  "l'accord %s existe déjà",
+	},
+	ErrAgreementStatusInvalid: {
+		LangEnglish: "agreement %s is %s, not %s",
+		LangFrench:  "l'accord %s est %s et non %s",
+	},
+	ErrAgreementAcceptForbidden: {
+		LangEnglish: "only %s can accept agreement %s",
+		LangFrench:  "seul %s peut accepter l'accord %s",
+	},
+	ErrAgreementAlreadyRevoked: {
+		LangEnglish: "agreement %s is already revoked",
+		LangFrench:  "l'accord %s est déjà révoqué",
+	},
+	ErrAgreementRevokeForbidden: {
+		LangEnglish: "only the parties of agreement %s can revoke it",
+		LangFrench:  "seules les parties de l'accord %s peuvent le révoquer",
+	},
+	ErrAgreementNotFound: {
+		LangEnglish: "agreement %s does not exist",
+		LangFrench:  "l'accord %s n'existe pas",
+	},
+	ErrAgreementScopeUnknown: {
+		LangEnglish: "unknown agreement scope %q",
+		LangFrench:  "périmètre d'accord %q inconnu",
+	},
+	ErrAgreementScopeRequired: {
+		LangEnglish: "agreement scope is required",
+		LangFrench:  "le périmètre de l'accord est requis",
+	},
+
+	// Approval matrices
+	ErrOrganizationRequired: {
+		LangEnglish: "organization MSP is required",
+		LangFrench:  "le MSP de l'organisation est requis",
+	},
+	ErrApprovalRulesInvalid: {
+		LangEnglish: "invalid approval rules: %v",
+		LangFrench:  "règles d'approbation invalides : %v",
+	},
+	ErrEffectiveDatePast: {
+		LangEnglish: "effective date %s is in the past; versions already in effect cannot be changed",
+		LangFrench:  "la date d'effet %s est passée ; les versions déjà en vigueur ne peuvent pas être modifiées",
+	},
+	ErrApprovalMatrixNotFound: {
+		LangEnglish: "approval matrix of %s does not exist",
+		LangFrench:  "la matrice d'approbation de %s n'existe pas",
+	},
+	ErrApprovalMatrixVersionInEffect: {
+		LangEnglish: "version %s of the approval matrix of %s is already in effect",
+		LangFrench:  "la version %s de la matrice d'approbation de %s est déjà en vigueur",
+	},
+	ErrApprovalMatrixVersionNotFound: {
+		LangEnglish: "version %s of the approval matrix of %s does not exist",
+		LangFrench:  "la version %s de la matrice d'approbation de %s n'existe pas",
+	},
+	ErrApprovalSettingsInvalid: {
+		LangEnglish: "invalid approvals.transferRoles setting: %v",
+		LangFrench:  "paramètre approvals.transferRoles invalide : %v",
+	},
+	ErrApprovalOperationUnknown: {
+		LangEnglish: "unknown operation %q (expected %s)",
+		LangFrench:  "opération %q inconnue (valeurs attendues %s)",
+	},
+	ErrApprovalMinQuantityNegative: {
+		LangEnglish: "the minimum quantity of a rule cannot be negative",
+		LangFrench:  "la quantité minimale d'une règle ne peut pas être négative",
+	},
+	ErrApprovalRuleDuplicate: {
+		LangEnglish: "more than one %s rule over %g",
+		LangFrench:  "plus d'une règle %s au-delà de %g",
+	},
+	ErrApprovalRoleUnknown: {
+		LangEnglish: "unknown role %q (expected %s)",
+		LangFrench:  "rôle %q inconnu (valeurs attendues %s)",
+	},
+	ErrApprovalRuleNoRole: {
+		LangEnglish: "the %s rule over %g lists no role",
+		LangFrench:  "la règle %s au-delà de %g n'indique aucun rôle",
+	},
+	ErrEffectiveDateInvalid: {
+		LangEnglish: "invalid effective date %q (expected YYYY-MM-DD or RFC3339)",
+		LangFrench:  "date d'effet %q invalide (format attendu AAAA-MM-JJ ou RFC3339)",
+	},
+	ErrApprovalMatrixReadForbidden: {
+		LangEnglish: "only %s or an admin can read its approval matrix",
+		LangFrench:  "seul %s ou un administrateur peut lire sa matrice d'approbation",
+	},
+
+	// Approvals
+	ErrApprovalRoleNotNeeded: {
+		LangEnglish: "approval %s does not need the %s role (needs %s)",
+		LangFrench:  "l'approbation %s ne requiert pas le rôle %s (rôles requis %s)",
+	},
+	ErrApprovalRoleAlreadySigned: {
+		LangEnglish: "approval %s was already signed as %s by %s",
+		LangFrench:  "l'approbation %s a déjà été signée en tant que %s par %s",
+	},
+	ErrApprovalAlreadySigned: {
+		LangEnglish: "%s already signed approval %s as %s",
+		LangFrench:  "%s a déjà signé l'approbation %s en tant que %s",
+	},
+	ErrApprovalSignForbidden: {
+		LangEnglish: "caller cannot approve %s as %s",
+		LangFrench:  "l'appelant ne peut pas approuver %s en tant que %s",
+	},
+	ErrRejectionReasonRequired: {
+		LangEnglish: "a rejection reason is required",
+		LangFrench:  "un motif de rejet est requis",
+	},
+	ErrApprovalRejectForbidden: {
+		LangEnglish: "caller cannot reject approval %s",
+		LangFrench:  "l'appelant ne peut pas rejeter l'approbation %s",
+	},
+	ErrApprovalNotFound: {
+		LangEnglish: "approval %s does not exist",
+		LangFrench:  "l'approbation %s n'existe pas",
+	},
+	ErrApprovalStatusInvalid: {
+		LangEnglish: "approval %s is %s",
+		LangFrench:  "l'approbation %s est %s",
+	},
+
+	// Point-in-time reads
+	ErrWasteNotFoundAt: {
+		LangEnglish: "waste %s did not exist at %s",
+		LangFrench:  "le déchet %s n'existait pas à %s",
+	},
+	ErrWasteHistoryDecodeFailed: {
+		LangEnglish: "failed to decode waste %s as of %s: %v",
+		LangFrench:  "échec du décodage du déchet %s à la date %s : %v",
+	},
+	ErrTimeInvalid: {
+		LangEnglish: "invalid time %q (expected RFC3339 or YYYY-MM-DD)",
+		LangFrench:  "heure %q invalide (format attendu RFC3339 ou AAAA-MM-JJ)",
+	},
+	ErrWasteErasedAt: {
+		LangEnglish: "waste %s cannot be read as of %s: the personal data of its participant was erased at %s",
+		LangFrench:  "le déchet %s ne peut être lu à la date %s : les données personnelles de son participant ont été effacées le %s",
+	},
+
+	// Audit trail
+	ErrFunctionRequired: {
+		LangEnglish: "the failed function name is required",
+		LangFrench:  "le nom de la fonction en échec est requis",
+	},
+	ErrAuditTrailForbidden: {
+		LangEnglish: "only admins and auditors can query the audit trail",
+		LangFrench:  "seuls les administrateurs et les auditeurs peuvent consulter la piste d'audit",
+	},
+	ErrBookmarkInvalid: {
+		LangEnglish: "invalid bookmark %s",
+		LangFrench:  "signet %s invalide",
+	},
+	ErrAuditDateInvalid: {
+		LangEnglish: "invalid date %s: use YYYY-MM-DD or RFC 3339",
+		LangFrench:  "date %s invalide : utilisez AAAA-MM-JJ ou RFC 3339",
+	},
+
+	// Automation
+	ErrTransitionRulesSettingInvalid: {
+		LangEnglish: "invalid automation.transitionRules setting: %v",
+		LangFrench:  "paramètre automation.transitionRules invalide : %v",
+	},
+	ErrTransitionRuleInvalid: {
+		LangEnglish: "invalid transition rule %q",
+		LangFrench:  "règle de transition %q invalide",
+	},
+
+	// Blended recyclings
+	ErrBlendInputsTooFew: {
+		LangEnglish: "a blended recycling needs at least two input lots",
+		LangFrench:  "un recyclage mélangé nécessite au moins deux lots en entrée",
+	},
+	ErrBlendInputInvalid: {
+		LangEnglish: "input %d needs a wasteId and a positive quantity",
+		LangFrench:  "l'entrée %d nécessite un wasteId et une quantité positive",
+	},
+	ErrBlendInputDuplicate: {
+		LangEnglish: "waste %s is listed more than once",
+		LangFrench:  "le déchet %s est listé plus d'une fois",
+	},
+	ErrWasteQuantityInsufficient: {
+		LangEnglish: "waste %s has %.2f units left, %.2f requested",
+		LangFrench:  "il reste %[2].2f unités du déchet %[1]s, %[3].2f demandées",
+	},
+
+	// Extraction outputs
+	ErrOutputLineNotFound: {
+		LangEnglish: "extraction %s has no output line %d",
+		LangFrench:  "l'extraction %s n'a pas de ligne de sortie %d",
+	},
+	ErrOutputLinesRequired: {
+		LangEnglish: "at least one output line is required",
+		LangFrench:  "au moins une ligne de sortie est requise",
+	},
+	ErrMassBalanceExceeded: {
+		LangEnglish: "%s",
+		LangFrench:  "bilan matière dépassé : %s",
+	},
+	ErrOutputLineInvalid: {
+		LangEnglish: "output line %d: %v",
+		LangFrench:  "ligne de sortie %d : %v",
+	},
+
+	// Campaigns
+	ErrCampaignFieldsRequired: {
+		LangEnglish: "campaign id and name are required",
+		LangFrench:  "l'identifiant et le nom de la campagne sont requis",
+	},
+	ErrStartDateInvalid: {
+		LangEnglish: "invalid start date %q (expected YYYY-MM-DD)",
+		LangFrench:  "date de début %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrEndDateInvalid: {
+		LangEnglish: "invalid end date %q (expected YYYY-MM-DD)",
+		LangFrench:  "date de fin %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrDateRangeInvalid: {
+		LangEnglish: "end date must not be before start date",
+		LangFrench:  "la date de fin ne doit pas précéder la date de début",
+	},
+	ErrCampaignAlreadyExists: {
+		LangEnglish: "campaign %s already exists",
+		LangFrench:  "la campagne %s existe déjà",
+	},
+	ErrCampaignOverlap: {
+		LangEnglish: "campaign dates overlap with campaign %s",
+		LangFrench:  "les dates chevauchent celles de la campagne %s",
+	},
+	ErrCampaignAlreadyClosed: {
+		LangEnglish: "campaign %s is already closed",
+		LangFrench:  "la campagne %s est déjà clôturée",
+	},
+	ErrCampaignCloseForbidden: {
+		LangEnglish: "only %s can close campaign %s",
+		LangFrench:  "seul %s peut clôturer la campagne %s",
+	},
+	ErrCampaignNotFound: {
+		LangEnglish: "campaign %s does not exist",
+		LangFrench:  "la campagne %s n'existe pas",
+	},
+
+	// Completion certificates
+	ErrWasteNotValorized: {
+		LangEnglish: "waste %s has not been fully valorized yet",
+		LangFrench:  "le déchet %s n'a pas encore été entièrement valorisé",
+	},
+	ErrCertificateNotFound: {
+		LangEnglish: "certificate %s does not exist",
+		LangFrench:  "le certificat %s n'existe pas",
+	},
+
+	// Certifiers
+	ErrCertifierRoleRequired: {
+		LangEnglish: "only auditors can register as certifiers",
+		LangFrench:  "seuls les auditeurs peuvent s'enregistrer comme certificateurs",
+	},
+	ErrCertifierNameRequired: {
+		LangEnglish: "certifier name is required",
+		LangFrench:  "le nom du certificateur est requis",
+	},
+	ErrCertifierCapacityInvalid: {
+		LangEnglish: "certifier capacity must be positive",
+		LangFrench:  "la capacité du certificateur doit être positive",
+	},
+	ErrCertifierRetireForbidden: {
+		LangEnglish: "only certifier %s or an admin can retire it",
+		LangFrench:  "seul le certificateur %s ou un administrateur peut le retirer",
+	},
+	ErrCertifierAlreadyRetired: {
+		LangEnglish: "certifier %s is already retired",
+		LangFrench:  "le certificateur %s est déjà retiré",
+	},
+	ErrSampleAssignForbidden: {
+		LangEnglish: "only admins and auditors can assign audit samples",
+		LangFrench:  "seuls les administrateurs et les auditeurs peuvent attribuer des échantillons d'audit",
+	},
+	ErrSampleUnassignable: {
+		LangEnglish: "no lot of audit sample %s could be assigned",
+		LangFrench:  "aucun lot de l'échantillon d'audit %s n'a pu être attribué",
+	},
+	ErrDeclineReasonRequired: {
+		LangEnglish: "a reason is required to decline an inspection",
+		LangFrench:  "un motif est requis pour refuser une inspection",
+	},
+	ErrWasteNotInSample: {
+		LangEnglish: "waste %s is not in audit sample %s",
+		LangFrench:  "le déchet %s ne fait pas partie de l'échantillon d'audit %s",
+	},
+	ErrWasteAlreadyInspected: {
+		LangEnglish: "waste %s was already inspected for audit sample %s",
+		LangFrench:  "le déchet %s a déjà été inspecté pour l'échantillon d'audit %s",
+	},
+	ErrWasteNotAssigned: {
+		LangEnglish: "waste %s of audit sample %s is not assigned",
+		LangFrench:  "le déchet %s de l'échantillon d'audit %s n'est pas attribué",
+	},
+	ErrWorkloadReadForbidden: {
+		LangEnglish: "only admins and auditors can read certifier workloads",
+		LangFrench:  "seuls les administrateurs et les auditeurs peuvent consulter la charge des certificateurs",
+	},
+	ErrCertifierNotFound: {
+		LangEnglish: "certifier %s does not exist",
+		LangFrench:  "le certificateur %s n'existe pas",
+	},
+	ErrCertifierNotAssigned: {
+		LangEnglish: "only certifier %s can act on waste %s of audit sample %s",
+		LangFrench:  "seul le certificateur %s peut intervenir sur le déchet %s de l'échantillon d'audit %s",
+	},
+
+	// Checklists
+	ErrChecklistItemsInvalid: {
+		LangEnglish: "invalid checklist items: %v",
+		LangFrench:  "éléments de liste de contrôle invalides : %v",
+	},
+	ErrChecklistEmpty: {
+		LangEnglish: "a checklist needs at least one item",
+		LangFrench:  "une liste de contrôle nécessite au moins un élément",
+	},
+	ErrChecklistItemInvalid: {
+		LangEnglish: "checklist item %d needs a key and a label",
+		LangFrench:  "l'élément %d de la liste de contrôle nécessite une clé et un libellé",
+	},
+	ErrChecklistItemDuplicate: {
+		LangEnglish: "duplicate checklist item %q",
+		LangFrench:  "élément de liste de contrôle %q en double",
+	},
+	ErrChecklistNotDefined: {
+		LangEnglish: "no checklist is defined for %s",
+		LangFrench:  "aucune liste de contrôle n'est définie pour %s",
+	},
+	ErrChecklistItemNotFound: {
+		LangEnglish: "checklist %s has no item %q",
+		LangFrench:  "la liste de contrôle %s n'a pas d'élément %q",
+	},
+	ErrChecklistValueRequired: {
+		LangEnglish: "checklist item %q needs a value",
+		LangFrench:  "l'élément %q de la liste de contrôle nécessite une valeur",
+	},
+	ErrWasteStatusUnchanged: {
+		LangEnglish: "waste %s is already %s",
+		LangFrench:  "le déchet %s est déjà %s",
+	},
+	ErrChecklistIncomplete: {
+		LangEnglish: "waste %s cannot become %s before completing checklist items: %s",
+		LangFrench:  "le déchet %s ne peut devenir %s avant d'avoir complété les éléments de la liste de contrôle : %s",
+	},
+
+	// Insurance claims
+	ErrClaimKindUnsupported: {
+		LangEnglish: "unsupported claim kind %q (expected LOST or DAMAGED)",
+		LangFrench:  "type de réclamation %q non pris en charge (valeurs attendues LOST ou DAMAGED)",
+	},
+	ErrClaimDescriptionRequired: {
+		LangEnglish: "claim description is required",
+		LangFrench:  "la description de la réclamation est requise",
+	},
+	ErrClaimFileForbidden: {
+		LangEnglish: "only the owner of waste %s can file a claim for it",
+		LangFrench:  "seul le propriétaire du déchet %s peut déposer une réclamation à son sujet",
+	},
+	ErrLossQuantityInvalid: {
+		LangEnglish: "loss quantity must be positive and at most the lot quantity %.2f",
+		LangFrench:  "la quantité perdue doit être positive et au plus égale à la quantité du lot %.2f",
+	},
+	ErrClaimAlreadyOpen: {
+		LangEnglish: "waste %s already has open claim %s",
+		LangFrench:  "le déchet %s a déjà une réclamation ouverte %s",
+	},
+	ErrClaimAlreadyExists: {
+		LangEnglish: "claim %s already exists",
+		LangFrench:  "la réclamation %s existe déjà",
+	},
+	ErrDocumentTypeRequired: {
+		LangEnglish: "document type is required",
+		LangFrench:  "le type de document est requis",
+	},
+	ErrDocumentHashInvalid: {
+		LangEnglish: "document hash must be a hex-encoded SHA-256 digest",
+		LangFrench:  "l'empreinte du document doit être un condensat SHA-256 en hexadécimal",
+	},
+	ErrClaimStatusInvalid: {
+		LangEnglish: "claim %s is %s",
+		LangFrench:  "la réclamation %s est %s",
+	},
+	ErrClaimEvidenceForbidden: {
+		LangEnglish: "only the claimant, assessors and insurers can add evidence to claim %s",
+		LangFrench:  "seuls le réclamant, les experts et les assureurs peuvent ajouter des preuves à la réclamation %s",
+	},
+	ErrClaimDocumentDuplicate: {
+		LangEnglish: "document %s is already attached to claim %s",
+		LangFrench:  "le document %s est déjà joint à la réclamation %s",
+	},
+	ErrAssessorRequired: {
+		LangEnglish: "only assessors can assess claims",
+		LangFrench:  "seuls les experts peuvent évaluer les réclamations",
+	},
+	ErrFindingRequired: {
+		LangEnglish: "finding is required",
+		LangFrench:  "la conclusion est requise",
+	},
+	ErrAssessmentAmountsNegative: {
+		LangEnglish: "loss quantity and recommended amount must not be negative",
+		LangFrench:  "la quantité perdue et le montant recommandé ne doivent pas être négatifs",
+	},
+	ErrClaimStatusUnexpected: {
+		LangEnglish: "claim %s is %s, expected %s",
+		LangFrench:  "la réclamation %s est %s, %s attendu",
+	},
+	ErrAssessedLossExceedsClaim: {
+		LangEnglish: "assessed loss %.2f exceeds the claimed %.2f",
+		LangFrench:  "la perte évaluée %.2f dépasse les %.2f réclamés",
+	},
+	ErrClaimSelfAssessment: {
+		LangEnglish: "the claimant cannot assess claim %s",
+		LangFrench:  "le réclamant ne peut pas évaluer la réclamation %s",
+	},
+	ErrReasonRequired: {
+		LangEnglish: "reason is required",
+		LangFrench:  "un motif est requis",
+	},
+	ErrSettlementAmountInvalid: {
+		LangEnglish: "settlement amount must be positive",
+		LangFrench:  "le montant de l'indemnisation doit être positif",
+	},
+	ErrClaimNotFound: {
+		LangEnglish: "claim %s does not exist",
+		LangFrench:  "la réclamation %s n'existe pas",
+	},
+	ErrInsurerRequired: {
+		LangEnglish: "only insurers can decide and settle claims",
+		LangFrench:  "seuls les assureurs peuvent statuer sur les réclamations et les indemniser",
+	},
+	ErrClaimInsurerMismatch: {
+		LangEnglish: "claim %s is insured by %s",
+		LangFrench:  "la réclamation %s est assurée par %s",
+	},
+
+	// Collection requests
+	ErrFarmRequired: {
+		LangEnglish: "farm is required",
+		LangFrench:  "l'exploitation est requise",
+	},
+	ErrEstimatedQuantityInvalid: {
+		LangEnglish: "estimated quantity must be positive",
+		LangFrench:  "la quantité estimée doit être positive",
+	},
+	ErrWindowStartInvalid: {
+		LangEnglish: "invalid window start %q (expected YYYY-MM-DD)",
+		LangFrench:  "début de créneau %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrWindowEndInvalid: {
+		LangEnglish: "invalid window end %q (expected YYYY-MM-DD)",
+		LangFrench:  "fin de créneau %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrWindowRangeInvalid: {
+		LangEnglish: "window end must not be before window start",
+		LangFrench:  "la fin du créneau ne doit pas précéder son début",
+	},
+	ErrCollectionAlreadyExists: {
+		LangEnglish: "collection request %s already exists",
+		LangFrench:  "la demande de collecte %s existe déjà",
+	},
+	ErrCollectorRequired: {
+		LangEnglish: "collector is required",
+		LangFrench:  "le collecteur est requis",
+	},
+	ErrCollectionNotAssignable: {
+		LangEnglish: "collection request %s is %s and cannot be assigned",
+		LangFrench:  "la demande de collecte %s est %s et ne peut pas être attribuée",
+	},
+	ErrCollectionStatusUnchanged: {
+		LangEnglish: "collection request %s is already %s",
+		LangFrench:  "la demande de collecte %s est déjà %s",
+	},
+	ErrCollectionNotFulfillable: {
+		LangEnglish: "collection request %s must be %s to be fulfilled (is %s)",
+		LangFrench:  "la demande de collecte %s doit être %s pour être honorée (elle est %s)",
+	},
+	ErrCollectorMismatch: {
+		LangEnglish: "only collector %s can fulfill collection request %s",
+		LangFrench:  "seul le collecteur %s peut honorer la demande de collecte %s",
+	},
+	ErrCollectionNotFound: {
+		LangEnglish: "collection request %s does not exist",
+		LangFrench:  "la demande de collecte %s n'existe pas",
+	},
+	ErrCollectionManageForbidden: {
+		LangEnglish: "only %s can manage collection request %s",
+		LangFrench:  "seul %s peut gérer la demande de collecte %s",
+	},
+
+	// Composition
+	ErrCompositionInvalid: {
+		LangEnglish: "invalid composition: %v",
+		LangFrench:  "composition invalide : %v",
+	},
+	ErrCompositionForbidden: {
+		LangEnglish: "only %s can record the composition of waste %s",
+		LangFrench:  "seul %s peut enregistrer la composition du déchet %s",
+	},
+	ErrCompositionEmpty: {
+		LangEnglish: "composition needs moisturePct or oilContentPct",
+		LangFrench:  "la composition nécessite moisturePct ou oilContentPct",
+	},
+	ErrMoistureOutOfRange: {
+		LangEnglish: "moisture must be at least 0%% and below 100%%",
+		LangFrench:  "l'humidité doit être d'au moins 0%% et inférieure à 100%%",
+	},
+	ErrOilContentOutOfRange: {
+		LangEnglish: "oil content must be between 0%% and 100%%",
+		LangFrench:  "la teneur en huile doit être comprise entre 0%% et 100%%",
+	},
+	ErrCompositionOverTotal: {
+		LangEnglish: "moisture and oil content together exceed 100%%",
+		LangFrench:  "l'humidité et la teneur en huile dépassent ensemble 100%%",
+	},
+
+	// Configuration
+	ErrConfigKeyRequired: {
+		LangEnglish: "config namespace and key are required",
+		LangFrench:  "l'espace de noms et la clé de configuration sont requis",
+	},
+	ErrConfigNamespaceInvalid: {
+		LangEnglish: "config namespace must not contain '.'",
+		LangFrench:  "l'espace de noms de configuration ne doit pas contenir '.'",
+	},
+
+	// Cooperatives
+	ErrCooperativeNameRequired: {
+		LangEnglish: "cooperative name is required",
+		LangFrench:  "le nom de la coopérative est requis",
+	},
+	ErrCooperativeNameTaken: {
+		LangEnglish: "cooperative %s is already registered as %s",
+		LangFrench:  "la coopérative %s est déjà enregistrée sous %s",
+	},
+	ErrAdminIdentityRequired: {
+		LangEnglish: "admin identity is required",
+		LangFrench:  "l'identité de l'administrateur est requise",
+	},
+	ErrPermissionMatrixInvalid: {
+		LangEnglish: "invalid permission matrix: %v",
+		LangFrench:  "matrice de permissions invalide : %v",
+	},
+	ErrMemberTiersRequired: {
+		LangEnglish: "member tiers must not be empty",
+		LangFrench:  "les niveaux de membres ne doivent pas être vides",
+	},
+	ErrCooperativeActionUnknown: {
+		LangEnglish: "unknown cooperative action %q (expected one of %v)",
+		LangFrench:  "action de coopérative %q inconnue (valeurs attendues %v)",
+	},
+	ErrMemberTierExcluded: {
+		LangEnglish: "member %s is in tier %s, which the matrix leaves out",
+		LangFrench:  "le membre %s est au niveau %s, que la matrice omet",
+	},
+	ErrCooperativeSelfMembership: {
+		LangEnglish: "a cooperative cannot be a member of itself",
+		LangFrench:  "une coopérative ne peut pas être membre d'elle-même",
+	},
+	ErrMemberTierUnknown: {
+		LangEnglish: "cooperative %s has no member tier %s",
+		LangFrench:  "la coopérative %s n'a pas de niveau de membre %s",
+	},
+	ErrCooperativeTooDeep: {
+		LangEnglish: "cooperatives cannot be nested more than %d levels deep",
+		LangFrench:  "les coopératives ne peuvent pas être imbriquées sur plus de %d niveaux",
+	},
+	ErrCooperativeCycle: {
+		LangEnglish: "%s is already above cooperative %s",
+		LangFrench:  "%s est déjà au-dessus de la coopérative %s",
+	},
+	ErrAlreadyMember: {
+		LangEnglish: "%s is already a member of cooperative %s",
+		LangFrench:  "%s est déjà membre de la coopérative %s",
+	},
+	ErrNotAMember: {
+		LangEnglish: "%s is not a member of any cooperative",
+		LangFrench:  "%s n'est membre d'aucune coopérative",
+	},
+	ErrMembershipEndForbidden: {
+		LangEnglish: "only %s or the admins of cooperative %s can end this membership",
+		LangFrench:  "seuls %s ou les administrateurs de la coopérative %s peuvent mettre fin à cette adhésion",
+	},
+	ErrCooperativeNotFound: {
+		LangEnglish: "cooperative %s does not exist",
+		LangFrench:  "la coopérative %s n'existe pas",
+	},
+	ErrMembershipNotFound: {
+		LangEnglish: "%s has never joined a cooperative",
+		LangFrench:  "%s n'a jamais rejoint de coopérative",
+	},
+	ErrCooperativeAdminRequired: {
+		LangEnglish: "only the admins of cooperative %s can manage it",
+		LangFrench:  "seuls les administrateurs de la coopérative %s peuvent la gérer",
+	},
+	ErrParticipantActForbidden: {
+		LangEnglish: "only %s can act for participant %s",
+		LangFrench:  "seul %s peut agir pour le participant %s",
+	},
+
+	// Credit and invoices
+	ErrBuyerRequired: {
+		LangEnglish: "the buyer organization is required",
+		LangFrench:  "l'organisation acheteuse est requise",
+	},
+	ErrCreditTermsNegative: {
+		LangEnglish: "the limit and payment terms cannot be negative",
+		LangFrench:  "la limite et les délais de paiement ne peuvent pas être négatifs",
+	},
+	ErrCreditPolicyInvalid: {
+		LangEnglish: "policy must be %s, %s or empty",
+		LangFrench:  "la politique doit être %s, %s ou vide",
+	},
+	ErrCreditSelf: {
+		LangEnglish: "an organization cannot extend credit to itself",
+		LangFrench:  "une organisation ne peut pas s'accorder de crédit à elle-même",
+	},
+	ErrCreditLimitNotFound: {
+		LangEnglish: "no credit limit is set for %s",
+		LangFrench:  "aucune limite de crédit n'est définie pour %s",
+	},
+	ErrCreditExposureForbidden: {
+		LangEnglish: "only %s, %s and admins can see their credit exposure",
+		LangFrench:  "seuls %s, %s et les administrateurs peuvent consulter leur encours de crédit",
+	},
+	ErrInvoiceAmountInvalid: {
+		LangEnglish: "the invoiced amount must be positive",
+		LangFrench:  "le montant facturé doit être positif",
+	},
+	ErrInvoiceSelf: {
+		LangEnglish: "an organization cannot invoice itself",
+		LangFrench:  "une organisation ne peut pas se facturer elle-même",
+	},
+	ErrInvoiceStatusInvalid: {
+		LangEnglish: "invoice %s is %s",
+		LangFrench:  "la facture %s est %s",
+	},
+	ErrInvoiceReadForbidden: {
+		LangEnglish: "only %s, %s and admins can read invoice %s",
+		LangFrench:  "seuls %s, %s et les administrateurs peuvent lire la facture %s",
+	},
+	ErrCreditLimitExceeded: {
+		LangEnglish: "%s owes %s %.2f (%.2f overdue), over its credit limit of %.2f",
+		LangFrench:  "%s doit à %s %.2f (dont %.2f en retard), au-delà de sa limite de crédit de %.2f",
+	},
+	ErrInvoiceManageForbidden: {
+		LangEnglish: "only %s can manage invoice %s",
+		LangFrench:  "seul %s peut gérer la facture %s",
+	},
+	ErrInvoiceNotFound: {
+		LangEnglish: "invoice %s does not exist",
+		LangFrench:  "la facture %s n'existe pas",
+	},
+
+	// Deadlines
+	ErrUntilRequired: {
+		LangEnglish: "until is required",
+		LangFrench:  "until est requis",
+	},
+
+	// Delegations
+	ErrDelegationIDRequired: {
+		LangEnglish: "delegation id is required",
+		LangFrench:  "l'identifiant de la délégation est requis",
+	},
+	ErrDelegateRequired: {
+		LangEnglish: "delegate and delegateMsp are required",
+		LangFrench:  "delegate et delegateMsp sont requis",
+	},
+	ErrDelegationSelf: {
+		LangEnglish: "cannot delegate to yourself",
+		LangFrench:  "impossible de se déléguer à soi-même",
+	},
+	ErrExpiresAtInvalid: {
+		LangEnglish: "expiresAt must be an RFC3339 timestamp: %v",
+		LangFrench:  "expiresAt doit être un horodatage RFC3339 : %v",
+	},
+	ErrExpiresAtPast: {
+		LangEnglish: "expiresAt must be in the future",
+		LangFrench:  "expiresAt doit être dans le futur",
+	},
+	ErrDelegationAlreadyExists: {
+		LangEnglish: "delegation %s already exists",
+		LangFrench:  "la délégation %s existe déjà",
+	},
+	ErrDelegationAlreadyRevoked: {
+		LangEnglish: "delegation %s is already revoked",
+		LangFrench:  "la délégation %s est déjà révoquée",
+	},
+	ErrDelegationRevokeForbidden: {
+		LangEnglish: "only the parties of delegation %s can revoke it",
+		LangFrench:  "seules les parties de la délégation %s peuvent la révoquer",
+	},
+	ErrDelegationNotFound: {
+		LangEnglish: "delegation %s does not exist",
+		LangFrench:  "la délégation %s n'existe pas",
+	},
+
+	// Dispositions
+	ErrRecipientRequired: {
+		LangEnglish: "the recipient's name and kind are required",
+		LangFrench:  "le nom et le type du destinataire sont requis",
+	},
+	ErrFacilityTypeMismatch: {
+		LangEnglish: "facility %s is a %s facility, not %s",
+		LangFrench:  "l'installation %s est une installation %s et non %s",
+	},
+	ErrFacilityStatusInvalid: {
+		LangEnglish: "facility %s is %s",
+		LangFrench:  "l'installation %s est %s",
+	},
+	ErrDispositionNotFound: {
+		LangEnglish: "disposition %s does not exist",
+		LangFrench:  "la cession %s n'existe pas",
+	},
+	ErrDateInvalid: {
+		LangEnglish: "invalid date %q (expected YYYY-MM-DD)",
+		LangFrench:  "date %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrDispositionReasonInvalid: {
+		LangEnglish: "reason code must be one of %s",
+		LangFrench:  "le code motif doit être l'un de %s",
+	},
+	ErrDispositionForbidden: {
+		LangEnglish: "only %s can donate or dispose of waste %s",
+		LangFrench:  "seul %s peut donner ou éliminer le déchet %s",
+	},
+	ErrWasteExhausted: {
+		LangEnglish: "nothing remains of waste %s",
+		LangFrench:  "il ne reste rien du déchet %s",
+	},
+
+	// Documents
+	ErrDocumentAlreadyAttached: {
+		LangEnglish: "document %s is already attached to waste %s",
+		LangFrench:  "le document %s est déjà joint au déchet %s",
+	},
+
+	// Duplicates
+	ErrToleranceNegative: {
+		LangEnglish: "tolerance must not be negative",
+		LangFrench:  "la tolérance ne doit pas être négative",
+	},
+	ErrDuplicateIDsInvalid: {
+		LangEnglish: "invalid duplicate IDs: %v",
+		LangFrench:  "identifiants de doublons invalides : %v",
+	},
+	ErrDuplicatesRequired: {
+		LangEnglish: "at least one duplicate is required",
+		LangFrench:  "au moins un doublon est requis",
+	},
+	ErrWasteAlreadyMerged: {
+		LangEnglish: "waste %s was already merged into %s",
+		LangFrench:  "le déchet %s a déjà été fusionné dans %s",
+	},
+	ErrWasteHarvestMismatch: {
+		LangEnglish: "waste %s is not the same harvest as waste %s",
+		LangFrench:  "le déchet %s ne provient pas de la même récolte que le déchet %s",
+	},
+	ErrWasteInUse: {
+		LangEnglish: "waste %s is already in use and cannot be merged",
+		LangFrench:  "le déchet %s est déjà utilisé et ne peut pas être fusionné",
+	},
+	ErrWasteUsedByExtraction: {
+		LangEnglish: "waste %s is used by extraction %s and cannot be merged",
+		LangFrench:  "le déchet %s est utilisé par l'extraction %s et ne peut pas être fusionné",
+	},
+	ErrWasteUsedByRecycling: {
+		LangEnglish: "waste %s is used by recycling %s and cannot be merged",
+		LangFrench:  "le déchet %s est utilisé par le recyclage %s et ne peut pas être fusionné",
+	},
+	ErrParticipantLookupFailed: {
+		LangEnglish: "failed to look up participant: %v",
+		LangFrench:  "échec de la recherche du participant : %v",
+	},
+
+	// Embargoes
+	ErrEmbargoHoursNegative: {
+		LangEnglish: "embargo hours cannot be negative",
+		LangFrench:  "la durée d'embargo ne peut pas être négative",
+	},
+	ErrEmbargoForbidden: {
+		LangEnglish: "only %s can change the embargo of waste %s",
+		LangFrench:  "seul %s peut modifier l'embargo du déchet %s",
+	},
+	ErrTimestampInvalid: {
+		LangEnglish: "invalid timestamp %q: %v",
+		LangFrench:  "horodatage %q invalide : %v",
+	},
+
+	// Sealed fields
+	ErrSealedFieldInvalid: {
+		LangEnglish: "invalid sealed field name %q",
+		LangFrench:  "nom de champ scellé %q invalide",
+	},
+	ErrSealForbidden: {
+		LangEnglish: "only %s can seal fields of waste %s",
+		LangFrench:  "seul %s peut sceller des champs du déchet %s",
+	},
+	ErrSealedFieldsRestricted: {
+		LangEnglish: "sealed fields of waste %s are restricted to %s",
+		LangFrench:  "les champs scellés du déchet %s sont réservés à %s",
+	},
+	ErrSealedFieldNotFound: {
+		LangEnglish: "waste %s has no sealed field %s",
+		LangFrench:  "le déchet %s n'a pas de champ scellé %s",
+	},
+	ErrSealingKeyMismatch: {
+		LangEnglish: "field %s of waste %s was sealed with key %s, not %s",
+		LangFrench:  "le champ %s du déchet %s a été scellé avec la clé %s et non %s",
+	},
+	ErrSealingKeyRequired: {
+		LangEnglish: "the key must be passed in the %q transient entry",
+		LangFrench:  "la clé doit être transmise dans l'entrée transitoire %q",
+	},
+	ErrTransientDataInvalid: {
+		LangEnglish: "invalid %s transient data: %v",
+		LangFrench:  "données transitoires %s invalides : %v",
+	},
+	ErrSealingKeySize: {
+		LangEnglish: "the sealing key must be 32 bytes, got %d",
+		LangFrench:  "la clé de scellement doit faire 32 octets, %d reçus",
+	},
+	ErrCiphertextInvalid: {
+		LangEnglish: "invalid ciphertext: %v",
+		LangFrench:  "texte chiffré invalide : %v",
+	},
+	ErrCiphertextTooShort: {
+		LangEnglish: "ciphertext of field %s is too short",
+		LangFrench:  "le texte chiffré du champ %s est trop court",
+	},
+	ErrCiphertextKeyMismatch: {
+		LangEnglish: "ciphertext of field %s does not open with the supplied key",
+		LangFrench:  "le texte chiffré du champ %s ne s'ouvre pas avec la clé fournie",
+	},
+
+	// Recyclings
+	ErrRecyclingNotFound: {
+		LangEnglish: "recycling %s does not exist",
+		LangFrench:  "le recyclage %s n'existe pas",
+	},
+
+	// Facilities
+	ErrFacilityFieldsRequired: {
+		LangEnglish: "facility id and name are required",
+		LangFrench:  "l'identifiant et le nom de l'installation sont requis",
+	},
+	ErrFacilityTypeInvalid: {
+		LangEnglish: "facility type must be %s, %s, %s or %s",
+		LangFrench:  "le type d'installation doit être %s, %s, %s ou %s",
+	},
+	ErrDailyCapacityInvalid: {
+		LangEnglish: "daily capacity must be positive",
+		LangFrench:  "la capacité journalière doit être positive",
+	},
+	ErrFacilityAlreadyExists: {
+		LangEnglish: "facility %s already exists",
+		LangFrench:  "l'installation %s existe déjà",
+	},
+	ErrFacilityStatusUnknown: {
+		LangEnglish: "facility status must be %s or %s",
+		LangFrench:  "le statut de l'installation doit être %s ou %s",
+	},
+	ErrEquipmentFieldsRequired: {
+		LangEnglish: "equipment id and name are required",
+		LangFrench:  "l'identifiant et le nom de l'équipement sont requis",
+	},
+	ErrEquipmentAlreadyExists: {
+		LangEnglish: "equipment %s already exists",
+		LangFrench:  "l'équipement %s existe déjà",
+	},
+	ErrMaintenanceStatusInvalid: {
+		LangEnglish: "maintenance status must be %s, %s or %s",
+		LangFrench:  "le statut de maintenance doit être %s, %s ou %s",
+	},
+	ErrFacilityNotFound: {
+		LangEnglish: "facility %s does not exist",
+		LangFrench:  "l'installation %s n'existe pas",
+	},
+	ErrEquipmentNotFound: {
+		LangEnglish: "equipment %s does not exist",
+		LangFrench:  "l'équipement %s n'existe pas",
+	},
+	ErrFacilityRequired: {
+		LangEnglish: "a registered facility is required",
+		LangFrench:  "une installation enregistrée est requise",
+	},
+	ErrFacilityManageForbidden: {
+		LangEnglish: "only %s can manage facility %s",
+		LangFrench:  "seul %s peut gérer l'installation %s",
+	},
+	ErrCapacityExceeded: {
+		LangEnglish: "%s",
+		LangFrench:  "capacité dépassée : %s",
+	},
+
+	// Consumer feedback
+	ErrTraceTokenIssuerMismatch: {
+		LangEnglish: "trace token %s was issued by %s",
+		LangFrench:  "le jeton de traçabilité %s a été émis par %s",
+	},
+	ErrTraceTokenNotFound: {
+		LangEnglish: "trace token %s does not exist",
+		LangFrench:  "le jeton de traçabilité %s n'existe pas",
+	},
+	ErrRatingOutOfRange: {
+		LangEnglish: "rating must be between 1 and 5",
+		LangFrench:  "la note doit être comprise entre 1 et 5",
+	},
+	ErrCommentHashInvalid: {
+		LangEnglish: "comment hash must be a hex-encoded sha256 digest",
+		LangFrench:  "l'empreinte du commentaire doit être un condensat sha256 en hexadécimal",
+	},
+	ErrTraceTokenRevoked: {
+		LangEnglish: "trace token %s has been revoked",
+		LangFrench:  "le jeton de traçabilité %s a été révoqué",
+	},
+	ErrAssetTypeUnsupported: {
+		LangEnglish: "unsupported asset type %q (expected WASTE, EXTRACTION or RECYCLING)",
+		LangFrench:  "type d'actif %q non pris en charge (valeurs attendues WASTE, EXTRACTION ou RECYCLING)",
+	},
+	ErrRatingScopeUnsupported: {
+		LangEnglish: "unsupported rating scope %q (expected PRODUCT or FARM)",
+		LangFrench:  "périmètre de notation %q non pris en charge (valeurs attendues PRODUCT ou FARM)",
+	},
+
+	// Geofences
+	ErrGeofenceRadiusNegative: {
+		LangEnglish: "geofence radius must not be negative",
+		LangFrench:  "le rayon de la zone géographique ne doit pas être négatif",
+	},
+	ErrGeofenceOverrideForbidden: {
+		LangEnglish: "only admins can override the geofence of a delivery",
+		LangFrench:  "seuls les administrateurs peuvent lever la restriction géographique d'une livraison",
+	},
+	ErrPositionOutOfRange: {
+		LangEnglish: "position %.6f,%.6f is out of range",
+		LangFrench:  "la position %.6f,%.6f est hors limites",
+	},
+	ErrGeofenceRefused: {
+		LangEnglish: "%s",
+		LangFrench:  "livraison refusée : %s",
+	},
+
+	// Grading
+	ErrQualityGradeUnknown: {
+		LangEnglish: "unknown quality grade %q (expected one of %v)",
+		LangFrench:  "classe de qualité %q inconnue (valeurs attendues %v)",
+	},
+	ErrGradingEvidenceRequired: {
+		LangEnglish: "grading evidence is required",
+		LangFrench:  "les preuves du classement sont requises",
+	},
+	ErrExtractionUnderAppeal: {
+		LangEnglish: "extraction %s is under appeal (%s)",
+		LangFrench:  "l'extraction %s fait l'objet d'un recours (%s)",
+	},
+	ErrGraderAppealForbidden: {
+		LangEnglish: "graders cannot appeal grades",
+		LangFrench:  "les classificateurs ne peuvent pas contester les classements",
+	},
+	ErrAppealFieldsRequired: {
+		LangEnglish: "reason and evidence are required",
+		LangFrench:  "le motif et les preuves sont requis",
+	},
+	ErrExtractionAlreadyAppealed: {
+		LangEnglish: "extraction %s is already under appeal (%s)",
+		LangFrench:  "l'extraction %s fait déjà l'objet d'un recours (%s)",
+	},
+	ErrExtractionGradeUnchanged: {
+		LangEnglish: "extraction %s is already grade %s",
+		LangFrench:  "l'extraction %s est déjà de classe %s",
+	},
+	ErrRegradeStatusUnchanged: {
+		LangEnglish: "regrade request %s is already %s",
+		LangFrench:  "la demande de reclassement %s est déjà %s",
+	},
+	ErrAppealOwnOrganization: {
+		LangEnglish: "a grader from %s cannot decide its own organization's appeal",
+		LangFrench:  "un classificateur de %s ne peut pas statuer sur le recours de sa propre organisation",
+	},
+	ErrAppealOwnGrade: {
+		LangEnglish: "the grader of the appealed grade cannot decide the appeal",
+		LangFrench:  "l'auteur du classement contesté ne peut pas statuer sur le recours",
+	},
+	ErrRegradeNotFound: {
+		LangEnglish: "regrade request %s does not exist",
+		LangFrench:  "la demande de reclassement %s n'existe pas",
+	},
+
+	// Relationship graph
+	ErrGraphAssetNotFound: {
+		LangEnglish: "no asset %q in the relationship graph",
+		LangFrench:  "aucun actif %q dans le graphe des relations",
+	},
+
+	// Handoffs
+	ErrHandoffDestinationRequired: {
+		LangEnglish: "the destination system and external reference are required",
+		LangFrench:  "le système de destination et la référence externe sont requis",
+	},
+	ErrHandoffForbidden: {
+		LangEnglish: "only %s can hand off waste %s",
+		LangFrench:  "seul %s peut transmettre le déchet %s",
+	},
+	ErrWasteTransferPending: {
+		LangEnglish: "waste %s has a transfer awaiting approval %s",
+		LangFrench:  "le déchet %s a un transfert en attente d'approbation %s",
+	},
+	ErrWasteLocked: {
+		LangEnglish: "waste %s is locked by settlement %s",
+		LangFrench:  "le déchet %s est verrouillé par le règlement %s",
+	},
+	ErrHandoffSourceRequired: {
+		LangEnglish: "the source system and external reference are required",
+		LangFrench:  "le système source et la référence externe sont requis",
+	},
+	ErrContentHashInvalid: {
+		LangEnglish: "the content hash must be a hex-encoded sha256 digest",
+		LangFrench:  "l'empreinte du contenu doit être un condensat sha256 en hexadécimal",
+	},
+	ErrHandoffNotFound: {
+		LangEnglish: "handoff %s does not exist",
+		LangFrench:  "la transmission %s n'existe pas",
+	},
+	ErrHandoffReferenceNotFound: {
+		LangEnglish: "no %s handoff has reference %s in %s",
+		LangFrench:  "aucune transmission %s n'a la référence %s dans %s",
+	},
+	ErrHandoffReferenceDuplicate: {
+		LangEnglish: "reference %s of %s was already handed off (%s)",
+		LangFrench:  "la référence %s de %s a déjà été transmise (%s)",
+	},
+
+	// Identity bindings
+	ErrIdentityBindingFieldsRequired: {
+		LangEnglish: "MSP ID, enrollment ID and participant ID are required",
+		LangFrench:  "l'identifiant MSP, l'identifiant d'inscription et l'identifiant du participant sont requis",
+	},
+	ErrRebindReasonRequired: {
+		LangEnglish: "a reason is required to rebind an identity",
+		LangFrench:  "un motif est requis pour réassocier une identité",
+	},
+	ErrParticipantOrganizationMismatch: {
+		LangEnglish: "participant %s belongs to %s, not %s",
+		LangFrench:  "le participant %s appartient à %s et non à %s",
+	},
+	ErrIdentityRequired: {
+		LangEnglish: "identity is required",
+		LangFrench:  "l'identité est requise",
+	},
+
+	// Impact reports
+	ErrImpactReportForbidden: {
+		LangEnglish: "only %s or an admin can compute its impact report",
+		LangFrench:  "seul %s ou un administrateur peut calculer son rapport d'impact",
+	},
+
+	// Incidents
+	ErrIncidentKindUnsupported: {
+		LangEnglish: "unsupported incident kind %q (expected one of %s)",
+		LangFrench:  "type d'incident %q non pris en charge (valeurs attendues %s)",
+	},
+	ErrSeverityUnsupported: {
+		LangEnglish: "unsupported severity %q (expected one of %s)",
+		LangFrench:  "gravité %q non prise en charge (valeurs attendues %s)",
+	},
+	ErrIncidentDescriptionRequired: {
+		LangEnglish: "incident description is required",
+		LangFrench:  "la description de l'incident est requise",
+	},
+	ErrIncidentSubjectRequired: {
+		LangEnglish: "an incident must name a facility, a shipment or a waste lot",
+		LangFrench:  "un incident doit désigner une installation, une expédition ou un lot de déchets",
+	},
+	ErrOccurrenceTimeInvalid: {
+		LangEnglish: "invalid occurrence time %q (expected RFC 3339)",
+		LangFrench:  "heure de survenue %q invalide (format attendu RFC 3339)",
+	},
+	ErrOccurrenceTimeFuture: {
+		LangEnglish: "an incident cannot occur in the future",
+		LangFrench:  "un incident ne peut pas survenir dans le futur",
+	},
+	ErrIncidentAlreadyExists: {
+		LangEnglish: "incident %s already exists",
+		LangFrench:  "l'incident %s existe déjà",
+	},
+	ErrCorrectiveActionRequired: {
+		LangEnglish: "corrective action description is required",
+		LangFrench:  "la description de l'action corrective est requise",
+	},
+	ErrDueDateInvalid: {
+		LangEnglish: "invalid due date %q (expected YYYY-MM-DD)",
+		LangFrench:  "date d'échéance %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrCorrectiveActionNotFound: {
+		LangEnglish: "incident %s has no corrective action %d",
+		LangFrench:  "l'incident %s n'a pas d'action corrective %d",
+	},
+	ErrCorrectiveActionCompleted: {
+		LangEnglish: "corrective action %d of incident %s is already completed",
+		LangFrench:  "l'action corrective %d de l'incident %s est déjà terminée",
+	},
+	ErrRegulatorAlreadyNotified: {
+		LangEnglish: "the regulator was already notified of incident %s",
+		LangFrench:  "le régulateur a déjà été informé de l'incident %s",
+	},
+	ErrCorrectiveActionsMissing: {
+		LangEnglish: "incident %s has no corrective action",
+		LangFrench:  "l'incident %s n'a pas d'action corrective",
+	},
+	ErrCorrectiveActionPending: {
+		LangEnglish: "corrective action %d of incident %s is not completed",
+		LangFrench:  "l'action corrective %d de l'incident %s n'est pas terminée",
+	},
+	ErrRegulatorNotNotified: {
+		LangEnglish: "the regulator must be notified of %s incident %s before it is closed",
+		LangFrench:  "le régulateur doit être informé de l'incident %s %s avant sa clôture",
+	},
+	ErrIncidentNotFound: {
+		LangEnglish: "incident %s does not exist",
+		LangFrench:  "l'incident %s n'existe pas",
+	},
+	ErrIncidentStatusInvalid: {
+		LangEnglish: "incident %s is %s",
+		LangFrench:  "l'incident %s est %s",
+	},
+	ErrIncidentUpdateForbidden: {
+		LangEnglish: "only %s or %s can update incident %s",
+		LangFrench:  "seuls %s ou %s peuvent mettre à jour l'incident %s",
+	},
+
+	// Integrity checks
+	ErrIntegrityCheckForbidden: {
+		LangEnglish: "only admins and auditors can check the integrity of the ledger",
+		LangFrench:  "seuls les administrateurs et les auditeurs peuvent vérifier l'intégrité du registre",
+	},
+
+	// Marketplace
+	ErrListingForbidden: {
+		LangEnglish: "only %s can list waste %s",
+		LangFrench:  "seul %s peut mettre en vente le déchet %s",
+	},
+	ErrListedQuantityInvalid: {
+		LangEnglish: "listed quantity must be positive and at most %.2f",
+		LangFrench:  "la quantité mise en vente doit être positive et au plus égale à %.2f",
+	},
+	ErrReservePriceNegative: {
+		LangEnglish: "reserve price must not be negative",
+		LangFrench:  "le prix de réserve ne doit pas être négatif",
+	},
+	ErrListingExpiryPast: {
+		LangEnglish: "listing must expire in the future",
+		LangFrench:  "l'annonce doit expirer dans le futur",
+	},
+	ErrWasteAlreadyListed: {
+		LangEnglish: "waste %s is already listed under %s",
+		LangFrench:  "le déchet %s est déjà en vente sous %s",
+	},
+	ErrListingAlreadyExists: {
+		LangEnglish: "listing %s already exists",
+		LangFrench:  "l'annonce %s existe déjà",
+	},
+	ErrSellerBidForbidden: {
+		LangEnglish: "the seller cannot bid on listing %s",
+		LangFrench:  "le vendeur ne peut pas enchérir sur l'annonce %s",
+	},
+	ErrListingClosed: {
+		LangEnglish: "listing %s is closed for bids",
+		LangFrench:  "l'annonce %s est fermée aux enchères",
+	},
+	ErrBidAmountRequired: {
+		LangEnglish: "the bid amount must be passed in the \"bid\" transient entry",
+		LangFrench:  "le montant de l'enchère doit être transmis dans l'entrée transitoire \"bid\"",
+	},
+	ErrBidTransientInvalid: {
+		LangEnglish: "invalid bid transient data: %v",
+		LangFrench:  "données transitoires d'enchère invalides : %v",
+	},
+	ErrBidAmountInvalid: {
+		LangEnglish: "bid amount must be positive",
+		LangFrench:  "le montant de l'enchère doit être positif",
+	},
+	ErrBidSaltRequired: {
+		LangEnglish: "the bid must carry a random salt of at least 16 characters",
+		LangFrench:  "l'enchère doit comporter un sel aléatoire d'au moins 16 caractères",
+	},
+	ErrBidRevealClosed: {
+		LangEnglish: "listing %s is %s; its bids can no longer be revealed",
+		LangFrench:  "l'annonce %s est %s ; ses enchères ne peuvent plus être révélées",
+	},
+	ErrBidsSealed: {
+		LangEnglish: "bids on listing %s stay sealed until %s",
+		LangFrench:  "les enchères sur l'annonce %s restent scellées jusqu'au %s",
+	},
+	ErrBidRevealExpired: {
+		LangEnglish: "bids on listing %s could be revealed until %s",
+		LangFrench:  "les enchères sur l'annonce %s pouvaient être révélées jusqu'au %s",
+	},
+	ErrBidNotFound: {
+		LangEnglish: "%s has no bid on listing %s",
+		LangFrench:  "%s n'a pas d'enchère sur l'annonce %s",
+	},
+	ErrBidAlreadyRevealed: {
+		LangEnglish: "the bid of %s on listing %s is already revealed",
+		LangFrench:  "l'enchère de %s sur l'annonce %s est déjà révélée",
+	},
+	ErrBidNotOnPeer: {
+		LangEnglish: "the sealed bid of %s on listing %s is not on this peer",
+		LangFrench:  "l'enchère scellée de %s sur l'annonce %s n'est pas sur ce pair",
+	},
+	ErrBidCommitmentMismatch: {
+		LangEnglish: "the bid of %s on listing %s does not match its commitment",
+		LangFrench:  "l'enchère de %s sur l'annonce %s ne correspond pas à son engagement",
+	},
+	ErrListingNotAwardable: {
+		LangEnglish: "listing %s is %s and cannot be awarded",
+		LangFrench:  "l'annonce %s est %s et ne peut pas être attribuée",
+	},
+	ErrBidsUnrevealed: {
+		LangEnglish: "%d of %d bids on listing %s are revealed; bidders have until %s",
+		LangFrench:  "%d enchères sur %d de l'annonce %s sont révélées ; les enchérisseurs ont jusqu'au %s",
+	},
+	ErrListingNotSettleable: {
+		LangEnglish: "listing %s must be %s to be settled (is %s)",
+		LangFrench:  "l'annonce %s doit être %s pour être réglée (elle est %s)",
+	},
+	ErrListingNotFound: {
+		LangEnglish: "listing %s does not exist",
+		LangFrench:  "l'annonce %s n'existe pas",
+	},
+	ErrListingManageForbidden: {
+		LangEnglish: "only %s can manage listing %s",
+		LangFrench:  "seul %s peut gérer l'annonce %s",
+	},
+	ErrListingExpiryInvalid: {
+		LangEnglish: "listing %s has an invalid expiry: %v",
+		LangFrench:  "l'annonce %s a une date d'expiration invalide : %v",
+	},
+
+	// Media
+	ErrMediaInvalid: {
+		LangEnglish: "invalid media: %v",
+		LangFrench:  "média invalide : %v",
+	},
+	ErrMediaHashInvalid: {
+		LangEnglish: "media hash must be a hex-encoded SHA-256 digest",
+		LangFrench:  "l'empreinte du média doit être un condensat SHA-256 en hexadécimal",
+	},
+	ErrMediaTypeUnsupported: {
+		LangEnglish: "unsupported media type %q (expected an image or a video)",
+		LangFrench:  "type de média %q non pris en charge (image ou vidéo attendue)",
+	},
+	ErrMediaURIRequired: {
+		LangEnglish: "media URI is required",
+		LangFrench:  "l'URI du média est requise",
+	},
+	ErrMediaSizeNegative: {
+		LangEnglish: "media size cannot be negative",
+		LangFrench:  "la taille du média ne peut pas être négative",
+	},
+	ErrCapturePositionIncomplete: {
+		LangEnglish: "capture position needs both latitude and longitude",
+		LangFrench:  "la position de capture nécessite la latitude et la longitude",
+	},
+	ErrCapturePositionOutOfRange: {
+		LangEnglish: "capture position %.6f,%.6f is out of range",
+		LangFrench:  "la position de capture %.6f,%.6f est hors limites",
+	},
+	ErrCaptureTimeInvalid: {
+		LangEnglish: "invalid capture time %q (expected RFC 3339)",
+		LangFrench:  "heure de capture %q invalide (format attendu RFC 3339)",
+	},
+	ErrCaptureTimeFuture: {
+		LangEnglish: "media cannot be captured in the future",
+		LangFrench:  "un média ne peut pas être capturé dans le futur",
+	},
+	ErrMediaAlreadyAttached: {
+		LangEnglish: "media %s is already attached to %s %s",
+		LangFrench:  "le média %s est déjà joint à %s %s",
+	},
+	ErrMediaLimitReached: {
+		LangEnglish: "%s %s already has the maximum of %d media",
+		LangFrench:  "%s %s a déjà le maximum de %d médias",
+	},
+	ErrMediaNotAttached: {
+		LangEnglish: "media %s is not attached to %s %s",
+		LangFrench:  "le média %s n'est pas joint à %s %s",
+	},
+	ErrMediaRemoveForbidden: {
+		LangEnglish: "only %s can remove media %s",
+		LangFrench:  "seul %s peut retirer le média %s",
+	},
+	ErrMediaOrderInvalid: {
+		LangEnglish: "invalid media order: %v",
+		LangFrench:  "ordre des médias invalide : %v",
+	},
+	ErrMediaOrderIncomplete: {
+		LangEnglish: "the new order must list all %d media of %s %s",
+		LangFrench:  "le nouvel ordre doit lister les %d médias de %s %s",
+	},
+	ErrMediaOrderMismatch: {
+		LangEnglish: "media %s is not attached to %s %s or is listed twice",
+		LangFrench:  "le média %s n'est pas joint à %s %s ou est listé deux fois",
+	},
+
+	// Recycling methods
+	ErrMethodCodeInvalid: {
+		LangEnglish: "invalid method code %q",
+		LangFrench:  "code de méthode %q invalide",
+	},
+	ErrMethodNameRequired: {
+		LangEnglish: "method name is required",
+		LangFrench:  "le nom de la méthode est requis",
+	},
+	ErrEmissionFactorNegative: {
+		LangEnglish: "emission factor must not be negative",
+		LangFrench:  "le facteur d'émission ne doit pas être négatif",
+	},
+	ErrTypicalDurationNegative: {
+		LangEnglish: "typical duration must not be negative",
+		LangFrench:  "la durée typique ne doit pas être négative",
+	},
+	ErrMethodNotInCatalog: {
+		LangEnglish: "recycling method %s is not in the catalog",
+		LangFrench:  "la méthode de recyclage %s ne figure pas au catalogue",
+	},
+	ErrMethodRetired: {
+		LangEnglish: "recycling method %s is retired",
+		LangFrench:  "la méthode de recyclage %s est retirée",
+	},
+	ErrFacilityCertificationMissing: {
+		LangEnglish: "facility %s lacks certification %s required by method %s",
+		LangFrench:  "l'installation %s n'a pas la certification %s requise par la méthode %s",
+	},
+
+	// Metrics
+	ErrMetricsReadForbidden: {
+		LangEnglish: "only admins and auditors can read the function metrics",
+		LangFrench:  "seuls les administrateurs et les auditeurs peuvent consulter les métriques des fonctions",
+	},
+
+	// Migrations
+	ErrMigrationOrder: {
+		LangEnglish: "migration %d must complete before migration %d",
+		LangFrench:  "la migration %d doit se terminer avant la migration %d",
+	},
+	ErrMigrationNotRegistered: {
+		LangEnglish: "migration %d is not registered",
+		LangFrench:  "la migration %d n'est pas enregistrée",
+	},
+	ErrMigrationFailed: {
+		LangEnglish: "migration %d failed on %s: %v",
+		LangFrench:  "la migration %d a échoué sur %s : %v",
+	},
+
+	// Notifications
+	ErrNotificationNotFound: {
+		LangEnglish: "notification %s does not exist",
+		LangFrench:  "la notification %s n'existe pas",
+	},
+
+	// Onboarding
+	ErrOnboardingStepUnknown: {
+		LangEnglish: "unknown onboarding step %q (expected one of: %s)",
+		LangFrench:  "étape d'intégration %q inconnue (valeurs attendues : %s)",
+	},
+	ErrEvidenceHashInvalid: {
+		LangEnglish: "the evidence hash must be a hex-encoded sha256 digest",
+		LangFrench:  "l'empreinte de la preuve doit être un condensat sha256 en hexadécimal",
+	},
+	ErrOnboardingStepOrder: {
+		LangEnglish: "onboarding step %s must be verified before %s",
+		LangFrench:  "l'étape d'intégration %s doit être vérifiée avant %s",
+	},
+	ErrParticipantIDRequired: {
+		LangEnglish: "participant ID is required",
+		LangFrench:  "l'identifiant du participant est requis",
+	},
+	ErrParticipantAlreadyActive: {
+		LangEnglish: "participant %s is already active",
+		LangFrench:  "le participant %s est déjà actif",
+	},
+	ErrOnboardingNotFound: {
+		LangEnglish: "onboarding of participant %s does not exist",
+		LangFrench:  "l'intégration du participant %s n'existe pas",
+	},
+	ErrParticipantNotActive: {
+		LangEnglish: "participant %s is not activated yet; complete its onboarding first",
+		LangFrench:  "le participant %s n'est pas encore activé ; terminez d'abord son intégration",
+	},
+
+	// Co-ownership
+	ErrWasteAlreadyCoowned: {
+		LangEnglish: "waste %s is already co-owned; transfer shares instead",
+		LangFrench:  "le déchet %s est déjà en copropriété ; transférez plutôt des parts",
+	},
+	ErrOwnershipSplitForbidden: {
+		LangEnglish: "only %s can split the ownership of waste %s",
+		LangFrench:  "seul %s peut répartir la propriété du déchet %s",
+	},
+	ErrShareHolderRequired: {
+		LangEnglish: "each share needs a holderId and holderMsp",
+		LangFrench:  "chaque part nécessite un holderId et un holderMsp",
+	},
+	ErrShareNotPositive: {
+		LangEnglish: "share of %s must be positive",
+		LangFrench:  "la part de %s doit être positive",
+	},
+	ErrShareHolderDuplicate: {
+		LangEnglish: "holder %s appears more than once",
+		LangFrench:  "le détenteur %s apparaît plus d'une fois",
+	},
+	ErrCoownershipHoldersTooFew: {
+		LangEnglish: "co-ownership needs at least two holders",
+		LangFrench:  "une copropriété nécessite au moins deux détenteurs",
+	},
+	ErrSharesTotalInvalid: {
+		LangEnglish: "shares total %.4f%%, expected 100%%",
+		LangFrench:  "les parts totalisent %.4f%%, 100%% attendus",
+	},
+	ErrTransferPercentageInvalid: {
+		LangEnglish: "transferred percentage must be positive",
+		LangFrench:  "le pourcentage transféré doit être positif",
+	},
+	ErrBuyerIdentityRequired: {
+		LangEnglish: "buyer id and organization are required",
+		LangFrench:  "l'identifiant et l'organisation de l'acheteur sont requis",
+	},
+	ErrWasteHandedOff: {
+		LangEnglish: "waste %s left the network (handoff %s)",
+		LangFrench:  "le déchet %s a quitté le réseau (transmission %s)",
+	},
+	ErrTransferSameParty: {
+		LangEnglish: "seller and buyer must differ",
+		LangFrench:  "le vendeur et l'acheteur doivent être différents",
+	},
+	ErrShareInsufficient: {
+		LangEnglish: "%s holds only %.4f%% of waste %s",
+		LangFrench:  "%s ne détient que %.4f%% du déchet %s",
+	},
+	ErrShareNotHeld: {
+		LangEnglish: "%s holds no share of waste %s",
+		LangFrench:  "%s ne détient aucune part du déchet %s",
+	},
+	ErrAmountNegative: {
+		LangEnglish: "amount must not be negative",
+		LangFrench:  "le montant ne doit pas être négatif",
+	},
+
+	// Intake planning
+	ErrIntakeDatePast: {
+		LangEnglish: "intake cannot be reserved for a past date (%s)",
+		LangFrench:  "la réception ne peut pas être réservée pour une date passée (%s)",
+	},
+	ErrIntakeReservationsUnsupported: {
+		LangEnglish: "facility %s does not take intake reservations",
+		LangFrench:  "l'installation %s n'accepte pas de réservations de réception",
+	},
+	ErrSupplyContractStatusInvalid: {
+		LangEnglish: "supply contract %s is %s",
+		LangFrench:  "le contrat d'approvisionnement %s est %s",
+	},
+	ErrSupplyContractPartyMismatch: {
+		LangEnglish: "supply contract %s is not with %s, the operator of facility %s",
+		LangFrench:  "le contrat d'approvisionnement %s n'est pas conclu avec %s, l'exploitant de l'installation %s",
+	},
+	ErrIntakeReserveForbidden: {
+		LangEnglish: "only the parties to supply contract %s can reserve intake under it",
+		LangFrench:  "seules les parties du contrat d'approvisionnement %s peuvent réserver une réception à ce titre",
+	},
+	ErrIntakeReservationAlreadyExists: {
+		LangEnglish: "intake reservation %s already exists",
+		LangFrench:  "la réservation de réception %s existe déjà",
+	},
+	ErrIntakeReservationStatusUnchanged: {
+		LangEnglish: "intake reservation %s is already %s",
+		LangFrench:  "la réservation de réception %s est déjà %s",
+	},
+	ErrIntakeCancelForbidden: {
+		LangEnglish: "only %s or %s can cancel intake reservation %s",
+		LangFrench:  "seuls %s ou %s peuvent annuler la réservation de réception %s",
+	},
+	ErrIntakeReservationNotFound: {
+		LangEnglish: "intake reservation %s does not exist",
+		LangFrench:  "la réservation de réception %s n'existe pas",
+	},
+	ErrIntakePlanForbidden: {
+		LangEnglish: "only %s can plan the intake of facility %s",
+		LangFrench:  "seul %s peut planifier la réception de l'installation %s",
+	},
+	ErrIntakeUnsupported: {
+		LangEnglish: "facility %s does not take intake",
+		LangFrench:  "l'installation %s n'accepte pas de réceptions",
+	},
+	ErrPeriodInvalid: {
+		LangEnglish: "the end of the period must not be before its start",
+		LangFrench:  "la fin de la période ne doit pas précéder son début",
+	},
+	ErrForecastTooLong: {
+		LangEnglish: "a forecast covers at most %d days",
+		LangFrench:  "une prévision couvre au plus %d jours",
+	},
+
+	// Plots
+	ErrPlotFieldsRequired: {
+		LangEnglish: "plot farm and parcel identifier are required",
+		LangFrench:  "l'exploitation et l'identifiant de la parcelle sont requis",
+	},
+	ErrParcelSchemeInvalid: {
+		LangEnglish: "invalid parcel scheme %q (expected %s or %s)",
+		LangFrench:  "schéma de parcelle %q invalide (valeurs attendues %s ou %s)",
+	},
+	ErrPlotAreaNegative: {
+		LangEnglish: "plot area must not be negative",
+		LangFrench:  "la surface de la parcelle ne doit pas être négative",
+	},
+	ErrPlotAlreadyExists: {
+		LangEnglish: "plot %s already exists",
+		LangFrench:  "la parcelle %s existe déjà",
+	},
+	ErrParcelAlreadyRegistered: {
+		LangEnglish: "%s parcel %s is already registered as plot %s",
+		LangFrench:  "la parcelle %s %s est déjà enregistrée sous %s",
+	},
+	ErrPlotAlreadyRetired: {
+		LangEnglish: "plot %s is already retired",
+		LangFrench:  "la parcelle %s est déjà retirée",
+	},
+	ErrPlotRetireForbidden: {
+		LangEnglish: "only %s can retire plot %s",
+		LangFrench:  "seul %s peut retirer la parcelle %s",
+	},
+	ErrPlotNotFound: {
+		LangEnglish: "plot %s does not exist",
+		LangFrench:  "la parcelle %s n'existe pas",
+	},
+
+	// Prices
+	ErrProductTypeRequired: {
+		LangEnglish: "product type is required",
+		LangFrench:  "le type de produit est requis",
+	},
+	ErrPriceNegative: {
+		LangEnglish: "price must not be negative",
+		LangFrench:  "le prix ne doit pas être négatif",
+	},
+	ErrCurrencyInvalid: {
+		LangEnglish: "currency must be an ISO 4217 code such as EUR",
+		LangFrench:  "la devise doit être un code ISO 4217 tel que EUR",
+	},
+	ErrPriceAlreadyRecorded: {
+		LangEnglish: "a price for %s on %s is already recorded",
+		LangFrench:  "un prix pour %s au %s est déjà enregistré",
+	},
+	ErrPriceOracleRequired: {
+		LangEnglish: "only admins and the designated price oracle may record prices",
+		LangFrench:  "seuls les administrateurs et l'oracle de prix désigné peuvent enregistrer des prix",
+	},
+
+	// Personal data
+	ErrPersonalDataRestricted: {
+		LangEnglish: "personal data of waste %s is restricted to its owner",
+		LangFrench:  "les données personnelles du déchet %s sont réservées à son propriétaire",
+	},
+	ErrPersonalDataNotFound: {
+		LangEnglish: "waste %s has no personal data on record",
+		LangFrench:  "le déchet %s n'a pas de données personnelles enregistrées",
+	},
+	ErrPIITransientInvalid: {
+		LangEnglish: "invalid pii transient data: %v",
+		LangFrench:  "données transitoires pii invalides : %v",
+	},
+	ErrPIISubjectRequired: {
+		LangEnglish: "a participant ID or the owner in the pii transient entry is required",
+		LangFrench:  "un identifiant de participant ou le propriétaire dans l'entrée transitoire pii est requis",
+	},
+	ErrParticipantManageForbidden: {
+		LangEnglish: "only %s can manage participant %s",
+		LangFrench:  "seul %s peut gérer le participant %s",
+	},
+	ErrParticipantNotFound: {
+		LangEnglish: "participant %s does not exist or was erased",
+		LangFrench:  "le participant %s n'existe pas ou a été effacé",
+	},
+
+	// Public statistics
+	ErrPublicStatisticsDisabled: {
+		LangEnglish: "public statistics are disabled (public.enabled)",
+		LangFrench:  "les statistiques publiques sont désactivées (public.enabled)",
+	},
+	ErrPublicGroupByInvalid: {
+		LangEnglish: "invalid public.groupBy setting %q (expected region, year or none)",
+		LangFrench:  "paramètre public.groupBy %q invalide (valeurs attendues region, year ou none)",
+	},
+
+	// Data quality
+	ErrFieldUnknown: {
+		LangEnglish: "unknown field %q (expected %s)",
+		LangFrench:  "champ %q inconnu (valeurs attendues %s)",
+	},
+	ErrSeverityInvalid: {
+		LangEnglish: "severity must be %s, %s or %s",
+		LangFrench:  "la gravité doit être %s, %s ou %s",
+	},
+	ErrEnrichmentsInvalid: {
+		LangEnglish: "invalid enrichments JSON: %v",
+		LangFrench:  "JSON d'enrichissements invalide : %v",
+	},
+	ErrEnrichmentRequired: {
+		LangEnglish: "no enrichment given",
+		LangFrench:  "aucun enrichissement fourni",
+	},
+	ErrEnrichmentBatchTooLarge: {
+		LangEnglish: "at most %d lots can be enriched at once, got %d",
+		LangFrench:  "au plus %d lots peuvent être enrichis à la fois, %d reçus",
+	},
+	ErrEnrichmentForbidden: {
+		LangEnglish: "only %s can enrich waste %s",
+		LangFrench:  "seul %s peut enrichir le déchet %s",
+	},
+	ErrPersonalDataErased: {
+		LangEnglish: "personal data of waste %s was erased",
+		LangFrench:  "les données personnelles du déchet %s ont été effacées",
+	},
+	ErrFieldAlreadySet: {
+		LangEnglish: "%s is already set; only missing fields can be backfilled",
+		LangFrench:  "%s est déjà renseigné ; seuls les champs manquants peuvent être complétés",
+	},
+	ErrNoMissingField: {
+		LangEnglish: "no missing field to backfill",
+		LangFrench:  "aucun champ manquant à compléter",
+	},
+	ErrHarvestDateFuture: {
+		LangEnglish: "harvest date %s is in the future",
+		LangFrench:  "la date de récolte %s est dans le futur",
+	},
+
+	// Quotas
+	ErrSeasonInvalid: {
+		LangEnglish: "invalid season %q (expected e.g. 2025-2026 or 2025)",
+		LangFrench:  "saison %q invalide (format attendu par exemple 2025-2026 ou 2025)",
+	},
+	ErrQuotaPolicyInvalid: {
+		LangEnglish: "policy must be %s or %s",
+		LangFrench:  "la politique doit être %s ou %s",
+	},
+	ErrQuotaValuesNegative: {
+		LangEnglish: "area, yield and quota cannot be negative",
+		LangFrench:  "la surface, le rendement et le quota ne peuvent pas être négatifs",
+	},
+	ErrFarmAreaUnknown: {
+		LangEnglish: "farm %q has no plot area on record; pass its area or a quota",
+		LangFrench:  "l'exploitation %q n'a pas de surface de parcelles enregistrée ; indiquez sa surface ou un quota",
+	},
+	ErrQuotaNotFound: {
+		LangEnglish: "farm %q has no quota for season %s",
+		LangFrench:  "l'exploitation %q n'a pas de quota pour la saison %s",
+	},
+
+	// References
+	ErrReferenceNotFound: {
+		LangEnglish: "no asset has reference %s",
+		LangFrench:  "aucun actif n'a la référence %s",
+	},
+
+	// Research exports
+	ErrDatasetFieldsRequired: {
+		LangEnglish: "export id, requester and purpose are required",
+		LangFrench:  "l'identifiant de l'export, le demandeur et la finalité sont requis",
+	},
+	ErrRecordCountNegative: {
+		LangEnglish: "record count must not be negative",
+		LangFrench:  "le nombre d'enregistrements ne doit pas être négatif",
+	},
+	ErrDatasetAlreadyExists: {
+		LangEnglish: "dataset export %s already exists",
+		LangFrench:  "l'export de données %s existe déjà",
+	},
+	ErrDatasetNotFound: {
+		LangEnglish: "dataset export %s does not exist",
+		LangFrench:  "l'export de données %s n'existe pas",
+	},
+
+	// Data residency
+	ErrRegionCodeInvalid: {
+		LangEnglish: "region code must be capitals, digits and underscores",
+		LangFrench:  "le code de région doit contenir des majuscules, des chiffres et des soulignés",
+	},
+	ErrRegionCollectionRequired: {
+		LangEnglish: "region %s needs a collection of its own",
+		LangFrench:  "la région %s nécessite sa propre collection",
+	},
+	ErrRegionOrganizationsInvalid: {
+		LangEnglish: "invalid region organizations: %v",
+		LangFrench:  "organisations de la région invalides : %v",
+	},
+	ErrRegionOrganizationsRequired: {
+		LangEnglish: "region %s needs at least one organization",
+		LangFrench:  "la région %s nécessite au moins une organisation",
+	},
+	ErrRegionReadersInvalid: {
+		LangEnglish: "invalid region readers: %v",
+		LangFrench:  "lecteurs de la région invalides : %v",
+	},
+	ErrRegionCollectionTaken: {
+		LangEnglish: "collection %s already belongs to region %s",
+		LangFrench:  "la collection %s appartient déjà à la région %s",
+	},
+	ErrRegionAlreadyPinned: {
+		LangEnglish: "%s is already pinned to region %s",
+		LangFrench:  "%s est déjà rattaché à la région %s",
+	},
+	ErrRegionInUse: {
+		LangEnglish: "region %s has %d participants pinned to %s",
+		LangFrench:  "la région %s a %d participants rattachés à %s",
+	},
+	ErrRegionNotFound: {
+		LangEnglish: "residency region %s does not exist",
+		LangFrench:  "la région de résidence %s n'existe pas",
+	},
+	ErrPersonalDataRegionRestricted: {
+		LangEnglish: "personal data of waste %s is restricted to its owner and the readers of region %s",
+		LangFrench:  "les données personnelles du déchet %s sont réservées à son propriétaire et aux lecteurs de la région %s",
+	},
+
+	// Validation rules
+	ErrRuleAssetTypeInvalid: {
+		LangEnglish: "asset type must be one of %s",
+		LangFrench:  "le type d'actif doit être l'un de %s",
+	},
+	ErrExpressionInvalid: {
+		LangEnglish: "invalid expression: %v",
+		LangFrench:  "expression invalide : %v",
+	},
+	ErrRuleSeverityInvalid: {
+		LangEnglish: "severity must be %s or %s",
+		LangFrench:  "la gravité doit être %s ou %s",
+	},
+	ErrRuleWarningUnsupported: {
+		LangEnglish: "only WASTE rules can warn; %s rules must reject",
+		LangFrench:  "seules les règles WASTE peuvent avertir ; les règles %s doivent rejeter",
+	},
+	ErrRuleNotFound: {
+		LangEnglish: "validation rule %s does not exist",
+		LangFrench:  "la règle de validation %s n'existe pas",
+	},
+	ErrAssetJSONInvalid: {
+		LangEnglish: "invalid %s JSON: %v",
+		LangFrench:  "JSON %s invalide : %v",
+	},
+	ErrRuleEvaluationFailed: {
+		LangEnglish: "validation rule %s: %v",
+		LangFrench:  "règle de validation %s : %v",
+	},
+
+	// Audit sampling
+	ErrSampleSelectForbidden: {
+		LangEnglish: "only admins and auditors can select audit samples",
+		LangFrench:  "seuls les administrateurs et les auditeurs peuvent sélectionner des échantillons d'audit",
+	},
+	ErrSampleSizeInvalid: {
+		LangEnglish: "sample size must be positive",
+		LangFrench:  "la taille de l'échantillon doit être positive",
+	},
+	ErrSampleFilterInvalid: {
+		LangEnglish: "invalid sample filter: %v",
+		LangFrench:  "filtre d'échantillon invalide : %v",
+	},
+	ErrSampleAlreadyExists: {
+		LangEnglish: "audit sample %s already exists",
+		LangFrench:  "l'échantillon d'audit %s existe déjà",
+	},
+	ErrSamplePopulationEmpty: {
+		LangEnglish: "no lot matches the sample filter",
+		LangFrench:  "aucun lot ne correspond au filtre de l'échantillon",
+	},
+	ErrSampleTooLarge: {
+		LangEnglish: "sample size %d exceeds the population of %d lots",
+		LangFrench:  "la taille d'échantillon %d dépasse la population de %d lots",
+	},
+	ErrInspectionRecordForbidden: {
+		LangEnglish: "only admins and auditors can record audit inspections",
+		LangFrench:  "seuls les administrateurs et les auditeurs peuvent enregistrer des inspections d'audit",
+	},
+	ErrSampleNotFound: {
+		LangEnglish: "audit sample %s does not exist",
+		LangFrench:  "l'échantillon d'audit %s n'existe pas",
+	},
+
+	// Sensors
+	ErrSensorAssetTypeInvalid: {
+		LangEnglish: "asset type must be WASTE or EXTRACTION",
+		LangFrench:  "le type d'actif doit être WASTE ou EXTRACTION",
+	},
+	ErrSensorIDRequired: {
+		LangEnglish: "sensor id is required",
+		LangFrench:  "l'identifiant du capteur est requis",
+	},
+	ErrRecordedAtInvalid: {
+		LangEnglish: "recordedAt must be an RFC3339 timestamp: %v",
+		LangFrench:  "recordedAt doit être un horodatage RFC3339 : %v",
+	},
+	ErrReadingOutOfOrder: {
+		LangEnglish: "reading at %s is older than the last reading at %s",
+		LangFrench:  "la mesure du %s est antérieure à la dernière mesure du %s",
+	},
+	ErrBreachRulesSettingInvalid: {
+		LangEnglish: "invalid quality.breachRules setting: %v",
+		LangFrench:  "paramètre quality.breachRules invalide : %v",
+	},
+	ErrBreachRuleInvalid: {
+		LangEnglish: "invalid breach rule %q",
+		LangFrench:  "règle de dépassement %q invalide",
+	},
+	ErrSensorGatewayUnregistered: {
+		LangEnglish: "no sensor gateway is registered (set oracle.sensorIdentities)",
+		LangFrench:  "aucune passerelle de capteurs n'est enregistrée (définissez oracle.sensorIdentities)",
+	},
+	ErrSensorGatewayRequired: {
+		LangEnglish: "only a registered sensor gateway may record readings",
+		LangFrench:  "seule une passerelle de capteurs enregistrée peut enregistrer des mesures",
+	},
+
+	// Settlements
+	ErrTokenChaincodeUnset: {
+		LangEnglish: "no token chaincode configured (settlement.tokenChaincode)",
+		LangFrench:  "aucun chaincode de jetons n'est configuré (settlement.tokenChaincode)",
+	},
+	ErrSettlementConfirmForbidden: {
+		LangEnglish: "only the seller %s can confirm settlement %s",
+		LangFrench:  "seul le vendeur %s peut confirmer le règlement %s",
+	},
+	ErrCancelReasonRequired: {
+		LangEnglish: "a cancelation reason is required",
+		LangFrench:  "un motif d'annulation est requis",
+	},
+	ErrSettlementCancelForbidden: {
+		LangEnglish: "caller cannot cancel settlement %s",
+		LangFrench:  "l'appelant ne peut pas annuler le règlement %s",
+	},
+	ErrSettlementNotFound: {
+		LangEnglish: "settlement %s does not exist",
+		LangFrench:  "le règlement %s n'existe pas",
+	},
+	ErrSettlementStatusInvalid: {
+		LangEnglish: "settlement %s is %s",
+		LangFrench:  "le règlement %s est %s",
+	},
+	ErrTokenCallFailed: {
+		LangEnglish: "%s %s failed: %s",
+		LangFrench:  "%s %s a échoué : %s",
+	},
+
+	// SLAs
+	ErrSLADaysInvalid: {
+		LangEnglish: "SLA days must be positive",
+		LangFrench:  "le nombre de jours du SLA doit être positif",
+	},
+	ErrSLADefineForbidden: {
+		LangEnglish: "only %s can define the SLA of supply contract %s",
+		LangFrench:  "seul %s peut définir le SLA du contrat d'approvisionnement %s",
+	},
+	ErrSLAScopeInvalid: {
+		LangEnglish: "SLA scope must be %s or %s",
+		LangFrench:  "le périmètre du SLA doit être %s ou %s",
+	},
+	ErrSLANotFound: {
+		LangEnglish: "no SLA is defined for %s %s",
+		LangFrench:  "aucun SLA n'est défini pour %s %s",
+	},
+
+	// Snapshots
+	ErrSnapshotIncomplete: {
+		LangEnglish: "snapshot %s is not complete",
+		LangFrench:  "l'instantané %s n'est pas terminé",
+	},
+	ErrSnapshotAlreadyAnchored: {
+		LangEnglish: "snapshot %s is already anchored at block %d",
+		LangFrench:  "l'instantané %s est déjà ancré au bloc %d",
+	},
+	ErrSnapshotNotFound: {
+		LangEnglish: "snapshot %s does not exist",
+		LangFrench:  "l'instantané %s n'existe pas",
+	},
+	ErrSnapshotKeyNotFound: {
+		LangEnglish: "key %s is not part of snapshot %s",
+		LangFrench:  "la clé %s ne fait pas partie de l'instantané %s",
+	},
+	ErrSnapshotChunkMissing: {
+		LangEnglish: "snapshot %s is missing chunk %d",
+		LangFrench:  "il manque le segment %[2]d de l'instantané %[1]s",
+	},
+	ErrLeafHashInvalid: {
+		LangEnglish: "invalid leaf hash for %s: %v",
+		LangFrench:  "empreinte de feuille invalide pour %s : %v",
+	},
+
+	// Status reasons
+	ErrStatusReasonsInvalid: {
+		LangEnglish: "invalid status reasons: %v",
+		LangFrench:  "motifs de statut invalides : %v",
+	},
+	ErrStatusReasonsEmpty: {
+		LangEnglish: "a reason catalog needs at least one reason",
+		LangFrench:  "un catalogue de motifs nécessite au moins un motif",
+	},
+	ErrStatusReasonInvalid: {
+		LangEnglish: "reason %d needs a code of capitals, digits and underscores and a label",
+		LangFrench:  "le motif %d nécessite un code en majuscules, chiffres et soulignés et un libellé",
+	},
+	ErrStatusReasonDuplicate: {
+		LangEnglish: "duplicate reason %q",
+		LangFrench:  "motif %q en double",
+	},
+	ErrStatusReasonTaken: {
+		LangEnglish: "reason %q already belongs to %s",
+		LangFrench:  "le motif %q appartient déjà à %s",
+	},
+	ErrStatusReasonsNotDefined: {
+		LangEnglish: "no reasons are defined for %s",
+		LangFrench:  "aucun motif n'est défini pour %s",
+	},
+	ErrStatusReasonRequired: {
+		LangEnglish: "waste %s cannot become %s without a reason code",
+		LangFrench:  "le déchet %s ne peut devenir %s sans code motif",
+	},
+	ErrStatusReasonUnknown: {
+		LangEnglish: "unknown reason %q for %s",
+		LangFrench:  "motif %q inconnu pour %s",
+	},
+
+	// Storage
+	ErrStorageFieldsRequired: {
+		LangEnglish: "storage site id and name are required",
+		LangFrench:  "l'identifiant et le nom du site de stockage sont requis",
+	},
+	ErrStorageCapacityInvalid: {
+		LangEnglish: "storage capacity must be positive",
+		LangFrench:  "la capacité de stockage doit être positive",
+	},
+	ErrTemperatureRangeInvalid: {
+		LangEnglish: "minimum temperature must not exceed maximum temperature",
+		LangFrench:  "la température minimale ne doit pas dépasser la température maximale",
+	},
+	ErrStorageAlreadyExists: {
+		LangEnglish: "storage site %s already exists",
+		LangFrench:  "le site de stockage %s existe déjà",
+	},
+	ErrStorageStatusInvalid: {
+		LangEnglish: "storage site %s is %s",
+		LangFrench:  "le site de stockage %s est %s",
+	},
+	ErrWasteAlreadyStored: {
+		LangEnglish: "waste %s is already stored at site %s",
+		LangFrench:  "le déchet %s est déjà stocké sur le site %s",
+	},
+	ErrCheckInQuantityInvalid: {
+		LangEnglish: "check-in quantity must be positive and at most the remaining %.2f of waste %s",
+		LangFrench:  "la quantité entrée doit être positive et au plus égale aux %.2f restants du déchet %s",
+	},
+	ErrWasteNotStored: {
+		LangEnglish: "waste %s is not stored at site %s",
+		LangFrench:  "le déchet %s n'est pas stocké sur le site %s",
+	},
+	ErrStorageNotFound: {
+		LangEnglish: "storage site %s does not exist",
+		LangFrench:  "le site de stockage %s n'existe pas",
+	},
+	ErrStorageManageForbidden: {
+		LangEnglish: "only %s can manage storage site %s",
+		LangFrench:  "seul %s peut gérer le site de stockage %s",
+	},
+
+	// Subsidies
+	ErrSchemeIDInvalid: {
+		LangEnglish: "scheme ID must be capitals, digits and underscores",
+		LangFrench:  "l'identifiant du dispositif doit contenir des majuscules, des chiffres et des soulignés",
+	},
+	ErrSchemeFieldsRequired: {
+		LangEnglish: "a subsidy scheme needs a name and a paying agency",
+		LangFrench:  "un dispositif d'aide nécessite un nom et un organisme payeur",
+	},
+	ErrRatePerUnitNegative: {
+		LangEnglish: "rate per unit must not be negative",
+		LangFrench:  "le taux unitaire ne doit pas être négatif",
+	},
+	ErrValidityPeriodInvalid: {
+		LangEnglish: "validTo must not be before validFrom",
+		LangFrench:  "validTo ne doit pas précéder validFrom",
+	},
+	ErrSubsidyConditionsInvalid: {
+		LangEnglish: "invalid subsidy conditions: %v",
+		LangFrench:  "conditions d'aide invalides : %v",
+	},
+	ErrSubsidyConditionsEmpty: {
+		LangEnglish: "a subsidy scheme needs at least one condition",
+		LangFrench:  "un dispositif d'aide nécessite au moins une condition",
+	},
+	ErrSubsidyConditionInvalid: {
+		LangEnglish: "condition %d needs a code of capitals, digits and underscores and a description",
+		LangFrench:  "la condition %d nécessite un code en majuscules, chiffres et soulignés et une description",
+	},
+	ErrSubsidyConditionDuplicate: {
+		LangEnglish: "duplicate condition %q",
+		LangFrench:  "condition %q en double",
+	},
+	ErrSubsidyExpressionInvalid: {
+		LangEnglish: "condition %s: invalid expression: %v",
+		LangFrench:  "condition %s : expression invalide : %v",
+	},
+	ErrSchemeAlreadyRetired: {
+		LangEnglish: "subsidy scheme %s is already retired",
+		LangFrench:  "le dispositif d'aide %s est déjà retiré",
+	},
+	ErrSchemeNotFound: {
+		LangEnglish: "subsidy scheme %s does not exist",
+		LangFrench:  "le dispositif d'aide %s n'existe pas",
+	},
+	ErrSchemeRetired: {
+		LangEnglish: "subsidy scheme %s is retired",
+		LangFrench:  "le dispositif d'aide %s est retiré",
+	},
+	ErrSubsidyClaimForbidden: {
+		LangEnglish: "only %s can claim subsidies for waste %s",
+		LangFrench:  "seul %s peut demander des aides pour le déchet %s",
+	},
+	ErrSubsidyConditionFailed: {
+		LangEnglish: "subsidy scheme %s condition %s: %v",
+		LangFrench:  "dispositif d'aide %s, condition %s : %v",
+	},
+	ErrSubsidyExportForbidden: {
+		LangEnglish: "only admins and auditors can export subsidy claims",
+		LangFrench:  "seuls les administrateurs et les auditeurs peuvent exporter les demandes d'aide",
+	},
+
+	// Supply contracts
+	ErrSupplyContractFieldsRequired: {
+		LangEnglish: "supply contract id and product type are required",
+		LangFrench:  "l'identifiant du contrat d'approvisionnement et le type de produit sont requis",
+	},
+	ErrSupplyContractPartiesInvalid: {
+		LangEnglish: "supplier and buyer must be two different organizations",
+		LangFrench:  "le fournisseur et l'acheteur doivent être deux organisations différentes",
+	},
+	ErrCommittedVolumeInvalid: {
+		LangEnglish: "committed volume must be positive",
+		LangFrench:  "le volume engagé doit être positif",
+	},
+	ErrBasePriceInvalid: {
+		LangEnglish: "fixed-price contracts need a positive base price",
+		LangFrench:  "les contrats à prix fixe nécessitent un prix de base positif",
+	},
+	ErrPriceKindInvalid: {
+		LangEnglish: "price kind must be %s or %s",
+		LangFrench:  "le type de prix doit être %s ou %s",
+	},
+	ErrSupplyContractPartyRequired: {
+		LangEnglish: "the caller's organization must be the supplier or the buyer",
+		LangFrench:  "l'organisation de l'appelant doit être le fournisseur ou l'acheteur",
+	},
+	ErrSupplyContractAlreadyExists: {
+		LangEnglish: "supply contract %s already exists",
+		LangFrench:  "le contrat d'approvisionnement %s existe déjà",
+	},
+	ErrSupplyContractStatusUnexpected: {
+		LangEnglish: "supply contract %s is %s, not %s",
+		LangFrench:  "le contrat d'approvisionnement %s est %s et non %s",
+	},
+	ErrSupplyContractAcceptForbidden: {
+		LangEnglish: "only the counterparty of %s can accept supply contract %s",
+		LangFrench:  "seule la contrepartie de %s peut accepter le contrat d'approvisionnement %s",
+	},
+	ErrExtractionAlreadyLinked: {
+		LangEnglish: "extraction %s is already linked to supply contract %s",
+		LangFrench:  "l'extraction %s est déjà liée au contrat d'approvisionnement %s",
+	},
+	ErrSupplyContractNotFound: {
+		LangEnglish: "supply contract %s does not exist",
+		LangFrench:  "le contrat d'approvisionnement %s n'existe pas",
+	},
+	ErrWasteSupplierMismatch: {
+		LangEnglish: "waste %s does not belong to supplier %s",
+		LangFrench:  "le déchet %s n'appartient pas au fournisseur %s",
+	},
+	ErrWasteStatusUnexpected: {
+		LangEnglish: "waste %s is %s, not %s",
+		LangFrench:  "le déchet %s est %s et non %s",
+	},
+	ErrWasteAlreadyDelivered: {
+		LangEnglish: "waste %s is already delivered under supply contract %s",
+		LangFrench:  "le déchet %s est déjà livré au titre du contrat d'approvisionnement %s",
+	},
+	ErrDeliveredQuantityExceeded: {
+		LangEnglish: "delivered quantity exceeds the %.2f of waste %s",
+		LangFrench:  "la quantité livrée dépasse les %.2f du déchet %s",
+	},
+	ErrSupplyContractOutOfTerm: {
+		LangEnglish: "supply contract %s runs from %s to %s",
+		LangFrench:  "le contrat d'approvisionnement %s court du %s au %s",
+	},
+	ErrSupplyContractManageForbidden: {
+		LangEnglish: "only %s and %s can manage supply contract %s",
+		LangFrench:  "seuls %s et %s peuvent gérer le contrat d'approvisionnement %s",
+	},
+
+	// Tags
+	ErrTagInvalid: {
+		LangEnglish: "invalid tag %q: use up to 32 lowercase letters, digits, '-' or '_'",
+		LangFrench:  "étiquette %q invalide : utilisez jusqu'à 32 lettres minuscules, chiffres, '-' ou '_'",
+	},
+	ErrWasteAlreadyTagged: {
+		LangEnglish: "waste %s is already tagged %s",
+		LangFrench:  "le déchet %s porte déjà l'étiquette %s",
+	},
+	ErrTagLimitReached: {
+		LangEnglish: "waste %s already has the maximum of %d tags",
+		LangFrench:  "le déchet %s a déjà le maximum de %d étiquettes",
+	},
+	ErrWasteNotTagged: {
+		LangEnglish: "waste %s is not tagged %s",
+		LangFrench:  "le déchet %s ne porte pas l'étiquette %s",
+	},
+
+	// Taxonomy
+	ErrCategoryCodeInvalid: {
+		LangEnglish: "invalid category code %q: use uppercase letters, digits and '_'",
+		LangFrench:  "code de catégorie %q invalide : utilisez des lettres majuscules, des chiffres et '_'",
+	},
+	ErrSubtypeCodeInvalid: {
+		LangEnglish: "invalid subtype code %q: use uppercase letters, digits and '_'",
+		LangFrench:  "code de sous-type %q invalide : utilisez des lettres majuscules, des chiffres et '_'",
+	},
+	ErrCategoryNotFound: {
+		LangEnglish: "category %s does not exist",
+		LangFrench:  "la catégorie %s n'existe pas",
+	},
+	ErrTaxonomyNodeNotFound: {
+		LangEnglish: "taxonomy node %s/%s does not exist",
+		LangFrench:  "le nœud de taxonomie %s/%s n'existe pas",
+	},
+
+	// Waste templates
+	ErrTemplateNameRequired: {
+		LangEnglish: "a waste template needs a name",
+		LangFrench:  "un modèle de déchet nécessite un nom",
+	},
+	ErrTemplateNotFound: {
+		LangEnglish: "waste template %q of participant %s does not exist",
+		LangFrench:  "le modèle de déchet %q du participant %s n'existe pas",
+	},
+	ErrTemplateInvalid: {
+		LangEnglish: "invalid waste template: %v",
+		LangFrench:  "modèle de déchet invalide : %v",
+	},
+	ErrTemplateWasteTypeRequired: {
+		LangEnglish: "a waste template needs a waste type",
+		LangFrench:  "un modèle de déchet nécessite un type de déchet",
+	},
+	ErrUnitUnknown: {
+		LangEnglish: "unknown unit %q (expected %s or %s)",
+		LangFrench:  "unité %q inconnue (valeurs attendues %s ou %s)",
+	},
+	ErrDefaultQuantityNegative: {
+		LangEnglish: "default quantity must not be negative",
+		LangFrench:  "la quantité par défaut ne doit pas être négative",
+	},
+
+	// Traceability
+	ErrOffsetNegative: {
+		LangEnglish: "offset must not be negative",
+		LangFrench:  "le décalage ne doit pas être négatif",
+	},
+	ErrTraceAssetTypeInvalid: {
+		LangEnglish: "asset type must be WASTE, EXTRACTION or RECYCLING",
+		LangFrench:  "le type d'actif doit être WASTE, EXTRACTION ou RECYCLING",
+	},
+
+	// Transport
+	ErrVehiclePlateRequired: {
+		LangEnglish: "vehicle plate is required",
+		LangFrench:  "l'immatriculation du véhicule est requise",
+	},
+	ErrVehicleCapacityNegative: {
+		LangEnglish: "vehicle capacity must not be negative",
+		LangFrench:  "la capacité du véhicule ne doit pas être négative",
+	},
+	ErrVehicleAlreadyExists: {
+		LangEnglish: "vehicle %s already exists",
+		LangFrench:  "le véhicule %s existe déjà",
+	},
+	ErrPlateAlreadyRegistered: {
+		LangEnglish: "plate %s is already registered as vehicle %s",
+		LangFrench:  "l'immatriculation %s est déjà enregistrée pour le véhicule %s",
+	},
+	ErrDriverFieldsRequired: {
+		LangEnglish: "driver id and license number are required",
+		LangFrench:  "l'identifiant du conducteur et le numéro de permis sont requis",
+	},
+	ErrLicenseExpiryInvalid: {
+		LangEnglish: "invalid license expiry %q (expected YYYY-MM-DD)",
+		LangFrench:  "date d'expiration du permis %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrDriverAlreadyExists: {
+		LangEnglish: "driver %s already exists",
+		LangFrench:  "le conducteur %s existe déjà",
+	},
+	ErrPermitFieldsRequired: {
+		LangEnglish: "permit kind and number are required",
+		LangFrench:  "le type et le numéro de l'autorisation sont requis",
+	},
+	ErrPermitExpiryInvalid: {
+		LangEnglish: "invalid permit expiry %q (expected YYYY-MM-DD)",
+		LangFrench:  "date d'expiration de l'autorisation %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrVehiclePermitForbidden: {
+		LangEnglish: "only %s can record permits of vehicle %s",
+		LangFrench:  "seul %s peut enregistrer les autorisations du véhicule %s",
+	},
+	ErrDriverPermitForbidden: {
+		LangEnglish: "only %s can record permits of driver %s",
+		LangFrench:  "seul %s peut enregistrer les autorisations du conducteur %s",
+	},
+	ErrPermitHolderTypeInvalid: {
+		LangEnglish: "invalid permit holder type %q (expected %s or %s)",
+		LangFrench:  "type de titulaire d'autorisation %q invalide (valeurs attendues %s ou %s)",
+	},
+	ErrVehicleAlreadyRetired: {
+		LangEnglish: "vehicle %s is already retired",
+		LangFrench:  "le véhicule %s est déjà retiré",
+	},
+	ErrVehicleRetireForbidden: {
+		LangEnglish: "only %s can retire vehicle %s",
+		LangFrench:  "seul %s peut retirer le véhicule %s",
+	},
+	ErrVehicleNotFound: {
+		LangEnglish: "vehicle %s does not exist",
+		LangFrench:  "le véhicule %s n'existe pas",
+	},
+	ErrDriverNotFound: {
+		LangEnglish: "driver %s does not exist",
+		LangFrench:  "le conducteur %s n'existe pas",
+	},
+	ErrShipmentCarrierRequired: {
+		LangEnglish: "shipments must reference a registered vehicle and driver",
+		LangFrench:  "les expéditions doivent désigner un véhicule et un conducteur enregistrés",
+	},
+	ErrShipmentDestinationRequired: {
+		LangEnglish: "shipment destination is required",
+		LangFrench:  "la destination de l'expédition est requise",
+	},
+	ErrDepartureDateInvalid: {
+		LangEnglish: "invalid departure date %q (expected YYYY-MM-DD)",
+		LangFrench:  "date de départ %q invalide (format attendu AAAA-MM-JJ)",
+	},
+	ErrShipmentQuantityInvalid: {
+		LangEnglish: "shipment quantity must be positive and at most the lot quantity %.2f",
+		LangFrench:  "la quantité expédiée doit être positive et au plus égale à la quantité du lot %.2f",
+	},
+	ErrVehicleUnavailable: {
+		LangEnglish: "vehicle %s is not an active vehicle of %s",
+		LangFrench:  "le véhicule %s n'est pas un véhicule actif de %s",
+	},
+	ErrDriverUnavailable: {
+		LangEnglish: "driver %s is not an active driver of %s",
+		LangFrench:  "le conducteur %s n'est pas un conducteur actif de %s",
+	},
+	ErrDriverLicenseExpired: {
+		LangEnglish: "the license of driver %s expired on %s",
+		LangFrench:  "le permis du conducteur %s a expiré le %s",
+	},
+	ErrShipmentAlreadyExists: {
+		LangEnglish: "shipment %s already exists",
+		LangFrench:  "l'expédition %s existe déjà",
+	},
+	ErrShipmentStatusInvalid: {
+		LangEnglish: "shipment %s is %s",
+		LangFrench:  "l'expédition %s est %s",
+	},
+	ErrShipmentDeliverForbidden: {
+		LangEnglish: "only %s can deliver shipment %s",
+		LangFrench:  "seul %s peut livrer l'expédition %s",
+	},
+	ErrShipmentNotFound: {
+		LangEnglish: "shipment %s does not exist",
+		LangFrench:  "l'expédition %s n'existe pas",
+	},
+
+	// Validation warnings
+	ErrResolutionRequired: {
+		LangEnglish: "a resolution is required",
+		LangFrench:  "une résolution est requise",
+	},
+	ErrWarningResolveForbidden: {
+		LangEnglish: "only %s can resolve the warnings of waste %s",
+		LangFrench:  "seul %s peut résoudre les avertissements du déchet %s",
+	},
+	ErrWarningNotFound: {
+		LangEnglish: "waste %s has no open %s warning",
+		LangFrench:  "le déchet %s n'a pas d'avertissement %s ouvert",
+	},
+	ErrFieldNotRequirable: {
+		LangEnglish: "field %q cannot be required (expected %s)",
+		LangFrench:  "le champ %q ne peut pas être rendu obligatoire (valeurs attendues %s)",
+	},
+
+	// Weather
+	ErrWeatherEventUnknown: {
+		LangEnglish: "unknown weather event %q",
+		LangFrench:  "événement météo %q inconnu",
+	},
+	ErrWeatherSeverityUnknown: {
+		LangEnglish: "unknown weather severity %q",
+		LangFrench:  "gravité météo %q inconnue",
+	},
+	ErrTemperatureBoundsInvalid: {
+		LangEnglish: "temperatureMin must not exceed temperatureMax",
+		LangFrench:  "temperatureMin ne doit pas dépasser temperatureMax",
+	},
+	ErrPrecipitationNegative: {
+		LangEnglish: "precipitation must not be negative",
+		LangFrench:  "les précipitations ne doivent pas être négatives",
+	},
+	ErrWeatherAlreadyRecorded: {
+		LangEnglish: "%s was already recorded for farm %s on %s",
+		LangFrench:  "%s a déjà été enregistré pour l'exploitation %s le %s",
+	},
+	ErrRangeInvalid: {
+		LangEnglish: "to must not be before from",
+		LangFrench:  "to ne doit pas précéder from",
+	},
+	ErrWeatherOracleUnset: {
+		LangEnglish: "no weather oracle is designated (set oracle.weatherIdentity)",
+		LangFrench:  "aucun oracle météo n'est désigné (définissez oracle.weatherIdentity)",
+	},
+	ErrWeatherOracleRequired: {
+		LangEnglish: "only the designated weather oracle may record observations",
+		LangFrench:  "seul l'oracle météo désigné peut enregistrer des observations",
+	},
+}
+
+// CodedError is an error carrying a stable code and a localized message;
+// its text is "CODE: message" so clients can recover the code
+type CodedError struct {
+	Code    string
+	Message string
+}
+
+func (e *CodedError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// newError builds a CodedError localized for the caller's language
+func newError(ctx contractapi.TransactionContextInterface, code string, args ...interface{}) error {
+	return &CodedError{
+		Code:    code,
+		Message: localize(requestLanguage(ctx), code, args...),
+	}
+}
+
+// localize renders the template of a code, falling back to English
+func localize(lang string, code string, args ...interface{}) string {
+	templates, ok := messageCatalog[code]
+	if !ok {
+		return code
+	}
+	template, ok := templates[lang]
+	if !ok {
+		template = templates[LangEnglish]
+	}
+
+	return fmt.Sprintf(template, args...)
+}
+
+// requestLanguage picks the message language from the transient "lang" hint,
+// then the gateway's transient "accept-language" header, then the
+// "i18n.defaultLanguage" setting
+func requestLanguage(ctx contractapi.TransactionContextInterface) string {
+	transient, err := ctx.GetStub().GetTransient()
+	if err == nil {
+		if lang := negotiateLanguage(string(transient["lang"])); lang != "" {
+			return lang
+		}
+		if lang := negotiateLanguage(string(transient["accept-language"])); lang != "" {
+			return lang
+		}
+	}
+
+	if lang := negotiateLanguage(configString(ctx, "i18n", "defaultLanguage", "")); lang != "" {
+		return lang
+	}
+
+	return LangEnglish
+}
+
+// negotiateLanguage returns the supported language with the highest quality
+// in an Accept-Language style list (e.g. "fr-FR,fr;q=0.9,en;q=0.8")
+func negotiateLanguage(header string) string {
+	best := ""
+	bestQuality := 0.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag != LangEnglish && tag != LangFrench {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > bestQuality {
+			best = tag
+			bestQuality = quality
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,183 @@
+package contract
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultRecyclingMethods are available until an admin redefines them;
+// emission factors are indicative tCO2e avoided per tonne of waste
+func defaultRecyclingMethods() map[string]*models.RecyclingMethod {
+	methods := []*models.RecyclingMethod{
+		{Code: "COMPOSTING", Name: "Composting", EmissionFactor: 0.42, TypicalDurationDays: 60},
+		{Code: "BIOCHAR", Name: "Pyrolysis to biochar", EmissionFactor: 1.9, TypicalDurationDays: 2},
+		{Code: "BIOGAS", Name: "Anaerobic digestion", EmissionFactor: 0.65, TypicalDurationDays: 30},
+		{Code: "PELLETS", Name: "Pelletizing for biomass fuel", EmissionFactor: 1.2, TypicalDurationDays: 5},
+	}
+
+	byCode := map[string]*models.RecyclingMethod{}
+	for _, method := range methods {
+		method.RequiredCertifications = []string{}
+		method.Active = true
+		byCode[method.Code] = method
+	}
+
+	return byCode
+}
+
+// DefineRecyclingMethod adds or updates a catalog method; requiredCertifications
+// is a comma-separated list. Admin only.
+func (s *SmartContract) DefineRecyclingMethod(ctx contractapi.TransactionContextInterface, code string, name string, emissionFactor float64, requiredCertifications string, typicalDurationDays int) (*models.RecyclingMethod, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	code = methodCode(code)
+	if !taxonomyCodePattern.MatchString(code) {
+		return nil, newError(ctx, ErrMethodCodeInvalid, code)
+	}
+	if name == "" {
+		return nil, newError(ctx, ErrMethodNameRequired)
+	}
+	if emissionFactor < 0 {
+		return nil, newError(ctx, ErrEmissionFactorNegative)
+	}
+	if typicalDurationDays < 0 {
+		return nil, newError(ctx, ErrTypicalDurationNegative)
+	}
+
+	method := &models.RecyclingMethod{
+		Code:                   code,
+		Name:                   name,
+		EmissionFactor:         emissionFactor,
+		RequiredCertifications: splitList(requiredCertifications),
+		TypicalDurationDays:    typicalDurationDays,
+		Active:                 true,
+	}
+	if method.RequiredCertifications == nil {
+		method.RequiredCertifications = []string{}
+	}
+
+	if err := s.putRecyclingMethod(ctx, method); err != nil {
+		return nil, err
+	}
+
+	return method, nil
+}
+
+// RetireRecyclingMethod stops new recyclings from using a method; existing
+// records keep their parameters. Admin only.
+func (s *SmartContract) RetireRecyclingMethod(ctx contractapi.TransactionContextInterface, code string) (*models.RecyclingMethod, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	method, err := s.ReadRecyclingMethod(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	method.Active = false
+
+	if err := s.putRecyclingMethod(ctx, method); err != nil {
+		return nil, err
+	}
+
+	return method, nil
+}
+
+// ReadRecyclingMethod returns a catalog method by code (case-insensitive)
+func (s *SmartContract) ReadRecyclingMethod(ctx contractapi.TransactionContextInterface, code string) (*models.RecyclingMethod, error) {
+	code = methodCode(code)
+
+	var method models.RecyclingMethod
+	found, err := newAssetStore(ctx).Get("METHOD_"+code, &method)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "METHOD_"+code, err)
+	}
+	if !found {
+		if method, ok := defaultRecyclingMethods()[code]; ok {
+			return method, nil
+		}
+		return nil, newError(ctx, ErrMethodNotInCatalog, code)
+	}
+
+	return &method, nil
+}
+
+// GetAllRecyclingMethods returns the catalog, including default methods that
+// have not been redefined
+func (s *SmartContract) GetAllRecyclingMethods(ctx contractapi.TransactionContextInterface) ([]*models.RecyclingMethod, error) {
+	byCode := defaultRecyclingMethods()
+
+	err := newAssetStore(ctx).Range("METHOD_", "METHOD_~", func(_ string, value []byte) error {
+		var method models.RecyclingMethod
+		if err := json.Unmarshal(value, &method); err != nil {
+			return err
+		}
+		byCode[method.Code] = &method
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make([]*models.RecyclingMethod, 0, len(byCode))
+	for _, method := range byCode {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Code < methods[j].Code })
+
+	return methods, nil
+}
+
+// checkRecyclingMethod verifies that a method is active in the catalog and
+// that the facility holds the certifications it requires
+func (s *SmartContract) checkRecyclingMethod(ctx contractapi.TransactionContextInterface, code string, facilityId string) (*models.RecyclingMethod, error) {
+	method, err := s.ReadRecyclingMethod(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if !method.Active {
+		return nil, newError(ctx, ErrMethodRetired, method.Code)
+	}
+
+	if len(method.RequiredCertifications) == 0 {
+		return method, nil
+	}
+	facility, err := s.ReadFacility(ctx, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	held := map[string]bool{}
+	for _, certification := range facility.Certifications {
+		held[strings.ToUpper(certification)] = true
+	}
+	for _, required := range method.RequiredCertifications {
+		if !held[strings.ToUpper(required)] {
+			return nil, newError(ctx, ErrFacilityCertificationMissing, facilityId, required, method.Code)
+		}
+	}
+
+	return method, nil
+}
+
+func methodCode(code string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(code)), "_")
+}
+
+func (s *SmartContract) putRecyclingMethod(ctx contractapi.TransactionContextInterface, method *models.RecyclingMethod) error {
+	var err error
+	if method.UpdatedAt, err = txTimestamp(ctx); err != nil {
+		return err
+	}
+	if method.UpdatedBy, err = callerID(ctx); err != nil {
+		return err
+	}
+
+	return newAssetStore(ctx).Put("METHOD_"+method.Code, method)
+}
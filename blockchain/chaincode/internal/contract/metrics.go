@@ -0,0 +1,404 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// metricPrefix keys metric samples by transaction time, so that a window
+// is a key range
+const metricPrefix = "METRIC_"
+
+// Function metrics defaults, overridden by metrics.slowMs (invocations
+// logged as slow), metrics.windowHours (window GetFunctionMetrics covers by
+// default) and metrics.retentionDays (samples kept by maintenance)
+const (
+	defaultMetricSlowMs        = 500
+	defaultMetricWindowHours   = 24
+	defaultMetricRetentionDays = 7
+)
+
+// maxPeerDurations caps the durations kept per function on each peer
+const maxPeerDurations = 200
+
+// staleInvocationAge is how long an invocation may stay open before it is
+// logged as unfinished
+const staleInvocationAge = 10 * time.Minute
+
+// invocation is an invocation in progress: the keys it read and wrote
+type invocation struct {
+	function string
+	started  time.Time
+	reads    map[string]bool
+	writes   map[string]bool
+}
+
+// txMetrics follows each transaction from the before hook to the after hook
+var txMetrics = struct {
+	sync.Mutex
+	open map[string]*invocation
+}{
+	open: map[string]*invocation{},
+}
+
+// peerFunction holds a function's recent durations on this peer
+type peerFunction struct {
+	calls      int
+	unfinished int
+	slow       int
+	durations  []float64
+}
+
+// peerMetrics are the durations measured by this peer since it started the
+// chaincode; they differ from peer to peer and never reach the ledger
+var peerMetrics = struct {
+	sync.Mutex
+	functions map[string]*peerFunction
+}{
+	functions: map[string]*peerFunction{},
+}
+
+// metricLine is the structured log line of an invocation
+type metricLine struct {
+	Level      string  `json:"level"`
+	Msg        string  `json:"msg"`
+	TxID       string  `json:"txId"`
+	Function   string  `json:"function"`
+	DurationMs float64 `json:"durationMs"`
+	Reads      int     `json:"reads"`
+	Writes     int     `json:"writes"`
+	Outcome    string  `json:"outcome"`
+}
+
+// beginTransaction is the contract's before-transaction hook
+func beginTransaction(ctx contractapi.TransactionContextInterface) error {
+	beginMetrics(ctx)
+
+	return beginAudit(ctx)
+}
+
+// endTransaction is the contract's after-transaction hook; the metrics are
+// taken first so the audit record does not count in them
+func endTransaction(ctx contractapi.TransactionContextInterface, result interface{}) error {
+	if err := endMetrics(ctx); err != nil {
+		return err
+	}
+
+	return endAudit(ctx, result)
+}
+
+// noteMetricAccess counts a key read or written by a transaction; audit
+// records and metric samples are left out
+func noteMetricAccess(txID string, key string, write bool) {
+	if strings.HasPrefix(key, auditPrefix) || strings.HasPrefix(key, metricPrefix) {
+		return
+	}
+
+	txMetrics.Lock()
+	defer txMetrics.Unlock()
+	current := txMetrics.open[txID]
+	if current == nil {
+		return
+	}
+	if write {
+		current.writes[key] = true
+	} else {
+		current.reads[key] = true
+	}
+}
+
+// beginMetrics starts timing a transaction. Fabric skips the after hook of
+// failed transactions, so invocations still open after staleInvocationAge are
+// logged as unfinished.
+func beginMetrics(ctx contractapi.TransactionContextInterface) {
+	now := time.Now()
+	stale := map[string]*invocation{}
+
+	txMetrics.Lock()
+	for txID, open := range txMetrics.open {
+		if now.Sub(open.started) > staleInvocationAge {
+			stale[txID] = open
+			delete(txMetrics.open, txID)
+		}
+	}
+	txMetrics.open[ctx.GetStub().GetTxID()] = &invocation{
+		function: invokedFunction(ctx),
+		started:  now,
+		reads:    map[string]bool{},
+		writes:   map[string]bool{},
+	}
+	txMetrics.Unlock()
+
+	for txID, open := range stale {
+		durationMs := elapsedMs(open.started, now)
+		observeDuration(open.function, durationMs, false, false)
+		logMetric("warn", txID, open, durationMs, models.MetricUnfinished)
+	}
+}
+
+// endMetrics logs a successful invocation with its duration and read and
+// write set sizes, and with metrics.record on, samples it on chain when it
+// wrote to the world state. Routine lines are left out with metrics.log
+// false; slow invocations are always logged.
+func endMetrics(ctx contractapi.TransactionContextInterface) error {
+	txID := ctx.GetStub().GetTxID()
+
+	txMetrics.Lock()
+	current := txMetrics.open[txID]
+	delete(txMetrics.open, txID)
+	txMetrics.Unlock()
+	if current == nil {
+		return nil
+	}
+
+	durationMs := elapsedMs(current.started, time.Now())
+	slow := durationMs >= float64(configInt(ctx, "metrics", "slowMs", defaultMetricSlowMs))
+	observeDuration(current.function, durationMs, slow, true)
+	if slow {
+		logMetric("warn", txID, current, durationMs, models.AuditSucceeded)
+	} else if configBool(ctx, "metrics", "log", true) {
+		logMetric("info", txID, current, durationMs, models.AuditSucceeded)
+	}
+
+	// Queries write nothing and never reach the ledger
+	if len(current.writes) == 0 {
+		return nil
+	}
+
+	return putMetricSample(ctx, current.function, len(current.reads), len(current.writes), models.AuditSucceeded)
+}
+
+// putMetricSample records an invocation on chain when metrics.record is on
+func putMetricSample(ctx contractapi.TransactionContextInterface, function string, reads int, writes int, outcome string) error {
+	if !configBool(ctx, "metrics", "record", false) {
+		return nil
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	sample := &models.MetricSample{
+		TxID:      ctx.GetStub().GetTxID(),
+		Function:  function,
+		Reads:     reads,
+		Writes:    writes,
+		Outcome:   outcome,
+		Timestamp: now,
+	}
+
+	// A reported failure and the report itself share a transaction
+	return newAssetStore(ctx).Put(metricPrefix+now+"_"+sample.TxID+"_"+function, sample)
+}
+
+func logMetric(level string, txID string, current *invocation, durationMs float64, outcome string) {
+	line, err := json.Marshal(&metricLine{
+		Level:      level,
+		Msg:        "chaincode invocation",
+		TxID:       txID,
+		Function:   current.function,
+		DurationMs: durationMs,
+		Reads:      len(current.reads),
+		Writes:     len(current.writes),
+		Outcome:    outcome,
+	})
+	if err != nil {
+		return
+	}
+	fmt.Println(string(line))
+}
+
+// observeDuration adds an invocation to this peer's metrics
+func observeDuration(function string, durationMs float64, slow bool, finished bool) {
+	peerMetrics.Lock()
+	defer peerMetrics.Unlock()
+
+	stats := peerMetrics.functions[function]
+	if stats == nil {
+		stats = &peerFunction{}
+		peerMetrics.functions[function] = stats
+	}
+	stats.calls++
+	if !finished {
+		stats.unfinished++
+		return
+	}
+	if slow {
+		stats.slow++
+	}
+	stats.durations = append(stats.durations, durationMs)
+	if len(stats.durations) > maxPeerDurations {
+		stats.durations = stats.durations[len(stats.durations)-maxPeerDurations:]
+	}
+}
+
+// peerFunctionStats returns this peer's metrics of every function seen
+func peerFunctionStats() map[string]*models.PeerFunctionStats {
+	peerMetrics.Lock()
+	defer peerMetrics.Unlock()
+
+	result := map[string]*models.PeerFunctionStats{}
+	for function, stats := range peerMetrics.functions {
+		peer := &models.PeerFunctionStats{Calls: stats.calls, Unfinished: stats.unfinished, Slow: stats.slow}
+		if n := len(stats.durations); n > 0 {
+			durations := append([]float64{}, stats.durations...)
+			sort.Float64s(durations)
+			total := 0.0
+			for _, duration := range durations {
+				total += duration
+			}
+			peer.AvgMs = total / float64(n)
+			peer.P95Ms = durations[int(math.Ceil(0.95*float64(n)))-1]
+			peer.MaxMs = durations[n-1]
+		}
+		result[function] = peer
+	}
+
+	return result
+}
+
+func elapsedMs(started time.Time, now time.Time) float64 {
+	return float64(now.Sub(started).Microseconds()) / 1000
+}
+
+// GetFunctionMetrics returns, per function, the invocations recorded on
+// chain since since (a date or RFC 3339 time; by default the last
+// metrics.windowHours): how many there were, how many failed and the sizes
+// of their read and write sets. Only invocations that wrote to the world
+// state are recorded, and only while metrics.record is on. Each function
+// also carries the durations measured by the peer answering the query,
+// queries included. Admins and auditors only.
+func (s *SmartContract) GetFunctionMetrics(ctx contractapi.TransactionContextInterface, since string) (*models.FunctionMetricsReport, error) {
+	if !isAdmin(ctx) && !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrMetricsReadForbidden)
+	}
+
+	start, err := metricSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.FunctionMetricsReport{
+		Since:     start,
+		Recording: configBool(ctx, "metrics", "record", false),
+		SlowMs:    configInt(ctx, "metrics", "slowMs", defaultMetricSlowMs),
+		Functions: []*models.FunctionMetrics{},
+	}
+	functions := map[string]*models.FunctionMetrics{}
+	metricsOf := func(function string) *models.FunctionMetrics {
+		if functions[function] == nil {
+			functions[function] = &models.FunctionMetrics{Function: function}
+			report.Functions = append(report.Functions, functions[function])
+		}
+		return functions[function]
+	}
+
+	err = newAssetStore(ctx).Range(metricPrefix+start, metricPrefix+"~", func(key string, value []byte) error {
+		var sample models.MetricSample
+		if err := json.Unmarshal(value, &sample); err != nil {
+			return err
+		}
+		metrics := metricsOf(sample.Function)
+		metrics.Invocations++
+		if sample.Outcome != models.AuditSucceeded {
+			metrics.Failures++
+		}
+		// Averages are summed here and divided below
+		metrics.AvgReads += float64(sample.Reads)
+		metrics.AvgWrites += float64(sample.Writes)
+		if sample.Reads > metrics.MaxReads {
+			metrics.MaxReads = sample.Reads
+		}
+		if sample.Writes > metrics.MaxWrites {
+			metrics.MaxWrites = sample.Writes
+		}
+		if sample.Timestamp > metrics.LastInvokedAt {
+			metrics.LastInvokedAt = sample.Timestamp
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, metrics := range report.Functions {
+		// Failures carry no read or write sets
+		if succeeded := metrics.Invocations - metrics.Failures; succeeded > 0 {
+			metrics.AvgReads /= float64(succeeded)
+			metrics.AvgWrites /= float64(succeeded)
+		}
+	}
+	for function, peer := range peerFunctionStats() {
+		metricsOf(function).Peer = peer
+	}
+
+	sort.SliceStable(report.Functions, func(i, j int) bool {
+		a, b := report.Functions[i], report.Functions[j]
+		if a.Invocations != b.Invocations {
+			return a.Invocations > b.Invocations
+		}
+		return a.Function < b.Function
+	})
+
+	return report, nil
+}
+
+// metricSince returns the start of a metrics window as an RFC 3339 time
+func metricSince(ctx contractapi.TransactionContextInterface, since string) (string, error) {
+	if since == "" {
+		now, err := txTimestamp(ctx)
+		if err != nil {
+			return "", err
+		}
+		ts, err := time.Parse(time.RFC3339, now)
+		if err != nil {
+			return "", err
+		}
+		hours := configInt(ctx, "metrics", "windowHours", defaultMetricWindowHours)
+
+		return ts.Add(-time.Duration(hours) * time.Hour).Format(time.RFC3339), nil
+	}
+
+	if day, err := time.Parse("2006-01-02", since); err == nil {
+		return day.Format(time.RFC3339), nil
+	}
+	ts, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return "", newError(ctx, ErrAuditDateInvalid, since)
+	}
+
+	return ts.UTC().Format(time.RFC3339), nil
+}
+
+// pruneMetricSamples deletes the metric samples recorded before cutoff, as
+// many as the query budget lets one run read; later runs delete the rest
+func pruneMetricSamples(ctx contractapi.TransactionContextInterface, cutoff time.Time) (int, error) {
+	keys := []string{}
+	err := newAssetStore(ctx).Range(metricPrefix, metricPrefix+cutoff.UTC().Format(time.RFC3339), func(key string, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if budgetExceeded(err) && len(keys) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if err := newAssetStore(ctx).Delete(key); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
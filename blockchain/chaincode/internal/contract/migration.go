@@ -0,0 +1,320 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/chaincode/internal/store"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultMigrationBatchSize is the number of keys visited per invocation
+// unless a batch size is passed or migration.batchSize is configured
+const defaultMigrationBatchSize = 200
+
+// quantityScale is the precision quantities are stored with (grams when the
+// unit is kilograms)
+const quantityScale = 1000
+
+// migration is one registered schema upgrade: apply is called with each key
+// under the prefixes and its raw record, in key order, writes the record back
+// itself when it needs upgrading and reports whether it did
+type migration struct {
+	version     int
+	name        string
+	description string
+	prefixes    []string
+	apply       func(s *SmartContract, ctx contractapi.TransactionContextInterface, key string, value []byte) (bool, error)
+}
+
+// migrations is the registry, in version order; a migration only runs once
+// every earlier one is complete. Prefixes are listed in key order.
+var migrations = []migration{
+	{
+		version:     1,
+		name:        "waste-version-backfill",
+		description: "Give waste lots written before versioning a version and an updatedAt timestamp",
+		prefixes:    []string{"WASTE_"},
+		apply:       backfillWasteVersion,
+	},
+	{
+		version:     2,
+		name:        "quantity-decimals",
+		description: "Round stored quantities to three decimals, dropping floating-point residue",
+		prefixes:    []string{"EXTRACTION_", "RECYCLING_", "WASTE_"},
+		apply:       roundStoredQuantities,
+	},
+	{
+		version:     3,
+		name:        "waste-link-index",
+		description: "Index extractions and recyclings recorded before the waste link index under their lots",
+		prefixes:    []string{"EXTRACTION_", "RECYCLING_"},
+		apply:       backfillWasteLinks,
+	},
+}
+
+// RunMigration advances a registered migration by one batch of keys, starting
+// after bookmark, or after the recorded progress when bookmark is empty. A
+// batchSize of 0 uses migration.batchSize (default 200). Call it until the
+// returned migration is COMPLETE. Admin only.
+func (s *SmartContract) RunMigration(ctx contractapi.TransactionContextInterface, version int, batchSize int, bookmark string) (*models.Migration, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var registered *migration
+	for i := range migrations {
+		if migrations[i].version == version {
+			registered = &migrations[i]
+			break
+		}
+		previous, err := loadMigration(ctx, &migrations[i])
+		if err != nil {
+			return nil, err
+		}
+		if previous.Status != models.MigrationComplete {
+			return nil, newError(ctx, ErrMigrationOrder, previous.Version, version)
+		}
+	}
+	if registered == nil {
+		return nil, newError(ctx, ErrMigrationNotRegistered, version)
+	}
+
+	record, err := loadMigration(ctx, registered)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == models.MigrationComplete {
+		return record, nil
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == models.MigrationPending {
+		startedBy, err := callerID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		record.Status = models.MigrationInProgress
+		record.StartedAt = now
+		record.StartedBy = startedBy
+	}
+	if bookmark != "" {
+		record.Bookmark = bookmark
+	}
+
+	if batchSize <= 0 {
+		batchSize = configInt(ctx, "migration", "batchSize", defaultMigrationBatchSize)
+	}
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+
+	visited := 0
+	exhausted := true
+	for _, prefix := range registered.prefixes {
+		start, end := prefix, prefix+"~"
+		if record.Bookmark >= end {
+			continue
+		}
+		if record.Bookmark >= start {
+			start = record.Bookmark + "\x00"
+		}
+
+		visitedBefore := visited
+		err := newAssetStore(ctx).Range(start, end, func(key string, value []byte) error {
+			if visited == batchSize {
+				exhausted = false
+				return store.ErrStopRange
+			}
+			migrated, err := registered.apply(s, ctx, key, value)
+			if err != nil {
+				return newError(ctx, ErrMigrationFailed, version, key, err)
+			}
+			if migrated {
+				record.Migrated++
+			}
+			visited++
+			record.Bookmark = key
+
+			return nil
+		})
+		// A batch larger than the query budget ends where the budget did
+		if budgetExceeded(err) && visited > visitedBefore {
+			exhausted = false
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !exhausted {
+			break
+		}
+	}
+
+	record.Batches++
+	record.Scanned += visited
+	record.UpdatedAt = now
+	if exhausted {
+		record.Status = models.MigrationComplete
+		record.Bookmark = ""
+		record.CompletedAt = now
+	}
+
+	if err := newAssetStore(ctx).Put(migrationKey(version), record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// GetMigrations returns every registered migration with its progress, in
+// version order
+func (s *SmartContract) GetMigrations(ctx contractapi.TransactionContextInterface) ([]*models.Migration, error) {
+	records := make([]*models.Migration, 0, len(migrations))
+	for i := range migrations {
+		record, err := loadMigration(ctx, &migrations[i])
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// loadMigration returns the recorded progress of a migration, or a PENDING
+// record when it has never run
+func loadMigration(ctx contractapi.TransactionContextInterface, registered *migration) (*models.Migration, error) {
+	record := &models.Migration{}
+	found, err := newAssetStore(ctx).Get(migrationKey(registered.version), record)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, migrationKey(registered.version), err)
+	}
+	if !found {
+		record = &models.Migration{Version: registered.version, Status: models.MigrationPending}
+	}
+	record.Name = registered.name
+	record.Description = registered.description
+
+	return record, nil
+}
+
+func migrationKey(version int) string {
+	return fmt.Sprintf("MIGRATION_%04d", version)
+}
+
+// backfillWasteVersion rewrites lots stored with version 0, which putWaste
+// bumps to 1, and fills a missing updatedAt from createdAt
+func backfillWasteVersion(s *SmartContract, ctx contractapi.TransactionContextInterface, _ string, value []byte) (bool, error) {
+	var waste models.Waste
+	if err := json.Unmarshal(value, &waste); err != nil {
+		return false, err
+	}
+	if waste.Version > 0 && waste.UpdatedAt != "" {
+		return false, nil
+	}
+
+	if waste.UpdatedAt == "" {
+		waste.UpdatedAt = waste.CreatedAt
+	}
+
+	return true, s.putWaste(ctx, &waste)
+}
+
+// roundStoredQuantities rounds the quantities of wastes, extractions and
+// recyclings to quantityScale
+func roundStoredQuantities(s *SmartContract, ctx contractapi.TransactionContextInterface, key string, value []byte) (bool, error) {
+	switch {
+	case strings.HasPrefix(key, "WASTE_"):
+		var waste models.Waste
+		if err := json.Unmarshal(value, &waste); err != nil {
+			return false, err
+		}
+		quantity, consumed := roundQuantity(waste.Quantity), roundQuantity(waste.Consumed)
+		if quantity == waste.Quantity && consumed == waste.Consumed {
+			return false, nil
+		}
+		waste.Quantity, waste.Consumed = quantity, consumed
+
+		return true, s.putWaste(ctx, &waste)
+	case strings.HasPrefix(key, "RECYCLING_"):
+		var recycling models.Recycling
+		if err := json.Unmarshal(value, &recycling); err != nil {
+			return false, err
+		}
+		quantity := roundQuantity(recycling.Quantity)
+		if quantity == recycling.Quantity {
+			return false, nil
+		}
+		recycling.Quantity = quantity
+
+		return true, s.putRecycling(ctx, &recycling)
+	default:
+		var extraction models.Extraction
+		if err := json.Unmarshal(value, &extraction); err != nil {
+			return false, err
+		}
+		quantity := roundQuantity(extraction.Quantity)
+		if quantity == extraction.Quantity {
+			return false, nil
+		}
+		extraction.Quantity = quantity
+
+		return true, s.putExtraction(ctx, &extraction)
+	}
+}
+
+// backfillWasteLinks writes the waste link index entries of an extraction
+// or recycling that has none
+func backfillWasteLinks(s *SmartContract, ctx contractapi.TransactionContextInterface, key string, value []byte) (bool, error) {
+	var assetType, id string
+	var wasteIDs []string
+	if strings.HasPrefix(key, "RECYCLING_") {
+		var recycling models.Recycling
+		if err := json.Unmarshal(value, &recycling); err != nil {
+			return false, err
+		}
+		assetType, id = "RECYCLING", recycling.ID
+		for _, input := range recycling.InputLots() {
+			wasteIDs = append(wasteIDs, input.WasteID)
+		}
+	} else {
+		var extraction models.Extraction
+		if err := json.Unmarshal(value, &extraction); err != nil {
+			return false, err
+		}
+		assetType, id = "EXTRACTION", extraction.ID
+		wasteIDs = []string{extraction.WasteID}
+	}
+
+	written := false
+	for _, wasteID := range wasteIDs {
+		indexKey, err := ctx.GetStub().CreateCompositeKey(wasteLinkIndex, []string{wasteID, assetType, id})
+		if err != nil {
+			return false, err
+		}
+		existing, err := ctx.GetStub().GetState(indexKey)
+		if err != nil {
+			return false, err
+		}
+		if existing != nil {
+			continue
+		}
+		if err := ctx.GetStub().PutState(indexKey, []byte{0x00}); err != nil {
+			return false, err
+		}
+		written = true
+	}
+
+	return written, nil
+}
+
+func roundQuantity(quantity float64) float64 {
+	return math.Round(quantity*quantityScale) / quantityScale
+}
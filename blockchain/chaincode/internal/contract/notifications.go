@@ -0,0 +1,213 @@
+package contract
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/chaincode/internal/store"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultNotificationPageSize is used when GetMyNotifications gets no page size
+const defaultNotificationPageSize = 20
+
+// GetMyNotifications returns a page of the caller organization's inbox, oldest
+// first; pass the returned bookmark to get the next page
+func (s *SmartContract) GetMyNotifications(ctx contractapi.TransactionContextInterface, unreadOnly bool, pageSize int, bookmark string) (*models.NotificationPage, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if pageSize <= 0 {
+		pageSize = defaultNotificationPageSize
+	}
+
+	prefix := notificationPrefix(mspID)
+	start := prefix
+	if bookmark != "" {
+		start = prefix + bookmark
+	}
+
+	page := &models.NotificationPage{Notifications: []*models.Notification{}}
+	scanned := ""
+	err = newAssetStore(ctx).Range(start, prefix+"~", func(_ string, value []byte) error {
+		var notification models.Notification
+		if err := json.Unmarshal(value, &notification); err != nil {
+			return err
+		}
+		if notification.ID == bookmark || (unreadOnly && notification.Read) {
+			scanned = notification.ID
+			return nil
+		}
+		if len(page.Notifications) == pageSize {
+			page.Bookmark = page.Notifications[pageSize-1].ID
+			return store.ErrStopRange
+		}
+		page.Notifications = append(page.Notifications, &notification)
+		scanned = notification.ID
+
+		return nil
+	})
+	// Read notifications skipped by unreadOnly count in the budget too, so
+	// a truncated page resumes after the last one scanned
+	if budgetExceeded(err) && scanned != "" && scanned != bookmark {
+		page.Bookmark = scanned
+		page.Truncated = len(page.Notifications) < pageSize
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// MarkNotificationsRead marks the given comma-separated notifications of the
+// caller's organization as read (all of them when ids is empty) and returns
+// how many changed
+func (s *SmartContract) MarkNotificationsRead(ctx contractapi.TransactionContextInterface, ids string) (int, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var notifications []*models.Notification
+	if ids == "" {
+		if notifications, err = loadNotifications(ctx, notificationPrefix(mspID)); err != nil {
+			return 0, err
+		}
+	} else {
+		for _, id := range splitList(ids) {
+			notification, err := readNotification(ctx, mspID, id)
+			if err != nil {
+				return 0, err
+			}
+			notifications = append(notifications, notification)
+		}
+	}
+
+	marked := 0
+	for _, notification := range notifications {
+		if notification.Read {
+			continue
+		}
+		notification.Read = true
+		notification.ReadAt = now
+		if err := putNotification(ctx, notification); err != nil {
+			return 0, err
+		}
+		marked++
+	}
+
+	return marked, nil
+}
+
+// notify queues a notification for an organization; organizations are not
+// notified of their own actions, but the notice is still emitted with the
+// transaction's LedgerChanged event for off-chain alerting
+func notify(ctx contractapi.TransactionContextInterface, recipient string, kind string, subject string, message string) error {
+	if recipient == "" {
+		return nil
+	}
+	if err := recordNotice(ctx, kind, subject, message, recipient); err != nil {
+		return err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if recipient == mspID {
+		return nil
+	}
+
+	id, err := newAssetID(ctx, "NOTIF")
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	return putNotification(ctx, &models.Notification{
+		ID:        id,
+		Recipient: recipient,
+		Kind:      kind,
+		Subject:   subject,
+		Message:   message,
+		CreatedAt: now,
+	})
+}
+
+// pruneNotifications deletes the notifications created before the cutoff
+// on the next page of the maintenance scan and returns how many it removed
+func pruneNotifications(ctx contractapi.TransactionContextInterface, run *maintenanceRun, cutoff time.Time) (int, error) {
+	keys := []string{}
+	err := run.scan(ctx, "notifications", "NOTIFICATION_", func(key string, value []byte) error {
+		var notification models.Notification
+		if err := json.Unmarshal(value, &notification); err != nil {
+			return err
+		}
+		created, err := time.Parse(time.RFC3339, notification.CreatedAt)
+		if err == nil && created.Before(cutoff) {
+			keys = append(keys, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if err := newAssetStore(ctx).Delete(key); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+func notificationPrefix(recipient string) string {
+	return "NOTIFICATION_" + recipient + "_"
+}
+
+func readNotification(ctx contractapi.TransactionContextInterface, recipient string, id string) (*models.Notification, error) {
+	var notification models.Notification
+	found, err := newAssetStore(ctx).Get(notificationPrefix(recipient)+id, &notification)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, notificationPrefix(recipient)+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrNotificationNotFound, id)
+	}
+
+	return &notification, nil
+}
+
+func putNotification(ctx contractapi.TransactionContextInterface, notification *models.Notification) error {
+	return newAssetStore(ctx).Put(notificationPrefix(notification.Recipient)+notification.ID, notification)
+}
+
+func loadNotifications(ctx contractapi.TransactionContextInterface, prefix string) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+	err := newAssetStore(ctx).Range(prefix, prefix+"~", func(_ string, value []byte) error {
+		var notification models.Notification
+		if err := json.Unmarshal(value, &notification); err != nil {
+			return err
+		}
+		notifications = append(notifications, &notification)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
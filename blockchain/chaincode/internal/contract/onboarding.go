@@ -0,0 +1,255 @@
+package contract
+
+import (
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// requiredOnboardingSteps returns the steps a participant must have verified
+// before activation, in the order they are verified (onboarding.requiredSteps,
+// comma-separated; all known steps by default)
+func requiredOnboardingSteps(ctx contractapi.TransactionContextInterface) []string {
+	configured := splitList(configString(ctx, "onboarding", "requiredSteps", strings.Join(models.OnboardingSteps, ",")))
+	steps := []string{}
+	for _, step := range configured {
+		step = strings.ToUpper(step)
+		if isOnboardingStep(step) {
+			steps = append(steps, step)
+		}
+	}
+
+	return steps
+}
+
+// RecordOnboardingStep records that a step of a participant's onboarding
+// was verified; evidenceHash is the SHA-256 of what the verifier reviewed,
+// which stays off chain, and note what the step settled (such as the role
+// assigned). Required steps are verified in order: a step is refused while
+// an earlier required step is not verified. The participant's organization
+// records its steps; when participantId is empty the participant is the
+// owner named in the "pii" transient entry, registered on first use.
+func (s *SmartContract) RecordOnboardingStep(ctx contractapi.TransactionContextInterface, participantId string, step string, evidenceHash string, note string) (*models.ParticipantOnboarding, error) {
+	step = strings.ToUpper(strings.TrimSpace(step))
+	if !isOnboardingStep(step) {
+		return nil, newError(ctx, ErrOnboardingStepUnknown, step, strings.Join(models.OnboardingSteps, ", "))
+	}
+	if !commentHashPattern.MatchString(evidenceHash) {
+		return nil, newError(ctx, ErrEvidenceHashInvalid)
+	}
+
+	participant, _, err := managedParticipant(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	onboarding, err := readParticipantOnboarding(ctx, participant.ID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if onboarding == nil {
+		onboarding = &models.ParticipantOnboarding{
+			ParticipantID: participant.ID,
+			MSP:           participant.MSP,
+			Status:        models.OnboardingInProgress,
+			Steps:         []models.OnboardingStep{},
+			CreatedAt:     now,
+			History:       []models.History{},
+		}
+	}
+
+	for _, required := range requiredOnboardingSteps(ctx) {
+		if required == step {
+			break
+		}
+		if !onboarding.StepVerified(required) {
+			return nil, newError(ctx, ErrOnboardingStepOrder, required, step)
+		}
+	}
+
+	verified := models.OnboardingStep{
+		Step:         step,
+		EvidenceHash: evidenceHash,
+		Note:         strings.TrimSpace(note),
+		VerifiedBy:   actor,
+		VerifiedAt:   now,
+	}
+	action := "ONBOARDING_STEP_VERIFIED"
+	replaced := false
+	for i := range onboarding.Steps {
+		if onboarding.Steps[i].Step == step {
+			onboarding.Steps[i] = verified
+			action = "ONBOARDING_STEP_REVERIFIED"
+			replaced = true
+		}
+	}
+	if !replaced {
+		onboarding.Steps = append(onboarding.Steps, verified)
+	}
+	details := step
+	if verified.Note != "" {
+		details += ": " + verified.Note
+	}
+	onboarding.UpdatedAt = now
+	onboarding.History = append(onboarding.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   details,
+	})
+
+	if err := putParticipantOnboarding(ctx, onboarding); err != nil {
+		return nil, err
+	}
+
+	return onboarding, nil
+}
+
+// ActivateParticipant activates a participant once every required
+// onboarding step is verified; it fails with ONBOARDING_INCOMPLETE naming
+// the steps still missing otherwise. The participant's organization
+// activates its participants. With onboarding.requireActivation set, no lot
+// can be created for a participant whose onboarding is under way.
+func (s *SmartContract) ActivateParticipant(ctx contractapi.TransactionContextInterface, participantId string) (*models.ParticipantOnboarding, error) {
+	if strings.TrimSpace(participantId) == "" {
+		return nil, newError(ctx, ErrParticipantIDRequired)
+	}
+	participant, _, err := managedParticipant(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	onboarding, err := readParticipantOnboarding(ctx, participant.ID)
+	if err != nil {
+		return nil, err
+	}
+	if onboarding == nil {
+		onboarding = &models.ParticipantOnboarding{ParticipantID: participant.ID, Steps: []models.OnboardingStep{}}
+	}
+	if onboarding.Status == models.OnboardingActive {
+		return nil, newError(ctx, ErrParticipantAlreadyActive, participant.ID)
+	}
+	if missing := missingOnboardingSteps(ctx, onboarding); len(missing) > 0 {
+		return nil, newError(ctx, ErrOnboardingIncomplete, participant.ID, strings.Join(missing, ", "))
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	onboarding.Status = models.OnboardingActive
+	onboarding.ActivatedBy = actor
+	onboarding.ActivatedAt = now
+	onboarding.UpdatedAt = now
+	onboarding.History = append(onboarding.History, models.History{
+		Timestamp: now,
+		Action:    "PARTICIPANT_ACTIVATED",
+		Actor:     actor,
+	})
+
+	if err := putParticipantOnboarding(ctx, onboarding); err != nil {
+		return nil, err
+	}
+
+	return onboarding, nil
+}
+
+// GetParticipantOnboarding returns the onboarding record of a participant
+func (s *SmartContract) GetParticipantOnboarding(ctx contractapi.TransactionContextInterface, participantId string) (*models.ParticipantOnboarding, error) {
+	onboarding, err := readParticipantOnboarding(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	if onboarding == nil {
+		return nil, newError(ctx, ErrOnboardingNotFound, participantId)
+	}
+
+	return onboarding, nil
+}
+
+// GetMissingOnboardingSteps returns the required steps a participant has
+// not had verified yet, in order; empty once it may be activated
+func (s *SmartContract) GetMissingOnboardingSteps(ctx contractapi.TransactionContextInterface, participantId string) ([]string, error) {
+	onboarding, err := readParticipantOnboarding(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	if onboarding == nil {
+		return requiredOnboardingSteps(ctx), nil
+	}
+
+	return missingOnboardingSteps(ctx, onboarding), nil
+}
+
+func missingOnboardingSteps(ctx contractapi.TransactionContextInterface, onboarding *models.ParticipantOnboarding) []string {
+	missing := []string{}
+	for _, step := range requiredOnboardingSteps(ctx) {
+		if !onboarding.StepVerified(step) {
+			missing = append(missing, step)
+		}
+	}
+
+	return missing
+}
+
+func isOnboardingStep(step string) bool {
+	for _, known := range models.OnboardingSteps {
+		if known == step {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requireActiveOwner refuses a lot owner whose onboarding started but was
+// not activated, when onboarding.requireActivation is set; owners that never
+// started an onboarding are not held back
+func requireActiveOwner(ctx contractapi.TransactionContextInterface, mspID string, owner string) error {
+	if !configBool(ctx, "onboarding", "requireActivation", false) {
+		return nil
+	}
+	participantID, err := lookupParticipantID(ctx, mspID, owner)
+	if err != nil || participantID == "" {
+		return err
+	}
+	onboarding, err := readParticipantOnboarding(ctx, participantID)
+	if err != nil {
+		return err
+	}
+	if onboarding != nil && onboarding.Status != models.OnboardingActive {
+		return newError(ctx, ErrParticipantNotActive, participantID)
+	}
+
+	return nil
+}
+
+// readParticipantOnboarding returns a participant's onboarding, or nil if
+// it never started one
+func readParticipantOnboarding(ctx contractapi.TransactionContextInterface, participantID string) (*models.ParticipantOnboarding, error) {
+	var onboarding models.ParticipantOnboarding
+	found, err := newAssetStore(ctx).Get("ONBOARDING_"+participantID, &onboarding)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "ONBOARDING_"+participantID, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &onboarding, nil
+}
+
+func putParticipantOnboarding(ctx contractapi.TransactionContextInterface, onboarding *models.ParticipantOnboarding) error {
+	return newAssetStore(ctx).Put("ONBOARDING_"+onboarding.ParticipantID, onboarding)
+}
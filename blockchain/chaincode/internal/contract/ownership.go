@@ -0,0 +1,286 @@
+package contract
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// shareTolerance absorbs floating-point error when shares are summed
+const shareTolerance = 1e-6
+
+// SplitOwnership turns a solely owned lot into a co-owned one; shares must be
+// positive and total 100%. Only the owning organization or an admin may split.
+// A non-zero expectedVersion rejects the split if the lot has changed since
+// it was read.
+func (s *SmartContract) SplitOwnership(ctx contractapi.TransactionContextInterface, wasteId string, shares []models.OwnershipShare, expectedVersion int) (*models.Waste, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	if len(waste.Owners) > 0 {
+		return nil, newError(ctx, ErrWasteAlreadyCoowned, wasteId)
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrOwnershipSplitForbidden, waste.OwnerMSP, wasteId)
+	}
+
+	total := 0.0
+	seen := map[string]bool{}
+	for _, share := range shares {
+		if share.HolderID == "" || share.HolderMSP == "" {
+			return nil, newError(ctx, ErrShareHolderRequired)
+		}
+		if share.Percentage <= 0 {
+			return nil, newError(ctx, ErrShareNotPositive, share.HolderID)
+		}
+		if seen[share.HolderID] {
+			return nil, newError(ctx, ErrShareHolderDuplicate, share.HolderID)
+		}
+		seen[share.HolderID] = true
+		total += share.Percentage
+	}
+	if len(shares) < 2 {
+		return nil, newError(ctx, ErrCoownershipHoldersTooFew)
+	}
+	if math.Abs(total-100) > shareTolerance {
+		return nil, newError(ctx, ErrSharesTotalInvalid, total)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	waste.Owners = sortedShares(shares)
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "OWNERSHIP_SPLIT",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Split between %d holders", len(shares)),
+	})
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+	for _, share := range waste.Owners {
+		if err := notify(ctx, share.HolderMSP, models.NotifyShareReceived, "WASTE_"+wasteId, fmt.Sprintf("%.2f%% of waste %s assigned to %s", share.Percentage, wasteId, share.HolderID)); err != nil {
+			return nil, err
+		}
+	}
+
+	return waste, nil
+}
+
+// TransferShare moves part or all of the caller's share of a co-owned lot to
+// a buyer; the caller must be the selling holder. Transfers over a threshold
+// of the seller organization's approval matrix (see SetApprovalMatrix), or
+// of "approvals.transferThreshold" without one, wait in PENDING_APPROVAL
+// until the approvers sign them (see Approve) and the lot is returned
+// unchanged apart from its pending approval. Buyers owing the seller's
+// organization more than its credit limit are warned about or refused (see
+// SetCreditLimit). A non-zero expectedVersion rejects the transfer if the lot
+// has changed since it was read.
+func (s *SmartContract) TransferShare(ctx contractapi.TransactionContextInterface, wasteId string, percentage float64, buyerId string, buyerMsp string, expectedVersion int) (*models.Waste, error) {
+	if percentage <= 0 {
+		return nil, newError(ctx, ErrTransferPercentageInvalid)
+	}
+	if buyerId == "" || buyerMsp == "" {
+		return nil, newError(ctx, ErrBuyerIdentityRequired)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	if waste.PendingApprovalID != "" {
+		return nil, newError(ctx, ErrWasteTransferPending, wasteId, waste.PendingApprovalID)
+	}
+	if waste.PendingSettlementID != "" {
+		return nil, newError(ctx, ErrWasteLocked, wasteId, waste.PendingSettlementID)
+	}
+	seller, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transfer := &models.ShareTransfer{
+		SellerID:   seller,
+		Percentage: percentage,
+		Quantity:   waste.Quantity * percentage / 100,
+		BuyerID:    buyerId,
+		BuyerMSP:   buyerMsp,
+	}
+	if err := checkShareTransfer(ctx, waste, transfer); err != nil {
+		return nil, err
+	}
+	sellerMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exposure, allowed, err := creditAllows(ctx, sellerMSP, buyerMsp, 0, "WASTE_"+wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, creditRefusal(ctx, exposure)
+	}
+
+	rule, version, err := transferApprovalRule(ctx, sellerMSP, transfer.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		if err := s.requestTransferApproval(ctx, waste, transfer, rule, version); err != nil {
+			return nil, err
+		}
+		return waste, nil
+	}
+
+	if err := s.applyShareTransfer(ctx, waste, transfer, seller); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// checkShareTransfer verifies that the lot is still on the network and that
+// the seller holds the share to transfer
+func checkShareTransfer(ctx contractapi.TransactionContextInterface, waste *models.Waste, transfer *models.ShareTransfer) error {
+	if waste.Status == models.WasteExported {
+		return newError(ctx, ErrWasteHandedOff, waste.ID, waste.ExportHandoffID)
+	}
+	if transfer.SellerID == transfer.BuyerID {
+		return newError(ctx, ErrTransferSameParty)
+	}
+	for _, share := range waste.Owners {
+		if share.HolderID != transfer.SellerID {
+			continue
+		}
+		if transfer.Percentage > share.Percentage+shareTolerance {
+			return newError(ctx, ErrShareInsufficient, transfer.SellerID, share.Percentage, waste.ID)
+		}
+		return nil
+	}
+
+	return newError(ctx, ErrShareNotHeld, transfer.SellerID, waste.ID)
+}
+
+// applyShareTransfer moves the share, writes the lot and notifies the buyer;
+// actor is recorded as having made the transfer
+func (s *SmartContract) applyShareTransfer(ctx contractapi.TransactionContextInterface, waste *models.Waste, transfer *models.ShareTransfer, actor string) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	seller, buyerId, percentage := transfer.SellerID, transfer.BuyerID, transfer.Percentage
+	owners := make([]models.OwnershipShare, 0, len(waste.Owners)+1)
+	bought := false
+	for _, share := range waste.Owners {
+		switch share.HolderID {
+		case seller:
+			share.Percentage -= percentage
+			if share.Percentage <= shareTolerance {
+				continue
+			}
+		case buyerId:
+			share.Percentage += percentage
+			bought = true
+		}
+		owners = append(owners, share)
+	}
+	if !bought {
+		owners = append(owners, models.OwnershipShare{HolderID: buyerId, HolderMSP: transfer.BuyerMSP, Percentage: percentage})
+	}
+
+	waste.Owners = sortedShares(owners)
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "SHARE_TRANSFERRED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%.4f%% transferred to %s (%s)", percentage, buyerId, transfer.BuyerMSP),
+	})
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return err
+	}
+	if err := recordValuation(ctx, waste, models.ValuationTransferred, waste.Quantity*percentage/100, 0, fmt.Sprintf("%.4f%% to %s", percentage, transfer.BuyerMSP)); err != nil {
+		return err
+	}
+
+	return notify(ctx, transfer.BuyerMSP, models.NotifyShareReceived, "WASTE_"+waste.ID, fmt.Sprintf("%.2f%% of waste %s transferred to %s", percentage, waste.ID, buyerId))
+}
+
+// GetSettlementSplit divides a payment for a lot among its holders in
+// proportion to their shares, rounded to cents with the remainder going to
+// the largest holder; solely owned lots settle entirely to the owning
+// organization. This is the hook payment and marketplace flows settle through.
+func (s *SmartContract) GetSettlementSplit(ctx contractapi.TransactionContextInterface, wasteId string, amount float64) ([]*models.SettlementLine, error) {
+	if amount < 0 {
+		return nil, newError(ctx, ErrAmountNegative)
+	}
+	waste, err := s.ReadWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+
+	return settlementSplit(waste, amount), nil
+}
+
+func settlementSplit(waste *models.Waste, amount float64) []*models.SettlementLine {
+	shares := waste.Owners
+	if len(shares) == 0 {
+		shares = []models.OwnershipShare{{HolderID: waste.Owner, HolderMSP: waste.OwnerMSP, Percentage: 100}}
+	}
+
+	lines := make([]*models.SettlementLine, len(shares))
+	allocated := 0.0
+	largest := 0
+	for i, share := range shares {
+		lines[i] = &models.SettlementLine{
+			HolderID:   share.HolderID,
+			HolderMSP:  share.HolderMSP,
+			Percentage: share.Percentage,
+			Amount:     math.Floor(amount*share.Percentage) / 100,
+		}
+		allocated += lines[i].Amount
+		if share.Percentage > shares[largest].Percentage {
+			largest = i
+		}
+	}
+	lines[largest].Amount = math.Round((lines[largest].Amount+amount-allocated)*100) / 100
+
+	return lines
+}
+
+// sortedShares orders shares by decreasing percentage, then holder ID
+func sortedShares(shares []models.OwnershipShare) []models.OwnershipShare {
+	sorted := append([]models.OwnershipShare(nil), shares...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Percentage != sorted[j].Percentage {
+			return sorted[i].Percentage > sorted[j].Percentage
+		}
+		return sorted[i].HolderID < sorted[j].HolderID
+	})
+
+	return sorted
+}
@@ -0,0 +1,454 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	intakePrefix = "INTAKE_"
+
+	// Days a shipment takes from departure to its destination when planning
+	defaultPlanningTransitDays = 1
+	// Longest period an intake forecast covers
+	defaultPlanningMaxDays = 92
+)
+
+// ReserveIntake books quantity of a facility's intake on a date for a
+// delivery to come, optionally of a known lot or under a supply contract of
+// the facility's operator; the ID is generated when id is empty. The
+// facility operator is notified. Reservations may exceed the facility's
+// capacity: the forecast shows the day as overbooked.
+func (s *SmartContract) ReserveIntake(ctx contractapi.TransactionContextInterface, id string, facilityId string, date string, quantity float64, wasteId string, contractId string, notes string) (*models.IntakeReservation, error) {
+	if quantity <= 0 {
+		return nil, newError(ctx, ErrQuantityInvalid)
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, newError(ctx, ErrDateInvalid, date)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if date < now[:len("2006-01-02")] {
+		return nil, newError(ctx, ErrIntakeDatePast, date)
+	}
+
+	facility, err := s.ReadFacility(ctx, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	if facility.Status != models.FacilityActive || facility.Type == models.FacilityLandfill {
+		return nil, newError(ctx, ErrIntakeReservationsUnsupported, facilityId)
+	}
+
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if wasteId != "" {
+		if _, err := s.readWaste(ctx, wasteId); err != nil {
+			return nil, err
+		}
+	}
+	if contractId != "" {
+		contract, err := s.ReadSupplyContract(ctx, contractId)
+		if err != nil {
+			return nil, err
+		}
+		if contract.Status != models.SupplyActive {
+			return nil, newError(ctx, ErrSupplyContractStatusInvalid, contractId, contract.Status)
+		}
+		if contract.Buyer != facility.Operator {
+			return nil, newError(ctx, ErrSupplyContractPartyMismatch, contractId, facility.Operator, facilityId)
+		}
+		if mspID != contract.Supplier && mspID != contract.Buyer {
+			return nil, newError(ctx, ErrIntakeReserveForbidden, contractId)
+		}
+	}
+
+	if id == "" {
+		if id, err = newAssetID(ctx, "INTAKE"); err != nil {
+			return nil, err
+		}
+	}
+	exists, err := newAssetStore(ctx).Exists(intakePrefix + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrIntakeReservationAlreadyExists, id)
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := &models.IntakeReservation{
+		ID:          id,
+		FacilityID:  facilityId,
+		Date:        date,
+		Quantity:    quantity,
+		WasteID:     wasteId,
+		ContractID:  contractId,
+		Notes:       notes,
+		ReservedBy:  actor,
+		ReservedMSP: mspID,
+		Status:      models.IntakeReserved,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		History: []models.History{{
+			Timestamp: now,
+			Action:    "RESERVED",
+			Actor:     actor,
+			Details:   fmt.Sprintf("%.2f units at facility %s on %s", quantity, facilityId, date),
+		}},
+	}
+	if err := putIntakeReservation(ctx, reservation); err != nil {
+		return nil, err
+	}
+
+	if facility.Operator != mspID {
+		message := fmt.Sprintf("%s reserved %.2f units of intake at %s on %s", mspID, quantity, facility.Name, date)
+		if err := notify(ctx, facility.Operator, models.NotifyIntakeReserved, intakePrefix+id, message); err != nil {
+			return nil, err
+		}
+	}
+
+	return reservation, nil
+}
+
+// CancelIntakeReservation releases a reservation; the organization that
+// made it, the facility operator or an admin may cancel it
+func (s *SmartContract) CancelIntakeReservation(ctx contractapi.TransactionContextInterface, id string, reason string) (*models.IntakeReservation, error) {
+	reservation, err := s.ReadIntakeReservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status != models.IntakeReserved {
+		return nil, newError(ctx, ErrIntakeReservationStatusUnchanged, id, reservation.Status)
+	}
+	facility, err := s.ReadFacility(ctx, reservation.FacilityID)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != reservation.ReservedMSP && mspID != facility.Operator && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrIntakeCancelForbidden, reservation.ReservedMSP, facility.Operator, id)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reservation.Status = models.IntakeCancelled
+	reservation.UpdatedAt = now
+	reservation.History = append(reservation.History, models.History{
+		Timestamp: now,
+		Action:    "CANCELLED",
+		Actor:     actor,
+		Details:   reason,
+	})
+	if err := putIntakeReservation(ctx, reservation); err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// ReadIntakeReservation returns the intake reservation stored with the given id
+func (s *SmartContract) ReadIntakeReservation(ctx contractapi.TransactionContextInterface, id string) (*models.IntakeReservation, error) {
+	var reservation models.IntakeReservation
+	found, err := newAssetStore(ctx).Get(intakePrefix+id, &reservation)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, intakePrefix+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrIntakeReservationNotFound, id)
+	}
+
+	return &reservation, nil
+}
+
+// GetIntakeReservations returns the reservations of a facility (all of them
+// when facilityId is empty) dated between from and to (YYYY-MM-DD,
+// inclusive, either may be empty), by date. Organizations see the
+// reservations they made and those at facilities they operate.
+func (s *SmartContract) GetIntakeReservations(ctx contractapi.TransactionContextInterface, facilityId string, from string, to string) ([]*models.IntakeReservation, error) {
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	operated, err := s.operatedFacilities(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+
+	admin := isAdmin(ctx)
+	reservations, err := loadIntakeReservations(ctx, func(reservation *models.IntakeReservation) bool {
+		return (facilityId == "" || reservation.FacilityID == facilityId) &&
+			(from == "" || reservation.Date >= from) && (to == "" || reservation.Date <= to) &&
+			(admin || reservation.ReservedMSP == mspID || operated[reservation.FacilityID])
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(reservations, func(i, j int) bool { return reservations[i].Date < reservations[j].Date })
+
+	return reservations, nil
+}
+
+// GetIntakeForecast returns, for each day between from and to (YYYY-MM-DD,
+// inclusive; from defaults to today and to to a week later), what the
+// caller's facilities (one of them with facilityId, every facility for
+// admins) expect to receive: reserved intake, shipments in transit to the
+// facility (matched by ID or name) due that day, planning.transitDays
+// (default 1) after departure, and the volume supply contracts of the
+// operator still await, spread evenly over the days left until each
+// contract ends and across the operator's facilities. Contract volume
+// already reserved is not counted twice.
+func (s *SmartContract) GetIntakeForecast(ctx contractapi.TransactionContextInterface, facilityId string, from string, to string) (*models.IntakeForecast, error) {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today, _ := time.Parse("2006-01-02", now[:len("2006-01-02")])
+	start, end, err := planningPeriod(ctx, today, from, to)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+
+	all, err := s.GetAllFacilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Facilities that take intake, by operator, and those being planned
+	byOperator := map[string][]*models.Facility{}
+	planned := map[string]*models.Facility{}
+	for _, facility := range all {
+		if facility.Status != models.FacilityActive || facility.Type == models.FacilityLandfill {
+			continue
+		}
+		byOperator[facility.Operator] = append(byOperator[facility.Operator], facility)
+		if (facilityId == "" || facility.ID == facilityId) && (admin || facility.Operator == mspID) {
+			planned[facility.ID] = facility
+		}
+	}
+	if facilityId != "" && planned[facilityId] == nil {
+		facility, err := s.ReadFacility(ctx, facilityId)
+		if err != nil {
+			return nil, err
+		}
+		if !admin && facility.Operator != mspID {
+			return nil, newError(ctx, ErrIntakePlanForbidden, facility.Operator, facilityId)
+		}
+		return nil, newError(ctx, ErrIntakeUnsupported, facilityId)
+	}
+
+	forecast := &models.IntakeForecast{From: start.Format("2006-01-02"), To: end.Format("2006-01-02"), Days: []*models.IntakeForecastDay{}}
+	days := map[string]*models.IntakeForecastDay{}
+	for _, facility := range planned {
+		capacity, err := s.facilityCapacity(ctx, facility)
+		if err != nil {
+			return nil, err
+		}
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			entry := &models.IntakeForecastDay{FacilityID: facility.ID, FacilityName: facility.Name, Date: date, Capacity: capacity}
+			days[facility.ID+"|"+date] = entry
+			forecast.Days = append(forecast.Days, entry)
+		}
+	}
+
+	// Reservations from today on; those under a contract reduce the volume
+	// the contract is still expected to bring
+	reservedUnder := map[string]float64{}
+	reservations, err := loadIntakeReservations(ctx, func(reservation *models.IntakeReservation) bool {
+		return reservation.Status == models.IntakeReserved && reservation.Date >= now[:len("2006-01-02")]
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, reservation := range reservations {
+		if reservation.ContractID != "" {
+			reservedUnder[reservation.ContractID] += reservation.Quantity
+		}
+		if entry := days[reservation.FacilityID+"|"+reservation.Date]; entry != nil {
+			entry.Reserved += reservation.Quantity
+			entry.Reservations++
+		}
+	}
+
+	// Shipments overdue are expected on the first day planned
+	transitDays := configInt(ctx, "planning", "transitDays", defaultPlanningTransitDays)
+	shipments, err := loadShipments(ctx, func(shipment *models.Shipment) bool {
+		return shipment.Status == models.ShipmentInTransit
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, shipment := range shipments {
+		departure, err := time.Parse("2006-01-02", shipment.DepartureDate)
+		if err != nil {
+			continue
+		}
+		due := departure.AddDate(0, 0, transitDays)
+		if due.Before(start) {
+			due = start
+		}
+		for _, facility := range planned {
+			if shipment.Destination != facility.ID && !strings.EqualFold(shipment.Destination, facility.Name) {
+				continue
+			}
+			if entry := days[facility.ID+"|"+due.Format("2006-01-02")]; entry != nil {
+				entry.InTransit += shipment.Quantity
+				entry.Shipments++
+			}
+			break
+		}
+	}
+
+	contracts, err := loadSupplyContracts(ctx, func(contract *models.SupplyContract) bool {
+		return contract.Status == models.SupplyActive
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, contract := range contracts {
+		facilities := byOperator[contract.Buyer]
+		awaited := contract.CommittedVolume - contract.FulfilledVolume - reservedUnder[contract.ID]
+		contractStart, errStart := time.Parse("2006-01-02", contract.StartDate)
+		contractEnd, errEnd := time.Parse("2006-01-02", contract.EndDate)
+		if len(facilities) == 0 || awaited <= 0 || errStart != nil || errEnd != nil || contractEnd.Before(today) {
+			continue
+		}
+		if contractStart.Before(today) {
+			contractStart = today
+		}
+		daily := awaited / (contractEnd.Sub(contractStart).Hours()/24 + 1) / float64(len(facilities))
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			if day.Before(contractStart) || day.After(contractEnd) {
+				continue
+			}
+			for _, facility := range facilities {
+				if entry := days[facility.ID+"|"+day.Format("2006-01-02")]; entry != nil {
+					entry.Committed += daily
+				}
+			}
+		}
+	}
+
+	for _, entry := range forecast.Days {
+		entry.Reserved = roundQuantity(entry.Reserved)
+		entry.InTransit = roundQuantity(entry.InTransit)
+		entry.Committed = roundQuantity(entry.Committed)
+		entry.Expected = roundQuantity(entry.Reserved + entry.InTransit + entry.Committed)
+		entry.Remaining = roundQuantity(entry.Capacity - entry.Expected)
+		entry.Overbooked = entry.Expected > entry.Capacity
+	}
+	sort.Slice(forecast.Days, func(i, j int) bool {
+		if forecast.Days[i].FacilityID != forecast.Days[j].FacilityID {
+			return forecast.Days[i].FacilityID < forecast.Days[j].FacilityID
+		}
+		return forecast.Days[i].Date < forecast.Days[j].Date
+	})
+
+	return forecast, nil
+}
+
+// planningPeriod parses the period of a forecast, from today for a week by
+// default and at most planning.maxDays (default 92) long
+func planningPeriod(ctx contractapi.TransactionContextInterface, today time.Time, from string, to string) (time.Time, time.Time, error) {
+	start := today
+	if from != "" {
+		parsed, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return start, start, newError(ctx, ErrDateInvalid, from)
+		}
+		start = parsed
+	}
+	end := start.AddDate(0, 0, 6)
+	if to != "" {
+		parsed, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return start, end, newError(ctx, ErrDateInvalid, to)
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		return start, end, newError(ctx, ErrPeriodInvalid)
+	}
+	if maxDays := configInt(ctx, "planning", "maxDays", defaultPlanningMaxDays); end.Sub(start).Hours()/24+1 > float64(maxDays) {
+		return start, end, newError(ctx, ErrForecastTooLong, maxDays)
+	}
+
+	return start, end, nil
+}
+
+// operatedFacilities returns the IDs of the facilities an organization operates
+func (s *SmartContract) operatedFacilities(ctx contractapi.TransactionContextInterface, mspID string) (map[string]bool, error) {
+	facilities, err := s.GetAllFacilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	operated := map[string]bool{}
+	for _, facility := range facilities {
+		if facility.Operator == mspID {
+			operated[facility.ID] = true
+		}
+	}
+
+	return operated, nil
+}
+
+func putIntakeReservation(ctx contractapi.TransactionContextInterface, reservation *models.IntakeReservation) error {
+	return newAssetStore(ctx).Put(intakePrefix+reservation.ID, reservation)
+}
+
+// loadIntakeReservations returns the stored reservations accepted by keep
+func loadIntakeReservations(ctx contractapi.TransactionContextInterface, keep func(*models.IntakeReservation) bool) ([]*models.IntakeReservation, error) {
+	reservations := []*models.IntakeReservation{}
+	err := newAssetStore(ctx).Range(intakePrefix, intakePrefix+"~", func(_ string, value []byte) error {
+		var reservation models.IntakeReservation
+		if err := json.Unmarshal(value, &reservation); err != nil {
+			return err
+		}
+		if keep(&reservation) {
+			reservations = append(reservations, &reservation)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
@@ -0,0 +1,301 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegisterPlot registers a parcel of one of the caller organization's farms
+// under its LPIS or cadastral identifier; a parcel can only be registered once
+// per scheme
+func (s *SmartContract) RegisterPlot(ctx contractapi.TransactionContextInterface, id string, farm string, parcelId string, scheme string, areaHa float64, region string, actor string) (*models.Plot, error) {
+	farm = strings.TrimSpace(farm)
+	parcelId = strings.TrimSpace(parcelId)
+	scheme = strings.ToUpper(strings.TrimSpace(scheme))
+	if farm == "" || parcelId == "" {
+		return nil, newError(ctx, ErrPlotFieldsRequired)
+	}
+	if scheme != models.ParcelSchemeLPIS && scheme != models.ParcelSchemeCadastral {
+		return nil, newError(ctx, ErrParcelSchemeInvalid, scheme, models.ParcelSchemeLPIS, models.ParcelSchemeCadastral)
+	}
+	if areaHa < 0 {
+		return nil, newError(ctx, ErrPlotAreaNegative)
+	}
+	if id == "" {
+		generated, err := newAssetID(ctx, "PLOT")
+		if err != nil {
+			return nil, err
+		}
+		id = generated
+	}
+
+	exists, err := newAssetStore(ctx).Exists("PLOT_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrPlotAlreadyExists, id)
+	}
+
+	plots, err := loadPlots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range plots {
+		if other.Scheme == scheme && strings.EqualFold(other.ParcelID, parcelId) && other.Status == models.PlotActive {
+			return nil, newError(ctx, ErrParcelAlreadyRegistered, scheme, parcelId, other.ID)
+		}
+	}
+
+	ownerMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plot := &models.Plot{
+		ID:        id,
+		Farm:      farm,
+		OwnerMSP:  ownerMSP,
+		ParcelID:  parcelId,
+		Scheme:    scheme,
+		AreaHa:    areaHa,
+		Region:    region,
+		Status:    models.PlotActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "REGISTERED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("%s parcel %s of farm %s", scheme, parcelId, farm),
+			},
+		},
+	}
+
+	if err := s.putPlot(ctx, plot); err != nil {
+		return nil, err
+	}
+
+	return plot, nil
+}
+
+// RetirePlot stops a plot from being declared on new lots, e.g. after the
+// parcel was sold or merged; lots already harvested on it keep their origin
+func (s *SmartContract) RetirePlot(ctx contractapi.TransactionContextInterface, id string, reason string, actor string) (*models.Plot, error) {
+	plot, err := s.ReadPlot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if plot.Status == models.PlotRetired {
+		return nil, newError(ctx, ErrPlotAlreadyRetired, id)
+	}
+
+	organization, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if organization != plot.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrPlotRetireForbidden, plot.OwnerMSP, id)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plot.Status = models.PlotRetired
+	plot.UpdatedAt = now
+	plot.History = append(plot.History, models.History{
+		Timestamp: now,
+		Action:    "RETIRED",
+		Actor:     actor,
+		Details:   reason,
+	})
+
+	if err := s.putPlot(ctx, plot); err != nil {
+		return nil, err
+	}
+
+	return plot, nil
+}
+
+// ReadPlot returns the plot stored with the given id
+func (s *SmartContract) ReadPlot(ctx contractapi.TransactionContextInterface, id string) (*models.Plot, error) {
+	var plot models.Plot
+	found, err := newAssetStore(ctx).Get("PLOT_"+id, &plot)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "PLOT_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrPlotNotFound, id)
+	}
+
+	return &plot, nil
+}
+
+// GetPlotsForFarm returns the plots registered for a farm, or all plots when
+// farm is empty
+func (s *SmartContract) GetPlotsForFarm(ctx contractapi.TransactionContextInterface, farm string) ([]*models.Plot, error) {
+	plots, err := loadPlots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []*models.Plot{}
+	for _, plot := range plots {
+		if farm == "" || sameFarm(plot.Farm, farm) {
+			matching = append(matching, plot)
+		}
+	}
+
+	return matching, nil
+}
+
+// GetPlotStatistics returns collected, processed and recycled totals per plot
+// of a farm, or of all plots when farm is empty
+func (s *SmartContract) GetPlotStatistics(ctx contractapi.TransactionContextInterface, farm string) ([]*models.PlotStatistics, error) {
+	plots, err := s.GetPlotsForFarm(ctx, farm)
+	if err != nil {
+		return nil, err
+	}
+	byPlot := map[string]*models.PlotStatistics{}
+	for _, plot := range plots {
+		byPlot[plot.ID] = &models.PlotStatistics{
+			PlotID:   plot.ID,
+			ParcelID: plot.ParcelID,
+			Scheme:   plot.Scheme,
+			Farm:     plot.Farm,
+			AreaHa:   plot.AreaHa,
+		}
+	}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wastePlots := map[string]string{}
+	for _, waste := range wastes {
+		stats := byPlot[waste.PlotID]
+		if stats == nil {
+			continue
+		}
+		wastePlots[waste.ID] = waste.PlotID
+		stats.WasteCount++
+		stats.TotalCollected += waste.Quantity
+		if waste.Status == "PROCESSED" || waste.Status == "RECYCLED" {
+			stats.TotalProcessed += waste.Quantity
+		}
+		donated, disposed := dispositionQuantities(waste)
+		stats.TotalDonated += donated
+		stats.TotalDisposed += disposed
+	}
+
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, recycling := range recyclings {
+		for _, input := range recycling.InputLots() {
+			if plotID, ok := wastePlots[input.WasteID]; ok {
+				byPlot[plotID].TotalRecycled += input.Quantity
+			}
+		}
+	}
+
+	statistics := []*models.PlotStatistics{}
+	for _, stats := range byPlot {
+		if recyclable := stats.TotalCollected - stats.TotalDonated; recyclable > 0 {
+			stats.RecyclingRate = stats.TotalRecycled / recyclable
+		}
+		if stats.AreaHa > 0 {
+			stats.YieldPerHa = stats.TotalCollected / stats.AreaHa
+		}
+		statistics = append(statistics, stats)
+	}
+	sort.Slice(statistics, func(i, j int) bool {
+		return statistics[i].PlotID < statistics[j].PlotID
+	})
+
+	return statistics, nil
+}
+
+// checkWastePlot validates the plot declared on a new lot: it must be an
+// active plot of the caller's organization registered for the declared farm.
+// It returns the plot and the farm, taken from the plot when none was
+// declared.
+func checkWastePlot(ctx contractapi.TransactionContextInterface, plotID string, farm string, ownerMSP string) (*models.Plot, string, error) {
+	var plot models.Plot
+	found, err := newAssetStore(ctx).Get("PLOT_"+plotID, &plot)
+	if err != nil {
+		return nil, "", newError(ctx, ErrLedgerRead, "PLOT_"+plotID, err)
+	}
+	if !found || plot.Status != models.PlotActive || plot.OwnerMSP != ownerMSP {
+		return nil, "", newError(ctx, ErrPlotUnavailable, plotID)
+	}
+	if farm == "" {
+		return &plot, plot.Farm, nil
+	}
+	if !sameFarm(plot.Farm, farm) {
+		return nil, "", newError(ctx, ErrPlotFarmMismatch, plotID, plot.Farm, farm)
+	}
+
+	return &plot, farm, nil
+}
+
+// plotOrigin returns the parcel-level origin of a lot, if it declared a plot
+func plotOrigin(ctx contractapi.TransactionContextInterface, waste *models.Waste) (*models.PlotOrigin, error) {
+	if waste.PlotID == "" {
+		return nil, nil
+	}
+
+	var plot models.Plot
+	found, err := newAssetStore(ctx).Get("PLOT_"+waste.PlotID, &plot)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &models.PlotOrigin{
+		PlotID:   plot.ID,
+		ParcelID: plot.ParcelID,
+		Scheme:   plot.Scheme,
+		AreaHa:   plot.AreaHa,
+		Region:   plot.Region,
+	}, nil
+}
+
+// sameFarm compares farm names ignoring case and surrounding spaces
+func sameFarm(a string, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+func (s *SmartContract) putPlot(ctx contractapi.TransactionContextInterface, plot *models.Plot) error {
+	return newAssetStore(ctx).Put("PLOT_"+plot.ID, plot)
+}
+
+func loadPlots(ctx contractapi.TransactionContextInterface) ([]*models.Plot, error) {
+	plots := []*models.Plot{}
+	err := newAssetStore(ctx).Range("PLOT_", "PLOT_~", func(_ string, value []byte) error {
+		var plot models.Plot
+		if err := json.Unmarshal(value, &plot); err != nil {
+			return err
+		}
+		plots = append(plots, &plot)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plots, nil
+}
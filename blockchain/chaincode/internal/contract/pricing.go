@@ -0,0 +1,198 @@
+package contract
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RecordPriceIndex records the market price of a product type from a date
+// (YYYY-MM-DD) on. Admins and the identity designated by oracle.priceIdentity
+// maintain the index; each product type has at most one price per date.
+func (s *SmartContract) RecordPriceIndex(ctx contractapi.TransactionContextInterface, productType string, date string, pricePerUnit float64, currency string, source string) (*models.PriceEntry, error) {
+	recordedBy, err := requirePriceOracle(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	productType = priceIndexType(productType)
+	if productType == "" {
+		return nil, newError(ctx, ErrProductTypeRequired)
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, newError(ctx, ErrDateInvalid, date)
+	}
+	if pricePerUnit < 0 {
+		return nil, newError(ctx, ErrPriceNegative)
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return nil, newError(ctx, ErrCurrencyInvalid)
+	}
+
+	key := priceIndexKey(productType, date)
+	exists, err := newAssetStore(ctx).Exists(key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrPriceAlreadyRecorded, productType, date)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.PriceEntry{
+		ProductType:  productType,
+		Date:         date,
+		PricePerUnit: pricePerUnit,
+		Currency:     currency,
+		Source:       source,
+		RecordedBy:   recordedBy,
+		RecordedAt:   now,
+	}
+	if err := newAssetStore(ctx).Put(key, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetPriceIndex returns the dated prices of a product type, oldest first
+func (s *SmartContract) GetPriceIndex(ctx contractapi.TransactionContextInterface, productType string) ([]*models.PriceEntry, error) {
+	return loadPriceEntries(ctx, priceIndexType(productType), "~")
+}
+
+// GetValuationHistory returns the valuation snapshots of a lot, oldest first
+func (s *SmartContract) GetValuationHistory(ctx contractapi.TransactionContextInterface, wasteId string) ([]*models.Valuation, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, newError(ctx, ErrWasteNotVisible, wasteId)
+	}
+
+	valuations := []*models.Valuation{}
+	prefix := "VALUATION_" + wasteId + "_"
+	err = newAssetStore(ctx).Range(prefix, prefix+"~", func(_ string, value []byte) error {
+		var valuation models.Valuation
+		if err := json.Unmarshal(value, &valuation); err != nil {
+			return err
+		}
+		// IDs sharing a prefix (A and A_B) share the key range
+		if valuation.WasteID == wasteId {
+			valuations = append(valuations, &valuation)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return valuations, nil
+}
+
+// recordValuation snapshots the value of a quantity of a lot at the price in
+// force on the transaction date
+func recordValuation(ctx contractapi.TransactionContextInterface, waste *models.Waste, event string, quantity float64, salePrice float64, details string) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	productType := priceIndexType(waste.Type)
+	entries, err := loadPriceEntries(ctx, productType, now[:10])
+	if err != nil {
+		return err
+	}
+
+	valuation := &models.Valuation{
+		WasteID:     waste.ID,
+		Event:       event,
+		ProductType: productType,
+		Quantity:    quantity,
+		SalePrice:   salePrice,
+		Details:     details,
+		TxID:        ctx.GetStub().GetTxID(),
+		Timestamp:   now,
+	}
+	if len(entries) == 0 {
+		valuation.Unpriced = true
+	} else {
+		price := entries[len(entries)-1]
+		valuation.PricePerUnit = price.PricePerUnit
+		valuation.Currency = price.Currency
+		valuation.PriceDate = price.Date
+		valuation.Value = math.Round(quantity*price.PricePerUnit*100) / 100
+	}
+
+	return newAssetStore(ctx).Put("VALUATION_"+waste.ID+"_"+now+"_"+event, valuation)
+}
+
+// loadPriceEntries returns the prices of a product type dated up to and
+// including the given date, oldest first
+func loadPriceEntries(ctx contractapi.TransactionContextInterface, productType string, until string) ([]*models.PriceEntry, error) {
+	entries := []*models.PriceEntry{}
+	prefix := priceIndexKey(productType, "")
+	err := newAssetStore(ctx).Range(prefix, prefix+until+"~", func(_ string, value []byte) error {
+		var entry models.PriceEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return err
+		}
+		// Product types may themselves contain '_'
+		if entry.ProductType == productType {
+			entries = append(entries, &entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// requirePriceOracle lets admins and the identity designated by
+// oracle.priceIdentity maintain the price index and returns the caller
+func requirePriceOracle(ctx contractapi.TransactionContextInterface) (string, error) {
+	id, err := callerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if isAdmin(ctx) {
+		return id, nil
+	}
+	oracle := configString(ctx, "oracle", "priceIdentity", "")
+	if oracle == "" || id != oracle {
+		return "", newError(ctx, ErrPriceOracleRequired)
+	}
+
+	return id, nil
+}
+
+// priceIndexType normalizes a product type so that "Olive Pomace" and
+// "olive pomace" share an index
+func priceIndexType(productType string) string {
+	return strings.ToLower(strings.TrimSpace(productType))
+}
+
+func priceIndexKey(productType string, date string) string {
+	return "PRICEINDEX_" + productType + "_" + date
+}
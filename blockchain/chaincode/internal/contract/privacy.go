@@ -0,0 +1,483 @@
+package contract
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// piiCollection is the shared private data collection holding participants'
+// personal data (see collections_config.json); participants of organizations
+// pinned to a residency region use the region's collection instead. The
+// world state only keeps pseudonyms.
+const piiCollection = "participantPII"
+
+// erasedMarker replaces erased free-text personal data in public records
+const erasedMarker = "[erased]"
+
+// ReadWastePersonalData returns the owner, farm and location of a waste lot;
+// only the owning organization or an admin may read them
+func (s *SmartContract) ReadWastePersonalData(ctx contractapi.TransactionContextInterface, wasteId string) (*models.WastePII, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrPersonalDataRestricted, wasteId)
+	}
+
+	pii, err := readWastePII(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if pii == nil {
+		return nil, newError(ctx, ErrPersonalDataNotFound, wasteId)
+	}
+
+	return pii, nil
+}
+
+// readWastePII reads the personal data of a lot from the collection its
+// owner is pinned to, or nil if it has none; it fails on peers outside the
+// collection
+func readWastePII(ctx contractapi.TransactionContextInterface, waste *models.Waste) (*models.WastePII, error) {
+	collection, err := participantCollection(ctx, waste.ParticipantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return readWastePIIFrom(ctx, collection, waste.ID)
+}
+
+func readWastePIIFrom(ctx contractapi.TransactionContextInterface, collection string, wasteID string) (*models.WastePII, error) {
+	piiJSON, err := ctx.GetStub().GetPrivateData(collection, "WASTEPII_"+wasteID)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "WASTEPII_"+wasteID, err)
+	}
+	if piiJSON == nil {
+		return nil, nil
+	}
+
+	var pii models.WastePII
+	if err := json.Unmarshal(piiJSON, &pii); err != nil {
+		return nil, err
+	}
+
+	return &pii, nil
+}
+
+// EraseParticipantPII honors an erasure request: the participant's personal
+// data is deleted and purged from the private collection, names left in
+// public history are replaced by the pseudonym, and a receipt is stored.
+// Traceability records stay intact under the pseudonymous ID. Admin only.
+func (s *SmartContract) EraseParticipantPII(ctx contractapi.TransactionContextInterface, participantId string, reason string) (*models.ErasureReceipt, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	collection, err := participantCollection(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	participant, err := readParticipant(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+
+	replacements := map[string]string{participant.Name: participant.ID}
+	for _, wasteID := range participant.WasteIDs {
+		pii, err := readWastePIIFrom(ctx, collection, wasteID)
+		if err != nil {
+			return nil, err
+		}
+		if pii != nil {
+			for _, value := range []string{pii.Farm, pii.Location} {
+				if value != "" {
+					replacements[value] = erasedMarker
+				}
+			}
+		}
+		if err := purgePrivate(ctx, collection, "WASTEPII_"+wasteID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, wasteID := range participant.WasteIDs {
+		waste, err := s.readWaste(ctx, wasteID)
+		if err != nil {
+			return nil, err
+		}
+		waste.History = scrubHistory(waste.History, replacements)
+		if err := s.putWaste(ctx, waste); err != nil {
+			return nil, err
+		}
+		if err := scrubArchivedHistory(ctx, "WASTE", wasteID, replacements); err != nil {
+			return nil, err
+		}
+	}
+
+	requests, err := s.GetAllCollectionRequests(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		if request.OwnerMSP != participant.MSP || request.Owner != participant.Name {
+			continue
+		}
+		request.Owner = participant.ID
+		request.Farm = erasedMarker
+		request.History = scrubHistory(request.History, replacements)
+		if err := s.putCollectionRequest(ctx, request); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := purgePrivate(ctx, collection, participantNameKey(participant.MSP, participant.Name)); err != nil {
+		return nil, err
+	}
+	if err := purgePrivate(ctx, collection, "PARTICIPANT_"+participant.ID); err != nil {
+		return nil, err
+	}
+
+	requestedBy, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// An erased participant no longer contributes to research datasets
+	consent, err := readResearchConsent(ctx, participant.ID)
+	if err != nil {
+		return nil, err
+	}
+	if consent.Granted {
+		consent.Granted = false
+		consent.UpdatedBy = requestedBy
+		consent.UpdatedAt = now
+		consent.History = append(consent.History, models.History{
+			Timestamp: now,
+			Action:    "CONSENT_WITHDRAWN",
+			Actor:     requestedBy,
+			Details:   "Personal data erased",
+		})
+		if err := newAssetStore(ctx).Put("CONSENT_"+participant.ID, consent); err != nil {
+			return nil, err
+		}
+	}
+
+	receipt := &models.ErasureReceipt{
+		ParticipantID: participant.ID,
+		MSP:           participant.MSP,
+		WasteIDs:      participant.WasteIDs,
+		Reason:        reason,
+		RequestedBy:   requestedBy,
+		ErasedAt:      now,
+		TxID:          ctx.GetStub().GetTxID(),
+	}
+	if err := newAssetStore(ctx).Put("ERASURE_"+participant.ID, receipt); err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// personalData returns the owner, farm and location of a new lot, preferring
+// the "pii" transient entry so that personal data stays out of the
+// transaction arguments recorded in blocks
+func personalData(ctx contractapi.TransactionContextInterface, owner string, farm string, location string) (string, string, string, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", "", "", err
+	}
+	piiJSON, ok := transient["pii"]
+	if !ok {
+		return owner, farm, location, nil
+	}
+
+	var pii models.WastePII
+	if err := json.Unmarshal(piiJSON, &pii); err != nil {
+		return "", "", "", newError(ctx, ErrPIITransientInvalid, err)
+	}
+
+	return pii.Owner, pii.Farm, pii.Location, nil
+}
+
+// pseudonymizeWaste moves a lot's owner, farm and location to the private
+// collection of its owner's residency region and replaces them in the world-state copy with the owner's
+// participant ID; lots that are already pseudonymized are left untouched
+func pseudonymizeWaste(ctx contractapi.TransactionContextInterface, waste *models.Waste) error {
+	if waste.ParticipantID != "" || (waste.Owner == "" && waste.Farm == "" && waste.Location == "") {
+		return nil
+	}
+
+	participant, collection, err := ensureParticipant(ctx, waste.OwnerMSP, waste.Owner)
+	if err != nil {
+		return err
+	}
+	participant.WasteIDs = append(participant.WasteIDs, waste.ID)
+	if err := putPrivate(ctx, collection, "PARTICIPANT_"+participant.ID, participant); err != nil {
+		return err
+	}
+
+	pii := &models.WastePII{
+		WasteID:       waste.ID,
+		ParticipantID: participant.ID,
+		Owner:         waste.Owner,
+		Farm:          waste.Farm,
+		Location:      waste.Location,
+	}
+	if err := putPrivate(ctx, collection, "WASTEPII_"+waste.ID, pii); err != nil {
+		return err
+	}
+
+	waste.History = scrubHistory(waste.History, map[string]string{waste.Owner: participant.ID})
+	waste.ParticipantID = participant.ID
+	waste.Owner = participant.ID
+	if waste.Region == "" {
+		waste.Region = coarseRegion(waste.Location)
+	}
+	waste.Farm = ""
+	waste.Location = ""
+
+	return nil
+}
+
+// purgeExpiredPersonalData purges the personal data of lots created before
+// the cutoff from the collection of the caller's organization, one page of
+// the collection per run, and returns how many lots were affected. Residency
+// regions are purged when maintenance runs from one of their organizations.
+func purgeExpiredPersonalData(ctx contractapi.TransactionContextInterface, run *maintenanceRun, cutoff time.Time) (int, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return 0, err
+	}
+	collection, err := residencyCollection(ctx, mspID)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := []string{}
+	err = run.scanPrivate(ctx, "personalData:"+collection, collection, "WASTEPII_", func(key string, value []byte) error {
+		var pii models.WastePII
+		if err := json.Unmarshal(value, &pii); err != nil {
+			return err
+		}
+		var waste models.Waste
+		found, err := newAssetStore(ctx).Get("WASTE_"+pii.WasteID, &waste)
+		if err != nil {
+			return newError(ctx, ErrLedgerRead, "WASTE_"+pii.WasteID, err)
+		}
+		if !found {
+			return nil
+		}
+		created, err := time.Parse(time.RFC3339, waste.CreatedAt)
+		if err == nil && created.Before(cutoff) {
+			expired = append(expired, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range expired {
+		if err := purgePrivate(ctx, collection, key); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(expired), nil
+}
+
+// ensureParticipant returns the participant registered for a name within an
+// organization and the collection holding its data, registering a new
+// pseudonymous ID on first use. Organizations pinned to a residency region
+// register their participants in the region's collection, where names
+// registered before the region was defined are not found.
+func ensureParticipant(ctx contractapi.TransactionContextInterface, mspID string, name string) (*models.Participant, string, error) {
+	region, err := residencyOf(ctx, mspID)
+	if err != nil {
+		return nil, "", err
+	}
+	collection := piiCollection
+	if region != nil {
+		collection = region.Collection
+	}
+	idJSON, err := ctx.GetStub().GetPrivateData(collection, participantNameKey(mspID, name))
+	if err != nil {
+		return nil, "", newError(ctx, ErrParticipantLookupFailed, err)
+	}
+	if idJSON != nil {
+		participant, err := readParticipantFrom(ctx, collection, string(idJSON))
+		return participant, collection, err
+	}
+
+	id, err := newAssetID(ctx, "PARTICIPANT")
+	if err != nil {
+		return nil, "", err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := ctx.GetStub().PutPrivateData(collection, participantNameKey(mspID, name), []byte(id)); err != nil {
+		return nil, "", err
+	}
+	if region != nil {
+		pin := &models.ResidencyPin{ParticipantID: id, Region: region.Code, Collection: collection, PinnedAt: now}
+		if err := newAssetStore(ctx).Put("RESIDENCYPIN_"+id, pin); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return &models.Participant{ID: id, Name: name, MSP: mspID, WasteIDs: []string{}, CreatedAt: now}, collection, nil
+}
+
+// managedParticipant returns a participant the caller manages (its
+// templates, its onboarding) and the collection holding it. An empty
+// participantId names the owner of the "pii" transient entry, registered
+// when new; otherwise the participant must belong to the caller's
+// organization.
+func managedParticipant(ctx contractapi.TransactionContextInterface, participantId string) (*models.Participant, string, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if participantId == "" {
+		owner, _, _, err := personalData(ctx, "", "", "")
+		if err != nil {
+			return nil, "", err
+		}
+		if strings.TrimSpace(owner) == "" {
+			return nil, "", newError(ctx, ErrPIISubjectRequired)
+		}
+		participant, collection, err := ensureParticipant(ctx, mspID, owner)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := putPrivate(ctx, collection, "PARTICIPANT_"+participant.ID, participant); err != nil {
+			return nil, "", err
+		}
+
+		return participant, collection, nil
+	}
+
+	collection, err := participantCollection(ctx, participantId)
+	if err != nil {
+		return nil, "", err
+	}
+	participant, err := readParticipantFrom(ctx, collection, participantId)
+	if err != nil {
+		return nil, "", err
+	}
+	if participant.MSP != mspID {
+		return nil, "", newError(ctx, ErrParticipantManageForbidden, participant.MSP, participantId)
+	}
+
+	return participant, collection, nil
+}
+
+func readParticipant(ctx contractapi.TransactionContextInterface, id string) (*models.Participant, error) {
+	collection, err := participantCollection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return readParticipantFrom(ctx, collection, id)
+}
+
+func readParticipantFrom(ctx contractapi.TransactionContextInterface, collection string, id string) (*models.Participant, error) {
+	participantJSON, err := ctx.GetStub().GetPrivateData(collection, "PARTICIPANT_"+id)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "PARTICIPANT_"+id, err)
+	}
+	if participantJSON == nil {
+		return nil, newError(ctx, ErrParticipantNotFound, id)
+	}
+
+	var participant models.Participant
+	if err := json.Unmarshal(participantJSON, &participant); err != nil {
+		return nil, err
+	}
+
+	return &participant, nil
+}
+
+// coarseRegion keeps the last comma-separated part of a location ("Jaén,
+// Andalusia" -> "Andalusia"), which is public for regional statistics
+func coarseRegion(location string) string {
+	parts := strings.Split(location, ",")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
+
+// participantNameKey indexes participants by organization and normalized name
+func participantNameKey(mspID string, name string) string {
+	return "PARTICIPANT_NAME_" + mspID + "_" + models.NormalizeWasteType(name)
+}
+
+// scrubHistory replaces personal data in history actors and details,
+// longest values first so that a value containing another (a location naming
+// the farm) is erased whole, in the same order on every endorser
+func scrubHistory(history []models.History, replacements map[string]string) []models.History {
+	values := make([]string, 0, len(replacements))
+	for value := range replacements {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(a, b int) bool {
+		if len(values[a]) != len(values[b]) {
+			return len(values[a]) > len(values[b])
+		}
+		return values[a] < values[b]
+	})
+
+	for i := range history {
+		for _, value := range values {
+			replacement := replacements[value]
+			if history[i].Actor == value {
+				history[i].Actor = replacement
+			}
+			history[i].Details = strings.ReplaceAll(history[i].Details, value, replacement)
+			for j := range history[i].Changes {
+				change := &history[i].Changes[j]
+				change.OldValue = strings.ReplaceAll(change.OldValue, value, replacement)
+				change.NewValue = strings.ReplaceAll(change.NewValue, value, replacement)
+			}
+		}
+	}
+
+	return history
+}
+
+func putPrivate(ctx contractapi.TransactionContextInterface, collection string, key string, value interface{}) error {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutPrivateData(collection, key, valueJSON)
+}
+
+// purgePrivate deletes a private key and purges it from the private data
+// store of every peer, including its history
+func purgePrivate(ctx contractapi.TransactionContextInterface, collection string, key string) error {
+	if err := ctx.GetStub().DelPrivateData(collection, key); err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PurgePrivateData(collection, key)
+}
@@ -0,0 +1,199 @@
+package contract
+
+import (
+	"math"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Public statistics defaults, overridden by public.minGroupSize,
+// public.groupBy (region, year or none) and public.precision (tonnes the
+// figures are rounded to)
+const (
+	defaultPublicMinGroupSize = 5
+	defaultPublicGroupBy      = "region"
+	defaultPublicPrecision    = 1.0
+)
+
+// otherGroup collects the groups covering too few farms to be published
+const otherGroup = "OTHER"
+
+// publicGroup accumulates the figures of a group with the farms behind them
+type publicGroup struct {
+	figures models.PublicFigures
+	farms   map[string]bool
+}
+
+func newPublicGroup(name string) *publicGroup {
+	return &publicGroup{figures: models.PublicFigures{Group: name}, farms: map[string]bool{}}
+}
+
+func (g *publicGroup) merge(other *publicGroup) {
+	for farm := range other.farms {
+		g.farms[farm] = true
+	}
+	g.figures.Lots += other.figures.Lots
+	g.figures.TonnesRecorded += other.figures.TonnesRecorded
+	g.figures.TonnesValorized += other.figures.TonnesValorized
+	g.figures.CO2eAvoided += other.figures.CO2eAvoided
+}
+
+// result returns the group's rounded figures
+func (g *publicGroup) result(precision float64) *models.PublicFigures {
+	figures := g.figures
+	figures.Farms = len(g.farms)
+	figures.TonnesRecorded = roundTo(figures.TonnesRecorded, precision)
+	figures.TonnesValorized = roundTo(figures.TonnesValorized, precision)
+	figures.CO2eAvoided = roundTo(figures.CO2eAvoided, precision)
+
+	return &figures
+}
+
+// GetPublicStatistics returns the network's k-anonymized figures: farms
+// participating, tonnes recorded and valorized (consumed by extractions and
+// recyclings) and CO2e avoided, in total and per region or harvest year. A
+// group is only published when at least public.minGroupSize farms are behind
+// it; smaller groups are merged into OTHER, itself grown with the next
+// smallest groups until it is large enough, so no group can be recovered by
+// subtracting the others from the totals. Open to every caller.
+func (s *SmartContract) GetPublicStatistics(ctx contractapi.TransactionContextInterface) (*models.PublicStatistics, error) {
+	if !configBool(ctx, "public", "enabled", true) {
+		return nil, newError(ctx, ErrPublicStatisticsDisabled)
+	}
+	minGroupSize := configInt(ctx, "public", "minGroupSize", defaultPublicMinGroupSize)
+	if minGroupSize < 2 {
+		minGroupSize = 2
+	}
+	groupBy := configString(ctx, "public", "groupBy", defaultPublicGroupBy)
+	if groupBy != "region" && groupBy != "year" && groupBy != "none" {
+		return nil, newError(ctx, ErrPublicGroupByInvalid, groupBy)
+	}
+	precision := configFloat(ctx, "public", "precision", defaultPublicPrecision)
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// CO2e avoided is shared among a recycling's input lots by quantity
+	avoided := map[string]float64{}
+	for _, recycling := range recyclings {
+		inputs := recycling.InputLots()
+		total := 0.0
+		for _, input := range inputs {
+			total += input.Quantity
+		}
+		for _, input := range inputs {
+			if total > 0 {
+				avoided[input.WasteID] += recycling.CO2eAvoided * input.Quantity / total
+			}
+		}
+	}
+
+	totals := newPublicGroup("")
+	groups := map[string]*publicGroup{}
+	for _, waste := range wastes {
+		lot := newPublicGroup("")
+		lot.farms[farmKey(waste)] = true
+		lot.figures.Lots = 1
+		lot.figures.TonnesRecorded = waste.Quantity
+		lot.figures.TonnesValorized = waste.Consumed
+		lot.figures.CO2eAvoided = avoided[waste.ID]
+		totals.merge(lot)
+
+		if groupBy == "none" {
+			continue
+		}
+		name := waste.Region
+		if groupBy == "year" && len(waste.HarvestDate) >= 4 {
+			name = waste.HarvestDate[:4]
+		}
+		if name == "" {
+			name = otherGroup
+		}
+		if groups[name] == nil {
+			groups[name] = newPublicGroup(name)
+		}
+		groups[name].merge(lot)
+	}
+
+	statistics := &models.PublicStatistics{
+		GeneratedAt:  now,
+		GroupBy:      groupBy,
+		MinGroupSize: minGroupSize,
+		Groups:       []*models.PublicFigures{},
+	}
+	if len(totals.farms) < minGroupSize {
+		return statistics, nil
+	}
+	statistics.Totals = totals.result(precision)
+
+	// Smallest groups first, so the fewest farms move into OTHER
+	published := []*publicGroup{}
+	other := newPublicGroup(otherGroup)
+	for name, group := range groups {
+		if name == otherGroup || len(group.farms) < minGroupSize {
+			if name != otherGroup {
+				statistics.MergedGroups++
+			}
+			other.merge(group)
+			continue
+		}
+		published = append(published, group)
+	}
+	sort.Slice(published, func(i, j int) bool {
+		if len(published[i].farms) != len(published[j].farms) {
+			return len(published[i].farms) < len(published[j].farms)
+		}
+		return published[i].figures.Group < published[j].figures.Group
+	})
+	for other.figures.Lots > 0 && len(other.farms) < minGroupSize && len(published) > 0 {
+		other.merge(published[0])
+		published = published[1:]
+		statistics.MergedGroups++
+	}
+
+	sort.Slice(published, func(i, j int) bool {
+		return published[i].figures.Group < published[j].figures.Group
+	})
+	for _, group := range published {
+		statistics.Groups = append(statistics.Groups, group.result(precision))
+	}
+	if other.figures.Lots > 0 {
+		statistics.Groups = append(statistics.Groups, other.result(precision))
+	}
+
+	return statistics, nil
+}
+
+// farmKey identifies the farm behind a lot: its pseudonymous participant,
+// else its owner
+func farmKey(waste *models.Waste) string {
+	if waste.ParticipantID != "" {
+		return waste.ParticipantID
+	}
+	if waste.Owner != "" {
+		return waste.Owner
+	}
+
+	return waste.OwnerMSP + "/" + waste.Farm
+}
+
+// roundTo rounds a figure to a multiple of precision
+func roundTo(value float64, precision float64) float64 {
+	if precision <= 0 {
+		return value
+	}
+
+	return math.Round(value/precision) * precision
+}
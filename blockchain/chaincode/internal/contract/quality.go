@@ -0,0 +1,469 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Completeness rules with their default weights; config
+// quality.<field>Weight overrides them. A field the owner's validation
+// profile now requires weighs double, since new lots cannot leave it out.
+var qualityRules = []struct {
+	field  string
+	weight int
+}{
+	{models.FieldFarm, 30},
+	{models.FieldHarvestDate, 30},
+	{models.FieldLocation, 25},
+	{models.FieldPlot, 15},
+}
+
+// defaultMaxEnrichments caps a bulk enrichment; config
+// quality.maxEnrichments overrides it
+const defaultMaxEnrichments = 200
+
+// GetDataQualityReport returns the lots the caller may see in full that
+// fail completeness rules, worst first. field keeps the lots missing that
+// field and minSeverity (LOW, MEDIUM or HIGH) the lots at least that bad.
+func (s *SmartContract) GetDataQualityReport(ctx contractapi.TransactionContextInterface, field string, minSeverity string) (*models.DataQualityReport, error) {
+	if field != "" && !isQualityField(field) {
+		return nil, newError(ctx, ErrFieldUnknown, field, strings.Join(qualityFields(), ", "))
+	}
+	minScore := 1
+	switch strings.ToUpper(minSeverity) {
+	case "", models.QualityLow:
+	case models.QualityMedium:
+		minScore = 30
+	case models.QualityHigh:
+		minScore = 60
+	default:
+		return nil, newError(ctx, ErrSeverityInvalid, models.QualityLow, models.QualityMedium, models.QualityHigh)
+	}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	weights := qualityWeights(ctx)
+	profiles := map[string]*models.ValidationProfile{}
+
+	report := &models.DataQualityReport{
+		ByField:    map[string]int{},
+		BySeverity: map[string]int{},
+		Records:    []*models.WasteQuality{},
+	}
+	for _, waste := range wastes {
+		visible, err := viewer.canView(ctx, waste)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			continue
+		}
+		report.Checked++
+		profile, err := ownerProfile(ctx, profiles, waste.OwnerMSP)
+		if err != nil {
+			return nil, err
+		}
+		quality := assessWaste(waste, lotValues(ctx, waste), profile, weights)
+		if quality.Score == 0 {
+			continue
+		}
+		report.Incomplete++
+		report.BySeverity[quality.Severity]++
+		for _, missing := range quality.Missing {
+			report.ByField[missing]++
+		}
+		if quality.Score >= minScore && (field == "" || containsField(quality.Missing, field)) {
+			report.Records = append(report.Records, quality)
+		}
+	}
+	sort.Slice(report.Records, func(i, j int) bool {
+		a, b := report.Records[i], report.Records[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		return a.WasteID < b.WasteID
+	})
+
+	return report, nil
+}
+
+// GetWasteQuality returns the completeness of one lot
+func (s *SmartContract) GetWasteQuality(ctx contractapi.TransactionContextInterface, wasteId string) (*models.WasteQuality, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, newError(ctx, ErrWasteNotVisible, wasteId)
+	}
+	profile, err := readValidationProfile(ctx, waste.OwnerMSP)
+	if err != nil {
+		return nil, err
+	}
+
+	return assessWaste(waste, lotValues(ctx, waste), profile, qualityWeights(ctx)), nil
+}
+
+// EnrichWastes backfills missing fields (farm, location, harvestDate,
+// plotId) on a batch of lots, given as a JSON array of
+// {wasteId, farm, location, harvestDate, plotId}. Fields already set are
+// never overwritten, and each addition is noted in the lot's history as a
+// late one. The batch may come in the "enrichments" transient entry instead,
+// keeping farms and locations out of the transaction arguments. Lots the
+// caller's organization does not own (unless admin) or whose values are
+// invalid are reported as failed; the others are still updated.
+func (s *SmartContract) EnrichWastes(ctx contractapi.TransactionContextInterface, enrichments string) (*models.EnrichmentResult, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := transient["enrichments"]; ok {
+		enrichments = string(data)
+	}
+	var batch []models.WasteEnrichment
+	if err := json.Unmarshal([]byte(enrichments), &batch); err != nil {
+		return nil, newError(ctx, ErrEnrichmentsInvalid, err)
+	}
+	if len(batch) == 0 {
+		return nil, newError(ctx, ErrEnrichmentRequired)
+	}
+	if max := configInt(ctx, "quality", "maxEnrichments", defaultMaxEnrichments); len(batch) > max {
+		return nil, newError(ctx, ErrEnrichmentBatchTooLarge, max, len(batch))
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	weights := qualityWeights(ctx)
+
+	result := &models.EnrichmentResult{
+		Enriched: map[string][]string{},
+		Failed:   []models.EnrichmentFailure{},
+		Quality:  []*models.WasteQuality{},
+	}
+	seen := map[string]bool{}
+	for _, enrichment := range batch {
+		if seen[enrichment.WasteID] {
+			result.Failed = append(result.Failed, models.EnrichmentFailure{WasteID: enrichment.WasteID, Error: "listed more than once"})
+			continue
+		}
+		seen[enrichment.WasteID] = true
+		quality, added, err := s.enrichWaste(ctx, enrichment, mspID, actor, now, weights)
+		if err != nil {
+			result.Failed = append(result.Failed, models.EnrichmentFailure{WasteID: enrichment.WasteID, Error: err.Error()})
+			continue
+		}
+		result.Enriched[enrichment.WasteID] = added
+		result.Quality = append(result.Quality, quality)
+	}
+
+	return result, nil
+}
+
+// enrichWaste backfills the missing fields of one lot and returns its new
+// quality and the fields added. Nothing is written unless every given
+// field can be added.
+func (s *SmartContract) enrichWaste(ctx contractapi.TransactionContextInterface, enrichment models.WasteEnrichment, mspID string, actor string, now string, weights map[string]int) (*models.WasteQuality, []string, error) {
+	waste, err := s.readWaste(ctx, enrichment.WasteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, nil, newError(ctx, ErrEnrichmentForbidden, waste.OwnerMSP, waste.ID)
+	}
+
+	// Farm and location of pseudonymized lots live in the private collection
+	var pii *models.WastePII
+	if waste.ParticipantID != "" {
+		if pii, err = readWastePII(ctx, waste); err != nil {
+			return nil, nil, err
+		}
+		if pii == nil {
+			return nil, nil, newError(ctx, ErrPersonalDataErased, waste.ID)
+		}
+	}
+	values := lotValues(ctx, waste)
+	if pii != nil {
+		values[models.FieldFarm] = pii.Farm
+		values[models.FieldLocation] = pii.Location
+	}
+
+	added := map[string]string{}
+	add := func(field string, value string) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil
+		}
+		if values[field] != "" && (field != models.FieldHarvestDate || validHarvestDate(values[field])) {
+			return newError(ctx, ErrFieldAlreadySet, field)
+		}
+		added[field] = value
+		values[field] = value
+		return nil
+	}
+	for _, field := range []struct{ name, value string }{
+		{models.FieldFarm, enrichment.Farm},
+		{models.FieldLocation, enrichment.Location},
+		{models.FieldHarvestDate, enrichment.HarvestDate},
+		{models.FieldPlot, enrichment.PlotID},
+	} {
+		if err := add(field.name, field.value); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil, newError(ctx, ErrNoMissingField)
+	}
+	if harvestDate, ok := added[models.FieldHarvestDate]; ok {
+		if !validHarvestDate(harvestDate) {
+			return nil, nil, newError(ctx, ErrHarvestDate, harvestDate)
+		}
+		if harvestDate > now[:10] {
+			return nil, nil, newError(ctx, ErrHarvestDateFuture, harvestDate)
+		}
+	}
+	if plotID, ok := added[models.FieldPlot]; ok {
+		// A plot names its farm, which fills in a missing one
+		_, farm, err := checkWastePlot(ctx, plotID, values[models.FieldFarm], waste.OwnerMSP)
+		if err != nil {
+			return nil, nil, err
+		}
+		if values[models.FieldFarm] == "" && farm != "" {
+			added[models.FieldFarm] = farm
+			values[models.FieldFarm] = farm
+		}
+	}
+
+	if _, ok := added[models.FieldHarvestDate]; ok {
+		waste.HarvestDate = added[models.FieldHarvestDate]
+	}
+	if _, ok := added[models.FieldPlot]; ok {
+		waste.PlotID = added[models.FieldPlot]
+	}
+	_, addedFarm := added[models.FieldFarm]
+	_, addedLocation := added[models.FieldLocation]
+	piiChanged := pii != nil && (addedFarm || addedLocation)
+	if piiChanged {
+		pii.Farm = values[models.FieldFarm]
+		pii.Location = values[models.FieldLocation]
+	} else if pii == nil {
+		waste.Farm = values[models.FieldFarm]
+		waste.Location = values[models.FieldLocation]
+	}
+	if addedLocation && waste.Region == "" {
+		waste.Region = coarseRegion(values[models.FieldLocation])
+	}
+
+	fields := make([]string, 0, len(added))
+	for _, rule := range qualityRules {
+		if value, ok := added[rule.field]; ok {
+			fields = append(fields, rule.field)
+			// Farms and locations are personal data: the public history
+			// only notes that they were added
+			details := fmt.Sprintf("%s added after the lot was recorded on %s", rule.field, waste.CreatedAt)
+			if rule.field == models.FieldHarvestDate || rule.field == models.FieldPlot {
+				details = fmt.Sprintf("%s set to %s after the lot was recorded on %s", rule.field, value, waste.CreatedAt)
+			}
+			waste.History = append(waste.History, models.History{
+				Timestamp: now,
+				Action:    "FIELD_BACKFILLED",
+				Actor:     actor,
+				Details:   details,
+			})
+		}
+	}
+	for i := range waste.Warnings {
+		warning := &waste.Warnings[i]
+		if warning.Resolved {
+			continue
+		}
+		if (warning.Code == models.WarnFarmMissing && addedFarm) || (warning.Code == models.WarnLocationMissing && addedLocation) {
+			warning.Resolved = true
+			warning.ResolvedBy = actor
+			warning.ResolvedAt = now
+			warning.Resolution = "Backfilled by enrichment"
+		}
+	}
+	waste.UpdatedAt = now
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, nil, err
+	}
+	if piiChanged {
+		collection, err := participantCollection(ctx, waste.ParticipantID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := putPrivate(ctx, collection, "WASTEPII_"+waste.ID, pii); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	profile, err := readValidationProfile(ctx, waste.OwnerMSP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return assessWaste(waste, values, profile, weights), fields, nil
+}
+
+// assessWaste scores a lot against the completeness rules, given the
+// values of the checked fields
+func assessWaste(waste *models.Waste, values map[string]string, profile *models.ValidationProfile, weights map[string]int) *models.WasteQuality {
+	quality := &models.WasteQuality{
+		WasteID:   waste.ID,
+		OwnerMSP:  waste.OwnerMSP,
+		Status:    waste.Status,
+		CreatedAt: waste.CreatedAt,
+		Missing:   []string{},
+		Issues:    []models.QualityIssue{},
+	}
+	for _, rule := range qualityRules {
+		value := values[rule.field]
+		problem := ""
+		switch {
+		case value == "":
+			problem = "MISSING"
+		case rule.field == models.FieldHarvestDate && !validHarvestDate(value):
+			problem = "INVALID"
+		}
+		if problem == "" {
+			continue
+		}
+		issue := models.QualityIssue{Field: rule.field, Problem: problem, Weight: weights[rule.field]}
+		if profile != nil && containsField(profile.RequiredFields, rule.field) {
+			issue.Required = true
+			issue.Weight *= 2
+		}
+		quality.Issues = append(quality.Issues, issue)
+		quality.Missing = append(quality.Missing, rule.field)
+		quality.Score += issue.Weight
+	}
+	if quality.Score > 100 {
+		quality.Score = 100
+	}
+	switch {
+	case quality.Score >= 60:
+		quality.Severity = models.QualityHigh
+	case quality.Score >= 30:
+		quality.Severity = models.QualityMedium
+	case quality.Score > 0:
+		quality.Severity = models.QualityLow
+	}
+
+	return quality
+}
+
+// lotValues returns the checked fields of a lot. Pseudonymized lots keep
+// their farm and location in the private collection; on peers that cannot
+// read it, or once it was erased, "set" stands in for a farm without an
+// open FARM_MISSING warning and for a location that left a region.
+func lotValues(ctx contractapi.TransactionContextInterface, waste *models.Waste) map[string]string {
+	values := map[string]string{
+		models.FieldFarm:        waste.Farm,
+		models.FieldLocation:    waste.Location,
+		models.FieldHarvestDate: waste.HarvestDate,
+		models.FieldPlot:        waste.PlotID,
+	}
+	if waste.ParticipantID == "" {
+		return values
+	}
+	if pii, err := readWastePII(ctx, waste); err == nil && pii != nil {
+		values[models.FieldFarm] = pii.Farm
+		values[models.FieldLocation] = pii.Location
+		return values
+	}
+	if !hasOpenWarning(waste, models.WarnFarmMissing) {
+		values[models.FieldFarm] = "set"
+	}
+	if waste.Region != "" && !hasOpenWarning(waste, models.WarnLocationMissing) {
+		values[models.FieldLocation] = "set"
+	}
+
+	return values
+}
+
+// qualityWeights returns the weight of each completeness rule
+func qualityWeights(ctx contractapi.TransactionContextInterface) map[string]int {
+	weights := map[string]int{}
+	for _, rule := range qualityRules {
+		weights[rule.field] = configInt(ctx, "quality", rule.field+"Weight", rule.weight)
+	}
+
+	return weights
+}
+
+// ownerProfile returns the validation profile of an organization, caching
+// it for the rest of the report
+func ownerProfile(ctx contractapi.TransactionContextInterface, profiles map[string]*models.ValidationProfile, orgMSP string) (*models.ValidationProfile, error) {
+	if profile, ok := profiles[orgMSP]; ok {
+		return profile, nil
+	}
+	profile, err := readValidationProfile(ctx, orgMSP)
+	if err != nil {
+		return nil, err
+	}
+	profiles[orgMSP] = profile
+
+	return profile, nil
+}
+
+func validHarvestDate(value string) bool {
+	_, err := time.Parse("2006-01-02", value)
+	return err == nil
+}
+
+func qualityFields() []string {
+	fields := make([]string, len(qualityRules))
+	for i, rule := range qualityRules {
+		fields[i] = rule.field
+	}
+
+	return fields
+}
+
+func isQualityField(field string) bool {
+	return containsField(qualityFields(), field)
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,160 @@
+package contract
+
+import (
+	"errors"
+
+	"github.com/chaincode/internal/store"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Query budget defaults, overridden by query.maxResults and query.maxBytes;
+// a setting of 0 or less leaves that limit off. Each range or composite-key
+// query of a transaction has its own budget.
+const (
+	defaultQueryMaxResults = 10000
+	defaultQueryMaxBytes   = 16 << 20
+)
+
+// TruncatedError is the QUERY_TRUNCATED error of a query stopped by its
+// budget; it unwraps to the store.BudgetExceeded, from which paged queries
+// take the key to resume at
+type TruncatedError struct {
+	*CodedError
+	Exceeded *store.BudgetExceeded
+}
+
+// Unwrap returns the budget the query exceeded
+func (e *TruncatedError) Unwrap() error {
+	return e.Exceeded
+}
+
+// queryLimits returns the configured result-size budget of a query
+func queryLimits(ctx contractapi.TransactionContextInterface) store.Limits {
+	limits := store.Limits{
+		MaxResults: configInt(ctx, "query", "maxResults", defaultQueryMaxResults),
+		MaxBytes:   configInt(ctx, "query", "maxBytes", defaultQueryMaxBytes),
+	}
+	if limits.MaxResults < 0 {
+		limits.MaxResults = 0
+	}
+	if limits.MaxBytes < 0 {
+		limits.MaxBytes = 0
+	}
+
+	return limits
+}
+
+// truncatedError localizes a query stopped by its budget
+func truncatedError(ctx contractapi.TransactionContextInterface, exceeded *store.BudgetExceeded) error {
+	coded := newError(ctx, ErrQueryTruncated, exceeded.StartKey, exceeded.EndKey, exceeded.Limits.MaxResults, exceeded.Limits.MaxBytes, exceeded.Results).(*CodedError)
+
+	return &TruncatedError{CodedError: coded, Exceeded: exceeded}
+}
+
+// budgetExceeded reports whether err stopped a query at its budget. Paged
+// queries then return what they read as a truncated page whose bookmark
+// resumes after it; any other error is returned as is.
+func budgetExceeded(err error) bool {
+	var exceeded *store.BudgetExceeded
+
+	return errors.As(err, &exceeded)
+}
+
+// partialKeyQuery visits the entries of a composite-key index whose leading
+// attributes are attrs, holding the query to the same budget as range
+// queries; visit may return store.ErrStopRange to end it early
+func partialKeyQuery(ctx contractapi.TransactionContextInterface, index string, attrs []string, visit func(keyParts []string, value []byte) error) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, attrs)
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	startKey, err := ctx.GetStub().CreateCompositeKey(index, attrs)
+	if err != nil {
+		return err
+	}
+	tally := store.NewTally(queryLimits(ctx), startKey, startKey+"\U0010FFFF")
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		if exceeded := tally.Admit(queryResponse.Key, queryResponse.Value); exceeded != nil {
+			return truncatedError(ctx, exceeded)
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return err
+		}
+		if err := visit(keyParts, queryResponse.Value); err != nil {
+			if err == store.ErrStopRange {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanPage visits the assets of [startKey, endKey) from cursor (the start
+// when empty) until the query's budget runs out, and returns the key the
+// next page starts at, or "" once the range was read to its end. A single
+// asset larger than the whole budget is an error, since no page could hold
+// it.
+func scanPage(ctx contractapi.TransactionContextInterface, startKey string, endKey string, cursor string, visit func(key string, value []byte) error) (string, error) {
+	from := startKey
+	if cursor > startKey {
+		from = cursor
+	}
+	err := newAssetStore(ctx).Range(from, endKey, visit)
+	var exceeded *store.BudgetExceeded
+	if errors.As(err, &exceeded) && exceeded.Results > 0 {
+		return exceeded.NextKey, nil
+	}
+
+	return "", err
+}
+
+// scanPrivatePage is scanPage over a private data collection
+func scanPrivatePage(ctx contractapi.TransactionContextInterface, collection string, startKey string, endKey string, cursor string, visit func(key string, value []byte) error) (string, error) {
+	from := startKey
+	if cursor > startKey {
+		from = cursor
+	}
+	err := privateRange(ctx, collection, from, endKey, visit)
+	var exceeded *store.BudgetExceeded
+	if errors.As(err, &exceeded) && exceeded.Results > 0 {
+		return exceeded.NextKey, nil
+	}
+
+	return "", err
+}
+
+// privateRange visits the entries of a private data collection between
+// startKey and endKey, holding the query to the same budget as the range
+// queries of the world state
+func privateRange(ctx contractapi.TransactionContextInterface, collection string, startKey string, endKey string, visit func(key string, value []byte) error) error {
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByRange(collection, startKey, endKey)
+	if err != nil {
+		return newError(ctx, ErrLedgerRead, collection+"/"+startKey, err)
+	}
+	defer resultsIterator.Close()
+
+	tally := store.NewTally(queryLimits(ctx), startKey, endKey)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		if exceeded := tally.Admit(queryResponse.Key, queryResponse.Value); exceeded != nil {
+			return truncatedError(ctx, exceeded)
+		}
+		if err := visit(queryResponse.Key, queryResponse.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
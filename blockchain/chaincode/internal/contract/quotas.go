@@ -0,0 +1,266 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Quota defaults; config quotas.yieldPerHa (or quotas.yieldPerHa.<crop>),
+// quotas.warnRatio and quotas.seasonStartMonth override them. Olive seasons
+// run from the October harvest to the following September.
+const (
+	defaultQuotaYieldPerHa  = 3000
+	defaultQuotaWarnRatio   = 0.9
+	defaultSeasonStartMonth = 10
+)
+
+// SetFarmQuota sets a farm's quota for a season (e.g. 2025-2026, see
+// GetQuotaSeason). quota, when positive, is used as is; otherwise it is
+// areaHa times yieldPerHa, where a zero area is the total of the farm's
+// active plots and a zero yield is the configured yield of the crop. policy
+// is FLAG (over-quota lots are recorded with a warning) or BLOCK (they are
+// refused). Quantities already declared are kept. Admin only.
+func (s *SmartContract) SetFarmQuota(ctx contractapi.TransactionContextInterface, farm string, season string, crop string, areaHa float64, yieldPerHa float64, quota float64, policy string) (*models.FarmQuota, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	farm = strings.TrimSpace(farm)
+	if farm == "" {
+		return nil, newError(ctx, ErrFarmRequired)
+	}
+	if !isQuotaSeason(season) {
+		return nil, newError(ctx, ErrSeasonInvalid, season)
+	}
+	policy = strings.ToUpper(strings.TrimSpace(policy))
+	if policy == "" {
+		policy = models.QuotaFlag
+	}
+	if policy != models.QuotaFlag && policy != models.QuotaBlock {
+		return nil, newError(ctx, ErrQuotaPolicyInvalid, models.QuotaFlag, models.QuotaBlock)
+	}
+	if areaHa < 0 || yieldPerHa < 0 || quota < 0 {
+		return nil, newError(ctx, ErrQuotaValuesNegative)
+	}
+
+	if quota == 0 {
+		if areaHa == 0 {
+			plots, err := s.GetPlotsForFarm(ctx, farm)
+			if err != nil {
+				return nil, err
+			}
+			for _, plot := range plots {
+				if plot.Status == models.PlotActive {
+					areaHa += plot.AreaHa
+				}
+			}
+		}
+		if areaHa == 0 {
+			return nil, newError(ctx, ErrFarmAreaUnknown, farm)
+		}
+		if yieldPerHa == 0 {
+			yieldPerHa = cropYieldPerHa(ctx, crop)
+		}
+		quota = areaHa * yieldPerHa
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := readFarmQuota(ctx, farm, season)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		existing = &models.FarmQuota{Farm: farm, Season: season, CreatedAt: now, History: []models.History{}}
+	}
+	existing.Crop = crop
+	existing.AreaHa = areaHa
+	existing.YieldPerHa = yieldPerHa
+	existing.Quota = quota
+	existing.WarnRatio = configFloat(ctx, "quotas", "warnRatio", defaultQuotaWarnRatio)
+	existing.Policy = policy
+	existing.UpdatedAt = now
+	existing.History = append(existing.History, models.History{
+		Timestamp: now,
+		Action:    "QUOTA_SET",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Quota %.2f (%s), %.2f already declared", quota, policy, existing.Declared),
+	})
+
+	if err := putFarmQuota(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// ReadFarmQuota returns a farm's quota for a season
+func (s *SmartContract) ReadFarmQuota(ctx contractapi.TransactionContextInterface, farm string, season string) (*models.FarmQuota, error) {
+	quota, err := readFarmQuota(ctx, farm, season)
+	if err != nil {
+		return nil, err
+	}
+	if quota == nil {
+		return nil, newError(ctx, ErrQuotaNotFound, farm, season)
+	}
+
+	return quota, nil
+}
+
+// GetFarmQuotas returns the quotas of a season, or of all seasons when
+// season is empty
+func (s *SmartContract) GetFarmQuotas(ctx contractapi.TransactionContextInterface, season string) ([]*models.FarmQuota, error) {
+	prefix := "QUOTA_"
+	if season != "" {
+		prefix += season + "_"
+	}
+	quotas := []*models.FarmQuota{}
+	err := newAssetStore(ctx).Range(prefix, prefix+"~", func(_ string, value []byte) error {
+		var quota models.FarmQuota
+		if err := json.Unmarshal(value, &quota); err != nil {
+			return err
+		}
+		quotas = append(quotas, &quota)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return quotas, nil
+}
+
+// GetQuotaSeason returns the season a harvest date (YYYY-MM-DD) falls in
+func (s *SmartContract) GetQuotaSeason(ctx contractapi.TransactionContextInterface, harvestDate string) (string, error) {
+	return quotaSeason(ctx, harvestDate)
+}
+
+// checkFarmQuota tests a new lot against its farm's quota for the season of
+// its harvest, refusing it under a BLOCK policy and otherwise returning the
+// warning to record; lots of farms without a quota are not checked
+func checkFarmQuota(ctx contractapi.TransactionContextInterface, farm string, harvestDate string, quantity float64, now string) (*models.ValidationWarning, error) {
+	if strings.TrimSpace(farm) == "" {
+		return nil, nil
+	}
+	season, err := quotaSeason(ctx, harvestDate)
+	if err != nil {
+		return nil, err
+	}
+	quota, err := readFarmQuota(ctx, farm, season)
+	if err != nil || quota == nil || quota.Quota <= 0 {
+		return nil, err
+	}
+
+	declared := quota.Declared + quantity
+	if declared > quota.Quota {
+		if quota.Policy == models.QuotaBlock {
+			return nil, newError(ctx, ErrQuotaExceeded, quota.Farm, declared, season, quota.Quota)
+		}
+		warning := newValidationWarning(models.WarnQuotaExceeded, fmt.Sprintf("farm %q has declared %.2f in season %s, over its quota of %.2f", quota.Farm, declared, season, quota.Quota), now)
+		return &warning, nil
+	}
+	if declared >= quota.Quota*quota.WarnRatio {
+		warning := newValidationWarning(models.WarnQuotaNear, fmt.Sprintf("farm %q has declared %.2f in season %s, %.0f%% of its quota of %.2f", quota.Farm, declared, season, declared/quota.Quota*100, quota.Quota), now)
+		return &warning, nil
+	}
+
+	return nil, nil
+}
+
+// recordQuotaUsage adds a stored lot to its farm's declared quantity for the
+// season. Lots of one farm and season are serialized on the quota record,
+// so concurrent creations conflict and are retried.
+func recordQuotaUsage(ctx contractapi.TransactionContextInterface, waste *models.Waste) error {
+	if strings.TrimSpace(waste.Farm) == "" {
+		return nil
+	}
+	season, err := quotaSeason(ctx, waste.HarvestDate)
+	if err != nil {
+		return err
+	}
+	quota, err := readFarmQuota(ctx, waste.Farm, season)
+	if err != nil || quota == nil {
+		return err
+	}
+
+	quota.Declared += waste.Quantity
+	quota.Lots++
+	quota.UpdatedAt = waste.CreatedAt
+
+	return putFarmQuota(ctx, quota)
+}
+
+// quotaSeason names the season of a harvest date, e.g. 2025-2026 for
+// 2025-11-03 with seasons starting in October, or 2025 when they start in
+// January
+func quotaSeason(ctx contractapi.TransactionContextInterface, harvestDate string) (string, error) {
+	date, err := time.Parse("2006-01-02", harvestDate)
+	if err != nil {
+		return "", newError(ctx, ErrHarvestDate, harvestDate)
+	}
+	start := configInt(ctx, "quotas", "seasonStartMonth", defaultSeasonStartMonth)
+	if start <= 1 || start > 12 {
+		return fmt.Sprintf("%d", date.Year()), nil
+	}
+	year := date.Year()
+	if int(date.Month()) < start {
+		year--
+	}
+
+	return fmt.Sprintf("%d-%d", year, year+1), nil
+}
+
+// isQuotaSeason accepts YYYY and YYYY-YYYY season names
+func isQuotaSeason(season string) bool {
+	var from, to int
+	if n, _ := fmt.Sscanf(season, "%4d-%4d", &from, &to); n == 2 {
+		return to == from+1 && len(season) == len("2006-2007")
+	}
+	_, err := time.Parse("2006", season)
+
+	return err == nil
+}
+
+// cropYieldPerHa is the configured plausible yield of a crop per hectare
+func cropYieldPerHa(ctx contractapi.TransactionContextInterface, crop string) float64 {
+	yield := configFloat(ctx, "quotas", "yieldPerHa", defaultQuotaYieldPerHa)
+	if crop == "" {
+		return yield
+	}
+
+	return configFloat(ctx, "quotas", "yieldPerHa."+strings.ToLower(crop), yield)
+}
+
+func quotaKey(farm string, season string) string {
+	return "QUOTA_" + season + "_" + strings.ToLower(strings.TrimSpace(farm))
+}
+
+// readFarmQuota returns a farm's quota for a season, or nil if none is set
+func readFarmQuota(ctx contractapi.TransactionContextInterface, farm string, season string) (*models.FarmQuota, error) {
+	var quota models.FarmQuota
+	found, err := newAssetStore(ctx).Get(quotaKey(farm, season), &quota)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, quotaKey(farm, season), err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &quota, nil
+}
+
+func putFarmQuota(ctx contractapi.TransactionContextInterface, quota *models.FarmQuota) error {
+	return newAssetStore(ctx).Put(quotaKey(quota.Farm, quota.Season), quota)
+}
@@ -0,0 +1,117 @@
+package contract
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// referenceShards is how many counters share the numbering of one asset type
+// and year. A transaction only touches the shard its ID hashes to, so
+// concurrent creations rarely collide on the same key. Never change it:
+// references already issued this year would be issued again.
+const referenceShards = 16
+
+// referencePrefixes abbreviates the asset types in references
+var referencePrefixes = map[string]string{
+	"WASTE":      "WST",
+	"EXTRACTION": "EXT",
+	"RECYCLING":  "RCY",
+}
+
+// GetAssetByReference returns the asset stamped with a reference such as
+// WST-2025-000123
+func (s *SmartContract) GetAssetByReference(ctx contractapi.TransactionContextInterface, reference string) (*models.ReferencedAsset, error) {
+	reference = strings.ToUpper(strings.TrimSpace(reference))
+	var found models.ReferencedAsset
+	ok, err := newAssetStore(ctx).Get("REFERENCE_"+reference, &found)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, newError(ctx, ErrReferenceNotFound, reference)
+	}
+
+	switch found.AssetType {
+	case "WASTE":
+		if found.Waste, err = s.ReadWaste(ctx, found.AssetID); err != nil {
+			return nil, err
+		}
+	case "EXTRACTION":
+		if found.Extraction, err = s.readExtraction(ctx, found.AssetID); err != nil {
+			return nil, err
+		}
+	case "RECYCLING":
+		if found.Recycling, err = s.GetRecycling(ctx, found.AssetID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &found, nil
+}
+
+// assignReference numbers a new asset within its type and the year of the
+// transaction and indexes it under the reference. Numbers are unique and
+// grow within each shard; shards interleave, so the sequence has gaps
+// until every shard has caught up.
+func assignReference(ctx contractapi.TransactionContextInterface, assetType string, id string) (string, error) {
+	claim, err := claimReference(ctx, assetType, id)
+	if err != nil {
+		return "", err
+	}
+
+	return claim.entry.Reference, claim.store(ctx)
+}
+
+// referenceClaim is a reference numbered for a new asset and not yet
+// written, for operations that validate everything they write first
+type referenceClaim struct {
+	counterKey string
+	count      int
+	entry      *models.ReferencedAsset
+}
+
+// claimReference numbers a new asset as assignReference does without
+// writing anything; store writes the shard counter and the index
+func claimReference(ctx contractapi.TransactionContextInterface, assetType string, id string) (*referenceClaim, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, newError(ctx, ErrTimestamp, err)
+	}
+	year := time.Unix(ts.GetSeconds(), 0).UTC().Year()
+	txID := ctx.GetStub().GetTxID()
+
+	hash := fnv.New32a()
+	hash.Write([]byte(txID))
+	shard := int(hash.Sum32() % referenceShards)
+
+	// Reads do not see the transaction's own writes, so assets created by
+	// the same transaction are counted in memory on top of the stored count
+	key := fmt.Sprintf("SEQUENCE_%s_%d_%02d", assetType, year, shard)
+	var count int
+	if _, err := newAssetStore(ctx).Get(key, &count); err != nil {
+		return nil, err
+	}
+	local := count + transactionState(ctx).next(key) - 1
+
+	reference := fmt.Sprintf("%s-%d-%06d", referencePrefixes[assetType], year, local*referenceShards+shard+1)
+
+	return &referenceClaim{
+		counterKey: key,
+		count:      local + 1,
+		entry:      &models.ReferencedAsset{Reference: reference, AssetType: assetType, AssetID: id},
+	}, nil
+}
+
+// store writes the shard counter and the index entry of a claimed reference
+func (c *referenceClaim) store(ctx contractapi.TransactionContextInterface) error {
+	if err := newAssetStore(ctx).Put(c.counterKey, c.count); err != nil {
+		return err
+	}
+
+	return newAssetStore(ctx).Put("REFERENCE_"+c.entry.Reference, c.entry)
+}
@@ -0,0 +1,186 @@
+package contract
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SetResearchConsent records whether a participant's lots may appear in
+// research datasets; the participant's organization (or the admins of a
+// cooperative participant) decides
+func (s *SmartContract) SetResearchConsent(ctx contractapi.TransactionContextInterface, participantId string, granted bool) (*models.ResearchConsent, error) {
+	if _, err := s.requireParticipantSide(ctx, participantId); err != nil {
+		return nil, err
+	}
+
+	consent, err := readResearchConsent(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	action := "CONSENT_WITHDRAWN"
+	if granted {
+		action = "CONSENT_GRANTED"
+	}
+	consent.Granted = granted
+	consent.UpdatedBy = actor
+	consent.UpdatedAt = now
+	consent.History = append(consent.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+	})
+
+	if err := newAssetStore(ctx).Put("CONSENT_"+participantId, consent); err != nil {
+		return nil, err
+	}
+
+	return consent, nil
+}
+
+// GetResearchConsent returns a participant's research consent; participants
+// that never decided have not consented
+func (s *SmartContract) GetResearchConsent(ctx contractapi.TransactionContextInterface, participantId string) (*models.ResearchConsent, error) {
+	return readResearchConsent(ctx, participantId)
+}
+
+// GetResearchLots returns the lots of participants who consented to research
+// use, as stored on the world state (pseudonymized); the export job strips
+// and generalizes them further. Admin only.
+func (s *SmartContract) GetResearchLots(ctx contractapi.TransactionContextInterface) ([]*models.Waste, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	consented := map[string]bool{}
+	err := newAssetStore(ctx).Range("CONSENT_", "CONSENT_~", func(_ string, value []byte) error {
+		var consent models.ResearchConsent
+		if err := json.Unmarshal(value, &consent); err != nil {
+			return err
+		}
+		consented[consent.ParticipantID] = consent.Granted
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lots := []*models.Waste{}
+	for _, waste := range wastes {
+		if waste.ParticipantID != "" && consented[waste.ParticipantID] {
+			lots = append(lots, waste)
+		}
+	}
+
+	return lots, nil
+}
+
+// RecordDatasetExport anchors an exported research dataset: who received it,
+// for what purpose, its fields and the sha256 hex digest of its content.
+// Admin only.
+func (s *SmartContract) RecordDatasetExport(ctx contractapi.TransactionContextInterface, id string, requester string, purpose string, recordCount int, fields string, contentHash string) (*models.DatasetExport, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if id == "" || requester == "" || purpose == "" {
+		return nil, newError(ctx, ErrDatasetFieldsRequired)
+	}
+	contentHash = strings.ToLower(contentHash)
+	if !commentHashPattern.MatchString(contentHash) {
+		return nil, newError(ctx, ErrContentHashInvalid)
+	}
+	if recordCount < 0 {
+		return nil, newError(ctx, ErrRecordCountNegative)
+	}
+
+	exists, err := newAssetStore(ctx).Exists("DATASET_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrDatasetAlreadyExists, id)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.DatasetExport{
+		ID:          id,
+		Requester:   requester,
+		Purpose:     purpose,
+		RecordCount: recordCount,
+		Fields:      splitList(fields),
+		ContentHash: contentHash,
+		ExportedBy:  actor,
+		ExportedAt:  now,
+	}
+	if err := newAssetStore(ctx).Put("DATASET_"+id, export); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// ReadDatasetExport returns the dataset export recorded with the given id
+func (s *SmartContract) ReadDatasetExport(ctx contractapi.TransactionContextInterface, id string) (*models.DatasetExport, error) {
+	var export models.DatasetExport
+	found, err := newAssetStore(ctx).Get("DATASET_"+id, &export)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "DATASET_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrDatasetNotFound, id)
+	}
+
+	return &export, nil
+}
+
+// GetDatasetExports returns every recorded dataset export
+func (s *SmartContract) GetDatasetExports(ctx contractapi.TransactionContextInterface) ([]*models.DatasetExport, error) {
+	exports := []*models.DatasetExport{}
+	err := newAssetStore(ctx).Range("DATASET_", "DATASET_~", func(_ string, value []byte) error {
+		var export models.DatasetExport
+		if err := json.Unmarshal(value, &export); err != nil {
+			return err
+		}
+		exports = append(exports, &export)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+func readResearchConsent(ctx contractapi.TransactionContextInterface, participantID string) (*models.ResearchConsent, error) {
+	consent := &models.ResearchConsent{ParticipantID: participantID, History: []models.History{}}
+	if _, err := newAssetStore(ctx).Get("CONSENT_"+participantID, consent); err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "CONSENT_"+participantID, err)
+	}
+
+	return consent, nil
+}
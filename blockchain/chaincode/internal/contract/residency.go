@@ -0,0 +1,332 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DefineResidencyRegion pins the private data of participants of the given
+// organizations to a region's own collection (admin only); mspsJson and
+// readersJson are lists of MSP IDs. The collection must be declared in
+// collections_config.json with only the region's organizations as members.
+// Participants registered from then on are pinned to it; earlier ones stay
+// where their data was written. Sealed marketplace bids are not routed: an
+// award has to see every bid of a listing.
+func (s *SmartContract) DefineResidencyRegion(ctx contractapi.TransactionContextInterface, code string, label string, collection string, mspsJson string, readersJson string) (*models.ResidencyRegion, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !taxonomyCodePattern.MatchString(code) {
+		return nil, newError(ctx, ErrRegionCodeInvalid)
+	}
+	collection = strings.TrimSpace(collection)
+	if collection == "" || collection == piiCollection || strings.HasPrefix(collection, implicitCollectionPrefix) {
+		return nil, newError(ctx, ErrRegionCollectionRequired, code)
+	}
+
+	var msps []string
+	if err := json.Unmarshal([]byte(mspsJson), &msps); err != nil {
+		return nil, newError(ctx, ErrRegionOrganizationsInvalid, err)
+	}
+	if len(msps) == 0 {
+		return nil, newError(ctx, ErrRegionOrganizationsRequired, code)
+	}
+	readers := []string{}
+	if strings.TrimSpace(readersJson) != "" {
+		if err := json.Unmarshal([]byte(readersJson), &readers); err != nil {
+			return nil, newError(ctx, ErrRegionReadersInvalid, err)
+		}
+	}
+
+	regions, err := loadResidencyRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range regions {
+		if other.Code == code {
+			continue
+		}
+		if other.Collection == collection {
+			return nil, newError(ctx, ErrRegionCollectionTaken, collection, other.Code)
+		}
+		for _, mspID := range msps {
+			if containsMSP(other.MSPs, mspID) {
+				return nil, newError(ctx, ErrRegionAlreadyPinned, mspID, other.Code)
+			}
+		}
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := readResidencyRegion(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	action := "REGION_CHANGED"
+	if region == nil {
+		action = "REGION_DEFINED"
+		region = &models.ResidencyRegion{
+			Code:      code,
+			CreatedAt: now,
+			History:   []models.History{},
+		}
+	} else if region.Collection != collection {
+		pinned, err := countResidencyPins(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if pinned > 0 {
+			return nil, newError(ctx, ErrRegionInUse, code, pinned, region.Collection)
+		}
+	}
+	region.Label = label
+	region.Collection = collection
+	region.MSPs = msps
+	region.Readers = readers
+	region.Version++
+	region.UpdatedAt = now
+	region.History = append(region.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("Version %d: %s held by %s, readable by %s", region.Version, collection, strings.Join(msps, ", "), strings.Join(readers, ", ")),
+	})
+
+	if err := newAssetStore(ctx).Put("RESIDENCY_"+code, region); err != nil {
+		return nil, err
+	}
+
+	return region, nil
+}
+
+// RemoveResidencyRegion drops a region no participant is pinned to (admin
+// only)
+func (s *SmartContract) RemoveResidencyRegion(ctx contractapi.TransactionContextInterface, code string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	code = strings.ToUpper(code)
+	region, err := readResidencyRegion(ctx, code)
+	if err != nil {
+		return err
+	}
+	if region == nil {
+		return newError(ctx, ErrRegionNotFound, code)
+	}
+	pinned, err := countResidencyPins(ctx, code)
+	if err != nil {
+		return err
+	}
+	if pinned > 0 {
+		return newError(ctx, ErrRegionInUse, code, pinned, region.Collection)
+	}
+
+	return newAssetStore(ctx).Delete("RESIDENCY_" + code)
+}
+
+// ReadResidencyRegion returns a residency region
+func (s *SmartContract) ReadResidencyRegion(ctx contractapi.TransactionContextInterface, code string) (*models.ResidencyRegion, error) {
+	region, err := readResidencyRegion(ctx, strings.ToUpper(code))
+	if err != nil {
+		return nil, err
+	}
+	if region == nil {
+		return nil, newError(ctx, ErrRegionNotFound, strings.ToUpper(code))
+	}
+
+	return region, nil
+}
+
+// GetResidencyRegions returns all residency regions
+func (s *SmartContract) GetResidencyRegions(ctx contractapi.TransactionContextInterface) ([]*models.ResidencyRegion, error) {
+	return loadResidencyRegions(ctx)
+}
+
+func loadResidencyRegions(ctx contractapi.TransactionContextInterface) ([]*models.ResidencyRegion, error) {
+	regions := []*models.ResidencyRegion{}
+	err := newAssetStore(ctx).Range("RESIDENCY_", "RESIDENCY_~", func(_ string, value []byte) error {
+		var region models.ResidencyRegion
+		if err := json.Unmarshal(value, &region); err != nil {
+			return err
+		}
+		regions = append(regions, &region)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return regions, nil
+}
+
+// ReadWasteFullView assembles a lot with its personal data from the
+// collection it is pinned to. The owning organization and admins may read
+// any lot; organizations of a region and its readers may read the lots
+// pinned to it. Meant to be evaluated on a peer of the region: elsewhere
+// the view comes back unresolved, naming the organizations whose peers
+// hold the data.
+func (s *SmartContract) ReadWasteFullView(ctx contractapi.TransactionContextInterface, wasteId string) (*models.WasteFullView, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	view := &models.WasteFullView{Waste: waste, Collection: piiCollection}
+	if waste.ParticipantID == "" {
+		view.Resolved = true
+		return view, nil
+	}
+
+	pin, err := readResidencyPin(ctx, waste.ParticipantID)
+	if err != nil {
+		return nil, err
+	}
+	var region *models.ResidencyRegion
+	if pin != nil {
+		view.Region = pin.Region
+		view.Collection = pin.Collection
+		if region, err = readResidencyRegion(ctx, pin.Region); err != nil {
+			return nil, err
+		}
+	}
+
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allowed := mspID == waste.OwnerMSP || isAdmin(ctx)
+	if region != nil {
+		allowed = allowed || containsMSP(region.MSPs, mspID) || containsMSP(region.Readers, mspID)
+	}
+	if !allowed {
+		if region != nil {
+			return nil, newError(ctx, ErrPersonalDataRegionRestricted, wasteId, region.Code)
+		}
+		return nil, newError(ctx, ErrPersonalDataRestricted, wasteId)
+	}
+
+	if region != nil {
+		peerMSP, err := shim.GetMSPID()
+		if err != nil {
+			return nil, err
+		}
+		if !containsMSP(region.MSPs, peerMSP) {
+			view.Hosts = region.MSPs
+			view.Reason = fmt.Sprintf("personal data of waste %s stays on the peers of region %s", wasteId, region.Code)
+			return view, nil
+		}
+	}
+
+	pii, err := readWastePIIFrom(ctx, view.Collection, waste.ID)
+	if err != nil {
+		return nil, err
+	}
+	view.PersonalData = pii
+	view.Resolved = true
+	if pii == nil {
+		view.Reason = fmt.Sprintf("waste %s has no personal data on record", wasteId)
+	}
+
+	return view, nil
+}
+
+func readResidencyRegion(ctx contractapi.TransactionContextInterface, code string) (*models.ResidencyRegion, error) {
+	var region models.ResidencyRegion
+	found, err := newAssetStore(ctx).Get("RESIDENCY_"+code, &region)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &region, nil
+}
+
+// residencyOf returns the region an organization's participants are pinned
+// to, or nil when their data goes to the shared collection
+func residencyOf(ctx contractapi.TransactionContextInterface, mspID string) (*models.ResidencyRegion, error) {
+	regions, err := loadResidencyRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, region := range regions {
+		if containsMSP(region.MSPs, mspID) {
+			return region, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func readResidencyPin(ctx contractapi.TransactionContextInterface, participantID string) (*models.ResidencyPin, error) {
+	var pin models.ResidencyPin
+	found, err := newAssetStore(ctx).Get("RESIDENCYPIN_"+participantID, &pin)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &pin, nil
+}
+
+// participantCollection returns the collection holding a participant's
+// private data
+func participantCollection(ctx contractapi.TransactionContextInterface, participantID string) (string, error) {
+	pin, err := readResidencyPin(ctx, participantID)
+	if err != nil {
+		return "", err
+	}
+	if pin == nil {
+		return piiCollection, nil
+	}
+
+	return pin.Collection, nil
+}
+
+// residencyCollection returns the collection new participants of an
+// organization are registered in
+func residencyCollection(ctx contractapi.TransactionContextInterface, mspID string) (string, error) {
+	region, err := residencyOf(ctx, mspID)
+	if err != nil || region == nil {
+		return piiCollection, err
+	}
+
+	return region.Collection, nil
+}
+
+func countResidencyPins(ctx contractapi.TransactionContextInterface, code string) (int, error) {
+	pinned := 0
+	err := newAssetStore(ctx).Range("RESIDENCYPIN_", "RESIDENCYPIN_~", func(_ string, value []byte) error {
+		var pin models.ResidencyPin
+		if err := json.Unmarshal(value, &pin); err != nil {
+			return err
+		}
+		if pin.Region == code {
+			pinned++
+		}
+
+		return nil
+	})
+
+	return pinned, err
+}
+
+func containsMSP(msps []string, mspID string) bool {
+	for _, msp := range msps {
+		if msp == mspID {
+			return true
+		}
+	}
+
+	return false
+}
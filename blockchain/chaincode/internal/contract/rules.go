@@ -0,0 +1,319 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chaincode/internal/expr"
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const validationRulePrefix = "VRULE_"
+
+// Asset types validation rules can apply to
+var ruleAssetTypes = []string{"WASTE", "EXTRACTION", "RECYCLING"}
+
+// SetValidationRule creates or replaces a validation rule: a boolean
+// expression every later write of the asset type must satisfy, e.g.
+// waste.quantity <= 50000 || waste.documents.size() > 0. severity is REJECT
+// (the default) or, for WASTE rules, WARN. Admin only.
+func (s *SmartContract) SetValidationRule(ctx contractapi.TransactionContextInterface, id string, assetType string, expression string, message string, severity string) (*models.ValidationRule, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	assetType = strings.ToUpper(strings.TrimSpace(assetType))
+	if !isRuleAssetType(assetType) {
+		return nil, newError(ctx, ErrRuleAssetTypeInvalid, strings.Join(ruleAssetTypes, ", "))
+	}
+	if _, err := expr.Compile(expression); err != nil {
+		return nil, newError(ctx, ErrExpressionInvalid, err)
+	}
+	severity = strings.ToUpper(strings.TrimSpace(severity))
+	if severity == "" {
+		severity = models.RuleReject
+	}
+	if severity != models.RuleReject && severity != models.RuleWarn {
+		return nil, newError(ctx, ErrRuleSeverityInvalid, models.RuleReject, models.RuleWarn)
+	}
+	if severity == models.RuleWarn && assetType != "WASTE" {
+		return nil, newError(ctx, ErrRuleWarningUnsupported, assetType)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rule *models.ValidationRule
+	if id != "" {
+		if rule, err = readValidationRule(ctx, id); err != nil {
+			return nil, err
+		}
+	} else if id, err = newAssetID(ctx, "VRULE"); err != nil {
+		return nil, err
+	}
+	action := "RULE_UPDATED"
+	if rule == nil {
+		rule = &models.ValidationRule{ID: id, CreatedAt: now, History: []models.History{}}
+		action = "RULE_CREATED"
+	}
+	rule.AssetType = assetType
+	rule.Expression = expression
+	rule.Message = message
+	rule.Severity = severity
+	rule.Enabled = true
+	rule.UpdatedAt = now
+	rule.History = append(rule.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("%s %s rule: %s", severity, assetType, expression),
+	})
+
+	if err := newAssetStore(ctx).Put(validationRulePrefix+rule.ID, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// SetValidationRuleEnabled turns a validation rule on or off. Admin only.
+func (s *SmartContract) SetValidationRuleEnabled(ctx contractapi.TransactionContextInterface, id string, enabled bool) (*models.ValidationRule, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	rule, err := readValidationRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, newError(ctx, ErrRuleNotFound, id)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	action := "RULE_DISABLED"
+	if enabled {
+		action = "RULE_ENABLED"
+	}
+	rule.Enabled = enabled
+	rule.UpdatedAt = now
+	rule.History = append(rule.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+	})
+
+	if err := newAssetStore(ctx).Put(validationRulePrefix+rule.ID, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// GetValidationRules returns the validation rules of an asset type, or all
+// of them when assetType is empty
+func (s *SmartContract) GetValidationRules(ctx contractapi.TransactionContextInterface, assetType string) ([]*models.ValidationRule, error) {
+	rules, err := loadValidationRules(ctx, strings.ToUpper(assetType), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// TestValidationRule evaluates an expression against an asset without
+// storing anything, so admins can try a rule before setting it. asset is
+// either the JSON of an asset or the ID of a stored one. Admin only.
+func (s *SmartContract) TestValidationRule(ctx contractapi.TransactionContextInterface, assetType string, expression string, asset string) (*models.RuleEvaluation, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	assetType = strings.ToUpper(strings.TrimSpace(assetType))
+	if !isRuleAssetType(assetType) {
+		return nil, newError(ctx, ErrRuleAssetTypeInvalid, strings.Join(ruleAssetTypes, ", "))
+	}
+
+	value, err := s.ruleTestAsset(ctx, assetType, strings.TrimSpace(asset))
+	if err != nil {
+		return nil, err
+	}
+	vars, err := ruleVariables(ctx, assetType, value)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation := &models.RuleEvaluation{AssetType: assetType, Expression: expression}
+	program, err := expr.Compile(expression)
+	if err != nil {
+		evaluation.Error = err.Error()
+		return evaluation, nil
+	}
+	result, err := program.Eval(vars)
+	if err != nil {
+		evaluation.Error = err.Error()
+		return evaluation, nil
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	evaluation.Result = string(encoded)
+	evaluation.Passed = result == true
+	if _, ok := result.(bool); !ok {
+		evaluation.Error = fmt.Sprintf("expression yields %s, not a bool", encoded)
+	}
+
+	return evaluation, nil
+}
+
+// ruleTestAsset decodes the asset JSON into the model of the asset type,
+// so it has the same fields as at write time, or reads the stored asset
+func (s *SmartContract) ruleTestAsset(ctx contractapi.TransactionContextInterface, assetType string, asset string) (interface{}, error) {
+	if !strings.HasPrefix(asset, "{") {
+		switch assetType {
+		case "WASTE":
+			return s.readWaste(ctx, asset)
+		case "EXTRACTION":
+			return s.readExtraction(ctx, asset)
+		}
+		return s.GetRecycling(ctx, asset)
+	}
+
+	var value interface{}
+	switch assetType {
+	case "WASTE":
+		value = &models.Waste{}
+	case "EXTRACTION":
+		value = &models.Extraction{}
+	default:
+		value = &models.Recycling{}
+	}
+	if err := json.Unmarshal([]byte(asset), value); err != nil {
+		return nil, newError(ctx, ErrAssetJSONInvalid, strings.ToLower(assetType), err)
+	}
+
+	return value, nil
+}
+
+// applyValidationRules evaluates the enabled rules of an asset type against
+// an asset about to be written. A failing REJECT rule fails the write; the
+// warnings of failing WARN rules are returned. A rule that cannot be
+// evaluated (a missing field, a type error) counts as failing.
+func applyValidationRules(ctx contractapi.TransactionContextInterface, assetType string, id string, asset interface{}) ([]models.ValidationWarning, error) {
+	rules, err := loadValidationRules(ctx, assetType, true)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	vars, err := ruleVariables(ctx, assetType, asset)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []models.ValidationWarning
+	for _, rule := range rules {
+		program, err := expr.Compile(rule.Expression)
+		if err != nil {
+			return nil, newError(ctx, ErrRuleEvaluationFailed, rule.ID, err)
+		}
+		passed, err := program.EvalBool(vars)
+		if passed {
+			continue
+		}
+		message := rule.Message
+		if message == "" {
+			message = rule.Expression
+		}
+		if err != nil {
+			message = fmt.Sprintf("%s (%v)", message, err)
+		}
+		if rule.Severity == models.RuleWarn {
+			warnings = append(warnings, newValidationWarning(models.WarnRulePrefix+rule.ID, message, now))
+			continue
+		}
+		return nil, newError(ctx, ErrRuleViolated, strings.ToLower(assetType), id, rule.ID, message)
+	}
+
+	return warnings, nil
+}
+
+// ruleVariables binds an asset and the transaction context to the names
+// rules refer to
+func ruleVariables(ctx contractapi.TransactionContextInterface, assetType string, asset interface{}) (map[string]interface{}, error) {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		strings.ToLower(assetType): expr.Value(asset),
+		"now":                      now,
+		"mspId":                    mspID,
+	}, nil
+}
+
+// loadValidationRules returns the rules of an asset type (all of them when
+// it is empty) in key order, so every peer evaluates them in the same order
+func loadValidationRules(ctx contractapi.TransactionContextInterface, assetType string, enabledOnly bool) ([]*models.ValidationRule, error) {
+	rules := []*models.ValidationRule{}
+	err := newAssetStore(ctx).Range(validationRulePrefix, validationRulePrefix+"~", func(_ string, value []byte) error {
+		var rule models.ValidationRule
+		if err := json.Unmarshal(value, &rule); err != nil {
+			return err
+		}
+		if (assetType == "" || rule.AssetType == assetType) && (rule.Enabled || !enabledOnly) {
+			rules = append(rules, &rule)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func readValidationRule(ctx contractapi.TransactionContextInterface, id string) (*models.ValidationRule, error) {
+	var rule models.ValidationRule
+	found, err := newAssetStore(ctx).Get(validationRulePrefix+id, &rule)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, validationRulePrefix+id, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &rule, nil
+}
+
+func isRuleAssetType(assetType string) bool {
+	for _, t := range ruleAssetTypes {
+		if t == assetType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,298 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SelectAuditSample draws size lots at random from the lots matching
+// filterJson (an AuditPopulation) for physical inspection and moves them to
+// UNDER_AUDIT; admins and auditors only. The transaction ID seeds the draw:
+// lots are ranked by SHA-256(txId|wasteId), so anyone holding the recorded
+// population can check the selection. Lots already under audit are not
+// eligible. Each selected lot is assigned to a registered certifier, drawn
+// from the same seed in proportion to the certifiers' capacities (see
+// pickCertifier). The ID is generated when id is empty.
+func (s *SmartContract) SelectAuditSample(ctx contractapi.TransactionContextInterface, id string, filterJson string, size int) (*models.AuditSample, error) {
+	if !isAdmin(ctx) && !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrSampleSelectForbidden)
+	}
+	if size <= 0 {
+		return nil, newError(ctx, ErrSampleSizeInvalid)
+	}
+	var filter models.AuditPopulation
+	if filterJson != "" {
+		if err := json.Unmarshal([]byte(filterJson), &filter); err != nil {
+			return nil, newError(ctx, ErrSampleFilterInvalid, err)
+		}
+	}
+	for _, date := range []string{filter.HarvestFrom, filter.HarvestTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+
+	var err error
+	if id == "" {
+		if id, err = newAssetID(ctx, "SAMPLE"); err != nil {
+			return nil, err
+		}
+	}
+	exists, err := newAssetStore(ctx).Exists("AUDITSAMPLE_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrSampleAlreadyExists, id)
+	}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	population := []*models.Waste{}
+	for _, waste := range wastes {
+		if waste.Status != models.WasteUnderAudit && inAuditPopulation(waste, filter) {
+			population = append(population, waste)
+		}
+	}
+	if len(population) == 0 {
+		return nil, newError(ctx, ErrSamplePopulationEmpty)
+	}
+	if size > len(population) {
+		return nil, newError(ctx, ErrSampleTooLarge, size, len(population))
+	}
+
+	seed := ctx.GetStub().GetTxID()
+	ranks := map[string]string{}
+	populationIDs := make([]string, len(population))
+	for i, waste := range population {
+		digest := sha256.Sum256([]byte(seed + "|" + waste.ID))
+		ranks[waste.ID] = hex.EncodeToString(digest[:])
+		populationIDs[i] = waste.ID
+	}
+	sort.Strings(populationIDs)
+	sort.Slice(population, func(i, j int) bool {
+		return ranks[population[i].ID] < ranks[population[j].ID]
+	})
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := &models.AuditSample{
+		ID:         id,
+		Filter:     filter,
+		Population: populationIDs,
+		Size:       size,
+		Seed:       seed,
+		Method:     models.SampleSelectionMethod,
+		Lots:       []models.SampledLot{},
+		Status:     models.SampleOpen,
+		SelectedBy: actor,
+		CreatedAt:  now,
+		History: []models.History{{
+			Timestamp: now,
+			Action:    "SELECTED",
+			Actor:     actor,
+			Details:   fmt.Sprintf("%d of %d lots selected", size, len(population)),
+		}},
+	}
+	openSamples, err := s.GetAuditSamples(ctx, models.SampleOpen)
+	if err != nil {
+		return nil, err
+	}
+	assigner, err := newCertifierAssigner(ctx, openSamples)
+	if err != nil {
+		return nil, err
+	}
+	for _, waste := range population[:size] {
+		sample.Lots = append(sample.Lots, models.SampledLot{
+			WasteID:        waste.ID,
+			PreviousStatus: waste.Status,
+		})
+		if err := assigner.assign(ctx, sample, &sample.Lots[len(sample.Lots)-1], now); err != nil {
+			return nil, err
+		}
+		applyStatusChange(waste, models.WasteUnderAudit, actor, fmt.Sprintf("Selected for inspection in audit sample %s", id), now)
+		if err := s.putWaste(ctx, waste); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := putAuditSample(ctx, sample); err != nil {
+		return nil, err
+	}
+	if err := assigner.notifyAssigned(ctx); err != nil {
+		return nil, err
+	}
+
+	return sample, nil
+}
+
+// RecordAuditInspection records the outcome of inspecting a sampled lot and
+// returns the lot to the status it had before the draw; a failed inspection
+// is noted in the lot's history and its owner is notified. Assigned lots
+// are recorded by their certifier or an admin. The sample is completed once
+// all of its lots are inspected.
+func (s *SmartContract) RecordAuditInspection(ctx contractapi.TransactionContextInterface, sampleId string, wasteId string, passed bool, notes string) (*models.AuditSample, error) {
+	if !isAdmin(ctx) && !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrInspectionRecordForbidden)
+	}
+	sample, err := s.ReadAuditSample(ctx, sampleId)
+	if err != nil {
+		return nil, err
+	}
+	lot := sampledLot(sample, wasteId)
+	if lot == nil {
+		return nil, newError(ctx, ErrWasteNotInSample, wasteId, sampleId)
+	}
+	if lot.Inspected {
+		return nil, newError(ctx, ErrWasteAlreadyInspected, wasteId, sampleId)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAssignedCertifier(ctx, actor, sample, lot); err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lot.Inspected = true
+	lot.Passed = passed
+	lot.Notes = notes
+	lot.InspectedBy = actor
+	lot.InspectedAt = now
+
+	outcome := "passed"
+	if !passed {
+		outcome = "failed"
+	}
+	sample.History = append(sample.History, models.History{
+		Timestamp: now,
+		Action:    "LOT_INSPECTED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Waste %s %s inspection", wasteId, outcome),
+	})
+	if sampleInspected(sample) {
+		sample.Status = models.SampleCompleted
+		sample.CompletedAt = now
+	}
+
+	if waste.Status == models.WasteUnderAudit {
+		applyStatusChange(waste, lot.PreviousStatus, actor, fmt.Sprintf("Audit sample %s inspection %s. %s", sampleId, outcome, notes), now)
+	} else {
+		waste.UpdatedAt = now
+		waste.History = append(waste.History, models.History{
+			Timestamp: now,
+			Action:    "AUDIT_INSPECTED",
+			Actor:     actor,
+			Details:   fmt.Sprintf("Audit sample %s inspection %s. %s", sampleId, outcome, notes),
+		})
+	}
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+	if err := putAuditSample(ctx, sample); err != nil {
+		return nil, err
+	}
+
+	if !passed && waste.OwnerMSP != "" {
+		message := fmt.Sprintf("Waste %s failed its audit inspection (sample %s): %s", wasteId, sampleId, notes)
+		if err := notify(ctx, waste.OwnerMSP, models.NotifyAuditFailed, "WASTE_"+wasteId, message); err != nil {
+			return nil, err
+		}
+	}
+
+	return sample, nil
+}
+
+// ReadAuditSample returns the audit sample stored with the given id
+func (s *SmartContract) ReadAuditSample(ctx contractapi.TransactionContextInterface, id string) (*models.AuditSample, error) {
+	var sample models.AuditSample
+	found, err := newAssetStore(ctx).Get("AUDITSAMPLE_"+id, &sample)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "AUDITSAMPLE_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrSampleNotFound, id)
+	}
+
+	return &sample, nil
+}
+
+// GetAuditSamples returns the audit samples, newest first; status filters
+// them when set
+func (s *SmartContract) GetAuditSamples(ctx contractapi.TransactionContextInterface, status string) ([]*models.AuditSample, error) {
+	samples := []*models.AuditSample{}
+	err := newAssetStore(ctx).Range("AUDITSAMPLE_", "AUDITSAMPLE_~", func(_ string, value []byte) error {
+		var sample models.AuditSample
+		if err := json.Unmarshal(value, &sample); err != nil {
+			return err
+		}
+		if status == "" || sample.Status == status {
+			samples = append(samples, &sample)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].CreatedAt > samples[j].CreatedAt
+	})
+
+	return samples, nil
+}
+
+// inAuditPopulation reports whether a lot matches a sample filter
+func inAuditPopulation(waste *models.Waste, filter models.AuditPopulation) bool {
+	return (filter.Type == "" || waste.Type == filter.Type) &&
+		(filter.Category == "" || waste.Category == filter.Category) &&
+		(filter.Status == "" || waste.Status == filter.Status) &&
+		(filter.Region == "" || waste.Region == filter.Region) &&
+		(filter.OwnerMSP == "" || waste.OwnerMSP == filter.OwnerMSP) &&
+		(filter.HarvestFrom == "" || waste.HarvestDate >= filter.HarvestFrom) &&
+		(filter.HarvestTo == "" || waste.HarvestDate <= filter.HarvestTo)
+}
+
+// sampleInspected reports whether every lot of a sample was inspected
+func sampleInspected(sample *models.AuditSample) bool {
+	for _, lot := range sample.Lots {
+		if !lot.Inspected {
+			return false
+		}
+	}
+
+	return true
+}
+
+func putAuditSample(ctx contractapi.TransactionContextInterface, sample *models.AuditSample) error {
+	return newAssetStore(ctx).Put("AUDITSAMPLE_"+sample.ID, sample)
+}
@@ -0,0 +1,162 @@
+package contract
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ComputeParticipantScorecard rates an organization (its MSP ID; the
+// caller's when empty) on on-time delivery, the grades of its extraction
+// runs, the regrade disputes it was party to and its SLA breaches between
+// from and to (YYYY-MM-DD, inclusive, either may be empty). Scorecards only
+// hold aggregates, so every member may compare participants.
+func (s *SmartContract) ComputeParticipantScorecard(ctx contractapi.TransactionContextInterface, participant string, from string, to string) (*models.ParticipantScorecard, error) {
+	if participant == "" {
+		mspID, err := callerMSP(ctx)
+		if err != nil {
+			return nil, err
+		}
+		participant = mspID
+	}
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	card := &models.ParticipantScorecard{Participant: participant, From: from, To: to, ComputedAt: now}
+
+	if err := scoreContractDeliveries(ctx, card); err != nil {
+		return nil, err
+	}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, waste := range wastes {
+		sla := waste.SLA
+		if sla == nil || sla.Processor != participant || !receivedBetween(sla, from, to) {
+			continue
+		}
+		switch sla.Status {
+		case models.SLAMet:
+			card.Deliveries++
+			card.OnTimeDeliveries++
+		case models.SLABreached:
+			card.Deliveries++
+			card.SLABreaches++
+		}
+	}
+
+	extractions, err := s.GetAllExtractions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	processors := map[string]string{}
+	scoreTotal := 0
+	for _, extraction := range extractions {
+		processor := extractionProcessor(extraction)
+		processors[extraction.ID] = processor
+		rank := gradeRank(extraction.QualityGrade)
+		if processor != participant || rank == len(qualityGrades) || !onDayBetween(extraction.CreatedAt, from, to) {
+			continue
+		}
+		card.GradedRuns++
+		scoreTotal += len(qualityGrades) - rank
+	}
+
+	err = newAssetStore(ctx).Range("REGRADE_", "REGRADE_~", func(_ string, value []byte) error {
+		var request models.RegradeRequest
+		if err := json.Unmarshal(value, &request); err != nil {
+			return err
+		}
+		party := request.RequestedMSP == participant || processors[request.ExtractionID] == participant
+		if party && onDayBetween(request.CreatedAt, from, to) {
+			card.Disputes++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if card.Deliveries > 0 {
+		card.OnTimeDeliveryRate = math.Round(float64(card.OnTimeDeliveries)/float64(card.Deliveries)*10000) / 100
+	}
+	if card.GradedRuns > 0 {
+		average := float64(scoreTotal) / float64(card.GradedRuns)
+		card.AverageGradeScore = math.Round(average*100) / 100
+		card.AverageGrade = qualityGrades[len(qualityGrades)-int(math.Round(average))]
+	}
+
+	return card, nil
+}
+
+// scoreContractDeliveries counts the deliveries the participant made under
+// its supply contracts in the scorecard's period; deliveries that drew a
+// lateness penalty are late
+func scoreContractDeliveries(ctx contractapi.TransactionContextInterface, card *models.ParticipantScorecard) error {
+	contracts, err := loadSupplyContracts(ctx, func(contract *models.SupplyContract) bool {
+		return contract.Supplier == card.Participant
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, contract := range contracts {
+		adjustments, err := loadContractAdjustments(ctx, contract.ID)
+		if err != nil {
+			return err
+		}
+		late := map[string]bool{}
+		for _, adjustment := range adjustments {
+			if adjustment.DaysLate > 0 {
+				late[adjustment.WasteID] = true
+			}
+		}
+		for _, delivery := range contract.Deliveries {
+			if !onDayBetween(delivery.DeliveredAt, card.From, card.To) {
+				continue
+			}
+			card.Deliveries++
+			if !late[delivery.WasteID] {
+				card.OnTimeDeliveries++
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractionProcessor is the organization that ran an extraction, recorded
+// as the grader of the grade it inherited from its source lot
+func extractionProcessor(extraction *models.Extraction) string {
+	if len(extraction.Grading) == 0 || extraction.Grading[0].Kind != models.GradeInherited {
+		return ""
+	}
+
+	return extraction.Grading[0].GraderMSP
+}
+
+// onDayBetween reports whether an RFC3339 timestamp falls between from and
+// to (YYYY-MM-DD, inclusive, either may be empty)
+func onDayBetween(timestamp string, from string, to string) bool {
+	if len(timestamp) < len("2006-01-02") {
+		return false
+	}
+	day := timestamp[:len("2006-01-02")]
+
+	return (from == "" || day >= from) && (to == "" || day <= to)
+}
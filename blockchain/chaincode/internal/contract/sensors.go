@@ -0,0 +1,274 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Quality grades from best to worst; lots start at the first grade
+var qualityGrades = []string{"A", "B", "C", "D"}
+
+// defaultBreachRules apply unless "quality.breachRules" holds a JSON rule list
+var defaultBreachRules = []models.BreachRule{
+	{Name: "warm", MaxTemperature: 25, MaxDurationMinutes: 240, DowngradeSteps: 1},
+	{Name: "hot", MaxTemperature: 35, MaxDurationMinutes: 30, DowngradeSteps: 2},
+}
+
+// RecordSensorReading stores a temperature reading (recordedAt in RFC3339) for
+// a WASTE or EXTRACTION lot and applies the breach rules, downgrading the
+// lot's quality grade when a breach has lasted too long. Registered sensor
+// gateways only (oracle.sensorIdentities).
+func (s *SmartContract) RecordSensorReading(ctx contractapi.TransactionContextInterface, assetType string, assetId string, sensorId string, temperature float64, recordedAt string) error {
+	if err := requireSensorGateway(ctx); err != nil {
+		return err
+	}
+	if assetType != "WASTE" && assetType != "EXTRACTION" {
+		return newError(ctx, ErrSensorAssetTypeInvalid)
+	}
+	if sensorId == "" {
+		return newError(ctx, ErrSensorIDRequired)
+	}
+	readingTime, err := time.Parse(time.RFC3339, recordedAt)
+	if err != nil {
+		return newError(ctx, ErrRecordedAtInvalid, err)
+	}
+
+	rules, err := loadBreachRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	log, err := s.readSensorLog(ctx, assetType, assetId)
+	if err != nil {
+		return err
+	}
+	if n := len(log.Readings); n > 0 {
+		last, err := time.Parse(time.RFC3339, log.Readings[n-1].RecordedAt)
+		if err == nil && readingTime.Before(last) {
+			return newError(ctx, ErrReadingOutOfOrder, recordedAt, log.Readings[n-1].RecordedAt)
+		}
+	}
+	log.Readings = append(log.Readings, models.SensorReading{
+		SensorID:    sensorId,
+		Temperature: temperature,
+		RecordedAt:  recordedAt,
+	})
+
+	// Evaluate each rule against the ongoing breach
+	var downgrades []qualityDowngrade
+	for _, rule := range rules {
+		if temperature <= rule.MaxTemperature {
+			delete(log.BreachStarted, rule.Name)
+			delete(log.Triggered, rule.Name)
+			continue
+		}
+
+		started, ok := log.BreachStarted[rule.Name]
+		if !ok {
+			log.BreachStarted[rule.Name] = recordedAt
+			started = recordedAt
+		}
+		if log.Triggered[rule.Name] {
+			continue
+		}
+
+		startTime, err := time.Parse(time.RFC3339, started)
+		if err != nil {
+			return err
+		}
+		minutes := int(readingTime.Sub(startTime).Minutes())
+		if minutes >= rule.MaxDurationMinutes {
+			log.Triggered[rule.Name] = true
+			downgrades = append(downgrades, qualityDowngrade{
+				steps:  rule.DowngradeSteps,
+				reason: fmt.Sprintf("Temperature above %.1f°C for %d minutes (rule %s)", rule.MaxTemperature, minutes, rule.Name),
+			})
+		}
+	}
+
+	if len(downgrades) > 0 {
+		if err := s.downgradeQuality(ctx, assetType, assetId, sensorId, recordedAt, downgrades); err != nil {
+			return err
+		}
+	}
+
+	return s.putSensorLog(ctx, log)
+}
+
+// GetSensorLog returns the readings and breach state of a lot
+func (s *SmartContract) GetSensorLog(ctx contractapi.TransactionContextInterface, assetType string, assetId string) (*models.SensorLog, error) {
+	return s.readSensorLog(ctx, assetType, assetId)
+}
+
+// qualityDowngrade is a triggered breach rule awaiting application
+type qualityDowngrade struct {
+	steps  int
+	reason string
+}
+
+// downgradeQuality applies triggered breach rules to a lot's quality grade and
+// records one history (waste) or grading (extraction) entry per rule
+func (s *SmartContract) downgradeQuality(ctx contractapi.TransactionContextInterface, assetType string, assetId string, sensorId string, recordedAt string, downgrades []qualityDowngrade) error {
+	if assetType == "WASTE" {
+		waste, err := s.readWaste(ctx, assetId)
+		if err != nil {
+			return err
+		}
+		waste.QualityGrade, waste.History = applyDowngrades(waste.QualityGrade, waste.History, sensorId, recordedAt, downgrades)
+		waste.UpdatedAt, err = txTimestamp(ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.putWaste(ctx, waste); err != nil {
+			return err
+		}
+		return notify(ctx, waste.OwnerMSP, models.NotifyQualityDowngraded, "WASTE_"+waste.ID, fmt.Sprintf("Waste %s downgraded to grade %s by sensor %s", waste.ID, waste.QualityGrade, sensorId))
+	}
+
+	extraction, err := s.readExtraction(ctx, assetId)
+	if err != nil {
+		return err
+	}
+	// Extraction grades are tracked in the grading history
+	for _, downgrade := range downgrades {
+		previous := gradeOrDefault(extraction.QualityGrade)
+		extraction.QualityGrade = lowerGrade(previous, downgrade.steps)
+		extraction.Grading = append(extraction.Grading, models.GradeRecord{
+			Grade:         extraction.QualityGrade,
+			PreviousGrade: previous,
+			Kind:          models.GradeSensor,
+			Grader:        sensorId,
+			Evidence:      "SENSORLOG_EXTRACTION_" + assetId,
+			Reason:        downgrade.reason,
+			GradedAt:      recordedAt,
+		})
+	}
+	if err := s.putExtraction(ctx, extraction); err != nil {
+		return err
+	}
+	return s.applyContractAdjustments(ctx, extraction)
+}
+
+// applyDowngrades returns the lowered grade and the history with a
+// QUALITY_DOWNGRADED entry per triggered rule
+func applyDowngrades(grade string, history []models.History, sensorId string, recordedAt string, downgrades []qualityDowngrade) (string, []models.History) {
+	for _, downgrade := range downgrades {
+		previous := gradeOrDefault(grade)
+		grade = lowerGrade(previous, downgrade.steps)
+		history = append(history, models.History{
+			Timestamp: recordedAt,
+			Action:    "QUALITY_DOWNGRADED",
+			Actor:     sensorId,
+			Details:   fmt.Sprintf("%s: grade %s -> %s", downgrade.reason, previous, grade),
+		})
+	}
+
+	return grade, history
+}
+
+// lowerGrade moves a grade down by the given number of steps, stopping at the worst grade
+func lowerGrade(grade string, steps int) string {
+	grade = gradeOrDefault(grade)
+	index := 0
+	for i, g := range qualityGrades {
+		if g == grade {
+			index = i
+		}
+	}
+	index += steps
+	if index >= len(qualityGrades) {
+		index = len(qualityGrades) - 1
+	}
+
+	return qualityGrades[index]
+}
+
+// gradeOrDefault treats lots recorded before grading existed as top grade
+func gradeOrDefault(grade string) string {
+	if grade == "" {
+		return qualityGrades[0]
+	}
+
+	return grade
+}
+
+func loadBreachRules(ctx contractapi.TransactionContextInterface) ([]models.BreachRule, error) {
+	value := configString(ctx, "quality", "breachRules", "")
+	if value == "" {
+		return defaultBreachRules, nil
+	}
+
+	var rules []models.BreachRule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, newError(ctx, ErrBreachRulesSettingInvalid, err)
+	}
+	for _, rule := range rules {
+		if rule.Name == "" || rule.MaxDurationMinutes < 0 || rule.DowngradeSteps < 1 {
+			return nil, newError(ctx, ErrBreachRuleInvalid, rule.Name)
+		}
+	}
+
+	return rules, nil
+}
+
+func (s *SmartContract) readSensorLog(ctx contractapi.TransactionContextInterface, assetType string, assetId string) (*models.SensorLog, error) {
+	log := &models.SensorLog{
+		AssetType:     assetType,
+		AssetID:       assetId,
+		Readings:      []models.SensorReading{},
+		BreachStarted: map[string]string{},
+		Triggered:     map[string]bool{},
+	}
+	found, err := newAssetStore(ctx).Get("SENSORLOG_"+assetType+"_"+assetId, log)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "SENSORLOG_"+assetType+"_"+assetId, err)
+	}
+	if !found {
+		return log, nil
+	}
+	if log.BreachStarted == nil {
+		log.BreachStarted = map[string]string{}
+	}
+	if log.Triggered == nil {
+		log.Triggered = map[string]bool{}
+	}
+
+	return log, nil
+}
+
+func (s *SmartContract) putSensorLog(ctx contractapi.TransactionContextInterface, log *models.SensorLog) error {
+	return newAssetStore(ctx).Put("SENSORLOG_"+log.AssetType+"_"+log.AssetID, log)
+}
+
+// requireSensorGateway rejects the transaction unless the caller is one of
+// the identities registered to relay sensor readings (oracle.sensorIdentities,
+// comma-separated), from oracle.sensorMsp when that is set
+func requireSensorGateway(ctx contractapi.TransactionContextInterface) error {
+	gateways := splitList(configString(ctx, "oracle", "sensorIdentities", ""))
+	if len(gateways) == 0 {
+		return newError(ctx, ErrSensorGatewayUnregistered)
+	}
+
+	id, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if gatewayMSP := configString(ctx, "oracle", "sensorMsp", ""); gatewayMSP != "" && mspID != gatewayMSP {
+		return newError(ctx, ErrSensorGatewayRequired)
+	}
+	for _, gateway := range gateways {
+		if id == gateway {
+			return nil
+		}
+	}
+
+	return newError(ctx, ErrSensorGatewayRequired)
+}
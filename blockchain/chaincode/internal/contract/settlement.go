@@ -0,0 +1,442 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Token settlement defaults, overridden by the "settlement" config namespace.
+// The token chaincode (settlement.tokenChaincode) must run on this channel,
+// so its hold, capture and release commit in the same transaction as the
+// lot's side, and expose:
+//
+//	HoldFunds(holdId, payee, amount, expiresAt)  hold the caller's funds
+//	CaptureHold(holdId)                          pay the held funds to payee
+//	ReleaseHold(holdId)                          return them to the payer
+//
+// Releases run as the seller, buyer or maintenance admin; the token
+// chaincode must accept them from any of these once the hold expires.
+const (
+	defaultSettlementTTLMinutes = 60
+	defaultHoldFunction         = "HoldFunds"
+	defaultCaptureFunction      = "CaptureHold"
+	defaultReleaseFunction      = "ReleaseHold"
+)
+
+// settlementActor is recorded as the actor of settlements released on expiry
+const settlementActor = "maintenance"
+
+// tokenErrorThreshold is the lowest chaincode response status meaning failure
+const tokenErrorThreshold = 400
+
+// PrepareTokenSettlement starts paying for a share of a co-owned lot with
+// tokens: the buyer's funds are held in the token chaincode and the lot is
+// locked against other transfers until the seller confirms, either side
+// cancels or the settlement expires. The caller is the buyer. A non-zero
+// expectedVersion rejects the settlement if the lot has changed since it was
+// read.
+func (s *SmartContract) PrepareTokenSettlement(ctx contractapi.TransactionContextInterface, wasteId string, sellerId string, percentage float64, amount float64, expectedVersion int) (*models.TokenSettlement, error) {
+	if percentage <= 0 {
+		return nil, newError(ctx, ErrTransferPercentageInvalid)
+	}
+	if amount <= 0 {
+		return nil, newError(ctx, ErrSettlementAmountInvalid)
+	}
+	tokenChaincode := configString(ctx, "settlement", "tokenChaincode", "")
+	if tokenChaincode == "" {
+		return nil, newError(ctx, ErrTokenChaincodeUnset)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	if waste.PendingApprovalID != "" {
+		return nil, newError(ctx, ErrWasteTransferPending, wasteId, waste.PendingApprovalID)
+	}
+	if waste.PendingSettlementID != "" {
+		return nil, newError(ctx, ErrWasteLocked, wasteId, waste.PendingSettlementID)
+	}
+	buyer, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buyerMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transfer := &models.ShareTransfer{
+		SellerID:   sellerId,
+		Percentage: percentage,
+		Quantity:   waste.Quantity * percentage / 100,
+		BuyerID:    buyer,
+		BuyerMSP:   buyerMSP,
+	}
+	if err := checkShareTransfer(ctx, waste, transfer); err != nil {
+		return nil, err
+	}
+
+	id, err := newAssetID(ctx, "SETTLEMENT")
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	created, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return nil, err
+	}
+	ttl := configInt(ctx, "settlement", "ttlMinutes", defaultSettlementTTLMinutes)
+	expiresAt := created.Add(time.Duration(ttl) * time.Minute).UTC().Format(time.RFC3339)
+
+	// Phase one on the token side; a refused hold fails the whole transaction
+	holdFunction := configString(ctx, "settlement", "holdFunction", defaultHoldFunction)
+	if _, err := invokeToken(ctx, tokenChaincode, holdFunction, id, sellerId, strconv.FormatFloat(amount, 'f', 2, 64), expiresAt); err != nil {
+		return nil, err
+	}
+
+	settlement := &models.TokenSettlement{
+		ID:             id,
+		WasteID:        wasteId,
+		Transfer:       transfer,
+		Amount:         amount,
+		TokenChaincode: tokenChaincode,
+		HoldID:         id,
+		Status:         models.SettlementPrepared,
+		RequestedBy:    buyer,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		History: []models.History{{
+			Timestamp: now,
+			Action:    "PREPARED",
+			Actor:     buyer,
+			Details:   fmt.Sprintf("%.2f held in %s for %.4f%% of waste %s from %s", amount, tokenChaincode, percentage, wasteId, sellerId),
+		}},
+	}
+
+	// Phase one on the lot's side
+	waste.PendingSettlementID = id
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "SETTLEMENT_PREPARED",
+		Actor:     buyer,
+		Details:   fmt.Sprintf("Locked by settlement %s until %s", id, expiresAt),
+	})
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+	if err := putSettlement(ctx, settlement); err != nil {
+		return nil, err
+	}
+	if sellerMSP := holderMSP(waste, sellerId); sellerMSP != "" && sellerMSP != buyerMSP {
+		message := fmt.Sprintf("%s offers %.2f for %.2f%% of waste %s; confirm settlement %s before %s", buyer, amount, percentage, wasteId, id, expiresAt)
+		if err := notify(ctx, sellerMSP, models.NotifySettlementPrepared, "SETTLEMENT_"+id, message); err != nil {
+			return nil, err
+		}
+	}
+
+	return settlement, nil
+}
+
+// ConfirmTokenSettlement completes a prepared settlement: the held funds are
+// captured and the share transferred in the same transaction. The caller is
+// the seller. When the settlement expired, the share can no longer be
+// transferred or the token chaincode refuses the capture, the hold and the
+// lot are released instead and the settlement is returned closed.
+func (s *SmartContract) ConfirmTokenSettlement(ctx contractapi.TransactionContextInterface, settlementId string) (*models.TokenSettlement, error) {
+	settlement, err := s.preparedSettlement(ctx, settlementId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if actor != settlement.Transfer.SellerID {
+		return nil, newError(ctx, ErrSettlementConfirmForbidden, settlement.Transfer.SellerID, settlementId)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if now > settlement.ExpiresAt {
+		if err := s.releaseSettlement(ctx, settlement, "settlement deadline passed", actor, now); err != nil {
+			return nil, err
+		}
+		return settlement, nil
+	}
+
+	waste, err := s.readWaste(ctx, settlement.WasteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkShareTransfer(ctx, waste, settlement.Transfer); err != nil {
+		if err := s.releaseSettlement(ctx, settlement, err.Error(), actor, now); err != nil {
+			return nil, err
+		}
+		return settlement, nil
+	}
+
+	// Compensation: a refused capture releases both sides. Past this point
+	// any error fails the transaction, capture included.
+	captureFunction := configString(ctx, "settlement", "captureFunction", defaultCaptureFunction)
+	if _, err := invokeToken(ctx, settlement.TokenChaincode, captureFunction, settlement.HoldID); err != nil {
+		if err := s.releaseSettlement(ctx, settlement, "capture refused: "+err.Error(), actor, now); err != nil {
+			return nil, err
+		}
+		return settlement, nil
+	}
+
+	waste.PendingSettlementID = ""
+	if err := s.applyShareTransfer(ctx, waste, settlement.Transfer, actor); err != nil {
+		return nil, err
+	}
+	settlement.Status = models.SettlementConfirmed
+	settlement.ClosedAt = now
+	settlement.UpdatedAt = now
+	settlement.History = append(settlement.History, models.History{
+		Timestamp: now,
+		Action:    "CONFIRMED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%.2f captured and %.4f%% transferred to %s", settlement.Amount, settlement.Transfer.Percentage, settlement.Transfer.BuyerID),
+	})
+	if err := putSettlement(ctx, settlement); err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// CancelTokenSettlement releases a prepared settlement before it expires; the
+// buyer, the seller and admins may cancel
+func (s *SmartContract) CancelTokenSettlement(ctx contractapi.TransactionContextInterface, settlementId string, reason string) (*models.TokenSettlement, error) {
+	if reason == "" {
+		return nil, newError(ctx, ErrCancelReasonRequired)
+	}
+	settlement, err := s.preparedSettlement(ctx, settlementId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if actor != settlement.Transfer.BuyerID && actor != settlement.Transfer.SellerID && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrSettlementCancelForbidden, settlementId)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.releaseSettlement(ctx, settlement, reason, actor, now); err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// ReadTokenSettlement returns the settlement stored with the given id
+func (s *SmartContract) ReadTokenSettlement(ctx contractapi.TransactionContextInterface, id string) (*models.TokenSettlement, error) {
+	var settlement models.TokenSettlement
+	found, err := newAssetStore(ctx).Get("SETTLEMENT_"+id, &settlement)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "SETTLEMENT_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrSettlementNotFound, id)
+	}
+
+	return &settlement, nil
+}
+
+// GetTokenSettlements returns settlements, newest first; wasteId and status
+// filter them when set
+func (s *SmartContract) GetTokenSettlements(ctx contractapi.TransactionContextInterface, wasteId string, status string) ([]*models.TokenSettlement, error) {
+	status = strings.ToUpper(status)
+	return loadSettlements(ctx, func(settlement *models.TokenSettlement) bool {
+		return (wasteId == "" || settlement.WasteID == wasteId) && (status == "" || settlement.Status == status)
+	})
+}
+
+// releaseExpiredSettlements releases the prepared settlements of the next
+// page of the maintenance scan past their deadline and retries the holds
+// still to release; it returns how many holds it released
+func (s *SmartContract) releaseExpiredSettlements(ctx contractapi.TransactionContextInterface, run *maintenanceRun, ranAt time.Time) (int, error) {
+	now := ranAt.UTC().Format(time.RFC3339)
+	settlements := []*models.TokenSettlement{}
+	err := run.scan(ctx, "settlements", "SETTLEMENT_", func(_ string, value []byte) error {
+		var settlement models.TokenSettlement
+		if err := json.Unmarshal(value, &settlement); err != nil {
+			return err
+		}
+		if settlement.Status == models.SettlementReleasing || (settlement.Status == models.SettlementPrepared && settlement.ExpiresAt < now) {
+			settlements = append(settlements, &settlement)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, settlement := range settlements {
+		if err := s.releaseSettlement(ctx, settlement, "settlement deadline passed", settlementActor, now); err != nil {
+			return 0, err
+		}
+		if settlement.Status == models.SettlementReleased {
+			released++
+		}
+	}
+
+	return released, nil
+}
+
+// releaseSettlement closes a settlement without transferring the share. A
+// prepared one unlocks its lot; then the hold is released in the token
+// chaincode, or left RELEASING for the next maintenance run if it refuses.
+func (s *SmartContract) releaseSettlement(ctx contractapi.TransactionContextInterface, settlement *models.TokenSettlement, reason string, actor string, now string) error {
+	if settlement.Status == models.SettlementPrepared {
+		settlement.Reason = reason
+		settlement.ClosedAt = now
+		settlement.History = append(settlement.History, models.History{
+			Timestamp: now,
+			Action:    "CLOSED",
+			Actor:     actor,
+			Details:   reason,
+		})
+		if err := s.unlockSettledWaste(ctx, settlement, reason, actor, now); err != nil {
+			return err
+		}
+	}
+
+	releaseFunction := configString(ctx, "settlement", "releaseFunction", defaultReleaseFunction)
+	if _, err := invokeToken(ctx, settlement.TokenChaincode, releaseFunction, settlement.HoldID); err != nil {
+		settlement.Status = models.SettlementReleasing
+		settlement.ReleaseAttempts++
+		settlement.LastError = err.Error()
+		settlement.History = append(settlement.History, models.History{
+			Timestamp: now,
+			Action:    "RELEASE_FAILED",
+			Actor:     actor,
+			Details:   err.Error(),
+		})
+	} else {
+		settlement.Status = models.SettlementReleased
+		settlement.LastError = ""
+		settlement.History = append(settlement.History, models.History{
+			Timestamp: now,
+			Action:    "RELEASED",
+			Actor:     actor,
+			Details:   fmt.Sprintf("%.2f returned to %s", settlement.Amount, settlement.Transfer.BuyerID),
+		})
+		message := fmt.Sprintf("Settlement %s for waste %s was released: %s", settlement.ID, settlement.WasteID, settlement.Reason)
+		if err := notify(ctx, settlement.Transfer.BuyerMSP, models.NotifySettlementClosed, "SETTLEMENT_"+settlement.ID, message); err != nil {
+			return err
+		}
+	}
+	settlement.UpdatedAt = now
+
+	return putSettlement(ctx, settlement)
+}
+
+// unlockSettledWaste frees a lot locked by a settlement that was closed
+func (s *SmartContract) unlockSettledWaste(ctx contractapi.TransactionContextInterface, settlement *models.TokenSettlement, reason string, actor string, now string) error {
+	waste, err := s.readWaste(ctx, settlement.WasteID)
+	if err != nil {
+		return err
+	}
+	if waste.PendingSettlementID != settlement.ID {
+		return nil
+	}
+	waste.PendingSettlementID = ""
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "SETTLEMENT_RELEASED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Settlement %s: %s", settlement.ID, reason),
+	})
+
+	return s.putWaste(ctx, waste)
+}
+
+// preparedSettlement reads a settlement still awaiting confirmation
+func (s *SmartContract) preparedSettlement(ctx contractapi.TransactionContextInterface, settlementId string) (*models.TokenSettlement, error) {
+	settlement, err := s.ReadTokenSettlement(ctx, settlementId)
+	if err != nil {
+		return nil, err
+	}
+	if settlement.Status != models.SettlementPrepared {
+		return nil, newError(ctx, ErrSettlementStatusInvalid, settlementId, settlement.Status)
+	}
+
+	return settlement, nil
+}
+
+// invokeToken calls a function of the token chaincode on this channel and
+// returns its payload
+func invokeToken(ctx contractapi.TransactionContextInterface, chaincode string, function string, args ...string) ([]byte, error) {
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	response := ctx.GetStub().InvokeChaincode(chaincode, invokeArgs, "")
+	if response.Status >= tokenErrorThreshold {
+		return nil, newError(ctx, ErrTokenCallFailed, chaincode, function, response.Message)
+	}
+
+	return response.Payload, nil
+}
+
+// holderMSP returns the organization of a holder of a co-owned lot
+func holderMSP(waste *models.Waste, holderID string) string {
+	for _, share := range waste.Owners {
+		if share.HolderID == holderID {
+			return share.HolderMSP
+		}
+	}
+
+	return ""
+}
+
+func putSettlement(ctx contractapi.TransactionContextInterface, settlement *models.TokenSettlement) error {
+	return newAssetStore(ctx).Put("SETTLEMENT_"+settlement.ID, settlement)
+}
+
+// loadSettlements returns the settlements kept by keep, newest first
+func loadSettlements(ctx contractapi.TransactionContextInterface, keep func(*models.TokenSettlement) bool) ([]*models.TokenSettlement, error) {
+	settlements := []*models.TokenSettlement{}
+	err := newAssetStore(ctx).Range("SETTLEMENT_", "SETTLEMENT_~", func(_ string, value []byte) error {
+		var settlement models.TokenSettlement
+		if err := json.Unmarshal(value, &settlement); err != nil {
+			return err
+		}
+		if keep(&settlement) {
+			settlements = append(settlements, &settlement)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(settlements, func(i, j int) bool {
+		return settlements[i].CreatedAt > settlements[j].CreatedAt
+	})
+
+	return settlements, nil
+}
@@ -0,0 +1,104 @@
+package contract
+
+import (
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SimulateCreateWaste runs CreateWaste validation, including the validation
+// rules its commit would apply, without writing to the ledger
+func (s *SmartContract) SimulateCreateWaste(ctx contractapi.TransactionContextInterface, id string, wasteType string, quantity float64, harvestDate string, owner string, farm string, location string, plotId string) (*models.WasteSimulation, error) {
+	staged := newStagedWrites(ctx)
+	waste, warnings, err := s.buildWaste(ctx, staged, id, wasteType, quantity, harvestDate, owner, farm, location, plotId)
+	if err != nil {
+		return nil, err
+	}
+	staged.waste(waste)
+	if warnings, err = simulateCommit(staged, warnings); err != nil {
+		return nil, err
+	}
+
+	// Bump versions as the put helpers would so results match the stored assets
+	waste.Version++
+
+	return &models.WasteSimulation{Waste: waste, Warnings: warnings}, nil
+}
+
+// SimulateUpdateWasteStatus runs UpdateWasteStatus validation, including the
+// validation rules, without writing to the ledger
+func (s *SmartContract) SimulateUpdateWasteStatus(ctx contractapi.TransactionContextInterface, id string, newStatus string, actor string, details string, reasonCode string, expectedVersion int) (*models.WasteSimulation, error) {
+	waste, warnings, err := s.buildWasteStatusUpdate(ctx, id, newStatus, actor, details, reasonCode, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	staged := newStagedWrites(ctx)
+	staged.waste(waste)
+	if warnings, err = simulateCommit(staged, warnings); err != nil {
+		return nil, err
+	}
+
+	waste.Version++
+
+	return &models.WasteSimulation{Waste: waste, Warnings: warnings}, nil
+}
+
+// SimulateCreateExtraction runs CreateExtraction validation, including the
+// validation rules its commit would apply, without writing to the ledger
+func (s *SmartContract) SimulateCreateExtraction(ctx contractapi.TransactionContextInterface, id string, wasteId string, productType string, quantity float64, quality string, processor string, facilityId string) (*models.ExtractionSimulation, error) {
+	staged := newStagedWrites(ctx)
+	extraction, waste, warnings, err := s.buildExtraction(ctx, staged, id, wasteId, singleOutput(productType, quantity, quality), processor, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	staged.extraction(extraction)
+	staged.waste(waste)
+	if warnings, err = simulateCommit(staged, warnings); err != nil {
+		return nil, err
+	}
+
+	extraction.Version++
+	waste.Version++
+
+	return &models.ExtractionSimulation{Extraction: extraction, Waste: waste, Warnings: warnings}, nil
+}
+
+// SimulateCreateRecycling runs CreateRecycling validation, including the
+// validation rules its commit would apply, without writing to the ledger
+func (s *SmartContract) SimulateCreateRecycling(ctx contractapi.TransactionContextInterface, id string, wasteId string, recycledProduct string, quantity float64, method string, recycler string, facilityId string) (*models.RecyclingSimulation, error) {
+	staged := newStagedWrites(ctx)
+	recycling, waste, warnings, err := s.buildRecycling(ctx, staged, id, wasteId, recycledProduct, quantity, method, recycler, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	waste.Consumed += quantity
+	staged.recycling(recycling)
+	staged.waste(waste)
+	if warnings, err = simulateCommit(staged, warnings); err != nil {
+		return nil, err
+	}
+
+	recycling.Version++
+	waste.Version++
+
+	return &models.RecyclingSimulation{Recycling: recycling, Waste: waste, Warnings: warnings}, nil
+}
+
+// simulateCommit runs the checks of staged writes as commit would, leaving
+// the writes out, and adds the warnings they raised to those of the build
+func simulateCommit(staged *stagedWrites, warnings []string) ([]string, error) {
+	raised, err := staged.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return nonNilWarnings(append(warnings, warningMessages(raised)...)), nil
+}
+
+// nonNilWarnings makes sure an empty warning list serializes as [] rather than null
+func nonNilWarnings(warnings []string) []string {
+	if warnings == nil {
+		return []string{}
+	}
+
+	return warnings
+}
@@ -0,0 +1,348 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DefineSLA sets the number of days a processor has to process lots received
+// under a supply contract (its buyer) or at a facility (its operator).
+// Redefining an SLA only affects lots received afterwards.
+func (s *SmartContract) DefineSLA(ctx contractapi.TransactionContextInterface, scope string, scopeId string, maxDays int) (*models.SLA, error) {
+	scope = strings.ToUpper(scope)
+	if maxDays <= 0 {
+		return nil, newError(ctx, ErrSLADaysInvalid)
+	}
+
+	var processor string
+	switch scope {
+	case models.SLAScopeContract:
+		contract, err := s.ReadSupplyContract(ctx, scopeId)
+		if err != nil {
+			return nil, err
+		}
+		mspID, err := callerMSP(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if mspID != contract.Buyer && !isAdmin(ctx) {
+			return nil, newError(ctx, ErrSLADefineForbidden, contract.Buyer, scopeId)
+		}
+		processor = contract.Buyer
+	case models.SLAScopeFacility:
+		facility, err := s.ReadFacility(ctx, scopeId)
+		if err != nil {
+			return nil, err
+		}
+		if err := requireFacilityOperator(ctx, facility); err != nil {
+			return nil, err
+		}
+		processor = facility.Operator
+	default:
+		return nil, newError(ctx, ErrSLAScopeInvalid, models.SLAScopeContract, models.SLAScopeFacility)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sla, err := readSLA(ctx, scope, scopeId)
+	if err != nil {
+		return nil, err
+	}
+	action := "SLA_CHANGED"
+	if sla == nil {
+		action = "SLA_DEFINED"
+		sla = &models.SLA{
+			ID:        slaID(scope, scopeId),
+			Scope:     scope,
+			ScopeID:   scopeId,
+			CreatedAt: now,
+			History:   []models.History{},
+		}
+	}
+	sla.Processor = processor
+	sla.MaxDays = maxDays
+	sla.UpdatedAt = now
+	sla.History = append(sla.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("Lots processed within %d days", maxDays),
+	})
+
+	if err := newAssetStore(ctx).Put("SLA_"+sla.ID, sla); err != nil {
+		return nil, err
+	}
+
+	return sla, nil
+}
+
+// ReadSLA returns the SLA defined for a supply contract or facility
+func (s *SmartContract) ReadSLA(ctx contractapi.TransactionContextInterface, scope string, scopeId string) (*models.SLA, error) {
+	sla, err := readSLA(ctx, strings.ToUpper(scope), scopeId)
+	if err != nil {
+		return nil, err
+	}
+	if sla == nil {
+		return nil, newError(ctx, ErrSLANotFound, strings.ToLower(scope), scopeId)
+	}
+
+	return sla, nil
+}
+
+// ReceiveLot records that a lot arrived at a processing facility; the
+// operator's SLA for the facility, if any, starts running for the lot. A
+// non-zero expectedVersion guards against concurrent updates.
+func (s *SmartContract) ReceiveLot(ctx contractapi.TransactionContextInterface, facilityId string, wasteId string, expectedVersion int) (*models.Waste, error) {
+	facility, err := s.ReadFacility(ctx, facilityId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireFacilityOperator(ctx, facility); err != nil {
+		return nil, err
+	}
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "RECEIVED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Received at facility %s", facilityId),
+	})
+	if _, err := startSLA(ctx, waste, models.SLAScopeFacility, facilityId, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// GetSLACompliance reports, per processor, how the lots received between
+// from and to (YYYY-MM-DD, inclusive) kept their SLAs. Admins see every
+// processor, or the one given; other organizations see their own.
+func (s *SmartContract) GetSLACompliance(ctx contractapi.TransactionContextInterface, processor string, from string, to string) ([]*models.SLACompliance, error) {
+	if !isAdmin(ctx) {
+		mspID, err := callerMSP(ctx)
+		if err != nil {
+			return nil, err
+		}
+		processor = mspID
+	}
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := map[string][]*models.SLAStatus{}
+	for _, waste := range wastes {
+		sla := waste.SLA
+		if sla == nil || (processor != "" && sla.Processor != processor) || !receivedBetween(sla, from, to) {
+			continue
+		}
+		statuses[sla.Processor] = append(statuses[sla.Processor], sla)
+	}
+
+	results := []*models.SLACompliance{}
+	for name, received := range statuses {
+		results = append(results, summarizeSLAs(name, from, to, received))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Processor < results[j].Processor
+	})
+
+	return results, nil
+}
+
+// receivedBetween reports whether a lot was received between from and to
+// (YYYY-MM-DD, inclusive, either may be empty)
+func receivedBetween(sla *models.SLAStatus, from string, to string) bool {
+	day := sla.ReceivedAt[:len("2006-01-02")]
+	return (from == "" || day >= from) && (to == "" || day <= to)
+}
+
+// summarizeSLAs counts how the given received lots of a processor kept
+// their SLAs
+func summarizeSLAs(processor string, from string, to string, statuses []*models.SLAStatus) *models.SLACompliance {
+	report := &models.SLACompliance{Processor: processor, From: from, To: to}
+	turnaround := 0.0
+	completed := 0
+	for _, sla := range statuses {
+		report.Received++
+		switch sla.Status {
+		case models.SLAMet:
+			report.Met++
+		case models.SLABreached:
+			report.Breached++
+		default:
+			report.Pending++
+		}
+		if sla.CompletedAt != "" {
+			received, errReceived := time.Parse(time.RFC3339, sla.ReceivedAt)
+			end, errCompleted := time.Parse(time.RFC3339, sla.CompletedAt)
+			if errReceived == nil && errCompleted == nil {
+				turnaround += end.Sub(received).Hours() / 24
+				completed++
+			}
+		}
+	}
+
+	if decided := report.Met + report.Breached; decided > 0 {
+		report.ComplianceRate = math.Round(float64(report.Met)/float64(decided)*10000) / 100
+	}
+	if completed > 0 {
+		report.AvgTurnaroundDays = math.Round(turnaround/float64(completed)*10) / 10
+	}
+
+	return report
+}
+
+// startSLA puts a received lot under the SLA defined for the scope and
+// reports whether it did; lots already running under an SLA keep it
+func startSLA(ctx contractapi.TransactionContextInterface, waste *models.Waste, scope string, scopeID string, receivedAt string) (bool, error) {
+	if waste.SLA != nil && waste.SLA.Status == models.SLAPending {
+		return false, nil
+	}
+	sla, err := readSLA(ctx, scope, scopeID)
+	if err != nil || sla == nil {
+		return false, err
+	}
+	received, err := time.Parse(time.RFC3339, receivedAt)
+	if err != nil {
+		return false, err
+	}
+
+	waste.SLA = &models.SLAStatus{
+		SLAID:      sla.ID,
+		Processor:  sla.Processor,
+		ReceivedAt: receivedAt,
+		DueAt:      received.AddDate(0, 0, sla.MaxDays).UTC().Format(time.RFC3339),
+		Status:     models.SLAPending,
+	}
+
+	return true, nil
+}
+
+// completeSLA closes the SLA of a lot whose processing started at now
+func completeSLA(waste *models.Waste, now string) {
+	if waste.SLA == nil || waste.SLA.CompletedAt != "" {
+		return
+	}
+	waste.SLA.CompletedAt = now
+	if waste.SLA.Status == models.SLAPending {
+		waste.SLA.Status = models.SLAMet
+		if now > waste.SLA.DueAt {
+			waste.SLA.Status = models.SLABreached
+			waste.SLA.BreachedAt = waste.SLA.DueAt
+		}
+	}
+}
+
+// checkSLABreaches marks the lots of the next page of the maintenance scan
+// still unprocessed past their SLA deadline as breached, notifies the
+// processor and the lot's owner, and returns how many lots were marked
+func (s *SmartContract) checkSLABreaches(ctx contractapi.TransactionContextInterface, run *maintenanceRun, ranAt time.Time) (int, error) {
+	now := ranAt.UTC().Format(time.RFC3339)
+	wastes := []*models.Waste{}
+	err := run.scan(ctx, "slaBreaches", "WASTE_", func(_ string, value []byte) error {
+		var waste models.Waste
+		if err := json.Unmarshal(value, &waste); err != nil {
+			return err
+		}
+		if waste.SLA != nil && waste.SLA.Status == models.SLAPending && waste.SLA.DueAt < now {
+			wastes = append(wastes, &waste)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	breached := 0
+	for _, waste := range wastes {
+		sla := waste.SLA
+		sla.Status = models.SLABreached
+		sla.BreachedAt = now
+		waste.UpdatedAt = now
+		waste.History = append(waste.History, models.History{
+			Timestamp: now,
+			Action:    "SLA_BREACHED",
+			Actor:     "maintenance",
+			Details:   fmt.Sprintf("Not processed by %s (SLA %s)", sla.DueAt, sla.SLAID),
+		})
+		if err := s.putWaste(ctx, waste); err != nil {
+			return 0, err
+		}
+
+		message := fmt.Sprintf("Waste %s was not processed by %s as promised by SLA %s", waste.ID, sla.DueAt, sla.SLAID)
+		if err := notify(ctx, sla.Processor, models.NotifySLABreached, "WASTE_"+waste.ID, message); err != nil {
+			return 0, err
+		}
+		if waste.OwnerMSP != "" && waste.OwnerMSP != sla.Processor {
+			if err := notify(ctx, waste.OwnerMSP, models.NotifySLABreached, "WASTE_"+waste.ID, message); err != nil {
+				return 0, err
+			}
+		}
+		breached++
+	}
+
+	return breached, nil
+}
+
+func slaID(scope string, scopeID string) string {
+	return scope + "_" + scopeID
+}
+
+// readSLA returns the SLA of a scope, or nil if none is defined
+func readSLA(ctx contractapi.TransactionContextInterface, scope string, scopeID string) (*models.SLA, error) {
+	var sla models.SLA
+	found, err := newAssetStore(ctx).Get("SLA_"+slaID(scope, scopeID), &sla)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "SLA_"+slaID(scope, scopeID), err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &sla, nil
+}
@@ -0,0 +1,330 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/chaincode/internal/store"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultSnapshotPageSize is the number of keys hashed per invocation unless
+// snapshot.pageSize is configured
+const defaultSnapshotPageSize = 500
+
+// snapshotKeyPrefix covers snapshot records and their leaf chunks, which are
+// left out of the digest they belong to
+const snapshotKeyPrefix = "SNAPSHOT"
+
+// SnapshotDigest hashes the next page of world-state keys into a snapshot
+// (today's, by transaction date, when snapshotId is empty) and computes the
+// Merkle root once the last key is reached. Call it until the returned
+// snapshot is COMPLETE; completion raises a SNAPSHOT_COMPLETED notice in the
+// LedgerChanged event. Admin only.
+func (s *SmartContract) SnapshotDigest(ctx contractapi.TransactionContextInterface, snapshotId string) (*models.Snapshot, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return advanceSnapshot(ctx, snapshotId)
+}
+
+// RecordSnapshotBlock stores the height of the block that committed a
+// snapshot's completing transaction. Admin only.
+func (s *SmartContract) RecordSnapshotBlock(ctx contractapi.TransactionContextInterface, snapshotId string, blockHeight uint64) (*models.Snapshot, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.ReadSnapshot(ctx, snapshotId)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Status != models.SnapshotComplete {
+		return nil, newError(ctx, ErrSnapshotIncomplete, snapshotId)
+	}
+	if snapshot.BlockHeight != 0 && snapshot.BlockHeight != blockHeight {
+		return nil, newError(ctx, ErrSnapshotAlreadyAnchored, snapshotId, snapshot.BlockHeight)
+	}
+
+	snapshot.BlockHeight = blockHeight
+	if err := putSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// ReadSnapshot returns the snapshot stored with the given id
+func (s *SmartContract) ReadSnapshot(ctx contractapi.TransactionContextInterface, id string) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+	found, err := newAssetStore(ctx).Get("SNAPSHOT_"+id, &snapshot)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "SNAPSHOT_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrSnapshotNotFound, id)
+	}
+
+	return &snapshot, nil
+}
+
+// GetSnapshots returns all snapshots, oldest first
+func (s *SmartContract) GetSnapshots(ctx contractapi.TransactionContextInterface) ([]*models.Snapshot, error) {
+	snapshots := []*models.Snapshot{}
+	err := newAssetStore(ctx).Range("SNAPSHOT_", "SNAPSHOT_~", func(_ string, value []byte) error {
+		var snapshot models.Snapshot
+		if err := json.Unmarshal(value, &snapshot); err != nil {
+			return err
+		}
+		snapshots = append(snapshots, &snapshot)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// GetSnapshotProof returns the Merkle path proving that a key was part of a
+// complete snapshot
+func (s *SmartContract) GetSnapshotProof(ctx contractapi.TransactionContextInterface, snapshotId string, key string) (*models.SnapshotProof, error) {
+	snapshot, err := s.ReadSnapshot(ctx, snapshotId)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Status != models.SnapshotComplete {
+		return nil, newError(ctx, ErrSnapshotIncomplete, snapshotId)
+	}
+
+	leaves, err := loadSnapshotLeaves(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, leaf := range leaves {
+		if leaf.Key == key {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, newError(ctx, ErrSnapshotKeyNotFound, key, snapshotId)
+	}
+
+	levels, err := merkleLevels(ctx, leaves)
+	if err != nil {
+		return nil, err
+	}
+	path := []models.ProofStep{}
+	position := index
+	for _, level := range levels[:len(levels)-1] {
+		sibling := position ^ 1
+		// The last node of an odd level is carried up without a sibling
+		if sibling < len(level) {
+			path = append(path, models.ProofStep{Hash: hex.EncodeToString(level[sibling]), Left: sibling < position})
+		}
+		position /= 2
+	}
+
+	return &models.SnapshotProof{
+		SnapshotID:  snapshotId,
+		Key:         key,
+		LeafHash:    leaves[index].Hash,
+		Index:       index,
+		LeafCount:   len(leaves),
+		Path:        path,
+		Root:        snapshot.Root,
+		BlockHeight: snapshot.BlockHeight,
+	}, nil
+}
+
+// advanceSnapshot hashes one page of keys into a snapshot, starting it if
+// needed; complete snapshots are returned unchanged
+func advanceSnapshot(ctx contractapi.TransactionContextInterface, snapshotId string) (*models.Snapshot, error) {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotId == "" {
+		snapshotId = now[:len("2006-01-02")]
+	}
+
+	snapshot := &models.Snapshot{}
+	found, err := newAssetStore(ctx).Get("SNAPSHOT_"+snapshotId, snapshot)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "SNAPSHOT_"+snapshotId, err)
+	}
+	if !found {
+		startedBy, err := callerID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		snapshot = &models.Snapshot{ID: snapshotId, Status: models.SnapshotInProgress, StartedAt: now, StartedBy: startedBy}
+	}
+	if snapshot.Status == models.SnapshotComplete {
+		return snapshot, nil
+	}
+
+	pageSize := configInt(ctx, "snapshot", "pageSize", defaultSnapshotPageSize)
+	if pageSize <= 0 {
+		pageSize = defaultSnapshotPageSize
+	}
+	start := ""
+	if snapshot.Cursor != "" {
+		start = snapshot.Cursor + "\x00"
+	}
+
+	chunk := &models.SnapshotChunk{SnapshotID: snapshotId, Index: snapshot.Chunks, Leaves: []models.SnapshotLeaf{}}
+	exhausted := true
+	scanned := ""
+	err = newAssetStore(ctx).Range(start, "", func(key string, value []byte) error {
+		if strings.HasPrefix(key, snapshotKeyPrefix) {
+			scanned = key
+			return nil
+		}
+		if len(chunk.Leaves) == pageSize {
+			exhausted = false
+			return store.ErrStopRange
+		}
+		chunk.Leaves = append(chunk.Leaves, models.SnapshotLeaf{Key: key, Hash: hex.EncodeToString(leafHash(key, value))})
+		scanned = key
+
+		return nil
+	})
+	// A page larger than the query budget ends where the budget did; the
+	// next call resumes after the last key scanned
+	if budgetExceeded(err) && scanned != "" {
+		exhausted = false
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunk.Leaves) > 0 {
+		if err := newAssetStore(ctx).Put(snapshotChunkKey(snapshotId, chunk.Index), chunk); err != nil {
+			return nil, err
+		}
+		snapshot.Chunks++
+		snapshot.LeafCount += len(chunk.Leaves)
+		snapshot.Cursor = chunk.Leaves[len(chunk.Leaves)-1].Key
+	}
+	if !exhausted && scanned > snapshot.Cursor {
+		snapshot.Cursor = scanned
+	}
+
+	if exhausted {
+		leaves, err := loadSnapshotLeaves(ctx, snapshot)
+		if err != nil {
+			return nil, err
+		}
+		levels, err := merkleLevels(ctx, leaves)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Root = hex.EncodeToString(levels[len(levels)-1][0])
+		snapshot.Status = models.SnapshotComplete
+		snapshot.Cursor = ""
+		snapshot.CompletedAt = now
+		snapshot.TxID = ctx.GetStub().GetTxID()
+
+		// Raised with the LedgerChanged event so that the block committing it
+		// can be recorded with RecordSnapshotBlock
+		mspID, err := callerMSP(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordNotice(ctx, models.NoticeSnapshotCompleted, "SNAPSHOT_"+snapshotId, snapshot.Root, mspID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := putSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// loadSnapshotLeaves returns the leaves of a snapshot in key order
+func loadSnapshotLeaves(ctx contractapi.TransactionContextInterface, snapshot *models.Snapshot) ([]models.SnapshotLeaf, error) {
+	leaves := make([]models.SnapshotLeaf, 0, snapshot.LeafCount)
+	for index := 0; index < snapshot.Chunks; index++ {
+		var chunk models.SnapshotChunk
+		found, err := newAssetStore(ctx).Get(snapshotChunkKey(snapshot.ID, index), &chunk)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, newError(ctx, ErrSnapshotChunkMissing, snapshot.ID, index)
+		}
+		leaves = append(leaves, chunk.Leaves...)
+	}
+
+	return leaves, nil
+}
+
+// leafHash is sha256(0x00 || key || 0x00 || sha256(value))
+func leafHash(key string, value []byte) []byte {
+	valueHash := sha256.Sum256(value)
+	hash := sha256.New()
+	hash.Write([]byte{0x00})
+	hash.Write([]byte(key))
+	hash.Write([]byte{0x00})
+	hash.Write(valueHash[:])
+
+	return hash.Sum(nil)
+}
+
+// merkleLevels builds the tree bottom-up; inner nodes are
+// sha256(0x01 || left || right) and the last node of an odd level is carried
+// up unchanged. An empty snapshot has the single node sha256("").
+func merkleLevels(ctx contractapi.TransactionContextInterface, leaves []models.SnapshotLeaf) ([][][]byte, error) {
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hash, err := hex.DecodeString(leaf.Hash)
+		if err != nil {
+			return nil, newError(ctx, ErrLeafHashInvalid, leaf.Key, err)
+		}
+		level[i] = hash
+	}
+	if len(level) == 0 {
+		empty := sha256.Sum256(nil)
+		return [][][]byte{{empty[:]}}, nil
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			hash := sha256.New()
+			hash.Write([]byte{0x01})
+			hash.Write(level[i])
+			hash.Write(level[i+1])
+			next = append(next, hash.Sum(nil))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+
+	return levels, nil
+}
+
+func snapshotChunkKey(snapshotId string, index int) string {
+	return fmt.Sprintf("SNAPSHOTLEAVES_%s_%06d", snapshotId, index)
+}
+
+func putSnapshot(ctx contractapi.TransactionContextInterface, snapshot *models.Snapshot) error {
+	return newAssetStore(ctx).Put("SNAPSHOT_"+snapshot.ID, snapshot)
+}
@@ -0,0 +1,81 @@
+package contract
+
+import (
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// stagedWrites collects the writes of a composite operation, one that
+// writes several assets at once (an extraction and its source lot, a
+// blended run and each of its inputs). Each write is staged with the check
+// validating it, and commit runs every check before the first write: a
+// validation rule rejecting the last asset then fails the operation before
+// anything reached the world state, instead of after the first assets did.
+// Checks run at commit time, on the assets as the operation left them.
+type stagedWrites struct {
+	ctx    contractapi.TransactionContextInterface
+	checks []func() ([]models.ValidationWarning, error)
+	writes []func() error
+}
+
+// newStagedWrites returns an empty set of staged writes
+func newStagedWrites(ctx contractapi.TransactionContextInterface) *stagedWrites {
+	return &stagedWrites{ctx: ctx}
+}
+
+// write stages a write that needs no validation
+func (w *stagedWrites) write(write func() error) {
+	w.writes = append(w.writes, write)
+}
+
+// waste stages a waste item, written as putWaste would
+func (w *stagedWrites) waste(waste *models.Waste) {
+	w.checks = append(w.checks, func() ([]models.ValidationWarning, error) { return checkWaste(w.ctx, waste) })
+	w.write(func() error { return writeWaste(w.ctx, waste) })
+}
+
+// extraction stages an extraction record, written as putExtraction would
+func (w *stagedWrites) extraction(extraction *models.Extraction) {
+	w.checks = append(w.checks, func() ([]models.ValidationWarning, error) { return checkExtraction(w.ctx, extraction) })
+	w.write(func() error { return writeExtraction(w.ctx, extraction) })
+}
+
+// recycling stages a recycling record, written as putRecycling would
+func (w *stagedWrites) recycling(recycling *models.Recycling) {
+	w.checks = append(w.checks, func() ([]models.ValidationWarning, error) { return checkRecycling(w.ctx, recycling) })
+	w.write(func() error { return writeRecycling(w.ctx, recycling) })
+}
+
+// reference stages the counter and index entry of a claimed reference
+func (w *stagedWrites) reference(claim *referenceClaim) {
+	w.write(func() error { return claim.store(w.ctx) })
+}
+
+// validate runs every check and returns the warnings they raised; the
+// simulations stop there, commit goes on with the writes
+func (w *stagedWrites) validate() ([]models.ValidationWarning, error) {
+	var warnings []models.ValidationWarning
+	for _, check := range w.checks {
+		raised, err := check()
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, raised...)
+	}
+
+	return warnings, nil
+}
+
+// commit runs every check, then every write in the order they were staged
+func (w *stagedWrites) commit() error {
+	if _, err := w.validate(); err != nil {
+		return err
+	}
+	for _, write := range w.writes {
+		if err := write(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package contract
+
+import (
+	"github.com/chaincode/internal/store"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// newAssetStore returns the world state of the current transaction
+var newAssetStore func(ctx contractapi.TransactionContextInterface) store.AssetStore
+
+// stubAssetStore reads the query budget from the config asset, itself read
+// through newAssetStore, so it is only assigned at init
+func init() {
+	newAssetStore = stubAssetStore
+}
+
+// stubAssetStore is the world state over the transaction's stub, noting the
+// keys it reads and writes for the function metrics and the keys it writes
+// for the audit trail, and holding range queries to the query budget
+func stubAssetStore(ctx contractapi.TransactionContextInterface) store.AssetStore {
+	txID := ctx.GetStub().GetTxID()
+	metered := store.NewMeter(store.NewStubStore(ctx.GetStub()), func(key string, write bool) {
+		noteMetricAccess(txID, key, write)
+	})
+	budgeted := store.NewBudget(metered, func() store.Limits {
+		return queryLimits(ctx)
+	}, func(exceeded *store.BudgetExceeded) error {
+		return truncatedError(ctx, exceeded)
+	})
+
+	return store.NewRecorder(budgeted, func(key string) {
+		noteAuditWrite(ctx, key)
+	})
+}
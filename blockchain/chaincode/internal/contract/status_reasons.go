@@ -0,0 +1,287 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DefineStatusReasons sets the reason codes of status transition (admin
+// only); reasonsJson is a list of {"code", "label"}. A required catalog
+// marks a negative transition: lots only move to it with one of its codes.
+// Codes already counted in statistics keep their history when a catalog
+// changes, but a code may only belong to one transition.
+func (s *SmartContract) DefineStatusReasons(ctx contractapi.TransactionContextInterface, transition string, required bool, reasonsJson string) (*models.StatusReasonCatalog, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	transition = strings.ToUpper(strings.TrimSpace(transition))
+	if transition == "" {
+		return nil, newError(ctx, ErrStatusRequired)
+	}
+
+	var reasons []models.StatusReason
+	if err := json.Unmarshal([]byte(reasonsJson), &reasons); err != nil {
+		return nil, newError(ctx, ErrStatusReasonsInvalid, err)
+	}
+	if len(reasons) == 0 {
+		return nil, newError(ctx, ErrStatusReasonsEmpty)
+	}
+	catalogs, err := s.GetStatusReasonCatalogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	taken := map[string]string{}
+	for _, catalog := range catalogs {
+		for _, reason := range catalog.Reasons {
+			taken[reason.Code] = catalog.Transition
+		}
+	}
+	seen := map[string]bool{}
+	for i := range reasons {
+		reason := &reasons[i]
+		reason.Code = strings.ToUpper(strings.TrimSpace(reason.Code))
+		if !taxonomyCodePattern.MatchString(reason.Code) || reason.Label == "" {
+			return nil, newError(ctx, ErrStatusReasonInvalid, i+1)
+		}
+		if seen[reason.Code] {
+			return nil, newError(ctx, ErrStatusReasonDuplicate, reason.Code)
+		}
+		if other, ok := taken[reason.Code]; ok && other != transition {
+			return nil, newError(ctx, ErrStatusReasonTaken, reason.Code, other)
+		}
+		seen[reason.Code] = true
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := readStatusReasons(ctx, transition)
+	if err != nil {
+		return nil, err
+	}
+	action := "REASONS_CHANGED"
+	if catalog == nil {
+		action = "REASONS_DEFINED"
+		catalog = &models.StatusReasonCatalog{
+			Transition: transition,
+			CreatedAt:  now,
+			History:    []models.History{},
+		}
+	}
+	catalog.Required = required
+	catalog.Reasons = reasons
+	catalog.Version++
+	catalog.UpdatedAt = now
+	catalog.History = append(catalog.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("Version %d with %d reasons, required: %t", catalog.Version, len(reasons), required),
+	})
+
+	if err := newAssetStore(ctx).Put("STATUSREASONS_"+transition, catalog); err != nil {
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+// RemoveStatusReasons drops the reason catalog of a transition (admin only)
+func (s *SmartContract) RemoveStatusReasons(ctx contractapi.TransactionContextInterface, transition string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	transition = strings.ToUpper(transition)
+	catalog, err := readStatusReasons(ctx, transition)
+	if err != nil {
+		return err
+	}
+	if catalog == nil {
+		return newError(ctx, ErrStatusReasonsNotDefined, transition)
+	}
+
+	return newAssetStore(ctx).Delete("STATUSREASONS_" + transition)
+}
+
+// ReadStatusReasons returns the reason catalog of a transition
+func (s *SmartContract) ReadStatusReasons(ctx contractapi.TransactionContextInterface, transition string) (*models.StatusReasonCatalog, error) {
+	catalog, err := readStatusReasons(ctx, strings.ToUpper(transition))
+	if err != nil {
+		return nil, err
+	}
+	if catalog == nil {
+		return nil, newError(ctx, ErrStatusReasonsNotDefined, strings.ToUpper(transition))
+	}
+
+	return catalog, nil
+}
+
+// GetStatusReasonCatalogs returns the reason catalogs of all transitions
+func (s *SmartContract) GetStatusReasonCatalogs(ctx contractapi.TransactionContextInterface) ([]*models.StatusReasonCatalog, error) {
+	catalogs := []*models.StatusReasonCatalog{}
+	err := newAssetStore(ctx).Range("STATUSREASONS_", "STATUSREASONS_~", func(_ string, value []byte) error {
+		var catalog models.StatusReasonCatalog
+		if err := json.Unmarshal(value, &catalog); err != nil {
+			return err
+		}
+		catalogs = append(catalogs, &catalog)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalogs, nil
+}
+
+// GetStatusReasonStatistics counts the status changes of lots between from
+// and to (YYYY-MM-DD, inclusive, either may be empty) by reason code,
+// archived history included. Admins see every organization's lots, others
+// their own.
+func (s *SmartContract) GetStatusReasonStatistics(ctx contractapi.TransactionContextInterface, from string, to string) (*models.StatusReasonStatistics, error) {
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+
+	catalogs, err := s.GetStatusReasonCatalogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]*models.StatusReasonCount{}
+	for _, catalog := range catalogs {
+		for _, reason := range catalog.Reasons {
+			known[reason.Code] = &models.StatusReasonCount{Transition: catalog.Transition, ReasonCode: reason.Code, Label: reason.Label}
+		}
+	}
+
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := map[string]*models.Waste{}
+	for _, waste := range wastes {
+		if admin || waste.OwnerMSP == mspID {
+			visible[waste.ID] = waste
+		}
+	}
+
+	stats := &models.StatusReasonStatistics{From: from, To: to, ByReason: []*models.StatusReasonCount{}}
+	counts := map[string]*models.StatusReasonCount{}
+	count := func(waste *models.Waste, entries []models.History) {
+		for _, entry := range entries {
+			if entry.Action != "STATUS_CHANGED" || !onDayBetween(entry.Timestamp, from, to) {
+				continue
+			}
+			stats.Changes++
+			if entry.ReasonCode == "" {
+				stats.Unexplained++
+				continue
+			}
+			reason, ok := counts[entry.ReasonCode]
+			if !ok {
+				// Codes of a removed catalog are still counted, unlabelled
+				reason = &models.StatusReasonCount{ReasonCode: entry.ReasonCode, Label: entry.ReasonCode}
+				if catalogued, ok := known[entry.ReasonCode]; ok {
+					reason = catalogued
+				}
+				counts[entry.ReasonCode] = reason
+				stats.ByReason = append(stats.ByReason, reason)
+			}
+			reason.Count++
+			reason.Quantity += waste.Quantity
+		}
+	}
+
+	for _, waste := range visible {
+		count(waste, waste.History)
+	}
+	err = newAssetStore(ctx).Range("HISTORY_WASTE_", "HISTORY_WASTE_~", func(_ string, value []byte) error {
+		var checkpoint models.HistoryCheckpoint
+		if err := json.Unmarshal(value, &checkpoint); err != nil {
+			return err
+		}
+		if waste := visible[checkpoint.AssetID]; waste != nil {
+			count(waste, checkpoint.Entries)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(stats.ByReason, func(i, j int) bool {
+		if stats.ByReason[i].Transition != stats.ByReason[j].Transition {
+			return stats.ByReason[i].Transition < stats.ByReason[j].Transition
+		}
+		return stats.ByReason[i].ReasonCode < stats.ByReason[j].ReasonCode
+	})
+
+	return stats, nil
+}
+
+// checkStatusReason refuses a reason code outside the catalog of newStatus,
+// and a missing one when the catalog is required
+func checkStatusReason(ctx contractapi.TransactionContextInterface, wasteID string, newStatus string, reasonCode string) error {
+	catalog, err := readStatusReasons(ctx, newStatus)
+	if err != nil {
+		return err
+	}
+	if catalog == nil {
+		if reasonCode != "" {
+			return newError(ctx, ErrStatusReasonsNotDefined, newStatus)
+		}
+		return nil
+	}
+	if reasonCode == "" {
+		if catalog.Required {
+			return newError(ctx, ErrStatusReasonRequired, wasteID, newStatus)
+		}
+		return nil
+	}
+	for _, reason := range catalog.Reasons {
+		if reason.Code == reasonCode {
+			return nil
+		}
+	}
+
+	return newError(ctx, ErrStatusReasonUnknown, reasonCode, newStatus)
+}
+
+// readStatusReasons returns the reason catalog of a transition, or nil if
+// none is defined
+func readStatusReasons(ctx contractapi.TransactionContextInterface, transition string) (*models.StatusReasonCatalog, error) {
+	var catalog models.StatusReasonCatalog
+	found, err := newAssetStore(ctx).Get("STATUSREASONS_"+transition, &catalog)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "STATUSREASONS_"+transition, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &catalog, nil
+}
@@ -0,0 +1,325 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultStorageWarnOccupancy is the occupancy rate from which check-ins
+// warn that a site is nearly full, unless storage.warnOccupancy is configured
+const defaultStorageWarnOccupancy = 0.9
+
+// Storage movement directions
+const (
+	storageIn  = "IN"
+	storageOut = "OUT"
+)
+
+// RegisterStorageSite registers a depot operated by the caller's organization;
+// with temperatureControlled set, lots are kept between minTempC and maxTempC
+func (s *SmartContract) RegisterStorageSite(ctx contractapi.TransactionContextInterface, id string, name string, location string, capacity float64, temperatureControlled bool, minTempC float64, maxTempC float64) (*models.StorageSite, error) {
+	if id == "" || name == "" {
+		return nil, newError(ctx, ErrStorageFieldsRequired)
+	}
+	if capacity <= 0 {
+		return nil, newError(ctx, ErrStorageCapacityInvalid)
+	}
+	if temperatureControlled && minTempC > maxTempC {
+		return nil, newError(ctx, ErrTemperatureRangeInvalid)
+	}
+
+	exists, err := newAssetStore(ctx).Exists("STORAGE_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrStorageAlreadyExists, id)
+	}
+
+	operator, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("Storage site with capacity %.2f", capacity)
+	site := &models.StorageSite{
+		ID:        id,
+		Name:      name,
+		Operator:  operator,
+		Location:  location,
+		Capacity:  capacity,
+		Lots:      []models.StoredLot{},
+		Status:    models.FacilityActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if temperatureControlled {
+		site.TemperatureControlled = true
+		site.MinTempC = minTempC
+		site.MaxTempC = maxTempC
+		details += fmt.Sprintf(", kept between %.1f and %.1f °C", minTempC, maxTempC)
+	}
+	site.History = []models.History{
+		{
+			Timestamp: now,
+			Action:    "REGISTERED",
+			Actor:     actor,
+			Details:   details,
+		},
+	}
+
+	if err := s.putStorageSite(ctx, site); err != nil {
+		return nil, err
+	}
+
+	return site, nil
+}
+
+// CheckIn stores a quantity of a lot at a site operated by the caller's
+// organization. Check-ins beyond the site capacity are accepted so that the
+// ledger reflects reality, but come back with an overcapacity warning. A
+// non-zero expectedVersion guards against concurrent updates of the lot.
+func (s *SmartContract) CheckIn(ctx contractapi.TransactionContextInterface, siteId string, wasteId string, quantity float64, expectedVersion int) (*models.StorageMovement, error) {
+	site, err := s.ReadStorageSite(ctx, siteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStorageOperator(ctx, site); err != nil {
+		return nil, err
+	}
+	if site.Status != models.FacilityActive {
+		return nil, newError(ctx, ErrStorageStatusInvalid, siteId, site.Status)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	if waste.StorageSiteID != "" {
+		return nil, newError(ctx, ErrWasteAlreadyStored, wasteId, waste.StorageSiteID)
+	}
+	if quantity <= 0 {
+		quantity = waste.Quantity - waste.Consumed
+	}
+	if quantity <= 0 || quantity > waste.Quantity-waste.Consumed {
+		return nil, newError(ctx, ErrCheckInQuantityInvalid, waste.Quantity-waste.Consumed, wasteId)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	site.Lots = append(site.Lots, models.StoredLot{WasteID: wasteId, Quantity: quantity, CheckedInAt: now})
+	site.CurrentStock += quantity
+	site.UpdatedAt = now
+	site.History = append(site.History, models.History{
+		Timestamp: now,
+		Action:    "CHECKED_IN",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%.2f of waste %s checked in, stock %.2f/%.2f", quantity, wasteId, site.CurrentStock, site.Capacity),
+	})
+	if err := s.putStorageSite(ctx, site); err != nil {
+		return nil, err
+	}
+
+	waste.StorageSiteID = siteId
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "STORED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%.2f stored at %s", quantity, site.Name),
+	})
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+	warnOccupancy := configFloat(ctx, "storage", "warnOccupancy", defaultStorageWarnOccupancy)
+	if site.CurrentStock > site.Capacity {
+		warnings = append(warnings, fmt.Sprintf("storage site %s is over capacity: %.2f stored for a capacity of %.2f", siteId, site.CurrentStock, site.Capacity))
+	} else if site.CurrentStock >= site.Capacity*warnOccupancy {
+		warnings = append(warnings, fmt.Sprintf("storage site %s is %.0f%% full", siteId, 100*site.CurrentStock/site.Capacity))
+	}
+
+	return &models.StorageMovement{
+		SiteID:     siteId,
+		WasteID:    wasteId,
+		Direction:  storageIn,
+		Quantity:   quantity,
+		StockAfter: site.CurrentStock,
+		Warnings:   warnings,
+	}, nil
+}
+
+// CheckOut releases a lot from a site operated by the caller's organization,
+// e.g. when it leaves for processing; a non-zero expectedVersion guards
+// against concurrent updates of the lot
+func (s *SmartContract) CheckOut(ctx contractapi.TransactionContextInterface, siteId string, wasteId string, expectedVersion int) (*models.StorageMovement, error) {
+	site, err := s.ReadStorageSite(ctx, siteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStorageOperator(ctx, site); err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i, lot := range site.Lots {
+		if lot.WasteID == wasteId {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, newError(ctx, ErrWasteNotStored, wasteId, siteId)
+	}
+	quantity := site.Lots[index].Quantity
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	site.Lots = append(site.Lots[:index], site.Lots[index+1:]...)
+	site.CurrentStock -= quantity
+	if site.CurrentStock < 0 {
+		site.CurrentStock = 0
+	}
+	site.UpdatedAt = now
+	site.History = append(site.History, models.History{
+		Timestamp: now,
+		Action:    "CHECKED_OUT",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%.2f of waste %s checked out, stock %.2f/%.2f", quantity, wasteId, site.CurrentStock, site.Capacity),
+	})
+	if err := s.putStorageSite(ctx, site); err != nil {
+		return nil, err
+	}
+
+	waste.StorageSiteID = ""
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "RELEASED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Released from %s", site.Name),
+	})
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return &models.StorageMovement{
+		SiteID:     siteId,
+		WasteID:    wasteId,
+		Direction:  storageOut,
+		Quantity:   quantity,
+		StockAfter: site.CurrentStock,
+		Warnings:   []string{},
+	}, nil
+}
+
+// ReadStorageSite returns the storage site stored with the given id
+func (s *SmartContract) ReadStorageSite(ctx contractapi.TransactionContextInterface, id string) (*models.StorageSite, error) {
+	var site models.StorageSite
+	found, err := newAssetStore(ctx).Get("STORAGE_"+id, &site)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "STORAGE_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrStorageNotFound, id)
+	}
+
+	return &site, nil
+}
+
+// GetSiteOccupancy returns the stock and occupancy of every active storage
+// site, fullest first; operator optionally restricts it to one organization
+func (s *SmartContract) GetSiteOccupancy(ctx contractapi.TransactionContextInterface, operator string) ([]*models.SiteOccupancy, error) {
+	occupancy := []*models.SiteOccupancy{}
+	err := newAssetStore(ctx).Range("STORAGE_", "STORAGE_~", func(_ string, value []byte) error {
+		var site models.StorageSite
+		if err := json.Unmarshal(value, &site); err != nil {
+			return err
+		}
+		if site.Status != models.FacilityActive || (operator != "" && site.Operator != operator) {
+			return nil
+		}
+
+		available := site.Capacity - site.CurrentStock
+		if available < 0 {
+			available = 0
+		}
+		occupancy = append(occupancy, &models.SiteOccupancy{
+			SiteID:        site.ID,
+			Name:          site.Name,
+			Operator:      site.Operator,
+			Capacity:      site.Capacity,
+			CurrentStock:  site.CurrentStock,
+			Available:     available,
+			OccupancyRate: site.CurrentStock / site.Capacity,
+			LotCount:      len(site.Lots),
+			OverCapacity:  site.CurrentStock > site.Capacity,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(occupancy, func(i, j int) bool {
+		return occupancy[i].OccupancyRate > occupancy[j].OccupancyRate
+	})
+
+	return occupancy, nil
+}
+
+// requireStorageOperator rejects the transaction unless the caller's
+// organization operates the site
+func requireStorageOperator(ctx contractapi.TransactionContextInterface, site *models.StorageSite) error {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if mspID != site.Operator && !isAdmin(ctx) {
+		return newError(ctx, ErrStorageManageForbidden, site.Operator, site.ID)
+	}
+
+	return nil
+}
+
+func (s *SmartContract) putStorageSite(ctx contractapi.TransactionContextInterface, site *models.StorageSite) error {
+	return newAssetStore(ctx).Put("STORAGE_"+site.ID, site)
+}
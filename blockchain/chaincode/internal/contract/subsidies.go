@@ -0,0 +1,518 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/expr"
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DefineSubsidyScheme creates or replaces a subsidy scheme (admin only).
+// conditionsJson lists {"code", "description", "expression"}; expressions
+// see the lot as waste, its valorization as facts (see SubsidyFacts), and
+// now and mspId as validation rules do, e.g.
+//
+//	"FARM_CERTIFICATE" in facts.documentTypes
+//	facts.daysToRecycling >= 0 && facts.daysToRecycling <= 30
+//	facts.recyclingMethods.all(m, m in ["COMPOST", "BIOGAS"])
+//
+// validFrom and validTo bound the harvest dates of the lots it covers.
+func (s *SmartContract) DefineSubsidyScheme(ctx contractapi.TransactionContextInterface, id string, name string, agency string, conditionsJson string, ratePerUnit float64, currency string, validFrom string, validTo string) (*models.SubsidyScheme, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if !taxonomyCodePattern.MatchString(id) {
+		return nil, newError(ctx, ErrSchemeIDInvalid)
+	}
+	if strings.TrimSpace(name) == "" || strings.TrimSpace(agency) == "" {
+		return nil, newError(ctx, ErrSchemeFieldsRequired)
+	}
+	if ratePerUnit < 0 {
+		return nil, newError(ctx, ErrRatePerUnitNegative)
+	}
+	for _, date := range []string{validFrom, validTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+	if validFrom != "" && validTo != "" && validTo < validFrom {
+		return nil, newError(ctx, ErrValidityPeriodInvalid)
+	}
+
+	var conditions []models.SubsidyCondition
+	if err := json.Unmarshal([]byte(conditionsJson), &conditions); err != nil {
+		return nil, newError(ctx, ErrSubsidyConditionsInvalid, err)
+	}
+	if len(conditions) == 0 {
+		return nil, newError(ctx, ErrSubsidyConditionsEmpty)
+	}
+	seen := map[string]bool{}
+	for i := range conditions {
+		condition := &conditions[i]
+		condition.Code = strings.ToUpper(strings.TrimSpace(condition.Code))
+		if !taxonomyCodePattern.MatchString(condition.Code) || condition.Description == "" {
+			return nil, newError(ctx, ErrSubsidyConditionInvalid, i+1)
+		}
+		if seen[condition.Code] {
+			return nil, newError(ctx, ErrSubsidyConditionDuplicate, condition.Code)
+		}
+		seen[condition.Code] = true
+		if _, err := expr.Compile(condition.Expression); err != nil {
+			return nil, newError(ctx, ErrSubsidyExpressionInvalid, condition.Code, err)
+		}
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme, err := readSubsidyScheme(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	action := "SCHEME_CHANGED"
+	if scheme == nil {
+		action = "SCHEME_DEFINED"
+		scheme = &models.SubsidyScheme{
+			ID:        id,
+			CreatedAt: now,
+			History:   []models.History{},
+		}
+	}
+	scheme.Name = strings.TrimSpace(name)
+	scheme.Agency = strings.TrimSpace(agency)
+	scheme.Conditions = conditions
+	scheme.RatePerUnit = ratePerUnit
+	scheme.Currency = strings.ToUpper(strings.TrimSpace(currency))
+	scheme.ValidFrom = validFrom
+	scheme.ValidTo = validTo
+	scheme.Active = true
+	scheme.Version++
+	scheme.UpdatedAt = now
+	scheme.History = append(scheme.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("Version %d with %d conditions, %.2f %s per unit", scheme.Version, len(conditions), ratePerUnit, scheme.Currency),
+	})
+
+	if err := newAssetStore(ctx).Put("SUBSIDYSCHEME_"+id, scheme); err != nil {
+		return nil, err
+	}
+
+	return scheme, nil
+}
+
+// RetireSubsidyScheme closes a scheme to new evaluations; lots already
+// found eligible stay claimable (admin only)
+func (s *SmartContract) RetireSubsidyScheme(ctx contractapi.TransactionContextInterface, id string) (*models.SubsidyScheme, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	scheme, err := s.ReadSubsidyScheme(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !scheme.Active {
+		return nil, newError(ctx, ErrSchemeAlreadyRetired, scheme.ID)
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Active = false
+	scheme.UpdatedAt = now
+	scheme.History = append(scheme.History, models.History{
+		Timestamp: now,
+		Action:    "SCHEME_RETIRED",
+		Actor:     actor,
+	})
+
+	if err := newAssetStore(ctx).Put("SUBSIDYSCHEME_"+scheme.ID, scheme); err != nil {
+		return nil, err
+	}
+
+	return scheme, nil
+}
+
+// ReadSubsidyScheme returns a subsidy scheme
+func (s *SmartContract) ReadSubsidyScheme(ctx contractapi.TransactionContextInterface, id string) (*models.SubsidyScheme, error) {
+	scheme, err := readSubsidyScheme(ctx, strings.ToUpper(id))
+	if err != nil {
+		return nil, err
+	}
+	if scheme == nil {
+		return nil, newError(ctx, ErrSchemeNotFound, strings.ToUpper(id))
+	}
+
+	return scheme, nil
+}
+
+// GetSubsidySchemes returns all subsidy schemes, retired ones included
+func (s *SmartContract) GetSubsidySchemes(ctx contractapi.TransactionContextInterface) ([]*models.SubsidyScheme, error) {
+	schemes := []*models.SubsidyScheme{}
+	err := newAssetStore(ctx).Range("SUBSIDYSCHEME_", "SUBSIDYSCHEME_~", func(_ string, value []byte) error {
+		var scheme models.SubsidyScheme
+		if err := json.Unmarshal(value, &scheme); err != nil {
+			return err
+		}
+		schemes = append(schemes, &scheme)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schemes, nil
+}
+
+// EvaluateSubsidyEligibility checks a lot against the conditions of an
+// active scheme and records the outcome, replacing any earlier one: the
+// evaluation is stored for the claims export and the lot is flagged
+// eligible or not, with the conditions it failed as reasons. A condition
+// that cannot be evaluated counts as failed. The lot's owner or an admin
+// only.
+func (s *SmartContract) EvaluateSubsidyEligibility(ctx contractapi.TransactionContextInterface, wasteId string, schemeId string) (*models.SubsidyEligibility, error) {
+	scheme, err := s.ReadSubsidyScheme(ctx, schemeId)
+	if err != nil {
+		return nil, err
+	}
+	if !scheme.Active {
+		return nil, newError(ctx, ErrSchemeRetired, scheme.ID)
+	}
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrSubsidyClaimForbidden, waste.OwnerMSP, wasteId)
+	}
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	facts, err := s.subsidyFacts(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := ruleVariables(ctx, "WASTE", waste)
+	if err != nil {
+		return nil, err
+	}
+	vars["facts"] = expr.Value(facts)
+
+	eligibility := &models.SubsidyEligibility{
+		SchemeID:      scheme.ID,
+		SchemeVersion: scheme.Version,
+		WasteID:       waste.ID,
+		Reference:     waste.Reference,
+		Owner:         waste.Owner,
+		OwnerMSP:      waste.OwnerMSP,
+		Quantity:      waste.Quantity,
+		Reasons:       []string{},
+		Conditions:    []models.SubsidyConditionResult{},
+		Facts:         *facts,
+		Currency:      scheme.Currency,
+		EvaluatedBy:   actor,
+		EvaluatedAt:   now,
+	}
+	if !onDayBetween(waste.HarvestDate, scheme.ValidFrom, scheme.ValidTo) {
+		eligibility.Reasons = append(eligibility.Reasons, fmt.Sprintf("Harvested %s, outside the scheme period", waste.HarvestDate))
+	}
+	for _, condition := range scheme.Conditions {
+		result := models.SubsidyConditionResult{Code: condition.Code, Description: condition.Description}
+		program, err := expr.Compile(condition.Expression)
+		if err != nil {
+			return nil, newError(ctx, ErrSubsidyConditionFailed, scheme.ID, condition.Code, err)
+		}
+		result.Passed, err = program.EvalBool(vars)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if !result.Passed {
+			eligibility.Reasons = append(eligibility.Reasons, condition.Description)
+		}
+		eligibility.Conditions = append(eligibility.Conditions, result)
+	}
+	eligibility.Eligible = len(eligibility.Reasons) == 0
+	if eligibility.Eligible {
+		eligibility.Amount = waste.Quantity * scheme.RatePerUnit
+	}
+
+	flag := models.SubsidyFlag{
+		SchemeID:    scheme.ID,
+		Eligible:    eligibility.Eligible,
+		Reasons:     eligibility.Reasons,
+		EvaluatedAt: now,
+	}
+	replaced := false
+	for i := range waste.Subsidies {
+		if waste.Subsidies[i].SchemeID == scheme.ID {
+			waste.Subsidies[i] = flag
+			replaced = true
+		}
+	}
+	if !replaced {
+		waste.Subsidies = append(waste.Subsidies, flag)
+	}
+	details := fmt.Sprintf("Eligible for subsidy scheme %s: %.2f %s", scheme.ID, eligibility.Amount, scheme.Currency)
+	if !eligibility.Eligible {
+		details = fmt.Sprintf("Not eligible for subsidy scheme %s: %s", scheme.ID, strings.Join(eligibility.Reasons, "; "))
+	}
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "SUBSIDY_EVALUATED",
+		Actor:     actor,
+		Details:   details,
+	})
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+	if err := newAssetStore(ctx).Put(subsidyKey(scheme.ID, waste.ID), eligibility); err != nil {
+		return nil, err
+	}
+
+	return eligibility, nil
+}
+
+// GetSubsidyEligibilities returns the latest evaluations of lots against a
+// scheme, only the eligible ones when eligibleOnly is set. Admins and
+// auditors see every lot, other organizations their own.
+func (s *SmartContract) GetSubsidyEligibilities(ctx contractapi.TransactionContextInterface, schemeId string, eligibleOnly bool) ([]*models.SubsidyEligibility, error) {
+	schemeId = strings.ToUpper(schemeId)
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all := isAdmin(ctx) || hasRole(ctx, AuditorRole)
+
+	evaluations := []*models.SubsidyEligibility{}
+	prefix := "SUBSIDY_" + schemeId + "_"
+	err = newAssetStore(ctx).Range(prefix, prefix+"~", func(_ string, value []byte) error {
+		var eligibility models.SubsidyEligibility
+		if err := json.Unmarshal(value, &eligibility); err != nil {
+			return err
+		}
+		if (eligibility.Eligible || !eligibleOnly) && (all || eligibility.OwnerMSP == mspID) {
+			evaluations = append(evaluations, &eligibility)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluations, nil
+}
+
+// ExportSubsidyClaims lists the lots found eligible for a scheme, evaluated
+// between from and to (YYYY-MM-DD, inclusive, open when empty), with the
+// amounts to claim from the paying agency; admins and auditors only
+func (s *SmartContract) ExportSubsidyClaims(ctx contractapi.TransactionContextInterface, schemeId string, from string, to string) (*models.SubsidyClaimExport, error) {
+	if !isAdmin(ctx) && !hasRole(ctx, AuditorRole) {
+		return nil, newError(ctx, ErrSubsidyExportForbidden)
+	}
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+	scheme, err := s.ReadSubsidyScheme(ctx, schemeId)
+	if err != nil {
+		return nil, err
+	}
+	evaluations, err := s.GetSubsidyEligibilities(ctx, scheme.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.SubsidyClaimExport{
+		SchemeID:    scheme.ID,
+		SchemeName:  scheme.Name,
+		Agency:      scheme.Agency,
+		Currency:    scheme.Currency,
+		From:        from,
+		To:          to,
+		Lines:       []models.SubsidyClaimLine{},
+		GeneratedAt: now,
+	}
+	for _, eligibility := range evaluations {
+		if !onDayBetween(eligibility.EvaluatedAt, from, to) {
+			continue
+		}
+		var waste models.Waste
+		if _, err := newAssetStore(ctx).Get("WASTE_"+eligibility.WasteID, &waste); err != nil {
+			return nil, err
+		}
+		export.Lines = append(export.Lines, models.SubsidyClaimLine{
+			WasteID:     eligibility.WasteID,
+			Reference:   eligibility.Reference,
+			Owner:       eligibility.Owner,
+			OwnerMSP:    eligibility.OwnerMSP,
+			HarvestDate: waste.HarvestDate,
+			Quantity:    eligibility.Quantity,
+			Amount:      eligibility.Amount,
+			EvaluatedAt: eligibility.EvaluatedAt,
+		})
+		export.TotalQuantity += eligibility.Quantity
+		export.TotalAmount += eligibility.Amount
+	}
+	sort.Slice(export.Lines, func(i, j int) bool {
+		return export.Lines[i].EvaluatedAt < export.Lines[j].EvaluatedAt
+	})
+
+	return export, nil
+}
+
+// subsidyFacts gathers what the ledger knows about the valorization of a
+// lot: its recyclings, extraction, the certifications of the facilities
+// involved and the documents attached to it
+func (s *SmartContract) subsidyFacts(ctx contractapi.TransactionContextInterface, waste *models.Waste) (*models.SubsidyFacts, error) {
+	facts := &models.SubsidyFacts{
+		DaysToRecycling:        -1,
+		RecyclingMethods:       []string{},
+		FacilityCertifications: []string{},
+		DocumentTypes:          []string{},
+		CertificateIssued:      waste.CertificateID != "",
+	}
+	facilities := map[string]bool{}
+
+	recyclings, err := s.GetAllRecyclings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	firstRecycling := ""
+	methods := map[string]bool{}
+	for _, recycling := range recyclings {
+		if recycling.Status == models.RecordOrphaned {
+			continue
+		}
+		for _, input := range recycling.InputLots() {
+			if input.WasteID != waste.ID {
+				continue
+			}
+			facts.RecycledQuantity += input.Quantity
+			if recycling.Method != "" && !methods[recycling.Method] {
+				methods[recycling.Method] = true
+				facts.RecyclingMethods = append(facts.RecyclingMethods, recycling.Method)
+			}
+			if recycling.FacilityID != "" {
+				facilities[recycling.FacilityID] = true
+			}
+			if firstRecycling == "" || recycling.CreatedAt < firstRecycling {
+				firstRecycling = recycling.CreatedAt
+			}
+		}
+	}
+	if firstRecycling != "" {
+		registered, err := time.Parse(time.RFC3339, waste.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		recycled, err := time.Parse(time.RFC3339, firstRecycling)
+		if err != nil {
+			return nil, err
+		}
+		facts.DaysToRecycling = int(recycled.Sub(registered).Hours() / 24)
+	}
+
+	extractions, err := s.GetAllExtractions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, extraction := range extractions {
+		if extraction.WasteID != waste.ID || extraction.Status == models.RecordOrphaned {
+			continue
+		}
+		facts.Extracted = true
+		if extraction.FacilityID != "" {
+			facilities[extraction.FacilityID] = true
+		}
+	}
+
+	ids := make([]string, 0, len(facilities))
+	for id := range facilities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	certifications := map[string]bool{}
+	for _, id := range ids {
+		var facility models.Facility
+		found, err := newAssetStore(ctx).Get("FACILITY_"+id, &facility)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		for _, certification := range facility.Certifications {
+			if !certifications[certification] {
+				certifications[certification] = true
+				facts.FacilityCertifications = append(facts.FacilityCertifications, certification)
+			}
+		}
+	}
+
+	types := map[string]bool{}
+	for _, document := range waste.Documents {
+		if !types[document.Type] {
+			types[document.Type] = true
+			facts.DocumentTypes = append(facts.DocumentTypes, document.Type)
+		}
+	}
+
+	return facts, nil
+}
+
+func readSubsidyScheme(ctx contractapi.TransactionContextInterface, id string) (*models.SubsidyScheme, error) {
+	var scheme models.SubsidyScheme
+	found, err := newAssetStore(ctx).Get("SUBSIDYSCHEME_"+id, &scheme)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &scheme, nil
+}
+
+func subsidyKey(schemeID string, wasteID string) string {
+	return "SUBSIDY_" + schemeID + "_" + wasteID
+}
@@ -0,0 +1,510 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultSupplyRiskWindowDays is how many days before its end a contract
+// behind on its commitment is flagged, unless supply.riskWindowDays is set
+const defaultSupplyRiskWindowDays = 30
+
+// ProposeSupplyContract offers a standing supply contract between a supplier
+// and a buyer organization, one of which must be the caller's. priceKind is
+// FIXED (basePrice per unit) or INDEX (index price of the product on the
+// delivery date plus premium).
+func (s *SmartContract) ProposeSupplyContract(ctx contractapi.TransactionContextInterface, id string, supplier string, buyer string, productType string, committedVolume float64, priceKind string, basePrice float64, premium float64, currency string, startDate string, endDate string) (*models.SupplyContract, error) {
+	if id == "" || productType == "" {
+		return nil, newError(ctx, ErrSupplyContractFieldsRequired)
+	}
+	if supplier == "" || buyer == "" || supplier == buyer {
+		return nil, newError(ctx, ErrSupplyContractPartiesInvalid)
+	}
+	if committedVolume <= 0 {
+		return nil, newError(ctx, ErrCommittedVolumeInvalid)
+	}
+
+	price := models.PriceFormula{Kind: strings.ToUpper(priceKind), Currency: strings.ToUpper(strings.TrimSpace(currency))}
+	switch price.Kind {
+	case models.PriceFixed:
+		if basePrice <= 0 {
+			return nil, newError(ctx, ErrBasePriceInvalid)
+		}
+		price.BasePrice = basePrice
+	case models.PriceIndex:
+		price.Premium = premium
+	default:
+		return nil, newError(ctx, ErrPriceKindInvalid, models.PriceFixed, models.PriceIndex)
+	}
+	if len(price.Currency) != 3 {
+		return nil, newError(ctx, ErrCurrencyInvalid)
+	}
+
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, newError(ctx, ErrStartDateInvalid, startDate)
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, newError(ctx, ErrEndDateInvalid, endDate)
+	}
+	if end.Before(start) {
+		return nil, newError(ctx, ErrDateRangeInvalid)
+	}
+
+	proposer, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if proposer != supplier && proposer != buyer {
+		return nil, newError(ctx, ErrSupplyContractPartyRequired)
+	}
+
+	exists, err := newAssetStore(ctx).Exists("SUPPLY_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrSupplyContractAlreadyExists, id)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contract := &models.SupplyContract{
+		ID:              id,
+		Supplier:        supplier,
+		Buyer:           buyer,
+		ProductType:     productType,
+		CommittedVolume: committedVolume,
+		Price:           price,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		Status:          models.SupplyProposed,
+		Deliveries:      []models.ContractDelivery{},
+		ProposedBy:      proposer,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "PROPOSED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("%.2f of %s from %s to %s, %s to %s", committedVolume, productType, supplier, buyer, startDate, endDate),
+			},
+		},
+	}
+
+	if err := s.putSupplyContract(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	counterparty := buyer
+	if proposer == buyer {
+		counterparty = supplier
+	}
+	if err := notify(ctx, counterparty, models.NotifySupplyProposed, "SUPPLY_"+id, fmt.Sprintf("%s proposes a supply contract for %.2f of %s from %s to %s", proposer, committedVolume, productType, startDate, endDate)); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// AcceptSupplyContract activates a proposed supply contract; only the party
+// that did not propose it may accept
+func (s *SmartContract) AcceptSupplyContract(ctx contractapi.TransactionContextInterface, id string) (*models.SupplyContract, error) {
+	contract, err := s.ReadSupplyContract(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status != models.SupplyProposed {
+		return nil, newError(ctx, ErrSupplyContractStatusUnexpected, id, contract.Status, models.SupplyProposed)
+	}
+
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if (mspID != contract.Supplier && mspID != contract.Buyer) || mspID == contract.ProposedBy {
+		return nil, newError(ctx, ErrSupplyContractAcceptForbidden, contract.ProposedBy, id)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contract.Status = models.SupplyActive
+	contract.UpdatedAt = now
+	contract.History = append(contract.History, models.History{
+		Timestamp: now,
+		Action:    "ACCEPTED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("Accepted by %s", mspID),
+	})
+
+	if err := s.putSupplyContract(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// RecordContractDelivery counts a lot of the supplier delivered to the buyer
+// towards the contract commitment; quantity defaults to the whole lot. Each
+// lot is counted once per contract.
+func (s *SmartContract) RecordContractDelivery(ctx contractapi.TransactionContextInterface, contractId string, wasteId string, quantity float64) (*models.SupplyContract, error) {
+	contract, err := s.ReadSupplyContract(ctx, contractId)
+	if err != nil {
+		return nil, err
+	}
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addContractDelivery(ctx, contract, waste, "", quantity); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// LinkExtractionToContract attaches an extraction to the delivery of its
+// source lot, recording the whole lot as delivered if it was not yet. From
+// then on, grading the extraction applies the contract's adjustment rules.
+func (s *SmartContract) LinkExtractionToContract(ctx contractapi.TransactionContextInterface, contractId string, extractionId string) (*models.SupplyContract, error) {
+	contract, err := s.ReadSupplyContract(ctx, contractId)
+	if err != nil {
+		return nil, err
+	}
+	extraction, err := s.readExtraction(ctx, extractionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if extraction.SupplyContractID != "" && extraction.SupplyContractID != contractId {
+		return nil, newError(ctx, ErrExtractionAlreadyLinked, extractionId, extraction.SupplyContractID)
+	}
+
+	linked := false
+	for i := range contract.Deliveries {
+		if contract.Deliveries[i].WasteID != extraction.WasteID {
+			continue
+		}
+		if err := requireSupplyParty(ctx, contract); err != nil {
+			return nil, err
+		}
+		now, err := txTimestamp(ctx)
+		if err != nil {
+			return nil, err
+		}
+		contract.Deliveries[i].ExtractionID = extractionId
+		contract.UpdatedAt = now
+		if err := s.putSupplyContract(ctx, contract); err != nil {
+			return nil, err
+		}
+		linked = true
+		break
+	}
+
+	if !linked {
+		waste, err := s.readWaste(ctx, extraction.WasteID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.addContractDelivery(ctx, contract, waste, extractionId, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	// An extraction graded before it was linked gets its adjustments now
+	extraction.SupplyContractID = contractId
+	if err := s.putExtraction(ctx, extraction); err != nil {
+		return nil, err
+	}
+	if err := s.applyContractAdjustments(ctx, extraction); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// ReadSupplyContract returns the supply contract stored with the given id
+func (s *SmartContract) ReadSupplyContract(ctx contractapi.TransactionContextInterface, id string) (*models.SupplyContract, error) {
+	var contract models.SupplyContract
+	found, err := newAssetStore(ctx).Get("SUPPLY_"+id, &contract)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "SUPPLY_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrSupplyContractNotFound, id)
+	}
+
+	return &contract, nil
+}
+
+// GetSupplyContracts returns the supply contracts of the caller's
+// organization, or all of them for admins
+func (s *SmartContract) GetSupplyContracts(ctx contractapi.TransactionContextInterface) ([]*models.SupplyContract, error) {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := isAdmin(ctx)
+
+	return loadSupplyContracts(ctx, func(contract *models.SupplyContract) bool {
+		return admin || contract.Supplier == mspID || contract.Buyer == mspID
+	})
+}
+
+// GetCommitmentStatus returns the progress of the caller organization's
+// active supply contracts; a contract is at risk when, within
+// supply.riskWindowDays of its end, its delivery pace falls short of the
+// commitment
+func (s *SmartContract) GetCommitmentStatus(ctx contractapi.TransactionContextInterface) ([]*models.CommitmentStatus, error) {
+	contracts, err := s.GetSupplyContracts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return nil, err
+	}
+	window := configInt(ctx, "supply", "riskWindowDays", defaultSupplyRiskWindowDays)
+
+	statuses := []*models.CommitmentStatus{}
+	for _, contract := range contracts {
+		if contract.Status == models.SupplyProposed {
+			continue
+		}
+		statuses = append(statuses, commitmentStatus(contract, today, window))
+	}
+
+	return statuses, nil
+}
+
+// checkSupplyCommitments notifies both parties of each active contract of
+// the next page of the maintenance scan that became at risk, once per
+// contract, and returns how many were notified
+func checkSupplyCommitments(ctx contractapi.TransactionContextInterface, run *maintenanceRun, today time.Time) (int, error) {
+	window := configInt(ctx, "supply", "riskWindowDays", defaultSupplyRiskWindowDays)
+	contracts := []*models.SupplyContract{}
+	err := run.scan(ctx, "supplyCommitments", "SUPPLY_", func(_ string, value []byte) error {
+		var contract models.SupplyContract
+		if err := json.Unmarshal(value, &contract); err != nil {
+			return err
+		}
+		if contract.Status == models.SupplyActive && contract.AtRiskNotifiedAt == "" {
+			contracts = append(contracts, &contract)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, contract := range contracts {
+		status := commitmentStatus(contract, today, window)
+		if !status.AtRisk {
+			continue
+		}
+
+		message := fmt.Sprintf("Supply contract %s: %.2f of %.2f %s delivered with %d days left", contract.ID, contract.FulfilledVolume, contract.CommittedVolume, contract.ProductType, status.DaysLeft)
+		for _, party := range []string{contract.Supplier, contract.Buyer} {
+			if err := notify(ctx, party, models.NotifySupplyAtRisk, "SUPPLY_"+contract.ID, message); err != nil {
+				return 0, err
+			}
+		}
+		contract.AtRiskNotifiedAt = today.UTC().Format(time.RFC3339)
+		if err := newAssetStore(ctx).Put("SUPPLY_"+contract.ID, contract); err != nil {
+			return 0, err
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+// commitmentStatus projects the contract's volume at its end from the pace of
+// deliveries so far
+func commitmentStatus(contract *models.SupplyContract, today time.Time, windowDays int) *models.CommitmentStatus {
+	status := &models.CommitmentStatus{
+		ContractID:      contract.ID,
+		Supplier:        contract.Supplier,
+		Buyer:           contract.Buyer,
+		ProductType:     contract.ProductType,
+		CommittedVolume: contract.CommittedVolume,
+		FulfilledVolume: contract.FulfilledVolume,
+		Shortfall:       math.Max(contract.CommittedVolume-contract.FulfilledVolume, 0),
+		FulfilledRate:   contract.FulfilledVolume / contract.CommittedVolume,
+		EndDate:         contract.EndDate,
+	}
+
+	start, errStart := time.Parse("2006-01-02", contract.StartDate)
+	end, errEnd := time.Parse("2006-01-02", contract.EndDate)
+	if errStart != nil || errEnd != nil {
+		return status
+	}
+	day := today.UTC().Truncate(24 * time.Hour)
+	status.DaysLeft = int(end.Sub(day).Hours()/24) + 1
+	if status.DaysLeft < 0 {
+		status.DaysLeft = 0
+	}
+	if contract.Status != models.SupplyActive || status.Shortfall == 0 || status.DaysLeft > windowDays || day.Before(start) {
+		return status
+	}
+
+	periodDays := end.Sub(start).Hours()/24 + 1
+	elapsedDays := math.Min(day.Sub(start).Hours()/24+1, periodDays)
+	projected := contract.FulfilledVolume * periodDays / elapsedDays
+	status.AtRisk = projected < contract.CommittedVolume
+
+	return status
+}
+
+// addContractDelivery validates and prices a delivery of the supplier's lot,
+// then stores the updated contract
+func (s *SmartContract) addContractDelivery(ctx contractapi.TransactionContextInterface, contract *models.SupplyContract, waste *models.Waste, extractionID string, quantity float64) error {
+	if err := requireSupplyParty(ctx, contract); err != nil {
+		return err
+	}
+	if contract.Status != models.SupplyActive && contract.Status != models.SupplyFulfilled {
+		return newError(ctx, ErrSupplyContractStatusInvalid, contract.ID, contract.Status)
+	}
+	if waste.OwnerMSP != contract.Supplier {
+		return newError(ctx, ErrWasteSupplierMismatch, waste.ID, contract.Supplier)
+	}
+	if priceIndexType(waste.Type) != priceIndexType(contract.ProductType) {
+		return newError(ctx, ErrWasteStatusUnexpected, waste.ID, waste.Type, contract.ProductType)
+	}
+	for _, delivery := range contract.Deliveries {
+		if delivery.WasteID == waste.ID {
+			return newError(ctx, ErrWasteAlreadyDelivered, waste.ID, contract.ID)
+		}
+	}
+	if quantity <= 0 {
+		quantity = waste.Quantity
+	}
+	if quantity > waste.Quantity {
+		return newError(ctx, ErrDeliveredQuantityExceeded, waste.Quantity, waste.ID)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if today := now[:10]; today < contract.StartDate || today > contract.EndDate {
+		return newError(ctx, ErrSupplyContractOutOfTerm, contract.ID, contract.StartDate, contract.EndDate)
+	}
+
+	delivery := models.ContractDelivery{WasteID: waste.ID, ExtractionID: extractionID, Quantity: quantity, DeliveredAt: now}
+	switch contract.Price.Kind {
+	case models.PriceFixed:
+		delivery.UnitPrice = contract.Price.BasePrice
+	case models.PriceIndex:
+		entries, err := loadPriceEntries(ctx, priceIndexType(contract.ProductType), now[:10])
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			delivery.Unpriced = true
+		} else {
+			delivery.UnitPrice = entries[len(entries)-1].PricePerUnit + contract.Price.Premium
+		}
+	}
+	delivery.Amount = math.Round(quantity*delivery.UnitPrice*100) / 100
+
+	contract.Deliveries = append(contract.Deliveries, delivery)
+	contract.FulfilledVolume += quantity
+	action := "DELIVERY"
+	if contract.Status == models.SupplyActive && contract.FulfilledVolume >= contract.CommittedVolume {
+		contract.Status = models.SupplyFulfilled
+		action = "FULFILLED"
+	}
+	contract.UpdatedAt = now
+	contract.History = append(contract.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("%.2f delivered as waste %s, %.2f of %.2f fulfilled", quantity, waste.ID, contract.FulfilledVolume, contract.CommittedVolume),
+	})
+
+	// A lot delivered unprocessed runs under the buyer's SLA for the contract
+	if extractionID == "" {
+		started, err := startSLA(ctx, waste, models.SLAScopeContract, contract.ID, now)
+		if err != nil {
+			return err
+		}
+		if started {
+			if err := s.putWaste(ctx, waste); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.putSupplyContract(ctx, contract)
+}
+
+// requireSupplyParty rejects the transaction unless the caller's
+// organization is a party to the contract
+func requireSupplyParty(ctx contractapi.TransactionContextInterface, contract *models.SupplyContract) error {
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if mspID != contract.Supplier && mspID != contract.Buyer && !isAdmin(ctx) {
+		return newError(ctx, ErrSupplyContractManageForbidden, contract.Supplier, contract.Buyer, contract.ID)
+	}
+
+	return nil
+}
+
+func (s *SmartContract) putSupplyContract(ctx contractapi.TransactionContextInterface, contract *models.SupplyContract) error {
+	return newAssetStore(ctx).Put("SUPPLY_"+contract.ID, contract)
+}
+
+func loadSupplyContracts(ctx contractapi.TransactionContextInterface, keep func(*models.SupplyContract) bool) ([]*models.SupplyContract, error) {
+	contracts := []*models.SupplyContract{}
+	err := newAssetStore(ctx).Range("SUPPLY_", "SUPPLY_~", func(_ string, value []byte) error {
+		var contract models.SupplyContract
+		if err := json.Unmarshal(value, &contract); err != nil {
+			return err
+		}
+		if keep(&contract) {
+			contracts = append(contracts, &contract)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return contracts, nil
+}
@@ -0,0 +1,179 @@
+package contract
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// tagIndex is the composite-key object type indexing wastes by tag
+const tagIndex = "tag~waste"
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// AddTag labels a waste item (e.g. "priority", "export", "contested"); the
+// number of tags per waste is capped by the "tags.maxPerAsset" setting. A
+// non-zero expectedVersion guards against concurrent updates.
+func (s *SmartContract) AddTag(ctx contractapi.TransactionContextInterface, wasteId string, tag string, actor string, expectedVersion int) (*models.Waste, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(tag) {
+		return nil, newError(ctx, ErrTagInvalid, tag)
+	}
+
+	waste, err := s.readTaggableWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	for _, existing := range waste.Tags {
+		if existing == tag {
+			return nil, newError(ctx, ErrWasteAlreadyTagged, wasteId, tag)
+		}
+	}
+
+	limit := configInt(ctx, "tags", "maxPerAsset", 10)
+	if len(waste.Tags) >= limit {
+		return nil, newError(ctx, ErrTagLimitReached, wasteId, limit)
+	}
+
+	waste.Tags = append(waste.Tags, tag)
+	sort.Strings(waste.Tags)
+
+	indexKey, err := ctx.GetStub().CreateCompositeKey(tagIndex, []string{tag, wasteId})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(indexKey, []byte{0x00}); err != nil {
+		return nil, err
+	}
+
+	if err := s.putTaggedWaste(ctx, waste, "TAGGED", models.NoticeWasteTagged, tag, actor); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// RemoveTag removes a tag from a waste item; a non-zero expectedVersion
+// guards against concurrent updates
+func (s *SmartContract) RemoveTag(ctx contractapi.TransactionContextInterface, wasteId string, tag string, actor string, expectedVersion int) (*models.Waste, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	waste, err := s.readTaggableWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	var remaining []string
+	for _, existing := range waste.Tags {
+		if existing != tag {
+			remaining = append(remaining, existing)
+		}
+	}
+	if len(remaining) == len(waste.Tags) {
+		return nil, newError(ctx, ErrWasteNotTagged, wasteId, tag)
+	}
+	waste.Tags = remaining
+
+	indexKey, err := ctx.GetStub().CreateCompositeKey(tagIndex, []string{tag, wasteId})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().DelState(indexKey); err != nil {
+		return nil, err
+	}
+
+	if err := s.putTaggedWaste(ctx, waste, "UNTAGGED", models.NoticeWasteUntagged, tag, actor); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// QueryWastesByTag returns the wastes carrying a tag, redacted where the
+// caller's organization may not see them in full
+func (s *SmartContract) QueryWastesByTag(ctx contractapi.TransactionContextInterface, tag string) ([]*models.Waste, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var wastes []*models.Waste
+	err = partialKeyQuery(ctx, tagIndex, []string{tag}, func(keyParts []string, _ []byte) error {
+		if len(keyParts) != 2 {
+			return nil
+		}
+
+		waste, err := s.readWaste(ctx, keyParts[1])
+		if err != nil {
+			return err
+		}
+		visible, err := viewer.view(ctx, waste)
+		if err != nil {
+			return err
+		}
+		wastes = append(wastes, visible)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wastes, nil
+}
+
+// readTaggableWaste loads a waste the caller's organization is allowed to see
+func (s *SmartContract) readTaggableWaste(ctx contractapi.TransactionContextInterface, wasteId string) (*models.Waste, error) {
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, newError(ctx, ErrWasteNotVisible, wasteId)
+	}
+
+	return waste, nil
+}
+
+// putTaggedWaste records a tag change in the waste history, stores the waste
+// and raises the tag notice with the transaction's LedgerChanged event
+func (s *SmartContract) putTaggedWaste(ctx contractapi.TransactionContextInterface, waste *models.Waste, action string, notice string, tag string, actor string) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    action,
+		Actor:     actor,
+		Details:   fmt.Sprintf("Tag %s", tag),
+	})
+	if err := s.putWaste(ctx, waste); err != nil {
+		return err
+	}
+
+	return recordNotice(ctx, notice, "WASTE_"+waste.ID, fmt.Sprintf("Tag %s by %s", tag, actor), waste.OwnerMSP)
+}
@@ -0,0 +1,205 @@
+package contract
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const taxonomyKey = "TAXONOMY"
+
+var taxonomyCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
+
+// defaultTaxonomy is used until an admin edits the taxonomy
+func defaultTaxonomy() *models.Taxonomy {
+	return &models.Taxonomy{
+		Categories: []models.TaxonomyCategory{
+			{Code: "PRUNING", Label: "Pruning residue", Subtypes: []models.TaxonomySubtype{
+				{Code: "BRANCHES", Label: "Branches"},
+				{Code: "LEAVES", Label: "Leaves"},
+			}},
+			{Code: "MILLING", Label: "Milling by-products", Subtypes: []models.TaxonomySubtype{
+				{Code: "POMACE", Label: "Pomace"},
+				{Code: "PITS", Label: "Pits"},
+				{Code: "VEGETATION_WATER", Label: "Vegetation water"},
+			}},
+		},
+		Mappings: map[string]string{
+			"olive branches":   "PRUNING/BRANCHES",
+			"branches":         "PRUNING/BRANCHES",
+			"pruning residue":  "PRUNING/BRANCHES",
+			"olive leaves":     "PRUNING/LEAVES",
+			"leaves":           "PRUNING/LEAVES",
+			"olive pomace":     "MILLING/POMACE",
+			"pomace":           "MILLING/POMACE",
+			"olive pits":       "MILLING/PITS",
+			"pits":             "MILLING/PITS",
+			"vegetation water": "MILLING/VEGETATION_WATER",
+		},
+	}
+}
+
+// DefineWasteCategory adds or relabels a taxonomy category (admin only)
+func (s *SmartContract) DefineWasteCategory(ctx contractapi.TransactionContextInterface, code string, label string) (*models.Taxonomy, error) {
+	if !taxonomyCodePattern.MatchString(code) {
+		return nil, newError(ctx, ErrCategoryCodeInvalid, code)
+	}
+
+	return s.updateTaxonomy(ctx, func(taxonomy *models.Taxonomy) error {
+		if category := taxonomy.Category(code); category != nil {
+			category.Label = label
+			return nil
+		}
+		taxonomy.Categories = append(taxonomy.Categories, models.TaxonomyCategory{
+			Code:     code,
+			Label:    label,
+			Subtypes: []models.TaxonomySubtype{},
+		})
+		return nil
+	})
+}
+
+// DefineWasteSubtype adds or relabels a subtype within a category (admin only)
+func (s *SmartContract) DefineWasteSubtype(ctx contractapi.TransactionContextInterface, categoryCode string, code string, label string) (*models.Taxonomy, error) {
+	if !taxonomyCodePattern.MatchString(code) {
+		return nil, newError(ctx, ErrSubtypeCodeInvalid, code)
+	}
+
+	return s.updateTaxonomy(ctx, func(taxonomy *models.Taxonomy) error {
+		category := taxonomy.Category(categoryCode)
+		if category == nil {
+			return newError(ctx, ErrCategoryNotFound, categoryCode)
+		}
+		for i := range category.Subtypes {
+			if category.Subtypes[i].Code == code {
+				category.Subtypes[i].Label = label
+				return nil
+			}
+		}
+		category.Subtypes = append(category.Subtypes, models.TaxonomySubtype{Code: code, Label: label})
+		return nil
+	})
+}
+
+// MapWasteType maps a free-text waste type onto a taxonomy node (admin only);
+// an empty category removes the mapping
+func (s *SmartContract) MapWasteType(ctx contractapi.TransactionContextInterface, wasteType string, categoryCode string, subtypeCode string) (*models.Taxonomy, error) {
+	key := models.NormalizeWasteType(wasteType)
+	if key == "" {
+		return nil, newError(ctx, ErrWasteTypeRequired)
+	}
+
+	return s.updateTaxonomy(ctx, func(taxonomy *models.Taxonomy) error {
+		if categoryCode == "" {
+			delete(taxonomy.Mappings, key)
+			return nil
+		}
+		if !taxonomy.HasNode(categoryCode, subtypeCode) {
+			return newError(ctx, ErrTaxonomyNodeNotFound, categoryCode, subtypeCode)
+		}
+		taxonomy.Mappings[key] = categoryCode + "/" + subtypeCode
+		return nil
+	})
+}
+
+// GetTaxonomy returns the waste taxonomy and its type mappings
+func (s *SmartContract) GetTaxonomy(ctx contractapi.TransactionContextInterface) (*models.Taxonomy, error) {
+	return loadTaxonomy(ctx)
+}
+
+// GetWasteStatisticsByCategory groups waste counts and quantities by taxonomy
+// category, classifying legacy free-text types through the mappings
+func (s *SmartContract) GetWasteStatisticsByCategory(ctx contractapi.TransactionContextInterface) ([]*models.CategoryStatistics, error) {
+	taxonomy, err := loadTaxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := map[string]*models.CategoryStatistics{}
+	for _, waste := range wastes {
+		category, subtype := waste.Category, waste.Subtype
+		if category == "" {
+			category, subtype = taxonomy.Classify(waste.Type)
+		}
+
+		stats, ok := byCategory[category]
+		if !ok {
+			label := "Uncategorized"
+			if node := taxonomy.Category(category); node != nil {
+				label = node.Label
+			}
+			stats = &models.CategoryStatistics{Category: category, Label: label, Subtypes: map[string]float64{}}
+			byCategory[category] = stats
+		}
+		stats.Count++
+		stats.Quantity += waste.Quantity
+		if subtype != "" {
+			stats.Subtypes[subtype] += waste.Quantity
+		}
+	}
+
+	statistics := []*models.CategoryStatistics{}
+	for _, stats := range byCategory {
+		statistics = append(statistics, stats)
+	}
+	sort.Slice(statistics, func(i, j int) bool {
+		return statistics[i].Category < statistics[j].Category
+	})
+
+	return statistics, nil
+}
+
+// loadTaxonomy reads the taxonomy asset, returning the default one if unset
+func loadTaxonomy(ctx contractapi.TransactionContextInterface) (*models.Taxonomy, error) {
+	var taxonomy models.Taxonomy
+	found, err := newAssetStore(ctx).Get(taxonomyKey, &taxonomy)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, taxonomyKey, err)
+	}
+	if !found {
+		return defaultTaxonomy(), nil
+	}
+	if taxonomy.Mappings == nil {
+		taxonomy.Mappings = map[string]string{}
+	}
+
+	return &taxonomy, nil
+}
+
+// updateTaxonomy applies an admin edit to the taxonomy and stores it
+func (s *SmartContract) updateTaxonomy(ctx contractapi.TransactionContextInterface, edit func(*models.Taxonomy) error) (*models.Taxonomy, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	taxonomy, err := loadTaxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := edit(taxonomy); err != nil {
+		return nil, err
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	taxonomy.UpdatedAt = now
+	taxonomy.UpdatedBy = actor
+
+	if err := newAssetStore(ctx).Put(taxonomyKey, taxonomy); err != nil {
+		return nil, err
+	}
+
+	return taxonomy, nil
+}
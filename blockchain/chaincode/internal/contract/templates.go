@@ -0,0 +1,258 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SaveWasteTemplate creates or replaces a participant's lot template named
+// name from templateJson ({"type", "unit", "defaultQuantity", "farm",
+// "location", "plotId", "qualityGrade", "composition"}), preferring the
+// "template" transient entry so that the farm and location stay out of the
+// transaction arguments. Templates are kept per participant of the caller's
+// organization; when participantId is empty the participant is the owner
+// named in the "pii" transient entry, registered on first use.
+func (s *SmartContract) SaveWasteTemplate(ctx contractapi.TransactionContextInterface, participantId string, name string, templateJson string) (*models.WasteTemplate, error) {
+	if models.NormalizeWasteType(name) == "" {
+		return nil, newError(ctx, ErrTemplateNameRequired)
+	}
+	template, err := templateData(ctx, templateJson)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWasteTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	participant, collection, err := managedParticipant(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	if template.PlotID != "" {
+		if _, template.Farm, err = checkWastePlot(ctx, template.PlotID, template.Farm, participant.MSP); err != nil {
+			return nil, err
+		}
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := readWasteTemplate(ctx, collection, participant.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	template.CreatedAt = now
+	if existing != nil {
+		template.Uses = existing.Uses
+		template.LastUsedAt = existing.LastUsedAt
+		template.CreatedAt = existing.CreatedAt
+	}
+	template.Name = strings.TrimSpace(name)
+	template.ParticipantID = participant.ID
+	template.UpdatedAt = now
+
+	if err := putPrivate(ctx, collection, wasteTemplateKey(participant.ID, name), template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// DeleteWasteTemplate removes a participant's lot template
+func (s *SmartContract) DeleteWasteTemplate(ctx contractapi.TransactionContextInterface, participantId string, name string) error {
+	participant, collection, err := managedParticipant(ctx, participantId)
+	if err != nil {
+		return err
+	}
+	template, err := readWasteTemplate(ctx, collection, participant.ID, name)
+	if err != nil {
+		return err
+	}
+	if template == nil {
+		return newError(ctx, ErrTemplateNotFound, name, participant.ID)
+	}
+
+	return ctx.GetStub().DelPrivateData(collection, wasteTemplateKey(participant.ID, name))
+}
+
+// ReadWasteTemplate returns a participant's lot template
+func (s *SmartContract) ReadWasteTemplate(ctx contractapi.TransactionContextInterface, participantId string, name string) (*models.WasteTemplate, error) {
+	participant, collection, err := managedParticipant(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	template, err := readWasteTemplate(ctx, collection, participant.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, newError(ctx, ErrTemplateNotFound, name, participant.ID)
+	}
+
+	return template, nil
+}
+
+// GetWasteTemplates returns a participant's lot templates, the most used
+// first
+func (s *SmartContract) GetWasteTemplates(ctx contractapi.TransactionContextInterface, participantId string) ([]*models.WasteTemplate, error) {
+	participant, collection, err := managedParticipant(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	prefix := "WASTETEMPLATE_" + participant.ID + "_"
+	templates := []*models.WasteTemplate{}
+	err = privateRange(ctx, collection, prefix, prefix+"~", func(key string, value []byte) error {
+		var template models.WasteTemplate
+		if err := json.Unmarshal(value, &template); err != nil {
+			return err
+		}
+		// IDs sharing a prefix (A and A_B) share the key range
+		if template.ParticipantID == participant.ID {
+			templates = append(templates, &template)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(templates, func(i, j int) bool {
+		return templates[i].Uses > templates[j].Uses
+	})
+
+	return templates, nil
+}
+
+// CreateWasteFromTemplate records a lot with the defaults of a participant's
+// template. quantity is in the template's unit, its default quantity when
+// 0; harvestDate defaults to the transaction date and the ID is generated
+// when id is empty. The lot is checked and stored as CreateWaste would.
+func (s *SmartContract) CreateWasteFromTemplate(ctx contractapi.TransactionContextInterface, participantId string, name string, id string, quantity float64, harvestDate string) (*models.Waste, error) {
+	participant, collection, err := managedParticipant(ctx, participantId)
+	if err != nil {
+		return nil, err
+	}
+	template, err := readWasteTemplate(ctx, collection, participant.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, newError(ctx, ErrTemplateNotFound, name, participant.ID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if quantity == 0 {
+		quantity = template.DefaultQuantity
+	}
+	if template.Unit == models.UnitKilograms {
+		quantity /= 1000
+	}
+	if harvestDate == "" {
+		harvestDate = now[:10]
+	}
+
+	staged := newStagedWrites(ctx)
+	waste, _, err := s.buildWaste(ctx, staged, id, template.Type, quantity, harvestDate, participant.Name, template.Farm, template.Location, template.PlotID)
+	if err != nil {
+		return nil, err
+	}
+	if template.QualityGrade != "" {
+		waste.QualityGrade = template.QualityGrade
+	}
+	if template.Composition != nil {
+		composition := *template.Composition
+		waste.Composition = &composition
+		waste.DryMatter = dryMatter(&composition, waste.Quantity)
+	}
+	waste.History[0].Details += fmt.Sprintf(" (template %q)", template.Name)
+
+	template.Uses++
+	template.LastUsedAt = now
+	staged.write(func() error {
+		return putPrivate(ctx, collection, wasteTemplateKey(participant.ID, template.Name), template)
+	})
+
+	if err := s.commitNewWaste(ctx, staged, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// templateData returns the template of a SaveWasteTemplate call, preferring
+// the "template" transient entry over the argument
+func templateData(ctx contractapi.TransactionContextInterface, templateJson string) (*models.WasteTemplate, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	if templateJSON, ok := transient["template"]; ok {
+		templateJson = string(templateJSON)
+	}
+
+	var template models.WasteTemplate
+	if err := json.Unmarshal([]byte(templateJson), &template); err != nil {
+		return nil, newError(ctx, ErrTemplateInvalid, err)
+	}
+
+	return &template, nil
+}
+
+// validateWasteTemplate checks a template's defaults, defaulting its unit
+// to tonnes
+func validateWasteTemplate(ctx contractapi.TransactionContextInterface, template *models.WasteTemplate) error {
+	template.Type = strings.TrimSpace(template.Type)
+	if template.Type == "" {
+		return newError(ctx, ErrTemplateWasteTypeRequired)
+	}
+	switch template.Unit {
+	case "":
+		template.Unit = models.UnitTonnes
+	case models.UnitTonnes, models.UnitKilograms:
+	default:
+		return newError(ctx, ErrUnitUnknown, template.Unit, models.UnitTonnes, models.UnitKilograms)
+	}
+	if template.DefaultQuantity < 0 {
+		return newError(ctx, ErrDefaultQuantityNegative)
+	}
+	if template.QualityGrade != "" && gradeRank(template.QualityGrade) == len(qualityGrades) {
+		return newError(ctx, ErrQualityGradeUnknown, template.QualityGrade, qualityGrades)
+	}
+	if template.Composition != nil {
+		if err := validateComposition(ctx, template.Composition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readWasteTemplate(ctx contractapi.TransactionContextInterface, collection string, participantID string, name string) (*models.WasteTemplate, error) {
+	templateJSON, err := ctx.GetStub().GetPrivateData(collection, wasteTemplateKey(participantID, name))
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, wasteTemplateKey(participantID, name), err)
+	}
+	if templateJSON == nil {
+		return nil, nil
+	}
+
+	var template models.WasteTemplate
+	if err := json.Unmarshal(templateJSON, &template); err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+// wasteTemplateKey indexes templates by participant and normalized name
+func wasteTemplateKey(participantID string, name string) string {
+	return "WASTETEMPLATE_" + participantID + "_" + models.NormalizeWasteType(name)
+}
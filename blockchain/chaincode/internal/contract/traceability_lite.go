@@ -0,0 +1,228 @@
+package contract
+
+import (
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Limits that keep lightweight traceability payloads small
+const (
+	defaultHistoryLimit = 10
+	maxHistoryLimit     = 100
+)
+
+// wasteLinkIndex lists the extractions and recyclings made from each lot:
+// waste ID, asset type, asset ID
+const wasteLinkIndex = "waste~asset~id"
+
+// GetTraceabilityLite returns the assets linked to a waste and its last
+// historyLimit history entries; full histories are fetched in segments with
+// GetAssetHistorySegment. Linked assets are read from the waste link index;
+// those recorded before it existed are listed once the waste-link-index
+// migration ran.
+func (s *SmartContract) GetTraceabilityLite(ctx contractapi.TransactionContextInterface, wasteId string, historyLimit int) (*models.TraceabilityLite, error) {
+	historyLimit = clampHistoryLimit(historyLimit)
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+
+	lite := &models.TraceabilityLite{
+		Waste:         models.AssetRef{ID: waste.ID, Status: waste.Status, Version: waste.Version},
+		CertificateID: waste.CertificateID,
+		Extractions:   []models.AssetRef{},
+		Recyclings:    []models.AssetRef{},
+		RecentEvents:  []models.History{},
+	}
+	if !canView {
+		lite.Redacted = true
+		return lite, nil
+	}
+	lite.Waste.HistoryTotal = waste.ArchivedHistory + len(waste.History)
+	if len(waste.History) > historyLimit {
+		lite.RecentEvents = waste.History[len(waste.History)-historyLimit:]
+	} else {
+		lite.RecentEvents = waste.History
+	}
+
+	err = partialKeyQuery(ctx, wasteLinkIndex, []string{wasteId}, func(keyParts []string, _ []byte) error {
+		switch keyParts[1] {
+		case "EXTRACTION":
+			var extraction models.Extraction
+			found, err := newAssetStore(ctx).Get("EXTRACTION_"+keyParts[2], &extraction)
+			if err != nil || !found {
+				return err
+			}
+			lite.Extractions = append(lite.Extractions, models.AssetRef{ID: extraction.ID, Status: extraction.Status, Version: extraction.Version, HistoryTotal: extraction.ArchivedHistory + len(extraction.History)})
+		case "RECYCLING":
+			var recycling models.Recycling
+			found, err := newAssetStore(ctx).Get("RECYCLING_"+keyParts[2], &recycling)
+			if err != nil || !found {
+				return err
+			}
+			lite.Recyclings = append(lite.Recyclings, models.AssetRef{ID: recycling.ID, Status: recycling.Status, Version: recycling.Version, HistoryTotal: recycling.ArchivedHistory + len(recycling.History)})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return lite, nil
+}
+
+// indexExtractionLink stages the waste link index entry of a new extraction
+func indexExtractionLink(staged *stagedWrites, extraction *models.Extraction) {
+	staged.write(func() error {
+		return putWasteLink(staged.ctx, extraction.WasteID, "EXTRACTION", extraction.ID)
+	})
+}
+
+// indexRecyclingLinks stages the waste link index entries of a new
+// recycling, one per input lot as the operation left them at commit
+func indexRecyclingLinks(staged *stagedWrites, recycling *models.Recycling) {
+	staged.write(func() error {
+		for _, input := range recycling.InputLots() {
+			if err := putWasteLink(staged.ctx, input.WasteID, "RECYCLING", recycling.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func putWasteLink(ctx contractapi.TransactionContextInterface, wasteID string, assetType string, id string) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(wasteLinkIndex, []string{wasteID, assetType, id})
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(indexKey, []byte{0x00})
+}
+
+// GetAssetHistorySegment returns limit history entries of a waste, extraction
+// or recycling starting at offset (oldest first); offsets span archived and
+// embedded entries alike
+func (s *SmartContract) GetAssetHistorySegment(ctx contractapi.TransactionContextInterface, assetType string, id string, offset int, limit int) (*models.HistorySegment, error) {
+	if offset < 0 {
+		return nil, newError(ctx, ErrOffsetNegative)
+	}
+	limit = clampHistoryLimit(limit)
+
+	history, archived, err := s.visibleHistory(ctx, assetType, id)
+	if err != nil {
+		return nil, err
+	}
+	total := archived + len(history)
+
+	segment := &models.HistorySegment{
+		AssetType:  assetType,
+		ID:         id,
+		Offset:     offset,
+		Total:      total,
+		Entries:    []models.History{},
+		NextOffset: -1,
+	}
+	if offset >= total {
+		return segment, nil
+	}
+	end := offset + limit
+	if end < total {
+		segment.NextOffset = end
+	} else {
+		end = total
+	}
+
+	if offset < archived {
+		checkpoints, err := loadHistoryCheckpoints(ctx, assetType, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, checkpoint := range checkpoints {
+			for i, entry := range checkpoint.Entries {
+				index := checkpoint.FirstIndex + i
+				if index >= offset && index < end {
+					segment.Entries = append(segment.Entries, entry)
+				}
+			}
+		}
+	}
+	for i, entry := range history {
+		index := archived + i
+		if index >= offset && index < end {
+			segment.Entries = append(segment.Entries, entry)
+		}
+	}
+
+	return segment, nil
+}
+
+// visibleHistory returns the embedded history and archived entry count of a
+// waste, extraction or recycling, provided the caller may view the source
+// waste
+func (s *SmartContract) visibleHistory(ctx contractapi.TransactionContextInterface, assetType string, id string) ([]models.History, int, error) {
+	var history []models.History
+	var archived int
+	var wasteID string
+	switch assetType {
+	case "WASTE":
+		wasteID = id
+	case "EXTRACTION":
+		extraction, err := s.readExtraction(ctx, id)
+		if err != nil {
+			return nil, 0, err
+		}
+		wasteID, history, archived = extraction.WasteID, extraction.History, extraction.ArchivedHistory
+	case "RECYCLING":
+		recycling, err := s.GetRecycling(ctx, id)
+		if err != nil {
+			return nil, 0, err
+		}
+		wasteID, history, archived = recycling.WasteID, recycling.History, recycling.ArchivedHistory
+	default:
+		return nil, 0, newError(ctx, ErrTraceAssetTypeInvalid)
+	}
+
+	waste, err := s.readWaste(ctx, wasteID)
+	if err != nil {
+		return nil, 0, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !canView {
+		return nil, 0, newError(ctx, ErrWasteNotVisible, wasteID)
+	}
+	if assetType == "WASTE" {
+		history, archived = waste.History, waste.ArchivedHistory
+	}
+
+	return history, archived, nil
+}
+
+func clampHistoryLimit(limit int) int {
+	if limit <= 0 {
+		return defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		return maxHistoryLimit
+	}
+
+	return limit
+}
@@ -0,0 +1,589 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Holder types accepted by RecordPermit
+const (
+	permitHolderVehicle = "VEHICLE"
+	permitHolderDriver  = "DRIVER"
+)
+
+// RegisterVehicle registers a truck of the caller's organization; a plate can
+// only belong to one active vehicle
+func (s *SmartContract) RegisterVehicle(ctx contractapi.TransactionContextInterface, id string, plate string, vehicleType string, capacityKg float64, actor string) (*models.Vehicle, error) {
+	plate = normalizePlate(plate)
+	if plate == "" {
+		return nil, newError(ctx, ErrVehiclePlateRequired)
+	}
+	if capacityKg < 0 {
+		return nil, newError(ctx, ErrVehicleCapacityNegative)
+	}
+	if id == "" {
+		generated, err := newAssetID(ctx, "VEHICLE")
+		if err != nil {
+			return nil, err
+		}
+		id = generated
+	}
+
+	exists, err := newAssetStore(ctx).Exists("VEHICLE_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrVehicleAlreadyExists, id)
+	}
+
+	vehicles, err := loadVehicles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range vehicles {
+		if other.Plate == plate && other.Status == models.TransportActive {
+			return nil, newError(ctx, ErrPlateAlreadyRegistered, plate, other.ID)
+		}
+	}
+
+	operatorMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicle := &models.Vehicle{
+		ID:          id,
+		Plate:       plate,
+		Type:        vehicleType,
+		CapacityKg:  capacityKg,
+		OperatorMSP: operatorMSP,
+		Status:      models.TransportActive,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "REGISTERED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("Vehicle %s registered", plate),
+			},
+		},
+	}
+
+	if err := s.putVehicle(ctx, vehicle); err != nil {
+		return nil, err
+	}
+
+	return vehicle, nil
+}
+
+// RegisterDriver registers a driver of the caller's organization with the
+// expiry date (YYYY-MM-DD) of their driving license
+func (s *SmartContract) RegisterDriver(ctx contractapi.TransactionContextInterface, id string, licenseNumber string, licenseExpiresAt string, actor string) (*models.Driver, error) {
+	licenseNumber = strings.TrimSpace(licenseNumber)
+	if id == "" || licenseNumber == "" {
+		return nil, newError(ctx, ErrDriverFieldsRequired)
+	}
+	if _, err := time.Parse("2006-01-02", licenseExpiresAt); err != nil {
+		return nil, newError(ctx, ErrLicenseExpiryInvalid, licenseExpiresAt)
+	}
+
+	exists, err := newAssetStore(ctx).Exists("DRIVER_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrDriverAlreadyExists, id)
+	}
+
+	operatorMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	driver := &models.Driver{
+		ID:               id,
+		LicenseNumber:    licenseNumber,
+		LicenseExpiresAt: licenseExpiresAt,
+		OperatorMSP:      operatorMSP,
+		Status:           models.TransportActive,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "REGISTERED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("Driver registered, license valid until %s", licenseExpiresAt),
+			},
+		},
+	}
+
+	if err := s.putDriver(ctx, driver); err != nil {
+		return nil, err
+	}
+
+	return driver, nil
+}
+
+// RecordPermit adds or renews a permit (e.g. "ADR") of a vehicle or driver of
+// the caller's organization; holderType is VEHICLE or DRIVER and a permit of
+// the same kind is replaced
+func (s *SmartContract) RecordPermit(ctx contractapi.TransactionContextInterface, holderType string, holderId string, kind string, number string, expiresAt string, actor string) error {
+	kind = strings.ToUpper(strings.TrimSpace(kind))
+	if kind == "" || number == "" {
+		return newError(ctx, ErrPermitFieldsRequired)
+	}
+	if _, err := time.Parse("2006-01-02", expiresAt); err != nil {
+		return newError(ctx, ErrPermitExpiryInvalid, expiresAt)
+	}
+	organization, err := callerMSP(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	permit := models.Permit{Kind: kind, Number: number, ExpiresAt: expiresAt}
+	entry := models.History{
+		Timestamp: now,
+		Action:    "PERMIT_RECORDED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%s permit %s valid until %s", kind, number, expiresAt),
+	}
+
+	switch strings.ToUpper(holderType) {
+	case permitHolderVehicle:
+		vehicle, err := s.ReadVehicle(ctx, holderId)
+		if err != nil {
+			return err
+		}
+		if vehicle.OperatorMSP != organization && !isAdmin(ctx) {
+			return newError(ctx, ErrVehiclePermitForbidden, vehicle.OperatorMSP, holderId)
+		}
+		vehicle.Permits = setPermit(vehicle.Permits, permit)
+		vehicle.UpdatedAt = now
+		vehicle.History = append(vehicle.History, entry)
+
+		return s.putVehicle(ctx, vehicle)
+	case permitHolderDriver:
+		driver, err := s.ReadDriver(ctx, holderId)
+		if err != nil {
+			return err
+		}
+		if driver.OperatorMSP != organization && !isAdmin(ctx) {
+			return newError(ctx, ErrDriverPermitForbidden, driver.OperatorMSP, holderId)
+		}
+		driver.Permits = setPermit(driver.Permits, permit)
+		driver.UpdatedAt = now
+		driver.History = append(driver.History, entry)
+
+		return s.putDriver(ctx, driver)
+	default:
+		return newError(ctx, ErrPermitHolderTypeInvalid, holderType, permitHolderVehicle, permitHolderDriver)
+	}
+}
+
+// RetireVehicle takes a vehicle out of service; it can no longer be used on
+// new shipments
+func (s *SmartContract) RetireVehicle(ctx contractapi.TransactionContextInterface, id string, reason string, actor string) (*models.Vehicle, error) {
+	vehicle, err := s.ReadVehicle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if vehicle.Status == models.TransportRetired {
+		return nil, newError(ctx, ErrVehicleAlreadyRetired, id)
+	}
+	organization, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if organization != vehicle.OperatorMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrVehicleRetireForbidden, vehicle.OperatorMSP, id)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicle.Status = models.TransportRetired
+	vehicle.UpdatedAt = now
+	vehicle.History = append(vehicle.History, models.History{
+		Timestamp: now,
+		Action:    "RETIRED",
+		Actor:     actor,
+		Details:   reason,
+	})
+
+	if err := s.putVehicle(ctx, vehicle); err != nil {
+		return nil, err
+	}
+
+	return vehicle, nil
+}
+
+// ReadVehicle returns the vehicle stored with the given id
+func (s *SmartContract) ReadVehicle(ctx contractapi.TransactionContextInterface, id string) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	found, err := newAssetStore(ctx).Get("VEHICLE_"+id, &vehicle)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "VEHICLE_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrVehicleNotFound, id)
+	}
+
+	return &vehicle, nil
+}
+
+// GetVehicles returns the vehicles of the caller's organization, or all
+// vehicles for admins
+func (s *SmartContract) GetVehicles(ctx contractapi.TransactionContextInterface) ([]*models.Vehicle, error) {
+	organization, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vehicles, err := loadVehicles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []*models.Vehicle{}
+	for _, vehicle := range vehicles {
+		if vehicle.OperatorMSP == organization || isAdmin(ctx) {
+			visible = append(visible, vehicle)
+		}
+	}
+
+	return visible, nil
+}
+
+// ReadDriver returns the driver stored with the given id
+func (s *SmartContract) ReadDriver(ctx contractapi.TransactionContextInterface, id string) (*models.Driver, error) {
+	var driver models.Driver
+	found, err := newAssetStore(ctx).Get("DRIVER_"+id, &driver)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "DRIVER_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrDriverNotFound, id)
+	}
+
+	return &driver, nil
+}
+
+// CreateShipment records the transport of a lot by one of the caller's
+// registered vehicles and drivers. The driver's license must be valid on the
+// departure date; expired vehicle or driver permits do not block the
+// shipment but flag it and notify the lot owner.
+func (s *SmartContract) CreateShipment(ctx contractapi.TransactionContextInterface, id string, wasteId string, vehicleId string, driverId string, quantity float64, origin string, destination string, departureDate string, actor string) (*models.Shipment, error) {
+	if vehicleId == "" || driverId == "" {
+		return nil, newError(ctx, ErrShipmentCarrierRequired)
+	}
+	if destination == "" {
+		return nil, newError(ctx, ErrShipmentDestinationRequired)
+	}
+	if _, err := time.Parse("2006-01-02", departureDate); err != nil {
+		return nil, newError(ctx, ErrDepartureDateInvalid, departureDate)
+	}
+
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	canView, err := viewer.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, newError(ctx, ErrWasteNotVisible, wasteId)
+	}
+	if quantity <= 0 || quantity > waste.Quantity {
+		return nil, newError(ctx, ErrShipmentQuantityInvalid, waste.Quantity)
+	}
+
+	carrierMSP, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vehicle, err := s.ReadVehicle(ctx, vehicleId)
+	if err != nil {
+		return nil, err
+	}
+	if vehicle.Status != models.TransportActive || vehicle.OperatorMSP != carrierMSP {
+		return nil, newError(ctx, ErrVehicleUnavailable, vehicleId, carrierMSP)
+	}
+	driver, err := s.ReadDriver(ctx, driverId)
+	if err != nil {
+		return nil, err
+	}
+	if driver.Status != models.TransportActive || driver.OperatorMSP != carrierMSP {
+		return nil, newError(ctx, ErrDriverUnavailable, driverId, carrierMSP)
+	}
+	if driver.LicenseExpiresAt < departureDate {
+		return nil, newError(ctx, ErrDriverLicenseExpired, driverId, driver.LicenseExpiresAt)
+	}
+
+	if id == "" {
+		if id, err = newAssetID(ctx, "SHIPMENT"); err != nil {
+			return nil, err
+		}
+	}
+	exists, err := newAssetStore(ctx).Exists("SHIPMENT_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrShipmentAlreadyExists, id)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := []string{}
+	for _, permit := range expiredPermits(vehicle.Permits, departureDate) {
+		flags = append(flags, fmt.Sprintf("vehicle %s %s permit %s expired on %s", vehicle.Plate, permit.Kind, permit.Number, permit.ExpiresAt))
+	}
+	for _, permit := range expiredPermits(driver.Permits, departureDate) {
+		flags = append(flags, fmt.Sprintf("driver %s %s permit %s expired on %s", driver.ID, permit.Kind, permit.Number, permit.ExpiresAt))
+	}
+
+	shipment := &models.Shipment{
+		ID:            id,
+		WasteID:       wasteId,
+		VehicleID:     vehicleId,
+		Plate:         vehicle.Plate,
+		DriverID:      driverId,
+		CarrierMSP:    carrierMSP,
+		Quantity:      quantity,
+		Origin:        origin,
+		Destination:   destination,
+		DepartureDate: departureDate,
+		Status:        models.ShipmentInTransit,
+		Flagged:       len(flags) > 0,
+		Flags:         flags,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		History: []models.History{
+			{
+				Timestamp: now,
+				Action:    "DISPATCHED",
+				Actor:     actor,
+				Details:   fmt.Sprintf("%.2f of lot %s to %s with vehicle %s", quantity, wasteId, destination, vehicle.Plate),
+			},
+		},
+	}
+
+	if err := s.putShipment(ctx, shipment); err != nil {
+		return nil, err
+	}
+
+	if shipment.Flagged && waste.OwnerMSP != "" {
+		if err := notify(ctx, waste.OwnerMSP, models.NotifyShipmentFlagged, "SHIPMENT_"+id, fmt.Sprintf("Shipment %s of lot %s: %s", id, wasteId, strings.Join(flags, "; "))); err != nil {
+			return nil, err
+		}
+	}
+
+	return shipment, nil
+}
+
+// DeliverShipment marks a shipment of the caller's organization as delivered
+// at latitude, longitude, which must fall within the geofence of the
+// destination facility unless an admin gives an override reason; the
+// result of the check is kept with the shipment
+func (s *SmartContract) DeliverShipment(ctx contractapi.TransactionContextInterface, id string, actor string, latitude float64, longitude float64, overrideReason string) (*models.Shipment, error) {
+	shipment, err := s.ReadShipment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.Status != models.ShipmentInTransit {
+		return nil, newError(ctx, ErrShipmentStatusInvalid, id, shipment.Status)
+	}
+	organization, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if organization != shipment.CarrierMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrShipmentDeliverForbidden, shipment.CarrierMSP, id)
+	}
+	check, err := s.checkDeliveryPosition(ctx, shipment, latitude, longitude, overrideReason)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("Delivered to %s at %.6f,%.6f", shipment.Destination, latitude, longitude)
+	switch check.Result {
+	case models.GeofenceInside:
+		details += fmt.Sprintf(", %.0f m from facility %s (geofence %.0f m)", check.DistanceMeters, check.FacilityID, check.RadiusMeters)
+	case models.GeofenceOverridden:
+		details += fmt.Sprintf(", geofence overridden by %s: %s", check.OverriddenBy, check.OverrideReason)
+	default:
+		details += ", no geofence to check against"
+	}
+	shipment.Status = models.ShipmentDelivered
+	shipment.Delivery = check
+	shipment.UpdatedAt = now
+	shipment.History = append(shipment.History, models.History{
+		Timestamp: now,
+		Action:    "DELIVERED",
+		Actor:     actor,
+		Details:   details,
+	})
+
+	if err := s.putShipment(ctx, shipment); err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// ReadShipment returns the shipment stored with the given id
+func (s *SmartContract) ReadShipment(ctx contractapi.TransactionContextInterface, id string) (*models.Shipment, error) {
+	var shipment models.Shipment
+	found, err := newAssetStore(ctx).Get("SHIPMENT_"+id, &shipment)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "SHIPMENT_"+id, err)
+	}
+	if !found {
+		return nil, newError(ctx, ErrShipmentNotFound, id)
+	}
+
+	return &shipment, nil
+}
+
+// GetShipmentsForWaste returns the shipments of a lot
+func (s *SmartContract) GetShipmentsForWaste(ctx contractapi.TransactionContextInterface, wasteId string) ([]*models.Shipment, error) {
+	return loadShipments(ctx, func(shipment *models.Shipment) bool {
+		return shipment.WasteID == wasteId
+	})
+}
+
+// GetFlaggedShipments returns the shipments made with expired permits
+func (s *SmartContract) GetFlaggedShipments(ctx contractapi.TransactionContextInterface) ([]*models.Shipment, error) {
+	return loadShipments(ctx, func(shipment *models.Shipment) bool {
+		return shipment.Flagged
+	})
+}
+
+// expiredPermits returns the permits no longer valid on the given date
+func expiredPermits(permits []models.Permit, date string) []models.Permit {
+	var expired []models.Permit
+	for _, permit := range permits {
+		if permit.ExpiresAt < date {
+			expired = append(expired, permit)
+		}
+	}
+
+	return expired
+}
+
+// setPermit replaces the permit of the same kind, or adds it
+func setPermit(permits []models.Permit, permit models.Permit) []models.Permit {
+	for i := range permits {
+		if permits[i].Kind == permit.Kind {
+			permits[i] = permit
+			return permits
+		}
+	}
+
+	return append(permits, permit)
+}
+
+// normalizePlate upper-cases a plate and drops spaces and dashes
+func normalizePlate(plate string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.ToUpper(strings.TrimSpace(plate)))
+}
+
+func (s *SmartContract) putVehicle(ctx contractapi.TransactionContextInterface, vehicle *models.Vehicle) error {
+	return newAssetStore(ctx).Put("VEHICLE_"+vehicle.ID, vehicle)
+}
+
+func (s *SmartContract) putDriver(ctx contractapi.TransactionContextInterface, driver *models.Driver) error {
+	return newAssetStore(ctx).Put("DRIVER_"+driver.ID, driver)
+}
+
+func (s *SmartContract) putShipment(ctx contractapi.TransactionContextInterface, shipment *models.Shipment) error {
+	return newAssetStore(ctx).Put("SHIPMENT_"+shipment.ID, shipment)
+}
+
+func loadVehicles(ctx contractapi.TransactionContextInterface) ([]*models.Vehicle, error) {
+	vehicles := []*models.Vehicle{}
+	err := newAssetStore(ctx).Range("VEHICLE_", "VEHICLE_~", func(_ string, value []byte) error {
+		var vehicle models.Vehicle
+		if err := json.Unmarshal(value, &vehicle); err != nil {
+			return err
+		}
+		vehicles = append(vehicles, &vehicle)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vehicles, nil
+}
+
+func loadDrivers(ctx contractapi.TransactionContextInterface) ([]*models.Driver, error) {
+	drivers := []*models.Driver{}
+	err := newAssetStore(ctx).Range("DRIVER_", "DRIVER_~", func(_ string, value []byte) error {
+		var driver models.Driver
+		if err := json.Unmarshal(value, &driver); err != nil {
+			return err
+		}
+		drivers = append(drivers, &driver)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return drivers, nil
+}
+
+func loadShipments(ctx contractapi.TransactionContextInterface, keep func(*models.Shipment) bool) ([]*models.Shipment, error) {
+	shipments := []*models.Shipment{}
+	err := newAssetStore(ctx).Range("SHIPMENT_", "SHIPMENT_~", func(_ string, value []byte) error {
+		var shipment models.Shipment
+		if err := json.Unmarshal(value, &shipment); err != nil {
+			return err
+		}
+		if keep(&shipment) {
+			shipments = append(shipments, &shipment)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipments, nil
+}
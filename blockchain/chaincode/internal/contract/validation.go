@@ -0,0 +1,299 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Default plausible harvest, in quantity per hectare of the declared plot;
+// config validation.minYieldPerHa and validation.maxYieldPerHa override it
+const (
+	defaultMinYieldPerHa = 0
+	defaultMaxYieldPerHa = 5000
+)
+
+// ResolveWasteWarning closes the open warning with the given code on a lot,
+// explaining how it was handled; the owning organization or an admin only.
+// A non-zero expectedVersion guards against concurrent updates.
+func (s *SmartContract) ResolveWasteWarning(ctx contractapi.TransactionContextInterface, wasteId string, code string, resolution string, expectedVersion int) (*models.Waste, error) {
+	if resolution == "" {
+		return nil, newError(ctx, ErrResolutionRequired)
+	}
+	waste, err := s.readWaste(ctx, wasteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ctx, "WASTE_"+wasteId, waste.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waste.OwnerMSP != "" && mspID != waste.OwnerMSP && !isAdmin(ctx) {
+		return nil, newError(ctx, ErrWarningResolveForbidden, waste.OwnerMSP, wasteId)
+	}
+
+	var warning *models.ValidationWarning
+	for i := range waste.Warnings {
+		if waste.Warnings[i].Code == code && !waste.Warnings[i].Resolved {
+			warning = &waste.Warnings[i]
+		}
+	}
+	if warning == nil {
+		return nil, newError(ctx, ErrWarningNotFound, wasteId, code)
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	warning.Resolved = true
+	warning.ResolvedBy = actor
+	warning.ResolvedAt = now
+	warning.Resolution = resolution
+	waste.UpdatedAt = now
+	waste.History = append(waste.History, models.History{
+		Timestamp: now,
+		Action:    "WARNING_RESOLVED",
+		Actor:     actor,
+		Details:   fmt.Sprintf("%s: %s", code, resolution),
+	})
+
+	if err := s.putWaste(ctx, waste); err != nil {
+		return nil, err
+	}
+
+	return waste, nil
+}
+
+// GetWastesWithWarnings returns the lots the caller may see in full that
+// still have open warnings, oldest first; code filters them when set
+func (s *SmartContract) GetWastesWithWarnings(ctx contractapi.TransactionContextInterface, code string) ([]*models.Waste, error) {
+	wastes, err := loadWastes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := newWasteViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	flagged := []*models.Waste{}
+	for _, waste := range wastes {
+		if !hasOpenWarning(waste, code) {
+			continue
+		}
+		visible, err := viewer.canView(ctx, waste)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			flagged = append(flagged, waste)
+		}
+	}
+	sort.Slice(flagged, func(i, j int) bool {
+		return flagged[i].CreatedAt < flagged[j].CreatedAt
+	})
+
+	return flagged, nil
+}
+
+// SetValidationProfile sets the optional fields (farm, location, plotId,
+// comma separated) that new lots of an organization must fill in; an empty
+// list lifts the requirements. Admin only.
+func (s *SmartContract) SetValidationProfile(ctx contractapi.TransactionContextInterface, orgMsp string, requiredFields string) (*models.ValidationProfile, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if orgMsp == "" {
+		return nil, newError(ctx, ErrOrganizationRequired)
+	}
+	fields := []string{}
+	seen := map[string]bool{}
+	for _, field := range splitList(requiredFields) {
+		if !isProfileField(field) {
+			return nil, newError(ctx, ErrFieldNotRequirable, field, strings.Join(profileFields, ", "))
+		}
+		if !seen[field] {
+			fields = append(fields, field)
+		}
+		seen[field] = true
+	}
+
+	actor, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := readValidationProfile(ctx, orgMsp)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = &models.ValidationProfile{
+			OrgMSP:    orgMsp,
+			CreatedAt: now,
+			History:   []models.History{},
+		}
+	}
+	details := "No optional field required"
+	if len(fields) > 0 {
+		details = "Required: " + strings.Join(fields, ", ")
+	}
+	profile.RequiredFields = fields
+	profile.UpdatedAt = now
+	profile.History = append(profile.History, models.History{
+		Timestamp: now,
+		Action:    "PROFILE_SET",
+		Actor:     actor,
+		Details:   details,
+	})
+
+	if err := newAssetStore(ctx).Put("VALIDATIONPROFILE_"+orgMsp, profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// ReadValidationProfile returns the validation profile of an organization;
+// one that was never set requires no optional field
+func (s *SmartContract) ReadValidationProfile(ctx contractapi.TransactionContextInterface, orgMsp string) (*models.ValidationProfile, error) {
+	profile, err := readValidationProfile(ctx, orgMsp)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = &models.ValidationProfile{
+			OrgMSP:         orgMsp,
+			RequiredFields: []string{},
+			History:        []models.History{},
+		}
+	}
+
+	return profile, nil
+}
+
+// GetValidationProfiles returns the validation profiles of all organizations
+func (s *SmartContract) GetValidationProfiles(ctx contractapi.TransactionContextInterface) ([]*models.ValidationProfile, error) {
+	profiles := []*models.ValidationProfile{}
+	err := newAssetStore(ctx).Range("VALIDATIONPROFILE_", "VALIDATIONPROFILE_~", func(_ string, value []byte) error {
+		var profile models.ValidationProfile
+		if err := json.Unmarshal(value, &profile); err != nil {
+			return err
+		}
+		profiles = append(profiles, &profile)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// checkRequiredFields rejects a new lot of an organization leaving out a
+// field its validation profile requires
+func checkRequiredFields(ctx contractapi.TransactionContextInterface, ownerMSP string, values map[string]string) error {
+	profile, err := readValidationProfile(ctx, ownerMSP)
+	if err != nil || profile == nil {
+		return err
+	}
+	for _, field := range profile.RequiredFields {
+		if strings.TrimSpace(values[field]) == "" {
+			return newError(ctx, ErrFieldRequired, field, ownerMSP)
+		}
+	}
+
+	return nil
+}
+
+// readValidationProfile returns the validation profile of an organization,
+// or nil if none was set
+func readValidationProfile(ctx contractapi.TransactionContextInterface, orgMSP string) (*models.ValidationProfile, error) {
+	var profile models.ValidationProfile
+	found, err := newAssetStore(ctx).Get("VALIDATIONPROFILE_"+orgMSP, &profile)
+	if err != nil {
+		return nil, newError(ctx, ErrLedgerRead, "VALIDATIONPROFILE_"+orgMSP, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &profile, nil
+}
+
+var profileFields = []string{models.FieldFarm, models.FieldLocation, models.FieldPlot}
+
+func isProfileField(field string) bool {
+	for _, known := range profileFields {
+		if field == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasOpenWarning reports whether a lot has an unresolved warning, of the
+// given code when set
+func hasOpenWarning(waste *models.Waste, code string) bool {
+	for _, warning := range waste.Warnings {
+		if !warning.Resolved && (code == "" || warning.Code == code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// yieldWarning flags a lot whose quantity per hectare of its plot falls
+// outside the plausible range; lots without a plot area are not checked
+func yieldWarning(ctx contractapi.TransactionContextInterface, plot *models.Plot, quantity float64, now string) *models.ValidationWarning {
+	if plot == nil || plot.AreaHa <= 0 {
+		return nil
+	}
+	yield := quantity / plot.AreaHa
+	min := configFloat(ctx, "validation", "minYieldPerHa", defaultMinYieldPerHa)
+	max := configFloat(ctx, "validation", "maxYieldPerHa", defaultMaxYieldPerHa)
+	if (max > 0 && yield > max) || yield < min {
+		warning := newValidationWarning(models.WarnYieldUnusual, fmt.Sprintf("yield of %.2f per hectare on plot %s is outside the expected %.2f to %.2f", yield, plot.ID, min, max), now)
+		return &warning
+	}
+
+	return nil
+}
+
+func newValidationWarning(code string, message string, now string) models.ValidationWarning {
+	return models.ValidationWarning{
+		Code:     code,
+		Message:  message,
+		RaisedAt: now,
+	}
+}
+
+// warningMessages returns the text of each warning
+func warningMessages(warnings []models.ValidationWarning) []string {
+	var messages []string
+	for _, warning := range warnings {
+		messages = append(messages, warning.Message)
+	}
+
+	return messages
+}
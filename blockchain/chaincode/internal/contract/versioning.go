@@ -0,0 +1,16 @@
+package contract
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// checkVersion implements optimistic concurrency: a caller passes the version
+// it last read and the write is rejected with CONFLICT if the record has moved
+// on since. An expected version of 0 skips the check.
+func checkVersion(ctx contractapi.TransactionContextInterface, key string, current int, expected int) error {
+	if expected == 0 || expected == current {
+		return nil
+	}
+
+	return newError(ctx, ErrConflict, key, expected, current)
+}
@@ -0,0 +1,123 @@
+package contract
+
+import (
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// wasteViewer decides, for one transaction, which wastes the caller may read
+// in full; other organizations' lots require an active sharing agreement, a
+// delegation to the caller or the caller administering a cooperative the
+// lot's owner belongs to
+type wasteViewer struct {
+	id          string
+	mspID       string
+	admin       bool
+	enforce     bool
+	now         string
+	today       string
+	agreements  []*models.Agreement
+	delegations []*models.Delegation
+	loaded      bool
+	cooperative *cooperativeAccess
+}
+
+func newWasteViewer(ctx contractapi.TransactionContextInterface) (*wasteViewer, error) {
+	id, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wasteViewer{
+		id:          id,
+		mspID:       mspID,
+		admin:       isAdmin(ctx),
+		enforce:     configBool(ctx, "sharing", "enforceAgreements", true),
+		now:         now,
+		today:       now[:len("2006-01-02")],
+		cooperative: newCooperativeAccess(ctx),
+	}, nil
+}
+
+// canView reports whether the caller may read the waste without redaction;
+// other organizations see nothing beyond the stub while the lot is under
+// embargo
+func (v *wasteViewer) canView(ctx contractapi.TransactionContextInterface, waste *models.Waste) (bool, error) {
+	// Wastes created before ownership tracking stay public
+	if v.admin || waste.OwnerMSP == "" || waste.OwnerMSP == v.mspID {
+		return true, nil
+	}
+	if embargoed(waste, v.now) {
+		return false, nil
+	}
+	if !v.enforce {
+		return true, nil
+	}
+
+	if !v.loaded {
+		agreements, err := loadAgreements(ctx)
+		if err != nil {
+			return false, err
+		}
+		delegations, err := loadDelegations(ctx)
+		if err != nil {
+			return false, err
+		}
+		v.agreements = agreements
+		v.delegations = delegations
+		v.loaded = true
+	}
+
+	for _, agreement := range v.agreements {
+		if agreement.CoversScope(v.mspID, waste.OwnerMSP, "WASTE", v.today) {
+			return true, nil
+		}
+	}
+	for _, delegation := range v.delegations {
+		if delegation.Grants(v.id, v.mspID, waste.OwnerMSP, "WASTE", v.now) {
+			return true, nil
+		}
+	}
+
+	return v.cooperative.grants(ctx, waste.ParticipantID, models.CoopView)
+}
+
+// view returns the waste as the caller may see it
+func (v *wasteViewer) view(ctx contractapi.TransactionContextInterface, waste *models.Waste) (*models.Waste, error) {
+	visible, err := v.canView(ctx, waste)
+	if err != nil {
+		return nil, err
+	}
+	if visible {
+		return waste, nil
+	}
+
+	return redactWaste(waste), nil
+}
+
+// redactWaste keeps only the fields needed to reference a lot
+func redactWaste(waste *models.Waste) *models.Waste {
+	return &models.Waste{
+		ID:            waste.ID,
+		Reference:     waste.Reference,
+		Type:          waste.Type,
+		Category:      waste.Category,
+		Subtype:       waste.Subtype,
+		Region:        waste.Region,
+		Status:        waste.Status,
+		OwnerMSP:      waste.OwnerMSP,
+		EmbargoUntil:  waste.EmbargoUntil,
+		CertificateID: waste.CertificateID,
+		CreatedAt:     waste.CreatedAt,
+		UpdatedAt:     waste.UpdatedAt,
+		History:       []models.History{},
+	}
+}
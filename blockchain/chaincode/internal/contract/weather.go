@@ -0,0 +1,185 @@
+package contract
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/chaincode/internal/models"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// weatherEvents lists the event kinds the oracle may report
+var weatherEvents = map[string]bool{
+	"CLEAR":      true,
+	"RAIN":       true,
+	"HEAVY_RAIN": true,
+	"STORM":      true,
+	"HAIL":       true,
+	"FROST":      true,
+	"HEATWAVE":   true,
+	"DROUGHT":    true,
+}
+
+// weatherSeverities lists the accepted severities; severity is optional
+var weatherSeverities = map[string]bool{
+	"":         true,
+	"LOW":      true,
+	"MODERATE": true,
+	"HIGH":     true,
+	"EXTREME":  true,
+}
+
+// defaultHarvestWeatherDays is how many days before a harvest observations
+// are considered relevant unless oracle.harvestWindowDays is configured
+const defaultHarvestWeatherDays = 7
+
+// RecordWeatherObservation stores a weather event for a farm on a day
+// (YYYY-MM-DD). Only the identity designated by oracle.weatherIdentity (and,
+// when set, oracle.weatherMsp) may record observations; each event is
+// recorded once per farm and day.
+func (s *SmartContract) RecordWeatherObservation(ctx contractapi.TransactionContextInterface, farm string, date string, event string, severity string, temperatureMin float64, temperatureMax float64, precipitationMm float64, source string) (*models.WeatherObservation, error) {
+	oracle, err := requireWeatherOracle(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if farm == "" {
+		return nil, newError(ctx, ErrFarmRequired)
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, newError(ctx, ErrDateInvalid, date)
+	}
+	event = strings.ToUpper(strings.TrimSpace(event))
+	if !weatherEvents[event] {
+		return nil, newError(ctx, ErrWeatherEventUnknown, event)
+	}
+	severity = strings.ToUpper(strings.TrimSpace(severity))
+	if !weatherSeverities[severity] {
+		return nil, newError(ctx, ErrWeatherSeverityUnknown, severity)
+	}
+	if temperatureMin > temperatureMax {
+		return nil, newError(ctx, ErrTemperatureBoundsInvalid)
+	}
+	if precipitationMm < 0 {
+		return nil, newError(ctx, ErrPrecipitationNegative)
+	}
+
+	id := farm + "_" + date + "_" + event
+	exists, err := newAssetStore(ctx).Exists("WEATHER_" + id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newError(ctx, ErrWeatherAlreadyRecorded, event, farm, date)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	observation := &models.WeatherObservation{
+		ID:              id,
+		Farm:            farm,
+		Date:            date,
+		Event:           event,
+		Severity:        severity,
+		TemperatureMin:  temperatureMin,
+		TemperatureMax:  temperatureMax,
+		PrecipitationMM: precipitationMm,
+		Source:          source,
+		RecordedAt:      now,
+		RecordedBy:      oracle,
+		TxID:            ctx.GetStub().GetTxID(),
+	}
+	if err := newAssetStore(ctx).Put("WEATHER_"+id, observation); err != nil {
+		return nil, err
+	}
+
+	return observation, nil
+}
+
+// GetWeatherObservations returns a farm's observations between two days
+// (YYYY-MM-DD, inclusive), oldest first
+func (s *SmartContract) GetWeatherObservations(ctx contractapi.TransactionContextInterface, farm string, from string, to string) ([]*models.WeatherObservation, error) {
+	if farm == "" {
+		return nil, newError(ctx, ErrFarmRequired)
+	}
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newError(ctx, ErrDateInvalid, date)
+		}
+	}
+	if to < from {
+		return nil, newError(ctx, ErrRangeInvalid)
+	}
+
+	return loadFarmWeather(ctx, farm, from, to)
+}
+
+// harvestWeather returns the observations recorded for a lot's farm in the
+// days leading up to and including its harvest
+func harvestWeather(ctx contractapi.TransactionContextInterface, waste *models.Waste) ([]*models.WeatherObservation, error) {
+	if waste.Farm == "" {
+		return nil, nil
+	}
+	harvest, err := time.Parse("2006-01-02", waste.HarvestDate)
+	if err != nil {
+		return nil, nil
+	}
+
+	days := configInt(ctx, "oracle", "harvestWindowDays", defaultHarvestWeatherDays)
+	if days < 0 {
+		days = defaultHarvestWeatherDays
+	}
+	from := harvest.AddDate(0, 0, -days).Format("2006-01-02")
+
+	return loadFarmWeather(ctx, waste.Farm, from, waste.HarvestDate)
+}
+
+func loadFarmWeather(ctx contractapi.TransactionContextInterface, farm string, from string, to string) ([]*models.WeatherObservation, error) {
+	observations := []*models.WeatherObservation{}
+	prefix := "WEATHER_" + farm + "_"
+	err := newAssetStore(ctx).Range(prefix+from, prefix+to+"~", func(_ string, value []byte) error {
+		var observation models.WeatherObservation
+		if err := json.Unmarshal(value, &observation); err != nil {
+			return err
+		}
+		// Farm names may themselves contain '_'
+		if observation.Farm == farm {
+			observations = append(observations, &observation)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return observations, nil
+}
+
+// requireWeatherOracle rejects the transaction unless the caller is the
+// designated weather oracle, whose identity it returns
+func requireWeatherOracle(ctx contractapi.TransactionContextInterface) (string, error) {
+	oracle := configString(ctx, "oracle", "weatherIdentity", "")
+	if oracle == "" {
+		return "", newError(ctx, ErrWeatherOracleUnset)
+	}
+
+	id, err := callerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	mspID, err := callerMSP(ctx)
+	if err != nil {
+		return "", err
+	}
+	oracleMSP := configString(ctx, "oracle", "weatherMsp", "")
+	if id != oracle || (oracleMSP != "" && mspID != oracleMSP) {
+		return "", newError(ctx, ErrWeatherOracleRequired)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,752 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type node interface {
+	eval(e *evaluator) (interface{}, error)
+}
+
+type evaluator struct {
+	vars     map[string]interface{}
+	scopes   []scope
+	budget   int
+	patterns map[string]*regexp.Regexp
+}
+
+// scope binds the variable of a macro while its body is evaluated
+type scope struct {
+	name  string
+	value interface{}
+}
+
+func (e *evaluator) step() error {
+	e.budget--
+	if e.budget < 0 {
+		return ErrBudget
+	}
+
+	return nil
+}
+
+func (e *evaluator) lookup(name string) (interface{}, error) {
+	for i := len(e.scopes) - 1; i >= 0; i-- {
+		if e.scopes[i].name == name {
+			return e.scopes[i].value, nil
+		}
+	}
+	value, ok := e.vars[name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared reference to %q", name)
+	}
+
+	return value, nil
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n *literal) eval(e *evaluator) (interface{}, error) {
+	return n.value, e.step()
+}
+
+type identNode struct {
+	name string
+}
+
+func (n *identNode) eval(e *evaluator) (interface{}, error) {
+	if err := e.step(); err != nil {
+		return nil, err
+	}
+
+	return e.lookup(n.name)
+}
+
+type selectNode struct {
+	operand node
+	field   string
+}
+
+func (n *selectNode) eval(e *evaluator) (interface{}, error) {
+	operand, err := n.operand.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := operand.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot select %s from %s", n.field, typeName(operand))
+	}
+	value, ok := object[n.field]
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", n.field)
+	}
+
+	return value, e.step()
+}
+
+// hasNode tests whether a field is present, without failing when it is not
+type hasNode struct {
+	selection *selectNode
+}
+
+func (n *hasNode) eval(e *evaluator) (interface{}, error) {
+	operand, err := n.selection.operand.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := operand.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("has() cannot test %s of %s", n.selection.field, typeName(operand))
+	}
+	value, ok := object[n.selection.field]
+
+	return ok && value != nil, e.step()
+}
+
+type indexNode struct {
+	operand node
+	index   node
+}
+
+func (n *indexNode) eval(e *evaluator) (interface{}, error) {
+	operand, err := n.operand.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := operand.(type) {
+	case []interface{}:
+		i, ok := index.(float64)
+		if !ok || i != math.Trunc(i) {
+			return nil, fmt.Errorf("list index must be an integer, got %s", typeName(index))
+		}
+		if i < 0 || int(i) >= len(container) {
+			return nil, fmt.Errorf("index %d out of range of a list of %d", int(i), len(container))
+		}
+		return container[int(i)], nil
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be a string, got %s", typeName(index))
+		}
+		value, ok := container[key]
+		if !ok {
+			return nil, fmt.Errorf("no such key: %s", key)
+		}
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("cannot index %s", typeName(operand))
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(e *evaluator) (interface{}, error) {
+	if err := e.step(); err != nil {
+		return nil, err
+	}
+	list := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = value
+	}
+
+	return list, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(e *evaluator) (interface{}, error) {
+	operand, err := n.operand.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.step(); err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := operand.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! needs a bool, got %s", typeName(operand))
+		}
+		return !b, nil
+	}
+	number, ok := operand.(float64)
+	if !ok {
+		return nil, fmt.Errorf("operator - needs a number, got %s", typeName(operand))
+	}
+
+	return -number, nil
+}
+
+type condNode struct {
+	cond      node
+	then      node
+	otherwise node
+}
+
+func (n *condNode) eval(e *evaluator) (interface{}, error) {
+	cond, err := n.cond.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := cond.(bool)
+	if !ok {
+		return nil, fmt.Errorf("condition of ?: must be a bool, got %s", typeName(cond))
+	}
+	if b {
+		return n.then.eval(e)
+	}
+
+	return n.otherwise.eval(e)
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *binaryNode) eval(e *evaluator) (interface{}, error) {
+	if n.op == "&&" || n.op == "||" {
+		return n.evalLogical(e)
+	}
+	left, err := n.left.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.step(); err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("operator %s: %v", n.op, err)
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case "in":
+		return contains(right, left)
+	}
+
+	return arithmetic(n.op, left, right)
+}
+
+// evalLogical evaluates && and || like CEL: an error on one side is
+// ignored when the other side alone decides the result
+func (n *binaryNode) evalLogical(e *evaluator) (interface{}, error) {
+	decisive := n.op == "||"
+	left, leftErr := evalBool(e, n.left, n.op)
+	if leftErr == nil && left == decisive {
+		return decisive, nil
+	}
+	right, rightErr := evalBool(e, n.right, n.op)
+	if rightErr == nil && right == decisive {
+		return decisive, nil
+	}
+	if leftErr != nil {
+		return nil, leftErr
+	}
+	if rightErr != nil {
+		return nil, rightErr
+	}
+
+	return !decisive, nil
+}
+
+func evalBool(e *evaluator, n node, op string) (bool, error) {
+	value, err := n.eval(e)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("operator %s needs bools, got %s", op, typeName(value))
+	}
+
+	return b, nil
+}
+
+// Arity of the global functions and of the methods
+var (
+	functions = map[string]int{
+		"size":   1,
+		"int":    1,
+		"double": 1,
+		"string": 1,
+		"type":   1,
+	}
+	methods = map[string]int{
+		"size":       0,
+		"startsWith": 1,
+		"endsWith":   1,
+		"contains":   1,
+		"matches":    1,
+		"lowerAscii": 0,
+		"upperAscii": 0,
+		"trim":       0,
+	}
+)
+
+type callNode struct {
+	target node
+	name   string
+	args   []node
+}
+
+func (n *callNode) eval(e *evaluator) (interface{}, error) {
+	var args []interface{}
+	if n.target != nil {
+		target, err := n.target.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, target)
+	}
+	for _, arg := range n.args {
+		value, err := arg.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	if err := e.step(); err != nil {
+		return nil, err
+	}
+
+	switch n.name {
+	case "size":
+		return size(args[0])
+	case "int":
+		return toInt(args[0])
+	case "double":
+		return toDouble(args[0])
+	case "string":
+		return toString(args[0]), nil
+	case "type":
+		return typeName(args[0]), nil
+	case "matches":
+		return e.matches(args[0], args[1])
+	}
+
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() applies to strings, not %s", n.name, typeName(args[0]))
+	}
+	switch n.name {
+	case "lowerAscii":
+		return strings.ToLower(s), nil
+	case "upperAscii":
+		return strings.ToUpper(s), nil
+	case "trim":
+		return strings.TrimSpace(s), nil
+	}
+	arg, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() takes a string, not %s", n.name, typeName(args[1]))
+	}
+	switch n.name {
+	case "startsWith":
+		return strings.HasPrefix(s, arg), nil
+	case "endsWith":
+		return strings.HasSuffix(s, arg), nil
+	}
+
+	return strings.Contains(s, arg), nil
+}
+
+func (e *evaluator) matches(value interface{}, pattern interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	p, ok2 := pattern.(string)
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("matches() needs a string and a pattern")
+	}
+	re, ok := e.patterns[p]
+	if !ok {
+		if len(p) > maxPatternSize {
+			return nil, fmt.Errorf("pattern is longer than %d characters", maxPatternSize)
+		}
+		var err error
+		if re, err = regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+		e.patterns[p] = re
+	}
+
+	return re.MatchString(s), nil
+}
+
+func isMacro(name string) bool {
+	switch name {
+	case "exists", "all", "exists_one", "filter", "map":
+		return true
+	}
+
+	return false
+}
+
+// macroNode evaluates a comprehension over the elements of a list or the
+// keys of a map, in sorted key order
+type macroNode struct {
+	macro    string
+	target   node
+	variable string
+	body     node
+}
+
+func (n *macroNode) eval(e *evaluator) (interface{}, error) {
+	target, err := n.target.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	var items []interface{}
+	switch container := target.(type) {
+	case []interface{}:
+		items = container
+	case map[string]interface{}:
+		keys := make([]string, 0, len(container))
+		for key := range container {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			items = append(items, key)
+		}
+	default:
+		return nil, fmt.Errorf("%s() applies to lists and maps, not %s", n.macro, typeName(target))
+	}
+
+	matched := 0
+	results := []interface{}{}
+	e.scopes = append(e.scopes, scope{name: n.variable})
+	defer func() { e.scopes = e.scopes[:len(e.scopes)-1] }()
+	for _, item := range items {
+		e.scopes[len(e.scopes)-1].value = item
+		value, err := n.body.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		if n.macro == "map" {
+			results = append(results, value)
+			continue
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("the expression of %s() must be a bool, got %s", n.macro, typeName(value))
+		}
+		switch {
+		case n.macro == "exists" && b:
+			return true, nil
+		case n.macro == "all" && !b:
+			return false, nil
+		case b:
+			matched++
+			results = append(results, item)
+		}
+	}
+
+	switch n.macro {
+	case "exists":
+		return false, nil
+	case "all":
+		return true, nil
+	case "exists_one":
+		return matched == 1, nil
+	}
+
+	return results, nil
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+func equal(a interface{}, b interface{}) bool {
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func compare(a interface{}, b interface{}) (int, error) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot compare %s with %s", typeName(a), typeName(b))
+}
+
+func contains(container interface{}, item interface{}) (interface{}, error) {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, element := range c {
+			if equal(element, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := item.(string)
+		if !ok {
+			return false, nil
+		}
+		_, found := c[key]
+		return found, nil
+	}
+
+	return nil, fmt.Errorf("operator in needs a list or map, got %s", typeName(container))
+}
+
+func arithmetic(op string, left interface{}, right interface{}) (interface{}, error) {
+	if op == "+" {
+		switch x := left.(type) {
+		case string:
+			if y, ok := right.(string); ok {
+				return x + y, nil
+			}
+		case []interface{}:
+			if y, ok := right.([]interface{}); ok {
+				return append(append([]interface{}{}, x...), y...), nil
+			}
+		}
+	}
+	x, ok := left.(float64)
+	y, ok2 := right.(float64)
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("operator %s cannot apply to %s and %s", op, typeName(left), typeName(right))
+	}
+
+	switch op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return x / y, nil
+	}
+	if y == 0 {
+		return nil, fmt.Errorf("modulus by zero")
+	}
+
+	return math.Mod(x, y), nil
+}
+
+func size(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+
+	return nil, fmt.Errorf("size() applies to strings, lists and maps, not %s", typeName(value))
+}
+
+func toInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return math.Trunc(v), nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("int(): %q is not an integer", v)
+		}
+		return float64(n), nil
+	case bool:
+		if v {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	}
+
+	return nil, fmt.Errorf("int() cannot convert %s", typeName(value))
+}
+
+func toDouble(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("double(): %q is not a number", v)
+		}
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("double() cannot convert %s", typeName(value))
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return typeName(value)
+}
+
+// Value converts a Go value (typically a model struct) into the values
+// expressions work on. Struct fields are named after their json tags and,
+// unlike in JSON, empty fields are kept, so rules can test
+// waste.farm != "" without has(). Numbers become float64.
+func Value(v interface{}) interface{} {
+	return convert(reflect.ValueOf(v))
+}
+
+func convert(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return convert(v.Elem())
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = convert(v.Index(i))
+		}
+		return list
+	case reflect.Map:
+		object := map[string]interface{}{}
+		iter := v.MapRange()
+		for iter.Next() {
+			object[fmt.Sprint(iter.Key().Interface())] = convert(iter.Value())
+		}
+		return object
+	case reflect.Struct:
+		object := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			object[name] = convert(v.Field(i))
+		}
+		return object
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package expr evaluates the validation expressions admins store on-chain.
+// The language is a subset of CEL (Common Expression Language): literals,
+// field selection, indexing, arithmetic, comparison, logical and ternary
+// operators, the in operator, and a small set of functions and macros:
+//
+//	waste.quantity > 0 && waste.quantity <= 50000
+//	waste.type in ["pomace", "leaves"] || has(waste.composition)
+//	waste.farm.startsWith("FARM-") ? size(waste.plotId) > 0 : true
+//	waste.documents.exists(d, d.type == "WEIGHBRIDGE_TICKET")
+//
+// Evaluation is deterministic: there is no clock, randomness or I/O, map
+// keys are visited in sorted order and every evaluation has a fixed step
+// budget, so all endorsing peers reach the same result.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Limits keeping expressions cheap to parse and evaluate
+const (
+	MaxSourceLength = 2000
+	MaxDepth        = 50
+	MaxSteps        = 10000
+	maxPatternSize  = 256
+)
+
+// ErrBudget is returned when an evaluation takes more than MaxSteps steps
+var ErrBudget = errors.New("expression exceeded its evaluation budget")
+
+// Program is a parsed expression, ready to be evaluated any number of times
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parses an expression
+func Compile(source string) (*Program, error) {
+	if len(source) > MaxSourceLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", MaxSourceLength)
+	}
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+	}
+
+	return &Program{source: source, root: root}, nil
+}
+
+// Source returns the expression the program was compiled from
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Eval evaluates the program with the given variables, whose values are
+// nil, bool, float64, string, []interface{} or map[string]interface{} (see
+// Value)
+func (p *Program) Eval(vars map[string]interface{}) (interface{}, error) {
+	e := &evaluator{vars: vars, budget: MaxSteps, patterns: map[string]*regexp.Regexp{}}
+	return p.root.eval(e)
+}
+
+// EvalBool evaluates a program that must yield a boolean
+func (p *Program) EvalBool(vars map[string]interface{}) (bool, error) {
+	value, err := p.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression yields %s, not bool", typeName(value))
+	}
+
+	return result, nil
+}
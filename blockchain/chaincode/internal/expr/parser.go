@@ -0,0 +1,406 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+var twoCharOps = []string{"&&", "||", "==", "!=", "<=", ">="}
+
+const oneCharOps = "()[],.?:!<>+-*/%"
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lex splits an expression into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			num, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", src[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: num, pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			text, end, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokString, text: text, pos: start})
+		case isLetter(c):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range twoCharOps {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+				}
+			}
+			if op == "" && strings.IndexByte(oneCharOps, c) >= 0 {
+				op = string(c)
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexString reads a quoted string starting at src[start], returning its
+// unescaped text and the index after the closing quote
+func lexString(src string, start int) (string, int, error) {
+	quote := src[start]
+	var text strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return text.String(), i + 1, nil
+		case c == '\\' && i+1 < len(src):
+			i++
+			switch src[i] {
+			case 'n':
+				text.WriteByte('\n')
+			case 't':
+				text.WriteByte('\t')
+			case '\\', '"', '\'':
+				text.WriteByte(src[i])
+			default:
+				return "", 0, fmt.Errorf("invalid escape \\%c at %d", src[i], i-1)
+			}
+		default:
+			text.WriteByte(c)
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated string at %d", start)
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords
+func (p *parser) accept(texts ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp && tok.kind != tokIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if tok.text == text {
+			p.advance()
+			return text, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *parser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		tok := p.peek()
+		if tok.kind == tokEOF {
+			return fmt.Errorf("expected %q at end of expression", text)
+		}
+		return fmt.Errorf("expected %q at %d, found %q", text, tok.pos, tok.text)
+	}
+
+	return nil
+}
+
+// parseExpr parses a ternary: or ['?' expr ':' expr]
+func (p *parser) parseExpr() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > MaxDepth {
+		return nil, fmt.Errorf("expression is nested deeper than %d levels", MaxDepth)
+	}
+
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+	then, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &condNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// Binary operators by increasing precedence
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > MaxDepth {
+			return nil, fmt.Errorf("expression is nested deeper than %d levels", MaxDepth)
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+
+	return p.parseMember()
+}
+
+// parseMember parses a primary followed by selections, method calls and
+// indexes
+func (p *parser) parseMember() (node, error) {
+	operand, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("."); ok {
+			name := p.advance()
+			if name.kind != tokIdent {
+				return nil, fmt.Errorf("expected a field or method name at %d", name.pos)
+			}
+			if _, ok := p.accept("("); !ok {
+				operand = &selectNode{operand: operand, field: name.text}
+				continue
+			}
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			if operand, err = newCall(operand, name.text, args, name.pos); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			index, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			operand = &indexNode{operand: operand, index: index}
+			continue
+		}
+
+		return operand, nil
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokNumber:
+		return &literal{value: tok.num}, nil
+	case tokString:
+		return &literal{value: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected \"in\" at %d", tok.pos)
+		}
+		if _, ok := p.accept("("); ok {
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return newCall(nil, tok.text, args, tok.pos)
+		}
+		return &identNode{name: tok.text}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+}
+
+// parseList parses comma-separated expressions up to the closing token
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if _, ok := p.accept(closing); ok {
+		return items, nil
+	}
+	for {
+		item, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if _, ok := p.accept(closing); ok {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// newCall checks a function or method call against the known functions,
+// turning macros (has, exists, all, exists_one, filter, map) into their
+// nodes
+func newCall(target node, name string, args []node, pos int) (node, error) {
+	if target == nil && name == "has" {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("has() takes one field selection at %d", pos)
+		}
+		selection, ok := args[0].(*selectNode)
+		if !ok {
+			return nil, fmt.Errorf("has() needs a field selection such as has(waste.farm) at %d", pos)
+		}
+		return &hasNode{selection: selection}, nil
+	}
+	if target != nil && isMacro(name) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s() takes a variable and an expression at %d", name, pos)
+		}
+		variable, ok := args[0].(*identNode)
+		if !ok {
+			return nil, fmt.Errorf("the first argument of %s() must be a variable name at %d", name, pos)
+		}
+		return &macroNode{macro: name, target: target, variable: variable.name, body: args[1]}, nil
+	}
+
+	arity, ok := functions[name]
+	if target != nil {
+		arity, ok = methods[name]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at %d", name, pos)
+	}
+	if len(args) != arity {
+		return nil, fmt.Errorf("%s() takes %d argument(s), got %d at %d", name, arity, len(args), pos)
+	}
+
+	return &callNode{target: target, name: name, args: args}, nil
+}
@@ -0,0 +1,43 @@
+package models
+
+// Agreement statuses
+const (
+	AgreementProposed = "PROPOSED"
+	AgreementActive   = "ACTIVE"
+	AgreementRevoked  = "REVOKED"
+)
+
+// Agreement is a data-sharing agreement between two organizations
+type Agreement struct {
+	ID           string    `json:"id"`
+	Proposer     string    `json:"proposer"`
+	Counterparty string    `json:"counterparty"`
+	Scope        []string  `json:"scope"`
+	ValidFrom    string    `json:"validFrom"`
+	ValidUntil   string    `json:"validUntil"`
+	Status       string    `json:"status"`
+	CreatedAt    string    `json:"createdAt"`
+	UpdatedAt    string    `json:"updatedAt"`
+	History      []History `json:"history"`
+}
+
+// CoversScope reports whether the agreement links both organizations for the
+// given asset type on the given date (YYYY-MM-DD)
+func (a *Agreement) CoversScope(orgA string, orgB string, assetType string, today string) bool {
+	if a.Status != AgreementActive {
+		return false
+	}
+	if !(a.Proposer == orgA && a.Counterparty == orgB) && !(a.Proposer == orgB && a.Counterparty == orgA) {
+		return false
+	}
+	if today < a.ValidFrom || today > a.ValidUntil {
+		return false
+	}
+	for _, scope := range a.Scope {
+		if scope == assetType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package models
+
+// Approval statuses
+const (
+	ApprovalPending  = "PENDING_APPROVAL"
+	ApprovalExecuted = "EXECUTED"
+	ApprovalRejected = "REJECTED"
+	ApprovalExpired  = "EXPIRED"
+	ApprovalFailed   = "FAILED"
+)
+
+// Operations that may need approval
+const ApprovalTransferShare = "TRANSFER_SHARE"
+
+// Approver roles
+const (
+	ApproverOwner            = "OWNER"
+	ApproverCooperativeAdmin = "COOPERATIVE_ADMIN"
+	ApproverAdmin            = "ADMIN"
+	ApproverAuditor          = "AUDITOR"
+	ApproverBuyer            = "BUYER"
+)
+
+// Approval holds a high-value operation until a distinct identity has
+// approved it for each required role; the operation runs in the transaction
+// that completes the quorum. Threshold is the quantity the operation went
+// over; MatrixMSP and MatrixVersion name the approval matrix version that
+// required it, when one did.
+type Approval struct {
+	ID             string              `json:"id"`
+	Operation      string              `json:"operation"`
+	WasteID        string              `json:"wasteId"`
+	Transfer       *ShareTransfer      `json:"transfer,omitempty"`
+	RequiredRoles  []string            `json:"requiredRoles"`
+	Threshold      float64             `json:"threshold"`
+	MatrixMSP      string              `json:"matrixMsp,omitempty"`
+	MatrixVersion  string              `json:"matrixVersion,omitempty"`
+	Signatures     []ApprovalSignature `json:"signatures"`
+	Status         string              `json:"status"`
+	Reason         string              `json:"reason,omitempty"`
+	RequestedBy    string              `json:"requestedBy"`
+	RequestedByMSP string              `json:"requestedByMsp"`
+	ExpiresAt      string              `json:"expiresAt"`
+	DecidedAt      string              `json:"decidedAt,omitempty"`
+	CreatedAt      string              `json:"createdAt"`
+	History        []History           `json:"history"`
+}
+
+// ApprovalSignature is one identity's approval in a role
+type ApprovalSignature struct {
+	Role        string `json:"role"`
+	Approver    string `json:"approver"`
+	ApproverMSP string `json:"approverMsp"`
+	ApprovedAt  string `json:"approvedAt"`
+}
+
+// ShareTransfer is a pending TransferShare; Quantity is the part of the lot
+// the share stands for when it was requested
+type ShareTransfer struct {
+	SellerID   string  `json:"sellerId"`
+	Percentage float64 `json:"percentage"`
+	Quantity   float64 `json:"quantity"`
+	BuyerID    string  `json:"buyerId"`
+	BuyerMSP   string  `json:"buyerMsp"`
+}
+
+// ApprovalMatrix is the approval policy of an organization: its versions,
+// each in effect from its EffectiveFrom until the next one. An organization
+// without a version in effect falls back to the approvals.* settings.
+type ApprovalMatrix struct {
+	OrgMSP    string                  `json:"orgMsp"`
+	Versions  []ApprovalMatrixVersion `json:"versions"`
+	CreatedAt string                  `json:"createdAt"`
+	UpdatedAt string                  `json:"updatedAt"`
+	History   []History               `json:"history"`
+}
+
+// ApprovalMatrixVersion is a set of approval rules in effect from an RFC3339
+// time; a version without rules requires no approval
+type ApprovalMatrixVersion struct {
+	EffectiveFrom string         `json:"effectiveFrom"`
+	Rules         []ApprovalRule `json:"rules"`
+	SetBy         string         `json:"setBy"`
+	SetAt         string         `json:"setAt"`
+}
+
+// ApprovalRule requires the RequiredRoles to approve an operation of more
+// than MinQuantity; of the rules an operation goes over, the one with the
+// highest MinQuantity applies
+type ApprovalRule struct {
+	Operation     string   `json:"operation"`
+	MinQuantity   float64  `json:"minQuantity"`
+	RequiredRoles []string `json:"requiredRoles"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// WasteAsOf is a lot as it stood at a past time: the version written by the
+// last transaction at or before AsOf. Deleted is set when that transaction
+// removed the lot, and Waste is then nil.
+type WasteAsOf struct {
+	WasteID    string `json:"wasteId"`
+	AsOf       string `json:"asOf"`
+	Waste      *Waste `json:"waste,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"`
+	TxID       string `json:"txId"`
+	ModifiedAt string `json:"modifiedAt"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// Audit record outcomes
+const (
+	AuditSucceeded = "SUCCEEDED"
+	AuditFailed    = "FAILED"
+)
+
+// AuditRecord records one invocation of a state-mutating function: who
+// called it, when, which world state keys it wrote and how it ended
+type AuditRecord struct {
+	TxID      string   `json:"txId"`
+	Function  string   `json:"function"`
+	Actor     string   `json:"actor"`
+	ActorMSP  string   `json:"actorMsp"`
+	Assets    []string `json:"assets"`
+	Outcome   string   `json:"outcome"`
+	Error     string   `json:"error,omitempty"`
+	Timestamp string   `json:"timestamp"`
+}
+
+// AuditPage is one page of the audit trail, oldest first; Truncated marks a
+// page cut short by the query budget
+type AuditPage struct {
+	Records   []*AuditRecord `json:"records"`
+	Bookmark  string         `json:"bookmark"`
+	Truncated bool           `json:"truncated,omitempty"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// TransitionRule moves the waste lot linked to an asset of type On to status
+// Transition when that asset reaches status Event
+type TransitionRule struct {
+	Name       string `json:"name"`
+	On         string `json:"on"`
+	Event      string `json:"event"`
+	Transition string `json:"transition"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// InputProvenance describes one lot blended into a recycling run; origin
+// fields are left empty when the caller may not see the lot
+type InputProvenance struct {
+	WasteID     string  `json:"wasteId"`
+	Quantity    float64 `json:"quantity"`
+	Share       float64 `json:"share"`
+	OwnerMSP    string  `json:"ownerMsp,omitempty"`
+	Farm        string  `json:"farm,omitempty"`
+	HarvestDate string  `json:"harvestDate,omitempty"`
+	Redacted    bool    `json:"redacted"`
+}
+
+// RecyclingProvenance fans a recycled product out to every contributing lot
+// and farm
+type RecyclingProvenance struct {
+	Recycling *Recycling         `json:"recycling"`
+	Inputs    []*InputProvenance `json:"inputs"`
+	Farms     []string           `json:"farms"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// ExtractionOutput is one product line of an extraction run (oil, pomace,
+// wastewater...); Consumed and Downstream track what was made from it.
+// DryMatter is derived from Quantity when the composition gives moisture.
+type ExtractionOutput struct {
+	Line        int          `json:"line,omitempty"`
+	ProductType string       `json:"productType"`
+	Quantity    float64      `json:"quantity"`
+	Quality     string       `json:"quality,omitempty"`
+	Composition *Composition `json:"composition,omitempty"`
+	DryMatter   float64      `json:"dryMatter,omitempty"`
+	Consumed    float64      `json:"consumed,omitempty"`
+	Downstream  []string     `json:"downstream,omitempty"`
+}
+
+// Mass balance bases: wet weight, or dry matter when the moisture of the lot
+// and of every output line is known
+const (
+	BalanceWet = "WET"
+	BalanceDry = "DRY"
+)
+
+// MassBalance compares the input lot with the sum of all output lines. Input,
+// Output and Loss are wet weights; on a dry basis the Dry fields are the ones
+// the balance was validated on.
+type MassBalance struct {
+	Input     float64 `json:"input"`
+	Output    float64 `json:"output"`
+	Loss      float64 `json:"loss"`
+	Basis     string  `json:"basis,omitempty"`
+	DryInput  float64 `json:"dryInput,omitempty"`
+	DryOutput float64 `json:"dryOutput,omitempty"`
+	DryLoss   float64 `json:"dryLoss,omitempty"`
+}
+
+// ExtractionOutputTrace follows one output line into downstream products
+type ExtractionOutputTrace struct {
+	ExtractionID string            `json:"extractionId"`
+	WasteID      string            `json:"wasteId"`
+	Output       *ExtractionOutput `json:"output"`
+	Recyclings   []*Recycling      `json:"recyclings"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// Campaign statuses
+const (
+	CampaignOpen   = "OPEN"
+	CampaignClosed = "CLOSED"
+)
+
+// Campaign groups an organization's wastes by harvest season
+type Campaign struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Organization string    `json:"organization"`
+	StartDate    string    `json:"startDate"`
+	EndDate      string    `json:"endDate"`
+	Status       string    `json:"status"`
+	CreatedAt    string    `json:"createdAt"`
+	ClosedAt     string    `json:"closedAt,omitempty"`
+	History      []History `json:"history"`
+}
+
+// CampaignStatistics aggregates the activity recorded under a campaign
+type CampaignStatistics struct {
+	CampaignID        string  `json:"campaignId"`
+	Status            string  `json:"status"`
+	WasteCount        int     `json:"wasteCount"`
+	TotalCollected    float64 `json:"totalCollected"`
+	TotalProcessed    float64 `json:"totalProcessed"`
+	TotalExtracted    float64 `json:"totalExtracted"`
+	TotalRecycled     float64 `json:"totalRecycled"`
+	TotalDonated      float64 `json:"totalDonated"`
+	TotalDisposed     float64 `json:"totalDisposed"`
+	ExtractionYield   float64 `json:"extractionYield"`
+	RecyclingRate     float64 `json:"recyclingRate"`
+	ExtractionRecords int     `json:"extractionRecords"`
+	RecyclingRecords  int     `json:"recyclingRecords"`
+}
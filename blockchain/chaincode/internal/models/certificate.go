@@ -0,0 +1,31 @@
+package models
+
+// CertificateOutput is one extraction or recycling a certified lot went into
+type CertificateOutput struct {
+	AssetType string  `json:"assetType"`
+	ID        string  `json:"id"`
+	Reference string  `json:"reference,omitempty"`
+	Product   string  `json:"product"`
+	Quantity  float64 `json:"quantity"`
+	Actor     string  `json:"actor"`
+	Date      string  `json:"date"`
+}
+
+// CompletionCertificate is issued once when the whole of a lot has been
+// extracted or recycled, as public proof of its full valorization.
+// Quantity is the lot's quantity; each output's Quantity is what the
+// extraction produced or what the recycling took from the lot.
+type CompletionCertificate struct {
+	ID          string              `json:"id"`
+	WasteID     string              `json:"wasteId"`
+	Reference   string              `json:"reference,omitempty"`
+	WasteType   string              `json:"wasteType"`
+	Quantity    float64             `json:"quantity"`
+	Producer    string              `json:"producer"`
+	ProducerMSP string              `json:"producerMsp,omitempty"`
+	Outputs     []CertificateOutput `json:"outputs"`
+	Actors      []string            `json:"actors"`
+	CollectedAt string              `json:"collectedAt"`
+	CompletedAt string              `json:"completedAt"`
+	IssuedAt    string              `json:"issuedAt"`
+}
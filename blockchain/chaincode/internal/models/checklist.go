@@ -0,0 +1,58 @@
+package models
+
+// ChecklistTemplate lists what must be checked before a waste lot can move
+// to status Transition (e.g. the intake checklist for RECEIVED)
+type ChecklistTemplate struct {
+	Transition string              `json:"transition"`
+	Name       string              `json:"name"`
+	Items      []ChecklistItemSpec `json:"items"`
+	Version    int                 `json:"version"`
+	CreatedAt  string              `json:"createdAt"`
+	UpdatedAt  string              `json:"updatedAt"`
+	History    []History           `json:"history"`
+}
+
+// ChecklistItemSpec is an item of a template; ValueRequired items are
+// completed with a reading or reference (a weighbridge ticket number, a
+// moisture percentage)
+type ChecklistItemSpec struct {
+	Key           string `json:"key"`
+	Label         string `json:"label"`
+	Mandatory     bool   `json:"mandatory"`
+	ValueRequired bool   `json:"valueRequired,omitempty"`
+}
+
+// Checklist holds the items completed for a lot ahead of a transition
+type Checklist struct {
+	WasteID    string                         `json:"wasteId"`
+	Transition string                         `json:"transition"`
+	Items      map[string]ChecklistCompletion `json:"items"`
+	CreatedAt  string                         `json:"createdAt"`
+	UpdatedAt  string                         `json:"updatedAt"`
+	History    []History                      `json:"history"`
+}
+
+// ChecklistCompletion records who completed an item, when and with what value
+type ChecklistCompletion struct {
+	Value          string `json:"value,omitempty"`
+	CompletedBy    string `json:"completedBy"`
+	CompletedByMSP string `json:"completedByMsp"`
+	CompletedAt    string `json:"completedAt"`
+}
+
+// ChecklistProgress is a lot's checklist for a transition set against the
+// current template; Missing lists the mandatory items still open
+type ChecklistProgress struct {
+	WasteID    string                  `json:"wasteId"`
+	Transition string                  `json:"transition"`
+	Name       string                  `json:"name"`
+	Items      []ChecklistItemProgress `json:"items"`
+	Missing    []string                `json:"missing"`
+	Complete   bool                    `json:"complete"`
+}
+
+// ChecklistItemProgress is one template item with its completion, if any
+type ChecklistItemProgress struct {
+	ChecklistItemSpec
+	Completion *ChecklistCompletion `json:"completion,omitempty"`
+}
@@ -0,0 +1,69 @@
+package models
+
+// Claim statuses
+const (
+	ClaimFiled    = "FILED"
+	ClaimAssessed = "ASSESSED"
+	ClaimApproved = "APPROVED"
+	ClaimRejected = "REJECTED"
+	ClaimSettled  = "SETTLED"
+)
+
+// Claim kinds, and the waste status each one leaves the lot in once settled
+const (
+	ClaimLost    = "LOST"
+	ClaimDamaged = "DAMAGED"
+
+	WasteLost       = "LOST"
+	WasteWrittenOff = "WRITTEN_OFF"
+)
+
+// Claim is an insurance claim for a lot lost or damaged in transit. An
+// assessor other than the claimant records the findings, then the insurer
+// approves or rejects the claim and finally records its settlement.
+type Claim struct {
+	ID           string           `json:"id"`
+	WasteID      string           `json:"wasteId"`
+	ShipmentRef  string           `json:"shipmentRef,omitempty"`
+	Kind         string           `json:"kind"`
+	Description  string           `json:"description"`
+	LossQuantity float64          `json:"lossQuantity"`
+	Claimant     string           `json:"claimant"`
+	ClaimantMSP  string           `json:"claimantMsp"`
+	InsurerMSP   string           `json:"insurerMsp,omitempty"`
+	Status       string           `json:"status"`
+	Evidence     []Document       `json:"evidence"`
+	Assessment   *ClaimAssessment `json:"assessment,omitempty"`
+	Decision     *ClaimDecision   `json:"decision,omitempty"`
+	Settlement   *ClaimSettlement `json:"settlement,omitempty"`
+	CreatedAt    string           `json:"createdAt"`
+	UpdatedAt    string           `json:"updatedAt"`
+	History      []History        `json:"history"`
+}
+
+// ClaimAssessment holds the assessor's findings
+type ClaimAssessment struct {
+	Assessor          string  `json:"assessor"`
+	AssessorMSP       string  `json:"assessorMsp"`
+	Finding           string  `json:"finding"`
+	LossQuantity      float64 `json:"lossQuantity"`
+	RecommendedAmount float64 `json:"recommendedAmount"`
+	AssessedAt        string  `json:"assessedAt"`
+}
+
+// ClaimDecision is the insurer's approval or rejection
+type ClaimDecision struct {
+	Approved   bool   `json:"approved"`
+	Reason     string `json:"reason"`
+	Insurer    string `json:"insurer"`
+	InsurerMSP string `json:"insurerMsp"`
+	DecidedAt  string `json:"decidedAt"`
+}
+
+// ClaimSettlement records the payout of an approved claim
+type ClaimSettlement struct {
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference,omitempty"`
+	SettledBy string  `json:"settledBy"`
+	SettledAt string  `json:"settledAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Collection request statuses
+const (
+	CollectionRequested = "REQUESTED"
+	CollectionAssigned  = "ASSIGNED"
+	CollectionFulfilled = "FULFILLED"
+	CollectionCancelled = "CANCELLED"
+)
+
+// CollectionRequest is a farmer's pickup request raised before the lot exists on-chain
+type CollectionRequest struct {
+	ID                string    `json:"id"`
+	Farm              string    `json:"farm"`
+	Owner             string    `json:"owner"`
+	OwnerMSP          string    `json:"ownerMsp"`
+	EstimatedQuantity float64   `json:"estimatedQuantity"`
+	WindowStart       string    `json:"windowStart"`
+	WindowEnd         string    `json:"windowEnd"`
+	Status            string    `json:"status"`
+	Collector         string    `json:"collector,omitempty"`
+	WasteID           string    `json:"wasteId,omitempty"`
+	CreatedAt         string    `json:"createdAt"`
+	UpdatedAt         string    `json:"updatedAt"`
+	History           []History `json:"history"`
+}
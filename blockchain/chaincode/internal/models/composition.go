@@ -0,0 +1,65 @@
+package models
+
+import "encoding/json"
+
+// Composition is what a lot or an output line is made of, as measured by a
+// lab or an inline sensor; HasMoisture and HasOilContent tell a measured 0
+// from a field that was not measured
+type Composition struct {
+	MoisturePct   float64 `json:"moisturePct,omitempty"`
+	HasMoisture   bool    `json:"hasMoisture,omitempty"`
+	OilContentPct float64 `json:"oilContentPct,omitempty"`
+	HasOilContent bool    `json:"hasOilContent,omitempty"`
+}
+
+// MarshalJSON writes a measured percentage even when it is 0
+func (c Composition) MarshalJSON() ([]byte, error) {
+	var fields struct {
+		MoisturePct   *float64 `json:"moisturePct,omitempty"`
+		HasMoisture   bool     `json:"hasMoisture,omitempty"`
+		OilContentPct *float64 `json:"oilContentPct,omitempty"`
+		HasOilContent bool     `json:"hasOilContent,omitempty"`
+	}
+	if c.HasMoisture {
+		fields.MoisturePct, fields.HasMoisture = &c.MoisturePct, true
+	}
+	if c.HasOilContent {
+		fields.OilContentPct, fields.HasOilContent = &c.OilContentPct, true
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON marks a percentage as measured whenever its field is
+// present, so inputs and records that carry only the percentages keep them
+func (c *Composition) UnmarshalJSON(data []byte) error {
+	type plain Composition
+	var fields struct {
+		plain
+		MoisturePct   *float64 `json:"moisturePct"`
+		OilContentPct *float64 `json:"oilContentPct"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*c = Composition(fields.plain)
+	if fields.MoisturePct != nil {
+		c.MoisturePct, c.HasMoisture = *fields.MoisturePct, true
+	}
+	if fields.OilContentPct != nil {
+		c.OilContentPct, c.HasOilContent = *fields.OilContentPct, true
+	}
+
+	return nil
+}
+
+// DryMatter returns the dry-matter equivalent of a wet quantity, and false
+// when the moisture content is unknown
+func (c *Composition) DryMatter(quantity float64) (float64, bool) {
+	if c == nil || !c.HasMoisture {
+		return 0, false
+	}
+
+	return quantity * (100 - c.MoisturePct) / 100, true
+}
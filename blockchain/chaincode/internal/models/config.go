@@ -0,0 +1,18 @@
+package models
+
+// ChaincodeConfig holds runtime settings keyed by "namespace.name"
+type ChaincodeConfig struct {
+	Settings  map[string]string `json:"settings"`
+	UpdatedAt string            `json:"updatedAt,omitempty"`
+	UpdatedBy string            `json:"updatedBy,omitempty"`
+}
+
+// ConfigChange is the payload of the ConfigChanged event
+type ConfigChange struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	OldValue  string `json:"oldValue"`
+	NewValue  string `json:"newValue"`
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt string `json:"updatedAt"`
+}
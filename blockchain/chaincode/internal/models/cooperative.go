@@ -0,0 +1,57 @@
+package models
+
+// Membership statuses
+const (
+	MembershipActive = "ACTIVE"
+	MembershipLeft   = "LEFT"
+)
+
+// DefaultMemberTier is the tier of members who join without one
+const DefaultMemberTier = "MEMBER"
+
+// Actions a cooperative's admins may take on the lots of its members
+const (
+	CoopView    = "VIEW"
+	CoopList    = "LIST"
+	CoopClaim   = "CLAIM"
+	CoopApprove = "APPROVE"
+)
+
+// Cooperative is a participant that aggregates member participants (farms or
+// other cooperatives). Permissions is its permission matrix: for each member
+// tier, the actions its admins may take on those members' lots.
+type Cooperative struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	MSP         string              `json:"msp"`
+	Admins      []string            `json:"admins"`
+	Permissions map[string][]string `json:"permissions"`
+	CreatedAt   string              `json:"createdAt"`
+	UpdatedAt   string              `json:"updatedAt"`
+	History     []History           `json:"history"`
+}
+
+// Permits reports whether the matrix grants an action on members of a tier
+func (c *Cooperative) Permits(tier string, action string) bool {
+	for _, granted := range c.Permissions[tier] {
+		if granted == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Membership links a participant to the cooperative it belongs to; a
+// participant belongs to one cooperative at a time and History keeps its
+// earlier joins and departures
+type Membership struct {
+	MemberID      string    `json:"memberId"`
+	CooperativeID string    `json:"cooperativeId"`
+	MemberMSP     string    `json:"memberMsp"`
+	Tier          string    `json:"tier"`
+	Status        string    `json:"status"`
+	JoinedAt      string    `json:"joinedAt"`
+	LeftAt        string    `json:"leftAt,omitempty"`
+	History       []History `json:"history"`
+}
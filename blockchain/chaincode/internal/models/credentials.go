@@ -0,0 +1,52 @@
+package models
+
+// VerifiableCredential is an unsigned W3C verifiable credential; the proof is
+// added off-chain by the gateway that holds the issuer's signing key
+type VerifiableCredential struct {
+	Context           []string             `json:"@context"`
+	ID                string               `json:"id"`
+	Type              []string             `json:"type"`
+	Issuer            string               `json:"issuer"`
+	IssuanceDate      string               `json:"issuanceDate"`
+	CredentialSubject *ProvenanceSummary   `json:"credentialSubject"`
+	Evidence          []CredentialEvidence `json:"evidence"`
+}
+
+// ProvenanceSummary is the credential subject describing a waste lot's journey
+type ProvenanceSummary struct {
+	ID           string                `json:"id"`
+	WasteType    string                `json:"wasteType"`
+	Quantity     float64               `json:"quantity"`
+	HarvestDate  string                `json:"harvestDate"`
+	Farm         string                `json:"farm,omitempty"`
+	Location     string                `json:"location,omitempty"`
+	Plot         *PlotOrigin           `json:"plot,omitempty"`
+	Owner        string                `json:"owner"`
+	OwnerMSP     string                `json:"ownerMsp,omitempty"`
+	Status       string                `json:"status"`
+	QualityGrade string                `json:"qualityGrade,omitempty"`
+	CampaignID   string                `json:"campaignId,omitempty"`
+	Extraction   *ProcessSummary       `json:"extraction,omitempty"`
+	Recycling    *ProcessSummary       `json:"recycling,omitempty"`
+	Documents    []Document            `json:"documents,omitempty"`
+	Weather      []*WeatherObservation `json:"weather,omitempty"`
+	EventCount   int                   `json:"eventCount"`
+}
+
+// ProcessSummary describes a processing step applied to the lot
+type ProcessSummary struct {
+	ID         string  `json:"id"`
+	Product    string  `json:"product"`
+	Quantity   float64 `json:"quantity"`
+	Method     string  `json:"method,omitempty"`
+	Operator   string  `json:"operator"`
+	FacilityID string  `json:"facilityId,omitempty"`
+	Date       string  `json:"date"`
+}
+
+// CredentialEvidence points back to the ledger transaction that produced the export
+type CredentialEvidence struct {
+	Type          []string `json:"type"`
+	Channel       string   `json:"channel"`
+	TransactionID string   `json:"transactionId"`
+}
@@ -0,0 +1,64 @@
+package models
+
+// Invoice statuses
+const (
+	InvoiceUnpaid = "UNPAID"
+	InvoicePaid   = "PAID"
+	InvoiceVoid   = "VOID"
+)
+
+// Credit policies applied when a buyer's unpaid exposure exceeds the limit a
+// seller set: WARN notifies the seller and lets the transfer through, BLOCK
+// refuses it
+const (
+	CreditWarn  = "WARN"
+	CreditBlock = "BLOCK"
+)
+
+// Invoice is what a buyer organization owes a seller organization for a
+// sale: issued when a listing is awarded, or by the seller for sales made
+// off the marketplace, and paid when the listing is settled or the seller
+// records the payment
+type Invoice struct {
+	ID        string    `json:"id"`
+	SellerMSP string    `json:"sellerMsp"`
+	BuyerMSP  string    `json:"buyerMsp"`
+	WasteID   string    `json:"wasteId,omitempty"`
+	ListingID string    `json:"listingId,omitempty"`
+	Reference string    `json:"reference,omitempty"`
+	Amount    float64   `json:"amount"`
+	Status    string    `json:"status"`
+	IssuedAt  string    `json:"issuedAt"`
+	DueAt     string    `json:"dueAt"`
+	PaidAt    string    `json:"paidAt,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	History   []History `json:"history"`
+}
+
+// CreditLimit is the unpaid amount a seller organization accepts from a
+// buyer organization, with the payment terms of its invoices; Policy is
+// empty to follow credit.policy
+type CreditLimit struct {
+	SellerMSP        string  `json:"sellerMsp"`
+	BuyerMSP         string  `json:"buyerMsp"`
+	Limit            float64 `json:"limit"`
+	PaymentTermsDays int     `json:"paymentTermsDays"`
+	Policy           string  `json:"policy,omitempty"`
+	UpdatedBy        string  `json:"updatedBy"`
+	UpdatedAt        string  `json:"updatedAt"`
+}
+
+// CreditExposure is what a buyer owes a seller: the unpaid invoices, the
+// part of them past due and, when Limited, what remains of the credit limit
+type CreditExposure struct {
+	SellerMSP      string  `json:"sellerMsp"`
+	BuyerMSP       string  `json:"buyerMsp"`
+	Outstanding    float64 `json:"outstanding"`
+	Overdue        float64 `json:"overdue"`
+	UnpaidInvoices int     `json:"unpaidInvoices"`
+	Limited        bool    `json:"limited"`
+	Limit          float64 `json:"limit"`
+	Available      float64 `json:"available"`
+	Policy         string  `json:"policy,omitempty"`
+	Exceeded       bool    `json:"exceeded"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// Kinds of deadlines
+const (
+	DeadlineAgreement      = "AGREEMENT"
+	DeadlineSupplyContract = "SUPPLY_CONTRACT"
+	DeadlineListing        = "LISTING"
+	DeadlineDelegation     = "DELEGATION"
+	DeadlinePermit         = "PERMIT"
+	DeadlineSettlement     = "SETTLEMENT"
+	DeadlineApproval       = "APPROVAL"
+)
+
+// Deadline is the end of something still running: an agreement or supply
+// contract, an open listing, a delegation, a vehicle or driver permit, a
+// token settlement hold or an approval. Parties are the organizations to
+// remind; DueAt is a date or an RFC3339 time.
+type Deadline struct {
+	Kind    string   `json:"kind"`
+	AssetID string   `json:"assetId"`
+	Label   string   `json:"label"`
+	DueAt   string   `json:"dueAt"`
+	Parties []string `json:"parties"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// Delegation statuses
+const (
+	DelegationActive  = "ACTIVE"
+	DelegationRevoked = "REVOKED"
+)
+
+// Delegation lets another identity act on behalf of the delegator's
+// organization for the listed asset types until ExpiresAt
+type Delegation struct {
+	ID           string    `json:"id"`
+	Delegator    string    `json:"delegator"`
+	DelegatorMSP string    `json:"delegatorMsp"`
+	Delegate     string    `json:"delegate"`
+	DelegateMSP  string    `json:"delegateMsp"`
+	Scope        []string  `json:"scope"`
+	ExpiresAt    string    `json:"expiresAt"`
+	Status       string    `json:"status"`
+	CreatedAt    string    `json:"createdAt"`
+	UpdatedAt    string    `json:"updatedAt"`
+	History      []History `json:"history"`
+}
+
+// Grants reports whether the delegation lets the delegate act for the
+// delegator's organization on the given asset type at the given RFC3339 time
+func (d *Delegation) Grants(delegate string, delegateMSP string, delegatorMSP string, assetType string, now string) bool {
+	if d.Status != DelegationActive || now >= d.ExpiresAt {
+		return false
+	}
+	if d.Delegate != delegate || d.DelegateMSP != delegateMSP || d.DelegatorMSP != delegatorMSP {
+		return false
+	}
+	for _, scope := range d.Scope {
+		if scope == assetType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package models
+
+// Waste statuses of lots that left the value chain without being sold
+const (
+	WasteDonated  = "DONATED"
+	WasteDisposed = "DISPOSED"
+)
+
+// Disposition kinds
+const (
+	DispositionDonation = "DONATION"
+	DispositionDisposal = "DISPOSAL"
+)
+
+// DonationReasons are the reason codes accepted by DonateWaste
+var DonationReasons = []string{"SURPLUS", "NO_BUYER", "COMMUNITY", "RESEARCH", "SOIL_AMENDMENT"}
+
+// DisposalReasons are the reason codes accepted by DisposeWaste
+var DisposalReasons = []string{"CONTAMINATED", "SPOILED", "NO_OUTLET", "REGULATORY_ORDER"}
+
+// DonationRecipient is who received a donated lot; MSP is set when the
+// recipient is a member organization
+type DonationRecipient struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Contact string `json:"contact,omitempty"`
+	MSP     string `json:"msp,omitempty"`
+}
+
+// Disposition records a lot donated to a recipient or disposed of at a
+// landfill; Quantity is what remained of the lot at the time
+type Disposition struct {
+	ID            string             `json:"id"`
+	WasteID       string             `json:"wasteId"`
+	Kind          string             `json:"kind"`
+	ReasonCode    string             `json:"reasonCode"`
+	Notes         string             `json:"notes,omitempty"`
+	Quantity      float64            `json:"quantity"`
+	Recipient     *DonationRecipient `json:"recipient,omitempty"`
+	FacilityID    string             `json:"facilityId,omitempty"`
+	RecordedBy    string             `json:"recordedBy"`
+	RecordedByMSP string             `json:"recordedByMsp"`
+	CreatedAt     string             `json:"createdAt"`
+}
+
+// DispositionStatistics sets donated and disposed quantities apart from
+// recycling. RecyclingRate is recycled over what was collected and not
+// donated (donations are not a recycling failure); DiversionRate counts
+// donations as diverted from landfill.
+type DispositionStatistics struct {
+	From          string             `json:"from"`
+	To            string             `json:"to"`
+	Collected     float64            `json:"collected"`
+	Recycled      float64            `json:"recycled"`
+	Donated       float64            `json:"donated"`
+	Disposed      float64            `json:"disposed"`
+	Donations     int                `json:"donations"`
+	Disposals     int                `json:"disposals"`
+	ByReason      map[string]float64 `json:"byReason"`
+	RecyclingRate float64            `json:"recyclingRate"`
+	DiversionRate float64            `json:"diversionRate"`
+	LandfillRate  float64            `json:"landfillRate"`
+}
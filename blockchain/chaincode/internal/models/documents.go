@@ -0,0 +1,10 @@
+package models
+
+// Document references an off-chain file (report, certificate) by its hash
+type Document struct {
+	Type    string `json:"type"`
+	Hash    string `json:"hash"`
+	URI     string `json:"uri,omitempty"`
+	AddedBy string `json:"addedBy"`
+	AddedAt string `json:"addedAt"`
+}
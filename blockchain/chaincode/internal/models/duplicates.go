@@ -0,0 +1,29 @@
+package models
+
+// WasteMerged is the status of a lot consolidated into another as its
+// duplicate; it keeps its own history and names the lot it went into
+const WasteMerged = "MERGED"
+
+// DuplicateGroup is a set of lots of one owner, harvest date and waste type
+// whose quantities lie within the tolerance of each other, most likely the
+// same harvest recorded more than once (e.g. an offline upload retried
+// under a new ID). SpreadPct is how far the largest quantity lies above the
+// smallest.
+type DuplicateGroup struct {
+	OwnerMSP    string    `json:"ownerMsp"`
+	Owner       string    `json:"owner"`
+	HarvestDate string    `json:"harvestDate"`
+	Type        string    `json:"type"`
+	PlotID      string    `json:"plotId,omitempty"`
+	WasteIDs    []string  `json:"wasteIds"`
+	Quantities  []float64 `json:"quantities"`
+	SpreadPct   float64   `json:"spreadPct"`
+}
+
+// DuplicateReport lists the suspected duplicate lots found on the ledger
+type DuplicateReport struct {
+	TolerancePct float64          `json:"tolerancePct"`
+	Lots         int              `json:"lots"`
+	Groups       []DuplicateGroup `json:"groups"`
+	CheckedAt    string           `json:"checkedAt"`
+}
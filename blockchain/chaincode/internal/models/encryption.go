@@ -0,0 +1,24 @@
+package models
+
+// SealAlgorithm is the cipher of sealed fields: AES-256-GCM with a 12-byte
+// nonce prepended to the ciphertext
+const SealAlgorithm = "AES-256-GCM"
+
+// SealedField is a value encrypted by the client before it reached the
+// ledger; only the fingerprint of the key is stored
+type SealedField struct {
+	Ciphertext     string `json:"ciphertext"`
+	Algorithm      string `json:"algorithm"`
+	KeyFingerprint string `json:"keyFingerprint"`
+	SealedBy       string `json:"sealedBy"`
+	SealedAt       string `json:"sealedAt"`
+}
+
+// UnsealedField is the plaintext of a sealed field, returned to a caller
+// that supplied its key
+type UnsealedField struct {
+	WasteID        string `json:"wasteId"`
+	Field          string `json:"field"`
+	Value          string `json:"value"`
+	KeyFingerprint string `json:"keyFingerprint"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// Notice kinds raised for off-chain processes rather than an inbox
+const (
+	NoticeSnapshotCompleted = "SNAPSHOT_COMPLETED"
+	NoticeWasteTagged       = "WASTE_TAGGED"
+	NoticeWasteUntagged     = "WASTE_UNTAGGED"
+)
+
+// AssetChange identifies an asset written by a transaction
+type AssetChange struct {
+	AssetType string `json:"assetType"`
+	ID        string `json:"id"`
+	Version   int    `json:"version"`
+}
+
+// Notice is a business occurrence raised by a transaction (an SLA breach, an
+// appeal...), reported once per kind and subject with every organization
+// notified of it
+type Notice struct {
+	Kind       string   `json:"kind"`
+	Subject    string   `json:"subject"`
+	Message    string   `json:"message"`
+	Recipients []string `json:"recipients"`
+}
+
+// LedgerChangedEvent lists every asset written by a transaction so that
+// read models can refresh them, and the notices it raised for alerting
+type LedgerChangedEvent struct {
+	Changes []AssetChange `json:"changes"`
+	Notices []Notice      `json:"notices,omitempty"`
+}
@@ -0,0 +1,67 @@
+package models
+
+// Facility types
+const (
+	FacilityExtraction = "EXTRACTION"
+	FacilityRecycling  = "RECYCLING"
+	FacilityMixed      = "MIXED"
+	// Landfills only receive lots disposed of as a last resort
+	FacilityLandfill = "LANDFILL"
+)
+
+// Facility and equipment statuses
+const (
+	FacilityActive   = "ACTIVE"
+	FacilityInactive = "INACTIVE"
+
+	EquipmentOperational      = "OPERATIONAL"
+	EquipmentUnderMaintenance = "UNDER_MAINTENANCE"
+	EquipmentOutOfService     = "OUT_OF_SERVICE"
+)
+
+// Facility is a processing plant or recycling site
+type Facility struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Operator       string    `json:"operator"`
+	Location       string    `json:"location,omitempty"`
+	DailyCapacity  float64   `json:"dailyCapacity"`
+	Certifications []string  `json:"certifications"`
+	Geofence       *Geofence `json:"geofence,omitempty"`
+	Status         string    `json:"status"`
+	CreatedAt      string    `json:"createdAt"`
+	UpdatedAt      string    `json:"updatedAt"`
+	History        []History `json:"history"`
+}
+
+// Geofence is the circle around a facility within which deliveries to it
+// are confirmed
+type Geofence struct {
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters float64 `json:"radiusMeters"`
+}
+
+// Equipment is a production line or machine installed in a facility
+type Equipment struct {
+	ID                string    `json:"id"`
+	FacilityID        string    `json:"facilityId"`
+	Name              string    `json:"name"`
+	Type              string    `json:"type"`
+	DailyCapacity     float64   `json:"dailyCapacity"`
+	MaintenanceStatus string    `json:"maintenanceStatus"`
+	LastMaintenance   string    `json:"lastMaintenance,omitempty"`
+	CreatedAt         string    `json:"createdAt"`
+	UpdatedAt         string    `json:"updatedAt"`
+	History           []History `json:"history"`
+}
+
+// FacilityUtilization reports the throughput claimed at a facility on a day
+type FacilityUtilization struct {
+	FacilityID string  `json:"facilityId"`
+	Date       string  `json:"date"`
+	Capacity   float64 `json:"capacity"`
+	Claimed    float64 `json:"claimed"`
+	Remaining  float64 `json:"remaining"`
+}
@@ -0,0 +1,62 @@
+package models
+
+// Rating summary scopes
+const (
+	RatingScopeProduct = "PRODUCT"
+	RatingScopeFarm    = "FARM"
+)
+
+// TraceToken is the public handle printed on a traced product (e.g. as a QR
+// code). Consumers submit feedback through it without a ledger identity; the
+// feedback counters throttle submissions per token.
+type TraceToken struct {
+	Token          string `json:"token"`
+	AssetType      string `json:"assetType"`
+	AssetID        string `json:"assetId"`
+	WasteID        string `json:"wasteId"`
+	FarmID         string `json:"farmId,omitempty"`
+	IssuedBy       string `json:"issuedBy"`
+	IssuedByMSP    string `json:"issuedByMsp"`
+	Revoked        bool   `json:"revoked,omitempty"`
+	CreatedAt      string `json:"createdAt"`
+	FeedbackCount  int    `json:"feedbackCount"`
+	LastFeedbackAt string `json:"lastFeedbackAt,omitempty"`
+	FeedbackDay    string `json:"feedbackDay,omitempty"`
+	FeedbackToday  int    `json:"feedbackToday,omitempty"`
+}
+
+// Feedback is a consumer rating (1-5) of a traced product. Only the hash of
+// the comment is recorded; the text stays with whoever collected it.
+type Feedback struct {
+	ID          string `json:"id"`
+	Token       string `json:"token"`
+	AssetType   string `json:"assetType"`
+	AssetID     string `json:"assetId"`
+	WasteID     string `json:"wasteId"`
+	FarmID      string `json:"farmId,omitempty"`
+	Rating      int    `json:"rating"`
+	CommentHash string `json:"commentHash,omitempty"`
+	SubmittedAt string `json:"submittedAt"`
+	TxID        string `json:"txId"`
+}
+
+// RatingSummary aggregates the ratings of a product or a farm; Distribution
+// counts ratings 1 to 5
+type RatingSummary struct {
+	Scope          string  `json:"scope"`
+	Key            string  `json:"key"`
+	Count          int     `json:"count"`
+	Sum            int     `json:"sum"`
+	Average        float64 `json:"average"`
+	Distribution   [5]int  `json:"distribution"`
+	LastFeedbackAt string  `json:"lastFeedbackAt,omitempty"`
+}
+
+// Add folds a rating into the summary
+func (r *RatingSummary) Add(rating int, at string) {
+	r.Count++
+	r.Sum += rating
+	r.Distribution[rating-1]++
+	r.Average = float64(r.Sum) / float64(r.Count)
+	r.LastFeedbackAt = at
+}
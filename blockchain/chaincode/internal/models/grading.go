@@ -0,0 +1,50 @@
+package models
+
+// How a grade was assigned
+const (
+	GradeInherited = "INHERITED"
+	GradeAssessed  = "ASSESSED"
+	GradeSensor    = "SENSOR"
+	GradeRegraded  = "REGRADED"
+)
+
+// Regrade request statuses
+const (
+	RegradePending   = "PENDING"
+	RegradeConfirmed = "CONFIRMED"
+	RegradeDismissed = "DISMISSED"
+)
+
+// GradeRecord is one grading decision on an extraction; grading history is
+// kept apart from the status history
+type GradeRecord struct {
+	Grade         string `json:"grade"`
+	PreviousGrade string `json:"previousGrade,omitempty"`
+	Kind          string `json:"kind"`
+	Grader        string `json:"grader"`
+	GraderMSP     string `json:"graderMsp,omitempty"`
+	Evidence      string `json:"evidence"`
+	Reason        string `json:"reason,omitempty"`
+	RequestID     string `json:"requestId,omitempty"`
+	GradedAt      string `json:"gradedAt"`
+}
+
+// RegradeRequest appeals an extraction's grade; an independent grader
+// confirms a new grade or dismisses the appeal by keeping the current one
+type RegradeRequest struct {
+	ID             string `json:"id"`
+	ExtractionID   string `json:"extractionId"`
+	CurrentGrade   string `json:"currentGrade"`
+	ProposedGrade  string `json:"proposedGrade"`
+	Reason         string `json:"reason"`
+	Evidence       string `json:"evidence"`
+	RequestedBy    string `json:"requestedBy"`
+	RequestedMSP   string `json:"requestedMsp"`
+	Status         string `json:"status"`
+	Grade          string `json:"grade,omitempty"`
+	Grader         string `json:"grader,omitempty"`
+	GraderMSP      string `json:"graderMsp,omitempty"`
+	GraderEvidence string `json:"graderEvidence,omitempty"`
+	CreatedAt      string `json:"createdAt"`
+	ResolvedAt     string `json:"resolvedAt,omitempty"`
+}
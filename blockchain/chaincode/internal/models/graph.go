@@ -0,0 +1,56 @@
+package models
+
+// Node types of the relationship graph
+const (
+	GraphFarm       = "FARM"
+	GraphPlot       = "PLOT"
+	GraphWaste      = "WASTE"
+	GraphExtraction = "EXTRACTION"
+	GraphProduct    = "PRODUCT"
+	GraphRecycling  = "RECYCLING"
+	GraphShipment   = "SHIPMENT"
+)
+
+// Edge types of the relationship graph, pointing downstream
+const (
+	EdgeDeclared      = "DECLARED"       // farm -> waste
+	EdgeGrownOn       = "GROWN_ON"       // plot -> waste
+	EdgeProcessedInto = "PROCESSED_INTO" // waste -> extraction
+	EdgeProduced      = "PRODUCED"       // extraction or recycling -> product
+	EdgeRecycledInto  = "RECYCLED_INTO"  // waste or product -> recycling
+	EdgeCarries       = "CARRIES"        // shipment -> waste
+)
+
+// GraphNode is an asset of the relationship graph. IDs are TYPE:assetId;
+// products are PRODUCT:extractionId/line for extraction output lines and
+// PRODUCT:recyclingId for recycled products. Redacted lots are shown
+// without their details or their farm and plot.
+type GraphNode struct {
+	ID       string  `json:"id"`
+	Type     string  `json:"type"`
+	AssetID  string  `json:"assetId"`
+	Label    string  `json:"label"`
+	Status   string  `json:"status,omitempty"`
+	Quantity float64 `json:"quantity,omitempty"`
+	Date     string  `json:"date,omitempty"`
+	Redacted bool    `json:"redacted,omitempty"`
+}
+
+// GraphEdge links two nodes of the relationship graph
+type GraphEdge struct {
+	ID       string  `json:"id"`
+	Source   string  `json:"source"`
+	Target   string  `json:"target"`
+	Type     string  `json:"type"`
+	Quantity float64 `json:"quantity,omitempty"`
+}
+
+// RelationshipGraph is the neighbourhood of a root asset up to Depth hops;
+// Truncated is set when it was cut at the node limit
+type RelationshipGraph struct {
+	Root      string       `json:"root"`
+	Depth     int          `json:"depth"`
+	Nodes     []*GraphNode `json:"nodes"`
+	Edges     []*GraphEdge `json:"edges"`
+	Truncated bool         `json:"truncated"`
+}
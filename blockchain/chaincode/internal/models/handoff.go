@@ -0,0 +1,52 @@
+package models
+
+// WasteExported is the status of a lot handed off to another network or
+// system
+const WasteExported = "EXPORTED"
+
+// Handoff kinds
+const (
+	HandoffExport = "EXPORT"
+	HandoffImport = "IMPORT"
+)
+
+// HandoffHashAlgorithm is the digest of the traceability bundles handed off
+const HandoffHashAlgorithm = "sha256"
+
+// ExternalOrigin is the provenance of a lot received from another network
+// or system: where it was recorded, under which reference and the hash of
+// the traceability bundle that came with it
+type ExternalOrigin struct {
+	System            string `json:"system"`
+	ExternalReference string `json:"externalReference"`
+	ExternalLotID     string `json:"externalLotId,omitempty"`
+	ContentHash       string `json:"contentHash"`
+	HandoffID         string `json:"handoffId,omitempty"`
+}
+
+// Handoff records a lot leaving the network (EXPORT) or entering it from
+// outside (IMPORT). System is the destination or source system and
+// ContentHash the digest of the traceability bundle that went with the lot,
+// so both sides can prove they hold the same provenance.
+type Handoff struct {
+	ID                string  `json:"id"`
+	Kind              string  `json:"kind"`
+	WasteID           string  `json:"wasteId"`
+	System            string  `json:"system"`
+	ExternalReference string  `json:"externalReference"`
+	ExternalLotID     string  `json:"externalLotId,omitempty"`
+	ContentHash       string  `json:"contentHash"`
+	HashAlgorithm     string  `json:"hashAlgorithm"`
+	Quantity          float64 `json:"quantity"`
+	Notes             string  `json:"notes,omitempty"`
+	RecordedBy        string  `json:"recordedBy"`
+	RecordedByMSP     string  `json:"recordedByMsp"`
+	CreatedAt         string  `json:"createdAt"`
+}
+
+// ExportedHandoff is an export handoff with its traceability bundle, exactly
+// as hashed, to send along with the lot
+type ExportedHandoff struct {
+	Handoff *Handoff `json:"handoff"`
+	Bundle  string   `json:"bundle"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// HistoryCheckpoint holds history entries rolled out of an asset, stored
+// under HISTORY_<TYPE>_<id>_<first index>
+type HistoryCheckpoint struct {
+	AssetType  string    `json:"assetType"`
+	AssetID    string    `json:"assetId"`
+	FirstIndex int       `json:"firstIndex"`
+	Entries    []History `json:"entries"`
+	ArchivedAt string    `json:"archivedAt"`
+}
+
+// ArchivedHistoryPage is a page of checkpoints, oldest first; Bookmark is
+// empty on the last page and Truncated marks a page cut short by the query
+// budget
+type ArchivedHistoryPage struct {
+	Checkpoints []*HistoryCheckpoint `json:"checkpoints"`
+	Bookmark    string               `json:"bookmark"`
+	Truncated   bool                 `json:"truncated,omitempty"`
+}
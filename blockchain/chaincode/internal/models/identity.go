@@ -0,0 +1,35 @@
+package models
+
+// IdentityBinding ties an on-chain participant to the enrollment ID of its
+// certificates rather than to the certificates themselves, so renewed or
+// re-keyed certificates keep acting as the same participant. Identities
+// lists every certificate identity seen for the enrollment; records made
+// under any of them belong to ParticipantID.
+type IdentityBinding struct {
+	ParticipantID string    `json:"participantId"`
+	MSP           string    `json:"msp"`
+	EnrollmentID  string    `json:"enrollmentId"`
+	Identities    []string  `json:"identities"`
+	BoundAt       string    `json:"boundAt"`
+	UpdatedAt     string    `json:"updatedAt"`
+	History       []History `json:"history"`
+}
+
+// HasIdentity reports whether a certificate identity belongs to the binding
+func (b *IdentityBinding) HasIdentity(identity string) bool {
+	for _, known := range b.Identities {
+		if known == identity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IdentityResolution is the participant an identity found in history
+// resolves to; Binding is nil for identities never bound to an enrollment
+type IdentityResolution struct {
+	Identity      string           `json:"identity"`
+	ParticipantID string           `json:"participantId"`
+	Binding       *IdentityBinding `json:"binding,omitempty"`
+}
@@ -0,0 +1,74 @@
+package models
+
+// How a buyer acquired a lot of an impact report
+const (
+	AcquiredByInvoice        = "INVOICE"
+	AcquiredBySupplyDelivery = "SUPPLY_DELIVERY"
+)
+
+// ImpactReport aggregates the environmental impact of the lots a buyer
+// organization acquired over a period: invoiced to it, or delivered to it
+// under a supply contract. Quantities are in tonnes and emissions in
+// tCO2e. Every figure adds up the Lots, each of which names the ledger
+// transactions its numbers come from.
+type ImpactReport struct {
+	Buyer              string      `json:"buyer"`
+	From               string      `json:"from"`
+	To                 string      `json:"to"`
+	LotCount           int         `json:"lotCount"`
+	AcquiredQuantity   float64     `json:"acquiredQuantity"`
+	DivertedQuantity   float64     `json:"divertedQuantity"`
+	RecycledQuantity   float64     `json:"recycledQuantity"`
+	DonatedQuantity    float64     `json:"donatedQuantity"`
+	LandfilledQuantity float64     `json:"landfilledQuantity"`
+	DiversionRate      float64     `json:"diversionRate"`
+	CO2eAvoided        float64     `json:"co2eAvoided"`
+	FullyValorizedLots int         `json:"fullyValorizedLots"`
+	Farms              int         `json:"farms"`
+	CertifiedFarms     int         `json:"certifiedFarms"`
+	CertifiedFarmShare float64     `json:"certifiedFarmShare"`
+	CertificationTypes []string    `json:"certificationTypes"`
+	Lots               []ImpactLot `json:"lots"`
+	ComputedAt         string      `json:"computedAt"`
+}
+
+// ImpactLot is what one acquired lot contributes to an impact report.
+// Farm is the pseudonymous participant that produced it; Certifications
+// lists its certification documents of the report's types.
+type ImpactLot struct {
+	WasteID          string               `json:"wasteId"`
+	Reference        string               `json:"reference,omitempty"`
+	AcquiredBy       string               `json:"acquiredBy"`
+	AcquisitionID    string               `json:"acquisitionId"`
+	AcquiredAt       string               `json:"acquiredAt"`
+	Quantity         float64              `json:"quantity"`
+	Diverted         float64              `json:"diverted"`
+	Recycled         float64              `json:"recycled"`
+	Donated          float64              `json:"donated"`
+	Landfilled       float64              `json:"landfilled"`
+	CO2eAvoided      float64              `json:"co2eAvoided"`
+	Farm             string               `json:"farm"`
+	Certifications   []Document           `json:"certifications"`
+	CompletionCertID string               `json:"completionCertificateId,omitempty"`
+	Sources          []ImpactSource       `json:"sources"`
+	Recyclings       []ImpactContribution `json:"recyclings"`
+}
+
+// ImpactSource is a ledger record an impact figure was read from, with the
+// transaction that wrote the version used
+type ImpactSource struct {
+	AssetType string `json:"assetType"`
+	ID        string `json:"id"`
+	TxID      string `json:"txId"`
+	Timestamp string `json:"timestamp"`
+}
+
+// ImpactContribution is a recycling run's share of a lot: the quantity it
+// took from the lot and the avoided emissions in proportion
+type ImpactContribution struct {
+	RecyclingID string  `json:"recyclingId"`
+	Method      string  `json:"method"`
+	Quantity    float64 `json:"quantity"`
+	CO2eAvoided float64 `json:"co2eAvoided"`
+	TxID        string  `json:"txId"`
+}
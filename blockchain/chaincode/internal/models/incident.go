@@ -0,0 +1,85 @@
+package models
+
+// Incident severities, from least to most serious
+const (
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+// Incident kinds
+const (
+	IncidentAccident = "ACCIDENT"
+	IncidentSpill    = "SPILL"
+	IncidentEmission = "EMISSION"
+	IncidentFire     = "FIRE"
+	IncidentOther    = "OTHER"
+)
+
+// Incident statuses
+const (
+	IncidentOpen   = "OPEN"
+	IncidentClosed = "CLOSED"
+)
+
+// Incident is an accident or environmental incident (spill, emission...)
+// at a facility, during a shipment or involving a lot. It is closed once
+// its corrective actions are done and, when serious, the regulator was told.
+type Incident struct {
+	ID                string             `json:"id"`
+	FacilityID        string             `json:"facilityId,omitempty"`
+	ShipmentID        string             `json:"shipmentId,omitempty"`
+	WasteID           string             `json:"wasteId,omitempty"`
+	Kind              string             `json:"kind"`
+	Severity          string             `json:"severity"`
+	Description       string             `json:"description"`
+	OccurredAt        string             `json:"occurredAt"`
+	Reporter          string             `json:"reporter"`
+	ReporterMSP       string             `json:"reporterMsp"`
+	ResponsibleMSP    string             `json:"responsibleMsp"`
+	CorrectiveActions []CorrectiveAction `json:"correctiveActions"`
+	RegulatorNotified bool               `json:"regulatorNotified"`
+	RegulatorNotice   *RegulatorNotice   `json:"regulatorNotice,omitempty"`
+	Status            string             `json:"status"`
+	ClosedAt          string             `json:"closedAt,omitempty"`
+	ClosureNotes      string             `json:"closureNotes,omitempty"`
+	CreatedAt         string             `json:"createdAt"`
+	UpdatedAt         string             `json:"updatedAt"`
+	History           []History          `json:"history"`
+}
+
+// CorrectiveAction is a numbered step taken to contain an incident or to
+// keep it from happening again
+type CorrectiveAction struct {
+	Line        int    `json:"line"`
+	Description string `json:"description"`
+	DueDate     string `json:"dueDate,omitempty"`
+	AddedBy     string `json:"addedBy"`
+	AddedAt     string `json:"addedAt"`
+	CompletedBy string `json:"completedBy,omitempty"`
+	CompletedAt string `json:"completedAt,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+}
+
+// RegulatorNotice records when and under which reference the environmental
+// regulator was told about an incident
+type RegulatorNotice struct {
+	Reference  string `json:"reference,omitempty"`
+	NotifiedBy string `json:"notifiedBy"`
+	NotifiedAt string `json:"notifiedAt"`
+}
+
+// FacilityCompliance gathers what an inspector checks at a facility: its
+// status and certifications, how lots received there kept the facility SLA
+// and the incidents still open
+type FacilityCompliance struct {
+	FacilityID          string         `json:"facilityId"`
+	Name                string         `json:"name"`
+	Operator            string         `json:"operator"`
+	Status              string         `json:"status"`
+	Certifications      []string       `json:"certifications"`
+	SLA                 *SLACompliance `json:"sla,omitempty"`
+	OpenIncidents       []*Incident    `json:"openIncidents"`
+	HighestOpenSeverity string         `json:"highestOpenSeverity,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// RecordOrphaned is the status of an extraction or recycling record whose
+// source lot or extraction does not exist
+const RecordOrphaned = "ORPHANED"
+
+// Integrity issue kinds: a record whose source is missing, a lot left in
+// its status before the record that processed or recycled it, a lot whose
+// consumed quantity misses recyclings made from it, and an extraction
+// output line that does not list a recycling made from it
+const (
+	IntegrityOrphanedRecord      = "ORPHANED_RECORD"
+	IntegrityLotNotUpdated       = "LOT_NOT_UPDATED"
+	IntegrityConsumptionMismatch = "CONSUMPTION_MISMATCH"
+	IntegrityOutputLinkMissing   = "OUTPUT_LINK_MISSING"
+)
+
+// IntegrityIssue is an inconsistency between an extraction or recycling
+// record and the lot or extraction it was made from
+type IntegrityIssue struct {
+	Kind      string `json:"kind"`
+	AssetType string `json:"assetType"`
+	AssetID   string `json:"assetId"`
+	WasteID   string `json:"wasteId,omitempty"`
+	Details   string `json:"details"`
+	Repaired  bool   `json:"repaired"`
+}
+
+// IntegrityReport lists the issues an integrity check found among the
+// extraction and recycling records, and whether they were repaired
+type IntegrityReport struct {
+	CheckedAt   string           `json:"checkedAt"`
+	Extractions int              `json:"extractions"`
+	Recyclings  int              `json:"recyclings"`
+	Issues      []IntegrityIssue `json:"issues"`
+	Repaired    int              `json:"repaired"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// MaintenanceReport summarizes what a maintenance run cleaned up
+type MaintenanceReport struct {
+	RanAt                 string    `json:"ranAt"`
+	NotificationsPruned   int       `json:"notificationsPruned"`
+	PersonalDataPurged    int       `json:"personalDataPurged"`
+	SupplyContractsAtRisk int       `json:"supplyContractsAtRisk"`
+	SLABreaches           int       `json:"slaBreaches"`
+	ApprovalsExpired      int       `json:"approvalsExpired"`
+	SettlementsReleased   int       `json:"settlementsReleased"`
+	MetricSamplesPruned   int       `json:"metricSamplesPruned"`
+	Snapshot              *Snapshot `json:"snapshot,omitempty"`
+	// Resume maps the steps that stopped at their query budget to the key
+	// their next run starts at
+	Resume map[string]string `json:"resume,omitempty"`
+}
+
+// MaintenanceCursors is where each maintenance step that scans the ledger
+// resumes, so that every run reads one budgeted page per step
+type MaintenanceCursors struct {
+	Cursors map[string]string `json:"cursors"`
+}
@@ -0,0 +1,56 @@
+package models
+
+// Listing statuses
+const (
+	ListingOpen    = "OPEN"
+	ListingAwarded = "AWARDED"
+	ListingExpired = "EXPIRED"
+	ListingSettled = "SETTLED"
+)
+
+// Listing offers a lot for sale by sealed bids until ExpiresAt; bidders then
+// reveal their amounts against the commitments they made, and the listing is
+// awarded among the revealed bids
+type Listing struct {
+	ID            string            `json:"id"`
+	WasteID       string            `json:"wasteId"`
+	Seller        string            `json:"seller"`
+	SellerMSP     string            `json:"sellerMsp"`
+	Quantity      float64           `json:"quantity"`
+	ReservePrice  float64           `json:"reservePrice"`
+	ExpiresAt     string            `json:"expiresAt"`
+	Status        string            `json:"status"`
+	Bids          []BidReceipt      `json:"bids"`
+	WinningBidder string            `json:"winningBidder,omitempty"`
+	WinningMSP    string            `json:"winningMsp,omitempty"`
+	WinningPrice  float64           `json:"winningPrice,omitempty"`
+	InvoiceID     string            `json:"invoiceId,omitempty"`
+	Settlement    []*SettlementLine `json:"settlement,omitempty"`
+	CreatedAt     string            `json:"createdAt"`
+	UpdatedAt     string            `json:"updatedAt"`
+	History       []History         `json:"history"`
+}
+
+// BidReceipt is the public trace of a sealed bid: who bid, when and the
+// SHA-256 commitment of the sealed bid; the bidder and amount are filled in
+// when the bid is revealed
+type BidReceipt struct {
+	BidderMSP   string  `json:"bidderMsp"`
+	SubmittedAt string  `json:"submittedAt"`
+	Commitment  string  `json:"commitment"`
+	Bidder      string  `json:"bidder,omitempty"`
+	Amount      float64 `json:"amount,omitempty"`
+	RevealedAt  string  `json:"revealedAt,omitempty"`
+}
+
+// SealedBid is a bid amount kept in the bidding organization's own implicit
+// collection, salted so that its commitment cannot be guessed from likely
+// amounts; each organization holds at most one bid per listing
+type SealedBid struct {
+	ListingID   string  `json:"listingId"`
+	Bidder      string  `json:"bidder"`
+	BidderMSP   string  `json:"bidderMsp"`
+	Amount      float64 `json:"amount"`
+	Salt        string  `json:"salt"`
+	SubmittedAt string  `json:"submittedAt"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "encoding/json"
+
+// MediaAttachment is a photo or video of an asset kept in off-chain storage
+// and anchored by its SHA-256 hash; Latitude and Longitude are where it was
+// taken, when HasLocation says the device recorded it
+type MediaAttachment struct {
+	Hash        string  `json:"hash"`
+	MimeType    string  `json:"mimeType"`
+	URI         string  `json:"uri"`
+	Size        int64   `json:"size,omitempty"`
+	CapturedAt  string  `json:"capturedAt,omitempty"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	HasLocation bool    `json:"hasLocation,omitempty"`
+	Caption     string  `json:"caption,omitempty"`
+	AddedBy     string  `json:"addedBy"`
+	AddedByMSP  string  `json:"addedByMsp"`
+	AddedAt     string  `json:"addedAt"`
+}
+
+// MarshalJSON writes a recorded position even on the equator or the prime
+// meridian
+func (m MediaAttachment) MarshalJSON() ([]byte, error) {
+	type plain MediaAttachment
+	fields := struct {
+		plain
+		Latitude  *float64 `json:"latitude,omitempty"`
+		Longitude *float64 `json:"longitude,omitempty"`
+	}{plain: plain(m)}
+	if m.HasLocation {
+		fields.Latitude, fields.Longitude = &m.Latitude, &m.Longitude
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON sets HasLocation whenever both coordinates are present, so
+// inputs and records that carry only the coordinates keep their position
+func (m *MediaAttachment) UnmarshalJSON(data []byte) error {
+	type plain MediaAttachment
+	var fields struct {
+		plain
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*m = MediaAttachment(fields.plain)
+	if fields.Latitude != nil {
+		m.Latitude = *fields.Latitude
+	}
+	if fields.Longitude != nil {
+		m.Longitude = *fields.Longitude
+	}
+	if fields.Latitude != nil && fields.Longitude != nil {
+		m.HasLocation = true
+	}
+
+	return nil
+}
+
+// MediaGallery holds the media of a waste lot, extraction or recycling
+// product in display order
+type MediaGallery struct {
+	AssetType string            `json:"assetType"`
+	AssetID   string            `json:"assetId"`
+	WasteID   string            `json:"wasteId"`
+	Items     []MediaAttachment `json:"items"`
+	UpdatedAt string            `json:"updatedAt"`
+	History   []History         `json:"history"`
+}
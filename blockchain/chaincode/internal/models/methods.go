@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RecyclingMethod is a catalog entry describing a recycling process and its
+// environmental parameters
+type RecyclingMethod struct {
+	Code                   string   `json:"code"`
+	Name                   string   `json:"name"`
+	EmissionFactor         float64  `json:"emissionFactor"`
+	RequiredCertifications []string `json:"requiredCertifications"`
+	TypicalDurationDays    int      `json:"typicalDurationDays"`
+	Active                 bool     `json:"active"`
+	UpdatedAt              string   `json:"updatedAt,omitempty"`
+	UpdatedBy              string   `json:"updatedBy,omitempty"`
+}
+
+// ExpectedCompletion adds the method's typical duration to an RFC3339 time
+func (m *RecyclingMethod) ExpectedCompletion(start string) string {
+	started, err := time.Parse(time.RFC3339, start)
+	if err != nil || m.TypicalDurationDays == 0 {
+		return ""
+	}
+
+	return started.AddDate(0, 0, m.TypicalDurationDays).Format(time.RFC3339)
+}
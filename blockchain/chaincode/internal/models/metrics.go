@@ -0,0 +1,50 @@
+package models
+
+// MetricUnfinished is the outcome logged for an invocation that never
+// reached the after-transaction hook: it failed, panicked or was abandoned
+const MetricUnfinished = "UNFINISHED"
+
+// MetricSample is the on-chain record of one invocation that wrote to the
+// world state, or of a failure reported through RecordFailedInvocation.
+// Durations differ from peer to peer and are never written on chain.
+type MetricSample struct {
+	TxID      string `json:"txId"`
+	Function  string `json:"function"`
+	Reads     int    `json:"reads"`
+	Writes    int    `json:"writes"`
+	Outcome   string `json:"outcome"`
+	Timestamp string `json:"timestamp"`
+}
+
+// PeerFunctionStats are the durations of a function's recent invocations
+// as measured by the peer that answered the query, queries included
+type PeerFunctionStats struct {
+	Calls      int     `json:"calls"`
+	Unfinished int     `json:"unfinished"`
+	Slow       int     `json:"slow"`
+	AvgMs      float64 `json:"avgMs"`
+	P95Ms      float64 `json:"p95Ms"`
+	MaxMs      float64 `json:"maxMs"`
+}
+
+// FunctionMetrics aggregates a function's recorded invocations over a window
+type FunctionMetrics struct {
+	Function      string             `json:"function"`
+	Invocations   int                `json:"invocations"`
+	Failures      int                `json:"failures"`
+	AvgReads      float64            `json:"avgReads"`
+	MaxReads      int                `json:"maxReads"`
+	AvgWrites     float64            `json:"avgWrites"`
+	MaxWrites     int                `json:"maxWrites"`
+	LastInvokedAt string             `json:"lastInvokedAt,omitempty"`
+	Peer          *PeerFunctionStats `json:"peer,omitempty"`
+}
+
+// FunctionMetricsReport lists the metrics of every function seen since a
+// point in time, busiest first
+type FunctionMetricsReport struct {
+	Since     string             `json:"since"`
+	Recording bool               `json:"recording"`
+	SlowMs    int                `json:"slowMs"`
+	Functions []*FunctionMetrics `json:"functions"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Migration statuses
+const (
+	MigrationPending    = "PENDING"
+	MigrationInProgress = "IN_PROGRESS"
+	MigrationComplete   = "COMPLETE"
+)
+
+// Migration records the progress of one registered schema migration, which
+// upgrades records a batch of keys per invocation. Bookmark is the last key
+// visited; Scanned counts visited keys and Migrated the records rewritten.
+type Migration struct {
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	Bookmark    string `json:"bookmark,omitempty"`
+	Batches     int    `json:"batches"`
+	Scanned     int    `json:"scanned"`
+	Migrated    int    `json:"migrated"`
+	StartedAt   string `json:"startedAt,omitempty"`
+	StartedBy   string `json:"startedBy,omitempty"`
+	UpdatedAt   string `json:"updatedAt,omitempty"`
+	CompletedAt string `json:"completedAt,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+// Notification kinds
+const (
+	NotifyAgreementProposed   = "AGREEMENT_PROPOSED"
+	NotifyAgreementAccepted   = "AGREEMENT_ACCEPTED"
+	NotifyAgreementRevoked    = "AGREEMENT_REVOKED"
+	NotifyCollectionAssigned  = "COLLECTION_ASSIGNED"
+	NotifyCollectionDone      = "COLLECTION_FULFILLED"
+	NotifyQualityDowngraded   = "QUALITY_DOWNGRADED"
+	NotifyShareReceived       = "OWNERSHIP_SHARE_RECEIVED"
+	NotifyListingAwarded      = "LISTING_AWARDED"
+	NotifyDelegationGranted   = "DELEGATION_GRANTED"
+	NotifyDelegationRevoked   = "DELEGATION_REVOKED"
+	NotifyRegradeRequested    = "REGRADE_REQUESTED"
+	NotifyRegradeResolved     = "REGRADE_RESOLVED"
+	NotifyClaimFiled          = "CLAIM_FILED"
+	NotifyClaimDecided        = "CLAIM_DECIDED"
+	NotifyClaimSettled        = "CLAIM_SETTLED"
+	NotifyShipmentFlagged     = "SHIPMENT_FLAGGED"
+	NotifySupplyProposed      = "SUPPLY_CONTRACT_PROPOSED"
+	NotifySupplyAtRisk        = "SUPPLY_COMMITMENT_AT_RISK"
+	NotifyMembershipChanged   = "COOPERATIVE_MEMBERSHIP_CHANGED"
+	NotifySLABreached         = "SLA_BREACHED"
+	NotifyIncidentReported    = "INCIDENT_REPORTED"
+	NotifyAuditFailed         = "AUDIT_INSPECTION_FAILED"
+	NotifyApprovalRequested   = "APPROVAL_REQUESTED"
+	NotifyLotCompleted        = "LOT_COMPLETED"
+	NotifySettlementPrepared  = "SETTLEMENT_PREPARED"
+	NotifySettlementClosed    = "SETTLEMENT_CLOSED"
+	NotifyCreditLimitExceeded = "CREDIT_LIMIT_EXCEEDED"
+	NotifyIntakeReserved      = "INTAKE_RESERVED"
+	NotifyAuditAssigned       = "AUDIT_ASSIGNED"
+)
+
+// Notification is an entry in an organization's inbox
+type Notification struct {
+	ID        string `json:"id"`
+	Recipient string `json:"recipient"`
+	Kind      string `json:"kind"`
+	Subject   string `json:"subject"`
+	Message   string `json:"message"`
+	CreatedAt string `json:"createdAt"`
+	Read      bool   `json:"read"`
+	ReadAt    string `json:"readAt,omitempty"`
+}
+
+// NotificationPage is one page of an inbox; Bookmark is empty on the last
+// page and Truncated marks a page cut short by the query budget
+type NotificationPage struct {
+	Notifications []*Notification `json:"notifications"`
+	Bookmark      string          `json:"bookmark"`
+	Truncated     bool            `json:"truncated,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+// Onboarding steps a new participant goes through before activation; the
+// backend's onboarding wizard collects and verifies them in this order
+const (
+	OnboardingDocuments        = "DOCUMENTS"
+	OnboardingFarmRegistration = "FARM_REGISTRATION"
+	OnboardingRoleAssignment   = "ROLE_ASSIGNMENT"
+)
+
+// OnboardingSteps lists the known onboarding steps in their order
+var OnboardingSteps = []string{OnboardingDocuments, OnboardingFarmRegistration, OnboardingRoleAssignment}
+
+// Onboarding statuses of a participant
+const (
+	OnboardingInProgress = "ONBOARDING"
+	OnboardingActive     = "ACTIVE"
+)
+
+// ParticipantOnboarding is the public record of a participant's onboarding.
+// It names no personal data: each verified step only carries the SHA-256 of
+// the evidence the verifier reviewed off chain.
+type ParticipantOnboarding struct {
+	ParticipantID string           `json:"participantId"`
+	MSP           string           `json:"msp"`
+	Status        string           `json:"status"`
+	Steps         []OnboardingStep `json:"steps"`
+	ActivatedBy   string           `json:"activatedBy,omitempty"`
+	ActivatedAt   string           `json:"activatedAt,omitempty"`
+	CreatedAt     string           `json:"createdAt"`
+	UpdatedAt     string           `json:"updatedAt"`
+	History       []History        `json:"history"`
+}
+
+// OnboardingStep is a verified onboarding step; Note carries what the step
+// settled that is not personal, such as the role assigned
+type OnboardingStep struct {
+	Step         string `json:"step"`
+	EvidenceHash string `json:"evidenceHash"`
+	Note         string `json:"note,omitempty"`
+	VerifiedBy   string `json:"verifiedBy"`
+	VerifiedAt   string `json:"verifiedAt"`
+}
+
+// StepVerified reports whether a step of the onboarding was verified
+func (o *ParticipantOnboarding) StepVerified(step string) bool {
+	for _, verified := range o.Steps {
+		if verified.Step == step {
+			return true
+		}
+	}
+
+	return false
+}
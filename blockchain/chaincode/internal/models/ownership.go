@@ -0,0 +1,17 @@
+package models
+
+// OwnershipShare is a holder's percentage of a co-owned lot; HolderID is the
+// holder's client identity, which must sign transfers of the share
+type OwnershipShare struct {
+	HolderID   string  `json:"holderId"`
+	HolderMSP  string  `json:"holderMsp"`
+	Percentage float64 `json:"percentage"`
+}
+
+// SettlementLine is one holder's part of a payment for a lot
+type SettlementLine struct {
+	HolderID   string  `json:"holderId"`
+	HolderMSP  string  `json:"holderMsp"`
+	Percentage float64 `json:"percentage"`
+	Amount     float64 `json:"amount"`
+}
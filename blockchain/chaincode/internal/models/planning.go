@@ -0,0 +1,51 @@
+package models
+
+// Intake reservation statuses
+const (
+	IntakeReserved  = "RESERVED"
+	IntakeCancelled = "CANCELLED"
+)
+
+// IntakeReservation books part of a facility's intake on a day for a
+// delivery to come, optionally of a known lot or under a supply contract
+type IntakeReservation struct {
+	ID          string    `json:"id"`
+	FacilityID  string    `json:"facilityId"`
+	Date        string    `json:"date"`
+	Quantity    float64   `json:"quantity"`
+	WasteID     string    `json:"wasteId,omitempty"`
+	ContractID  string    `json:"contractId,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+	ReservedBy  string    `json:"reservedBy"`
+	ReservedMSP string    `json:"reservedMsp"`
+	Status      string    `json:"status"`
+	CreatedAt   string    `json:"createdAt"`
+	UpdatedAt   string    `json:"updatedAt"`
+	History     []History `json:"history"`
+}
+
+// IntakeForecastDay is what a facility expects to receive on a day:
+// reserved intake, shipments in transit due that day and the daily share
+// of the supply contract volume its operator still awaits
+type IntakeForecastDay struct {
+	FacilityID   string  `json:"facilityId"`
+	FacilityName string  `json:"facilityName"`
+	Date         string  `json:"date"`
+	Capacity     float64 `json:"capacity"`
+	Reserved     float64 `json:"reserved"`
+	InTransit    float64 `json:"inTransit"`
+	Committed    float64 `json:"committed"`
+	Expected     float64 `json:"expected"`
+	Remaining    float64 `json:"remaining"`
+	Overbooked   bool    `json:"overbooked"`
+	Reservations int     `json:"reservations"`
+	Shipments    int     `json:"shipments"`
+}
+
+// IntakeForecast is the day-by-day intake expected at facilities between
+// two dates (inclusive)
+type IntakeForecast struct {
+	From string               `json:"from"`
+	To   string               `json:"to"`
+	Days []*IntakeForecastDay `json:"days"`
+}
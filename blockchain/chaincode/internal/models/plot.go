@@ -0,0 +1,57 @@
+package models
+
+// Plot statuses
+const (
+	PlotActive  = "ACTIVE"
+	PlotRetired = "RETIRED"
+)
+
+// Parcel identification schemes
+const (
+	ParcelSchemeLPIS      = "LPIS"
+	ParcelSchemeCadastral = "CADASTRAL"
+)
+
+// Plot is a parcel of a farm, identified in the EU Land Parcel
+// Identification System (LPIS) or the national cadastre; lots may declare
+// the plot they were harvested on
+type Plot struct {
+	ID        string    `json:"id"`
+	Farm      string    `json:"farm"`
+	OwnerMSP  string    `json:"ownerMsp"`
+	ParcelID  string    `json:"parcelId"`
+	Scheme    string    `json:"scheme"`
+	AreaHa    float64   `json:"areaHa,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt string    `json:"createdAt"`
+	UpdatedAt string    `json:"updatedAt"`
+	History   []History `json:"history"`
+}
+
+// PlotOrigin is the parcel-level origin of a lot in compliance exports
+type PlotOrigin struct {
+	PlotID   string  `json:"plotId"`
+	ParcelID string  `json:"parcelId"`
+	Scheme   string  `json:"scheme"`
+	AreaHa   float64 `json:"areaHa,omitempty"`
+	Region   string  `json:"region,omitempty"`
+}
+
+// PlotStatistics aggregates the lots harvested on one plot; YieldPerHa is
+// the collected quantity per hectare when the plot area is known
+type PlotStatistics struct {
+	PlotID         string  `json:"plotId"`
+	ParcelID       string  `json:"parcelId"`
+	Scheme         string  `json:"scheme"`
+	Farm           string  `json:"farm"`
+	AreaHa         float64 `json:"areaHa,omitempty"`
+	WasteCount     int     `json:"wasteCount"`
+	TotalCollected float64 `json:"totalCollected"`
+	TotalProcessed float64 `json:"totalProcessed"`
+	TotalRecycled  float64 `json:"totalRecycled"`
+	TotalDonated   float64 `json:"totalDonated"`
+	TotalDisposed  float64 `json:"totalDisposed"`
+	RecyclingRate  float64 `json:"recyclingRate"`
+	YieldPerHa     float64 `json:"yieldPerHa,omitempty"`
+}
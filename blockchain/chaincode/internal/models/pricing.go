@@ -0,0 +1,38 @@
+package models
+
+// Valuation events
+const (
+	ValuationCreated     = "CREATED"
+	ValuationTransferred = "TRANSFERRED"
+	ValuationSold        = "SOLD"
+)
+
+// PriceEntry is the market price of a product type from a given date
+type PriceEntry struct {
+	ProductType  string  `json:"productType"`
+	Date         string  `json:"date"`
+	PricePerUnit float64 `json:"pricePerUnit"`
+	Currency     string  `json:"currency"`
+	Source       string  `json:"source,omitempty"`
+	RecordedBy   string  `json:"recordedBy"`
+	RecordedAt   string  `json:"recordedAt"`
+}
+
+// Valuation values a quantity of a lot at the price index in force when an
+// event happened; Unpriced is set when the index had no price for the lot's
+// type yet. SalePrice is the price actually paid for sales.
+type Valuation struct {
+	WasteID      string  `json:"wasteId"`
+	Event        string  `json:"event"`
+	ProductType  string  `json:"productType"`
+	Quantity     float64 `json:"quantity"`
+	PricePerUnit float64 `json:"pricePerUnit"`
+	Currency     string  `json:"currency,omitempty"`
+	PriceDate    string  `json:"priceDate,omitempty"`
+	Value        float64 `json:"value"`
+	Unpriced     bool    `json:"unpriced,omitempty"`
+	SalePrice    float64 `json:"salePrice,omitempty"`
+	Details      string  `json:"details,omitempty"`
+	TxID         string  `json:"txId"`
+	Timestamp    string  `json:"timestamp"`
+}
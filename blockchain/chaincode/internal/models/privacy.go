@@ -0,0 +1,31 @@
+package models
+
+// Participant links a pseudonymous participant ID to the person behind it
+type Participant struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	MSP       string   `json:"msp"`
+	WasteIDs  []string `json:"wasteIds"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// WastePII is the personal data of a waste lot kept off the world state
+type WastePII struct {
+	WasteID       string `json:"wasteId"`
+	ParticipantID string `json:"participantId"`
+	Owner         string `json:"owner"`
+	Farm          string `json:"farm,omitempty"`
+	Location      string `json:"location,omitempty"`
+}
+
+// ErasureReceipt is the public proof that a participant's personal data was
+// erased; it names no personal data itself
+type ErasureReceipt struct {
+	ParticipantID string   `json:"participantId"`
+	MSP           string   `json:"msp"`
+	WasteIDs      []string `json:"wasteIds"`
+	Reason        string   `json:"reason"`
+	RequestedBy   string   `json:"requestedBy"`
+	ErasedAt      string   `json:"erasedAt"`
+	TxID          string   `json:"txId,omitempty"`
+}
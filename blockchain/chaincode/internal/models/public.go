@@ -0,0 +1,25 @@
+package models
+
+// PublicFigures aggregates the lots of a group (or of the whole network);
+// quantities are in tonnes, CO2e avoided in tonnes of CO2 equivalent
+type PublicFigures struct {
+	Group           string  `json:"group,omitempty"`
+	Farms           int     `json:"farms"`
+	Lots            int     `json:"lots"`
+	TonnesRecorded  float64 `json:"tonnesRecorded"`
+	TonnesValorized float64 `json:"tonnesValorized"`
+	CO2eAvoided     float64 `json:"co2eAvoided"`
+}
+
+// PublicStatistics are the network figures safe to publish: every group
+// covers at least MinGroupSize farms, smaller ones are merged into OTHER,
+// and totals are left out when the network itself is smaller
+type PublicStatistics struct {
+	GeneratedAt  string           `json:"generatedAt"`
+	GroupBy      string           `json:"groupBy"`
+	MinGroupSize int              `json:"minGroupSize"`
+	Totals       *PublicFigures   `json:"totals,omitempty"`
+	Groups       []*PublicFigures `json:"groups"`
+	// Groups merged into OTHER for covering too few farms
+	MergedGroups int `json:"mergedGroups"`
+}
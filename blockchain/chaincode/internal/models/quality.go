@@ -0,0 +1,69 @@
+package models
+
+// Severity levels of a lot's data quality, from its score
+const (
+	QualityHigh   = "HIGH"
+	QualityMedium = "MEDIUM"
+	QualityLow    = "LOW"
+)
+
+// Lot fields the completeness rules check; farm, location and plotId are
+// the validation-profile fields
+const FieldHarvestDate = "harvestDate"
+
+// QualityIssue is a completeness rule a lot fails
+type QualityIssue struct {
+	Field string `json:"field"`
+	// MISSING or INVALID
+	Problem string `json:"problem"`
+	Weight  int    `json:"weight"`
+	// Set when the owner's validation profile now requires the field
+	Required bool `json:"required,omitempty"`
+}
+
+// WasteQuality is the completeness of one lot. Score adds up the weights of
+// the rules it fails, from 0 (complete) to 100.
+type WasteQuality struct {
+	WasteID   string         `json:"wasteId"`
+	OwnerMSP  string         `json:"ownerMsp"`
+	Status    string         `json:"status"`
+	CreatedAt string         `json:"createdAt"`
+	Score     int            `json:"score"`
+	Severity  string         `json:"severity,omitempty"`
+	Missing   []string       `json:"missing"`
+	Issues    []QualityIssue `json:"issues"`
+}
+
+// DataQualityReport lists the incomplete lots the caller may see, worst
+// first, with totals per field and severity
+type DataQualityReport struct {
+	Checked    int             `json:"checked"`
+	Incomplete int             `json:"incomplete"`
+	ByField    map[string]int  `json:"byField"`
+	BySeverity map[string]int  `json:"bySeverity"`
+	Records    []*WasteQuality `json:"records"`
+}
+
+// WasteEnrichment holds the fields to backfill on a lot; fields already set
+// are never overwritten
+type WasteEnrichment struct {
+	WasteID     string `json:"wasteId"`
+	Farm        string `json:"farm,omitempty"`
+	Location    string `json:"location,omitempty"`
+	HarvestDate string `json:"harvestDate,omitempty"`
+	PlotID      string `json:"plotId,omitempty"`
+}
+
+// EnrichmentFailure is a lot a bulk enrichment could not update
+type EnrichmentFailure struct {
+	WasteID string `json:"wasteId"`
+	Error   string `json:"error"`
+}
+
+// EnrichmentResult reports a bulk enrichment: the fields added per lot, the
+// lots left as they were, and their new quality
+type EnrichmentResult struct {
+	Enriched map[string][]string `json:"enriched"`
+	Failed   []EnrichmentFailure `json:"failed"`
+	Quality  []*WasteQuality     `json:"quality"`
+}
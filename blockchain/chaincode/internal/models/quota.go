@@ -0,0 +1,26 @@
+package models
+
+// What happens to a lot that takes its farm past its seasonal quota
+const (
+	QuotaFlag  = "FLAG"
+	QuotaBlock = "BLOCK"
+)
+
+// FarmQuota bounds the quantity a farm may plausibly declare in a season,
+// derived from its area and the expected yield of its crop unless set
+// outright. Declared and Lots track the lots recorded against it so far.
+type FarmQuota struct {
+	Farm       string    `json:"farm"`
+	Season     string    `json:"season"`
+	Crop       string    `json:"crop,omitempty"`
+	AreaHa     float64   `json:"areaHa,omitempty"`
+	YieldPerHa float64   `json:"yieldPerHa,omitempty"`
+	Quota      float64   `json:"quota"`
+	WarnRatio  float64   `json:"warnRatio"`
+	Policy     string    `json:"policy"`
+	Declared   float64   `json:"declared"`
+	Lots       int       `json:"lots"`
+	CreatedAt  string    `json:"createdAt"`
+	UpdatedAt  string    `json:"updatedAt"`
+	History    []History `json:"history"`
+}
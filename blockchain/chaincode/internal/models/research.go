@@ -0,0 +1,24 @@
+package models
+
+// ResearchConsent records whether a participant agrees to its lots appearing
+// in anonymized research datasets; without a record it does not
+type ResearchConsent struct {
+	ParticipantID string    `json:"participantId"`
+	Granted       bool      `json:"granted"`
+	UpdatedBy     string    `json:"updatedBy"`
+	UpdatedAt     string    `json:"updatedAt"`
+	History       []History `json:"history"`
+}
+
+// DatasetExport is the public record of an anonymized dataset handed to
+// researchers; ContentHash is the sha256 of the file exactly as delivered
+type DatasetExport struct {
+	ID          string   `json:"id"`
+	Requester   string   `json:"requester"`
+	Purpose     string   `json:"purpose"`
+	RecordCount int      `json:"recordCount"`
+	Fields      []string `json:"fields"`
+	ContentHash string   `json:"contentHash"`
+	ExportedBy  string   `json:"exportedBy"`
+	ExportedAt  string   `json:"exportedAt"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// ResidencyRegion pins the personal and commercial data of participants of
+// some organizations to a private data collection held by the peers of those
+// organizations only. Readers are organizations of other regions allowed to
+// assemble full views of the region's lots on its peers.
+type ResidencyRegion struct {
+	Code       string    `json:"code"`
+	Label      string    `json:"label,omitempty"`
+	Collection string    `json:"collection"`
+	MSPs       []string  `json:"msps"`
+	Readers    []string  `json:"readers"`
+	Version    int       `json:"version"`
+	CreatedAt  string    `json:"createdAt"`
+	UpdatedAt  string    `json:"updatedAt"`
+	History    []History `json:"history"`
+}
+
+// ResidencyPin records in the world state which collection holds the private
+// data of a participant; it names no personal data itself. Participants
+// without a pin live in the shared collection.
+type ResidencyPin struct {
+	ParticipantID string `json:"participantId"`
+	Region        string `json:"region"`
+	Collection    string `json:"collection"`
+	PinnedAt      string `json:"pinnedAt"`
+}
+
+// WasteFullView is a lot with the personal data kept in the collection it is
+// pinned to. When the evaluating peer does not hold that collection the view
+// is not resolved and Hosts lists the organizations whose peers can
+// assemble it.
+type WasteFullView struct {
+	Waste        *Waste    `json:"waste"`
+	PersonalData *WastePII `json:"personalData,omitempty"`
+	Region       string    `json:"region,omitempty"`
+	Collection   string    `json:"collection"`
+	Resolved     bool      `json:"resolved"`
+	Hosts        []string  `json:"hosts,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+// Validation rule severities: a REJECT rule fails the write, a WARN rule
+// records a warning on the lot (WASTE rules only)
+const (
+	RuleReject = "REJECT"
+	RuleWarn   = "WARN"
+)
+
+// WarnRulePrefix starts the warning code of a WARN rule, followed by the
+// rule ID
+const WarnRulePrefix = "RULE_"
+
+// ValidationRule is an admin-defined boolean expression (see package expr)
+// every write of an asset type must satisfy. The asset is bound to a
+// variable named after its type (waste, extraction, recycling), along with
+// now (the transaction time) and mspId (the submitter's organization).
+type ValidationRule struct {
+	ID         string    `json:"id"`
+	AssetType  string    `json:"assetType"`
+	Expression string    `json:"expression"`
+	Message    string    `json:"message"`
+	Severity   string    `json:"severity"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  string    `json:"createdAt"`
+	UpdatedAt  string    `json:"updatedAt"`
+	History    []History `json:"history"`
+}
+
+// RuleEvaluation is the outcome of a test evaluation of an expression;
+// Result is the JSON encoding of the value it yields
+type RuleEvaluation struct {
+	AssetType  string `json:"assetType"`
+	Expression string `json:"expression"`
+	Result     string `json:"result,omitempty"`
+	Passed     bool   `json:"passed"`
+	Error      string `json:"error,omitempty"`
+}
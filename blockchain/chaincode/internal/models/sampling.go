@@ -0,0 +1,108 @@
+package models
+
+// WasteUnderAudit is the status of a lot selected for physical inspection
+const WasteUnderAudit = "UNDER_AUDIT"
+
+// Audit sample statuses
+const (
+	SampleOpen      = "OPEN"
+	SampleCompleted = "COMPLETED"
+)
+
+// SampleSelectionMethod describes how samples are drawn so that anyone can
+// recompute a selection from the population and the transaction ID
+const SampleSelectionMethod = "lots ranked by SHA-256(txId + \"|\" + wasteId), lowest first"
+
+// CertifierAssignmentMethod describes how sampled lots are given to
+// certifiers so that anyone can recompute an assignment from the seed and
+// the certifiers' capacities
+const CertifierAssignmentMethod = "lowest -ln(u) / capacity among active certifiers, u from SHA-256(seed + \"|\" + wasteId + \"|\" + certifierId)"
+
+// AuditPopulation filters the lots a sample is drawn from; empty fields
+// match every lot and harvest dates are YYYY-MM-DD, inclusive
+type AuditPopulation struct {
+	Type        string `json:"type,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Region      string `json:"region,omitempty"`
+	OwnerMSP    string `json:"ownerMsp,omitempty"`
+	HarvestFrom string `json:"harvestFrom,omitempty"`
+	HarvestTo   string `json:"harvestTo,omitempty"`
+}
+
+// AuditSample is a random selection of lots for physical inspection. The
+// seed is the ID of the transaction that drew it; Population lists every
+// eligible lot so the draw can be verified.
+type AuditSample struct {
+	ID          string          `json:"id"`
+	Filter      AuditPopulation `json:"filter"`
+	Population  []string        `json:"population"`
+	Size        int             `json:"size"`
+	Seed        string          `json:"seed"`
+	Method      string          `json:"method"`
+	Assignment  string          `json:"assignment,omitempty"`
+	Lots        []SampledLot    `json:"lots"`
+	Status      string          `json:"status"`
+	SelectedBy  string          `json:"selectedBy"`
+	CreatedAt   string          `json:"createdAt"`
+	CompletedAt string          `json:"completedAt,omitempty"`
+	History     []History       `json:"history"`
+}
+
+// SampledLot is a selected lot, the status it returns to once inspected,
+// the certifier assigned to inspect it and the inspection outcome
+type SampledLot struct {
+	WasteID        string              `json:"wasteId"`
+	PreviousStatus string              `json:"previousStatus"`
+	AssignedTo     string              `json:"assignedTo,omitempty"`
+	AssignedAt     string              `json:"assignedAt,omitempty"`
+	Declines       []AssignmentDecline `json:"declines,omitempty"`
+	Inspected      bool                `json:"inspected"`
+	Passed         bool                `json:"passed,omitempty"`
+	Notes          string              `json:"notes,omitempty"`
+	InspectedBy    string              `json:"inspectedBy,omitempty"`
+	InspectedAt    string              `json:"inspectedAt,omitempty"`
+}
+
+// AssignmentDecline is a certifier turning down the inspection of a lot
+type AssignmentDecline struct {
+	CertifierID string `json:"certifierId"`
+	Reason      string `json:"reason"`
+	DeclinedAt  string `json:"declinedAt"`
+}
+
+// Certifier is an inspector of a certification body taking audit sample
+// lots; its declared capacity weighs its share of the assignments
+type Certifier struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Identity     string    `json:"identity"`
+	MSP          string    `json:"msp"`
+	Capacity     int       `json:"capacity"`
+	Active       bool      `json:"active"`
+	RegisteredAt string    `json:"registeredAt"`
+	UpdatedAt    string    `json:"updatedAt"`
+	History      []History `json:"history"`
+}
+
+// AuditAssignment is a sampled lot awaiting a certifier's inspection
+type AuditAssignment struct {
+	SampleID   string `json:"sampleId"`
+	WasteID    string `json:"wasteId"`
+	AssignedAt string `json:"assignedAt"`
+}
+
+// CertifierWorkload is what a certifier has been given: open assignments
+// against its capacity, and the lots it inspected or declined
+type CertifierWorkload struct {
+	CertifierID string            `json:"certifierId"`
+	Name        string            `json:"name"`
+	MSP         string            `json:"msp"`
+	Capacity    int               `json:"capacity"`
+	Active      bool              `json:"active"`
+	Open        int               `json:"open"`
+	Inspected   int               `json:"inspected"`
+	Declined    int               `json:"declined"`
+	Utilization float64           `json:"utilization"`
+	Assignments []AuditAssignment `json:"assignments"`
+}
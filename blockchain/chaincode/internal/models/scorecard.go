@@ -0,0 +1,21 @@
+package models
+
+// ParticipantScorecard rates an organization over a period from what the
+// ledger recorded about it. Deliveries count the supply-contract deliveries
+// it made and the lots it processed under an SLA; a delivery is late when
+// it drew a lateness penalty or breached its SLA. Grades are scored from 1
+// (worst) to the number of grades (best).
+type ParticipantScorecard struct {
+	Participant        string  `json:"participant"`
+	From               string  `json:"from"`
+	To                 string  `json:"to"`
+	Deliveries         int     `json:"deliveries"`
+	OnTimeDeliveries   int     `json:"onTimeDeliveries"`
+	OnTimeDeliveryRate float64 `json:"onTimeDeliveryRate"`
+	GradedRuns         int     `json:"gradedRuns"`
+	AverageGradeScore  float64 `json:"averageGradeScore"`
+	AverageGrade       string  `json:"averageGrade,omitempty"`
+	Disputes           int     `json:"disputes"`
+	SLABreaches        int     `json:"slaBreaches"`
+	ComputedAt         string  `json:"computedAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// BreachRule downgrades a lot when its temperature stays above MaxTemperature
+// for at least MaxDurationMinutes
+type BreachRule struct {
+	Name               string  `json:"name"`
+	MaxTemperature     float64 `json:"maxTemperature"`
+	MaxDurationMinutes int     `json:"maxDurationMinutes"`
+	DowngradeSteps     int     `json:"downgradeSteps"`
+}
+
+// SensorReading is a single temperature measurement for a lot
+type SensorReading struct {
+	SensorID    string  `json:"sensorId"`
+	Temperature float64 `json:"temperature"`
+	RecordedAt  string  `json:"recordedAt"`
+}
+
+// SensorLog holds the readings of a lot and the state of each breach rule
+type SensorLog struct {
+	AssetType     string            `json:"assetType"`
+	AssetID       string            `json:"assetId"`
+	Readings      []SensorReading   `json:"readings"`
+	BreachStarted map[string]string `json:"breachStarted"`
+	Triggered     map[string]bool   `json:"triggered"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// Token settlement statuses. A RELEASING settlement was closed but the token
+// chaincode has not released its hold yet; maintenance runs retry it.
+const (
+	SettlementPrepared  = "PREPARED"
+	SettlementConfirmed = "CONFIRMED"
+	SettlementReleasing = "RELEASING"
+	SettlementReleased  = "RELEASED"
+)
+
+// TokenSettlement pays for a share of a lot with tokens of an external token
+// chaincode in two phases: prepare holds the buyer's funds and locks the lot,
+// confirm captures the funds and transfers the share in one transaction.
+// Settlements not confirmed by ExpiresAt release both.
+type TokenSettlement struct {
+	ID             string         `json:"id"`
+	WasteID        string         `json:"wasteId"`
+	Transfer       *ShareTransfer `json:"transfer"`
+	Amount         float64        `json:"amount"`
+	TokenChaincode string         `json:"tokenChaincode"`
+	// Hold reference in the token chaincode
+	HoldID          string    `json:"holdId"`
+	Status          string    `json:"status"`
+	Reason          string    `json:"reason,omitempty"`
+	ReleaseAttempts int       `json:"releaseAttempts,omitempty"`
+	LastError       string    `json:"lastError,omitempty"`
+	RequestedBy     string    `json:"requestedBy"`
+	ExpiresAt       string    `json:"expiresAt"`
+	ClosedAt        string    `json:"closedAt,omitempty"`
+	CreatedAt       string    `json:"createdAt"`
+	UpdatedAt       string    `json:"updatedAt"`
+	History         []History `json:"history"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// WasteSimulation is the would-be state of a waste write that was not committed
+type WasteSimulation struct {
+	Waste    *Waste   `json:"waste"`
+	Warnings []string `json:"warnings"`
+}
+
+// ExtractionSimulation is the would-be result of CreateExtraction
+type ExtractionSimulation struct {
+	Extraction *Extraction `json:"extraction"`
+	Waste      *Waste      `json:"waste"`
+	Warnings   []string    `json:"warnings"`
+}
+
+// RecyclingSimulation is the would-be result of CreateRecycling
+type RecyclingSimulation struct {
+	Recycling *Recycling `json:"recycling"`
+	Waste     *Waste     `json:"waste"`
+	Warnings  []string   `json:"warnings"`
+}
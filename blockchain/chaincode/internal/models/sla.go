@@ -0,0 +1,53 @@
+package models
+
+// What a processing SLA applies to
+const (
+	SLAScopeContract = "CONTRACT"
+	SLAScopeFacility = "FACILITY"
+)
+
+// SLA statuses of a received lot
+const (
+	SLAPending  = "PENDING"
+	SLAMet      = "MET"
+	SLABreached = "BREACHED"
+)
+
+// SLA is a processor's promise to process the lots it receives under a
+// supply contract or at a facility within MaxDays
+type SLA struct {
+	ID        string    `json:"id"`
+	Scope     string    `json:"scope"`
+	ScopeID   string    `json:"scopeId"`
+	Processor string    `json:"processor"`
+	MaxDays   int       `json:"maxDays"`
+	CreatedAt string    `json:"createdAt"`
+	UpdatedAt string    `json:"updatedAt"`
+	History   []History `json:"history"`
+}
+
+// SLAStatus tracks a received lot against the SLA it fell under; DueAt is
+// when processing must have started
+type SLAStatus struct {
+	SLAID       string `json:"slaId"`
+	Processor   string `json:"processor"`
+	ReceivedAt  string `json:"receivedAt"`
+	DueAt       string `json:"dueAt"`
+	Status      string `json:"status"`
+	BreachedAt  string `json:"breachedAt,omitempty"`
+	CompletedAt string `json:"completedAt,omitempty"`
+}
+
+// SLACompliance summarizes how a processor kept its SLAs for the lots it
+// received in a period
+type SLACompliance struct {
+	Processor         string  `json:"processor"`
+	From              string  `json:"from"`
+	To                string  `json:"to"`
+	Received          int     `json:"received"`
+	Met               int     `json:"met"`
+	Breached          int     `json:"breached"`
+	Pending           int     `json:"pending"`
+	ComplianceRate    float64 `json:"complianceRate"`
+	AvgTurnaroundDays float64 `json:"avgTurnaroundDays"`
+}
@@ -0,0 +1,59 @@
+package models
+
+// Snapshot statuses
+const (
+	SnapshotInProgress = "IN_PROGRESS"
+	SnapshotComplete   = "COMPLETE"
+)
+
+// Snapshot is a Merkle digest of the world state built one page of keys per
+// invocation; Root is set once every key has been hashed. BlockHeight is the
+// block that committed the completing transaction, recorded afterwards by the
+// backend since chaincode cannot observe block numbers.
+type Snapshot struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	Cursor      string `json:"cursor,omitempty"`
+	LeafCount   int    `json:"leafCount"`
+	Chunks      int    `json:"chunks"`
+	Root        string `json:"root,omitempty"`
+	StartedAt   string `json:"startedAt"`
+	StartedBy   string `json:"startedBy"`
+	CompletedAt string `json:"completedAt,omitempty"`
+	TxID        string `json:"txId,omitempty"`
+	BlockHeight uint64 `json:"blockHeight,omitempty"`
+}
+
+// SnapshotLeaf is the hash of one world-state entry: sha256 of
+// 0x00 || key || 0x00 || sha256(value), hex encoded
+type SnapshotLeaf struct {
+	Key  string `json:"key"`
+	Hash string `json:"hash"`
+}
+
+// SnapshotChunk holds the leaves hashed by one invocation, in key order
+type SnapshotChunk struct {
+	SnapshotID string         `json:"snapshotId"`
+	Index      int            `json:"index"`
+	Leaves     []SnapshotLeaf `json:"leaves"`
+}
+
+// ProofStep is a sibling hash on the path from a leaf to the root; Left tells
+// whether the sibling is hashed on the left
+type ProofStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"`
+}
+
+// SnapshotProof proves that the leaf of a key is part of a snapshot's Merkle
+// tree; verifiers recompute LeafHash from the asset value they hold
+type SnapshotProof struct {
+	SnapshotID  string      `json:"snapshotId"`
+	Key         string      `json:"key"`
+	LeafHash    string      `json:"leafHash"`
+	Index       int         `json:"index"`
+	LeafCount   int         `json:"leafCount"`
+	Path        []ProofStep `json:"path"`
+	Root        string      `json:"root"`
+	BlockHeight uint64      `json:"blockHeight,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// StatusReasonCatalog lists the reason codes a waste lot moving to status
+// Transition may be given (e.g. REJECTED_QUALITY, REJECTED_MOISTURE for
+// REJECTED); a Required catalog marks a negative transition, refused
+// without one of its codes
+type StatusReasonCatalog struct {
+	Transition string         `json:"transition"`
+	Required   bool           `json:"required"`
+	Reasons    []StatusReason `json:"reasons"`
+	Version    int            `json:"version"`
+	CreatedAt  string         `json:"createdAt"`
+	UpdatedAt  string         `json:"updatedAt"`
+	History    []History      `json:"history"`
+}
+
+// StatusReason is a code of a catalog; codes are unique across catalogs
+type StatusReason struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+}
+
+// StatusReasonCount totals the status changes given one reason code
+type StatusReasonCount struct {
+	Transition string  `json:"transition"`
+	ReasonCode string  `json:"reasonCode"`
+	Label      string  `json:"label"`
+	Count      int     `json:"count"`
+	Quantity   float64 `json:"quantity"`
+}
+
+// StatusReasonStatistics counts the status changes made between two dates
+// by reason code; Unexplained are those given no code
+type StatusReasonStatistics struct {
+	From        string               `json:"from"`
+	To          string               `json:"to"`
+	Changes     int                  `json:"changes"`
+	Unexplained int                  `json:"unexplained"`
+	ByReason    []*StatusReasonCount `json:"byReason"`
+}
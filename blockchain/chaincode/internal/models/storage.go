@@ -0,0 +1,91 @@
+package models
+
+import "encoding/json"
+
+// StorageSite is a depot where lots wait between collection and processing;
+// MinTempC and MaxTempC give the controlled temperature range when
+// TemperatureControlled is set
+type StorageSite struct {
+	ID                    string      `json:"id"`
+	Name                  string      `json:"name"`
+	Operator              string      `json:"operator"`
+	Location              string      `json:"location,omitempty"`
+	Capacity              float64     `json:"capacity"`
+	TemperatureControlled bool        `json:"temperatureControlled,omitempty"`
+	MinTempC              float64     `json:"minTempC,omitempty"`
+	MaxTempC              float64     `json:"maxTempC,omitempty"`
+	CurrentStock          float64     `json:"currentStock"`
+	Lots                  []StoredLot `json:"lots"`
+	Status                string      `json:"status"`
+	CreatedAt             string      `json:"createdAt"`
+	UpdatedAt             string      `json:"updatedAt"`
+	History               []History   `json:"history"`
+}
+
+// MarshalJSON writes the temperature range of a controlled site even when a
+// bound is 0 °C
+func (s StorageSite) MarshalJSON() ([]byte, error) {
+	type plain StorageSite
+	fields := struct {
+		plain
+		MinTempC *float64 `json:"minTempC,omitempty"`
+		MaxTempC *float64 `json:"maxTempC,omitempty"`
+	}{plain: plain(s)}
+	if s.TemperatureControlled {
+		fields.MinTempC, fields.MaxTempC = &s.MinTempC, &s.MaxTempC
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON sets TemperatureControlled for sites recorded with a range
+// before the flag existed
+func (s *StorageSite) UnmarshalJSON(data []byte) error {
+	type plain StorageSite
+	var fields struct {
+		plain
+		MinTempC *float64 `json:"minTempC"`
+		MaxTempC *float64 `json:"maxTempC"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*s = StorageSite(fields.plain)
+	if fields.MinTempC != nil && fields.MaxTempC != nil {
+		s.MinTempC, s.MaxTempC, s.TemperatureControlled = *fields.MinTempC, *fields.MaxTempC, true
+	}
+
+	return nil
+}
+
+// StoredLot is the quantity of a lot currently held at a storage site
+type StoredLot struct {
+	WasteID     string  `json:"wasteId"`
+	Quantity    float64 `json:"quantity"`
+	CheckedInAt string  `json:"checkedInAt"`
+}
+
+// StorageMovement is the result of a check-in or check-out, with warnings
+// such as the site running over capacity
+type StorageMovement struct {
+	SiteID     string   `json:"siteId"`
+	WasteID    string   `json:"wasteId"`
+	Direction  string   `json:"direction"`
+	Quantity   float64  `json:"quantity"`
+	StockAfter float64  `json:"stockAfter"`
+	Warnings   []string `json:"warnings"`
+}
+
+// SiteOccupancy summarizes the stock of a storage site for planners
+type SiteOccupancy struct {
+	SiteID        string  `json:"siteId"`
+	Name          string  `json:"name"`
+	Operator      string  `json:"operator"`
+	Capacity      float64 `json:"capacity"`
+	CurrentStock  float64 `json:"currentStock"`
+	Available     float64 `json:"available"`
+	OccupancyRate float64 `json:"occupancyRate"`
+	LotCount      int     `json:"lotCount"`
+	OverCapacity  bool    `json:"overCapacity"`
+}
@@ -0,0 +1,106 @@
+package models
+
+// SubsidyCondition is one requirement of a subsidy scheme: a boolean
+// expression (see package expr) over the lot and the facts gathered about
+// it, e.g. facts.daysToRecycling >= 0 && facts.daysToRecycling <= 30
+type SubsidyCondition struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Expression  string `json:"expression"`
+}
+
+// SubsidyScheme is a government valorization subsidy: the conditions a lot
+// must meet, the lots' harvest period it covers (YYYY-MM-DD, inclusive,
+// open when empty) and the amount paid per unit of an eligible lot
+type SubsidyScheme struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Agency      string             `json:"agency"`
+	Conditions  []SubsidyCondition `json:"conditions"`
+	RatePerUnit float64            `json:"ratePerUnit"`
+	Currency    string             `json:"currency"`
+	ValidFrom   string             `json:"validFrom,omitempty"`
+	ValidTo     string             `json:"validTo,omitempty"`
+	Active      bool               `json:"active"`
+	Version     int                `json:"version"`
+	CreatedAt   string             `json:"createdAt"`
+	UpdatedAt   string             `json:"updatedAt"`
+	History     []History          `json:"history"`
+}
+
+// SubsidyFacts is what the ledger knows about a lot's valorization, bound to
+// the variable facts in scheme conditions. DaysToRecycling counts whole days
+// from the lot's registration to its first recycling, -1 when it was not
+// recycled.
+type SubsidyFacts struct {
+	DaysToRecycling        int      `json:"daysToRecycling"`
+	RecycledQuantity       float64  `json:"recycledQuantity"`
+	RecyclingMethods       []string `json:"recyclingMethods"`
+	Extracted              bool     `json:"extracted"`
+	FacilityCertifications []string `json:"facilityCertifications"`
+	DocumentTypes          []string `json:"documentTypes"`
+	CertificateIssued      bool     `json:"certificateIssued"`
+}
+
+// SubsidyConditionResult is the outcome of one scheme condition for a lot
+type SubsidyConditionResult struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Passed      bool   `json:"passed"`
+	Error       string `json:"error,omitempty"`
+}
+
+// SubsidyEligibility is the latest evaluation of a lot against a scheme;
+// Reasons give the conditions it failed
+type SubsidyEligibility struct {
+	SchemeID      string                   `json:"schemeId"`
+	SchemeVersion int                      `json:"schemeVersion"`
+	WasteID       string                   `json:"wasteId"`
+	Reference     string                   `json:"reference,omitempty"`
+	Owner         string                   `json:"owner"`
+	OwnerMSP      string                   `json:"ownerMsp,omitempty"`
+	Quantity      float64                  `json:"quantity"`
+	Eligible      bool                     `json:"eligible"`
+	Reasons       []string                 `json:"reasons"`
+	Conditions    []SubsidyConditionResult `json:"conditions"`
+	Facts         SubsidyFacts             `json:"facts"`
+	Amount        float64                  `json:"amount"`
+	Currency      string                   `json:"currency"`
+	EvaluatedBy   string                   `json:"evaluatedBy"`
+	EvaluatedAt   string                   `json:"evaluatedAt"`
+}
+
+// SubsidyFlag is the eligibility of a lot for a scheme as kept on the lot
+type SubsidyFlag struct {
+	SchemeID    string   `json:"schemeId"`
+	Eligible    bool     `json:"eligible"`
+	Reasons     []string `json:"reasons,omitempty"`
+	EvaluatedAt string   `json:"evaluatedAt"`
+}
+
+// SubsidyClaimLine is an eligible lot claimed from the paying agency
+type SubsidyClaimLine struct {
+	WasteID     string  `json:"wasteId"`
+	Reference   string  `json:"reference,omitempty"`
+	Owner       string  `json:"owner"`
+	OwnerMSP    string  `json:"ownerMsp,omitempty"`
+	HarvestDate string  `json:"harvestDate"`
+	Quantity    float64 `json:"quantity"`
+	Amount      float64 `json:"amount"`
+	EvaluatedAt string  `json:"evaluatedAt"`
+}
+
+// SubsidyClaimExport lists the lots found eligible for a scheme over a
+// period of evaluation dates, for the paying agency
+type SubsidyClaimExport struct {
+	SchemeID      string             `json:"schemeId"`
+	SchemeName    string             `json:"schemeName"`
+	Agency        string             `json:"agency"`
+	Currency      string             `json:"currency"`
+	From          string             `json:"from,omitempty"`
+	To            string             `json:"to,omitempty"`
+	Lines         []SubsidyClaimLine `json:"lines"`
+	TotalQuantity float64            `json:"totalQuantity"`
+	TotalAmount   float64            `json:"totalAmount"`
+	GeneratedAt   string             `json:"generatedAt"`
+}
@@ -0,0 +1,134 @@
+package models
+
+// Supply contract statuses
+const (
+	SupplyProposed  = "PROPOSED"
+	SupplyActive    = "ACTIVE"
+	SupplyFulfilled = "FULFILLED"
+)
+
+// Price formula kinds: a fixed unit price, or the price index of the product
+// on the delivery date plus a premium (negative for a discount)
+const (
+	PriceFixed = "FIXED"
+	PriceIndex = "INDEX"
+)
+
+// PriceFormula sets the unit price of the deliveries under a supply contract
+type PriceFormula struct {
+	Kind      string  `json:"kind"`
+	BasePrice float64 `json:"basePrice,omitempty"`
+	Premium   float64 `json:"premium,omitempty"`
+	Currency  string  `json:"currency"`
+}
+
+// SupplyContract is a standing agreement for a supplier (farmer) to deliver
+// a committed volume of a product to a buyer (processor) over a period
+type SupplyContract struct {
+	ID               string             `json:"id"`
+	Supplier         string             `json:"supplier"`
+	Buyer            string             `json:"buyer"`
+	ProductType      string             `json:"productType"`
+	CommittedVolume  float64            `json:"committedVolume"`
+	FulfilledVolume  float64            `json:"fulfilledVolume"`
+	Price            PriceFormula       `json:"price"`
+	StartDate        string             `json:"startDate"`
+	EndDate          string             `json:"endDate"`
+	Status           string             `json:"status"`
+	Rules            []AdjustmentRule   `json:"rules,omitempty"`
+	Deliveries       []ContractDelivery `json:"deliveries"`
+	AtRiskNotifiedAt string             `json:"atRiskNotifiedAt,omitempty"`
+	ProposedBy       string             `json:"proposedBy"`
+	CreatedAt        string             `json:"createdAt"`
+	UpdatedAt        string             `json:"updatedAt"`
+	History          []History          `json:"history"`
+}
+
+// Adjustment rule kinds and triggers
+const (
+	AdjustmentBonus   = "BONUS"
+	AdjustmentPenalty = "PENALTY"
+
+	TriggerGrade = "GRADE"
+	TriggerLate  = "LATE"
+)
+
+// Adjustment rule bases: an amount per unit delivered, or a percentage of the
+// delivery amount
+const (
+	BasisPerUnit = "PER_UNIT"
+	BasisPercent = "PERCENT"
+)
+
+// AdjustmentRule is a bonus or penalty clause of a supply contract, applied
+// to each delivery once its extraction is graded. A GRADE rule matches
+// deliveries graded Grade or better (bonus) or Grade or worse (penalty); a
+// LATE penalty applies Rate for each day the lot took beyond MaxDays from
+// harvest to delivery. Cap, when set, bounds the adjustment's absolute amount.
+type AdjustmentRule struct {
+	ID      string  `json:"id"`
+	Kind    string  `json:"kind"`
+	Trigger string  `json:"trigger"`
+	Grade   string  `json:"grade,omitempty"`
+	MaxDays int     `json:"maxDays,omitempty"`
+	Basis   string  `json:"basis"`
+	Rate    float64 `json:"rate"`
+	Cap     float64 `json:"cap,omitempty"`
+}
+
+// ContractAdjustment is an entry of a supply contract's adjustments ledger:
+// a bonus (positive) or penalty (negative) on one delivery. A regrade adds a
+// correcting entry for the difference rather than rewriting earlier ones.
+type ContractAdjustment struct {
+	ID           string  `json:"id"`
+	ContractID   string  `json:"contractId"`
+	RuleID       string  `json:"ruleId"`
+	Kind         string  `json:"kind"`
+	WasteID      string  `json:"wasteId"`
+	ExtractionID string  `json:"extractionId"`
+	Grade        string  `json:"grade,omitempty"`
+	DaysLate     int     `json:"daysLate,omitempty"`
+	Amount       float64 `json:"amount"`
+	Currency     string  `json:"currency"`
+	Reason       string  `json:"reason"`
+	CreatedAt    string  `json:"createdAt"`
+}
+
+// AdjustmentLedger lists a supply contract's adjustments, oldest first, with
+// their totals for invoicing
+type AdjustmentLedger struct {
+	ContractID  string               `json:"contractId"`
+	Currency    string               `json:"currency"`
+	Bonuses     float64              `json:"bonuses"`
+	Penalties   float64              `json:"penalties"`
+	Net         float64              `json:"net"`
+	Adjustments []ContractAdjustment `json:"adjustments"`
+}
+
+// ContractDelivery is a lot delivered under a supply contract; ExtractionID
+// is set once the lot is processed. Unpriced is set for index-priced
+// deliveries made before the index had a price.
+type ContractDelivery struct {
+	WasteID      string  `json:"wasteId"`
+	ExtractionID string  `json:"extractionId,omitempty"`
+	Quantity     float64 `json:"quantity"`
+	UnitPrice    float64 `json:"unitPrice"`
+	Amount       float64 `json:"amount"`
+	Unpriced     bool    `json:"unpriced,omitempty"`
+	DeliveredAt  string  `json:"deliveredAt"`
+}
+
+// CommitmentStatus reports how far a supply contract is from its commitment
+type CommitmentStatus struct {
+	ContractID      string  `json:"contractId"`
+	Supplier        string  `json:"supplier"`
+	Buyer           string  `json:"buyer"`
+	ProductType     string  `json:"productType"`
+	CommittedVolume float64 `json:"committedVolume"`
+	FulfilledVolume float64 `json:"fulfilledVolume"`
+	Shortfall       float64 `json:"shortfall"`
+	FulfilledRate   float64 `json:"fulfilledRate"`
+	EndDate         string  `json:"endDate"`
+	DaysLeft        int     `json:"daysLeft"`
+	AtRisk          bool    `json:"atRisk"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "strings"
+
+// Uncategorized groups wastes whose type maps to no taxonomy node
+const Uncategorized = "UNCATEGORIZED"
+
+// Taxonomy is the two-level waste classification (category -> subtype) and the
+// mapping of free-text waste types onto it
+type Taxonomy struct {
+	Categories []TaxonomyCategory `json:"categories"`
+	Mappings   map[string]string  `json:"mappings"`
+	UpdatedAt  string             `json:"updatedAt,omitempty"`
+	UpdatedBy  string             `json:"updatedBy,omitempty"`
+}
+
+// TaxonomyCategory is a top-level waste category
+type TaxonomyCategory struct {
+	Code     string            `json:"code"`
+	Label    string            `json:"label"`
+	Subtypes []TaxonomySubtype `json:"subtypes"`
+}
+
+// TaxonomySubtype is a waste subtype within a category
+type TaxonomySubtype struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+}
+
+// CategoryStatistics aggregates wastes of one taxonomy category
+type CategoryStatistics struct {
+	Category string             `json:"category"`
+	Label    string             `json:"label"`
+	Count    int                `json:"count"`
+	Quantity float64            `json:"quantity"`
+	Subtypes map[string]float64 `json:"subtypes"`
+}
+
+// Classify resolves a waste type given either as "CATEGORY/SUBTYPE" or as
+// mapped free text; unknown types are Uncategorized
+func (t *Taxonomy) Classify(wasteType string) (string, string) {
+	node := strings.TrimSpace(wasteType)
+	if mapped, ok := t.Mappings[NormalizeWasteType(wasteType)]; ok {
+		node = mapped
+	}
+
+	parts := strings.SplitN(node, "/", 2)
+	if len(parts) == 2 && t.HasNode(parts[0], parts[1]) {
+		return parts[0], parts[1]
+	}
+
+	return Uncategorized, ""
+}
+
+// Category returns the category with the given code, or nil if unknown
+func (t *Taxonomy) Category(code string) *TaxonomyCategory {
+	for i := range t.Categories {
+		if t.Categories[i].Code == code {
+			return &t.Categories[i]
+		}
+	}
+
+	return nil
+}
+
+// HasNode reports whether the category/subtype pair exists
+func (t *Taxonomy) HasNode(categoryCode string, subtypeCode string) bool {
+	category := t.Category(categoryCode)
+	if category == nil {
+		return false
+	}
+	for _, subtype := range category.Subtypes {
+		if subtype.Code == subtypeCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NormalizeWasteType lowercases free text and collapses whitespace
+func NormalizeWasteType(wasteType string) string {
+	return strings.Join(strings.Fields(strings.ToLower(wasteType)), " ")
+}
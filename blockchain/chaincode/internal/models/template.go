@@ -0,0 +1,30 @@
+package models
+
+// Units a waste template may enter quantities in; lots are recorded in
+// tonnes
+const (
+	UnitTonnes    = "t"
+	UnitKilograms = "kg"
+)
+
+// WasteTemplate holds the defaults a participant reuses when recording the
+// same kind of lot day after day during harvest. It names a farm and a
+// location, so it is kept in the participant's private collection.
+// DefaultQuantity is in Unit, which is also the unit of the quantities
+// entered when creating a lot from the template.
+type WasteTemplate struct {
+	Name            string       `json:"name"`
+	ParticipantID   string       `json:"participantId"`
+	Type            string       `json:"type"`
+	Unit            string       `json:"unit"`
+	DefaultQuantity float64      `json:"defaultQuantity,omitempty"`
+	Farm            string       `json:"farm,omitempty"`
+	Location        string       `json:"location,omitempty"`
+	PlotID          string       `json:"plotId,omitempty"`
+	QualityGrade    string       `json:"qualityGrade,omitempty"`
+	Composition     *Composition `json:"composition,omitempty"`
+	Uses            int          `json:"uses"`
+	LastUsedAt      string       `json:"lastUsedAt,omitempty"`
+	CreatedAt       string       `json:"createdAt"`
+	UpdatedAt       string       `json:"updatedAt"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// AssetRef identifies a linked asset without its history
+type AssetRef struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	Version      int    `json:"version"`
+	HistoryTotal int    `json:"historyTotal"`
+}
+
+// TraceabilityLite is a bounded-size traceability view: linked asset IDs and
+// statuses plus the most recent history entries of the waste
+type TraceabilityLite struct {
+	Waste         AssetRef   `json:"waste"`
+	Redacted      bool       `json:"redacted"`
+	CertificateID string     `json:"certificateId,omitempty"`
+	Extractions   []AssetRef `json:"extractions"`
+	Recyclings    []AssetRef `json:"recyclings"`
+	RecentEvents  []History  `json:"recentEvents"`
+}
+
+// HistorySegment is a page of an asset's history; NextOffset is -1 on the
+// last page
+type HistorySegment struct {
+	AssetType  string    `json:"assetType"`
+	ID         string    `json:"id"`
+	Offset     int       `json:"offset"`
+	Total      int       `json:"total"`
+	Entries    []History `json:"entries"`
+	NextOffset int       `json:"nextOffset"`
+}
@@ -0,0 +1,93 @@
+package models
+
+// Transport asset statuses
+const (
+	TransportActive  = "ACTIVE"
+	TransportRetired = "RETIRED"
+)
+
+// Shipment statuses
+const (
+	ShipmentInTransit = "IN_TRANSIT"
+	ShipmentDelivered = "DELIVERED"
+)
+
+// Results of checking a delivery position against the destination geofence
+const (
+	GeofenceInside     = "INSIDE"
+	GeofenceOverridden = "OVERRIDDEN"
+	GeofenceUndefined  = "NO_GEOFENCE"
+)
+
+// DeliveryCheck is where a delivery was confirmed and how that position
+// compared with the geofence of the destination facility (FacilityID is
+// empty when the destination is not a registered facility); deliveries
+// outside it are only accepted on an admin's override
+type DeliveryCheck struct {
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	FacilityID     string  `json:"facilityId,omitempty"`
+	DistanceMeters float64 `json:"distanceMeters,omitempty"`
+	RadiusMeters   float64 `json:"radiusMeters,omitempty"`
+	Result         string  `json:"result"`
+	OverriddenBy   string  `json:"overriddenBy,omitempty"`
+	OverrideReason string  `json:"overrideReason,omitempty"`
+}
+
+// Permit is a dated authorization held by a vehicle or driver, e.g. an ADR
+// certificate for dangerous goods or a waste carrier registration
+type Permit struct {
+	Kind      string `json:"kind"`
+	Number    string `json:"number"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// Vehicle is a truck registered by a carrier organization
+type Vehicle struct {
+	ID          string    `json:"id"`
+	Plate       string    `json:"plate"`
+	Type        string    `json:"type,omitempty"`
+	CapacityKg  float64   `json:"capacityKg,omitempty"`
+	OperatorMSP string    `json:"operatorMsp"`
+	Permits     []Permit  `json:"permits,omitempty"`
+	Status      string    `json:"status"`
+	CreatedAt   string    `json:"createdAt"`
+	UpdatedAt   string    `json:"updatedAt"`
+	History     []History `json:"history"`
+}
+
+// Driver is a driver employed by a carrier organization; the ID is the
+// carrier's own reference, so no name is kept on the ledger
+type Driver struct {
+	ID               string    `json:"id"`
+	LicenseNumber    string    `json:"licenseNumber"`
+	LicenseExpiresAt string    `json:"licenseExpiresAt"`
+	OperatorMSP      string    `json:"operatorMsp"`
+	Permits          []Permit  `json:"permits,omitempty"`
+	Status           string    `json:"status"`
+	CreatedAt        string    `json:"createdAt"`
+	UpdatedAt        string    `json:"updatedAt"`
+	History          []History `json:"history"`
+}
+
+// Shipment moves (part of) a lot with a registered vehicle and driver;
+// Flags lists the permits that had expired on the departure date
+type Shipment struct {
+	ID            string         `json:"id"`
+	WasteID       string         `json:"wasteId"`
+	VehicleID     string         `json:"vehicleId"`
+	Plate         string         `json:"plate"`
+	DriverID      string         `json:"driverId"`
+	CarrierMSP    string         `json:"carrierMsp"`
+	Quantity      float64        `json:"quantity"`
+	Origin        string         `json:"origin,omitempty"`
+	Destination   string         `json:"destination"`
+	DepartureDate string         `json:"departureDate"`
+	Status        string         `json:"status"`
+	Flagged       bool           `json:"flagged"`
+	Flags         []string       `json:"flags,omitempty"`
+	Delivery      *DeliveryCheck `json:"delivery,omitempty"`
+	CreatedAt     string         `json:"createdAt"`
+	UpdatedAt     string         `json:"updatedAt"`
+	History       []History      `json:"history"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// Codes of the non-blocking issues recorded on lots
+const (
+	WarnFarmMissing     = "FARM_MISSING"
+	WarnLocationMissing = "LOCATION_MISSING"
+	WarnTypeUnmapped    = "TYPE_NOT_IN_TAXONOMY"
+	WarnYieldUnusual    = "YIELD_UNUSUAL"
+	WarnQuotaNear       = "QUOTA_NEAR"
+	WarnQuotaExceeded   = "QUOTA_EXCEEDED"
+	WarnDuplicate       = "POSSIBLE_DUPLICATE"
+)
+
+// ValidationWarning is an issue found when a lot was recorded that did not
+// block the write; it stays open until someone resolves it
+type ValidationWarning struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	RaisedAt   string `json:"raisedAt"`
+	Resolved   bool   `json:"resolved"`
+	ResolvedBy string `json:"resolvedBy,omitempty"`
+	ResolvedAt string `json:"resolvedAt,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// Optional lot fields a validation profile can make mandatory
+const (
+	FieldFarm     = "farm"
+	FieldLocation = "location"
+	FieldPlot     = "plotId"
+)
+
+// ValidationProfile lists the optional fields an organization's new lots
+// must fill in
+type ValidationProfile struct {
+	OrgMSP         string    `json:"orgMsp"`
+	RequiredFields []string  `json:"requiredFields"`
+	CreatedAt      string    `json:"createdAt"`
+	UpdatedAt      string    `json:"updatedAt"`
+	History        []History `json:"history"`
+}
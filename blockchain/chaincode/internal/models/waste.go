@@ -0,0 +1,190 @@
+// Package models defines the assets stored by the waste chaincode and the
+// results its transactions return
+package models
+
+// Waste represents agricultural waste in the blockchain
+type Waste struct {
+	ID                  string                  `json:"id"`
+	Reference           string                  `json:"reference,omitempty"`
+	Type                string                  `json:"type"`
+	Category            string                  `json:"category,omitempty"`
+	Subtype             string                  `json:"subtype,omitempty"`
+	Quantity            float64                 `json:"quantity"`
+	Consumed            float64                 `json:"consumed,omitempty"`
+	Composition         *Composition            `json:"composition,omitempty"`
+	DryMatter           float64                 `json:"dryMatter,omitempty"`
+	HarvestDate         string                  `json:"harvestDate"`
+	Status              string                  `json:"status"`
+	Owner               string                  `json:"owner"`
+	ParticipantID       string                  `json:"participantId,omitempty"`
+	Owners              []OwnershipShare        `json:"owners,omitempty"`
+	OwnerMSP            string                  `json:"ownerMsp,omitempty"`
+	Farm                string                  `json:"farm,omitempty"`
+	Location            string                  `json:"location,omitempty"`
+	PlotID              string                  `json:"plotId,omitempty"`
+	Region              string                  `json:"region,omitempty"`
+	CreatedAt           string                  `json:"createdAt"`
+	UpdatedAt           string                  `json:"updatedAt"`
+	History             []History               `json:"history"`
+	ArchivedHistory     int                     `json:"archivedHistory,omitempty"`
+	Documents           []Document              `json:"documents,omitempty"`
+	CollectionRequestID string                  `json:"collectionRequestId,omitempty"`
+	CampaignID          string                  `json:"campaignId,omitempty"`
+	StorageSiteID       string                  `json:"storageSiteId,omitempty"`
+	SLA                 *SLAStatus              `json:"sla,omitempty"`
+	DispositionID       string                  `json:"dispositionId,omitempty"`
+	ExportHandoffID     string                  `json:"exportHandoffId,omitempty"`
+	Origin              *ExternalOrigin         `json:"origin,omitempty"`
+	CertificateID       string                  `json:"certificateId,omitempty"`
+	PendingApprovalID   string                  `json:"pendingApprovalId,omitempty"`
+	PendingSettlementID string                  `json:"pendingSettlementId,omitempty"`
+	EmbargoUntil        string                  `json:"embargoUntil,omitempty"`
+	Warnings            []ValidationWarning     `json:"warnings,omitempty"`
+	Sealed              map[string]*SealedField `json:"sealed,omitempty"`
+	QualityGrade        string                  `json:"qualityGrade,omitempty"`
+	Tags                []string                `json:"tags,omitempty"`
+	Subsidies           []SubsidyFlag           `json:"subsidies,omitempty"`
+	MergedInto          string                  `json:"mergedInto,omitempty"`
+	MergedFrom          []string                `json:"mergedFrom,omitempty"`
+	Version             int                     `json:"version"`
+}
+
+// Extraction represents the extraction process; ProductType and Quality
+// describe the primary output line and Quantity is the total of all outputs
+type Extraction struct {
+	ID               string             `json:"id"`
+	Reference        string             `json:"reference,omitempty"`
+	WasteID          string             `json:"wasteId"`
+	ProductType      string             `json:"productType"`
+	Quantity         float64            `json:"quantity"`
+	Quality          string             `json:"quality"`
+	QualityGrade     string             `json:"qualityGrade,omitempty"`
+	Grading          []GradeRecord      `json:"grading,omitempty"`
+	PendingRegrade   string             `json:"pendingRegrade,omitempty"`
+	Outputs          []ExtractionOutput `json:"outputs,omitempty"`
+	MassBalance      *MassBalance       `json:"massBalance,omitempty"`
+	ExtractionDate   string             `json:"extractionDate"`
+	Processor        string             `json:"processor"`
+	FacilityID       string             `json:"facilityId,omitempty"`
+	SupplyContractID string             `json:"supplyContractId,omitempty"`
+	Status           string             `json:"status"`
+	CreatedAt        string             `json:"createdAt"`
+	History          []History          `json:"history"`
+	ArchivedHistory  int                `json:"archivedHistory,omitempty"`
+	Version          int                `json:"version"`
+}
+
+// Recycling represents the recycling process
+type Recycling struct {
+	ID              string           `json:"id"`
+	Reference       string           `json:"reference,omitempty"`
+	WasteID         string           `json:"wasteId"`
+	RecycledProduct string           `json:"recycledProduct"`
+	Quantity        float64          `json:"quantity"`
+	Method          string           `json:"method"`
+	EmissionFactor  float64          `json:"emissionFactor,omitempty"`
+	CO2eAvoided     float64          `json:"co2eAvoided,omitempty"`
+	RecyclingDate   string           `json:"recyclingDate"`
+	Recycler        string           `json:"recycler"`
+	FacilityID      string           `json:"facilityId,omitempty"`
+	ExpectedEnd     string           `json:"expectedEnd,omitempty"`
+	ExtractionID    string           `json:"extractionId,omitempty"`
+	OutputLine      int              `json:"outputLine,omitempty"`
+	Inputs          []RecyclingInput `json:"inputs,omitempty"`
+	Status          string           `json:"status"`
+	CreatedAt       string           `json:"createdAt"`
+	History         []History        `json:"history"`
+	ArchivedHistory int              `json:"archivedHistory,omitempty"`
+	Version         int              `json:"version"`
+}
+
+// RecyclingInput is one lot blended into a recycling run
+type RecyclingInput struct {
+	WasteID  string  `json:"wasteId"`
+	Quantity float64 `json:"quantity"`
+}
+
+// InputLots returns the lots a recycling consumed; records made from a
+// single lot consumed their whole quantity from WasteID
+func (r *Recycling) InputLots() []RecyclingInput {
+	if len(r.Inputs) > 0 {
+		return r.Inputs
+	}
+
+	return []RecyclingInput{{WasteID: r.WasteID, Quantity: r.Quantity}}
+}
+
+// UsesWaste reports whether the lot is one of the recycling's inputs
+func (r *Recycling) UsesWaste(wasteID string) bool {
+	for _, input := range r.InputLots() {
+		if input.WasteID == wasteID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// History represents a change in the lifecycle; ReasonCode is set on status
+// changes given a code of the transition's reason catalog, Changes lists the
+// fields the update modified
+type History struct {
+	Timestamp  string        `json:"timestamp"`
+	Action     string        `json:"action"`
+	Actor      string        `json:"actor"`
+	Details    string        `json:"details"`
+	ReasonCode string        `json:"reasonCode,omitempty"`
+	Changes    []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is a field modified by an update, nested fields joined with
+// dots; values are rendered as text, lists and objects as JSON, and a value
+// is empty when the field was absent
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+// WastePage is one page of the wastes; Bookmark is empty on the last page.
+// Truncated marks a page cut short by the query budget.
+type WastePage struct {
+	Wastes    []*Waste `json:"wastes"`
+	Bookmark  string   `json:"bookmark"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// ExtractionPage is one page of the extractions, paged like WastePage
+type ExtractionPage struct {
+	Extractions []*Extraction `json:"extractions"`
+	Bookmark    string        `json:"bookmark"`
+	Truncated   bool          `json:"truncated,omitempty"`
+}
+
+// RecyclingPage is one page of the recyclings, paged like WastePage
+type RecyclingPage struct {
+	Recyclings []*Recycling `json:"recyclings"`
+	Bookmark   string       `json:"bookmark"`
+	Truncated  bool         `json:"truncated,omitempty"`
+}
+
+// TraceabilityInfo provides complete traceability chain
+type TraceabilityInfo struct {
+	Waste       *Waste                 `json:"waste,omitempty"`
+	Extraction  *Extraction            `json:"extraction,omitempty"`
+	Recycling   *Recycling             `json:"recycling,omitempty"`
+	Chain       []History              `json:"chain"`
+	Weather     []*WeatherObservation  `json:"weather,omitempty"`
+	Certificate *CompletionCertificate `json:"certificate,omitempty"`
+}
+
+// ReferencedAsset is the asset stamped with a sequential reference; exactly
+// one of Waste, Extraction and Recycling is set
+type ReferencedAsset struct {
+	Reference  string      `json:"reference"`
+	AssetType  string      `json:"assetType"`
+	AssetID    string      `json:"assetId"`
+	Waste      *Waste      `json:"waste,omitempty"`
+	Extraction *Extraction `json:"extraction,omitempty"`
+	Recycling  *Recycling  `json:"recycling,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// WeatherObservation is a weather event reported by the designated oracle for
+// a farm on a given day (YYYY-MM-DD)
+type WeatherObservation struct {
+	ID              string  `json:"id"`
+	Farm            string  `json:"farm"`
+	Date            string  `json:"date"`
+	Event           string  `json:"event"`
+	Severity        string  `json:"severity,omitempty"`
+	TemperatureMin  float64 `json:"temperatureMin"`
+	TemperatureMax  float64 `json:"temperatureMax"`
+	PrecipitationMM float64 `json:"precipitationMm"`
+	Source          string  `json:"source,omitempty"`
+	RecordedAt      string  `json:"recordedAt"`
+	RecordedBy      string  `json:"recordedBy"`
+	TxID            string  `json:"txId"`
+}
@@ -0,0 +1,90 @@
+package store
+
+import "fmt"
+
+// Limits is the result-size budget of a single query: how many assets and
+// how many bytes of them it may read; zero leaves a limit off
+type Limits struct {
+	MaxResults int `json:"maxResults"`
+	MaxBytes   int `json:"maxBytes"`
+}
+
+// BudgetExceeded is returned by a query stopped because its next asset
+// would take it over its budget. The assets before NextKey were visited;
+// callers that page resume from there, the others should page instead.
+type BudgetExceeded struct {
+	StartKey string `json:"startKey"`
+	EndKey   string `json:"endKey"`
+	NextKey  string `json:"nextKey"`
+	Results  int    `json:"results"`
+	Bytes    int    `json:"bytes"`
+	Limits   Limits `json:"limits"`
+}
+
+func (e *BudgetExceeded) Error() string {
+	return fmt.Sprintf("query [%s, %s) truncated after %d results (%d bytes) by its budget of %d results and %d bytes; page from %s", e.StartKey, e.EndKey, e.Results, e.Bytes, e.Limits.MaxResults, e.Limits.MaxBytes, e.NextKey)
+}
+
+// Tally counts what one query has read against its budget
+type Tally struct {
+	limits   Limits
+	startKey string
+	endKey   string
+	results  int
+	bytes    int
+}
+
+// NewTally starts counting a query over [startKey, endKey)
+func NewTally(limits Limits, startKey string, endKey string) *Tally {
+	return &Tally{limits: limits, startKey: startKey, endKey: endKey}
+}
+
+// Admit counts an asset the query is about to visit, or returns what was
+// read so far when the asset does not fit in the budget
+func (t *Tally) Admit(key string, value []byte) *BudgetExceeded {
+	overResults := t.limits.MaxResults > 0 && t.results+1 > t.limits.MaxResults
+	overBytes := t.limits.MaxBytes > 0 && t.bytes+len(value) > t.limits.MaxBytes
+	if overResults || overBytes {
+		return &BudgetExceeded{
+			StartKey: t.startKey,
+			EndKey:   t.endKey,
+			NextKey:  key,
+			Results:  t.results,
+			Bytes:    t.bytes,
+			Limits:   t.limits,
+		}
+	}
+	t.results++
+	t.bytes += len(value)
+
+	return nil
+}
+
+// Budget is an AssetStore whose range queries stop with an error once they
+// would read more than their limits, so that a query over a large ledger
+// cannot load all of it into the peer's memory
+type Budget struct {
+	AssetStore
+	limits   func() Limits
+	exceeded func(*BudgetExceeded) error
+}
+
+// NewBudget wraps a store; limits is called at the start of each range
+// query, so reading a configured budget does not itself run one, and
+// exceeded turns a BudgetExceeded into the error Range returns
+func NewBudget(inner AssetStore, limits func() Limits, exceeded func(*BudgetExceeded) error) *Budget {
+	return &Budget{AssetStore: inner, limits: limits, exceeded: exceeded}
+}
+
+// Range implements AssetStore
+func (b *Budget) Range(startKey string, endKey string, visit func(key string, value []byte) error) error {
+	tally := NewTally(b.limits(), startKey, endKey)
+
+	return b.AssetStore.Range(startKey, endKey, func(key string, value []byte) error {
+		if exceeded := tally.Admit(key, value); exceeded != nil {
+			return b.exceeded(exceeded)
+		}
+
+		return visit(key, value)
+	})
+}
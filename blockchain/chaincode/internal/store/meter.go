@@ -0,0 +1,51 @@
+package store
+
+// Meter is an AssetStore that reports every key read or written through it,
+// e.g. to measure the read and write sets of a transaction
+type Meter struct {
+	AssetStore
+	onAccess func(key string, write bool)
+}
+
+// NewMeter wraps a store; onAccess is called for each key read (Get, Exists
+// and every key a Range visits) and each key written (Put, Delete)
+func NewMeter(inner AssetStore, onAccess func(key string, write bool)) *Meter {
+	return &Meter{AssetStore: inner, onAccess: onAccess}
+}
+
+// Get implements AssetStore
+func (m *Meter) Get(key string, asset interface{}) (bool, error) {
+	m.onAccess(key, false)
+
+	return m.AssetStore.Get(key, asset)
+}
+
+// Exists implements AssetStore
+func (m *Meter) Exists(key string) (bool, error) {
+	m.onAccess(key, false)
+
+	return m.AssetStore.Exists(key)
+}
+
+// Put implements AssetStore
+func (m *Meter) Put(key string, asset interface{}) error {
+	m.onAccess(key, true)
+
+	return m.AssetStore.Put(key, asset)
+}
+
+// Delete implements AssetStore
+func (m *Meter) Delete(key string) error {
+	m.onAccess(key, true)
+
+	return m.AssetStore.Delete(key)
+}
+
+// Range implements AssetStore
+func (m *Meter) Range(startKey string, endKey string, visit func(key string, value []byte) error) error {
+	return m.AssetStore.Range(startKey, endKey, func(key string, value []byte) error {
+		m.onAccess(key, false)
+
+		return visit(key, value)
+	})
+}
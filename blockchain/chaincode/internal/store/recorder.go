@@ -0,0 +1,34 @@
+package store
+
+// Recorder is an AssetStore that reports every key written or deleted
+// through it, e.g. to audit which assets a transaction touched
+type Recorder struct {
+	AssetStore
+	onWrite func(key string)
+}
+
+// NewRecorder wraps a store; onWrite is called after each successful Put or
+// Delete
+func NewRecorder(inner AssetStore, onWrite func(key string)) *Recorder {
+	return &Recorder{AssetStore: inner, onWrite: onWrite}
+}
+
+// Put implements AssetStore
+func (r *Recorder) Put(key string, asset interface{}) error {
+	if err := r.AssetStore.Put(key, asset); err != nil {
+		return err
+	}
+	r.onWrite(key)
+
+	return nil
+}
+
+// Delete implements AssetStore
+func (r *Recorder) Delete(key string) error {
+	if err := r.AssetStore.Delete(key); err != nil {
+		return err
+	}
+	r.onWrite(key)
+
+	return nil
+}
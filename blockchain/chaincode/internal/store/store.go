@@ -0,0 +1,101 @@
+// Package store abstracts world-state access behind AssetStore, whose
+// decorators meter, budget and record the reads and writes of the stub
+package store
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// ErrStopRange ends a Range early when returned by the visit function; Range
+// itself then returns nil
+var ErrStopRange = errors.New("stop range")
+
+// AssetStore reads and writes JSON assets by key
+type AssetStore interface {
+	// Get unmarshals the asset stored at key into asset and reports whether
+	// it exists
+	Get(key string, asset interface{}) (bool, error)
+	// Exists reports whether an asset is stored at key
+	Exists(key string) (bool, error)
+	// Put marshals asset and stores it at key
+	Put(key string, asset interface{}) error
+	// Delete removes the asset stored at key
+	Delete(key string) error
+	// Range visits the raw assets in [startKey, endKey) in key order
+	Range(startKey string, endKey string, visit func(key string, value []byte) error) error
+}
+
+// StubStore is the AssetStore over a transaction's chaincode stub
+type StubStore struct {
+	stub shim.ChaincodeStubInterface
+}
+
+// NewStubStore wraps the stub of the current transaction
+func NewStubStore(stub shim.ChaincodeStubInterface) *StubStore {
+	return &StubStore{stub: stub}
+}
+
+// Get implements AssetStore
+func (s *StubStore) Get(key string, asset interface{}) (bool, error) {
+	assetJSON, err := s.stub.GetState(key)
+	if err != nil {
+		return false, err
+	}
+	if assetJSON == nil {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(assetJSON, asset)
+}
+
+// Exists implements AssetStore
+func (s *StubStore) Exists(key string) (bool, error) {
+	assetJSON, err := s.stub.GetState(key)
+	if err != nil {
+		return false, err
+	}
+
+	return assetJSON != nil, nil
+}
+
+// Put implements AssetStore
+func (s *StubStore) Put(key string, asset interface{}) error {
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+
+	return s.stub.PutState(key, assetJSON)
+}
+
+// Delete implements AssetStore
+func (s *StubStore) Delete(key string) error {
+	return s.stub.DelState(key)
+}
+
+// Range implements AssetStore
+func (s *StubStore) Range(startKey string, endKey string, visit func(key string, value []byte) error) error {
+	resultsIterator, err := s.stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		if err := visit(queryResponse.Key, queryResponse.Value); err != nil {
+			if err == ErrStopRange {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
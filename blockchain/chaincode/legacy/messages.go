@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Langues des messages prises en charge
+const (
+	LangEnglish = "en"
+	LangFrench  = "fr"
+)
+
+// Codes d'erreur stables, les mêmes que ceux du chaincode principal ; les
+// clients doivent s'appuyer sur ces codes et non sur le texte
+const (
+	ErrLedgerInit      = "LEDGER_INIT_FAILED"
+	ErrLedgerRead      = "LEDGER_READ_FAILED"
+	ErrIDRequired      = "ID_REQUIRED"
+	ErrQuantityInvalid = "QUANTITY_NOT_POSITIVE"
+	ErrHarvestDate     = "HARVEST_DATE_INVALID"
+	ErrWasteExists     = "WASTE_ALREADY_EXISTS"
+	ErrWasteNotFound   = "WASTE_NOT_FOUND"
+	ErrDecode          = "DECODE_FAILED"
+	ErrEncode          = "ENCODE_FAILED"
+)
+
+// messageCatalog contient le modèle localisé de chaque code d'erreur
+var messageCatalog = map[string]map[string]string{
+	ErrLedgerInit: {
+		LangEnglish: "failed to initialize ledger: %v",
+		LangFrench:  "échec de l'initialisation du ledger : %v",
+	},
+	ErrLedgerRead: {
+		LangEnglish: "failed to read the ledger: %v",
+		LangFrench:  "échec de lecture du ledger : %v",
+	},
+	ErrIDRequired: {
+		LangEnglish: "ID must not be empty",
+		LangFrench:  "l'ID ne peut pas être vide",
+	},
+	ErrQuantityInvalid: {
+		LangEnglish: "quantity must be a positive number",
+		LangFrench:  "la quantité doit être un nombre positif",
+	},
+	ErrHarvestDate: {
+		LangEnglish: "invalid date format (use YYYY-MM-DD)",
+		LangFrench:  "format de date invalide (utiliser YYYY-MM-DD)",
+	},
+	ErrWasteExists: {
+		LangEnglish: "a waste with ID %s already exists",
+		LangFrench:  "un déchet avec l'ID %s existe déjà",
+	},
+	ErrWasteNotFound: {
+		LangEnglish: "waste %s does not exist",
+		LangFrench:  "le déchet %s n'existe pas",
+	},
+	ErrDecode: {
+		LangEnglish: "failed to decode JSON: %v",
+		LangFrench:  "échec de décodage JSON : %v",
+	},
+	ErrEncode: {
+		LangEnglish: "failed to encode JSON: %v",
+		LangFrench:  "échec de sérialisation JSON : %v",
+	},
+}
+
+// CodedError porte un code stable et un message localisé ; son texte est
+// "CODE: message" pour que les clients retrouvent le code
+type CodedError struct {
+	Code    string
+	Message string
+}
+
+func (e *CodedError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// newError construit une CodedError dans la langue de l'appelant
+func newError(ctx contractapi.TransactionContextInterface, code string, args ...interface{}) error {
+	return &CodedError{
+		Code:    code,
+		Message: localize(requestLanguage(ctx), code, args...),
+	}
+}
+
+// localize rend le modèle d'un code, en anglais à défaut de traduction
+func localize(lang string, code string, args ...interface{}) string {
+	templates, ok := messageCatalog[code]
+	if !ok {
+		return code
+	}
+	template, ok := templates[lang]
+	if !ok {
+		template = templates[LangEnglish]
+	}
+
+	return fmt.Sprintf(template, args...)
+}
+
+// requestLanguage choisit la langue des messages d'après l'indication
+// transitoire "lang", puis l'en-tête transitoire "accept-language" de la
+// passerelle ; le français reste la langue par défaut de ce chaincode
+func requestLanguage(ctx contractapi.TransactionContextInterface) string {
+	transient, err := ctx.GetStub().GetTransient()
+	if err == nil {
+		if lang := negotiateLanguage(string(transient["lang"])); lang != "" {
+			return lang
+		}
+		if lang := negotiateLanguage(string(transient["accept-language"])); lang != "" {
+			return lang
+		}
+	}
+
+	return LangFrench
+}
+
+// negotiateLanguage retourne la langue prise en charge de plus haute
+// qualité dans une liste de type Accept-Language (ex. "fr-FR,fr;q=0.9")
+func negotiateLanguage(header string) string {
+	best := ""
+	bestQuality := 0.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag != LangEnglish && tag != LangFrench {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > bestQuality {
+			best = tag
+			bestQuality = quality
+		}
+	}
+
+	return best
+}
@@ -44,7 +44,7 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 	for _, waste := range wastes {
 		err := s.saveWaste(ctx, waste)
 		if err != nil {
-			return fmt.Errorf("failed to initialize ledger: %v", err)
+			return newError(ctx, ErrLedgerInit, err)
 		}
 	}
 	return nil
@@ -62,25 +62,25 @@ func (s *SmartContract) CreateWaste(
 ) error {
 	// Validation des entrées
 	if id == "" {
-		return fmt.Errorf("l'ID ne peut pas être vide")
+		return newError(ctx, ErrIDRequired)
 	}
 
 	qty, err := strconv.Atoi(quantity)
 	if err != nil || qty <= 0 {
-		return fmt.Errorf("la quantité doit être un nombre positif")
+		return newError(ctx, ErrQuantityInvalid)
 	}
 
 	if _, err := time.Parse("2006-01-02", harvestDate); err != nil {
-		return fmt.Errorf("format de date invalide (utiliser YYYY-MM-DD)")
+		return newError(ctx, ErrHarvestDate)
 	}
 
 	// Vérification des doublons
 	existing, err := ctx.GetStub().GetState(id)
 	if err != nil {
-		return fmt.Errorf("échec de lecture du ledger: %v", err)
+		return newError(ctx, ErrLedgerRead, err)
 	}
 	if existing != nil {
-		return fmt.Errorf("un déchet avec l'ID %s existe déjà", id)
+		return newError(ctx, ErrWasteExists, id)
 	}
 
 	// Création du déchet
@@ -102,16 +102,16 @@ func (s *SmartContract) CreateWaste(
 func (s *SmartContract) GetWaste(ctx contractapi.TransactionContextInterface, id string) (*Waste, error) {
 	wasteJSON, err := ctx.GetStub().GetState(id)
 	if err != nil {
-		return nil, fmt.Errorf("échec de lecture du ledger: %v", err)
+		return nil, newError(ctx, ErrLedgerRead, err)
 	}
 	if wasteJSON == nil {
-		return nil, fmt.Errorf("le déchet %s n'existe pas", id)
+		return nil, newError(ctx, ErrWasteNotFound, id)
 	}
 
 	var waste Waste
 	err = json.Unmarshal(wasteJSON, &waste)
 	if err != nil {
-		return nil, fmt.Errorf("échec de décodage JSON: %v", err)
+		return nil, newError(ctx, ErrDecode, err)
 	}
 
 	return &waste, nil
@@ -138,7 +138,7 @@ func (s *SmartContract) UpdateWasteStatus(
 func (s *SmartContract) GetAllWastes(ctx contractapi.TransactionContextInterface) ([]*Waste, error) {
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
 	if err != nil {
-		return nil, fmt.Errorf("échec de lecture du ledger: %v", err)
+		return nil, newError(ctx, ErrLedgerRead, err)
 	}
 	defer resultsIterator.Close()
 
@@ -146,13 +146,13 @@ func (s *SmartContract) GetAllWastes(ctx contractapi.TransactionContextInterface
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
 		if err != nil {
-			return nil, fmt.Errorf("erreur d'itération: %v", err)
+			return nil, newError(ctx, ErrLedgerRead, err)
 		}
 
 		var waste Waste
 		err = json.Unmarshal(queryResponse.Value, &waste)
 		if err != nil {
-			return nil, fmt.Errorf("échec de décodage JSON: %v", err)
+			return nil, newError(ctx, ErrDecode, err)
 		}
 		wastes = append(wastes, &waste)
 	}
@@ -164,7 +164,7 @@ func (s *SmartContract) GetAllWastes(ctx contractapi.TransactionContextInterface
 func (s *SmartContract) saveWaste(ctx contractapi.TransactionContextInterface, waste Waste) error {
 	wasteJSON, err := json.Marshal(waste)
 	if err != nil {
-		return fmt.Errorf("échec de sérialisation JSON: %v", err)
+		return newError(ctx, ErrEncode, err)
 	}
 
 	return ctx.GetStub().PutState(waste.ID, wasteJSON)
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chaincode/internal/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func main() {
+	assetChaincode, err := contractapi.NewChaincode(contract.NewSmartContract())
+	if err != nil {
+		fmt.Printf("Error creating waste chaincode: %v", err)
+		return
+	}
+
+	if err := assetChaincode.Start(); err != nil {
+		fmt.Printf("Error starting waste chaincode: %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/chaincode/internal/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TestNewChaincode builds the chaincode as main does, so that a transaction
+// whose parameter or return types the contract API rejects fails here rather
+// than when the peer starts it
+func TestNewChaincode(t *testing.T) {
+	if _, err := contractapi.NewChaincode(contract.NewSmartContract()); err != nil {
+		t.Fatalf("failed to create the waste chaincode: %v", err)
+	}
+}
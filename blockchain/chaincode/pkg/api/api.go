@@ -0,0 +1,473 @@
+// Package api describes the operations of the REST gateway and the bodies
+// they accept; cmd/openapi turns it into the gateway's OpenAPI document and
+// into the generated part of pkg/client.
+//
+// Request fields are documented with struct tags: json names the field and
+// validate lists its constraints (required, min=, max=, gt=, enum=a|b).
+package api
+
+//go:generate go run ../../cmd/openapi -spec ../../../../api/openapi.json -client ../client/zz_generated.go
+
+import "github.com/chaincode/internal/models"
+
+// Operation is one REST gateway endpoint
+type Operation struct {
+	ID      string
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	// Query is a struct whose fields are the query parameters, or nil
+	Query interface{}
+	// Body is the JSON request body, or nil
+	Body interface{}
+	// Data is the "data" member of the success envelope, or nil when the
+	// gateway returns an untyped record
+	Data interface{}
+	// Paged operations answer with a page of list results
+	Paged bool
+	// Created operations answer 201 rather than 200
+	Created bool
+}
+
+// Organization is the gateway identity a request acts with
+type Organization struct {
+	Org string `json:"org,omitempty" validate:"enum=farmer|processor|recycler"`
+}
+
+// ListQuery holds the paging and sorting parameters shared by the lists
+type ListQuery struct {
+	Limit  int    `json:"limit,omitempty" validate:"min=1,max=1000"`
+	Cursor string `json:"cursor,omitempty"`
+	Sort   string `json:"sort,omitempty" validate:"enum=createdAt|updatedAt|quantity"`
+	Order  string `json:"order,omitempty" validate:"enum=asc|desc"`
+	Count  string `json:"count,omitempty" validate:"enum=none|exact|estimate"`
+}
+
+// WasteListQuery filters the lot list
+type WasteListQuery struct {
+	ListQuery
+	Status      string  `json:"status,omitempty"`
+	Type        string  `json:"type,omitempty"`
+	Category    string  `json:"category,omitempty"`
+	Region      string  `json:"region,omitempty"`
+	Farm        string  `json:"farm,omitempty"`
+	PlotID      string  `json:"plotId,omitempty"`
+	OwnerMSP    string  `json:"ownerMsp,omitempty"`
+	MinQuantity float64 `json:"minQuantity,omitempty"`
+	MaxQuantity float64 `json:"maxQuantity,omitempty"`
+	CreatedFrom string  `json:"createdFrom,omitempty"`
+	CreatedTo   string  `json:"createdTo,omitempty"`
+}
+
+// WarningListQuery selects the lots with open validation warnings
+type WarningListQuery struct {
+	ListQuery
+	Organization
+	Code string `json:"code,omitempty"`
+}
+
+// ProductListQuery filters the extraction and recycling lists
+type ProductListQuery struct {
+	ListQuery
+	Status      string  `json:"status,omitempty"`
+	WasteID     string  `json:"wasteId,omitempty"`
+	FacilityID  string  `json:"facilityId,omitempty"`
+	MinQuantity float64 `json:"minQuantity,omitempty"`
+	MaxQuantity float64 `json:"maxQuantity,omitempty"`
+	CreatedFrom string  `json:"createdFrom,omitempty"`
+	CreatedTo   string  `json:"createdTo,omitempty"`
+}
+
+// AsOfQuery selects the time of an as-of read and where it is answered from
+type AsOfQuery struct {
+	Organization
+	At     string `json:"at" validate:"required"`
+	Source string `json:"source,omitempty" validate:"enum=ledger|read-model"`
+}
+
+// ApprovalListQuery filters the approvals
+type ApprovalListQuery struct {
+	Organization
+	WasteID string `json:"wasteId,omitempty"`
+	Status  string `json:"status,omitempty"`
+}
+
+// WasteData is a new lot
+type WasteData struct {
+	Type            string  `json:"type" validate:"required"`
+	Quantity        float64 `json:"quantity" validate:"required,gt=0"`
+	HarvestDate     string  `json:"harvestDate" validate:"required"`
+	Status          string  `json:"status" validate:"required"`
+	Farm            string  `json:"farm,omitempty"`
+	Location        string  `json:"location,omitempty"`
+	PlotID          string  `json:"plotId,omitempty"`
+	FarmerID        string  `json:"farmerId,omitempty"`
+	QualityGrade    string  `json:"qualityGrade,omitempty"`
+	MoistureContent string  `json:"moistureContent,omitempty"`
+}
+
+// CreateWasteRequest records a lot
+type CreateWasteRequest struct {
+	WasteData WasteData `json:"wasteData" validate:"required"`
+}
+
+// TransferData describes who moves a lot to its new status and why;
+// ReasonCode is required on transitions whose reason catalog says so
+type TransferData struct {
+	Actor        string `json:"actor,omitempty"`
+	Details      string `json:"details,omitempty"`
+	ReasonCode   string `json:"reasonCode,omitempty"`
+	ProcessorID  string `json:"processorId,omitempty"`
+	TransferDate string `json:"transferDate,omitempty"`
+}
+
+// UpdateWasteStatusRequest moves a lot to a new status; a non-zero
+// ExpectedVersion rejects the update if the lot changed since it was read
+type UpdateWasteStatusRequest struct {
+	WasteID         string        `json:"wasteId" validate:"required"`
+	NewStatus       string        `json:"newStatus" validate:"required"`
+	ExpectedVersion int           `json:"expectedVersion,omitempty" validate:"min=0"`
+	TransferData    *TransferData `json:"transferData,omitempty"`
+}
+
+// CompositionRequest records the measured composition of a lot
+type CompositionRequest struct {
+	Organization
+	MoisturePct     *float64 `json:"moisturePct,omitempty" validate:"min=0,max=100"`
+	OilContentPct   *float64 `json:"oilContentPct,omitempty" validate:"min=0,max=100"`
+	ExpectedVersion int      `json:"expectedVersion,omitempty" validate:"min=0"`
+}
+
+// EmbargoRequest hides a lot from other organizations for Hours; 0 lifts
+// the embargo
+type EmbargoRequest struct {
+	Organization
+	Hours           int `json:"hours" validate:"required,min=0"`
+	ExpectedVersion int `json:"expectedVersion,omitempty" validate:"min=0"`
+}
+
+// SealFieldRequest stores a field encrypted by the client; Key is the
+// base64 AES-256 key, sent to the chaincode as transient data only
+type SealFieldRequest struct {
+	Organization
+	Ciphertext      string `json:"ciphertext" validate:"required"`
+	Key             string `json:"key" validate:"required"`
+	ExpectedVersion int    `json:"expectedVersion,omitempty" validate:"min=0"`
+}
+
+// OpenSealedFieldRequest decrypts a sealed field with its key
+type OpenSealedFieldRequest struct {
+	Organization
+	Key string `json:"key" validate:"required"`
+}
+
+// ShareTransferRequest transfers a percentage of a lot to a buyer
+type ShareTransferRequest struct {
+	Organization
+	Percentage      float64 `json:"percentage" validate:"required,gt=0,max=100"`
+	BuyerID         string  `json:"buyerId" validate:"required"`
+	BuyerMSP        string  `json:"buyerMsp" validate:"required"`
+	ExpectedVersion int     `json:"expectedVersion,omitempty" validate:"min=0"`
+}
+
+// ResolveWarningRequest closes an open validation warning
+type ResolveWarningRequest struct {
+	Organization
+	Resolution      string `json:"resolution" validate:"required"`
+	ExpectedVersion int    `json:"expectedVersion,omitempty" validate:"min=0"`
+}
+
+// ExtractionData is a processing run on a lot
+type ExtractionData struct {
+	WasteID          string  `json:"wasteId" validate:"required"`
+	ProductType      string  `json:"productType" validate:"required"`
+	Quantity         float64 `json:"quantity" validate:"required,gt=0"`
+	Quality          string  `json:"quality" validate:"required"`
+	FacilityID       string  `json:"facilityId,omitempty"`
+	ProcessorID      string  `json:"processorId,omitempty"`
+	ExtractionDate   string  `json:"extractionDate,omitempty"`
+	ExtractionMethod string  `json:"extractionMethod,omitempty"`
+}
+
+// CreateExtractionRequest records an extraction
+type CreateExtractionRequest struct {
+	ExtractionData ExtractionData `json:"extractionData" validate:"required"`
+}
+
+// RecyclingData is a recycling run on a lot
+type RecyclingData struct {
+	WasteID         string  `json:"wasteId" validate:"required"`
+	RecycledProduct string  `json:"recycledProduct" validate:"required"`
+	Quantity        float64 `json:"quantity" validate:"required,gt=0"`
+	Method          string  `json:"method" validate:"required"`
+	FacilityID      string  `json:"facilityId,omitempty"`
+	RecyclerID      string  `json:"recyclerId,omitempty"`
+	RecyclingDate   string  `json:"recyclingDate,omitempty"`
+}
+
+// CreateRecyclingRequest records a recycling
+type CreateRecyclingRequest struct {
+	RecyclingData RecyclingData `json:"recyclingData" validate:"required"`
+}
+
+// ApproveRequest signs an approval in one of the approver roles
+type ApproveRequest struct {
+	Organization
+	Role string `json:"role" validate:"required,enum=OWNER|COOPERATIVE_ADMIN|ADMIN|AUDITOR|BUYER"`
+}
+
+// RejectApprovalRequest refuses an approval
+type RejectApprovalRequest struct {
+	Organization
+	Reason string `json:"reason" validate:"required"`
+}
+
+// ApprovalRuleData requires the roles to approve an operation of more than
+// MinQuantity
+type ApprovalRuleData struct {
+	Operation     string   `json:"operation" validate:"required,enum=TRANSFER_SHARE"`
+	MinQuantity   float64  `json:"minQuantity" validate:"min=0"`
+	RequiredRoles []string `json:"requiredRoles" validate:"required"`
+}
+
+// SetApprovalMatrixRequest schedules a version of an organization's
+// approval matrix; no rules lift approvals from EffectiveFrom
+type SetApprovalMatrixRequest struct {
+	Organization
+	EffectiveFrom string             `json:"effectiveFrom,omitempty"`
+	Rules         []ApprovalRuleData `json:"rules" validate:"required"`
+}
+
+// EffectiveRulesQuery selects when approval rules are looked up
+type EffectiveRulesQuery struct {
+	Organization
+	At string `json:"at,omitempty"`
+}
+
+// WasteTemplateData holds the defaults of a lot template; quantities are in
+// Unit
+type WasteTemplateData struct {
+	Type            string              `json:"type" validate:"required"`
+	Unit            string              `json:"unit,omitempty" validate:"enum=t|kg"`
+	DefaultQuantity float64             `json:"defaultQuantity,omitempty" validate:"min=0"`
+	Farm            string              `json:"farm,omitempty"`
+	Location        string              `json:"location,omitempty"`
+	PlotID          string              `json:"plotId,omitempty"`
+	QualityGrade    string              `json:"qualityGrade,omitempty" validate:"enum=A|B|C|D"`
+	Composition     *models.Composition `json:"composition,omitempty"`
+}
+
+// SaveWasteTemplateRequest creates or replaces a participant's lot template
+type SaveWasteTemplateRequest struct {
+	Organization
+	Template WasteTemplateData `json:"template" validate:"required"`
+}
+
+// RegisterWasteTemplateRequest saves a template for an owner who has no
+// participant ID yet, registering one
+type RegisterWasteTemplateRequest struct {
+	Organization
+	Owner    string            `json:"owner" validate:"required"`
+	Name     string            `json:"name" validate:"required"`
+	Template WasteTemplateData `json:"template" validate:"required"`
+}
+
+// CreateWasteFromTemplateRequest records a lot from a template; Quantity is
+// in the template's unit and falls back to its default quantity, and
+// HarvestDate to today
+type CreateWasteFromTemplateRequest struct {
+	Organization
+	WasteID     string  `json:"wasteId,omitempty"`
+	Quantity    float64 `json:"quantity,omitempty" validate:"min=0"`
+	HarvestDate string  `json:"harvestDate,omitempty"`
+}
+
+// Operations lists the endpoints described by the OpenAPI document, in the
+// order they appear in it
+var Operations = []Operation{
+	{
+		ID: "CreateWaste", Method: "POST", Path: "/api/waste/add", Tag: "waste",
+		Summary: "Record a lot",
+		Body:    CreateWasteRequest{},
+		Created: true,
+	},
+	{
+		ID: "ListWastes", Method: "GET", Path: "/api/waste/list", Tag: "waste",
+		Summary: "List lots",
+		Query:   WasteListQuery{},
+		Data:    []models.Waste{},
+		Paged:   true,
+	},
+	{
+		ID: "UpdateWasteStatus", Method: "PUT", Path: "/api/waste/update-status", Tag: "waste",
+		Summary: "Move a lot to a new status",
+		Body:    UpdateWasteStatusRequest{},
+	},
+	{
+		ID: "SetWasteComposition", Method: "PUT", Path: "/api/waste/{wasteId}/composition", Tag: "waste",
+		Summary: "Record the moisture and oil content of a lot",
+		Body:    CompositionRequest{},
+		Data:    models.Waste{},
+	},
+	{
+		ID: "SetWasteEmbargo", Method: "PUT", Path: "/api/waste/{wasteId}/embargo", Tag: "waste",
+		Summary: "Hide a lot from other organizations for a while",
+		Body:    EmbargoRequest{},
+		Data:    models.Waste{},
+	},
+	{
+		ID: "SealWasteField", Method: "PUT", Path: "/api/waste/{wasteId}/sealed/{field}", Tag: "waste",
+		Summary: "Store a field encrypted by the client",
+		Body:    SealFieldRequest{},
+		Data:    models.Waste{},
+	},
+	{
+		ID: "OpenSealedWasteField", Method: "POST", Path: "/api/waste/{wasteId}/sealed/{field}/open", Tag: "waste",
+		Summary: "Decrypt a sealed field with its key",
+		Body:    OpenSealedFieldRequest{},
+		Data:    models.UnsealedField{},
+	},
+	{
+		ID: "TransferShare", Method: "POST", Path: "/api/waste/{wasteId}/ownership/transfer", Tag: "waste",
+		Summary: "Transfer a share of a lot; large transfers await approval",
+		Body:    ShareTransferRequest{},
+		Data:    models.Waste{},
+	},
+	{
+		ID: "ListWastesWithWarnings", Method: "GET", Path: "/api/waste/warnings", Tag: "waste",
+		Summary: "List lots with open validation warnings",
+		Query:   WarningListQuery{},
+		Data:    []models.Waste{},
+		Paged:   true,
+	},
+	{
+		ID: "GetWasteAsOf", Method: "GET", Path: "/api/waste/{wasteId}/as-of", Tag: "waste",
+		Summary: "Get a lot as it stood at a past time",
+		Query:   AsOfQuery{},
+		Data:    models.WasteAsOf{},
+	},
+	{
+		ID: "ResolveWasteWarning", Method: "POST", Path: "/api/waste/{wasteId}/warnings/{code}/resolve", Tag: "waste",
+		Summary: "Resolve an open validation warning of a lot",
+		Body:    ResolveWarningRequest{},
+		Data:    models.Waste{},
+	},
+	{
+		ID: "ListWasteTemplates", Method: "GET", Path: "/api/waste-templates/{participantId}", Tag: "waste",
+		Summary: "List a participant's lot templates, the most used first",
+		Query:   Organization{},
+		Data:    []models.WasteTemplate{},
+	},
+	{
+		ID: "RegisterWasteTemplate", Method: "POST", Path: "/api/waste-templates", Tag: "waste",
+		Summary: "Save a lot template for an owner without a participant ID yet",
+		Body:    RegisterWasteTemplateRequest{},
+		Data:    models.WasteTemplate{},
+		Created: true,
+	},
+	{
+		ID: "SaveWasteTemplate", Method: "PUT", Path: "/api/waste-templates/{participantId}/{name}", Tag: "waste",
+		Summary: "Create or replace a participant's lot template",
+		Body:    SaveWasteTemplateRequest{},
+		Data:    models.WasteTemplate{},
+	},
+	{
+		ID: "DeleteWasteTemplate", Method: "DELETE", Path: "/api/waste-templates/{participantId}/{name}", Tag: "waste",
+		Summary: "Delete a participant's lot template",
+		Query:   Organization{},
+	},
+	{
+		ID: "CreateWasteFromTemplate", Method: "POST", Path: "/api/waste-templates/{participantId}/{name}/wastes", Tag: "waste",
+		Summary: "Record a lot with the defaults of a template",
+		Body:    CreateWasteFromTemplateRequest{},
+		Data:    models.Waste{},
+		Created: true,
+	},
+	{
+		ID: "CreateExtraction", Method: "POST", Path: "/api/extraction/add", Tag: "extraction",
+		Summary: "Record an extraction",
+		Body:    CreateExtractionRequest{},
+		Created: true,
+	},
+	{
+		ID: "ListExtractions", Method: "GET", Path: "/api/extraction/list", Tag: "extraction",
+		Summary: "List extractions",
+		Query:   ProductListQuery{},
+		Data:    []models.Extraction{},
+		Paged:   true,
+	},
+	{
+		ID: "CreateRecycling", Method: "POST", Path: "/api/recycling/add", Tag: "recycling",
+		Summary: "Record a recycling",
+		Body:    CreateRecyclingRequest{},
+		Created: true,
+	},
+	{
+		ID: "ListRecyclings", Method: "GET", Path: "/api/recycling/list", Tag: "recycling",
+		Summary: "List recyclings",
+		Query:   ProductListQuery{},
+		Data:    []models.Recycling{},
+		Paged:   true,
+	},
+	{
+		ID: "GetAssetByReference", Method: "GET", Path: "/api/references/{reference}", Tag: "references",
+		Summary: "Find a lot, extraction or recycling by its sequential reference",
+		Query:   Organization{},
+		Data:    models.ReferencedAsset{},
+	},
+	{
+		ID: "GetCompletionCertificate", Method: "GET", Path: "/api/traceability/{wasteId}/certificate", Tag: "references",
+		Summary: "Get the completion certificate of a fully valorized lot",
+		Query:   Organization{},
+		Data:    models.CompletionCertificate{},
+	},
+	{
+		ID: "ListApprovals", Method: "GET", Path: "/api/approvals", Tag: "approvals",
+		Summary: "List approvals of high-value operations",
+		Query:   ApprovalListQuery{},
+		Data:    []models.Approval{},
+	},
+	{
+		ID: "Approve", Method: "POST", Path: "/api/approvals/{approvalId}/approve", Tag: "approvals",
+		Summary: "Sign an approval",
+		Body:    ApproveRequest{},
+		Data:    models.Approval{},
+	},
+	{
+		ID: "RejectApproval", Method: "POST", Path: "/api/approvals/{approvalId}/reject", Tag: "approvals",
+		Summary: "Reject an approval",
+		Body:    RejectApprovalRequest{},
+		Data:    models.Approval{},
+	},
+	{
+		ID: "ListApprovalMatrices", Method: "GET", Path: "/api/approvals/matrices", Tag: "approvals",
+		Summary: "List the approval matrices of all organizations",
+		Query:   Organization{},
+		Data:    []models.ApprovalMatrix{},
+	},
+	{
+		ID: "GetApprovalMatrix", Method: "GET", Path: "/api/approvals/matrices/{orgMsp}", Tag: "approvals",
+		Summary: "Get an organization's approval matrix",
+		Query:   Organization{},
+		Data:    models.ApprovalMatrix{},
+	},
+	{
+		ID: "SetApprovalMatrix", Method: "PUT", Path: "/api/approvals/matrices/{orgMsp}", Tag: "approvals",
+		Summary: "Schedule a version of an organization's approval matrix",
+		Body:    SetApprovalMatrixRequest{},
+		Data:    models.ApprovalMatrix{},
+	},
+	{
+		ID: "GetEffectiveApprovalRules", Method: "GET", Path: "/api/approvals/matrices/{orgMsp}/effective", Tag: "approvals",
+		Summary: "Get the approval rules of an organization in effect at a time",
+		Query:   EffectiveRulesQuery{},
+		Data:    models.ApprovalMatrixVersion{},
+	},
+	{
+		ID: "CancelApprovalMatrixVersion", Method: "DELETE", Path: "/api/approvals/matrices/{orgMsp}/versions/{effectiveFrom}", Tag: "approvals",
+		Summary: "Cancel a version of an approval matrix not in effect yet",
+		Query:   Organization{},
+		Data:    models.ApprovalMatrix{},
+	},
+}
@@ -0,0 +1,175 @@
+// Package client calls the REST gateway from Go. The operation methods and
+// the request and result types are generated from pkg/api (see
+// zz_generated.go); this file holds the transport they share.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Client sends requests to one REST gateway
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Token is sent as a bearer token when set (service accounts)
+	Token string
+	// Language selects the language of chaincode messages (e.g. "fr")
+	Language string
+}
+
+// New returns a client of the gateway at baseURL, e.g.
+// "http://localhost:5000"
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Response is the envelope of a successful response; Data holds the raw
+// result, which typed methods also decode
+type Response struct {
+	Success        bool            `json:"success"`
+	Message        string          `json:"message,omitempty"`
+	Data           json.RawMessage `json:"data,omitempty"`
+	Count          int             `json:"count,omitempty"`
+	Page           *Page           `json:"page,omitempty"`
+	BlockchainTxID string          `json:"blockchainTxId,omitempty"`
+	StatusCode     int             `json:"-"`
+}
+
+// Page describes one page of a list
+type Page struct {
+	Limit           int               `json:"limit"`
+	Sort            string            `json:"sort"`
+	Order           string            `json:"order"`
+	Filters         map[string]string `json:"filters,omitempty"`
+	NextCursor      *string           `json:"nextCursor"`
+	Total           *int              `json:"total,omitempty"`
+	TotalIsEstimate bool              `json:"totalIsEstimate,omitempty"`
+}
+
+// Problem is an RFC 7807 error returned by the gateway; Type identifies the
+// kind of failure and Code the chaincode or Fabric code behind it
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%s (%d)", p.Title, p.Status)
+	}
+
+	return fmt.Sprintf("%s (%d): %s", p.Title, p.Status, p.Detail)
+}
+
+// do sends a request and decodes the envelope's data into data when set;
+// gateway errors come back as *Problem
+func (c *Client) do(ctx context.Context, method string, path string, query interface{}, body interface{}, data interface{}) (*Response, error) {
+	target := c.BaseURL + path
+	if query != nil && !reflect.ValueOf(query).IsNil() {
+		if values := encodeQuery(query); len(values) > 0 {
+			target += "?" + values.Encode()
+		}
+	}
+
+	var payload *bytes.Reader
+	if body != nil && !reflect.ValueOf(body).IsNil() {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = bytes.NewReader(encoded)
+	} else {
+		payload = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, payload)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	if payload.Len() > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.Language != "" {
+		req.Header.Set("X-Language", c.Language)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		problem := &Problem{Status: resp.StatusCode, Title: resp.Status}
+		if err := json.Unmarshal(raw, problem); err != nil {
+			problem.Detail = string(raw)
+		}
+		return nil, problem
+	}
+
+	response := &Response{StatusCode: resp.StatusCode}
+	if err := json.Unmarshal(raw, response); err != nil {
+		return nil, fmt.Errorf("invalid response from %s %s: %v", method, path, err)
+	}
+	if data != nil && len(response.Data) > 0 {
+		if err := json.Unmarshal(response.Data, data); err != nil {
+			return response, fmt.Errorf("invalid data from %s %s: %v", method, path, err)
+		}
+	}
+
+	return response, nil
+}
+
+// encodeQuery turns the non-zero fields of a query struct into parameters
+// named after their json tags
+func encodeQuery(query interface{}) url.Values {
+	values := url.Values{}
+	addQueryFields(values, reflect.Indirect(reflect.ValueOf(query)))
+
+	return values
+}
+
+func addQueryFields(values url.Values, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			addQueryFields(values, v.Field(i))
+			continue
+		}
+		if name == "" || name == "-" || v.Field(i).IsZero() {
+			continue
+		}
+		switch field := v.Field(i); field.Kind() {
+		case reflect.Float32, reflect.Float64:
+			values.Set(name, strconv.FormatFloat(field.Float(), 'f', -1, 64))
+		default:
+			values.Set(name, fmt.Sprint(field.Interface()))
+		}
+	}
+}
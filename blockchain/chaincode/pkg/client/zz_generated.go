@@ -0,0 +1,349 @@
+// Code generated by cmd/openapi; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/chaincode/internal/models"
+	"github.com/chaincode/pkg/api"
+)
+
+// Types of the requests and results
+type (
+	Approval                       = models.Approval
+	ApprovalListQuery              = api.ApprovalListQuery
+	ApprovalMatrix                 = models.ApprovalMatrix
+	ApprovalMatrixVersion          = models.ApprovalMatrixVersion
+	ApprovalRule                   = models.ApprovalRule
+	ApprovalRuleData               = api.ApprovalRuleData
+	ApprovalSignature              = models.ApprovalSignature
+	ApproveRequest                 = api.ApproveRequest
+	AsOfQuery                      = api.AsOfQuery
+	CertificateOutput              = models.CertificateOutput
+	CompletionCertificate          = models.CompletionCertificate
+	Composition                    = models.Composition
+	CompositionRequest             = api.CompositionRequest
+	CreateExtractionRequest        = api.CreateExtractionRequest
+	CreateRecyclingRequest         = api.CreateRecyclingRequest
+	CreateWasteFromTemplateRequest = api.CreateWasteFromTemplateRequest
+	CreateWasteRequest             = api.CreateWasteRequest
+	Document                       = models.Document
+	EffectiveRulesQuery            = api.EffectiveRulesQuery
+	EmbargoRequest                 = api.EmbargoRequest
+	ExternalOrigin                 = models.ExternalOrigin
+	Extraction                     = models.Extraction
+	ExtractionData                 = api.ExtractionData
+	ExtractionOutput               = models.ExtractionOutput
+	FieldChange                    = models.FieldChange
+	GradeRecord                    = models.GradeRecord
+	History                        = models.History
+	ListQuery                      = api.ListQuery
+	MassBalance                    = models.MassBalance
+	OpenSealedFieldRequest         = api.OpenSealedFieldRequest
+	Organization                   = api.Organization
+	OwnershipShare                 = models.OwnershipShare
+	ProductListQuery               = api.ProductListQuery
+	Recycling                      = models.Recycling
+	RecyclingData                  = api.RecyclingData
+	RecyclingInput                 = models.RecyclingInput
+	ReferencedAsset                = models.ReferencedAsset
+	RegisterWasteTemplateRequest   = api.RegisterWasteTemplateRequest
+	RejectApprovalRequest          = api.RejectApprovalRequest
+	ResolveWarningRequest          = api.ResolveWarningRequest
+	SLAStatus                      = models.SLAStatus
+	SaveWasteTemplateRequest       = api.SaveWasteTemplateRequest
+	SealFieldRequest               = api.SealFieldRequest
+	SealedField                    = models.SealedField
+	SetApprovalMatrixRequest       = api.SetApprovalMatrixRequest
+	ShareTransfer                  = models.ShareTransfer
+	ShareTransferRequest           = api.ShareTransferRequest
+	SubsidyFlag                    = models.SubsidyFlag
+	TransferData                   = api.TransferData
+	UnsealedField                  = models.UnsealedField
+	UpdateWasteStatusRequest       = api.UpdateWasteStatusRequest
+	ValidationWarning              = models.ValidationWarning
+	WarningListQuery               = api.WarningListQuery
+	Waste                          = models.Waste
+	WasteAsOf                      = models.WasteAsOf
+	WasteData                      = api.WasteData
+	WasteListQuery                 = api.WasteListQuery
+	WasteTemplate                  = models.WasteTemplate
+	WasteTemplateData              = api.WasteTemplateData
+)
+
+// CreateWaste calls POST /api/waste/add: Record a lot
+func (c *Client) CreateWaste(ctx context.Context, body *CreateWasteRequest) (*Response, error) {
+	return c.do(ctx, "POST", "/api/waste/add", nil, body, nil)
+}
+
+// ListWastes calls GET /api/waste/list: List lots
+func (c *Client) ListWastes(ctx context.Context, query *WasteListQuery) ([]Waste, *Response, error) {
+	var data []Waste
+	response, err := c.do(ctx, "GET", "/api/waste/list", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return data, response, nil
+}
+
+// UpdateWasteStatus calls PUT /api/waste/update-status: Move a lot to a new status
+func (c *Client) UpdateWasteStatus(ctx context.Context, body *UpdateWasteStatusRequest) (*Response, error) {
+	return c.do(ctx, "PUT", "/api/waste/update-status", nil, body, nil)
+}
+
+// SetWasteComposition calls PUT /api/waste/{wasteId}/composition: Record the moisture and oil content of a lot
+func (c *Client) SetWasteComposition(ctx context.Context, wasteID string, body *CompositionRequest) (*Waste, *Response, error) {
+	var data Waste
+	response, err := c.do(ctx, "PUT", "/api/waste/"+url.PathEscape(wasteID)+"/composition", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// SetWasteEmbargo calls PUT /api/waste/{wasteId}/embargo: Hide a lot from other organizations for a while
+func (c *Client) SetWasteEmbargo(ctx context.Context, wasteID string, body *EmbargoRequest) (*Waste, *Response, error) {
+	var data Waste
+	response, err := c.do(ctx, "PUT", "/api/waste/"+url.PathEscape(wasteID)+"/embargo", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// SealWasteField calls PUT /api/waste/{wasteId}/sealed/{field}: Store a field encrypted by the client
+func (c *Client) SealWasteField(ctx context.Context, wasteID string, field string, body *SealFieldRequest) (*Waste, *Response, error) {
+	var data Waste
+	response, err := c.do(ctx, "PUT", "/api/waste/"+url.PathEscape(wasteID)+"/sealed/"+url.PathEscape(field), nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// OpenSealedWasteField calls POST /api/waste/{wasteId}/sealed/{field}/open: Decrypt a sealed field with its key
+func (c *Client) OpenSealedWasteField(ctx context.Context, wasteID string, field string, body *OpenSealedFieldRequest) (*UnsealedField, *Response, error) {
+	var data UnsealedField
+	response, err := c.do(ctx, "POST", "/api/waste/"+url.PathEscape(wasteID)+"/sealed/"+url.PathEscape(field)+"/open", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// TransferShare calls POST /api/waste/{wasteId}/ownership/transfer: Transfer a share of a lot; large transfers await approval
+func (c *Client) TransferShare(ctx context.Context, wasteID string, body *ShareTransferRequest) (*Waste, *Response, error) {
+	var data Waste
+	response, err := c.do(ctx, "POST", "/api/waste/"+url.PathEscape(wasteID)+"/ownership/transfer", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// ListWastesWithWarnings calls GET /api/waste/warnings: List lots with open validation warnings
+func (c *Client) ListWastesWithWarnings(ctx context.Context, query *WarningListQuery) ([]Waste, *Response, error) {
+	var data []Waste
+	response, err := c.do(ctx, "GET", "/api/waste/warnings", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return data, response, nil
+}
+
+// GetWasteAsOf calls GET /api/waste/{wasteId}/as-of: Get a lot as it stood at a past time
+func (c *Client) GetWasteAsOf(ctx context.Context, wasteID string, query *AsOfQuery) (*WasteAsOf, *Response, error) {
+	var data WasteAsOf
+	response, err := c.do(ctx, "GET", "/api/waste/"+url.PathEscape(wasteID)+"/as-of", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// ResolveWasteWarning calls POST /api/waste/{wasteId}/warnings/{code}/resolve: Resolve an open validation warning of a lot
+func (c *Client) ResolveWasteWarning(ctx context.Context, wasteID string, code string, body *ResolveWarningRequest) (*Waste, *Response, error) {
+	var data Waste
+	response, err := c.do(ctx, "POST", "/api/waste/"+url.PathEscape(wasteID)+"/warnings/"+url.PathEscape(code)+"/resolve", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// ListWasteTemplates calls GET /api/waste-templates/{participantId}: List a participant's lot templates, the most used first
+func (c *Client) ListWasteTemplates(ctx context.Context, participantID string, query *Organization) ([]WasteTemplate, *Response, error) {
+	var data []WasteTemplate
+	response, err := c.do(ctx, "GET", "/api/waste-templates/"+url.PathEscape(participantID), query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return data, response, nil
+}
+
+// RegisterWasteTemplate calls POST /api/waste-templates: Save a lot template for an owner without a participant ID yet
+func (c *Client) RegisterWasteTemplate(ctx context.Context, body *RegisterWasteTemplateRequest) (*WasteTemplate, *Response, error) {
+	var data WasteTemplate
+	response, err := c.do(ctx, "POST", "/api/waste-templates", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// SaveWasteTemplate calls PUT /api/waste-templates/{participantId}/{name}: Create or replace a participant's lot template
+func (c *Client) SaveWasteTemplate(ctx context.Context, participantID string, name string, body *SaveWasteTemplateRequest) (*WasteTemplate, *Response, error) {
+	var data WasteTemplate
+	response, err := c.do(ctx, "PUT", "/api/waste-templates/"+url.PathEscape(participantID)+"/"+url.PathEscape(name), nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// DeleteWasteTemplate calls DELETE /api/waste-templates/{participantId}/{name}: Delete a participant's lot template
+func (c *Client) DeleteWasteTemplate(ctx context.Context, participantID string, name string, query *Organization) (*Response, error) {
+	return c.do(ctx, "DELETE", "/api/waste-templates/"+url.PathEscape(participantID)+"/"+url.PathEscape(name), query, nil, nil)
+}
+
+// CreateWasteFromTemplate calls POST /api/waste-templates/{participantId}/{name}/wastes: Record a lot with the defaults of a template
+func (c *Client) CreateWasteFromTemplate(ctx context.Context, participantID string, name string, body *CreateWasteFromTemplateRequest) (*Waste, *Response, error) {
+	var data Waste
+	response, err := c.do(ctx, "POST", "/api/waste-templates/"+url.PathEscape(participantID)+"/"+url.PathEscape(name)+"/wastes", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// CreateExtraction calls POST /api/extraction/add: Record an extraction
+func (c *Client) CreateExtraction(ctx context.Context, body *CreateExtractionRequest) (*Response, error) {
+	return c.do(ctx, "POST", "/api/extraction/add", nil, body, nil)
+}
+
+// ListExtractions calls GET /api/extraction/list: List extractions
+func (c *Client) ListExtractions(ctx context.Context, query *ProductListQuery) ([]Extraction, *Response, error) {
+	var data []Extraction
+	response, err := c.do(ctx, "GET", "/api/extraction/list", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return data, response, nil
+}
+
+// CreateRecycling calls POST /api/recycling/add: Record a recycling
+func (c *Client) CreateRecycling(ctx context.Context, body *CreateRecyclingRequest) (*Response, error) {
+	return c.do(ctx, "POST", "/api/recycling/add", nil, body, nil)
+}
+
+// ListRecyclings calls GET /api/recycling/list: List recyclings
+func (c *Client) ListRecyclings(ctx context.Context, query *ProductListQuery) ([]Recycling, *Response, error) {
+	var data []Recycling
+	response, err := c.do(ctx, "GET", "/api/recycling/list", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return data, response, nil
+}
+
+// GetAssetByReference calls GET /api/references/{reference}: Find a lot, extraction or recycling by its sequential reference
+func (c *Client) GetAssetByReference(ctx context.Context, reference string, query *Organization) (*ReferencedAsset, *Response, error) {
+	var data ReferencedAsset
+	response, err := c.do(ctx, "GET", "/api/references/"+url.PathEscape(reference), query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// GetCompletionCertificate calls GET /api/traceability/{wasteId}/certificate: Get the completion certificate of a fully valorized lot
+func (c *Client) GetCompletionCertificate(ctx context.Context, wasteID string, query *Organization) (*CompletionCertificate, *Response, error) {
+	var data CompletionCertificate
+	response, err := c.do(ctx, "GET", "/api/traceability/"+url.PathEscape(wasteID)+"/certificate", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// ListApprovals calls GET /api/approvals: List approvals of high-value operations
+func (c *Client) ListApprovals(ctx context.Context, query *ApprovalListQuery) ([]Approval, *Response, error) {
+	var data []Approval
+	response, err := c.do(ctx, "GET", "/api/approvals", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return data, response, nil
+}
+
+// Approve calls POST /api/approvals/{approvalId}/approve: Sign an approval
+func (c *Client) Approve(ctx context.Context, approvalID string, body *ApproveRequest) (*Approval, *Response, error) {
+	var data Approval
+	response, err := c.do(ctx, "POST", "/api/approvals/"+url.PathEscape(approvalID)+"/approve", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// RejectApproval calls POST /api/approvals/{approvalId}/reject: Reject an approval
+func (c *Client) RejectApproval(ctx context.Context, approvalID string, body *RejectApprovalRequest) (*Approval, *Response, error) {
+	var data Approval
+	response, err := c.do(ctx, "POST", "/api/approvals/"+url.PathEscape(approvalID)+"/reject", nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// ListApprovalMatrices calls GET /api/approvals/matrices: List the approval matrices of all organizations
+func (c *Client) ListApprovalMatrices(ctx context.Context, query *Organization) ([]ApprovalMatrix, *Response, error) {
+	var data []ApprovalMatrix
+	response, err := c.do(ctx, "GET", "/api/approvals/matrices", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return data, response, nil
+}
+
+// GetApprovalMatrix calls GET /api/approvals/matrices/{orgMsp}: Get an organization's approval matrix
+func (c *Client) GetApprovalMatrix(ctx context.Context, orgMsp string, query *Organization) (*ApprovalMatrix, *Response, error) {
+	var data ApprovalMatrix
+	response, err := c.do(ctx, "GET", "/api/approvals/matrices/"+url.PathEscape(orgMsp), query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// SetApprovalMatrix calls PUT /api/approvals/matrices/{orgMsp}: Schedule a version of an organization's approval matrix
+func (c *Client) SetApprovalMatrix(ctx context.Context, orgMsp string, body *SetApprovalMatrixRequest) (*ApprovalMatrix, *Response, error) {
+	var data ApprovalMatrix
+	response, err := c.do(ctx, "PUT", "/api/approvals/matrices/"+url.PathEscape(orgMsp), nil, body, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// GetEffectiveApprovalRules calls GET /api/approvals/matrices/{orgMsp}/effective: Get the approval rules of an organization in effect at a time
+func (c *Client) GetEffectiveApprovalRules(ctx context.Context, orgMsp string, query *EffectiveRulesQuery) (*ApprovalMatrixVersion, *Response, error) {
+	var data ApprovalMatrixVersion
+	response, err := c.do(ctx, "GET", "/api/approvals/matrices/"+url.PathEscape(orgMsp)+"/effective", query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
+
+// CancelApprovalMatrixVersion calls DELETE /api/approvals/matrices/{orgMsp}/versions/{effectiveFrom}: Cancel a version of an approval matrix not in effect yet
+func (c *Client) CancelApprovalMatrixVersion(ctx context.Context, orgMsp string, effectiveFrom string, query *Organization) (*ApprovalMatrix, *Response, error) {
+	var data ApprovalMatrix
+	response, err := c.do(ctx, "DELETE", "/api/approvals/matrices/"+url.PathEscape(orgMsp)+"/versions/"+url.PathEscape(effectiveFrom), query, nil, &data)
+	if err != nil {
+		return nil, response, err
+	}
+	return &data, response, nil
+}
@@ -1,7 +1,10 @@
 // Enhanced Hyperledger Fabric Client for Green Olive Chain
 const { Wallets, Gateway } = require("fabric-network");
+const { common } = require("fabric-protos");
 const fs = require("fs");
 const path = require("path");
+const { languageTransient } = require("./requestLanguage");
+const { PeerPool, isPeerFailure } = require("./peers");
 
 // Configuration
 const PROJECT_ROOT = __dirname;
@@ -31,6 +34,27 @@ const NETWORK_CONFIG = {
   },
 };
 
+// Peer every organization reaches when FABRIC_PEERS_FILE does not list
+// its peers
+const DEFAULT_PEER_URL = "grpcs://localhost:7051";
+
+// Service discovery finds the endorsers and orderers from the gateway peer;
+// set FABRIC_DISCOVERY_AS_LOCALHOST=false outside a local Docker network
+const DISCOVERY = {
+  enabled: true,
+  asLocalhost: process.env.FABRIC_DISCOVERY_AS_LOCALHOST !== "false",
+};
+
+// Gateway peers and their health, shared by every client of the process
+const peerPool = new PeerPool(
+  Object.fromEntries(
+    Object.entries(NETWORK_CONFIG.organizations).map(([orgName, org]) => [
+      orgName,
+      org.peers.map((name) => ({ name, url: DEFAULT_PEER_URL })),
+    ])
+  )
+);
+
 class BlockchainClient {
   constructor() {
     this.isInitialized = false;
@@ -57,6 +81,7 @@ class BlockchainClient {
         await this.initializeIdentity(orgName, orgConfig);
       }
 
+      peerPool.watch();
       this.isInitialized = true;
       console.log("✅ Blockchain client initialized successfully");
       return true;
@@ -135,8 +160,9 @@ class BlockchainClient {
     }
   }
 
-  // Get connection profile for organization
-  getConnectionProfile(orgName) {
+  // Get connection profile for organization, with a single gateway peer
+  // (the healthiest one unless given); discovery finds the others
+  getConnectionProfile(orgName, peer = peerPool.candidates(orgName)[0]) {
     const orgConfig = NETWORK_CONFIG.organizations[orgName];
     if (!orgConfig) {
       throw new Error(`Organization ${orgName} not found in configuration`);
@@ -153,29 +179,80 @@ class BlockchainClient {
       organizations: {
         [`${orgName.charAt(0).toUpperCase() + orgName.slice(1)}Org`]: {
           mspid: orgConfig.mspId,
-          peers: orgConfig.peers,
+          peers: [peer.name],
         },
       },
       peers: {
-        [orgConfig.peers[0]]: {
-          url: "grpcs://localhost:7051",
+        [peer.name]: {
+          url: peer.url,
           tlsCACerts: {
-            path: path.join(
-              NETWORK_ROOT,
-              "crypto-config",
-              "peerOrganizations",
-              `${orgName}.olive.com`,
-              "tlsca",
-              `tlsca.${orgName}.olive.com-cert.pem`
-            ),
+            path:
+              peer.tlsCACert ||
+              path.join(
+                NETWORK_ROOT,
+                "crypto-config",
+                "peerOrganizations",
+                `${orgName}.olive.com`,
+                "tlsca",
+                `tlsca.${orgName}.olive.com-cert.pem`
+              ),
+          },
+          grpcOptions: {
+            "ssl-target-name-override": peer.name,
           },
         },
       },
     };
   }
 
+  // Run work(peer) through the organization's peers, healthiest first,
+  // moving to the next one when a peer cannot be reached. Errors from the
+  // chaincode itself are not retried.
+  async withPeerFailover(orgName, work) {
+    const candidates = peerPool.candidates(orgName);
+    let lastError;
+    for (const peer of candidates) {
+      const startedAt = Date.now();
+      try {
+        const result = await work(peer);
+        peerPool.recordSuccess(orgName, peer.name, Date.now() - startedAt);
+        return result;
+      } catch (error) {
+        if (!isPeerFailure(error)) {
+          throw error;
+        }
+        peerPool.recordFailure(orgName, peer.name, error);
+        lastError = error;
+        console.warn(`⚠️ Peer ${peer.name} unreachable:`, error.message);
+      }
+    }
+    throw lastError || new Error(`No peers configured for ${orgName}`);
+  }
+
+  // Configured gateway peers and their health
+  getPeerStatus() {
+    return peerPool.status();
+  }
+
+  // Read the peers configuration again; false when it is invalid and the
+  // current peers were kept
+  reloadPeers() {
+    return peerPool.reload();
+  }
+
   // Submit transaction to blockchain
   async submitTransaction(orgName, functionName, ...args) {
+    return this.submitPrivateTransaction(orgName, functionName, {}, ...args);
+  }
+
+  // Submit a transaction with extra transient data (e.g. personal data that
+  // must not appear in the transaction arguments recorded in blocks)
+  async submitPrivateTransaction(
+    orgName,
+    functionName,
+    transientData,
+    ...args
+  ) {
     if (!this.isInitialized) {
       throw new Error("Blockchain client not initialized");
     }
@@ -185,54 +262,184 @@ class BlockchainClient {
       throw new Error(`Organization ${orgName} not supported`);
     }
 
-    let gateway;
-    try {
-      // Check if identity exists in wallet
-      const identity = await this.wallet.get(orgConfig.userId);
-      if (!identity) {
-        throw new Error(`Identity ${orgConfig.userId} not found in wallet`);
+    // Check if identity exists in wallet
+    const identity = await this.wallet.get(orgConfig.userId);
+    if (!identity) {
+      throw new Error(`Identity ${orgConfig.userId} not found in wallet`);
+    }
+
+    // A transaction failing over to another peer was never ordered: only
+    // unreachable peers fail over, not commit timeouts
+    return this.withPeerFailover(orgName, async (peer) => {
+      let gateway;
+      let contract;
+      try {
+        // Connect to gateway
+        gateway = new Gateway();
+        const connectionProfile = this.getConnectionProfile(orgName, peer);
+
+        await gateway.connect(connectionProfile, {
+          identity: orgConfig.userId,
+          wallet: this.wallet,
+          discovery: DISCOVERY,
+        });
+
+        // Get network and contract
+        const network = await gateway.getNetwork(NETWORK_CONFIG.channelName);
+        contract = network.getContract(NETWORK_CONFIG.chaincodeName);
+
+        // Submit transaction
+        console.log(
+          `🔗 Submitting transaction via ${peer.name}: ${functionName}(${args.join(", ")})`
+        );
+        const transient = languageTransient();
+        Object.entries(transientData || {}).forEach(([key, value]) => {
+          transient[key] = Buffer.from(
+            typeof value === "string" ? value : JSON.stringify(value)
+          );
+        });
+        const transaction = contract
+          .createTransaction(functionName)
+          .setTransient(transient);
+        const payload = await transaction.submit(...args);
+
+        console.log("✅ Transaction submitted successfully");
+
+        // Same shape as the mock client: the chaincode's returned asset plus
+        // the transaction ID, so callers need no follow-up read
+        let result;
+        try {
+          result = JSON.parse(payload.toString());
+        } catch {
+          result = payload.toString();
+        }
+        return {
+          transactionId: transaction.getTransactionId(),
+          result,
+          timestamp: new Date().toISOString(),
+        };
+      } catch (error) {
+        console.error(`❌ Transaction failed: ${error.message}`);
+        if (
+          contract &&
+          functionName !== "RecordFailedInvocation" &&
+          !isPeerFailure(error)
+        ) {
+          await this.recordFailedInvocation(
+            contract,
+            functionName,
+            args,
+            error
+          );
+        }
+        throw error;
+      } finally {
+        if (gateway) {
+          await gateway.disconnect();
+        }
       }
+    });
+  }
 
-      // Connect to gateway
-      gateway = new Gateway();
-      const connectionProfile = this.getConnectionProfile(orgName);
+  // Failed transactions leave nothing on the ledger, so report them to the
+  // chaincode audit trail in a separate transaction. Best effort: the
+  // original error is what the caller gets either way.
+  async recordFailedInvocation(contract, functionName, args, error) {
+    // The first argument is the ID of the asset concerned for most functions
+    const subject = typeof args[0] === "string" ? args[0] : "";
+    const assetIds = /^[\w.:-]{1,128}$/.test(subject) ? subject : "";
+    try {
+      await contract.submitTransaction(
+        "RecordFailedInvocation",
+        functionName,
+        assetIds,
+        String(error.message || error).slice(0, 500)
+      );
+    } catch (auditError) {
+      console.warn(
+        `⚠️ Could not record failed ${functionName} in the audit trail:`,
+        auditError.message
+      );
+    }
+  }
 
-      await gateway.connect(connectionProfile, {
-        identity: orgConfig.userId,
-        wallet: this.wallet,
-        discovery: { enabled: true, asLocalhost: true },
-      });
+  // Query blockchain (read-only operations)
+  async queryBlockchain(orgName, functionName, ...args) {
+    return this.queryPrivate(orgName, functionName, {}, ...args);
+  }
 
-      // Get network and contract
-      const network = await gateway.getNetwork(NETWORK_CONFIG.channelName);
-      const contract = network.getContract(NETWORK_CONFIG.chaincodeName);
+  // Query with extra transient data (e.g. a key that must not appear in the
+  // proposal arguments)
+  async queryPrivate(orgName, functionName, transientData, ...args) {
+    if (!this.isInitialized) {
+      throw new Error("Blockchain client not initialized");
+    }
 
-      // Submit transaction
-      console.log(
-        `🔗 Submitting transaction: ${functionName}(${args.join(", ")})`
-      );
-      const result = await contract.submitTransaction(functionName, ...args);
+    const orgConfig = NETWORK_CONFIG.organizations[orgName];
+    if (!orgConfig) {
+      throw new Error(`Organization ${orgName} not supported`);
+    }
 
-      console.log("✅ Transaction submitted successfully");
+    // Check if identity exists in wallet
+    const identity = await this.wallet.get(orgConfig.userId);
+    if (!identity) {
+      throw new Error(`Identity ${orgConfig.userId} not found in wallet`);
+    }
 
-      // Parse result
+    return this.withPeerFailover(orgName, async (peer) => {
+      let gateway;
       try {
-        return JSON.parse(result.toString());
-      } catch {
-        return result.toString();
-      }
-    } catch (error) {
-      console.error(`❌ Transaction failed: ${error.message}`);
-      throw error;
-    } finally {
-      if (gateway) {
-        await gateway.disconnect();
+        // Connect to gateway
+        gateway = new Gateway();
+        const connectionProfile = this.getConnectionProfile(orgName, peer);
+
+        await gateway.connect(connectionProfile, {
+          identity: orgConfig.userId,
+          wallet: this.wallet,
+          discovery: DISCOVERY,
+        });
+
+        // Get network and contract
+        const network = await gateway.getNetwork(NETWORK_CONFIG.channelName);
+        const contract = network.getContract(NETWORK_CONFIG.chaincodeName);
+
+        // Evaluate transaction (query)
+        console.log(
+          `🔍 Querying blockchain via ${peer.name}: ${functionName}(${args.join(", ")})`
+        );
+        const transient = languageTransient();
+        Object.entries(transientData || {}).forEach(([key, value]) => {
+          transient[key] = Buffer.from(
+            typeof value === "string" ? value : JSON.stringify(value)
+          );
+        });
+        const result = await contract
+          .createTransaction(functionName)
+          .setTransient(transient)
+          .evaluate(...args);
+
+        console.log("✅ Query completed successfully");
+
+        // Parse result
+        try {
+          return JSON.parse(result.toString());
+        } catch {
+          return result.toString();
+        }
+      } catch (error) {
+        console.error(`❌ Query failed: ${error.message}`);
+        throw error;
+      } finally {
+        if (gateway) {
+          await gateway.disconnect();
+        }
       }
-    }
+    });
   }
 
-  // Query blockchain (read-only operations)
-  async queryBlockchain(orgName, functionName, ...args) {
+  // Subscribe to chaincode events, from options.startBlock when given to
+  // replay past blocks; returns a function closing the subscription
+  async addContractListener(orgName, listener, options = {}) {
     if (!this.isInitialized) {
       throw new Error("Blockchain client not initialized");
     }
@@ -242,50 +449,79 @@ class BlockchainClient {
       throw new Error(`Organization ${orgName} not supported`);
     }
 
-    let gateway;
-    try {
-      // Check if identity exists in wallet
-      const identity = await this.wallet.get(orgConfig.userId);
-      if (!identity) {
-        throw new Error(`Identity ${orgConfig.userId} not found in wallet`);
+    const { gateway, network } = await this.withPeerFailover(
+      orgName,
+      async (peer) => {
+        const connected = new Gateway();
+        try {
+          await connected.connect(this.getConnectionProfile(orgName, peer), {
+            identity: orgConfig.userId,
+            wallet: this.wallet,
+            discovery: DISCOVERY,
+          });
+          return {
+            gateway: connected,
+            network: await connected.getNetwork(NETWORK_CONFIG.channelName),
+          };
+        } catch (error) {
+          connected.disconnect();
+          throw error;
+        }
       }
-
-      // Connect to gateway
-      gateway = new Gateway();
-      const connectionProfile = this.getConnectionProfile(orgName);
-
-      await gateway.connect(connectionProfile, {
-        identity: orgConfig.userId,
-        wallet: this.wallet,
-        discovery: { enabled: true, asLocalhost: true },
+    );
+    const contract = network.getContract(NETWORK_CONFIG.chaincodeName);
+    const contractListener = async (event) => {
+      const transactionEvent = event.getTransactionEvent();
+      listener({
+        eventName: event.eventName,
+        payload: event.payload ? event.payload.toString() : "",
+        transactionId: transactionEvent.transactionId,
+        blockNumber: Number(transactionEvent.getBlockEvent().blockNumber),
       });
+    };
+    await contract.addContractListener(
+      contractListener,
+      options.startBlock !== undefined
+        ? { startBlock: options.startBlock }
+        : undefined
+    );
+    console.log(`👂 Listening to chaincode events for ${orgName}`);
 
-      // Get network and contract
-      const network = await gateway.getNetwork(NETWORK_CONFIG.channelName);
-      const contract = network.getContract(NETWORK_CONFIG.chaincodeName);
+    return () => {
+      contract.removeContractListener(contractListener);
+      gateway.disconnect();
+    };
+  }
 
-      // Evaluate transaction (query)
-      console.log(
-        `🔍 Querying blockchain: ${functionName}(${args.join(", ")})`
-      );
-      const result = await contract.evaluateTransaction(functionName, ...args);
+  // Height of the channel's ledger on an organization's gateway peer, from
+  // the peer's query system chaincode
+  async getBlockHeight(orgName) {
+    if (!this.isInitialized) {
+      throw new Error("Blockchain client not initialized");
+    }
 
-      console.log("✅ Query completed successfully");
+    const orgConfig = NETWORK_CONFIG.organizations[orgName];
+    if (!orgConfig) {
+      throw new Error(`Organization ${orgName} not supported`);
+    }
 
-      // Parse result
+    return this.withPeerFailover(orgName, async (peer) => {
+      const gateway = new Gateway();
       try {
-        return JSON.parse(result.toString());
-      } catch {
-        return result.toString();
+        await gateway.connect(this.getConnectionProfile(orgName, peer), {
+          identity: orgConfig.userId,
+          wallet: this.wallet,
+          discovery: DISCOVERY,
+        });
+        const network = await gateway.getNetwork(NETWORK_CONFIG.channelName);
+        const info = await network
+          .getContract("qscc")
+          .evaluateTransaction("GetChainInfo", NETWORK_CONFIG.channelName);
+        return Number(common.BlockchainInfo.decode(info).height);
+      } finally {
+        gateway.disconnect();
       }
-    } catch (error) {
-      console.error(`❌ Query failed: ${error.message}`);
-      throw error;
-    } finally {
-      if (gateway) {
-        await gateway.disconnect();
-      }
-    }
+    });
   }
 
   // Check if blockchain is available
@@ -326,6 +562,8 @@ class BlockchainClient {
       }
     }
 
+    status.peers = peerPool.status();
+
     return status;
   }
 }
@@ -356,6 +594,7 @@ module.exports = class BlockchainClient {
         await this.initializeIdentity(orgName, orgConfig);
       }
 
+      peerPool.watch();
       this.isInitialized = true;
       console.log("✅ Enhanced blockchain client initialized successfully");
       return true;
@@ -414,6 +653,20 @@ module.exports = class BlockchainClient {
     };
   }
 
+  async submitPrivateTransaction(
+    orgName,
+    functionName,
+    transientData,
+    ...args
+  ) {
+    console.log(
+      `🔒 [MOCK] Transient fields for ${functionName}: ${Object.keys(
+        transientData || {}
+      ).join(", ")}`
+    );
+    return this.submitTransaction(orgName, functionName, ...args);
+  }
+
   async query(orgName, functionName, ...args) {
     console.log(
       `🔍 [MOCK] Querying: ${functionName}(${args.join(", ")}) for ${orgName}`
@@ -438,6 +691,15 @@ module.exports = class BlockchainClient {
     }
   }
 
+  async queryPrivate(orgName, functionName, transientData, ...args) {
+    console.log(
+      `🔒 [MOCK] Transient fields for ${functionName}: ${Object.keys(
+        transientData || {}
+      ).join(", ")}`
+    );
+    return this.query(orgName, functionName, ...args);
+  }
+
   async queryAll(orgName, functionName, ...args) {
     return await this.query(orgName, functionName, ...args);
   }
@@ -451,6 +713,44 @@ module.exports = class BlockchainClient {
     return [];
   }
 
+  async addContractListener(orgName, listener, options = {}) {
+    console.log(`👂 [MOCK] Listening to chaincode events for ${orgName}`);
+    return () => {};
+  }
+
+  // The mock reaches no peer, but reports the configured ones
+  getPeerStatus() {
+    return peerPool.status();
+  }
+
+  reloadPeers() {
+    return peerPool.reload();
+  }
+
+  // Wallet identity of an organization's gateway user, used to sign exports
+  async getSigningIdentity(orgName) {
+    const orgConfig = NETWORK_CONFIG.organizations[orgName];
+    if (!orgConfig || !this.wallet) {
+      return null;
+    }
+
+    const identity = await this.wallet.get(orgConfig.userId);
+    if (!identity) {
+      return null;
+    }
+
+    return {
+      mspId: identity.mspId,
+      certificate: identity.credentials.certificate,
+      privateKey: identity.credentials.privateKey,
+    };
+  }
+
+  // The mock has no ledger to measure
+  async getBlockHeight(orgName) {
+    return null;
+  }
+
   async getNetworkInfo(orgName) {
     return {
       organization: orgName,
@@ -0,0 +1,205 @@
+// Gateway peers of each organization with their health, read from an
+// optional JSON file that is reloaded whenever it changes:
+//
+//   {
+//     "farmer": [
+//       { "name": "peer0.farmer.olive.com", "url": "grpcs://localhost:7051" },
+//       { "name": "peer1.farmer.olive.com", "url": "grpcs://localhost:7151",
+//         "tlsCACert": "/path/to/tlsca.pem" }
+//     ]
+//   }
+//
+// Each request goes through the healthiest peer first and fails over to the
+// next one; the rest of the network is found through service discovery.
+const fs = require("fs");
+const path = require("path");
+
+const PEERS_FILE = process.env.FABRIC_PEERS_FILE;
+
+// Consecutive failures after which a peer is skipped, and for how long
+const FAILURE_THRESHOLD =
+  parseInt(process.env.PEER_FAILURE_THRESHOLD, 10) || 3;
+const COOLDOWN_MS = parseInt(process.env.PEER_COOLDOWN_MS, 10) || 30000;
+
+// Score changes on success and failure, out of 100
+const SUCCESS_CREDIT = 10;
+const FAILURE_PENALTY = 25;
+
+// Errors meaning the peer could not be reached, as opposed to the chaincode
+// answering with an error; only these fail over to another peer
+const PEER_FAILURE_PATTERN =
+  /UNAVAILABLE|DEADLINE_EXCEEDED|Failed to connect|ECONNREFUSED|ECONNRESET|ETIMEDOUT|DiscoveryService|No peers available|failed to get discovery/i;
+
+const isPeerFailure = (error) =>
+  Boolean(error) && PEER_FAILURE_PATTERN.test(error.message || "");
+
+const newHealth = () => ({
+  score: 100,
+  successes: 0,
+  failures: 0,
+  consecutiveFailures: 0,
+  latencyMs: null,
+  downUntil: null,
+  lastError: null,
+  lastSuccessAt: null,
+  lastFailureAt: null,
+});
+
+class PeerPool {
+  // defaults: organization -> [{ name, url, tlsCACert }] used when there is
+  // no peers file or it does not list the organization
+  constructor(defaults) {
+    this.defaults = defaults;
+    this.peers = {};
+    this.health = new Map();
+    this.loadedAt = null;
+    this.lastReloadError = null;
+    this.watching = false;
+    this.reload();
+  }
+
+  // Read the peers file again; an invalid file keeps the current peers
+  reload() {
+    try {
+      const configured = PEERS_FILE
+        ? JSON.parse(fs.readFileSync(path.resolve(PEERS_FILE), "utf8"))
+        : {};
+      const peers = {};
+      Object.keys(this.defaults).forEach((orgName) => {
+        peers[orgName] = validatePeers(
+          orgName,
+          configured[orgName] || this.defaults[orgName]
+        );
+      });
+      this.peers = peers;
+      this.loadedAt = new Date().toISOString();
+      this.lastReloadError = null;
+      // Peers still configured keep their health
+      const names = new Set(
+        Object.entries(peers).flatMap(([orgName, list]) =>
+          list.map((peer) => `${orgName}/${peer.name}`)
+        )
+      );
+      [...this.health.keys()]
+        .filter((key) => !names.has(key))
+        .forEach((key) => this.health.delete(key));
+      return true;
+    } catch (error) {
+      this.lastReloadError = error.message;
+      console.error(
+        "❌ Could not load the peers configuration:",
+        error.message
+      );
+      return false;
+    }
+  }
+
+  // Reload whenever the peers file changes and on SIGHUP
+  watch() {
+    if (this.watching) {
+      return;
+    }
+    this.watching = true;
+    if (PEERS_FILE) {
+      fs.watchFile(path.resolve(PEERS_FILE), { interval: 5000 }, () => {
+        if (this.reload()) {
+          console.log("🔄 Peers configuration reloaded");
+        }
+      }).unref();
+    }
+    process.on("SIGHUP", () => this.reload());
+  }
+
+  healthOf(orgName, peerName) {
+    const key = `${orgName}/${peerName}`;
+    if (!this.health.has(key)) {
+      this.health.set(key, newHealth());
+    }
+    return this.health.get(key);
+  }
+
+  // Peers of an organization to try, in order: those not cooling down by
+  // score then latency, then the ones cooling down as a last resort
+  candidates(orgName) {
+    const now = Date.now();
+    const ranked = (this.peers[orgName] || []).map((peer) => ({
+      peer,
+      health: this.healthOf(orgName, peer.name),
+    }));
+    const byHealth = (a, b) =>
+      b.health.score - a.health.score ||
+      (a.health.latencyMs ?? Infinity) - (b.health.latencyMs ?? Infinity);
+    const up = ranked.filter(({ health }) => !(health.downUntil > now));
+    const down = ranked.filter(({ health }) => health.downUntil > now);
+    return [...up.sort(byHealth), ...down.sort(byHealth)].map(
+      ({ peer }) => peer
+    );
+  }
+
+  recordSuccess(orgName, peerName, latencyMs) {
+    const health = this.healthOf(orgName, peerName);
+    health.score = Math.min(100, health.score + SUCCESS_CREDIT);
+    health.successes += 1;
+    health.consecutiveFailures = 0;
+    health.downUntil = null;
+    health.latencyMs =
+      health.latencyMs === null
+        ? latencyMs
+        : Math.round(0.8 * health.latencyMs + 0.2 * latencyMs);
+    health.lastSuccessAt = new Date().toISOString();
+  }
+
+  recordFailure(orgName, peerName, error) {
+    const health = this.healthOf(orgName, peerName);
+    health.score = Math.max(0, health.score - FAILURE_PENALTY);
+    health.failures += 1;
+    health.consecutiveFailures += 1;
+    health.lastError = error.message;
+    health.lastFailureAt = new Date().toISOString();
+    if (health.consecutiveFailures >= FAILURE_THRESHOLD) {
+      health.downUntil = Date.now() + COOLDOWN_MS;
+    }
+  }
+
+  // Configured peers and their health, per organization
+  status() {
+    const now = Date.now();
+    const organizations = {};
+    Object.entries(this.peers).forEach(([orgName, peers]) => {
+      organizations[orgName] = peers.map((peer) => {
+        const health = this.healthOf(orgName, peer.name);
+        return {
+          name: peer.name,
+          url: peer.url,
+          ...health,
+          available: !(health.downUntil > now),
+          downUntil: health.downUntil
+            ? new Date(health.downUntil).toISOString()
+            : null,
+        };
+      });
+    });
+    return {
+      source: PEERS_FILE || "defaults",
+      loadedAt: this.loadedAt,
+      lastReloadError: this.lastReloadError,
+      organizations,
+    };
+  }
+}
+
+const validatePeers = (orgName, peers) => {
+  if (!Array.isArray(peers) || peers.length === 0) {
+    throw new Error(`${orgName} must list at least one peer`);
+  }
+  peers.forEach((peer) => {
+    if (!peer?.name || !/^grpcs?:\/\/[^/\s]+$/.test(peer.url || "")) {
+      throw new Error(
+        `Peers of ${orgName} need a name and a grpc:// or grpcs:// url`
+      );
+    }
+  });
+  return peers.map(({ name, url, tlsCACert }) => ({ name, url, tlsCACert }));
+};
+
+module.exports = { PeerPool, isPeerFailure };
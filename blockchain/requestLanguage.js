@@ -0,0 +1,33 @@
+// Per-request language hint forwarded to the chaincode as transient data
+const { AsyncLocalStorage } = require("async_hooks");
+
+const storage = new AsyncLocalStorage();
+
+// Express middleware keeping the caller's Accept-Language for the request
+const languageMiddleware = (req, res, next) => {
+  storage.run(
+    {
+      lang: req.query.lang || req.get("X-Language") || "",
+      acceptLanguage: req.get("Accept-Language") || "",
+    },
+    next
+  );
+};
+
+// Transient map for the current request ("lang" and "accept-language")
+const languageTransient = () => {
+  const store = storage.getStore();
+  if (!store) {
+    return {};
+  }
+  const transient = {};
+  if (store.lang) {
+    transient.lang = Buffer.from(store.lang);
+  }
+  if (store.acceptLanguage) {
+    transient["accept-language"] = Buffer.from(store.acceptLanguage);
+  }
+  return transient;
+};
+
+module.exports = { languageMiddleware, languageTransient };
@@ -0,0 +1,106 @@
+// Scoped service-account tokens bound to an on-chain delegation
+const crypto = require("crypto");
+
+const TOKEN_VERSION = "v1";
+
+// Delegates named with this prefix are backend service accounts
+const SERVICE_ACCOUNT_PREFIX = "svc:";
+
+// Read-only API areas a service token may reach, by delegation scope
+const SCOPE_PATHS = {
+  WASTE: ["/api/waste", "/api/traceability", "/api/reports"],
+  EXTRACTION: ["/api/extraction"],
+  RECYCLING: ["/api/recycling"],
+};
+
+const base64url = (buffer) =>
+  buffer
+    .toString("base64")
+    .replace(/=+$/, "")
+    .replace(/\+/g, "-")
+    .replace(/\//g, "_");
+
+const fromBase64url = (text) =>
+  Buffer.from(text.replace(/-/g, "+").replace(/_/g, "/"), "base64");
+
+// Placeholder of .env.example, which must never sign real tokens
+const PLACEHOLDER_SECRET = "change-me";
+
+const tokenSecret = () => {
+  const secret = process.env.SERVICE_TOKEN_SECRET;
+  if (!secret) {
+    throw new Error("SERVICE_TOKEN_SECRET is not configured");
+  }
+  if (secret === PLACEHOLDER_SECRET) {
+    throw new Error("SERVICE_TOKEN_SECRET is still the example placeholder");
+  }
+  return secret;
+};
+
+// Throw unless a real signing secret is configured; the server checks this
+// before it starts
+const checkServiceTokenSecret = () => {
+  tokenSecret();
+};
+
+const sign = (payload) =>
+  base64url(
+    crypto
+      .createHmac("sha256", tokenSecret())
+      .update(`${TOKEN_VERSION}.${payload}`)
+      .digest()
+  );
+
+// Issue a token for a service account; claims are { sub, delegationId, org,
+// scopes, exp } with exp in seconds since the epoch
+const issueServiceToken = (claims) => {
+  const payload = base64url(Buffer.from(JSON.stringify(claims)));
+  return `${TOKEN_VERSION}.${payload}.${sign(payload)}`;
+};
+
+// Return the claims of a valid, unexpired token or throw
+const verifyServiceToken = (token) => {
+  const [version, payload, signature] = String(token).split(".");
+  if (version !== TOKEN_VERSION || !payload || !signature) {
+    throw new Error("Malformed service token");
+  }
+
+  const expected = Buffer.from(sign(payload));
+  const actual = Buffer.from(signature);
+  if (
+    expected.length !== actual.length ||
+    !crypto.timingSafeEqual(expected, actual)
+  ) {
+    throw new Error("Invalid service token signature");
+  }
+
+  const claims = JSON.parse(fromBase64url(payload).toString("utf8"));
+  if (!claims.exp || claims.exp * 1000 <= Date.now()) {
+    throw new Error("Service token has expired");
+  }
+  return claims;
+};
+
+// Delegation scope required to reach a path, or null when service tokens may
+// not use it at all
+const scopeForPath = (requestPath) => {
+  for (const [scope, prefixes] of Object.entries(SCOPE_PATHS)) {
+    if (
+      prefixes.some(
+        (prefix) =>
+          requestPath === prefix || requestPath.startsWith(`${prefix}/`)
+      )
+    ) {
+      return scope;
+    }
+  }
+  return null;
+};
+
+module.exports = {
+  SERVICE_ACCOUNT_PREFIX,
+  checkServiceTokenSecret,
+  issueServiceToken,
+  verifyServiceToken,
+  scopeForPath,
+};
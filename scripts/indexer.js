@@ -0,0 +1,76 @@
+#!/usr/bin/env node
+// Indexer CLI - dead letters, re-sync and consistency checks of a running
+// API's read model, through its admin endpoints
+//
+//   npm run indexer -- status
+//   npm run indexer -- dead-letters [OPEN|REPLAYED|DISCARDED]
+//   npm run indexer -- replay [deadLetterId]
+//   npm run indexer -- discard <deadLetterId>
+//   npm run indexer -- resync <fromBlock>
+//   npm run indexer -- check [--repair]
+require("dotenv").config();
+
+const API_URL = (
+  process.env.INDEXER_API_URL || `http://localhost:${process.env.PORT || 5000}`
+).replace(/\/$/, "");
+
+const call = async (method, path, body) => {
+  const response = await fetch(`${API_URL}/api/admin/indexer${path}`, {
+    method,
+    headers: body ? { "Content-Type": "application/json" } : {},
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const result = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(
+      `${response.status} ${result.error || response.statusText}` +
+        (result.details ? `: ${result.details}` : "")
+    );
+  }
+  return result;
+};
+
+const commands = {
+  status: () => call("GET", ""),
+  "dead-letters": (status) =>
+    call("GET", `/dead-letters${status ? `?status=${status}` : ""}`),
+  replay: (id) =>
+    id
+      ? call("POST", `/dead-letters/${encodeURIComponent(id)}/replay`)
+      : call("POST", "/dead-letters/replay"),
+  discard: (id) => {
+    if (!id) {
+      throw new Error("Usage: discard <deadLetterId>");
+    }
+    return call("POST", `/dead-letters/${encodeURIComponent(id)}/discard`);
+  },
+  resync: (fromBlock) => {
+    if (fromBlock === undefined) {
+      throw new Error("Usage: resync <fromBlock>");
+    }
+    return call("POST", "/resync", { fromBlock: Number(fromBlock) });
+  },
+  check: (flag) =>
+    call("POST", "/consistency-checks", { repair: flag === "--repair" }),
+};
+
+const main = async () => {
+  const [name, ...args] = process.argv.slice(2);
+  const command = commands[name];
+  if (!command) {
+    console.error(
+      `Usage: indexer <${Object.keys(commands).join("|")}> [arguments]`
+    );
+    process.exit(2);
+  }
+  const result = await command(...args);
+  if (result.message) {
+    console.log(result.message);
+  }
+  console.log(JSON.stringify(result.data, null, 2));
+};
+
+main().catch((error) => {
+  console.error("❌", error.message);
+  process.exit(1);
+});
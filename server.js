@@ -2,13 +2,88 @@ const express = require("express");
 const bodyParser = require("body-parser");
 const dotenv = require("dotenv");
 const cors = require("cors");
+const { languageMiddleware } = require("./blockchain/requestLanguage");
+const { checkServiceTokenSecret } = require("./blockchain/serviceTokens");
+const {
+  problemResponses,
+  notFoundHandler,
+  errorHandler,
+  listProblemTypes,
+  getProblemType,
+} = require("./api/problems");
+const {
+  openApiDocument,
+  validateRequest,
+} = require("./api/openapiValidator");
 
 // Load environment variables
 dotenv.config();
 
+// Service tokens must not be signed with a missing or well-known secret
+try {
+  checkServiceTokenSecret();
+} catch (error) {
+  console.error(`❌ ${error.message}`);
+  process.exit(1);
+}
+
 const wasteRoutes = require("./api/routes/waste");
 const extractionRoutes = require("./api/routes/extraction");
 const recyclingRoutes = require("./api/routes/recycling");
+const reportRoutes = require("./api/routes/reports");
+const adminRoutes = require("./api/routes/admin");
+const agreementRoutes = require("./api/routes/agreements");
+const collectionRoutes = require("./api/routes/collections");
+const campaignRoutes = require("./api/routes/campaigns");
+const facilityRoutes = require("./api/routes/facilities");
+const sensorRoutes = require("./api/routes/sensors");
+const eventRoutes = require("./api/routes/events");
+const taxonomyRoutes = require("./api/routes/taxonomy");
+const notificationRoutes = require("./api/routes/notifications");
+const analyticsRoutes = require("./api/routes/analytics");
+const syncRoutes = require("./api/routes/sync");
+const marketplaceRoutes = require("./api/routes/marketplace");
+const snapshotRoutes = require("./api/routes/snapshots");
+const delegationRoutes = require("./api/routes/delegations");
+const weatherRoutes = require("./api/routes/weather");
+const feedbackRoutes = require("./api/routes/feedback");
+const claimRoutes = require("./api/routes/claims");
+const importRoutes = require("./api/routes/imports");
+const exportRoutes = require("./api/routes/exports");
+const dataQualityRoutes = require("./api/routes/dataQuality");
+const plotRoutes = require("./api/routes/plots");
+const transportRoutes = require("./api/routes/transport");
+const storageRoutes = require("./api/routes/storage");
+const pricingRoutes = require("./api/routes/pricing");
+const supplyRoutes = require("./api/routes/supply");
+const cooperativeRoutes = require("./api/routes/cooperatives");
+const researchRoutes = require("./api/routes/research");
+const slaRoutes = require("./api/routes/sla");
+const scorecardRoutes = require("./api/routes/scorecards");
+const incidentRoutes = require("./api/routes/incidents");
+const checklistRoutes = require("./api/routes/checklists");
+const statusReasonRoutes = require("./api/routes/statusReasons");
+const residencyRoutes = require("./api/routes/residency");
+const mediaRoutes = require("./api/routes/media");
+const approvalRoutes = require("./api/routes/approvals");
+const settlementRoutes = require("./api/routes/settlements");
+const handoffRoutes = require("./api/routes/handoffs");
+const creditRoutes = require("./api/routes/credit");
+const planningRoutes = require("./api/routes/planning");
+const publicRoutes = require("./api/routes/public");
+const alertRoutes = require("./api/routes/alerts");
+const erpRoutes = require("./api/routes/erp");
+const subsidyRoutes = require("./api/routes/subsidies");
+const templateRoutes = require("./api/routes/templates");
+const onboardingRoutes = require("./api/routes/onboarding");
+const documentRoutes = require("./api/routes/documents");
+const fileRoutes = require("./api/routes/files");
+const { startGrpcServer } = require("./api/grpc");
+const { auditMiddleware } = require("./api/audit");
+const { watchTenants } = require("./api/tenants");
+const {
+  authenticateServiceAccount,
+} = require("./api/controllers/delegationController");
 const app = express();
 
 // Middleware CORS - utilise la variable d'environnement
@@ -16,19 +91,90 @@ app.use(
   cors({
     origin: process.env.FRONTEND_URL || "http://localhost:3000",
     methods: ["GET", "POST", "PUT", "DELETE"],
-    allowedHeaders: ["Content-Type", "Authorization"],
+    allowedHeaders: ["Content-Type", "Authorization", "X-Language"],
     credentials: true,
   })
 );
 
+// Enveloppes de réponse : succès { success, data } et erreurs RFC 7807
+app.use(problemResponses);
+
+// Journal d'audit de chaque appel API, hors du ledger (api/audit)
+app.use(auditMiddleware);
+
+// Fichiers des stockages locaux : URLs signées, corps bruts non parsés
+app.use("/api/files", fileRoutes);
+
 // Middleware pour parser JSON
 app.use(bodyParser.json());
 app.use(bodyParser.urlencoded({ extended: true }));
 
+// Langue des messages de la blockchain (Accept-Language, X-Language ou ?lang=)
+app.use(languageMiddleware);
+
+// Public statistics: no authentication, readable from any origin
+app.use(
+  "/public",
+  cors({ origin: process.env.PUBLIC_CORS_ORIGIN || "*", methods: ["GET"] }),
+  publicRoutes
+);
+
+// Service-account bearer tokens are limited to their delegated read scopes
+app.use(authenticateServiceAccount);
+
+// Validation des requêtes contre le document OpenAPI (api/openapi.json)
+app.use(validateRequest);
+
 // Routes API
 app.use("/api/waste", wasteRoutes);
 app.use("/api/extraction", extractionRoutes);
 app.use("/api/recycling", recyclingRoutes);
+app.use("/api/reports", reportRoutes);
+app.use("/api/admin", adminRoutes);
+app.use("/api/agreements", agreementRoutes);
+app.use("/api/collections", collectionRoutes);
+app.use("/api/campaigns", campaignRoutes);
+app.use("/api/plots", plotRoutes);
+app.use("/api/transport", transportRoutes);
+app.use("/api/storage", storageRoutes);
+app.use("/api/pricing", pricingRoutes);
+app.use("/api/supply-contracts", supplyRoutes);
+app.use("/api/cooperatives", cooperativeRoutes);
+app.use("/api/research", researchRoutes);
+app.use("/api/sla", slaRoutes);
+app.use("/api/scorecards", scorecardRoutes);
+app.use("/api/incidents", incidentRoutes);
+app.use("/api/checklists", checklistRoutes);
+app.use("/api/status-reasons", statusReasonRoutes);
+app.use("/api/residency", residencyRoutes);
+app.use("/api/media", mediaRoutes);
+app.use("/api/documents", documentRoutes);
+app.use("/api/approvals", approvalRoutes);
+app.use("/api/settlements", settlementRoutes);
+app.use("/api/handoffs", handoffRoutes);
+app.use("/api/credit", creditRoutes);
+app.use("/api/planning", planningRoutes);
+app.use("/api/alerts", alertRoutes);
+app.use("/api/erp", erpRoutes);
+app.use("/api/subsidies", subsidyRoutes);
+app.use("/api/waste-templates", templateRoutes);
+app.use("/api/onboarding", onboardingRoutes);
+app.use("/api/facilities", facilityRoutes);
+app.use("/api/sensors", sensorRoutes);
+app.use("/api/events", eventRoutes);
+app.use("/api/taxonomy", taxonomyRoutes);
+app.use("/api/notifications", notificationRoutes);
+app.use("/api/analytics", analyticsRoutes);
+app.use("/api/sync", syncRoutes);
+app.use("/api/marketplace", marketplaceRoutes);
+app.use("/api/snapshots", snapshotRoutes);
+app.use("/api/delegations", delegationRoutes);
+app.use("/api/weather", weatherRoutes);
+app.use("/api/feedback", feedbackRoutes);
+app.use("/api/claims", claimRoutes);
+app.use("/api/imports", importRoutes);
+app.use("/api/exports", exportRoutes);
+app.use("/api/data-quality", dataQualityRoutes);
 
 // Route de santé
 app.get("/health", (req, res) => {
@@ -46,12 +192,275 @@ app.get("/", (req, res) => {
     version: "1.0.0",
     endpoints: {
       health: "/health",
+      publicStats: "/public/stats",
+      openapi: "/openapi.json",
+      problems: "/problems",
       waste: "/api/waste",
       extraction: "/api/extraction",
       recycling: "/api/recycling",
+      recyclingMethods: "/api/recycling/methods",
+      reports: {
+        traceability: "/api/reports/traceability/:wasteId",
+        anchor: "/api/reports/traceability/:wasteId/anchor",
+        credential: "/api/reports/traceability/:wasteId/credential",
+        theme: "/api/reports/theme?org=&token=&lang=",
+        impact:
+          "/api/reports/impact?org=&buyer=&period=&from=&to=&format=json|pdf",
+      },
+      agreements: "/api/agreements",
+      collections: "/api/collections",
+      campaigns: "/api/campaigns",
+      plots: {
+        list: "/api/plots?farm=",
+        statistics: "/api/plots/statistics?farm=&format=csv",
+        quotas: "/api/plots/quotas?season=2025-2026",
+        quota: "/api/plots/quotas/:season/:farm",
+      },
+      pricing: {
+        index: "/api/pricing/index/:productType",
+        valuations: "/api/pricing/valuations/:wasteId",
+      },
+      cooperatives: {
+        register: "/api/cooperatives",
+        members: "/api/cooperatives/:cooperativeId/members?nested=true",
+        assets: "/api/cooperatives/:cooperativeId/assets",
+        permissions: "/api/cooperatives/:cooperativeId/permissions",
+        membership: "/api/cooperatives/memberships/:memberId",
+      },
+      research: {
+        consent: "/api/research/consent/:participantId",
+        exports: "/api/research/exports",
+        download: "/api/research/exports/:exportId/download",
+      },
+      sla: {
+        define: "/api/sla",
+        receipts: "/api/sla/receipts",
+        compliance: "/api/sla/compliance?from=2025-01-01&to=2025-12-31",
+      },
+      scorecards: {
+        participant: "/api/scorecards/:mspId?from=2025-01-01&to=2025-12-31",
+        compare: "/api/scorecards?participants=ProcessorOrgMSP,RecyclerOrgMSP",
+      },
+      alerts: {
+        rules: "/api/alerts/rules",
+        test: "/api/alerts/rules/:ruleId/test",
+        deliveries: "/api/alerts/deliveries?status=FAILED&ruleId=",
+      },
+      erp: {
+        connectors: "/api/erp/connectors",
+        postings: "/api/erp/postings?status=FAILED&provider=odoo",
+        retry: "/api/erp/postings/:postingId/retry",
+        reconciliations: "/api/erp/reconciliations",
+      },
+      subsidies: {
+        schemes: "/api/subsidies/schemes/:schemeId (conditions, ratePerUnit)",
+        evaluate: "/api/subsidies/schemes/:schemeId/wastes/:wasteId",
+        eligibilities: "/api/subsidies/schemes/:schemeId/eligibilities",
+        claims: "/api/subsidies/schemes/:schemeId/claims?from=&to=&format=csv",
+      },
+      wasteTemplates: {
+        templates: "/api/waste-templates/:participantId?org=farmer",
+        template: "/api/waste-templates/:participantId/:name (template)",
+        createWaste: "/api/waste-templates/:participantId/:name/wastes",
+      },
+      onboarding: {
+        wizard: "/api/onboarding/wizard",
+        onboardings: "/api/onboarding?org=farmer&status=IN_PROGRESS&stalled=",
+        steps: "/api/onboarding/:id/steps/:step (submit), /review",
+        activate: "/api/onboarding/:id/activate",
+        participant: "/api/onboarding/participants/:participantId?org=farmer",
+        reminders: "/api/onboarding/reminders/run (dryRun)",
+      },
+      incidents: {
+        incidents: "/api/incidents?facilityId=:facilityId&status=OPEN",
+        actions: "/api/incidents/:incidentId/actions",
+        regulator: "/api/incidents/:incidentId/regulator",
+        close: "/api/incidents/:incidentId/close",
+        facilityCompliance: "/api/facilities/:facilityId/compliance",
+      },
+      checklists: {
+        templates: "/api/checklists/:transition",
+        progress: "/api/checklists/RECEIVED/waste/:wasteId",
+        complete: "/api/checklists/RECEIVED/waste/:wasteId/items/:itemKey",
+      },
+      statusReasons: {
+        catalogs: "/api/status-reasons/:transition (required, reasons)",
+        statistics: "/api/status-reasons/statistics?from=&to=&org=farmer",
+      },
+      residency: {
+        regions: "/api/residency/:code (collection, msps, readers)",
+        fullView: "/api/residency/wastes/:wasteId?org=farmer",
+      },
+      media: {
+        uploads: "/api/media/uploads",
+        complete: "/api/media/uploads/:uploadId/complete",
+        gallery: "/api/media/WASTE/:wasteId",
+        order: "/api/media/WASTE/:wasteId/order",
+      },
+      documents: {
+        uploads: "/api/documents/uploads",
+        complete: "/api/documents/uploads/:uploadId/complete",
+        list: "/api/documents/:wasteId?org=farmer",
+        download: "/api/documents/:wasteId/:hash/download?org=farmer",
+      },
+      approvals: {
+        pending: "/api/approvals?status=PENDING_APPROVAL",
+        approve: "/api/approvals/:approvalId/approve",
+        reject: "/api/approvals/:approvalId/reject",
+        matrices: "/api/approvals/matrices/:orgMsp",
+        effectiveRules: "/api/approvals/matrices/:orgMsp/effective?at=",
+      },
+      settlements: {
+        list: "/api/settlements?wasteId=&status=PREPARED&org=farmer",
+        prepare: "/api/settlements (wasteId, sellerId, percentage, amount)",
+        confirm: "/api/settlements/:settlementId/confirm",
+        cancel: "/api/settlements/:settlementId/cancel",
+      },
+      handoffs: {
+        list: "/api/handoffs?kind=EXPORT&system=&org=farmer",
+        byReference: "/api/handoffs/by-reference?kind=&system=&reference=",
+        export: "/api/handoffs/exports (wasteId, system, externalReference)",
+        import: "/api/handoffs/imports (wasteData, origin, bundle)",
+        verify: "/api/handoffs/:handoffId/verify (bundle)",
+      },
+      credit: {
+        limits: "/api/credit/limits?org=farmer",
+        setLimit: "/api/credit/limits/:buyerMsp (limit, paymentTermsDays)",
+        exposure: "/api/credit/exposure?sellerMsp=&buyerMsp=&org=farmer",
+        invoices: "/api/credit/invoices?counterpartyMsp=&status=UNPAID",
+        paid: "/api/credit/invoices/:invoiceId/paid",
+        void: "/api/credit/invoices/:invoiceId/void (reason)",
+      },
+      planning: {
+        forecast: "/api/planning?facilityId=&from=&to=&format=csv",
+        reservations: "/api/planning/reservations?facilityId=&from=&to=",
+        reserve: "/api/planning/reservations (facilityId, date, quantity)",
+        cancel: "/api/planning/reservations/:reservationId/cancel",
+      },
+      supplyContracts: {
+        contracts: "/api/supply-contracts?org=farmer",
+        commitments: "/api/supply-contracts/commitments?atRisk=true",
+        deliveries: "/api/supply-contracts/:contractId/deliveries",
+        rules: "/api/supply-contracts/:contractId/rules",
+        adjustments: "/api/supply-contracts/:contractId/adjustments?format=csv",
+      },
+      storage: {
+        sites: "/api/storage",
+        occupancy: "/api/storage/occupancy?org=processor",
+        checkIn: "/api/storage/:siteId/check-in",
+        checkOut: "/api/storage/:siteId/check-out",
+      },
+      transport: {
+        vehicles: "/api/transport/vehicles?org=recycler",
+        drivers: "/api/transport/drivers",
+        permits: "/api/transport/vehicles/:vehicleId/permits",
+        shipments: "/api/transport/shipments?org=recycler&flagged=true",
+        deliver: "/api/transport/shipments/:shipmentId/deliver",
+      },
+      facilities: "/api/facilities",
+      geofences: "/api/facilities/:facilityId/geofence",
+      sensors: "/api/sensors",
+      taxonomy: {
+        tree: "/api/taxonomy",
+        statistics: "/api/taxonomy/statistics",
+      },
+      notifications: "/api/notifications?org=farmer&unread=true",
+      deadlines: "/api/notifications/deadlines?participant=&days=30",
+      deadlineDigests: "/api/notifications/digests",
+      digestPreferences: "/api/notifications/digests/preferences/:userId",
+      analytics: {
+        collected: "/api/analytics/collected?groupBy=month",
+        recyclingRate: "/api/analytics/recycling-rate",
+        topProcessors: "/api/analytics/top-processors?limit=5",
+        graph: "/api/analytics/graph/:rootId?depth=2&format=cytoscape",
+      },
+      sync: {
+        pull: "/api/sync?since=<cursor>",
+        mutations: "/api/sync/mutations",
+      },
+      marketplace: {
+        listings: "/api/marketplace/listings",
+        bids: "/api/marketplace/listings/:listingId/bids",
+        reveal: "/api/marketplace/listings/:listingId/bids/reveal",
+        award: "/api/marketplace/listings/:listingId/award",
+        settle: "/api/marketplace/listings/:listingId/settle",
+      },
+      snapshots: {
+        list: "/api/snapshots",
+        proof: "/api/snapshots/:snapshotId/proof?key=<key>",
+        verify: "/api/snapshots/:snapshotId/verify",
+      },
+      delegations: {
+        list: "/api/delegations?org=farmer",
+        revoke: "/api/delegations/:delegationId/revoke",
+        tokens: "/api/delegations/:delegationId/tokens",
+      },
+      weather: "/api/weather/observations?farm=<farm>&from=&to=",
+      feedback: {
+        tokens: "/api/feedback/tokens",
+        trace: "/api/feedback/trace/:token",
+        ratings: "/api/feedback/ratings/:scope (products|farms)",
+      },
+      claims: {
+        list: "/api/claims?wasteId=<wasteId>&org=farmer",
+        evidence: "/api/claims/:claimId/evidence",
+        assessment: "/api/claims/:claimId/assessment",
+        decision: "/api/claims/:claimId/decision",
+        settlement: "/api/claims/:claimId/settlement",
+      },
+      imports: {
+        start: "/api/imports/:kind (wastes|extractions, text/csv body)",
+        jobs: "/api/imports/jobs",
+        report: "/api/imports/jobs/:jobId?format=csv&outcome=REJECTED",
+      },
+      exports: {
+        start: "/api/exports/jobs (dataset: wastes|extractions|recyclings)",
+        job: "/api/exports/jobs/:jobId",
+        cancel: "/api/exports/jobs/:jobId/cancel",
+        download: "/api/exports/jobs/:jobId/download",
+      },
+      dataQuality: {
+        report: "/api/data-quality?field=farm&minSeverity=MEDIUM",
+        waste: "/api/data-quality/wastes/:wasteId",
+        enrich: "/api/data-quality/enrich",
+        duplicates: "/api/data-quality/duplicates?tolerancePct=2&org=farmer",
+        merge: "/api/data-quality/duplicates/merge (keepId, duplicateIds)",
+      },
+      events: {
+        stream: "/api/events/stream?tags=priority,export",
+        filters: "/api/events/filters",
+      },
+      admin: {
+        config: "/api/admin/config",
+        maintenance: "/api/admin/maintenance",
+        erasureRequests: "/api/admin/erasure-requests",
+        identities: "/api/admin/identities?mspId=",
+        validationRules: "/api/admin/validation-rules?assetType=WASTE",
+        testValidationRule: "/api/admin/validation-rules/test",
+        resolveIdentity: "/api/admin/identities/resolve?identity=",
+        migrations: "/api/admin/migrations",
+        auditTrail: "/api/admin/audit?actor=&from=&to=",
+        apiAudit: "/api/admin/api-audit?log=&from=&to=&actor=&path=&status=",
+        verifyApiAudit: "/api/admin/api-audit/verify",
+        functionMetrics: "/api/admin/metrics?since=",
+        integrity: "/api/admin/integrity",
+        repairIntegrity: "/api/admin/integrity/repair",
+        auditSamples: "/api/admin/audit-samples",
+        certifiers: "/api/admin/certifiers",
+        certifierWorkloads: "/api/admin/certifiers/workloads",
+        peers: "/api/admin/peers",
+        objectStorage: "/api/admin/object-storage",
+        indexer: "/api/admin/indexer",
+        deadLetters: "/api/admin/indexer/dead-letters?status=OPEN",
+        consistencyChecks: "/api/admin/indexer/consistency-checks",
+      },
       blockchain: {
         status: "/api/blockchain/status",
         traceability: "/api/traceability/:wasteId",
+        traceabilityLite: "/api/traceability/:wasteId/lite?historyLimit=10",
+        completionCertificate: "/api/traceability/:wasteId/certificate",
+        history: "/api/recycling/history/:assetType/:assetId?offset=0",
+        reference: "/api/references/:reference",
       },
     },
   });
@@ -83,6 +492,57 @@ app.get("/api/traceability/:wasteId", async (req, res) => {
   }
 });
 
+// Lightweight traceability route (bounded payload for long-lived lots)
+app.get("/api/traceability/:wasteId/lite", async (req, res) => {
+  try {
+    const recyclingController = require("./api/controllers/recyclingController");
+    await recyclingController.getTraceabilityLite(req, res);
+  } catch (error) {
+    res.status(500).json({
+      error: "Error fetching traceability data",
+      details: error.message,
+    });
+  }
+});
+
+// Completion certificate of a fully valorized lot
+app.get("/api/traceability/:wasteId/certificate", async (req, res) => {
+  try {
+    const recyclingController = require("./api/controllers/recyclingController");
+    await recyclingController.getCompletionCertificate(req, res);
+  } catch (error) {
+    res.status(500).json({
+      error: "Error fetching completion certificate",
+      details: error.message,
+    });
+  }
+});
+
+// Lookup by sequential reference (WST-2025-000123)
+app.get("/api/references/:reference", async (req, res) => {
+  try {
+    const recyclingController = require("./api/controllers/recyclingController");
+    await recyclingController.getAssetByReference(req, res);
+  } catch (error) {
+    res.status(500).json({
+      error: "Error fetching referenced asset",
+      details: error.message,
+    });
+  }
+});
+
+// OpenAPI document of the gateway
+app.get("/openapi.json", (req, res) => {
+  res.status(200).json(openApiDocument);
+});
+
+// Documentation of the problem types of error responses
+app.get("/problems", listProblemTypes);
+app.get("/problems/:type", getProblemType);
+
+app.use(notFoundHandler);
+app.use(errorHandler);
+
 const PORT = process.env.PORT || 5000;
 app.listen(PORT, () => {
   console.log(`🚀 Backend server is running on port ${PORT}`);
@@ -94,3 +554,13 @@ app.listen(PORT, () => {
     }`
   );
 });
+
+// Tenant branding follows the tenants file
+watchTenants();
+
+// Server-streaming gRPC queries, when a port is configured
+if (process.env.GRPC_PORT) {
+  startGrpcServer(process.env.GRPC_PORT)
+    .then((port) => console.log(`📡 gRPC queries available on port ${port}`))
+    .catch((error) => console.error("❌ gRPC server error:", error));
+}